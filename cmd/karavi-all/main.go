@@ -0,0 +1,294 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command karavi-all runs the proxy-server, tenant-service, role-service and
+// storage-service in a single process, for evaluation and small installs
+// without Kubernetes. The services talk over in-process gRPC, the database
+// is an embedded Redis-compatible store and the roles and storage systems
+// are kept in files of a data directory. OPA is still a separate process.
+//
+// The embedded database is in memory, so tenants, tokens and quotas are
+// lost on restart; roles and storage systems are not.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"karavi-authorization/internal/appconfig"
+	"karavi-authorization/internal/decision"
+	"karavi-authorization/internal/errcode"
+	"karavi-authorization/internal/eventsvc"
+	"karavi-authorization/internal/proxyserver"
+	"karavi-authorization/internal/reportsvc"
+	"karavi-authorization/internal/role-service"
+	rolemw "karavi-authorization/internal/role-service/middleware"
+	"karavi-authorization/internal/role-service/validate"
+	storage "karavi-authorization/internal/storage-service"
+	storagemw "karavi-authorization/internal/storage-service/middleware"
+	"karavi-authorization/internal/tenantsvc"
+	tenantmw "karavi-authorization/internal/tenantsvc/middleware"
+	"karavi-authorization/internal/token/jwx"
+	"karavi-authorization/internal/validation"
+	"karavi-authorization/internal/webhook"
+	"karavi-authorization/pb"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	bufSize = 1024 * 1024
+
+	tenantService  = "tenant-service"
+	roleService    = "role-service"
+	storageService = "storage-service"
+
+	concurrentPowerFlexRequests = "CONCURRENT_POWERFLEX_REQUESTS"
+)
+
+// Config is the configuration of the embedded services. The same file
+// also holds the configuration of the proxy-server, e.g. proxy.host, and
+// its csm-config-params, e.g. LOG_LEVEL.
+type Config struct {
+	Embedded struct {
+		// DataDir is the directory of the roles and storage systems.
+		DataDir string
+		// RedisListenAddr is the address of the embedded database.
+		RedisListenAddr string
+		// PolicyDir, if set, is a directory of rego policies that are
+		// pushed to OPA on startup.
+		PolicyDir string
+	}
+	Web struct {
+		JWTSigningSecret    string
+		JWTSigningAlgorithm string
+		JWTIssuer           string
+		JWTAudience         string
+		JWTEncryptionKey    string
+		LegacyTokensUntil   string
+	}
+	OpenPolicyAgent struct {
+		Host string
+	}
+	Tenant struct {
+		// Webhooks are called around changes of tenants, as in the
+		// tenant-service.
+		Webhooks []webhook.Config
+	}
+}
+
+func main() {
+	log := logrus.NewEntry(logrus.New())
+
+	cfgFile := flag.String("config", "karavi-all.yaml", "path of the configuration file")
+	flag.Parse()
+
+	if err := run(log, *cfgFile); err != nil {
+		log.Errorf("main: error: %+v", err)
+		os.Exit(1)
+	}
+}
+
+func run(log *logrus.Entry, cfgFile string) error {
+	// Environment variables override the config file, with the dots of
+	// keys replaced by underscores, e.g. EMBEDDED_DATADIR.
+	var cfg Config
+	appCfg, err := appconfig.Load(log, appconfig.Source{
+		File: cfgFile,
+		Defaults: appconfig.Defaults{
+			"embedded.datadir":          ".",
+			"embedded.redislistenaddr":  "127.0.0.1:6379",
+			"embedded.policydir":        "",
+			"web.jwtsigningsecret":      "secret",
+			"web.jwtsigningalgorithm":   string(jwx.HS256),
+			"web.jwtissuer":             "",
+			"web.jwtaudience":           "",
+			"web.jwtencryptionkey":      "",
+			"web.legacytokensuntil":     "",
+			"openpolicyagent.host":      "localhost:8181",
+			concurrentPowerFlexRequests: 10,
+		},
+	}, &cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Initialize the data directory and database
+
+	if err := os.MkdirAll(cfg.Embedded.DataDir, 0o700); err != nil {
+		return fmt.Errorf("creating data directory: %w", err)
+	}
+	store := newFileStore(cfg.Embedded.DataDir, cfg.OpenPolicyAgent.Host)
+	if err := store.init(); err != nil {
+		return fmt.Errorf("initializing data directory: %w", err)
+	}
+
+	mr := miniredis.NewMiniRedis()
+	if err := mr.StartAddr(cfg.Embedded.RedisListenAddr); err != nil {
+		return fmt.Errorf("starting embedded database: %w", err)
+	}
+	defer mr.Close()
+	log.WithField("addr", mr.Addr()).Info("main: embedded database listening")
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer func() {
+		if err := rdb.Close(); err != nil {
+			log.Printf("closing redis: %+v", err)
+		}
+	}()
+
+	if cfg.Embedded.PolicyDir != "" {
+		if err := loadPolicies(ctx, cfg.OpenPolicyAgent.Host, cfg.Embedded.PolicyDir); err != nil {
+			return err
+		}
+	}
+
+	// Create the services
+
+	legacyUntil, err := jwx.LegacyWindowEnd(cfg.Web.LegacyTokensUntil)
+	if err != nil {
+		return err
+	}
+	signingAlg, err := jwx.ParseSignatureAlgorithm(cfg.Web.JWTSigningAlgorithm)
+	if err != nil {
+		return err
+	}
+	hooks, err := webhook.New(log, cfg.Tenant.Webhooks)
+	if err != nil {
+		return err
+	}
+	tenantsvc.JWTSigningSecret = cfg.Web.JWTSigningSecret
+	tenantSvc := tenantsvc.NewTenantService(
+		tenantsvc.WithLogger(log),
+		tenantsvc.WithRedis(rdb),
+		tenantsvc.WithWebhooks(hooks),
+		tenantsvc.WithTokenManager(jwx.NewTokenManager(signingAlg,
+			jwx.WithIssuer(cfg.Web.JWTIssuer),
+			jwx.WithAudience(cfg.Web.JWTAudience),
+			jwx.WithLegacyTokensUntil(legacyUntil),
+			jwx.WithEncryptionKey(cfg.Web.JWTEncryptionKey))))
+
+	// Sync role changes to OPA in the background, retrying failures. The
+	// configured roles are synced first, since OPA does not keep them.
+	reconciler := role.NewReconciler(store, role.WithReconcilerLogger(log))
+	go reconciler.Run(ctx)
+	configured, err := store.GetConfiguredRoles(ctx)
+	if err != nil {
+		return fmt.Errorf("reading roles: %w", err)
+	}
+	if err := reconciler.UpdateRoles(ctx, configured); err != nil {
+		return fmt.Errorf("syncing roles: %w", err)
+	}
+	roleSvc := role.NewService(reconciler, validate.NewRoleValidator(store, log))
+
+	storageSvc := storage.NewService(store, storage.NewSystemValidator(store, log))
+	storageSvc.SetConcurrentPowerFlexRequests(appCfg.Viper().GetInt(concurrentPowerFlexRequests))
+
+	// Serve the services in-process
+
+	listeners := map[string]*bufconn.Listener{
+		tenantService:  bufconn.Listen(bufSize),
+		roleService:    bufconn.Listen(bufSize),
+		storageService: bufconn.Listen(bufSize),
+	}
+
+	newServer := func() *grpc.Server {
+		return grpc.NewServer(grpc.ChainUnaryInterceptor(errcode.UnaryServerInterceptor(), validation.UnaryServerInterceptor()))
+	}
+	tenantServer := newServer()
+	pb.RegisterTenantServiceServer(tenantServer, tenantmw.NewTelemetryMW(log, tenantSvc))
+	reportSvc := reportsvc.NewReportService(
+		reportsvc.WithLogger(log),
+		reportsvc.WithRedis(rdb),
+		reportsvc.WithTenants(reportsvc.LocalTenants{Server: tenantSvc}))
+	pb.RegisterReportServiceServer(tenantServer, reportSvc)
+	go reportSvc.Run(ctx, time.Hour)
+	pb.RegisterEventServiceServer(tenantServer, eventsvc.NewEventService(
+		eventsvc.WithLogger(log),
+		eventsvc.WithRedis(rdb)))
+	roleServer := newServer()
+	pb.RegisterRoleServiceServer(roleServer, rolemw.NewRoleTelemetryMW(log, roleSvc))
+	storageServer := newServer()
+	pb.RegisterStorageServiceServer(storageServer, storagemw.NewStorageTelemetryMW(log, storageSvc))
+
+	for name, gs := range map[string]*grpc.Server{
+		tenantService:  tenantServer,
+		roleService:    roleServer,
+		storageService: storageServer,
+	} {
+		l := listeners[name]
+		go func(name string, gs *grpc.Server) {
+			if err := gs.Serve(l); err != nil {
+				log.WithError(err).Errorf("main: serving %s", name)
+			}
+		}(name, gs)
+		defer gs.Stop()
+	}
+
+	dialer := grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		l, ok := listeners[addr]
+		if !ok {
+			return nil, fmt.Errorf("unknown service %q", addr)
+		}
+		return l.DialContext(ctx)
+	})
+
+	// Run the proxy-server until shutdown
+
+	return proxyserver.Run(log, proxyserver.Options{
+		RedisHost:          mr.Addr(),
+		TenantService:      tenantService,
+		RoleService:        roleService,
+		StorageService:     storageService,
+		ConfigFile:         cfgFile,
+		CSMConfigFile:      cfgFile,
+		StorageSystemsFile: store.storagePath(),
+		DialOptions:        []grpc.DialOption{dialer},
+	})
+}
+
+// loadPolicies pushes the rego policies in dir to OPA, other than tests.
+func loadPolicies(ctx context.Context, opaHost, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.rego"))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), ".rego")
+		if strings.HasSuffix(name, "_test") {
+			continue
+		}
+		module, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		// The roles module replaces common.rego, so they share an id.
+		if err := decision.PutPolicy(ctx, opaHost, "karavi/"+name, module); err != nil {
+			return fmt.Errorf("pushing policy %s to opa: %w", f, err)
+		}
+	}
+	return nil
+}
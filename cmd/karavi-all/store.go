@@ -0,0 +1,178 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"karavi-authorization/cmd/karavictl/cmd"
+	"karavi-authorization/internal/decision"
+	"karavi-authorization/internal/role-service/roles"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	rolesFile          = "roles.json"
+	storageSystemsFile = "storage-systems.yaml"
+	// rolesPolicyID is the id of the rego module of the roles in OPA.
+	rolesPolicyID = "karavi/common"
+)
+
+// fileStore keeps the roles and storage systems in files of a data
+// directory, in place of the roles ConfigMap and storage Secret of a
+// Kubernetes installation. Roles are pushed to OPA as they are updated,
+// and the storage systems file is the one watched by the proxy-server.
+type fileStore struct {
+	dir     string
+	opaHost string
+	putFn   func(ctx context.Context, host, id string, module []byte) error
+
+	mu sync.Mutex // guards the files
+}
+
+func newFileStore(dir, opaHost string) *fileStore {
+	return &fileStore{
+		dir:     dir,
+		opaHost: opaHost,
+		putFn:   decision.PutPolicy,
+	}
+}
+
+func (s *fileStore) storagePath() string {
+	return filepath.Join(s.dir, storageSystemsFile)
+}
+
+// init creates the storage systems file, if it does not exist, so that it
+// can be watched from the start.
+func (s *fileStore) init() error {
+	if _, err := os.Stat(s.storagePath()); err == nil {
+		return nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return s.UpdateStorages(context.Background(), cmd.Storage{})
+}
+
+// GetConfiguredRoles returns the configured roles
+func (s *fileStore) GetConfiguredRoles(_ context.Context) (*roles.JSON, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := os.ReadFile(filepath.Join(s.dir, rolesFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		existing := roles.NewJSON()
+		return &existing, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var existing roles.JSON
+	if err := json.Unmarshal(b, &existing); err != nil {
+		return nil, fmt.Errorf("decoding roles json: %w", err)
+	}
+	return &existing, nil
+}
+
+// UpdateRoles pushes the roles to OPA and writes them
+func (s *fileStore) UpdateRoles(ctx context.Context, rs *roles.JSON) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(rs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	module := `package karavi.common
+default roles = {}
+roles = ` + string(data)
+	if err := s.putFn(ctx, s.opaHost, rolesPolicyID, []byte(module)); err != nil {
+		return fmt.Errorf("pushing roles to opa: %w", err)
+	}
+
+	return writeFile(filepath.Join(s.dir, rolesFile), data)
+}
+
+// GetConfiguredStorage returns the configured storage systems
+func (s *fileStore) GetConfiguredStorage(_ context.Context) (cmd.Storage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readStorage()
+}
+
+// UpdateStorages writes the storage systems
+func (s *fileStore) UpdateStorages(_ context.Context, storages cmd.Storage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeStorage(storages)
+}
+
+// ModifyStorages applies fn to the storage systems and writes them. The
+// file is locked throughout, so concurrent modifications are not lost.
+func (s *fileStore) ModifyStorages(_ context.Context, fn func(cmd.Storage) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	storages, err := s.readStorage()
+	if err != nil {
+		return err
+	}
+	if err := fn(storages); err != nil {
+		return err
+	}
+	return s.writeStorage(storages)
+}
+
+func (s *fileStore) readStorage() (cmd.Storage, error) {
+	b, err := os.ReadFile(s.storagePath())
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]cmd.Storage
+	if err := yaml.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+	if data["storage"] == nil {
+		return cmd.Storage{}, nil
+	}
+	return data["storage"], nil
+}
+
+func (s *fileStore) writeStorage(storages cmd.Storage) error {
+	b, err := yaml.Marshal(map[string]cmd.Storage{"storage": storages})
+	if err != nil {
+		return err
+	}
+	return writeFile(s.storagePath(), b)
+}
+
+// writeFile replaces the file at path, by way of a temporary file so that
+// the proxy-server never reads it partially written.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
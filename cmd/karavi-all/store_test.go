@@ -0,0 +1,134 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"karavi-authorization/cmd/karavictl/cmd"
+	"karavi-authorization/internal/role-service/roles"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	t.Run("it initializes an empty storage systems file", func(t *testing.T) {
+		sut := newFileStore(t.TempDir(), "")
+
+		if err := sut.init(); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := sut.GetConfiguredStorage(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 0 {
+			t.Errorf("got %v, want no storage systems", got)
+		}
+	})
+	t.Run("it keeps the storage systems", func(t *testing.T) {
+		sut := newFileStore(t.TempDir(), "")
+		want := cmd.Storage{
+			"powerflex": cmd.SystemType{
+				"542a2d5f5122210f": cmd.System{User: "admin", Password: "password", Endpoint: "https://10.0.0.1"},
+			},
+		}
+
+		if err := sut.UpdateStorages(context.Background(), want); err != nil {
+			t.Fatal(err)
+		}
+		if err := sut.init(); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := sut.GetConfiguredStorage(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got["powerflex"]["542a2d5f5122210f"].Endpoint != "https://10.0.0.1" {
+			t.Errorf("got %v, want %v", got, want)
+		}
+		fi, err := os.Stat(filepath.Join(sut.dir, storageSystemsFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0o600 {
+			t.Errorf("got mode %v, want %v", fi.Mode().Perm(), os.FileMode(0o600))
+		}
+	})
+	t.Run("it returns no roles when none are configured", func(t *testing.T) {
+		sut := newFileStore(t.TempDir(), "")
+
+		got, err := sut.GetConfiguredRoles(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Instances()) != 0 {
+			t.Errorf("got %d roles, want 0", len(got.Instances()))
+		}
+	})
+	t.Run("it pushes the roles to opa and keeps them", func(t *testing.T) {
+		sut := newFileStore(t.TempDir(), "localhost:8181")
+		var gotID, gotModule string
+		sut.putFn = func(_ context.Context, _, id string, module []byte) error {
+			gotID, gotModule = id, string(module)
+			return nil
+		}
+		r, err := roles.NewInstance("bronze", "powerflex", "542a2d5f5122210f", "bronze", "9GB")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs := roles.NewJSON()
+		if err := rs.Add(r); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := sut.UpdateRoles(context.Background(), &rs); err != nil {
+			t.Fatal(err)
+		}
+
+		if gotID != rolesPolicyID {
+			t.Errorf("got id %q, want %q", gotID, rolesPolicyID)
+		}
+		if !strings.HasPrefix(gotModule, "package karavi.common\n") || !strings.Contains(gotModule, "bronze") {
+			t.Errorf("unexpected module %q", gotModule)
+		}
+		got, err := sut.GetConfiguredRoles(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Instances()) != 1 {
+			t.Errorf("got %d roles, want 1", len(got.Instances()))
+		}
+	})
+	t.Run("it does not keep roles that opa rejected", func(t *testing.T) {
+		sut := newFileStore(t.TempDir(), "localhost:8181")
+		sut.putFn = func(_ context.Context, _, _ string, _ []byte) error {
+			return errors.New("test error")
+		}
+		rs := roles.NewJSON()
+
+		if err := sut.UpdateRoles(context.Background(), &rs); err == nil {
+			t.Fatal("expected an error")
+		}
+
+		if _, err := os.Stat(filepath.Join(sut.dir, rolesFile)); !os.IsNotExist(err) {
+			t.Errorf("got %v, want no roles file", err)
+		}
+	})
+}
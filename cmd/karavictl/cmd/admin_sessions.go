@@ -0,0 +1,115 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"karavi-authorization/internal/proxy"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// NewAdminSessionsCmd creates a new command for the sessions of admin tokens
+func NewAdminSessionsCmd() *cobra.Command {
+	sessionsCmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Manage the sessions of admin tokens",
+		Long: `Manages the sessions of admin tokens. A session starts the first time the
+refresh token of an admin token is used and ends when it expires, is idle for too
+long or is revoked.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			err := cmd.Usage()
+			if err != nil {
+				reportErrorAndExit(JSONOutput, os.Stderr, err)
+			}
+			os.Exit(1)
+		},
+	}
+
+	sessionsCmd.PersistentFlags().StringP("admin-token", "f", "", "Path to admin token file; required")
+	sessionsCmd.PersistentFlags().String("addr", "", "Address of the CSM Authorization Proxy Server; required")
+	sessionsCmd.PersistentFlags().Bool("insecure", false, "Skip certificate validation of the CSM Authorization Proxy Server")
+
+	sessionsCmd.AddCommand(NewAdminSessionsListCmd())
+	sessionsCmd.AddCommand(NewAdminSessionsRevokeCmd())
+	return sessionsCmd
+}
+
+// NewAdminSessionsListCmd creates a new command to list the sessions of admin tokens
+func NewAdminSessionsListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the sessions of admin tokens",
+		Long:  `Lists the sessions of admin tokens`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			client, adminTknBody := policyClient(cmd)
+
+			var resp proxy.AdminSessionsResponse
+			err := doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Get(ctx, "/proxy/admin/sessions/", headers, nil, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			err = JSONOutput(cmd.OutOrStdout(), &resp)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+}
+
+// NewAdminSessionsRevokeCmd creates a new command to revoke a session of an admin token
+func NewAdminSessionsRevokeCmd() *cobra.Command {
+	revokeCmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Revoke a session of an admin token",
+		Long:  `Revokes a session of an admin token so its refresh token can no longer be used`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			id, err := cmd.Flags().GetString("id")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if id = strings.TrimSpace(id); id == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("empty session id"))
+			}
+
+			client, adminTknBody := policyClient(cmd)
+
+			query := url.Values{}
+			query.Set("id", id)
+			err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Delete(ctx, "/proxy/admin/sessions/", headers, query, nil, nil)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+
+	revokeCmd.Flags().String("id", "", "Session ID")
+	return revokeCmd
+}
@@ -0,0 +1,114 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestAdminSessions(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	t.Run("it lists the sessions", func(t *testing.T) {
+		defer afterFn()
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				GetFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, resp interface{}) error {
+					if path != "/proxy/admin/sessions/" {
+						t.Errorf("got path %q", path)
+					}
+					b := []byte(`{"sessions": [{"id": "abc", "admin": "admin", "createdAt": "2024-01-01T00:00:00Z"}]}`)
+					return json.Unmarshal(b, resp)
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs([]string{"admin", "sessions", "list", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		var got proxy.AdminSessionsResponse
+		if err := json.Unmarshal(gotOutput.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Sessions) != 1 || got.Sessions[0].ID != "abc" {
+			t.Errorf("got %+v, want session abc", got)
+		}
+	})
+
+	t.Run("it revokes a session", func(t *testing.T) {
+		defer afterFn()
+		var gotQuery url.Values
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				DeleteFn: func(_ context.Context, _ string, _ map[string]string, query url.Values, _, _ interface{}) error {
+					gotQuery = query
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+
+		cmd := NewRootCmd()
+		cmd.SetArgs([]string{"admin", "sessions", "revoke", "--id", "abc", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		if got := gotQuery.Get("id"); got != "abc" {
+			t.Errorf("got id %q, want %q", got, "abc")
+		}
+	})
+
+	t.Run("it requires a session id", func(t *testing.T) {
+		defer afterFn()
+		var gotCode int
+		done := make(chan struct{})
+		osExit = func(code int) {
+			gotCode = code
+			done <- struct{}{}
+			done <- struct{}{} // we can't let this function return
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetErr(&gotOutput)
+		cmd.SetArgs([]string{"admin", "sessions", "revoke", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		go cmd.Execute()
+		<-done
+
+		if gotCode != 1 {
+			t.Errorf("got exit code %d, want 1", gotCode)
+		}
+	})
+}
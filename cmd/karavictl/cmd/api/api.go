@@ -23,6 +23,8 @@ import (
 	"karavi-authorization/internal/web"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 )
 
 const (
@@ -79,6 +81,9 @@ type ClientOptions struct {
 	// Insecure is a flag that indicates whether or not to validate certificates.
 	Insecure bool
 
+	// CertificateAuthority is a PEM certificate authority trusted in addition to the system ones.
+	CertificateAuthority []byte
+
 	// HttpClient specifies a custom http client for this client.
 	HTTPClient *http.Client
 }
@@ -114,6 +119,9 @@ func New(
 		if err != nil {
 			return nil, err
 		}
+		if len(opts.CertificateAuthority) > 0 && !pool.AppendCertsFromPEM(opts.CertificateAuthority) {
+			return nil, fmt.Errorf("no certificates found in the certificate authority")
+		}
 
 		c.http.Transport = &http.Transport{
 			TLSClientConfig: &tls.Config{
@@ -297,6 +305,10 @@ func (c *client) DoAndGetResponseBody(
 		req.Header.Add(header, value)
 	}
 
+	// make the request unique for replay protection
+	req.Header.Set(web.HeaderRequestNonce, web.NewRequestNonce())
+	req.Header.Set(web.HeaderRequestTime, strconv.FormatInt(time.Now().Unix(), 10))
+
 	// add query values to the request
 	if query != nil {
 		req.URL.RawQuery = query.Encode()
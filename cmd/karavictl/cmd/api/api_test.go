@@ -16,9 +16,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"karavi-authorization/internal/web"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -47,6 +49,8 @@ func TestAPI(t *testing.T) {
 			w.Write([]byte(fmt.Sprintf(`{"key": "%s"}`, b.Key)))
 		case "/delete":
 			w.Write([]byte(fmt.Sprintf(`{"key": "%s"}`, r.URL.Query().Get("key"))))
+		case "/nonce":
+			w.Write([]byte(fmt.Sprintf(`{"key": "%s"}`, r.Header.Get(web.HeaderRequestNonce)+"@"+r.Header.Get(web.HeaderRequestTime))))
 		default:
 			t.Fatalf("%s not supported", r.URL.Path)
 		}
@@ -105,6 +109,26 @@ func TestAPI(t *testing.T) {
 		}
 	})
 
+	t.Run("it makes each request unique", func(t *testing.T) {
+		var first, second body
+		err = insecureClient.Get(context.Background(), "/nonce", nil, nil, &first)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = insecureClient.Get(context.Background(), "/nonce", nil, nil, &second)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nonce, iat, _ := strings.Cut(first.Key, "@")
+		if nonce == "" || iat == "" {
+			t.Fatalf("expected a nonce and a time, got %q", first.Key)
+		}
+		if next, _, _ := strings.Cut(second.Key, "@"); next == nonce {
+			t.Errorf("expected a new nonce, got %q twice", nonce)
+		}
+	})
+
 	t.Run("DELETE", func(t *testing.T) {
 		var resp body
 		values := url.Values{
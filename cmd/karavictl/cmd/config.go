@@ -0,0 +1,341 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// ConfigPathEnv is the environment variable that overrides the path of the
+// karavictl config file.
+const ConfigPathEnv = "KARAVICTL_CONFIG"
+
+// karavictlConfig is the karavictl config file, holding the contexts of the
+// CSM Authorization deployments managed by the operator.
+type karavictlConfig struct {
+	CurrentContext string          `json:"current-context"`
+	Contexts       []configContext `json:"contexts"`
+}
+
+// configContext is the proxy server of a CSM Authorization deployment and how
+// to reach it.
+type configContext struct {
+	Name                 string `json:"name"`
+	Addr                 string `json:"addr"`
+	AdminToken           string `json:"admin-token,omitempty"`
+	CertificateAuthority string `json:"certificate-authority,omitempty"`
+	Insecure             bool   `json:"insecure,omitempty"`
+}
+
+// context returns the context with the name, if any.
+func (c *karavictlConfig) context(name string) (*configContext, bool) {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			return &c.Contexts[i], true
+		}
+	}
+	return nil, false
+}
+
+// configPath returns the path of the karavictl config file, ~/.karavictl/config
+// unless overridden by KARAVICTL_CONFIG.
+func configPath() (string, error) {
+	if p := os.Getenv(ConfigPathEnv); p != "" {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".karavictl", "config"), nil
+}
+
+// readConfig reads the karavictl config file. A missing file is an empty config.
+func readConfig() (*karavictlConfig, error) {
+	p, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(filepath.Clean(p))
+	if errors.Is(err, os.ErrNotExist) {
+		return &karavictlConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cfg karavictlConfig
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", p, err)
+	}
+	return &cfg, nil
+}
+
+// writeConfig writes the karavictl config file, which may hold the paths of
+// admin tokens and so is only readable by the user.
+func writeConfig(cfg *karavictlConfig) error {
+	p, err := configPath()
+	if err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(p, b, 0o600)
+}
+
+// applyCurrentContext sets the addr, admin-token and insecure flags of the
+// command that were not given from the current context, if any, and reads the
+// certificate authority of the context.
+func applyCurrentContext(cmd *cobra.Command) error {
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.CurrentContext == "" {
+		return nil
+	}
+	cur, ok := cfg.context(cfg.CurrentContext)
+	if !ok {
+		return fmt.Errorf("current context %s not found", cfg.CurrentContext)
+	}
+
+	set := func(name, value string) error {
+		f := cmd.Flags().Lookup(name)
+		if f == nil || f.Changed || value == "" {
+			return nil
+		}
+		return cmd.Flags().Set(name, value)
+	}
+	if err := set("addr", cur.Addr); err != nil {
+		return err
+	}
+	if err := set("admin-token", cur.AdminToken); err != nil {
+		return err
+	}
+	if cur.Insecure {
+		if err := set("insecure", "true"); err != nil {
+			return err
+		}
+	}
+
+	if cur.CertificateAuthority != "" {
+		certificateAuthority, err = os.ReadFile(filepath.Clean(cur.CertificateAuthority))
+		if err != nil {
+			return fmt.Errorf("reading certificate authority of context %s: %w", cur.Name, err)
+		}
+	}
+	return nil
+}
+
+// NewConfigCmd creates a new config command
+func NewConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage karavictl contexts",
+		Long: `Manages the contexts of karavictl in ~/.karavictl/config, or the file in KARAVICTL_CONFIG.
+A context holds the address, certificate authority and admin token of a CSM Authorization
+Proxy Server, used by commands for the flags that are not given.`,
+		// Overrides the root hook so that set-context does not take the
+		// flags of the current context.
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			return nil
+		},
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := cmd.Usage(); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %+v\n", err)
+			}
+			os.Exit(1)
+		},
+	}
+
+	configCmd.AddCommand(NewConfigSetContextCmd())
+	configCmd.AddCommand(NewConfigUseContextCmd())
+	configCmd.AddCommand(NewConfigGetContextsCmd())
+	configCmd.AddCommand(NewConfigCurrentContextCmd())
+	configCmd.AddCommand(NewConfigDeleteContextCmd())
+	return configCmd
+}
+
+// NewConfigSetContextCmd creates a new command to create or update a context
+func NewConfigSetContextCmd() *cobra.Command {
+	setContextCmd := &cobra.Command{
+		Use:   "set-context NAME",
+		Short: "Create or update a context",
+		Long:  `Creates a context, or updates the given fields of an existing one.`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := readConfig()
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			ctx, ok := cfg.context(args[0])
+			if !ok {
+				cfg.Contexts = append(cfg.Contexts, configContext{Name: args[0]})
+				ctx = &cfg.Contexts[len(cfg.Contexts)-1]
+			}
+
+			if cmd.Flags().Changed("addr") {
+				ctx.Addr, err = cmd.Flags().GetString("addr")
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+			}
+			if cmd.Flags().Changed("insecure") {
+				ctx.Insecure, err = cmd.Flags().GetBool("insecure")
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+			}
+			// Paths are made absolute so that the context works from any directory.
+			for name, field := range map[string]*string{"admin-token": &ctx.AdminToken, "certificate-authority": &ctx.CertificateAuthority} {
+				if !cmd.Flags().Changed(name) {
+					continue
+				}
+				p, err := cmd.Flags().GetString(name)
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+				if p != "" {
+					p, err = filepath.Abs(p)
+					if err != nil {
+						reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+					}
+				}
+				*field = p
+			}
+			if ctx.Addr == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("context %s has no address", ctx.Name))
+			}
+
+			if err := writeConfig(cfg); err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+
+	setContextCmd.Flags().String("addr", "", "Address of the CSM Authorization Proxy Server")
+	setContextCmd.Flags().StringP("admin-token", "f", "", "Path to admin token file")
+	setContextCmd.Flags().String("certificate-authority", "", "Path to the certificate authority of the CSM Authorization Proxy Server")
+	setContextCmd.Flags().Bool("insecure", false, "Skip certificate validation of the CSM Authorization Proxy Server")
+	return setContextCmd
+}
+
+// NewConfigUseContextCmd creates a new command to set the current context
+func NewConfigUseContextCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use-context NAME",
+		Short: "Set the current context",
+		Long:  `Sets the current context, used by commands for the flags that are not given.`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := readConfig()
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if _, ok := cfg.context(args[0]); !ok {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("context %s not found", args[0]))
+			}
+
+			cfg.CurrentContext = args[0]
+			if err := writeConfig(cfg); err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+}
+
+// NewConfigGetContextsCmd creates a new command to list the contexts
+func NewConfigGetContextsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get-contexts",
+		Short: "List the contexts",
+		Long:  `Lists the contexts and the current context.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			cfg, err := readConfig()
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if cfg.Contexts == nil {
+				cfg.Contexts = []configContext{}
+			}
+			if err := JSONOutput(cmd.OutOrStdout(), cfg); err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+}
+
+// NewConfigCurrentContextCmd creates a new command to show the current context
+func NewConfigCurrentContextCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "current-context",
+		Short: "Show the current context",
+		Long:  `Shows the name of the current context.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			cfg, err := readConfig()
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if cfg.CurrentContext == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("current context is not set"))
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), cfg.CurrentContext)
+		},
+	}
+}
+
+// NewConfigDeleteContextCmd creates a new command to delete a context
+func NewConfigDeleteContextCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete-context NAME",
+		Short: "Delete a context",
+		Long:  `Deletes a context, unsetting the current context if it was the one deleted.`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := readConfig()
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if _, ok := cfg.context(args[0]); !ok {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("context %s not found", args[0]))
+			}
+
+			contexts := cfg.Contexts[:0]
+			for _, c := range cfg.Contexts {
+				if c.Name != args[0] {
+					contexts = append(contexts, c)
+				}
+			}
+			cfg.Contexts = contexts
+			if cfg.CurrentContext == args[0] {
+				cfg.CurrentContext = ""
+			}
+			if err := writeConfig(cfg); err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+		certificateAuthority = nil
+	}
+
+	execute := func(t *testing.T, args ...string) string {
+		var gotOutput bytes.Buffer
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		return gotOutput.String()
+	}
+
+	t.Run("it creates and uses contexts", func(t *testing.T) {
+		defer afterFn()
+		dir := t.TempDir()
+		t.Setenv(ConfigPathEnv, filepath.Join(dir, "config"))
+
+		execute(t, "config", "set-context", "prod", "--addr", "prod.proxy.com", "--admin-token", filepath.Join(dir, "prod.yaml"))
+		execute(t, "config", "set-context", "dev", "--addr", "dev.proxy.com", "--admin-token", filepath.Join(dir, "dev.yaml"), "--insecure")
+		execute(t, "config", "set-context", "prod", "--certificate-authority", filepath.Join(dir, "ca.pem"))
+		execute(t, "config", "use-context", "prod")
+
+		var got karavictlConfig
+		if err := json.Unmarshal([]byte(execute(t, "config", "get-contexts")), &got); err != nil {
+			t.Fatal(err)
+		}
+		want := karavictlConfig{
+			CurrentContext: "prod",
+			Contexts: []configContext{
+				{Name: "prod", Addr: "prod.proxy.com", AdminToken: filepath.Join(dir, "prod.yaml"), CertificateAuthority: filepath.Join(dir, "ca.pem")},
+				{Name: "dev", Addr: "dev.proxy.com", AdminToken: filepath.Join(dir, "dev.yaml"), Insecure: true},
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+		if got := execute(t, "config", "current-context"); got != "prod\n" {
+			t.Errorf("got current context %q, want %q", got, "prod\n")
+		}
+
+		execute(t, "config", "delete-context", "prod")
+		cfg, err := readConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.CurrentContext != "" || len(cfg.Contexts) != 1 || cfg.Contexts[0].Name != "dev" {
+			t.Errorf("got %+v, want only the dev context", cfg)
+		}
+	})
+
+	t.Run("commands take the flags not given from the current context", func(t *testing.T) {
+		defer afterFn()
+		dir := t.TempDir()
+		t.Setenv(ConfigPathEnv, filepath.Join(dir, "config"))
+		err := writeConfig(&karavictlConfig{
+			CurrentContext: "dev",
+			Contexts:       []configContext{{Name: "dev", Addr: "dev.proxy.com", AdminToken: "dev.yaml", Insecure: true}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var gotAddr, gotTokenFile string
+		var gotInsecure bool
+		CreateHTTPClient = func(addr string, insecure bool) (api.Client, error) {
+			gotAddr, gotInsecure = addr, insecure
+			return &mocks.FakeClient{
+				PostFn: func(_ context.Context, _ string, _ map[string]string, _ url.Values, _, _ interface{}) error {
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(file string) (string, string, error) {
+			gotTokenFile = file
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+
+		execute(t, "storage", "resume", "--system-id", "542a2d5f5122210f")
+		if gotAddr != "https://dev.proxy.com" || gotTokenFile != "dev.yaml" || !gotInsecure {
+			t.Errorf("got addr %q, token %q, insecure %v, want those of the dev context", gotAddr, gotTokenFile, gotInsecure)
+		}
+
+		execute(t, "storage", "resume", "--system-id", "542a2d5f5122210f", "--addr", "other.proxy.com")
+		if gotAddr != "https://other.proxy.com" || gotTokenFile != "dev.yaml" {
+			t.Errorf("got addr %q, token %q, want the given addr and the token of the dev context", gotAddr, gotTokenFile)
+		}
+	})
+
+	t.Run("it fails to use a missing context", func(t *testing.T) {
+		defer afterFn()
+		t.Setenv(ConfigPathEnv, filepath.Join(t.TempDir(), "config"))
+
+		var gotCode int
+		done := make(chan struct{})
+		osExit = func(code int) {
+			gotCode = code
+			done <- struct{}{}
+			done <- struct{}{} // we can't let this function return
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetErr(&gotOutput)
+		cmd.SetArgs([]string{"config", "use-context", "prod"})
+		go cmd.Execute()
+		<-done
+
+		if gotCode != 1 {
+			t.Errorf("got exit code %d, want 1", gotCode)
+		}
+		var gotErr CommandError
+		if err := json.NewDecoder(&gotOutput).Decode(&gotErr); err != nil {
+			t.Fatal(err)
+		}
+		if want := "context prod not found"; gotErr.ErrorMsg != want {
+			t.Errorf("got err %q, want %q", gotErr.ErrorMsg, want)
+		}
+	})
+}
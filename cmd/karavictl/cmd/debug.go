@@ -0,0 +1,204 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// NewDebugCmd creates a new debug command
+func NewDebugCmd() *cobra.Command {
+	debugCmd := &cobra.Command{
+		Use:   "debug",
+		Short: "Troubleshoot the CSM Authorization Proxy Server",
+		Long:  `Troubleshoot the CSM Authorization Proxy Server`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := cmd.Usage(); err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("error: %+v", err))
+			}
+			os.Exit(1)
+		},
+	}
+
+	debugCmd.PersistentFlags().StringP("admin-token", "f", "", "Path to admin token file; required")
+	debugCmd.PersistentFlags().String("addr", "", "Address of the CSM Authorization Proxy Server; required")
+	debugCmd.PersistentFlags().Bool("insecure", false, "Skip certificate validation of the CSM Authorization Proxy Server")
+
+	err := debugCmd.MarkPersistentFlagRequired("admin-token")
+	if err != nil {
+		reportErrorAndExit(JSONOutput, debugCmd.ErrOrStderr(), err)
+	}
+
+	err = debugCmd.MarkPersistentFlagRequired("addr")
+	if err != nil {
+		reportErrorAndExit(JSONOutput, debugCmd.ErrOrStderr(), err)
+	}
+
+	debugCmd.AddCommand(NewDebugCaptureCmd())
+	return debugCmd
+}
+
+// NewDebugCaptureCmd creates a new debug capture command
+func NewDebugCaptureCmd() *cobra.Command {
+	captureCmd := &cobra.Command{
+		Use:   "capture",
+		Short: "Capture the requests of a tenant",
+		Long: `Captures the requests of a tenant to its storage systems for a limited time, without
+raising the log level of the proxy server. The method, path, status, duration and headers
+of the latest requests are kept, with their secrets redacted. Bodies are only captured
+with --bodies.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := cmd.Usage(); err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("error: %+v", err))
+			}
+			os.Exit(1)
+		},
+	}
+
+	captureCmd.PersistentFlags().StringP("tenant", "n", "", "Tenant name; required")
+
+	captureCmd.AddCommand(NewDebugCaptureStartCmd())
+	captureCmd.AddCommand(NewDebugCaptureGetCmd())
+	captureCmd.AddCommand(NewDebugCaptureStopCmd())
+	return captureCmd
+}
+
+// NewDebugCaptureStartCmd creates a new debug capture start command
+func NewDebugCaptureStartCmd() *cobra.Command {
+	startCmd := &cobra.Command{
+		Use:     "start",
+		Short:   "Start capturing the requests of a tenant",
+		Long:    `Starts capturing the requests of a tenant, or restarts the capture with new settings.`,
+		Example: `karavictl debug capture start --tenant mytenant --duration 15m --admin-token admintoken.yaml --addr csm-authorization.com`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			tenant := captureTenant(cmd)
+			duration, err := cmd.Flags().GetDuration("duration")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			size, err := cmd.Flags().GetInt("size")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			bodies, err := cmd.Flags().GetBool("bodies")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			client, adminTknBody := policyClient(cmd)
+			body := proxy.Capture{
+				Tenant:   tenant,
+				Duration: proxy.Duration(duration),
+				Size:     size,
+				Bodies:   bodies,
+			}
+			var resp proxy.Capture
+			err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Post(ctx, "/proxy/captures/", headers, nil, &body, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			err = JSONOutput(cmd.OutOrStdout(), &resp)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("unable to format json output: %v", err))
+			}
+		},
+	}
+
+	startCmd.Flags().Duration("duration", proxy.DefaultCaptureDuration, fmt.Sprintf("How long to capture the requests, at most %v", proxy.MaxCaptureDuration))
+	startCmd.Flags().Int("size", proxy.DefaultCaptureSize, fmt.Sprintf("Number of the latest requests to keep, at most %d", proxy.MaxCaptureSize))
+	startCmd.Flags().Bool("bodies", false, fmt.Sprintf("Capture the first %d bytes of the request and response bodies", proxy.CaptureBodyLimit))
+	return startCmd
+}
+
+// NewDebugCaptureGetCmd creates a new debug capture get command
+func NewDebugCaptureGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get",
+		Short: "Get the captured requests of a tenant",
+		Long: fmt.Sprintf(`Gets the capture of a tenant, if it is running, and the captured requests, which are
+kept for %v after the capture ended.`, proxy.CaptureRetention),
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			tenant := captureTenant(cmd)
+			client, adminTknBody := policyClient(cmd)
+
+			query := url.Values{}
+			query.Set("tenant", tenant)
+			var resp proxy.CaptureResponse
+			err := doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Get(ctx, "/proxy/captures/", headers, query, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			err = JSONOutput(cmd.OutOrStdout(), &resp)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("unable to format json output: %v", err))
+			}
+		},
+	}
+}
+
+// NewDebugCaptureStopCmd creates a new debug capture stop command
+func NewDebugCaptureStopCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop",
+		Short: "Stop capturing the requests of a tenant",
+		Long:  `Stops capturing the requests of a tenant. The captured requests can still be retrieved.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			tenant := captureTenant(cmd)
+			client, adminTknBody := policyClient(cmd)
+
+			query := url.Values{}
+			query.Set("tenant", tenant)
+			err := doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Delete(ctx, "/proxy/captures/", headers, query, nil, nil)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+}
+
+func captureTenant(cmd *cobra.Command) string {
+	tenant, err := cmd.Flags().GetString("tenant")
+	if err != nil {
+		reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+	}
+	if tenant == "" {
+		reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("empty tenant name"))
+	}
+	return tenant
+}
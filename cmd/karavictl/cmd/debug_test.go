@@ -0,0 +1,111 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDebugCapture(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	var gotPath string
+	var gotQuery url.Values
+	var gotBody interface{}
+	setup := func() {
+		gotPath, gotQuery, gotBody = "", nil, nil
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				GetFn: func(_ context.Context, path string, _ map[string]string, query url.Values, resp interface{}) error {
+					gotPath, gotQuery = path, query
+					*resp.(*proxy.CaptureResponse) = proxy.CaptureResponse{Requests: []proxy.CaptureRecord{{Method: "GET", Path: "/api/version", Status: 200}}}
+					return nil
+				},
+				PostFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, resp interface{}) error {
+					gotPath, gotBody = path, body
+					*resp.(*proxy.Capture) = *body.(*proxy.Capture)
+					return nil
+				},
+				DeleteFn: func(_ context.Context, path string, _ map[string]string, query url.Values, _, _ interface{}) error {
+					gotPath, gotQuery = path, query
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+	}
+	run := func(t *testing.T, args ...string) []byte {
+		var out bytes.Buffer
+		cmd := NewRootCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs(append(args, "--admin-token", "admin.yaml", "--addr", "proxy.com"))
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		return out.Bytes()
+	}
+
+	t.Run("it starts a capture", func(t *testing.T) {
+		defer afterFn()
+		setup()
+
+		run(t, "debug", "capture", "start", "--tenant", "mytenant", "--duration", "15m", "--bodies")
+
+		want := proxy.Capture{Tenant: "mytenant", Duration: proxy.Duration(15 * time.Minute), Size: proxy.DefaultCaptureSize, Bodies: true}
+		if gotPath != "/proxy/captures/" || *gotBody.(*proxy.Capture) != want {
+			t.Errorf("got %s %+v, want /proxy/captures/ %+v", gotPath, gotBody, want)
+		}
+	})
+	t.Run("it gets the captured requests", func(t *testing.T) {
+		defer afterFn()
+		setup()
+
+		out := run(t, "debug", "capture", "get", "--tenant", "mytenant")
+
+		var got proxy.CaptureResponse
+		if err := json.Unmarshal(out, &got); err != nil {
+			t.Fatal(err)
+		}
+		if gotQuery.Get("tenant") != "mytenant" || len(got.Requests) != 1 || got.Requests[0].Path != "/api/version" {
+			t.Errorf("got query %v and %+v, want the requests of mytenant", gotQuery, got)
+		}
+	})
+	t.Run("it stops a capture", func(t *testing.T) {
+		defer afterFn()
+		setup()
+
+		run(t, "debug", "capture", "stop", "--tenant", "mytenant")
+
+		if gotPath != "/proxy/captures/" || gotQuery.Get("tenant") != "mytenant" {
+			t.Errorf("got %s %v, want the capture of mytenant stopped", gotPath, gotQuery)
+		}
+	})
+}
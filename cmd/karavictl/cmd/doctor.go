@@ -0,0 +1,337 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/role-service/roles"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Statuses of a doctor check.
+const (
+	checkPass = "pass"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// doctorCheck is the result of a doctor check.
+type doctorCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// doctorReport is the result of every doctor check.
+type doctorReport struct {
+	Checks []doctorCheck `json:"checks"`
+}
+
+// failed returns true if any check failed.
+func (r *doctorReport) failed() bool {
+	for _, c := range r.Checks {
+		if c.Status == checkFail {
+			return true
+		}
+	}
+	return false
+}
+
+// NewDoctorCmd creates a new command to check the CSM Authorization deployment
+func NewDoctorCmd() *cobra.Command {
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the connectivity and consistency of CSM Authorization",
+		Long: `Checks that the proxy server is reachable, the admin token is valid, the OPA
+policies are loaded, the roles bound to tenants exist, the storage systems are
+reachable and the quota usage in Redis is consistent with the roles of the
+tenants. Failed checks have a hint on how to remedy them; the command exits
+with status 1 if any check failed.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			outputFormat, err := cmd.Flags().GetString("output")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if outputFormat != "table" && outputFormat != "json" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("unknown output format %q", outputFormat))
+			}
+
+			client, adminTknBody := policyClient(cmd)
+			report := runDoctor(ctx, client, adminTknBody)
+
+			if outputFormat == "json" {
+				err = JSONOutput(cmd.OutOrStdout(), &report)
+			} else {
+				err = writeDoctorTable(cmd.OutOrStdout(), &report)
+			}
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if report.failed() {
+				osExit(1)
+			}
+		},
+	}
+
+	doctorCmd.Flags().StringP("admin-token", "f", "", "Path to admin token file; required")
+	doctorCmd.Flags().String("addr", "", "Address of the CSM Authorization Proxy Server; required")
+	doctorCmd.Flags().Bool("insecure", false, "Skip certificate validation of the CSM Authorization Proxy Server")
+	doctorCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	return doctorCmd
+}
+
+// runDoctor runs the doctor checks in order. Checks that depend on a check
+// that failed are skipped.
+func runDoctor(ctx context.Context, client api.Client, adminTknBody token.AdminToken) doctorReport {
+	var report doctorReport
+	add := func(name string, err error, hint, passMsg string) bool {
+		c := doctorCheck{Name: name, Status: checkPass, Message: passMsg}
+		if err != nil {
+			c = doctorCheck{Name: name, Status: checkFail, Message: err.Error(), Hint: hint}
+		}
+		report.Checks = append(report.Checks, c)
+		return err == nil
+	}
+	skip := func(reason string, names ...string) {
+		for _, name := range names {
+			report.Checks = append(report.Checks, doctorCheck{Name: name, Status: checkSkip, Message: reason})
+		}
+	}
+	get := func(path string, query url.Values, resp interface{}) error {
+		return doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+			return client.Get(ctx, path, headers, query, resp)
+		})
+	}
+
+	err := client.Get(ctx, web.HealthzPath, nil, nil, nil)
+	if !add("proxy reachable", err,
+		"Check --addr, the proxy-server pods and the ingress; use --insecure for a self-signed certificate", "") {
+		skip("proxy not reachable", "admin token valid", "policies loaded", "tenant roles exist",
+			"storage systems reachable", "quota usage consistent")
+		return report
+	}
+
+	var tenants pb.ListTenantResponse
+	err = get("/proxy/tenant/", nil, &tenants)
+	if !add("admin token valid", err, "Generate a new admin token with karavictl admin token", "") {
+		skip("admin token not valid", "policies loaded", "tenant roles exist",
+			"storage systems reachable", "quota usage consistent")
+		return report
+	}
+	sort.Slice(tenants.Tenants, func(i, j int) bool { return tenants.Tenants[i].Name < tenants.Tenants[j].Name })
+
+	var policies []proxy.PolicyInfo
+	err = get("/proxy/policies/", nil, &policies)
+	if err == nil {
+		err = checkPoliciesLoaded(policies)
+	}
+	add("policies loaded", err,
+		"Push the policies with karavictl policy push, or check the logs of the opa container of the proxy-server", "")
+
+	var list pb.RoleListResponse
+	r := roles.NewJSON()
+	err = get("/proxy/roles", nil, &list)
+	if err == nil {
+		err = r.UnmarshalJSON(list.Roles)
+	}
+	rolesOK := err == nil
+	if err == nil {
+		err = checkTenantRoles(tenants.Tenants, &r)
+	}
+	add("tenant roles exist", err,
+		"Create the missing roles with karavictl role create, or unbind them with karavictl rolebinding delete",
+		fmt.Sprintf("%d tenants", len(tenants.Tenants)))
+
+	// The status is in the protobuf JSON format, which has 64-bit integers
+	// as strings.
+	var statusResp json.RawMessage
+	var status pb.StorageStatusResponse
+	err = get("/proxy/storage/status/", nil, &statusResp)
+	if err == nil {
+		err = protojson.Unmarshal(statusResp, &status)
+	}
+	if err == nil {
+		err = checkStorageReachable(status.Systems)
+	}
+	add("storage systems reachable", err,
+		"Check the endpoint and credentials of the storage systems, shown by karavictl storage status, and update them with karavictl storage update",
+		fmt.Sprintf("%d storage systems", len(status.Systems)))
+
+	if !rolesOK {
+		skip("roles not listed", "quota usage consistent")
+		return report
+	}
+	var errs []error
+	for _, t := range tenants.Tenants {
+		var quotaResp json.RawMessage
+		var quota pb.TenantQuota
+		err = get("/proxy/tenant/quota/", url.Values{"name": []string{t.Name}}, &quotaResp)
+		if err == nil {
+			err = protojson.Unmarshal(quotaResp, &quota)
+		}
+		if err == nil {
+			err = checkQuotaUsage(t, quota.Pools, &r)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", t.Name, err))
+		}
+	}
+	add("quota usage consistent", errors.Join(errs...),
+		"Bind the roles of the pools to the tenants again, or delete the volumes the tenants still have in them", "")
+
+	return report
+}
+
+// checkPoliciesLoaded returns an error if no policy module is loaded in OPA,
+// or if a managed policy is not loaded.
+func checkPoliciesLoaded(policies []proxy.PolicyInfo) error {
+	var modules int
+	var notLoaded []string
+	for _, p := range policies {
+		if !p.Loaded {
+			notLoaded = append(notLoaded, fmt.Sprintf("%s %s", p.Kind, p.ID))
+			continue
+		}
+		if p.Kind == proxy.PolicyKindModule {
+			modules++
+		}
+	}
+	if len(notLoaded) != 0 {
+		return fmt.Errorf("not loaded: %s", strings.Join(notLoaded, ", "))
+	}
+	if modules == 0 {
+		return errors.New("no policy modules loaded")
+	}
+	return nil
+}
+
+// checkTenantRoles returns an error listing the roles bound to the tenants
+// that do not exist.
+func checkTenantRoles(tenants []*pb.Tenant, r *roles.JSON) error {
+	names := roleNames(r)
+	var missing []string
+	for _, t := range tenants {
+		for _, role := range strings.Split(t.Roles, ",") {
+			role = strings.TrimSpace(role)
+			if role == "" {
+				continue
+			}
+			if _, ok := names[role]; !ok {
+				missing = append(missing, fmt.Sprintf("%s (tenant %s)", role, t.Name))
+			}
+		}
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("missing roles: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// checkStorageReachable returns an error listing the storage systems whose
+// last health check was not reachable.
+func checkStorageReachable(systems []*pb.StorageSystemStatus) error {
+	var failed []string
+	for _, s := range systems {
+		if s.Status == "reachable" {
+			continue
+		}
+		msg := fmt.Sprintf("%s %s is %s", s.StorageType, s.SystemId, s.Status)
+		if s.Error != "" {
+			msg += ": " + s.Error
+		}
+		failed = append(failed, msg)
+	}
+	if len(failed) != 0 {
+		return errors.New(strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// checkQuotaUsage returns an error listing the pools the tenant has approved
+// usage in that none of its roles permit. The pools of protection domain
+// roles are not known, so every pool of the system is taken as permitted.
+func checkQuotaUsage(tenant *pb.Tenant, pools []*pb.PoolUsage, r *roles.JSON) error {
+	bound := make(map[string]struct{})
+	for _, role := range strings.Split(tenant.Roles, ",") {
+		bound[strings.TrimSpace(role)] = struct{}{}
+	}
+	permitted := func(u *pb.PoolUsage) bool {
+		for _, in := range r.Instances() {
+			if _, ok := bound[in.Name]; !ok || in.SystemType != u.SystemType || in.SystemID != u.SystemId {
+				continue
+			}
+			if in.Pool == u.Pool || strings.HasPrefix(in.Pool, roles.ProtectionDomainPrefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var orphaned []string
+	for _, u := range pools {
+		if u.ApprovedCapacity == 0 && u.ApprovedVolumes == 0 {
+			continue
+		}
+		if !permitted(u) {
+			orphaned = append(orphaned, fmt.Sprintf("%s %s %s", u.SystemType, u.SystemId, u.Pool))
+		}
+	}
+	if len(orphaned) != 0 {
+		return fmt.Errorf("usage in pools without a role: %s", strings.Join(orphaned, ", "))
+	}
+	return nil
+}
+
+// roleNames returns the names of the roles.
+func roleNames(r *roles.JSON) map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, in := range r.Instances() {
+		names[in.Name] = struct{}{}
+	}
+	return names
+}
+
+// writeDoctorTable writes the checks of the report as a table, with the hint
+// of a failed check on the line below it.
+func writeDoctorTable(w io.Writer, report *doctorReport) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tMESSAGE")
+	for _, c := range report.Checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Name, strings.ToUpper(c.Status), c.Message)
+		if c.Hint != "" {
+			fmt.Fprintf(tw, "\t\thint: %s\n", c.Hint)
+		}
+	}
+	return tw.Flush()
+}
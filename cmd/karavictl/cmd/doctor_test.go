@@ -0,0 +1,189 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/role-service/roles"
+	"karavi-authorization/pb"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestDoctor(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	r := roles.NewJSON()
+	for _, pool := range []string{"bronze", "silver"} {
+		err := r.Add(&roles.Instance{
+			Quota:   10,
+			RoleKey: roles.RoleKey{Name: "role-" + pool, SystemType: "powerflex", SystemID: "542a2d5f5122210f", Pool: pool},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	rolesJSON, err := r.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// responses are the GET responses of the proxy server by path. An error
+	// response is returned as is.
+	type responses map[string]interface{}
+	healthy := func() responses {
+		return responses{
+			"/healthz":               nil,
+			"/proxy/tenant/":         pb.ListTenantResponse{Tenants: []*pb.Tenant{{Name: "team-a", Roles: "role-bronze,role-silver"}}},
+			"/proxy/policies/":       []proxy.PolicyInfo{{Kind: proxy.PolicyKindModule, ID: "volumes_create", Loaded: true}},
+			"/proxy/roles":           pb.RoleListResponse{Roles: rolesJSON},
+			"/proxy/storage/status/": json.RawMessage(`{"systems": [{"storageType": "powerflex", "systemId": "542a2d5f5122210f", "status": "reachable"}]}`),
+			"/proxy/tenant/quota/":   json.RawMessage(`{"name": "team-a", "pools": [{"systemType": "powerflex", "systemId": "542a2d5f5122210f", "pool": "bronze", "approvedCapacity": "8388608", "approvedVolumes": "1"}]}`),
+		}
+	}
+	run := func(t *testing.T, resps responses) (doctorReport, int) {
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				GetFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, resp interface{}) error {
+					v, ok := resps[path]
+					if !ok {
+						t.Errorf("unexpected path %q", path)
+						return nil
+					}
+					if err, ok := v.(error); ok {
+						return err
+					}
+					if resp == nil {
+						return nil
+					}
+					b, err := json.Marshal(v)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return json.Unmarshal(b, resp)
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		exitCode := 0
+		osExit = func(code int) {
+			exitCode = code
+		}
+
+		var gotOutput bytes.Buffer
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs([]string{"doctor", "--output", "json", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		var got doctorReport
+		if err := json.Unmarshal(gotOutput.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		return got, exitCode
+	}
+	statuses := func(report doctorReport) map[string]string {
+		m := make(map[string]string)
+		for _, c := range report.Checks {
+			m[c.Name] = c.Status
+		}
+		return m
+	}
+
+	t.Run("it passes every check", func(t *testing.T) {
+		defer afterFn()
+
+		got, exitCode := run(t, healthy())
+
+		for _, c := range got.Checks {
+			if c.Status != checkPass {
+				t.Errorf("got %+v, want the check passed", c)
+			}
+		}
+		if len(got.Checks) != 6 {
+			t.Errorf("got %d checks, want 6", len(got.Checks))
+		}
+		if exitCode != 0 {
+			t.Errorf("got exit code %d, want 0", exitCode)
+		}
+	})
+
+	t.Run("it reports inconsistencies with hints", func(t *testing.T) {
+		defer afterFn()
+		resps := healthy()
+		resps["/proxy/tenant/"] = pb.ListTenantResponse{Tenants: []*pb.Tenant{{Name: "team-a", Roles: "role-silver,role-gold"}}}
+		resps["/proxy/policies/"] = []proxy.PolicyInfo{{Kind: proxy.PolicyKindModule, ID: "volumes_create", Loaded: false}}
+		resps["/proxy/storage/status/"] = json.RawMessage(`{"systems": [{"storageType": "powerflex", "systemId": "542a2d5f5122210f", "status": "auth-failed", "error": "bad credentials"}]}`)
+
+		got, exitCode := run(t, resps)
+
+		want := map[string]string{
+			"proxy reachable":           checkPass,
+			"admin token valid":         checkPass,
+			"policies loaded":           checkFail,
+			"tenant roles exist":        checkFail,
+			"storage systems reachable": checkFail,
+			"quota usage consistent":    checkFail,
+		}
+		if !reflect.DeepEqual(statuses(got), want) {
+			t.Errorf("got %v, want %v", statuses(got), want)
+		}
+		for _, c := range got.Checks {
+			if c.Status == checkFail && c.Hint == "" {
+				t.Errorf("got %+v, want a hint", c)
+			}
+		}
+		if exitCode != 1 {
+			t.Errorf("got exit code %d, want 1", exitCode)
+		}
+	})
+
+	t.Run("it skips the checks of an unreachable proxy", func(t *testing.T) {
+		defer afterFn()
+		resps := healthy()
+		resps["/healthz"] = errors.New("connection refused")
+
+		got, exitCode := run(t, resps)
+
+		if got.Checks[0].Status != checkFail {
+			t.Errorf("got %+v, want the check failed", got.Checks[0])
+		}
+		for _, c := range got.Checks[1:] {
+			if c.Status != checkSkip {
+				t.Errorf("got %+v, want the check skipped", c)
+			}
+		}
+		if exitCode != 1 {
+			t.Errorf("got exit code %d, want 1", exitCode)
+		}
+	})
+}
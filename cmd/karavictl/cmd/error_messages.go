@@ -0,0 +1,94 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/errcode"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+)
+
+// errorMessages are the messages reported for the stable codes of the
+// errors of the services. Codes without a message are reported with the
+// message of the service.
+var errorMessages = map[pb.ErrorCode]func(params map[string]string) string{
+	pb.ErrorCode_CONCURRENT_UPDATE: message("the configuration was changed by another request at the same time; run the command again"),
+	pb.ErrorCode_UNAVAILABLE:       message("the service is unavailable; try again later"),
+
+	pb.ErrorCode_TENANT_NOT_FOUND:      message("the tenant does not exist"),
+	pb.ErrorCode_TENANT_ALREADY_EXISTS: message("the tenant already exists"),
+	pb.ErrorCode_TENANT_DELETED:        message("a deleted tenant of the same name exists; restore it or choose another name"),
+	pb.ErrorCode_TENANT_REVOKED:        message("the tenant has been revoked"),
+	pb.ErrorCode_TENANT_HAS_NO_ROLES:   message("the tenant has no roles; bind a role to the tenant first"),
+	pb.ErrorCode_TENANT_PROTECTED:      message("the tenant is protected from deletion; unprotect it first"),
+	pb.ErrorCode_TOKEN_REVOKED:         message("the token has been revoked"),
+
+	pb.ErrorCode_ORGANIZATION_NOT_FOUND:      message("the organization does not exist"),
+	pb.ErrorCode_ORGANIZATION_ALREADY_EXISTS: message("the organization already exists"),
+	pb.ErrorCode_ORGANIZATION_HAS_TENANTS:    message("the organization still has tenants; delete them first"),
+
+	pb.ErrorCode_ROLE_NOT_FOUND:      paramMessage("role", "role %q does not exist", "the role does not exist"),
+	pb.ErrorCode_ROLE_ALREADY_EXISTS: paramMessage("role", "role %q already exists", "the role already exists"),
+
+	pb.ErrorCode_STORAGE_NOT_FOUND: func(params map[string]string) string {
+		if id, ok := params["system"]; ok {
+			return fmt.Sprintf("storage system %q is not registered", id)
+		}
+		if typ, ok := params["type"]; ok {
+			return fmt.Sprintf("no %s storage is registered", typ)
+		}
+		return "the storage system is not registered"
+	},
+	pb.ErrorCode_STORAGE_ALREADY_EXISTS: paramMessage("system", "storage system %q is already registered", "the storage system is already registered"),
+}
+
+func message(msg string) func(map[string]string) string {
+	return func(map[string]string) string {
+		return msg
+	}
+}
+
+// paramMessage returns the message formatted with the param, or the
+// fallback if the error has no such param.
+func paramMessage(param, format, fallback string) func(map[string]string) string {
+	return func(params map[string]string) string {
+		if v, ok := params[param]; ok {
+			return fmt.Sprintf(format, v)
+		}
+		return fallback
+	}
+}
+
+// errorCode returns the stable code of an error of a service, with its
+// params, from either the JSON error response of the proxy or the gRPC
+// status of the error.
+func errorCode(err error) (pb.ErrorCode, map[string]string) {
+	var jsonErr web.JSONError
+	if errors.As(err, &jsonErr) {
+		if code, ok := pb.ErrorCode_value[jsonErr.ServiceCode]; ok {
+			return pb.ErrorCode(code), jsonErr.Params
+		}
+		if jsonErr.Reason == web.CodeConcurrentUpdate {
+			return pb.ErrorCode_CONCURRENT_UPDATE, nil
+		}
+		return pb.ErrorCode_ERROR_CODE_UNSPECIFIED, nil
+	}
+	if detail := errcode.Attached(err); detail != nil {
+		return detail.Code, detail.Params
+	}
+	return pb.ErrorCode_ERROR_CODE_UNSPECIFIED, nil
+}
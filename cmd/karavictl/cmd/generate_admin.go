@@ -36,5 +36,8 @@ func NewAdminCmd() *cobra.Command {
 	}
 
 	adminCmd.AddCommand(NewAdminTokenCmd())
+	adminCmd.AddCommand(NewAdminBootstrapTokenCmd())
+	adminCmd.AddCommand(NewAdminEncryptStorageCmd())
+	adminCmd.AddCommand(NewAdminSessionsCmd())
 	return adminCmd
 }
@@ -0,0 +1,110 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"karavi-authorization/internal/token/jwx"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// BootstrapToken is the output of the bootstrap-token command
+type BootstrapToken struct {
+	Token string `json:"token"`
+}
+
+// NewAdminBootstrapTokenCmd creates a new bootstrap-token command
+func NewAdminBootstrapTokenCmd() *cobra.Command {
+	bootstrapTokenCmd := &cobra.Command{
+		Use:   "bootstrap-token",
+		Short: "Generate a bootstrap token for a cluster.",
+		Long: `Generates a cluster-scoped bootstrap token. The proxy-server exchanges it
+for the token of a tenant named after the cluster, or the namespace of the
+volume, and creates the tenant with the default role if it does not exist.
+It is only accepted when bootstrap.enabled is set in the proxy-server config.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cluster, err := cmd.Flags().GetString("cluster-id")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return err
+			}
+			if strings.TrimSpace(cluster) == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("empty cluster id not allowed"))
+			}
+
+			expiration, err := cmd.Flags().GetDuration("expiration")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return err
+			}
+
+			secret, err := cmd.Flags().GetString("jwt-signing-secret")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return err
+			}
+			if pf := cmd.Flags().Lookup("jwt-signing-secret"); !pf.Changed {
+				readPassword(cmd.ErrOrStderr(), "Enter JWT Signing Secret: ", &secret)
+			}
+
+			issuer, err := cmd.Flags().GetString("issuer")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return err
+			}
+
+			audience, err := cmd.Flags().GetString("audience")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return err
+			}
+
+			algName, err := cmd.Flags().GetString("signing-algorithm")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return err
+			}
+			alg, err := jwx.ParseSignatureAlgorithm(algName)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return err
+			}
+
+			tkn, err := jwx.GenerateBootstrapToken(cluster, secret, expiration, jwx.WithSigningAlgorithm(alg), jwx.WithIssuer(issuer), jwx.WithAudience(audience))
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return nil
+			}
+
+			err = JSONOutput(cmd.OutOrStdout(), &BootstrapToken{Token: tkn})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return nil
+			}
+			return nil
+		},
+	}
+
+	bootstrapTokenCmd.Flags().String("cluster-id", "", "ID of the cluster the token is for")
+	bootstrapTokenCmd.Flags().StringP("jwt-signing-secret", "s", "", "Specify JWT signing secret, or omit to use stdin")
+	bootstrapTokenCmd.Flags().Duration("expiration", 365*24*time.Hour, "Expiration time of the token, e.g. 720h")
+	bootstrapTokenCmd.Flags().String("issuer", "", "Issuer of the token, must match web.jwtIssuer of the installation")
+	bootstrapTokenCmd.Flags().String("audience", "", "Audience of the token, must match web.jwtAudience of the installation")
+	bootstrapTokenCmd.Flags().String("signing-algorithm", string(jwx.HS256), "Signing algorithm of the token, HS256, HS512 or RS256; must match web.jwtSigningAlgorithm of the installation")
+	return bootstrapTokenCmd
+}
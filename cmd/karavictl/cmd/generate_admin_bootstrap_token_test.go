@@ -0,0 +1,84 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/token/jwx"
+	"os"
+	"testing"
+)
+
+func TestGenerateAdminBootstrapToken(t *testing.T) {
+	afterFn := func() {
+		osExit = os.Exit
+	}
+	t.Run("it generates a bootstrap token for the cluster", func(t *testing.T) {
+		defer afterFn()
+		osExit = func(_ int) {
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOutput(&gotOutput)
+		cmd.SetArgs([]string{"admin", "bootstrap-token", "--cluster-id", "cluster-1", "-s", "secret"})
+		cmd.Execute()
+
+		var got BootstrapToken
+		if err := json.NewDecoder(&gotOutput).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		var claims token.Claims
+		if _, err := jwx.NewTokenManager(jwx.HS256).ParseWithClaims(got.Token, "secret", &claims); err != nil {
+			t.Fatal(err)
+		}
+		if claims.Subject != token.SubjectBootstrap || claims.Cluster != "cluster-1" {
+			t.Errorf("got (%q, %q), want (%q, %q)", claims.Subject, claims.Cluster, token.SubjectBootstrap, "cluster-1")
+		}
+	})
+	t.Run("it requires a cluster id", func(t *testing.T) {
+		defer afterFn()
+		var gotCode int
+		done := make(chan struct{})
+		osExit = func(code int) {
+			gotCode = code
+			done <- struct{}{}
+			done <- struct{}{} // we can't let this function return
+		}
+
+		var gotOutput bytes.Buffer
+
+		rootCmd := NewRootCmd()
+		rootCmd.SetErr(&gotOutput)
+		rootCmd.SetArgs([]string{"admin", "bootstrap-token", "-s", "secret"})
+		go rootCmd.Execute()
+		<-done
+
+		wantCode := 1
+		if gotCode != wantCode {
+			t.Errorf("got exit code %d, want %d", gotCode, wantCode)
+		}
+		var gotErr CommandError
+		if err := json.NewDecoder(&gotOutput).Decode(&gotErr); err != nil {
+			t.Fatal(err)
+		}
+		wantErrMsg := "empty cluster id not allowed"
+		if gotErr.ErrorMsg != wantErrMsg {
+			t.Errorf("got err %q, want %q", gotErr.ErrorMsg, wantErrMsg)
+		}
+	})
+}
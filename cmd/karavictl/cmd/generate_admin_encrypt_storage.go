@@ -0,0 +1,156 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"karavi-authorization/internal/envelope"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// NewAdminEncryptStorageCmd creates a new command that encrypts the
+// passwords of an existing storage secret.
+func NewAdminEncryptStorageCmd() *cobra.Command {
+	encryptCmd := &cobra.Command{
+		Use:   "encrypt-storage",
+		Short: "Encrypt the storage system passwords of the storage secret.",
+		Long: `Encrypts the plain storage system passwords of the storage-systems.yaml
+data of the karavi-storage-secret with a master key, and writes the result
+to stdout. Passwords that are already encrypted are left as they are.
+
+  kubectl get secret karavi-storage-secret -n authorization \
+    -o jsonpath='{.data.storage-systems\.yaml}' | base64 -d | \
+    karavictl admin encrypt-storage --master-key master.key`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			keyFile, err := cmd.Flags().GetString("master-key")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return err
+			}
+			if strings.TrimSpace(keyFile) == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("master key file not specified"))
+			}
+
+			key, err := envelope.LoadMasterKey(keyFile)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return nil
+			}
+
+			file, err := cmd.Flags().GetString("file")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return err
+			}
+
+			var in io.Reader = cmd.InOrStdin()
+			if file != "" {
+				f, err := os.Open(file) // #nosec G304
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+					return nil
+				}
+				defer f.Close()
+				in = f
+			}
+
+			out, n, err := encryptStorageData(key, in)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return nil
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "encrypted %d password(s)\n", n)
+			_, err = cmd.OutOrStdout().Write(out)
+			return err
+		},
+	}
+
+	encryptCmd.Flags().String("master-key", "", "Path to the base64 encoded master key file; required")
+	encryptCmd.Flags().String("file", "", "Path to the storage-systems.yaml data, or omit to use stdin")
+	return encryptCmd
+}
+
+// encryptStorageData encrypts the plain passwords of the storage secret data
+// and returns the data with the number of passwords encrypted.
+func encryptStorageData(kw envelope.KeyWrapper, r io.Reader) ([]byte, int, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var data map[string]Storage
+	if err := yaml.Unmarshal(b, &data); err != nil {
+		return nil, 0, fmt.Errorf("decoding storage data: %w", err)
+	}
+	storages, ok := data["storage"]
+	if !ok {
+		return nil, 0, errors.New("storage key not found in storage data")
+	}
+
+	n, err := storages.Encrypt(kw)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	out, err := yaml.Marshal(&data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, n, nil
+}
+
+// Encrypt encrypts the plain passwords of the storage systems with new data
+// keys wrapped by kw, and returns the number of passwords encrypted.
+func (s Storage) Encrypt(kw envelope.KeyWrapper) (int, error) {
+	var n int
+	for _, systems := range s {
+		for id, system := range systems {
+			if envelope.IsEncrypted(system.Password) {
+				continue
+			}
+			enc, err := envelope.Encrypt(kw, system.Password)
+			if err != nil {
+				return n, fmt.Errorf("encrypting password of %s: %w", id, err)
+			}
+			system.Password = enc
+			systems[id] = system
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Decrypt decrypts the encrypted passwords of the storage systems. Plain
+// passwords are left as they are.
+func (s Storage) Decrypt(kw envelope.KeyWrapper) error {
+	for _, systems := range s {
+		for id, system := range systems {
+			dec, err := envelope.Decrypt(kw, system.Password)
+			if err != nil {
+				return fmt.Errorf("decrypting password of %s: %w", id, err)
+			}
+			system.Password = dec
+			systems[id] = system
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"karavi-authorization/internal/envelope"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestAdminEncryptStorage(t *testing.T) {
+	afterFn := func() {
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "master.key")
+	rawKey := bytes.Repeat([]byte{1}, envelope.MasterKeySize)
+	if err := os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(rawKey)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := envelope.NewMasterKey(rawKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("it encrypts the plain passwords", func(t *testing.T) {
+		defer afterFn()
+		in := `storage:
+  powerflex:
+    542a2d5f5122210f:
+      Endpoint: https://10.0.0.1
+      Insecure: true
+      Password: Password123
+      User: admin
+`
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetIn(strings.NewReader(in))
+		cmd.SetArgs([]string{"admin", "encrypt-storage", "--master-key", keyFile})
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		var data map[string]Storage
+		if err := yaml.Unmarshal(gotOutput.Bytes(), &data); err != nil {
+			t.Fatal(err)
+		}
+		got := data["storage"]["powerflex"]["542a2d5f5122210f"]
+		if !envelope.IsEncrypted(got.Password) {
+			t.Fatalf("expected an encrypted password, got %q", got.Password)
+		}
+		if err := data["storage"].Decrypt(key); err != nil {
+			t.Fatal(err)
+		}
+		want := System{User: "admin", Password: "Password123", Endpoint: "https://10.0.0.1", Insecure: true}
+		if got := data["storage"]["powerflex"]["542a2d5f5122210f"]; got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("it leaves encrypted passwords", func(t *testing.T) {
+		enc, err := envelope.Encrypt(key, "Password123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		storage := Storage{
+			"powermax": SystemType{
+				"000197900714": System{User: "admin", Password: enc},
+			},
+		}
+
+		n, err := storage.Encrypt(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n != 0 {
+			t.Errorf("got %d passwords encrypted, want 0", n)
+		}
+		if got := storage["powermax"]["000197900714"].Password; got != enc {
+			t.Errorf("got %q, want %q", got, enc)
+		}
+	})
+
+	t.Run("it requires a master key", func(t *testing.T) {
+		defer afterFn()
+		var gotCode int
+		done := make(chan struct{})
+		osExit = func(code int) {
+			gotCode = code
+			done <- struct{}{}
+			done <- struct{}{} // we can't let this function return
+		}
+
+		var gotOutput bytes.Buffer
+
+		rootCmd := NewRootCmd()
+		rootCmd.SetErr(&gotOutput)
+		rootCmd.SetArgs([]string{"admin", "encrypt-storage"})
+		go rootCmd.Execute()
+		<-done
+
+		wantCode := 1
+		if gotCode != wantCode {
+			t.Errorf("got exit code %d, want %d", gotCode, wantCode)
+		}
+		var gotErr CommandError
+		if err := json.NewDecoder(&gotOutput).Decode(&gotErr); err != nil {
+			t.Fatal(err)
+		}
+		wantErrMsg := "master key file not specified"
+		if gotErr.ErrorMsg != wantErrMsg {
+			t.Errorf("got err %q, want %q", gotErr.ErrorMsg, wantErrMsg)
+		}
+	})
+}
@@ -70,12 +70,42 @@ func NewAdminTokenCmd() *cobra.Command {
 				readPassword(cmd.ErrOrStderr(), prompt, &secret)
 			}
 
+			issuer, err := cmd.Flags().GetString("issuer")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return err
+			}
+
+			audience, err := cmd.Flags().GetString("audience")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return err
+			}
+
+			algName, err := cmd.Flags().GetString("signing-algorithm")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return err
+			}
+			alg, err := jwx.ParseSignatureAlgorithm(algName)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return err
+			}
+
+			organization, err := cmd.Flags().GetString("organization")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				return err
+			}
+
 			resp, err := jwx.GenerateAdminToken(context.Background(), &pb.GenerateAdminTokenRequest{
 				AdminName:         adminName,
 				JWTSigningSecret:  secret,
 				RefreshExpiration: int64(refExpTime),
 				AccessExpiration:  int64(accExpTime),
-			})
+				Organization:      organization,
+			}, jwx.WithSigningAlgorithm(alg), jwx.WithIssuer(issuer), jwx.WithAudience(audience))
 			if err != nil {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
 				return nil
@@ -99,5 +129,9 @@ func NewAdminTokenCmd() *cobra.Command {
 	adminTokenCmd.Flags().StringP("jwt-signing-secret", "s", "", "Specify JWT signing secret, or omit to use stdin")
 	adminTokenCmd.Flags().Duration("refresh-token-expiration", 30*24*time.Hour, "Expiration time of the refresh token, e.g. 48h")
 	adminTokenCmd.Flags().Duration("access-token-expiration", time.Minute, "Expiration time of the access token, e.g. 1m30s")
+	adminTokenCmd.Flags().String("issuer", "", "Issuer of the tokens, must match web.jwtIssuer of the installation")
+	adminTokenCmd.Flags().String("audience", "", "Audience of the tokens, must match web.jwtAudience of the installation")
+	adminTokenCmd.Flags().String("signing-algorithm", string(jwx.HS256), "Signing algorithm of the tokens, HS256, HS512 or RS256; must match web.jwtSigningAlgorithm of the installation")
+	adminTokenCmd.Flags().String("organization", "", "Organization the admin may manage, or omit to manage every tenant")
 	return adminTokenCmd
 }
@@ -0,0 +1,272 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/pb"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+	"sigs.k8s.io/yaml"
+)
+
+// githubEndpoint is the GitHub device flow endpoint; overridden in tests.
+var githubEndpoint = endpoints.GitHub
+
+// LoginConfig is the local configuration written by karavictl login.
+type LoginConfig struct {
+	Addr     string `json:"addr"`
+	Provider string `json:"provider"`
+	Access   string `json:"access"`
+	Refresh  string `json:"refresh"`
+}
+
+// NewLoginCmd creates a new login command
+func NewLoginCmd() *cobra.Command {
+	loginCmd := &cobra.Command{
+		Use:   "login",
+		Short: "Log in as a tenant through an identity provider",
+		Long: `Logs in through the device flow of GitHub or an OpenID Connect provider and
+outputs the proxy-authz-tokens secret of the tenant the identity is mapped to.
+With --apply, the secret is applied with kubectl instead. The tokens are also
+kept in a local configuration file readable only by the current user.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			addr, err := cmd.Flags().GetString("addr")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if addr == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("address not specified"))
+			}
+
+			insecure, err := cmd.Flags().GetBool("insecure")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			provider, err := cmd.Flags().GetString("provider")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if provider != proxy.LoginProviderGitHub && provider != proxy.LoginProviderOIDC {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("provider must be %s or %s", proxy.LoginProviderGitHub, proxy.LoginProviderOIDC))
+			}
+
+			client, err := CreateHTTPClient(fmt.Sprintf("https://%s", addr), insecure)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			// the proxy server advertises the providers it trusts
+			var providers proxy.LoginProviders
+			err = client.Get(ctx, "/proxy/login/", nil, nil, &providers)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			var advertised proxy.LoginProvider
+			switch {
+			case provider == proxy.LoginProviderGitHub && providers.GitHub != nil:
+				advertised = *providers.GitHub
+			case provider == proxy.LoginProviderOIDC && providers.OIDC != nil:
+				advertised = *providers.OIDC
+			}
+			clientID, err := cmd.Flags().GetString("client-id")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if clientID == "" {
+				clientID = advertised.ClientID
+			}
+			issuer, err := cmd.Flags().GetString("issuer")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if issuer == "" {
+				issuer = advertised.Issuer
+			}
+			if clientID == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("login provider %s is not configured", provider))
+			}
+
+			idToken, err := deviceLogin(ctx, cmd.ErrOrStderr(), provider, clientID, issuer)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			var resp pb.GenerateTokenResponse
+			body := proxy.LoginBody{
+				Provider: provider,
+				Token:    idToken,
+			}
+			err = client.Post(ctx, "/proxy/login/", nil, nil, &body, &resp)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			cfgFile, err := cmd.Flags().GetString("config")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			err = saveLoginConfig(cfgFile, addr, provider, resp.Token)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			apply, err := cmd.Flags().GetBool("apply")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if !apply {
+				err = Output(cmd.OutOrStdout(), resp.Token)
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+				return
+			}
+
+			namespace, err := cmd.Flags().GetString("namespace")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			args := []string{"apply", "-f", "-"}
+			if namespace != "" {
+				args = append(args, "--namespace", namespace)
+			}
+			kubectl := execCommandContext(ctx, "kubectl", args...)
+			kubectl.Stdin = strings.NewReader(resp.Token)
+			kubectl.Stdout = cmd.OutOrStdout()
+			kubectl.Stderr = cmd.ErrOrStderr()
+			err = kubectl.Run()
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("applying secret: %w", err))
+			}
+		},
+	}
+
+	loginCmd.Flags().String("addr", "", "Address of the server")
+	loginCmd.Flags().Bool("insecure", false, "Skip certificate validation of the server")
+	loginCmd.Flags().String("provider", proxy.LoginProviderGitHub, "Identity provider, github or oidc")
+	loginCmd.Flags().String("client-id", "", "OAuth client ID; defaults to the one advertised by the server")
+	loginCmd.Flags().String("issuer", "", "OpenID Connect issuer; defaults to the one advertised by the server")
+	loginCmd.Flags().Bool("apply", false, "Apply the secret with kubectl instead of outputting it")
+	loginCmd.Flags().String("namespace", "", "Namespace to apply the secret in")
+	loginCmd.Flags().String("config", defaultLoginConfigFile(), "Local configuration file the tokens are kept in")
+	return loginCmd
+}
+
+// deviceLogin runs the device flow of the provider and returns the token
+// that identifies the user to the proxy server: the access token for GitHub
+// and the ID token for OpenID Connect.
+func deviceLogin(ctx context.Context, w io.Writer, provider, clientID, issuer string) (string, error) {
+	cfg := oauth2.Config{ClientID: clientID}
+
+	switch provider {
+	case proxy.LoginProviderGitHub:
+		cfg.Endpoint = githubEndpoint
+		cfg.Scopes = []string{"read:user"}
+	case proxy.LoginProviderOIDC:
+		if issuer == "" {
+			return "", errors.New("oidc issuer not specified")
+		}
+		d, err := proxy.DiscoverOIDC(ctx, http.DefaultClient, issuer)
+		if err != nil {
+			return "", err
+		}
+		if d.DeviceAuthorizationEndpoint == "" {
+			return "", fmt.Errorf("oidc issuer %s does not support the device flow", issuer)
+		}
+		cfg.Endpoint = oauth2.Endpoint{
+			DeviceAuthURL: d.DeviceAuthorizationEndpoint,
+			TokenURL:      d.TokenEndpoint,
+		}
+		cfg.Scopes = []string{"openid", "email"}
+	}
+
+	da, err := cfg.DeviceAuth(ctx)
+	if err != nil {
+		return "", fmt.Errorf("starting device login: %w", err)
+	}
+	fmt.Fprintf(w, "Open %s and enter the code %s\n", da.VerificationURI, da.UserCode)
+
+	tok, err := cfg.DeviceAccessToken(ctx, da)
+	if err != nil {
+		return "", fmt.Errorf("completing device login: %w", err)
+	}
+
+	if provider == proxy.LoginProviderGitHub {
+		return tok.AccessToken, nil
+	}
+	idToken, ok := tok.Extra("id_token").(string)
+	if !ok || idToken == "" {
+		return "", errors.New("oidc provider returned no id token")
+	}
+	return idToken, nil
+}
+
+func defaultLoginConfigFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".karavi", "config.yaml")
+}
+
+// saveLoginConfig keeps the tokens of the secret in the configuration file,
+// which only the current user may read.
+func saveLoginConfig(path, addr, provider, secret string) error {
+	if path == "" {
+		return errors.New("config file not specified")
+	}
+
+	var s struct {
+		Data map[string][]byte `json:"data"`
+	}
+	if err := yaml.Unmarshal([]byte(secret), &s); err != nil {
+		return fmt.Errorf("decoding token secret: %w", err)
+	}
+
+	b, err := yaml.Marshal(&LoginConfig{
+		Addr:     addr,
+		Provider: provider,
+		Access:   string(s.Data["access"]),
+		Refresh:  string(s.Data["refresh"]),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(path, 0o600)
+}
@@ -0,0 +1,197 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/pb"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/oauth2"
+	"sigs.k8s.io/yaml"
+)
+
+const loginTestSecret = `apiVersion: v1
+data:
+  access: YWNjZXNz
+  refresh: cmVmcmVzaA==
+kind: Secret
+metadata:
+  name: proxy-authz-tokens
+type: Opaque
+`
+
+func TestLogin(t *testing.T) {
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/device/code":
+			w.Write([]byte(`{"device_code":"dc","user_code":"ABCD-1234","verification_uri":"https://github.com/login/device","interval":1}`))
+		case "/token":
+			w.Write([]byte(`{"access_token":"gho_valid","token_type":"bearer"}`))
+		}
+	}))
+	defer github.Close()
+
+	origEndpoint := githubEndpoint
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		execCommandContext = exec.CommandContext
+		githubEndpoint = origEndpoint
+	}
+
+	var gotBody proxy.LoginBody
+	setup := func() {
+		githubEndpoint = oauth2.Endpoint{DeviceAuthURL: github.URL + "/device/code", TokenURL: github.URL + "/token"}
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				GetFn: func(_ context.Context, _ string, _ map[string]string, _ url.Values, resp interface{}) error {
+					*resp.(*proxy.LoginProviders) = proxy.LoginProviders{GitHub: &proxy.LoginProvider{ClientID: "github-client"}}
+					return nil
+				},
+				PostFn: func(_ context.Context, _ string, _ map[string]string, _ url.Values, body, resp interface{}) error {
+					gotBody = *body.(*proxy.LoginBody)
+					resp.(*pb.GenerateTokenResponse).Token = loginTestSecret
+					return nil
+				},
+			}, nil
+		}
+		osExit = func(code int) {
+			t.Fatalf("unexpected exit %d", code)
+		}
+	}
+
+	t.Run("it outputs the secret and keeps the tokens", func(t *testing.T) {
+		defer afterFn()
+		setup()
+		cfgFile := filepath.Join(t.TempDir(), "karavi", "config.yaml")
+
+		var stdout, stderr bytes.Buffer
+		cmd := NewRootCmd()
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs([]string{"login", "--provider", "github", "--addr", "proxy.com", "--config", cfgFile})
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		if gotBody.Provider != "github" || gotBody.Token != "gho_valid" {
+			t.Errorf("unexpected login body %+v", gotBody)
+		}
+		if stdout.String() != loginTestSecret {
+			t.Errorf("expected the secret, got %q", stdout.String())
+		}
+		if !bytes.Contains(stderr.Bytes(), []byte("ABCD-1234")) {
+			t.Errorf("expected the user code, got %q", stderr.String())
+		}
+
+		fi, err := os.Stat(cfgFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0o600 {
+			t.Errorf("expected config file mode 0600, got %v", fi.Mode().Perm())
+		}
+		b, err := os.ReadFile(cfgFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got LoginConfig
+		if err := yaml.Unmarshal(b, &got); err != nil {
+			t.Fatal(err)
+		}
+		want := LoginConfig{Addr: "proxy.com", Provider: "github", Access: "access", Refresh: "refresh"}
+		if got != want {
+			t.Errorf("expected config %+v, got %+v", want, got)
+		}
+	})
+
+	t.Run("it applies the secret", func(t *testing.T) {
+		defer afterFn()
+		setup()
+		applied := filepath.Join(t.TempDir(), "applied.yaml")
+		var gotArgs []string
+		execCommandContext = func(ctx context.Context, _ string, args ...string) *exec.Cmd {
+			gotArgs = args
+			return exec.CommandContext(ctx, "sh", "-c", "cat > "+applied)
+		}
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{"login", "--addr", "proxy.com", "--apply", "--namespace", "vxflexos", "--config", filepath.Join(t.TempDir(), "config.yaml")})
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := os.ReadFile(applied)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != loginTestSecret {
+			t.Errorf("expected the secret to be applied, got %q", b)
+		}
+		wantArgs := []string{"apply", "-f", "-", "--namespace", "vxflexos"}
+		if !reflect.DeepEqual(gotArgs, wantArgs) {
+			t.Errorf("expected args %v, got %v", wantArgs, gotArgs)
+		}
+	})
+
+	t.Run("it rejects an unknown provider", func(t *testing.T) {
+		defer afterFn()
+		setup()
+		var gotCode int
+		done := make(chan struct{})
+		osExit = func(code int) {
+			gotCode = code
+			done <- struct{}{}
+			done <- struct{}{} // we can't let this function return
+		}
+
+		var gotOutput bytes.Buffer
+		cmd := NewRootCmd()
+		cmd.SetErr(&gotOutput)
+		cmd.SetArgs([]string{"login", "--provider", "gitlab", "--addr", "proxy.com"})
+
+		go cmd.Execute()
+		<-done
+
+		if gotCode != 1 {
+			t.Errorf("got exit code %d, want %d", gotCode, 1)
+		}
+		var gotErr CommandError
+		if err := json.NewDecoder(&gotOutput).Decode(&gotErr); err != nil {
+			t.Fatal(err)
+		}
+		wantErrMsg := "provider must be github or oidc"
+		if gotErr.ErrorMsg != wantErrMsg {
+			t.Errorf("got err %q, want %q", gotErr.ErrorMsg, wantErrMsg)
+		}
+	})
+}
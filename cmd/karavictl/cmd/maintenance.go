@@ -0,0 +1,105 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/quota"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// NewMaintenanceCmd creates a new maintenance command
+func NewMaintenanceCmd() *cobra.Command {
+	maintenanceCmd := &cobra.Command{
+		Use:   "maintenance",
+		Short: "Maintain the data of the CSM Authorization Proxy Server",
+		Long:  `Maintain the data of the CSM Authorization Proxy Server`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := cmd.Usage(); err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("error: %+v", err))
+			}
+			os.Exit(1)
+		},
+	}
+
+	maintenanceCmd.PersistentFlags().StringP("admin-token", "f", "", "Path to admin token file; required")
+	maintenanceCmd.PersistentFlags().String("addr", "", "Address of the CSM Authorization Proxy Server; required")
+	maintenanceCmd.PersistentFlags().Bool("insecure", false, "Skip certificate validation of the CSM Authorization Proxy Server")
+
+	err := maintenanceCmd.MarkPersistentFlagRequired("admin-token")
+	if err != nil {
+		reportErrorAndExit(JSONOutput, maintenanceCmd.ErrOrStderr(), err)
+	}
+
+	err = maintenanceCmd.MarkPersistentFlagRequired("addr")
+	if err != nil {
+		reportErrorAndExit(JSONOutput, maintenanceCmd.ErrOrStderr(), err)
+	}
+
+	maintenanceCmd.AddCommand(NewMaintenanceCompactCmd())
+	return maintenanceCmd
+}
+
+// NewMaintenanceCompactCmd creates a new maintenance compact command
+func NewMaintenanceCompactCmd() *cobra.Command {
+	compactCmd := &cobra.Command{
+		Use:   "compact",
+		Short: "Compact the quota data in redis",
+		Long: `Compacts the quota data of every tenant in redis now, instead of waiting for the
+periodic compaction: removes the volumes that were deleted longer ago than the retention and
+trims the quota streams to their latest entries. The retention and stream length configured
+for the proxy server are used unless they are set.`,
+		Example: `karavictl maintenance compact --deleted-retention 168h --admin-token admintoken.yaml --addr csm-authorization.com`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			retention, err := cmd.Flags().GetDuration("deleted-retention")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			maxLen, err := cmd.Flags().GetInt64("stream-max-len")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			client, adminTknBody := policyClient(cmd)
+			body := proxy.CompactRequest{
+				DeletedRetention: proxy.Duration(retention),
+				StreamMaxLen:     maxLen,
+			}
+			var resp quota.CompactResult
+			err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Post(ctx, "/proxy/maintenance/compact/", headers, nil, &body, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			err = JSONOutput(cmd.OutOrStdout(), &resp)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("unable to format json output: %v", err))
+			}
+		},
+	}
+
+	compactCmd.Flags().Duration("deleted-retention", 0, "How long to keep the data of deleted volumes; defaults to the configured retention")
+	compactCmd.Flags().Int64("stream-max-len", 0, "Number of the latest entries to keep in each quota stream; defaults to the configured length")
+	return compactCmd
+}
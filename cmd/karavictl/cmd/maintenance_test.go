@@ -0,0 +1,95 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/quota"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceCompact(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	var gotPath string
+	var gotBody interface{}
+	setup := func() {
+		gotPath, gotBody = "", nil
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				PostFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, resp interface{}) error {
+					gotPath, gotBody = path, body
+					*resp.(*quota.CompactResult) = quota.CompactResult{Keys: 2, Volumes: 5, StreamEntries: 100}
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+	}
+	run := func(t *testing.T, args ...string) []byte {
+		var out bytes.Buffer
+		cmd := NewRootCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs(append(args, "--admin-token", "admin.yaml", "--addr", "proxy.com"))
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		return out.Bytes()
+	}
+
+	t.Run("it compacts with the configured limits", func(t *testing.T) {
+		defer afterFn()
+		setup()
+
+		out := run(t, "maintenance", "compact")
+
+		if gotPath != "/proxy/maintenance/compact/" || *gotBody.(*proxy.CompactRequest) != (proxy.CompactRequest{}) {
+			t.Errorf("got %s %+v, want /proxy/maintenance/compact/ without limits", gotPath, gotBody)
+		}
+		var got quota.CompactResult
+		if err := json.Unmarshal(out, &got); err != nil {
+			t.Fatal(err)
+		}
+		if want := (quota.CompactResult{Keys: 2, Volumes: 5, StreamEntries: 100}); got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+	t.Run("it compacts with the given limits", func(t *testing.T) {
+		defer afterFn()
+		setup()
+
+		run(t, "maintenance", "compact", "--deleted-retention", "168h", "--stream-max-len", "500")
+
+		want := proxy.CompactRequest{DeletedRetention: proxy.Duration(168 * time.Hour), StreamMaxLen: 500}
+		if *gotBody.(*proxy.CompactRequest) != want {
+			t.Errorf("got %+v, want %+v", gotBody, want)
+		}
+	})
+}
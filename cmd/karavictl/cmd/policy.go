@@ -0,0 +1,128 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// NewPolicyCmd creates a new policy command
+func NewPolicyCmd() *cobra.Command {
+	policyCmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Manage policies",
+		Long:  `Manage policies`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := cmd.Usage(); err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("error: %+v", err))
+			}
+			os.Exit(1)
+		},
+	}
+
+	policyCmd.PersistentFlags().StringP("admin-token", "f", "", "Path to admin token file; required")
+	policyCmd.PersistentFlags().String("addr", "", "Address of the CSM Authorization Proxy Server; required")
+	policyCmd.PersistentFlags().Bool("insecure", false, "Skip certificate validation of the CSM Authorization Proxy Server")
+
+	err := policyCmd.MarkPersistentFlagRequired("admin-token")
+	if err != nil {
+		reportErrorAndExit(JSONOutput, policyCmd.ErrOrStderr(), err)
+	}
+
+	err = policyCmd.MarkPersistentFlagRequired("addr")
+	if err != nil {
+		reportErrorAndExit(JSONOutput, policyCmd.ErrOrStderr(), err)
+	}
+
+	policyCmd.AddCommand(NewPolicySimulateCmd())
+	policyCmd.AddCommand(NewPolicyPushCmd())
+	policyCmd.AddCommand(NewPolicyListCmd())
+	policyCmd.AddCommand(NewPolicyDeleteCmd())
+	policyCmd.AddCommand(NewPolicyRollbackCmd())
+	return policyCmd
+}
+
+// policyClient returns a client for the proxy server and the admin token
+// read from the persistent policy flags.
+func policyClient(cmd *cobra.Command) (api.Client, token.AdminToken) {
+	addr, err := cmd.Flags().GetString("addr")
+	if err != nil {
+		reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+	}
+	if addr == "" {
+		reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("address not specified"))
+	}
+
+	insecure, err := cmd.Flags().GetBool("insecure")
+	if err != nil {
+		reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+	}
+
+	admTknFile, err := cmd.Flags().GetString("admin-token")
+	if err != nil {
+		reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+	}
+	if admTknFile == "" {
+		reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("specify token file"))
+	}
+	accessToken, refreshToken, err := ReadAccessAdminToken(admTknFile)
+	if err != nil {
+		reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+	}
+
+	client, err := CreateHTTPClient(fmt.Sprintf("https://%s", addr), insecure)
+	if err != nil {
+		reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+	}
+
+	return client, token.AdminToken{
+		Refresh: refreshToken,
+		Access:  accessToken,
+	}
+}
+
+// doWithAdminRefresh calls fn with an admin authorization header and, if the
+// access token is rejected, refreshes it and calls fn once more.
+func doWithAdminRefresh(ctx context.Context, client api.Client, adminTknBody token.AdminToken, fn func(headers map[string]string) error) error {
+	headers := make(map[string]string)
+	headers["Authorization"] = fmt.Sprintf("Bearer %s", adminTknBody.Access)
+
+	err := fn(headers)
+	var jsonErr web.JSONError
+	if err == nil || !errors.As(err, &jsonErr) || jsonErr.Code != http.StatusUnauthorized {
+		return err
+	}
+
+	// refresh admin token
+	var adminTknResp pb.RefreshAdminTokenResponse
+	headers["Authorization"] = fmt.Sprintf("Bearer %s", adminTknBody.Refresh)
+	err = client.Post(ctx, "/proxy/refresh-admin", headers, nil, &adminTknBody, &adminTknResp)
+	if err != nil {
+		return err
+	}
+	// retry with refresh token
+	headers["Authorization"] = fmt.Sprintf("Bearer %s", adminTknResp.AccessToken)
+	return fn(headers)
+}
@@ -0,0 +1,61 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"karavi-authorization/internal/proxy"
+
+	"github.com/spf13/cobra"
+)
+
+// NewPolicyDeleteCmd creates a new policy delete command
+func NewPolicyDeleteCmd() *cobra.Command {
+	policyDeleteCmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a policy or data document",
+		Long:  `Deletes a rego policy or data document from OPA along with its version history.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			id, err := cmd.Flags().GetString("id")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if id == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("policy id not specified"))
+			}
+			kind, err := cmd.Flags().GetString("kind")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			client, adminTknBody := policyClient(cmd)
+			body := proxy.PolicyBody{Kind: kind, ID: id}
+			err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Delete(ctx, "/proxy/policies/", headers, nil, &body, nil)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+
+	policyDeleteCmd.Flags().String("id", "", "Policy id, or data path for data documents")
+	policyDeleteCmd.Flags().String("kind", proxy.PolicyKindModule, "Kind of document: module or data")
+	return policyDeleteCmd
+}
@@ -0,0 +1,52 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+
+	"github.com/spf13/cobra"
+)
+
+// NewPolicyListCmd creates a new policy list command
+func NewPolicyListCmd() *cobra.Command {
+	policyListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List policies and data documents",
+		Long:  `Lists the rego policies loaded in OPA and the managed data documents with their versions.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			client, adminTknBody := policyClient(cmd)
+			var resp []proxy.PolicyInfo
+			err := doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Get(ctx, "/proxy/policies/", headers, nil, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			err = JSONOutput(cmd.OutOrStdout(), &resp)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("unable to format json output: %v", err))
+			}
+		},
+	}
+
+	return policyListCmd
+}
@@ -0,0 +1,125 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// NewPolicyPushCmd creates a new policy push command
+func NewPolicyPushCmd() *cobra.Command {
+	policyPushCmd := &cobra.Command{
+		Use:   "push",
+		Short: "Push a rego policy or data document to OPA",
+		Long: `Pushes a rego policy module or a data document to OPA. Rego modules must compile before
+they are accepted and every pushed version is kept so that it can be rolled back.`,
+		Example: `karavictl policy push --file volumes_create.rego --admin-token admintoken.yaml --addr csm-authorization.com
+karavictl policy push --file common.yaml --id karavi/common --admin-token admintoken.yaml --addr csm-authorization.com`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			file, err := cmd.Flags().GetString("file")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if file == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("file not specified"))
+			}
+			id, err := cmd.Flags().GetString("id")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			body, err := readPolicyFile(file, id)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			client, adminTknBody := policyClient(cmd)
+			var resp proxy.PolicyInfo
+			err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Post(ctx, "/proxy/policies/", headers, nil, &body, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			err = JSONOutput(cmd.OutOrStdout(), &resp)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("unable to format json output: %v", err))
+			}
+		},
+	}
+
+	policyPushCmd.Flags().String("file", "", "Path to a .rego module or a .json/.yaml data document")
+	policyPushCmd.Flags().String("id", "", "Policy id, or data path for data documents; defaults to the rego file name")
+	return policyPushCmd
+}
+
+// readPolicyFile builds a push request from a rego module or a JSON/YAML
+// data document. Rego modules are checked for a package declaration before
+// being sent; OPA performs the full compile check.
+func readPolicyFile(file, id string) (proxy.PolicyBody, error) {
+	b, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return proxy.PolicyBody{}, err
+	}
+
+	switch ext := filepath.Ext(file); ext {
+	case ".rego":
+		if !hasRegoPackage(b) {
+			return proxy.PolicyBody{}, fmt.Errorf("%s: missing package declaration", file)
+		}
+		if id == "" {
+			id = strings.TrimSuffix(filepath.Base(file), ext)
+		}
+		return proxy.PolicyBody{Kind: proxy.PolicyKindModule, ID: id, Content: string(b)}, nil
+	case ".json", ".yaml", ".yml":
+		if id == "" {
+			return proxy.PolicyBody{}, errors.New("data path must be specified with --id for data documents")
+		}
+		j, err := yaml.YAMLToJSON(b)
+		if err != nil {
+			return proxy.PolicyBody{}, fmt.Errorf("%s: %w", file, err)
+		}
+		return proxy.PolicyBody{Kind: proxy.PolicyKindData, ID: id, Content: string(j)}, nil
+	default:
+		return proxy.PolicyBody{}, fmt.Errorf("%s: unsupported file type %q", file, ext)
+	}
+}
+
+func hasRegoPackage(b []byte) bool {
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return strings.HasPrefix(line, "package ")
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicyPushHandler(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	dir := t.TempDir()
+	regoFile := filepath.Join(dir, "volumes_create.rego")
+	if err := os.WriteFile(regoFile, []byte("# comment\npackage karavi.volumes.create\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dataFile := filepath.Join(dir, "common.yaml")
+	if err := os.WriteFile(dataFile, []byte("roles:\n  role-1: {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	push := func(t *testing.T, args ...string) proxy.PolicyBody {
+		t.Helper()
+		var gotBody proxy.PolicyBody
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				PostFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, _ interface{}) error {
+					if path != "/proxy/policies/" {
+						t.Errorf("got path %q, want %q", path, "/proxy/policies/")
+					}
+					gotBody = *body.(*proxy.PolicyBody)
+					return nil
+				},
+			}, nil
+		}
+		osExit = func(_ int) {}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+
+		cmd := NewRootCmd()
+		cmd.SetOutput(&bytes.Buffer{})
+		cmd.SetArgs(append([]string{"policy", "push", "--admin-token", "admin.yaml", "--addr", "proxy.com"}, args...))
+		cmd.Execute()
+		return gotBody
+	}
+
+	t.Run("it pushes a rego module named after the file", func(t *testing.T) {
+		defer afterFn()
+
+		got := push(t, "--file", regoFile)
+
+		if got.Kind != proxy.PolicyKindModule || got.ID != "volumes_create" {
+			t.Errorf("got %+v, want module volumes_create", got)
+		}
+	})
+
+	t.Run("it converts a yaml data document to json", func(t *testing.T) {
+		defer afterFn()
+
+		got := push(t, "--file", dataFile, "--id", "karavi/common")
+
+		if got.Kind != proxy.PolicyKindData || got.ID != "karavi/common" {
+			t.Errorf("got %+v, want data karavi/common", got)
+		}
+		if !json.Valid([]byte(got.Content)) {
+			t.Errorf("got content %q, want json", got.Content)
+		}
+	})
+
+	t.Run("it rejects a rego file without a package", func(t *testing.T) {
+		defer afterFn()
+		badFile := filepath.Join(dir, "bad.rego")
+		if err := os.WriteFile(badFile, []byte("allow = true\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		var gotCode int
+		done := make(chan struct{})
+		osExit = func(code int) {
+			gotCode = code
+			done <- struct{}{}
+			done <- struct{}{} // we can't let this function return
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetErr(&gotOutput)
+		cmd.SetArgs([]string{"policy", "push", "--file", badFile, "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		go cmd.Execute()
+		<-done
+
+		if gotCode != 1 {
+			t.Errorf("got exit code %d, want 1", gotCode)
+		}
+		var gotErr CommandError
+		if err := json.NewDecoder(&gotOutput).Decode(&gotErr); err != nil {
+			t.Fatal(err)
+		}
+		if want := badFile + ": missing package declaration"; gotErr.ErrorMsg != want {
+			t.Errorf("got err %q, want %q", gotErr.ErrorMsg, want)
+		}
+	})
+}
+
+func TestPolicyRollbackHandler(t *testing.T) {
+	defer func() {
+		CreateHTTPClient = createHTTPClient
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}()
+
+	var gotBody proxy.PolicyRollbackBody
+	CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+		return &mocks.FakeClient{
+			PostFn: func(_ context.Context, _ string, _ map[string]string, _ url.Values, body, _ interface{}) error {
+				gotBody = *body.(*proxy.PolicyRollbackBody)
+				return nil
+			},
+		}, nil
+	}
+	osExit = func(_ int) {}
+	ReadAccessAdminToken = func(_ string) (string, string, error) {
+		return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+	}
+
+	cmd := NewRootCmd()
+	cmd.SetOutput(&bytes.Buffer{})
+	cmd.SetArgs([]string{"policy", "rollback", "--id", "volumes_create", "--version", "2", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+	cmd.Execute()
+
+	want := proxy.PolicyRollbackBody{Kind: proxy.PolicyKindModule, ID: "volumes_create", Version: 2}
+	if gotBody != want {
+		t.Errorf("got %+v, want %+v", gotBody, want)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+
+	"github.com/spf13/cobra"
+)
+
+// NewPolicyRollbackCmd creates a new policy rollback command
+func NewPolicyRollbackCmd() *cobra.Command {
+	policyRollbackCmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Restore a previous version of a policy or data document",
+		Long:  `Restores a previously pushed version of a rego policy or data document. Without --version the version before the current one is restored.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			id, err := cmd.Flags().GetString("id")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if id == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("policy id not specified"))
+			}
+			kind, err := cmd.Flags().GetString("kind")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			version, err := cmd.Flags().GetInt("version")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			client, adminTknBody := policyClient(cmd)
+			body := proxy.PolicyRollbackBody{Kind: kind, ID: id, Version: version}
+			var resp proxy.PolicyInfo
+			err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Post(ctx, "/proxy/policies/rollback/", headers, nil, &body, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			err = JSONOutput(cmd.OutOrStdout(), &resp)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("unable to format json output: %v", err))
+			}
+		},
+	}
+
+	policyRollbackCmd.Flags().String("id", "", "Policy id, or data path for data documents")
+	policyRollbackCmd.Flags().String("kind", proxy.PolicyKindModule, "Kind of document: module or data")
+	policyRollbackCmd.Flags().Int("version", 0, "Version to restore; defaults to the previous version")
+	return policyRollbackCmd
+}
@@ -0,0 +1,103 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+
+	"github.com/spf13/cobra"
+)
+
+// NewPolicySimulateCmd creates a new policy simulate command
+func NewPolicySimulateCmd() *cobra.Command {
+	policySimulateCmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Simulate a storage request against the configured policies",
+		Long: `Evaluates a hypothetical storage request against the configured policies and quotas
+and reports the decision without creating, deleting or mapping anything.`,
+		Example: `karavictl policy simulate --tenant Alice --roles role-1 --type powerflex --system-id 542a2d5f5122210f --pool bronze --capacity 8GiB --operation create --admin-token admintoken.yaml --addr csm-authorization.com`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			flagStringValue := func(v string, err error) string {
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+				return v
+			}
+
+			body := proxy.SimulateBody{
+				Tenant:             flagStringValue(cmd.Flags().GetString("tenant")),
+				Roles:              flagStringValue(cmd.Flags().GetString("roles")),
+				SystemType:         flagStringValue(cmd.Flags().GetString("type")),
+				SystemID:           flagStringValue(cmd.Flags().GetString("system-id")),
+				Pool:               flagStringValue(cmd.Flags().GetString("pool")),
+				ProtectionDomainID: flagStringValue(cmd.Flags().GetString("protection-domain-id")),
+				ServiceLevel:       flagStringValue(cmd.Flags().GetString("service-level")),
+				Capacity:           flagStringValue(cmd.Flags().GetString("capacity")),
+				Operation:          flagStringValue(cmd.Flags().GetString("operation")),
+			}
+			claims, err := cmd.Flags().GetStringToString("claim")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if len(claims) > 0 {
+				body.Claims = claims
+			}
+			if body.Tenant == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("tenant not specified"))
+			}
+
+			resp, err := doPolicySimulateRequest(ctx, cmd, body)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			err = JSONOutput(cmd.OutOrStdout(), resp)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("unable to format json output: %v", err))
+			}
+		},
+	}
+
+	policySimulateCmd.Flags().String("tenant", "", "Tenant name")
+	policySimulateCmd.Flags().String("roles", "", "Comma separated list of roles bound to the tenant")
+	policySimulateCmd.Flags().StringP("type", "t", "", "Type of storage system")
+	policySimulateCmd.Flags().StringP("system-id", "s", "", "Storage system identifier")
+	policySimulateCmd.Flags().StringP("pool", "p", "", "Storage pool")
+	policySimulateCmd.Flags().String("protection-domain-id", "", "PowerFlex protection domain ID of the storage pool")
+	policySimulateCmd.Flags().String("service-level", "", "PowerMax service level of the volume")
+	policySimulateCmd.Flags().StringP("capacity", "c", "", "Requested capacity, e.g. 8GiB")
+	policySimulateCmd.Flags().StringP("operation", "o", proxy.SimulateCreate, "Operation to simulate: create, delete, map or unmap")
+	policySimulateCmd.Flags().StringToString("claim", nil, "Custom claim of the tenant, e.g. team=payments; may be repeated")
+	return policySimulateCmd
+}
+
+func doPolicySimulateRequest(ctx context.Context, cmd *cobra.Command, body proxy.SimulateBody) (*proxy.SimulateResponse, error) {
+	client, adminTknBody := policyClient(cmd)
+
+	var resp proxy.SimulateResponse
+	err := doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+		return client.Post(ctx, "/proxy/simulate/", headers, nil, &body, &resp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
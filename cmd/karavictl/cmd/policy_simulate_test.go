@@ -0,0 +1,134 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestPolicySimulateHandler(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	t.Run("it requests a policy simulation", func(t *testing.T) {
+		defer afterFn()
+
+		var gotPath string
+		var gotBody proxy.SimulateBody
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				PostFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, resp interface{}) error {
+					gotPath = path
+					gotBody = *body.(*proxy.SimulateBody)
+					*resp.(*proxy.SimulateResponse) = proxy.SimulateResponse{
+						Allowed: false,
+						Reasons: []string{"not enough quota"},
+					}
+					return nil
+				},
+			}, nil
+		}
+		osExit = func(_ int) {}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOutput(&gotOutput)
+		cmd.SetArgs([]string{"policy", "simulate", "--tenant", "Alice", "--roles", "role-1", "--type", "powerflex",
+			"--system-id", "542a2d5f5122210f", "--pool", "bronze", "--capacity", "8GiB",
+			"--admin-token", "admin.yaml", "--addr", "proxy.com", "--insecure"})
+		cmd.Execute()
+
+		if gotPath != "/proxy/simulate/" {
+			t.Errorf("got path %q, want %q", gotPath, "/proxy/simulate/")
+		}
+		want := proxy.SimulateBody{
+			Tenant:     "Alice",
+			Roles:      "role-1",
+			SystemType: "powerflex",
+			SystemID:   "542a2d5f5122210f",
+			Pool:       "bronze",
+			Capacity:   "8GiB",
+			Operation:  proxy.SimulateCreate,
+		}
+		if !reflect.DeepEqual(gotBody, want) {
+			t.Errorf("got body %+v, want %+v", gotBody, want)
+		}
+
+		var gotResp proxy.SimulateResponse
+		if err := json.Unmarshal(gotOutput.Bytes(), &gotResp); err != nil {
+			t.Fatal(err)
+		}
+		if gotResp.Allowed || len(gotResp.Reasons) != 1 {
+			t.Errorf("unexpected output %s", gotOutput.String())
+		}
+	})
+
+	t.Run("it handles server errors", func(t *testing.T) {
+		defer afterFn()
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				PostFn: func(_ context.Context, _ string, _ map[string]string, _ url.Values, _, _ interface{}) error {
+					return errors.New("test error")
+				},
+			}, nil
+		}
+
+		var gotCode int
+		done := make(chan struct{})
+		osExit = func(code int) {
+			gotCode = code
+			done <- struct{}{}
+			done <- struct{}{} // we can't let this function return
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetErr(&gotOutput)
+		cmd.SetArgs([]string{"policy", "simulate", "--tenant", "Alice", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		go cmd.Execute()
+		<-done
+
+		if gotCode != 1 {
+			t.Errorf("got exit code %d, want 1", gotCode)
+		}
+		var gotErr CommandError
+		if err := json.NewDecoder(&gotOutput).Decode(&gotErr); err != nil {
+			t.Fatal(err)
+		}
+		if gotErr.ErrorMsg != "test error" {
+			t.Errorf("got err %q, want %q", gotErr.ErrorMsg, "test error")
+		}
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"karavi-authorization/pb"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewReportUsageCmd creates a new command for the usage history of tenants
+func NewReportUsageCmd() *cobra.Command {
+	reportUsageCmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Report the usage history of tenants",
+		Long: `Reports the capacity, in kilobytes, approved for each tenant in each storage pool
+from the daily snapshots between --from and --to, inclusive, as YYYY-MM-DD. The
+summary has the peak and the average capacity and the capacity-days, in kilobyte-days,
+to charge for. Both days default to those of the previous month.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			lastMonthFrom, lastMonthTo := previousMonth(time.Now())
+			query := url.Values{}
+			for _, f := range []struct{ name, def string }{
+				{"from", lastMonthFrom},
+				{"to", lastMonthTo},
+				{"tenant", ""},
+				{"organization", ""},
+			} {
+				v, err := cmd.Flags().GetString(f.name)
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+				if v = strings.TrimSpace(v); v == "" {
+					v = f.def
+				}
+				if v != "" {
+					query.Set(f.name, v)
+				}
+			}
+
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if format != "csv" && format != "json" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("unknown format %q, want csv or json", format))
+			}
+
+			daily, err := cmd.Flags().GetBool("daily")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			client, adminTknBody := policyClient(cmd)
+
+			// The history is in the protobuf JSON format, which has 64-bit
+			// integers as strings.
+			var resp json.RawMessage
+			err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Get(ctx, "/proxy/reports/usage/", headers, query, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			var history pb.GetUsageHistoryResponse
+			err = protojson.Unmarshal(resp, &history)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("decoding usage history: %w", err))
+			}
+
+			if format == "json" {
+				err = jsonOutputEmitEmpty(cmd.OutOrStdout(), &history)
+			} else {
+				err = writeUsageHistoryCSV(cmd.OutOrStdout(), &history, daily)
+			}
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+
+	reportUsageCmd.Flags().String("from", "", "First day of the report, as YYYY-MM-DD")
+	reportUsageCmd.Flags().String("to", "", "Last day of the report, as YYYY-MM-DD")
+	reportUsageCmd.Flags().StringP("tenant", "n", "", "Only report the usage of the tenant")
+	reportUsageCmd.Flags().String("organization", "", "Only report the usage of the tenants of the organization")
+	reportUsageCmd.Flags().String("format", "csv", "Output format: csv or json")
+	reportUsageCmd.Flags().Bool("daily", false, "With csv, output the daily snapshots instead of the summary")
+	return reportUsageCmd
+}
+
+// previousMonth returns the first and the last day of the month before the
+// month of now, in UTC.
+func previousMonth(now time.Time) (string, string) {
+	now = now.UTC()
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return first.AddDate(0, -1, 0).Format(time.DateOnly), first.AddDate(0, 0, -1).Format(time.DateOnly)
+}
+
+// writeUsageHistoryCSV writes the summaries, or the daily snapshots, of the
+// usage history as CSV.
+func writeUsageHistoryCSV(w io.Writer, history *pb.GetUsageHistoryResponse, daily bool) error {
+	itoa := func(i int64) string { return strconv.FormatInt(i, 10) }
+
+	var rows [][]string
+	if daily {
+		rows = append(rows, []string{"date", "tenant", "system_type", "system_id", "pool", "approved_capacity_kb", "approved_volumes"})
+		for _, s := range history.Snapshots {
+			rows = append(rows, []string{s.Date, s.Tenant, s.SystemType, s.SystemId, s.Pool, itoa(s.ApprovedCapacity), itoa(s.ApprovedVolumes)})
+		}
+	} else {
+		rows = append(rows, []string{"tenant", "system_type", "system_id", "pool", "days", "peak_capacity_kb", "average_capacity_kb", "capacity_kb_days", "peak_volumes"})
+		for _, s := range history.Summaries {
+			rows = append(rows, []string{s.Tenant, s.SystemType, s.SystemId, s.Pool, itoa(s.Days), itoa(s.PeakCapacity), itoa(s.AverageCapacity), itoa(s.CapacityDays), itoa(s.PeakVolumes)})
+		}
+	}
+
+	return csv.NewWriter(w).WriteAll(rows)
+}
@@ -0,0 +1,137 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/pb"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestReportUsage(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	var gotQuery url.Values
+	fakeClient := func(_ string, _ bool) (api.Client, error) {
+		return &mocks.FakeClient{
+			GetFn: func(_ context.Context, path string, _ map[string]string, query url.Values, resp interface{}) error {
+				if path != "/proxy/reports/usage/" {
+					t.Errorf("got path %q, want %q", path, "/proxy/reports/usage/")
+				}
+				gotQuery = query
+				b := []byte(`{
+					"snapshots": [{"date": "2024-03-01", "tenant": "testname", "systemType": "powerflex", "systemId": "542a2d5f5122210f", "pool": "bronze", "approvedCapacity": "8388608", "approvedVolumes": "1"}],
+					"summaries": [{"tenant": "testname", "systemType": "powerflex", "systemId": "542a2d5f5122210f", "pool": "bronze", "days": "31", "peakCapacity": "8388608", "averageCapacity": "270600", "capacityDays": "8388608", "peakVolumes": "1"}]
+				}`)
+				return json.Unmarshal(b, resp)
+			},
+		}, nil
+	}
+	readToken := func(_ string) (string, string, error) {
+		return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+	}
+
+	t.Run("it reports the usage summary as csv", func(t *testing.T) {
+		defer afterFn()
+		CreateHTTPClient = fakeClient
+		ReadAccessAdminToken = readToken
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs([]string{"report", "usage", "--from", "2024-03-01", "--to", "2024-03-31", "-n", "testname", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		want := url.Values{"from": {"2024-03-01"}, "to": {"2024-03-31"}, "tenant": {"testname"}}
+		if gotQuery.Encode() != want.Encode() {
+			t.Errorf("got query %v, want %v", gotQuery, want)
+		}
+		wantOutput := `tenant,system_type,system_id,pool,days,peak_capacity_kb,average_capacity_kb,capacity_kb_days,peak_volumes
+testname,powerflex,542a2d5f5122210f,bronze,31,8388608,270600,8388608,1
+`
+		if got := gotOutput.String(); got != wantOutput {
+			t.Errorf("got %q, want %q", got, wantOutput)
+		}
+	})
+
+	t.Run("it reports the daily usage as csv", func(t *testing.T) {
+		defer afterFn()
+		CreateHTTPClient = fakeClient
+		ReadAccessAdminToken = readToken
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs([]string{"report", "usage", "--daily", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		from, to := previousMonth(time.Now())
+		if gotQuery.Get("from") != from || gotQuery.Get("to") != to {
+			t.Errorf("got query %v, want the previous month", gotQuery)
+		}
+		wantOutput := `date,tenant,system_type,system_id,pool,approved_capacity_kb,approved_volumes
+2024-03-01,testname,powerflex,542a2d5f5122210f,bronze,8388608,1
+`
+		if got := gotOutput.String(); got != wantOutput {
+			t.Errorf("got %q, want %q", got, wantOutput)
+		}
+	})
+
+	t.Run("it reports the usage as json", func(t *testing.T) {
+		defer afterFn()
+		CreateHTTPClient = fakeClient
+		ReadAccessAdminToken = readToken
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs([]string{"report", "usage", "--format", "json", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		var got pb.GetUsageHistoryResponse
+		if err := protojson.Unmarshal(gotOutput.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Summaries) != 1 || got.Summaries[0].Days != 31 {
+			t.Errorf("got %v, want the usage history", &got)
+		}
+	})
+}
+
+func TestPreviousMonth(t *testing.T) {
+	from, to := previousMonth(time.Date(2024, 3, 15, 1, 0, 0, 0, time.UTC))
+	if from != "2024-02-01" || to != "2024-02-29" {
+		t.Errorf("got %s to %s, want 2024-02-01 to 2024-02-29", from, to)
+	}
+
+	from, to = previousMonth(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if from != "2023-12-01" || to != "2023-12-31" {
+		t.Errorf("got %s to %s, want 2023-12-01 to 2023-12-31", from, to)
+	}
+}
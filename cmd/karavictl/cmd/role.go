@@ -44,7 +44,7 @@ import (
 // PoolQuota contains the storage pool name and quota for the pool
 type PoolQuota struct {
 	Pool  string `json:"pool"`
-	Quota uint64 `json:"quota"`
+	Quota int64  `json:"quota"`
 }
 
 // Role contains a storage system ID and slice of pool quotas for the role
@@ -83,6 +83,7 @@ func NewRoleCmd() *cobra.Command {
 
 	roleCmd.AddCommand(NewRoleCreateCmd())
 	roleCmd.AddCommand(NewRoleDeleteCmd())
+	roleCmd.AddCommand(NewRoleGenerateCmd())
 	roleCmd.AddCommand(NewRoleGetCmd())
 	roleCmd.AddCommand(NewRoleListCmd())
 	roleCmd.AddCommand(NewRoleUpdateCmd())
@@ -204,8 +205,8 @@ func validatePowerFlexPool(storageSystemDetails System, storageSystemID string,
 		return err
 	}
 
-	if poolQuota.Quota < 0 {
-		return errors.New("the specified quota needs to be a positive number")
+	if poolQuota.Quota < roles.UnlimitedQuota {
+		return errors.New("the specified quota needs to be a positive number, 0 or unlimited")
 	}
 	return nil
 }
@@ -234,8 +235,8 @@ func validatePowerMaxStorageResourcePool(ctx context.Context, storageSystemDetai
 		return err
 	}
 
-	if poolQuota.Quota < 0 {
-		return errors.New("the specified quota needs to be a positive number")
+	if poolQuota.Quota < roles.UnlimitedQuota {
+		return errors.New("the specified quota needs to be a positive number, 0 or unlimited")
 	}
 	return nil
 }
@@ -257,8 +258,8 @@ func validatePowerScaleIsiPath(storageSystemDetails System, _ string, poolQuota
 		return err
 	}
 
-	if poolQuota.Quota != 0 {
-		return errors.New("quota must be 0 as it is not enforced by CSM-Authorization")
+	if poolQuota.Quota != 0 && poolQuota.Quota != roles.UnlimitedQuota {
+		return errors.New("quota must be 0 or unlimited as it is not enforced by CSM-Authorization")
 	}
 
 	return nil
@@ -320,7 +321,7 @@ func validateRole(ctx context.Context, role *roles.Instance) error {
 	case "powerflex":
 		err := validatePowerFlexPool(storageSystemDetails, role.SystemID, PoolQuota{
 			Pool:  role.Pool,
-			Quota: uint64(role.Quota),
+			Quota: role.Quota,
 		})
 		if err != nil {
 			return err
@@ -328,7 +329,7 @@ func validateRole(ctx context.Context, role *roles.Instance) error {
 	case "powermax":
 		err := validatePowerMaxStorageResourcePool(ctx, storageSystemDetails, role.SystemID, PoolQuota{
 			Pool:  role.Pool,
-			Quota: uint64(role.Quota),
+			Quota: role.Quota,
 		})
 		if err != nil {
 			return err
@@ -336,7 +337,7 @@ func validateRole(ctx context.Context, role *roles.Instance) error {
 	case "powerscale":
 		err := validatePowerScaleIsiPath(storageSystemDetails, role.SystemID, PoolQuota{
 			Pool:  role.Pool,
-			Quota: uint64(role.Quota),
+			Quota: role.Quota,
 		})
 		if err != nil {
 			return err
@@ -47,7 +47,12 @@ func NewRoleCreateCmd() *cobra.Command {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf(outFormat, err))
 			}
 
-			if len(roleFlags) == 0 {
+			roleFile, err := cmd.Flags().GetString("from-file")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf(outFormat, err))
+			}
+
+			if len(roleFlags) == 0 && roleFile == "" {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf(outFormat, errors.New("no input")))
 			}
 
@@ -84,6 +89,21 @@ func NewRoleCreateCmd() *cobra.Command {
 				}
 			}
 
+			// process role file
+
+			if roleFile != "" {
+				fileRoles, err := readRoleFile(roleFile)
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf(outFormat, err))
+				}
+				for _, r := range fileRoles {
+					err = rff.Add(r)
+					if err != nil {
+						reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf(outFormat, err))
+					}
+				}
+			}
+
 			admTknFile, err := cmd.Flags().GetString("admin-token")
 			if err != nil {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
@@ -108,7 +128,8 @@ func NewRoleCreateCmd() *cobra.Command {
 		},
 	}
 
-	roleCreateCmd.Flags().StringSlice("role", []string{}, "role in the form <name>=<type>=<id>=<pool>=<quota>")
+	roleCreateCmd.Flags().StringSlice("role", []string{}, "role in the form <name>=<type>=<id>=<pool>=<quota>[=<soft quota>[=<service levels>[=<min size>[=<max size>]]]], where the quotas and sizes have units such as GB or TiB, or are in kilobytes; a quota of 0 denies provisioning and unlimited (or -1) removes the cap; usage above the soft quota is allowed only during the grace period; a powerflex <pool> of pd:<protection domain> permits each pool of the protection domain; a powermax role may be pinned to service levels separated by +, e.g. Diamond+Gold; volumes smaller than the min size or larger than the max size are denied")
+	roleCreateCmd.Flags().String("from-file", "", "Path to a YAML file of roles, such as one made by role generate")
 	return roleCreateCmd
 }
 
@@ -123,7 +144,17 @@ func doRoleCreateRequest(_ context.Context, addr string, insecure bool, role *ro
 		StorageType: role.SystemType,
 		SystemId:    role.SystemID,
 		Pool:        role.Pool,
-		Quota:       strconv.FormatUint(role.Quota, 10),
+		Quota:       strconv.FormatInt(role.Quota, 10),
+	}
+	if role.SoftQuota > 0 {
+		body.SoftQuota = strconv.FormatInt(role.SoftQuota, 10)
+	}
+	body.ServiceLevels = role.ServiceLevels
+	if role.MinSize > 0 {
+		body.MinSize = strconv.FormatInt(role.MinSize, 10)
+	}
+	if role.MaxSize > 0 {
+		body.MaxSize = strconv.FormatInt(role.MaxSize, 10)
 	}
 
 	headers := make(map[string]string)
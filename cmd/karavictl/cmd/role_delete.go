@@ -105,7 +105,7 @@ func doRoleDeleteRequest(ctx context.Context, addr string, insecure bool, role *
 		StorageType: role.SystemType,
 		SystemId:    role.SystemID,
 		Pool:        role.Pool,
-		Quota:       strconv.FormatUint(role.Quota, 10),
+		Quota:       strconv.FormatInt(role.Quota, 10),
 	}
 
 	headers := make(map[string]string)
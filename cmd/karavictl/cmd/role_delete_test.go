@@ -22,6 +22,8 @@ import (
 	"io"
 	"karavi-authorization/cmd/karavictl/cmd/api"
 	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/web"
+	"net/http"
 	"net/url"
 	"os"
 	"testing"
@@ -148,4 +150,39 @@ func TestRoleDeleteHandler(t *testing.T) {
 			t.Errorf("got err %q, want %q", gotErr.ErrorMsg, wantErrMsg)
 		}
 	})
+	t.Run("it reports the code of service errors", func(t *testing.T) {
+		defer afterFn()
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				DeleteFn: func(_ context.Context, _ string, _ map[string]string, _ url.Values, _, _ interface{}) error {
+					return web.JSONError{ErrorMsg: "rpc error: code = NotFound desc = role not found", Code: http.StatusNotFound, Reason: web.CodeNotFound, ServiceCode: "ROLE_NOT_FOUND", Params: map[string]string{"role": "bar"}}
+				},
+			}, nil
+		}
+		done := make(chan struct{})
+		osExit = func(_ int) {
+			done <- struct{}{}
+			done <- struct{}{} // we can't let this function return
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		var gotOutput bytes.Buffer
+
+		rootCmd := NewRootCmd()
+		rootCmd.SetErr(&gotOutput)
+		rootCmd.SetArgs([]string{"role", "delete", "--insecure", "--role=bar=powerflex=11e4e7d35817bd0f=mypool=75GB", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+
+		go rootCmd.Execute()
+		<-done
+
+		var gotErr CommandError
+		if err := json.NewDecoder(&gotOutput).Decode(&gotErr); err != nil {
+			t.Fatal(err)
+		}
+		want := CommandError{ErrorMsg: `role "bar" does not exist`, Code: "ROLE_NOT_FOUND"}
+		if gotErr != want {
+			t.Errorf("got err %+v, want %+v", gotErr, want)
+		}
+	})
 }
@@ -0,0 +1,216 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/role-service/roles"
+	"karavi-authorization/pb"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"sigs.k8s.io/yaml"
+)
+
+// RoleSpec is a role in a file of roles, such as the one emitted by role generate
+type RoleSpec struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	SystemID  string `json:"systemId"`
+	Pool      string `json:"pool"`
+	Quota     string `json:"quota"`
+	SoftQuota string `json:"softQuota,omitempty"`
+	// ServiceLevels pins a powermax role to the service levels.
+	ServiceLevels []string `json:"serviceLevels,omitempty"`
+	// MinSize and MaxSize bound the size of each volume of the pool.
+	MinSize string `json:"minSize,omitempty"`
+	MaxSize string `json:"maxSize,omitempty"`
+}
+
+// RoleFile is a file of roles
+type RoleFile struct {
+	Roles []RoleSpec `json:"roles"`
+}
+
+// defaultQuotaPercent is the percentage of a pool's capacity proposed as its quota
+const defaultQuotaPercent = 80
+
+var invalidRoleNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// NewRoleGenerateCmd creates a new generate command
+func NewRoleGenerateCmd() *cobra.Command {
+	roleGenerateCmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Propose roles for the storage pools of a storage system",
+		Long: `Queries the pools, protection domains and capacity of a registered storage
+system and prints a YAML file of proposed roles, one per pool, with a quota of a
+percentage of the pool's capacity. Edit the file, then apply it with
+role create --from-file.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			flagStringValue := func(v string, err error) string {
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+				return v
+			}
+
+			sysID := flagStringValue(cmd.Flags().GetString("from-array"))
+			if sysID == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errSystemIDNotSpecified)
+			}
+			storType := flagStringValue(cmd.Flags().GetString("type"))
+			if storType == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errSystemTypeNotSpecified)
+			}
+			percent, err := cmd.Flags().GetInt("quota-percent")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if percent < 1 || percent > 100 {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("quota percent must be between 1 and 100"))
+			}
+			byPD, err := cmd.Flags().GetBool("by-protection-domain")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			client, adminTknBody := policyClient(cmd)
+
+			query := url.Values{
+				"StorageType": []string{storType},
+				"SystemId":    []string{sysID},
+			}
+			// The pools are in the protobuf JSON format, which has 64-bit
+			// integers as strings.
+			var resp json.RawMessage
+			err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Get(ctx, "/proxy/storage/discover/", headers, query, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			var discovered pb.StorageDiscoverResponse
+			err = protojson.Unmarshal(resp, &discovered)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("decoding discovered storage: %w", err))
+			}
+
+			b, err := yaml.Marshal(proposeRoles(storType, sysID, discovered.Pools, percent, byPD))
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "# Roles proposed for %s system %s. Edit, then apply with:\n", storType, sysID)
+			fmt.Fprintf(cmd.OutOrStdout(), "#   karavictl role create --from-file <file>\n")
+			fmt.Fprint(cmd.OutOrStdout(), string(b))
+		},
+	}
+
+	roleGenerateCmd.Flags().String("from-array", "", "ID of the registered storage system to query; required")
+	roleGenerateCmd.Flags().StringP("type", "t", "powerflex", "Type of storage system")
+	roleGenerateCmd.Flags().Int("quota-percent", defaultQuotaPercent, "Percentage of the capacity of a pool proposed as its quota")
+	roleGenerateCmd.Flags().Bool("by-protection-domain", false, "Propose one role per protection domain, permitting each of its pools, instead of one per pool")
+	return roleGenerateCmd
+}
+
+// proposeRoles returns a role per pool or, by protection domain, a role per
+// protection domain with the quota of its smallest pool.
+func proposeRoles(storType, sysID string, pools []*pb.DiscoveredPool, percent int, byPD bool) RoleFile {
+	quota := func(capacityInKb int64) string {
+		return roles.FormatQuota(capacityInKb * int64(percent) / 100)
+	}
+
+	var rf RoleFile
+	if !byPD {
+		for _, p := range pools {
+			rf.Roles = append(rf.Roles, RoleSpec{
+				Name:     roleName(p.ProtectionDomain, p.Name),
+				Type:     storType,
+				SystemID: sysID,
+				Pool:     p.Name,
+				Quota:    quota(p.CapacityInKb),
+			})
+		}
+		return rf
+	}
+
+	// The quota of a protection domain role applies to each of its pools.
+	var pds []string
+	minCapacity := make(map[string]int64)
+	for _, p := range pools {
+		c, ok := minCapacity[p.ProtectionDomain]
+		if !ok {
+			pds = append(pds, p.ProtectionDomain)
+		}
+		if !ok || p.CapacityInKb < c {
+			minCapacity[p.ProtectionDomain] = p.CapacityInKb
+		}
+	}
+	for _, pd := range pds {
+		rf.Roles = append(rf.Roles, RoleSpec{
+			Name:     roleName(pd),
+			Type:     storType,
+			SystemID: sysID,
+			Pool:     roles.ProtectionDomainPrefix + pd,
+			Quota:    quota(minCapacity[pd]),
+		})
+	}
+	return rf
+}
+
+// roleName joins the parts into a lowercase role name of letters, digits and dashes
+func roleName(parts ...string) string {
+	var name []string
+	for _, p := range parts {
+		if p = strings.Trim(invalidRoleNameChars.ReplaceAllString(strings.ToLower(p), "-"), "-"); p != "" {
+			name = append(name, p)
+		}
+	}
+	return strings.Join(name, "-")
+}
+
+// readRoleFile reads the roles of a role file
+func readRoleFile(path string) ([]*roles.Instance, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rf RoleFile
+	err = yaml.UnmarshalStrict(b, &rf)
+	if err != nil {
+		return nil, fmt.Errorf("parsing role file %s: %w", path, err)
+	}
+
+	var ret []*roles.Instance
+	for _, r := range rf.Roles {
+		ins, err := roles.NewInstance(r.Name, r.Type, r.SystemID, r.Pool, r.Quota, r.SoftQuota, strings.Join(r.ServiceLevels, roles.ServiceLevelSeparator), r.MinSize, r.MaxSize)
+		if err != nil {
+			return nil, fmt.Errorf("role %s: %w", r.Name, err)
+		}
+		ret = append(ret, ins)
+	}
+	return ret, nil
+}
@@ -0,0 +1,102 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/pb"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRoleGenerateHandler(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	t.Run("it proposes a role per pool that role create accepts", func(t *testing.T) {
+		defer afterFn()
+		var gotQuery url.Values
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				GetFn: func(_ context.Context, path string, _ map[string]string, query url.Values, resp interface{}) error {
+					if path != "/proxy/storage/discover/" {
+						t.Errorf("got path %q, want %q", path, "/proxy/storage/discover/")
+					}
+					gotQuery = query
+					b := []byte(`{"pools": [{"name": "Bronze", "protectionDomain": "domain1", "capacityInKb": "100000000", "usedInKb": "0"}]}`)
+					return json.Unmarshal(b, resp)
+				},
+			}, nil
+		}
+		osExit = func(_ int) {}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOutput(&gotOutput)
+		cmd.SetArgs([]string{"role", "generate", "--from-array", "542a2d5f5122210f", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		if want := (url.Values{"StorageType": {"powerflex"}, "SystemId": {"542a2d5f5122210f"}}); !reflect.DeepEqual(gotQuery, want) {
+			t.Errorf("got query %v, want %v", gotQuery, want)
+		}
+
+		path := filepath.Join(t.TempDir(), "roles.yaml")
+		if err := os.WriteFile(path, gotOutput.Bytes(), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := readRoleFile(path)
+		if err != nil {
+			t.Fatalf("reading generated roles %s: %v", gotOutput.String(), err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("got %d roles, want 1", len(got))
+		}
+		if got[0].Name != "domain1-bronze" || got[0].Pool != "Bronze" || got[0].Quota != 80000000 {
+			t.Errorf("got role %+v, want domain1-bronze with 80%% of the pool's capacity", got[0])
+		}
+	})
+}
+
+func TestProposeRoles(t *testing.T) {
+	pools := []*pb.DiscoveredPool{
+		{Name: "bronze", ProtectionDomain: "Domain 1", CapacityInKb: 200000000},
+		{Name: "silver", ProtectionDomain: "Domain 1", CapacityInKb: 100000000},
+		{Name: "gold", ProtectionDomain: "domain2", CapacityInKb: 50000000},
+	}
+
+	got := proposeRoles("powerflex", "542a2d5f5122210f", pools, 50, true)
+
+	want := RoleFile{Roles: []RoleSpec{
+		{Name: "domain-1", Type: "powerflex", SystemID: "542a2d5f5122210f", Pool: "pd:Domain 1", Quota: "50 GB"},
+		{Name: "domain2", Type: "powerflex", SystemID: "542a2d5f5122210f", Pool: "pd:domain2", Quota: "25 GB"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -16,9 +16,9 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"karavi-authorization/internal/role-service/roles"
 	"karavi-authorization/internal/token"
 	"karavi-authorization/internal/web"
 	"karavi-authorization/pb"
@@ -56,7 +56,6 @@ func NewRoleGetCmd() *cobra.Command {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
 			}
 
-			var out map[string]interface{}
 			admTknFile, err := cmd.Flags().GetString("admin-token")
 			if err != nil {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
@@ -73,12 +72,13 @@ func NewRoleGetCmd() *cobra.Command {
 				Access:  accessToken,
 			}
 
-			out, err = doRoleGetRequest(ctx, addr, insecure, roleName, cmd, adminTknBody)
+			role, err := doRoleGetRequest(ctx, addr, insecure, roleName, cmd, adminTknBody)
 			if err != nil {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
 			}
 
-			err = JSONOutput(cmd.OutOrStdout(), out)
+			readRole := roles.TransformReadable(role)
+			err = JSONOutput(cmd.OutOrStdout(), &readRole)
 			if err != nil {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("unable to format json output: %v", err))
 			}
@@ -89,7 +89,7 @@ func NewRoleGetCmd() *cobra.Command {
 	return roleGetCmd
 }
 
-func doRoleGetRequest(ctx context.Context, addr string, insecure bool, name string, cmd *cobra.Command, adminTknBody token.AdminToken) (map[string]interface{}, error) {
+func doRoleGetRequest(ctx context.Context, addr string, insecure bool, name string, cmd *cobra.Command, adminTknBody token.AdminToken) (*roles.JSON, error) {
 	client, err := CreateHTTPClient(fmt.Sprintf("https://%s", addr), insecure)
 	if err != nil {
 		reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
@@ -128,11 +128,11 @@ func doRoleGetRequest(ctx context.Context, addr string, insecure bool, name stri
 		}
 	}
 
-	var m map[string]interface{}
-	err = json.Unmarshal(role.GetRole(), &m)
+	r := roles.NewJSON()
+	err = r.UnmarshalJSON(role.GetRole())
 	if err != nil {
 		reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
 	}
 
-	return m, nil
+	return &r, nil
 }
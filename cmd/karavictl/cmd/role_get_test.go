@@ -86,7 +86,7 @@ func TestRoleGetHandler(t *testing.T) {
 		got := strings.ReplaceAll(gotOutput.String(), "\n", "")
 		got = strings.ReplaceAll(got, " ", "")
 
-		want := `{"test":{"system_types":{"powerflex":{"system_ids":{"542a2d5f5122210f":{"pool_quotas":{"bronze":10}}}}}}}`
+		want := `{"test":{"system_types":{"powerflex":{"system_ids":{"542a2d5f5122210f":{"pool_quotas":{"bronze":"10kB"}}}}}}}`
 		if want != got {
 			t.Errorf("want %s, got \n%s", want, got)
 		}
@@ -101,7 +101,7 @@ func NewRoleUpdateCmd() *cobra.Command {
 		},
 	}
 
-	roleUpdateCmd.Flags().StringSlice("role", []string{}, "role in the form <name>=<type>=<id>=<pool>=<quota>")
+	roleUpdateCmd.Flags().StringSlice("role", []string{}, "role in the form <name>=<type>=<id>=<pool>=<quota>[=<soft quota>[=<service levels>[=<min size>[=<max size>]]]], where the quotas and sizes have units such as GB or TiB, or are in kilobytes; a quota of 0 denies provisioning and unlimited (or -1) removes the cap; usage above the soft quota is allowed only during the grace period; a powerflex <pool> of pd:<protection domain> permits each pool of the protection domain; a powermax role may be pinned to service levels separated by +, e.g. Diamond+Gold; volumes smaller than the min size or larger than the max size are denied")
 	return roleUpdateCmd
 }
 
@@ -116,7 +116,17 @@ func doRoleUpdateRequest(ctx context.Context, addr string, insecure bool, role *
 		StorageType: role.SystemType,
 		SystemId:    role.SystemID,
 		Pool:        role.Pool,
-		Quota:       strconv.FormatUint(role.Quota, 10),
+		Quota:       strconv.FormatInt(role.Quota, 10),
+	}
+	if role.SoftQuota > 0 {
+		body.SoftQuota = strconv.FormatInt(role.SoftQuota, 10)
+	}
+	body.ServiceLevels = role.ServiceLevels
+	if role.MinSize > 0 {
+		body.MinSize = strconv.FormatInt(role.MinSize, 10)
+	}
+	if role.MaxSize > 0 {
+		body.MaxSize = strconv.FormatInt(role.MaxSize, 10)
 	}
 
 	headers := make(map[string]string)
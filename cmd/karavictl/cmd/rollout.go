@@ -0,0 +1,115 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// NewRolloutCmd creates a new rollout command
+func NewRolloutCmd() *cobra.Command {
+	rolloutCmd := &cobra.Command{
+		Use:   "rollout",
+		Short: "Manage canary rollouts of policy data",
+		Long: `Manage canary rollouts of policy data. A rollout pushes data documents to a canary OPA
+instance that decides the requests of a percentage of the tenants, and is rolled back
+automatically if it denies requests of the cohort more often than the primary instance.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := cmd.Usage(); err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("error: %+v", err))
+			}
+			os.Exit(1)
+		},
+	}
+
+	rolloutCmd.PersistentFlags().StringP("admin-token", "f", "", "Path to admin token file; required")
+	rolloutCmd.PersistentFlags().String("addr", "", "Address of the CSM Authorization Proxy Server; required")
+	rolloutCmd.PersistentFlags().Bool("insecure", false, "Skip certificate validation of the CSM Authorization Proxy Server")
+
+	err := rolloutCmd.MarkPersistentFlagRequired("admin-token")
+	if err != nil {
+		reportErrorAndExit(JSONOutput, rolloutCmd.ErrOrStderr(), err)
+	}
+
+	err = rolloutCmd.MarkPersistentFlagRequired("addr")
+	if err != nil {
+		reportErrorAndExit(JSONOutput, rolloutCmd.ErrOrStderr(), err)
+	}
+
+	rolloutCmd.AddCommand(NewRolloutStartCmd())
+	rolloutCmd.AddCommand(NewRolloutStatusCmd())
+	rolloutCmd.AddCommand(newRolloutEndCmd("promote", "Promote the running rollout to the primary OPA instance", "promote/"))
+	rolloutCmd.AddCommand(newRolloutEndCmd("rollback", "Roll back the running rollout", "rollback/"))
+	return rolloutCmd
+}
+
+// NewRolloutStatusCmd creates a new rollout status command
+func NewRolloutStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the current rollout and its decisions",
+		Long:  `Shows the current rollout with the decisions and denials of the canary cohort and of the other tenants.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			client, adminTknBody := policyClient(cmd)
+			var resp proxy.RolloutStatus
+			err := doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Get(ctx, "/proxy/rollouts/", headers, nil, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			writeRolloutStatus(cmd, &resp)
+		},
+	}
+}
+
+// newRolloutEndCmd creates a command that ends the running rollout at the
+// path under /proxy/rollouts/.
+func newRolloutEndCmd(use, short, path string) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Long:  short,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			client, adminTknBody := policyClient(cmd)
+			var resp proxy.RolloutStatus
+			err := doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Post(ctx, "/proxy/rollouts/"+path, headers, nil, nil, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			writeRolloutStatus(cmd, &resp)
+		},
+	}
+}
+
+func writeRolloutStatus(cmd *cobra.Command, status *proxy.RolloutStatus) {
+	err := JSONOutput(cmd.OutOrStdout(), status)
+	if err != nil {
+		reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("unable to format json output: %v", err))
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// NewRolloutStartCmd creates a new rollout start command
+func NewRolloutStartCmd() *cobra.Command {
+	rolloutStartCmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start a canary rollout of policy data",
+		Long: `Pushes data documents to the canary OPA instance and routes the requests of a percentage
+of the tenants to it. The rollout is rolled back automatically once the cohort made
+--min-decisions decisions and its denial rate exceeds the one of the other tenants by more
+than --max-deny-rate-increase percentage points.`,
+		Example: `karavictl rollout start --id roles-v2 --opa-host opa-canary:8181 --percent 10 --data karavi/common=common.yaml --admin-token admintoken.yaml --addr csm-authorization.com`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			id, err := cmd.Flags().GetString("id")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			opaHost, err := cmd.Flags().GetString("opa-host")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if opaHost == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("canary opa host not specified"))
+			}
+			percent, err := cmd.Flags().GetFloat64("percent")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			maxIncrease, err := cmd.Flags().GetFloat64("max-deny-rate-increase")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			minDecisions, err := cmd.Flags().GetInt64("min-decisions")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			files, err := cmd.Flags().GetStringToString("data")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			data, err := readRolloutData(files)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			client, adminTknBody := policyClient(cmd)
+			body := proxy.Rollout{
+				ID:                  id,
+				OPAHost:             opaHost,
+				Percent:             percent,
+				Data:                data,
+				MaxDenyRateIncrease: maxIncrease,
+				MinDecisions:        minDecisions,
+			}
+			var resp proxy.RolloutStatus
+			err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Post(ctx, "/proxy/rollouts/", headers, nil, &body, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			writeRolloutStatus(cmd, &resp)
+		},
+	}
+
+	rolloutStartCmd.Flags().String("id", "", "Rollout id; defaults to one generated by the proxy server")
+	rolloutStartCmd.Flags().String("opa-host", "", "Host of the canary OPA instance; required")
+	rolloutStartCmd.Flags().Float64("percent", 10, "Percentage of the tenants decided by the canary")
+	rolloutStartCmd.Flags().Float64("max-deny-rate-increase", proxy.DefaultRolloutMaxDenyRateIncrease, "Percentage points the denial rate of the cohort may exceed the one of the other tenants by")
+	rolloutStartCmd.Flags().Int64("min-decisions", proxy.DefaultRolloutMinDecisions, "Decisions of the cohort before the denial rates are compared")
+	rolloutStartCmd.Flags().StringToString("data", nil, "Data documents to push to the canary, as path=file with .json or .yaml files")
+	return rolloutStartCmd
+}
+
+// readRolloutData reads the data document files by their data path.
+func readRolloutData(files map[string]string) (map[string]json.RawMessage, error) {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	data := make(map[string]json.RawMessage, len(files))
+	for _, path := range paths {
+		b, err := os.ReadFile(files[path])
+		if err != nil {
+			return nil, fmt.Errorf("reading data %s: %w", path, err)
+		}
+		doc, err := yaml.YAMLToJSON(b)
+		if err != nil {
+			return nil, fmt.Errorf("decoding data %s: %w", path, err)
+		}
+		data[path] = doc
+	}
+	return data, nil
+}
@@ -0,0 +1,122 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRollout(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	var gotPath string
+	var gotBody interface{}
+	setup := func() {
+		gotPath, gotBody = "", nil
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				GetFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, resp interface{}) error {
+					gotPath = path
+					*resp.(*proxy.RolloutStatus) = proxy.RolloutStatus{
+						Rollout: &proxy.Rollout{ID: "roles-v2", State: proxy.RolloutRunning},
+						Stats:   proxy.RolloutStats{CanaryDecisions: 10, CanaryDenied: 1},
+					}
+					return nil
+				},
+				PostFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, resp interface{}) error {
+					gotPath, gotBody = path, body
+					*resp.(*proxy.RolloutStatus) = proxy.RolloutStatus{Rollout: &proxy.Rollout{ID: "roles-v2", State: proxy.RolloutPromoted}}
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+	}
+	run := func(t *testing.T, args ...string) proxy.RolloutStatus {
+		var out bytes.Buffer
+		cmd := NewRootCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs(append(args, "--admin-token", "admin.yaml", "--addr", "proxy.com"))
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		var status proxy.RolloutStatus
+		if err := json.Unmarshal(out.Bytes(), &status); err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	t.Run("it starts a rollout with the data documents", func(t *testing.T) {
+		defer afterFn()
+		setup()
+		file := filepath.Join(t.TempDir(), "common.yaml")
+		if err := os.WriteFile(file, []byte("teams:\n- a\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		run(t, "rollout", "start", "--id", "roles-v2", "--opa-host", "opa-canary:8181", "--percent", "20", "--data", "karavi/common="+file)
+
+		if gotPath != "/proxy/rollouts/" {
+			t.Errorf("got path %q, want %q", gotPath, "/proxy/rollouts/")
+		}
+		body := gotBody.(*proxy.Rollout)
+		if body.ID != "roles-v2" || body.OPAHost != "opa-canary:8181" || body.Percent != 20 || body.MinDecisions != proxy.DefaultRolloutMinDecisions {
+			t.Errorf("got %+v, want the rollout of roles-v2", body)
+		}
+		if got, want := string(body.Data["karavi/common"]), `{"teams":["a"]}`; got != want {
+			t.Errorf("got data %s, want %s", got, want)
+		}
+	})
+
+	t.Run("it shows the rollout status", func(t *testing.T) {
+		defer afterFn()
+		setup()
+
+		got := run(t, "rollout", "status")
+
+		if gotPath != "/proxy/rollouts/" || got.Rollout.ID != "roles-v2" || got.Stats.CanaryDenied != 1 {
+			t.Errorf("got %+v from %q, want the status of roles-v2", got, gotPath)
+		}
+	})
+
+	t.Run("it promotes and rolls back the rollout", func(t *testing.T) {
+		defer afterFn()
+		setup()
+
+		for _, action := range []string{"promote", "rollback"} {
+			run(t, "rollout", action)
+			if want := "/proxy/rollouts/" + action + "/"; gotPath != want {
+				t.Errorf("got path %q, want %q", gotPath, want)
+			}
+		}
+	})
+}
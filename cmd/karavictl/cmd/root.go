@@ -34,6 +34,10 @@ const (
 	K3sPath = "/usr/local/bin/k3s"
 )
 
+// certificateAuthority is the PEM certificate authority of the proxy server,
+// read from the current context.
+var certificateAuthority []byte
+
 // NewRootCmd creates a new base command when called without any subcommands
 func NewRootCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
@@ -41,6 +45,9 @@ func NewRootCmd() *cobra.Command {
 		Short: "karavictl is used to interact with karavi server",
 		Long: `karavictl provides security, RBAC, and quota limits for accessing Dell
 	storage products from Kubernetes clusters`,
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			return applyCurrentContext(cmd)
+		},
 		Run: func(cmd *cobra.Command, _ []string) {
 			if err := cmd.Execute(); err != nil {
 				fmt.Println(err)
@@ -56,13 +63,24 @@ func NewRootCmd() *cobra.Command {
 	rootCmd.AddCommand(NewGenerateCmd())
 	rootCmd.AddCommand(NewStorageCmd())
 	rootCmd.AddCommand(NewAdminCmd())
+	rootCmd.AddCommand(NewPolicyCmd())
+	rootCmd.AddCommand(NewLoginCmd())
+	rootCmd.AddCommand(NewUsageCmd())
+	rootCmd.AddCommand(NewReportCmd())
+	rootCmd.AddCommand(NewVolumeCmd())
+	rootCmd.AddCommand(NewConfigCmd())
+	rootCmd.AddCommand(NewDoctorCmd())
+	rootCmd.AddCommand(NewRolloutCmd())
+	rootCmd.AddCommand(NewDebugCmd())
+	rootCmd.AddCommand(NewMaintenanceCmd())
 	return rootCmd
 }
 
 func createHTTPClient(addr string, insecure bool) (api.Client, error) {
 	c, err := api.New(context.Background(), addr, api.ClientOptions{
-		Insecure:   insecure,
-		HTTPClient: http.DefaultClient,
+		Insecure:             insecure,
+		CertificateAuthority: certificateAuthority,
+		HTTPClient:           http.DefaultClient,
 	})
 	if err != nil {
 		return nil, err
@@ -64,6 +64,9 @@ func NewStorageCmd() *cobra.Command {
 	storageCmd.AddCommand(NewStorageGetCmd())
 	storageCmd.AddCommand(NewStorageListCmd())
 	storageCmd.AddCommand(NewStorageUpdateCmd())
+	storageCmd.AddCommand(NewStorageStatusCmd())
+	storageCmd.AddCommand(NewStoragePauseCmd())
+	storageCmd.AddCommand(NewStorageResumeCmd())
 	return storageCmd
 }
 
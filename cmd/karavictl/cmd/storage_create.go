@@ -44,10 +44,33 @@ type SystemType map[string]System
 
 // System represents the properties of a system.
 type System struct {
-	User     string `yaml:"User"`
-	Password string `yaml:"Password"`
-	Endpoint string `yaml:"Endpoint"`
-	Insecure bool   `yaml:"Insecure"`
+	User     string          `yaml:"User"`
+	Password string          `yaml:"Password"`
+	Endpoint string          `yaml:"Endpoint"`
+	Insecure bool            `yaml:"Insecure"`
+	Timeouts *SystemTimeouts `yaml:"Timeouts,omitempty" json:"Timeouts,omitempty"`
+	Headers  *SystemHeaders  `yaml:"Headers,omitempty" json:"Headers,omitempty"`
+	// CertificateFingerprint pins the SHA-256 fingerprint of the certificate
+	// the system must present to the proxy-server.
+	CertificateFingerprint string `yaml:"CertificateFingerprint,omitempty" json:"CertificateFingerprint,omitempty"`
+}
+
+// SystemTimeouts are the timeouts, such as "30s", of the calls the
+// proxy-server makes to a system for each operation.
+type SystemTimeouts struct {
+	Default string `yaml:"Default,omitempty"`
+	Create  string `yaml:"Create,omitempty"`
+	Delete  string `yaml:"Delete,omitempty"`
+	Map     string `yaml:"Map,omitempty"`
+	Query   string `yaml:"Query,omitempty"`
+}
+
+// SystemHeaders are the request headers the proxy-server sets, e.g. the
+// User-Agent, and removes, e.g. Forwarded, before forwarding the requests
+// to a system.
+type SystemHeaders struct {
+	Set    map[string]string `yaml:"Set,omitempty"`
+	Remove []string          `yaml:"Remove,omitempty"`
 }
 
 // SystemID wraps a system ID to be a quoted string because system IDs could be all numbers
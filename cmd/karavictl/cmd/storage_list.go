@@ -16,15 +16,20 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"karavi-authorization/internal/token"
 	"karavi-authorization/internal/web"
 	"karavi-authorization/pb"
 	"net/http"
+	"sort"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
 	"sigs.k8s.io/yaml"
 )
 
@@ -33,7 +38,10 @@ func NewStorageListCmd() *cobra.Command {
 	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List registered storage systems.",
-		Long:  `Lists registered storage systems.`,
+		Long: `Lists registered storage systems, optionally of one type or with one system ID.
+Passwords are redacted unless --show-credentials is set, which requires an admin
+token. The table and wide outputs show one system per line; wide adds the status
+of the last health check of each system.`,
 		Run: func(cmd *cobra.Command, _ []string) {
 			errAndExit := func(err error) {
 				fmt.Fprintf(cmd.ErrOrStderr(), "error: %+v\n", err)
@@ -57,6 +65,12 @@ func NewStorageListCmd() *cobra.Command {
 			}
 
 			storageType := flagStringValue(cmd.Flags().GetString("type"))
+			systemID := flagStringValue(cmd.Flags().GetString("system-id"))
+			showCredentials := flagBoolValue(cmd.Flags().GetBool("show-credentials"))
+			output := flagStringValue(cmd.Flags().GetString("output"))
+			if output != "json" && output != "table" && output != "wide" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("unknown output %q, want json, table or wide", output))
+			}
 			addr := flagStringValue(cmd.Flags().GetString("addr"))
 			insecure := flagBoolValue(cmd.Flags().GetBool("insecure"))
 
@@ -83,46 +97,151 @@ func NewStorageListCmd() *cobra.Command {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
 			}
 
-			scrubbed, err := scrubPasswords(decodedSystems)
-			if err != nil {
-				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			if !showCredentials {
+				decodedSystems, err = scrubPasswords(decodedSystems)
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
 			}
 
 			m := make(map[string]interface{})
-			if err := yaml.Unmarshal(scrubbed, &m); err != nil {
+			if err := yaml.Unmarshal(decodedSystems, &m); err != nil {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
 			}
 
-			s := filterStorage(storageType, m)
-			if err := JSONOutput(cmd.OutOrStdout(), &s); err != nil {
+			if output == "json" {
+				s := filterStorage(storageType, systemID, m)
+				if err := JSONOutput(cmd.OutOrStdout(), &s); err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+				return
+			}
+
+			var status map[string]string
+			if output == "wide" {
+				status = doStorageStatusRequest(context.Background(), addr, insecure, adminTknBody)
+			}
+			if err := writeStorageTable(cmd.OutOrStdout(), storageRows(storageType, systemID, m), status, showCredentials); err != nil {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
 			}
 		},
 	}
 
 	listCmd.Flags().StringP("type", "t", "", "Type of storage system")
+	listCmd.Flags().String("system-id", "", "System ID of the storage system")
+	listCmd.Flags().Bool("show-credentials", false, "Show the passwords of the storage systems")
+	listCmd.Flags().StringP("output", "o", "json", "Output format: json, table or wide")
 	return listCmd
 }
 
-func filterStorage(storageType string, allStorage map[string]interface{}) interface{} {
-	if storageType == "" {
+// filterStorage returns the systems of allStorage with the type and the
+// system ID, if set. The systems of a type are returned by system ID only.
+func filterStorage(storageType, systemID string, allStorage map[string]interface{}) interface{} {
+	if storageType == "" && systemID == "" {
 		return allStorage
 	}
 
 	output := make(map[string]interface{})
 	for i, v := range allStorage {
-		if i != storageType {
+		if storageType != "" && i != storageType {
+			continue
+		}
+		systems, ok := v.(map[string]interface{})
+		if !ok {
 			continue
 		}
-		if systems, ok := v.(map[string]interface{}); ok {
-			for id, system := range systems {
-				output[id] = system
+		filtered := make(map[string]interface{})
+		for id, system := range systems {
+			if systemID == "" || id == systemID {
+				filtered[id] = system
 			}
 		}
+		if storageType != "" {
+			return filtered
+		}
+		if len(filtered) != 0 {
+			output[i] = filtered
+		}
 	}
 	return output
 }
 
+// storageRow is a storage system in the table output.
+type storageRow struct {
+	storageType string
+	systemID    string
+	system      map[string]interface{}
+}
+
+// storageRows returns the systems of allStorage with the type and the system
+// ID, if set, sorted by type and system ID.
+func storageRows(storageType, systemID string, allStorage map[string]interface{}) []storageRow {
+	var rows []storageRow
+	for t, v := range allStorage {
+		if storageType != "" && t != storageType {
+			continue
+		}
+		systems, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for id, system := range systems {
+			if systemID != "" && id != systemID {
+				continue
+			}
+			s, _ := system.(map[string]interface{})
+			rows = append(rows, storageRow{storageType: t, systemID: id, system: s})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].storageType != rows[j].storageType {
+			return rows[i].storageType < rows[j].storageType
+		}
+		return rows[i].systemID < rows[j].systemID
+	})
+	return rows
+}
+
+// writeStorageTable writes the rows as a table. With status, keyed by type
+// and system ID, the table is wide and has the health of each system.
+func writeStorageTable(w io.Writer, rows []storageRow, status map[string]string, showCredentials bool) error {
+	header := []string{"TYPE", "SYSTEM ID", "ENDPOINT", "USER"}
+	if showCredentials {
+		header = append(header, "PASSWORD")
+	}
+	if status != nil {
+		header = append(header, "INSECURE", "STATUS")
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, r := range rows {
+		field := func(k string) string {
+			if v, ok := r.system[k]; ok && v != nil {
+				return fmt.Sprint(v)
+			}
+			return ""
+		}
+		cols := []string{r.storageType, r.systemID, field("Endpoint"), field("User")}
+		if showCredentials {
+			cols = append(cols, field("Password"))
+		}
+		if status != nil {
+			s, ok := status[healthKey(r.storageType, r.systemID)]
+			if !ok {
+				s = "unknown"
+			}
+			cols = append(cols, field("Insecure"), s)
+		}
+		fmt.Fprintln(tw, strings.Join(cols, "\t"))
+	}
+	return tw.Flush()
+}
+
+func healthKey(storageType, systemID string) string {
+	return storageType + ":" + systemID
+}
+
 func scrubPasswords(b []byte) ([]byte, error) {
 	m := make(map[string]interface{})
 	err := yaml.Unmarshal(b, &m)
@@ -188,3 +307,34 @@ func doStorageListRequest(ctx context.Context, addr string, insecure bool, cmd *
 
 	return list.Storage, nil
 }
+
+// doStorageStatusRequest returns the health status of each storage system,
+// keyed by type and system ID. The status is empty when it cannot be
+// requested, e.g. when health checks are disabled, so that every system is
+// listed with an unknown status.
+func doStorageStatusRequest(ctx context.Context, addr string, insecure bool, adminTknBody token.AdminToken) map[string]string {
+	status := make(map[string]string)
+	client, err := CreateHTTPClient(fmt.Sprintf("https://%s", addr), insecure)
+	if err != nil {
+		return status
+	}
+
+	// The status is in the protobuf JSON format, which has 64-bit
+	// integers as strings.
+	var resp json.RawMessage
+	err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+		return client.Get(ctx, "/proxy/storage/status/", headers, nil, &resp)
+	})
+	if err != nil {
+		return status
+	}
+
+	var statusResp pb.StorageStatusResponse
+	if err := protojson.Unmarshal(resp, &statusResp); err != nil {
+		return status
+	}
+	for _, s := range statusResp.Systems {
+		status[healthKey(s.StorageType, s.SystemId)] = s.Status
+	}
+	return status
+}
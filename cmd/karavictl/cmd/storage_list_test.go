@@ -22,8 +22,11 @@ import (
 	"fmt"
 	"karavi-authorization/cmd/karavictl/cmd/api"
 	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/pb"
 	"net/url"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -143,3 +146,87 @@ func TestStorageListHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestStorageListOutput(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	storage := `{"powerflex":{"11e4e7d35817bd0f":{"User":"admin","Password":"test","Endpoint":"https://10.0.0.1","Insecure":false}},
+		"powermax":{"000197900714":{"User":"smc","Password":"secret","Endpoint":"https://10.0.0.2","Insecure":true}}}`
+	setup := func(t *testing.T) {
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				GetFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, resp interface{}) error {
+					if path == "/proxy/storage/status/" {
+						b := []byte(`{"systems": [{"storageType": "powerflex", "systemId": "11e4e7d35817bd0f", "status": "reachable", "lastCheckTime": "1700000000"}]}`)
+						return json.Unmarshal(b, resp)
+					}
+					b, err := json.Marshal(&pb.StorageListResponse{Storage: []byte(storage)})
+					if err != nil {
+						t.Fatal(err)
+					}
+					return json.Unmarshal(b, resp)
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+	}
+	run := func(t *testing.T, args ...string) string {
+		var gotOutput bytes.Buffer
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs(append([]string{"storage", "list", "--admin-token", "admin.yaml", "--addr", "proxy.com"}, args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		return gotOutput.String()
+	}
+
+	t.Run("it redacts passwords and filters by system id", func(t *testing.T) {
+		defer afterFn()
+		setup(t)
+
+		var got map[string]map[string]System
+		if err := json.Unmarshal([]byte(run(t, "--system-id", "000197900714")), &got); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]map[string]System{
+			"powermax": {"000197900714": {User: "smc", Password: "(omitted)", Endpoint: "https://10.0.0.2", Insecure: true}},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("it shows credentials when asked", func(t *testing.T) {
+		defer afterFn()
+		setup(t)
+
+		var got map[string]System
+		if err := json.Unmarshal([]byte(run(t, "--type", "powerflex", "--show-credentials")), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got["11e4e7d35817bd0f"].Password != "test" {
+			t.Errorf("got %+v, want the password", got)
+		}
+	})
+
+	t.Run("it writes a wide table with the health of each system", func(t *testing.T) {
+		defer afterFn()
+		setup(t)
+
+		got := strings.Fields(run(t, "-o", "wide"))
+		want := strings.Fields(`TYPE SYSTEM ID ENDPOINT USER INSECURE STATUS
+			powerflex 11e4e7d35817bd0f https://10.0.0.1 admin false reachable
+			powermax 000197900714 https://10.0.0.2 smc true unknown`)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// NewStoragePauseCmd creates a new command to pause a storage system for maintenance
+func NewStoragePauseCmd() *cobra.Command {
+	pauseCmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Pause a storage system for maintenance",
+		Long: `Pauses a registered storage system for maintenance. The proxy refuses the requests
+that create, delete or map volumes on it with a retryable error until it is resumed,
+while reads are still forwarded, so that the CSI drivers retry them afterwards.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			body, err := pauseSystemBody(cmd)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			body.Reason, err = cmd.Flags().GetString("reason")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			retryAfter, err := cmd.Flags().GetDuration("retry-after")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			body.RetryAfter = proxy.Duration(retryAfter)
+
+			client, adminTknBody := policyClient(cmd)
+			err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Post(ctx, "/proxy/storage/pause/", headers, nil, &body, nil)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("pausing system %s: %w", body.SystemID, err))
+			}
+		},
+	}
+
+	pauseCmd.Flags().StringP("type", "t", "powerflex", "Type of storage system")
+	pauseCmd.Flags().StringP("system-id", "s", "", "System identifier")
+	pauseCmd.Flags().String("reason", "", "Reason of the pause, returned with the refused requests")
+	pauseCmd.Flags().Duration("retry-after", 0, "How long clients are told to wait before they retry, e.g. 5m; defaults to the proxy-server configuration")
+	return pauseCmd
+}
+
+// pauseSystemBody returns the body of a request to pause or resume the
+// storage system given by the flags.
+func pauseSystemBody(cmd *cobra.Command) (proxy.PauseSystemBody, error) {
+	var body proxy.PauseSystemBody
+	for _, f := range []struct {
+		name string
+		v    *string
+	}{
+		{"type", &body.StorageType},
+		{"system-id", &body.SystemID},
+	} {
+		v, err := cmd.Flags().GetString(f.name)
+		if err != nil {
+			return body, err
+		}
+		if strings.TrimSpace(v) == "" {
+			return body, errors.New("empty " + f.name + " not allowed")
+		}
+		*f.v = v
+	}
+	return body, nil
+}
@@ -0,0 +1,110 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStoragePause(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	t.Run("it pauses a storage system", func(t *testing.T) {
+		defer afterFn()
+		var gotPath string
+		var gotBody proxy.PauseSystemBody
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				PostFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, _ interface{}) error {
+					gotPath = path
+					gotBody = *body.(*proxy.PauseSystemBody)
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+
+		cmd := NewRootCmd()
+		cmd.SetArgs([]string{"storage", "pause", "--system-id", "542a2d5f5122210f", "--reason", "firmware upgrade", "--retry-after", "5m",
+			"--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		if want := "/proxy/storage/pause/"; gotPath != want {
+			t.Errorf("got path %q, want %q", gotPath, want)
+		}
+		want := proxy.PauseSystemBody{StorageType: "powerflex", SystemID: "542a2d5f5122210f", Reason: "firmware upgrade", RetryAfter: proxy.Duration(5 * time.Minute)}
+		if gotBody != want {
+			t.Errorf("got body %v, want %v", gotBody, want)
+		}
+	})
+	t.Run("it requires a system id", func(t *testing.T) {
+		defer afterFn()
+		var posted bool
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				PostFn: func(_ context.Context, _ string, _ map[string]string, _ url.Values, _, _ interface{}) error {
+					posted = true
+					return nil
+				},
+			}, nil
+		}
+		var gotCode int
+		done := make(chan struct{})
+		osExit = func(code int) {
+			gotCode = code
+			done <- struct{}{}
+			done <- struct{}{} // we can't let this function return
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetErr(&gotOutput)
+		cmd.SetArgs([]string{"storage", "pause", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		go cmd.Execute()
+		<-done
+
+		if gotCode != 1 {
+			t.Errorf("got exit code %d, want 1", gotCode)
+		}
+		var gotErr CommandError
+		if err := json.NewDecoder(&gotOutput).Decode(&gotErr); err != nil {
+			t.Fatal(err)
+		}
+		if want := "empty system-id not allowed"; gotErr.ErrorMsg != want {
+			t.Errorf("got err %q, want %q", gotErr.ErrorMsg, want)
+		}
+		if posted {
+			t.Error("expected the system not to be paused")
+		}
+	})
+}
@@ -0,0 +1,67 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestStorageResume(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	t.Run("it resumes a storage system", func(t *testing.T) {
+		defer afterFn()
+		var gotPath string
+		var gotBody proxy.PauseSystemBody
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				PostFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, _ interface{}) error {
+					gotPath = path
+					gotBody = *body.(*proxy.PauseSystemBody)
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+
+		cmd := NewRootCmd()
+		cmd.SetArgs([]string{"storage", "resume", "--type", "powermax", "--system-id", "000197900046",
+			"--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		if want := "/proxy/storage/resume/"; gotPath != want {
+			t.Errorf("got path %q, want %q", gotPath, want)
+		}
+		want := proxy.PauseSystemBody{StorageType: "powermax", SystemID: "000197900046"}
+		if gotBody != want {
+			t.Errorf("got body %v, want %v", gotBody, want)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/pb"
+	"net/url"
+	"os"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestStorageStatus(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	t.Run("it shows the status of storage systems", func(t *testing.T) {
+		defer afterFn()
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				GetFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, resp interface{}) error {
+					if path != "/proxy/storage/status/" {
+						t.Errorf("got path %q, want %q", path, "/proxy/storage/status/")
+					}
+					b := []byte(`{"systems": [{"storageType": "powerflex", "systemId": "542a2d5f5122210f", "status": "auth-failed", "error": "bad credentials", "lastCheckTime": "1700000000"}]}`)
+					return json.Unmarshal(b, resp)
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs([]string{"storage", "status", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		var got pb.StorageStatusResponse
+		if err := protojson.Unmarshal(gotOutput.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Systems) != 1 || got.Systems[0].Status != "auth-failed" || got.Systems[0].LastCheckTime != 1700000000 {
+			t.Errorf("got %v, want the storage status", &got)
+		}
+	})
+}
@@ -22,6 +22,8 @@ import (
 	"io"
 	"karavi-authorization/cmd/karavictl/cmd/api"
 	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/web"
+	"net/http"
 	"net/url"
 	"os"
 	"testing"
@@ -139,4 +141,39 @@ func TestStorageUpdateHandler(t *testing.T) {
 			t.Errorf("got err %q, want %q", gotErr.ErrorMsg, wantErrMsg)
 		}
 	})
+	t.Run("it reports concurrent updates", func(t *testing.T) {
+		defer afterFn()
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				PatchFn: func(_ context.Context, _ string, _ map[string]string, _ url.Values, _ interface{}, _ interface{}) error {
+					return web.JSONError{ErrorMsg: "the configuration was changed by another update at the same time", Code: http.StatusConflict, Reason: web.CodeConcurrentUpdate}
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		done := make(chan struct{})
+		osExit = func(_ int) {
+			done <- struct{}{}
+			done <- struct{}{} // we can't let this function return
+		}
+		var gotOutput bytes.Buffer
+
+		rootCmd := NewRootCmd()
+		rootCmd.SetErr(&gotOutput)
+		rootCmd.SetArgs([]string{"storage", "update", "--type=powerflex", "--insecure", "--endpoint=https://10.0.0.1", "--system-id=542a2d5f5122210f", "--user=admin", "--password=test", "--array-insecure", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+
+		go rootCmd.Execute()
+		<-done
+
+		var gotErr CommandError
+		if err := json.NewDecoder(&gotOutput).Decode(&gotErr); err != nil {
+			t.Fatal(err)
+		}
+		wantErrMsg := "the configuration was changed by another request at the same time; run the command again"
+		if gotErr.ErrorMsg != wantErrMsg {
+			t.Errorf("got err %q, want %q", gotErr.ErrorMsg, wantErrMsg)
+		}
+	})
 }
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"karavi-authorization/pb"
 	"log"
 	"os"
 
@@ -55,18 +56,26 @@ func NewTenantCmd() *cobra.Command {
 		reportErrorAndExit(JSONOutput, tenantCmd.ErrOrStderr(), err)
 	}
 
+	tenantCmd.AddCommand(NewTenantActivityCmd())
 	tenantCmd.AddCommand(NewTenantCreateCmd())
+	tenantCmd.AddCommand(NewTenantDefaultRoleCmd())
 	tenantCmd.AddCommand(NewTenantDeleteCmd())
 	tenantCmd.AddCommand(NewTenantGetCmd())
 	tenantCmd.AddCommand(NewTenantListCmd())
+	tenantCmd.AddCommand(NewTenantQuotaCmd())
+	tenantCmd.AddCommand(NewTenantRestoreCmd())
 	tenantCmd.AddCommand(NewTenantRevokeCmd())
+	tenantCmd.AddCommand(NewTenantRotateTokensCmd())
+	tenantCmd.AddCommand(NewTenantSdcCmd())
 	tenantCmd.AddCommand(NewTenantUpdateCmd())
 	return tenantCmd
 }
 
-// CommandError wraps errors for reporting.
+// CommandError wraps errors for reporting. Code is the stable code of an
+// error of a service, for scripts to check rather than the message.
 type CommandError struct {
 	ErrorMsg string
+	Code     string `json:",omitempty"`
 }
 
 // ErrorReporter represents a reporting function that can report in a specific format.
@@ -74,6 +83,12 @@ type ErrorReporter func(io.Writer, interface{}) error
 
 func reportErrorAndExit(er ErrorReporter, w io.Writer, err error) {
 	v := &CommandError{ErrorMsg: err.Error()}
+	if code, params := errorCode(err); code != pb.ErrorCode_ERROR_CODE_UNSPECIFIED {
+		v.Code = code.String()
+		if msg, ok := errorMessages[code]; ok {
+			v.ErrorMsg = msg(params)
+		}
+	}
 	reporterErr := er(w, v)
 	if reporterErr != nil {
 		log.Fatal(reporterErr)
@@ -0,0 +1,77 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"karavi-authorization/pb"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewTenantActivityCmd creates a new command for the activity of tenants
+func NewTenantActivityCmd() *cobra.Command {
+	tenantActivityCmd := &cobra.Command{
+		Use:   "activity",
+		Short: "Show the activity of tenants",
+		Long: `Shows the number of volumes each tenant has created, deleted and mapped, its
+denied requests, its token refreshes and the time it was last active, in seconds
+since the Unix epoch. Without --name, shows every tenant.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			name, err := cmd.Flags().GetString("name")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			client, adminTknBody := policyClient(cmd)
+
+			// The activity is in the protobuf JSON format, which has 64-bit
+			// integers as strings.
+			var resp json.RawMessage
+			query := url.Values{}
+			if name = strings.TrimSpace(name); name != "" {
+				query.Set("name", name)
+			}
+			err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Get(ctx, "/proxy/tenant/activity/", headers, query, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			var activity pb.GetActivityResponse
+			err = protojson.Unmarshal(resp, &activity)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("decoding tenant activity: %w", err))
+			}
+
+			err = jsonOutputEmitEmpty(cmd.OutOrStdout(), &activity)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+
+	tenantActivityCmd.Flags().StringP("name", "n", "", "Tenant name")
+	return tenantActivityCmd
+}
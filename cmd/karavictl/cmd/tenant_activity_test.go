@@ -0,0 +1,103 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/pb"
+	"net/url"
+	"os"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestTenantActivity(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	t.Run("it shows the activity of a tenant", func(t *testing.T) {
+		defer afterFn()
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				GetFn: func(_ context.Context, path string, _ map[string]string, query url.Values, resp interface{}) error {
+					if path != "/proxy/tenant/activity/" || query.Get("name") != "testname" {
+						t.Errorf("got path %q query %v", path, query)
+					}
+					b := []byte(`{"tenants": [{"name": "testname", "creates": "3", "maps": "2", "refreshes": "5", "lastActivity": "1700000000"}]}`)
+					return json.Unmarshal(b, resp)
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs([]string{"tenant", "activity", "-n", "testname", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		var got pb.GetActivityResponse
+		if err := protojson.Unmarshal(gotOutput.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Tenants) != 1 || got.Tenants[0].Creates != 3 || got.Tenants[0].LastActivity != 1700000000 {
+			t.Errorf("got %v, want the activity of testname", &got)
+		}
+	})
+
+	t.Run("it shows the activity of every tenant", func(t *testing.T) {
+		defer afterFn()
+		var gotQuery url.Values
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				GetFn: func(_ context.Context, _ string, _ map[string]string, query url.Values, resp interface{}) error {
+					gotQuery = query
+					return json.Unmarshal([]byte(`{"tenants": [{"name": "a"}, {"name": "b"}]}`), resp)
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs([]string{"tenant", "activity", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		if gotQuery.Has("name") {
+			t.Errorf("got query %v, want no tenant name", gotQuery)
+		}
+		var got pb.GetActivityResponse
+		if err := protojson.Unmarshal(gotOutput.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Tenants) != 2 {
+			t.Errorf("got %v, want the activity of two tenants", &got)
+		}
+	})
+}
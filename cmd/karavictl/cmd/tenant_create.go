@@ -59,14 +59,35 @@ func NewTenantCreateCmd() *cobra.Command {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
 			}
 
+			organization, err := cmd.Flags().GetString("organization")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			maxVolumes, err := cmd.Flags().GetInt64("max-volumes")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if maxVolumes < 0 {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("max volumes must not be negative"))
+			}
+
+			noDefaultRole, err := cmd.Flags().GetBool("no-default-role")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
 			client, err := CreateHTTPClient(fmt.Sprintf("https://%s", addr), insecure)
 			if err != nil {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
 			}
 
 			body := proxy.CreateTenantBody{
-				Tenant:     name,
-				ApproveSdc: approveSdc,
+				Tenant:        name,
+				ApproveSdc:    approveSdc,
+				Organization:  organization,
+				MaxVolumes:    maxVolumes,
+				NoDefaultRole: noDefaultRole,
 			}
 
 			admTknFile, err := cmd.Flags().GetString("admin-token")
@@ -120,5 +141,8 @@ func NewTenantCreateCmd() *cobra.Command {
 
 	tenantCreateCmd.Flags().StringP("name", "n", "", "Tenant name")
 	tenantCreateCmd.Flags().BoolP("approvesdc", "a", true, "To allow/deny SDC approval requests")
+	tenantCreateCmd.Flags().String("organization", "", "Organization of the tenant")
+	tenantCreateCmd.Flags().Int64("max-volumes", 0, "Maximum number of volumes the tenant may have in each storage pool; 0 is no maximum")
+	tenantCreateCmd.Flags().Bool("no-default-role", false, "Do not bind the default role to the tenant")
 	return tenantCreateCmd
 }
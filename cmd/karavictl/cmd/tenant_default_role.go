@@ -0,0 +1,72 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"karavi-authorization/internal/proxy"
+
+	"github.com/spf13/cobra"
+)
+
+// NewTenantDefaultRoleCmd creates a new command for the default role of tenants
+func NewTenantDefaultRoleCmd() *cobra.Command {
+	tenantDefaultRoleCmd := &cobra.Command{
+		Use:   "default-role",
+		Short: "Show or set the role bound to new tenants",
+		Long: `Shows the role that is bound to tenants when they are created. With --role, first
+sets or replaces the default role; an empty role removes it. Tenants that already
+exist are not changed.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			client, adminTknBody := policyClient(cmd)
+
+			if cmd.Flags().Changed("role") {
+				role, err := cmd.Flags().GetString("role")
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+
+				body := proxy.DefaultRoleBody{
+					Role: role,
+				}
+				err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+					return client.Patch(ctx, "/proxy/tenant/default-role/", headers, nil, &body, nil)
+				})
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+			}
+
+			var resp proxy.DefaultRoleBody
+			err := doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Get(ctx, "/proxy/tenant/default-role/", headers, nil, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			err = JSONOutput(cmd.OutOrStdout(), &resp)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+
+	tenantDefaultRoleCmd.Flags().String("role", "", "Role to bind to new tenants; empty removes the default role")
+	return tenantDefaultRoleCmd
+}
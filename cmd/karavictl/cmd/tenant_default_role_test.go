@@ -0,0 +1,105 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestTenantDefaultRole(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	var gotBody *proxy.DefaultRoleBody
+	setup := func() {
+		gotBody = nil
+		defaultRole := "role-1"
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				GetFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, resp interface{}) error {
+					if path != "/proxy/tenant/default-role/" {
+						t.Errorf("got path %q, want %q", path, "/proxy/tenant/default-role/")
+					}
+					resp.(*proxy.DefaultRoleBody).Role = defaultRole
+					return nil
+				},
+				PatchFn: func(_ context.Context, _ string, _ map[string]string, _ url.Values, body, _ interface{}) error {
+					gotBody = body.(*proxy.DefaultRoleBody)
+					defaultRole = gotBody.Role
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+	}
+
+	t.Run("it shows the default role", func(t *testing.T) {
+		defer afterFn()
+		setup()
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs([]string{"tenant", "default-role", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		if gotBody != nil {
+			t.Error("expected the default role not to be set")
+		}
+		var got proxy.DefaultRoleBody
+		if err := json.Unmarshal(gotOutput.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Role != "role-1" {
+			t.Errorf("got role %q, want %q", got.Role, "role-1")
+		}
+	})
+
+	t.Run("it removes the default role", func(t *testing.T) {
+		defer afterFn()
+		setup()
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs([]string{"tenant", "default-role", "--role", "", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		if gotBody == nil || gotBody.Role != "" {
+			t.Errorf("got body %v, want the default role removed", gotBody)
+		}
+		var got proxy.DefaultRoleBody
+		if err := json.Unmarshal(gotOutput.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Role != "" {
+			t.Errorf("got role %q, want none", got.Role)
+		}
+	})
+}
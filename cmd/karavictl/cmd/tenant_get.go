@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"karavi-authorization/internal/token"
@@ -26,6 +27,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 // NewTenantGetCmd creates a new get command
@@ -62,7 +64,9 @@ func NewTenantGetCmd() *cobra.Command {
 				"name": []string{name},
 			}
 
-			var tenant pb.Tenant
+			// The tenant is in the protobuf JSON format, which has 64-bit
+			// integers as strings.
+			var resp json.RawMessage
 
 			admTknFile, err := cmd.Flags().GetString("admin-token")
 			if err != nil {
@@ -79,7 +83,7 @@ func NewTenantGetCmd() *cobra.Command {
 			headers := make(map[string]string)
 			headers["Authorization"] = fmt.Sprintf("Bearer %s", accessToken)
 
-			err = client.Get(context.Background(), "/proxy/tenant/", headers, query, &tenant)
+			err = client.Get(context.Background(), "/proxy/tenant/", headers, query, &resp)
 			if err != nil {
 				var jsonErr web.JSONError
 				if errors.As(err, &jsonErr) {
@@ -99,7 +103,7 @@ func NewTenantGetCmd() *cobra.Command {
 
 						// retry with refresh token
 						headers["Authorization"] = fmt.Sprintf("Bearer %s", adminTknResp.AccessToken)
-						err = client.Get(context.Background(), "/proxy/tenant/", headers, query, &tenant)
+						err = client.Get(context.Background(), "/proxy/tenant/", headers, query, &resp)
 						if err != nil {
 							reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
 						}
@@ -111,6 +115,12 @@ func NewTenantGetCmd() *cobra.Command {
 				}
 			}
 
+			var tenant pb.Tenant
+			err = protojson.Unmarshal(resp, &tenant)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("decoding tenant: %w", err))
+			}
+
 			err = jsonOutputEmitEmpty(cmd.ErrOrStderr(), &tenant)
 			if err != nil {
 				reportErrorAndExit(jsonOutput, cmd.ErrOrStderr(), err)
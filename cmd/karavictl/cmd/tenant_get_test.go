@@ -25,6 +25,8 @@ import (
 	"net/url"
 	"os"
 	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 func TestTenantGet(t *testing.T) {
@@ -60,7 +62,7 @@ func TestTenantGet(t *testing.T) {
 		cmd.Execute()
 
 		var resp pb.Tenant
-		if err := json.NewDecoder(&gotOutput).Decode(&resp); err != nil {
+		if err := protojson.Unmarshal(gotOutput.Bytes(), &resp); err != nil {
 			t.Fatal(err)
 		}
 		if resp.Name != "testname" {
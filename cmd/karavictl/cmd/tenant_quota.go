@@ -0,0 +1,103 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/pb"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewTenantQuotaCmd creates a new command for the quota usage of a tenant
+func NewTenantQuotaCmd() *cobra.Command {
+	tenantQuotaCmd := &cobra.Command{
+		Use:   "quota",
+		Short: "Show or limit the quota usage of a tenant",
+		Long: `Shows the capacity, in kilobytes, and the number of volumes approved for a tenant
+in each storage pool. With --max-volumes, first sets the maximum number of volumes
+the tenant may have in each storage pool; 0 removes the maximum.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			name, err := cmd.Flags().GetString("name")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if strings.TrimSpace(name) == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("empty name not allowed"))
+			}
+
+			client, adminTknBody := policyClient(cmd)
+
+			if cmd.Flags().Changed("max-volumes") {
+				maxVolumes, err := cmd.Flags().GetInt64("max-volumes")
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+				if maxVolumes < 0 {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("max volumes must not be negative"))
+				}
+
+				body := proxy.TenantMaxVolumesBody{
+					Tenant:     name,
+					MaxVolumes: maxVolumes,
+				}
+				err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+					return client.Patch(ctx, "/proxy/tenant/quota/", headers, nil, &body, nil)
+				})
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+			}
+
+			// The quota is in the protobuf JSON format, which has 64-bit
+			// integers as strings.
+			var resp json.RawMessage
+			query := url.Values{
+				"name": []string{name},
+			}
+			err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Get(ctx, "/proxy/tenant/quota/", headers, query, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			var quota pb.TenantQuota
+			err = protojson.Unmarshal(resp, &quota)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("decoding tenant quota: %w", err))
+			}
+
+			err = jsonOutputEmitEmpty(cmd.OutOrStdout(), &quota)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+
+	tenantQuotaCmd.Flags().StringP("name", "n", "", "Tenant name")
+	tenantQuotaCmd.Flags().Int64("max-volumes", 0, "Maximum number of volumes the tenant may have in each storage pool; 0 is no maximum")
+	return tenantQuotaCmd
+}
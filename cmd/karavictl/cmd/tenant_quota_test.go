@@ -0,0 +1,111 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/pb"
+	"net/url"
+	"os"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestTenantQuota(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	t.Run("it shows the quota usage of a tenant", func(t *testing.T) {
+		defer afterFn()
+		var patched bool
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				GetFn: func(_ context.Context, path string, _ map[string]string, query url.Values, resp interface{}) error {
+					if path != "/proxy/tenant/quota/" || query.Get("name") != "testname" {
+						t.Errorf("got path %q query %v", path, query)
+					}
+					b := []byte(`{"name": "testname", "maxVolumes": "10", "pools": [{"systemType": "powerflex", "systemId": "542a2d5f5122210f", "pool": "bronze", "approvedVolumes": "2"}]}`)
+					return json.Unmarshal(b, resp)
+				},
+				PatchFn: func(_ context.Context, _ string, _ map[string]string, _ url.Values, _, _ interface{}) error {
+					patched = true
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs([]string{"tenant", "quota", "-n", "testname", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		if patched {
+			t.Error("expected the max volumes not to be set")
+		}
+		var got pb.TenantQuota
+		if err := protojson.Unmarshal(gotOutput.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.MaxVolumes != 10 || len(got.Pools) != 1 || got.Pools[0].ApprovedVolumes != 2 {
+			t.Errorf("got %v, want the quota usage", &got)
+		}
+	})
+
+	t.Run("it sets the max volumes", func(t *testing.T) {
+		defer afterFn()
+		var gotBody proxy.TenantMaxVolumesBody
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				GetFn: func(_ context.Context, _ string, _ map[string]string, _ url.Values, resp interface{}) error {
+					return json.Unmarshal([]byte(`{"name": "testname"}`), resp)
+				},
+				PatchFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, _ interface{}) error {
+					if path != "/proxy/tenant/quota/" {
+						t.Errorf("got path %q, want %q", path, "/proxy/tenant/quota/")
+					}
+					gotBody = *body.(*proxy.TenantMaxVolumesBody)
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+
+		cmd := NewRootCmd()
+		cmd.SetOutput(&bytes.Buffer{})
+		cmd.SetArgs([]string{"tenant", "quota", "-n", "testname", "--max-volumes", "10", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		want := proxy.TenantMaxVolumesBody{Tenant: "testname", MaxVolumes: 10}
+		if gotBody != want {
+			t.Errorf("got body %v, want %v", gotBody, want)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"karavi-authorization/internal/proxy"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// NewTenantRestoreCmd creates a new restore command for tenant
+func NewTenantRestoreCmd() *cobra.Command {
+	tenantRestoreCmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore a deleted tenant resource within CSM Authorization",
+		Long: `Restores a deleted tenant resource within CSM Authorization, with its role
+bindings and quota data. Tenants can be restored until the delete retention of
+the tenant-service has passed.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			name, err := cmd.Flags().GetString("name")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if strings.TrimSpace(name) == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("empty name not allowed"))
+			}
+
+			client, adminTknBody := policyClient(cmd)
+
+			body := proxy.RestoreTenantBody{
+				Tenant: name,
+			}
+			err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Post(ctx, "/proxy/tenant/restore/", headers, nil, &body, nil)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+
+	tenantRestoreCmd.Flags().StringP("name", "n", "", "Tenant name")
+	return tenantRestoreCmd
+}
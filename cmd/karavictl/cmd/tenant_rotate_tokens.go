@@ -0,0 +1,182 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/pb"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// NewTenantRotateTokensCmd creates a new command to rotate the tokens of tenants
+func NewTenantRotateTokensCmd() *cobra.Command {
+	rotateTokensCmd := &cobra.Command{
+		Use:   "rotate-tokens",
+		Short: "Rotate the tokens of tenants",
+		Long: `Generates new tokens for the tenant, or for every tenant with --all, and refuses to
+refresh the tokens issued to them before, e.g. after the signing secret was
+compromised. The proxy-authz-tokens secret of each tenant is output, in the namespace
+given by --namespace or --tenant-namespace, or applied with kubectl with --apply.
+Access tokens issued before expire on their own.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			tenant, err := cmd.Flags().GetString("name")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			all, err := cmd.Flags().GetBool("all")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if (tenant == "") == !all {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("specify either a tenant name or --all"))
+			}
+
+			refExpTime, err := cmd.Flags().GetDuration("refresh-token-expiration")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			accExpTime, err := cmd.Flags().GetDuration("access-token-expiration")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			namespace, err := cmd.Flags().GetString("namespace")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			tenantNamespaces, err := cmd.Flags().GetStringToString("tenant-namespace")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			apply, err := cmd.Flags().GetBool("apply")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			client, adminTknBody := policyClient(cmd)
+
+			tenants := []string{tenant}
+			if all {
+				var list pb.ListTenantResponse
+				err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+					return client.Get(ctx, "/proxy/tenant/", headers, nil, &list)
+				})
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+				tenants = tenants[:0]
+				for _, t := range list.Tenants {
+					tenants = append(tenants, t.Name)
+				}
+				sort.Strings(tenants)
+			}
+
+			var secrets []string
+			for _, name := range tenants {
+				body := proxy.GenerateTokenBody{
+					Tenant:          name,
+					AccessTokenTTL:  accExpTime.String(),
+					RefreshTokenTTL: refExpTime.String(),
+					RevokePrevious:  true,
+				}
+				var resp pb.GenerateTokenResponse
+				err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+					return client.Post(ctx, "/proxy/tenant/token", headers, nil, &body, &resp)
+				})
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("rotating the tokens of tenant %s: %w", name, err))
+				}
+
+				ns := namespace
+				if v, ok := tenantNamespaces[name]; ok {
+					ns = v
+				}
+				secret, err := tokenSecretInNamespace(resp.Token, name, ns)
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+
+				if !apply {
+					secrets = append(secrets, secret)
+					continue
+				}
+				kubectl := execCommandContext(ctx, "kubectl", "apply", "-f", "-")
+				kubectl.Stdin = strings.NewReader(secret)
+				kubectl.Stdout = cmd.OutOrStdout()
+				kubectl.Stderr = cmd.ErrOrStderr()
+				err = kubectl.Run()
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("applying secret of tenant %s: %w", name, err))
+				}
+			}
+
+			if !apply {
+				err = Output(cmd.OutOrStdout(), strings.Join(secrets, "---\n"))
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+			}
+		},
+	}
+
+	rotateTokensCmd.Flags().StringP("name", "n", "", "Tenant name")
+	rotateTokensCmd.Flags().Bool("all", false, "Rotate the tokens of every tenant")
+	rotateTokensCmd.Flags().Duration("refresh-token-expiration", 30*24*time.Hour, "Expiration time of the refresh tokens, e.g. 48h")
+	rotateTokensCmd.Flags().Duration("access-token-expiration", time.Minute, "Expiration time of the access tokens, e.g. 1m30s")
+	rotateTokensCmd.Flags().String("namespace", "", "Namespace of the secrets")
+	rotateTokensCmd.Flags().StringToString("tenant-namespace", nil, "Namespace of the secret of a tenant, as tenant=namespace; overrides --namespace")
+	rotateTokensCmd.Flags().Bool("apply", false, "Apply the secrets with kubectl instead of outputting them")
+	return rotateTokensCmd
+}
+
+// tokenSecretInNamespace returns the token secret of the tenant in the
+// namespace, if set, labelled with the tenant.
+func tokenSecretInNamespace(secret, tenant, namespace string) (string, error) {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(secret), &m); err != nil {
+		return "", fmt.Errorf("decoding token secret of tenant %s: %w", tenant, err)
+	}
+
+	metadata, _ := m["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+		m["metadata"] = metadata
+	}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	labels, _ := metadata["labels"].(map[string]interface{})
+	if labels == nil {
+		labels = make(map[string]interface{})
+		metadata["labels"] = labels
+	}
+	labels["csm-authorization/tenant"] = tenant
+
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,144 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/pb"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestTenantRotateTokens(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+		execCommandContext = exec.CommandContext
+	}
+
+	var gotBodies []proxy.GenerateTokenBody
+	setup := func() {
+		gotBodies = nil
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				GetFn: func(_ context.Context, _ string, _ map[string]string, _ url.Values, resp interface{}) error {
+					*resp.(*pb.ListTenantResponse) = pb.ListTenantResponse{Tenants: []*pb.Tenant{{Name: "team-b"}, {Name: "team-a"}}}
+					return nil
+				},
+				PostFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, resp interface{}) error {
+					if path != "/proxy/tenant/token" {
+						t.Errorf("got path %q, want %q", path, "/proxy/tenant/token")
+					}
+					gotBodies = append(gotBodies, *body.(*proxy.GenerateTokenBody))
+					resp.(*pb.GenerateTokenResponse).Token = loginTestSecret
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+	}
+
+	t.Run("it rotates the tokens of every tenant", func(t *testing.T) {
+		defer afterFn()
+		setup()
+
+		var gotOutput bytes.Buffer
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs([]string{"tenant", "rotate-tokens", "--all", "--namespace", "vxflexos", "--tenant-namespace", "team-b=isilon",
+			"--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		var gotTenants []string
+		for _, b := range gotBodies {
+			if !b.RevokePrevious {
+				t.Errorf("got %+v, want the previous tokens revoked", b)
+			}
+			gotTenants = append(gotTenants, b.Tenant)
+		}
+		if want := []string{"team-a", "team-b"}; !reflect.DeepEqual(gotTenants, want) {
+			t.Errorf("got tenants %v, want %v", gotTenants, want)
+		}
+
+		docs := strings.Split(gotOutput.String(), "---\n")
+		if len(docs) != 2 {
+			t.Fatalf("got %d secrets, want 2", len(docs))
+		}
+		for i, want := range []struct{ tenant, namespace string }{{"team-a", "vxflexos"}, {"team-b", "isilon"}} {
+			var secret struct {
+				Metadata struct {
+					Name      string            `json:"name"`
+					Namespace string            `json:"namespace"`
+					Labels    map[string]string `json:"labels"`
+				} `json:"metadata"`
+			}
+			if err := yaml.Unmarshal([]byte(docs[i]), &secret); err != nil {
+				t.Fatal(err)
+			}
+			if secret.Metadata.Name != "proxy-authz-tokens" || secret.Metadata.Namespace != want.namespace || secret.Metadata.Labels["csm-authorization/tenant"] != want.tenant {
+				t.Errorf("got secret %+v, want the secret of %s in %s", secret.Metadata, want.tenant, want.namespace)
+			}
+		}
+	})
+
+	t.Run("it applies the secret of a tenant", func(t *testing.T) {
+		defer afterFn()
+		setup()
+		applied := filepath.Join(t.TempDir(), "applied.yaml")
+		var gotArgs []string
+		execCommandContext = func(ctx context.Context, _ string, args ...string) *exec.Cmd {
+			gotArgs = args
+			return exec.CommandContext(ctx, "sh", "-c", "cat > "+applied)
+		}
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetArgs([]string{"tenant", "rotate-tokens", "--name", "team-a", "--apply", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(gotBodies) != 1 || gotBodies[0].Tenant != "team-a" {
+			t.Errorf("got %+v, want the tokens of team-a rotated", gotBodies)
+		}
+		b, err := os.ReadFile(applied)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), "csm-authorization/tenant: team-a") {
+			t.Errorf("got %q, want the secret of team-a applied", b)
+		}
+		if want := []string{"apply", "-f", "-"}; !reflect.DeepEqual(gotArgs, want) {
+			t.Errorf("got args %v, want %v", gotArgs, want)
+		}
+	})
+}
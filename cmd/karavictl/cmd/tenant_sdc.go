@@ -0,0 +1,154 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// NewTenantSdcCmd creates a new command for the SDCs a tenant may map
+// volumes to.
+func NewTenantSdcCmd() *cobra.Command {
+	tenantSdcCmd := &cobra.Command{
+		Use:              "sdc",
+		TraverseChildren: true,
+		Short:            "Manage the SDCs a tenant may map volumes to",
+		Long: `Manages the SDCs, by GUID or IP, that volumes of a tenant may be mapped to.
+NVMe/TCP hosts of PowerFlex 4.x are managed the same way, by NQN.
+Volumes may be mapped to any SDC or host until a tenant allows some in particular.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := cmd.Usage(); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %+v\n", err)
+			}
+			os.Exit(1)
+		},
+	}
+
+	tenantSdcCmd.AddCommand(newTenantSdcUpdateCmd("allow", "Allow mapping volumes of a tenant to SDCs", "/proxy/tenant/sdc/allow/"))
+	tenantSdcCmd.AddCommand(newTenantSdcUpdateCmd("disallow", "Disallow mapping volumes of a tenant to SDCs", "/proxy/tenant/sdc/disallow/"))
+	return tenantSdcCmd
+}
+
+func newTenantSdcUpdateCmd(use, short, path string) *cobra.Command {
+	tenantSdcUpdateCmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Long:  short,
+		Run: func(cmd *cobra.Command, _ []string) {
+			addr, err := cmd.Flags().GetString("addr")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			insecure, err := cmd.Flags().GetBool("insecure")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			name, err := cmd.Flags().GetString("name")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if strings.TrimSpace(name) == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("empty name not allowed"))
+			}
+
+			sdcs, err := cmd.Flags().GetStringSlice("sdc")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if len(sdcs) == 0 {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("specify at least one sdc"))
+			}
+
+			client, err := CreateHTTPClient(fmt.Sprintf("https://%s", addr), insecure)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			body := proxy.TenantSdcBody{
+				Tenant: name,
+				Sdcs:   sdcs,
+			}
+			var resp proxy.TenantSdcBody
+
+			admTknFile, err := cmd.Flags().GetString("admin-token")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if admTknFile == "" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("specify token file"))
+			}
+			accessToken, refreshToken, err := ReadAccessAdminToken(admTknFile)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			headers := make(map[string]string)
+			headers["Authorization"] = fmt.Sprintf("Bearer %s", accessToken)
+			err = client.Post(context.Background(), path, headers, nil, &body, &resp)
+			if err != nil {
+				var jsonErr web.JSONError
+				if errors.As(err, &jsonErr) {
+					if jsonErr.Code == http.StatusUnauthorized {
+						// expired token, refresh admin token
+						adminTknBody := token.AdminToken{
+							Refresh: refreshToken,
+							Access:  accessToken,
+						}
+						var adminTknResp pb.RefreshAdminTokenResponse
+
+						headers["Authorization"] = fmt.Sprintf("Bearer %s", refreshToken)
+						err = client.Post(context.Background(), "/proxy/refresh-admin", headers, nil, &adminTknBody, &adminTknResp)
+						if err != nil {
+							reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+						}
+
+						// retry with refresh token
+						headers["Authorization"] = fmt.Sprintf("Bearer %s", adminTknResp.AccessToken)
+						err = client.Post(context.Background(), path, headers, nil, &body, &resp)
+						if err != nil {
+							reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+						}
+					} else {
+						reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+					}
+				} else {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+			}
+
+			err = JSONOutput(cmd.OutOrStdout(), &resp)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+
+	tenantSdcUpdateCmd.Flags().StringP("name", "n", "", "Tenant name")
+	tenantSdcUpdateCmd.Flags().StringSlice("sdc", nil, "SDC GUID or IP, or NVMe host NQN; may be repeated or comma separated")
+	return tenantSdcUpdateCmd
+}
@@ -0,0 +1,116 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestTenantSdc(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	tests := map[string]string{
+		"allow":    "/proxy/tenant/sdc/allow/",
+		"disallow": "/proxy/tenant/sdc/disallow/",
+	}
+	for name, wantPath := range tests {
+		t.Run("it requests to "+name+" sdcs", func(t *testing.T) {
+			defer afterFn()
+			var gotPath string
+			var gotBody proxy.TenantSdcBody
+			CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+				return &mocks.FakeClient{
+					PostFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, _ interface{}) error {
+						gotPath = path
+						gotBody = *body.(*proxy.TenantSdcBody)
+						return nil
+					},
+				}, nil
+			}
+			ReadAccessAdminToken = func(_ string) (string, string, error) {
+				return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+			}
+			JSONOutput = func(_ io.Writer, _ interface{}) error {
+				return nil
+			}
+			osExit = func(_ int) {
+			}
+
+			cmd := NewRootCmd()
+			cmd.SetOutput(&bytes.Buffer{})
+			cmd.SetArgs([]string{"tenant", "sdc", name, "-n", "testname", "--sdc", "0f8da9e8-1b0a-4c4b-b0a5-8bc6f6b1e0d1", "--sdc", "10.0.0.1", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+			cmd.Execute()
+
+			if gotPath != wantPath {
+				t.Errorf("got path %q, want %q", gotPath, wantPath)
+			}
+			want := proxy.TenantSdcBody{Tenant: "testname", Sdcs: []string{"0f8da9e8-1b0a-4c4b-b0a5-8bc6f6b1e0d1", "10.0.0.1"}}
+			if !reflect.DeepEqual(gotBody, want) {
+				t.Errorf("got body %v, want %v", gotBody, want)
+			}
+		})
+	}
+
+	t.Run("it requires an sdc", func(t *testing.T) {
+		defer afterFn()
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{}, nil
+		}
+		var gotCode int
+		done := make(chan struct{})
+		osExit = func(code int) {
+			gotCode = code
+			done <- struct{}{}
+			done <- struct{}{} // we can't let this function return
+		}
+
+		var gotOutput bytes.Buffer
+
+		rootCmd := NewRootCmd()
+		rootCmd.SetErr(&gotOutput)
+		rootCmd.SetArgs([]string{"tenant", "sdc", "allow", "-n", "testname", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+
+		go rootCmd.Execute()
+		<-done
+
+		wantCode := 1
+		if gotCode != wantCode {
+			t.Errorf("got exit code %d, want %d", gotCode, wantCode)
+		}
+		var gotErr CommandError
+		if err := json.NewDecoder(&gotOutput).Decode(&gotErr); err != nil {
+			t.Fatal(err)
+		}
+		wantErrMsg := "specify at least one sdc"
+		if gotErr.ErrorMsg != wantErrMsg {
+			t.Errorf("got err %q, want %q", gotErr.ErrorMsg, wantErrMsg)
+		}
+	})
+}
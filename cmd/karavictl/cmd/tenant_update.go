@@ -26,6 +26,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // NewTenantUpdateCmd creates a new update command for tenant
@@ -34,7 +35,19 @@ func NewTenantUpdateCmd() *cobra.Command {
 		Use:              "update",
 		TraverseChildren: true,
 		Short:            "Update a tenant resource within CSM Authorization",
-		Long:             `Updates a tenant resource within CSM Authorization`,
+		Long: `Updates a tenant resource within CSM Authorization.
+
+Claims set with --claim are embedded in the tokens of the tenant and passed
+to OPA as input.claims.custom, e.g. --claim team=payments. Tokens pick up
+changed claims as they are refreshed.
+
+Protected tenants, set with --protect, cannot be deleted until the
+protection is removed with --protect=false.
+
+Volumes the tenant creates must have names that start with the prefix set
+with --volume-prefix and match the regular expression set with
+--volume-pattern, e.g. --volume-prefix pay- --volume-pattern '^pay-[a-z0-9-]+$'.
+An empty value removes the prefix or the pattern.`,
 		Run: func(cmd *cobra.Command, _ []string) {
 			addr, err := cmd.Flags().GetString("addr")
 			if err != nil {
@@ -54,17 +67,37 @@ func NewTenantUpdateCmd() *cobra.Command {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("empty name not allowed"))
 			}
 
-			approveSdc, err := cmd.Flags().GetBool("approvesdc")
+			approveSdc, err := cmd.Flags().GetBool("approve-sdc")
 			if err != nil {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
 			}
 
+			claims, err := cmd.Flags().GetStringToString("claim")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			removeClaims, err := cmd.Flags().GetStringSlice("remove-claim")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			updateClaims := len(claims) > 0 || len(removeClaims) > 0
+			protect, err := cmd.Flags().GetBool("protect")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			updateProtect := cmd.Flags().Changed("protect")
+			volumeName, err := volumeNameBody(cmd, name)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			updateVolumeName := volumeName != nil
+
 			client, err := CreateHTTPClient(fmt.Sprintf("https://%s", addr), insecure)
 			if err != nil {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
 			}
 
-			body := proxy.CreateTenantBody{
+			body := proxy.ApproveSdcBody{
 				Tenant:     name,
 				ApproveSdc: approveSdc,
 			}
@@ -80,9 +113,60 @@ func NewTenantUpdateCmd() *cobra.Command {
 				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
 			}
 
+			if updateClaims {
+				adminTknBody := token.AdminToken{
+					Refresh: refreshToken,
+					Access:  accessToken,
+				}
+				err = doWithAdminRefresh(context.Background(), client, adminTknBody, func(headers map[string]string) error {
+					return client.Patch(context.Background(), "/proxy/tenant/claims/", headers, nil, &proxy.TenantClaimsBody{
+						Tenant: name,
+						Claims: claims,
+						Remove: removeClaims,
+					}, nil)
+				})
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+			}
+
+			if updateProtect {
+				adminTknBody := token.AdminToken{
+					Refresh: refreshToken,
+					Access:  accessToken,
+				}
+				err = doWithAdminRefresh(context.Background(), client, adminTknBody, func(headers map[string]string) error {
+					return client.Patch(context.Background(), "/proxy/tenant/protect/", headers, nil, &proxy.TenantProtectBody{
+						Tenant:    name,
+						Protected: protect,
+					}, nil)
+				})
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+			}
+
+			if updateVolumeName {
+				adminTknBody := token.AdminToken{
+					Refresh: refreshToken,
+					Access:  accessToken,
+				}
+				err = doWithAdminRefresh(context.Background(), client, adminTknBody, func(headers map[string]string) error {
+					return client.Patch(context.Background(), "/proxy/tenant/volume-name/", headers, nil, volumeName, nil)
+				})
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+			}
+
+			// the approve-sdc flag is only applied along with other updates if given
+			if (updateClaims || updateProtect || updateVolumeName) && !cmd.Flags().Changed("approve-sdc") {
+				return
+			}
+
 			headers := make(map[string]string)
 			headers["Authorization"] = fmt.Sprintf("Bearer %s", accessToken)
-			err = client.Patch(context.Background(), "/proxy/tenant/", headers, nil, body, nil)
+			err = client.Patch(context.Background(), "/proxy/tenant/approve-sdc/", headers, nil, body, nil)
 			if err != nil {
 				var jsonErr web.JSONError
 				if errors.As(err, &jsonErr) {
@@ -102,7 +186,7 @@ func NewTenantUpdateCmd() *cobra.Command {
 
 						// retry with refresh token
 						headers["Authorization"] = fmt.Sprintf("Bearer %s", adminTknResp.AccessToken)
-						err = client.Patch(context.Background(), "/proxy/tenant/", headers, nil, body, nil)
+						err = client.Patch(context.Background(), "/proxy/tenant/approve-sdc/", headers, nil, body, nil)
 						if err != nil {
 							reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
 						}
@@ -117,6 +201,49 @@ func NewTenantUpdateCmd() *cobra.Command {
 	}
 
 	tenantUpdateCmd.Flags().StringP("name", "n", "", "Tenant name")
-	tenantUpdateCmd.Flags().BoolP("approvesdc", "a", true, "To allow/deny SDC approval requests")
+	tenantUpdateCmd.Flags().BoolP("approve-sdc", "a", true, "To allow/deny SDC approval requests and mapping volumes to unapproved SDCs")
+	tenantUpdateCmd.Flags().StringToString("claim", nil, "Custom claim to set on the tenant, e.g. team=payments; may be repeated")
+	tenantUpdateCmd.Flags().StringSlice("remove-claim", nil, "Key of a custom claim to remove from the tenant; may be repeated")
+	tenantUpdateCmd.Flags().Bool("protect", false, "Protect the tenant from deletion; --protect=false removes the protection")
+	tenantUpdateCmd.Flags().String("volume-prefix", "", "Prefix the names of the volumes of the tenant must start with; empty removes it")
+	tenantUpdateCmd.Flags().String("volume-pattern", "", "Regular expression the names of the volumes of the tenant must match; empty removes it")
+	tenantUpdateCmd.Flags().SetNormalizeFunc(func(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+		// --approvesdc is the original spelling of --approve-sdc.
+		if name == "approvesdc" {
+			name = "approve-sdc"
+		}
+		return pflag.NormalizedName(name)
+	})
 	return tenantUpdateCmd
 }
+
+// volumeNameBody returns the request to update the volume name policy of the
+// tenant from the --volume-prefix and --volume-pattern flags, or nil if
+// neither is given.
+func volumeNameBody(cmd *cobra.Command, tenant string) (*proxy.TenantVolumeNameBody, error) {
+	body := &proxy.TenantVolumeNameBody{Tenant: tenant}
+	for _, f := range []struct {
+		flag, part string
+		value      *string
+	}{
+		{"volume-prefix", "prefix", &body.Prefix},
+		{"volume-pattern", "pattern", &body.Pattern},
+	} {
+		if !cmd.Flags().Changed(f.flag) {
+			continue
+		}
+		v, err := cmd.Flags().GetString(f.flag)
+		if err != nil {
+			return nil, err
+		}
+		if v == "" {
+			body.Remove = append(body.Remove, f.part)
+			continue
+		}
+		*f.value = v
+	}
+	if body.Prefix == "" && body.Pattern == "" && len(body.Remove) == 0 {
+		return nil, nil
+	}
+	return body, nil
+}
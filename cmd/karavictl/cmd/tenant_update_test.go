@@ -22,8 +22,10 @@ import (
 	"io"
 	"karavi-authorization/cmd/karavictl/cmd/api"
 	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
 	"net/url"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -63,6 +65,163 @@ func TestTenantUpdate(t *testing.T) {
 			t.Errorf("expected zero output but got %q", string(gotOutput.Bytes()))
 		}
 	})
+	t.Run("it requests disabling sdc approval for a tenant", func(t *testing.T) {
+		defer afterFn()
+		var gotPath string
+		var gotBody proxy.ApproveSdcBody
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				PatchFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, _ interface{}) error {
+					gotPath = path
+					gotBody = body.(proxy.ApproveSdcBody)
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		osExit = func(_ int) {
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOutput(&gotOutput)
+		cmd.SetArgs([]string{"tenant", "update", "-n", "testname", "--approve-sdc=false", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		if gotPath != "/proxy/tenant/approve-sdc/" {
+			t.Errorf("got path %q, want %q", gotPath, "/proxy/tenant/approve-sdc/")
+		}
+		want := proxy.ApproveSdcBody{Tenant: "testname", ApproveSdc: false}
+		if gotBody != want {
+			t.Errorf("got body %v, want %v", gotBody, want)
+		}
+		if len(gotOutput.Bytes()) != 0 {
+			t.Errorf("expected zero output but got %q", string(gotOutput.Bytes()))
+		}
+	})
+	t.Run("it requests setting claims of a tenant", func(t *testing.T) {
+		defer afterFn()
+		var gotPaths []string
+		var gotBody *proxy.TenantClaimsBody
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				PatchFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, _ interface{}) error {
+					gotPaths = append(gotPaths, path)
+					if b, ok := body.(*proxy.TenantClaimsBody); ok {
+						gotBody = b
+					}
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		osExit = func(_ int) {
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOutput(&gotOutput)
+		cmd.SetArgs([]string{"tenant", "update", "-n", "testname", "--claim", "team=payments", "--claim", "env=prod", "--remove-claim", "owner", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		// sdc approval is left as is
+		if !reflect.DeepEqual(gotPaths, []string{"/proxy/tenant/claims/"}) {
+			t.Errorf("got paths %v, want only %q", gotPaths, "/proxy/tenant/claims/")
+		}
+		want := &proxy.TenantClaimsBody{
+			Tenant: "testname",
+			Claims: map[string]string{"team": "payments", "env": "prod"},
+			Remove: []string{"owner"},
+		}
+		if !reflect.DeepEqual(gotBody, want) {
+			t.Errorf("got body %v, want %v", gotBody, want)
+		}
+		if len(gotOutput.Bytes()) != 0 {
+			t.Errorf("expected zero output but got %q", string(gotOutput.Bytes()))
+		}
+	})
+	t.Run("it requests removing the protection of a tenant", func(t *testing.T) {
+		defer afterFn()
+		var gotPaths []string
+		var gotBody *proxy.TenantProtectBody
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				PatchFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, _ interface{}) error {
+					gotPaths = append(gotPaths, path)
+					if b, ok := body.(*proxy.TenantProtectBody); ok {
+						gotBody = b
+					}
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		osExit = func(_ int) {
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOutput(&gotOutput)
+		cmd.SetArgs([]string{"tenant", "update", "-n", "testname", "--protect=false", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		// sdc approval is left as is
+		if !reflect.DeepEqual(gotPaths, []string{"/proxy/tenant/protect/"}) {
+			t.Errorf("got paths %v, want only %q", gotPaths, "/proxy/tenant/protect/")
+		}
+		want := &proxy.TenantProtectBody{Tenant: "testname", Protected: false}
+		if !reflect.DeepEqual(gotBody, want) {
+			t.Errorf("got body %v, want %v", gotBody, want)
+		}
+		if len(gotOutput.Bytes()) != 0 {
+			t.Errorf("expected zero output but got %q", string(gotOutput.Bytes()))
+		}
+	})
+	t.Run("it requests setting the volume name policy of a tenant", func(t *testing.T) {
+		defer afterFn()
+		var gotPaths []string
+		var gotBody *proxy.TenantVolumeNameBody
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				PatchFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, _ interface{}) error {
+					gotPaths = append(gotPaths, path)
+					if b, ok := body.(*proxy.TenantVolumeNameBody); ok {
+						gotBody = b
+					}
+					return nil
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		osExit = func(_ int) {
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOutput(&gotOutput)
+		cmd.SetArgs([]string{"tenant", "update", "-n", "testname", "--volume-prefix", "pay-", "--volume-pattern=", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		// sdc approval is left as is
+		if !reflect.DeepEqual(gotPaths, []string{"/proxy/tenant/volume-name/"}) {
+			t.Errorf("got paths %v, want only %q", gotPaths, "/proxy/tenant/volume-name/")
+		}
+		want := &proxy.TenantVolumeNameBody{Tenant: "testname", Prefix: "pay-", Remove: []string{"pattern"}}
+		if !reflect.DeepEqual(gotBody, want) {
+			t.Errorf("got body %v, want %v", gotBody, want)
+		}
+		if len(gotOutput.Bytes()) != 0 {
+			t.Errorf("expected zero output but got %q", string(gotOutput.Bytes()))
+		}
+	})
 	t.Run("it requires a valid tenant server connection", func(t *testing.T) {
 		defer afterFn()
 		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
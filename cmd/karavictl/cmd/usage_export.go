@@ -0,0 +1,102 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/usage"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// NewUsageExportCmd creates a new command to export the usage of tenants
+func NewUsageExportCmd() *cobra.Command {
+	usageExportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a snapshot of the usage of tenants",
+		Long: `Exports a snapshot of the capacity, in kilobytes, and the number of volumes
+approved for each tenant in each storage pool, as CSV for chargeback or as JSON.
+Without --organization, exports every tenant the admin token may manage.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+			if format != "csv" && format != "json" {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("unknown format %q, want csv or json", format))
+			}
+
+			organization, err := cmd.Flags().GetString("organization")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			client, adminTknBody := policyClient(cmd)
+
+			var resp proxy.TenantUsageResponse
+			query := url.Values{}
+			if organization = strings.TrimSpace(organization); organization != "" {
+				query.Set("organization", organization)
+			}
+			err = doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Get(ctx, "/proxy/tenant/usage/", headers, query, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+
+			var w io.Writer = cmd.OutOrStdout()
+			if output != "" {
+				f, err := os.Create(output)
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			if format == "json" {
+				if resp.Records == nil {
+					resp.Records = []usage.Record{}
+				}
+				err = jsonOutput(w, resp.Records)
+			} else {
+				err = usage.WriteCSV(w, resp.Records)
+			}
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+
+	usageExportCmd.Flags().String("format", "csv", "Output format: csv or json")
+	usageExportCmd.Flags().String("organization", "", "Only export the tenants of the organization")
+	usageExportCmd.Flags().StringP("output", "o", "", "File to write the snapshot to; defaults to stdout")
+	return usageExportCmd
+}
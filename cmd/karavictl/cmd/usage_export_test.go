@@ -0,0 +1,126 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUsageExport(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	var gotQuery url.Values
+	fakeClient := func(_ string, _ bool) (api.Client, error) {
+		return &mocks.FakeClient{
+			GetFn: func(_ context.Context, path string, _ map[string]string, query url.Values, resp interface{}) error {
+				if path != "/proxy/tenant/usage/" {
+					t.Errorf("got path %q, want %q", path, "/proxy/tenant/usage/")
+				}
+				gotQuery = query
+				b := []byte(`{"records": [{"time": "2024-03-01T12:00:00Z", "tenant": "testname", "systemType": "powerflex", "systemId": "542a2d5f5122210f", "pool": "bronze", "approvedCapacity": 8388608, "approvedVolumes": 1}]}`)
+				return json.Unmarshal(b, resp)
+			},
+		}, nil
+	}
+	readToken := func(_ string) (string, string, error) {
+		return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+	}
+
+	t.Run("it exports the usage as csv", func(t *testing.T) {
+		defer afterFn()
+		CreateHTTPClient = fakeClient
+		ReadAccessAdminToken = readToken
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs([]string{"usage", "export", "--organization", "acme", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		if gotQuery.Get("organization") != "acme" {
+			t.Errorf("got query %v, want the organization", gotQuery)
+		}
+		want := `time,tenant,system_type,system_id,pool,approved_capacity_kb,approved_volumes
+2024-03-01T12:00:00Z,testname,powerflex,542a2d5f5122210f,bronze,8388608,1
+`
+		if got := gotOutput.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("it exports the usage as json to a file", func(t *testing.T) {
+		defer afterFn()
+		CreateHTTPClient = fakeClient
+		ReadAccessAdminToken = readToken
+		output := filepath.Join(t.TempDir(), "usage.json")
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetArgs([]string{"usage", "export", "--format", "json", "-o", output, "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		b, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []map[string]interface{}
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0]["tenant"] != "testname" || got[0]["approvedCapacity"] != float64(8388608) {
+			t.Errorf("got %v, want the usage of testname", got)
+		}
+	})
+
+	t.Run("it rejects an unknown format", func(t *testing.T) {
+		defer afterFn()
+		CreateHTTPClient = fakeClient
+		ReadAccessAdminToken = readToken
+		var gotCode int
+		done := make(chan struct{})
+		osExit = func(code int) {
+			gotCode = code
+			done <- struct{}{}
+			done <- struct{}{} // we can't let this function return
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetErr(&gotOutput)
+		cmd.SetArgs([]string{"usage", "export", "--format", "xml", "--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		go cmd.Execute()
+		<-done
+
+		if gotCode != 1 {
+			t.Errorf("got exit code %d, want 1", gotCode)
+		}
+		if !bytes.Contains(gotOutput.Bytes(), []byte("unknown format")) {
+			t.Errorf("got %q, want the unknown format error", gotOutput.String())
+		}
+	})
+}
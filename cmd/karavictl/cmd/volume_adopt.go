@@ -0,0 +1,93 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/pb"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewVolumeAdoptCmd creates a new command to adopt an existing volume for a tenant
+func NewVolumeAdoptCmd() *cobra.Command {
+	volumeAdoptCmd := &cobra.Command{
+		Use:   "adopt",
+		Short: "Adopt an existing volume for a tenant",
+		Long: `Registers a volume of a storage system that was created outside of CSM Authorization,
+e.g. a statically provisioned volume, as owned by the tenant. Its capacity counts
+against the quota of the tenant from then on, even if that exceeds the quota.
+Volumes that a tenant already owns cannot be adopted.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var body proxy.AdoptVolumeBody
+			for _, f := range []struct {
+				name string
+				v    *string
+			}{
+				{"tenant", &body.Tenant},
+				{"system-id", &body.SystemID},
+				{"volume-id", &body.VolumeID},
+				{"storage-type", &body.StorageType},
+			} {
+				v, err := cmd.Flags().GetString(f.name)
+				if err != nil {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+				}
+				if strings.TrimSpace(v) == "" {
+					reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), errors.New("empty "+f.name+" not allowed"))
+				}
+				*f.v = v
+			}
+
+			client, adminTknBody := policyClient(cmd)
+
+			// The volume is in the protobuf JSON format, which has 64-bit
+			// integers as strings.
+			var resp json.RawMessage
+			err := doWithAdminRefresh(ctx, client, adminTknBody, func(headers map[string]string) error {
+				return client.Post(ctx, "/proxy/storage/adopt/", headers, nil, &body, &resp)
+			})
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("adopting volume %s: %w", body.VolumeID, err))
+			}
+
+			var vol pb.Volume
+			err = protojson.Unmarshal(resp, &vol)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), fmt.Errorf("decoding volume: %w", err))
+			}
+
+			err = jsonOutputEmitEmpty(cmd.OutOrStdout(), &vol)
+			if err != nil {
+				reportErrorAndExit(JSONOutput, cmd.ErrOrStderr(), err)
+			}
+		},
+	}
+
+	volumeAdoptCmd.Flags().String("tenant", "", "Name of the tenant to adopt the volume for")
+	volumeAdoptCmd.Flags().String("system-id", "", "ID of the storage system of the volume")
+	volumeAdoptCmd.Flags().String("volume-id", "", "ID the storage system assigned to the volume")
+	volumeAdoptCmd.Flags().String("storage-type", "powerflex", "Type of the storage system of the volume")
+	return volumeAdoptCmd
+}
@@ -0,0 +1,119 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/cmd/karavictl/cmd/api"
+	"karavi-authorization/cmd/karavictl/cmd/api/mocks"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/pb"
+	"net/url"
+	"os"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestVolumeAdopt(t *testing.T) {
+	afterFn := func() {
+		CreateHTTPClient = createHTTPClient
+		JSONOutput = jsonOutput
+		osExit = os.Exit
+		ReadAccessAdminToken = readAccessAdminToken
+	}
+
+	t.Run("it adopts a volume for a tenant", func(t *testing.T) {
+		defer afterFn()
+		var gotBody proxy.AdoptVolumeBody
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				PostFn: func(_ context.Context, path string, _ map[string]string, _ url.Values, body, resp interface{}) error {
+					if path != "/proxy/storage/adopt/" {
+						t.Errorf("got path %q, want %q", path, "/proxy/storage/adopt/")
+					}
+					gotBody = *body.(*proxy.AdoptVolumeBody)
+					b := []byte(`{"name": "k8s-456", "id": "0a1b2c3d00000001", "pool": "bronze", "systemId": "542a2d5f5122210f", "sizeInKb": "8388608"}`)
+					return json.Unmarshal(b, resp)
+				},
+			}, nil
+		}
+		ReadAccessAdminToken = func(_ string) (string, string, error) {
+			return "AUnumberTokenIsNotWorkingman", "AUnumberTokenIsNotWorkingman", nil
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetOut(&gotOutput)
+		cmd.SetArgs([]string{"volume", "adopt", "--tenant", "testname", "--system-id", "542a2d5f5122210f", "--volume-id", "0a1b2c3d00000001",
+			"--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		cmd.Execute()
+
+		want := proxy.AdoptVolumeBody{StorageType: "powerflex", SystemID: "542a2d5f5122210f", VolumeID: "0a1b2c3d00000001", Tenant: "testname"}
+		if gotBody != want {
+			t.Errorf("got body %v, want %v", gotBody, want)
+		}
+		var got pb.Volume
+		if err := protojson.Unmarshal(gotOutput.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != "k8s-456" || got.SizeInKb != 8388608 {
+			t.Errorf("got %v, want the adopted volume", &got)
+		}
+	})
+	t.Run("it requires a tenant", func(t *testing.T) {
+		defer afterFn()
+		var posted bool
+		CreateHTTPClient = func(_ string, _ bool) (api.Client, error) {
+			return &mocks.FakeClient{
+				PostFn: func(_ context.Context, _ string, _ map[string]string, _ url.Values, _, _ interface{}) error {
+					posted = true
+					return nil
+				},
+			}, nil
+		}
+		var gotCode int
+		done := make(chan struct{})
+		osExit = func(code int) {
+			gotCode = code
+			done <- struct{}{}
+			done <- struct{}{} // we can't let this function return
+		}
+		var gotOutput bytes.Buffer
+
+		cmd := NewRootCmd()
+		cmd.SetErr(&gotOutput)
+		cmd.SetArgs([]string{"volume", "adopt", "--system-id", "542a2d5f5122210f", "--volume-id", "0a1b2c3d00000001",
+			"--admin-token", "admin.yaml", "--addr", "proxy.com"})
+		go cmd.Execute()
+		<-done
+
+		if gotCode != 1 {
+			t.Errorf("got exit code %d, want 1", gotCode)
+		}
+		var gotErr CommandError
+		if err := json.NewDecoder(&gotOutput).Decode(&gotErr); err != nil {
+			t.Fatal(err)
+		}
+		if want := "empty tenant not allowed"; gotErr.ErrorMsg != want {
+			t.Errorf("got err %q, want %q", gotErr.ErrorMsg, want)
+		}
+		if posted {
+			t.Error("expected the volume not to be adopted")
+		}
+	})
+}
@@ -14,39 +14,37 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"io"
+	"karavi-authorization/internal/appconfig"
+	"karavi-authorization/internal/envelope"
+	"karavi-authorization/internal/errcode"
 	"karavi-authorization/internal/k8s"
+	"karavi-authorization/internal/mounts"
 	"karavi-authorization/internal/role-service"
 	"karavi-authorization/internal/role-service/middleware"
 	"karavi-authorization/internal/role-service/validate"
+	"karavi-authorization/internal/validation"
 	"karavi-authorization/pb"
-	stdLog "log"
 	"net"
+	"net/http"
 	"os"
-	"strings"
 	"sync"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/zipkin"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	"go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"google.golang.org/grpc"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
 const (
-	listenAddr   = ":50051"
-	namespaceEnv = "NAMESPACE"
-	logLevel     = "LOG_LEVEL"
-	logFormat    = "LOG_FORMAT"
+	listenAddr         = ":50051"
+	namespaceEnv       = "NAMESPACE"
+	csmConfigParamsDir = "/etc/karavi-authorization/csm-config-params/"
 )
 
 var cfg Config
@@ -54,56 +52,40 @@ var cfg Config
 // Config is the configuration details on the role-service
 type Config struct {
 	GrpcListenAddr string
-	Zipkin         struct {
-		CollectorURI string
-		ServiceName  string
-		Probability  float64
+	Zipkin         appconfig.Zipkin
+	Web            struct {
+		DebugHost string
+	}
+	Storage struct {
+		MasterKeyFile string
 	}
 }
 
 func main() {
 	log := logrus.NewEntry(logrus.New())
 
-	csmViper := viper.New()
-	csmViper.SetConfigName("csm-config-params")
-	csmViper.AddConfigPath("/etc/karavi-authorization/csm-config-params/")
-
-	csmViper.SetDefault("grpclistenaddr", listenAddr)
-	csmViper.SetDefault("zipkin.collectoruri", "http://localhost:9411/api/v2/spans")
-	csmViper.SetDefault("zipkin.servicename", "proxy-server")
-	csmViper.SetDefault("zipkin.probability", 0.8)
-
-	if err := csmViper.ReadInConfig(); err != nil {
-		log.Fatalf("reading config file: %+v", err)
+	if err := mounts.Check(mounts.Mount{Name: "csm-config-params", Path: csmConfigParamsDir}); err != nil {
+		log.Fatalf("checking mounts: %v", err)
 	}
 
-	if err := csmViper.Unmarshal(&cfg); err != nil {
-		log.Fatalf("decoding config file: %+v", err)
-	}
-
-	updateLoggingSettings := func(log *logrus.Entry) {
-		logFormat := csmViper.GetString(logFormat)
-		if strings.EqualFold(logFormat, "json") {
-			log.Logger.SetFormatter(&logrus.JSONFormatter{})
-		} else {
-			// use text formatter by default
-			log.Logger.SetFormatter(&logrus.TextFormatter{})
-		}
-		logLevel := csmViper.GetString(logLevel)
-		level, err := logrus.ParseLevel(logLevel)
-		if err != nil {
-			// use INFO level by default
-			level = logrus.InfoLevel
-		}
-		log.Logger.SetLevel(level)
-		log.WithField("LOG_LEVEL", level).Info("Configuration updated")
+	// Environment variables override the config file, with the dots of
+	// keys replaced by underscores, e.g. GRPCLISTENADDR.
+	csmCfg, err := appconfig.Load(log, appconfig.Source{
+		Name:  "csm-config-params",
+		Paths: []string{csmConfigParamsDir},
+		Defaults: appconfig.Defaults{
+			"grpclistenaddr":        listenAddr,
+			"web.debughost":         ":9090",
+			"storage.masterkeyfile": "",
+		}.With(appconfig.ZipkinDefaults(appconfig.DefaultZipkinCollectorURI)),
+		Optional: true,
+	}, &cfg)
+	if err != nil {
+		log.Fatalf("%+v", err)
 	}
-	updateLoggingSettings(log)
+	csmCfg.WatchLogging(log, appconfig.DefaultLogKeys)
 
-	_, err := initTracing(log,
-		cfg.Zipkin.CollectorURI,
-		"csm-authorization-role-service",
-		cfg.Zipkin.Probability)
+	_, err = appconfig.InitTracing(log, cfg.Zipkin, "csm-authorization-role-service")
 	if err != nil {
 		log.WithError(err).Println("main: initializng tracing")
 	}
@@ -135,44 +117,37 @@ func main() {
 		Namespace: ns,
 		Log:       log,
 	}
+	if cfg.Storage.MasterKeyFile != "" {
+		key, err := envelope.LoadMasterKey(cfg.Storage.MasterKeyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		api.Keys = key
+	}
 
-	roleSvc := role.NewService(api, validate.NewRoleValidator(api, log))
+	// Sync role changes to OPA in the background, retrying failures.
+	reconciler := role.NewReconciler(api, role.WithReconcilerLogger(log))
+	go reconciler.Run(context.Background())
+
+	prometheus.MustRegister(reconciler.LagCollector())
+	http.Handle("/metrics", promhttp.Handler())
+	go func() {
+		log.WithField("debug host", cfg.Web.DebugHost).Debug("main: debug listening")
+		s := http.Server{
+			Addr:              cfg.Web.DebugHost,
+			Handler:           http.DefaultServeMux,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		if err := s.ListenAndServe(); err != nil {
+			log.WithError(err).Warn("main: debug listener closed")
+		}
+	}()
 
-	gs := grpc.NewServer(grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor()), grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()))
+	roleSvc := role.NewService(reconciler, validate.NewRoleValidator(api, log))
+
+	gs := grpc.NewServer(grpc.ChainUnaryInterceptor(otelgrpc.UnaryServerInterceptor(), errcode.UnaryServerInterceptor(), validation.UnaryServerInterceptor()), grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()))
 	pb.RegisterRoleServiceServer(gs, middleware.NewRoleTelemetryMW(log, roleSvc))
 
 	log.Infof("Serving role service on %s", cfg.GrpcListenAddr)
 	log.Fatal(gs.Serve(l))
 }
-
-func initTracing(log *logrus.Entry, uri, name string, prob float64) (*trace.TracerProvider, error) {
-	if len(strings.TrimSpace(uri)) == 0 {
-		return nil, nil
-	}
-
-	log.Info("main: initializing otel/zipkin tracing support")
-
-	exporter, err := zipkin.New(
-		uri,
-		zipkin.WithLogger(stdLog.New(io.Discard, "", stdLog.LstdFlags)),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("creating zipkin exporter: %w", err)
-	}
-
-	tp := trace.NewTracerProvider(
-		trace.WithSampler(trace.TraceIDRatioBased(prob)),
-		trace.WithBatcher(
-			exporter,
-			trace.WithMaxExportBatchSize(trace.DefaultMaxExportBatchSize),
-			trace.WithBatchTimeout(trace.DefaultScheduleDelay),
-			trace.WithMaxExportBatchSize(trace.DefaultMaxExportBatchSize),
-		),
-		trace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
-			attribute.KeyValue{Key: semconv.ServiceNameKey, Value: attribute.StringValue(name)})),
-	)
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}))
-
-	return tp, nil
-}
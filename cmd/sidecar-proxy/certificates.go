@@ -0,0 +1,324 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Certificate issuers of the listeners of the sidecar, set in CERT_ISSUER.
+const (
+	certIssuerSelfSigned = "self-signed"
+	certIssuerVault      = "vault"
+)
+
+// Defaults of the certificates issued by Vault.
+const (
+	defaultVaultSignPath    = "pki/sign/csm-authorization-sidecar"
+	defaultVaultAuthPath    = "kubernetes"
+	defaultCertTTL          = 24 * time.Hour
+	serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token" // #nosec G101
+	// certRetryInterval is how long a failed renewal waits before it is
+	// retried, while the current certificate is still served.
+	certRetryInterval = 30 * time.Second
+)
+
+// vaultIssuer gets the certificate of the listeners signed by the PKI
+// secrets engine of Vault, from a certificate signing request for a key that
+// never leaves the sidecar, and renews it before it expires. The drivers can
+// then verify the sidecar with the CA of Vault instead of skipping
+// verification.
+type vaultIssuer struct {
+	log    *logrus.Entry
+	client *http.Client
+
+	addr     string // address of Vault, e.g. https://vault.vault:8200
+	signPath string // path of the sign endpoint, below /v1/
+	// The token of Vault is read from tokenFile if set. Otherwise the
+	// sidecar logs in with its service account token to the Kubernetes
+	// auth method at authPath, as role.
+	tokenFile  string
+	authPath   string
+	role       string
+	jwtFile    string
+	commonName string
+	ttl        time.Duration
+
+	mu   sync.RWMutex // guards cert
+	cert *tls.Certificate
+}
+
+// newVaultIssuerFromEnv returns the vaultIssuer configured by the VAULT_*
+// and CERT_* environment variables.
+func newVaultIssuerFromEnv(log *logrus.Entry) (*vaultIssuer, error) {
+	env := func(name, def string) string {
+		if v, ok := os.LookupEnv(name); ok && v != "" {
+			return v
+		}
+		return def
+	}
+
+	v := &vaultIssuer{
+		log:        log,
+		addr:       strings.TrimSuffix(env("VAULT_ADDR", ""), "/"),
+		signPath:   strings.Trim(env("VAULT_SIGN_PATH", defaultVaultSignPath), "/"),
+		tokenFile:  env("VAULT_TOKEN_FILE", ""),
+		authPath:   strings.Trim(env("VAULT_AUTH_PATH", defaultVaultAuthPath), "/"),
+		role:       env("VAULT_ROLE", ""),
+		jwtFile:    serviceAccountTokenPath,
+		commonName: env("CERT_COMMON_NAME", "localhost"),
+		ttl:        defaultCertTTL,
+	}
+	if v.addr == "" {
+		return nil, errors.New("missing VAULT_ADDR")
+	}
+	if v.tokenFile == "" && v.role == "" {
+		return nil, errors.New("missing VAULT_TOKEN_FILE or VAULT_ROLE")
+	}
+	if s := env("CERT_TTL", ""); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("parsing CERT_TTL: %w", err)
+		}
+		v.ttl = d
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile := env("VAULT_CACERT", ""); caFile != "" {
+		b, err := os.ReadFile(filepath.Clean(caFile))
+		if err != nil {
+			return nil, fmt.Errorf("reading VAULT_CACERT: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, errors.New("no certificates in VAULT_CACERT")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	v.client = &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+	return v, nil
+}
+
+// Start issues the first certificate, and renews it in the background until
+// ctx is done.
+func (v *vaultIssuer) Start(ctx context.Context) error {
+	cert, err := v.issue(ctx)
+	if err != nil {
+		return fmt.Errorf("issuing certificate: %w", err)
+	}
+	v.setCertificate(cert)
+	go v.renew(ctx)
+	return nil
+}
+
+// GetCertificate returns the current certificate, for tls.Config.
+func (v *vaultIssuer) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.cert == nil {
+		return nil, errors.New("no certificate issued")
+	}
+	return v.cert, nil
+}
+
+func (v *vaultIssuer) setCertificate(cert *tls.Certificate) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.cert = cert
+	v.log.WithFields(logrus.Fields{
+		"serial":   cert.Leaf.SerialNumber.Text(16),
+		"notAfter": cert.Leaf.NotAfter,
+	}).Info("certificate has been issued")
+}
+
+// renew replaces the certificate once two thirds of its lifetime have
+// passed, and retries until it succeeds.
+func (v *vaultIssuer) renew(ctx context.Context) {
+	v.mu.RLock()
+	next := renewalTime(v.cert.Leaf)
+	v.mu.RUnlock()
+
+	for {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		cert, err := v.issue(ctx)
+		if err != nil {
+			v.log.WithError(err).Warn("renewing certificate")
+			next = time.Now().Add(certRetryInterval)
+			continue
+		}
+		v.setCertificate(cert)
+		next = renewalTime(cert.Leaf)
+	}
+}
+
+// renewalTime returns when the certificate is renewed, after two thirds
+// of its lifetime.
+func renewalTime(cert *x509.Certificate) time.Time {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return cert.NotBefore.Add(lifetime * 2 / 3)
+}
+
+// issue gets a certificate for a new key signed by Vault.
+func (v *vaultIssuer) issue(ctx context.Context) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating private key: %w", err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:     pkix.Name{CommonName: v.commonName},
+		DNSNames:    []string{v.commonName},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}, key)
+	if err != nil {
+		return nil, fmt.Errorf("creating certificate request: %w", err)
+	}
+
+	token, err := v.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data struct {
+			Certificate string   `json:"certificate"`
+			CAChain     []string `json:"ca_chain"`
+		} `json:"data"`
+	}
+	err = v.post(ctx, v.signPath, token, map[string]string{
+		"csr":         string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})),
+		"common_name": v.commonName,
+		"ip_sans":     "127.0.0.1,::1",
+		"ttl":         v.ttl.String(),
+		"format":      "pem",
+	}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("signing certificate request: %w", err)
+	}
+
+	cert := &tls.Certificate{PrivateKey: key}
+	rest := []byte(resp.Data.Certificate + "\n" + strings.Join(resp.Data.CAChain, "\n"))
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			cert.Certificate = append(cert.Certificate, block.Bytes)
+		}
+	}
+	if len(cert.Certificate) == 0 {
+		return nil, errors.New("no certificate in the response of vault")
+	}
+	cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("parsing certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// token returns a token for Vault, from the token file or by logging in with
+// the service account token.
+func (v *vaultIssuer) token(ctx context.Context) (string, error) {
+	if v.tokenFile != "" {
+		b, err := os.ReadFile(filepath.Clean(v.tokenFile))
+		if err != nil {
+			return "", fmt.Errorf("reading vault token: %w", err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	jwt, err := os.ReadFile(filepath.Clean(v.jwtFile))
+	if err != nil {
+		return "", fmt.Errorf("reading service account token: %w", err)
+	}
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	err = v.post(ctx, "auth/"+v.authPath+"/login", "", map[string]string{
+		"role": v.role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	}, &resp)
+	if err != nil {
+		return "", fmt.Errorf("logging in to vault: %w", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", errors.New("logging in to vault: no token")
+	}
+	return resp.Auth.ClientToken, nil
+}
+
+// post posts the body to the path of the Vault API and decodes the response
+// into resp.
+func (v *vaultIssuer) post(ctx context.Context, path, token string, body, resp interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.addr+"/v1/"+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		var errResp struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(res.Body).Decode(&errResp)
+		return fmt.Errorf("vault responded with %d: %s", res.StatusCode, strings.Join(errResp.Errors, "; "))
+	}
+	return json.NewDecoder(res.Body).Decode(resp)
+}
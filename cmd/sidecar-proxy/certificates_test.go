@@ -0,0 +1,250 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fakeVault signs certificate requests with a CA of its own, for the
+// clients that log in with the service account token or send the token.
+type fakeVault struct {
+	t      *testing.T
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	mu     sync.Mutex
+	signed int
+}
+
+func newFakeVault(t *testing.T) *fakeVault {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tml := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Vault CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tml, tml, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeVault{t: t, ca: ca, caKey: key}
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		f.t.Fatal(err)
+	}
+	switch r.URL.Path {
+	case "/v1/auth/kubernetes/login":
+		if body["role"] != "sidecar" || body["jwt"] != "service-account-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"auth": {"client_token": "vault-token"}}`))
+	case "/v1/pki/sign/csm-authorization-sidecar":
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors": ["permission denied"]}`))
+			return
+		}
+		block, _ := pem.Decode([]byte(body["csr"]))
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		ttl, err := time.ParseDuration(body["ttl"])
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		f.mu.Lock()
+		f.signed++
+		serial := int64(f.signed + 1)
+		f.mu.Unlock()
+		now := time.Now()
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      csr.Subject,
+			DNSNames:     csr.DNSNames,
+			IPAddresses:  csr.IPAddresses,
+			NotBefore:    now,
+			NotAfter:     now.Add(ttl),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}, f.ca, csr.PublicKey, f.caKey)
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"certificate": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+				"ca_chain":    []string{string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.ca.Raw}))},
+			},
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeVault) signedCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.signed
+}
+
+func TestVaultIssuer(t *testing.T) {
+	newIssuer := func(t *testing.T, vault *fakeVault, ttl time.Duration) *vaultIssuer {
+		srv := httptest.NewTLSServer(vault)
+		t.Cleanup(srv.Close)
+
+		dir := t.TempDir()
+		jwtFile := filepath.Join(dir, "token")
+		if err := os.WriteFile(jwtFile, []byte("service-account-token\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		caFile := filepath.Join(dir, "ca.pem")
+		if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("VAULT_ADDR", srv.URL)
+		t.Setenv("VAULT_ROLE", "sidecar")
+		t.Setenv("VAULT_CACERT", caFile)
+		t.Setenv("CERT_TTL", ttl.String())
+
+		vi, err := newVaultIssuerFromEnv(logrus.NewEntry(logrus.New()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		vi.jwtFile = jwtFile
+		return vi
+	}
+
+	t.Run("it serves a certificate signed by vault", func(t *testing.T) {
+		vault := newFakeVault(t)
+		vi := newIssuer(t, vault, time.Hour)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		if err := vi.Start(ctx); err != nil {
+			t.Fatal(err)
+		}
+		cert, err := vi.GetCertificate(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		roots := x509.NewCertPool()
+		roots.AddCert(vault.ca)
+		if _, err := cert.Leaf.Verify(x509.VerifyOptions{DNSName: "localhost", Roots: roots}); err != nil {
+			t.Errorf("got certificate not valid for localhost: %v", err)
+		}
+		if _, err := cert.Leaf.Verify(x509.VerifyOptions{DNSName: "127.0.0.1", Roots: roots}); err != nil {
+			t.Errorf("got certificate not valid for 127.0.0.1: %v", err)
+		}
+		if len(cert.Certificate) != 2 {
+			t.Errorf("got %d certificates, want the certificate and the CA", len(cert.Certificate))
+		}
+	})
+	t.Run("it renews the certificate before it expires", func(t *testing.T) {
+		vault := newFakeVault(t)
+		vi := newIssuer(t, vault, 900*time.Millisecond)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		if err := vi.Start(ctx); err != nil {
+			t.Fatal(err)
+		}
+		first, err := vi.GetCertificate(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for vault.signedCount() < 2 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if renewedAt := time.Now(); !renewedAt.Before(first.Leaf.NotAfter) {
+			t.Errorf("got the certificate renewed at %v, want before it expired at %v", renewedAt, first.Leaf.NotAfter)
+		}
+		time.Sleep(50 * time.Millisecond)
+		renewed, err := vi.GetCertificate(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if renewed.Leaf.SerialNumber.Cmp(first.Leaf.SerialNumber) == 0 {
+			t.Error("expected the certificate to be renewed")
+		}
+	})
+	t.Run("it uses the vault token file", func(t *testing.T) {
+		vault := newFakeVault(t)
+		vi := newIssuer(t, vault, time.Hour)
+		vi.tokenFile = filepath.Join(t.TempDir(), "vault-token")
+		if err := os.WriteFile(vi.tokenFile, []byte("wrong-token"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := vi.issue(context.Background())
+		if err == nil {
+			t.Fatal("expected an error with the wrong token")
+		}
+		if want := "signing certificate request: vault responded with 403: permission denied"; err.Error() != want {
+			t.Errorf("got error %q, want %q", err, want)
+		}
+	})
+	t.Run("it requires the address of vault", func(t *testing.T) {
+		t.Setenv("VAULT_ADDR", "")
+		t.Setenv("VAULT_ROLE", "sidecar")
+
+		if _, err := newVaultIssuerFromEnv(logrus.NewEntry(logrus.New())); err == nil {
+			t.Error("expected an error without VAULT_ADDR")
+		}
+	})
+}
+
+func TestRenewalTime(t *testing.T) {
+	notBefore := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	cert := &x509.Certificate{NotBefore: notBefore, NotAfter: notBefore.Add(24 * time.Hour)}
+
+	if got, want := renewalTime(cert), notBefore.Add(16*time.Hour); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/subtle"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -28,6 +29,10 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
+	"karavi-authorization/internal/appconfig"
+	"karavi-authorization/internal/mounts"
+	"karavi-authorization/internal/proxy"
 	"karavi-authorization/internal/web"
 	"math/big"
 	"net"
@@ -35,23 +40,31 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/fsnotify/fsnotify"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 )
 
 // Common constants.
 const (
-	HeaderAuthz     = "Authorization"
-	HeaderForwarded = "Forwarded"
-	Bearer          = "Bearer "
-	ContentType     = "application/json"
-	csiLogLevel     = "CSI_LOG_LEVEL"
-	csiLogFormat    = "CSI_LOG_FORMAT"
+	HeaderAuthz       = "Authorization"
+	HeaderForwarded   = "Forwarded"
+	Bearer            = "Bearer "
+	ContentType       = "application/json"
+	csiLogLevel       = "CSI_LOG_LEVEL"
+	csiLogFormat      = "CSI_LOG_FORMAT"
+	logRedactFields   = "LOG_REDACT_FIELDS"
+	logRedactPatterns = "LOG_REDACT_PATTERNS"
+
+	configPath          = "/etc/karavi-authorization/config/config"
+	rootCertificatePath = "/etc/karavi-authorization/root-certificates/rootCertificate.pem"
+
+	// HeaderListenerSecret carries the shared secret that the driver must
+	// send when LISTENER_SECRET or LISTENER_SECRET_FILE is set.
+	HeaderListenerSecret = "X-Csm-Listener-Secret"
 )
 
 // Hooks that may be overridden for testing.
@@ -73,6 +86,9 @@ type SecretData struct {
 	SystemID         string `json:"systemID"`
 	Insecure         bool   `json:"insecure"`
 	IsDefault        bool   `json:"isDefault"`
+	// SocketPath is the unix domain socket to listen on instead of the
+	// port of the endpoint.
+	SocketPath string `json:"socketPath,omitempty"`
 }
 
 // ProxyInstance is an instance of a proxy server to a backend storage system
@@ -86,30 +102,34 @@ type ProxyInstance struct {
 	l                net.Listener
 	rp               *httputil.ReverseProxy
 	svr              *http.Server
+
+	// Secret, if set, must be sent by the driver in HeaderListenerSecret.
+	Secret string
+	// SocketDir, if set, is a directory shared only with the driver
+	// container, where the instance listens on a unix domain socket named
+	// after the port of its endpoint instead of on the port.
+	SocketDir string
+	// SocketPath, if set, is the unix domain socket the instance listens on,
+	// for drivers that are configured with a socket rather than a port.
+	SocketPath string
 }
 
 // Start serves a ProxyInstance http server
-func (pi *ProxyInstance) Start(proxyHost, access, refresh string) error {
+func (pi *ProxyInstance) Start(proxyHost string, tokens *TokenSelector) error {
 	var err error
 
-	ep, err := url.Parse(pi.Endpoint)
-	if err != nil {
-		return err
-	}
-
-	_, port, err := net.SplitHostPort(ep.Host)
-	if err != nil {
-		return err
-	}
-
-	listenAddr := fmt.Sprintf(":%v", port)
 	proxyURL := url.URL{
 		Scheme: "https",
 		Host:   proxyHost,
 	}
 	pi.rp = httputil.NewSingleHostReverseProxy(&proxyURL)
+	pi.rp.ModifyResponse = denyHeaders
+	// The transports negotiate HTTP/2 with the proxy-server, since a custom
+	// TLS config otherwise disables it, so that HTTP/2 requests of the
+	// driver, e.g. gRPC calls, are passed through without downgrade.
 	if insecureProxy {
 		pi.rp.Transport = &http.Transport{
+			ForceAttemptHTTP2: true,
 			TLSClientConfig: &tls.Config{
 				InsecureSkipVerify: true, // #nosec G402
 				MinVersion:         tls.VersionTLS12,
@@ -124,6 +144,7 @@ func (pi *ProxyInstance) Start(proxyHost, access, refresh string) error {
 		}
 
 		pi.rp.Transport = &http.Transport{
+			ForceAttemptHTTP2: true,
 			TLSClientConfig: &tls.Config{
 				RootCAs:            pool,
 				InsecureSkipVerify: false,
@@ -134,24 +155,90 @@ func (pi *ProxyInstance) Start(proxyHost, access, refresh string) error {
 		}
 	}
 
-	pi.log.Infof("Listening on %s", listenAddr)
+	pi.l, err = pi.listen()
+	if err != nil {
+		return err
+	}
+
+	pi.log.Infof("Listening on %s", pi.l.Addr())
 	pi.svr = &http.Server{
-		Addr:              listenAddr,
-		Handler:           pi.Handler(proxyURL, access, refresh),
+		Handler:           pi.Handler(proxyURL, tokens),
 		TLSConfig:         pi.TLSConfig,
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 
-	if err := pi.svr.ListenAndServeTLS("", ""); err != nil {
+	if err := pi.svr.ServeTLS(pi.l, "", ""); err != nil {
 		fmt.Printf("error: %+v\n", err)
 		return err
 	}
 	return nil
 }
 
-// Handler is the ProxyInstance http handler function
-func (pi *ProxyInstance) Handler(proxyHost url.URL, access, refresh string) http.HandlerFunc {
+// listen listens on the configured unix domain socket, or on the port of the
+// endpoint, or on the socket for the port if a socket directory is
+// configured. A socket is only accessible to the user of the sidecar, which
+// the driver container must run as.
+func (pi *ProxyInstance) listen() (net.Listener, error) {
+	sock := pi.SocketPath
+	if sock == "" {
+		ep, err := url.Parse(pi.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		_, port, err := net.SplitHostPort(ep.Host)
+		if err != nil {
+			return nil, err
+		}
+		if pi.SocketDir == "" {
+			return net.Listen("tcp", fmt.Sprintf(":%v", port))
+		}
+		sock = filepath.Join(pi.SocketDir, port+".sock")
+	}
+
+	if err := os.Remove(sock); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("removing stale socket: %w", err)
+	}
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(sock, 0o600); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("restricting socket: %w", err)
+	}
+	return l, nil
+}
+
+// authorized reports whether the request carries the listener secret, if
+// one is configured.
+func (pi *ProxyInstance) authorized(r *http.Request) bool {
+	if pi.Secret == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(HeaderListenerSecret)), []byte(pi.Secret)) == 1
+}
+
+// Handler is the ProxyInstance http handler function. The tokens of each
+// request are selected by the storage system and the namespace of the
+// volume.
+func (pi *ProxyInstance) Handler(proxyHost url.URL, tokenSelector *TokenSelector) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !pi.authorized(r) {
+			pi.log.WithField("remote_addr", r.RemoteAddr).Warn("Rejected request without the listener secret")
+			http.Error(w, "missing or invalid listener secret", http.StatusForbidden)
+			return
+		}
+		// The secret is only for the sidecar.
+		r.Header.Del(HeaderListenerSecret)
+
+		tokens := tokenSelector.Select(pi.SystemID, r.Header.Get(proxy.HeaderPVNamespace))
+		if tokens == nil {
+			pi.log.WithField("namespace", r.Header.Get(proxy.HeaderPVNamespace)).Warn("Rejected request without tokens")
+			http.Error(w, "no tokens for the request", http.StatusForbidden)
+			return
+		}
+		access := tokens.Access()
+
 		// Override the Authorization header with our Bearer token.
 		r.Header.Set(HeaderAuthz, fmt.Sprintf("Bearer %s", access))
 
@@ -173,7 +260,7 @@ func (pi *ProxyInstance) Handler(proxyHost url.URL, access, refresh string) http
 
 		if sw.Status == http.StatusUnauthorized {
 			pi.log.Debug("Refreshing tokens!")
-			err := refreshTokens(proxyHost, refresh, &access, pi.log)
+			err := tokens.Refresh(proxyHost, access, pi.log)
 			if err != nil {
 				pi.log.WithError(err).Error("refreshing tokens")
 			}
@@ -181,8 +268,35 @@ func (pi *ProxyInstance) Handler(proxyHost url.URL, access, refresh string) http
 	})
 }
 
+// denyHeaders passes the machine-readable reason of a request denied by the
+// proxy-server to the driver as response headers, so that it can be surfaced
+// without parsing the error message.
+func denyHeaders(resp *http.Response) error {
+	if resp.StatusCode < http.StatusBadRequest || resp.Body == nil {
+		return nil
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+
+	var errBody struct {
+		Deny *web.Deny `json:"deny"`
+	}
+	if err := json.Unmarshal(b, &errBody); err != nil || errBody.Deny == nil {
+		return nil
+	}
+	errBody.Deny.SetHeaders(resp.Header)
+	return nil
+}
+
 // Stop closes the ProxyInstance http server
 func (pi *ProxyInstance) Stop() error {
+	if pi.svr == nil {
+		return nil
+	}
 	return pi.svr.Close()
 }
 
@@ -205,60 +319,38 @@ func run(log *logrus.Entry) error {
 	if !ok {
 		return errors.New("missing proxy host")
 	}
-	refresh, ok := os.LookupEnv("REFRESH_TOKEN")
-	if !ok {
-		return errors.New("missing refresh token")
-	}
-	access, ok := os.LookupEnv("ACCESS_TOKEN")
-	if !ok {
-		return errors.New("missing access token")
+	tokens, err := readTokens()
+	if err != nil {
+		return err
 	}
 	skipCertValue, _ := os.LookupEnv("SKIP_CERTIFICATE_VALIDATION")
 	insecureValue, _ := os.LookupEnv("INSECURE")
 	if skipCertValue == "true" || insecureValue == "true" {
 		insecureProxy = true
 	}
+	listenerSecret, err := readListenerSecret()
+	if err != nil {
+		return err
+	}
+	socketDir, _ := os.LookupEnv("LISTENER_SOCKET_DIR")
+	if err := mounts.Check(requiredMounts(socketDir)...); err != nil {
+		return fmt.Errorf("checking mounts: %w", err)
+	}
 	driverConfigParamsFile = flag.String("driver-config-params", "", "Full path to the YAML file containing the driver ConfigMap")
 	flag.Parse()
 
-	driverCfg := viper.New()
-	driverCfg.SetConfigFile("/etc/karavi-authorization/driver-config-params.yaml")
-
-	if err := driverCfg.ReadInConfig(); err != nil {
+	driverCfg := appconfig.New(log, appconfig.Source{File: "/etc/karavi-authorization/driver-config-params.yaml"})
+	if err := driverCfg.Read(true); err != nil {
 		log.WithError(err).Error("reading config file")
 	}
-
-	updateLoggingSettings := func(log *logrus.Entry) {
-		logFormat := driverCfg.GetString(csiLogFormat)
-		if strings.EqualFold(logFormat, "json") {
-			log.Logger.SetFormatter(&logrus.JSONFormatter{})
-		} else {
-			// use text formatter by default
-			log.Logger.SetFormatter(&logrus.TextFormatter{})
-		}
-		if logFormat != "" {
-			log.WithField(csiLogFormat, logFormat).Info("configuration has been set")
-		}
-
-		logLevel := driverCfg.GetString(csiLogLevel)
-		level, err := logrus.ParseLevel(logLevel)
-		if err != nil {
-			// use INFO level by default
-			level = logrus.InfoLevel
-		}
-		log.WithField(csiLogLevel, level.String()).Info("configuration has been set")
-		log.Logger.SetLevel(level)
-		log.WithField(csiLogLevel, level.String()).Info("configuration has been set")
-	}
-	updateLoggingSettings(log)
-
-	driverCfg.WatchConfig()
-	driverCfg.OnConfigChange(func(e fsnotify.Event) {
-		log.Infof("Configuration changed! %+v, %s", e.Op, e.Name)
-		updateLoggingSettings(log)
+	driverCfg.WatchLogging(log, appconfig.LogKeys{
+		Level:          csiLogLevel,
+		Format:         csiLogFormat,
+		RedactFields:   logRedactFields,
+		RedactPatterns: logRedactPatterns,
 	})
 
-	cfgFile, err := os.Open("/etc/karavi-authorization/config/config")
+	cfgFile, err := os.Open(configPath)
 	if err != nil {
 		return err
 	}
@@ -268,19 +360,53 @@ func run(log *logrus.Entry) error {
 		return err
 	}
 
-	// Generate a self-signed certificate for the CSI driver to trust,
-	// since we will always be inside the same Pod talking over localhost.
-	tlsCert, err := generateX509Certificate()
-	if err != nil {
-		return err
+	// With PORT_ALLOCATION=proxy, the ports of the listeners are allocated
+	// by the proxy-server, so that drivers sharing a node do not collide.
+	if mode, _ := os.LookupEnv("PORT_ALLOCATION"); mode == portAllocationProxy && socketDir == "" {
+		proxyURL := url.URL{Scheme: "https", Host: proxyHost}
+		defaultTokens, err := tokens.Default()
+		if err != nil {
+			return err
+		}
+		if err := allocatePorts(proxyURL, pluginID, configs, defaultTokens, log); err != nil {
+			return fmt.Errorf("allocating ports: %w", err)
+		}
+		if f, ok := os.LookupEnv("ALLOCATED_ENDPOINTS_FILE"); ok {
+			if err := writeEndpoints(f, configs); err != nil {
+				return fmt.Errorf("writing allocated endpoints: %w", err)
+			}
+		}
 	}
+
 	tlsConfig := &tls.Config{
-		Certificates:       []tls.Certificate{tlsCert},
 		InsecureSkipVerify: true, // #nosec G402
 		MinVersion:         tls.VersionTLS12,
 		MaxVersion:         tls.VersionTLS13,
 		CipherSuites:       GetSecuredCipherSuites(),
 	}
+	switch issuer, _ := os.LookupEnv("CERT_ISSUER"); issuer {
+	case "", certIssuerSelfSigned:
+		// Generate a self-signed certificate for the CSI driver to trust,
+		// since we will always be inside the same Pod talking over localhost.
+		tlsCert, err := generateX509Certificate()
+		if err != nil {
+			return err
+		}
+		tlsConfig.Certificates = []tls.Certificate{tlsCert}
+	case certIssuerVault:
+		// Get a certificate signed by Vault, so that the CSI driver can
+		// verify it with the CA of Vault.
+		vi, err := newVaultIssuerFromEnv(log)
+		if err != nil {
+			return fmt.Errorf("configuring vault certificates: %w", err)
+		}
+		if err := vi.Start(context.Background()); err != nil {
+			return err
+		}
+		tlsConfig.GetCertificate = vi.GetCertificate
+	default:
+		return fmt.Errorf("unknown certificate issuer %q", issuer)
+	}
 
 	var proxyInstances []*ProxyInstance
 	for _, v := range configs {
@@ -292,6 +418,7 @@ func run(log *logrus.Entry) error {
 			"isDefault":        v.IsDefault,
 			"systemID":         v.SystemID,
 			"insecure":         v.Insecure,
+			"socketPath":       v.SocketPath,
 		}
 
 		log.WithFields(fields).Infof("main: config: ")
@@ -303,6 +430,9 @@ func run(log *logrus.Entry) error {
 			IntendedEndpoint: v.IntendedEndpoint,
 			SystemID:         v.SystemID,
 			TLSConfig:        tlsConfig,
+			Secret:           listenerSecret,
+			SocketDir:        socketDir,
+			SocketPath:       v.SocketPath,
 		}
 		proxyInstances = append(proxyInstances, pi)
 	}
@@ -312,7 +442,7 @@ func run(log *logrus.Entry) error {
 		go func(pi *ProxyInstance) {
 			defer wg.Done()
 			defer pi.Stop()
-			err := pi.Start(proxyHost, access, refresh)
+			err := pi.Start(proxyHost, tokens)
 			if err != nil {
 				fmt.Printf("error: %+v\n", err)
 				return
@@ -325,6 +455,59 @@ func run(log *logrus.Entry) error {
 	return nil
 }
 
+// readTokens returns the selector of the tokens of the requests. The tokens
+// of ACCESS_TOKEN and REFRESH_TOKEN are used for all requests, unless
+// TOKENS_FILE names a file of token rules, for drivers that serve the
+// volumes of more than one tenant. They are then used for the requests that
+// match no rule, if they are set.
+func readTokens() (*TokenSelector, error) {
+	refresh, hasRefresh := os.LookupEnv("REFRESH_TOKEN")
+	access, hasAccess := os.LookupEnv("ACCESS_TOKEN")
+	f, ok := os.LookupEnv("TOKENS_FILE")
+	if !ok {
+		if !hasRefresh {
+			return nil, errors.New("missing refresh token")
+		}
+		if !hasAccess {
+			return nil, errors.New("missing access token")
+		}
+		return NewTokenSelector(nil, NewTokens(access, refresh)), nil
+	}
+
+	rules, err := readTokenRules(f)
+	if err != nil {
+		return nil, err
+	}
+	var fallback *Tokens
+	switch {
+	case hasAccess && hasRefresh:
+		fallback = NewTokens(access, refresh)
+	case hasAccess || hasRefresh:
+		return nil, errors.New("missing access or refresh token")
+	case len(rules) == 0:
+		return nil, errors.New("missing access token")
+	}
+	return NewTokenSelector(rules, fallback), nil
+}
+
+// readListenerSecret returns the secret that the driver must send to use the
+// listeners, from LISTENER_SECRET or the file named by LISTENER_SECRET_FILE,
+// e.g. a Secret mounted into both the driver and the sidecar containers.
+func readListenerSecret() (string, error) {
+	if v, ok := os.LookupEnv("LISTENER_SECRET"); ok {
+		return v, nil
+	}
+	f, ok := os.LookupEnv("LISTENER_SECRET_FILE")
+	if !ok {
+		return "", nil
+	}
+	b, err := os.ReadFile(filepath.Clean(f))
+	if err != nil {
+		return "", fmt.Errorf("reading listener secret: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
 func refreshTokens(proxyHost url.URL, refreshToken string, accessToken *string, log *logrus.Entry) error {
 	type tokenPair struct {
 		RefreshToken string `json:"refreshToken"`
@@ -346,30 +529,9 @@ func refreshTokens(proxyHost url.URL, refreshToken string, accessToken *string,
 		log.WithError(err).Error("parsing refresh url")
 		return err
 	}
-	httpClient := &http.Client{}
-	if insecureProxy {
-		httpClient.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true, // #nosec G402
-				MinVersion:         tls.VersionTLS12,
-				MaxVersion:         tls.VersionTLS13,
-				CipherSuites:       GetSecuredCipherSuites(),
-			},
-		}
-	} else {
-		pool, err := getRootCertificatePool(log)
-		if err != nil {
-			return err
-		}
-		httpClient.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				RootCAs:            pool,
-				InsecureSkipVerify: false,
-				MinVersion:         tls.VersionTLS12,
-				MaxVersion:         tls.VersionTLS13,
-				CipherSuites:       GetSecuredCipherSuites(),
-			},
-		}
+	httpClient, err := proxyHTTPClient(log)
+	if err != nil {
+		return err
 	}
 
 	resp, err := httpPost(httpClient, proxyHost.ResolveReference(proxyRefresh).String(), ContentType, bytes.NewReader(reqBytes))
@@ -397,6 +559,36 @@ func refreshTokens(proxyHost url.URL, refreshToken string, accessToken *string,
 	return nil
 }
 
+// proxyHTTPClient returns a client of the REST API of the proxy-server.
+func proxyHTTPClient(log *logrus.Entry) (*http.Client, error) {
+	httpClient := &http.Client{}
+	if insecureProxy {
+		httpClient.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true, // #nosec G402
+				MinVersion:         tls.VersionTLS12,
+				MaxVersion:         tls.VersionTLS13,
+				CipherSuites:       GetSecuredCipherSuites(),
+			},
+		}
+	} else {
+		pool, err := getRootCertificatePool(log)
+		if err != nil {
+			return nil, err
+		}
+		httpClient.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:            pool,
+				InsecureSkipVerify: false,
+				MinVersion:         tls.VersionTLS12,
+				MaxVersion:         tls.VersionTLS13,
+				CipherSuites:       GetSecuredCipherSuites(),
+			},
+		}
+	}
+	return httpClient, nil
+}
+
 func defaultHTTPPost(c *http.Client, url, contentType string, body io.Reader) (*http.Response, error) {
 	return c.Post(url, contentType, body)
 }
@@ -451,9 +643,23 @@ func generateX509Certificate() (tls.Certificate, error) {
 	return tlsCert, nil
 }
 
+// requiredMounts returns the files and directories the sidecar needs: the
+// config of the storage systems, the root certificate of the proxy-server
+// unless it is not verified and the directory of the listener sockets.
+func requiredMounts(socketDir string) []mounts.Mount {
+	ms := []mounts.Mount{{Name: "storage systems config", Path: configPath}}
+	if !insecureProxy {
+		ms = append(ms, mounts.Mount{Name: "root certificate", Path: rootCertificatePath})
+	}
+	if socketDir != "" {
+		ms = append(ms, mounts.Mount{Name: "listener socket directory", Path: socketDir, Writable: true})
+	}
+	return ms
+}
+
 func getRootCertificatePool(log *logrus.Entry) (*x509.CertPool, error) {
 	pool := x509.NewCertPool()
-	rootCAData, err := os.ReadFile("/etc/karavi-authorization/root-certificates/rootCertificate.pem")
+	rootCAData, err := os.ReadFile(rootCertificatePath)
 	if err != nil {
 		return nil, fmt.Errorf("reading root certificate file: %w", err)
 	}
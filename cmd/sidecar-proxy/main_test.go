@@ -16,10 +16,13 @@ package main
 
 import (
 	"crypto/tls"
+	"karavi-authorization/internal/web"
 	"net/http"
 	"net/http/httptest"
 	"net/http/httputil"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/sirupsen/logrus"
@@ -52,7 +55,7 @@ func TestProxyInstanceHandler(t *testing.T) {
 			rp:               rp,
 		}
 
-		handler := pi.Handler(*u, "access", "refresh")
+		handler := pi.Handler(*u, NewTokenSelector(nil, NewTokens("access", "refresh")))
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -72,4 +75,172 @@ func TestProxyInstanceHandler(t *testing.T) {
 			t.Errorf("got %s, want %s", fwdFor, want)
 		}
 	})
+	t.Run("it passes the deny reason as headers", func(t *testing.T) {
+		body := `{"errorCode":507,"httpStatusCode":507,"message":"request denied: not enough quota","deny":{"code":"QUOTA_EXCEEDED","reason":"not enough quota","tenant":"mytenant","pool":"bronze","limit":10,"usage":8,"alternatives":[{"pool":"silver","available":-1},{"pool":"gold","available":20}]}}`
+		fakeProxyServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInsufficientStorage)
+			w.Write([]byte(body))
+		}))
+		defer fakeProxyServer.Close()
+
+		u, err := url.Parse(fakeProxyServer.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rp := httputil.NewSingleHostReverseProxy(u)
+		rp.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		}
+		rp.ModifyResponse = denyHeaders
+
+		pi := &ProxyInstance{
+			log:              logrus.NewEntry(logrus.New()),
+			PluginID:         "powerflex",
+			IntendedEndpoint: "https://powerflex.com",
+			SystemID:         "542a2d5f5122210f",
+			rp:               rp,
+		}
+
+		w := httptest.NewRecorder()
+		pi.Handler(*u, NewTokenSelector(nil, NewTokens("access", "refresh"))).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+
+		want := map[string]string{
+			web.HeaderDenyCode:         "QUOTA_EXCEEDED",
+			web.HeaderDenyReason:       "not enough quota",
+			web.HeaderDenyTenant:       "mytenant",
+			web.HeaderDenyPool:         "bronze",
+			web.HeaderDenyLimit:        "10",
+			web.HeaderDenyUsage:        "8",
+			web.HeaderDenyAlternatives: "silver,gold",
+		}
+		for k, v := range want {
+			if got := w.Header().Get(k); got != v {
+				t.Errorf("%s: got %q, want %q", k, got, v)
+			}
+		}
+		if w.Body.String() != body {
+			t.Errorf("expected the body to be passed through, got %q", w.Body.String())
+		}
+	})
+	t.Run("it requires the listener secret", func(t *testing.T) {
+		var gotSecret string
+		fakeProxyServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotSecret = r.Header.Get(HeaderListenerSecret)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer fakeProxyServer.Close()
+
+		u, err := url.Parse(fakeProxyServer.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rp := httputil.NewSingleHostReverseProxy(u)
+		rp.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		}
+
+		pi := &ProxyInstance{
+			log:      logrus.NewEntry(logrus.New()),
+			PluginID: "powerflex",
+			Secret:   "s3cret",
+			rp:       rp,
+		}
+		handler := pi.Handler(*u, NewTokenSelector(nil, NewTokens("access", "refresh")))
+
+		for _, tt := range []struct {
+			secret string
+			want   int
+		}{
+			{"", http.StatusForbidden},
+			{"wrong", http.StatusForbidden},
+			{"s3cret", http.StatusOK},
+		} {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.secret != "" {
+				r.Header.Set(HeaderListenerSecret, tt.secret)
+			}
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("secret %q: got status %d, want %d", tt.secret, w.Code, tt.want)
+			}
+		}
+		if gotSecret != "" {
+			t.Errorf("expected the secret not to be forwarded, got %q", gotSecret)
+		}
+	})
+}
+
+func TestProxyInstanceListen(t *testing.T) {
+	t.Run("it listens on a unix domain socket", func(t *testing.T) {
+		dir := t.TempDir()
+		pi := &ProxyInstance{Endpoint: "https://localhost:443", SocketDir: dir}
+
+		l, err := pi.listen()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+
+		fi, err := os.Stat(filepath.Join(dir, "443.sock"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode()&os.ModeSocket == 0 || fi.Mode().Perm() != 0o600 {
+			t.Errorf("got mode %v, want a socket with permissions 0600", fi.Mode())
+		}
+	})
+	t.Run("it listens on the configured socket", func(t *testing.T) {
+		sock := filepath.Join(t.TempDir(), "powerflex.sock")
+		pi := &ProxyInstance{Endpoint: "https://localhost", SocketPath: sock}
+
+		l, err := pi.listen()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+
+		if l.Addr().Network() != "unix" || l.Addr().String() != sock {
+			t.Errorf("got address %s %s, want unix %s", l.Addr().Network(), l.Addr(), sock)
+		}
+	})
+}
+
+func TestReadListenerSecret(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(f, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LISTENER_SECRET_FILE", f)
+
+	got, err := readListenerSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "s3cret" {
+		t.Errorf("got secret %q, want %q", got, "s3cret")
+	}
+}
+
+func TestRequiredMounts(t *testing.T) {
+	defer func(v bool) { insecureProxy = v }(insecureProxy)
+
+	insecureProxy = true
+	got := requiredMounts("")
+	if len(got) != 1 || got[0].Path != configPath {
+		t.Errorf("got %+v, want only the config", got)
+	}
+
+	insecureProxy = false
+	got = requiredMounts("/var/run/csm")
+	if len(got) != 3 || got[1].Path != rootCertificatePath || got[2].Path != "/var/run/csm" || !got[2].Writable {
+		t.Errorf("got %+v, want the config, root certificate and a writable socket directory", got)
+	}
 }
@@ -0,0 +1,153 @@
+// Copyright © 2021-2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/web"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// portAllocationProxy is the PORT_ALLOCATION mode in which the ports of the
+// listeners are allocated by the proxy-server instead of being taken from
+// the endpoints of the config.
+const portAllocationProxy = "proxy"
+
+// allocatePorts requests the ports of the listeners of the configs that
+// listen on a port from the proxy-server, and sets the port of their
+// endpoints, or the endpoint on localhost if they have none. The access
+// token is refreshed if it expired.
+func allocatePorts(proxyHost url.URL, pluginID string, configs []SecretData, tokens *Tokens, log *logrus.Entry) error {
+	var systemIDs []string
+	for _, c := range configs {
+		if c.SocketPath == "" {
+			systemIDs = append(systemIDs, c.SystemID)
+		}
+	}
+	if len(systemIDs) == 0 {
+		return nil
+	}
+
+	httpClient, err := proxyHTTPClient(log)
+	if err != nil {
+		return err
+	}
+	reqBytes, err := jsonMarshal(&proxy.PortAllocationBody{PluginID: pluginID, SystemIDs: systemIDs})
+	if err != nil {
+		return err
+	}
+	u := proxyHost.ResolveReference(&url.URL{Path: web.VersionedPath(web.RoutePorts)}).String()
+	var access string
+	post := func() (*http.Response, error) {
+		access = tokens.Access()
+		req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(reqBytes))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", ContentType)
+		req.Header.Set("Authorization", "Bearer "+access)
+		return httpClient.Do(req)
+	}
+
+	resp, err := post()
+	if err != nil {
+		return fmt.Errorf("requesting ports: %w", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		if err := tokens.Refresh(proxyHost, access, log); err != nil {
+			return fmt.Errorf("refreshing tokens: %w", err)
+		}
+		resp, err = post()
+		if err != nil {
+			return fmt.Errorf("requesting ports: %w", err)
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("requesting ports: status code was %d", resp.StatusCode)
+	}
+
+	var allocation proxy.PortAllocationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&allocation); err != nil {
+		return fmt.Errorf("decoding ports: %w", err)
+	}
+	for i, c := range configs {
+		if c.SocketPath != "" {
+			continue
+		}
+		port, ok := allocation.Ports[c.SystemID]
+		if !ok {
+			return fmt.Errorf("no port allocated to system %s", c.SystemID)
+		}
+		ep, err := endpointWithPort(c.Endpoint, port)
+		if err != nil {
+			return fmt.Errorf("endpoint of system %s: %w", c.SystemID, err)
+		}
+		configs[i].Endpoint = ep
+		log.WithFields(logrus.Fields{
+			"systemID": c.SystemID,
+			"endpoint": ep,
+		}).Info("main: allocated port")
+	}
+	return nil
+}
+
+// endpointWithPort returns the endpoint with the port, or the endpoint on
+// localhost if it is empty.
+func endpointWithPort(endpoint string, port int) (string, error) {
+	if endpoint == "" {
+		endpoint = "https://localhost"
+	}
+	ep, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	host := ep.Hostname()
+	if host == "" {
+		host = "localhost"
+	}
+	ep.Host = net.JoinHostPort(host, strconv.Itoa(port))
+	return ep.String(), nil
+}
+
+// writeEndpoints writes the endpoints of the configs by system ID as JSON to
+// the file, e.g. in a volume shared with the driver container, so that the
+// driver can be configured with the allocated ports.
+func writeEndpoints(file string, configs []SecretData) error {
+	endpoints := make(map[string]string, len(configs))
+	for _, c := range configs {
+		endpoints[c.SystemID] = c.Endpoint
+	}
+	b, err := jsonMarshal(endpoints)
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(filepath.Clean(tmp), b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
@@ -0,0 +1,100 @@
+// Copyright © 2021-2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"karavi-authorization/internal/proxy"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestAllocatePorts(t *testing.T) {
+	defer func() { insecureProxy = false }()
+	insecureProxy = true
+
+	var gotBody proxy.PortAllocationBody
+	fakeProxyServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/proxy/refresh-token":
+			_ = json.NewEncoder(w).Encode(map[string]string{"accessToken": "new-access"})
+		case "/api/v1/ports/":
+			if r.Header.Get("Authorization") != "Bearer new-access" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewDecoder(r.Body).Decode(&gotBody)
+			_ = json.NewEncoder(w).Encode(&proxy.PortAllocationResponse{Ports: map[string]int{"542a2d5f5122210f": 9400, "1b2e5a7c9d3f4a6b": 9401}})
+		default:
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+	}))
+	defer fakeProxyServer.Close()
+	u, err := url.Parse(fakeProxyServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configs := []SecretData{
+		{SystemID: "542a2d5f5122210f", Endpoint: "https://localhost:9000"},
+		{SystemID: "1b2e5a7c9d3f4a6b"},
+		{SystemID: "000197900714", SocketPath: "/var/run/powermax.sock"},
+	}
+	tokens := NewTokens("expired-access", "refresh")
+	err = allocatePorts(*u, "csi-vxflexos", configs, tokens, logrus.NewEntry(logrus.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := proxy.PortAllocationBody{PluginID: "csi-vxflexos", SystemIDs: []string{"542a2d5f5122210f", "1b2e5a7c9d3f4a6b"}}
+	if !reflect.DeepEqual(gotBody, want) {
+		t.Errorf("got body %+v, want %+v", gotBody, want)
+	}
+	if access := tokens.Access(); access != "new-access" {
+		t.Errorf("got access token %q, want it refreshed", access)
+	}
+	var gotEndpoints []string
+	for _, c := range configs {
+		gotEndpoints = append(gotEndpoints, c.Endpoint)
+	}
+	if want := []string{"https://localhost:9400", "https://localhost:9401", ""}; !reflect.DeepEqual(gotEndpoints, want) {
+		t.Errorf("got endpoints %v, want %v", gotEndpoints, want)
+	}
+
+	t.Run("it writes the allocated endpoints", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "endpoints.json")
+		if err := writeEndpoints(file, configs[:2]); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]string
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]string{"542a2d5f5122210f": "https://localhost:9400", "1b2e5a7c9d3f4a6b": "https://localhost:9401"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
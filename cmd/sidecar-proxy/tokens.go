@@ -0,0 +1,178 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Tokens are the access and refresh tokens of a tenant. The access token is
+// replaced when it is refreshed, for all of the listeners that use it.
+type Tokens struct {
+	mu      sync.Mutex
+	access  string
+	refresh string
+}
+
+// NewTokens returns the Tokens of a tenant.
+func NewTokens(access, refresh string) *Tokens {
+	return &Tokens{access: access, refresh: refresh}
+}
+
+// Access returns the current access token.
+func (t *Tokens) Access() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.access
+}
+
+// Refresh refreshes the access token that was rejected. It does nothing if
+// the token was already refreshed by another request.
+func (t *Tokens) Refresh(proxyHost url.URL, rejected string, log *logrus.Entry) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.access != rejected {
+		return nil
+	}
+	return refreshTokens(proxyHost, t.refresh, &t.access, log)
+}
+
+// TokenRule selects the tokens of a tenant for the requests to some of the
+// storage systems, or for the volumes of some of the namespaces, when one
+// driver serves the volumes of more than one tenant. A rule without systems
+// or namespaces matches any.
+type TokenRule struct {
+	SystemIDs []string `json:"systemIDs,omitempty"`
+	// Namespaces are patterns, as in path.Match, of the namespaces of the
+	// volumes, from the x-csi-pv-namespace header of the driver. Requests
+	// without the header, e.g. listing volumes, do not match them.
+	Namespaces   []string `json:"namespaces,omitempty"`
+	AccessToken  string   `json:"accessToken"`
+	RefreshToken string   `json:"refreshToken"`
+}
+
+func (r TokenRule) matches(systemID, namespace string) bool {
+	if len(r.SystemIDs) > 0 && !contains(r.SystemIDs, systemID) {
+		return false
+	}
+	if len(r.Namespaces) == 0 {
+		return true
+	}
+	if namespace == "" {
+		return false
+	}
+	for _, p := range r.Namespaces {
+		if ok, _ := path.Match(p, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// readTokenRules reads the token rules from the file named by TOKENS_FILE,
+// e.g. a Secret mounted into the sidecar container.
+func readTokenRules(file string) ([]TokenRule, error) {
+	b, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("reading tokens: %w", err)
+	}
+	var rules []TokenRule
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("decoding tokens: %w", err)
+	}
+	for i, r := range rules {
+		if r.AccessToken == "" || r.RefreshToken == "" {
+			return nil, fmt.Errorf("tokens %d: missing access or refresh token", i)
+		}
+		for _, p := range r.Namespaces {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("tokens %d: namespace pattern %q: %w", i, p, err)
+			}
+		}
+	}
+	return rules, nil
+}
+
+type tokenRule struct {
+	TokenRule
+	tokens *Tokens
+}
+
+// TokenSelector selects the tokens of each request to a storage system, by
+// the first rule that matches it, or the default tokens if none does.
+type TokenSelector struct {
+	rules    []tokenRule
+	fallback *Tokens
+}
+
+// NewTokenSelector returns a TokenSelector of the rules. The fallback, the
+// tokens of ACCESS_TOKEN and REFRESH_TOKEN, may be nil. Rules with the same
+// tokens share them, so that they are refreshed once.
+func NewTokenSelector(rules []TokenRule, fallback *Tokens) *TokenSelector {
+	s := &TokenSelector{fallback: fallback}
+	shared := make(map[string]*Tokens)
+	for _, r := range rules {
+		t, ok := shared[r.RefreshToken]
+		if !ok {
+			t = NewTokens(r.AccessToken, r.RefreshToken)
+			shared[r.RefreshToken] = t
+		}
+		s.rules = append(s.rules, tokenRule{TokenRule: r, tokens: t})
+	}
+	return s
+}
+
+// Select returns the tokens for a request to the storage system for a
+// volume of the namespace, which may be empty. It returns nil if there are
+// none.
+func (s *TokenSelector) Select(systemID, namespace string) *Tokens {
+	for _, r := range s.rules {
+		if r.matches(systemID, namespace) {
+			return r.tokens
+		}
+	}
+	return s.fallback
+}
+
+// Default returns the tokens for requests that are not for a storage
+// system, e.g. allocating ports: the fallback, or else those of the first
+// rule.
+func (s *TokenSelector) Default() (*Tokens, error) {
+	if s.fallback != nil {
+		return s.fallback, nil
+	}
+	if len(s.rules) > 0 {
+		return s.rules[0].tokens, nil
+	}
+	return nil, errors.New("no tokens configured")
+}
@@ -0,0 +1,181 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"karavi-authorization/internal/proxy"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestTokenSelector(t *testing.T) {
+	sut := NewTokenSelector([]TokenRule{
+		{Namespaces: []string{"team-a-*"}, AccessToken: "a-access", RefreshToken: "a-refresh"},
+		{SystemIDs: []string{"1b2e5a7c9d3f4a6b"}, AccessToken: "b-access", RefreshToken: "b-refresh"},
+		{SystemIDs: []string{"542a2d5f5122210f"}, Namespaces: []string{"team-b"}, AccessToken: "b-access", RefreshToken: "b-refresh"},
+	}, NewTokens("default-access", "default-refresh"))
+
+	tests := map[string]struct {
+		systemID, namespace string
+		want                string
+	}{
+		"by namespace":            {"542a2d5f5122210f", "team-a-dev", "a-access"},
+		"by system":               {"1b2e5a7c9d3f4a6b", "team-a-dev", "a-access"},
+		"by system and namespace": {"542a2d5f5122210f", "team-b", "b-access"},
+		"by system only":          {"1b2e5a7c9d3f4a6b", "", "b-access"},
+		"without namespace":       {"542a2d5f5122210f", "", "default-access"},
+		"no match":                {"542a2d5f5122210f", "team-c", "default-access"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := sut.Select(tc.systemID, tc.namespace)
+			if got == nil || got.Access() != tc.want {
+				t.Errorf("got %v, want tokens %q", got, tc.want)
+			}
+		})
+	}
+	t.Run("rules with the same tokens share them", func(t *testing.T) {
+		if sut.Select("1b2e5a7c9d3f4a6b", "") != sut.Select("542a2d5f5122210f", "team-b") {
+			t.Error("expected the tokens to be shared")
+		}
+	})
+	t.Run("it selects nothing without a fallback", func(t *testing.T) {
+		sut := NewTokenSelector([]TokenRule{{Namespaces: []string{"team-a"}, AccessToken: "a-access", RefreshToken: "a-refresh"}}, nil)
+
+		if got := sut.Select("542a2d5f5122210f", ""); got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+	})
+}
+
+func TestReadTokens(t *testing.T) {
+	writeRules := func(t *testing.T, rules string) string {
+		f := filepath.Join(t.TempDir(), "tokens.json")
+		if err := os.WriteFile(f, []byte(rules), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	t.Run("it reads the token rules", func(t *testing.T) {
+		t.Setenv("TOKENS_FILE", writeRules(t, `[{"namespaces":["team-a"],"accessToken":"a-access","refreshToken":"a-refresh"}]`))
+
+		got, err := readTokens()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tokens := got.Select("542a2d5f5122210f", "team-a"); tokens == nil || tokens.Access() != "a-access" {
+			t.Errorf("got %v, want the tokens of the rule", tokens)
+		}
+	})
+	t.Run("it requires tokens", func(t *testing.T) {
+		for name, rules := range map[string]string{
+			"no rules":        `[]`,
+			"missing token":   `[{"namespaces":["team-a"],"accessToken":"a-access"}]`,
+			"invalid pattern": `[{"namespaces":["team-["],"accessToken":"a-access","refreshToken":"a-refresh"}]`,
+		} {
+			t.Setenv("TOKENS_FILE", writeRules(t, rules))
+
+			if _, err := readTokens(); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+	})
+	t.Run("it uses the tokens of the environment without rules", func(t *testing.T) {
+		t.Setenv("ACCESS_TOKEN", "access")
+		t.Setenv("REFRESH_TOKEN", "refresh")
+
+		got, err := readTokens()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tokens, err := got.Default(); err != nil || tokens.Access() != "access" {
+			t.Errorf("got %v, %v, want the tokens of the environment", tokens, err)
+		}
+	})
+}
+
+func TestProxyInstanceHandler_Tenants(t *testing.T) {
+	defer func() { insecureProxy = false }()
+	insecureProxy = true
+
+	var gotAuthz []string
+	fakeProxyServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/proxy/refresh-token" {
+			_ = json.NewEncoder(w).Encode(map[string]string{"accessToken": "b-new-access"})
+			return
+		}
+		gotAuthz = append(gotAuthz, r.Header.Get(HeaderAuthz))
+		if r.Header.Get(HeaderAuthz) == "Bearer b-access" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer fakeProxyServer.Close()
+
+	u, err := url.Parse(fakeProxyServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp := httputil.NewSingleHostReverseProxy(u)
+	rp.Transport = &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+		},
+	}
+	pi := &ProxyInstance{
+		log:      logrus.NewEntry(logrus.New()),
+		PluginID: "powerflex",
+		SystemID: "542a2d5f5122210f",
+		rp:       rp,
+	}
+	tokens := NewTokenSelector([]TokenRule{
+		{Namespaces: []string{"team-a"}, AccessToken: "a-access", RefreshToken: "a-refresh"},
+		{Namespaces: []string{"team-b"}, AccessToken: "b-access", RefreshToken: "b-refresh"},
+	}, nil)
+	handler := pi.Handler(*u, tokens)
+
+	for _, ns := range []string{"team-a", "team-b", "team-b"} {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set(proxy.HeaderPVNamespace, ns)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := []string{"Bearer a-access", "Bearer b-access", "Bearer b-new-access"}
+	if len(gotAuthz) != len(want) {
+		t.Fatalf("got %v, want %v", gotAuthz, want)
+	}
+	for i := range want {
+		if gotAuthz[i] != want[i] {
+			t.Errorf("request %d: got %q, want %q", i, gotAuthz[i], want[i])
+		}
+	}
+	if access := tokens.Select("542a2d5f5122210f", "team-a").Access(); access != "a-access" {
+		t.Errorf("got %q, want the tokens of the other tenant unchanged", access)
+	}
+	if w.Code != http.StatusForbidden {
+		t.Errorf("got status %d, want %d for a request without tokens", w.Code, http.StatusForbidden)
+	}
+}
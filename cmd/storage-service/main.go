@@ -15,30 +15,25 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"io"
+	"karavi-authorization/internal/appconfig"
+	"karavi-authorization/internal/envelope"
+	"karavi-authorization/internal/errcode"
 	"karavi-authorization/internal/k8s"
+	"karavi-authorization/internal/mounts"
 	storage "karavi-authorization/internal/storage-service"
 	"karavi-authorization/internal/storage-service/middleware"
+	"karavi-authorization/internal/validation"
 	"karavi-authorization/pb"
-	stdLog "log"
 	"net"
 	"os"
 	"strconv"
-	"strings"
 	"sync"
+	"time"
 
-	"github.com/fsnotify/fsnotify"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/zipkin"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	"go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"google.golang.org/grpc"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -47,9 +42,8 @@ import (
 const (
 	listenAddr                  = ":50051"
 	namespaceEnv                = "NAMESPACE"
-	logLevel                    = "LOG_LEVEL"
-	logFormat                   = "LOG_FORMAT"
 	concurrentPowerFlexRequests = "CONCURRENT_POWERFLEX_REQUESTS"
+	csmConfigParamsDir          = "/etc/karavi-authorization/csm-config-params/"
 )
 
 var cfg Config
@@ -57,10 +51,12 @@ var cfg Config
 // Config is the configuration details on the storage-service
 type Config struct {
 	GrpcListenAddr string
-	Zipkin         struct {
-		CollectorURI string
-		ServiceName  string
-		Probability  float64
+	Zipkin         appconfig.Zipkin
+	Storage        struct {
+		MasterKeyFile string
+	}
+	Health struct {
+		Interval time.Duration
 	}
 }
 
@@ -68,22 +64,21 @@ func main() {
 	// define the logger
 	log := logrus.NewEntry(logrus.New())
 
-	// declare Config values
-	cfgViper := viper.New()
-	cfgViper.SetConfigName("config")
-	cfgViper.AddConfigPath(".")
-	cfgViper.AddConfigPath("/etc/karavi-authorization/config/")
-
-	cfgViper.SetDefault("grpclistenaddr", listenAddr)
-	cfgViper.SetDefault("zipkin.collectoruri", "http://localhost:9411/api/v2/spans")
-	cfgViper.SetDefault("zipkin.servicename", "proxy-server")
-	cfgViper.SetDefault("zipkin.probability", 0.8)
-
-	if err := cfgViper.ReadInConfig(); err != nil {
-		log.Fatalf("reading config file: %+v", err)
-	}
-	if err := cfgViper.Unmarshal(&cfg); err != nil {
-		log.Fatalf("decoding config file: %+v", err)
+	// declare Config values. Environment variables override the config
+	// file, with the dots of keys replaced by underscores, e.g.
+	// ZIPKIN_COLLECTORURI.
+	_, err := appconfig.Load(log, appconfig.Source{
+		Name:  "config",
+		Paths: []string{".", "/etc/karavi-authorization/config/"},
+		Defaults: appconfig.Defaults{
+			"grpclistenaddr":        listenAddr,
+			"storage.masterkeyfile": "",
+			"health.interval":       storage.DefaultHealthInterval,
+		}.With(appconfig.ZipkinDefaults(appconfig.DefaultZipkinCollectorURI)),
+		Optional: true,
+	}, &cfg)
+	if err != nil {
+		log.Fatalf("%+v", err)
 	}
 
 	// define the storage service
@@ -104,39 +99,50 @@ func main() {
 		Namespace: ns,
 		Log:       log,
 	}
+	if cfg.Storage.MasterKeyFile != "" {
+		key, err := envelope.LoadMasterKey(cfg.Storage.MasterKeyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		api.Keys = key
+	}
 
-	storageSvc := storage.NewService(api, storage.NewSystemValidator(api, log))
+	// Move the systems of the storage secret of an older release to
+	// secrets of their own.
+	if n, err := api.MigrateStorage(context.Background()); err != nil {
+		log.WithError(err).Fatal("migrating storage systems")
+	} else if n > 0 {
+		log.WithField("systems", n).Info("migrated storage systems")
+	}
 
-	// read and watch configuration
-	csmViper := viper.New()
-	csmViper.SetConfigName("csm-config-params")
-	csmViper.AddConfigPath("/etc/karavi-authorization/csm-config-params/")
+	var svcOpts []storage.Option
+	// An interval of zero disables the storage health checks.
+	if cfg.Health.Interval > 0 {
+		checker := storage.NewHealthChecker(api,
+			storage.WithHealthInterval(cfg.Health.Interval),
+			storage.WithHealthLogger(log))
+		go checker.Run(context.Background())
+		svcOpts = append(svcOpts, storage.WithHealthChecker(checker))
+	}
+
+	storageSvc := storage.NewService(api, storage.NewSystemValidator(api, log), svcOpts...)
 
-	if err := csmViper.ReadInConfig(); err != nil {
-		log.Fatalf("reading config file: %+v", err)
+	// read and watch configuration
+	if err := mounts.Check(mounts.Mount{Name: "csm-config-params", Path: csmConfigParamsDir}); err != nil {
+		log.Fatalf("checking mounts: %v", err)
 	}
 
-	updateLoggingSettings := func(log *logrus.Entry) {
-		logFormat := csmViper.GetString(logFormat)
-		if strings.EqualFold(logFormat, "json") {
-			log.Logger.SetFormatter(&logrus.JSONFormatter{})
-		} else {
-			// use text formatter by default
-			log.Logger.SetFormatter(&logrus.TextFormatter{})
-		}
-		logLevel := csmViper.GetString(logLevel)
-		level, err := logrus.ParseLevel(logLevel)
-		if err != nil {
-			// use INFO level by default
-			level = logrus.InfoLevel
-		}
-		log.Logger.SetLevel(level)
-		log.WithField("LOG_LEVEL", level).Info("Configuration updated")
+	csmCfg, err := appconfig.Load(log, appconfig.Source{
+		Name:  "csm-config-params",
+		Paths: []string{csmConfigParamsDir},
+	}, nil)
+	if err != nil {
+		log.Fatalf("%+v", err)
 	}
-	updateLoggingSettings(log)
+	csmCfg.WatchLogging(log, appconfig.DefaultLogKeys)
 
 	updateConcurrentPowerFlexRequests := func(s *storage.Service, log *logrus.Entry) {
-		requests := csmViper.GetString(concurrentPowerFlexRequests)
+		requests := csmCfg.Viper().GetString(concurrentPowerFlexRequests)
 		n, err := strconv.Atoi(requests)
 		if err != nil {
 			log.WithError(err).Fatal("CONCURRENT_POWERFLEX_REQUESTS was not set to a valid number")
@@ -145,19 +151,13 @@ func main() {
 		log.WithField(concurrentPowerFlexRequests, n).Info("Configuration updated")
 	}
 	updateConcurrentPowerFlexRequests(storageSvc, log)
-
-	csmViper.WatchConfig()
-	csmViper.OnConfigChange(func(_ fsnotify.Event) {
-		updateLoggingSettings(log)
+	csmCfg.Watch(func() {
 		updateConcurrentPowerFlexRequests(storageSvc, log)
 	})
 
 	// Start tracing support
 
-	_, err = initTracing(log,
-		cfg.Zipkin.CollectorURI,
-		"csm-authorization-storage-service",
-		cfg.Zipkin.Probability)
+	_, err = appconfig.InitTracing(log, cfg.Zipkin, "csm-authorization-storage-service")
 	if err != nil {
 		log.WithError(err).Println("main: initializng tracing")
 	}
@@ -172,41 +172,9 @@ func main() {
 		}
 	}()
 
-	gs := grpc.NewServer(grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor()), grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()))
+	gs := grpc.NewServer(grpc.ChainUnaryInterceptor(otelgrpc.UnaryServerInterceptor(), errcode.UnaryServerInterceptor(), validation.UnaryServerInterceptor()), grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()))
 	pb.RegisterStorageServiceServer(gs, middleware.NewStorageTelemetryMW(log, storageSvc))
 
 	log.Infof("Serving storage service on %s", cfg.GrpcListenAddr)
 	log.Fatal(gs.Serve(l))
 }
-
-func initTracing(log *logrus.Entry, uri, name string, prob float64) (*trace.TracerProvider, error) {
-	if len(strings.TrimSpace(uri)) == 0 {
-		return nil, nil
-	}
-
-	log.Info("main: initializing otel/zipkin tracing support")
-
-	exporter, err := zipkin.New(
-		uri,
-		zipkin.WithLogger(stdLog.New(io.Discard, "", stdLog.LstdFlags)),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("creating zipkin exporter: %w", err)
-	}
-
-	tp := trace.NewTracerProvider(
-		trace.WithSampler(trace.TraceIDRatioBased(prob)),
-		trace.WithBatcher(
-			exporter,
-			trace.WithMaxExportBatchSize(trace.DefaultMaxExportBatchSize),
-			trace.WithBatchTimeout(trace.DefaultScheduleDelay),
-			trace.WithMaxExportBatchSize(trace.DefaultMaxExportBatchSize),
-		),
-		trace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
-			attribute.KeyValue{Key: semconv.ServiceNameKey, Value: attribute.StringValue(name)})),
-	)
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}))
-
-	return tp, nil
-}
@@ -15,39 +15,32 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"io"
+	"karavi-authorization/internal/appconfig"
+	"karavi-authorization/internal/errcode"
+	"karavi-authorization/internal/eventsvc"
+	"karavi-authorization/internal/mounts"
+	"karavi-authorization/internal/reportsvc"
 	"karavi-authorization/internal/tenantsvc"
 	"karavi-authorization/internal/tenantsvc/middleware"
 	"karavi-authorization/internal/token/jwx"
+	"karavi-authorization/internal/validation"
+	"karavi-authorization/internal/webhook"
 	"karavi-authorization/pb"
 	"net"
 	"os"
-	"strings"
 	"time"
 
-	stdLog "log"
-
-	"github.com/fsnotify/fsnotify"
 	"github.com/go-redis/redis"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/zipkin"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	"go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"google.golang.org/grpc"
 )
 
-const (
-	logLevel  = "LOG_LEVEL"
-	logFormat = "LOG_FORMAT"
-)
+const csmConfigParamsDir = "/etc/karavi-authorization/csm-config-params/"
 
 var cfg Config
 
@@ -55,20 +48,47 @@ var cfg Config
 type Config struct {
 	GrpcListenAddr string
 	Version        string
-	Zipkin         struct {
-		CollectorURI string
-		ServiceName  string
-		Probability  float64
-	}
-	Web struct {
+	Zipkin         appconfig.Zipkin
+	Web            struct {
 		DebugHost        string
 		ShutdownTimeout  time.Duration
 		JWTSigningSecret string
+		// JWTSigningAlgorithm is HS256, HS512 or RS256. It must be the
+		// same for the proxy-server.
+		JWTSigningAlgorithm string
+		JWTIssuer           string
+		JWTAudience         string
+		// JWTEncryptionKey, when set, encrypts the tokens of tenants so
+		// that their claims are hidden from the CSI driver side. It must
+		// be the same for the proxy-server.
+		JWTEncryptionKey string
+		// LegacyTokensUntil is the end, in RFC 3339, of the upgrade window
+		// in which tokens in the legacy format are accepted. They are not
+		// accepted if it is empty.
+		LegacyTokensUntil string
 	}
 	Database struct {
 		Host     string
 		Password string
 	}
+	Tenant struct {
+		// DeleteRetention is how long deleted tenants can be restored
+		// for. Tenants are deleted at once if it is 0.
+		DeleteRetention time.Duration
+		// Webhooks are called before tenants are created or deleted, or
+		// their roles are bound or unbound, to approve the change, and
+		// after it to be informed about it.
+		Webhooks []webhook.Config
+	}
+	Report struct {
+		// SnapshotInterval is how often the usage of tenants is snapshot
+		// for usage history reports. The last snapshot of a day is kept.
+		// No snapshots are taken if it is 0.
+		SnapshotInterval time.Duration
+		// Retention is how long snapshots are kept for. They are kept
+		// forever if it is 0.
+		Retention time.Duration
+	}
 }
 
 func main() {
@@ -77,69 +97,54 @@ func main() {
 	redisHost := flag.String("redis-host", "", "address of redis host")
 	flag.Parse()
 
-	cfgViper := viper.New()
-	cfgViper.SetConfigName("config")
-	cfgViper.AddConfigPath(".")
-	cfgViper.AddConfigPath("/etc/karavi-authorization/config/")
-
-	cfgViper.SetDefault("grpclistenaddr", ":50051")
-
-	cfgViper.SetDefault("web.debughost", ":9090")
-	cfgViper.SetDefault("web.shutdowntimeout", 15*time.Second)
-	cfgViper.SetDefault("web.jwtsigningsecret", "secret")
-
-	cfgViper.SetDefault("zipkin.collectoruri", "http://localhost:9411/api/v2/spans")
-	cfgViper.SetDefault("zipkin.servicename", "proxy-server")
-	cfgViper.SetDefault("zipkin.probability", 0.8)
-
-	cfgViper.SetDefault("database.host", "redis.karavi.svc.cluster.local:6379")
-	cfgViper.SetDefault("database.password", "")
-
-	if err := cfgViper.ReadInConfig(); err != nil {
-		log.Fatalf("reading config file: %+v", err)
-	}
-	if err := cfgViper.Unmarshal(&cfg); err != nil {
-		log.Fatalf("decoding config file: %+v", err)
+	// Environment variables override the config file, with the dots of
+	// keys replaced by underscores, e.g. DATABASE_PASSWORD.
+	appCfg, err := appconfig.Load(log, appconfig.Source{
+		Name:  "config",
+		Paths: []string{".", "/etc/karavi-authorization/config/"},
+		Defaults: appconfig.Defaults{
+			"grpclistenaddr": ":50051",
+
+			"web.debughost":           ":9090",
+			"web.shutdowntimeout":     15 * time.Second,
+			"web.jwtsigningsecret":    "secret",
+			"web.jwtsigningalgorithm": string(jwx.HS256),
+			"web.jwtissuer":           "",
+			"web.jwtaudience":         "",
+			"web.jwtencryptionkey":    "",
+			"web.legacytokensuntil":   "",
+
+			"database.host":     "redis.karavi.svc.cluster.local:6379",
+			"database.password": "",
+
+			"tenant.deleteretention": 0,
+
+			"report.snapshotinterval": time.Hour,
+			"report.retention":        400 * 24 * time.Hour,
+		}.With(appconfig.ZipkinDefaults(appconfig.DefaultZipkinCollectorURI)),
+		Optional: true,
+	}, &cfg)
+	if err != nil {
+		log.Fatalf("%+v", err)
 	}
-
-	cfgViper.WatchConfig()
-	cfgViper.OnConfigChange(func(_ fsnotify.Event) {
-		updateConfiguration(cfgViper, log)
+	appCfg.Watch(func() {
+		updateConfiguration(appCfg.Viper(), log)
 	})
 
 	log.Infof("Config: %+v", cfg)
 
-	csmViper := viper.New()
-	csmViper.SetConfigName("csm-config-params")
-	csmViper.AddConfigPath("/etc/karavi-authorization/csm-config-params/")
-
-	if err := csmViper.ReadInConfig(); err != nil {
-		log.Fatalf("reading config file: %+v", err)
+	if err := mounts.Check(mounts.Mount{Name: "csm-config-params", Path: csmConfigParamsDir}); err != nil {
+		log.Fatalf("checking mounts: %v", err)
 	}
 
-	updateLoggingSettings := func(log *logrus.Entry) {
-		logFormat := csmViper.GetString(logFormat)
-		if strings.EqualFold(logFormat, "json") {
-			log.Logger.SetFormatter(&logrus.JSONFormatter{})
-		} else {
-			// use text formatter by default
-			log.Logger.SetFormatter(&logrus.TextFormatter{})
-		}
-		logLevel := csmViper.GetString(logLevel)
-		level, err := logrus.ParseLevel(logLevel)
-		if err != nil {
-			// use INFO level by default
-			level = logrus.InfoLevel
-		}
-		log.Logger.SetLevel(level)
+	csmCfg, err := appconfig.Load(log, appconfig.Source{
+		Name:  "csm-config-params",
+		Paths: []string{csmConfigParamsDir},
+	}, nil)
+	if err != nil {
+		log.Fatalf("%+v", err)
 	}
-	updateLoggingSettings(log)
-
-	csmViper.WatchConfig()
-	csmViper.OnConfigChange(func(_ fsnotify.Event) {
-		log.Info("csm-config-params changed!")
-		updateLoggingSettings(log)
-	})
+	csmCfg.WatchLogging(log, appconfig.DefaultLogKeys)
 
 	// Initialize the database connection
 
@@ -161,10 +166,7 @@ func main() {
 
 	// Start tracing support
 
-	_, err := initTracing(log,
-		cfg.Zipkin.CollectorURI,
-		"csm-authorization-tenant-service",
-		cfg.Zipkin.Probability)
+	_, err = appconfig.InitTracing(log, cfg.Zipkin, "csm-authorization-tenant-service")
 	if err != nil {
 		log.WithError(err).Println("main: initializng tracing")
 	}
@@ -181,14 +183,57 @@ func main() {
 		}
 	}()
 
+	legacyUntil, err := jwx.LegacyWindowEnd(cfg.Web.LegacyTokensUntil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	signingAlg, err := jwx.ParseSignatureAlgorithm(cfg.Web.JWTSigningAlgorithm)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hooks, err := webhook.New(log, cfg.Tenant.Webhooks)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	tenantsvc.JWTSigningSecret = cfg.Web.JWTSigningSecret
 	tenantSvc := tenantsvc.NewTenantService(
 		tenantsvc.WithLogger(log),
 		tenantsvc.WithRedis(rdb),
-		tenantsvc.WithTokenManager(jwx.NewTokenManager(jwx.HS256)))
-	gs := grpc.NewServer(grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor()), grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()))
+		tenantsvc.WithDeleteRetention(cfg.Tenant.DeleteRetention),
+		tenantsvc.WithWebhooks(hooks),
+		tenantsvc.WithTokenManager(jwx.NewTokenManager(signingAlg,
+			jwx.WithIssuer(cfg.Web.JWTIssuer),
+			jwx.WithAudience(cfg.Web.JWTAudience),
+			jwx.WithLegacyTokensUntil(legacyUntil),
+			jwx.WithEncryptionKey(cfg.Web.JWTEncryptionKey))))
+	if err := tenantSvc.MigrateTenantIDs(context.Background()); err != nil {
+		log.WithError(err).Error("migrating tenants to UUIDs")
+	}
+
+	gs := grpc.NewServer(grpc.ChainUnaryInterceptor(otelgrpc.UnaryServerInterceptor(), errcode.UnaryServerInterceptor(), validation.UnaryServerInterceptor()), grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()))
 	pb.RegisterTenantServiceServer(gs, middleware.NewTelemetryMW(log, tenantSvc))
 
+	// Usage history is kept alongside the tenants, whose quota usage it
+	// snapshots.
+	reportSvc := reportsvc.NewReportService(
+		reportsvc.WithLogger(log),
+		reportsvc.WithRedis(rdb),
+		reportsvc.WithTenants(reportsvc.LocalTenants{Server: tenantSvc}),
+		reportsvc.WithRetention(cfg.Report.Retention))
+	pb.RegisterReportServiceServer(gs, reportSvc)
+	if cfg.Report.SnapshotInterval > 0 {
+		go reportSvc.Run(context.Background(), cfg.Report.SnapshotInterval)
+	}
+
+	// Events are published by the tenant service and the proxy-server into
+	// the stream that is watched here.
+	pb.RegisterEventServiceServer(gs, eventsvc.NewEventService(
+		eventsvc.WithLogger(log),
+		eventsvc.WithRedis(rdb)))
+
 	log.Infof("Serving tenant service on %s", cfg.GrpcListenAddr)
 	log.Fatal(gs.Serve(l))
 }
@@ -202,35 +247,3 @@ func updateConfiguration(vc *viper.Viper, log *logrus.Entry) {
 	}
 	tenantsvc.JWTSigningSecret = jwtSigningSecret
 }
-
-func initTracing(log *logrus.Entry, uri, name string, prob float64) (*trace.TracerProvider, error) {
-	if len(strings.TrimSpace(uri)) == 0 {
-		return nil, nil
-	}
-
-	log.Info("main: initializing otel/zipkin tracing support")
-
-	exporter, err := zipkin.New(
-		uri,
-		zipkin.WithLogger(stdLog.New(io.Discard, "", stdLog.LstdFlags)),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("creating zipkin exporter: %w", err)
-	}
-
-	tp := trace.NewTracerProvider(
-		trace.WithSampler(trace.TraceIDRatioBased(prob)),
-		trace.WithBatcher(
-			exporter,
-			trace.WithMaxExportBatchSize(trace.DefaultMaxExportBatchSize),
-			trace.WithBatchTimeout(trace.DefaultScheduleDelay),
-			trace.WithMaxExportBatchSize(trace.DefaultMaxExportBatchSize),
-		),
-		trace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
-			attribute.KeyValue{Key: semconv.ServiceNameKey, Value: attribute.StringValue(name)})),
-	)
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}))
-
-	return tp, nil
-}
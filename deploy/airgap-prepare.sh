@@ -32,6 +32,8 @@ sed -i 's/\${BUILDER_TAG}/'${BUILDER_TAG}'/g' ${DIST}/deployment.yaml
 
 CRED_SHIELD_DEPLOYMENT_MANIFEST=${DIST}/deployment.yaml
 CRED_SHIELD_INGRESS_MANIFEST=ingress-traefik.yaml
+CRED_SHIELD_NGINX_INGRESS_MANIFEST=ingress-nginx.yaml
+CRED_SHIELD_LOADBALANCER_MANIFEST=service-loadbalancer.yaml
 CRED_SHIELD_TLS_OPTION_MANIFEST=tls-option.yaml
 CERT_MANAGER_MANIFEST=cert-manager.yaml
 CERT_MANAGER_CONFIG_MANIFEST=self-cert.yaml
@@ -92,7 +94,7 @@ else
 fi
 
 # Create the bundle airgap tarfile.
-cp $CRED_SHIELD_DEPLOYMENT_MANIFEST $CRED_SHIELD_INGRESS_MANIFEST $CERT_MANAGER_CONFIG_MANIFEST $CERT_MANIFEST $CRED_SHIELD_TLS_OPTION_MANIFEST $TLS_STORE_MANIFEST $DIST/.
+cp $CRED_SHIELD_DEPLOYMENT_MANIFEST $CRED_SHIELD_INGRESS_MANIFEST $CRED_SHIELD_NGINX_INGRESS_MANIFEST $CRED_SHIELD_LOADBALANCER_MANIFEST $CERT_MANAGER_CONFIG_MANIFEST $CERT_MANIFEST $CRED_SHIELD_TLS_OPTION_MANIFEST $TLS_STORE_MANIFEST $DIST/.
 cp ../bin/$KARAVICTL $DIST/.
 
 ${BUILDER} save localhost/$SIDECAR_PROXY:$SIDECAR_BUILDER_TAG -o $DIST/$SIDECAR_PROXY-$SIDECAR_BUILDER_TAG.tar
@@ -110,6 +112,8 @@ rm $K3S_INSTALL_SCRIPT \
 	${DIST}/$CERT_MANIFEST \
 	$CRED_SHIELD_DEPLOYMENT_MANIFEST \
 	${DIST}/$CRED_SHIELD_INGRESS_MANIFEST \
+	${DIST}/$CRED_SHIELD_NGINX_INGRESS_MANIFEST \
+	${DIST}/$CRED_SHIELD_LOADBALANCER_MANIFEST \
 	${DIST}/$CRED_SHIELD_TLS_OPTION_MANIFEST \
 	${DIST}/$TLS_STORE_MANIFEST \
 	${DIST}/$SIDECAR_PROXY-$SIDECAR_BUILDER_TAG.tar \
@@ -0,0 +1,164 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Overrides for testing purposes.
+var (
+	osStat    = os.Stat
+	backupDir = "/var/lib/rancher/karavi-backup"
+)
+
+const (
+	backupManifestsDir = "manifests"
+	backupImagesDir    = "images"
+	backupSecrets      = "secrets.yaml"
+)
+
+// BackupPreviousInstall snapshots the k3s binary, the rancher manifests
+// and images, and the karavi secrets of an existing installation so that
+// it can be restored if the upgrade fails. It does nothing on a fresh
+// install.
+func (dp *DeployProcess) BackupPreviousInstall() {
+	if dp.Err != nil {
+		return
+	}
+
+	if _, err := osStat(installedK3s); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			dp.Err = fmt.Errorf("checking for previous install: %w", err)
+		}
+		return
+	}
+
+	fmt.Fprintf(dp.stdout, "Backing up previous installation...")
+	defer fmt.Fprintln(dp.stdout, "Done!")
+
+	if err := osRemoveAll(backupDir); err != nil {
+		dp.Err = fmt.Errorf("removing old backup %s: %w", backupDir, err)
+		return
+	}
+	if err := createDir(backupDir); err != nil {
+		dp.Err = fmt.Errorf("creating directory %s: %w", backupDir, err)
+		return
+	}
+
+	copies := map[string]string{
+		installedK3s:        filepath.Join(backupDir, k3sBinary),
+		RancherManifestsDir: filepath.Join(backupDir, backupManifestsDir),
+		RancherImagesDir:    filepath.Join(backupDir, backupImagesDir),
+	}
+	for src, dst := range copies {
+		cmd := execCommand("cp", "-p", "--recursive", src, dst)
+		if err := cmd.Run(); err != nil {
+			dp.Err = fmt.Errorf("backing up %s to %s: %w", src, dst, err)
+			return
+		}
+	}
+
+	// The secrets are only readable while k3s is running, so a failure
+	// here is reported without aborting the upgrade.
+	cmd := execCommand(installedK3s, "kubectl", "get", "secrets", "-n", "karavi", "-o", "yaml")
+	out, err := cmd.Output()
+	if err != nil {
+		fmt.Fprintf(dp.stderr, "warning: unable to back up karavi secrets: %v\n", err)
+	} else if err := ioutilWriteFile(filepath.Join(backupDir, backupSecrets), out, 0o600); err != nil {
+		dp.Err = fmt.Errorf("writing secrets backup: %w", err)
+		return
+	}
+
+	dp.backupTaken = true
+}
+
+// RollbackOnFailure restores the installation saved by
+// BackupPreviousInstall if any earlier step failed and rollback was
+// requested. Unlike the other steps, it only acts when there is an error.
+func (dp *DeployProcess) RollbackOnFailure() {
+	if dp.Err == nil || !dp.rollbackOnFailure {
+		return
+	}
+	if !dp.backupTaken {
+		fmt.Fprintln(dp.stderr, "warning: no previous installation was backed up, nothing to roll back")
+		return
+	}
+
+	fmt.Fprintf(dp.stdout, "Rolling back to previous installation...")
+	if err := dp.restoreBackup(); err != nil {
+		fmt.Fprintln(dp.stdout, "Failed!")
+		dp.Err = fmt.Errorf("%w; rollback failed: %v", dp.Err, err)
+		return
+	}
+	fmt.Fprintln(dp.stdout, "Done!")
+	dp.Err = fmt.Errorf("%w; rolled back to previous installation", dp.Err)
+}
+
+func (dp *DeployProcess) restoreBackup() error {
+	dirs := map[string]string{
+		filepath.Join(backupDir, backupManifestsDir): RancherManifestsDir,
+		filepath.Join(backupDir, backupImagesDir):    RancherImagesDir,
+	}
+	for src, dst := range dirs {
+		if err := osRemoveAll(dst); err != nil {
+			return fmt.Errorf("removing %s: %w", dst, err)
+		}
+		cmd := execCommand("cp", "-p", "--recursive", src, dst)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("restoring %s to %s: %w", src, dst, err)
+		}
+	}
+
+	src := filepath.Join(backupDir, k3sBinary)
+	cmd := execCommand("cp", "-p", src, installedK3s)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("restoring %s to %s: %w", src, installedK3s, err)
+	}
+
+	// k3s only imports images on start up.
+	cmd = execCommand("systemctl", "restart", "k3s")
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("restarting k3s: %w", err)
+	}
+
+	secrets := filepath.Join(backupDir, backupSecrets)
+	if _, err := osStat(secrets); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("checking for secrets backup: %w", err)
+	}
+	cmd = execCommand(installedK3s, "kubectl", "apply", "-f", secrets)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("restoring karavi secrets: %w", err)
+	}
+	return nil
+}
+
+// RemoveBackup removes the backup of the previous installation once the
+// upgrade has succeeded, since it contains the karavi secrets.
+func (dp *DeployProcess) RemoveBackup() {
+	if dp.Err != nil || !dp.backupTaken {
+		return
+	}
+
+	if err := osRemoveAll(backupDir); err != nil {
+		fmt.Fprintf(dp.stderr, "error: cleaning up backup dir: %s", backupDir)
+	}
+}
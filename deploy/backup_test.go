@@ -0,0 +1,294 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDeployProcess_BackupPreviousInstall(t *testing.T) {
+	var testOut, testErr bytes.Buffer
+	sut := buildDeployProcess(&testOut, &testErr)
+
+	afterEach := func() {
+		sut.Err = nil
+		sut.backupTaken = false
+		testOut.Reset()
+		testErr.Reset()
+		osStat = os.Stat
+		osRemoveAll = os.RemoveAll
+		ioutilWriteFile = os.WriteFile
+		execCommand = exec.Command
+		backupDir = "/var/lib/rancher/karavi-backup"
+	}
+	previousInstall := func() {
+		osStat = func(_ string) (fs.FileInfo, error) {
+			return nil, nil
+		}
+		osRemoveAll = func(_ string) error {
+			return nil
+		}
+	}
+
+	t.Run("it is a noop on sticky error", func(t *testing.T) {
+		defer afterEach()
+		sut.Err = errors.New("test error")
+
+		sut.BackupPreviousInstall()
+
+		if sut.backupTaken {
+			t.Error("expected no backup")
+		}
+	})
+	t.Run("it does nothing on a fresh install", func(t *testing.T) {
+		defer afterEach()
+		osStat = func(_ string) (fs.FileInfo, error) {
+			return nil, os.ErrNotExist
+		}
+		execCommand = func(_ string, _ ...string) *exec.Cmd {
+			t.Error("unexpected command")
+			return exec.Command("true")
+		}
+
+		sut.BackupPreviousInstall()
+
+		if sut.Err != nil || sut.backupTaken {
+			t.Errorf("got err = %v, backupTaken = %v, want no backup", sut.Err, sut.backupTaken)
+		}
+	})
+	t.Run("it backs up k3s, manifests, images and secrets", func(t *testing.T) {
+		defer afterEach()
+		previousInstall()
+		var gotCmds []string
+		execCommand = func(name string, args ...string) *exec.Cmd {
+			gotCmds = append(gotCmds, strings.Join(append([]string{name}, args...), " "))
+			return exec.Command("echo", "kind: List")
+		}
+		var gotSecrets string
+		ioutilWriteFile = func(name string, data []byte, perm os.FileMode) error {
+			gotSecrets = string(data)
+			if want := filepath.Join(backupDir, backupSecrets); name != want || perm != 0o600 {
+				t.Errorf("got secrets written to %s (%v), want %s (0600)", name, perm, want)
+			}
+			return nil
+		}
+
+		sut.BackupPreviousInstall()
+
+		if sut.Err != nil {
+			t.Fatalf("got err = %v, want nil", sut.Err)
+		}
+		if !sut.backupTaken {
+			t.Error("expected backup to be taken")
+		}
+		if got, want := len(gotCmds), 4; got != want {
+			t.Errorf("got %d commands, want %d: %v", got, want, gotCmds)
+		}
+		for _, want := range []string{installedK3s, RancherManifestsDir, RancherImagesDir} {
+			var found bool
+			for _, c := range gotCmds {
+				found = found || strings.HasPrefix(c, "cp -p --recursive "+want+" ")
+			}
+			if !found {
+				t.Errorf("expected %s to be backed up: %v", want, gotCmds)
+			}
+		}
+		if gotSecrets != "kind: List\n" {
+			t.Errorf("got secrets %q, want %q", gotSecrets, "kind: List\n")
+		}
+	})
+	t.Run("it continues when secrets cannot be read", func(t *testing.T) {
+		defer afterEach()
+		previousInstall()
+		execCommand = func(name string, _ ...string) *exec.Cmd {
+			if name == installedK3s {
+				return exec.Command("false")
+			}
+			return exec.Command("true")
+		}
+
+		sut.BackupPreviousInstall()
+
+		if sut.Err != nil {
+			t.Fatalf("got err = %v, want nil", sut.Err)
+		}
+		if !strings.Contains(testErr.String(), "unable to back up karavi secrets") {
+			t.Errorf("expected warning, got %q", testErr.String())
+		}
+	})
+	t.Run("it handles copy failure", func(t *testing.T) {
+		defer afterEach()
+		previousInstall()
+		execCommand = func(_ string, _ ...string) *exec.Cmd {
+			return exec.Command("false")
+		}
+
+		sut.BackupPreviousInstall()
+
+		if sut.Err == nil || sut.backupTaken {
+			t.Errorf("got err = %v, backupTaken = %v, want error", sut.Err, sut.backupTaken)
+		}
+	})
+}
+
+func TestDeployProcess_RollbackOnFailure(t *testing.T) {
+	var testOut, testErr bytes.Buffer
+	sut := buildDeployProcess(&testOut, &testErr)
+	installErr := errors.New("install error")
+
+	afterEach := func() {
+		sut.Err = nil
+		sut.backupTaken = false
+		sut.rollbackOnFailure = false
+		testOut.Reset()
+		testErr.Reset()
+		osStat = os.Stat
+		osRemoveAll = os.RemoveAll
+		execCommand = exec.Command
+	}
+
+	t.Run("it does nothing without an error", func(t *testing.T) {
+		defer afterEach()
+		sut.backupTaken = true
+		sut.rollbackOnFailure = true
+		execCommand = func(_ string, _ ...string) *exec.Cmd {
+			t.Error("unexpected command")
+			return exec.Command("true")
+		}
+
+		sut.RollbackOnFailure()
+
+		if sut.Err != nil {
+			t.Errorf("got err = %v, want nil", sut.Err)
+		}
+	})
+	t.Run("it does nothing unless rollback was requested", func(t *testing.T) {
+		defer afterEach()
+		sut.Err = installErr
+		sut.backupTaken = true
+		execCommand = func(_ string, _ ...string) *exec.Cmd {
+			t.Error("unexpected command")
+			return exec.Command("true")
+		}
+
+		sut.RollbackOnFailure()
+
+		if sut.Err != installErr {
+			t.Errorf("got err = %v, want %v", sut.Err, installErr)
+		}
+	})
+	t.Run("it restores the backup", func(t *testing.T) {
+		defer afterEach()
+		sut.Err = installErr
+		sut.backupTaken = true
+		sut.rollbackOnFailure = true
+		osStat = func(_ string) (fs.FileInfo, error) {
+			return nil, nil
+		}
+		var gotRemoved []string
+		osRemoveAll = func(path string) error {
+			gotRemoved = append(gotRemoved, path)
+			return nil
+		}
+		var gotCmds []string
+		execCommand = func(name string, args ...string) *exec.Cmd {
+			gotCmds = append(gotCmds, strings.Join(append([]string{name}, args...), " "))
+			return exec.Command("true")
+		}
+
+		sut.RollbackOnFailure()
+
+		if !errors.Is(sut.Err, installErr) || !strings.Contains(sut.Err.Error(), "rolled back") {
+			t.Errorf("got err = %v, want wrapped %v", sut.Err, installErr)
+		}
+		if got, want := len(gotRemoved), 2; got != want {
+			t.Errorf("got %d removed dirs, want %d: %v", got, want, gotRemoved)
+		}
+		want := []string{
+			"systemctl restart k3s",
+			installedK3s + " kubectl apply -f " + filepath.Join(backupDir, backupSecrets),
+		}
+		for _, w := range want {
+			var found bool
+			for _, c := range gotCmds {
+				found = found || c == w
+			}
+			if !found {
+				t.Errorf("expected command %q, got %v", w, gotCmds)
+			}
+		}
+	})
+	t.Run("it reports rollback failure", func(t *testing.T) {
+		defer afterEach()
+		sut.Err = installErr
+		sut.backupTaken = true
+		sut.rollbackOnFailure = true
+		osRemoveAll = func(_ string) error {
+			return nil
+		}
+		execCommand = func(_ string, _ ...string) *exec.Cmd {
+			return exec.Command("false")
+		}
+
+		sut.RollbackOnFailure()
+
+		if !errors.Is(sut.Err, installErr) || !strings.Contains(sut.Err.Error(), "rollback failed") {
+			t.Errorf("got err = %v, want wrapped %v", sut.Err, installErr)
+		}
+	})
+}
+
+func TestDeployProcess_RemoveBackup(t *testing.T) {
+	sut := buildDeployProcess(nil, nil)
+	afterEach := func() {
+		sut.Err = nil
+		sut.backupTaken = false
+		osRemoveAll = os.RemoveAll
+	}
+
+	t.Run("it is a noop on sticky error", func(t *testing.T) {
+		defer afterEach()
+		sut.Err = errors.New("test error")
+		sut.backupTaken = true
+		osRemoveAll = func(_ string) error {
+			t.Error("unexpected removal")
+			return nil
+		}
+
+		sut.RemoveBackup()
+	})
+	t.Run("it removes the backup", func(t *testing.T) {
+		defer afterEach()
+		sut.backupTaken = true
+		var got string
+		osRemoveAll = func(path string) error {
+			got = path
+			return nil
+		}
+
+		sut.RemoveBackup()
+
+		if got != backupDir {
+			t.Errorf("got removed %q, want %q", got, backupDir)
+		}
+	})
+}
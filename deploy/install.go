@@ -21,6 +21,7 @@ import (
 	"compress/gzip"
 	"encoding/base64"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"io/fs"
@@ -97,6 +98,8 @@ const (
 	authImagesTar          = "credential-shield-images.tar"
 	authDeploymentManifest = "deployment.yaml"
 	authIngressManifest    = "ingress-traefik.yaml"
+	nginxIngressManifest   = "ingress-nginx.yaml"
+	loadBalancerManifest   = "service-loadbalancer.yaml"
 	authTLSOptionManifest  = "tls-option.yaml"
 	certManagerManifest    = "cert-manager.yaml"
 	certManagerImagesTar   = "cert-manager-images.tar"
@@ -108,15 +111,44 @@ const (
 
 	defaultProxyHostName               = "temporary.Host.Name"
 	defaultGrpcHostName                = "grpc.tenants.cluster"
+	defaultIngressClassName            = "nginx"
 	defaultConcurrentPowerFlexRequests = "10"
 	defaultLogLevel                    = "debug"
+	defaultNoProxy                     = "127.0.0.1,localhost,.svc,.cluster.local"
+	networkConfigName                  = "karavi-network-config"
+	caBundleConfigName                 = "karavi-ca-bundle"
+	caBundleKey                        = "ca-bundle.crt"
+	caBundleMountPath                  = "/etc/karavi-authorization/ca-bundle"
 	getVersion                         = "DOCKER_TAG \\?= ([0-9]+(\\.[0-9]+)+)"
+	proxyTLSHost                       = ":8443"
+	proxyTLSMountPath                  = "/etc/karavi-authorization/tls"
+)
+
+// Ingress modes, i.e. how the proxy-server is exposed outside of the
+// cluster, as configured by ingress.mode.
+const (
+	// IngressModeTraefik uses the traefik packaged with k3s.
+	IngressModeTraefik = "traefik"
+	// IngressModeNginx creates an Ingress for an nginx ingress controller
+	// that is installed separately.
+	IngressModeNginx = "nginx"
+	// IngressModeLoadBalancer exposes the proxy-server by a Service of
+	// type LoadBalancer, with the proxy-server terminating TLS.
+	IngressModeLoadBalancer = "loadbalancer"
 )
 
 func main() {
+	validateOnly := flag.Bool("validate-only", false, "validate the configuration and exit without installing")
+	rollback := flag.Bool("rollback", false, "restore the previous installation if the upgrade fails")
+	flag.Parse()
+
 	// see embed.go / embed_prod.go
 	dp := NewDeploymentProcess(os.Stdout, os.Stderr, embedBundleTar)
 	dp.cfg = config()
+	dp.rollbackOnFailure = *rollback
+	if *validateOnly {
+		dp.Steps = []StepFunc{dp.ValidateConfig}
+	}
 
 	if err := run(dp); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %+v\n", err)
@@ -158,16 +190,18 @@ type StepFunc func()
 // Following the sticky error pattern, each step should first check
 // the Err field to determine if it should continue or return immediately.
 type DeployProcess struct {
-	Err             error // sticky error.
-	cfg             *viper.Viper
-	stdout          io.Writer
-	stderr          io.Writer
-	bundleTar       fs.FS
-	tmpDir          string
-	processOwnerUID int
-	processOwnerGID int
-	Steps           []StepFunc
-	manifests       []string
+	Err               error // sticky error.
+	cfg               *viper.Viper
+	stdout            io.Writer
+	stderr            io.Writer
+	bundleTar         fs.FS
+	tmpDir            string
+	processOwnerUID   int
+	processOwnerGID   int
+	Steps             []StepFunc
+	manifests         []string
+	backupTaken       bool
+	rollbackOnFailure bool
 }
 
 // NewDeploymentProcess creates a new DeployProcess, pre-configured
@@ -181,24 +215,31 @@ func NewDeploymentProcess(stdout, stderr io.Writer, bundle fs.FS) *DeployProcess
 	}
 	dp.Steps = append(dp.Steps,
 		dp.CheckRootPermissions,
+		dp.ValidateConfig,
 		dp.CreateTempWorkspace,
 		dp.UntarFiles,
+		dp.ConfigureIngress,
 		dp.AddCertificate,
 		dp.AddHostName,
 		dp.InstallKaravictl,
 		dp.CreateRancherDirs,
+		dp.BackupPreviousInstall,
 		dp.InstallK3s,
 		dp.CopyImagesToRancherDirs,
 		dp.CopyManifestsToRancherDirs,
 		dp.WriteConfigSecretManifest,
 		dp.WriteStorageSecretManifest,
 		dp.WriteConfigMapManifest,
+		dp.WriteNetworkConfigManifest,
 		dp.ExecuteK3sInstallScript,
 		dp.ChownK3sKubeConfig,
 		dp.RemoveSecretManifest,
+		dp.VerifyDeployment,
 		dp.CopySidecarProxyToCwd,
+		dp.RemoveBackup,
 		dp.Cleanup,
 		dp.PrintFinishedMessage,
+		dp.RollbackOnFailure,
 	)
 	return dp
 }
@@ -551,6 +592,7 @@ func (dp *DeployProcess) CopyManifestsToRancherDirs() {
 		return
 	}
 
+	dp.removeStaleIngressManifests()
 	for _, man := range dp.manifests {
 		tmpPath := filepath.Join(dp.tmpDir, man)
 		tgtPath := filepath.Join(RancherManifestsDir, man)
@@ -756,6 +798,111 @@ func (dp *DeployProcess) WriteConfigMapManifest() {
 	}
 }
 
+// WriteNetworkConfigManifest generates and writes the Kubernetes
+// ConfigMap manifests holding the corporate proxy settings and the custom
+// CA bundle, if any. The ConfigMaps are always written so that removing
+// the settings from the configuration removes them from the deployment.
+func (dp *DeployProcess) WriteNetworkConfigManifest() {
+	if dp.Err != nil {
+		return
+	}
+
+	networkCM := corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      networkConfigName,
+			Namespace: "karavi",
+		},
+		Data: make(map[string]string),
+	}
+	for _, v := range dp.networkProxyEnv() {
+		kv := strings.SplitN(v, "=", 2)
+		networkCM.Data[kv[0]] = kv[1]
+	}
+
+	caCM := corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      caBundleConfigName,
+			Namespace: "karavi",
+		},
+		Data: make(map[string]string),
+	}
+	if caFile := dp.cfg.GetString("cabundle"); caFile != "" {
+		content, err := ioutilReadFile(caFile)
+		if err != nil {
+			dp.Err = fmt.Errorf("failed to read file %s: %w", caFile, err)
+			return
+		}
+		caCM.Data[caBundleKey] = string(content)
+		// Add the bundle to the system roots rather than replacing them.
+		networkCM.Data["SSL_CERT_DIR"] = "/etc/ssl/certs:" + caBundleMountPath
+	}
+
+	var manifest []byte
+	for _, cm := range []*corev1.ConfigMap{&networkCM, &caCM} {
+		cmBytes, err := yamlMarshalConfigMap(cm)
+		if err != nil {
+			dp.Err = fmt.Errorf("marshalling %+v: %w", cm, err)
+			return
+		}
+		if len(manifest) > 0 {
+			manifest = append(manifest, []byte("---\n")...)
+		}
+		manifest = append(manifest, cmBytes...)
+	}
+
+	fname := filepath.Join(RancherManifestsDir, networkConfigName+".yaml")
+	f, err := osOpenFile(fname, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o341)
+	if err != nil {
+		dp.Err = fmt.Errorf("creating %s: %w", fname, err)
+		return
+	}
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			dp.Err = fmt.Errorf("closing RancherManifestsDir: %w", err)
+		}
+	}()
+
+	_, err = f.Write(manifest)
+	if err != nil {
+		dp.Err = fmt.Errorf("writing configMap: %w", err)
+		return
+	}
+}
+
+// networkProxyEnv returns the corporate proxy settings as environment
+// variables. Cluster-internal addresses are always excluded from the
+// proxy.
+func (dp *DeployProcess) networkProxyEnv() []string {
+	httpProxy := dp.cfg.GetString("networkproxy.httpproxy")
+	httpsProxy := dp.cfg.GetString("networkproxy.httpsproxy")
+	if httpProxy == "" && httpsProxy == "" {
+		return nil
+	}
+
+	var env []string
+	if httpProxy != "" {
+		env = append(env, "HTTP_PROXY="+httpProxy)
+	}
+	if httpsProxy != "" {
+		env = append(env, "HTTPS_PROXY="+httpsProxy)
+	}
+	noProxy := defaultNoProxy
+	if v := dp.cfg.GetString("networkproxy.noproxy"); v != "" {
+		noProxy = v + "," + noProxy
+	}
+	env = append(env, "NO_PROXY="+noProxy)
+	return env
+}
+
 func realYamlMarshalSettings(v *map[string]interface{}) ([]byte, error) {
 	return k8sYaml.Marshal(v)
 }
@@ -792,7 +939,10 @@ func (dp *DeployProcess) ExecuteK3sInstallScript() {
 	}
 
 	cmd := execCommand(filepath.Join(dp.tmpDir, k3SInstallScript))
-	cmd.Env = append(os.Environ(), EnvK3sInstallSkipDownload, EnvK3sForceRestart, EnvK3sSkipSelinuxRpm, EnvK3sInstallExec)
+	cmd.Env = append(os.Environ(), EnvK3sInstallSkipDownload, EnvK3sForceRestart, EnvK3sSkipSelinuxRpm, dp.k3sInstallExec())
+	// The k3s install script persists any proxy variables into the k3s
+	// service environment.
+	cmd.Env = append(cmd.Env, dp.networkProxyEnv()...)
 	cmd.Stdout = logFile
 	cmd.Stderr = logFile
 	err = cmd.Run()
@@ -812,6 +962,17 @@ func (dp *DeployProcess) PrintFinishedMessage() {
 	fmt.Fprintln(dp.stdout, "Check cluster status with karavictl cluster-info --watch")
 	fmt.Fprintf(dp.stdout, "The sidecar container image has been saved at %q.\n", sidecarImageTar)
 	fmt.Fprintln(dp.stdout, "Please push this image to a container registry accessible to tenant Kubernetes clusters.")
+
+	switch dp.ingressMode() {
+	case IngressModeNginx:
+		fmt.Fprintf(dp.stdout, "The proxy-server is exposed by an Ingress of class %q; make sure an nginx ingress controller serves this class.\n", dp.ingressClassName())
+	case IngressModeLoadBalancer:
+		fmt.Fprintln(dp.stdout, "The proxy-server is exposed by the LoadBalancer Service karavi/proxy-server-lb; point the hostname at its external address.")
+	}
+	fmt.Fprintf(dp.stdout, "Set PROXY_HOST of the sidecar proxy in tenant clusters to %q.\n", dp.cfg.GetString("hostname"))
+	if !dp.cfg.IsSet("certificate") {
+		fmt.Fprintln(dp.stdout, "The certificate is self-signed, so also set SKIP_CERTIFICATE_VALIDATION to \"true\" or provide a signed certificate.")
+	}
 }
 
 func realCreateDir(newDir string) error {
@@ -900,7 +1061,7 @@ func (dp *DeployProcess) AddHostName() {
 	hostName := dp.cfg.GetString("hostname")
 
 	// update hostnames in ingress manifest
-	ingressFile := filepath.Join(dp.tmpDir, authIngressManifest)
+	ingressFile := filepath.Join(dp.tmpDir, dp.ingressManifest())
 
 	read, err := ioutilReadFile(ingressFile)
 	if err != nil {
@@ -910,6 +1071,7 @@ func (dp *DeployProcess) AddHostName() {
 
 	newContents := strings.Replace(string(read), defaultProxyHostName, hostName, -1)
 	newContents = strings.Replace(newContents, defaultGrpcHostName, "grpc."+hostName, -1)
+	newContents = strings.Replace(newContents, "ingressClassName: "+defaultIngressClassName, "ingressClassName: "+dp.ingressClassName(), -1)
 
 	err = ioutilWriteFile(ingressFile, []byte(newContents), 0)
 	if err != nil {
@@ -918,6 +1080,112 @@ func (dp *DeployProcess) AddHostName() {
 	}
 }
 
+// ConfigureIngress selects the manifests that expose the proxy-server,
+// replacing those of the packaged traefik unless it is the configured
+// ingress mode. In the loadbalancer mode, the proxy-server is configured to
+// serve TLS with the certificate secret mounted into its pod.
+func (dp *DeployProcess) ConfigureIngress() {
+	if dp.Err != nil {
+		return
+	}
+
+	var manifest string
+	switch mode := dp.ingressMode(); mode {
+	case IngressModeTraefik:
+		return
+	case IngressModeNginx:
+		manifest = nginxIngressManifest
+	case IngressModeLoadBalancer:
+		manifest = loadBalancerManifest
+		dp.cfg.Set("proxy.tlshost", proxyTLSHost)
+		dp.cfg.Set("proxy.tlscertfile", filepath.Join(proxyTLSMountPath, corev1.TLSCertKey))
+		dp.cfg.Set("proxy.tlskeyfile", filepath.Join(proxyTLSMountPath, corev1.TLSPrivateKeyKey))
+	default:
+		dp.Err = fmt.Errorf("unknown ingress mode %q", mode)
+		return
+	}
+
+	var manifests []string
+	for _, man := range dp.manifests {
+		if !isTraefikManifest(man) {
+			manifests = append(manifests, man)
+		}
+	}
+	dp.manifests = append(manifests, manifest)
+}
+
+// ingressMode returns the configured ingress mode, which defaults to the
+// packaged traefik.
+func (dp *DeployProcess) ingressMode() string {
+	mode := strings.ToLower(dp.cfg.GetString("ingress.mode"))
+	if mode == "" {
+		return IngressModeTraefik
+	}
+	return mode
+}
+
+func (dp *DeployProcess) ingressClassName() string {
+	if name := dp.cfg.GetString("ingress.classname"); name != "" {
+		return name
+	}
+	return defaultIngressClassName
+}
+
+// ingressManifest returns the manifest that routes the hostname to the
+// proxy-server in the configured ingress mode.
+func (dp *DeployProcess) ingressManifest() string {
+	switch dp.ingressMode() {
+	case IngressModeNginx:
+		return nginxIngressManifest
+	case IngressModeLoadBalancer:
+		return loadBalancerManifest
+	default:
+		return authIngressManifest
+	}
+}
+
+// k3sInstallExec returns the INSTALL_K3S_EXEC setting, which disables the
+// packaged traefik when another ingress mode is configured so that it does
+// not hold the HTTP(S) ports.
+func (dp *DeployProcess) k3sInstallExec() string {
+	if dp.ingressMode() == IngressModeTraefik {
+		return EnvK3sInstallExec
+	}
+	return EnvK3sInstallExec + " --disable traefik"
+}
+
+func isTraefikManifest(man string) bool {
+	switch man {
+	case authIngressManifest, authTLSOptionManifest, tlsStoreManifest:
+		return true
+	}
+	return false
+}
+
+// removeStaleIngressManifests removes the ingress manifests of the other
+// ingress modes from a previous install, so that k3s deletes what they
+// created.
+func (dp *DeployProcess) removeStaleIngressManifests() {
+	for _, man := range []string{authIngressManifest, authTLSOptionManifest, tlsStoreManifest, nginxIngressManifest, loadBalancerManifest} {
+		if contains(dp.manifests, man) {
+			continue
+		}
+		path := filepath.Join(RancherManifestsDir, man)
+		if err := osRemove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(dp.stderr, "warning: failed to remove stale manifest %s: %v\n", path, err)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func sanitizeExtractPath(filePath string, destination string) (string, error) {
 	destpath := filepath.Join(destination, filePath)
 	if !strings.HasPrefix(destpath, filepath.Clean(destination)+string(os.PathSeparator)) {
@@ -25,6 +25,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/spf13/viper"
@@ -1017,6 +1018,32 @@ func TestDeployProcess_CopyManifestsToRancherDirs(t *testing.T) {
 			t.Errorf("got callCount %d, want %d", got, want)
 		}
 	})
+	t.Run("it removes the manifests of other ingress modes", func(t *testing.T) {
+		t.Cleanup(func() {
+			sut.manifests = []string{}
+			execCommand = exec.Command
+			osRemove = os.Remove
+		})
+		sut.manifests = []string{authDeploymentManifest, nginxIngressManifest}
+		execCommand = func(_ string, _ ...string) *exec.Cmd {
+			return exec.Command("true")
+		}
+		var removed []string
+		osRemove = func(name string) error {
+			removed = append(removed, filepath.Base(name))
+			return fs.ErrNotExist
+		}
+
+		sut.CopyManifestsToRancherDirs()
+
+		want := []string{authIngressManifest, authTLSOptionManifest, tlsStoreManifest, loadBalancerManifest}
+		if !reflect.DeepEqual(removed, want) {
+			t.Errorf("got removed %v, want %v", removed, want)
+		}
+		if sut.Err != nil {
+			t.Errorf("got err %v, want nil", sut.Err)
+		}
+	})
 }
 
 func Test_config(t *testing.T) {
@@ -1397,6 +1424,104 @@ func TestDeployProcess_WriteConfigMapManifest(t *testing.T) {
 	})
 }
 
+func TestDeployProcess_WriteNetworkConfigManifest(t *testing.T) {
+	sut := buildDeployProcess(nil, nil)
+
+	afterEach := func() {
+		osOpenFile = os.OpenFile
+		ioutilReadFile = os.ReadFile
+		yamlMarshalConfigMap = realYamlMarshalConfigMap
+		sut.cfg = viper.New()
+		sut.Err = nil
+	}
+
+	t.Run("it is a noop on sticky error", func(t *testing.T) {
+		defer afterEach()
+		var callCount int
+		osOpenFile = func(_ string, _ int, _ os.FileMode) (*os.File, error) {
+			callCount++
+			return nil, nil
+		}
+		sut.Err = errors.New("test error")
+
+		sut.WriteNetworkConfigManifest()
+
+		want := 0
+		if got := callCount; got != want {
+			t.Errorf("got callCount = %v, want %v", got, want)
+		}
+	})
+	t.Run("it writes proxy settings and the CA bundle to configMap manifests", func(t *testing.T) {
+		defer afterEach()
+		tmpDir, err := os.MkdirTemp("", "WriteNetworkConfigManifest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tmpDir)
+		var configPath string
+		osOpenFile = func(path string, _ int, _ os.FileMode) (*os.File, error) {
+			configPath = filepath.Join(tmpDir, path)
+			if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			return os.Create(configPath)
+		}
+		ioutilReadFile = func(_ string) ([]byte, error) {
+			return []byte("ca"), nil
+		}
+		sut.cfg.Set("networkproxy.httpproxy", "http://proxy.example.com:3128")
+		sut.cfg.Set("networkproxy.httpsproxy", "http://proxy.example.com:3128")
+		sut.cfg.Set("networkproxy.noproxy", "10.0.0.0/8")
+		sut.cfg.Set("cabundle", "ca.crt")
+
+		sut.WriteNetworkConfigManifest()
+
+		if sut.Err != nil {
+			t.Fatalf("got err = %v, want nil", sut.Err)
+		}
+		got, err := os.ReadFile(configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile("testdata/karavi-network-config.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", string(got), string(want))
+		}
+	})
+	t.Run("it handles CA bundle read failure", func(t *testing.T) {
+		defer afterEach()
+		wantErr := errors.New("test error")
+		ioutilReadFile = func(_ string) ([]byte, error) {
+			return nil, wantErr
+		}
+		sut.cfg.Set("cabundle", "ca.crt")
+
+		sut.WriteNetworkConfigManifest()
+
+		want := wantErr
+		if got := errors.Unwrap(sut.Err); got != want {
+			t.Errorf("got err %v, want %v", got, want)
+		}
+	})
+	t.Run("it handles marshal failure", func(t *testing.T) {
+		defer afterEach()
+		wantErr := errors.New("test error")
+		yamlMarshalConfigMap = func(_ *corev1.ConfigMap) ([]byte, error) {
+			return nil, wantErr
+		}
+
+		sut.WriteNetworkConfigManifest()
+
+		want := wantErr
+		if got := errors.Unwrap(sut.Err); got != want {
+			t.Errorf("got err %v, want %v", got, want)
+		}
+	})
+}
+
 func TestDeployProcess_ExecuteK3sInstallScript(t *testing.T) {
 	var testOut, testErr bytes.Buffer
 	sut := buildDeployProcess(&testOut, &testErr)
@@ -1474,6 +1599,78 @@ func TestDeployProcess_ExecuteK3sInstallScript(t *testing.T) {
 			t.Errorf("got err = %v, want non-nil", got)
 		}
 	})
+	t.Run("it passes proxy settings to the script", func(t *testing.T) {
+		defer afterEach()
+		defer func() { sut.cfg = viper.New() }()
+		osChmod = func(_ string, _ fs.FileMode) error {
+			return nil
+		}
+		tmpFile, err := os.CreateTemp("", "testExecuteK3sInstallScript")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(tmpFile.Name())
+		ioutilTempFile = func(_, _ string) (*os.File, error) {
+			return tmpFile, nil
+		}
+		var cmd *exec.Cmd
+		execCommand = func(_ string, _ ...string) *exec.Cmd {
+			cmd = exec.Command("true")
+			return cmd
+		}
+		sut.cfg.Set("networkproxy.httpsproxy", "http://proxy.example.com:3128")
+
+		sut.ExecuteK3sInstallScript()
+
+		if sut.Err != nil {
+			t.Fatalf("got err = %v, want nil", sut.Err)
+		}
+		want := []string{"HTTPS_PROXY=http://proxy.example.com:3128", "NO_PROXY=" + defaultNoProxy}
+		for _, w := range want {
+			var found bool
+			for _, e := range cmd.Env {
+				found = found || e == w
+			}
+			if !found {
+				t.Errorf("expected env to contain %q", w)
+			}
+		}
+	})
+	t.Run("it disables traefik for other ingress modes", func(t *testing.T) {
+		defer afterEach()
+		defer func() { sut.cfg = viper.New() }()
+		osChmod = func(_ string, _ fs.FileMode) error {
+			return nil
+		}
+		tmpFile, err := os.CreateTemp("", "testExecuteK3sInstallScript")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(tmpFile.Name())
+		ioutilTempFile = func(_, _ string) (*os.File, error) {
+			return tmpFile, nil
+		}
+		var cmd *exec.Cmd
+		execCommand = func(_ string, _ ...string) *exec.Cmd {
+			cmd = exec.Command("true")
+			return cmd
+		}
+		sut.cfg.Set("ingress.mode", IngressModeNginx)
+
+		sut.ExecuteK3sInstallScript()
+
+		if sut.Err != nil {
+			t.Fatalf("got err = %v, want nil", sut.Err)
+		}
+		want := EnvK3sInstallExec + " --disable traefik"
+		var found bool
+		for _, e := range cmd.Env {
+			found = found || e == want
+		}
+		if !found {
+			t.Errorf("expected env to contain %q", want)
+		}
+	})
 }
 
 func TestDeployProcess_PrintFinishedMessage(t *testing.T) {
@@ -1500,11 +1697,34 @@ func TestDeployProcess_PrintFinishedMessage(t *testing.T) {
 		})
 		sut.PrintFinishedMessage()
 
-		want := 220
+		want := 397
 		if got := len(testOut.Bytes()); got != want {
 			t.Errorf("len(stdout): got = %d, want %d", got, want)
 		}
 	})
+	t.Run("it prints the sidecar proxy host for the ingress mode", func(t *testing.T) {
+		t.Cleanup(func() {
+			sut.Err = nil
+			sut.cfg = viper.New()
+			testOut.Reset()
+		})
+		testOut.Reset()
+		sut.cfg.Set("hostname", "auth.example.com")
+		sut.cfg.Set("ingress.mode", IngressModeLoadBalancer)
+		sut.cfg.Set("certificate.crtfile", "tls.crt")
+
+		sut.PrintFinishedMessage()
+
+		got := testOut.String()
+		for _, want := range []string{"karavi/proxy-server-lb", `PROXY_HOST of the sidecar proxy in tenant clusters to "auth.example.com"`} {
+			if !strings.Contains(got, want) {
+				t.Errorf("expected output to contain %q, got %q", want, got)
+			}
+		}
+		if strings.Contains(got, "SKIP_CERTIFICATE_VALIDATION") {
+			t.Errorf("expected no self-signed guidance, got %q", got)
+		}
+	})
 }
 
 func buildDeployProcess(stdout, stderr io.Writer) *DeployProcess {
@@ -1698,6 +1918,41 @@ func TestDeployProcess_AddHostName(t *testing.T) {
 			t.Errorf("Error: got = %s, want not nil", got)
 		}
 	})
+	t.Run("it sets the hostname and class of the nginx ingress", func(t *testing.T) {
+		t.Cleanup(func() {
+			sut.Err = nil
+			sut.cfg = viper.New()
+			ioutilReadFile = os.ReadFile
+			ioutilWriteFile = os.WriteFile
+		})
+		sut.cfg.Set("hostName", hostName)
+		sut.cfg.Set("ingress.mode", IngressModeNginx)
+		sut.cfg.Set("ingress.className", "public")
+		sut.tmpDir = "testData"
+		var gotFile string
+		var gotContent []byte
+		ioutilReadFile = func(name string) ([]byte, error) {
+			return os.ReadFile(filepath.Base(name))
+		}
+		ioutilWriteFile = func(name string, data []byte, _ fs.FileMode) error {
+			gotFile, gotContent = name, data
+			return nil
+		}
+
+		sut.AddHostName()
+
+		if sut.Err != nil {
+			t.Fatalf("got err %v, want nil", sut.Err)
+		}
+		if want := filepath.Join("testData", nginxIngressManifest); gotFile != want {
+			t.Errorf("got file %q, want %q", gotFile, want)
+		}
+		for _, want := range []string{"ingressClassName: public", "host: " + hostName, "- " + hostName, "secretName: karavi-auth-tls"} {
+			if !strings.Contains(string(gotContent), want) {
+				t.Errorf("expected manifest to contain %q, got %s", want, gotContent)
+			}
+		}
+	})
 	t.Run("ingress file write error", func(t *testing.T) {
 		t.Cleanup(func() {
 			sut.Err = nil
@@ -1716,3 +1971,53 @@ func TestDeployProcess_AddHostName(t *testing.T) {
 		}
 	})
 }
+
+func TestDeployProcess_ConfigureIngress(t *testing.T) {
+	traefikManifests := []string{authDeploymentManifest, authIngressManifest, authTLSOptionManifest, certManagerManifest, tlsStoreManifest}
+	tests := []struct {
+		name          string
+		mode          string
+		wantManifests []string
+		wantTLSHost   string
+		wantErr       bool
+	}{
+		{"traefik by default", "", traefikManifests, "", false},
+		{"traefik", "Traefik", traefikManifests, "", false},
+		{"nginx", "nginx", []string{authDeploymentManifest, certManagerManifest, nginxIngressManifest}, "", false},
+		{"loadbalancer", "loadbalancer", []string{authDeploymentManifest, certManagerManifest, loadBalancerManifest}, proxyTLSHost, false},
+		{"unknown", "haproxy", traefikManifests, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sut := buildDeployProcess(nil, nil)
+			sut.manifests = append([]string{}, traefikManifests...)
+			if tt.mode != "" {
+				sut.cfg.Set("ingress.mode", tt.mode)
+			}
+
+			sut.ConfigureIngress()
+
+			if gotErr := sut.Err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got err %v, want err %v", sut.Err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(sut.manifests, tt.wantManifests) {
+				t.Errorf("got manifests %v, want %v", sut.manifests, tt.wantManifests)
+			}
+			if got := sut.cfg.GetString("proxy.tlshost"); got != tt.wantTLSHost {
+				t.Errorf("got proxy.tlshost %q, want %q", got, tt.wantTLSHost)
+			}
+		})
+	}
+
+	t.Run("it is a noop on sticky error", func(t *testing.T) {
+		sut := buildDeployProcess(nil, nil)
+		sut.Err = errors.New("test error")
+		sut.cfg.Set("ingress.mode", IngressModeNginx)
+
+		sut.ConfigureIngress()
+
+		if len(sut.manifests) != 0 {
+			t.Errorf("got manifests %v, want none", sut.manifests)
+		}
+	})
+}
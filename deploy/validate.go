@@ -0,0 +1,218 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Overrides for testing purposes.
+var (
+	netLookupHost = net.LookupHost
+	redisPing     = realRedisPing
+	timeNow       = time.Now
+)
+
+// certExpiryWarning is how close to expiry a certificate may be before
+// validation warns about it.
+const certExpiryWarning = 30 * 24 * time.Hour
+
+// ErrInvalidConfig is returned when the configuration fails validation.
+var ErrInvalidConfig = errors.New("configuration is invalid")
+
+// Validation check statuses.
+const (
+	CheckPass = "PASS"
+	CheckWarn = "WARN"
+	CheckFail = "FAIL"
+	CheckSkip = "SKIP"
+)
+
+// CheckResult is the outcome of a single configuration check.
+type CheckResult struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+// ValidateConfig checks the values that will be written to the
+// karavi-config-secret and prints a report of the results. Any failed
+// check stops the deployment; warnings are reported but do not.
+func (dp *DeployProcess) ValidateConfig() {
+	if dp.Err != nil {
+		return
+	}
+
+	results := dp.validateConfig()
+
+	tw := tabwriter.NewWriter(dp.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tDETAIL")
+	failed := 0
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Name, r.Status, r.Detail)
+		if r.Status == CheckFail {
+			failed++
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		dp.Err = fmt.Errorf("writing validation report: %w", err)
+		return
+	}
+
+	if failed > 0 {
+		dp.Err = fmt.Errorf("%w: %d check(s) failed", ErrInvalidConfig, failed)
+	}
+}
+
+func (dp *DeployProcess) validateConfig() []CheckResult {
+	var results []CheckResult
+
+	hostName := dp.cfg.GetString("hostname")
+	if hostName == "" {
+		results = append(results, CheckResult{"hostname", CheckFail, "hostname is required"})
+	} else {
+		results = append(results, CheckResult{"hostname", CheckPass, hostName})
+		if _, err := netLookupHost(hostName); err != nil {
+			results = append(results, CheckResult{"hostname resolution", CheckWarn, err.Error()})
+		} else {
+			results = append(results, CheckResult{"hostname resolution", CheckPass, hostName + " resolves"})
+		}
+	}
+
+	results = append(results, dp.validateIngress())
+	results = append(results, dp.validateCertificate(hostName)...)
+	results = append(results, dp.validateCABundle())
+	results = append(results, dp.validateRedis())
+
+	return results
+}
+
+func (dp *DeployProcess) validateCertificate(hostName string) []CheckResult {
+	if !dp.cfg.IsSet("certificate") {
+		return []CheckResult{{"certificate", CheckSkip, "no certificate provided, a self-signed certificate will be created"}}
+	}
+
+	crtFile := dp.cfg.GetString("certificate.crtfile")
+	keyFile := dp.cfg.GetString("certificate.keyfile")
+	if crtFile == "" || keyFile == "" {
+		return []CheckResult{{"certificate", CheckFail, "certificate.crtFile and certificate.keyFile are required"}}
+	}
+
+	crtPEM, err := ioutilReadFile(crtFile)
+	if err != nil {
+		return []CheckResult{{"certificate", CheckFail, fmt.Sprintf("reading %s: %v", crtFile, err)}}
+	}
+	keyPEM, err := ioutilReadFile(keyFile)
+	if err != nil {
+		return []CheckResult{{"certificate", CheckFail, fmt.Sprintf("reading %s: %v", keyFile, err)}}
+	}
+	if _, err := tls.X509KeyPair(crtPEM, keyPEM); err != nil {
+		return []CheckResult{{"certificate", CheckFail, fmt.Sprintf("loading key pair: %v", err)}}
+	}
+
+	block, _ := pem.Decode(crtPEM)
+	if block == nil {
+		return []CheckResult{{"certificate", CheckFail, fmt.Sprintf("no PEM data found in %s", crtFile)}}
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return []CheckResult{{"certificate", CheckFail, fmt.Sprintf("parsing %s: %v", crtFile, err)}}
+	}
+
+	results := []CheckResult{{"certificate", CheckPass, fmt.Sprintf("%s matches %s", crtFile, keyFile)}}
+
+	now := timeNow()
+	expiry := cert.NotAfter.Format(time.RFC3339)
+	switch {
+	case now.Before(cert.NotBefore):
+		results = append(results, CheckResult{"certificate validity", CheckFail, "not valid before " + cert.NotBefore.Format(time.RFC3339)})
+	case now.After(cert.NotAfter):
+		results = append(results, CheckResult{"certificate validity", CheckFail, "expired " + expiry})
+	case now.Add(certExpiryWarning).After(cert.NotAfter):
+		results = append(results, CheckResult{"certificate validity", CheckWarn, "expires " + expiry})
+	default:
+		results = append(results, CheckResult{"certificate validity", CheckPass, "expires " + expiry})
+	}
+
+	if hostName != "" {
+		if err := cert.VerifyHostname(hostName); err != nil {
+			results = append(results, CheckResult{"certificate hostname", CheckWarn, err.Error()})
+		} else {
+			results = append(results, CheckResult{"certificate hostname", CheckPass, "valid for " + hostName})
+		}
+	}
+
+	return results
+}
+
+func (dp *DeployProcess) validateIngress() CheckResult {
+	switch mode := dp.ingressMode(); mode {
+	case IngressModeTraefik, IngressModeLoadBalancer:
+		return CheckResult{"ingress", CheckPass, mode}
+	case IngressModeNginx:
+		return CheckResult{"ingress", CheckPass, fmt.Sprintf("%s (class %s)", mode, dp.ingressClassName())}
+	default:
+		return CheckResult{"ingress", CheckFail, fmt.Sprintf("unknown mode %q, want one of %s, %s or %s", mode, IngressModeTraefik, IngressModeNginx, IngressModeLoadBalancer)}
+	}
+}
+
+func (dp *DeployProcess) validateCABundle() CheckResult {
+	caFile := dp.cfg.GetString("cabundle")
+	if caFile == "" {
+		return CheckResult{"ca bundle", CheckSkip, "no CA bundle provided"}
+	}
+	content, err := ioutilReadFile(caFile)
+	if err != nil {
+		return CheckResult{"ca bundle", CheckFail, fmt.Sprintf("reading %s: %v", caFile, err)}
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(content) {
+		return CheckResult{"ca bundle", CheckFail, fmt.Sprintf("no certificates found in %s", caFile)}
+	}
+	return CheckResult{"ca bundle", CheckPass, caFile}
+}
+
+// validateRedis checks that an externally configured Redis is reachable.
+// The bundled Redis is not running until after the install, so it is
+// skipped when no database host is configured.
+func (dp *DeployProcess) validateRedis() CheckResult {
+	addr := dp.cfg.GetString("database.host")
+	if addr == "" {
+		return CheckResult{"redis", CheckSkip, "using bundled redis"}
+	}
+	if err := redisPing(addr, dp.cfg.GetString("database.password")); err != nil {
+		return CheckResult{"redis", CheckFail, fmt.Sprintf("%s: %v", addr, err)}
+	}
+	return CheckResult{"redis", CheckPass, addr + " is reachable"}
+}
+
+func realRedisPing(addr, password string) error {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:        addr,
+		Password:    password,
+		DialTimeout: 5 * time.Second,
+		MaxRetries:  1,
+	})
+	defer rdb.Close()
+	return rdb.Ping().Err()
+}
@@ -0,0 +1,288 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeployProcess_ValidateConfig(t *testing.T) {
+	var testOut bytes.Buffer
+	now := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	afterEach := func() {
+		netLookupHost = net.LookupHost
+		redisPing = realRedisPing
+		timeNow = time.Now
+		testOut.Reset()
+	}
+	beforeEach := func() {
+		netLookupHost = func(_ string) ([]string, error) {
+			return []string{"10.0.0.1"}, nil
+		}
+		redisPing = func(_, _ string) error {
+			return nil
+		}
+		timeNow = func() time.Time {
+			return now
+		}
+	}
+
+	t.Run("it is a noop on sticky error", func(t *testing.T) {
+		sut := buildDeployProcess(&testOut, nil)
+		wantErr := errors.New("test error")
+		sut.Err = wantErr
+
+		sut.ValidateConfig()
+
+		if got := sut.Err; got != wantErr {
+			t.Errorf("got err %v, want %v", got, wantErr)
+		}
+		if testOut.Len() != 0 {
+			t.Errorf("expected no report, got %q", testOut.String())
+		}
+	})
+	t.Run("it fails when hostname is missing", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+		sut := buildDeployProcess(&testOut, nil)
+
+		sut.ValidateConfig()
+
+		if !errors.Is(sut.Err, ErrInvalidConfig) {
+			t.Errorf("got err %v, want %v", sut.Err, ErrInvalidConfig)
+		}
+		if !strings.Contains(testOut.String(), "hostname is required") {
+			t.Errorf("expected report to contain missing hostname, got %q", testOut.String())
+		}
+	})
+	t.Run("it warns when hostname does not resolve", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+		netLookupHost = func(_ string) ([]string, error) {
+			return nil, errors.New("no such host")
+		}
+		sut := buildDeployProcess(&testOut, nil)
+		sut.cfg.Set("hostname", "karavi.example.com")
+
+		sut.ValidateConfig()
+
+		if sut.Err != nil {
+			t.Fatalf("got err %v, want nil", sut.Err)
+		}
+		want := []string{"hostname resolution", CheckWarn, "no such host"}
+		for _, w := range want {
+			if !strings.Contains(testOut.String(), w) {
+				t.Errorf("expected report to contain %q, got %q", w, testOut.String())
+			}
+		}
+	})
+	t.Run("it validates a provided certificate", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+		crt, key := writeTestCertificate(t, "karavi.example.com", now.Add(-time.Hour), now.Add(365*24*time.Hour))
+		sut := buildDeployProcess(&testOut, nil)
+		sut.cfg.Set("hostname", "karavi.example.com")
+		sut.cfg.Set("certificate.crtfile", crt)
+		sut.cfg.Set("certificate.keyfile", key)
+
+		sut.ValidateConfig()
+
+		if sut.Err != nil {
+			t.Fatalf("got err %v, want nil", sut.Err)
+		}
+		for _, line := range strings.Split(testOut.String(), "\n") {
+			if strings.HasPrefix(line, "certificate") && !strings.Contains(line, CheckPass) {
+				t.Errorf("expected certificate checks to pass, got %q", line)
+			}
+		}
+	})
+	t.Run("it fails an expired certificate", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+		crt, key := writeTestCertificate(t, "karavi.example.com", now.Add(-48*time.Hour), now.Add(-24*time.Hour))
+		sut := buildDeployProcess(&testOut, nil)
+		sut.cfg.Set("hostname", "karavi.example.com")
+		sut.cfg.Set("certificate.crtfile", crt)
+		sut.cfg.Set("certificate.keyfile", key)
+
+		sut.ValidateConfig()
+
+		if !errors.Is(sut.Err, ErrInvalidConfig) {
+			t.Errorf("got err %v, want %v", sut.Err, ErrInvalidConfig)
+		}
+		if !strings.Contains(testOut.String(), "expired") {
+			t.Errorf("expected report to contain expired certificate, got %q", testOut.String())
+		}
+	})
+	t.Run("it warns when the certificate is close to expiry or for another host", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+		crt, key := writeTestCertificate(t, "other.example.com", now.Add(-time.Hour), now.Add(24*time.Hour))
+		sut := buildDeployProcess(&testOut, nil)
+		sut.cfg.Set("hostname", "karavi.example.com")
+		sut.cfg.Set("certificate.crtfile", crt)
+		sut.cfg.Set("certificate.keyfile", key)
+
+		sut.ValidateConfig()
+
+		if sut.Err != nil {
+			t.Fatalf("got err %v, want nil", sut.Err)
+		}
+		if got := strings.Count(testOut.String(), CheckWarn); got != 2 {
+			t.Errorf("got %d warnings, want 2: %q", got, testOut.String())
+		}
+	})
+	t.Run("it fails when the key does not match the certificate", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+		crt, _ := writeTestCertificate(t, "karavi.example.com", now.Add(-time.Hour), now.Add(365*24*time.Hour))
+		_, key := writeTestCertificate(t, "karavi.example.com", now.Add(-time.Hour), now.Add(365*24*time.Hour))
+		sut := buildDeployProcess(&testOut, nil)
+		sut.cfg.Set("hostname", "karavi.example.com")
+		sut.cfg.Set("certificate.crtfile", crt)
+		sut.cfg.Set("certificate.keyfile", key)
+
+		sut.ValidateConfig()
+
+		if !errors.Is(sut.Err, ErrInvalidConfig) {
+			t.Errorf("got err %v, want %v", sut.Err, ErrInvalidConfig)
+		}
+	})
+	t.Run("it fails a CA bundle without certificates", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+		caFile := filepath.Join(t.TempDir(), "ca.crt")
+		if err := os.WriteFile(caFile, []byte("not a certificate"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		sut := buildDeployProcess(&testOut, nil)
+		sut.cfg.Set("hostname", "karavi.example.com")
+		sut.cfg.Set("cabundle", caFile)
+
+		sut.ValidateConfig()
+
+		if !errors.Is(sut.Err, ErrInvalidConfig) {
+			t.Errorf("got err %v, want %v", sut.Err, ErrInvalidConfig)
+		}
+		if !strings.Contains(testOut.String(), "no certificates found") {
+			t.Errorf("expected report to contain invalid CA bundle, got %q", testOut.String())
+		}
+	})
+	t.Run("it fails an unknown ingress mode", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+		sut := buildDeployProcess(&testOut, nil)
+		sut.cfg.Set("hostname", "karavi.example.com")
+		sut.cfg.Set("ingress.mode", "haproxy")
+
+		sut.ValidateConfig()
+
+		if !errors.Is(sut.Err, ErrInvalidConfig) {
+			t.Errorf("got err %v, want %v", sut.Err, ErrInvalidConfig)
+		}
+		if !strings.Contains(testOut.String(), `unknown mode "haproxy"`) {
+			t.Errorf("expected report to contain the unknown ingress mode, got %q", testOut.String())
+		}
+	})
+	t.Run("it checks a configured redis is reachable", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+		var gotAddr, gotPassword string
+		redisPing = func(addr, password string) error {
+			gotAddr, gotPassword = addr, password
+			return errors.New("connection refused")
+		}
+		sut := buildDeployProcess(&testOut, nil)
+		sut.cfg.Set("hostname", "karavi.example.com")
+		sut.cfg.Set("database.host", "redis.example.com:6379")
+		sut.cfg.Set("database.password", "pass")
+
+		sut.ValidateConfig()
+
+		if !errors.Is(sut.Err, ErrInvalidConfig) {
+			t.Errorf("got err %v, want %v", sut.Err, ErrInvalidConfig)
+		}
+		if gotAddr != "redis.example.com:6379" || gotPassword != "pass" {
+			t.Errorf("got redis %q/%q, want redis.example.com:6379/pass", gotAddr, gotPassword)
+		}
+	})
+	t.Run("it skips checks for the bundled redis and self-signed certificate", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+		redisPing = func(_, _ string) error {
+			t.Error("unexpected redis ping")
+			return nil
+		}
+		sut := buildDeployProcess(&testOut, nil)
+		sut.cfg.Set("hostname", "karavi.example.com")
+
+		sut.ValidateConfig()
+
+		if sut.Err != nil {
+			t.Fatalf("got err %v, want nil", sut.Err)
+		}
+		if got := strings.Count(testOut.String(), CheckSkip); got != 3 {
+			t.Errorf("got %d skipped checks, want 3: %q", got, testOut.String())
+		}
+	})
+}
+
+func writeTestCertificate(t *testing.T, hostName string, notBefore, notAfter time.Time) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: hostName},
+		DNSNames:     []string{hostName},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	crtFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(crtFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return crtFile, keyFile
+}
@@ -0,0 +1,211 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/web"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Overrides for testing purposes.
+var (
+	timeSleep      = time.Sleep
+	verifyTimeout  = 5 * time.Minute
+	verifyInterval = 5 * time.Second
+	ingressAddr    = "127.0.0.1:443"
+)
+
+const (
+	installedKaravictl      = "/usr/local/bin/karavictl"
+	defaultJWTSigningSecret = "secret"
+)
+
+// VerifyDeployment checks that the installation is working: all karavi
+// pods become Ready, the proxy-server answers health checks through the
+// ingress, and it accepts a newly generated admin token.
+func (dp *DeployProcess) VerifyDeployment() {
+	if dp.Err != nil {
+		return
+	}
+
+	fmt.Fprintf(dp.stdout, "Verifying deployment...")
+
+	checks := []func() error{
+		dp.waitForPods,
+		dp.checkProxyHealth,
+		dp.checkAdminToken,
+	}
+	for _, check := range checks {
+		if err := check(); err != nil {
+			fmt.Fprintln(dp.stdout, "Failed!")
+			dp.Err = err
+			return
+		}
+	}
+
+	fmt.Fprintln(dp.stdout, "Done!")
+}
+
+func (dp *DeployProcess) waitForPods() error {
+	var notReady []string
+	err := poll(func() error {
+		cmd := execCommand(installedK3s, "kubectl", "get", "pods", "-n", "karavi", "-o", "json")
+		out, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("listing pods: %w", err)
+		}
+		var pods corev1.PodList
+		if err := json.Unmarshal(out, &pods); err != nil {
+			return fmt.Errorf("decoding pods: %w", err)
+		}
+		if len(pods.Items) == 0 {
+			return fmt.Errorf("no pods found")
+		}
+
+		notReady = nil
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == corev1.PodSucceeded {
+				continue
+			}
+			if !isPodReady(pod) {
+				notReady = append(notReady, pod.Name)
+			}
+		}
+		if len(notReady) > 0 {
+			return fmt.Errorf("pods not ready: %s", strings.Join(notReady, ", "))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for karavi pods: %w; check them with 'k3s kubectl describe pods -n karavi'", err)
+	}
+	return nil
+}
+
+func isPodReady(pod corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func (dp *DeployProcess) checkProxyHealth() error {
+	err := poll(func() error {
+		resp, err := dp.ingressRequest(http.MethodGet, web.HealthzPath, "")
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("got status %d", resp.StatusCode)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("checking proxy-server health through the ingress: %w; check the ingress with 'k3s kubectl get ingressroute -n karavi' and the logs with 'k3s kubectl logs -n karavi deploy/proxy-server -c proxy-server'", err)
+	}
+	return nil
+}
+
+func (dp *DeployProcess) checkAdminToken() error {
+	secret := dp.cfg.GetString("web.jwtsigningsecret")
+	if secret == "" {
+		secret = defaultJWTSigningSecret
+	}
+
+	args := []string{"admin", "token", "--name", "install-check", "--jwt-signing-secret", secret}
+	if iss := dp.cfg.GetString("web.jwtissuer"); iss != "" {
+		args = append(args, "--issuer", iss)
+	}
+	if aud := dp.cfg.GetString("web.jwtaudience"); aud != "" {
+		args = append(args, "--audience", aud)
+	}
+	cmd := execCommand(installedKaravictl, args...)
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("generating admin token: %w; check that karavictl is installed at %s", err, installedKaravictl)
+	}
+	var tkn token.AdminToken
+	if err := json.Unmarshal(out, &tkn); err != nil || tkn.Access == "" {
+		return fmt.Errorf("generating admin token: unexpected output %q", string(out))
+	}
+
+	resp, err := dp.ingressRequest(http.MethodGet, web.ProxyRolesPath, tkn.Access)
+	if err != nil {
+		return fmt.Errorf("checking admin token with proxy-server: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("proxy-server rejected the admin token; check that web.jwtSigningSecret, web.jwtIssuer and web.jwtAudience in the configuration match those used by proxy-server")
+	}
+	return nil
+}
+
+// ingressRequest sends a request for the configured hostname to the local
+// ingress, so that the check does not depend on DNS being set up yet.
+func (dp *DeployProcess) ingressRequest(method, path, accessToken string) (*http.Response, error) {
+	hostName := dp.cfg.GetString("hostname")
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, ingressAddr)
+			},
+			TLSClientConfig: &tls.Config{
+				ServerName: hostName,
+				// The certificate may be self-signed at this point.
+				InsecureSkipVerify: true, // #nosec G402
+			},
+		},
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("https://%s%s", hostName, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return client.Do(req)
+}
+
+// poll calls fn until it succeeds or verifyTimeout elapses, returning the
+// last error.
+func poll(fn func() error) error {
+	deadline := timeNow().Add(verifyTimeout)
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if timeNow().After(deadline) {
+			return err
+		}
+		timeSleep(verifyInterval)
+	}
+}
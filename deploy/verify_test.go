@@ -0,0 +1,161 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	readyPods    = `{"items": [{"metadata": {"name": "proxy-server-1"}, "status": {"phase": "Running", "conditions": [{"type": "Ready", "status": "True"}]}}, {"metadata": {"name": "job-1"}, "status": {"phase": "Succeeded"}}]}`
+	notReadyPods = `{"items": [{"metadata": {"name": "proxy-server-1"}, "status": {"phase": "Pending", "conditions": [{"type": "Ready", "status": "False"}]}}]}`
+)
+
+func TestDeployProcess_VerifyDeployment(t *testing.T) {
+	var testOut bytes.Buffer
+	sut := buildDeployProcess(&testOut, nil)
+	sut.cfg.Set("hostname", "karavi.example.com")
+
+	var gotHost, gotAuthz string
+	rolesStatus := http.StatusOK
+	ingress := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		switch r.URL.Path {
+		case "/healthz":
+			w.Write([]byte("ok"))
+		case "/proxy/roles/":
+			gotAuthz = r.Header.Get("Authorization")
+			w.WriteHeader(rolesStatus)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ingress.Close()
+
+	fakeCommands := func(pods, adminToken string) {
+		execCommand = func(name string, _ ...string) *exec.Cmd {
+			switch name {
+			case installedK3s:
+				return exec.Command("echo", pods)
+			case installedKaravictl:
+				return exec.Command("echo", adminToken)
+			}
+			t.Errorf("unexpected command %s", name)
+			return exec.Command("false")
+		}
+	}
+	beforeEach := func() {
+		ingressAddr = strings.TrimPrefix(ingress.URL, "https://")
+		verifyTimeout = 0
+		timeSleep = func(_ time.Duration) {}
+		fakeCommands(readyPods, `{"Access": "access", "Refresh": "refresh"}`)
+	}
+	afterEach := func() {
+		sut.Err = nil
+		testOut.Reset()
+		execCommand = exec.Command
+		ingressAddr = "127.0.0.1:443"
+		verifyTimeout = 5 * time.Minute
+		timeSleep = time.Sleep
+		rolesStatus = http.StatusOK
+	}
+
+	t.Run("it is a noop on sticky error", func(t *testing.T) {
+		defer afterEach()
+		sut.Err = errors.New("test error")
+
+		sut.VerifyDeployment()
+
+		if testOut.Len() != 0 {
+			t.Errorf("got output = %q, wanted no output", testOut.String())
+		}
+	})
+	t.Run("it verifies a healthy deployment", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+
+		sut.VerifyDeployment()
+
+		if sut.Err != nil {
+			t.Fatalf("got err = %v, want nil", sut.Err)
+		}
+		if gotHost != "karavi.example.com" {
+			t.Errorf("got host %q, want karavi.example.com", gotHost)
+		}
+		if gotAuthz != "Bearer access" {
+			t.Errorf("got authorization %q, want %q", gotAuthz, "Bearer access")
+		}
+	})
+	t.Run("it waits for pods to become ready", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+		verifyTimeout = time.Minute
+		var sleeps int
+		timeSleep = func(_ time.Duration) {
+			sleeps++
+			fakeCommands(readyPods, `{"Access": "access"}`)
+		}
+		fakeCommands(notReadyPods, "")
+
+		sut.VerifyDeployment()
+
+		if sut.Err != nil {
+			t.Fatalf("got err = %v, want nil", sut.Err)
+		}
+		if sleeps != 1 {
+			t.Errorf("got %d retries, want 1", sleeps)
+		}
+	})
+	t.Run("it reports pods that are not ready", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+		fakeCommands(notReadyPods, "")
+
+		sut.VerifyDeployment()
+
+		if sut.Err == nil || !strings.Contains(sut.Err.Error(), "proxy-server-1") {
+			t.Errorf("got err = %v, want pod not ready", sut.Err)
+		}
+	})
+	t.Run("it reports a failed health check", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+		ingressAddr = "127.0.0.1:1"
+
+		sut.VerifyDeployment()
+
+		if sut.Err == nil || !strings.Contains(sut.Err.Error(), "health") {
+			t.Errorf("got err = %v, want health check failure", sut.Err)
+		}
+	})
+	t.Run("it reports a rejected admin token", func(t *testing.T) {
+		beforeEach()
+		defer afterEach()
+		rolesStatus = http.StatusUnauthorized
+
+		sut.VerifyDeployment()
+
+		if sut.Err == nil || !strings.Contains(sut.Err.Error(), "jwtSigningSecret") {
+			t.Errorf("got err = %v, want admin token rejected", sut.Err)
+		}
+	})
+}
@@ -0,0 +1,163 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package appconfig loads and watches the configuration of the services:
+// the configuration files with their defaults and environment overrides,
+// and the logging and tracing settings that every service shares.
+package appconfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Source is where a configuration is read from.
+type Source struct {
+	// Name is the name of the configuration file without its extension,
+	// looked up in Paths, e.g. "config".
+	Name  string
+	Paths []string
+	// File is the path of the configuration file. It takes precedence
+	// over Name and Paths.
+	File string
+	// Defaults are the values of the keys, e.g. "web.debughost", that
+	// are neither in the file nor in the environment.
+	Defaults Defaults
+	// Optional configurations are loaded from the defaults and the
+	// environment when the file does not exist.
+	Optional bool
+}
+
+// Defaults are the default values of configuration keys.
+type Defaults map[string]interface{}
+
+// With returns the defaults together with others, which take precedence.
+func (d Defaults) With(others Defaults) Defaults {
+	merged := make(Defaults, len(d)+len(others))
+	for k, v := range d {
+		merged[k] = v
+	}
+	for k, v := range others {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Config is a configuration, whose keys are overridden by the environment
+// variables with their dots replaced by underscores, e.g. the
+// DATABASE_PASSWORD environment variable overrides database.password.
+type Config struct {
+	log  *logrus.Entry
+	v    *viper.Viper
+	read bool
+
+	mu       sync.Mutex
+	watching bool
+	onChange []func()
+}
+
+// New returns the configuration of the source without reading it.
+func New(log *logrus.Entry, src Source) *Config {
+	v := viper.New()
+	if src.File != "" {
+		v.SetConfigFile(src.File)
+	} else {
+		v.SetConfigName(src.Name)
+		for _, p := range src.Paths {
+			v.AddConfigPath(p)
+		}
+	}
+	for k, d := range src.Defaults {
+		v.SetDefault(k, d)
+	}
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	return &Config{log: log, v: v}
+}
+
+// Load reads the configuration of the source and decodes it into cfg, a
+// pointer to the typed configuration of the service.
+func Load(log *logrus.Entry, src Source, cfg interface{}) (*Config, error) {
+	c := New(log, src)
+	if err := c.Read(src.Optional); err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return c, nil
+	}
+	if err := c.Decode(cfg); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Read reads the configuration file. A missing file is not an error if it
+// is optional.
+func (c *Config) Read(optional bool) error {
+	err := c.v.ReadInConfig()
+	var notFound viper.ConfigFileNotFoundError
+	switch {
+	case err == nil:
+		c.read = true
+	case optional && (errors.As(err, &notFound) || errors.Is(err, fs.ErrNotExist)):
+		c.log.Warn("config file not found; using the defaults and environment")
+	default:
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	return nil
+}
+
+// Decode decodes the configuration into cfg.
+func (c *Config) Decode(cfg interface{}) error {
+	if err := c.v.Unmarshal(cfg); err != nil {
+		return fmt.Errorf("decoding config file: %w", err)
+	}
+	return nil
+}
+
+// Viper returns the viper of the configuration, for the keys that are not
+// decoded into the typed configuration.
+func (c *Config) Viper() *viper.Viper {
+	return c.v
+}
+
+// Watch calls fn whenever the configuration file changes. Every function
+// is called, in the order they were added. Nothing is watched if the file
+// was not read.
+func (c *Config) Watch(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onChange = append(c.onChange, fn)
+	if c.watching || !c.read {
+		return
+	}
+	c.watching = true
+	c.v.OnConfigChange(func(e fsnotify.Event) {
+		c.log.WithField("file", e.Name).Info("config file changed")
+		c.mu.Lock()
+		fns := append([]func(){}, c.onChange...)
+		c.mu.Unlock()
+		for _, fn := range fns {
+			fn()
+		}
+	})
+	c.v.WatchConfig()
+}
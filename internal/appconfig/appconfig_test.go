@@ -0,0 +1,164 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appconfig_test
+
+import (
+	"io"
+	"karavi-authorization/internal/appconfig"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type testConfig struct {
+	GrpcListenAddr string
+	Zipkin         appconfig.Zipkin
+	Web            struct {
+		DebugHost       string
+		ShutdownTimeout time.Duration
+	}
+}
+
+func discardLogger() *logrus.Entry {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logrus.NewEntry(logger)
+}
+
+func TestLoad(t *testing.T) {
+	defaults := appconfig.Defaults{
+		"grpclistenaddr":      ":50051",
+		"web.debughost":       ":9090",
+		"web.shutdowntimeout": 15 * time.Second,
+	}.With(appconfig.ZipkinDefaults(appconfig.DefaultZipkinCollectorURI))
+
+	t.Run("it reads the file over the defaults", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "config.yaml"), "web:\n  debughost: :9091\n")
+
+		var cfg testConfig
+		_, err := appconfig.Load(discardLogger(), appconfig.Source{Name: "config", Paths: []string{dir}, Defaults: defaults}, &cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Web.DebugHost != ":9091" || cfg.GrpcListenAddr != ":50051" || cfg.Web.ShutdownTimeout != 15*time.Second {
+			t.Errorf("got %+v, want the debug host of the file and the other defaults", cfg)
+		}
+		if cfg.Zipkin.CollectorURI != appconfig.DefaultZipkinCollectorURI || cfg.Zipkin.Probability != 0.8 {
+			t.Errorf("got zipkin %+v, want the defaults", cfg.Zipkin)
+		}
+	})
+
+	t.Run("the environment overrides the file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "config.yaml"), "zipkin:\n  probability: 0.5\n")
+		t.Setenv("ZIPKIN_PROBABILITY", "0.1")
+		t.Setenv("GRPCLISTENADDR", ":50052")
+
+		var cfg testConfig
+		_, err := appconfig.Load(discardLogger(), appconfig.Source{Name: "config", Paths: []string{dir}, Defaults: defaults}, &cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Zipkin.Probability != 0.1 || cfg.GrpcListenAddr != ":50052" {
+			t.Errorf("got %+v, want the values of the environment", cfg)
+		}
+	})
+
+	t.Run("it uses the defaults without an optional file", func(t *testing.T) {
+		var cfg testConfig
+		_, err := appconfig.Load(discardLogger(), appconfig.Source{Name: "config", Paths: []string{t.TempDir()}, Defaults: defaults, Optional: true}, &cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Web.DebugHost != ":9090" {
+			t.Errorf("got debug host %q, want the default", cfg.Web.DebugHost)
+		}
+
+		_, err = appconfig.Load(discardLogger(), appconfig.Source{File: filepath.Join(t.TempDir(), "missing.yaml"), Optional: true}, nil)
+		if err != nil {
+			t.Errorf("got %v, want no error for a missing optional file", err)
+		}
+	})
+
+	t.Run("it fails without a required file", func(t *testing.T) {
+		_, err := appconfig.Load(discardLogger(), appconfig.Source{Name: "config", Paths: []string{t.TempDir()}}, nil)
+		if err == nil {
+			t.Error("got nil error, want an error for the missing file")
+		}
+	})
+
+	t.Run("it fails with an invalid file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "config.yaml")
+		writeFile(t, file, "web: [")
+		_, err := appconfig.Load(discardLogger(), appconfig.Source{File: file, Optional: true}, nil)
+		if err == nil {
+			t.Error("got nil error, want an error for the invalid file")
+		}
+	})
+}
+
+func TestConfig_Watch(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "csm-config-params.yaml")
+	writeFile(t, file, "LOG_LEVEL: info\n")
+
+	log := discardLogger()
+	cfg, err := appconfig.Load(log, appconfig.Source{File: file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.WatchLogging(log, appconfig.DefaultLogKeys)
+	if got := log.Logger.GetLevel(); got != logrus.InfoLevel {
+		t.Errorf("got level %v, want %v", got, logrus.InfoLevel)
+	}
+
+	changed := make(chan string, 10)
+	cfg.Watch(func() { changed <- cfg.Viper().GetString("LOG_LEVEL") })
+
+	writeFile(t, file, "LOG_LEVEL: debug\n")
+	select {
+	case got := <-changed:
+		if got != "debug" {
+			t.Errorf("got LOG_LEVEL %q, want %q", got, "debug")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the change")
+	}
+	if got := log.Logger.GetLevel(); got != logrus.DebugLevel {
+		t.Errorf("got level %v, want %v", got, logrus.DebugLevel)
+	}
+}
+
+func TestInitTracing(t *testing.T) {
+	tp, err := appconfig.InitTracing(discardLogger(), appconfig.Zipkin{}, "test")
+	if err != nil || tp != nil {
+		t.Errorf("got %v, %v, want no provider without a collector", tp, err)
+	}
+
+	tp, err = appconfig.InitTracing(discardLogger(), appconfig.Zipkin{CollectorURI: appconfig.DefaultZipkinCollectorURI, Probability: 1}, "test")
+	if err != nil || tp == nil {
+		t.Errorf("got %v, %v, want a provider", tp, err)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appconfig
+
+import (
+	"karavi-authorization/internal/redact"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogKeys are the keys of the logging settings in a configuration.
+type LogKeys struct {
+	Level          string
+	Format         string
+	RedactFields   string
+	RedactPatterns string
+}
+
+// DefaultLogKeys are the keys of the logging settings in the
+// csm-config-params ConfigMap.
+var DefaultLogKeys = LogKeys{
+	Level:          "LOG_LEVEL",
+	Format:         "LOG_FORMAT",
+	RedactFields:   "LOG_REDACT_FIELDS",
+	RedactPatterns: "LOG_REDACT_PATTERNS",
+}
+
+// ConfigureLogging sets the format, redaction and level of the logger from
+// the logging settings of the configuration. The text format and the info
+// level are used when they are not set or not valid.
+func (c *Config) ConfigureLogging(log *logrus.Entry, keys LogKeys) {
+	logFormat := c.v.GetString(keys.Format)
+	if strings.EqualFold(logFormat, "json") {
+		log.Logger.SetFormatter(redact.NewFormatter(&logrus.JSONFormatter{}))
+	} else {
+		// use text formatter by default
+		log.Logger.SetFormatter(redact.NewFormatter(&logrus.TextFormatter{}))
+	}
+	if err := redact.Configure(c.v.GetStringSlice(keys.RedactFields), c.v.GetStringSlice(keys.RedactPatterns)); err != nil {
+		log.WithError(err).Warn("invalid log redaction settings, keeping the previous ones")
+	}
+	if logFormat != "" {
+		log.WithField(keys.Format, logFormat).Info("configuration has been set")
+	}
+
+	level, err := logrus.ParseLevel(c.v.GetString(keys.Level))
+	if err != nil {
+		// use INFO level by default
+		level = logrus.InfoLevel
+	}
+
+	// There are two log statements to ensure that we capture all level changes
+	log.WithField(keys.Level, level.String()).Info("configuration has been set")
+	log.Logger.SetLevel(level)
+	log.WithField(keys.Level, level.String()).Info("configuration has been set")
+}
+
+// WatchLogging configures the logger from the logging settings of the
+// configuration now and whenever they change.
+func (c *Config) WatchLogging(log *logrus.Entry, keys LogKeys) {
+	c.ConfigureLogging(log, keys)
+	c.Watch(func() { c.ConfigureLogging(log, keys) })
+}
@@ -0,0 +1,85 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appconfig
+
+import (
+	"fmt"
+	"io"
+	stdLog "log"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/zipkin"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+// DefaultZipkinCollectorURI is the zipkin collector of the services that
+// trace by default.
+const DefaultZipkinCollectorURI = "http://localhost:9411/api/v2/spans"
+
+// Zipkin is the tracing configuration of a service.
+type Zipkin struct {
+	CollectorURI string
+	ServiceName  string
+	Probability  float64
+}
+
+// ZipkinDefaults returns the defaults of the tracing configuration, under
+// the zipkin key, with the collector.
+func ZipkinDefaults(collectorURI string) Defaults {
+	return Defaults{
+		"zipkin.collectoruri": collectorURI,
+		"zipkin.servicename":  "proxy-server",
+		"zipkin.probability":  0.8,
+	}
+}
+
+// InitTracing exports the traces of the service named name to the zipkin
+// collector, sampled with the probability of the configuration. It
+// returns a nil provider without a collector.
+func InitTracing(log *logrus.Entry, z Zipkin, name string) (*trace.TracerProvider, error) {
+	if len(strings.TrimSpace(z.CollectorURI)) == 0 {
+		return nil, nil
+	}
+
+	log.Info("main: initializing otel/zipkin tracing support")
+
+	exporter, err := zipkin.New(
+		z.CollectorURI,
+		zipkin.WithLogger(stdLog.New(io.Discard, "", stdLog.LstdFlags)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating zipkin exporter: %w", err)
+	}
+
+	tp := trace.NewTracerProvider(
+		trace.WithSampler(trace.TraceIDRatioBased(z.Probability)),
+		trace.WithBatcher(
+			exporter,
+			trace.WithMaxExportBatchSize(trace.DefaultMaxExportBatchSize),
+			trace.WithBatchTimeout(trace.DefaultScheduleDelay),
+		),
+		trace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			attribute.KeyValue{Key: semconv.ServiceNameKey, Value: attribute.StringValue(name)})),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}))
+	return tp, nil
+}
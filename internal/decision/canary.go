@@ -0,0 +1,108 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decision
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"karavi-authorization/internal/web"
+	"math/rand"
+	"sync/atomic"
+)
+
+// Canary routes the queries of a cohort of the tenants to a canary OPA
+// instance, e.g. one with new roles, so that a change is rolled out to a
+// percentage of the tenants first. Unlike with Shadow, the decision of the
+// canary instance is the one returned for the cohort.
+type Canary struct {
+	// Host is the host:port of the canary OPA instance.
+	Host string
+	// Percent is the percentage of the tenants in the cohort, from 0 to
+	// 100. Queries without a tenant are sampled at the same percentage.
+	Percent float64
+	// Observe, if set, is called with whether each query was answered by
+	// the canary instance and whether it was denied, for comparing the
+	// denial rates of the cohort and of the other tenants.
+	Observe func(canary, denied bool)
+}
+
+var canary atomic.Pointer[Canary]
+
+// SetCanary sets where the queries of the cohort are routed. A nil Canary,
+// an empty host or a percentage of 0 routes every query to its own host.
+func SetCanary(c *Canary) {
+	if c == nil || c.Host == "" || c.Percent <= 0 {
+		canary.Store(nil)
+		return
+	}
+	v := *c
+	canary.Store(&v)
+}
+
+// InCohort reports whether the tenant is in the cohort of the percentage
+// of the tenants. A tenant stays in the cohort when the percentage grows.
+func InCohort(tenant string, percent float64) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(tenant))
+	return float64(h.Sum32()%10000) < percent*100
+}
+
+// canaryHost returns the host of the canary instance if the query of the
+// request in ctx is in the cohort.
+func canaryHost(ctx context.Context, q Query) (*Canary, bool) {
+	c := canary.Load()
+	if c == nil || c.Host == q.Host {
+		return c, false
+	}
+	if tenant, _ := ctx.Value(web.JWTTenantName).(string); tenant != "" {
+		return c, InCohort(tenant, c.Percent)
+	}
+	return c, rand.Float64()*100 < c.Percent
+}
+
+// observeCanary reports the outcome of the query to the canary, if it
+// observes them and the outcome is a decision.
+func observeCanary(c *Canary, inCohort bool, ans []byte) {
+	if c == nil || c.Observe == nil {
+		return
+	}
+	if denied, ok := deniedResult(ans); ok {
+		c.Observe(inCohort, denied)
+	}
+}
+
+// deniedResult reports whether an OPA response denies the request: its
+// result is false or has allow false. ok is false if the result is not a
+// decision.
+func deniedResult(ans []byte) (denied, ok bool) {
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(ans, &resp); err != nil || len(resp.Result) == 0 {
+		return false, false
+	}
+	var allow bool
+	if err := json.Unmarshal(resp.Result, &allow); err == nil {
+		return !allow, true
+	}
+	var result struct {
+		Allow *bool `json:"allow"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil || result.Allow == nil {
+		return false, false
+	}
+	return !*result.Allow, true
+}
@@ -0,0 +1,118 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decision
+
+import (
+	"context"
+	"fmt"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCanary(t *testing.T) {
+	opa := func(allow bool, calls *atomic.Int32) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			fmt.Fprintf(w, `{"result": {"allow": %t}}`, allow)
+		}))
+		t.Cleanup(srv.Close)
+		return strings.TrimPrefix(srv.URL, "http://")
+	}
+	can := func(host, tenant string) []byte {
+		t.Helper()
+		ctx := context.WithValue(context.Background(), web.JWTTenantName, tenant)
+		ans, err := CanWithContext(ctx, func() Query {
+			return Query{Host: host, Policy: "/karavi/volumes/create", Input: map[string]interface{}{"name": "vol"}}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ans
+	}
+	defer SetCanary(nil)
+
+	t.Run("it routes the queries of the cohort to the canary", func(t *testing.T) {
+		var primaryCalls, canaryCalls atomic.Int32
+		primary := opa(true, &primaryCalls)
+		type outcome struct{ canary, denied bool }
+		var got []outcome
+		SetCanary(&Canary{Host: opa(false, &canaryCalls), Percent: 50, Observe: func(canary, denied bool) {
+			got = append(got, outcome{canary, denied})
+		}})
+
+		var in, out string
+		for i := 0; in == "" || out == ""; i++ {
+			tenant := fmt.Sprintf("tenant-%d", i)
+			if InCohort(tenant, 50) {
+				in = tenant
+			} else {
+				out = tenant
+			}
+		}
+		can(primary, in)
+		can(primary, out)
+
+		if primaryCalls.Load() != 1 || canaryCalls.Load() != 1 {
+			t.Errorf("got %d primary and %d canary queries, want 1 and 1", primaryCalls.Load(), canaryCalls.Load())
+		}
+		if want := []outcome{{true, true}, {false, false}}; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("got outcomes %v, want %v", got, want)
+		}
+	})
+
+	t.Run("it routes every query to its host without a canary", func(t *testing.T) {
+		var primaryCalls, canaryCalls atomic.Int32
+		primary := opa(true, &primaryCalls)
+		SetCanary(&Canary{Host: opa(false, &canaryCalls), Percent: 0})
+
+		can(primary, "tenant-1")
+
+		if primaryCalls.Load() != 1 || canaryCalls.Load() != 0 {
+			t.Errorf("got %d primary and %d canary queries, want 1 and 0", primaryCalls.Load(), canaryCalls.Load())
+		}
+	})
+
+	t.Run("it keeps tenants in the cohort as it grows", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			tenant := fmt.Sprintf("tenant-%d", i)
+			if InCohort(tenant, 10) && !InCohort(tenant, 20) {
+				t.Errorf("%s left the cohort as it grew", tenant)
+			}
+		}
+	})
+}
+
+func TestDeniedResult(t *testing.T) {
+	tests := []struct {
+		ans          string
+		denied, isOK bool
+	}{
+		{`{"result": {"allow": false, "deny": ["over quota"]}}`, true, true},
+		{`{"result": {"allow": true}}`, false, true},
+		{`{"result": false}`, true, true},
+		{`{"result": {"roles": {}}}`, false, false},
+		{`{}`, false, false},
+	}
+	for _, tt := range tests {
+		denied, ok := deniedResult([]byte(tt.ans))
+		if denied != tt.denied || ok != tt.isOK {
+			t.Errorf("%s: got %v, %v, want %v, %v", tt.ans, denied, ok, tt.denied, tt.isOK)
+		}
+	}
+}
@@ -16,11 +16,16 @@ package decision
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/url"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Query is the query format to OPA to allow or deny a request
@@ -32,7 +37,12 @@ type Query struct {
 
 // Can asks OPA for a request decision based on the supplied function that returns a Query
 func Can(fn func() Query) ([]byte, error) {
-	// TODO(ian): Need a context here for tracing.
+	return CanWithContext(context.Background(), fn)
+}
+
+// CanWithContext is like Can but records the OPA query as a child span of
+// the span found in ctx.
+func CanWithContext(ctx context.Context, fn func() Query) (_ []byte, err error) {
 	// Query:
 	//
 	//{
@@ -46,9 +56,39 @@ func Can(fn func() Query) ([]byte, error) {
 	//}
 	// curl -v -d @query-create-volume.json localhost:8181/v1/data/dell/policy/allow
 
-	var b bytes.Buffer
 	q := fn()
-	err := json.NewEncoder(&b).Encode(&q)
+	c, inCohort := canaryHost(ctx, q)
+	if inCohort {
+		q.Host = c.Host
+	}
+
+	ctx, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "opa.decision",
+		trace.WithAttributes(attribute.String("opa.policy", q.Policy), attribute.Bool("opa.canary", inCohort)))
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+
+	ans, err := query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	observeCanary(c, inCohort, ans)
+	// The decisions of the canary instance are compared by their denial
+	// rate rather than mirrored.
+	if !inCohort {
+		shadowQuery(ctx, q, ans)
+	}
+	return ans, nil
+}
+
+// query sends q to the OPA instance at q.Host.
+func query(ctx context.Context, q Query) ([]byte, error) {
+	var body bytes.Buffer
+	err := json.NewEncoder(&body).Encode(&q)
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +97,7 @@ func Can(fn func() Query) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest(http.MethodPost, u.String(), &b)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
 	if err != nil {
 		return nil, err
 	}
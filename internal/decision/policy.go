@@ -0,0 +1,131 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decision
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Policy is a rego module loaded in OPA.
+type Policy struct {
+	ID  string `json:"id"`
+	Raw string `json:"raw"`
+}
+
+// Error is an error response returned by the OPA REST API. Compile errors
+// for a rejected rego module are listed in Errors.
+type Error struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Errors     []struct {
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+		Location *struct {
+			File string `json:"file"`
+			Row  int    `json:"row"`
+			Col  int    `json:"col"`
+		} `json:"location,omitempty"`
+	} `json:"errors,omitempty"`
+}
+
+func (e *Error) Error() string {
+	msgs := []string{e.Message}
+	for _, v := range e.Errors {
+		if v.Location != nil {
+			msgs = append(msgs, fmt.Sprintf("%s:%d:%d: %s", v.Location.File, v.Location.Row, v.Location.Col, v.Message))
+			continue
+		}
+		msgs = append(msgs, v.Message)
+	}
+	return fmt.Sprintf("opa: %s", strings.Join(msgs, "; "))
+}
+
+// PutPolicy creates or replaces the rego module with the given id. OPA
+// compiles the module before accepting it, so a compile failure is
+// returned as an *Error and the previously loaded module is left in place.
+func PutPolicy(ctx context.Context, host, id string, module []byte) error {
+	_, err := doOPA(ctx, http.MethodPut, fmt.Sprintf("http://%s/v1/policies/%s", host, id), "text/plain", module)
+	return err
+}
+
+// DeletePolicy removes the rego module with the given id.
+func DeletePolicy(ctx context.Context, host, id string) error {
+	_, err := doOPA(ctx, http.MethodDelete, fmt.Sprintf("http://%s/v1/policies/%s", host, id), "", nil)
+	return err
+}
+
+// ListPolicies returns the rego modules loaded in OPA.
+func ListPolicies(ctx context.Context, host string) ([]Policy, error) {
+	b, err := doOPA(ctx, http.MethodGet, fmt.Sprintf("http://%s/v1/policies", host), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Result []Policy `json:"result"`
+	}
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+// PutData creates or replaces the data document at the given path, e.g.
+// "karavi/common".
+func PutData(ctx context.Context, host, path string, doc []byte) error {
+	_, err := doOPA(ctx, http.MethodPut, fmt.Sprintf("http://%s/v1/data/%s", host, strings.TrimPrefix(path, "/")), "application/json", doc)
+	return err
+}
+
+// DeleteData removes the data document at the given path.
+func DeleteData(ctx context.Context, host, path string) error {
+	_, err := doOPA(ctx, http.MethodDelete, fmt.Sprintf("http://%s/v1/data/%s", host, strings.TrimPrefix(path, "/")), "", nil)
+	return err
+}
+
+func doOPA(ctx context.Context, method, url, contentType string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		opaErr := &Error{StatusCode: resp.StatusCode}
+		if err := json.Unmarshal(b, opaErr); err != nil || opaErr.Message == "" {
+			opaErr.Message = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+		}
+		return nil, opaErr
+	}
+	return b, nil
+}
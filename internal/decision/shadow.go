@@ -0,0 +1,128 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decision
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ShadowTimeout is how long a shadow query may take.
+const ShadowTimeout = 10 * time.Second
+
+// Shadow mirrors a percentage of the queries to a secondary OPA instance,
+// e.g. one with staged policies, and logs the queries whose decisions
+// differ from those of the primary instance. The decision of the primary
+// instance is always the one returned.
+type Shadow struct {
+	// Host is the host:port of the secondary OPA instance.
+	Host string
+	// Percent is the percentage of the queries to mirror, from 0 to 100.
+	Percent float64
+	// Log receives the mismatches. It defaults to the standard logger.
+	Log *logrus.Entry
+}
+
+var (
+	shadow   atomic.Pointer[Shadow]
+	shadowWG sync.WaitGroup // tracks the running shadow queries, for tests
+)
+
+// SetShadow sets where queries are mirrored. A nil Shadow, an empty host
+// or a percentage of 0 turns shadowing off.
+func SetShadow(s *Shadow) {
+	if s == nil || s.Host == "" || s.Percent <= 0 {
+		shadow.Store(nil)
+		return
+	}
+	c := *s
+	if c.Log == nil {
+		c.Log = logrus.NewEntry(logrus.StandardLogger())
+	}
+	shadow.Store(&c)
+}
+
+// shadowQuery sends q to the shadow OPA instance in the background, if it
+// is sampled, and compares the result with that of the primary instance.
+func shadowQuery(ctx context.Context, q Query, primary []byte) {
+	s := shadow.Load()
+	if s == nil || s.Host == q.Host || rand.Float64()*100 >= s.Percent {
+		return
+	}
+
+	q.Host = s.Host
+	// The shadow query outlives the request being authorized.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ShadowTimeout)
+	shadowWG.Add(1)
+	go func() {
+		defer shadowWG.Done()
+		defer cancel()
+
+		log := s.Log.WithFields(logrus.Fields{
+			"policy":      q.Policy,
+			"shadow_host": s.Host,
+		})
+		ans, err := query(ctx, q)
+		if err != nil {
+			log.WithError(err).Warn("opa shadow query")
+			return
+		}
+		same, err := sameResult(primary, ans)
+		if err != nil {
+			log.WithError(err).Warn("opa shadow query")
+			return
+		}
+		if !same {
+			log.WithFields(logrus.Fields{
+				"input":   q.Input,
+				"primary": string(bytes.TrimSpace(primary)),
+				"shadow":  string(bytes.TrimSpace(ans)),
+			}).Warn("opa shadow decision mismatch")
+		}
+	}()
+}
+
+// sameResult reports whether two OPA responses hold the same result.
+func sameResult(a, b []byte) (bool, error) {
+	var ra, rb struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(a, &ra); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, &rb); err != nil {
+		return false, err
+	}
+	var va, vb interface{}
+	if len(ra.Result) > 0 {
+		if err := json.Unmarshal(ra.Result, &va); err != nil {
+			return false, err
+		}
+	}
+	if len(rb.Result) > 0 {
+		if err := json.Unmarshal(rb.Result, &vb); err != nil {
+			return false, err
+		}
+	}
+	return reflect.DeepEqual(va, vb), nil
+}
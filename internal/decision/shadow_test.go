@@ -0,0 +1,94 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decision
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestShadow(t *testing.T) {
+	opa := func(allow bool, calls *atomic.Int32) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			fmt.Fprintf(w, `{"result": {"allow": %t}}`, allow)
+		}))
+		t.Cleanup(srv.Close)
+		return strings.TrimPrefix(srv.URL, "http://")
+	}
+	can := func(host string) {
+		t.Helper()
+		_, err := CanWithContext(context.Background(), func() Query {
+			return Query{Host: host, Policy: "/karavi/volumes/create", Input: map[string]interface{}{"name": "vol"}}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		shadowWG.Wait()
+	}
+	defer SetShadow(nil)
+
+	t.Run("it logs mismatched decisions", func(t *testing.T) {
+		var primaryCalls, shadowCalls atomic.Int32
+		primary := opa(true, &primaryCalls)
+		logger, hook := test.NewNullLogger()
+		SetShadow(&Shadow{Host: opa(false, &shadowCalls), Percent: 100, Log: logrus.NewEntry(logger)})
+
+		can(primary)
+
+		if primaryCalls.Load() != 1 || shadowCalls.Load() != 1 {
+			t.Errorf("got %d primary and %d shadow queries, want 1 and 1", primaryCalls.Load(), shadowCalls.Load())
+		}
+		if got := hook.LastEntry(); got == nil || got.Message != "opa shadow decision mismatch" {
+			t.Errorf("got log entry %v, want a mismatch", got)
+		}
+	})
+
+	t.Run("it does not log matching decisions", func(t *testing.T) {
+		var primaryCalls, shadowCalls atomic.Int32
+		primary := opa(true, &primaryCalls)
+		logger, hook := test.NewNullLogger()
+		SetShadow(&Shadow{Host: opa(true, &shadowCalls), Percent: 100, Log: logrus.NewEntry(logger)})
+
+		can(primary)
+
+		if shadowCalls.Load() != 1 {
+			t.Errorf("got %d shadow queries, want 1", shadowCalls.Load())
+		}
+		if got := len(hook.AllEntries()); got != 0 {
+			t.Errorf("got %d log entries, want none", got)
+		}
+	})
+
+	t.Run("it does not mirror when turned off", func(t *testing.T) {
+		var primaryCalls, shadowCalls atomic.Int32
+		primary := opa(true, &primaryCalls)
+		SetShadow(&Shadow{Host: opa(false, &shadowCalls), Percent: 0})
+
+		can(primary)
+
+		if shadowCalls.Load() != 0 {
+			t.Errorf("got %d shadow queries, want none", shadowCalls.Load())
+		}
+	})
+}
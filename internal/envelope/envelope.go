@@ -0,0 +1,205 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package envelope provides envelope encryption of secret values, such as
+// the storage system passwords in the storage secret. Each value is
+// encrypted with its own data key, which is in turn wrapped by a key
+// encryption key, e.g. a master key mounted separately from the secret or
+// a key held by a KMS.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Prefix marks an encrypted value. An encrypted value has the format
+// enc:v1:<key id>:<wrapped data key>:<nonce and ciphertext>, where the
+// last two parts are base64 encoded.
+const Prefix = "enc:v1:"
+
+// MasterKeySize is the size of a master key, which is an AES-256 key.
+const MasterKeySize = 32
+
+// ErrNoKey is returned when an encrypted value is decrypted without a key.
+var ErrNoKey = errors.New("value is encrypted but no key is configured")
+
+// KeyWrapper wraps and unwraps data keys with a key encryption key.
+type KeyWrapper interface {
+	// KeyID identifies the key encryption key.
+	KeyID() string
+	// WrapKey encrypts a data key.
+	WrapKey(dek []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key wrapped by the key with the given id.
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// MasterKey is a KeyWrapper using a local AES-256 master key.
+type MasterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewMasterKey returns a MasterKey for the given 32 byte key.
+func NewMasterKey(key []byte) (*MasterKey, error) {
+	if len(key) != MasterKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", MasterKeySize, len(key))
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &MasterKey{
+		id:   hex.EncodeToString(sum[:8]),
+		aead: aead,
+	}, nil
+}
+
+// LoadMasterKey reads a base64 encoded master key from a file, e.g. one
+// generated with "openssl rand -base64 32".
+func LoadMasterKey(path string) (*MasterKey, error) {
+	b, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("reading master key: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, fmt.Errorf("decoding master key: %w", err)
+	}
+	return NewMasterKey(key)
+}
+
+// KeyID returns the id of the master key, derived from its hash.
+func (k *MasterKey) KeyID() string {
+	return k.id
+}
+
+// WrapKey encrypts a data key with the master key.
+func (k *MasterKey) WrapKey(dek []byte) ([]byte, error) {
+	return seal(k.aead, dek)
+}
+
+// UnwrapKey decrypts a data key wrapped by this master key.
+func (k *MasterKey) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	if keyID != k.id {
+		return nil, fmt.Errorf("value was encrypted with key %q, not %q", keyID, k.id)
+	}
+	return open(k.aead, wrapped)
+}
+
+// IsEncrypted returns true if the value was encrypted by Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Encrypt encrypts a value with a new data key wrapped by kw.
+func Encrypt(kw KeyWrapper, plaintext string) (string, error) {
+	dek := make([]byte, MasterKeySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return "", fmt.Errorf("generating data key: %w", err)
+	}
+	aead, err := newGCM(dek)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(aead, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := kw.WrapKey(dek)
+	if err != nil {
+		return "", fmt.Errorf("wrapping data key: %w", err)
+	}
+	return Prefix + strings.Join([]string{
+		kw.KeyID(),
+		base64.StdEncoding.EncodeToString(wrapped),
+		base64.StdEncoding.EncodeToString(ciphertext),
+	}, ":"), nil
+}
+
+// Decrypt decrypts a value encrypted by Encrypt. Values that are not
+// encrypted are returned as they are, so that secrets may be migrated to
+// encryption gradually.
+func Decrypt(kw KeyWrapper, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if kw == nil {
+		return "", ErrNoKey
+	}
+	parts := strings.Split(strings.TrimPrefix(value, Prefix), ":")
+	if len(parts) != 3 {
+		return "", errors.New("malformed encrypted value")
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("decoding data key: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("decoding ciphertext: %w", err)
+	}
+	dek, err := kw.UnwrapKey(parts[0], wrapped)
+	if err != nil {
+		return "", fmt.Errorf("unwrapping data key: %w", err)
+	}
+	aead, err := newGCM(dek)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext and prepends the nonce.
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts the output of seal.
+func open(aead cipher.AEAD, b []byte) ([]byte, error) {
+	if len(b) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	plaintext, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,129 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envelope_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"karavi-authorization/internal/envelope"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncrypt(t *testing.T) {
+	key := newMasterKey(t, 1)
+
+	enc, err := envelope.Encrypt(key, "Password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("it marks the value as encrypted", func(t *testing.T) {
+		if !envelope.IsEncrypted(enc) {
+			t.Errorf("expected %q to be encrypted", enc)
+		}
+	})
+
+	t.Run("it uses a new data key for each value", func(t *testing.T) {
+		other, err := envelope.Encrypt(key, "Password123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other == enc {
+			t.Error("expected encryptions of the same value to differ")
+		}
+	})
+
+	t.Run("it decrypts the value", func(t *testing.T) {
+		got, err := envelope.Decrypt(key, enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "Password123" {
+			t.Errorf("got %q, want %q", got, "Password123")
+		}
+	})
+
+	t.Run("it fails with another key", func(t *testing.T) {
+		if _, err := envelope.Decrypt(newMasterKey(t, 2), enc); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("it fails without a key", func(t *testing.T) {
+		if _, err := envelope.Decrypt(nil, enc); !errors.Is(err, envelope.ErrNoKey) {
+			t.Errorf("got err %v, want %v", err, envelope.ErrNoKey)
+		}
+	})
+
+	t.Run("it fails on a tampered value", func(t *testing.T) {
+		tampered := enc[:len(enc)-4] + "AAA="
+		if _, err := envelope.Decrypt(key, tampered); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestDecrypt_Plain(t *testing.T) {
+	got, err := envelope.Decrypt(nil, "Password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Password123" {
+		t.Errorf("got %q, want %q", got, "Password123")
+	}
+}
+
+func TestLoadMasterKey(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("it loads a base64 key", func(t *testing.T) {
+		path := filepath.Join(dir, "master.key")
+		raw := bytes.Repeat([]byte{1}, envelope.MasterKeySize)
+		if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(raw)+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := envelope.LoadMasterKey(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := newMasterKey(t, 1); got.KeyID() != want.KeyID() {
+			t.Errorf("got key id %q, want %q", got.KeyID(), want.KeyID())
+		}
+	})
+
+	t.Run("it refuses a short key", func(t *testing.T) {
+		path := filepath.Join(dir, "short.key")
+		if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString([]byte("short"))), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := envelope.LoadMasterKey(path); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func newMasterKey(t *testing.T, b byte) *envelope.MasterKey {
+	t.Helper()
+	key, err := envelope.NewMasterKey(bytes.Repeat([]byte{b}, envelope.MasterKeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
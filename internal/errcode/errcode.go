@@ -0,0 +1,165 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package errcode attaches the stable codes of pb.ErrorCode to the errors
+// of the tenant, role and storage services, as a pb.ErrorDetail of their
+// gRPC status, so that clients check the code rather than match the
+// message.
+package errcode
+
+import (
+	"context"
+	"fmt"
+	"karavi-authorization/pb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error is an error of a service with its stable code. It is sent with the
+// gRPC status code Status and a pb.ErrorDetail of the code and params.
+type Error struct {
+	Status codes.Code
+	Code   pb.ErrorCode
+	// Params name what the error is about, e.g. the role.
+	Params map[string]string
+	msg    string
+}
+
+// New returns an Error with the message.
+func New(c codes.Code, code pb.ErrorCode, msg string) *Error {
+	return &Error{Status: c, Code: code, msg: msg}
+}
+
+// Newf returns an Error with the formatted message.
+func Newf(c codes.Code, code pb.ErrorCode, format string, a ...interface{}) *Error {
+	return New(c, code, fmt.Sprintf(format, a...))
+}
+
+// WithParam returns a copy of e with the param.
+func (e *Error) WithParam(key, value string) *Error {
+	ret := *e
+	ret.Params = make(map[string]string, len(e.Params)+1)
+	for k, v := range e.Params {
+		ret.Params[k] = v
+	}
+	ret.Params[key] = value
+	return &ret
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+// Is reports whether target is an Error with the same code, so that the
+// copies made by WithParam match the error they were made from.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// GRPCStatus returns the status the error is sent with.
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(e.Status, e.msg)
+	detailed, err := st.WithDetails(&pb.ErrorDetail{Code: e.Code, Params: e.Params})
+	if err != nil {
+		return st
+	}
+	return detailed
+}
+
+// Detail returns the pb.ErrorDetail of an error of a service. For errors
+// without one, e.g. of services that predate the codes, it has the general
+// code of the gRPC status code. It returns nil for a nil error.
+func Detail(err error) *pb.ErrorDetail {
+	if err == nil {
+		return nil
+	}
+	if detail := Attached(err); detail != nil {
+		return detail
+	}
+	return &pb.ErrorDetail{Code: generalCode(status.Code(err))}
+}
+
+// Attached returns the pb.ErrorDetail attached to the gRPC status of err,
+// or nil if err has none.
+func Attached(err error) *pb.ErrorDetail {
+	st, ok := status.FromError(err)
+	if !ok || st == nil {
+		return nil
+	}
+	for _, d := range st.Details() {
+		if detail, ok := d.(*pb.ErrorDetail); ok {
+			return detail
+		}
+	}
+	return nil
+}
+
+// Code returns the code of an error of a service, as Detail does.
+func Code(err error) pb.ErrorCode {
+	return Detail(err).GetCode()
+}
+
+// generalCode returns the general code of a gRPC status code.
+func generalCode(c codes.Code) pb.ErrorCode {
+	switch c {
+	case codes.OK:
+		return pb.ErrorCode_ERROR_CODE_UNSPECIFIED
+	case codes.InvalidArgument, codes.OutOfRange:
+		return pb.ErrorCode_INVALID_ARGUMENT
+	case codes.NotFound:
+		return pb.ErrorCode_NOT_FOUND
+	case codes.AlreadyExists:
+		return pb.ErrorCode_ALREADY_EXISTS
+	case codes.PermissionDenied:
+		return pb.ErrorCode_PERMISSION_DENIED
+	case codes.FailedPrecondition:
+		return pb.ErrorCode_FAILED_PRECONDITION
+	case codes.Aborted:
+		return pb.ErrorCode_CONCURRENT_UPDATE
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return pb.ErrorCode_UNAVAILABLE
+	case codes.Unauthenticated:
+		return pb.ErrorCode_UNAUTHENTICATED
+	case codes.Unimplemented:
+		return pb.ErrorCode_UNIMPLEMENTED
+	case codes.ResourceExhausted:
+		return pb.ErrorCode_QUOTA_EXCEEDED
+	default:
+		return pb.ErrorCode_INTERNAL
+	}
+}
+
+// UnaryServerInterceptor returns an interceptor that attaches the general
+// code of the gRPC status code to the errors that have no code, so that
+// every error of the service has one. Other details of the errors are kept.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if Attached(err) != nil {
+			return resp, err
+		}
+		st := status.Convert(err)
+		detailed, detailErr := st.WithDetails(&pb.ErrorDetail{Code: generalCode(st.Code())})
+		if detailErr != nil {
+			return resp, err
+		}
+		return resp, detailed.Err()
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errcode_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/errcode"
+	"karavi-authorization/pb"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestError(t *testing.T) {
+	errNotFound := errcode.New(codes.NotFound, pb.ErrorCode_ROLE_NOT_FOUND, "role not found")
+
+	t.Run("it is sent with its code and params", func(t *testing.T) {
+		err := errNotFound.WithParam("role", "bronze")
+
+		// The error as a client of the service sees it.
+		got := status.ErrorProto(err.GRPCStatus().Proto())
+
+		if status.Code(got) != codes.NotFound {
+			t.Errorf("got status code %v, want %v", status.Code(got), codes.NotFound)
+		}
+		if status.Convert(got).Message() != "role not found" {
+			t.Errorf("got message %q, want %q", status.Convert(got).Message(), "role not found")
+		}
+		detail := errcode.Detail(got)
+		if detail.Code != pb.ErrorCode_ROLE_NOT_FOUND || detail.Params["role"] != "bronze" {
+			t.Errorf("got detail %v, want %v with the role param", detail, pb.ErrorCode_ROLE_NOT_FOUND)
+		}
+	})
+	t.Run("it keeps its code when wrapped", func(t *testing.T) {
+		err := fmt.Errorf("deleting role: %w", errNotFound)
+
+		if got := errcode.Code(err); got != pb.ErrorCode_ROLE_NOT_FOUND {
+			t.Errorf("got %v, want %v", got, pb.ErrorCode_ROLE_NOT_FOUND)
+		}
+		if got := status.Code(err); got != codes.NotFound {
+			t.Errorf("got status code %v, want %v", got, codes.NotFound)
+		}
+	})
+	t.Run("it matches the error it was made from", func(t *testing.T) {
+		err := errNotFound.WithParam("role", "bronze")
+
+		if !errors.Is(err, errNotFound) {
+			t.Errorf("got %v, want it to match %v", err, errNotFound)
+		}
+		if errNotFound.Params != nil {
+			t.Errorf("got params %v, want the error unchanged", errNotFound.Params)
+		}
+	})
+}
+
+func TestDetail(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want pb.ErrorCode
+	}{
+		"nil":            {nil, pb.ErrorCode_ERROR_CODE_UNSPECIFIED},
+		"coded":          {errcode.New(codes.AlreadyExists, pb.ErrorCode_TENANT_ALREADY_EXISTS, "tenant already exists"), pb.ErrorCode_TENANT_ALREADY_EXISTS},
+		"status":         {status.Error(codes.NotFound, "volume not found"), pb.ErrorCode_NOT_FOUND},
+		"aborted status": {status.Error(codes.Aborted, "conflict"), pb.ErrorCode_CONCURRENT_UPDATE},
+		"not rpc":        {errors.New("test error"), pb.ErrorCode_INTERNAL},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := errcode.Code(tc.err); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+	t.Run("it is only attached to coded errors", func(t *testing.T) {
+		if got := errcode.Attached(status.Error(codes.NotFound, "volume not found")); got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+	})
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	sut := errcode.UnaryServerInterceptor()
+	call := func(err error) error {
+		_, got := sut(context.Background(), nil, &grpc.UnaryServerInfo{}, func(_ context.Context, _ interface{}) (interface{}, error) {
+			return nil, err
+		})
+		return got
+	}
+
+	t.Run("it attaches the general code", func(t *testing.T) {
+		err := call(status.Error(codes.NotFound, "volume not found"))
+
+		if got := errcode.Attached(err); got.GetCode() != pb.ErrorCode_NOT_FOUND {
+			t.Errorf("got %v, want %v", got, pb.ErrorCode_NOT_FOUND)
+		}
+		if got := status.Convert(err).Message(); got != "volume not found" {
+			t.Errorf("got message %q, want %q", got, "volume not found")
+		}
+	})
+	t.Run("it keeps the code of coded errors", func(t *testing.T) {
+		err := call(errcode.New(codes.NotFound, pb.ErrorCode_TENANT_NOT_FOUND, "tenant not found"))
+
+		if got := errcode.Attached(err); got.GetCode() != pb.ErrorCode_TENANT_NOT_FOUND {
+			t.Errorf("got %v, want %v", got, pb.ErrorCode_TENANT_NOT_FOUND)
+		}
+	})
+	t.Run("it passes through successes", func(t *testing.T) {
+		if err := call(nil); err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eventsvc keeps a stream of the changes to tenants, role bindings,
+// quotas and tokens, and serves it to the external systems that watch it.
+package eventsvc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/pb"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+// Types of the events.
+const (
+	TypeTenantCreated         = "tenant.created"
+	TypeTenantDeleted         = "tenant.deleted"
+	TypeTenantRestored        = "tenant.restored"
+	TypeRoleBound             = "role.bound"
+	TypeRoleUnbound           = "role.unbound"
+	TypeQuotaThresholdCrossed = "quota.threshold_crossed"
+	TypeTokenRevoked          = "token.revoked"
+)
+
+// KeyEvents is the stream of the events. Each entry holds the fields of an
+// event, with its details encoded as a JSON object.
+const KeyEvents = "events"
+
+// MaxEvents is about how many events are kept in the stream, for watchers
+// to resume from after they were disconnected.
+const MaxEvents = 10000
+
+// Fields of an entry of the event stream.
+const (
+	fieldType    = "type"
+	fieldTenant  = "tenant"
+	fieldRole    = "role"
+	fieldTime    = "time"
+	fieldDetails = "details"
+)
+
+// Publish adds the event to the stream, at the current time unless its time
+// is set.
+func Publish(rdb *redis.Client, e *pb.Event) error {
+	if rdb == nil {
+		return errors.New("no redis client")
+	}
+	if e.Time == 0 {
+		e.Time = time.Now().Unix()
+	}
+	values := map[string]interface{}{
+		fieldType:   e.Type,
+		fieldTenant: e.Tenant,
+		fieldRole:   e.Role,
+		fieldTime:   e.Time,
+	}
+	if len(e.Details) > 0 {
+		b, err := json.Marshal(e.Details)
+		if err != nil {
+			return err
+		}
+		values[fieldDetails] = string(b)
+	}
+
+	id, err := rdb.XAdd(&redis.XAddArgs{
+		Stream:       KeyEvents,
+		MaxLenApprox: MaxEvents,
+		Values:       values,
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("publishing %s event: %w", e.Type, err)
+	}
+	e.Id = id
+	return nil
+}
+
+// Publisher publishes events for a service that must not fail because of
+// them, e.g. after a tenant was created, and logs the events it could not
+// publish instead. A nil Publisher publishes nothing.
+type Publisher struct {
+	Redis func() *redis.Client
+	Log   *logrus.Entry
+}
+
+// Publish publishes an event of the type for the tenant and role, with the
+// details given as key and value pairs.
+func (p *Publisher) Publish(typ, tenant, role string, details ...string) {
+	if p == nil || p.Redis == nil {
+		return
+	}
+	e := &pb.Event{Type: typ, Tenant: tenant, Role: role}
+	for i := 0; i+1 < len(details); i += 2 {
+		if e.Details == nil {
+			e.Details = make(map[string]string)
+		}
+		e.Details[details[i]] = details[i+1]
+	}
+	if err := Publish(p.Redis(), e); err != nil {
+		p.Log.WithError(err).WithFields(logrus.Fields{
+			"type":   typ,
+			"tenant": tenant,
+		}).Warn("publishing event")
+	}
+}
+
+// parseEvent returns the event of an entry of the stream.
+func parseEvent(msg redis.XMessage) (*pb.Event, error) {
+	str := func(k string) string {
+		s, _ := msg.Values[k].(string)
+		return s
+	}
+	e := &pb.Event{
+		Id:     msg.ID,
+		Type:   str(fieldType),
+		Tenant: str(fieldTenant),
+		Role:   str(fieldRole),
+	}
+	if s := str(fieldTime); s != "" {
+		t, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing time of event %s: %w", msg.ID, err)
+		}
+		e.Time = t
+	}
+	if s := str(fieldDetails); s != "" {
+		if err := json.Unmarshal([]byte(s), &e.Details); err != nil {
+			return nil, fmt.Errorf("parsing details of event %s: %w", msg.ID, err)
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,141 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventsvc
+
+import (
+	"karavi-authorization/pb"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultBlock is how long a watch waits for new events before it checks
+// whether the watcher is still connected.
+const DefaultBlock = 5 * time.Second
+
+// EventService is the gRPC implementation of the EventServiceServer.
+type EventService struct {
+	pb.UnimplementedEventServiceServer
+
+	log   *logrus.Entry
+	rdb   *redis.Client
+	block time.Duration
+}
+
+// Option allows for functional option arguments on the EventService.
+type Option func(*EventService)
+
+// WithLogger provides a logger.
+func WithLogger(log *logrus.Entry) Option {
+	return func(s *EventService) {
+		s.log = log
+	}
+}
+
+// WithRedis provides a redis client.
+func WithRedis(rdb *redis.Client) Option {
+	return func(s *EventService) {
+		s.rdb = rdb
+	}
+}
+
+// WithBlock sets how long a watch waits for new events at a time.
+func WithBlock(d time.Duration) Option {
+	return func(s *EventService) {
+		s.block = d
+	}
+}
+
+// NewEventService allocates a new EventService.
+func NewEventService(opts ...Option) *EventService {
+	s := EventService{
+		log:   logrus.NewEntry(logrus.New()),
+		block: DefaultBlock,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return &s
+}
+
+// Watch sends the events, of the requested types and tenant if any, until
+// the watcher disconnects. The events after the since id of the request are
+// sent first, if they are still in the stream.
+func (s *EventService) Watch(req *pb.WatchEventsRequest, stream pb.EventService_WatchServer) error {
+	ctx := stream.Context()
+
+	last := req.Since
+	if last == "" {
+		var err error
+		last, err = s.lastID()
+		if err != nil {
+			return err
+		}
+	}
+
+	types := make(map[string]bool)
+	for _, t := range req.Types {
+		types[t] = true
+	}
+
+	for ctx.Err() == nil {
+		streams, err := s.rdb.XRead(&redis.XReadArgs{
+			Streams: []string{KeyEvents, last},
+			Count:   100,
+			Block:   s.block,
+		}).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, st := range streams {
+			for _, msg := range st.Messages {
+				last = msg.ID
+				e, err := parseEvent(msg)
+				if err != nil {
+					s.log.WithError(err).Warn("skipping event")
+					continue
+				}
+				if len(types) > 0 && !types[e.Type] {
+					continue
+				}
+				if req.Tenant != "" && e.Tenant != req.Tenant {
+					continue
+				}
+				if err := stream.Send(e); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// lastID returns the id of the last event in the stream, so that a watch
+// without a since id gets only the events after it.
+func (s *EventService) lastID() (string, error) {
+	msgs, err := s.rdb.XRevRangeN(KeyEvents, "+", "-", 1).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(msgs) == 0 {
+		return "0-0", nil
+	}
+	return msgs[0].ID, nil
+}
@@ -0,0 +1,127 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventsvc_test
+
+import (
+	"context"
+	"karavi-authorization/internal/eventsvc"
+	"karavi-authorization/pb"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newClient(t *testing.T) (pb.EventServiceClient, *redis.Client) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mr.Close)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	pb.RegisterEventServiceServer(gs, eventsvc.NewEventService(
+		eventsvc.WithRedis(rdb),
+		eventsvc.WithBlock(50*time.Millisecond)))
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewEventServiceClient(conn), rdb
+}
+
+func TestEventService_Watch(t *testing.T) {
+	t.Run("it sends the events after since", func(t *testing.T) {
+		client, rdb := newClient(t)
+		first := &pb.Event{Type: eventsvc.TypeTenantCreated, Tenant: "tenant-a"}
+		if err := eventsvc.Publish(rdb, first); err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range []*pb.Event{
+			{Type: eventsvc.TypeRoleBound, Tenant: "tenant-a", Role: "role-a"},
+			{Type: eventsvc.TypeRoleBound, Tenant: "tenant-b", Role: "role-a"},
+			{Type: eventsvc.TypeTokenRevoked, Tenant: "tenant-a", Details: map[string]string{"reason": "tenant revoked"}},
+		} {
+			if err := eventsvc.Publish(rdb, e); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stream, err := client.Watch(ctx, &pb.WatchEventsRequest{Since: first.Id, Tenant: "tenant-a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, want := range []string{eventsvc.TypeRoleBound, eventsvc.TypeTokenRevoked} {
+			got, err := stream.Recv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Type != want || got.Tenant != "tenant-a" || got.Time == 0 {
+				t.Errorf("got event %v, want a %s event of tenant-a", got, want)
+			}
+			if want == eventsvc.TypeTokenRevoked && got.Details["reason"] != "tenant revoked" {
+				t.Errorf("got details %v, want the reason", got.Details)
+			}
+		}
+	})
+	t.Run("it sends only new events of the types", func(t *testing.T) {
+		client, rdb := newClient(t)
+		if err := eventsvc.Publish(rdb, &pb.Event{Type: eventsvc.TypeTenantCreated, Tenant: "old"}); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stream, err := client.Watch(ctx, &pb.WatchEventsRequest{Types: []string{eventsvc.TypeTenantCreated}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Publish once the watch has started, as seen by the stream of the
+		// watch being read from.
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			p := eventsvc.Publisher{Redis: func() *redis.Client { return rdb }}
+			p.Publish(eventsvc.TypeTenantDeleted, "old", "")
+			p.Publish(eventsvc.TypeTenantCreated, "new", "")
+		}()
+
+		got, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Type != eventsvc.TypeTenantCreated || got.Tenant != "new" {
+			t.Errorf("got event %v, want the creation of tenant new", got)
+		}
+	})
+}
@@ -20,16 +20,18 @@ import (
 	"fmt"
 	"karavi-authorization/cmd/karavictl/cmd"
 	storage "karavi-authorization/cmd/karavictl/cmd"
+	"karavi-authorization/internal/envelope"
 	"karavi-authorization/internal/role-service/roles"
 	"strings"
 	"sync"
 
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientv1 "k8s.io/client-go/applyconfigurations/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"sigs.k8s.io/yaml"
+	"k8s.io/client-go/util/retry"
 )
 
 // API holds data used to access the K8S API
@@ -38,6 +40,9 @@ type API struct {
 	Lock      sync.Mutex
 	Namespace string
 	Log       *logrus.Entry
+	// Keys optionally encrypts the storage system passwords in the
+	// storage secret. Encrypted passwords cannot be read without it.
+	Keys envelope.KeyWrapper
 }
 
 const (
@@ -46,9 +51,10 @@ const (
 	// RolesConfigMapDataKey is the key value for the roles in the configMap
 	RolesConfigMapDataKey = "common.rego"
 
-	// StorageSecret is the secret containing configured storage
+	// StorageSecret is the secret that contained the configured storage
+	// before each system got a secret of its own; see MigrateStorage
 	StorageSecret = "karavi-storage-secret"
-	// StorageSecretDataKey is the key value for the storage in the secret
+	// StorageSecretDataKey is the key value for the storage in the secrets
 	StorageSecretDataKey = "storage-systems.yaml"
 	// StorageSecretDataStorageField is the top level field in the storage data itself
 	StorageSecretDataStorageField = "storage"
@@ -74,12 +80,12 @@ func (api *API) GetConfiguredRoles(ctx context.Context) (*roles.JSON, error) {
 	if err != nil {
 		return nil, err
 	}
+	return decodeRoles(common)
+}
 
+// decodeRoles decodes the roles of the roles configMap.
+func decodeRoles(common *corev1.ConfigMap) (*roles.JSON, error) {
 	rolesRego := common.Data[RolesConfigMapDataKey]
-	if err != nil {
-		return nil, err
-	}
-
 	rolesJSON := strings.Replace(rolesRego, "package karavi.common\ndefault roles = {}\nroles = ", "", 1)
 
 	var existing roles.JSON
@@ -129,7 +135,9 @@ func (api *API) UpdateRoles(ctx context.Context, roles *roles.JSON) error {
 	return nil
 }
 
-// GetConfiguredStorage returns the configured storage systems
+// GetConfiguredStorage returns the configured storage systems. Systems that
+// have not been migrated to their own secrets are read from the storage
+// secret.
 func (api *API) GetConfiguredStorage(ctx context.Context) (storage.Storage, error) {
 	api.Lock.Lock()
 	defer api.Lock.Unlock()
@@ -142,43 +150,25 @@ func (api *API) GetConfiguredStorage(ctx context.Context) (storage.Storage, erro
 
 	api.Log.WithFields(logrus.Fields{
 		"Secret":        StorageSecret,
-		"SecretDataKey": StorageSecretDataKey,
-	}).Debug("Getting secret containing configured storage systems")
+		"LabelSelector": StorageTypeLabel,
+	}).Debug("Getting secrets containing configured storage systems")
 
-	storageSecret, err := api.Client.CoreV1().Secrets(api.Namespace).Get(ctx, StorageSecret, meta.GetOptions{})
+	st, err := api.readStorage(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	var data []byte
-	if v, ok := storageSecret.Data[StorageSecretDataKey]; ok {
-		data = v
-	} else {
-		return nil, fmt.Errorf("%s data key not found in secret %s", StorageSecretDataKey, StorageSecret)
-	}
-
-	var storage map[string]storage.Storage
-	if err := yaml.Unmarshal(data, &storage); err != nil {
+	if err := st.stored.Decrypt(api.Keys); err != nil {
 		return nil, err
 	}
-
-	if v, ok := storage[StorageSecretDataStorageField]; ok {
-		return v, nil
-	}
-
-	return nil, fmt.Errorf("%s key not found in secret %s", StorageSecretDataKey, StorageSecret)
+	return st.stored, nil
 }
 
 func (api *API) getApplyConfig(roles *roles.JSON) (*clientv1.ConfigMapApplyConfiguration, error) {
-	data, err := json.MarshalIndent(&roles, "", "  ")
+	stdFormat, err := encodeRoles(roles)
 	if err != nil {
 		return nil, err
 	}
 
-	stdFormat := (`package karavi.common
-default roles = {}
-roles = ` + string(data))
-
 	config := clientv1.ConfigMap(RolesConfigMap, api.Namespace)
 	config.WithData(map[string]string{
 		RolesConfigMapDataKey: stdFormat,
@@ -187,6 +177,65 @@ roles = ` + string(data))
 	return config, nil
 }
 
+// encodeRoles encodes the roles as the rego module of the roles configMap.
+func encodeRoles(roles *roles.JSON) (string, error) {
+	data, err := json.MarshalIndent(&roles, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return `package karavi.common
+default roles = {}
+roles = ` + string(data), nil
+}
+
+// ModifyRoles applies fn to the configured roles and writes the result on
+// the condition that the roles configMap has not been written since it was
+// read. On a conflict, the roles are read again and fn is retried;
+// ErrConflict is returned if the conflicts persist.
+func (api *API) ModifyRoles(ctx context.Context, fn func(*roles.JSON) error) error {
+	api.Lock.Lock()
+	defer api.Lock.Unlock()
+	if api.Client == nil {
+		err := ConnectFn(api)
+		if err != nil {
+			return err
+		}
+	}
+
+	configMaps := api.Client.CoreV1().ConfigMaps(api.Namespace)
+	return onConflict(retry.RetryOnConflict(ConflictBackoff, func() error {
+		common, err := configMaps.Get(ctx, RolesConfigMap, meta.GetOptions{})
+		if err != nil {
+			return err
+		}
+		existing, err := decodeRoles(common)
+		if err != nil {
+			return err
+		}
+		if err := fn(existing); err != nil {
+			return err
+		}
+		data, err := encodeRoles(existing)
+		if err != nil {
+			return err
+		}
+
+		api.Log.WithFields(logrus.Fields{
+			"ConfigMap":       RolesConfigMap,
+			"ResourceVersion": common.ResourceVersion,
+		}).Debug("Updating roles in configMap containing configured roles")
+
+		common = common.DeepCopy()
+		if common.Data == nil {
+			common.Data = make(map[string]string)
+		}
+		common.Data[RolesConfigMapDataKey] = data
+		_, err = configMaps.Update(ctx, common, meta.UpdateOptions{})
+		return err
+	}))
+}
+
 // ConnectFn will connect the client to the k8s API
 var ConnectFn = func(api *API) error {
 	config, err := getConfig()
@@ -218,49 +267,18 @@ func getConfig() (*rest.Config, error) {
 	return config, nil
 }
 
-// UpdateStorages updates the storage secret with supplied collection of storages
+// UpdateStorages replaces the configured storage systems with the supplied
+// collection of storages. Only the secrets of the systems that changed are
+// written, and those of the systems that were removed are deleted, so that a
+// write cannot clobber the other systems.
 func (api *API) UpdateStorages(ctx context.Context, storages cmd.Storage) error {
-	api.Lock.Lock()
-	defer api.Lock.Unlock()
-	if api.Client == nil {
-		err := ConnectFn(api)
-		if err != nil {
-			return err
+	return api.ModifyStorages(ctx, func(s storage.Storage) error {
+		for systemType := range s {
+			delete(s, systemType)
 		}
-	}
-
-	secret, err := api.getStorageSecret(storages)
-	if err != nil {
-		return err
-	}
-
-	api.Log.WithFields(logrus.Fields{
-		"Secret":        StorageSecret,
-		"SecretDataKey": StorageSecretDataKey,
-	}).Debug("Applying new storage to a secret containing configured storages")
-
-	_, err = api.Client.CoreV1().Secrets(api.Namespace).Apply(ctx, secret, meta.ApplyOptions{FieldManager: "application/apply-patch", Force: true})
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (api *API) getStorageSecret(storages storage.Storage) (*clientv1.SecretApplyConfiguration, error) {
-	var data map[string]storage.Storage = make(map[string]storage.Storage)
-
-	data["storage"] = storages
-
-	b, err := yaml.Marshal(&data)
-	if err != nil {
-		return nil, err
-	}
-
-	secret := clientv1.Secret(StorageSecret, api.Namespace)
-	secret.WithData(map[string][]byte{
-		StorageSecretDataKey: b,
+		for systemType, systems := range storages {
+			s[systemType] = systems
+		}
+		return nil
 	})
-
-	return secret, nil
 }
@@ -19,8 +19,10 @@ import (
 	"context"
 	"errors"
 	storage "karavi-authorization/cmd/karavictl/cmd"
+	"karavi-authorization/internal/envelope"
 	"karavi-authorization/internal/role-service/roles"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/sirupsen/logrus"
@@ -471,8 +473,85 @@ func testGetStorageSecret(t *testing.T) {
 				Log: logrus.NewEntry(logrus.StandardLogger()),
 			}
 
-			secret, err := api.getStorageSecret(storage)
+			secret, err := api.getSystemSecret("powerflex", "542a2d5f5122210f", storage["powerflex"]["542a2d5f5122210f"])
+			if got := secret.Name; got != "csm-auth-storage-542a2d5f5122210f" {
+				t.Errorf("got secret %s, want %s", got, "csm-auth-storage-542a2d5f5122210f")
+			}
+			if got := secret.Labels[StorageTypeLabel]; got != "powerflex" {
+				t.Errorf("got label %q, want %q", got, "powerflex")
+			}
 			checkFn(t, secret.Data[StorageSecretDataKey], err)
 		})
 	}
 }
+
+func TestStorageEncryption(t *testing.T) {
+	key, err := envelope.NewMasterKey(bytes.Repeat([]byte{1}, envelope.MasterKeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := storage.Storage{
+		"powerflex": storage.SystemType{
+			"542a2d5f5122210f": storage.System{
+				User:     "admin",
+				Password: "Password123",
+				Endpoint: "https://10.0.0.1",
+			},
+		},
+	}
+
+	api := API{
+		Namespace: "test",
+		Log:       logrus.NewEntry(logrus.StandardLogger()),
+		Keys:      key,
+	}
+	apply, err := api.getSystemSecret("powerflex", "542a2d5f5122210f", want["powerflex"]["542a2d5f5122210f"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := apply.Data[StorageSecretDataKey]
+
+	t.Run("it encrypts the passwords in the secret", func(t *testing.T) {
+		if strings.Contains(string(data), "Password123") {
+			t.Errorf("expected no plain password in %s", data)
+		}
+		if got := want["powerflex"]["542a2d5f5122210f"].Password; got != "Password123" {
+			t.Errorf("expected the storage to keep the plain password, got %q", got)
+		}
+	})
+
+	secret := &v1.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      apply.Name,
+			Namespace: "test",
+			Labels:    apply.Labels,
+		},
+		Data: map[string][]byte{
+			StorageSecretDataKey: data,
+		},
+	}
+	api.Client = fake.NewSimpleClientset(secret)
+
+	t.Run("it decrypts the passwords of the secret", func(t *testing.T) {
+		got, err := api.GetConfiguredStorage(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("want %+v, got %+v", want, got)
+		}
+	})
+
+	t.Run("it fails without the key", func(t *testing.T) {
+		api := API{
+			Client:    fake.NewSimpleClientset(secret),
+			Namespace: "test",
+			Log:       logrus.NewEntry(logrus.StandardLogger()),
+		}
+
+		_, err := api.GetConfiguredStorage(context.Background())
+		if !errors.Is(err, envelope.ErrNoKey) {
+			t.Errorf("got err %v, want %v", err, envelope.ErrNoKey)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServiceScheme prefixes service addresses that are looked up through the
+// Kubernetes API rather than cluster DNS.
+const ServiceScheme = "k8s://"
+
+// ServiceAddr returns the cluster DNS address of a service in the namespace.
+func ServiceAddr(name, namespace string, port int) string {
+	return net.JoinHostPort(fmt.Sprintf("%s.%s.svc.cluster.local", name, namespace), strconv.Itoa(port))
+}
+
+// ResolveServiceAddr resolves an address of the form
+// k8s://[namespace/]name[:port] to the host and port of the Kubernetes
+// Service: the external name of an ExternalName service, or the cluster IP
+// of any other. The namespace defaults to that of the API, and the port to
+// the first port of the service. Other addresses are returned unchanged.
+func (api *API) ResolveServiceAddr(ctx context.Context, addr string) (string, error) {
+	if !strings.HasPrefix(addr, ServiceScheme) {
+		return addr, nil
+	}
+
+	api.Lock.Lock()
+	if api.Client == nil {
+		err := ConnectFn(api)
+		if err != nil {
+			api.Lock.Unlock()
+			return "", err
+		}
+	}
+	client := api.Client
+	api.Lock.Unlock()
+
+	ns, name := api.Namespace, strings.TrimPrefix(addr, ServiceScheme)
+	if i := strings.Index(name, "/"); i >= 0 {
+		ns, name = name[:i], name[i+1:]
+	}
+	var port string
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name, port = name[:i], name[i+1:]
+	}
+	if name == "" {
+		return "", fmt.Errorf("invalid service address %q", addr)
+	}
+
+	svc, err := client.CoreV1().Services(ns).Get(ctx, name, meta.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("getting service %s/%s: %w", ns, name, err)
+	}
+
+	if port == "" {
+		if len(svc.Spec.Ports) == 0 {
+			return "", fmt.Errorf("service %s/%s has no ports", ns, name)
+		}
+		port = strconv.Itoa(int(svc.Spec.Ports[0].Port))
+	}
+
+	switch {
+	case svc.Spec.Type == corev1.ServiceTypeExternalName:
+		return net.JoinHostPort(svc.Spec.ExternalName, port), nil
+	case svc.Spec.ClusterIP != "" && svc.Spec.ClusterIP != corev1.ClusterIPNone:
+		return net.JoinHostPort(svc.Spec.ClusterIP, port), nil
+	default:
+		return "", fmt.Errorf("service %s/%s has no cluster IP", ns, name)
+	}
+}
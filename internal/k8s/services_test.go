@@ -0,0 +1,82 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResolveServiceAddr(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&v1.Service{
+			ObjectMeta: meta.ObjectMeta{Name: "tenant-service", Namespace: "authz"},
+			Spec: v1.ServiceSpec{
+				Type:      v1.ServiceTypeClusterIP,
+				ClusterIP: "10.96.0.10",
+				Ports:     []v1.ServicePort{{Port: 50051}},
+			},
+		},
+		&v1.Service{
+			ObjectMeta: meta.ObjectMeta{Name: "role-service", Namespace: "other"},
+			Spec: v1.ServiceSpec{
+				Type:         v1.ServiceTypeExternalName,
+				ExternalName: "role.example.com",
+			},
+		},
+		&v1.Service{
+			ObjectMeta: meta.ObjectMeta{Name: "headless", Namespace: "authz"},
+			Spec: v1.ServiceSpec{
+				ClusterIP: v1.ClusterIPNone,
+				Ports:     []v1.ServicePort{{Port: 50051}},
+			},
+		},
+	)
+	api := &API{Client: client, Namespace: "authz"}
+
+	tests := []struct {
+		name, addr, want string
+		wantErr          bool
+	}{
+		{"it leaves a plain address", "tenant-service.authz.svc.cluster.local:50051", "tenant-service.authz.svc.cluster.local:50051", false},
+		{"it resolves the cluster IP", "k8s://tenant-service", "10.96.0.10:50051", false},
+		{"it uses the given port", "k8s://tenant-service:50052", "10.96.0.10:50052", false},
+		{"it resolves an external name", "k8s://other/role-service:50051", "role.example.com:50051", false},
+		{"it needs a port for a service without one", "k8s://other/role-service", "", true},
+		{"it rejects a headless service", "k8s://headless", "", true},
+		{"it rejects a missing service", "k8s://missing:50051", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := api.ResolveServiceAddr(context.Background(), tt.addr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("it returns the cluster DNS address", func(t *testing.T) {
+		if got := ServiceAddr("redis", "authz", 6379); got != "redis.authz.svc.cluster.local:6379" {
+			t.Errorf("got %q", got)
+		}
+	})
+}
@@ -0,0 +1,357 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	storage "karavi-authorization/cmd/karavictl/cmd"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// StorageSystemSecretPrefix prefixes the names of the secrets that each
+	// hold one storage system
+	StorageSystemSecretPrefix = "csm-auth-storage-"
+	// StorageTypeLabel labels the storage system secrets with the type of
+	// their system. The secrets are selected by the presence of the label.
+	StorageTypeLabel = "csm-authorization/storage-type"
+)
+
+var invalidSecretNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// StorageSystemSecretName returns the name of the secret of a storage system.
+// IDs that are not valid in a secret name are sanitized and suffixed with a
+// hash of the ID so that distinct IDs keep distinct secrets.
+func StorageSystemSecretName(systemID string) string {
+	name := strings.Trim(invalidSecretNameChars.ReplaceAllString(strings.ToLower(systemID), "-"), "-")
+	if name != systemID {
+		sum := sha256.Sum256([]byte(systemID))
+		name = strings.TrimPrefix(name+"-"+hex.EncodeToString(sum[:4]), "-")
+	}
+	return StorageSystemSecretPrefix + name
+}
+
+// storageState is the storage systems as read from the secrets, with the
+// secrets kept for their resource versions.
+type storageState struct {
+	// secrets are the storage system secrets by name.
+	secrets map[string]corev1.Secret
+	// legacy is the storage secret; nil if there is none.
+	legacy *corev1.Secret
+	// legacyStorage are the systems left in the storage secret.
+	legacyStorage storage.Storage
+	// stored are the storage systems, with their passwords as stored.
+	stored storage.Storage
+}
+
+// readStorage reads the storage system secrets and the storage secret.
+func (api *API) readStorage(ctx context.Context) (*storageState, error) {
+	list, err := api.Client.CoreV1().Secrets(api.Namespace).List(ctx, meta.ListOptions{LabelSelector: StorageTypeLabel})
+	if err != nil {
+		return nil, err
+	}
+	st := &storageState{secrets: make(map[string]corev1.Secret, len(list.Items))}
+	for _, secret := range list.Items {
+		st.secrets[secret.Name] = secret
+	}
+
+	legacy, err := api.Client.CoreV1().Secrets(api.Namespace).Get(ctx, StorageSecret, meta.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return nil, err
+	default:
+		data, ok := legacy.Data[StorageSecretDataKey]
+		if !ok {
+			return nil, fmt.Errorf("%s data key not found in secret %s", StorageSecretDataKey, StorageSecret)
+		}
+		if st.legacyStorage, err = decodeStorageData(data, StorageSecret); err != nil {
+			return nil, err
+		}
+		st.legacy = legacy
+	}
+
+	if st.stored, err = mergeStorage(st.legacyStorage, list.Items); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// decodeStorageData decodes the storage field of storage-systems data read
+// from the named secret.
+func decodeStorageData(data []byte, name string) (storage.Storage, error) {
+	var v map[string]storage.Storage
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("decoding secret %s: %w", name, err)
+	}
+	s, ok := v[StorageSecretDataStorageField]
+	if !ok {
+		return nil, fmt.Errorf("%s key not found in secret %s", StorageSecretDataStorageField, name)
+	}
+	return s, nil
+}
+
+// mergeStorage merges the storage systems of the storage system secrets
+// over those left in the legacy storage secret. The passwords are left as
+// they are stored.
+func mergeStorage(legacy storage.Storage, secrets []corev1.Secret) (storage.Storage, error) {
+	merged := make(storage.Storage)
+	add := func(s storage.Storage) {
+		for systemType, systems := range s {
+			if merged[systemType] == nil {
+				merged[systemType] = make(storage.SystemType)
+			}
+			for id, system := range systems {
+				merged[systemType][id] = system
+			}
+		}
+	}
+	add(legacy)
+	for _, secret := range secrets {
+		s, err := decodeStorageData(secret.Data[StorageSecretDataKey], secret.Name)
+		if err != nil {
+			return nil, err
+		}
+		add(s)
+	}
+	return merged, nil
+}
+
+// getSystemSecret returns the secret of a storage system, encrypting its
+// password if the API has keys.
+func (api *API) getSystemSecret(systemType, systemID string, system storage.System) (*corev1.Secret, error) {
+	s := storage.Storage{systemType: storage.SystemType{systemID: system}}
+	if api.Keys != nil {
+		if _, err := s.Encrypt(api.Keys); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := yaml.Marshal(map[string]storage.Storage{StorageSecretDataStorageField: s})
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      StorageSystemSecretName(systemID),
+			Namespace: api.Namespace,
+			Labels:    map[string]string{StorageTypeLabel: systemType},
+		},
+		Data: map[string][]byte{
+			StorageSecretDataKey: b,
+		},
+	}, nil
+}
+
+// writeSystemSecret creates the secret of a storage system, or updates it
+// if it was read at the given version. Either fails with a conflict if the
+// secret was written in the meantime.
+func (api *API) writeSystemSecret(ctx context.Context, old *corev1.Secret, systemType, systemID string, system storage.System) error {
+	secret, err := api.getSystemSecret(systemType, systemID, system)
+	if err != nil {
+		return err
+	}
+
+	api.Log.WithFields(logrus.Fields{
+		"Secret":     secret.Name,
+		"SystemType": systemType,
+		"SystemID":   systemID,
+	}).Debug("Writing storage system secret")
+
+	secrets := api.Client.CoreV1().Secrets(api.Namespace)
+	if old == nil {
+		_, err = secrets.Create(ctx, secret, meta.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return apierrors.NewConflict(corev1.Resource("secrets"), secret.Name, err)
+		}
+		return err
+	}
+	secret.ResourceVersion = old.ResourceVersion
+	_, err = secrets.Update(ctx, secret, meta.UpdateOptions{})
+	return err
+}
+
+// deleteSystemSecret deletes the secret of a storage system if it is still
+// at the version it was read at.
+func (api *API) deleteSystemSecret(ctx context.Context, old corev1.Secret) error {
+	api.Log.WithField("Secret", old.Name).Debug("Deleting storage system secret")
+	rv := old.ResourceVersion
+	err := api.Client.CoreV1().Secrets(api.Namespace).Delete(ctx, old.Name, meta.DeleteOptions{
+		Preconditions: &meta.Preconditions{ResourceVersion: &rv},
+	})
+	if apierrors.IsNotFound(err) {
+		return apierrors.NewConflict(corev1.Resource("secrets"), old.Name, err)
+	}
+	return err
+}
+
+// clearLegacyStorage empties the storage secret, if it was read at the given
+// version, once its systems are held by their own secrets.
+func (api *API) clearLegacyStorage(ctx context.Context, old *corev1.Secret) error {
+	b, err := yaml.Marshal(map[string]storage.Storage{StorageSecretDataStorageField: {}})
+	if err != nil {
+		return err
+	}
+	secret := old.DeepCopy()
+	secret.Data[StorageSecretDataKey] = b
+	_, err = api.Client.CoreV1().Secrets(api.Namespace).Update(ctx, secret, meta.UpdateOptions{})
+	return err
+}
+
+// writeStorage writes the storages over the state they were derived from.
+// Only the secrets of the systems that changed are written, and those of
+// the systems that were removed are deleted, each on the condition that it
+// has not been written since it was read.
+func (api *API) writeStorage(ctx context.Context, st *storageState, storages storage.Storage) error {
+	want := make(map[string]bool)
+	for systemType, systems := range storages {
+		for id, system := range systems {
+			name := StorageSystemSecretName(id)
+			want[name] = true
+			var old *corev1.Secret
+			if secret, ok := st.secrets[name]; ok {
+				if stored, ok := st.stored[systemType][id]; ok && api.systemsEqual(stored, system) {
+					continue
+				}
+				old = &secret
+			}
+			if err := api.writeSystemSecret(ctx, old, systemType, id, system); err != nil {
+				return err
+			}
+		}
+	}
+
+	for name, secret := range st.secrets {
+		if want[name] {
+			continue
+		}
+		if err := api.deleteSystemSecret(ctx, secret); err != nil {
+			return err
+		}
+	}
+
+	// The systems left in the storage secret now have their own secrets.
+	if len(st.legacyStorage) > 0 {
+		return api.clearLegacyStorage(ctx, st.legacy)
+	}
+	return nil
+}
+
+// ModifyStorages applies fn to the configured storage systems and writes the
+// result. If a secret is written by another update in the meantime, the
+// systems are read again and fn is retried; ErrConflict is returned if the
+// conflicts persist.
+func (api *API) ModifyStorages(ctx context.Context, fn func(storage.Storage) error) error {
+	api.Lock.Lock()
+	defer api.Lock.Unlock()
+	if api.Client == nil {
+		err := ConnectFn(api)
+		if err != nil {
+			return err
+		}
+	}
+
+	return onConflict(retry.RetryOnConflict(ConflictBackoff, func() error {
+		st, err := api.readStorage(ctx)
+		if err != nil {
+			return err
+		}
+		storages := make(storage.Storage, len(st.stored))
+		for systemType, systems := range st.stored {
+			storages[systemType] = make(storage.SystemType, len(systems))
+			for id, system := range systems {
+				storages[systemType][id] = system
+			}
+		}
+		if err := storages.Decrypt(api.Keys); err != nil {
+			return err
+		}
+		if err := fn(storages); err != nil {
+			return err
+		}
+		return api.writeStorage(ctx, st, storages)
+	}))
+}
+
+// MigrateStorage moves the storage systems of the storage secret to secrets
+// of their own and then empties the storage secret. It returns the number
+// of systems moved; systems that already have their own secret are not
+// overwritten.
+func (api *API) MigrateStorage(ctx context.Context) (int, error) {
+	api.Lock.Lock()
+	defer api.Lock.Unlock()
+	if api.Client == nil {
+		err := ConnectFn(api)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	err := retry.RetryOnConflict(ConflictBackoff, func() error {
+		n = 0
+		st, err := api.readStorage(ctx)
+		if err != nil || len(st.legacyStorage) == 0 {
+			return err
+		}
+
+		for systemType, systems := range st.legacyStorage {
+			for id, system := range systems {
+				if _, ok := st.secrets[StorageSystemSecretName(id)]; ok {
+					continue
+				}
+				if err := api.writeSystemSecret(ctx, nil, systemType, id, system); err != nil {
+					return fmt.Errorf("migrating storage system %s: %w", id, err)
+				}
+				n++
+			}
+		}
+
+		// Only empty the storage secret once every system has been moved so
+		// that a failed migration is retried from the start.
+		return api.clearLegacyStorage(ctx, st.legacy)
+	})
+	if err != nil {
+		return n, onConflict(err)
+	}
+
+	if n > 0 {
+		api.Log.WithField("Systems", n).Info("Migrated storage systems to their own secrets")
+	}
+	return n, nil
+}
+
+// systemsEqual reports whether the stored system is the same as the given
+// one once its password is decrypted.
+func (api *API) systemsEqual(stored, system storage.System) bool {
+	s := storage.Storage{"": {"": stored}}
+	if err := s.Decrypt(api.Keys); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(s[""][""], system)
+}
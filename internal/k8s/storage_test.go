@@ -0,0 +1,296 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	storage "karavi-authorization/cmd/karavictl/cmd"
+	"karavi-authorization/internal/role-service/roles"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestStorageSystemSecrets(t *testing.T) {
+	ctx := context.Background()
+	pf := storage.System{User: "admin", Password: "password", Endpoint: "https://10.0.0.1"}
+	pm := storage.System{User: "admin", Password: "password", Endpoint: "https://10.0.0.2"}
+
+	newAPI := func(t *testing.T, legacy string) (*API, *fake.Clientset) {
+		t.Helper()
+		client := fake.NewClientset(&v1.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      StorageSecret,
+				Namespace: "test",
+			},
+			Data: map[string][]byte{
+				StorageSecretDataKey: []byte(legacy),
+			},
+		})
+		return &API{
+			Client:    client,
+			Namespace: "test",
+			Log:       logrus.NewEntry(logrus.StandardLogger()),
+		}, client
+	}
+	secretNames := func(t *testing.T, api *API) []string {
+		t.Helper()
+		st, err := api.readStorage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for name := range st.secrets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	writes := func(client *fake.Clientset) []string {
+		var names []string
+		for _, a := range client.Actions() {
+			switch a := a.(type) {
+			case clienttesting.CreateAction:
+				names = append(names, a.GetVerb()+" "+a.GetObject().(*v1.Secret).Name)
+			case clienttesting.UpdateAction:
+				names = append(names, a.GetVerb()+" "+a.GetObject().(*v1.Secret).Name)
+			case clienttesting.DeleteAction:
+				names = append(names, a.GetVerb()+" "+a.GetName())
+			}
+		}
+		return names
+	}
+
+	t.Run("it names the secrets after the system ids", func(t *testing.T) {
+		if got, want := StorageSystemSecretName("542a2d5f5122210f"), "csm-auth-storage-542a2d5f5122210f"; got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+		upper, lower := StorageSystemSecretName("Cluster_1"), StorageSystemSecretName("cluster-1")
+		if upper == lower || !strings.HasPrefix(upper, "csm-auth-storage-cluster-1-") {
+			t.Errorf("got %s and %s, want distinct valid names", upper, lower)
+		}
+	})
+
+	t.Run("it writes only the systems that changed", func(t *testing.T) {
+		api, client := newAPI(t, "storage: {}\n")
+		if err := api.UpdateStorages(ctx, storage.Storage{"powerflex": {"pf1": pf}, "powermax": {"pm1": pm}}); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := secretNames(t, api), []string{"csm-auth-storage-pf1", "csm-auth-storage-pm1"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+
+		client.ClearActions()
+		changed := pm
+		changed.Endpoint = "https://10.0.0.3"
+		if err := api.UpdateStorages(ctx, storage.Storage{"powerflex": {"pf1": pf}, "powermax": {"pm1": changed}}); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := writes(client), []string{"update csm-auth-storage-pm1"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got writes %v, want %v", got, want)
+		}
+
+		got, err := api.GetConfiguredStorage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (storage.Storage{"powerflex": {"pf1": pf}, "powermax": {"pm1": changed}}); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("it deletes the secrets of removed systems", func(t *testing.T) {
+		api, client := newAPI(t, "storage: {}\n")
+		if err := api.UpdateStorages(ctx, storage.Storage{"powerflex": {"pf1": pf}, "powermax": {"pm1": pm}}); err != nil {
+			t.Fatal(err)
+		}
+
+		client.ClearActions()
+		if err := api.UpdateStorages(ctx, storage.Storage{"powermax": {"pm1": pm}}); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := writes(client), []string{"delete csm-auth-storage-pf1"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got writes %v, want %v", got, want)
+		}
+	})
+
+	legacy := "storage:\n  powerflex:\n    pf1:\n      endpoint: https://10.0.0.1\n      user: admin\n      password: password\n"
+
+	t.Run("it migrates the systems of the storage secret", func(t *testing.T) {
+		api, _ := newAPI(t, legacy)
+		n, err := api.MigrateStorage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("got %d systems migrated, want 1", n)
+		}
+		if got, want := secretNames(t, api), []string{"csm-auth-storage-pf1"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+		st, err := api.readStorage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(st.legacyStorage) != 0 {
+			t.Errorf("got %+v left in the storage secret, want none", st.legacyStorage)
+		}
+
+		got, err := api.GetConfiguredStorage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (storage.Storage{"powerflex": {"pf1": pf}}); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+
+		if n, err := api.MigrateStorage(ctx); err != nil || n != 0 {
+			t.Errorf("got %d, %v, want nothing left to migrate", n, err)
+		}
+	})
+
+	t.Run("it moves the systems of the storage secret on update", func(t *testing.T) {
+		api, _ := newAPI(t, legacy)
+		if err := api.UpdateStorages(ctx, storage.Storage{"powermax": {"pm1": pm}}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := api.GetConfiguredStorage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (storage.Storage{"powermax": {"pm1": pm}}); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("it retries an update that conflicts with another", func(t *testing.T) {
+		api, client := newAPI(t, "storage: {}\n")
+		conflicts := 1
+		client.PrependReactor("create", "secrets", func(clienttesting.Action) (bool, runtime.Object, error) {
+			if conflicts == 0 {
+				return false, nil, nil
+			}
+			conflicts--
+			return true, nil, apierrors.NewAlreadyExists(v1.Resource("secrets"), "csm-auth-storage-pf1")
+		})
+
+		var calls int
+		err := api.ModifyStorages(ctx, func(s storage.Storage) error {
+			calls++
+			s["powerflex"] = storage.SystemType{"pf1": pf}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if calls != 2 {
+			t.Errorf("got %d calls, want the update retried once", calls)
+		}
+	})
+
+	t.Run("it returns a conflict error when the conflicts persist", func(t *testing.T) {
+		api, client := newAPI(t, "storage: {}\n")
+		client.PrependReactor("create", "secrets", func(clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewAlreadyExists(v1.Resource("secrets"), "csm-auth-storage-pf1")
+		})
+
+		err := api.ModifyStorages(ctx, func(s storage.Storage) error {
+			s["powerflex"] = storage.SystemType{"pf1": pf}
+			return nil
+		})
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("got %v, want %v", err, ErrConflict)
+		}
+	})
+}
+
+func TestModifyRoles(t *testing.T) {
+	ctx := context.Background()
+	newAPI := func() (*API, *fake.Clientset) {
+		client := fake.NewClientset(&v1.ConfigMap{
+			ObjectMeta: meta.ObjectMeta{
+				Name:            RolesConfigMap,
+				Namespace:       "test",
+				ResourceVersion: "1",
+			},
+			Data: map[string]string{
+				RolesConfigMapDataKey: "package karavi.common\ndefault roles = {}\nroles = {}",
+			},
+		})
+		return &API{
+			Client:    client,
+			Namespace: "test",
+			Log:       logrus.NewEntry(logrus.StandardLogger()),
+		}, client
+	}
+	addRole := func(rs *roles.JSON) error {
+		return rs.Add(&roles.Instance{
+			Quota:   100,
+			RoleKey: roles.RoleKey{Name: "test", SystemType: "powerflex", SystemID: "542a2d5f5122210f", Pool: "bronze"},
+		})
+	}
+
+	t.Run("it updates the roles at the version they were read", func(t *testing.T) {
+		api, client := newAPI()
+		var gotVersion string
+		client.PrependReactor("update", "configmaps", func(a clienttesting.Action) (bool, runtime.Object, error) {
+			gotVersion = a.(clienttesting.UpdateAction).GetObject().(*v1.ConfigMap).ResourceVersion
+			return false, nil, nil
+		})
+
+		if err := api.ModifyRoles(ctx, addRole); err != nil {
+			t.Fatal(err)
+		}
+		if gotVersion != "1" {
+			t.Errorf("got resource version %q, want %q", gotVersion, "1")
+		}
+		got, err := api.GetConfiguredRoles(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Instances()) != 1 {
+			t.Errorf("got %d roles, want 1", len(got.Instances()))
+		}
+	})
+
+	t.Run("it retries on a conflict and then gives up", func(t *testing.T) {
+		api, client := newAPI()
+		var calls int
+		client.PrependReactor("update", "configmaps", func(clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewConflict(v1.Resource("configmaps"), RolesConfigMap, errors.New("modified"))
+		})
+
+		err := api.ModifyRoles(ctx, func(rs *roles.JSON) error {
+			calls++
+			return addRole(rs)
+		})
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("got %v, want %v", err, ErrConflict)
+		}
+		if calls != ConflictBackoff.Steps {
+			t.Errorf("got %d calls, want %d", calls, ConflictBackoff.Steps)
+		}
+	})
+}
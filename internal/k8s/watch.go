@@ -0,0 +1,248 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	storage "karavi-authorization/cmd/karavictl/cmd"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"sigs.k8s.io/yaml"
+)
+
+// StorageResyncPeriod is how often the storage secret informer re-delivers
+// the cached secret so that replicas which missed an event still converge.
+var StorageResyncPeriod = 5 * time.Minute
+
+// WatchStorage starts informers on the storage secrets and calls fn with the
+// raw storage-systems data, merged from the storage system secrets and the
+// systems left in the storage secret, whenever one of them is added, changed
+// or deleted. It blocks until ctx is cancelled.
+func (api *API) WatchStorage(ctx context.Context, fn func([]byte)) error {
+	api.Lock.Lock()
+	if api.Client == nil {
+		err := ConnectFn(api)
+		if err != nil {
+			api.Lock.Unlock()
+			return err
+		}
+	}
+	client := api.Client
+	api.Lock.Unlock()
+
+	legacyFactory := informers.NewSharedInformerFactoryWithOptions(client, StorageResyncPeriod,
+		informers.WithNamespace(api.Namespace),
+		informers.WithTweakListOptions(func(opts *meta.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", StorageSecret).String()
+		}))
+	legacyInformer := legacyFactory.Core().V1().Secrets().Informer()
+
+	systemsFactory := informers.NewSharedInformerFactoryWithOptions(client, StorageResyncPeriod,
+		informers.WithNamespace(api.Namespace),
+		informers.WithTweakListOptions(func(opts *meta.ListOptions) {
+			opts.LabelSelector = StorageTypeLabel
+		}))
+	systemsInformer := systemsFactory.Core().V1().Secrets().Informer()
+
+	var (
+		mu     sync.Mutex
+		synced bool
+	)
+	deliver := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if !synced {
+			return
+		}
+
+		var legacy storage.Storage
+		for _, obj := range legacyInformer.GetStore().List() {
+			secret, ok := obj.(*corev1.Secret)
+			if !ok || secret.Name != StorageSecret {
+				continue
+			}
+			data, ok := secret.Data[StorageSecretDataKey]
+			if !ok {
+				api.Log.WithFields(logrus.Fields{
+					"Secret":        StorageSecret,
+					"SecretDataKey": StorageSecretDataKey,
+				}).Warn("Storage secret is missing data key")
+				continue
+			}
+			s, err := decodeStorageData(data, StorageSecret)
+			if err != nil {
+				api.Log.WithError(err).Error("Decoding storage secret")
+				return
+			}
+			legacy = s
+		}
+
+		var secrets []corev1.Secret
+		for _, obj := range systemsInformer.GetStore().List() {
+			if secret, ok := obj.(*corev1.Secret); ok {
+				secrets = append(secrets, *secret)
+			}
+		}
+		// Keep the order stable so that unchanged systems give the same data.
+		sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+
+		merged, err := mergeStorage(legacy, secrets)
+		if err != nil {
+			api.Log.WithError(err).Error("Merging storage system secrets")
+			return
+		}
+		data, err := yaml.Marshal(map[string]storage.Storage{StorageSecretDataStorageField: merged})
+		if err != nil {
+			api.Log.WithError(err).Error("Encoding storage systems")
+			return
+		}
+		api.Log.WithField("Secrets", len(secrets)).Debug("Storage secrets changed")
+		fn(data)
+	}
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(_ interface{}) {
+			deliver()
+		},
+		UpdateFunc: func(_, _ interface{}) {
+			deliver()
+		},
+		DeleteFunc: func(_ interface{}) {
+			deliver()
+		},
+	}
+	for _, informer := range []cache.SharedIndexInformer{legacyInformer, systemsInformer} {
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			return fmt.Errorf("adding storage secret event handler: %w", err)
+		}
+	}
+
+	legacyFactory.Start(ctx.Done())
+	systemsFactory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), legacyInformer.HasSynced, systemsInformer.HasSynced) {
+		return fmt.Errorf("waiting for %s informer cache to sync", StorageSecret)
+	}
+	// Deliver the storage systems once both caches are complete rather than
+	// for each secret listed.
+	mu.Lock()
+	synced = true
+	mu.Unlock()
+	deliver()
+
+	<-ctx.Done()
+	legacyFactory.Shutdown()
+	systemsFactory.Shutdown()
+	return nil
+}
+
+// LeaderElectionConfig configures RunAsLeader.
+type LeaderElectionConfig struct {
+	// LeaseName is the name of the Lease object used as the lock.
+	LeaseName string
+	// Identity uniquely identifies this replica, typically the pod name.
+	Identity      string
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+// RunAsLeader blocks while participating in leader election and runs the given
+// jobs only while this replica holds the lease. The context passed to each job
+// is cancelled when leadership is lost. It returns when ctx is cancelled.
+func (api *API) RunAsLeader(ctx context.Context, cfg LeaderElectionConfig, jobs ...func(context.Context)) error {
+	api.Lock.Lock()
+	if api.Client == nil {
+		err := ConnectFn(api)
+		if err != nil {
+			api.Lock.Unlock()
+			return err
+		}
+	}
+	client := api.Client
+	api.Lock.Unlock()
+
+	if cfg.LeaseDuration == 0 {
+		cfg.LeaseDuration = 15 * time.Second
+	}
+	if cfg.RenewDeadline == 0 {
+		cfg.RenewDeadline = 10 * time.Second
+	}
+	if cfg.RetryPeriod == 0 {
+		cfg.RetryPeriod = 2 * time.Second
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: meta.ObjectMeta{
+			Name:      cfg.LeaseName,
+			Namespace: api.Namespace,
+		},
+		Client: client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: cfg.Identity,
+		},
+	}
+
+	log := api.Log.WithFields(logrus.Fields{
+		"Lease":    cfg.LeaseName,
+		"Identity": cfg.Identity,
+	})
+
+	le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   cfg.LeaseDuration,
+		RenewDeadline:   cfg.RenewDeadline,
+		RetryPeriod:     cfg.RetryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				log.Info("Acquired leadership, starting singleton jobs")
+				for _, job := range jobs {
+					go job(ctx)
+				}
+			},
+			OnStoppedLeading: func() {
+				log.Info("Lost leadership, singleton jobs stopped")
+			},
+			OnNewLeader: func(identity string) {
+				log.WithField("Leader", identity).Debug("Observed leader")
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("creating leader elector: %w", err)
+	}
+
+	// Run returns whenever leadership is lost, so keep campaigning until
+	// the caller cancels the context.
+	for {
+		le.Run(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+)
+
+func TestWatchStorage(t *testing.T) {
+	t.Run("it delivers the merged storage systems on add, update and delete", func(t *testing.T) {
+		legacy := &v1.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      StorageSecret,
+				Namespace: "test",
+			},
+			Data: map[string][]byte{
+				StorageSecretDataKey: []byte("storage:\n  powerflex:\n    pf1:\n      endpoint: https://10.0.0.1\n"),
+			},
+		}
+		system := &v1.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      StorageSystemSecretName("pm1"),
+				Namespace: "test",
+				Labels:    map[string]string{StorageTypeLabel: "powermax"},
+			},
+			Data: map[string][]byte{
+				StorageSecretDataKey: []byte("storage:\n  powermax:\n    pm1:\n      endpoint: https://10.0.0.2\n"),
+			},
+		}
+		client := fake.NewSimpleClientset(legacy, system)
+		api := API{
+			Client:    client,
+			Namespace: "test",
+			Log:       logrus.NewEntry(logrus.StandardLogger()),
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		got := make(chan string, 16)
+		go func() {
+			err := api.WatchStorage(ctx, func(b []byte) {
+				got <- string(b)
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+		// Events may be delivered more than once, so wait for the wanted
+		// systems rather than the next delivery.
+		expect := func(want map[string][]string) {
+			t.Helper()
+			var gotIDs map[string][]string
+			timeout := time.After(5 * time.Second)
+			for {
+				select {
+				case s := <-got:
+					var gotStorage map[string]map[string]map[string]interface{}
+					if err := yaml.Unmarshal([]byte(s), &gotStorage); err != nil {
+						t.Fatal(err)
+					}
+					gotIDs = make(map[string][]string)
+					for systemType, systems := range gotStorage[StorageSecretDataStorageField] {
+						for id := range systems {
+							gotIDs[systemType] = append(gotIDs[systemType], id)
+						}
+					}
+					if reflect.DeepEqual(gotIDs, want) {
+						return
+					}
+				case <-timeout:
+					t.Fatalf("got %v, want %v", gotIDs, want)
+				}
+			}
+		}
+
+		expect(map[string][]string{"powerflex": {"pf1"}, "powermax": {"pm1"}})
+
+		legacy.Data[StorageSecretDataKey] = []byte("storage: {}\n")
+		legacy.ResourceVersion = "2"
+		if _, err := client.CoreV1().Secrets("test").Update(ctx, legacy, meta.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		expect(map[string][]string{"powermax": {"pm1"}})
+
+		if err := client.CoreV1().Secrets("test").Delete(ctx, system.Name, meta.DeleteOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		expect(map[string][]string{})
+	})
+	t.Run("it returns an error when it cannot connect", func(t *testing.T) {
+		oldConnectFn := ConnectFn
+		defer func() { ConnectFn = oldConnectFn }()
+		ConnectFn = func(_ *API) error {
+			return errors.New("error")
+		}
+
+		api := API{
+			Namespace: "test",
+			Log:       logrus.NewEntry(logrus.StandardLogger()),
+		}
+		err := api.WatchStorage(context.Background(), func(_ []byte) {})
+		if err == nil {
+			t.Error("expected non-nil error")
+		}
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mounts checks that the files and directories a service needs are
+// mounted, so that it fails at startup with a clear message rather than on
+// first use, e.g. when it runs with a read-only root filesystem.
+package mounts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Mount is a file or directory that a service needs.
+type Mount struct {
+	// Name describes what is mounted, e.g. the ConfigMap.
+	Name string
+	Path string
+	// Writable mounts are directories that the service writes to. They
+	// are created if they do not exist.
+	Writable bool
+}
+
+// Check returns an error describing every mount that is missing or, if it
+// is to be writable, that cannot be written to by the user of the process.
+func Check(mounts ...Mount) error {
+	var errs []error
+	for _, m := range mounts {
+		if err := check(m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func check(m Mount) error {
+	if !m.Writable {
+		if _, err := os.Stat(m.Path); err != nil {
+			return fmt.Errorf("%s is not mounted at %s: %w", m.Name, m.Path, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Path, 0o750); err != nil {
+		return notWritable(m, err)
+	}
+	f, err := os.CreateTemp(m.Path, ".write-check-*")
+	if err != nil {
+		return notWritable(m, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+func notWritable(m Mount, err error) error {
+	return fmt.Errorf("%s at %s is not writable by uid %d; mount a writable volume, such as an emptyDir, there: %w", m.Name, m.Path, os.Getuid(), err)
+}
@@ -0,0 +1,86 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mounts_test
+
+import (
+	"karavi-authorization/internal/mounts"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("it accepts existing and writable mounts", func(t *testing.T) {
+		err := mounts.Check(
+			mounts.Mount{Name: "config", Path: dir},
+			mounts.Mount{Name: "data", Path: filepath.Join(dir, "data"), Writable: true},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, err := os.ReadDir(filepath.Join(dir, "data"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("got %d files left in the writable mount, want 0", len(entries))
+		}
+	})
+
+	t.Run("it reports every missing mount", func(t *testing.T) {
+		err := mounts.Check(
+			mounts.Mount{Name: "csm-config-params", Path: filepath.Join(dir, "missing")},
+			mounts.Mount{Name: "storage", Path: filepath.Join(dir, "storage.yaml")},
+		)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		for _, want := range []string{"csm-config-params is not mounted", "storage is not mounted"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("got %q, want it to contain %q", err, want)
+			}
+		}
+	})
+
+	t.Run("it reports a read-only mount", func(t *testing.T) {
+		if os.Getuid() == 0 {
+			t.Skip("root may write to read-only directories")
+		}
+		ro := filepath.Join(dir, "ro")
+		if err := os.Mkdir(ro, 0o500); err != nil {
+			t.Fatal(err)
+		}
+
+		err := mounts.Check(mounts.Mount{Name: "data", Path: ro, Writable: true})
+		if err == nil || !strings.Contains(err.Error(), "is not writable") {
+			t.Errorf("got %v, want a not writable error", err)
+		}
+	})
+
+	t.Run("it reports a writable mount under a file", func(t *testing.T) {
+		file := filepath.Join(dir, "file")
+		if err := os.WriteFile(file, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		err := mounts.Check(mounts.Mount{Name: "data", Path: filepath.Join(file, "data"), Writable: true})
+		if err == nil || !strings.Contains(err.Error(), "is not writable") {
+			t.Errorf("got %v, want a not writable error", err)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package powerflex
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dell/goscaleio"
+)
+
+// TokenAuthVersion is the first PowerFlex version whose REST gateway issues
+// JWTs from /rest/auth/login. API requests carry them as bearer tokens.
+const TokenAuthVersion = "4.0"
+
+var errTokenAuthUnsupported = errors.New("token authentication is not supported")
+
+// UsesTokenAuth reports whether a PowerFlex of the given API version expects
+// a bearer token rather than the legacy session token.
+func UsesTokenAuth(version string) bool {
+	v, err := strconv.ParseFloat(version, 64)
+	return err == nil && v >= 4.0
+}
+
+// SetAuthorization sets the authorization header of a request to the
+// PowerFlex for the token and API version.
+func SetAuthorization(r *http.Request, token, version string) {
+	if UsesTokenAuth(version) {
+		r.Header.Set("Authorization", "Bearer "+token)
+		return
+	}
+	r.SetBasicAuth("", token)
+}
+
+// Authenticate logs the client in to the PowerFlex. The legacy /api/login is
+// tried first unless the PowerFlex is already known to be 4.x; if it fails,
+// the 4.x login is tried. On success, the API version in cc tells the
+// caller which login was used.
+func Authenticate(ctx context.Context, client *goscaleio.Client, cc *goscaleio.ConfigConnect) error {
+	var legacyErr error
+	if !UsesTokenAuth(cc.Version) {
+		if _, legacyErr = client.Authenticate(cc); legacyErr == nil {
+			return nil
+		}
+	}
+
+	token, err := tokenLogin(ctx, cc)
+	switch {
+	case errors.Is(err, errTokenAuthUnsupported) && legacyErr != nil:
+		return legacyErr
+	case err != nil:
+		return err
+	}
+
+	client.SetToken(token)
+	client.GetConfigConnect().Version = TokenAuthVersion
+	version, err := client.GetVersion()
+	if err != nil {
+		return fmt.Errorf("getting powerflex version: %w", err)
+	}
+	if UsesTokenAuth(version) {
+		client.GetConfigConnect().Version = version
+	}
+	cc.Version = client.GetConfigConnect().Version
+	return nil
+}
+
+// tokenLogin gets an access token from the PowerFlex 4.x REST gateway.
+func tokenLogin(ctx context.Context, cc *goscaleio.ConfigConnect) (string, error) {
+	body, err := json.Marshal(struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{cc.Username, cc.Password})
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(cc.Endpoint, "/")+"/rest/auth/login", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	c := &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: cc.Insecure, // #nosec G402
+			},
+		},
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return "", fmt.Errorf("powerflex token login: %s", resp.Status)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return "", errTokenAuthUnsupported
+	}
+
+	var tokens struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil || tokens.AccessToken == "" {
+		return "", errTokenAuthUnsupported
+	}
+	return tokens.AccessToken, nil
+}
@@ -0,0 +1,157 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package powerflex_test
+
+import (
+	"context"
+	"encoding/json"
+	"karavi-authorization/internal/powerflex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dell/goscaleio"
+)
+
+func TestAuthenticate(t *testing.T) {
+	t.Run("it uses the legacy login of PowerFlex 3.x", func(t *testing.T) {
+		svr := newPowerFlexTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/login":
+				w.Write([]byte(`"legacy-token"`))
+			case "/api/version":
+				w.Write([]byte(`"3.6"`))
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+		})
+		defer svr.Close()
+		client := newPowerFlexClient(t, svr.URL)
+		cc := &goscaleio.ConfigConnect{Endpoint: svr.URL, Username: "admin", Password: "Password123"}
+
+		if err := powerflex.Authenticate(context.Background(), client, cc); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := client.GetToken(); got != "legacy-token" {
+			t.Errorf("token: got %q, want %q", got, "legacy-token")
+		}
+		if powerflex.UsesTokenAuth(cc.Version) {
+			t.Errorf("version: got %q, want a legacy version", cc.Version)
+		}
+	})
+
+	t.Run("it uses the token login of PowerFlex 4.x", func(t *testing.T) {
+		var gotAuthz string
+		svr := newPowerFlexTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/login":
+				w.WriteHeader(http.StatusUnauthorized)
+			case "/rest/auth/login":
+				var creds map[string]string
+				if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+					t.Error(err)
+				}
+				if creds["username"] != "admin" || creds["password"] != "Password123" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte(`{"access_token": "jwt", "refresh_token": "refresh"}`))
+			case "/api/version":
+				gotAuthz = r.Header.Get("Authorization")
+				w.Write([]byte(`"4.5"`))
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+		})
+		defer svr.Close()
+		client := newPowerFlexClient(t, svr.URL)
+		cc := &goscaleio.ConfigConnect{Endpoint: svr.URL, Username: "admin", Password: "Password123"}
+
+		if err := powerflex.Authenticate(context.Background(), client, cc); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := client.GetToken(); got != "jwt" {
+			t.Errorf("token: got %q, want %q", got, "jwt")
+		}
+		if gotAuthz != "Bearer jwt" {
+			t.Errorf("authorization: got %q, want %q", gotAuthz, "Bearer jwt")
+		}
+		if cc.Version != "4.5" {
+			t.Errorf("version: got %q, want %q", cc.Version, "4.5")
+		}
+	})
+
+	t.Run("it returns the token login error", func(t *testing.T) {
+		svr := newPowerFlexTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/login", "/rest/auth/login":
+				w.WriteHeader(http.StatusUnauthorized)
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+		})
+		defer svr.Close()
+		client := newPowerFlexClient(t, svr.URL)
+		cc := &goscaleio.ConfigConnect{Endpoint: svr.URL, Username: "admin", Password: "wrong"}
+
+		if err := powerflex.Authenticate(context.Background(), client, cc); err == nil {
+			t.Error("expected non-nil error")
+		}
+	})
+
+	t.Run("it returns the legacy login error from PowerFlex 3.x", func(t *testing.T) {
+		svr := newPowerFlexTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/login":
+				w.WriteHeader(http.StatusUnauthorized)
+			case "/rest/auth/login":
+				w.WriteHeader(http.StatusNotFound)
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+		})
+		defer svr.Close()
+		client := newPowerFlexClient(t, svr.URL)
+		cc := &goscaleio.ConfigConnect{Endpoint: svr.URL, Username: "admin", Password: "wrong"}
+
+		if err := powerflex.Authenticate(context.Background(), client, cc); err == nil {
+			t.Error("expected non-nil error")
+		}
+	})
+}
+
+func TestSetAuthorization(t *testing.T) {
+	tests := map[string]struct {
+		version string
+		want    string
+	}{
+		"unknown version": {"", "Basic OnRva2Vu"},
+		"PowerFlex 3.x":   {"3.6", "Basic OnRva2Vu"},
+		"PowerFlex 4.x":   {"4.5", "Bearer token"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/types/Volume/instances", nil)
+
+			powerflex.SetAuthorization(r, "token", tc.version)
+
+			if got := r.Header.Get("Authorization"); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
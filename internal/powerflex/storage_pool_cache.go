@@ -25,6 +25,7 @@ import (
 )
 
 // StoragePoolCache is a least recently used cache of PowerFlex storage pool names
+// and the IDs of their protection domains
 type StoragePoolCache struct {
 	client *goscaleio.Client
 	cache  *lru.Cache
@@ -56,6 +57,13 @@ func NewStoragePoolCache(client *goscaleio.Client, cacheSize int) (*StoragePoolC
 // LoginTokenGetter manages and retains a valid token for a PowerFlex
 type LoginTokenGetter interface {
 	GetToken(context.Context) (string, error)
+	GetVersion() string
+}
+
+// storagePool is the cached data of a storage pool
+type storagePool struct {
+	name               string
+	protectionDomainID string
 }
 
 // GetStoragePoolNameByID returns the storage pool's name from the cache via the storage pool's ID
@@ -63,30 +71,55 @@ func (c *StoragePoolCache) GetStoragePoolNameByID(ctx context.Context, tokenGett
 	ctx, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "GetStoragePoolNameByID")
 	defer span.End()
 
+	pool, err := c.getStoragePool(ctx, tokenGetter, id)
+	if err != nil {
+		return "", err
+	}
+	return pool.name, nil
+}
+
+// GetProtectionDomainIDByID returns the ID of the storage pool's protection domain from the cache via the storage pool's ID
+func (c *StoragePoolCache) GetProtectionDomainIDByID(ctx context.Context, tokenGetter LoginTokenGetter, id string) (string, error) {
+	ctx, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "GetProtectionDomainIDByID")
+	defer span.End()
+
+	pool, err := c.getStoragePool(ctx, tokenGetter, id)
+	if err != nil {
+		return "", err
+	}
+	return pool.protectionDomainID, nil
+}
+
+func (c *StoragePoolCache) getStoragePool(ctx context.Context, tokenGetter LoginTokenGetter, id string) (storagePool, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	if v, ok := c.cache.Get(id); ok {
-		name, ok := v.(string)
+		pool, ok := v.(storagePool)
 		if !ok {
-			return "", fmt.Errorf("cache value %T is not a string", v)
+			return storagePool{}, fmt.Errorf("cache value %T is not a storage pool", v)
 		}
-		return name, nil
+		return pool, nil
 	}
 
 	token, err := tokenGetter.GetToken(ctx)
 	if err != nil {
-		return "", err
+		return storagePool{}, err
 	}
 
 	c.client.SetToken(token)
+	c.client.GetConfigConnect().Version = tokenGetter.GetVersion()
 
-	pool, err := c.client.FindStoragePool(id, "", "", "")
+	sp, err := c.client.FindStoragePool(id, "", "", "")
 	if err != nil {
-		return "", err
+		return storagePool{}, err
 	}
 
-	c.cache.Add(id, pool.Name)
+	pool := storagePool{
+		name:               sp.Name,
+		protectionDomainID: sp.ProtectionDomainID,
+	}
+	c.cache.Add(id, pool)
 
-	return pool.Name, nil
+	return pool, nil
 }
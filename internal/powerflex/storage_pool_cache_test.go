@@ -355,6 +355,59 @@ func TestStoragePoolCache_GetStoragePoolNameByID(t *testing.T) {
 	})
 }
 
+func TestStoragePoolCache_GetProtectionDomainIDByID(t *testing.T) {
+	// Variable to keep track of the /api/types/StoragePool/instances calls initiated from the cache
+	powerFlexCallCount := 0
+
+	// Setup httptest server to represent a PowerFlex
+	powerFlexSvr := newPowerFlexTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.String() {
+		case "/api/version":
+			w.Write([]byte("3.5"))
+		case "/api/types/StoragePool/instances":
+			switch powerFlexCallCount {
+			case 0:
+				powerFlexCallCount++
+				data, err := os.ReadFile("testdata/storage_pool_instances.json")
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Write(data)
+			default:
+				t.Fatal("unexpected call to PowerFlex server")
+			}
+		default:
+			t.Fatalf("path %s not supported", r.URL.String())
+		}
+	})
+	defer powerFlexSvr.Close()
+
+	client := newPowerFlexClient(t, powerFlexSvr.URL)
+	tk := newTokenGetter(t, client, powerFlexSvr.URL)
+
+	cache, err := powerflex.NewStoragePoolCache(client, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The name and the protection domain ID are cached together
+	poolName, err := cache.GetStoragePoolNameByID(context.Background(), tk, "3df6b86600000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if poolName != "mypool" {
+		t.Errorf("expected pool name %s, got %s", "mypool", poolName)
+	}
+
+	pdID, err := cache.GetProtectionDomainIDByID(context.Background(), tk, "3df6b86600000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "75b661b400000000"; pdID != want {
+		t.Errorf("expected protection domain ID %s, got %s", want, pdID)
+	}
+}
+
 func newPowerFlexClient(t *testing.T, addr string) *goscaleio.Client {
 	client, err := goscaleio.NewClientWithArgs(addr, "", 0, false, false)
 	if err != nil {
@@ -28,8 +28,9 @@ import (
 type TokenGetter struct {
 	Config       Config
 	sem          chan struct{}
-	mu           sync.Mutex // protects currentToken
+	mu           sync.Mutex // protects currentToken and version
 	currentToken string
+	version      string
 }
 
 // Config is the configuration for building a PowerFlexTokenGetter
@@ -86,6 +87,14 @@ func (tg *TokenGetter) GetToken(ctx context.Context) (string, error) {
 	return tg.getToken(), nil
 }
 
+// GetVersion returns the API version of the PowerFlex, which determines how
+// the token must be sent. It is empty until the first login.
+func (tg *TokenGetter) GetVersion() string {
+	tg.mu.Lock()
+	defer tg.mu.Unlock()
+	return tg.version
+}
+
 func (tg *TokenGetter) getToken() string {
 	tg.mu.Lock()
 	defer tg.mu.Unlock()
@@ -98,10 +107,11 @@ func (tg *TokenGetter) updateTokenFromPowerFlex() {
 		<-tg.sem
 	}()
 
-	if _, err := tg.Config.PowerFlexClient.Authenticate(tg.Config.ConfigConnect); err != nil {
+	if err := Authenticate(context.Background(), tg.Config.PowerFlexClient, tg.Config.ConfigConnect); err != nil {
 		tg.Config.Logger.Errorf("PowerFlex Auth error: %+v", err)
 	}
 	tg.mu.Lock()
 	tg.currentToken = tg.Config.PowerFlexClient.GetToken()
+	tg.version = tg.Config.PowerFlexClient.GetConfigConnect().Version
 	tg.mu.Unlock()
 }
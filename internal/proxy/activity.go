@@ -0,0 +1,73 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"karavi-authorization/internal/tenantsvc"
+	"net/http"
+	"time"
+)
+
+// operationDenied labels the requests of a tenant that were denied.
+const operationDenied = "denied"
+
+// ActivityRecorder stores the activity of tenants where the tenant service
+// can report it, e.g. in Redis with tenantsvc.RecordActivity.
+type ActivityRecorder func(tenant, field string, at time.Time) error
+
+// WithActivityRecorder provides the store of the activity of tenants. The
+// activity is only exported as metrics without one.
+func WithActivityRecorder(rec ActivityRecorder) DispatchOption {
+	return func(h *DispatchHandler) {
+		h.recordActivity = rec
+	}
+}
+
+// recordTenantActivity counts the request of the tenant that was answered
+// with the status, if it created, deleted or mapped a volume or was denied.
+func (h *DispatchHandler) recordTenantActivity(tenant, op string, status int) {
+	var field string
+	switch {
+	case status == http.StatusForbidden || status == http.StatusInsufficientStorage:
+		op, field = operationDenied, tenantsvc.ActivityDenials
+	case status >= http.StatusBadRequest:
+		return
+	case op == OperationCreate:
+		field = tenantsvc.ActivityCreates
+	case op == OperationDelete:
+		field = tenantsvc.ActivityDeletes
+	case op == OperationMap:
+		field = tenantsvc.ActivityMaps
+	default:
+		return
+	}
+
+	now := time.Now()
+	tenantRequests.WithLabelValues(tenant, op).Inc()
+	tenantLastActivity.WithLabelValues(tenant).Set(float64(now.Unix()))
+
+	if h.recordActivity == nil {
+		return
+	}
+	if err := h.recordActivity(tenant, field, now); err != nil {
+		h.log.WithError(err).WithField("tenant", tenant).Warn("recording tenant activity")
+	}
+}
+
+// RecordTokenRefresh counts a token refresh of the tenant.
+func RecordTokenRefresh(tenant string) {
+	tenantTokenRefreshes.WithLabelValues(tenant).Inc()
+	tenantLastActivity.WithLabelValues(tenant).Set(float64(time.Now().Unix()))
+}
@@ -0,0 +1,130 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/token/session"
+	"karavi-authorization/internal/web"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SessionStore lists and revokes the sessions of admin tokens.
+type SessionStore interface {
+	List(organization string) ([]session.Session, error)
+	Revoke(id, organization string) error
+}
+
+// AdminSessionHandler is the proxy handler for karavictl admin sessions
+// requests.
+type AdminSessionHandler struct {
+	mux   *http.ServeMux
+	store SessionStore
+	log   *logrus.Entry
+}
+
+// AdminSessionsResponse is the response of listing the admin sessions.
+type AdminSessionsResponse struct {
+	Sessions []session.Session `json:"sessions"`
+}
+
+// NewAdminSessionHandler returns an AdminSessionHandler. Requests fail if
+// store is nil, i.e. admin sessions are not tracked.
+func NewAdminSessionHandler(log *logrus.Entry, store SessionStore) *AdminSessionHandler {
+	sh := &AdminSessionHandler{
+		store: store,
+		log:   log,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(web.ProxyAdminSessionsPath, web.Adapt(web.HandlerWithError(sh.sessionHandler), web.TelemetryMW("adminSessionHandler", log)))
+	sh.mux = mux
+
+	return sh
+}
+
+// ServeHTTP implements the http.Handler interface
+func (sh *AdminSessionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sh.mux.ServeHTTP(w, r)
+}
+
+func (sh *AdminSessionHandler) sessionHandler(w http.ResponseWriter, r *http.Request) error {
+	// Only admins may see the sessions of admins.
+	if admin, _ := r.Context().Value(web.JWTAdminName).(string); admin == "" {
+		err := errors.New("admin token required")
+		handleJSONErrorResponse(sh.log, w, http.StatusForbidden, err)
+		return err
+	}
+	if sh.store == nil {
+		err := errors.New("admin sessions are not tracked")
+		handleJSONErrorResponse(sh.log, w, http.StatusNotImplemented, err)
+		return err
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		return sh.listHandler(w, r)
+	case http.MethodDelete:
+		return sh.revokeHandler(w, r)
+	default:
+		return handleMethodNotAllowed(sh.log, w, r)
+	}
+}
+
+func (sh *AdminSessionHandler) listHandler(w http.ResponseWriter, r *http.Request) error {
+	sessions, err := sh.store.List(adminOrganization(r))
+	if err != nil {
+		err = fmt.Errorf("listing admin sessions: %w", err)
+		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+
+	err = json.NewEncoder(w).Encode(&AdminSessionsResponse{Sessions: sessions})
+	if err != nil {
+		err = fmt.Errorf("writing admin sessions response: %w", err)
+		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return nil
+}
+
+func (sh *AdminSessionHandler) revokeHandler(w http.ResponseWriter, r *http.Request) error {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		err := errors.New("session id not provided in query parameters")
+		handleJSONErrorResponse(sh.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	sh.log.WithField("session", id).Info("Revoking admin session")
+
+	err := sh.store.Revoke(id, adminOrganization(r))
+	switch {
+	case errors.Is(err, session.ErrNotFound):
+		handleJSONErrorResponse(sh.log, w, http.StatusNotFound, err)
+		return err
+	case err != nil:
+		err = fmt.Errorf("revoking admin session %s: %w", id, err)
+		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"karavi-authorization/internal/token/session"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+type fakeSessionStore struct {
+	sessions   []session.Session
+	gotOrg     string
+	revokedID  string
+	revokeErr  error
+	listCalled bool
+}
+
+func (f *fakeSessionStore) List(organization string) ([]session.Session, error) {
+	f.listCalled = true
+	f.gotOrg = organization
+	return f.sessions, nil
+}
+
+func (f *fakeSessionStore) Revoke(id, organization string) error {
+	f.revokedID = id
+	f.gotOrg = organization
+	return f.revokeErr
+}
+
+func TestAdminSessionHandler(t *testing.T) {
+	adminRequest := func(method, target, org string) *http.Request {
+		r := httptest.NewRequest(method, target, nil)
+		ctx := context.WithValue(r.Context(), web.JWTAdminName, "admin-1")
+		ctx = context.WithValue(ctx, web.JWTOrganization, org)
+		return r.WithContext(ctx)
+	}
+
+	t.Run("it lists the sessions of the organization of the admin", func(t *testing.T) {
+		store := &fakeSessionStore{sessions: []session.Session{{ID: "abc", Admin: "admin-1", Organization: "org-1"}}}
+		sut := NewAdminSessionHandler(logrus.NewEntry(logrus.New()), store)
+
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, adminRequest(http.MethodGet, "/proxy/admin/sessions/", "org-1"))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		if store.gotOrg != "org-1" {
+			t.Errorf("expected the sessions of org-1 to be listed, got %q", store.gotOrg)
+		}
+		var got AdminSessionsResponse
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Sessions) != 1 || got.Sessions[0].ID != "abc" {
+			t.Errorf("expected the session in the response, got %+v", got)
+		}
+	})
+	t.Run("it revokes a session", func(t *testing.T) {
+		store := &fakeSessionStore{}
+		sut := NewAdminSessionHandler(logrus.NewEntry(logrus.New()), store)
+
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, adminRequest(http.MethodDelete, "/proxy/admin/sessions/?id=abc", ""))
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("expected status code %d, got %d", http.StatusNoContent, w.Code)
+		}
+		if store.revokedID != "abc" {
+			t.Errorf("expected session abc to be revoked, got %q", store.revokedID)
+		}
+	})
+	t.Run("it handles an unknown session", func(t *testing.T) {
+		store := &fakeSessionStore{revokeErr: session.ErrNotFound}
+		sut := NewAdminSessionHandler(logrus.NewEntry(logrus.New()), store)
+
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, adminRequest(http.MethodDelete, "/proxy/admin/sessions/?id=abc", ""))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+	t.Run("it refuses tenant tokens", func(t *testing.T) {
+		store := &fakeSessionStore{}
+		sut := NewAdminSessionHandler(logrus.NewEntry(logrus.New()), store)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/proxy/admin/sessions/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), web.JWTTenantName, "tenant-1"))
+		sut.ServeHTTP(w, r)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected status code %d, got %d", http.StatusForbidden, w.Code)
+		}
+		if store.listCalled {
+			t.Error("expected the sessions not to be listed")
+		}
+	})
+	t.Run("it handles untracked sessions", func(t *testing.T) {
+		sut := NewAdminSessionHandler(logrus.NewEntry(logrus.New()), nil)
+
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, adminRequest(http.MethodGet, "/proxy/admin/sessions/", ""))
+
+		if w.Code != http.StatusNotImplemented {
+			t.Errorf("expected status code %d, got %d", http.StatusNotImplemented, w.Code)
+		}
+	})
+}
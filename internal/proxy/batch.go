@@ -0,0 +1,341 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"karavi-authorization/internal/decision"
+	"karavi-authorization/internal/quota"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CSI drivers may delete dozens of volumes at once. Deletes that arrive
+// within the batch window are written to Redis together, and the OPA
+// decision for a tenant and storage pool is reused for a short time.
+const (
+	deleteBatchWindow = 10 * time.Millisecond
+	deleteBatchMax    = 100
+	deleteDecisionTTL = 5 * time.Second
+)
+
+// volumeDeletes holds the state shared by all PowerFlex volume delete
+// requests.
+type volumeDeletes struct {
+	enf       *quota.RedisEnforcement
+	requests  *requestBatcher
+	published *requestBatcher
+	decisions *decisionCache
+}
+
+func newVolumeDeletes(enf *quota.RedisEnforcement) *volumeDeletes {
+	return &volumeDeletes{
+		enf:       enf,
+		requests:  newRequestBatcher(deleteBatchWindow, deleteBatchMax, enf.DeleteRequests),
+		published: newRequestBatcher(deleteBatchWindow, deleteBatchMax, enf.PublishDeletedBatch),
+		decisions: newDecisionCache(deleteDecisionTTL),
+	}
+}
+
+// requestBatcher groups quota requests made at about the same time so that
+// they are sent to Redis in a single round trip.
+type requestBatcher struct {
+	window time.Duration
+	max    int
+	flush  func(context.Context, []quota.Request) ([]bool, error)
+
+	mu      sync.Mutex // guards pending
+	pending []*batchedRequest
+}
+
+type batchedRequest struct {
+	ctx  context.Context
+	r    quota.Request
+	done chan batchResult
+}
+
+type batchResult struct {
+	ok  bool
+	err error
+}
+
+func newRequestBatcher(window time.Duration, maxSize int, flush func(context.Context, []quota.Request) ([]bool, error)) *requestBatcher {
+	return &requestBatcher{
+		window: window,
+		max:    maxSize,
+		flush:  flush,
+	}
+}
+
+// Do adds the request to the current batch and returns its result once the
+// batch has been flushed.
+func (b *requestBatcher) Do(ctx context.Context, r quota.Request) (bool, error) {
+	req := &batchedRequest{ctx: ctx, r: r, done: make(chan batchResult, 1)}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, req)
+	switch {
+	case len(b.pending) >= b.max:
+		batch := b.pending
+		b.pending = nil
+		b.mu.Unlock()
+		go b.run(batch)
+	case len(b.pending) == 1:
+		b.mu.Unlock()
+		time.AfterFunc(b.window, b.flushPending)
+	default:
+		b.mu.Unlock()
+	}
+
+	select {
+	case res := <-req.done:
+		return res.ok, res.err
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+func (b *requestBatcher) flushPending() {
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	if len(batch) > 0 {
+		b.run(batch)
+	}
+}
+
+func (b *requestBatcher) run(batch []*batchedRequest) {
+	// The batch must not fail because the first of its requests was
+	// cancelled, but its trace is kept.
+	ctx := context.WithoutCancel(batch[0].ctx)
+
+	rs := make([]quota.Request, len(batch))
+	for i, req := range batch {
+		rs[i] = req.r
+	}
+
+	results, err := b.flush(ctx, rs)
+	if err == nil && len(results) != len(batch) {
+		err = fmt.Errorf("got %d results for %d requests", len(results), len(batch))
+	}
+	for i, req := range batch {
+		if err != nil {
+			req.done <- batchResult{err: err}
+			continue
+		}
+		req.done <- batchResult{ok: results[i]}
+	}
+}
+
+// decisionCache remembers OPA decisions for a short time. Concurrent
+// requests for a decision that is not cached only ask OPA once.
+type decisionCache struct {
+	ttl time.Duration
+	sf  singleflight.Group
+
+	mu      sync.Mutex // guards entries
+	entries map[string]cachedDecision
+}
+
+type cachedDecision struct {
+	ans     []byte
+	expires time.Time
+}
+
+func newDecisionCache(ttl time.Duration) *decisionCache {
+	return &decisionCache{
+		ttl:     ttl,
+		entries: make(map[string]cachedDecision),
+	}
+}
+
+// Get returns the cached decision for key, or calls ask and caches its
+// answer.
+func (c *decisionCache) Get(key string, ask func() ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	d, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(d.expires) {
+		return d.ans, nil
+	}
+
+	v, err, _ := c.sf.Do(key, func() (interface{}, error) {
+		ans, err := ask()
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		c.mu.Lock()
+		for k, d := range c.entries {
+			if now.After(d.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.entries[key] = cachedDecision{ans: ans, expires: now.Add(c.ttl)}
+		c.mu.Unlock()
+		return ans, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
+
+// When a node reboots, the CSI driver maps and unmaps hundreds of volumes at
+// once. OPA queries of a policy that arrive within the batch window are sent
+// together to its batch entrypoint, in policies/volumes_batch.rego.
+const (
+	decisionBatchWindow = 5 * time.Millisecond
+	decisionBatchMax    = 100
+)
+
+// decisionBatcher groups OPA queries of a policy made at about the same time
+// into a single query of the batch entrypoint of the policy. If OPA does not
+// have the batch entrypoint, the queries are sent one at a time.
+type decisionBatcher struct {
+	window      time.Duration
+	max         int
+	batchPolicy string
+	ask         func(context.Context, func() decision.Query) ([]byte, error)
+
+	mu      sync.Mutex                    // guards pending
+	pending map[string][]*batchedDecision // by OPA host
+}
+
+type batchedDecision struct {
+	ctx  context.Context
+	q    decision.Query
+	done chan decisionResult
+}
+
+type decisionResult struct {
+	ans []byte
+	err error
+}
+
+func newDecisionBatcher(window time.Duration, maxSize int, batchPolicy string) *decisionBatcher {
+	return &decisionBatcher{
+		window:      window,
+		max:         maxSize,
+		batchPolicy: batchPolicy,
+		ask:         decision.CanWithContext,
+		pending:     make(map[string][]*batchedDecision),
+	}
+}
+
+// Do adds the query to the current batch and returns the answer of OPA to
+// the query alone, once the batch has been sent.
+func (b *decisionBatcher) Do(ctx context.Context, q decision.Query) ([]byte, error) {
+	req := &batchedDecision{ctx: ctx, q: q, done: make(chan decisionResult, 1)}
+
+	b.mu.Lock()
+	b.pending[q.Host] = append(b.pending[q.Host], req)
+	switch n := len(b.pending[q.Host]); {
+	case n >= b.max:
+		batch := b.pending[q.Host]
+		delete(b.pending, q.Host)
+		b.mu.Unlock()
+		go b.run(batch)
+	case n == 1:
+		b.mu.Unlock()
+		time.AfterFunc(b.window, func() { b.flushPending(q.Host) })
+	default:
+		b.mu.Unlock()
+	}
+
+	select {
+	case res := <-req.done:
+		return res.ans, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *decisionBatcher) flushPending(host string) {
+	b.mu.Lock()
+	batch := b.pending[host]
+	delete(b.pending, host)
+	b.mu.Unlock()
+
+	if len(batch) > 0 {
+		b.run(batch)
+	}
+}
+
+func (b *decisionBatcher) run(batch []*batchedDecision) {
+	if len(batch) == 1 {
+		b.runEach(batch)
+		return
+	}
+
+	// The batch must not fail because the first of its queries was
+	// cancelled, but its trace is kept.
+	ctx := context.WithoutCancel(batch[0].ctx)
+
+	inputs := make([]map[string]interface{}, len(batch))
+	for i, req := range batch {
+		inputs[i] = req.q.Input
+	}
+	ans, err := b.ask(ctx, func() decision.Query {
+		return decision.Query{
+			Host:   batch[0].q.Host,
+			Policy: b.batchPolicy,
+			Input:  map[string]interface{}{"queries": inputs},
+		}
+	})
+	if err != nil {
+		for _, req := range batch {
+			req.done <- decisionResult{err: err}
+		}
+		return
+	}
+
+	var resp struct {
+		Result *[]json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(ans, &resp); err != nil || resp.Result == nil || len(*resp.Result) != len(batch) {
+		// OPA does not have the batch entrypoint.
+		b.runEach(batch)
+		return
+	}
+
+	for i, req := range batch {
+		// Each answer has the form of the answer to a single query.
+		single := make([]byte, 0, len((*resp.Result)[i])+len(`{"result":}`))
+		single = append(single, `{"result":`...)
+		single = append(single, (*resp.Result)[i]...)
+		single = append(single, '}')
+		req.done <- decisionResult{ans: single}
+	}
+}
+
+// runEach sends each query of the batch on its own.
+func (b *decisionBatcher) runEach(batch []*batchedDecision) {
+	var wg sync.WaitGroup
+	for _, req := range batch {
+		wg.Add(1)
+		go func(req *batchedDecision) {
+			defer wg.Done()
+			ans, err := b.ask(req.ctx, func() decision.Query { return req.q })
+			req.done <- decisionResult{ans: ans, err: err}
+		}(req)
+	}
+	wg.Wait()
+}
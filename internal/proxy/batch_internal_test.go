@@ -0,0 +1,279 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/decision"
+	"karavi-authorization/internal/quota"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequestBatcher(t *testing.T) {
+	t.Run("it flushes concurrent requests together", func(t *testing.T) {
+		var flushes int32
+		b := newRequestBatcher(50*time.Millisecond, 100, func(_ context.Context, rs []quota.Request) ([]bool, error) {
+			atomic.AddInt32(&flushes, 1)
+			results := make([]bool, len(rs))
+			for i, r := range rs {
+				results[i] = r.VolumeName != "k8s-unknown"
+			}
+			return results, nil
+		})
+
+		names := []string{"k8s-0", "k8s-1", "k8s-unknown", "k8s-3"}
+		got := make([]bool, len(names))
+		var wg sync.WaitGroup
+		for i, name := range names {
+			wg.Add(1)
+			go func(i int, name string) {
+				defer wg.Done()
+				ok, err := b.Do(context.Background(), quota.Request{VolumeName: name})
+				if err != nil {
+					t.Error(err)
+				}
+				got[i] = ok
+			}(i, name)
+		}
+		wg.Wait()
+
+		if n := atomic.LoadInt32(&flushes); n != 1 {
+			t.Errorf("got %d flushes, want 1", n)
+		}
+		for i, name := range names {
+			if want := name != "k8s-unknown"; got[i] != want {
+				t.Errorf("%s: got %v, want %v", name, got[i], want)
+			}
+		}
+	})
+
+	t.Run("it flushes a full batch without waiting", func(t *testing.T) {
+		b := newRequestBatcher(time.Hour, 1, func(_ context.Context, rs []quota.Request) ([]bool, error) {
+			return make([]bool, len(rs)), nil
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if _, err := b.Do(ctx, quota.Request{}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("it returns the flush error to every request", func(t *testing.T) {
+		wantErr := errors.New("test error")
+		b := newRequestBatcher(time.Millisecond, 2, func(_ context.Context, _ []quota.Request) ([]bool, error) {
+			return nil, wantErr
+		})
+
+		errs := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func(i int) {
+				_, err := b.Do(context.Background(), quota.Request{VolumeName: fmt.Sprintf("k8s-%d", i)})
+				errs <- err
+			}(i)
+		}
+		for i := 0; i < 2; i++ {
+			if err := <-errs; !errors.Is(err, wantErr) {
+				t.Errorf("got err %v, want %v", err, wantErr)
+			}
+		}
+	})
+
+	t.Run("it rejects a short result", func(t *testing.T) {
+		b := newRequestBatcher(time.Millisecond, 100, func(_ context.Context, _ []quota.Request) ([]bool, error) {
+			return nil, nil
+		})
+
+		if _, err := b.Do(context.Background(), quota.Request{}); err == nil {
+			t.Error("expected non-nil error")
+		}
+	})
+}
+
+func TestDecisionCache(t *testing.T) {
+	t.Run("it reuses a decision", func(t *testing.T) {
+		c := newDecisionCache(time.Minute)
+		var asked int
+		ask := func() ([]byte, error) {
+			asked++
+			return []byte(`{"result": {"allow": true}}`), nil
+		}
+
+		for i := 0; i < 3; i++ {
+			if _, err := c.Get("tenant|role|system|pool", ask); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if asked != 1 {
+			t.Errorf("asked OPA %d times, want 1", asked)
+		}
+	})
+
+	t.Run("it asks again once the decision expires", func(t *testing.T) {
+		c := newDecisionCache(0)
+		var asked int
+		ask := func() ([]byte, error) {
+			asked++
+			return []byte(`{}`), nil
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err := c.Get("key", ask); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if asked != 2 {
+			t.Errorf("asked OPA %d times, want 2", asked)
+		}
+	})
+
+	t.Run("it does not cache errors", func(t *testing.T) {
+		c := newDecisionCache(time.Minute)
+		if _, err := c.Get("key", func() ([]byte, error) { return nil, errors.New("test error") }); err == nil {
+			t.Fatal("expected non-nil error")
+		}
+
+		got, err := c.Get("key", func() ([]byte, error) { return []byte("ok"), nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "ok" {
+			t.Errorf("got %q, want %q", got, "ok")
+		}
+	})
+}
+
+func TestDecisionBatcher(t *testing.T) {
+	// askDecisions runs n queries of the batcher concurrently and returns
+	// the answers by index.
+	askDecisions := func(t *testing.T, b *decisionBatcher, n int) []string {
+		t.Helper()
+		got := make([]string, n)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				ans, err := b.Do(context.Background(), decision.Query{
+					Host:   "opa",
+					Policy: "/karavi/volumes/map",
+					Input:  map[string]interface{}{"i": i},
+				})
+				if err != nil {
+					t.Error(err)
+				}
+				got[i] = string(ans)
+			}(i)
+		}
+		wg.Wait()
+		return got
+	}
+
+	t.Run("it sends concurrent queries to the batch entrypoint", func(t *testing.T) {
+		b := newDecisionBatcher(50*time.Millisecond, 100, "/karavi/volumes/batch/map")
+		var asked int32
+		b.ask = func(_ context.Context, fn func() decision.Query) ([]byte, error) {
+			atomic.AddInt32(&asked, 1)
+			q := fn()
+			if q.Policy != "/karavi/volumes/batch/map" {
+				return nil, fmt.Errorf("unexpected policy %q", q.Policy)
+			}
+			qs := q.Input["queries"].([]map[string]interface{})
+			results := make([]map[string]interface{}, len(qs))
+			for i, in := range qs {
+				results[i] = map[string]interface{}{"i": in["i"]}
+			}
+			return json.Marshal(map[string]interface{}{"result": results})
+		}
+
+		got := askDecisions(t, b, 4)
+
+		if n := atomic.LoadInt32(&asked); n != 1 {
+			t.Errorf("asked OPA %d times, want 1", n)
+		}
+		for i, ans := range got {
+			if want := fmt.Sprintf(`{"result":{"i":%d}}`, i); ans != want {
+				t.Errorf("%d: got %s, want %s", i, ans, want)
+			}
+		}
+	})
+
+	t.Run("it sends a query alone to its own policy", func(t *testing.T) {
+		b := newDecisionBatcher(time.Millisecond, 100, "/karavi/volumes/batch/map")
+		var gotPolicy string
+		b.ask = func(_ context.Context, fn func() decision.Query) ([]byte, error) {
+			gotPolicy = fn().Policy
+			return []byte(`{"result":{}}`), nil
+		}
+
+		askDecisions(t, b, 1)
+
+		if gotPolicy != "/karavi/volumes/map" {
+			t.Errorf("got policy %q, want %q", gotPolicy, "/karavi/volumes/map")
+		}
+	})
+
+	t.Run("it sends each query when there is no batch entrypoint", func(t *testing.T) {
+		b := newDecisionBatcher(50*time.Millisecond, 100, "/karavi/volumes/batch/map")
+		var asked int32
+		b.ask = func(_ context.Context, fn func() decision.Query) ([]byte, error) {
+			atomic.AddInt32(&asked, 1)
+			q := fn()
+			if q.Policy != "/karavi/volumes/map" {
+				return []byte(`{}`), nil
+			}
+			return json.Marshal(map[string]interface{}{"result": q.Input})
+		}
+
+		got := askDecisions(t, b, 3)
+
+		if n := atomic.LoadInt32(&asked); n != 4 {
+			t.Errorf("asked OPA %d times, want 4", n)
+		}
+		for i, ans := range got {
+			if want := fmt.Sprintf(`{"result":{"i":%d}}`, i); ans != want {
+				t.Errorf("%d: got %s, want %s", i, ans, want)
+			}
+		}
+	})
+
+	t.Run("it returns the batch error to every query", func(t *testing.T) {
+		b := newDecisionBatcher(time.Millisecond, 2, "/karavi/volumes/batch/map")
+		b.ask = func(_ context.Context, _ func() decision.Query) ([]byte, error) {
+			return nil, errors.New("test error")
+		}
+
+		errs := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				_, err := b.Do(context.Background(), decision.Query{Host: "opa"})
+				errs <- err
+			}()
+		}
+		for i := 0; i < 2; i++ {
+			if err := <-errs; err == nil {
+				t.Error("expected non-nil error")
+			}
+		}
+	})
+}
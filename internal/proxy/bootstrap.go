@@ -0,0 +1,224 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"karavi-authorization/internal/errcode"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/yaml"
+)
+
+// Tenant naming of bootstrap tokens.
+const (
+	TenantNamingCluster   = "cluster"
+	TenantNamingNamespace = "namespace"
+)
+
+// ClaimBootstrapCluster is the tenant claim that records the cluster whose
+// bootstrap token created the tenant. Bootstrap tokens are only exchanged
+// for the tokens of the tenants their cluster created.
+const ClaimBootstrapCluster = "bootstrapCluster"
+
+// DefaultBootstrapTokenTTL is how long the tenant tokens that bootstrap
+// tokens are exchanged for are valid.
+const DefaultBootstrapTokenTTL = 5 * time.Minute
+
+// maxTenantNameLength is the longest name of an inferred tenant.
+const maxTenantNameLength = 253
+
+// BootstrapConfig configures the onboarding of clusters with cluster-scoped
+// bootstrap tokens, which are exchanged for the token of a tenant that is
+// created with the default role when it does not exist.
+type BootstrapConfig struct {
+	Enabled bool
+	// TenantNaming names the tenants after the cluster of the token or
+	// after the cluster and the namespace of the persistent volume of the
+	// request, falling back to the cluster.
+	TenantNaming string
+	// TokenTTL is how long the tenant tokens are valid and cached.
+	TokenTTL time.Duration
+}
+
+type bootstrapToken struct {
+	access    string
+	expiresAt time.Time
+}
+
+type bootstrapper struct {
+	log    *logrus.Entry
+	client pb.TenantServiceClient
+	tm     token.Manager
+	cfg    BootstrapConfig
+
+	mu     sync.Mutex
+	tokens map[string]bootstrapToken
+}
+
+// BootstrapMW exchanges bootstrap tokens for the tokens of the tenants
+// they are inferred to be for, creating unknown tenants, before the tokens
+// are validated by web.AuthMW. Requests are passed on unchanged when it is
+// disabled or for other tokens.
+func BootstrapMW(log *logrus.Entry, client pb.TenantServiceClient, tm token.Manager, cfg BootstrapConfig) web.Middleware {
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	if cfg.TokenTTL <= 0 {
+		cfg.TokenTTL = DefaultBootstrapTokenTTL
+	}
+	b := &bootstrapper{
+		log:    log,
+		client: client,
+		tm:     tm,
+		cfg:    cfg,
+		tokens: make(map[string]bootstrapToken),
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme, tkn, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !ok || scheme != "Bearer" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var claims token.Claims
+			if _, err := tm.ParseWithClaims(tkn, web.JWTSigningSecret, &claims); err != nil || claims.Subject != token.SubjectBootstrap {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tenant := BootstrapTenantName(cfg.TenantNaming, claims.Cluster, r)
+			if tenant == "" {
+				handleJSONErrorResponse(log, w, http.StatusUnauthorized, fmt.Errorf("no tenant can be inferred for cluster %q", claims.Cluster))
+				return
+			}
+
+			access, err := b.tenantToken(r.Context(), claims.Cluster, tenant)
+			if err != nil {
+				err = fmt.Errorf("onboarding tenant %s: %w", tenant, err)
+				handleRPCErrorResponse(log, w, err)
+				return
+			}
+
+			r.Header.Set("Authorization", "Bearer "+access)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// tenantToken returns a cached access token of the tenant or generates one,
+// creating the tenant for the cluster if it does not exist. Tenants that
+// the cluster did not create are refused.
+func (b *bootstrapper) tenantToken(ctx context.Context, cluster, tenant string) (string, error) {
+	key := cluster + "/" + tenant
+	now := time.Now()
+	b.mu.Lock()
+	cached, ok := b.tokens[key]
+	b.mu.Unlock()
+	// leave a margin for the requests that are in flight
+	if ok && now.Add(b.cfg.TokenTTL/5).Before(cached.expiresAt) {
+		return cached.access, nil
+	}
+
+	t, err := b.client.GetTenant(ctx, &pb.GetTenantRequest{Name: tenant})
+	if status.Code(err) == codes.NotFound {
+		b.log.WithFields(logrus.Fields{"tenant": tenant, "cluster": cluster}).Info("Creating tenant for bootstrap token")
+		t, err = b.client.CreateTenant(ctx, &pb.CreateTenantRequest{Tenant: &pb.Tenant{
+			Name:   tenant,
+			Claims: map[string]string{ClaimBootstrapCluster: cluster},
+		}})
+		if status.Code(err) == codes.AlreadyExists {
+			t, err = b.client.GetTenant(ctx, &pb.GetTenantRequest{Name: tenant})
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	if t.Claims[ClaimBootstrapCluster] != cluster {
+		return "", errcode.Newf(codes.PermissionDenied, pb.ErrorCode_PERMISSION_DENIED,
+			"tenant %s was not created by a bootstrap token of cluster %s", tenant, cluster)
+	}
+
+	resp, err := b.generateToken(ctx, tenant)
+	if err != nil {
+		return "", err
+	}
+
+	var secret struct {
+		Data map[string][]byte `json:"data"`
+	}
+	if err := yaml.Unmarshal([]byte(resp.Token), &secret); err != nil {
+		return "", fmt.Errorf("decoding token secret: %w", err)
+	}
+	access := string(secret.Data["access"])
+
+	b.mu.Lock()
+	b.tokens[key] = bootstrapToken{access: access, expiresAt: now.Add(b.cfg.TokenTTL)}
+	b.mu.Unlock()
+	return access, nil
+}
+
+func (b *bootstrapper) generateToken(ctx context.Context, tenant string) (*pb.GenerateTokenResponse, error) {
+	return b.client.GenerateToken(ctx, &pb.GenerateTokenRequest{
+		TenantName:      tenant,
+		AccessTokenTTL:  int64(b.cfg.TokenTTL),
+		RefreshTokenTTL: int64(b.cfg.TokenTTL),
+	})
+}
+
+// BootstrapTenantName returns the name of the tenant of a request with a
+// bootstrap token of the cluster, or an empty string if none is valid.
+// Names inferred from the namespace are prefixed with the cluster, so that
+// the namespaces of different clusters are different tenants.
+func BootstrapTenantName(naming, cluster string, r *http.Request) string {
+	prefix := sanitizeTenantName(cluster)
+	if prefix == "" {
+		return ""
+	}
+	if naming == TenantNamingNamespace {
+		if ns := sanitizeTenantName(r.Header.Get(HeaderPVNamespace)); ns != "" {
+			return sanitizeTenantName(prefix + "-" + ns)
+		}
+	}
+	return prefix
+}
+
+// sanitizeTenantName replaces the characters that tenant names may not have
+// and trims it to start and end with a letter or digit.
+func sanitizeTenantName(s string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '-'
+		}
+	}, s)
+	if len(name) > maxTenantNameLength {
+		name = name[:maxTenantNameLength]
+	}
+	return strings.Trim(name, "-_.")
+}
@@ -0,0 +1,228 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"karavi-authorization/internal/tenantsvc"
+	"karavi-authorization/internal/tenantsvc/mocks"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/token/jwx"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+func TestBootstrapMW(t *testing.T) {
+	tm := jwx.NewTokenManager(jwx.HS256)
+	bootstrap, err := jwx.GenerateBootstrapToken("cluster-1", web.JWTSigningSecret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// newClient fakes a tenant service with the tenants, which map to the
+	// cluster that bootstrapped them.
+	newClient := func(tenants map[string]string, created *[]string, generated *int) *mocks.FakeTenantServiceClient {
+		return &mocks.FakeTenantServiceClient{
+			GetTenantFn: func(_ context.Context, req *pb.GetTenantRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+				cluster, ok := tenants[req.Name]
+				if !ok {
+					return nil, tenantsvc.ErrTenantNotFound
+				}
+				t := &pb.Tenant{Name: req.Name}
+				if cluster != "" {
+					t.Claims = map[string]string{ClaimBootstrapCluster: cluster}
+				}
+				return t, nil
+			},
+			CreateTenantFn: func(_ context.Context, req *pb.CreateTenantRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+				*created = append(*created, req.Tenant.Name)
+				tenants[req.Tenant.Name] = req.Tenant.Claims[ClaimBootstrapCluster]
+				return req.Tenant, nil
+			},
+			GenerateTokenFn: func(_ context.Context, req *pb.GenerateTokenRequest, _ ...grpc.CallOption) (*pb.GenerateTokenResponse, error) {
+				*generated++
+				if _, ok := tenants[req.TenantName]; !ok {
+					return nil, tenantsvc.ErrTenantNotFound
+				}
+				s, err := token.CreateAsK8sSecret(tm, token.Config{
+					Tenant:            req.TenantName,
+					Roles:             []string{"role"},
+					JWTSigningSecret:  web.JWTSigningSecret,
+					AccessExpiration:  time.Duration(req.AccessTokenTTL),
+					RefreshExpiration: time.Duration(req.RefreshTokenTTL),
+				})
+				return &pb.GenerateTokenResponse{Token: s}, err
+			},
+		}
+	}
+
+	// serve returns the group of the token that reaches the next handler.
+	serve := func(t *testing.T, mw web.Middleware, tkn string, header http.Header) (int, string) {
+		var group string
+		next := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			var claims token.Claims
+			_, tkn, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			if _, err := tm.ParseWithClaims(tkn, web.JWTSigningSecret, &claims); err != nil {
+				t.Fatal(err)
+			}
+			group = claims.Group
+		})
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/types", nil)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		r.Header.Set("Authorization", "Bearer "+tkn)
+		web.Adapt(next, mw).ServeHTTP(w, r)
+		return w.Code, group
+	}
+
+	t.Run("it creates the tenant of the cluster", func(t *testing.T) {
+		var created []string
+		var generated int
+		tenants := map[string]string{}
+		client := newClient(tenants, &created, &generated)
+		mw := BootstrapMW(discardLogger(), client, tm, BootstrapConfig{Enabled: true})
+
+		code, group := serve(t, mw, bootstrap, nil)
+		if code != http.StatusOK || group != "cluster-1" {
+			t.Errorf("got (%d, %q), want (%d, %q)", code, group, http.StatusOK, "cluster-1")
+		}
+		if len(created) != 1 || created[0] != "cluster-1" {
+			t.Errorf("created %v, want [cluster-1]", created)
+		}
+		if tenants["cluster-1"] != "cluster-1" {
+			t.Errorf("got bootstrap cluster %q, want %q", tenants["cluster-1"], "cluster-1")
+		}
+
+		// the token of the tenant is cached
+		serve(t, mw, bootstrap, nil)
+		if generated != 1 {
+			t.Errorf("generated %d tokens, want 1", generated)
+		}
+	})
+
+	t.Run("it names the tenant after the namespace", func(t *testing.T) {
+		var created []string
+		var generated int
+		client := newClient(map[string]string{"cluster-1-team-a": "cluster-1"}, &created, &generated)
+		mw := BootstrapMW(discardLogger(), client, tm, BootstrapConfig{Enabled: true, TenantNaming: TenantNamingNamespace})
+
+		code, group := serve(t, mw, bootstrap, http.Header{http.CanonicalHeaderKey(HeaderPVNamespace): {"team-a"}})
+		if code != http.StatusOK || group != "cluster-1-team-a" {
+			t.Errorf("got (%d, %q), want (%d, %q)", code, group, http.StatusOK, "cluster-1-team-a")
+		}
+		if len(created) != 0 {
+			t.Errorf("created %v, want none", created)
+		}
+	})
+
+	t.Run("it refuses tenants the cluster did not create", func(t *testing.T) {
+		tenants := map[string]string{
+			"cluster-1":        "",
+			"cluster-1-team-a": "cluster-2",
+		}
+		for name, header := range map[string]http.Header{
+			"existing tenant":           nil,
+			"tenant of another cluster": {http.CanonicalHeaderKey(HeaderPVNamespace): {"team-a"}},
+		} {
+			t.Run(name, func(t *testing.T) {
+				var created []string
+				var generated int
+				client := newClient(tenants, &created, &generated)
+				mw := BootstrapMW(discardLogger(), client, tm, BootstrapConfig{Enabled: true, TenantNaming: TenantNamingNamespace})
+
+				code, _ := serve(t, mw, bootstrap, header)
+				if code != http.StatusForbidden {
+					t.Errorf("got %d, want %d", code, http.StatusForbidden)
+				}
+				if len(created) != 0 || generated != 0 {
+					t.Errorf("created %v and generated %d tokens, want none", created, generated)
+				}
+			})
+		}
+	})
+
+	t.Run("it passes other tokens on", func(t *testing.T) {
+		var created []string
+		var generated int
+		client := newClient(map[string]string{}, &created, &generated)
+		mw := BootstrapMW(discardLogger(), client, tm, BootstrapConfig{Enabled: true})
+
+		pair, err := token.Create(tm, token.Config{
+			Tenant:            "tenant-1",
+			Roles:             []string{"role"},
+			JWTSigningSecret:  web.JWTSigningSecret,
+			AccessExpiration:  time.Hour,
+			RefreshExpiration: time.Hour,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, group := serve(t, mw, pair.Access, nil)
+		if group != "tenant-1" || generated != 0 {
+			t.Errorf("got group %q after %d tokens, want %q after 0", group, generated, "tenant-1")
+		}
+	})
+
+	t.Run("it does nothing when disabled", func(t *testing.T) {
+		var created []string
+		var generated int
+		client := newClient(map[string]string{}, &created, &generated)
+		mw := BootstrapMW(discardLogger(), client, tm, BootstrapConfig{})
+
+		_, group := serve(t, mw, bootstrap, nil)
+		if group != "" || generated != 0 {
+			t.Errorf("got group %q after %d tokens, want none", group, generated)
+		}
+	})
+}
+
+func TestBootstrapTenantName(t *testing.T) {
+	tests := []struct {
+		name      string
+		naming    string
+		cluster   string
+		namespace string
+		want      string
+	}{
+		{"cluster", TenantNamingCluster, "cluster-1", "team-a", "cluster-1"},
+		{"namespace", TenantNamingNamespace, "cluster-1", "team-a", "cluster-1-team-a"},
+		{"no namespace", TenantNamingNamespace, "cluster-1", "", "cluster-1"},
+		{"sanitized", TenantNamingCluster, "-my cluster/1.", "", "my-cluster-1"},
+		{"sanitized namespace", TenantNamingNamespace, "cluster-1", "team a/", "cluster-1-team-a"},
+		{"invalid", TenantNamingCluster, "///", "", ""},
+		{"invalid cluster", TenantNamingNamespace, "///", "team-a", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.namespace != "" {
+				r.Header.Set(HeaderPVNamespace, tt.namespace)
+			}
+			if got := BootstrapTenantName(tt.naming, tt.cluster, r); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
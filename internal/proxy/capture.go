@@ -0,0 +1,350 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"karavi-authorization/internal/redact"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+// Limits of the captures of the requests of a tenant.
+const (
+	DefaultCaptureDuration = 10 * time.Minute
+	MaxCaptureDuration     = time.Hour
+	DefaultCaptureSize     = 100
+	MaxCaptureSize         = 1000
+	// CaptureBodyLimit is how much of a request or response body is
+	// captured, if bodies are captured.
+	CaptureBodyLimit = 4096
+	// CaptureRetention is how long the captured requests are kept after
+	// the capture ended.
+	CaptureRetention = time.Hour
+	// CaptureRefreshInterval is how long the capture of a tenant is cached
+	// by a proxy-server, and so how long captures started or stopped
+	// through other proxy-servers take to apply to its requests.
+	CaptureRefreshInterval = 5 * time.Second
+)
+
+// ErrInvalidCapture is returned when a capture is started with invalid
+// settings.
+var ErrInvalidCapture = errors.New("invalid capture")
+
+// ErrNoCapture is returned when there is no capture of the tenant.
+var ErrNoCapture = errors.New("no capture of the tenant")
+
+// Capture is a time-limited capture of the requests of a tenant, for
+// troubleshooting the tenant without raising the log level of every
+// request.
+type Capture struct {
+	Tenant string `json:"tenant"`
+	// Duration is how long the requests are captured.
+	Duration Duration `json:"duration,omitempty"`
+	// Size is how many of the latest requests are kept.
+	Size int `json:"size,omitempty"`
+	// Bodies captures the start of the request and response bodies, which
+	// are otherwise left out.
+	Bodies    bool      `json:"bodies,omitempty"`
+	StartedBy string    `json:"startedBy,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// CaptureRecord is a captured request of a tenant, with its secrets
+// redacted.
+type CaptureRecord struct {
+	Time            time.Time   `json:"time"`
+	Method          string      `json:"method"`
+	Path            string      `json:"path"`
+	Query           string      `json:"query,omitempty"`
+	StorageType     string      `json:"storageType"`
+	SystemID        string      `json:"systemId"`
+	Status          int         `json:"status"`
+	Duration        Duration    `json:"duration"`
+	RequestHeaders  http.Header `json:"requestHeaders,omitempty"`
+	ResponseHeaders http.Header `json:"responseHeaders,omitempty"`
+	RequestBody     string      `json:"requestBody,omitempty"`
+	ResponseBody    string      `json:"responseBody,omitempty"`
+}
+
+// Captures keeps the captures of the requests of tenants and the captured
+// requests, shared by every proxy-server through redis. The captures are
+// cached in memory, so that requests do not each look them up.
+type Captures struct {
+	log     *logrus.Entry
+	rdb     func() *redis.Client
+	refresh time.Duration
+
+	mu     sync.Mutex
+	active map[string]cachedCapture
+}
+
+// cachedCapture is the capture of a tenant, or nil, as looked up until
+// refreshAt.
+type cachedCapture struct {
+	capture   *Capture
+	failed    bool
+	refreshAt time.Time
+}
+
+// NewCaptures returns the Captures kept in redis.
+func NewCaptures(log *logrus.Entry, rdb func() *redis.Client) *Captures {
+	return &Captures{
+		log:     log,
+		rdb:     rdb,
+		refresh: CaptureRefreshInterval,
+		active:  make(map[string]cachedCapture),
+	}
+}
+
+// cache caches the capture of the tenant until the next refresh.
+func (c *Captures) cache(tenant string, capture *Capture, failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active[tenant] = cachedCapture{
+		capture:   capture,
+		failed:    failed,
+		refreshAt: time.Now().Add(c.refresh),
+	}
+}
+
+// current returns the capture of the tenant if its requests are being
+// captured, looking it up at most once every refresh interval. A failed
+// lookup is cached as no capture, and only logged if the previous one
+// succeeded.
+func (c *Captures) current(tenant string) *Capture {
+	now := time.Now()
+	c.mu.Lock()
+	cached, ok := c.active[tenant]
+	c.mu.Unlock()
+
+	if !ok || !now.Before(cached.refreshAt) {
+		capture, err := c.Get(tenant)
+		if err != nil && !cached.failed {
+			c.log.WithError(err).WithField("tenant", tenant).Warn("looking up capture")
+		}
+		c.cache(tenant, capture, err != nil)
+		cached.capture = capture
+	}
+	if cached.capture == nil || !now.Before(cached.capture.ExpiresAt) {
+		return nil
+	}
+	return cached.capture
+}
+
+func captureKey(tenant string) string {
+	return "capture:tenant:" + tenant
+}
+
+func captureRecordsKey(tenant string) string {
+	return "capture:tenant:" + tenant + ":requests"
+}
+
+// Start starts capturing the requests of the tenant, or restarts the
+// capture of the tenant with the new settings. Requests captured before
+// are kept.
+func (c *Captures) Start(capture Capture) (*Capture, error) {
+	if capture.Tenant == "" {
+		return nil, fmt.Errorf("%w: tenant must be provided", ErrInvalidCapture)
+	}
+	if capture.Duration == 0 {
+		capture.Duration = Duration(DefaultCaptureDuration)
+	}
+	if d := time.Duration(capture.Duration); d < 0 || d > MaxCaptureDuration {
+		return nil, fmt.Errorf("%w: duration %v must be above 0 and at most %v", ErrInvalidCapture, d, MaxCaptureDuration)
+	}
+	if capture.Size == 0 {
+		capture.Size = DefaultCaptureSize
+	}
+	if capture.Size < 0 || capture.Size > MaxCaptureSize {
+		return nil, fmt.Errorf("%w: size %d must be above 0 and at most %d", ErrInvalidCapture, capture.Size, MaxCaptureSize)
+	}
+	capture.StartedAt = time.Now().UTC()
+	capture.ExpiresAt = capture.StartedAt.Add(time.Duration(capture.Duration))
+
+	b, err := json.Marshal(capture)
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.rdb().TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(captureKey(capture.Tenant), b, time.Duration(capture.Duration))
+		pipe.LTrim(captureRecordsKey(capture.Tenant), int64(-capture.Size), -1)
+		pipe.Expire(captureRecordsKey(capture.Tenant), time.Duration(capture.Duration)+CaptureRetention)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.cache(capture.Tenant, &capture, false)
+	return &capture, nil
+}
+
+// Stop stops capturing the requests of the tenant. The captured requests
+// are kept for CaptureRetention.
+func (c *Captures) Stop(tenant string) error {
+	n, err := c.rdb().Del(captureKey(tenant)).Result()
+	if err != nil {
+		return err
+	}
+	c.cache(tenant, nil, false)
+	if n == 0 {
+		return ErrNoCapture
+	}
+	return c.rdb().Expire(captureRecordsKey(tenant), CaptureRetention).Err()
+}
+
+// Get returns the capture of the tenant, or nil if its requests are not
+// being captured.
+func (c *Captures) Get(tenant string) (*Capture, error) {
+	s, err := c.rdb().Get(captureKey(tenant)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var capture Capture
+	if err := json.Unmarshal([]byte(s), &capture); err != nil {
+		return nil, fmt.Errorf("decoding capture of %s: %w", tenant, err)
+	}
+	return &capture, nil
+}
+
+// Records returns the captured requests of the tenant, oldest first.
+func (c *Captures) Records(tenant string) ([]CaptureRecord, error) {
+	ss, err := c.rdb().LRange(captureRecordsKey(tenant), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]CaptureRecord, 0, len(ss))
+	for _, s := range ss {
+		var rec CaptureRecord
+		if err := json.Unmarshal([]byte(s), &rec); err != nil {
+			return nil, fmt.Errorf("decoding captured request of %s: %w", tenant, err)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func (c *Captures) record(capture *Capture, rec CaptureRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	key := captureRecordsKey(capture.Tenant)
+	_, err = c.rdb().TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.RPush(key, b)
+		pipe.LTrim(key, int64(-capture.Size), -1)
+		pipe.ExpireAt(key, capture.ExpiresAt.Add(CaptureRetention))
+		return nil
+	})
+	return err
+}
+
+// Handler captures the requests of the tenant to the storage system while
+// the tenant has a capture, and forwards all requests to next. Requests are
+// forwarded uncaptured if the capture cannot be looked up.
+func (c *Captures) Handler(tenant, storageType, systemID string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		capture := c.current(tenant)
+		if capture == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		red := redact.Current()
+		rec := CaptureRecord{
+			Time:           time.Now().UTC(),
+			Method:         r.Method,
+			Path:           r.URL.Path,
+			Query:          red.String(r.URL.RawQuery),
+			StorageType:    storageType,
+			SystemID:       systemID,
+			RequestHeaders: red.Header(r.Header),
+		}
+		cw := &captureWriter{StatusWriter: web.StatusWriter{ResponseWriter: w}}
+		var reqBody *limitedBuffer
+		if capture.Bodies {
+			cw.body = &limitedBuffer{limit: CaptureBodyLimit}
+			if r.Body != nil && r.Body != http.NoBody {
+				reqBody = &limitedBuffer{limit: CaptureBodyLimit}
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.TeeReader(r.Body, reqBody), r.Body}
+			}
+		}
+
+		next.ServeHTTP(cw, r)
+
+		rec.Status = cw.Status
+		if rec.Status == 0 {
+			rec.Status = http.StatusOK
+		}
+		rec.Duration = Duration(time.Since(rec.Time))
+		rec.ResponseHeaders = red.Header(w.Header())
+		if reqBody != nil {
+			rec.RequestBody = string(red.JSON(reqBody.Bytes()))
+		}
+		if cw.body != nil {
+			rec.ResponseBody = string(red.JSON(cw.body.Bytes()))
+		}
+		if err := c.record(capture, rec); err != nil {
+			c.log.WithError(err).WithField("tenant", tenant).Warn("recording captured request")
+		}
+	})
+}
+
+// captureWriter copies the start of the response body, if it is captured.
+type captureWriter struct {
+	web.StatusWriter
+	body *limitedBuffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if w.body != nil {
+		_, _ = w.body.Write(b)
+	}
+	return w.StatusWriter.Write(b)
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the
+// rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if n := b.limit - b.Len(); n > 0 {
+		if len(p) > n {
+			b.Buffer.Write(p[:n])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,172 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/web"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CaptureHandler is the proxy handler for karavictl debug capture requests.
+type CaptureHandler struct {
+	mux      *http.ServeMux
+	captures *Captures
+	log      *logrus.Entry
+}
+
+// CaptureResponse is the capture of a tenant, if its requests are being
+// captured, and the captured requests.
+type CaptureResponse struct {
+	Capture  *Capture        `json:"capture,omitempty"`
+	Requests []CaptureRecord `json:"requests"`
+}
+
+// NewCaptureHandler returns a CaptureHandler
+func NewCaptureHandler(log *logrus.Entry, captures *Captures) *CaptureHandler {
+	ch := &CaptureHandler{
+		captures: captures,
+		log:      log,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(web.ProxyCapturesPath, web.Adapt(web.HandlerWithError(ch.captureHandler), web.TelemetryMW("captureHandler", log)))
+	ch.mux = mux
+
+	return ch
+}
+
+// ServeHTTP implements the http.Handler interface
+func (ch *CaptureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ch.mux.ServeHTTP(w, r)
+}
+
+func (ch *CaptureHandler) captureHandler(w http.ResponseWriter, r *http.Request) error {
+	// The captured requests are not limited to the tenants of an
+	// organization.
+	if admin, _ := r.Context().Value(web.JWTAdminName).(string); admin == "" || adminOrganization(r) != "" {
+		err := errors.New("admin token required that is not scoped to an organization")
+		handleJSONErrorResponse(ch.log, w, http.StatusForbidden, err)
+		return err
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		return ch.getHandler(w, r)
+	case http.MethodPost:
+		return ch.startHandler(w, r)
+	case http.MethodDelete:
+		return ch.stopHandler(w, r)
+	default:
+		return handleMethodNotAllowed(ch.log, w, r)
+	}
+}
+
+func (ch *CaptureHandler) getHandler(w http.ResponseWriter, r *http.Request) error {
+	tenant := r.URL.Query().Get("tenant")
+	if tenant == "" {
+		err := errors.New("tenant not provided in query parameters")
+		handleJSONErrorResponse(ch.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	capture, err := ch.captures.Get(tenant)
+	if err != nil {
+		err = fmt.Errorf("getting capture: %w", err)
+		handleJSONErrorResponse(ch.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	records, err := ch.captures.Records(tenant)
+	if err != nil {
+		err = fmt.Errorf("getting captured requests: %w", err)
+		handleJSONErrorResponse(ch.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+
+	err = json.NewEncoder(w).Encode(&CaptureResponse{Capture: capture, Requests: records})
+	if err != nil {
+		err = fmt.Errorf("writing capture response: %w", err)
+		handleJSONErrorResponse(ch.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return nil
+}
+
+func (ch *CaptureHandler) startHandler(w http.ResponseWriter, r *http.Request) error {
+	var body Capture
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(ch.log, w, http.StatusBadRequest, err)
+		return err
+	}
+	body.StartedBy, _ = r.Context().Value(web.JWTAdminName).(string)
+
+	ch.log.WithFields(logrus.Fields{
+		"tenant":   body.Tenant,
+		"duration": body.Duration,
+		"bodies":   body.Bodies,
+		"admin":    body.StartedBy,
+	}).Info("Starting request capture")
+
+	capture, err := ch.captures.Start(body)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidCapture) {
+			status = http.StatusBadRequest
+		}
+		err = fmt.Errorf("starting capture: %w", err)
+		handleJSONErrorResponse(ch.log, w, status, err)
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(capture)
+	if err != nil {
+		err = fmt.Errorf("writing capture response: %w", err)
+		ch.log.WithError(err).Error()
+		return err
+	}
+	return nil
+}
+
+func (ch *CaptureHandler) stopHandler(w http.ResponseWriter, r *http.Request) error {
+	tenant := r.URL.Query().Get("tenant")
+	if tenant == "" {
+		err := errors.New("tenant not provided in query parameters")
+		handleJSONErrorResponse(ch.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	ch.log.WithField("tenant", tenant).Info("Stopping request capture")
+
+	err := ch.captures.Stop(tenant)
+	switch {
+	case errors.Is(err, ErrNoCapture):
+		handleJSONErrorResponse(ch.log, w, http.StatusNotFound, err)
+		return err
+	case err != nil:
+		err = fmt.Errorf("stopping capture: %w", err)
+		handleJSONErrorResponse(ch.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestCaptures_current(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	logger, hook := test.NewNullLogger()
+	captures := NewCaptures(logrus.NewEntry(logger), func() *redis.Client { return rdb })
+	// other is the Captures of another proxy-server.
+	other := NewCaptures(logrus.NewEntry(logger), func() *redis.Client { return rdb })
+	request := func() {
+		next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		captures.Handler("tenant-a", "powerflex", "542a2d5f5122210f", next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	expire := func() {
+		captures.mu.Lock()
+		defer captures.mu.Unlock()
+		for tenant, cached := range captures.active {
+			cached.refreshAt = time.Now()
+			captures.active[tenant] = cached
+		}
+	}
+	records := func(t *testing.T) int {
+		t.Helper()
+		n, err := rdb.LLen(captureRecordsKey("tenant-a")).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return int(n)
+	}
+
+	t.Run("it caches the capture between refreshes", func(t *testing.T) {
+		request()
+		if _, err := other.Start(Capture{Tenant: "tenant-a"}); err != nil {
+			t.Fatal(err)
+		}
+
+		request()
+		if got := records(t); got != 0 {
+			t.Errorf("got %d requests, want 0 before the refresh", got)
+		}
+
+		expire()
+		request()
+		if got := records(t); got != 1 {
+			t.Errorf("got %d requests, want 1 after the refresh", got)
+		}
+	})
+	t.Run("it applies the captures it stops at once", func(t *testing.T) {
+		if err := captures.Stop("tenant-a"); err != nil {
+			t.Fatal(err)
+		}
+
+		request()
+		if got := records(t); got != 1 {
+			t.Errorf("got %d requests, want 1", got)
+		}
+	})
+	t.Run("it only warns once while redis is unavailable", func(t *testing.T) {
+		hook.Reset()
+		mr.Close()
+
+		for i := 0; i < 3; i++ {
+			expire()
+			request()
+		}
+
+		if got := len(hook.AllEntries()); got != 1 {
+			t.Errorf("got %d log entries, want 1", got)
+		}
+	})
+}
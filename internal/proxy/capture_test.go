@@ -0,0 +1,161 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/redact"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+func TestCaptures(t *testing.T) {
+	mr, err := miniredis.Run()
+	checkError(t, err)
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	log := logrus.New().WithContext(context.Background())
+	captures := proxy.NewCaptures(log, func() *redis.Client { return rdb })
+	h := proxy.NewCaptureHandler(log, captures)
+	serve := func(method, target, body, org string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		ctx := context.WithValue(r.Context(), web.JWTAdminName, "admin")
+		ctx = context.WithValue(ctx, web.JWTOrganization, org)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r.WithContext(ctx))
+		return w
+	}
+	// storage is a storage system handler that reads the request body and
+	// responds with a session token.
+	storage := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"vol-1","token":"s3cret"}`))
+	})
+	request := func(tenant string) {
+		r := httptest.NewRequest(http.MethodPost, "/api/types/Volume/instances?password=pw", strings.NewReader(`{"name":"k8s-1","password":"pw"}`))
+		r.Header.Set("Authorization", "Bearer abc.def.ghi")
+		captures.Handler(tenant, "powerflex", "542a2d5f5122210f", storage).ServeHTTP(httptest.NewRecorder(), r)
+	}
+	get := func(t *testing.T, tenant string) proxy.CaptureResponse {
+		t.Helper()
+		w := serve(http.MethodGet, web.ProxyCapturesPath+"?tenant="+tenant, "", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d: %s, want %d", w.Code, w.Body, http.StatusOK)
+		}
+		var resp proxy.CaptureResponse
+		checkError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp
+	}
+
+	t.Run("it does not capture without a capture", func(t *testing.T) {
+		request("tenant-a")
+
+		if got := get(t, "tenant-a"); got.Capture != nil || len(got.Requests) != 0 {
+			t.Errorf("got %+v, want nothing captured", got)
+		}
+	})
+	t.Run("it captures the redacted metadata of the requests", func(t *testing.T) {
+		w := serve(http.MethodPost, web.ProxyCapturesPath, `{"tenant": "tenant-a", "duration": "5m", "size": 2}`, "")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("got status %d: %s, want %d", w.Code, w.Body, http.StatusCreated)
+		}
+
+		request("tenant-a")
+		request("tenant-b")
+
+		got := get(t, "tenant-a")
+		if got.Capture == nil || got.Capture.StartedBy != "admin" || got.Capture.Size != 2 {
+			t.Fatalf("got capture %+v, want the capture started by admin", got.Capture)
+		}
+		if len(got.Requests) != 1 {
+			t.Fatalf("got %d requests, want 1", len(got.Requests))
+		}
+		rec := got.Requests[0]
+		if rec.Status != http.StatusCreated || rec.SystemID != "542a2d5f5122210f" || rec.Path != "/api/types/Volume/instances" {
+			t.Errorf("got %+v, want the request to the system", rec)
+		}
+		if rec.RequestHeaders.Get("Authorization") != redact.Mask || rec.ResponseHeaders.Get("Set-Cookie") != redact.Mask || strings.Contains(rec.Query, "pw") {
+			t.Errorf("got %+v, want the secrets redacted", rec)
+		}
+		if rec.RequestBody != "" || rec.ResponseBody != "" {
+			t.Errorf("got bodies %q and %q, want none", rec.RequestBody, rec.ResponseBody)
+		}
+	})
+	t.Run("it keeps the latest requests", func(t *testing.T) {
+		request("tenant-a")
+		request("tenant-a")
+
+		if got := get(t, "tenant-a"); len(got.Requests) != 2 {
+			t.Errorf("got %d requests, want 2", len(got.Requests))
+		}
+	})
+	t.Run("it captures the redacted bodies if enabled", func(t *testing.T) {
+		serve(http.MethodPost, web.ProxyCapturesPath, `{"tenant": "tenant-c", "bodies": true}`, "")
+
+		request("tenant-c")
+
+		got := get(t, "tenant-c")
+		if len(got.Requests) != 1 {
+			t.Fatalf("got %d requests, want 1", len(got.Requests))
+		}
+		rec := got.Requests[0]
+		if !strings.Contains(rec.RequestBody, "k8s-1") || strings.Contains(rec.RequestBody, `"pw"`) {
+			t.Errorf("got request body %q, want it with the password redacted", rec.RequestBody)
+		}
+		if !strings.Contains(rec.ResponseBody, "vol-1") || strings.Contains(rec.ResponseBody, "s3cret") {
+			t.Errorf("got response body %q, want it with the token redacted", rec.ResponseBody)
+		}
+	})
+	t.Run("it stops a capture and keeps the requests", func(t *testing.T) {
+		if w := serve(http.MethodDelete, web.ProxyCapturesPath+"?tenant=tenant-a", "", ""); w.Code != http.StatusNoContent {
+			t.Fatalf("got status %d: %s, want %d", w.Code, w.Body, http.StatusNoContent)
+		}
+
+		request("tenant-a")
+
+		if got := get(t, "tenant-a"); got.Capture != nil || len(got.Requests) != 2 {
+			t.Errorf("got %+v, want the requests captured before it stopped", got)
+		}
+		if w := serve(http.MethodDelete, web.ProxyCapturesPath+"?tenant=tenant-a", "", ""); w.Code != http.StatusNotFound {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+	t.Run("it rejects invalid captures", func(t *testing.T) {
+		for _, body := range []string{`{"duration": "5m"}`, `{"tenant": "tenant-a", "duration": "2h"}`, `{"tenant": "tenant-a", "size": 5000}`} {
+			if w := serve(http.MethodPost, web.ProxyCapturesPath, body, ""); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: got status %d, want %d", body, w.Code, http.StatusBadRequest)
+			}
+		}
+	})
+	t.Run("it requires an admin token not scoped to an organization", func(t *testing.T) {
+		if w := serve(http.MethodGet, web.ProxyCapturesPath+"?tenant=tenant-a", "", "org-1"); w.Code != http.StatusForbidden {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusForbidden)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"karavi-authorization/internal/quota"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Defaults of the compaction of the quota data in redis.
+const (
+	// DefaultCompactionInterval is how often the quota data is compacted.
+	DefaultCompactionInterval = 24 * time.Hour
+	// DefaultDeletedRetention is how long the fields of a deleted volume
+	// are kept.
+	DefaultDeletedRetention = 30 * 24 * time.Hour
+	// DefaultStreamMaxLen is how many of the latest entries of each quota
+	// stream are kept.
+	DefaultStreamMaxLen = 10000
+)
+
+// CompactionConfig configures the compaction of the quota data, which
+// otherwise grows with every volume ever created.
+type CompactionConfig struct {
+	// Interval is how often the quota data is compacted. It is only
+	// compacted on request when it is 0.
+	Interval time.Duration
+	// DeletedRetention is how long the fields of a deleted volume are
+	// kept. They are kept forever when it is 0.
+	DeletedRetention time.Duration
+	// StreamMaxLen is how many of the latest entries of each quota stream
+	// are kept. Streams are not trimmed when it is 0.
+	StreamMaxLen int64
+}
+
+// Compaction compacts the quota data of the enforcer, periodically or on
+// request, and exports the size of the quota data of each tenant.
+type Compaction struct {
+	log *logrus.Entry
+	enf *quota.RedisEnforcement
+	cfg CompactionConfig
+}
+
+// NewCompaction returns the Compaction of the quota data of the enforcer.
+func NewCompaction(log *logrus.Entry, enf *quota.RedisEnforcement, cfg CompactionConfig) *Compaction {
+	return &Compaction{log: log, enf: enf, cfg: cfg}
+}
+
+// Compact compacts the quota data with the options, or with the configured
+// retention and stream length where they are 0, and updates the keyspace
+// metrics.
+func (c *Compaction) Compact(ctx context.Context, opts quota.CompactOptions) (quota.CompactResult, error) {
+	if opts.DeletedRetention == 0 {
+		opts.DeletedRetention = c.cfg.DeletedRetention
+	}
+	if opts.StreamMaxLen == 0 {
+		opts.StreamMaxLen = c.cfg.StreamMaxLen
+	}
+	res, err := c.enf.Compact(ctx, opts)
+	if err != nil {
+		return res, err
+	}
+	c.log.WithFields(logrus.Fields{
+		"deletedRetention": opts.DeletedRetention,
+		"streamMaxLen":     opts.StreamMaxLen,
+		"keys":             res.Keys,
+		"volumes":          res.Volumes,
+		"streamEntries":    res.StreamEntries,
+	}).Info("Compacted quota data")
+
+	if err := c.UpdateMetrics(ctx); err != nil {
+		c.log.WithError(err).Warn("measuring quota keyspace")
+	}
+	return res, nil
+}
+
+// UpdateMetrics sets the keyspace metrics to the size of the quota data of
+// each tenant.
+func (c *Compaction) UpdateMetrics(ctx context.Context) error {
+	sizes, err := c.enf.KeyspaceSizes(ctx)
+	if err != nil {
+		return err
+	}
+	// Tenants without quota data any more are dropped.
+	quotaKeyspaceSize.Reset()
+	for _, s := range sizes {
+		quotaKeyspaceSize.WithLabelValues(s.Tenant, "keys").Set(float64(s.Keys))
+		quotaKeyspaceSize.WithLabelValues(s.Tenant, "fields").Set(float64(s.Fields))
+		quotaKeyspaceSize.WithLabelValues(s.Tenant, "stream_entries").Set(float64(s.StreamEntries))
+	}
+	return nil
+}
+
+// Run compacts the quota data every interval until ctx is done. A failed
+// compaction is logged and retried at the next interval. It returns at once
+// if there is no interval.
+func (c *Compaction) Run(ctx context.Context) {
+	if c.cfg.Interval <= 0 {
+		return
+	}
+	if err := c.UpdateMetrics(ctx); err != nil {
+		c.log.WithError(err).Warn("measuring quota keyspace")
+	}
+	t := time.NewTicker(c.cfg.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if _, err := c.Compact(ctx, quota.CompactOptions{}); err != nil {
+				c.log.WithError(err).Error("compacting quota data")
+			}
+		}
+	}
+}
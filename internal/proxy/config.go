@@ -14,6 +14,8 @@
 
 package proxy
 
+import "sync"
+
 // SystemConfig is a map of string keys to a Family of backend storage systems
 type SystemConfig map[string]Family
 
@@ -26,4 +28,33 @@ type SystemEntry struct {
 	User     string `json:"user"`
 	Password string `json:"password"`
 	Insecure bool   `json:"insecure"`
+	// Timeouts bound the calls to the system for each request.
+	Timeouts Timeouts `json:"timeouts"`
+	// Headers are set on and removed from the requests to the system.
+	Headers HeaderPolicy `json:"headers"`
+	// CertificateFingerprint pins the SHA-256 fingerprint of the certificate
+	// the system must present. Connections to a system presenting any other
+	// certificate are refused, even when Insecure is set.
+	CertificateFingerprint string `json:"certificateFingerprint,omitempty"`
+}
+
+// hostAddr is a host address that may be changed by a configuration reload
+// while requests are being served.
+type hostAddr struct {
+	mu   sync.RWMutex
+	host string
+}
+
+// Get returns the current host address.
+func (a *hostAddr) Get() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.host
+}
+
+// Set changes the host address.
+func (a *hostAddr) Set(host string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.host = host
 }
@@ -15,6 +15,7 @@
 package proxy
 
 import (
+	"errors"
 	"karavi-authorization/internal/web"
 	"net/http"
 	"strings"
@@ -26,25 +27,138 @@ import (
 type DispatchHandler struct {
 	log            *logrus.Entry
 	systemHandlers map[string]http.Handler
+	passthrough    http.Handler
+	recordActivity ActivityRecorder
+	urls           *URLPolicy
+	pauses         *SystemPauses
+	headers        *HeaderPolicies
+	captures       *Captures
+	forwarded      web.ForwardedParser
+}
+
+// DispatchOption allows for functional option arguments on the DispatchHandler.
+type DispatchOption func(*DispatchHandler)
+
+// WithPassthrough provides the handler of HTTP/2 passthrough requests, e.g.
+// gRPC calls, for all plugins.
+func WithPassthrough(next http.Handler) DispatchOption {
+	return func(h *DispatchHandler) {
+		h.passthrough = next
+	}
+}
+
+// WithURLPolicy provides the allow-list of the requests that are forwarded
+// to the systems. All requests are forwarded without one.
+func WithURLPolicy(p *URLPolicy) DispatchOption {
+	return func(h *DispatchHandler) {
+		h.urls = p
+	}
+}
+
+// WithHeaderPolicies provides the header policies of the systems, which are
+// applied to the requests forwarded to them. Headers are forwarded unchanged
+// without them.
+func WithHeaderPolicies(p *HeaderPolicies) DispatchOption {
+	return func(h *DispatchHandler) {
+		h.headers = p
+	}
+}
+
+// WithForwardedSchemes sets the schemes the storage system endpoints of the
+// Forwarded header may have, instead of web.DefaultForwardedSchemes.
+func WithForwardedSchemes(schemes []string) DispatchOption {
+	return func(h *DispatchHandler) {
+		h.forwarded.Schemes = schemes
+	}
+}
+
+// WithCaptures provides the captures of the requests of tenants. No
+// requests are captured without them.
+func WithCaptures(c *Captures) DispatchOption {
+	return func(h *DispatchHandler) {
+		h.captures = c
+	}
+}
+
+// WithSystemPauses provides the storage systems that are paused for
+// maintenance, whose changes are refused. No system is paused without them.
+func WithSystemPauses(p *SystemPauses) DispatchOption {
+	return func(h *DispatchHandler) {
+		h.pauses = p
+	}
 }
 
 // NewDispatchHandler returns a new DispatchHandler from the supplied map of pluginIDs to their respective http handler
-func NewDispatchHandler(log *logrus.Entry, m map[string]http.Handler) *DispatchHandler {
-	return &DispatchHandler{
+func NewDispatchHandler(log *logrus.Entry, m map[string]http.Handler, opts ...DispatchOption) *DispatchHandler {
+	h := &DispatchHandler{
 		systemHandlers: m,
 		log:            log,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 func (h *DispatchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	fwd := web.ForwardedHeader(r)
-	pluginID := web.NormalizePluginID(fwd["by"])
+	// Requests are routed by the Forwarded header, so one that could be
+	// read as naming another system than intended is refused. It is parsed
+	// once, by web.ForwardedMW or here, for every handler.
+	r, fwd, err := h.forwarded.Keep(r)
+	if err != nil {
+		reason := web.ForwardedSyntax
+		var fe *web.ForwardedError
+		if errors.As(err, &fe) {
+			reason = fe.Reason
+		}
+		malformedForwarded.WithLabelValues(reason).Inc()
+		h.log.WithError(err).Warn("Rejected a request with a malformed Forwarded header")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pluginID := web.NormalizePluginID(fwd.PluginID)
+	if _, ok := h.systemHandlers[pluginID]; ok && IsPassthrough(r) {
+		// The plugin handlers only understand HTTP/1.1 REST requests, so
+		// pass the stream through rather than downgrading it.
+		if h.passthrough == nil {
+			http.Error(w, "http/2 passthrough not supported", http.StatusBadGateway)
+			return
+		}
+		h.passthrough.ServeHTTP(w, r)
+		return
+	}
 	next, ok := h.systemHandlers[pluginID]
 	if !ok {
 		http.Error(w, "plugin id not found", http.StatusBadGateway)
 		return
 	}
-	next.ServeHTTP(w, r)
+	systemID := fwd.SystemID
+	tenant, _ := r.Context().Value(web.JWTTenantName).(string)
+	if h.captures != nil && tenant != "" {
+		next = h.captures.Handler(tenant, pluginID, systemID, next)
+	}
+	if h.headers != nil {
+		next = h.headers.Handler(pluginID, systemID, next)
+	}
+	if h.pauses != nil {
+		next = h.pauses.Handler(pluginID, systemID, next)
+	}
+	if h.urls != nil {
+		next = h.urls.Handler(pluginID, next)
+	}
+
+	active := activeRequests.WithLabelValues(pluginID, systemID)
+	active.Inc()
+	defer active.Dec()
+
+	if tenant == "" {
+		next.ServeHTTP(w, r)
+		return
+	}
+	op := backendOperation(r, pluginID)
+	sw := &web.StatusWriter{ResponseWriter: w}
+	next.ServeHTTP(sw, r)
+	h.recordTenantActivity(tenant, op, sw.Status)
 }
 
 // SplitEndpointSystemID split the endpoint to read systemID
@@ -17,10 +17,15 @@ package proxy_test
 import (
 	"context"
 	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/web"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 )
 
@@ -40,6 +45,276 @@ func TestDispatchHandler_ServeHTTP(t *testing.T) {
 	t.Run("empty dispatch handler returns 502", testEmptyDispatchHandler)
 	t.Run("configured dispatch handler proxies request", testConfiguredDispatchHandler)
 	t.Run("configured dispatch handler proxies request with various headers", testForwardedHeaders)
+	t.Run("dispatch handler passes grpc requests through", testPassthroughDispatch)
+	t.Run("dispatch handler counts active requests per system", testActiveRequests)
+	t.Run("dispatch handler records the activity of tenants", testTenantActivity)
+	t.Run("dispatch handler applies the header policies of systems", testHeaderPolicies)
+	t.Run("dispatch handler rejects malformed Forwarded headers", testMalformedForwarded)
+	t.Run("dispatch handler keeps the parsed Forwarded header", testKeptForwarded)
+}
+
+func testKeptForwarded(t *testing.T) {
+	ctx := context.Background()
+	log := logrus.New().WithContext(ctx)
+
+	var got map[string]string
+	systems := map[string]http.Handler{
+		"powerflex": http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			got = web.ForwardedHeader(r)
+		}),
+	}
+	h := proxy.NewDispatchHandler(log, systems, proxy.WithForwardedSchemes([]string{"http"}))
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/api/version/", nil)
+	checkError(t, err)
+	r.Header.Add("Forwarded", "for=csm-authorization;http://10.0.0.1;aaaa, by=csm-authorization;csi-vxflexos")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	want := map[string]string{"for": "http://10.0.0.1;aaaa", "by": "csi-vxflexos"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got Forwarded values %v, want %v", got, want)
+	}
+}
+
+func testMalformedForwarded(t *testing.T) {
+	ctx := context.Background()
+	log := logrus.New().WithContext(ctx)
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(proxy.Collectors()...)
+
+	var forwarded int
+	systems := map[string]http.Handler{
+		"powerflex": http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+			forwarded++
+		}),
+	}
+	h := proxy.NewDispatchHandler(log, systems)
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/api/version/", nil)
+	checkError(t, err)
+	r.Header.Add("Forwarded", "by=csm-authorization;csi-vxflexos")
+	r.Header.Add("Forwarded", "for=csm-authorization;https://10.0.0.1;1b2e5a7c9d3f4a6b")
+	r.Header.Add("Forwarded", "for=csm-authorization;https://10.0.0.2;542a2d5f5122210f")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if forwarded != 0 {
+		t.Errorf("got %d forwarded requests, want 0", forwarded)
+	}
+	var got float64
+	mfs, err := reg.Gather()
+	checkError(t, err)
+	for _, mf := range mfs {
+		if mf.GetName() != "karavi_proxy_malformed_forwarded_headers_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "reason" && l.GetValue() == web.ForwardedConflict {
+					got = m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	if got != 1 {
+		t.Errorf("got %v malformed headers with conflicting values, want 1", got)
+	}
+}
+
+func testActiveRequests(t *testing.T) {
+	const systemID = "1b2e5a7c9d3f4a6b"
+	ctx := context.Background()
+	log := logrus.New().WithContext(ctx)
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(proxy.Collectors()...)
+
+	var during float64
+	systems := map[string]http.Handler{
+		"powerflex": http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+			during = gaugeValue(t, reg, "karavi_proxy_active_requests", systemID)
+		}),
+	}
+	h := proxy.NewDispatchHandler(log, systems)
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/api/version/", nil)
+	checkError(t, err)
+	r.Header.Add("Forwarded", "by=csm-authorization;csi-vxflexos")
+	r.Header.Add("Forwarded", "for=csm-authorization;https://10.0.0.1;"+systemID)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if during != 1 {
+		t.Errorf("got %v active requests during the request, want 1", during)
+	}
+	if after := gaugeValue(t, reg, "karavi_proxy_active_requests", systemID); after != 0 {
+		t.Errorf("got %v active requests after the request, want 0", after)
+	}
+}
+
+func testTenantActivity(t *testing.T) {
+	ctx := context.Background()
+	log := logrus.New().WithContext(ctx)
+
+	systems := map[string]http.Handler{
+		"powerflex": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Deny") != "" {
+				w.WriteHeader(http.StatusForbidden)
+			}
+		}),
+	}
+	var got []string
+	h := proxy.NewDispatchHandler(log, systems, proxy.WithActivityRecorder(func(tenant, field string, _ time.Time) error {
+		got = append(got, tenant+":"+field)
+		return nil
+	}))
+
+	tests := []struct {
+		method, path, tenant string
+		deny                 bool
+	}{
+		{http.MethodPost, "/api/types/Volume/instances/", "tenant-a", false},
+		{http.MethodPost, "/api/instances/Volume::1/action/addMappedSdc/", "tenant-a", false},
+		{http.MethodPost, "/api/instances/Volume::1/action/removeVolume/", "tenant-b", false},
+		{http.MethodPost, "/api/types/Volume/instances/", "tenant-b", true},
+		{http.MethodGet, "/api/types/Volume/instances/", "tenant-b", false},
+		{http.MethodPost, "/api/types/Volume/instances/", "", false},
+	}
+	for _, tt := range tests {
+		r, err := http.NewRequestWithContext(ctx, tt.method, tt.path, nil)
+		checkError(t, err)
+		if tt.tenant != "" {
+			r = r.WithContext(context.WithValue(r.Context(), web.JWTTenantName, tt.tenant))
+		}
+		if tt.deny {
+			r.Header.Set("Deny", "true")
+		}
+		r.Header.Add("Forwarded", "by=csm-authorization;csi-vxflexos")
+		r.Header.Add("Forwarded", "for=csm-authorization;https://10.0.0.1;1b2e5a7c9d3f4a6b")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	want := []string{"tenant-a:creates", "tenant-a:maps", "tenant-b:deletes", "tenant-b:denials"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got recorded activity %v, want %v", got, want)
+	}
+}
+
+func testHeaderPolicies(t *testing.T) {
+	ctx := context.Background()
+	log := logrus.New().WithContext(ctx)
+
+	var gotHeader http.Header
+	var gotFwd map[string]string
+	systems := map[string]http.Handler{
+		"powermax": http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			gotHeader = r.Header.Clone()
+			gotFwd = web.ForwardedHeader(r)
+		}),
+	}
+	policies := proxy.NewHeaderPolicies()
+	err := policies.UpdateSystems(ctx, strings.NewReader(`{
+		"powermax": {
+			"000197900046": {
+				"endpoint": "https://10.0.0.1",
+				"headers": {
+					"set": {"Application-Type": "csm-authorization", "User-Agent": "csm-authorization"},
+					"remove": ["Forwarded", "X-Internal"]
+				}
+			},
+			"000197900047": {"endpoint": "https://10.0.0.2"}
+		}
+	}`), log)
+	checkError(t, err)
+	h := proxy.NewDispatchHandler(log, systems, proxy.WithHeaderPolicies(policies))
+
+	serve := func(systemID string) {
+		r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/univmax/restapi/version", nil)
+		checkError(t, err)
+		r.Header.Set("User-Agent", "gopowermax")
+		r.Header.Set("X-Internal", "true")
+		r.Header.Add("Forwarded", "by=csm-authorization;csi-powermax")
+		r.Header.Add("Forwarded", "for=csm-authorization;https://10.0.0.1;"+systemID)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	serve("000197900046")
+	if got := gotHeader.Get("Application-Type"); got != "csm-authorization" {
+		t.Errorf("got Application-Type %q, want %q", got, "csm-authorization")
+	}
+	if got := gotHeader.Get("User-Agent"); got != "csm-authorization" {
+		t.Errorf("got User-Agent %q, want %q", got, "csm-authorization")
+	}
+	for _, k := range []string{"Forwarded", "X-Internal"} {
+		if got := gotHeader.Values(k); len(got) != 0 {
+			t.Errorf("got %s %v, want it removed", k, got)
+		}
+	}
+	if want := map[string]string{"by": "csi-powermax", "for": "https://10.0.0.1;000197900046"}; !reflect.DeepEqual(gotFwd, want) {
+		t.Errorf("got forwarded values %v, want %v", gotFwd, want)
+	}
+
+	serve("000197900047")
+	if got := gotHeader.Get("User-Agent"); got != "gopowermax" {
+		t.Errorf("got User-Agent %q, want it unchanged", got)
+	}
+	if got := gotHeader.Values("Forwarded"); len(got) != 2 {
+		t.Errorf("got Forwarded %v, want it unchanged", got)
+	}
+}
+
+// gaugeValue returns the value of the gauge for the system, or zero if it
+// has not been set.
+func gaugeValue(t *testing.T, reg *prometheus.Registry, name, systemID string) float64 {
+	t.Helper()
+	mfs, err := reg.Gather()
+	checkError(t, err)
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "system_id" && l.GetValue() == systemID {
+					return m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func testPassthroughDispatch(t *testing.T) {
+	ctx := context.Background()
+	log := logrus.New().WithContext(ctx)
+	var gotPassthrough bool
+	systems := map[string]http.Handler{
+		"powerflex": http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}),
+	}
+	newRequest := func(t *testing.T) *http.Request {
+		r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/dell.array.v1.Volumes/List", nil)
+		checkError(t, err)
+		r.ProtoMajor, r.ProtoMinor = 2, 0
+		r.Header.Set("Content-Type", "application/grpc+proto")
+		r.Header.Set("Forwarded", "by=csm-authorization;powerflex")
+		return r
+	}
+
+	h := proxy.NewDispatchHandler(log, systems, proxy.WithPassthrough(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		gotPassthrough = true
+	})))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newRequest(t))
+	if !gotPassthrough {
+		t.Errorf("expected the request to be passed through")
+	}
+
+	h = proxy.NewDispatchHandler(log, systems)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, newRequest(t))
+	if got := w.Result().StatusCode; got != http.StatusBadGateway {
+		t.Errorf("got status %d without passthrough, want %d", got, http.StatusBadGateway)
+	}
 }
 
 func testEmptyDispatchHandler(t *testing.T) {
@@ -0,0 +1,134 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// EventHandler is the proxy handler that streams the events of tenants,
+// role bindings, quotas and tokens to external systems.
+type EventHandler struct {
+	mux    *http.ServeMux
+	client pb.EventServiceClient
+	log    *logrus.Entry
+}
+
+// NewEventHandler returns an EventHandler
+func NewEventHandler(log *logrus.Entry, client pb.EventServiceClient) *EventHandler {
+	eh := &EventHandler{
+		client: client,
+		log:    log,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(web.ProxyEventsPath, web.Adapt(web.HandlerWithError(eh.watchHandler), web.TelemetryMW("eventHandler", log)))
+	eh.mux = mux
+
+	return eh
+}
+
+// ServeHTTP implements the http.Handler interface
+func (eh *EventHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	eh.mux.ServeHTTP(w, r)
+}
+
+// watchHandler streams the events as server-sent events until the client
+// disconnects. The events can be limited by type, with any number of type
+// parameters, and by tenant. A client that reconnects with the
+// Last-Event-ID header, or the since parameter, gets the events it missed
+// first.
+func (eh *EventHandler) watchHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return handleMethodNotAllowed(eh.log, w, r)
+	}
+	if admin, _ := r.Context().Value(web.JWTAdminName).(string); admin == "" {
+		err := errors.New("admin token required")
+		handleJSONErrorResponse(eh.log, w, http.StatusForbidden, err)
+		return err
+	}
+	// Events are of every organization.
+	if adminOrganization(r) != "" {
+		err := errors.New("events are only available to admins of every organization")
+		handleJSONErrorResponse(eh.log, w, http.StatusForbidden, err)
+		return err
+	}
+
+	query := r.URL.Query()
+	req := &pb.WatchEventsRequest{
+		Types:  query["type"],
+		Tenant: query.Get("tenant"),
+		Since:  r.Header.Get("Last-Event-ID"),
+	}
+	if req.Since == "" {
+		req.Since = query.Get("since")
+	}
+
+	eh.log.WithFields(logrus.Fields{
+		"types":  req.Types,
+		"tenant": req.Tenant,
+		"since":  req.Since,
+	}).Info("Watching events")
+
+	stream, err := eh.client.Watch(r.Context(), req)
+	if err != nil {
+		err = fmt.Errorf("watching events: %w", err)
+		handleRPCErrorResponse(eh.log, w, err)
+		return err
+	}
+
+	// The stream lasts longer than the write timeout of the proxy-server.
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return fmt.Errorf("flushing events: %w", err)
+	}
+
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF || r.Context().Err() != nil {
+			return nil
+		}
+		if err != nil {
+			// The response has started, so the error can only be logged.
+			eh.log.WithError(err).Warn("receiving events")
+			return nil
+		}
+
+		data, err := protojson.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("encoding event %s: %w", e.Id, err)
+		}
+		if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.Id, e.Type, data); err != nil {
+			return nil
+		}
+		if err := rc.Flush(); err != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"io"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+type fakeEventStream struct {
+	grpc.ClientStream
+	events []*pb.Event
+}
+
+func (f *fakeEventStream) Recv() (*pb.Event, error) {
+	if len(f.events) == 0 {
+		return nil, io.EOF
+	}
+	e := f.events[0]
+	f.events = f.events[1:]
+	return e, nil
+}
+
+type fakeEventClient struct {
+	gotReq *pb.WatchEventsRequest
+	events []*pb.Event
+}
+
+func (f *fakeEventClient) Watch(_ context.Context, req *pb.WatchEventsRequest, _ ...grpc.CallOption) (pb.EventService_WatchClient, error) {
+	f.gotReq = req
+	return &fakeEventStream{events: f.events}, nil
+}
+
+func TestEventHandler(t *testing.T) {
+	adminRequest := func(method, target, org string) *http.Request {
+		r := httptest.NewRequest(method, target, nil)
+		ctx := context.WithValue(r.Context(), web.JWTAdminName, "admin-1")
+		ctx = context.WithValue(ctx, web.JWTOrganization, org)
+		return r.WithContext(ctx)
+	}
+
+	t.Run("it streams the events", func(t *testing.T) {
+		client := &fakeEventClient{events: []*pb.Event{
+			{Id: "1-0", Type: "tenant.created", Tenant: "tenant-a", Time: 1700000000},
+			{Id: "2-0", Type: "role.bound", Tenant: "tenant-a", Role: "role-a", Time: 1700000001},
+		}}
+		sut := NewEventHandler(logrus.NewEntry(logrus.New()), client)
+
+		w := httptest.NewRecorder()
+		r := adminRequest(http.MethodGet, "/proxy/events/?type=tenant.created&type=role.bound&tenant=tenant-a", "")
+		r.Header.Set("Last-Event-ID", "0-1")
+		sut.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get("Content-Type"); got != "text/event-stream" {
+			t.Errorf("expected content type text/event-stream, got %q", got)
+		}
+		got := client.gotReq
+		if len(got.Types) != 2 || got.Types[1] != "role.bound" || got.Tenant != "tenant-a" || got.Since != "0-1" {
+			t.Errorf("expected the types, tenant and last event id in the request, got %v", got)
+		}
+		body := w.Body.String()
+		for _, want := range []string{
+			"id: 1-0\nevent: tenant.created\ndata: {",
+			"id: 2-0\nevent: role.bound\ndata: {",
+			`"role":"role-a"`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected %q in the response, got %q", want, body)
+			}
+		}
+	})
+	t.Run("it requires an admin token of every organization", func(t *testing.T) {
+		sut := NewEventHandler(logrus.NewEntry(logrus.New()), &fakeEventClient{})
+
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, adminRequest(http.MethodGet, "/proxy/events/", "org-1"))
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected status code %d, got %d", http.StatusForbidden, w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		sut.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/proxy/events/", nil))
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected status code %d, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+	t.Run("it handles bad method", func(t *testing.T) {
+		sut := NewEventHandler(logrus.NewEntry(logrus.New()), &fakeEventClient{})
+
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, adminRequest(http.MethodPost, "/proxy/events/", ""))
+
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, w.Code)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Event reasons of the proxy decisions published as Kubernetes Events.
+const (
+	EventReasonQuotaExceeded = "QuotaExceeded"
+	EventReasonPolicyDenied  = "PolicyDenied"
+)
+
+// eventComponent is the source component of the published events.
+const eventComponent = "csm-authorization-proxy"
+
+// eventTimeout bounds how long publishing an event may take.
+const eventTimeout = 5 * time.Second
+
+// DecisionEvents publishes denied requests as Kubernetes Events on the
+// persistent volume claim the request was made for, so that application
+// teams see why provisioning failed. Requests that do not name a claim are
+// not published. A nil DecisionEvents publishes nothing.
+type DecisionEvents struct {
+	Client kubernetes.Interface
+	Log    *logrus.Entry
+}
+
+// Denied publishes a warning event with the reason and message on the
+// claim named by the headers of the request. The event is published in
+// the background so that the request is not delayed.
+func (e *DecisionEvents) Denied(r *http.Request, reason, message string) {
+	if e == nil || e.Client == nil {
+		return
+	}
+	namespace, claim := r.Header.Get(HeaderPVNamespace), r.Header.Get(HeaderPVClaimName)
+	if namespace == "" || claim == "" {
+		return
+	}
+
+	now := metav1.Now()
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", claim, now.UnixNano()),
+			Namespace: namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+			Namespace:  namespace,
+			Name:       claim,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           corev1.EventTypeWarning,
+		Source:         corev1.EventSource{Component: eventComponent},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), eventTimeout)
+		defer cancel()
+		if _, err := e.Client.CoreV1().Events(namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+			e.Log.WithError(err).WithFields(logrus.Fields{
+				"namespace": namespace,
+				"claim":     claim,
+				"reason":    reason,
+			}).Warn("publishing decision event")
+		}
+	}()
+}
+
+// QuotaExceeded publishes that a request of the tenant was denied by the
+// quota of the pool for the given reason.
+func (e *DecisionEvents) QuotaExceeded(r *http.Request, tenant, pool, reason string) {
+	e.Denied(r, EventReasonQuotaExceeded, fmt.Sprintf("%s for tenant %s on pool %s", reason, tenant, pool))
+}
+
+// PolicyDenied publishes that a request of the tenant for the pool was
+// denied by policy for the given reason.
+func (e *DecisionEvents) PolicyDenied(r *http.Request, tenant, pool, reason string) {
+	e.Denied(r, EventReasonPolicyDenied, fmt.Sprintf("request denied for tenant %s on pool %s: %s", tenant, pool, reason))
+}
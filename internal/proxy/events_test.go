@@ -0,0 +1,88 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDecisionEvents(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	listEvents := func(t *testing.T, client *fake.Clientset, namespace string) []corev1.Event {
+		t.Helper()
+		events, err := client.CoreV1().Events(namespace).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return events.Items
+	}
+
+	t.Run("it publishes a warning on the claim of the request", func(t *testing.T) {
+		client := fake.NewSimpleClientset()
+		sut := &DecisionEvents{Client: client, Log: logrus.NewEntry(log)}
+
+		r := httptest.NewRequest(http.MethodPost, "/api/types/Volume/instances/", nil)
+		r.Header.Set(HeaderPVNamespace, "app")
+		r.Header.Set(HeaderPVClaimName, "data")
+		sut.QuotaExceeded(r, "mytenant", "bronze", "not enough quota")
+
+		var got []corev1.Event
+		for i := 0; i < 50 && len(got) == 0; i++ {
+			time.Sleep(10 * time.Millisecond)
+			got = listEvents(t, client, "app")
+		}
+		if len(got) != 1 {
+			t.Fatalf("expected 1 event, got %d", len(got))
+		}
+		e := got[0]
+		if e.InvolvedObject.Kind != "PersistentVolumeClaim" || e.InvolvedObject.Name != "data" || e.InvolvedObject.Namespace != "app" {
+			t.Errorf("unexpected involved object %+v", e.InvolvedObject)
+		}
+		if e.Type != corev1.EventTypeWarning || e.Reason != EventReasonQuotaExceeded {
+			t.Errorf("expected a %s warning, got %s %s", EventReasonQuotaExceeded, e.Type, e.Reason)
+		}
+		if want := "not enough quota for tenant mytenant on pool bronze"; e.Message != want {
+			t.Errorf("expected message %q, got %q", want, e.Message)
+		}
+	})
+
+	t.Run("it publishes nothing without a claim", func(t *testing.T) {
+		client := fake.NewSimpleClientset()
+		sut := &DecisionEvents{Client: client, Log: logrus.NewEntry(log)}
+
+		r := httptest.NewRequest(http.MethodPost, "/api/types/Volume/instances/", nil)
+		sut.PolicyDenied(r, "mytenant", "bronze", "pool not permitted")
+
+		time.Sleep(50 * time.Millisecond)
+		if got := listEvents(t, client, ""); len(got) != 0 {
+			t.Errorf("expected no events, got %d", len(got))
+		}
+	})
+
+	t.Run("a nil publisher publishes nothing", func(_ *testing.T) {
+		var sut *DecisionEvents
+		sut.PolicyDenied(httptest.NewRequest(http.MethodPost, "/", nil), "mytenant", "bronze", "pool not permitted")
+	})
+}
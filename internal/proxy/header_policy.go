@@ -0,0 +1,109 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HeaderPolicy sets and removes the headers of the requests forwarded to a
+// storage system, e.g. the User-Agent or the application headers that
+// Unisphere requires, or the Forwarded header that carries the internal
+// routing of the proxy. Headers are removed before they are set.
+type HeaderPolicy struct {
+	Set    map[string]string `json:"set,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
+}
+
+// empty reports whether the policy leaves the headers unchanged.
+func (p HeaderPolicy) empty() bool {
+	return len(p.Set) == 0 && len(p.Remove) == 0
+}
+
+// HeaderPolicies are the header policies of the storage systems, from the
+// storage systems configuration. They are applied by the DispatchHandler.
+type HeaderPolicies struct {
+	mu       sync.RWMutex
+	policies map[string]HeaderPolicy
+}
+
+// NewHeaderPolicies returns HeaderPolicies without a policy.
+func NewHeaderPolicies() *HeaderPolicies {
+	return &HeaderPolicies{policies: make(map[string]HeaderPolicy)}
+}
+
+// UpdateSystems replaces the policies with those of the systems of the
+// storage systems configuration.
+func (p *HeaderPolicies) UpdateSystems(_ context.Context, r io.Reader, log *logrus.Entry) error {
+	var updated SystemConfig
+	if err := json.NewDecoder(r).Decode(&updated); err != nil {
+		return err
+	}
+
+	policies := make(map[string]HeaderPolicy)
+	for storageType, systems := range updated {
+		for systemID, system := range systems {
+			if system.Headers.empty() {
+				continue
+			}
+			log.WithFields(logrus.Fields{
+				"storage_type": storageType,
+				"system_id":    systemID,
+			}).Debug("Updating header policy")
+			policies[storageType+":"+systemID] = system.Headers
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.policies = policies
+	return nil
+}
+
+// Get returns the header policy of the storage system.
+func (p *HeaderPolicies) Get(storageType, systemID string) HeaderPolicy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.policies[storageType+":"+systemID]
+}
+
+// Handler applies the header policy of the storage system to the requests
+// before they are served by next. The Forwarded values stay available to
+// next through web.ForwardedHeader when the Forwarded header is removed.
+func (p *HeaderPolicies) Handler(storageType, systemID string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := p.Get(storageType, systemID)
+		if policy.empty() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		r = web.KeepForwarded(r)
+		for _, k := range policy.Remove {
+			r.Header.Del(k)
+		}
+		for k, v := range policy.Set {
+			r.Header.Set(k, v)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+)
+
+// maxPooledBufferSize is the largest buffer returned to encoderPool, so
+// that an occasional large response does not stay allocated.
+const maxPooledBufferSize = 64 * 1024
+
+// pooledEncoder is an encoder together with the buffer it encodes into.
+type pooledEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var encoderPool = sync.Pool{
+	New: func() interface{} {
+		e := &pooledEncoder{}
+		e.enc = json.NewEncoder(&e.buf)
+		return e
+	},
+}
+
+// writeJSON encodes v with a pooled encoder and writes it to w in a single
+// write. Nothing is written if v cannot be encoded.
+func writeJSON(w io.Writer, v interface{}) error {
+	e := encoderPool.Get().(*pooledEncoder)
+	defer func() {
+		if e.buf.Cap() <= maxPooledBufferSize {
+			e.buf.Reset()
+			encoderPool.Put(e)
+		}
+	}()
+
+	if err := e.enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := w.Write(e.buf.Bytes())
+	return err
+}
+
+// powerflexCreateVolumeBody is the body of a PowerFlex volume create
+// request. It is decoded once; the fields used by the proxy are taken from
+// the raw members, which are passed to OPA as they were sent.
+type powerflexCreateVolumeBody struct {
+	Name           string
+	VolumeSizeInKb string
+	StoragePoolID  string
+	Raw            map[string]json.RawMessage
+}
+
+// UnmarshalJSON decodes the body into its raw members and then the known
+// fields. Members are matched case-insensitively, as encoding/json does
+// for struct fields.
+func (b *powerflexCreateVolumeBody) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &b.Raw); err != nil {
+		return err
+	}
+	if err := b.member("name", &b.Name); err != nil {
+		return err
+	}
+	if err := b.member("volumeSizeInKb", &b.VolumeSizeInKb); err != nil {
+		return err
+	}
+	return b.member("storagePoolId", &b.StoragePoolID)
+}
+
+func (b *powerflexCreateVolumeBody) member(name string, v interface{}) error {
+	raw, ok := b.Raw[name]
+	if !ok {
+		for k, r := range b.Raw {
+			if strings.EqualFold(k, name) {
+				raw, ok = r, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,149 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+var testCreateVolumeBody = []byte(`{"volumeSizeInKb":"8388608","storagePoolId":"3df6b86600000000","name":"k8s-6aac50817e","volumeType":"ThinProvisioned"}`)
+
+func TestPowerFlexCreateVolumeBody(t *testing.T) {
+	t.Run("it decodes the fields and keeps the raw members", func(t *testing.T) {
+		var got powerflexCreateVolumeBody
+		if err := json.Unmarshal(testCreateVolumeBody, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		if got.VolumeSizeInKb != "8388608" || got.StoragePoolID != "3df6b86600000000" {
+			t.Errorf("got %+v", got)
+		}
+		if string(got.Raw["name"]) != `"k8s-6aac50817e"` {
+			t.Errorf("got raw name %s", got.Raw["name"])
+		}
+	})
+	t.Run("it matches members case-insensitively", func(t *testing.T) {
+		var got powerflexCreateVolumeBody
+		if err := json.Unmarshal([]byte(`{"VolumeSizeInKB":"8","StoragePoolId":"pool"}`), &got); err != nil {
+			t.Fatal(err)
+		}
+
+		if got.VolumeSizeInKb != "8" || got.StoragePoolID != "pool" {
+			t.Errorf("got %+v", got)
+		}
+	})
+	t.Run("it rejects a body that is not an object", func(t *testing.T) {
+		var got powerflexCreateVolumeBody
+		if err := json.Unmarshal([]byte(`["8"]`), &got); err == nil {
+			t.Error("expected an error")
+		}
+	})
+	t.Run("it rejects members of the wrong type", func(t *testing.T) {
+		var got powerflexCreateVolumeBody
+		if err := json.Unmarshal([]byte(`{"volumeSizeInKb":8}`), &got); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Run("it writes the encoded value", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		if err := writeJSON(w, map[string]int{"a": 1}); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := w.Body.String(), "{\"a\":1}\n"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+	t.Run("it writes nothing when encoding fails", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		if err := writeJSON(w, make(chan int)); err == nil {
+			t.Fatal("expected an error")
+		}
+
+		if w.Body.Len() != 0 {
+			t.Errorf("got %q, want no body", w.Body.String())
+		}
+	})
+}
+
+var testErrorBody = &struct {
+	Code       int    `json:"errorCode"`
+	StatusCode int    `json:"httpStatusCode"`
+	Message    string `json:"message"`
+}{400, 400, "request denied"}
+
+// BenchmarkDecodeCreateVolume_Twice is the previous decoding of a PowerFlex
+// volume create request, for comparison.
+func BenchmarkDecodeCreateVolume_Twice(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		body := struct {
+			VolumeSizeInKb string `json:"volumeSizeInKb"`
+			StoragePoolID  string `json:"storagePoolId"`
+		}{}
+		if err := json.NewDecoder(bytes.NewBuffer(testCreateVolumeBody)).Decode(&body); err != nil {
+			b.Fatal(err)
+		}
+		var raw map[string]json.RawMessage
+		if err := json.NewDecoder(bytes.NewReader(testCreateVolumeBody)).Decode(&raw); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeCreateVolume_Once(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var body powerflexCreateVolumeBody
+		if err := json.Unmarshal(testCreateVolumeBody, &body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeErrorResponse_Encoder(b *testing.B) {
+	v := testErrorBody
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := json.NewEncoder(io.Discard).Encode(v); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkEncodeErrorResponse_Pooled(b *testing.B) {
+	v := testErrorBody
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := writeJSON(io.Discard, v); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,384 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Login providers.
+const (
+	LoginProviderGitHub = "github"
+	LoginProviderOIDC   = "oidc"
+)
+
+// DefaultGitHubAPIURL is the GitHub API used to identify GitHub users.
+const DefaultGitHubAPIURL = "https://api.github.com"
+
+// Errors.
+var (
+	ErrUnknownIdentity = errors.New("identity is not mapped to a tenant")
+)
+
+// LoginConfig configures interactive login through an identity provider.
+type LoginConfig struct {
+	GitHub struct {
+		ClientID string
+		// ClientSecret is the secret of the OAuth app of ClientID, with
+		// which the access tokens are checked to be issued to the app.
+		ClientSecret string
+		APIURL       string
+	}
+	OIDC struct {
+		Issuer   string
+		ClientID string
+	}
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// Identities maps the identities of the providers to tenants.
+	Identities []LoginIdentity
+}
+
+// LoginIdentity maps the subject of a provider to a tenant.
+type LoginIdentity struct {
+	Provider string
+	Subject  string
+	Tenant   string
+}
+
+// LoginBody is the request body for logging in with a provider token
+type LoginBody struct {
+	Provider string `json:"provider"`
+	Token    string `json:"token"`
+}
+
+// LoginProviders describes the providers a client may log in with
+type LoginProviders struct {
+	GitHub *LoginProvider `json:"github,omitempty"`
+	OIDC   *LoginProvider `json:"oidc,omitempty"`
+}
+
+// LoginProvider describes a provider a client may log in with
+type LoginProvider struct {
+	ClientID string `json:"clientId"`
+	Issuer   string `json:"issuer,omitempty"`
+}
+
+// IdentityVerifier verifies a token issued by an identity provider
+// and returns the subject it identifies.
+type IdentityVerifier interface {
+	Verify(ctx context.Context, token string) (string, error)
+}
+
+// LoginHandler is the proxy handler for karavictl login requests
+type LoginHandler struct {
+	mux       *http.ServeMux
+	client    pb.TenantServiceClient
+	log       *logrus.Entry
+	cfg       LoginConfig
+	verifiers map[string]IdentityVerifier
+}
+
+// NewLoginHandler returns a LoginHandler
+func NewLoginHandler(log *logrus.Entry, client pb.TenantServiceClient, cfg LoginConfig) *LoginHandler {
+	lh := &LoginHandler{
+		client:    client,
+		log:       log,
+		cfg:       cfg,
+		verifiers: make(map[string]IdentityVerifier),
+	}
+
+	switch {
+	case cfg.GitHub.ClientID != "" && cfg.GitHub.ClientSecret != "":
+		apiURL := cfg.GitHub.APIURL
+		if apiURL == "" {
+			apiURL = DefaultGitHubAPIURL
+		}
+		lh.verifiers[LoginProviderGitHub] = &GitHubVerifier{
+			APIURL:       apiURL,
+			ClientID:     cfg.GitHub.ClientID,
+			ClientSecret: cfg.GitHub.ClientSecret,
+			Client:       http.DefaultClient,
+		}
+	case cfg.GitHub.ClientID != "":
+		log.Warn("login: github client secret is not configured, github login is disabled")
+	}
+	if cfg.OIDC.ClientID != "" && cfg.OIDC.Issuer != "" {
+		lh.verifiers[LoginProviderOIDC] = &OIDCVerifier{Issuer: cfg.OIDC.Issuer, ClientID: cfg.OIDC.ClientID, Client: http.DefaultClient}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(web.ProxyLoginPath, web.Adapt(web.HandlerWithError(lh.loginHandler), web.TelemetryMW("loginHandler", log)))
+	lh.mux = mux
+
+	return lh
+}
+
+// ServeHTTP implements the http.Handler interface
+func (lh *LoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lh.mux.ServeHTTP(w, r)
+}
+
+func (lh *LoginHandler) loginHandler(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return lh.providersHandler(w, r)
+	case http.MethodPost:
+		return lh.tokenHandler(w, r)
+	default:
+		return handleMethodNotAllowed(lh.log, w, r)
+	}
+}
+
+// providersHandler lists the configured providers so that clients can
+// start the device flow without being configured themselves.
+func (lh *LoginHandler) providersHandler(w http.ResponseWriter, _ *http.Request) error {
+	var providers LoginProviders
+	if _, ok := lh.verifiers[LoginProviderGitHub]; ok {
+		providers.GitHub = &LoginProvider{ClientID: lh.cfg.GitHub.ClientID}
+	}
+	if _, ok := lh.verifiers[LoginProviderOIDC]; ok {
+		providers.OIDC = &LoginProvider{ClientID: lh.cfg.OIDC.ClientID, Issuer: lh.cfg.OIDC.Issuer}
+	}
+
+	err := json.NewEncoder(w).Encode(&providers)
+	if err != nil {
+		err = fmt.Errorf("writing login providers response: %w", err)
+		handleJSONErrorResponse(lh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return nil
+}
+
+func (lh *LoginHandler) tokenHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	var body LoginBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(lh.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	verifier, ok := lh.verifiers[body.Provider]
+	if !ok {
+		err = fmt.Errorf("login provider %q is not configured", body.Provider)
+		handleJSONErrorResponse(lh.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	subject, err := verifier.Verify(ctx, body.Token)
+	if err != nil {
+		err = fmt.Errorf("verifying %s identity: %w", body.Provider, err)
+		handleJSONErrorResponse(lh.log, w, http.StatusUnauthorized, err)
+		return err
+	}
+
+	tenant, err := lh.tenantFor(body.Provider, subject)
+	if err != nil {
+		err = fmt.Errorf("logging in %s: %w", subject, err)
+		handleJSONErrorResponse(lh.log, w, http.StatusForbidden, err)
+		return err
+	}
+
+	setAttributes(span, map[string]interface{}{
+		"provider": body.Provider,
+		"subject":  subject,
+		"tenant":   tenant,
+	})
+	lh.log.WithFields(logrus.Fields{
+		"provider": body.Provider,
+		"subject":  subject,
+		"tenant":   tenant,
+	}).Info("Logging in")
+
+	token, err := lh.client.GenerateToken(ctx, &pb.GenerateTokenRequest{
+		TenantName:      tenant,
+		AccessTokenTTL:  int64(lh.cfg.AccessTokenTTL),
+		RefreshTokenTTL: int64(lh.cfg.RefreshTokenTTL),
+	})
+	if err != nil {
+		err = fmt.Errorf("generating token for %s: %w", tenant, err)
+		handleRPCErrorResponse(lh.log, w, err)
+		return err
+	}
+
+	err = json.NewEncoder(w).Encode(token)
+	if err != nil {
+		err = fmt.Errorf("writing login token response: %w", err)
+		handleJSONErrorResponse(lh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return nil
+}
+
+// tenantFor returns the tenant the subject of the provider is mapped to.
+// GitHub logins are case-insensitive; the subjects of other providers are
+// matched exactly.
+func (lh *LoginHandler) tenantFor(provider, subject string) (string, error) {
+	for _, id := range lh.cfg.Identities {
+		if id.Provider != provider {
+			continue
+		}
+		if id.Subject == subject || (provider == LoginProviderGitHub && strings.EqualFold(id.Subject, subject)) {
+			return id.Tenant, nil
+		}
+	}
+	return "", ErrUnknownIdentity
+}
+
+// GitHubVerifier identifies GitHub users by their login, for access tokens
+// issued to the OAuth app of ClientID.
+type GitHubVerifier struct {
+	APIURL       string
+	ClientID     string
+	ClientSecret string
+	Client       *http.Client
+}
+
+// Verify checks with the credentials of the OAuth app that the GitHub access
+// token was issued to the app, and returns the login of its user.
+func (v *GitHubVerifier) Verify(ctx context.Context, token string) (string, error) {
+	body, err := json.Marshal(map[string]string{"access_token": token})
+	if err != nil {
+		return "", err
+	}
+	u := strings.TrimSuffix(v.APIURL, "/") + "/applications/" + url.PathEscape(v.ClientID) + "/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(v.ClientID, v.ClientSecret)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("github returned %s", resp.Status)
+	}
+
+	var authz struct {
+		App struct {
+			ClientID string `json:"client_id"`
+		} `json:"app"`
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&authz); err != nil {
+		return "", fmt.Errorf("decoding github authorization: %w", err)
+	}
+	if authz.App.ClientID != v.ClientID {
+		return "", errors.New("github token was issued to another app")
+	}
+	if authz.User.Login == "" {
+		return "", errors.New("github user has no login")
+	}
+	return authz.User.Login, nil
+}
+
+// OIDCVerifier identifies users by the ID tokens of an OpenID Connect provider.
+type OIDCVerifier struct {
+	Issuer   string
+	ClientID string
+	Client   *http.Client
+}
+
+// OIDCDiscovery holds the parts of an OpenID Connect discovery document
+// used for logging in.
+type OIDCDiscovery struct {
+	Issuer                      string `json:"issuer"`
+	JWKSURI                     string `json:"jwks_uri"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+}
+
+// DiscoverOIDC fetches the discovery document of the OpenID Connect issuer.
+func DiscoverOIDC(ctx context.Context, client *http.Client, issuer string) (OIDCDiscovery, error) {
+	var d OIDCDiscovery
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return d, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return d, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return d, fmt.Errorf("oidc discovery returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return d, fmt.Errorf("decoding oidc discovery: %w", err)
+	}
+	return d, nil
+}
+
+// Verify validates the ID token against the keys of the issuer and returns
+// its email if the provider verified it, or else its subject.
+func (v *OIDCVerifier) Verify(ctx context.Context, token string) (string, error) {
+	d, err := DiscoverOIDC(ctx, v.Client, v.Issuer)
+	if err != nil {
+		return "", err
+	}
+
+	keys, err := jwk.Fetch(ctx, d.JWKSURI, jwk.WithHTTPClient(v.Client))
+	if err != nil {
+		return "", fmt.Errorf("fetching oidc keys: %w", err)
+	}
+
+	tkn, err := jwt.ParseString(token,
+		jwt.WithKeySet(keys),
+		jwt.UseDefaultKey(true),
+		jwt.WithValidate(true),
+		jwt.WithIssuer(v.Issuer),
+		jwt.WithAudience(v.ClientID))
+	if err != nil {
+		return "", err
+	}
+
+	if email, ok := tkn.Get("email"); ok {
+		verified, _ := tkn.Get("email_verified")
+		if s, ok := email.(string); ok && s != "" && verified == true {
+			return s, nil
+		}
+	}
+	if tkn.Subject() == "" {
+		return "", errors.New("id token has no subject")
+	}
+	return tkn.Subject(), nil
+}
@@ -0,0 +1,267 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"karavi-authorization/internal/tenantsvc/mocks"
+	"karavi-authorization/pb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+func TestLoginHandler(t *testing.T) {
+	// github checks the tokens of the OAuth apps; gho_other was issued to
+	// another app.
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if r.Method != http.MethodPost || r.URL.Path != "/applications/github-client/token" || id != "github-client" || secret != "github-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			AccessToken string `json:"access_token"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.AccessToken {
+		case "gho_valid":
+			w.Write([]byte(`{"app":{"client_id":"github-client"},"user":{"login":"Octocat"}}`))
+		case "gho_other":
+			w.Write([]byte(`{"app":{"client_id":"other-client"},"user":{"login":"Octocat"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer github.Close()
+
+	var gotReq *pb.GenerateTokenRequest
+	client := &mocks.FakeTenantServiceClient{
+		GenerateTokenFn: func(_ context.Context, req *pb.GenerateTokenRequest, _ ...grpc.CallOption) (*pb.GenerateTokenResponse, error) {
+			gotReq = req
+			return &pb.GenerateTokenResponse{Token: "secret"}, nil
+		},
+	}
+
+	var cfg LoginConfig
+	cfg.GitHub.ClientID = "github-client"
+	cfg.GitHub.ClientSecret = "github-secret"
+	cfg.GitHub.APIURL = github.URL
+	cfg.AccessTokenTTL = time.Minute
+	cfg.RefreshTokenTTL = time.Hour
+	cfg.Identities = []LoginIdentity{{Provider: LoginProviderGitHub, Subject: "octocat", Tenant: "mytenant"}}
+
+	sut := NewLoginHandler(logrus.NewEntry(logrus.New()), client, cfg)
+
+	login := func(t *testing.T, body LoginBody) *httptest.ResponseRecorder {
+		t.Helper()
+		payload, err := json.Marshal(&body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/proxy/login/", bytes.NewReader(payload)))
+		return w
+	}
+
+	t.Run("it lists the configured providers", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/proxy/login/", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		var got LoginProviders
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.GitHub == nil || got.GitHub.ClientID != "github-client" || got.OIDC != nil {
+			t.Errorf("expected only the github provider, got %+v", got)
+		}
+	})
+	t.Run("it generates a token for a mapped identity", func(t *testing.T) {
+		w := login(t, LoginBody{Provider: LoginProviderGitHub, Token: "gho_valid"})
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var got pb.GenerateTokenResponse
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Token != "secret" {
+			t.Errorf("expected token %q, got %q", "secret", got.Token)
+		}
+		if gotReq.TenantName != "mytenant" || gotReq.AccessTokenTTL != int64(time.Minute) || gotReq.RefreshTokenTTL != int64(time.Hour) {
+			t.Errorf("unexpected generate token request %v", gotReq)
+		}
+	})
+	t.Run("it rejects an invalid provider token", func(t *testing.T) {
+		w := login(t, LoginBody{Provider: LoginProviderGitHub, Token: "gho_invalid"})
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+	t.Run("it rejects a token issued to another app", func(t *testing.T) {
+		w := login(t, LoginBody{Provider: LoginProviderGitHub, Token: "gho_other"})
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+	t.Run("it rejects an unconfigured provider", func(t *testing.T) {
+		w := login(t, LoginBody{Provider: LoginProviderOIDC, Token: "id-token"})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+	t.Run("it rejects an unmapped identity", func(t *testing.T) {
+		var cfg LoginConfig
+		cfg.GitHub.ClientID = "github-client"
+		cfg.GitHub.ClientSecret = "github-secret"
+		cfg.GitHub.APIURL = github.URL
+		sut = NewLoginHandler(logrus.NewEntry(logrus.New()), client, cfg)
+
+		w := login(t, LoginBody{Provider: LoginProviderGitHub, Token: "gho_valid"})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected status code %d, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+}
+
+func TestOIDCVerifier(t *testing.T) {
+	rawKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := jwk.New(rawKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := key.Set(jwk.KeyIDKey, "key-1"); err != nil {
+		t.Fatal(err)
+	}
+	pubKey, err := jwk.PublicKeyOf(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pubKey.Set(jwk.AlgorithmKey, jwa.RS256); err != nil {
+		t.Fatal(err)
+	}
+	keys := jwk.NewSet()
+	keys.Add(pubKey)
+
+	var issuer string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(&OIDCDiscovery{Issuer: issuer, JWKSURI: issuer + "/keys"})
+		case "/keys":
+			json.NewEncoder(w).Encode(keys)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	issuer = srv.URL
+
+	idTokenWithEmail := func(t *testing.T, iss, aud string, emailVerified bool) string {
+		t.Helper()
+		tkn := jwt.New()
+		tkn.Set(jwt.IssuerKey, iss)
+		tkn.Set(jwt.AudienceKey, aud)
+		tkn.Set(jwt.SubjectKey, "1234")
+		tkn.Set("email", "user@example.com")
+		tkn.Set("email_verified", emailVerified)
+		tkn.Set(jwt.ExpirationKey, time.Now().Add(time.Minute))
+		b, err := jwt.Sign(tkn, jwa.RS256, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	idToken := func(t *testing.T, iss, aud string) string {
+		t.Helper()
+		return idTokenWithEmail(t, iss, aud, true)
+	}
+
+	sut := &OIDCVerifier{Issuer: issuer, ClientID: "karavictl", Client: srv.Client()}
+
+	t.Run("it returns the email of a valid id token", func(t *testing.T) {
+		got, err := sut.Verify(context.Background(), idToken(t, issuer, "karavictl"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "user@example.com" {
+			t.Errorf("expected subject %q, got %q", "user@example.com", got)
+		}
+	})
+	t.Run("it returns the subject of an id token with an unverified email", func(t *testing.T) {
+		got, err := sut.Verify(context.Background(), idTokenWithEmail(t, issuer, "karavictl", false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "1234" {
+			t.Errorf("expected subject %q, got %q", "1234", got)
+		}
+	})
+	t.Run("it rejects an id token for another client", func(t *testing.T) {
+		if _, err := sut.Verify(context.Background(), idToken(t, issuer, "other")); err == nil {
+			t.Error("expected an error")
+		}
+	})
+	t.Run("it rejects an id token from another issuer", func(t *testing.T) {
+		if _, err := sut.Verify(context.Background(), idToken(t, "https://other.example.com", "karavictl")); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestLoginHandler_tenantFor(t *testing.T) {
+	var cfg LoginConfig
+	cfg.Identities = []LoginIdentity{
+		{Provider: LoginProviderGitHub, Subject: "octocat", Tenant: "github-tenant"},
+		{Provider: LoginProviderOIDC, Subject: "user@example.com", Tenant: "oidc-tenant"},
+	}
+	sut := NewLoginHandler(logrus.NewEntry(logrus.New()), nil, cfg)
+
+	tests := map[string]struct {
+		provider, subject, want string
+	}{
+		"github login in another case": {LoginProviderGitHub, "Octocat", "github-tenant"},
+		"oidc subject":                 {LoginProviderOIDC, "user@example.com", "oidc-tenant"},
+		"oidc subject in another case": {LoginProviderOIDC, "User@example.com", ""},
+		"subject of another provider":  {LoginProviderOIDC, "octocat", ""},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := sut.tenantFor(tc.provider, tc.subject)
+			if tc.want == "" && err != ErrUnknownIdentity {
+				t.Errorf("expected %v, got %q, %v", ErrUnknownIdentity, got, err)
+			}
+			if got != tc.want {
+				t.Errorf("expected tenant %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"karavi-authorization/internal/quota"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MaintenanceHandler is the proxy handler for karavictl maintenance requests.
+type MaintenanceHandler struct {
+	mux        *http.ServeMux
+	compaction *Compaction
+	log        *logrus.Entry
+}
+
+// CompactRequest overrides the configured limits of a compaction of the
+// quota data where they are set.
+type CompactRequest struct {
+	DeletedRetention Duration `json:"deletedRetention,omitempty"`
+	StreamMaxLen     int64    `json:"streamMaxLen,omitempty"`
+}
+
+// NewMaintenanceHandler returns a MaintenanceHandler
+func NewMaintenanceHandler(log *logrus.Entry, compaction *Compaction) *MaintenanceHandler {
+	mh := &MaintenanceHandler{
+		compaction: compaction,
+		log:        log,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyMaintenancePath, "compact"), web.Adapt(web.HandlerWithError(mh.compactHandler), web.TelemetryMW("maintenanceHandler", log)))
+	mh.mux = mux
+
+	return mh
+}
+
+// ServeHTTP implements the http.Handler interface
+func (mh *MaintenanceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The quota data of every tenant is compacted, not only of the
+	// tenants of an organization.
+	if admin, _ := r.Context().Value(web.JWTAdminName).(string); admin == "" || adminOrganization(r) != "" {
+		handleJSONErrorResponse(mh.log, w, http.StatusForbidden, errors.New("admin token required that is not scoped to an organization"))
+		return
+	}
+	mh.mux.ServeHTTP(w, r)
+}
+
+func (mh *MaintenanceHandler) compactHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return handleMethodNotAllowed(mh.log, w, r)
+	}
+
+	var body CompactRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(mh.log, w, http.StatusBadRequest, err)
+		return err
+	}
+	if body.DeletedRetention < 0 || body.StreamMaxLen < 0 {
+		err := errors.New("retention and stream length must not be negative")
+		handleJSONErrorResponse(mh.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	admin, _ := r.Context().Value(web.JWTAdminName).(string)
+	mh.log.WithField("admin", admin).Info("Compacting quota data")
+
+	res, err := mh.compaction.Compact(r.Context(), quota.CompactOptions{
+		DeletedRetention: time.Duration(body.DeletedRetention),
+		StreamMaxLen:     body.StreamMaxLen,
+	})
+	if err != nil {
+		err = fmt.Errorf("compacting quota data: %w", err)
+		handleJSONErrorResponse(mh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(&res)
+	if err != nil {
+		err = fmt.Errorf("writing compact response: %w", err)
+		mh.log.WithError(err).Error()
+		return err
+	}
+	return nil
+}
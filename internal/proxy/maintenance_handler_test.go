@@ -0,0 +1,104 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/quota"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMaintenanceHandler(t *testing.T) {
+	mr, err := miniredis.Run()
+	checkError(t, err)
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	log := logrus.New().WithContext(context.Background())
+	enf := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rdb))
+	h := proxy.NewMaintenanceHandler(log, proxy.NewCompaction(log, enf, proxy.CompactionConfig{
+		DeletedRetention: 24 * time.Hour,
+	}))
+	serve := func(method, body, org string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/proxy/maintenance/compact/", strings.NewReader(body))
+		ctx := context.WithValue(r.Context(), web.JWTAdminName, "admin")
+		ctx = context.WithValue(ctx, web.JWTOrganization, org)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r.WithContext(ctx))
+		return w
+	}
+	deleted := quota.Request{SystemType: "powerflex", SystemID: "542a2d5f5122210f", StoragePoolID: "bronze", Group: "mytenant", VolumeName: "k8s-1"}
+	setup := func() {
+		mr.FlushAll()
+		mr.HSet(deleted.DataKey(), deleted.ApprovedField(), "1")
+		mr.HSet(deleted.DataKey(), deleted.DeletedField(), "1")
+		for i := 0; i < 3; i++ {
+			rdb.XAdd(&redis.XAddArgs{Stream: deleted.StreamKey(), Values: map[string]interface{}{"status": "approved"}})
+		}
+	}
+
+	t.Run("it compacts with the configured limits", func(t *testing.T) {
+		setup()
+
+		w := serve(http.MethodPost, "", "")
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+		}
+		var got quota.CompactResult
+		checkError(t, json.NewDecoder(w.Body).Decode(&got))
+		if want := (quota.CompactResult{Keys: 1, Volumes: 1}); got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+	t.Run("it compacts with the requested limits", func(t *testing.T) {
+		setup()
+
+		w := serve(http.MethodPost, `{"streamMaxLen":1}`, "")
+
+		var got quota.CompactResult
+		checkError(t, json.NewDecoder(w.Body).Decode(&got))
+		if want := (quota.CompactResult{Keys: 1, Volumes: 1, StreamEntries: 2}); got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+	t.Run("it rejects negative limits", func(t *testing.T) {
+		if w := serve(http.MethodPost, `{"streamMaxLen":-1}`, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+	t.Run("it requires an admin of every organization", func(t *testing.T) {
+		if w := serve(http.MethodPost, "", "finance"); w.Code != http.StatusForbidden {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusForbidden)
+		}
+	})
+	t.Run("it only allows posts", func(t *testing.T) {
+		if w := serve(http.MethodGet, "", ""); w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	activeRequests = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "karavi",
+		Subsystem: "proxy",
+		Name:      "active_requests",
+		Help:      "Requests being served for a storage system.",
+	}, []string{"plugin", "system_id"})
+
+	tokenGetters = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "karavi",
+		Subsystem: "proxy",
+		Name:      "token_getters",
+		Help:      "Running goroutines that keep the token of a PowerFlex system.",
+	}, []string{"system_id"})
+
+	tenantRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "karavi",
+		Subsystem: "proxy",
+		Name:      "tenant_requests_total",
+		Help:      "Volume creates, deletes and maps of a tenant, and its denied requests.",
+	}, []string{"tenant", "operation"})
+
+	tenantLastActivity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "karavi",
+		Subsystem: "proxy",
+		Name:      "tenant_last_activity_timestamp_seconds",
+		Help:      "Time of the last counted request or token refresh of a tenant.",
+	}, []string{"tenant"})
+
+	tenantTokenRefreshes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "karavi",
+		Subsystem: "proxy",
+		Name:      "tenant_token_refreshes_total",
+		Help:      "Token refreshes of a tenant.",
+	}, []string{"tenant"})
+
+	softQuotaExceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "karavi",
+		Subsystem: "proxy",
+		Name:      "soft_quota_exceeded_total",
+		Help:      "Volume requests approved beyond the soft quota of a tenant in a storage pool.",
+	}, []string{"tenant", "system_type", "system_id", "pool"})
+
+	responseCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "karavi",
+		Subsystem: "proxy",
+		Name:      "response_cache_requests_total",
+		Help:      "Requests of cached read-only endpoints of a storage system, by whether they were served from the cache.",
+	}, []string{"system_id", "result"})
+
+	malformedForwarded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "karavi",
+		Subsystem: "proxy",
+		Name:      "malformed_forwarded_headers_total",
+		Help:      "Requests refused for a malformed Forwarded header, by why it was malformed.",
+	}, []string{"reason"})
+
+	quotaKeyspaceSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "karavi",
+		Subsystem: "proxy",
+		Name:      "quota_keyspace_size",
+		Help:      "Size of the quota data of a tenant in redis, in keys, hash fields or stream entries, as of the last compaction.",
+	}, []string{"tenant", "kind"})
+)
+
+// Collectors returns the metrics of the proxy handlers, for registering
+// with Prometheus.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{activeRequests, tokenGetters, tenantRequests, tenantLastActivity, tenantTokenRefreshes, softQuotaExceeded, responseCacheRequests, malformedForwarded, quotaKeyspaceSize}
+}
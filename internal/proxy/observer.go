@@ -0,0 +1,266 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authenticationclient "k8s.io/client-go/kubernetes/typed/authentication/v1"
+)
+
+// DefaultObserverReviewTTL is how long the reviews of service account tokens
+// are cached.
+const DefaultObserverReviewTTL = time.Minute
+
+// maxObserverReviews is how many token reviews are cached at most.
+const maxObserverReviews = 1024
+
+// ObserverEndpoint allows observers the GET requests to the paths that
+// match one of the regular expressions, on the systems of the system type,
+// or only on the system with the ID if it is set.
+type ObserverEndpoint struct {
+	SystemType string   `json:"systemType"`
+	SystemID   string   `json:"systemID"`
+	Paths      []string `json:"paths"`
+}
+
+// ObserverConfig configures the read-only access of trusted in-cluster
+// services, e.g. the CSM observability modules, which authenticate with the
+// token of a Kubernetes service account instead of a tenant token.
+type ObserverConfig struct {
+	Enabled bool
+	// ServiceAccounts are the observers, as system:serviceaccount:<namespace>:<name>.
+	ServiceAccounts []string
+	// Audiences are the audiences the tokens are reviewed for, or the
+	// audience of the API server if empty.
+	Audiences []string
+	// Endpoints are the storage API requests observers are allowed.
+	Endpoints []ObserverEndpoint
+	// ReviewTTL is how long the token reviews are cached.
+	ReviewTTL time.Duration
+}
+
+type observerEndpoint struct {
+	systemType string
+	systemID   string
+	paths      []*regexp.Regexp
+}
+
+type observerReview struct {
+	user      string
+	expiresAt time.Time
+}
+
+type observers struct {
+	log       *logrus.Entry
+	reviews   authenticationclient.TokenReviewInterface
+	cfg       ObserverConfig
+	endpoints []observerEndpoint
+
+	mu     sync.Mutex
+	cached map[string]observerReview
+}
+
+// ObserverMW authenticates the requests to the storage systems with the
+// tokens of the observer service accounts using the Kubernetes TokenReview
+// API, and lets them through web.AuthMW for the GET requests to their
+// endpoints only. Requests are passed on unchanged when it is disabled, for
+// the REST API, and for the tokens of the proxy and of other service
+// accounts. It returns an error if a path of the endpoints is not a valid
+// regular expression.
+func ObserverMW(log *logrus.Entry, reviews authenticationclient.TokenReviewInterface, tm token.Manager, cfg ObserverConfig) (web.Middleware, error) {
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }, nil
+	}
+	if cfg.ReviewTTL <= 0 {
+		cfg.ReviewTTL = DefaultObserverReviewTTL
+	}
+	o := &observers{
+		log:     log,
+		reviews: reviews,
+		cfg:     cfg,
+		cached:  make(map[string]observerReview),
+	}
+	for _, e := range cfg.Endpoints {
+		ep := observerEndpoint{systemType: web.NormalizePluginID(e.SystemType), systemID: e.SystemID}
+		for _, p := range e.Paths {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("observer endpoint %s of %s: %w", p, e.SystemType, err)
+			}
+			ep.paths = append(ep.paths, re)
+		}
+		o.endpoints = append(o.endpoints, ep)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !web.IsDispatchPath(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			scheme, tkn, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !ok || scheme != "Bearer" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// tokens of the proxy are validated by web.AuthMW
+			var claims token.Claims
+			if _, err := tm.ParseWithClaims(tkn, web.JWTSigningSecret, &claims); err == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !o.reviewable(tkn) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user, err := o.review(r.Context(), tkn)
+			if err != nil {
+				log.WithError(err).Error("reviewing service account token")
+				handleJSONErrorResponse(log, w, http.StatusServiceUnavailable, fmt.Errorf("reviewing service account token: %w", err))
+				return
+			}
+			if !slices.Contains(cfg.ServiceAccounts, user) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			fwd := web.ForwardedHeader(r)
+			systemType := web.NormalizePluginID(fwd["by"])
+			_, systemID := SplitEndpointSystemID(fwd["for"])
+			if !o.allowed(systemType, systemID, r) {
+				log.WithFields(logrus.Fields{
+					"observer": user,
+					"method":   r.Method,
+					"path":     r.URL.Path,
+					"system":   systemID,
+				}).Warn("Denied an observer request")
+				writeError(w, systemType, fmt.Sprintf("observer %s is not allowed %s %s", user, r.Method, r.URL.Path), http.StatusForbidden, log)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), web.ObserverKey, user)))
+		})
+	}, nil
+}
+
+// allowed reports whether observers are allowed the request to the system.
+func (o *observers) allowed(systemType, systemID string, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	for _, e := range o.endpoints {
+		if e.systemType != systemType || (e.systemID != "" && e.systemID != systemID) {
+			continue
+		}
+		for _, p := range e.paths {
+			if p.MatchString(r.URL.Path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reviewable reports whether the token may be of an observer, i.e. is a JWT
+// whose unverified subject is one of the observer service accounts, so that
+// other tokens do not cause token reviews.
+func (o *observers) reviewable(tkn string) bool {
+	parts := strings.Split(tkn, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	var claims struct {
+		Subject string `json:"sub"`
+	}
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return false
+	}
+	return slices.Contains(o.cfg.ServiceAccounts, claims.Subject)
+}
+
+// review returns the user the token authenticates, or an empty string if
+// it does not authenticate one, from the cache or with a TokenReview.
+func (o *observers) review(ctx context.Context, tkn string) (string, error) {
+	sum := sha256.Sum256([]byte(tkn))
+	key := hex.EncodeToString(sum[:])
+	now := time.Now()
+
+	o.mu.Lock()
+	cached, ok := o.cached[key]
+	o.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.user, nil
+	}
+
+	resp, err := o.reviews.Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     tkn,
+			Audiences: o.cfg.Audiences,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	var user string
+	if resp.Status.Authenticated {
+		user = resp.Status.User.Username
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for k, v := range o.cached {
+		if now.After(v.expiresAt) {
+			delete(o.cached, k)
+		}
+	}
+	// make room by dropping any review
+	for k := range o.cached {
+		if len(o.cached) < maxObserverReviews {
+			break
+		}
+		delete(o.cached, k)
+	}
+	o.cached[key] = observerReview{user: user, expiresAt: now.Add(o.cfg.ReviewTTL)}
+	return user, nil
+}
+
+// isObserver reports whether the request is from an observer.
+func isObserver(r *http.Request) bool {
+	observer, _ := r.Context().Value(web.ObserverKey).(string)
+	return observer != ""
+}
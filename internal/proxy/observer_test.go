@@ -0,0 +1,189 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/token/jwx"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestObserverMW(t *testing.T) {
+	tm := jwx.NewTokenManager(jwx.HS256)
+	observer := "system:serviceaccount:karavi:karavi-observability"
+	observerToken := serviceAccountToken(observer, "1")
+	// forgedToken claims to be of the observer but is not authenticated
+	forgedToken := serviceAccountToken(observer, "2")
+	otherToken := serviceAccountToken("system:serviceaccount:default:default", "3")
+
+	client := fake.NewSimpleClientset()
+	var reviews int
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		reviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		switch review.Spec.Token {
+		case observerToken:
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: observer}}
+		case otherToken:
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "system:serviceaccount:default:default"}}
+		}
+		return true, review, nil
+	})
+
+	mw, err := ObserverMW(logrus.NewEntry(logrus.New()), client.AuthenticationV1().TokenReviews(), tm, ObserverConfig{
+		Enabled:         true,
+		ServiceAccounts: []string{observer},
+		Endpoints: []ObserverEndpoint{
+			{SystemType: "powerflex", Paths: []string{`^/api/types/[A-Za-z]+/instances/$`}},
+			{SystemType: "powerflex", SystemID: "542a2d5f5122210f", Paths: []string{`^/api/instances/Volume::[a-f0-9]+/relationships/Statistics/$`}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// serve returns the status of the response and the observer that
+	// reaches the next handler.
+	serve := func(method, path, system, tkn string) (int, string) {
+		var got string
+		next := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			got, _ = r.Context().Value(web.ObserverKey).(string)
+		})
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("Authorization", "Bearer "+tkn)
+		r.Header.Set("Forwarded", "by=csm-authorization;csi-vxflexos")
+		r.Header.Add("Forwarded", "for=csm-authorization;https://10.0.0.1;"+system)
+		mw(next).ServeHTTP(w, r)
+		return w.Code, got
+	}
+
+	t.Run("it lets observers through for their endpoints", func(t *testing.T) {
+		code, got := serve(http.MethodGet, "/api/types/Volume/instances/", "7045c4cc20dffc0f", observerToken)
+		if code != http.StatusOK || got != observer {
+			t.Errorf("got %d for %q, want %d for %q", code, got, http.StatusOK, observer)
+		}
+		code, got = serve(http.MethodGet, "/api/instances/Volume::1a2b/relationships/Statistics/", "542a2d5f5122210f", observerToken)
+		if code != http.StatusOK || got != observer {
+			t.Errorf("got %d for %q, want %d for %q", code, got, http.StatusOK, observer)
+		}
+	})
+
+	t.Run("it denies observers other requests", func(t *testing.T) {
+		for _, tc := range []struct{ method, path, system string }{
+			{http.MethodPost, "/api/types/Volume/instances/", "542a2d5f5122210f"},
+			{http.MethodGet, "/api/instances/Volume::1a2b/", "542a2d5f5122210f"},
+			{http.MethodGet, "/api/instances/Volume::1a2b/relationships/Statistics/", "7045c4cc20dffc0f"},
+		} {
+			if code, got := serve(tc.method, tc.path, tc.system, observerToken); code != http.StatusForbidden || got != "" {
+				t.Errorf("%s %s on %s: got %d for %q, want %d", tc.method, tc.path, tc.system, code, got, http.StatusForbidden)
+			}
+		}
+	})
+
+	t.Run("it passes other tokens on unchanged", func(t *testing.T) {
+		pair, err := token.Create(tm, token.Config{
+			Tenant:            "tenant-a",
+			Roles:             []string{"role"},
+			JWTSigningSecret:  web.JWTSigningSecret,
+			AccessExpiration:  time.Minute,
+			RefreshExpiration: time.Hour,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := reviews
+		for _, tkn := range []string{pair.Access, otherToken, forgedToken, "unknown-token"} {
+			if code, got := serve(http.MethodPost, "/api/types/Volume/instances/", "542a2d5f5122210f", tkn); code != http.StatusOK || got != "" {
+				t.Errorf("got %d for %q, want %d without an observer", code, got, http.StatusOK)
+			}
+		}
+		if got := reviews - before; got != 1 {
+			t.Errorf("got %d token reviews, want 1 for the token of the observer", got)
+		}
+	})
+
+	t.Run("it ignores the REST API", func(t *testing.T) {
+		before := reviews
+		for _, path := range []string{"/proxy/tenant/", "/api/v1/storage/", "/healthz"} {
+			if code, got := serve(http.MethodGet, path, "542a2d5f5122210f", serviceAccountToken(observer, path)); code != http.StatusOK || got != "" {
+				t.Errorf("%s: got %d for %q, want %d without an observer", path, code, got, http.StatusOK)
+			}
+		}
+		if reviews != before {
+			t.Errorf("got %d token reviews, want none", reviews-before)
+		}
+	})
+
+	t.Run("it caches the reviews", func(t *testing.T) {
+		before := reviews
+		serve(http.MethodGet, "/api/types/Volume/instances/", "542a2d5f5122210f", observerToken)
+		if reviews != before {
+			t.Errorf("got %d token reviews, want the cached review", reviews-before)
+		}
+	})
+}
+
+func TestObservers_Review(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, action.(k8stesting.CreateAction).GetObject(), nil
+	})
+	o := &observers{
+		reviews: client.AuthenticationV1().TokenReviews(),
+		cfg:     ObserverConfig{ReviewTTL: time.Minute},
+		cached:  make(map[string]observerReview),
+	}
+
+	for i := 0; i < maxObserverReviews+10; i++ {
+		if _, err := o.review(context.Background(), fmt.Sprintf("token-%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := len(o.cached); got > maxObserverReviews {
+		t.Errorf("got %d cached reviews, want at most %d", got, maxObserverReviews)
+	}
+}
+
+// serviceAccountToken returns an unsigned JWT of the service account.
+func serviceAccountToken(sub, id string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"RS256"}`)) + "." +
+		enc.EncodeToString([]byte(fmt.Sprintf(`{"sub":%q,"jti":%q}`, sub, id))) + ".signature"
+}
+
+func TestObserverMW_InvalidEndpoint(t *testing.T) {
+	_, err := ObserverMW(logrus.NewEntry(logrus.New()), nil, jwx.NewTokenManager(jwx.HS256), ObserverConfig{
+		Enabled:   true,
+		Endpoints: []ObserverEndpoint{{SystemType: "powerflex", Paths: []string{"("}}},
+	})
+	if err == nil {
+		t.Error("got nil error, want an error for the invalid path")
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/web"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+)
+
+// StreamAuthorizer decides, from the claims of the initial headers of a
+// stream, whether a tenant may reach a storage system.
+type StreamAuthorizer func(ctx context.Context, claims token.Claims, systemType, systemID string) error
+
+// PassthroughHandler proxies HTTP/2 requests, such as gRPC calls, to the
+// storage systems without downgrading them to HTTP/1.1. The request bodies
+// are not inspected, so each stream is authorized on its initial headers
+// before it is forwarded.
+type PassthroughHandler struct {
+	log       *logrus.Entry
+	authorize StreamAuthorizer
+	mu        sync.Mutex // guards systems map
+	systems   map[string]map[string]*httputil.ReverseProxy
+}
+
+// NewPassthroughHandler returns a new PassthroughHandler.
+func NewPassthroughHandler(log *logrus.Entry, authorize StreamAuthorizer) *PassthroughHandler {
+	return &PassthroughHandler{
+		log:       log,
+		authorize: authorize,
+		systems:   make(map[string]map[string]*httputil.ReverseProxy),
+	}
+}
+
+// IsPassthrough returns true if the request must be passed through over
+// HTTP/2, i.e. it is a gRPC request.
+func IsPassthrough(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// UpdateSystems updates the PassthroughHandler via a SystemConfig
+func (h *PassthroughHandler) UpdateSystems(_ context.Context, r io.Reader, log *logrus.Entry) error {
+	var updated SystemConfig
+	if err := json.NewDecoder(r).Decode(&updated); err != nil {
+		return err
+	}
+
+	systems := make(map[string]map[string]*httputil.ReverseProxy)
+	for systemType, family := range updated {
+		systems[systemType] = make(map[string]*httputil.ReverseProxy)
+		for id, e := range family {
+			rp, err := buildPassthroughProxy(e)
+			if err != nil {
+				log.WithError(err).WithField("system_id", id).Error("building passthrough proxy")
+				continue
+			}
+			systems[systemType][id] = rp
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.systems = systems
+	return nil
+}
+
+// buildPassthroughProxy returns a reverse proxy to the endpoint of a system
+// that speaks HTTP/2 over TLS for https endpoints, and h2c otherwise.
+func buildPassthroughProxy(e SystemEntry) (*httputil.ReverseProxy, error) {
+	tgt, err := url.Parse(e.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := e.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	t := &http2.Transport{TLSClientConfig: cfg}
+	if tgt.Scheme == "http" {
+		t.AllowHTTP = true
+		t.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		}
+	}
+
+	rp := httputil.NewSingleHostReverseProxy(tgt)
+	rp.Transport = t
+	// Flush each message of a stream as soon as it is received.
+	rp.FlushInterval = -1
+	return rp, nil
+}
+
+func (h *PassthroughHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fwd := web.ForwardedHeader(r)
+	systemType := web.NormalizePluginID(fwd["by"])
+	_, systemID := SplitEndpointSystemID(fwd["for"])
+	h.log.WithFields(logrus.Fields{
+		"system_type": systemType,
+		"system_id":   systemID,
+		"path":        r.URL.Path,
+	}).Debug("Serving passthrough request")
+
+	if r.ProtoMajor != 2 {
+		writeError(w, systemType, "passthrough requires HTTP/2", http.StatusHTTPVersionNotSupported, h.log)
+		return
+	}
+
+	h.mu.Lock()
+	rp, ok := h.systems[systemType][systemID]
+	h.mu.Unlock()
+	if !ok {
+		writeError(w, systemType, "system id not found", http.StatusBadGateway, h.log)
+		return
+	}
+
+	claims, err := passthroughClaims(r)
+	if err != nil {
+		writeError(w, systemType, err.Error(), http.StatusUnauthorized, h.log)
+		return
+	}
+	if err := h.authorize(r.Context(), claims, systemType, systemID); err != nil {
+		writeError(w, systemType, fmt.Sprintf("stream denied: %v", err), http.StatusForbidden, h.log)
+		return
+	}
+
+	// Strip the headers meant for the proxy.
+	r.Header.Del("Authorization")
+	r.Header.Del("Cookie")
+	r.Header.Del("Forwarded")
+	r.Header.Del("X-Forwarded-For")
+	r.Header.Del("X-Forwarded-Host")
+	r.Header.Del("X-Forwarded-Proto")
+
+	rp.ServeHTTP(w, r)
+}
+
+// passthroughClaims returns the claims of the token validated by the
+// authentication middleware.
+func passthroughClaims(r *http.Request) (token.Claims, error) {
+	jwtToken, ok := r.Context().Value(web.JWTKey).(token.Token)
+	if !ok {
+		return token.Claims{}, errors.New("missing token")
+	}
+	claims, err := jwtToken.Claims()
+	if err != nil {
+		return token.Claims{}, fmt.Errorf("decoding token claims: %w", err)
+	}
+	if claims.Group == "" {
+		return token.Claims{}, errors.New("token has no tenant")
+	}
+	return claims, nil
+}
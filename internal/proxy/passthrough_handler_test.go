@@ -0,0 +1,160 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_test
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/token/jwx"
+	"karavi-authorization/internal/web"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestPassthroughHandler(t *testing.T) {
+	log := logrus.New().WithContext(context.Background())
+
+	// The array serves gRPC over h2c and answers with a trailer.
+	var gotProto int
+	var gotAuthz string
+	array := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotProto = r.ProtoMajor
+		gotAuthz = r.Header.Get("Authorization")
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		_, _ = w.Write(b)
+		w.Header().Set("Grpc-Status", "0")
+	}), &http2.Server{}))
+	defer array.Close()
+
+	tm := jwx.NewTokenManager(jwx.HS256)
+	tkn, err := tm.NewWithClaims(token.Claims{
+		Issuer:    "com.dell.karavi",
+		ExpiresAt: time.Now().Add(30 * time.Second).Unix(),
+		Audience:  "karavi",
+		Subject:   "Alice",
+		Roles:     "DevTesting",
+		Group:     "TestingGroup",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newProxy := func(t *testing.T, authorize proxy.StreamAuthorizer) *httptest.Server {
+		t.Helper()
+		h := proxy.NewPassthroughHandler(log, authorize)
+		cfg := fmt.Sprintf(`{"powerflex":{"542a2d5f5122210f":{"endpoint":%q}}}`, array.URL)
+		if err := h.UpdateSystems(context.Background(), strings.NewReader(cfg), log); err != nil {
+			t.Fatal(err)
+		}
+		dh := proxy.NewDispatchHandler(log, map[string]http.Handler{
+			"powerflex": http.NotFoundHandler(),
+		}, proxy.WithPassthrough(h))
+		// Stand in for the authentication middleware.
+		authn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			dh.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), web.JWTKey, tkn)))
+		})
+		s := httptest.NewServer(h2c.NewHandler(authn, &http2.Server{}))
+		t.Cleanup(s.Close)
+		return s
+	}
+
+	call := func(t *testing.T, url string) *http.Response {
+		t.Helper()
+		client := &http.Client{Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		}}
+		req, err := http.NewRequest(http.MethodPost, url+"/dell.array.v1.Volumes/List", strings.NewReader("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/grpc")
+		req.Header.Set("Authorization", "Bearer token")
+		req.Header.Add("Forwarded", "for=csm-authorization;https://10.0.0.1;542a2d5f5122210f")
+		req.Header.Add("Forwarded", "by=csm-authorization;csi-vxflexos")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("it passes authorized streams through over HTTP/2", func(t *testing.T) {
+		var gotSystem string
+		s := newProxy(t, func(_ context.Context, claims token.Claims, systemType, systemID string) error {
+			gotSystem = fmt.Sprintf("%s:%s:%s", claims.Group, systemType, systemID)
+			return nil
+		})
+
+		resp := call(t, s.URL)
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("got status %d, want %d: %s", resp.StatusCode, http.StatusOK, b)
+		}
+		if string(b) != "hello" {
+			t.Errorf("got body %q, want %q", b, "hello")
+		}
+		if got := resp.Trailer.Get("Grpc-Status"); got != "0" {
+			t.Errorf("got grpc-status trailer %q, want %q", got, "0")
+		}
+		if gotProto != 2 {
+			t.Errorf("got array request protocol HTTP/%d, want HTTP/2", gotProto)
+		}
+		if gotAuthz != "" {
+			t.Errorf("expected the authorization header to be stripped, got %q", gotAuthz)
+		}
+		if want := "TestingGroup:powerflex:542a2d5f5122210f"; gotSystem != want {
+			t.Errorf("got authorized %q, want %q", gotSystem, want)
+		}
+	})
+
+	t.Run("it denies unauthorized streams", func(t *testing.T) {
+		s := newProxy(t, func(_ context.Context, _ token.Claims, _, _ string) error {
+			return errors.New("no role")
+		})
+
+		resp := call(t, s.URL)
+
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusForbidden)
+		}
+	})
+}
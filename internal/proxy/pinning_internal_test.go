@@ -0,0 +1,130 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCertificatePinning(t *testing.T) {
+	array := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Host+r.URL.Path)
+	}))
+	defer array.Close()
+	sum := sha256.Sum256(array.Certificate().Raw)
+	fingerprint := strings.ToUpper(hex.EncodeToString(sum[:]))
+	var colons []string
+	for i := 0; i < len(fingerprint); i += 2 {
+		colons = append(colons, fingerprint[i:i+2])
+	}
+	other := strings.Repeat("ab", sha256.Size)
+
+	get := func(t *testing.T, e SystemEntry, target string) (string, error) {
+		t.Helper()
+		rt, err := e.transport()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := (&http.Client{Transport: rt}).Get(target)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		return string(b), err
+	}
+
+	t.Run("it connects to a system presenting the pinned certificate", func(t *testing.T) {
+		for _, fp := range []string{fingerprint, strings.Join(colons, ":")} {
+			if _, err := get(t, SystemEntry{CertificateFingerprint: fp}, array.URL); err != nil {
+				t.Errorf("pin %s: %v", fp, err)
+			}
+		}
+	})
+
+	t.Run("it refuses a system presenting another certificate", func(t *testing.T) {
+		_, err := get(t, SystemEntry{CertificateFingerprint: other}, array.URL)
+		if !errors.Is(err, ErrCertificateMismatch) {
+			t.Errorf("got %v, want %v", err, ErrCertificateMismatch)
+		}
+	})
+
+	t.Run("it uses the default transport without a pin", func(t *testing.T) {
+		rt, err := SystemEntry{}.transport()
+		if err != nil || rt != nil {
+			t.Errorf("got %v, %v, want the default transport", rt, err)
+		}
+	})
+
+	t.Run("it rejects an invalid fingerprint", func(t *testing.T) {
+		for _, fp := range []string{"not-hex", "ab:cd"} {
+			if _, err := (SystemEntry{CertificateFingerprint: fp}).tlsConfig(); err == nil {
+				t.Errorf("pin %q: expected an error", fp)
+			}
+		}
+	})
+
+	t.Run("it pins the passthrough proxy", func(t *testing.T) {
+		_, err := buildPassthroughProxy(SystemEntry{Endpoint: array.URL, CertificateFingerprint: "zz"})
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("it forwards the clients of a pinned system", func(t *testing.T) {
+		tgt, err := url.Parse(array.URL + "/api")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tc := range []struct {
+			pin     string
+			wantErr bool
+		}{{fingerprint, false}, {other, true}} {
+			rt, err := SystemEntry{CertificateFingerprint: tc.pin}.transport()
+			if err != nil {
+				t.Fatal(err)
+			}
+			fwd, err := newPinnedForwarder(tgt, rt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.Get(fwd.url + "/api/version")
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			fwd.Close()
+
+			if tc.wantErr {
+				if resp.StatusCode != http.StatusBadGateway {
+					t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusBadGateway)
+				}
+				continue
+			}
+			if want := tgt.Host + "/api/version"; string(b) != want {
+				t.Errorf("got %q, want %q", b, want)
+			}
+		}
+	})
+}
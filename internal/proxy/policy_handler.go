@@ -0,0 +1,445 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/decision"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Kinds of policy documents managed by the PolicyHandler.
+const (
+	PolicyKindModule = "module"
+	PolicyKindData   = "data"
+)
+
+const policyIndexKey = "policy:index"
+
+// PolicyHandler is the proxy handler for karavictl policy requests. Rego
+// modules and data documents are pushed to OPA and every pushed version is
+// kept in redis so that a previous version can be restored.
+type PolicyHandler struct {
+	mux     *http.ServeMux
+	mu      sync.RWMutex // guards rdb
+	rdb     *redis.Client
+	opaHost hostAddr
+	log     *logrus.Entry
+}
+
+// NewPolicyHandler returns a PolicyHandler
+func NewPolicyHandler(log *logrus.Entry, rdb *redis.Client, opaHost string) *PolicyHandler {
+	ph := &PolicyHandler{
+		rdb: rdb,
+		log: log,
+	}
+	ph.opaHost.Set(opaHost)
+
+	mux := http.NewServeMux()
+	mux.Handle(web.ProxyPolicyPath, web.Adapt(web.HandlerWithError(ph.policyHandler), web.TelemetryMW("policyHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyPolicyPath, "rollback"), web.Adapt(web.HandlerWithError(ph.rollbackHandler), web.TelemetryMW("policyHandler", log)))
+	ph.mux = mux
+
+	return ph
+}
+
+// SetOPAHost changes the OPA host that policies are pushed to.
+func (ph *PolicyHandler) SetOPAHost(opaHost string) {
+	ph.opaHost.Set(opaHost)
+}
+
+// SetRedis changes the redis client that policy versions are kept in.
+func (ph *PolicyHandler) SetRedis(rdb *redis.Client) {
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
+	ph.rdb = rdb
+}
+
+func (ph *PolicyHandler) redisClient() *redis.Client {
+	ph.mu.RLock()
+	defer ph.mu.RUnlock()
+	return ph.rdb
+}
+
+// ServeHTTP implements the http.Handler interface
+func (ph *PolicyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The policies decide for every tenant, so only admins not scoped to
+	// an organization may manage them.
+	if admin, _ := r.Context().Value(web.JWTAdminName).(string); admin == "" || adminOrganization(r) != "" {
+		handleJSONErrorResponse(ph.log, w, http.StatusForbidden, errors.New("admin token required that is not scoped to an organization"))
+		return
+	}
+	ph.mux.ServeHTTP(w, r)
+}
+
+func (ph *PolicyHandler) policyHandler(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodPost:
+		return ph.pushHandler(w, r)
+	case http.MethodGet:
+		return ph.listHandler(w, r)
+	case http.MethodDelete:
+		return ph.deleteHandler(w, r)
+	default:
+		return handleMethodNotAllowed(ph.log, w, r)
+	}
+}
+
+// PolicyBody is the request body for pushing a rego module or data document.
+// For data documents, ID is the data path, e.g. "karavi/common".
+type PolicyBody struct {
+	Kind    string `json:"kind"`
+	ID      string `json:"id"`
+	Content string `json:"content,omitempty"`
+}
+
+// PolicyRollbackBody is the request body for restoring a previous version.
+// A zero Version restores the version before the current one.
+type PolicyRollbackBody struct {
+	Kind    string `json:"kind"`
+	ID      string `json:"id"`
+	Version int    `json:"version,omitempty"`
+}
+
+// PolicyInfo describes a managed policy document.
+type PolicyInfo struct {
+	Kind     string `json:"kind"`
+	ID       string `json:"id"`
+	Version  int    `json:"version"`
+	Versions int    `json:"versions"`
+	Loaded   bool   `json:"loaded"`
+}
+
+func (ph *PolicyHandler) pushHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	var body PolicyBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(ph.log, w, http.StatusBadRequest, err)
+		return err
+	}
+	if err := validatePolicyBody(body.Kind, body.ID); err != nil {
+		handleJSONErrorResponse(ph.log, w, http.StatusBadRequest, err)
+		return err
+	}
+	if body.Kind == PolicyKindData && !json.Valid([]byte(body.Content)) {
+		err = fmt.Errorf("data document %s is not valid json", body.ID)
+		handleJSONErrorResponse(ph.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	setAttributes(span, map[string]interface{}{
+		"kind": body.Kind,
+		"id":   body.ID,
+	})
+	ph.log.WithFields(logrus.Fields{
+		"kind": body.Kind,
+		"id":   body.ID,
+	}).Info("Requesting policy push")
+
+	err = ph.put(ctx, body.Kind, body.ID, []byte(body.Content))
+	if err != nil {
+		err = fmt.Errorf("pushing %s %s: %w", body.Kind, body.ID, err)
+		handleJSONErrorResponse(ph.log, w, opaErrorStatus(err), err)
+		return err
+	}
+
+	version, err := ph.recordVersion(body.Kind, body.ID, body.Content)
+	if err != nil {
+		err = fmt.Errorf("recording %s %s version: %w", body.Kind, body.ID, err)
+		handleJSONErrorResponse(ph.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(&PolicyInfo{Kind: body.Kind, ID: body.ID, Version: version, Versions: version, Loaded: true})
+	if err != nil {
+		ph.log.WithError(err).Error("writing policy push response")
+	}
+	return nil
+}
+
+func (ph *PolicyHandler) listHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	ph.log.Info("Requesting policy list")
+
+	loaded, err := decision.ListPolicies(ctx, ph.opaHost.Get())
+	if err != nil {
+		err = fmt.Errorf("listing policies: %w", err)
+		handleJSONErrorResponse(ph.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	infos := make(map[string]*PolicyInfo)
+	for _, p := range loaded {
+		infos[policyKey(PolicyKindModule, p.ID)] = &PolicyInfo{Kind: PolicyKindModule, ID: p.ID, Loaded: true}
+	}
+
+	managed, err := ph.redisClient().SMembers(policyIndexKey).Result()
+	if err != nil {
+		err = fmt.Errorf("listing policy versions: %w", err)
+		handleJSONErrorResponse(ph.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	for _, key := range managed {
+		kind, id, ok := strings.Cut(strings.TrimPrefix(key, "policy:"), ":")
+		if !ok {
+			continue
+		}
+		current, latest, err := ph.versions(kind, id)
+		if err != nil {
+			err = fmt.Errorf("getting %s %s versions: %w", kind, id, err)
+			handleJSONErrorResponse(ph.log, w, http.StatusInternalServerError, err)
+			return err
+		}
+		info, ok := infos[key]
+		if !ok {
+			info = &PolicyInfo{Kind: kind, ID: id, Loaded: kind == PolicyKindData}
+			infos[key] = info
+		}
+		info.Version = current
+		info.Versions = latest
+	}
+
+	list := make([]PolicyInfo, 0, len(infos))
+	for _, v := range infos {
+		list = append(list, *v)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Kind != list[j].Kind {
+			return list[i].Kind > list[j].Kind
+		}
+		return list[i].ID < list[j].ID
+	})
+
+	err = json.NewEncoder(w).Encode(&list)
+	if err != nil {
+		err = fmt.Errorf("writing policy list response: %w", err)
+		handleJSONErrorResponse(ph.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return nil
+}
+
+func (ph *PolicyHandler) deleteHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	var body PolicyBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(ph.log, w, http.StatusBadRequest, err)
+		return err
+	}
+	if err := validatePolicyBody(body.Kind, body.ID); err != nil {
+		handleJSONErrorResponse(ph.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	setAttributes(span, map[string]interface{}{
+		"kind": body.Kind,
+		"id":   body.ID,
+	})
+	ph.log.WithFields(logrus.Fields{
+		"kind": body.Kind,
+		"id":   body.ID,
+	}).Info("Requesting policy deletion")
+
+	if body.Kind == PolicyKindModule {
+		err = decision.DeletePolicy(ctx, ph.opaHost.Get(), body.ID)
+	} else {
+		err = decision.DeleteData(ctx, ph.opaHost.Get(), body.ID)
+	}
+	if err != nil {
+		err = fmt.Errorf("deleting %s %s: %w", body.Kind, body.ID, err)
+		handleJSONErrorResponse(ph.log, w, opaErrorStatus(err), err)
+		return err
+	}
+
+	key := policyKey(body.Kind, body.ID)
+	_, err = ph.redisClient().TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(key)
+		pipe.SRem(policyIndexKey, key)
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("deleting %s %s versions: %w", body.Kind, body.ID, err)
+		handleJSONErrorResponse(ph.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (ph *PolicyHandler) rollbackHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return nil
+	}
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	var body PolicyRollbackBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(ph.log, w, http.StatusBadRequest, err)
+		return err
+	}
+	if err := validatePolicyBody(body.Kind, body.ID); err != nil {
+		handleJSONErrorResponse(ph.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	current, latest, err := ph.versions(body.Kind, body.ID)
+	if err != nil {
+		err = fmt.Errorf("getting %s %s versions: %w", body.Kind, body.ID, err)
+		handleJSONErrorResponse(ph.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	version := body.Version
+	if version == 0 {
+		version = current - 1
+	}
+	if version < 1 || version > latest {
+		err = fmt.Errorf("%s %s has no version %d", body.Kind, body.ID, version)
+		handleJSONErrorResponse(ph.log, w, http.StatusNotFound, err)
+		return err
+	}
+
+	setAttributes(span, map[string]interface{}{
+		"kind":    body.Kind,
+		"id":      body.ID,
+		"version": version,
+	})
+	ph.log.WithFields(logrus.Fields{
+		"kind":    body.Kind,
+		"id":      body.ID,
+		"version": version,
+	}).Info("Requesting policy rollback")
+
+	key := policyKey(body.Kind, body.ID)
+	content, err := ph.redisClient().HGet(key, versionField(version)).Result()
+	if err != nil {
+		err = fmt.Errorf("getting %s %s version %d: %w", body.Kind, body.ID, version, err)
+		handleJSONErrorResponse(ph.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+
+	err = ph.put(ctx, body.Kind, body.ID, []byte(content))
+	if err != nil {
+		err = fmt.Errorf("restoring %s %s version %d: %w", body.Kind, body.ID, version, err)
+		handleJSONErrorResponse(ph.log, w, opaErrorStatus(err), err)
+		return err
+	}
+
+	err = ph.redisClient().HSet(key, "current", version).Err()
+	if err != nil {
+		err = fmt.Errorf("recording %s %s version: %w", body.Kind, body.ID, err)
+		handleJSONErrorResponse(ph.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+
+	err = json.NewEncoder(w).Encode(&PolicyInfo{Kind: body.Kind, ID: body.ID, Version: version, Versions: latest, Loaded: true})
+	if err != nil {
+		ph.log.WithError(err).Error("writing policy rollback response")
+	}
+	return nil
+}
+
+func (ph *PolicyHandler) put(ctx context.Context, kind, id string, content []byte) error {
+	if kind == PolicyKindModule {
+		return decision.PutPolicy(ctx, ph.opaHost.Get(), id, content)
+	}
+	return decision.PutData(ctx, ph.opaHost.Get(), id, content)
+}
+
+// recordVersion stores content as the next version of the document and
+// marks it as current.
+func (ph *PolicyHandler) recordVersion(kind, id, content string) (int, error) {
+	key := policyKey(kind, id)
+	latest, err := ph.redisClient().HIncrBy(key, "latest", 1).Result()
+	if err != nil {
+		return 0, err
+	}
+	_, err = ph.redisClient().TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(key, versionField(int(latest)), content)
+		pipe.HSet(key, "current", latest)
+		pipe.SAdd(policyIndexKey, key)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(latest), nil
+}
+
+func (ph *PolicyHandler) versions(kind, id string) (int, int, error) {
+	res, err := ph.redisClient().HMGet(policyKey(kind, id), "current", "latest").Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	var v [2]int
+	for i := range res {
+		s, ok := res[i].(string)
+		if !ok {
+			continue
+		}
+		v[i], err = strconv.Atoi(s)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return v[0], v[1], nil
+}
+
+func validatePolicyBody(kind, id string) error {
+	if kind != PolicyKindModule && kind != PolicyKindData {
+		return fmt.Errorf("unsupported policy kind %q", kind)
+	}
+	if id == "" {
+		return errors.New("policy id is required")
+	}
+	return nil
+}
+
+func opaErrorStatus(err error) int {
+	var opaErr *decision.Error
+	if errors.As(err, &opaErr) && opaErr.StatusCode >= 400 && opaErr.StatusCode < 500 {
+		return opaErr.StatusCode
+	}
+	return http.StatusInternalServerError
+}
+
+func policyKey(kind, id string) string {
+	return fmt.Sprintf("policy:%s:%s", kind, id)
+}
+
+func versionField(v int) string {
+	return fmt.Sprintf("v%d", v)
+}
@@ -0,0 +1,249 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+// fakePolicyOPA is a minimal in-memory OPA policy and data API.
+type fakePolicyOPA struct {
+	sync.Mutex
+	modules map[string]string
+	data    map[string]string
+}
+
+func (f *fakePolicyOPA) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	b, _ := io.ReadAll(r.Body)
+	switch {
+	case r.URL.Path == "/v1/policies" && r.Method == http.MethodGet:
+		var result []map[string]string
+		for id, raw := range f.modules {
+			result = append(result, map[string]string{"id": id, "raw": raw})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	case strings.HasPrefix(r.URL.Path, "/v1/policies/"):
+		id := strings.TrimPrefix(r.URL.Path, "/v1/policies/")
+		switch r.Method {
+		case http.MethodPut:
+			if !strings.HasPrefix(string(b), "package ") {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code": "invalid_parameter", "message": "error(s) occurred while compiling module(s)", "errors": [{"code": "rego_parse_error", "message": "package expected", "location": {"file": "` + id + `", "row": 1, "col": 1}}]}`))
+				return
+			}
+			f.modules[id] = string(b)
+		case http.MethodDelete:
+			if _, ok := f.modules[id]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"code": "resource_not_found", "message": "storage_not_found_error: policy id \"` + id + `\""}`))
+				return
+			}
+			delete(f.modules, id)
+		}
+	case strings.HasPrefix(r.URL.Path, "/v1/data/"):
+		path := strings.TrimPrefix(r.URL.Path, "/v1/data/")
+		switch r.Method {
+		case http.MethodPut:
+			f.data[path] = string(b)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			delete(f.data, path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+func TestPolicyHandler(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	opa := &fakePolicyOPA{modules: make(map[string]string), data: make(map[string]string)}
+	ts := httptest.NewServer(opa)
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sut := NewPolicyHandler(logrus.NewEntry(logrus.New()), rdb, u.Host)
+	// doAs serves the request with a token of the given claims.
+	doAs := func(t *testing.T, claims map[interface{}]string, method, path string, body interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+		var payload []byte
+		if body != nil {
+			payload, err = json.Marshal(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		r := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		ctx := r.Context()
+		for k, v := range claims {
+			ctx = context.WithValue(ctx, k, v)
+		}
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, r.WithContext(ctx))
+		return w
+	}
+	do := func(t *testing.T, method, path string, body interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+		return doAs(t, map[interface{}]string{web.JWTAdminName: "admin"}, method, path, body)
+	}
+
+	t.Run("it requires an admin of every organization", func(t *testing.T) {
+		for name, claims := range map[string]map[interface{}]string{
+			"tenant":             {web.JWTTenantName: "mytenant"},
+			"organization admin": {web.JWTAdminName: "admin", web.JWTOrganization: "finance"},
+		} {
+			for _, req := range []struct{ method, path string }{
+				{http.MethodPost, "/proxy/policies/"},
+				{http.MethodGet, "/proxy/policies/"},
+				{http.MethodDelete, "/proxy/policies/"},
+				{http.MethodPost, "/proxy/policies/rollback/"},
+			} {
+				w := doAs(t, claims, req.method, req.path, PolicyBody{Kind: PolicyKindModule, ID: "a", Content: "package karavi.a\n"})
+				if w.Code != http.StatusForbidden {
+					t.Errorf("%s: %s %s: got status %d, want %d", name, req.method, req.path, w.Code, http.StatusForbidden)
+				}
+			}
+		}
+		if _, ok := opa.modules["a"]; ok {
+			t.Error("got module a pushed, want none")
+		}
+	})
+
+	t.Run("it pushes a module and records a new version", func(t *testing.T) {
+		for _, content := range []string{"package karavi.a\n", "package karavi.a\ndefault allow = false\n"} {
+			w := do(t, http.MethodPost, "/proxy/policies/", PolicyBody{Kind: PolicyKindModule, ID: "a", Content: content})
+			if w.Code != http.StatusCreated {
+				t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+			}
+		}
+
+		if got := opa.modules["a"]; !strings.Contains(got, "default allow") {
+			t.Errorf("got module %q, want latest version", got)
+		}
+		if got := mr.HGet("policy:module:a", "latest"); got != "2" {
+			t.Errorf("got latest version %s, want 2", got)
+		}
+	})
+
+	t.Run("it rejects a module that does not compile", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/proxy/policies/", PolicyBody{Kind: PolicyKindModule, ID: "a", Content: "not rego"})
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusBadRequest)
+		}
+		if !strings.Contains(w.Body.String(), "package expected") {
+			t.Errorf("expected compile error in response, got %s", w.Body.String())
+		}
+		if got := mr.HGet("policy:module:a", "latest"); got != "2" {
+			t.Errorf("got latest version %s, want 2", got)
+		}
+	})
+
+	t.Run("it rejects a data document that is not json", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/proxy/policies/", PolicyBody{Kind: PolicyKindData, ID: "karavi/common", Content: "{"})
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("it rolls back to the previous version", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/proxy/policies/rollback/", PolicyRollbackBody{Kind: PolicyKindModule, ID: "a"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+
+		if got := opa.modules["a"]; got != "package karavi.a\n" {
+			t.Errorf("got module %q, want first version", got)
+		}
+		if got := mr.HGet("policy:module:a", "current"); got != "1" {
+			t.Errorf("got current version %s, want 1", got)
+		}
+
+		w = do(t, http.MethodPost, "/proxy/policies/rollback/", PolicyRollbackBody{Kind: PolicyKindModule, ID: "a"})
+		if w.Code != http.StatusNotFound {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("it lists modules and data documents", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/proxy/policies/", PolicyBody{Kind: PolicyKindData, ID: "karavi/common", Content: `{"roles": {}}`})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("got status %d, want %d", w.Code, http.StatusCreated)
+		}
+		opa.modules["unmanaged"] = "package karavi.b\n"
+
+		w = do(t, http.MethodGet, "/proxy/policies/", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+		}
+		var got []PolicyInfo
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		want := []PolicyInfo{
+			{Kind: PolicyKindModule, ID: "a", Version: 1, Versions: 2, Loaded: true},
+			{Kind: PolicyKindModule, ID: "unmanaged", Loaded: true},
+			{Kind: PolicyKindData, ID: "karavi/common", Version: 1, Versions: 1, Loaded: true},
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("got %+v, want %+v", got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("it deletes a module and its versions", func(t *testing.T) {
+		w := do(t, http.MethodDelete, "/proxy/policies/", PolicyBody{Kind: PolicyKindModule, ID: "a"})
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("got status %d, want %d", w.Code, http.StatusNoContent)
+		}
+		if _, ok := opa.modules["a"]; ok {
+			t.Errorf("expected module to be deleted from OPA")
+		}
+		if mr.Exists("policy:module:a") {
+			t.Errorf("expected module versions to be deleted")
+		}
+
+		w = do(t, http.MethodDelete, "/proxy/policies/", PolicyBody{Kind: PolicyKindModule, ID: "a"})
+		if w.Code != http.StatusNotFound {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+}
@@ -0,0 +1,302 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+// KeySidecarPorts is a hash of the ports allocated to the listeners of the
+// sidecar proxies, by <plugin id>:<system id>, to the port.
+const KeySidecarPorts = "sidecar:ports"
+
+// Defaults of the range of the ports allocated to the sidecar listeners, and
+// of how many of them a tenant may request.
+const (
+	DefaultPortRangeStart    = 9400
+	DefaultPortRangeEnd      = 9599
+	DefaultMaxPortsPerCaller = 16
+)
+
+// errPortLimit begins the error of allocatePortsScript when the caller
+// would exceed its limit.
+const errPortLimit = "PORTLIMIT"
+
+// allocatePortsScript returns the ports of the fields of the hash KEYS[1],
+// allocating the lowest free port from ARGV[1] to ARGV[2] to each field
+// without one. Ports that are allocated are never reused, so that a
+// listener keeps its port across restarts of the sidecar. The fields are
+// recorded in the set KEYS[2] of the caller, which may request at most
+// ARGV[3] of them.
+const allocatePortsScript = `
+local limit = tonumber(ARGV[3])
+local added = 0
+for i = 4, #ARGV do
+	if redis.call('SISMEMBER', KEYS[2], ARGV[i]) == 0 then
+		added = added + 1
+	end
+end
+if redis.call('SCARD', KEYS[2]) + added > limit then
+	return redis.error_reply('` + errPortLimit + ` at most ' .. limit .. ' ports may be requested')
+end
+local used = {}
+for _, v in ipairs(redis.call('HVALS', KEYS[1])) do
+	used[tonumber(v)] = true
+end
+local first, last = tonumber(ARGV[1]), tonumber(ARGV[2])
+local ports = {}
+for i = 4, #ARGV do
+	local port = tonumber(redis.call('HGET', KEYS[1], ARGV[i]))
+	if not port then
+		for p = first, last do
+			if not used[p] then
+				port = p
+				break
+			end
+		end
+		if not port then
+			return redis.error_reply('no free port from ' .. first .. ' to ' .. last)
+		end
+		used[port] = true
+		redis.call('HSET', KEYS[1], ARGV[i], port)
+	end
+	redis.call('SADD', KEYS[2], ARGV[i])
+	table.insert(ports, port)
+end
+return ports
+`
+
+// PortAllocationBody is the request of the sidecar proxy of a driver for the
+// ports of its listeners, one for each storage system.
+type PortAllocationBody struct {
+	PluginID  string   `json:"pluginID"`
+	SystemIDs []string `json:"systemIDs"`
+}
+
+// PortAllocationResponse is the ports of the listeners by system ID.
+type PortAllocationResponse struct {
+	Ports map[string]int `json:"ports"`
+}
+
+// PortHandler is the proxy handler for the port allocation requests of the
+// sidecar proxies. The ports are allocated from a range shared by every
+// driver, so that drivers running on the same node, e.g. with the host
+// network, do not collide. Ports are only allocated for the storage systems
+// of the storage systems configuration, and each tenant may only request a
+// limited number of them.
+type PortHandler struct {
+	mux          *http.ServeMux
+	log          *logrus.Entry
+	rdb          func() *redis.Client
+	script       *redis.Script
+	first, last  int
+	maxPerCaller int
+
+	mu      sync.RWMutex
+	systems map[string]bool
+}
+
+// NewPortHandler returns a PortHandler that allocates the ports from first
+// to last, inclusive, and at most maxPerCaller of them to each tenant, or
+// DefaultMaxPortsPerCaller if it is not positive.
+func NewPortHandler(log *logrus.Entry, rdb func() *redis.Client, first, last, maxPerCaller int) (*PortHandler, error) {
+	if first <= 0 || last > 65535 || first > last {
+		return nil, fmt.Errorf("invalid port range %d-%d", first, last)
+	}
+	if maxPerCaller <= 0 {
+		maxPerCaller = DefaultMaxPortsPerCaller
+	}
+	ph := &PortHandler{
+		log:          log,
+		rdb:          rdb,
+		script:       redis.NewScript(allocatePortsScript),
+		first:        first,
+		last:         last,
+		maxPerCaller: maxPerCaller,
+		systems:      make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(web.ProxyPortsPath, web.Adapt(web.HandlerWithError(ph.allocateHandler), web.TelemetryMW("portHandler", log)))
+	ph.mux = mux
+
+	return ph, nil
+}
+
+// ServeHTTP implements the http.Handler interface
+func (ph *PortHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ph.mux.ServeHTTP(w, r)
+}
+
+// UpdateSystems replaces the storage systems that ports are allocated for
+// with those of the storage systems configuration.
+func (ph *PortHandler) UpdateSystems(_ context.Context, r io.Reader, _ *logrus.Entry) error {
+	var updated SystemConfig
+	if err := json.NewDecoder(r).Decode(&updated); err != nil {
+		return err
+	}
+
+	systems := make(map[string]bool)
+	for storageType, family := range updated {
+		for systemID := range family {
+			systems[storageType+":"+systemID] = true
+		}
+	}
+
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
+	ph.systems = systems
+	return nil
+}
+
+// configured reports whether the storage system is configured.
+func (ph *PortHandler) configured(storageType, systemID string) bool {
+	ph.mu.RLock()
+	defer ph.mu.RUnlock()
+	return ph.systems[storageType+":"+systemID]
+}
+
+// ErrPortLimit is returned by Allocate when the caller would have more
+// ports than it may request.
+var ErrPortLimit = errors.New("port limit exceeded")
+
+// Allocate returns the ports of the listeners of the plugin for the storage
+// systems, by system ID, allocating the ports the listeners do not have yet.
+// The ports count towards the limit of the caller.
+func (ph *PortHandler) Allocate(caller, pluginID string, systemIDs []string) (map[string]int, error) {
+	args := []interface{}{ph.first, ph.last, ph.maxPerCaller}
+	for _, id := range systemIDs {
+		args = append(args, portField(pluginID, id))
+	}
+	vals, err := ph.script.Run(ph.rdb(), []string{KeySidecarPorts, sidecarPortsCallerKey(caller)}, args...).Result()
+	if err != nil {
+		if strings.HasPrefix(err.Error(), errPortLimit) {
+			return nil, fmt.Errorf("%w: %s", ErrPortLimit, strings.TrimSpace(strings.TrimPrefix(err.Error(), errPortLimit)))
+		}
+		return nil, err
+	}
+	list, ok := vals.([]interface{})
+	if !ok || len(list) != len(systemIDs) {
+		return nil, fmt.Errorf("unexpected allocation result %v", vals)
+	}
+
+	ports := make(map[string]int, len(systemIDs))
+	for i, id := range systemIDs {
+		port, ok := list[i].(int64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected port %v of system %s", list[i], id)
+		}
+		ports[id] = int(port)
+	}
+	return ports, nil
+}
+
+func portField(pluginID, systemID string) string {
+	return pluginID + ":" + systemID
+}
+
+// sidecarPortsCallerKey is the set of the fields of KeySidecarPorts that a
+// caller requested.
+func sidecarPortsCallerKey(caller string) string {
+	return KeySidecarPorts + ":caller:" + caller
+}
+
+func (ph *PortHandler) allocateHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return handleMethodNotAllowed(ph.log, w, r)
+	}
+	tenant, _ := r.Context().Value(web.JWTTenantName).(string)
+	admin, _ := r.Context().Value(web.JWTAdminName).(string)
+	if tenant == "" && admin == "" {
+		err := errors.New("token required")
+		handleJSONErrorResponse(ph.log, w, http.StatusUnauthorized, err)
+		return err
+	}
+
+	var body PortAllocationBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(ph.log, w, http.StatusBadRequest, err)
+		return err
+	}
+	if strings.TrimSpace(body.PluginID) == "" || len(body.SystemIDs) == 0 {
+		err := errors.New("plugin id and system ids must be provided")
+		handleJSONErrorResponse(ph.log, w, http.StatusBadRequest, err)
+		return err
+	}
+	storageType := web.NormalizePluginID(body.PluginID)
+	if storageType == "" {
+		err := fmt.Errorf("unknown plugin id %q", body.PluginID)
+		handleJSONErrorResponse(ph.log, w, http.StatusBadRequest, err)
+		return err
+	}
+	seen := make(map[string]bool, len(body.SystemIDs))
+	systemIDs := make([]string, 0, len(body.SystemIDs))
+	for _, id := range body.SystemIDs {
+		if strings.TrimSpace(id) == "" {
+			err := errors.New("empty system id not allowed")
+			handleJSONErrorResponse(ph.log, w, http.StatusBadRequest, err)
+			return err
+		}
+		if !ph.configured(storageType, id) {
+			err := fmt.Errorf("%s system %s is not configured", storageType, id)
+			handleJSONErrorResponse(ph.log, w, http.StatusBadRequest, err)
+			return err
+		}
+		if !seen[id] {
+			seen[id] = true
+			systemIDs = append(systemIDs, id)
+		}
+	}
+
+	caller := "tenant:" + tenant
+	if tenant == "" {
+		caller = "admin:" + admin
+	}
+	ports, err := ph.Allocate(caller, body.PluginID, systemIDs)
+	if errors.Is(err, ErrPortLimit) {
+		handleJSONErrorResponse(ph.log, w, http.StatusForbidden, err)
+		return err
+	}
+	if err != nil {
+		err = fmt.Errorf("allocating ports: %w", err)
+		handleJSONErrorResponse(ph.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	ph.log.WithFields(logrus.Fields{
+		"tenant":   tenant,
+		"pluginID": body.PluginID,
+		"ports":    ports,
+	}).Info("Allocated sidecar ports")
+
+	err = json.NewEncoder(w).Encode(&PortAllocationResponse{Ports: ports})
+	if err != nil {
+		err = fmt.Errorf("writing port allocation response: %w", err)
+		handleJSONErrorResponse(ph.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+func TestPortHandler(t *testing.T) {
+	mr, err := miniredis.Run()
+	checkError(t, err)
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	log := logrus.New().WithContext(context.Background())
+	h, err := proxy.NewPortHandler(log, func() *redis.Client { return rdb }, 9400, 9402, 3)
+	checkError(t, err)
+	checkError(t, h.UpdateSystems(context.Background(), strings.NewReader(`{
+		"powerflex": {"542a2d5f5122210f": {}, "1b2e5a7c9d3f4a6b": {}},
+		"powerscale": {"542a2d5f5122210f": {}},
+		"powermax": {"000197900714": {}, "000197900715": {}}
+	}`), log))
+
+	allocate := func(tenant, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, web.ProxyPortsPath, strings.NewReader(body))
+		if tenant != "" {
+			r = r.WithContext(context.WithValue(r.Context(), web.JWTTenantName, tenant))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	ports := func(t *testing.T, w *httptest.ResponseRecorder) map[string]int {
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d: %s, want %d", w.Code, w.Body, http.StatusOK)
+		}
+		var resp proxy.PortAllocationResponse
+		checkError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp.Ports
+	}
+
+	t.Run("it allocates distinct ports to the drivers", func(t *testing.T) {
+		got := ports(t, allocate("team-a", `{"pluginID": "csi-vxflexos", "systemIDs": ["542a2d5f5122210f", "1b2e5a7c9d3f4a6b"]}`))
+		if want := map[string]int{"542a2d5f5122210f": 9400, "1b2e5a7c9d3f4a6b": 9401}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+
+		got = ports(t, allocate("team-a", `{"pluginID": "csi-powerscale", "systemIDs": ["542a2d5f5122210f"]}`))
+		if want := map[string]int{"542a2d5f5122210f": 9402}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("it keeps the ports of the listeners", func(t *testing.T) {
+		got := ports(t, allocate("team-a", `{"pluginID": "csi-vxflexos", "systemIDs": ["1b2e5a7c9d3f4a6b"]}`))
+		if want := map[string]int{"1b2e5a7c9d3f4a6b": 9401}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("it fails when the range is exhausted", func(t *testing.T) {
+		w := allocate("team-b", `{"pluginID": "csi-powermax", "systemIDs": ["000197900714"]}`)
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusInternalServerError)
+		}
+	})
+
+	t.Run("it limits the ports of a tenant", func(t *testing.T) {
+		w := allocate("team-a", `{"pluginID": "csi-powermax", "systemIDs": ["000197900715"]}`)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusForbidden)
+		}
+
+		got := ports(t, allocate("team-b", `{"pluginID": "csi-vxflexos", "systemIDs": ["542a2d5f5122210f", "542a2d5f5122210f"]}`))
+		if want := map[string]int{"542a2d5f5122210f": 9400}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("it rejects an unknown plugin", func(t *testing.T) {
+		w := allocate("team-b", `{"pluginID": "csi-unity", "systemIDs": ["542a2d5f5122210f"]}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("it rejects a system that is not configured", func(t *testing.T) {
+		w := allocate("team-b", `{"pluginID": "csi-vxflexos", "systemIDs": ["000197900714"]}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("it requires a token", func(t *testing.T) {
+		w := allocate("", `{"pluginID": "csi-vxflexos", "systemIDs": ["542a2d5f5122210f"]}`)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("it rejects an invalid range", func(t *testing.T) {
+		if _, err := proxy.NewPortHandler(log, func() *redis.Client { return rdb }, 9500, 9400, 0); err == nil {
+			t.Error("expected an error, got nil")
+		}
+	})
+}
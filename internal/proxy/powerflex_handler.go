@@ -31,6 +31,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -39,6 +40,7 @@ import (
 	types "github.com/dell/goscaleio/types/v1"
 
 	"github.com/dell/goscaleio"
+	"github.com/go-redis/redis"
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/attribute"
@@ -61,8 +63,16 @@ type System struct {
 	rp  *httputil.ReverseProxy
 	tk  interface {
 		GetToken(context.Context) (string, error)
+		GetVersion() string
 	}
 	spc *powerflex.StoragePoolCache
+	// cache caches the responses to read-only requests; nil caches nothing.
+	cache *responseCache
+	// fwd forwards the requests of the PowerFlex clients when the system
+	// pins its certificate; nil if it does not.
+	fwd *pinnedForwarder
+	// stop stops the token getter of the system.
+	stop context.CancelFunc
 }
 
 // PowerFlexHandler is the proxy handler for PowerFlex systems
@@ -71,19 +81,58 @@ type PowerFlexHandler struct {
 	mu          sync.Mutex // guards systems map
 	systems     map[string]*System
 	enforcer    *quota.RedisEnforcement
+	deletes     *volumeDeletes
+	maps        *decisionBatcher
+	unmaps      *decisionBatcher
 	sdcapprover *sdc.RedisSdcApprover
-	opaHost     string
+	opaHost     hostAddr
+	filtered    []string // list endpoints filtered to the tenant's volumes
+	cached      []string // read-only endpoints whose responses are cached
+	cacheTTL    time.Duration
+	events      *DecisionEvents
 }
 
 // NewPowerFlexHandler returns a new PowerFlexHandler
 func NewPowerFlexHandler(log *logrus.Entry, enforcer *quota.RedisEnforcement, sdcapprover *sdc.RedisSdcApprover, opaHost string) *PowerFlexHandler {
-	return &PowerFlexHandler{
+	h := &PowerFlexHandler{
 		log:         log,
 		systems:     make(map[string]*System),
 		enforcer:    enforcer,
+		deletes:     newVolumeDeletes(enforcer),
+		maps:        newDecisionBatcher(decisionBatchWindow, decisionBatchMax, "/karavi/volumes/batch/map"),
+		unmaps:      newDecisionBatcher(decisionBatchWindow, decisionBatchMax, "/karavi/volumes/batch/unmap"),
 		sdcapprover: sdcapprover,
-		opaHost:     opaHost,
+		filtered:    DefaultPowerFlexFilteredPaths,
 	}
+	h.opaHost.Set(opaHost)
+	return h
+}
+
+// SetDecisionEvents sets the publisher of denied requests as Kubernetes
+// Events. It must be called before serving requests.
+func (h *PowerFlexHandler) SetDecisionEvents(events *DecisionEvents) {
+	h.events = events
+}
+
+// SetFilteredPaths sets the path patterns, in the syntax of path.Match, of
+// the list endpoints whose responses are filtered to the volumes of the
+// requesting tenant. It must be called before serving requests.
+func (h *PowerFlexHandler) SetFilteredPaths(paths []string) {
+	h.filtered = paths
+}
+
+// SetCachedPaths sets the path patterns, in the syntax of path.Match, of
+// the read-only endpoints whose responses are reused for the TTL by each
+// system. Nothing is cached without paths or with a TTL that is not
+// positive, the default. It must be called before the systems are updated.
+func (h *PowerFlexHandler) SetCachedPaths(paths []string, ttl time.Duration) {
+	h.cached = paths
+	h.cacheTTL = ttl
+}
+
+// SetOPAHost changes the OPA host asked for policy decisions.
+func (h *PowerFlexHandler) SetOPAHost(opaHost string) {
+	h.opaHost.Set(opaHost)
 }
 
 // GetSystems returns the configured systems
@@ -104,18 +153,23 @@ func (h *PowerFlexHandler) UpdateSystems(ctx context.Context, r io.Reader, log *
 	powerFlexSystems := updated["powerflex"]
 
 	// Remove systems
-	for k := range h.systems {
+	for k, v := range h.systems {
 		if _, ok := powerFlexSystems[k]; !ok {
 			// Removed
+			v.Stop()
 			delete(h.systems, k)
 		}
 	}
-	// Update systems
+	// Update systems, stopping the token getters of the replaced ones
 	for k, v := range powerFlexSystems {
+		old := h.systems[k]
 		var err error
-		if h.systems[k], err = buildSystem(ctx, v, log); err != nil {
+		if h.systems[k], err = buildSystem(ctx, k, v, log); err != nil {
 			h.log.WithError(err).Error("building powerflex system")
+		} else {
+			h.systems[k].cache = newResponseCache(k, log, h.cached, h.cacheTTL)
 		}
+		old.Stop()
 	}
 
 	for _, arr := range updated {
@@ -127,28 +181,66 @@ func (h *PowerFlexHandler) UpdateSystems(ctx context.Context, r io.Reader, log *
 	return nil
 }
 
-func buildSystem(ctx context.Context, e SystemEntry, log *logrus.Entry) (*System, error) {
+// Stop stops the token getter and the forwarder of the system. A nil system
+// is ignored.
+func (s *System) Stop() {
+	if s == nil {
+		return
+	}
+	if s.stop != nil {
+		s.stop()
+	}
+	s.fwd.Close()
+}
+
+// clientEndpoint returns the endpoint for the PowerFlex clients of the
+// system, which goes through the forwarder if the certificate is pinned.
+func (s *System) clientEndpoint() string {
+	if s.fwd != nil {
+		return s.fwd.url
+	}
+	return s.Endpoint
+}
+
+func buildSystem(ctx context.Context, systemID string, e SystemEntry, log *logrus.Entry) (*System, error) {
 	tgt, err := url.Parse(e.Endpoint)
 	if err != nil {
 		return nil, err
 	}
+	t, err := e.transport()
+	if err != nil {
+		return nil, err
+	}
+
+	// The transports of the PowerFlex clients cannot be replaced, so they
+	// reach a system with a pinned certificate through a forwarder.
+	s := &System{SystemEntry: e, log: log}
+	if t != nil {
+		if s.fwd, err = newPinnedForwarder(tgt, t); err != nil {
+			return nil, err
+		}
+	}
+	endpoint := s.clientEndpoint()
 
 	// Cannot use the same powerflex client for the storage pool cache and
 	// the token getter because of data races with concurrent usage so we
 	// create a powerflex client for each
 
-	spCacheClient, err := goscaleio.NewClientWithArgs(tgt.String(), "", 0, true, false)
+	spCacheClient, err := goscaleio.NewClientWithArgs(endpoint, "", 0, true, false)
 	if err != nil {
+		s.fwd.Close()
 		return nil, err
 	}
 
-	tgClient, err := goscaleio.NewClientWithArgs(tgt.String(), "", 0, true, false)
+	tgClient, err := goscaleio.NewClientWithArgs(endpoint, "", 0, true, false)
 	if err != nil {
+		s.fwd.Close()
 		return nil, err
 	}
 
 	spc, err := powerflex.NewStoragePoolCache(spCacheClient, 100)
 	if err != nil {
+		s.fwd.Close()
 		return nil, err
 	}
 
@@ -156,28 +248,31 @@ func buildSystem(ctx context.Context, e SystemEntry, log *logrus.Entry) (*System
 		PowerFlexClient:      tgClient,
 		TokenRefreshInterval: 5 * time.Minute,
 		ConfigConnect: &goscaleio.ConfigConnect{
-			Endpoint: e.Endpoint,
+			Endpoint: endpoint,
 			Username: e.User,
 			Password: e.Password,
+			Insecure: true,
 		},
 		Logger: log,
 	})
-	// TODO(ian): How do we ensure this gets cleaned up?
+	// The token getter runs until the system is replaced or removed.
+	ctx, cancel := context.WithCancel(ctx)
+	gauge := tokenGetters.WithLabelValues(systemID)
+	gauge.Inc()
 	go func() {
+		defer gauge.Dec()
 		err := tk.Start(ctx)
-		if err != nil {
-			log.Printf("token cache stopped for %s: %v", e.Endpoint, err)
+		if err != nil && !errors.Is(err, context.Canceled) {
 			log.WithError(err).WithField("endpoint", e.Endpoint).Error("token cached stopped")
 		}
 	}()
 
-	return &System{
-		SystemEntry: e,
-		log:         log,
-		rp:          httputil.NewSingleHostReverseProxy(tgt),
-		spc:         spc,
-		tk:          tk,
-	}, nil
+	s.rp = httputil.NewSingleHostReverseProxy(tgt)
+	s.rp.Transport = t
+	s.spc = spc
+	s.tk = tk
+	s.stop = cancel
+	return s, nil
 }
 
 func (h *PowerFlexHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -190,7 +285,7 @@ func (h *PowerFlexHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"system_id": systemID,
 	}).Debug("Serving request")
 	r = r.WithContext(context.WithValue(r.Context(), web.SystemIDKey, systemID))
-	r = r.WithContext(context.WithValue(r.Context(), web.SystemIDKey, systemID))
+	setRequestAttributes(r, "powerflex", systemID)
 
 	v, ok := h.systems[systemID]
 	if !ok {
@@ -198,44 +293,54 @@ func (h *PowerFlexHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	r, cancel := withBackendTimeout(r, v.Timeouts, "powerflex")
+	defer cancel()
+
 	// Use the authenticated session.
 	token, err := v.tk.GetToken(r.Context())
 	if err != nil {
 		writeError(w, "powerflex", "failed to authenticate", http.StatusUnauthorized, h.log)
 		return
 	}
-	r.SetBasicAuth("", token)
+	powerflex.SetAuthorization(r, token, v.tk.GetVersion())
 
 	// Instrument the proxy
 	attrs := trace.WithAttributes(attribute.String("powerflex.endpoint", ep), attribute.String("powerflex.systemid", systemID))
 	opts := otelhttp.WithSpanOptions(attrs)
-	proxyHandler := otelhttp.NewHandler(v.rp, "proxy", opts)
+	proxyHandler := v.cache.Handler(otelhttp.NewHandler(v.rp, "proxy", opts))
+
+	opaHost := h.opaHost.Get()
 
 	// TODO(ian): Probably shouldn't be building a servemux all the time :)
 	mux := http.NewServeMux()
+	listHandler := v.volumeListHandler(proxyHandler, h.enforcer, h.filtered)
 	mux.HandleFunc("/api/login/", h.spoofLoginRequest)
 	mux.Handle("/api/types/Volume/instances/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch {
 		case r.Method == http.MethodGet:
-			proxyHandler.ServeHTTP(w, r)
+			listHandler.ServeHTTP(w, r)
 		case strings.HasSuffix(r.URL.Path, "/action/queryIdByKey/"):
 			proxyHandler.ServeHTTP(w, r)
 		default:
-			v.volumeCreateHandler(proxyHandler, h.enforcer, h.opaHost).ServeHTTP(w, r)
+			v.volumeCreateHandler(proxyHandler, h.enforcer, h.events, opaHost).ServeHTTP(w, r)
 		}
 	}))
 	mux.Handle("/api/instances/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch {
 		case strings.HasSuffix(r.URL.Path, "/action/removeVolume/"):
-			v.volumeDeleteHandler(proxyHandler, h.enforcer, h.opaHost).ServeHTTP(w, r)
-		case strings.HasSuffix(r.URL.Path, "/action/addMappedSdc/"):
-			v.volumeMapHandler(proxyHandler, h.enforcer, h.opaHost).ServeHTTP(w, r)
-		case strings.HasSuffix(r.URL.Path, "/action/removeMappedSdc/"):
-			v.volumeUnmapHandler(proxyHandler, h.enforcer, h.opaHost).ServeHTTP(w, r)
+			v.volumeDeleteHandler(proxyHandler, h.deletes, opaHost).ServeHTTP(w, r)
+		case strings.HasSuffix(r.URL.Path, "/action/addMappedSdc/"), strings.HasSuffix(r.URL.Path, "/action/addMappedHost/"):
+			v.volumeMapHandler(proxyHandler, h.enforcer, h.sdcapprover, h.maps, opaHost).ServeHTTP(w, r)
+		case strings.HasSuffix(r.URL.Path, "/action/removeMappedSdc/"), strings.HasSuffix(r.URL.Path, "/action/removeMappedHost/"):
+			v.volumeUnmapHandler(proxyHandler, h.enforcer, h.unmaps, opaHost).ServeHTTP(w, r)
 		case strings.HasSuffix(r.URL.Path, "/action/approveSdc/"):
-			v.sdcApproveHandler(proxyHandler, h.sdcapprover, h.opaHost).ServeHTTP(w, r)
+			v.sdcApproveHandler(proxyHandler, h.sdcapprover, opaHost).ServeHTTP(w, r)
+		case strings.HasSuffix(r.URL.Path, "/action/snapshotVolumes/"):
+			v.volumeCloneHandler(proxyHandler, h.enforcer, h.events, opaHost).ServeHTTP(w, r)
+		case strings.HasPrefix(r.URL.Path, "/api/instances/Volume::") && strings.Contains(r.URL.Path, "/action/setVolume"):
+			v.volumeModifyHandler(proxyHandler, h.enforcer).ServeHTTP(w, r)
 		default:
-			proxyHandler.ServeHTTP(w, r)
+			listHandler.ServeHTTP(w, r)
 		}
 	}))
 	mux.Handle("/", proxyHandler)
@@ -252,7 +357,7 @@ func (h *PowerFlexHandler) spoofLoginRequest(w http.ResponseWriter, r *http.Requ
 	}
 }
 
-func (s *System) volumeCreateHandler(next http.Handler, enf *quota.RedisEnforcement, opaHost string) http.Handler {
+func (s *System) volumeCreateHandler(next http.Handler, enf *quota.RedisEnforcement, events *DecisionEvents, opaHost string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "volumeCreateHandler")
 		defer span.End()
@@ -274,19 +379,15 @@ func (s *System) volumeCreateHandler(next http.Handler, enf *quota.RedisEnforcem
 		}
 		defer r.Body.Close()
 
-		// Decode the body into a known structure.
-		body := struct {
-			VolumeSize     uint64
-			VolumeSizeInKb string `json:"volumeSizeInKb"`
-			StoragePoolID  string `json:"storagePoolId"`
-		}{}
-		err = json.NewDecoder(bytes.NewBuffer(b)).Decode(&body)
+		// Decode the body once, keeping the raw members for OPA.
+		var body powerflexCreateVolumeBody
+		err = json.Unmarshal(b, &body)
 		if err != nil {
 			s.log.WithError(err).Error("proxy: decoding create volume request")
 			writeError(w, "powerflex", "failed to extract cap data", http.StatusBadRequest, s.log)
 			return
 		}
-		body.VolumeSize, err = strconv.ParseUint(body.VolumeSizeInKb, 0, 64)
+		sizeInKb, err := strconv.ParseInt(body.VolumeSizeInKb, 0, 64)
 		if err != nil {
 			writeError(w, "powerflex", "failed to parse capacity", http.StatusBadRequest, s.log)
 			return
@@ -298,9 +399,16 @@ func (s *System) volumeCreateHandler(next http.Handler, enf *quota.RedisEnforcem
 			writeError(w, "powerflex", "failed to query pool name from id", http.StatusBadRequest, s.log)
 			return
 		}
+		// Roles may permit any pool of a protection domain.
+		pdID, err := s.spc.GetProtectionDomainIDByID(ctx, s.tk, body.StoragePoolID)
+		if err != nil {
+			writeError(w, "powerflex", "failed to query protection domain from pool id", http.StatusBadRequest, s.log)
+			return
+		}
 		s.log.WithFields(logrus.Fields{
-			"storage_pool_name": spName,
-			"storage_pool_id":   body.StoragePoolID,
+			"storage_pool_name":    spName,
+			"storage_pool_id":      body.StoragePoolID,
+			"protection_domain_id": pdID,
 		}).Debug()
 
 		// Get the remote host address.
@@ -316,13 +424,6 @@ func (s *System) volumeCreateHandler(next http.Handler, enf *quota.RedisEnforcem
 		// volReqCount.Add(pvName, 1)
 
 		// Ask OPA to make a decision
-		var requestBody map[string]json.RawMessage
-		err = json.NewDecoder(bytes.NewReader(b)).Decode(&requestBody)
-		if err != nil {
-			writeError(w, "powerflex", "decoding request body", http.StatusInternalServerError, s.log)
-			return
-		}
-
 		jwtGroup := r.Context().Value(web.JWTTenantName)
 		group, ok := jwtGroup.(string)
 		if !ok {
@@ -343,19 +444,27 @@ func (s *System) volumeCreateHandler(next http.Handler, enf *quota.RedisEnforcem
 			return
 		}
 
+		namePolicy, err := enf.VolumeNamePolicy(ctx, group)
+		if err != nil {
+			writeError(w, "powerflex", "resolving tenant volume name policy", http.StatusInternalServerError, s.log)
+			return
+		}
+
 		s.log.Debugln("Asking OPA...")
 		// Request policy decision from OPA
-		ans, err := decision.Can(func() decision.Query {
+		ans, err := decision.CanWithContext(ctx, func() decision.Query {
 			return decision.Query{
 				Host: opaHost,
 				// TODO(ian): This will need to be namespaced under "powerflex".
 				Policy: "/karavi/volumes/create",
 				Input: map[string]interface{}{
-					"claims":          claims,
-					"request":         requestBody,
-					"storagepool":     spName,
-					"storagesystemid": systemID,
-					"systemtype":      "powerflex",
+					"claims":             claims,
+					"request":            body.Raw,
+					"storagepool":        spName,
+					"protectiondomainid": pdID,
+					"storagesystemid":    systemID,
+					"systemtype":         "powerflex",
+					"volumename":         volumeNameInput(body.Name, namePolicy),
 				},
 			}
 		})
@@ -376,35 +485,88 @@ func (s *System) volumeCreateHandler(next http.Handler, enf *quota.RedisEnforcem
 		if resp := opaResp.Result; !resp.Allow {
 			reason := strings.Join(opaResp.Result.Deny, ",")
 			s.log.WithField("reason", reason).Debug("request denied")
-			writeError(w, "powerflex", fmt.Sprintf("request denied: %v", reason), http.StatusBadRequest, s.log)
+			setDecisionAttributes(span, false, reason)
+			events.PolicyDenied(r, group, spName, reason)
+			writeDenied(w, "powerflex", fmt.Sprintf("request denied: %v", reason), http.StatusBadRequest, web.Deny{Code: web.CodePolicyDenied, Reason: reason, Tenant: group, Pool: spName}, s.log)
+			return
+		}
+
+		// Policies that predate the volume name rules do not check the
+		// name, so it is checked here as well.
+		if err := namePolicy.Check(body.Name); err != nil {
+			if !errors.Is(err, quota.ErrVolumeName) {
+				s.log.WithError(err).Error("checking volume name")
+				writeError(w, "powerflex", "failed to check volume name", http.StatusInternalServerError, s.log)
+				return
+			}
+			reason := err.Error()
+			s.log.WithField("reason", reason).Debug("request denied")
+			setDecisionAttributes(span, false, reason)
+			events.PolicyDenied(r, group, spName, reason)
+			writeDenied(w, "powerflex", fmt.Sprintf("request denied: %v", reason), http.StatusBadRequest, web.Deny{Code: web.CodeVolumeName, Reason: reason, Tenant: group, Pool: spName}, s.log)
+			return
+		}
+
+		// Only the roles whose volume size bounds allow the request
+		// permit it. The bounds are checked here as well, for policies
+		// that report them without denying the request.
+		permittedRoles, err := sizePermittedRoles(opaResp.Result.PermittedRoles, opaResp.Result.MinSizes, opaResp.Result.MaxSizes, sizeInKb)
+		if err != nil {
+			reason := err.Error()
+			s.log.WithField("reason", reason).Debug("request denied")
+			setDecisionAttributes(span, false, reason)
+			events.PolicyDenied(r, group, spName, reason)
+			writeDenied(w, "powerflex", fmt.Sprintf("request denied: %v", reason), http.StatusBadRequest, web.Deny{Code: web.CodeVolumeSize, Reason: reason, Tenant: group, Pool: spName}, s.log)
 			return
 		}
 
 		// In the scenario where multiple roles are allowing
 		// this request, choose the one with the most quota.
-		var maxQuotaInKb uint64
-		for _, quota := range opaResp.Result.PermittedRoles {
-			if quota == 0 {
-				maxQuotaInKb = 0
-				break
-			}
-			if quota >= maxQuotaInKb {
-				maxQuotaInKb = quota
-			}
+		maxQuotaInKb := maxPermittedQuota(permittedRoles)
+
+		tenantKey, err := tenantQuotaKey(ctx, enf, group)
+		if err != nil {
+			writeError(w, "powerflex", "resolving tenant", http.StatusInternalServerError, s.log)
+			return
+		}
+		maxVolumes, err := enf.MaxVolumes(ctx, group)
+		if err != nil {
+			writeError(w, "powerflex", "resolving tenant max volumes", http.StatusInternalServerError, s.log)
+			return
 		}
 
 		qr := quota.Request{
 			SystemType:    "powerflex",
 			SystemID:      systemID,
 			StoragePoolID: spName,
-			Group:         group,
+			Group:         tenantKey,
 			VolumeName:    pvName,
 			Capacity:      body.VolumeSizeInKb,
+			MaxVolumes:    maxVolumes,
+			SoftQuota:     permittedSoftQuota(permittedRoles, opaResp.Result.SoftQuotas),
 		}
 
 		s.log.Debugln("Approving request...")
 		// Ask our quota enforcer if it approves the request.
-		ok, err = enf.ApproveRequest(ctx, qr, uint64(maxQuotaInKb))
+		ok, err = enf.ApproveRequest(ctx, qr, maxQuotaInKb)
+		if errors.Is(err, quota.ErrMaxVolumes) {
+			s.log.Debugln("request was not approved")
+			setDecisionAttributes(span, false, "maximum number of volumes reached")
+			events.QuotaExceeded(r, group, spName, "maximum number of volumes reached")
+			deny := quotaDeny(ctx, enf, qr, group, web.CodeMaxVolumes, maxVolumes, s.log)
+			deny.Alternatives = poolHints(ctx, enf, qr, sizeInKb, opaResp.Result.AlternativePools, s.log)
+			writeDenied(w, "powerflex", "request denied: maximum number of volumes reached", http.StatusInsufficientStorage, deny, s.log)
+			return
+		}
+		if errors.Is(err, quota.ErrGracePeriodExpired) {
+			s.log.Debugln("request was not approved")
+			setDecisionAttributes(span, false, "soft quota grace period expired")
+			events.QuotaExceeded(r, group, spName, "soft quota grace period expired")
+			deny := quotaDeny(ctx, enf, qr, group, web.CodeSoftQuotaExpired, qr.SoftQuota, s.log)
+			deny.Alternatives = poolHints(ctx, enf, qr, sizeInKb, opaResp.Result.AlternativePools, s.log)
+			writeDenied(w, "powerflex", "request denied: soft quota grace period expired", http.StatusInsufficientStorage, deny, s.log)
+			return
+		}
 		if err != nil {
 			s.log.WithError(err).Error("approving request")
 			writeError(w, "powerflex", "failed to approve request", http.StatusInternalServerError, s.log)
@@ -412,9 +574,14 @@ func (s *System) volumeCreateHandler(next http.Handler, enf *quota.RedisEnforcem
 		}
 		if !ok {
 			s.log.Debugln("request was not approved")
-			writeError(w, "powerflex", "request denied: not enough quota", http.StatusInsufficientStorage, s.log)
+			setDecisionAttributes(span, false, "not enough quota")
+			events.QuotaExceeded(r, group, spName, "not enough quota")
+			deny := quotaDeny(ctx, enf, qr, group, web.CodeQuotaExceeded, maxQuotaInKb, s.log)
+			deny.Alternatives = poolHints(ctx, enf, qr, sizeInKb, opaResp.Result.AlternativePools, s.log)
+			writeDenied(w, "powerflex", "request denied: not enough quota", http.StatusInsufficientStorage, deny, s.log)
 			return
 		}
+		setDecisionAttributes(span, true, "")
 
 		// At this point, the request has been approved.
 
@@ -424,8 +591,10 @@ func (s *System) volumeCreateHandler(next http.Handler, enf *quota.RedisEnforcem
 			s.log.WithError(err).Error("closing original request body")
 		}
 		r.Body = io.NopCloser(bytes.NewBuffer(b))
-		sw := &web.StatusWriter{
-			ResponseWriter: w,
+		sw := &teeResponseWriter{
+			StatusWriter: web.StatusWriter{
+				ResponseWriter: w,
+			},
 		}
 
 		s.log.Debugln("Proxying request...")
@@ -441,6 +610,12 @@ func (s *System) volumeCreateHandler(next http.Handler, enf *quota.RedisEnforcem
 		switch sw.Status {
 		case http.StatusOK:
 			s.log.Debugln("Publish created")
+			// The volume is tracked by the ID the PowerFlex assigned to it.
+			var created types.VolumeResp
+			if err := json.Unmarshal(sw.body.Bytes(), &created); err != nil {
+				s.log.WithError(err).Warn("decoding created volume, tracking it by name")
+			}
+			qr.VolumeID = created.ID
 			ok, err := enf.PublishCreated(r.Context(), qr)
 			if err != nil {
 				s.log.WithError(err).Error("publishing volume created")
@@ -453,7 +628,7 @@ func (s *System) volumeCreateHandler(next http.Handler, enf *quota.RedisEnforcem
 	})
 }
 
-func (s *System) volumeDeleteHandler(next http.Handler, enf *quota.RedisEnforcement, opaHost string) http.Handler {
+func (s *System) volumeDeleteHandler(next http.Handler, vd *volumeDeletes, opaHost string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "volumeDeleteHandler")
 		defer span.End()
@@ -475,7 +650,7 @@ func (s *System) volumeDeleteHandler(next http.Handler, enf *quota.RedisEnforcem
 			id = z[3]
 		}
 		pvName, err := func() (*types.Volume, error) {
-			c, err := goscaleio.NewClientWithArgs(s.Endpoint, "", 0, true, false)
+			c, err := goscaleio.NewClientWithArgs(s.clientEndpoint(), s.tk.GetVersion(), 0, true, false)
 			if err != nil {
 				return nil, err
 			}
@@ -535,15 +710,19 @@ func (s *System) volumeDeleteHandler(next http.Handler, enf *quota.RedisEnforcem
 			writeError(w, "powerflex", "decoding request body", http.StatusInternalServerError, s.log)
 			return
 		}
-		// Request policy decision from OPA
-		ans, err := decision.Can(func() decision.Query {
-			return decision.Query{
-				Host:   opaHost,
-				Policy: "/karavi/volumes/delete",
-				Input: map[string]interface{}{
-					"claims": claims,
-				},
-			}
+		// Request policy decision from OPA, reusing a recent decision for
+		// the same tenant and storage pool.
+		decisionKey := strings.Join([]string{claims.TenantKey(), claims.Roles, systemID, spName}, "|")
+		ans, err := vd.decisions.Get(decisionKey, func() ([]byte, error) {
+			return decision.CanWithContext(ctx, func() decision.Query {
+				return decision.Query{
+					Host:   opaHost,
+					Policy: "/karavi/volumes/delete",
+					Input: map[string]interface{}{
+						"claims": claims,
+					},
+				}
+			})
 		})
 		if err != nil {
 			s.log.WithError(err).Error("asking OPA for volume delete decision")
@@ -559,31 +738,41 @@ func (s *System) volumeDeleteHandler(next http.Handler, enf *quota.RedisEnforcem
 		}
 		s.log.WithField("opa_response", string(ans)).Debug("OPA Response")
 		if resp := opaResp.Result; !resp.Response.Allowed {
+			setDecisionAttributes(span, false, resp.Response.Status.Reason)
 			switch {
 			case resp.Claims.Group == "":
 				writeError(w, "powerflex", "invalid token", http.StatusUnauthorized, s.log)
 			default:
-				writeError(w, "powerflex", fmt.Sprintf("request denied: %v", resp.Response.Status.Reason), http.StatusBadRequest, s.log)
+				writeDenied(w, "powerflex", fmt.Sprintf("request denied: %v", resp.Response.Status.Reason), http.StatusBadRequest, web.Deny{Code: web.CodePolicyDenied, Reason: resp.Response.Status.Reason, Tenant: resp.Claims.Group}, s.log)
 			}
 			return
 		}
 
+		tenantKey, err := tenantQuotaKey(ctx, vd.enf, opaResp.Result.Claims.Group)
+		if err != nil {
+			writeError(w, "powerflex", "resolving tenant", http.StatusInternalServerError, s.log)
+			return
+		}
+
 		qr := quota.Request{
 			SystemType:    "powerflex",
 			SystemID:      systemID,
 			StoragePoolID: spName,
-			Group:         opaResp.Result.Claims.Group,
+			Group:         tenantKey,
 			VolumeName:    pvName.Name,
+			VolumeID:      pvName.ID,
 		}
-		ok, err = enf.DeleteRequest(r.Context(), qr)
+		ok, err = vd.requests.Do(ctx, qr)
 		if err != nil {
 			writeError(w, "powerflex", "delete request failed", http.StatusInternalServerError, s.log)
 			return
 		}
 		if !ok {
-			writeError(w, "powerflex", "request denied", http.StatusForbidden, s.log)
+			setDecisionAttributes(span, false, "volume not owned by tenant")
+			writeDenied(w, "powerflex", "request denied", http.StatusForbidden, web.Deny{Code: web.CodeNotOwner, Reason: "volume not owned by tenant", Tenant: opaResp.Result.Claims.Group, Pool: spName}, s.log)
 			return
 		}
+		setDecisionAttributes(span, true, "")
 
 		// Reset the original request
 		err = r.Body.Close()
@@ -603,7 +792,7 @@ func (s *System) volumeDeleteHandler(next http.Handler, enf *quota.RedisEnforcem
 		switch sw.Status {
 		case http.StatusOK:
 			s.log.Debugln("Publish deleted")
-			ok, err := enf.PublishDeleted(r.Context(), qr)
+			ok, err := vd.published.Do(r.Context(), qr)
 			if err != nil {
 				s.log.WithError(err).Error("publishing volume deleted")
 				return
@@ -615,7 +804,11 @@ func (s *System) volumeDeleteHandler(next http.Handler, enf *quota.RedisEnforcem
 	})
 }
 
-func (s *System) volumeMapHandler(next http.Handler, enf *quota.RedisEnforcement, opaHost string) http.Handler {
+// volumeMapHandler handles the requests that map a volume to an SDC, with
+// addMappedSdc, or to an NVMe/TCP host of PowerFlex 4.x, with
+// addMappedHost. Either is only allowed if the tenant owns the volume and
+// allows the SDC or host.
+func (s *System) volumeMapHandler(next http.Handler, enf *quota.RedisEnforcement, sdcapp *sdc.RedisSdcApprover, decisions *decisionBatcher, opaHost string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "volumeMapHandler")
 		defer span.End()
@@ -638,7 +831,7 @@ func (s *System) volumeMapHandler(next http.Handler, enf *quota.RedisEnforcement
 			return
 		}
 		pvName, err := func() (*types.Volume, error) {
-			c, err := goscaleio.NewClientWithArgs(s.Endpoint, "", 0, true, false)
+			c, err := goscaleio.NewClientWithArgs(s.clientEndpoint(), s.tk.GetVersion(), 0, true, false)
 			if err != nil {
 				return nil, err
 			}
@@ -700,15 +893,14 @@ func (s *System) volumeMapHandler(next http.Handler, enf *quota.RedisEnforcement
 			writeError(w, "powerflex", "decoding request body", http.StatusInternalServerError, s.log)
 			return
 		}
-		// Request policy decision from OPA
-		ans, err := decision.Can(func() decision.Query {
-			return decision.Query{
-				Host:   opaHost,
-				Policy: "/karavi/volumes/map",
-				Input: map[string]interface{}{
-					"claims": claims,
-				},
-			}
+		// Request policy decision from OPA, together with other
+		// requests made at the same time
+		ans, err := decisions.Do(ctx, decision.Query{
+			Host:   opaHost,
+			Policy: "/karavi/volumes/map",
+			Input: map[string]interface{}{
+				"claims": claims,
+			},
 		})
 		if err != nil {
 			s.log.WithError(err).Error("asking OPA for volume map decision")
@@ -726,7 +918,14 @@ func (s *System) volumeMapHandler(next http.Handler, enf *quota.RedisEnforcement
 		s.log.WithField("opa_response", opaResp).Debug()
 		if resp := opaResp.Result; !resp.Response.Allowed {
 			s.log.Printf("request denied: %v", resp.Response.Status.Reason)
-			writeError(w, "powerflex", fmt.Sprintf("request denied: %v", resp.Response.Status.Reason), http.StatusBadRequest, s.log)
+			setDecisionAttributes(span, false, resp.Response.Status.Reason)
+			writeDenied(w, "powerflex", fmt.Sprintf("request denied: %v", resp.Response.Status.Reason), http.StatusBadRequest, web.Deny{Code: web.CodePolicyDenied, Reason: resp.Response.Status.Reason, Tenant: resp.Claims.Group}, s.log)
+			return
+		}
+
+		tenantKey, err := tenantQuotaKey(ctx, enf, opaResp.Result.Claims.Group)
+		if err != nil {
+			writeError(w, "powerflex", "resolving tenant", http.StatusInternalServerError, s.log)
 			return
 		}
 
@@ -734,8 +933,9 @@ func (s *System) volumeMapHandler(next http.Handler, enf *quota.RedisEnforcement
 			SystemType:    "powerflex",
 			SystemID:      systemID,
 			StoragePoolID: spName,
-			Group:         opaResp.Result.Claims.Group,
+			Group:         tenantKey,
 			VolumeName:    pvName.Name,
+			VolumeID:      pvName.ID,
 		}
 		ok, err = enf.ValidateOwnership(ctx, qr)
 		if err != nil {
@@ -743,10 +943,79 @@ func (s *System) volumeMapHandler(next http.Handler, enf *quota.RedisEnforcement
 			return
 		}
 		if !ok {
-			writeError(w, "powerflex", "map denied", http.StatusForbidden, s.log)
+			setDecisionAttributes(span, false, "volume not owned by tenant")
+			writeDenied(w, "powerflex", "map denied", http.StatusForbidden, web.Deny{Code: web.CodeNotOwner, Reason: "volume not owned by tenant", Tenant: opaResp.Result.Claims.Group, Pool: spName}, s.log)
 			return
 		}
 
+		var sdcID, sdcGUID, hostID string
+		var allSdcs bool
+		for field, v := range map[string]interface{}{"sdcId": &sdcID, "guid": &sdcGUID, "allSdcs": &allSdcs, "hostId": &hostID} {
+			if raw, ok := requestBody[field]; ok {
+				if err := json.Unmarshal(raw, v); err != nil {
+					writeError(w, "powerflex", fmt.Sprintf("decoding %s", field), http.StatusBadRequest, s.log)
+					return
+				}
+			}
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/action/addMappedHost/") {
+			if hostID == "" {
+				writeError(w, "powerflex", "missing hostId", http.StatusBadRequest, s.log)
+				return
+			}
+			// Tenants that allow only certain SDCs may only map volumes
+			// to the NVMe hosts among them, by NQN. Hosts are not
+			// approved like SDCs, so the approve_sdc flag does not apply.
+			allowed, err := s.hostAllowed(ctx, sdcapp, claims.Group, hostID)
+			if err != nil {
+				writeError(w, "powerflex", fmt.Sprintf("query host allow list: %v", err), http.StatusInternalServerError, s.log)
+				return
+			}
+			if !allowed {
+				setDecisionAttributes(span, false, "host not allowed for tenant")
+				writeDenied(w, "powerflex", "map denied: host is not allowed for tenant", http.StatusForbidden, web.Deny{Code: web.CodeHostNotAllowed, Reason: "host not allowed for tenant", Tenant: claims.Group}, s.log)
+				return
+			}
+		} else {
+			// Tenants that allow only certain SDCs may only map volumes to
+			// those SDCs.
+			allowed, err := s.sdcAllowed(ctx, sdcapp, claims.Group, sdcID, sdcGUID, allSdcs)
+			if err != nil {
+				writeError(w, "powerflex", fmt.Sprintf("query sdc allow list: %v", err), http.StatusInternalServerError, s.log)
+				return
+			}
+			if !allowed {
+				setDecisionAttributes(span, false, "sdc not allowed for tenant")
+				writeDenied(w, "powerflex", "map denied: sdc is not allowed for tenant", http.StatusForbidden, web.Deny{Code: web.CodeSdcNotAllowed, Reason: "sdc not allowed for tenant", Tenant: claims.Group}, s.log)
+				return
+			}
+
+			// Tenants that may not approve SDCs may only map volumes to SDCs
+			// that the PowerFlex has already approved.
+			approveSdc, err := sdcapp.CheckSdcApproveFlag(ctx, sdc.Request{Group: claims.Group})
+			switch {
+			case errors.Is(err, redis.Nil):
+				approveSdc = true // the tenant predates the approve_sdc flag
+			case err != nil:
+				writeError(w, "powerflex", "map request failed", http.StatusInternalServerError, s.log)
+				return
+			}
+			if !approveSdc {
+				approved, err := s.sdcApproved(ctx, sdcID)
+				if err != nil {
+					writeError(w, "powerflex", fmt.Sprintf("query sdc approval: %v", err), http.StatusInternalServerError, s.log)
+					return
+				}
+				if !approved {
+					setDecisionAttributes(span, false, "sdc approval disabled for tenant")
+					writeDenied(w, "powerflex", "map denied: sdc is not approved", http.StatusForbidden, web.Deny{Code: web.CodeSdcNotAllowed, Reason: "sdc approval disabled for tenant", Tenant: claims.Group}, s.log)
+					return
+				}
+			}
+		}
+		setDecisionAttributes(span, true, "")
+
 		// Reset the original request
 		r.Body = io.NopCloser(bytes.NewBuffer(b))
 		r = r.WithContext(ctx)
@@ -754,7 +1023,84 @@ func (s *System) volumeMapHandler(next http.Handler, enf *quota.RedisEnforcement
 	})
 }
 
-func (s *System) volumeUnmapHandler(next http.Handler, enf *quota.RedisEnforcement, opaHost string) http.Handler {
+// sdcApproved reports whether the PowerFlex has approved the SDC.
+func (s *System) sdcApproved(ctx context.Context, sdcID string) (bool, error) {
+	if sdcID == "" {
+		return false, nil
+	}
+	found, err := s.getSdc(ctx, sdcID)
+	if err != nil {
+		return false, err
+	}
+	return found.Sdc.SdcApproved, nil
+}
+
+// sdcAllowed reports whether the tenant allows mapping volumes to the SDC,
+// which is given by its ID or GUID, or to all SDCs. The SDC is only looked
+// up on the PowerFlex if the tenant restricts the SDCs by GUID or IP.
+func (s *System) sdcAllowed(ctx context.Context, sdcapp *sdc.RedisSdcApprover, group, sdcID, sdcGUID string, allSdcs bool) (bool, error) {
+	r := sdc.Request{Group: group}
+	if allSdcs {
+		return sdcapp.CheckSdcAllowed(ctx, r)
+	}
+	allowed, err := sdcapp.CheckSdcAllowed(ctx, r, sdcGUID)
+	if err != nil || allowed || sdcID == "" {
+		return allowed, err
+	}
+
+	found, err := s.getSdc(ctx, sdcID)
+	if err != nil {
+		return false, err
+	}
+	ids := append([]string{found.Sdc.SdcGUID, found.Sdc.SdcIP}, found.Sdc.SdcIPs...)
+	return sdcapp.CheckSdcAllowed(ctx, r, ids...)
+}
+
+// hostAllowed reports whether the tenant allows mapping volumes to the NVMe
+// host, which is allowed by its NQN. The host is only looked up on the
+// PowerFlex if the tenant restricts the SDCs and hosts.
+func (s *System) hostAllowed(ctx context.Context, sdcapp *sdc.RedisSdcApprover, group, hostID string) (bool, error) {
+	r := sdc.Request{Group: group}
+	allowed, err := sdcapp.CheckSdcAllowed(ctx, r)
+	if err != nil || allowed {
+		return allowed, err
+	}
+
+	c, err := goscaleio.NewClientWithArgs(s.clientEndpoint(), s.tk.GetVersion(), 0, true, false)
+	if err != nil {
+		return false, err
+	}
+	token, err := s.tk.GetToken(ctx)
+	if err != nil {
+		return false, err
+	}
+	c.SetToken(token)
+
+	found, err := goscaleio.NewSystem(c).GetNvmeHostByID(hostID)
+	if err != nil {
+		return false, err
+	}
+	return sdcapp.CheckSdcAllowed(ctx, r, found.Nqn)
+}
+
+func (s *System) getSdc(ctx context.Context, sdcID string) (*goscaleio.Sdc, error) {
+	c, err := goscaleio.NewClientWithArgs(s.clientEndpoint(), s.tk.GetVersion(), 0, true, false)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.tk.GetToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.SetToken(token)
+
+	return goscaleio.NewSystem(c).GetSdcByID(sdcID)
+}
+
+// volumeUnmapHandler handles the requests that unmap a volume from an SDC,
+// with removeMappedSdc, or from an NVMe/TCP host, with removeMappedHost.
+// Either is only allowed if the tenant owns the volume.
+func (s *System) volumeUnmapHandler(next http.Handler, enf *quota.RedisEnforcement, decisions *decisionBatcher, opaHost string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "volumeUnmapHandler")
 		defer span.End()
@@ -777,7 +1123,7 @@ func (s *System) volumeUnmapHandler(next http.Handler, enf *quota.RedisEnforceme
 			return
 		}
 		pvName, err := func() (*types.Volume, error) {
-			c, err := goscaleio.NewClientWithArgs(s.Endpoint, "", 0, true, false)
+			c, err := goscaleio.NewClientWithArgs(s.clientEndpoint(), s.tk.GetVersion(), 0, true, false)
 			if err != nil {
 				return nil, err
 			}
@@ -839,15 +1185,14 @@ func (s *System) volumeUnmapHandler(next http.Handler, enf *quota.RedisEnforceme
 			writeError(w, "powerflex", "decoding request body", http.StatusInternalServerError, s.log)
 			return
 		}
-		// Request policy decision from OPA
-		ans, err := decision.Can(func() decision.Query {
-			return decision.Query{
-				Host:   opaHost,
-				Policy: "/karavi/volumes/unmap",
-				Input: map[string]interface{}{
-					"claims": claims,
-				},
-			}
+		// Request policy decision from OPA, together with other
+		// requests made at the same time
+		ans, err := decisions.Do(ctx, decision.Query{
+			Host:   opaHost,
+			Policy: "/karavi/volumes/unmap",
+			Input: map[string]interface{}{
+				"claims": claims,
+			},
 		})
 		if err != nil {
 			s.log.WithError(err).Error("asking OPA for volume unmap decision")
@@ -864,21 +1209,29 @@ func (s *System) volumeUnmapHandler(next http.Handler, enf *quota.RedisEnforceme
 		}
 		s.log.WithField("opa_response", opaResp).Debug()
 		if resp := opaResp.Result; !resp.Response.Allowed {
+			setDecisionAttributes(span, false, resp.Response.Status.Reason)
 			switch {
 			case resp.Claims.Group == "":
 				writeError(w, "powerflex", "invalid token", http.StatusUnauthorized, s.log)
 			default:
-				writeError(w, "powerflex", fmt.Sprintf("request denied: %v", resp.Response.Status.Reason), http.StatusBadRequest, s.log)
+				writeDenied(w, "powerflex", fmt.Sprintf("request denied: %v", resp.Response.Status.Reason), http.StatusBadRequest, web.Deny{Code: web.CodePolicyDenied, Reason: resp.Response.Status.Reason, Tenant: resp.Claims.Group}, s.log)
 			}
 			return
 		}
 
+		tenantKey, err := tenantQuotaKey(ctx, enf, opaResp.Result.Claims.Group)
+		if err != nil {
+			writeError(w, "powerflex", "resolving tenant", http.StatusInternalServerError, s.log)
+			return
+		}
+
 		qr := quota.Request{
 			SystemType:    "powerflex",
 			SystemID:      systemID,
 			StoragePoolID: spName,
-			Group:         opaResp.Result.Claims.Group,
+			Group:         tenantKey,
 			VolumeName:    pvName.Name,
+			VolumeID:      pvName.ID,
 		}
 		ok, err = enf.ValidateOwnership(ctx, qr)
 		if err != nil {
@@ -886,9 +1239,11 @@ func (s *System) volumeUnmapHandler(next http.Handler, enf *quota.RedisEnforceme
 			return
 		}
 		if !ok {
-			writeError(w, "powerflex", "unmap denied", http.StatusForbidden, s.log)
+			setDecisionAttributes(span, false, "volume not owned by tenant")
+			writeDenied(w, "powerflex", "unmap denied", http.StatusForbidden, web.Deny{Code: web.CodeNotOwner, Reason: "volume not owned by tenant", Tenant: opaResp.Result.Claims.Group, Pool: spName}, s.log)
 			return
 		}
+		setDecisionAttributes(span, true, "")
 
 		// Reset the original request
 		r.Body = io.NopCloser(bytes.NewBuffer(b))
@@ -897,6 +1252,416 @@ func (s *System) volumeUnmapHandler(next http.Handler, enf *quota.RedisEnforceme
 	})
 }
 
+// volumeCloneHandler handles a snapshotVolumes request, which the PowerFlex
+// CSI driver uses to clone volumes and to create volumes from snapshots.
+//
+// The REST call is:
+// POST /api/instances/System::<id>/action/snapshotVolumes/
+//
+// The payload looks like:
+//
+//	{"snapshotDefs":[{"volumeId":"d9ec3ce900000003","snapshotName":"k8s-7c0f62ba3e"}]}
+//
+// Each snapshot is only allowed if the tenant owns the source volume. The
+// capacity of the source volume is approved against the tenant's quota in the
+// source volume's pool and, once created, the snapshot is recorded as owned by
+// the tenant so that it can be mapped, unmapped and deleted like any other
+// volume.
+func (s *System) volumeCloneHandler(next http.Handler, enf *quota.RedisEnforcement, events *DecisionEvents, opaHost string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "volumeCloneHandler")
+		defer span.End()
+
+		var systemID string
+		if v := r.Context().Value(web.SystemIDKey); v != nil {
+			var ok bool
+			if systemID, ok = v.(string); !ok {
+				writeError(w, "powerflex", http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError, s.log)
+				return
+			}
+		}
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, "powerflex", "failed to read body", http.StatusInternalServerError, s.log)
+			return
+		}
+		defer r.Body.Close()
+
+		var body types.SnapshotVolumesParam
+		err = json.NewDecoder(bytes.NewReader(b)).Decode(&body)
+		if err != nil {
+			s.log.WithError(err).Error("proxy: decoding snapshot volumes request")
+			writeError(w, "powerflex", "failed to decode snapshot request", http.StatusBadRequest, s.log)
+			return
+		}
+
+		jwtValue := r.Context().Value(web.JWTKey)
+		jwtToken, ok := jwtValue.(token.Token)
+		if !ok {
+			writeError(w, "powerflex", "incorrect type for JWT token", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		claims, err := jwtToken.Claims()
+		if err != nil {
+			writeError(w, "powerflex", "decoding token claims", http.StatusInternalServerError, s.log)
+			return
+		}
+		tenantKey, err := tenantQuotaKey(ctx, enf, claims.Group)
+		if err != nil {
+			writeError(w, "powerflex", "resolving tenant", http.StatusInternalServerError, s.log)
+			return
+		}
+		maxVolumes, err := enf.MaxVolumes(ctx, claims.Group)
+		if err != nil {
+			writeError(w, "powerflex", "resolving tenant max volumes", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		c, err := goscaleio.NewClientWithArgs(s.clientEndpoint(), s.tk.GetVersion(), 0, true, false)
+		if err != nil {
+			writeError(w, "powerflex", "failed to build powerflex client", http.StatusInternalServerError, s.log)
+			return
+		}
+		token, err := s.tk.GetToken(ctx)
+		if err != nil {
+			writeError(w, "powerflex", "failed to authenticate", http.StatusUnauthorized, s.log)
+			return
+		}
+		c.SetToken(token)
+
+		// The snapshots are approved one at a time, so the approvals of the
+		// earlier ones are released if a later one is not approved.
+		var approved []quota.Request
+		forwarded := false
+		defer func() {
+			if forwarded {
+				return
+			}
+			for _, qr := range approved {
+				if _, err := enf.PublishDeleted(ctx, qr); err != nil {
+					s.log.WithError(err).WithField("name", qr.VolumeName).Error("releasing volume clone approval")
+				}
+			}
+		}()
+		for _, def := range body.SnapshotDefs {
+			if def == nil {
+				continue
+			}
+			vols, err := c.GetVolume("", strings.TrimPrefix(def.VolumeID, "Volume::"), "", "", false)
+			if err != nil || len(vols) == 0 {
+				s.log.WithError(err).WithField("volume_id", def.VolumeID).Error("querying source volume by id")
+				writeError(w, "powerflex", "query source volume by volid", http.StatusInternalServerError, s.log)
+				return
+			}
+			src := vols[0]
+
+			spName, err := s.spc.GetStoragePoolNameByID(ctx, s.tk, src.StoragePoolID)
+			if err != nil {
+				writeError(w, "powerflex", "failed to query pool name from id", http.StatusBadRequest, s.log)
+				return
+			}
+			pdID, err := s.spc.GetProtectionDomainIDByID(ctx, s.tk, src.StoragePoolID)
+			if err != nil {
+				writeError(w, "powerflex", "failed to query protection domain from pool id", http.StatusBadRequest, s.log)
+				return
+			}
+
+			// The tenant must own the volume being cloned.
+			ok, err := enf.ValidateOwnership(ctx, quota.Request{
+				SystemType:    "powerflex",
+				SystemID:      systemID,
+				StoragePoolID: spName,
+				Group:         tenantKey,
+				VolumeName:    src.Name,
+				VolumeID:      src.ID,
+			})
+			if err != nil {
+				writeError(w, "powerflex", "validating ownership failed", http.StatusInternalServerError, s.log)
+				return
+			}
+			if !ok {
+				setDecisionAttributes(span, false, "volume not owned by tenant")
+				writeDenied(w, "powerflex", "request denied", http.StatusForbidden, web.Deny{Code: web.CodeNotOwner, Reason: "volume not owned by tenant", Tenant: claims.Group, Pool: spName}, s.log)
+				return
+			}
+
+			sizeInKb := strconv.Itoa(src.SizeInKb)
+			ans, err := decision.CanWithContext(ctx, func() decision.Query {
+				return decision.Query{
+					Host:   opaHost,
+					Policy: "/karavi/volumes/create",
+					Input: map[string]interface{}{
+						"claims":             claims,
+						"request":            map[string]interface{}{"volumeSizeInKb": sizeInKb},
+						"storagepool":        spName,
+						"protectiondomainid": pdID,
+						"storagesystemid":    systemID,
+						"systemtype":         "powerflex",
+					},
+				}
+			})
+			if err != nil {
+				s.log.WithError(err).Error("asking OPA for volume clone decision")
+				writeError(w, "powerflex", fmt.Sprintf("asking OPA for volume clone decision: %v", err), http.StatusInternalServerError, s.log)
+				return
+			}
+
+			var opaResp CreateOPAResponse
+			err = json.NewDecoder(bytes.NewReader(ans)).Decode(&opaResp)
+			if err != nil {
+				s.log.WithError(err).Error("decoding opa response")
+				writeError(w, "powerflex", "decoding opa request body", http.StatusInternalServerError, s.log)
+				return
+			}
+			if resp := opaResp.Result; !resp.Allow {
+				reason := strings.Join(opaResp.Result.Deny, ",")
+				s.log.WithField("reason", reason).Debug("request denied")
+				setDecisionAttributes(span, false, reason)
+				events.PolicyDenied(r, claims.Group, spName, reason)
+				writeDenied(w, "powerflex", fmt.Sprintf("request denied: %v", reason), http.StatusBadRequest, web.Deny{Code: web.CodePolicyDenied, Reason: reason, Tenant: claims.Group, Pool: spName}, s.log)
+				return
+			}
+			permittedRoles, err := sizePermittedRoles(opaResp.Result.PermittedRoles, opaResp.Result.MinSizes, opaResp.Result.MaxSizes, int64(src.SizeInKb))
+			if err != nil {
+				reason := err.Error()
+				s.log.WithField("reason", reason).Debug("request denied")
+				setDecisionAttributes(span, false, reason)
+				events.PolicyDenied(r, claims.Group, spName, reason)
+				writeDenied(w, "powerflex", fmt.Sprintf("request denied: %v", reason), http.StatusBadRequest, web.Deny{Code: web.CodeVolumeSize, Reason: reason, Tenant: claims.Group, Pool: spName}, s.log)
+				return
+			}
+
+			qr := quota.Request{
+				SystemType:    "powerflex",
+				SystemID:      systemID,
+				StoragePoolID: spName,
+				Group:         tenantKey,
+				VolumeName:    def.SnapshotName,
+				Capacity:      sizeInKb,
+				MaxVolumes:    maxVolumes,
+				SoftQuota:     permittedSoftQuota(permittedRoles, opaResp.Result.SoftQuotas),
+			}
+			ok, err = enf.ApproveRequest(ctx, qr, maxPermittedQuota(permittedRoles))
+			if errors.Is(err, quota.ErrMaxVolumes) {
+				s.log.Debugln("request was not approved")
+				setDecisionAttributes(span, false, "maximum number of volumes reached")
+				events.QuotaExceeded(r, claims.Group, spName, "maximum number of volumes reached")
+				writeDenied(w, "powerflex", "request denied: maximum number of volumes reached", http.StatusInsufficientStorage, quotaDeny(ctx, enf, qr, claims.Group, web.CodeMaxVolumes, maxVolumes, s.log), s.log)
+				return
+			}
+			if errors.Is(err, quota.ErrGracePeriodExpired) {
+				s.log.Debugln("request was not approved")
+				setDecisionAttributes(span, false, "soft quota grace period expired")
+				events.QuotaExceeded(r, claims.Group, spName, "soft quota grace period expired")
+				writeDenied(w, "powerflex", "request denied: soft quota grace period expired", http.StatusInsufficientStorage, quotaDeny(ctx, enf, qr, claims.Group, web.CodeSoftQuotaExpired, qr.SoftQuota, s.log), s.log)
+				return
+			}
+			if err != nil {
+				s.log.WithError(err).Error("approving request")
+				writeError(w, "powerflex", "failed to approve request", http.StatusInternalServerError, s.log)
+				return
+			}
+			if !ok {
+				s.log.Debugln("request was not approved")
+				setDecisionAttributes(span, false, "not enough quota")
+				events.QuotaExceeded(r, claims.Group, spName, "not enough quota")
+				writeDenied(w, "powerflex", "request denied: not enough quota", http.StatusInsufficientStorage, quotaDeny(ctx, enf, qr, claims.Group, web.CodeQuotaExceeded, maxPermittedQuota(opaResp.Result.PermittedRoles), s.log), s.log)
+				return
+			}
+			approved = append(approved, qr)
+		}
+		setDecisionAttributes(span, true, "")
+		forwarded = true
+
+		// Reset the original request
+		r.Body = io.NopCloser(bytes.NewBuffer(b))
+		sw := &teeResponseWriter{
+			StatusWriter: web.StatusWriter{
+				ResponseWriter: w,
+			},
+		}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(sw, r)
+
+		s.log.WithFields(logrus.Fields{
+			"Response code": sw.Status,
+		}).Debug()
+		switch sw.Status {
+		case http.StatusOK:
+			// The snapshots are tracked by the IDs the PowerFlex assigned to
+			// them, which are in the order of the snapshot definitions.
+			var snapshots types.SnapshotVolumesResp
+			if err := json.Unmarshal(sw.body.Bytes(), &snapshots); err != nil || len(snapshots.VolumeIDList) != len(approved) {
+				s.log.WithError(err).Warn("decoding volume clones, tracking them by name")
+			} else {
+				for i := range approved {
+					approved[i].VolumeID = snapshots.VolumeIDList[i]
+				}
+			}
+			for _, qr := range approved {
+				ok, err := enf.PublishCreated(r.Context(), qr)
+				if err != nil {
+					s.log.WithError(err).Error("publishing volume clone created")
+					return
+				}
+				s.log.WithField("publish_result", ok).Debug("Publish volume clone created")
+			}
+		default:
+			s.log.Debugln("Non 200 response, nothing to publish")
+		}
+	})
+}
+
+// volumeModifyHandler only lets tenants change the volumes they own. Volumes
+// are renamed in the quota data too, so that they stay owned by the tenant.
+func (s *System) volumeModifyHandler(next http.Handler, enf *quota.RedisEnforcement) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "volumeModifyHandler")
+		defer span.End()
+
+		var systemID string
+		if v := r.Context().Value(web.SystemIDKey); v != nil {
+			var ok bool
+			if systemID, ok = v.(string); !ok {
+				writeError(w, "powerflex", http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError, s.log)
+				return
+			}
+		}
+
+		var id string
+		z := strings.SplitN(r.URL.Path, "/", 5)
+		if len(z) > 3 {
+			id = z[3]
+		} else {
+			writeError(w, "powerflex", "incomplete request", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, "powerflex", "failed to read body", http.StatusInternalServerError, s.log)
+			return
+		}
+		defer r.Body.Close()
+
+		rename := strings.HasSuffix(r.URL.Path, "/action/setVolumeName/")
+		var body types.SetVolumeNameParam
+		if rename {
+			err = json.Unmarshal(b, &body)
+			if err != nil || body.NewName == "" {
+				writeError(w, "powerflex", "failed to decode rename request", http.StatusBadRequest, s.log)
+				return
+			}
+		}
+
+		jwtValue := r.Context().Value(web.JWTKey)
+		jwtToken, ok := jwtValue.(token.Token)
+		if !ok {
+			writeError(w, "powerflex", "incorrect type for JWT token", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		claims, err := jwtToken.Claims()
+		if err != nil {
+			writeError(w, "powerflex", "decoding token claims", http.StatusInternalServerError, s.log)
+			return
+		}
+		tenantKey, err := tenantQuotaKey(ctx, enf, claims.Group)
+		if err != nil {
+			writeError(w, "powerflex", "resolving tenant", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		c, err := goscaleio.NewClientWithArgs(s.clientEndpoint(), s.tk.GetVersion(), 0, true, false)
+		if err != nil {
+			writeError(w, "powerflex", "failed to build powerflex client", http.StatusInternalServerError, s.log)
+			return
+		}
+		token, err := s.tk.GetToken(ctx)
+		if err != nil {
+			writeError(w, "powerflex", "failed to authenticate", http.StatusUnauthorized, s.log)
+			return
+		}
+		c.SetToken(token)
+
+		vols, err := c.GetVolume("", strings.TrimPrefix(id, "Volume::"), "", "", false)
+		if err != nil || len(vols) == 0 {
+			s.log.WithError(err).WithField("volume_id", id).Error("querying volume by id")
+			writeError(w, "powerflex", "query volume by volid", http.StatusInternalServerError, s.log)
+			return
+		}
+		vol := vols[0]
+
+		spName, err := s.spc.GetStoragePoolNameByID(ctx, s.tk, vol.StoragePoolID)
+		if err != nil {
+			writeError(w, "powerflex", "failed to query pool name from id", http.StatusBadRequest, s.log)
+			return
+		}
+
+		qr := quota.Request{
+			SystemType:    "powerflex",
+			SystemID:      systemID,
+			StoragePoolID: spName,
+			Group:         tenantKey,
+			VolumeName:    vol.Name,
+			VolumeID:      vol.ID,
+		}
+		ok, err = enf.ValidateOwnership(ctx, qr)
+		if err != nil {
+			writeError(w, "powerflex", "validating ownership failed", http.StatusInternalServerError, s.log)
+			return
+		}
+		if !ok {
+			setDecisionAttributes(span, false, "volume not owned by tenant")
+			writeDenied(w, "powerflex", "request denied", http.StatusForbidden, web.Deny{Code: web.CodeNotOwner, Reason: "volume not owned by tenant", Tenant: claims.Group, Pool: spName}, s.log)
+			return
+		}
+
+		if rename {
+			namePolicy, err := enf.VolumeNamePolicy(ctx, claims.Group)
+			if err != nil {
+				writeError(w, "powerflex", "resolving tenant volume name policy", http.StatusInternalServerError, s.log)
+				return
+			}
+			if err := namePolicy.Check(body.NewName); err != nil {
+				if !errors.Is(err, quota.ErrVolumeName) {
+					s.log.WithError(err).Error("checking volume name")
+					writeError(w, "powerflex", "failed to check volume name", http.StatusInternalServerError, s.log)
+					return
+				}
+				reason := err.Error()
+				setDecisionAttributes(span, false, reason)
+				writeDenied(w, "powerflex", fmt.Sprintf("request denied: %v", reason), http.StatusBadRequest, web.Deny{Code: web.CodeVolumeName, Reason: reason, Tenant: claims.Group, Pool: spName}, s.log)
+				return
+			}
+		}
+		setDecisionAttributes(span, true, "")
+
+		// Reset the original request
+		r.Body = io.NopCloser(bytes.NewBuffer(b))
+		sw := &web.StatusWriter{
+			ResponseWriter: w,
+		}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(sw, r)
+
+		if !rename || sw.Status != http.StatusOK {
+			return
+		}
+		ok, err = enf.RenameVolume(r.Context(), qr, body.NewName)
+		if err != nil {
+			s.log.WithError(err).WithFields(logrus.Fields{
+				"volume_name": vol.Name,
+				"new_name":    body.NewName,
+			}).Error("renaming volume in quota")
+			return
+		}
+		s.log.WithField("rename_result", ok).Debug("Renamed volume")
+	})
+}
+
 func (s *System) sdcApproveHandler(next http.Handler, sdcapp *sdc.RedisSdcApprover, opaHost string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "sdcApproveHandler")
@@ -939,7 +1704,7 @@ func (s *System) sdcApproveHandler(next http.Handler, sdcapp *sdc.RedisSdcApprov
 
 		s.log.Debugln("Asking OPA...")
 		// Request policy decision from OPA
-		ans, err := decision.Can(func() decision.Query {
+		ans, err := decision.CanWithContext(ctx, func() decision.Query {
 			return decision.Query{
 				Host:   opaHost,
 				Policy: "/karavi/sdc/approve",
@@ -962,11 +1727,12 @@ func (s *System) sdcApproveHandler(next http.Handler, sdcapp *sdc.RedisSdcApprov
 		}
 		s.log.WithField("opa_response", string(ans)).Debug("OPA Response")
 		if resp := opaResp.Result; !resp.Response.Allowed {
+			setDecisionAttributes(span, false, resp.Response.Status.Reason)
 			switch {
 			case resp.Claims.Group == "":
 				writeError(w, "powerflex", "invalid token", http.StatusUnauthorized, s.log)
 			default:
-				writeError(w, "powerflex", fmt.Sprintf("request denied: %v", resp.Response.Status.Reason), http.StatusBadRequest, s.log)
+				writeDenied(w, "powerflex", fmt.Sprintf("request denied: %v", resp.Response.Status.Reason), http.StatusBadRequest, web.Deny{Code: web.CodePolicyDenied, Reason: resp.Response.Status.Reason, Tenant: resp.Claims.Group}, s.log)
 			}
 			return
 		}
@@ -980,9 +1746,11 @@ func (s *System) sdcApproveHandler(next http.Handler, sdcapp *sdc.RedisSdcApprov
 			return
 		}
 		if !ok {
-			writeError(w, "powerflex", "sdc approve request denied", http.StatusForbidden, s.log)
+			setDecisionAttributes(span, false, "sdc approval disabled for tenant")
+			writeDenied(w, "powerflex", "sdc approve request denied", http.StatusForbidden, web.Deny{Code: web.CodeSdcNotAllowed, Reason: "sdc approval disabled for tenant", Tenant: group}, s.log)
 			return
 		}
+		setDecisionAttributes(span, true, "")
 
 		// Reset the original request
 		r.Body = io.NopCloser(bytes.NewBuffer(b))
@@ -1014,8 +1782,165 @@ type OPAResponse struct {
 // pool quota.
 type CreateOPAResponse struct {
 	Result struct {
-		Allow          bool              `json:"allow"`
-		Deny           []string          `json:"deny"`
-		PermittedRoles map[string]uint64 `json:"permitted_roles"`
+		Allow          bool             `json:"allow"`
+		Deny           []string         `json:"deny"`
+		PermittedRoles map[string]int64 `json:"permitted_roles"`
+		SoftQuotas     map[string]int64 `json:"soft_quotas"`
+		MinSizes       map[string]int64 `json:"min_sizes"`
+		MaxSizes       map[string]int64 `json:"max_sizes"`
+		// AlternativePools are the other pools of the storage system
+		// that the roles permit the request in, mapped to their quota.
+		AlternativePools map[string]int64 `json:"alternative_pools"`
 	} `json:"result"`
 }
+
+// volumeListHandler filters the responses of GET requests to the filtered
+// list endpoints to the volumes owned by the requesting tenant.
+func (s *System) volumeListHandler(next http.Handler, enf *quota.RedisEnforcement, filtered []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// observers are not tenants, so they see every volume
+		if r.Method != http.MethodGet || !isFilteredPath(filtered, r.URL.Path) || isObserver(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "volumeListHandler")
+		defer span.End()
+
+		var systemID string
+		if v := r.Context().Value(web.SystemIDKey); v != nil {
+			var ok bool
+			if systemID, ok = v.(string); !ok {
+				writeError(w, "powerflex", http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError, s.log)
+				return
+			}
+		}
+
+		jwtToken, ok := r.Context().Value(web.JWTKey).(token.Token)
+		if !ok {
+			writeError(w, "powerflex", "incorrect type for JWT token", http.StatusInternalServerError, s.log)
+			return
+		}
+		claims, err := jwtToken.Claims()
+		if err != nil {
+			writeError(w, "powerflex", "decoding token claims", http.StatusInternalServerError, s.log)
+			return
+		}
+		tenantKey, err := tenantQuotaKey(ctx, enf, claims.Group)
+		if err != nil {
+			writeError(w, "powerflex", "resolving tenant", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		owned := func(ctx context.Context, item map[string]json.RawMessage) (bool, error) {
+			var id, name, poolID string
+			if err := json.Unmarshal(item["id"], &id); err != nil {
+				return false, nil
+			}
+			if err := json.Unmarshal(item["name"], &name); err != nil {
+				return false, nil
+			}
+			if err := json.Unmarshal(item["storagePoolId"], &poolID); err != nil {
+				return false, nil
+			}
+			spName, err := s.spc.GetStoragePoolNameByID(ctx, s.tk, poolID)
+			if err != nil {
+				return false, err
+			}
+			return enf.ValidateOwnership(ctx, quota.Request{
+				SystemType:    "powerflex",
+				SystemID:      systemID,
+				StoragePoolID: spName,
+				Group:         tenantKey,
+				VolumeName:    name,
+				VolumeID:      id,
+			})
+		}
+		filterListHandler(next, s.log, owned).ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// maxPermittedQuota returns the largest quota among the roles permitting a
+// request, which is unlimited if any of the roles has an unlimited quota.
+func maxPermittedQuota(permittedRoles map[string]int64) int64 {
+	var maxQuotaInKb int64
+	for _, q := range permittedRoles {
+		if q < 0 {
+			return quota.Unlimited
+		}
+		if q >= maxQuotaInKb {
+			maxQuotaInKb = q
+		}
+	}
+	return maxQuotaInKb
+}
+
+// permittedSoftQuota returns the soft quota of the roles with the quota
+// chosen by maxPermittedQuota, the largest if there are several, or 0 if
+// one of them has no soft quota.
+func permittedSoftQuota(permittedRoles, softQuotas map[string]int64) int64 {
+	maxQuotaInKb := maxPermittedQuota(permittedRoles)
+	var softQuotaInKb int64
+	for role, q := range permittedRoles {
+		if q != maxQuotaInKb {
+			continue
+		}
+		soft, ok := softQuotas[role]
+		if !ok || soft <= 0 {
+			return 0
+		}
+		if soft > softQuotaInKb {
+			softQuotaInKb = soft
+		}
+	}
+	return softQuotaInKb
+}
+
+// errVolumeSize is the error for a volume request whose size is out of the
+// volume size bounds of every role permitting it.
+var errVolumeSize = errors.New("volume size not allowed")
+
+// sizePermittedRoles returns the permitted roles whose volume size bounds,
+// if any, allow a volume of the size. When none of them do, it returns an
+// error wrapping errVolumeSize that names the bounds of each role, as the
+// OPA volume create policies do.
+func sizePermittedRoles(permittedRoles, minSizes, maxSizes map[string]int64, sizeInKb int64) (map[string]int64, error) {
+	ret := make(map[string]int64)
+	var reasons []string
+	for role, q := range permittedRoles {
+		if minKb := minSizes[role]; minKb > 0 && sizeInKb < minKb {
+			reasons = append(reasons, fmt.Sprintf("%d Kb is below the minSize %d Kb of role %s", sizeInKb, minKb, role))
+			continue
+		}
+		if maxKb := maxSizes[role]; maxKb > 0 && sizeInKb > maxKb {
+			reasons = append(reasons, fmt.Sprintf("%d Kb is above the maxSize %d Kb of role %s", sizeInKb, maxKb, role))
+			continue
+		}
+		ret[role] = q
+	}
+	if len(ret) == 0 && len(reasons) > 0 {
+		sort.Strings(reasons)
+		return nil, fmt.Errorf("%w: %s", errVolumeSize, strings.Join(reasons, ","))
+	}
+	return ret, nil
+}
+
+// tenantQuotaKey returns the identifier that the requesting tenant's quota
+// data is stored under. Tokens issued before the tenant was assigned a UUID
+// are resolved by the tenant name.
+func tenantQuotaKey(ctx context.Context, enf *quota.RedisEnforcement, group string) (string, error) {
+	if id, ok := ctx.Value(web.JWTTenantID).(string); ok && id != "" {
+		return id, nil
+	}
+	return enf.TenantID(ctx, group)
+}
+
+// volumeNameInput returns the OPA input for the volume name policy of the
+// requesting tenant, checked by the volume create policies.
+func volumeNameInput(name string, p quota.VolumeNamePolicy) map[string]interface{} {
+	return map[string]interface{}{
+		"name":    name,
+		"prefix":  p.Prefix,
+		"pattern": p.Pattern,
+	}
+}
@@ -32,12 +32,15 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis"
 	redisclient "github.com/go-redis/redis"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 )
 
@@ -682,10 +685,14 @@ func TestPowerFlex(t *testing.T) {
 		// Create a redis enforcer
 		rdb := testCreateRedisInstance(t)
 		enf := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rdb))
+		sdcapp := sdc.NewSdcApprover(context.Background(), sdc.WithRedis(rdb))
+		if err := rdb.HSet(mocktenantKey("TestingGroup"), "approve_sdc", true).Err(); err != nil {
+			t.Fatal(err)
+		}
 
 		// Create the PowerFlex handler and configure it with a system
 		// where the endpoint is our test server.
-		powerFlexHandler := proxy.NewPowerFlexHandler(log, enf, nil, hostPort(t, fakeOPA.URL))
+		powerFlexHandler := proxy.NewPowerFlexHandler(log, enf, sdcapp, hostPort(t, fakeOPA.URL))
 		powerFlexHandler.UpdateSystems(context.Background(), strings.NewReader(fmt.Sprintf(`
 {
   "powerflex": {
@@ -823,7 +830,9 @@ func TestPowerFlex(t *testing.T) {
 		rtr := newTestRouter()
 
 		// Create a PowerFlexHandler and update it with the fake PowerFlex
-		powerFlexHandler := proxy.NewPowerFlexHandler(log, nil, nil, hostPort(t, fakeOPA.URL))
+		// The tenant has no volume name policy
+		enf := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})))
+		powerFlexHandler := proxy.NewPowerFlexHandler(log, enf, nil, hostPort(t, fakeOPA.URL))
 
 		// Cancel the powerflex token getter so we don't get any race conditions with the fakePowerFlex server
 		systemCtx, cancel := context.WithCancel(context.Background())
@@ -998,9 +1007,10 @@ func TestPowerFlex(t *testing.T) {
 
 		// Unmarshal the response and check for the exepcted http status code
 		errBody := struct {
-			Code       int    `json:"errorCode"`
-			StatusCode int    `json:"httpStatusCode"`
-			Message    string `json:"message"`
+			Code       int      `json:"errorCode"`
+			StatusCode int      `json:"httpStatusCode"`
+			Message    string   `json:"message"`
+			Deny       web.Deny `json:"deny"`
 		}{}
 
 		err = json.Unmarshal(w.Body.Bytes(), &errBody)
@@ -1011,6 +1021,166 @@ func TestPowerFlex(t *testing.T) {
 		if w.Code != http.StatusInsufficientStorage {
 			t.Errorf("expected status %d, got %d", http.StatusInsufficientStorage, w.Code)
 		}
+		want := web.Deny{Code: web.CodeQuotaExceeded, Reason: "not enough quota", Tenant: "mygroup", Pool: "notAllowed", Limit: 1}
+		if !reflect.DeepEqual(errBody.Deny, want) {
+			t.Errorf("expected deny %+v, got %+v", want, errBody.Deny)
+		}
+	})
+
+	t.Run("provisioning request with a volume name the tenant's policy does not allow", func(t *testing.T) {
+		// Logging
+		log := logrus.New().WithContext(context.Background())
+		log.Logger.SetOutput(os.Stdout)
+
+		// Stand up a docker Redis instance and create a Redis Enforcement for quota validation
+		rdb := testCreateRedisInstance(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		enf := quota.NewRedisEnforcement(ctx, quota.WithRedis(rdb))
+
+		// Names of the volumes of the tenant must start with "pay-"
+		err := rdb.HSet("tenant:mygroup:data", quota.VolumePrefixField, "pay-").Err()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rdb.HDel("tenant:mygroup:data", quota.VolumePrefixField)
+
+		// Prepare the create volume payload and request
+		body := struct {
+			Name           string `json:"name"`
+			VolumeSize     uint64
+			VolumeSizeInKb string `json:"volumeSizeInKb"`
+			StoragePoolID  string `json:"storagePoolId"`
+		}{
+			Name:           "k8s-0fc0695995",
+			VolumeSize:     10,
+			VolumeSizeInKb: "10",
+			StoragePoolID:  "3df6b86600000000",
+		}
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload := bytes.NewBuffer(data)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/types/Volume/instances/", payload)
+
+		// Add a jwt token to the request context
+		// In production, the jwt token would have the role information for OPA to make a decision on
+		// Since we are faking the OPA server, the jwt token doesn't require real info for the unit test
+		reqCtx := context.WithValue(context.Background(), web.JWTKey, token.Token(&jwx.Token{}))
+		reqCtx = context.WithValue(reqCtx, web.JWTTenantName, "mygroup")
+		r = r.WithContext(reqCtx)
+
+		// Build a httptest server to fake OPA with a policy that does not
+		// check the volume name, recording the input it is asked about
+		var gotInput struct {
+			Input struct {
+				VolumeName map[string]string `json:"volumename"`
+			} `json:"input"`
+		}
+		fakeOPA := buildTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			// This path validates a supported request path (/api/types/Volume/instances/), see policies/url.rego
+			case "/v1/data/karavi/authz/url":
+				w.Write([]byte(`{"result": {"allow": true}}`))
+			// This path returns the OPA decision to allow a create volume request in the requested storage pool
+			// Note: this is not when the quota is validated, that happens with Redis
+			case "/v1/data/karavi/volumes/create":
+				if err := json.NewDecoder(r.Body).Decode(&gotInput); err != nil {
+					t.Error(err)
+				}
+				w.Write([]byte(fmt.Sprintf(`{
+					"result": {
+						"allow": true,
+						"permitted_roles": {
+							"role": 1
+						}
+					}}`)))
+			default:
+				t.Fatalf("OPA path %s not supported", r.URL.Path)
+			}
+		}))
+
+		// Build a httptest TLS server to fake PowerFlex
+		fakePowerFlex := buildTestTLSServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/login" {
+				w.Write([]byte("token"))
+			}
+			if r.URL.Path == "/api/version" {
+				w.Write([]byte("3.5"))
+			}
+			if r.URL.Path == "/api/types/StoragePool/instances" {
+				data, err := os.ReadFile("testdata/storage_pool_instances.json")
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Write(data)
+			}
+		}))
+
+		// Add headers that the sidecar-proxy would add, in order to identify
+		// the request as intended for a PowerFlex with the given systemID.
+		r.Header.Add("Forwarded", "by=csm-authorization;csi-vxflexos")
+		r.Header.Add("Forwarded", fmt.Sprintf("for=csm-authorization;https://%s;542a2d5f5122210f", fakePowerFlex.URL))
+		rtr := newTestRouter()
+
+		// Create a PowerFlexHandler and update it with the fake PowerFlex
+		powerFlexHandler := proxy.NewPowerFlexHandler(log, enf, nil, hostPort(t, fakeOPA.URL))
+
+		// Cancel the powerflex token getter so we don't get any race conditions with the fakePowerFlex server
+		systemCtx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		powerFlexHandler.UpdateSystems(systemCtx, strings.NewReader(fmt.Sprintf(`
+		{
+		  "powerflex": {
+			"542a2d5f5122210f": {
+			  "endpoint": "%s",
+			  "user": "admin",
+			  "pass": "Password123",
+			  "insecure": true
+			}
+		  }
+		}
+		`, fakePowerFlex.URL)), logrus.New().WithContext(context.Background()))
+
+		// Create a dispatch handler with the powerFlexHandler
+		systemHandlers := map[string]http.Handler{
+			"powerflex": web.Adapt(powerFlexHandler),
+		}
+		dh := proxy.NewDispatchHandler(log, systemHandlers)
+		rtr.ProxyHandler = dh
+		h := web.Adapt(rtr.Handler(), web.CleanMW())
+
+		// Serve the request
+		h.ServeHTTP(w, r)
+
+		// Unmarshal the response and check for the exepcted http status code
+		errBody := struct {
+			Code       int      `json:"errorCode"`
+			StatusCode int      `json:"httpStatusCode"`
+			Message    string   `json:"message"`
+			Deny       web.Deny `json:"deny"`
+		}{}
+
+		err = json.Unmarshal(w.Body.Bytes(), &errBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		want := web.Deny{Code: web.CodeVolumeName, Reason: "volume name not allowed: k8s-0fc0695995 does not start with pay-", Tenant: "mygroup", Pool: "notAllowed"}
+		if !reflect.DeepEqual(errBody.Deny, want) {
+			t.Errorf("expected deny %+v, got %+v", want, errBody.Deny)
+		}
+		wantInput := map[string]string{"name": "k8s-0fc0695995", "prefix": "pay-", "pattern": ""}
+		if !reflect.DeepEqual(gotInput.Input.VolumeName, wantInput) {
+			t.Errorf("expected OPA volume name input %v, got %v", wantInput, gotInput.Input.VolumeName)
+		}
 	})
 
 	// This is the happy path test scenario. A tenent makes a request against a pool within the set quota limit
@@ -1462,6 +1632,833 @@ func TestPowerFlex(t *testing.T) {
 	})
 }
 
+func TestPowerFlexVolumeClone(t *testing.T) {
+	log := logrus.New().WithContext(context.Background())
+	log.Logger.SetOutput(io.Discard)
+
+	tm := jwx.NewTokenManager(jwx.HS256)
+	tkn, err := tm.NewWithClaims(token.Claims{
+		Issuer:    "com.dell.karavi",
+		ExpiresAt: time.Now().Add(30 * time.Second).Unix(),
+		Audience:  "karavi",
+		Subject:   "Alice",
+		Roles:     "DevTesting",
+		Group:     "TestingGroup",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var snapshotted bool
+	fakePowerFlex := buildTestTLSServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/instances/System::542a2d5f5122210f/action/snapshotVolumes/":
+			snapshotted = true
+			w.Write([]byte(`{"volumeIdList": ["000000000000002"], "snapshotGroupId": "1"}`))
+		case "/api/instances/Volume::000000000000001":
+			w.Write([]byte(`{"sizeInKb":10, "storagePoolId":"3df6b86600000000", "name": "TestVolume"}`))
+		case "/api/login":
+			w.Write([]byte("token"))
+		case "/api/version":
+			w.Write([]byte("3.5"))
+		case "/api/types/StoragePool/instances":
+			w.Write([]byte(`[{"protectionDomainId": "75b661b400000000", "mediaType": "HDD", "id": "3df6b86600000000", "name": "TestPool"}]`))
+		default:
+			t.Errorf("Unexpected api call to fake PowerFlex: %v", r.URL.Path)
+		}
+	}))
+	fakeOPA := buildTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/data/karavi/authz/url":
+			w.Write([]byte(`{"result": {"allow": true}}`))
+		case "/v1/data/karavi/volumes/create":
+			w.Write([]byte(`{"result": {"allow": true, "permitted_roles": {"role": 15}}}`))
+		default:
+			t.Errorf("Unexpected OPA request: %v", r.URL.Path)
+		}
+	}))
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	enf := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()})))
+
+	powerFlexHandler := proxy.NewPowerFlexHandler(log, enf, nil, hostPort(t, fakeOPA.URL))
+	powerFlexHandler.UpdateSystems(context.Background(), strings.NewReader(fmt.Sprintf(`
+	{
+	  "powerflex": {
+	    "542a2d5f5122210f": {
+	      "endpoint": "%s",
+	      "user": "admin",
+	      "pass": "Password123",
+	      "insecure": true
+	    }
+	  }
+	}
+	`, fakePowerFlex.URL)), logrus.New().WithContext(context.Background()))
+	rtr := newTestRouter()
+	rtr.ProxyHandler = proxy.NewDispatchHandler(log, map[string]http.Handler{
+		"powerflex": web.Adapt(powerFlexHandler),
+	})
+	h := web.Adapt(rtr.Handler(), web.CleanMW())
+
+	clone := func(t *testing.T, snapshotNames ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		var defs []string
+		for _, name := range snapshotNames {
+			defs = append(defs, fmt.Sprintf(`{"volumeId": "000000000000001", "snapshotName": "%s"}`, name))
+		}
+		payload := fmt.Sprintf(`{"snapshotDefs": [%s]}`, strings.Join(defs, ","))
+		r := httptest.NewRequest(http.MethodPost, "/api/instances/System::542a2d5f5122210f/action/snapshotVolumes/", strings.NewReader(payload))
+		ctx := context.WithValue(context.Background(), web.JWTKey, tkn)
+		ctx = context.WithValue(ctx, web.JWTTenantName, "TestingGroup")
+		r = r.WithContext(ctx)
+		r.Header.Add("Forwarded", "by=csm-authorization;csi-vxflexos")
+		r.Header.Add("Forwarded", fmt.Sprintf("for=csm-authorization;%s;542a2d5f5122210f", fakePowerFlex.URL))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	source := quota.Request{
+		SystemType:    "powerflex",
+		SystemID:      "542a2d5f5122210f",
+		StoragePoolID: "TestPool",
+		Group:         "TestingGroup",
+		VolumeName:    "TestVolume",
+	}
+
+	t.Run("it denies cloning a volume the tenant does not own", func(t *testing.T) {
+		snapshotted = false
+
+		w := clone(t, "k8s-clone-0")
+
+		if got, want := w.Code, http.StatusForbidden; got != want {
+			t.Errorf("got %d, want %d: %s", got, want, w.Body.String())
+		}
+		if snapshotted {
+			t.Error("expected request not to be forwarded to PowerFlex")
+		}
+	})
+
+	t.Run("it releases the approved clones if a later clone is denied", func(t *testing.T) {
+		snapshotted = false
+		mr.HSet(source.DataKey(), source.CreatedField(), "1")
+
+		// The second clone exceeds the quota once the first is approved.
+		w := clone(t, "k8s-clone-a", "k8s-clone-b")
+
+		if got, want := w.Code, http.StatusInsufficientStorage; got != want {
+			t.Errorf("got %d, want %d: %s", got, want, w.Body.String())
+		}
+		if snapshotted {
+			t.Error("expected request not to be forwarded to PowerFlex")
+		}
+		if got := mr.HGet(source.DataKey(), source.ApprovedCapacityField()); got != "0" {
+			t.Errorf("got approved capacity %q, want 0", got)
+		}
+		if got := mr.HGet(source.DataKey(), source.ApprovedVolumesField()); got != "0" {
+			t.Errorf("got approved volumes %q, want 0", got)
+		}
+	})
+
+	t.Run("it approves and records a clone of an owned volume", func(t *testing.T) {
+		snapshotted = false
+
+		w := clone(t, "k8s-clone-1")
+
+		if got, want := w.Code, http.StatusOK; got != want {
+			t.Fatalf("got %d, want %d: %s", got, want, w.Body.String())
+		}
+		if !snapshotted {
+			t.Error("expected request to be forwarded to PowerFlex")
+		}
+		cloned := source
+		cloned.VolumeName = "k8s-clone-1"
+		cloned.VolumeID = "000000000000002"
+		if got := mr.HGet(cloned.DataKey(), cloned.CreatedField()); got != "1" {
+			t.Errorf("expected clone to be recorded as created, got %q", got)
+		}
+		if got := mr.HGet(cloned.DataKey(), cloned.NameIndexField()); got != cloned.VolumeID {
+			t.Errorf("got clone indexed as %q, want %q", got, cloned.VolumeID)
+		}
+		if got := mr.HGet(cloned.DataKey(), cloned.ApprovedCapacityField()); got != "10" {
+			t.Errorf("got approved capacity %q, want 10", got)
+		}
+	})
+
+	t.Run("it denies a clone that exceeds the tenant's quota", func(t *testing.T) {
+		snapshotted = false
+
+		w := clone(t, "k8s-clone-2")
+
+		if got, want := w.Code, http.StatusInsufficientStorage; got != want {
+			t.Errorf("got %d, want %d: %s", got, want, w.Body.String())
+		}
+		if snapshotted {
+			t.Error("expected request not to be forwarded to PowerFlex")
+		}
+	})
+}
+
+func TestPowerFlexVolumeModify(t *testing.T) {
+	log := logrus.New().WithContext(context.Background())
+	log.Logger.SetOutput(io.Discard)
+
+	tm := jwx.NewTokenManager(jwx.HS256)
+	tkn, err := tm.NewWithClaims(token.Claims{
+		Issuer:    "com.dell.karavi",
+		ExpiresAt: time.Now().Add(30 * time.Second).Unix(),
+		Audience:  "karavi",
+		Subject:   "Alice",
+		Roles:     "DevTesting",
+		Group:     "TestingGroup",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var forwarded []string
+	fakePowerFlex := buildTestTLSServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/instances/Volume::000000000000001/action/setVolumeName/",
+			"/api/instances/Volume::000000000000001/action/setVolumeRmcacheUsage/":
+			forwarded = append(forwarded, r.URL.Path)
+		case "/api/instances/Volume::000000000000001":
+			w.Write([]byte(`{"id": "000000000000001", "sizeInKb":10, "storagePoolId":"3df6b86600000000", "name": "TestVolume"}`))
+		case "/api/login":
+			w.Write([]byte("token"))
+		case "/api/version":
+			w.Write([]byte("3.5"))
+		case "/api/types/StoragePool/instances":
+			w.Write([]byte(`[{"protectionDomainId": "75b661b400000000", "mediaType": "HDD", "id": "3df6b86600000000", "name": "TestPool"}]`))
+		default:
+			t.Errorf("Unexpected api call to fake PowerFlex: %v", r.URL.Path)
+		}
+	}))
+	fakeOPA := buildTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/data/karavi/authz/url":
+			w.Write([]byte(`{"result": {"allow": true}}`))
+		default:
+			t.Errorf("Unexpected OPA request: %v", r.URL.Path)
+		}
+	}))
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	enf := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()})))
+
+	powerFlexHandler := proxy.NewPowerFlexHandler(log, enf, nil, hostPort(t, fakeOPA.URL))
+	powerFlexHandler.UpdateSystems(context.Background(), strings.NewReader(fmt.Sprintf(`
+	{
+	  "powerflex": {
+	    "542a2d5f5122210f": {
+	      "endpoint": "%s",
+	      "user": "admin",
+	      "pass": "Password123",
+	      "insecure": true
+	    }
+	  }
+	}
+	`, fakePowerFlex.URL)), logrus.New().WithContext(context.Background()))
+	rtr := newTestRouter()
+	rtr.ProxyHandler = proxy.NewDispatchHandler(log, map[string]http.Handler{
+		"powerflex": web.Adapt(powerFlexHandler),
+	})
+	h := web.Adapt(rtr.Handler(), web.CleanMW())
+
+	modify := func(t *testing.T, action, payload string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodPost, "/api/instances/Volume::000000000000001/action/"+action+"/", strings.NewReader(payload))
+		ctx := context.WithValue(context.Background(), web.JWTKey, tkn)
+		ctx = context.WithValue(ctx, web.JWTTenantName, "TestingGroup")
+		r = r.WithContext(ctx)
+		r.Header.Add("Forwarded", "by=csm-authorization;csi-vxflexos")
+		r.Header.Add("Forwarded", fmt.Sprintf("for=csm-authorization;%s;542a2d5f5122210f", fakePowerFlex.URL))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	vol := quota.Request{
+		SystemType:    "powerflex",
+		SystemID:      "542a2d5f5122210f",
+		StoragePoolID: "TestPool",
+		Group:         "TestingGroup",
+		VolumeName:    "TestVolume",
+	}
+
+	t.Run("it denies renaming a volume the tenant does not own", func(t *testing.T) {
+		forwarded = nil
+
+		w := modify(t, "setVolumeName", `{"newName": "k8s-renamed"}`)
+
+		if got, want := w.Code, http.StatusForbidden; got != want {
+			t.Errorf("got %d, want %d: %s", got, want, w.Body.String())
+		}
+		if len(forwarded) != 0 {
+			t.Error("expected request not to be forwarded to PowerFlex")
+		}
+	})
+
+	t.Run("it denies changing a volume the tenant does not own", func(t *testing.T) {
+		forwarded = nil
+
+		w := modify(t, "setVolumeRmcacheUsage", `{"useRmcache": "true"}`)
+
+		if got, want := w.Code, http.StatusForbidden; got != want {
+			t.Errorf("got %d, want %d: %s", got, want, w.Body.String())
+		}
+		if len(forwarded) != 0 {
+			t.Error("expected request not to be forwarded to PowerFlex")
+		}
+	})
+
+	t.Run("it denies a name the tenant's policy does not allow", func(t *testing.T) {
+		forwarded = nil
+		mr.HSet(vol.DataKey(), vol.CreatedField(), "1")
+		mr.HSet("tenant:TestingGroup:data", quota.VolumePrefixField, "k8s-")
+		defer mr.HDel("tenant:TestingGroup:data", quota.VolumePrefixField)
+
+		w := modify(t, "setVolumeName", `{"newName": "renamed"}`)
+
+		if got, want := w.Code, http.StatusBadRequest; got != want {
+			t.Errorf("got %d, want %d: %s", got, want, w.Body.String())
+		}
+		if len(forwarded) != 0 {
+			t.Error("expected request not to be forwarded to PowerFlex")
+		}
+	})
+
+	t.Run("it renames an owned volume tracked by name in the quota data", func(t *testing.T) {
+		forwarded = nil
+		mr.HSet(vol.DataKey(), vol.ApprovedField(), "1")
+		mr.HSet(vol.DataKey(), vol.CreatedField(), "1")
+
+		w := modify(t, "setVolumeName", `{"newName": "k8s-renamed"}`)
+
+		if got, want := w.Code, http.StatusOK; got != want {
+			t.Fatalf("got %d, want %d: %s", got, want, w.Body.String())
+		}
+		if len(forwarded) != 1 {
+			t.Error("expected request to be forwarded to PowerFlex")
+		}
+		byID := vol
+		byID.VolumeID = "000000000000001"
+		if got := mr.HGet(byID.DataKey(), byID.CreatedField()); got != "1" {
+			t.Errorf("expected the volume to be tracked by ID, got %q", got)
+		}
+		if got := mr.HGet(byID.DataKey(), byID.NameField()); got != "k8s-renamed" {
+			t.Errorf("got name %q, want k8s-renamed", got)
+		}
+		if got := mr.HGet(vol.DataKey(), vol.CreatedField()); got != "" {
+			t.Errorf("expected the previous name to no longer be owned, got %q", got)
+		}
+	})
+}
+
+func TestPowerFlexVolumeListFiltering(t *testing.T) {
+	log := logrus.New().WithContext(context.Background())
+	log.Logger.SetOutput(io.Discard)
+
+	tm := jwx.NewTokenManager(jwx.HS256)
+	tkn, err := tm.NewWithClaims(token.Claims{
+		Issuer:    "com.dell.karavi",
+		ExpiresAt: time.Now().Add(30 * time.Second).Unix(),
+		Audience:  "karavi",
+		Subject:   "Alice",
+		Roles:     "DevTesting",
+		Group:     "TestingGroup",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	volumes := `[{"id": "1", "name": "OwnedVolume", "storagePoolId": "3df6b86600000000"}, {"id": "2", "name": "OtherVolume", "storagePoolId": "3df6b86600000000"}]`
+	fakePowerFlex := buildTestTLSServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/types/Volume/instances/":
+			w.Write([]byte(volumes))
+		case "/api/instances/Volume::2/":
+			w.Write([]byte(`{"id": "2", "name": "OtherVolume", "storagePoolId": "3df6b86600000000"}`))
+		case "/api/login":
+			w.Write([]byte("token"))
+		case "/api/version":
+			w.Write([]byte("3.5"))
+		case "/api/types/StoragePool/instances":
+			w.Write([]byte(`[{"protectionDomainId": "75b661b400000000", "mediaType": "HDD", "id": "3df6b86600000000", "name": "TestPool"}]`))
+		default:
+			t.Errorf("Unexpected api call to fake PowerFlex: %v", r.URL.Path)
+		}
+	}))
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	enf := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()})))
+	owned := quota.Request{
+		SystemType:    "powerflex",
+		SystemID:      "542a2d5f5122210f",
+		StoragePoolID: "TestPool",
+		Group:         "TestingGroup",
+		VolumeName:    "OwnedVolume",
+	}
+	mr.HSet(owned.DataKey(), owned.CreatedField(), "1")
+
+	powerFlexHandler := proxy.NewPowerFlexHandler(log, enf, nil, "")
+	powerFlexHandler.UpdateSystems(context.Background(), strings.NewReader(fmt.Sprintf(`
+	{
+	  "powerflex": {
+	    "542a2d5f5122210f": {
+	      "endpoint": "%s",
+	      "user": "admin",
+	      "pass": "Password123",
+	      "insecure": true
+	    }
+	  }
+	}
+	`, fakePowerFlex.URL)), logrus.New().WithContext(context.Background()))
+	rtr := newTestRouter()
+	rtr.ProxyHandler = proxy.NewDispatchHandler(log, map[string]http.Handler{
+		"powerflex": web.Adapt(powerFlexHandler),
+	})
+	h := web.Adapt(rtr.Handler(), web.CleanMW())
+
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r = r.WithContext(context.WithValue(context.Background(), web.JWTKey, tkn))
+		r.Header.Add("Forwarded", "by=csm-authorization;csi-vxflexos")
+		r.Header.Add("Forwarded", fmt.Sprintf("for=csm-authorization;%s;542a2d5f5122210f", fakePowerFlex.URL))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("it lists only the volumes owned by the tenant", func(t *testing.T) {
+		w := get(t, "/api/types/Volume/instances/")
+
+		if got, want := w.Code, http.StatusOK; got != want {
+			t.Fatalf("got %d, want %d: %s", got, want, w.Body.String())
+		}
+		body := w.Body.String()
+		var got []struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal([]byte(body), &got); err != nil {
+			t.Fatal(err)
+		}
+		if cl := w.Header().Get("Content-Length"); cl != fmt.Sprint(len(body)) {
+			t.Errorf("got content length %s, want %d", cl, len(body))
+		}
+		if len(got) != 1 || got[0].Name != "OwnedVolume" {
+			t.Errorf("expected only the owned volume, got %+v", got)
+		}
+	})
+
+	t.Run("it does not filter other endpoints", func(t *testing.T) {
+		w := get(t, "/api/instances/Volume::2/")
+
+		if got, want := w.Code, http.StatusOK; got != want {
+			t.Fatalf("got %d, want %d: %s", got, want, w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), "OtherVolume") {
+			t.Errorf("expected the volume to be returned, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("it does not filter when no paths are configured", func(t *testing.T) {
+		powerFlexHandler.SetFilteredPaths(nil)
+		defer powerFlexHandler.SetFilteredPaths(proxy.DefaultPowerFlexFilteredPaths)
+
+		w := get(t, "/api/types/Volume/instances/")
+
+		var got []struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 {
+			t.Errorf("expected all volumes, got %+v", got)
+		}
+	})
+}
+
+func TestPowerFlexHandler_TokenGetters(t *testing.T) {
+	const systemID = "7045c4cc20dffc0f"
+	fakePowerFlex := buildTestTLSServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/login":
+			fmt.Fprintf(w, `"token"`)
+		case "/api/version":
+			fmt.Fprintf(w, `"4.0"`)
+		}
+	}))
+	log := logrus.New().WithContext(context.Background())
+	log.Logger.SetOutput(io.Discard)
+	config := fmt.Sprintf(`{"powerflex": {"%s": {"endpoint": "%s", "user": "admin", "pass": "Password123", "insecure": true}}}`, systemID, fakePowerFlex.URL)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(proxy.Collectors()...)
+	getters := func() float64 {
+		return gaugeValue(t, reg, "karavi_proxy_token_getters", systemID)
+	}
+	waitFor := func(want float64) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for getters() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("got %v token getters, want %v", getters(), want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	t.Run("it stops the token getters of replaced systems", func(t *testing.T) {
+		h := proxy.NewPowerFlexHandler(log, nil, nil, "")
+		for i := 0; i < 10; i++ {
+			if err := h.UpdateSystems(context.Background(), strings.NewReader(config), log); err != nil {
+				t.Fatal(err)
+			}
+		}
+		waitFor(1)
+
+		t.Run("and of removed systems", func(t *testing.T) {
+			if err := h.UpdateSystems(context.Background(), strings.NewReader(`{"powerflex": {}}`), log); err != nil {
+				t.Fatal(err)
+			}
+			waitFor(0)
+		})
+	})
+}
+
+func TestPowerFlexVolumeMapSdcApproval(t *testing.T) {
+	log := logrus.New().WithContext(context.Background())
+	log.Logger.SetOutput(io.Discard)
+
+	tm := jwx.NewTokenManager(jwx.HS256)
+	tkn, err := tm.NewWithClaims(token.Claims{
+		Issuer:    "com.dell.karavi",
+		ExpiresAt: time.Now().Add(30 * time.Second).Unix(),
+		Audience:  "karavi",
+		Subject:   "Alice",
+		Roles:     "DevTesting",
+		Group:     "TestingGroup",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mapped bool
+	fakePowerFlex := buildTestTLSServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/instances/Volume::000000000000001/action/addMappedSdc/":
+			mapped = true
+			w.WriteHeader(http.StatusOK)
+		case "/api/instances/Volume::000000000000001":
+			w.Write([]byte(`{"sizeInKb":10, "storagePoolId":"3df6b86600000000", "name": "TestVolume"}`))
+		case "/api/instances/Sdc::approved":
+			w.Write([]byte(`{"id": "approved", "sdcApproved": true, "sdcGuid": "approved-guid", "SdcIp": "10.0.0.1"}`))
+		case "/api/instances/Sdc::unapproved":
+			w.Write([]byte(`{"id": "unapproved", "sdcApproved": false, "sdcGuid": "unapproved-guid", "SdcIp": "10.0.0.2"}`))
+		case "/api/login":
+			w.Write([]byte("token"))
+		case "/api/version":
+			w.Write([]byte("3.5"))
+		case "/api/types/StoragePool/instances":
+			w.Write([]byte(`[{"protectionDomainId": "75b661b400000000", "mediaType": "HDD", "id": "3df6b86600000000", "name": "TestPool"}]`))
+		default:
+			t.Errorf("Unexpected api call to fake PowerFlex: %v", r.URL.Path)
+		}
+	}))
+	fakeOPA := buildTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/data/karavi/authz/url":
+			w.Write([]byte(`{"result": {"allow": true}}`))
+		case "/v1/data/karavi/volumes/map":
+			w.Write([]byte(`{"result": {"claims": {"group": "TestingGroup"}, "response": {"allowed": true}}}`))
+		default:
+			t.Errorf("Unexpected OPA request: %v", r.URL.Path)
+		}
+	}))
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	enf := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rdb))
+	sdcapp := sdc.NewSdcApprover(context.Background(), sdc.WithRedis(rdb))
+
+	owned := quota.Request{
+		SystemType:    "powerflex",
+		SystemID:      "542a2d5f5122210f",
+		StoragePoolID: "TestPool",
+		Group:         "TestingGroup",
+		VolumeName:    "TestVolume",
+	}
+	mr.HSet(owned.DataKey(), owned.CreatedField(), "1")
+
+	powerFlexHandler := proxy.NewPowerFlexHandler(log, enf, sdcapp, hostPort(t, fakeOPA.URL))
+	powerFlexHandler.UpdateSystems(context.Background(), strings.NewReader(fmt.Sprintf(`
+	{
+	  "powerflex": {
+	    "542a2d5f5122210f": {
+	      "endpoint": "%s",
+	      "user": "admin",
+	      "pass": "Password123",
+	      "insecure": true
+	    }
+	  }
+	}
+	`, fakePowerFlex.URL)), logrus.New().WithContext(context.Background()))
+	rtr := newTestRouter()
+	rtr.ProxyHandler = proxy.NewDispatchHandler(log, map[string]http.Handler{
+		"powerflex": web.Adapt(powerFlexHandler),
+	})
+	h := web.Adapt(rtr.Handler(), web.CleanMW())
+
+	mapVolumeWith := func(t *testing.T, payload string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodPost, "/api/instances/Volume::000000000000001/action/addMappedSdc/", strings.NewReader(payload))
+		ctx := context.WithValue(context.Background(), web.JWTKey, tkn)
+		ctx = context.WithValue(ctx, web.JWTTenantName, "TestingGroup")
+		r = r.WithContext(ctx)
+		r.Header.Add("Forwarded", "by=csm-authorization;csi-vxflexos")
+		r.Header.Add("Forwarded", fmt.Sprintf("for=csm-authorization;%s;542a2d5f5122210f", fakePowerFlex.URL))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	mapVolume := func(t *testing.T, sdcID string) *httptest.ResponseRecorder {
+		t.Helper()
+		return mapVolumeWith(t, fmt.Sprintf(`{"sdcId": "%s"}`, sdcID))
+	}
+
+	tests := []struct {
+		name       string
+		approveSdc string
+		sdcID      string
+		wantCode   int
+	}{
+		{"it maps to an unapproved sdc when the tenant may approve sdcs", "true", "unapproved", http.StatusOK},
+		{"it maps to an approved sdc when the tenant may not approve sdcs", "false", "approved", http.StatusOK},
+		{"it denies mapping to an unapproved sdc when the tenant may not approve sdcs", "false", "unapproved", http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mapped = false
+			mr.HSet(mocktenantKey("TestingGroup"), "approve_sdc", tc.approveSdc)
+
+			w := mapVolume(t, tc.sdcID)
+
+			if got := w.Code; got != tc.wantCode {
+				t.Errorf("got %d, want %d: %s", got, tc.wantCode, w.Body.String())
+			}
+			if want := tc.wantCode == http.StatusOK; mapped != want {
+				t.Errorf("forwarded to PowerFlex: got %v, want %v", mapped, want)
+			}
+		})
+	}
+
+	allowed := sdc.Request{Group: "TestingGroup"}
+	mr.HSet(mocktenantKey("TestingGroup"), "approve_sdc", "true")
+	if _, err := mr.SAdd(allowed.AllowedSdcsKey(), "10.0.0.1", "guid-1"); err != nil {
+		t.Fatal(err)
+	}
+	allowListTests := []struct {
+		name     string
+		payload  string
+		wantCode int
+	}{
+		{"it maps to an sdc allowed by ip", `{"sdcId": "approved"}`, http.StatusOK},
+		{"it maps to an sdc allowed by guid", `{"guid": "GUID-1"}`, http.StatusOK},
+		{"it denies mapping to an sdc that is not allowed", `{"sdcId": "unapproved"}`, http.StatusForbidden},
+		{"it denies mapping to all sdcs", `{"allSdcs": true}`, http.StatusForbidden},
+	}
+	for _, tc := range allowListTests {
+		t.Run(tc.name, func(t *testing.T) {
+			mapped = false
+
+			w := mapVolumeWith(t, tc.payload)
+
+			if got := w.Code; got != tc.wantCode {
+				t.Errorf("got %d, want %d: %s", got, tc.wantCode, w.Body.String())
+			}
+			if want := tc.wantCode == http.StatusOK; mapped != want {
+				t.Errorf("forwarded to PowerFlex: got %v, want %v", mapped, want)
+			}
+		})
+	}
+}
+
+func TestPowerFlexVolumeMapNvmeHost(t *testing.T) {
+	log := logrus.New().WithContext(context.Background())
+	log.Logger.SetOutput(io.Discard)
+
+	tm := jwx.NewTokenManager(jwx.HS256)
+	tkn, err := tm.NewWithClaims(token.Claims{
+		Issuer:    "com.dell.karavi",
+		ExpiresAt: time.Now().Add(30 * time.Second).Unix(),
+		Audience:  "karavi",
+		Subject:   "Alice",
+		Roles:     "DevTesting",
+		Group:     "TestingGroup",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var forwarded string
+	fakePowerFlex := buildTestTLSServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/instances/Volume::000000000000001/action/addMappedHost/",
+			"/api/instances/Volume::000000000000001/action/removeMappedHost/",
+			"/api/instances/Volume::000000000000002/action/addMappedHost/",
+			"/api/instances/Volume::000000000000002/action/removeMappedHost/":
+			forwarded = r.URL.Path
+			w.WriteHeader(http.StatusOK)
+		case "/api/instances/Volume::000000000000001":
+			w.Write([]byte(`{"id": "000000000000001", "sizeInKb":10, "storagePoolId":"3df6b86600000000", "name": "TestVolume"}`))
+		case "/api/instances/Volume::000000000000002":
+			w.Write([]byte(`{"id": "000000000000002", "sizeInKb":10, "storagePoolId":"3df6b86600000000", "name": "OtherVolume"}`))
+		case "/api/instances/Sdc::host-1":
+			w.Write([]byte(`{"id": "host-1", "name": "worker-1", "nqn": "nqn.2014-08.org.nvmexpress:uuid:host-1", "hostType": "NVMeHost"}`))
+		case "/api/instances/Sdc::host-2":
+			w.Write([]byte(`{"id": "host-2", "name": "worker-2", "nqn": "nqn.2014-08.org.nvmexpress:uuid:host-2", "hostType": "NVMeHost"}`))
+		case "/api/login":
+			w.Write([]byte("token"))
+		case "/api/version":
+			w.Write([]byte("4.5"))
+		case "/api/types/StoragePool/instances":
+			w.Write([]byte(`[{"protectionDomainId": "75b661b400000000", "mediaType": "HDD", "id": "3df6b86600000000", "name": "TestPool"}]`))
+		default:
+			t.Errorf("Unexpected api call to fake PowerFlex: %v", r.URL.Path)
+		}
+	}))
+	fakeOPA := buildTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/data/karavi/authz/url":
+			w.Write([]byte(`{"result": {"allow": true}}`))
+		case "/v1/data/karavi/volumes/map", "/v1/data/karavi/volumes/unmap":
+			w.Write([]byte(`{"result": {"claims": {"group": "TestingGroup"}, "response": {"allowed": true}}}`))
+		default:
+			t.Errorf("Unexpected OPA request: %v", r.URL.Path)
+		}
+	}))
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	enf := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rdb))
+	sdcapp := sdc.NewSdcApprover(context.Background(), sdc.WithRedis(rdb))
+
+	owned := quota.Request{
+		SystemType:    "powerflex",
+		SystemID:      "542a2d5f5122210f",
+		StoragePoolID: "TestPool",
+		Group:         "TestingGroup",
+		VolumeName:    "TestVolume",
+	}
+	mr.HSet(owned.DataKey(), owned.CreatedField(), "1")
+
+	powerFlexHandler := proxy.NewPowerFlexHandler(log, enf, sdcapp, hostPort(t, fakeOPA.URL))
+	powerFlexHandler.UpdateSystems(context.Background(), strings.NewReader(fmt.Sprintf(`
+	{
+	  "powerflex": {
+	    "542a2d5f5122210f": {
+	      "endpoint": "%s",
+	      "user": "admin",
+	      "pass": "Password123",
+	      "insecure": true
+	    }
+	  }
+	}
+	`, fakePowerFlex.URL)), logrus.New().WithContext(context.Background()))
+	rtr := newTestRouter()
+	rtr.ProxyHandler = proxy.NewDispatchHandler(log, map[string]http.Handler{
+		"powerflex": web.Adapt(powerFlexHandler),
+	})
+	h := web.Adapt(rtr.Handler(), web.CleanMW())
+
+	volumeAction := func(t *testing.T, volumeID, action, payload string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/instances/Volume::%s/action/%s/", volumeID, action), strings.NewReader(payload))
+		ctx := context.WithValue(context.Background(), web.JWTKey, tkn)
+		ctx = context.WithValue(ctx, web.JWTTenantName, "TestingGroup")
+		r = r.WithContext(ctx)
+		r.Header.Add("Forwarded", "by=csm-authorization;csi-vxflexos")
+		r.Header.Add("Forwarded", fmt.Sprintf("for=csm-authorization;%s;542a2d5f5122210f", fakePowerFlex.URL))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	tests := []struct {
+		name      string
+		allowed   []string
+		volumeID  string
+		action    string
+		payload   string
+		wantCode  int
+		wantDeny  web.ErrorCode
+		forwarded bool
+	}{
+		{"it maps an owned volume to a host", nil, "000000000000001", "addMappedHost", `{"hostId": "host-1"}`, http.StatusOK, "", true},
+		{"it maps to a host allowed by nqn", []string{"nqn.2014-08.org.nvmexpress:uuid:host-1"}, "000000000000001", "addMappedHost", `{"hostId": "host-1"}`, http.StatusOK, "", true},
+		{"it denies mapping to a host that is not allowed", []string{"nqn.2014-08.org.nvmexpress:uuid:host-1"}, "000000000000001", "addMappedHost", `{"hostId": "host-2"}`, http.StatusForbidden, web.CodeHostNotAllowed, false},
+		{"it denies mapping a volume that is not owned", nil, "000000000000002", "addMappedHost", `{"hostId": "host-1"}`, http.StatusForbidden, web.CodeNotOwner, false},
+		{"it requires a host to map to", nil, "000000000000001", "addMappedHost", `{}`, http.StatusBadRequest, "", false},
+		{"it unmaps an owned volume from a host", nil, "000000000000001", "removeMappedHost", `{"hostId": "host-1"}`, http.StatusOK, "", true},
+		{"it denies unmapping a volume that is not owned", nil, "000000000000002", "removeMappedHost", `{"hostId": "host-1"}`, http.StatusForbidden, web.CodeNotOwner, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			forwarded = ""
+			allowed := sdc.Request{Group: "TestingGroup"}
+			mr.Del(allowed.AllowedSdcsKey())
+			if len(tc.allowed) > 0 {
+				if _, err := mr.SAdd(allowed.AllowedSdcsKey(), tc.allowed...); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			w := volumeAction(t, tc.volumeID, tc.action, tc.payload)
+
+			if got := w.Code; got != tc.wantCode {
+				t.Errorf("got %d, want %d: %s", got, tc.wantCode, w.Body.String())
+			}
+			if tc.wantDeny != "" {
+				var errBody struct {
+					Deny web.Deny `json:"deny"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &errBody); err != nil {
+					t.Fatal(err)
+				}
+				if errBody.Deny.Code != tc.wantDeny {
+					t.Errorf("got deny code %q, want %q", errBody.Deny.Code, tc.wantDeny)
+				}
+			}
+			if got := forwarded != ""; got != tc.forwarded {
+				t.Errorf("forwarded to PowerFlex: got %v, want %v", got, tc.forwarded)
+			}
+		})
+	}
+}
+
 func mocktenantKey(name string) string {
 	return fmt.Sprintf("tenant:%s:data", name)
 }
@@ -1469,13 +2466,24 @@ func mocktenantKey(name string) string {
 func newTestRouter() *web.Router {
 	noopHandler := http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {})
 	return &web.Router{
-		ProxyHandler:      noopHandler,
-		RolesHandler:      noopHandler,
-		TokenHandler:      noopHandler,
-		VolumesHandler:    noopHandler,
-		TenantHandler:     noopHandler,
-		StorageHandler:    noopHandler,
-		AdminTokenHandler: noopHandler,
+		ProxyHandler:        noopHandler,
+		RolesHandler:        noopHandler,
+		TokenHandler:        noopHandler,
+		VolumesHandler:      noopHandler,
+		RawVolumesHandler:   noopHandler,
+		TenantHandler:       noopHandler,
+		StorageHandler:      noopHandler,
+		AdminTokenHandler:   noopHandler,
+		SimulateHandler:     noopHandler,
+		PolicyHandler:       noopHandler,
+		LoginHandler:        noopHandler,
+		AdminSessionHandler: noopHandler,
+		ReportHandler:       noopHandler,
+		EventHandler:        noopHandler,
+		PortHandler:         noopHandler,
+		RolloutHandler:      noopHandler,
+		CaptureHandler:      noopHandler,
+		MaintenanceHandler:  noopHandler,
 	}
 }
 
@@ -18,10 +18,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"karavi-authorization/internal/decision"
 	"karavi-authorization/internal/quota"
+	"karavi-authorization/internal/sdc"
 	"karavi-authorization/internal/token"
 	"karavi-authorization/internal/web"
 	"net/http"
@@ -53,6 +55,9 @@ type PowerMaxSystem struct {
 	SystemEntry
 	log *logrus.Entry
 	rp  *httputil.ReverseProxy
+	// transport is the pinned transport to the system; nil if the system
+	// does not pin its certificate.
+	transport http.RoundTripper
 }
 
 // PowerMaxHandler is the proxy handler for PowerMax systems.
@@ -61,17 +66,38 @@ type PowerMaxHandler struct {
 	mu       sync.Mutex // guards systems map
 	systems  map[string]*PowerMaxSystem
 	enforcer *quota.RedisEnforcement
-	opaHost  string
+	opaHost  hostAddr
+	events   *DecisionEvents
+	sdcapp   *sdc.RedisSdcApprover
 }
 
 // NewPowerMaxHandler returns a new PowerMaxHandler.
 func NewPowerMaxHandler(log *logrus.Entry, enforcer *quota.RedisEnforcement, opaHost string) *PowerMaxHandler {
-	return &PowerMaxHandler{
+	h := &PowerMaxHandler{
 		log:      log,
 		systems:  make(map[string]*PowerMaxSystem),
 		enforcer: enforcer,
-		opaHost:  opaHost,
 	}
+	h.opaHost.Set(opaHost)
+	return h
+}
+
+// SetOPAHost changes the OPA host asked for policy decisions.
+func (h *PowerMaxHandler) SetOPAHost(opaHost string) {
+	h.opaHost.Set(opaHost)
+}
+
+// SetDecisionEvents sets the publisher of denied requests as Kubernetes
+// Events. It must be called before serving requests.
+func (h *PowerMaxHandler) SetDecisionEvents(events *DecisionEvents) {
+	h.events = events
+}
+
+// SetSdcApprover sets the store of the SDCs and hosts the tenants may map
+// volumes to, which restricts the initiators of the hosts, host groups and
+// masking views tenants create. It must be called before serving requests.
+func (h *PowerMaxHandler) SetSdcApprover(sdcapp *sdc.RedisSdcApprover) {
+	h.sdcapp = sdcapp
 }
 
 // GetSystems returns the configured systems
@@ -122,13 +148,33 @@ func buildPowerMaxSystem(_ context.Context, e SystemEntry, log *logrus.Entry) (*
 	if err != nil {
 		return nil, err
 	}
+	t, err := e.transport()
+	if err != nil {
+		return nil, err
+	}
+	rp := httputil.NewSingleHostReverseProxy(tgt)
+	rp.Transport = t
 	return &PowerMaxSystem{
 		SystemEntry: e,
 		log:         log,
-		rp:          httputil.NewSingleHostReverseProxy(tgt),
+		rp:          rp,
+		transport:   t,
 	}, nil
 }
 
+// newClient returns a Unisphere client for the system that connects over
+// the pinned transport, if any.
+func (s *PowerMaxSystem) newClient() (pmax.Pmax, error) {
+	client, err := pmax.NewClientWithArgs(s.Endpoint, appName, true, false, "")
+	if err != nil {
+		return nil, err
+	}
+	if s.transport != nil {
+		client.GetHTTPClient().Transport = s.transport
+	}
+	return client, nil
+}
+
 func (h *PowerMaxHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	fwd := web.ForwardedHeader(r)
 	fwdFor := fwd["for"]
@@ -139,6 +185,7 @@ func (h *PowerMaxHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"SystemID": systemID,
 	}).Debug("Serving request")
 	r = r.WithContext(context.WithValue(r.Context(), web.SystemIDKey, systemID))
+	setRequestAttributes(r, "powermax", systemID)
 
 	v, ok := h.systems[systemID]
 	if !ok {
@@ -146,6 +193,9 @@ func (h *PowerMaxHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	r, cancel := withBackendTimeout(r, v.Timeouts, "powermax")
+	defer cancel()
+
 	// Add authentication headers.
 	r.SetBasicAuth(v.User, v.Password)
 
@@ -154,17 +204,42 @@ func (h *PowerMaxHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	opts := otelhttp.WithSpanOptions(attrs)
 	proxyHandler := otelhttp.NewHandler(v.rp, "proxy", opts)
 
+	opaHost := h.opaHost.Get()
+
 	router := httprouter.New()
 	router.Handler(http.MethodPut,
 		"/univmax/restapi/:version/sloprovisioning/symmetrix/:systemid/storagegroup/:storagegroup/",
-		v.editStorageGroupHandler(proxyHandler, h.enforcer, h.opaHost))
+		v.editStorageGroupHandler(proxyHandler, h.enforcer, h.events, opaHost))
 	router.Handler(http.MethodPut,
 		"/univmax/restapi/:version/sloprovisioning/symmetrix/:systemid/volume/:volumeid/",
-		v.volumeModifyHandler(proxyHandler, h.enforcer, h.opaHost))
+		v.volumeModifyHandler(proxyHandler, h.enforcer, opaHost))
+	for _, kind := range []string{maskingHost, maskingHostGroup, maskingMaskingView} {
+		router.Handler(http.MethodPost,
+			"/univmax/restapi/:version/sloprovisioning/symmetrix/:systemid/"+kind+"/",
+			v.maskingHandler(kind, proxyHandler, h.enforcer, h.sdcapp, opaHost))
+		router.Handler(http.MethodPut,
+			"/univmax/restapi/:version/sloprovisioning/symmetrix/:systemid/"+kind+"/:id/",
+			v.maskingHandler(kind, proxyHandler, h.enforcer, h.sdcapp, opaHost))
+	}
 	router.NotFound = proxyHandler
 	router.MethodNotAllowed = proxyHandler
 	router.RedirectTrailingSlash = false
 
+	// Private API paths would conflict with the :version wildcard above, so
+	// they are routed separately.
+	if strings.HasPrefix(r.URL.Path, "/univmax/restapi/private/") {
+		privateRouter := httprouter.New()
+		privateRouter.Handler(http.MethodPut,
+			"/univmax/restapi/private/:version/replication/symmetrix/:systemid/snapshot/:snapid/",
+			v.snapshotLinkHandler(proxyHandler, h.enforcer, opaHost))
+		privateRouter.NotFound = proxyHandler
+		privateRouter.MethodNotAllowed = proxyHandler
+		privateRouter.RedirectTrailingSlash = false
+
+		privateRouter.ServeHTTP(w, r)
+		return
+	}
+
 	router.ServeHTTP(w, r)
 }
 
@@ -187,7 +262,7 @@ func (h *PowerMaxHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // The action ("expandStorageGroupParam" in the example) will be different depending on the
 // intended edit operation. This handler will process the action and delegate to the appropriate
 // handler.
-func (s *PowerMaxSystem) editStorageGroupHandler(next http.Handler, enf *quota.RedisEnforcement, opaHost string) http.Handler {
+func (s *PowerMaxSystem) editStorageGroupHandler(next http.Handler, enf *quota.RedisEnforcement, events *DecisionEvents, opaHost string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		_, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "powermaxEditStorageGroupHandler")
 		defer span.End()
@@ -215,11 +290,14 @@ func (s *PowerMaxSystem) editStorageGroupHandler(next http.Handler, enf *quota.R
 		case hasKey(action.Editstoragegroupactionparam, "expandStorageGroupParam"):
 			if m, ok := action.Editstoragegroupactionparam["expandStorageGroupParam"].(map[string]interface{}); ok {
 				if _, ok := m["addSpecificVolumeParam"]; ok {
-					next.ServeHTTP(w, r)
+					s.storageGroupMembershipHandler(next, enf, opaHost).ServeHTTP(w, r)
 					return
 				}
 			}
-			s.volumeCreateHandler(next, enf, opaHost).ServeHTTP(w, r)
+			s.volumeCreateHandler(next, enf, events, opaHost).ServeHTTP(w, r)
+			return
+		case hasKey(action.Editstoragegroupactionparam, "removeVolumeParam"):
+			s.storageGroupMembershipHandler(next, enf, opaHost).ServeHTTP(w, r)
 			return
 		default:
 			next.ServeHTTP(w, r)
@@ -260,7 +338,7 @@ func (s *PowerMaxSystem) editStorageGroupHandler(next http.Handler, enf *quota.R
 //	},
 //
 // "executionOption": "SYNCHRONOUS"}
-func (s *PowerMaxSystem) volumeCreateHandler(next http.Handler, enf *quota.RedisEnforcement, opaHost string) http.Handler {
+func (s *PowerMaxSystem) volumeCreateHandler(next http.Handler, enf *quota.RedisEnforcement, events *DecisionEvents, opaHost string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "powermaxVolumeCreateHandler")
 		defer span.End()
@@ -276,37 +354,26 @@ func (s *PowerMaxSystem) volumeCreateHandler(next http.Handler, enf *quota.Redis
 
 		defer r.Body.Close()
 
-		var payloadTemp map[string]interface{}
-		if err := json.NewDecoder(bytes.NewReader(b)).Decode(&payloadTemp); err != nil {
+		// Decode the body once; other modification operations leave
+		// the expand parameter unset.
+		var payload powermaxAddVolumeRequest
+		if err := json.Unmarshal(b, &payload); err != nil {
 			s.log.WithError(err).Error("proxy: decoding create volume request")
-			writeError(w, "powermax", "failed to decode body to json", http.StatusInternalServerError, s.log)
-			return
-		}
-
-		var op string
-		if action, ok := payloadTemp["editStorageGroupActionParam"]; ok {
-			v, ok := action.(map[string]interface{})
-			if !ok {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
 				writeError(w, "powermax", "invalid payload", http.StatusBadRequest, s.log)
 				return
 			}
-			if _, ok := v["expandStorageGroupParam"]; ok {
-				op = "expandStorageGroupParam"
-			}
+			writeError(w, "powermax", "failed to decode body to json", http.StatusInternalServerError, s.log)
+			return
 		}
 
 		// Other modification operations can pass through.
-		if op != "expandStorageGroupParam" {
+		if payload.Editstoragegroupactionparam.Expandstoragegroupparam == nil {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		var payload powermaxAddVolumeRequest
-		if err := json.NewDecoder(bytes.NewReader(b)).Decode(&payload); err != nil {
-			writeError(w, "powermax", "failed to decode addVolumeRequest body", http.StatusInternalServerError, s.log)
-			return
-		}
-
 		if len(payload.Editstoragegroupactionparam.Expandstoragegroupparam.Addvolumeparam.Volumeattributes) == 0 {
 			next.ServeHTTP(w, r)
 			return
@@ -321,7 +388,7 @@ func (s *PowerMaxSystem) volumeCreateHandler(next http.Handler, enf *quota.Redis
 		volID := payload.Editstoragegroupactionparam.Expandstoragegroupparam.Addvolumeparam.Volumeattributes[0].Volumeidentifier.IdentifierName
 
 		// Determine which pool this SG exists within, as it will form the quota key.
-		client, err := pmax.NewClientWithArgs(s.Endpoint, appName, true, false, "")
+		client, err := s.newClient()
 		if err != nil {
 			writeError(w, "powermax", "failed to build powermax client", http.StatusInternalServerError, s.log)
 			return
@@ -376,10 +443,16 @@ func (s *PowerMaxSystem) volumeCreateHandler(next http.Handler, enf *quota.Redis
 			"pvName":   paramPVName,
 		}).Debug("Create volume request")
 
+		namePolicy, err := enf.VolumeNamePolicy(ctx, group)
+		if err != nil {
+			writeError(w, "powermax", "resolving tenant volume name policy", http.StatusInternalServerError, s.log)
+			return
+		}
+
 		// Ask OPA if this request is valid against the policy.
 		s.log.Debugln("Asking OPA...")
 		// Request policy decision from OPA
-		ans, err := decision.Can(func() decision.Query {
+		ans, err := decision.CanWithContext(ctx, func() decision.Query {
 			return decision.Query{
 				Host:   opaHost,
 				Policy: "/karavi/volumes/powermax/create",
@@ -389,6 +462,8 @@ func (s *PowerMaxSystem) volumeCreateHandler(next http.Handler, enf *quota.Redis
 					"storagepool":     paramStoragePoolID,
 					"storagesystemid": paramSystemID,
 					"systemtype":      "powermax",
+					"servicelevel":    sg.SLO,
+					"volumename":      volumeNameInput(paramVolID, namePolicy),
 				},
 			}
 		})
@@ -410,21 +485,54 @@ func (s *PowerMaxSystem) volumeCreateHandler(next http.Handler, enf *quota.Redis
 		if resp := opaResp.Result; !resp.Allow {
 			reason := strings.Join(opaResp.Result.Deny, ",")
 			s.log.WithField("reason", reason).Debug("request denied")
-			writeError(w, "powermax", fmt.Sprintf("request denied: %v", reason), http.StatusBadRequest, s.log)
+			setDecisionAttributes(span, false, reason)
+			events.PolicyDenied(r, group, paramStoragePoolID, reason)
+			writeDenied(w, "powermax", fmt.Sprintf("request denied: %v", reason), http.StatusBadRequest, web.Deny{Code: web.CodePolicyDenied, Reason: reason, Tenant: group, Pool: paramStoragePoolID}, s.log)
+			return
+		}
+
+		// Policies that predate the volume name rules do not check the
+		// name, so it is checked here as well.
+		if err := namePolicy.Check(paramVolID); err != nil {
+			if !errors.Is(err, quota.ErrVolumeName) {
+				s.log.WithError(err).Error("checking volume name")
+				writeError(w, "powermax", "failed to check volume name", http.StatusInternalServerError, s.log)
+				return
+			}
+			reason := err.Error()
+			s.log.WithField("reason", reason).Debug("request denied")
+			setDecisionAttributes(span, false, reason)
+			events.PolicyDenied(r, group, paramStoragePoolID, reason)
+			writeDenied(w, "powermax", fmt.Sprintf("request denied: %v", reason), http.StatusBadRequest, web.Deny{Code: web.CodeVolumeName, Reason: reason, Tenant: group, Pool: paramStoragePoolID}, s.log)
+			return
+		}
+
+		// Only the roles whose volume size bounds allow the request
+		// permit it. The bounds are checked here as well, for policies
+		// that report them without denying the request.
+		permittedRoles, err := sizePermittedRoles(opaResp.Result.PermittedRoles, opaResp.Result.MinSizes, opaResp.Result.MaxSizes, paramVolSizeInKb)
+		if err != nil {
+			reason := err.Error()
+			s.log.WithField("reason", reason).Debug("request denied")
+			setDecisionAttributes(span, false, reason)
+			events.PolicyDenied(r, group, paramStoragePoolID, reason)
+			writeDenied(w, "powermax", fmt.Sprintf("request denied: %v", reason), http.StatusBadRequest, web.Deny{Code: web.CodeVolumeSize, Reason: reason, Tenant: group, Pool: paramStoragePoolID}, s.log)
 			return
 		}
 
 		// In the scenario where multiple roles are allowing
 		// this request, choose the one with the most quota.
-		var maxQuotaInKb uint64
-		for _, quota := range opaResp.Result.PermittedRoles {
-			if quota == 0 {
-				maxQuotaInKb = 0
-				break
-			}
-			if quota >= maxQuotaInKb {
-				maxQuotaInKb = quota
-			}
+		maxQuotaInKb := maxPermittedQuota(permittedRoles)
+
+		tenantKey, err := tenantQuotaKey(ctx, enf, group)
+		if err != nil {
+			writeError(w, "powermax", "resolving tenant", http.StatusInternalServerError, s.log)
+			return
+		}
+		maxVolumes, err := enf.MaxVolumes(ctx, group)
+		if err != nil {
+			writeError(w, "powermax", "resolving tenant max volumes", http.StatusInternalServerError, s.log)
+			return
 		}
 
 		// Ask Redis if this request is valid against existing volumes.
@@ -432,14 +540,30 @@ func (s *PowerMaxSystem) volumeCreateHandler(next http.Handler, enf *quota.Redis
 			SystemType:    "powermax",
 			SystemID:      paramSystemID,
 			StoragePoolID: paramStoragePoolID,
-			Group:         group,
+			Group:         tenantKey,
 			VolumeName:    volID,
 			Capacity:      fmt.Sprintf("%d", paramVolSizeInKb),
+			MaxVolumes:    maxVolumes,
+			SoftQuota:     permittedSoftQuota(permittedRoles, opaResp.Result.SoftQuotas),
 		}
 
 		s.log.Debugln("Approving request...")
 		// Ask our quota enforcer if it approves the request.
-		ok, err = enf.ApproveRequest(ctx, qr, uint64(maxQuotaInKb))
+		ok, err = enf.ApproveRequest(ctx, qr, maxQuotaInKb)
+		if errors.Is(err, quota.ErrMaxVolumes) {
+			s.log.Debugln("request was not approved")
+			setDecisionAttributes(span, false, "maximum number of volumes reached")
+			events.QuotaExceeded(r, group, paramStoragePoolID, "maximum number of volumes reached")
+			writeDenied(w, "powermax", "request denied: maximum number of volumes reached", http.StatusInsufficientStorage, quotaDeny(ctx, enf, qr, group, web.CodeMaxVolumes, maxVolumes, s.log), s.log)
+			return
+		}
+		if errors.Is(err, quota.ErrGracePeriodExpired) {
+			s.log.Debugln("request was not approved")
+			setDecisionAttributes(span, false, "soft quota grace period expired")
+			events.QuotaExceeded(r, group, paramStoragePoolID, "soft quota grace period expired")
+			writeDenied(w, "powermax", "request denied: soft quota grace period expired", http.StatusInsufficientStorage, quotaDeny(ctx, enf, qr, group, web.CodeSoftQuotaExpired, qr.SoftQuota, s.log), s.log)
+			return
+		}
 		if err != nil {
 			s.log.WithError(err).Error("approving request")
 			writeError(w, "powermax", "failed to approve request", http.StatusInternalServerError, s.log)
@@ -447,9 +571,12 @@ func (s *PowerMaxSystem) volumeCreateHandler(next http.Handler, enf *quota.Redis
 		}
 		if !ok {
 			s.log.Debugln("request was not approved")
-			writeError(w, "powermax", "request denied: not enough quota", http.StatusInsufficientStorage, s.log)
+			setDecisionAttributes(span, false, "not enough quota")
+			events.QuotaExceeded(r, group, paramStoragePoolID, "not enough quota")
+			writeDenied(w, "powermax", "request denied: not enough quota", http.StatusInsufficientStorage, quotaDeny(ctx, enf, qr, group, web.CodeQuotaExceeded, maxQuotaInKb, s.log), s.log)
 			return
 		}
+		setDecisionAttributes(span, true, "")
 
 		// Reset the original request
 		if err = r.Body.Close(); err != nil {
@@ -542,7 +669,7 @@ func (s *PowerMaxSystem) volumeModifyHandler(next http.Handler, enf *quota.Redis
 		}
 
 		// Determine which pool this SG exists within, as it will form the quota key.
-		client, err := pmax.NewClientWithArgs(s.Endpoint, appName, true, false, "")
+		client, err := s.newClient()
 		if err != nil {
 			writeError(w, "powermax", "failed to build powermax client", http.StatusInternalServerError, s.log)
 			return
@@ -594,11 +721,17 @@ func (s *PowerMaxSystem) volumeModifyHandler(next http.Handler, enf *quota.Redis
 			return
 		}
 
+		tenantKey, err := tenantQuotaKey(ctx, enf, jwtClaims.Group)
+		if err != nil {
+			writeError(w, "powermax", "resolving tenant", http.StatusInternalServerError, s.log)
+			return
+		}
+
 		qr := quota.Request{
 			SystemType:    "powermax",
 			SystemID:      params.ByName("systemid"),
 			StoragePoolID: storagePoolID,
-			Group:         jwtClaims.Group,
+			Group:         tenantKey,
 			VolumeName:    volID,
 		}
 		ok, err = enf.ValidateOwnership(ctx, qr)
@@ -607,9 +740,11 @@ func (s *PowerMaxSystem) volumeModifyHandler(next http.Handler, enf *quota.Redis
 			return
 		}
 		if !ok {
-			writeError(w, "powermax", "request was denied", http.StatusBadRequest, s.log)
+			setDecisionAttributes(span, false, "volume not owned by tenant")
+			writeDenied(w, "powermax", "request was denied", http.StatusBadRequest, web.Deny{Code: web.CodeNotOwner, Reason: "volume not owned by tenant", Tenant: jwtClaims.Group, Pool: qr.StoragePoolID}, s.log)
 			return
 		}
+		setDecisionAttributes(span, true, "")
 
 		r.Body = io.NopCloser(bytes.NewReader(b))
 		sw := &web.StatusWriter{
@@ -632,9 +767,291 @@ func (s *PowerMaxSystem) volumeModifyHandler(next http.Handler, enf *quota.Redis
 	})
 }
 
+// snapshotLinkHandler handles a modify snapshot request.
+//
+// The REST call is:
+// PUT /univmax/restapi/private/100/replication/symmetrix/:systemid/snapshot/:snapid
+//
+// The payload looks like:
+//
+//	{"deviceNameListSource":[{"name":"003E4"}],
+//	 "deviceNameListTarget":[{"name":"003E5"}],
+//	 "action":"Link","generation":0,"executionOption":"ASYNCHRONOUS"}
+//
+// The PowerMax CSI driver clones a volume by creating a new target volume,
+// which is approved against quota by the volumeCreateHandler, and linking a
+// snapshot of the source volume to it. A link is only allowed when the tenant
+// owns both the source and the target volumes.
+func (s *PowerMaxSystem) snapshotLinkHandler(next http.Handler, enf *quota.RedisEnforcement, _ string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "powermaxSnapshotLinkHandler")
+		defer span.End()
+
+		params := httprouter.ParamsFromContext(r.Context())
+
+		b, err := io.ReadAll(io.LimitReader(r.Body, limitBodySizeInBytes))
+		if err != nil {
+			writeError(w, "powermax", "failure reading request body", http.StatusInternalServerError, s.log)
+			return
+		}
+		defer r.Body.Close()
+
+		var payload powermaxModifySnapshotRequest
+		if err := json.Unmarshal(b, &payload); err != nil {
+			writeError(w, "powermax", "failure decoding request body", http.StatusInternalServerError, s.log)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(b))
+
+		// Other snapshot operations can pass through.
+		if payload.Action != "Link" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		s.log.WithFields(logrus.Fields{
+			"system_id": params.ByName("systemid"),
+			"snap_id":   params.ByName("snapid"),
+		}).Debug("Linking snapshot")
+
+		jwtValue := r.Context().Value(web.JWTKey)
+		jwtToken, ok := jwtValue.(token.Token)
+		if !ok {
+			writeError(w, "powermax", "incorrect type for JWT token", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		jwtClaims, err := jwtToken.Claims()
+		if err != nil {
+			writeError(w, "powermax", "decoding token claims", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		client, err := s.newClient()
+		if err != nil {
+			writeError(w, "powermax", "failed to build powermax client", http.StatusInternalServerError, s.log)
+			return
+		}
+		if err := client.Authenticate(ctx, &pmax.ConfigConnect{
+			Username: s.User,
+			Password: s.Password,
+		}); err != nil {
+			writeError(w, "powermax", "failed to authenticate with unisphere", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		tenantKey, err := tenantQuotaKey(ctx, enf, jwtClaims.Group)
+		if err != nil {
+			writeError(w, "powermax", "resolving tenant", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		devices := append(payload.DeviceNameListSource, payload.DeviceNameListTarget...)
+		for _, dev := range devices {
+			qr, err := s.volumeQuotaRequest(ctx, client, params.ByName("systemid"), dev.Name, tenantKey)
+			if err != nil {
+				writeError(w, "powermax", err.Error(), http.StatusInternalServerError, s.log)
+				return
+			}
+			ok, err = enf.ValidateOwnership(ctx, qr)
+			if err != nil {
+				writeError(w, "powermax", "validating ownership failed", http.StatusInternalServerError, s.log)
+				return
+			}
+			if !ok {
+				setDecisionAttributes(span, false, "volume not owned by tenant")
+				writeDenied(w, "powermax", "request was denied", http.StatusBadRequest, web.Deny{Code: web.CodeNotOwner, Reason: "volume not owned by tenant", Tenant: jwtClaims.Group, Pool: qr.StoragePoolID}, s.log)
+				return
+			}
+		}
+		setDecisionAttributes(span, true, "")
+
+		r = r.WithContext(ctx)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// storageGroupMembershipHandler handles requests that add existing volumes to,
+// or remove them from, a storage group.
+//
+// The REST call is:
+// PUT /univmax/restapi/91/sloprovisioning/symmetrix/:systemid/storagegroup/:storagegroupid
+//
+// The payload looks like:
+//
+//	{"editStorageGroupActionParam":{
+//	  "expandStorageGroupParam":{
+//	    "addSpecificVolumeParam":{"volumeId":["003E4"]}
+//	  }
+//	},"executionOption":"SYNCHRONOUS"}
+//
+// or, for a removal:
+//
+//	{"editStorageGroupActionParam":{
+//	  "removeVolumeParam":{"volumeId":["003E4"]}
+//	},"executionOption":"SYNCHRONOUS"}
+//
+// Storage groups are masked to hosts, so a tenant may only move volumes that
+// it owns in or out of them.
+func (s *PowerMaxSystem) storageGroupMembershipHandler(next http.Handler, enf *quota.RedisEnforcement, opaHost string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "powermaxStorageGroupMembershipHandler")
+		defer span.End()
+
+		params := httprouter.ParamsFromContext(r.Context())
+
+		b, err := io.ReadAll(io.LimitReader(r.Body, limitBodySizeInBytes))
+		if err != nil {
+			writeError(w, "powermax", "failure reading request body", http.StatusInternalServerError, s.log)
+			return
+		}
+		defer r.Body.Close()
+
+		var payload powermaxStorageGroupMembershipRequest
+		if err := json.Unmarshal(b, &payload); err != nil {
+			writeError(w, "powermax", "failure decoding request body", http.StatusInternalServerError, s.log)
+			return
+		}
+		action, volumeIDs := "add", payload.Editstoragegroupactionparam.Expandstoragegroupparam.Addspecificvolumeparam.VolumeID
+		if payload.Editstoragegroupactionparam.Removevolumeparam != nil {
+			action, volumeIDs = "remove", payload.Editstoragegroupactionparam.Removevolumeparam.VolumeID
+		}
+
+		s.log.WithFields(logrus.Fields{
+			"system_id":     params.ByName("systemid"),
+			"storage_group": params.ByName("storagegroup"),
+			"action":        action,
+			"volume_ids":    volumeIDs,
+		}).Debug("Changing storage group membership")
+
+		jwtValue := r.Context().Value(web.JWTKey)
+		jwtToken, ok := jwtValue.(token.Token)
+		if !ok {
+			writeError(w, "powermax", "incorrect type for JWT token", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		jwtClaims, err := jwtToken.Claims()
+		if err != nil {
+			writeError(w, "powermax", "decoding token claims", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		// Request policy decision from OPA
+		ans, err := decision.CanWithContext(ctx, func() decision.Query {
+			return decision.Query{
+				Host:   opaHost,
+				Policy: "/karavi/volumes/powermax/storagegroup",
+				Input: map[string]interface{}{
+					"claims":          jwtClaims,
+					"action":          action,
+					"storagegroup":    params.ByName("storagegroup"),
+					"storagesystemid": params.ByName("systemid"),
+					"systemtype":      "powermax",
+				},
+			}
+		})
+		if err != nil {
+			s.log.WithError(err).Error("asking OPA for storage group membership decision")
+			writeError(w, "powermax", fmt.Sprintf("asking OPA for storage group membership decision: %v", err), http.StatusInternalServerError, s.log)
+			return
+		}
+
+		var opaResp OPAResponse
+		if err := json.NewDecoder(bytes.NewReader(ans)).Decode(&opaResp); err != nil {
+			s.log.WithError(err).Error("decoding opa response")
+			writeError(w, "powermax", "decoding opa request body", http.StatusInternalServerError, s.log)
+			return
+		}
+		s.log.WithField("opa_response", opaResp).Debug()
+		if resp := opaResp.Result; !resp.Response.Allowed {
+			reason := resp.Response.Status.Reason
+			s.log.WithField("reason", reason).Debug("request denied")
+			setDecisionAttributes(span, false, reason)
+			writeDenied(w, "powermax", fmt.Sprintf("request denied: %v", reason), http.StatusBadRequest, web.Deny{Code: web.CodePolicyDenied, Reason: reason, Tenant: jwtClaims.Group}, s.log)
+			return
+		}
+
+		client, err := s.newClient()
+		if err != nil {
+			writeError(w, "powermax", "failed to build powermax client", http.StatusInternalServerError, s.log)
+			return
+		}
+		if err := client.Authenticate(ctx, &pmax.ConfigConnect{
+			Username: s.User,
+			Password: s.Password,
+		}); err != nil {
+			writeError(w, "powermax", "failed to authenticate with unisphere", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		tenantKey, err := tenantQuotaKey(ctx, enf, jwtClaims.Group)
+		if err != nil {
+			writeError(w, "powermax", "resolving tenant", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		for _, volumeID := range volumeIDs {
+			qr, err := s.volumeQuotaRequest(ctx, client, params.ByName("systemid"), volumeID, tenantKey)
+			if err != nil {
+				writeError(w, "powermax", err.Error(), http.StatusInternalServerError, s.log)
+				return
+			}
+			ok, err = enf.ValidateOwnership(ctx, qr)
+			if err != nil {
+				writeError(w, "powermax", "validating ownership failed", http.StatusInternalServerError, s.log)
+				return
+			}
+			if !ok {
+				setDecisionAttributes(span, false, "volume not owned by tenant")
+				writeDenied(w, "powermax", "request was denied", http.StatusBadRequest, web.Deny{Code: web.CodeNotOwner, Reason: "volume not owned by tenant", Tenant: jwtClaims.Group, Pool: qr.StoragePoolID}, s.log)
+				return
+			}
+		}
+		setDecisionAttributes(span, true, "")
+
+		r.Body = io.NopCloser(bytes.NewReader(b))
+		r = r.WithContext(ctx)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// volumeQuotaRequest builds the quota request identifying the volume with the
+// given device ID, using the storage pool of its first storage group that is
+// associated with an SRP.
+func (s *PowerMaxSystem) volumeQuotaRequest(ctx context.Context, client pmax.Pmax, systemID, volumeID, group string) (quota.Request, error) {
+	vol, err := client.GetVolumeByID(ctx, systemID, volumeID)
+	if err != nil {
+		return quota.Request{}, fmt.Errorf("get volume: %q: %w", volumeID, err)
+	}
+
+	var storagePoolID string
+	for _, sgID := range vol.StorageGroupIDList {
+		sg, err := client.GetStorageGroup(ctx, systemID, sgID)
+		if err != nil {
+			return quota.Request{}, fmt.Errorf("get storage group: %q: %w", sgID, err)
+		}
+		if sg.SRP != SRPNONE {
+			storagePoolID = sg.SRP
+			break
+		}
+	}
+	if strings.TrimSpace(storagePoolID) == "" {
+		return quota.Request{}, fmt.Errorf("no storage pool found for volume %q", volumeID)
+	}
+
+	return quota.Request{
+		SystemType:    "powermax",
+		SystemID:      systemID,
+		StoragePoolID: storagePoolID,
+		Group:         group,
+		VolumeName:    vol.VolumeIdentifier,
+	}, nil
+}
+
 type powermaxAddVolumeRequest struct {
 	Editstoragegroupactionparam struct {
-		Expandstoragegroupparam struct {
+		Expandstoragegroupparam *struct {
 			Addvolumeparam struct {
 				Emulation        string `json:"emulation"`
 				CreateNewVolumes bool   `json:"create_new_volumes"`
@@ -668,6 +1085,31 @@ type powermaxModifyVolumeRequest struct {
 	Executionoption string `json:"executionOption"`
 }
 
+type powermaxStorageGroupMembershipRequest struct {
+	Editstoragegroupactionparam struct {
+		Expandstoragegroupparam struct {
+			Addspecificvolumeparam struct {
+				VolumeID []string `json:"volumeId"`
+			} `json:"addSpecificVolumeParam"`
+		} `json:"expandStorageGroupParam"`
+		Removevolumeparam *struct {
+			VolumeID []string `json:"volumeId"`
+		} `json:"removeVolumeParam"`
+	} `json:"editStorageGroupActionParam"`
+	Executionoption string `json:"executionOption"`
+}
+
+type powermaxModifySnapshotRequest struct {
+	DeviceNameListSource []struct {
+		Name string `json:"name"`
+	} `json:"deviceNameListSource"`
+	DeviceNameListTarget []struct {
+		Name string `json:"name"`
+	} `json:"deviceNameListTarget"`
+	Action     string `json:"action"`
+	Generation int64  `json:"generation"`
+}
+
 func hasKey(m map[string]interface{}, key string) bool {
 	_, ok := m[key]
 	return ok
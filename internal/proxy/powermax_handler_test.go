@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"karavi-authorization/internal/quota"
+	"karavi-authorization/internal/sdc"
 	"karavi-authorization/internal/token"
 	"karavi-authorization/internal/token/jwx"
 	"karavi-authorization/internal/web"
@@ -31,6 +32,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-redis/redis"
 	"github.com/sirupsen/logrus"
 )
 
@@ -122,11 +124,14 @@ func testPowerMaxServeHTTP(t *testing.T) {
 			}
 		}))
 		enf := quota.NewRedisEnforcement(context.Background(), quota.WithDB(&quota.FakeRedis{
-			HExistsFn: func(key, field string) (bool, error) {
-				gotExistsKey, gotExistsField = key, field
-				return true, nil
-			},
-			EvalIntFn: func(_ string, _ []string, _ ...interface{}) (int, error) {
+			HGetFn:  unmigratedTenant,
+			HMGetFn: emptyTenantFields,
+			EvalIntFn: func(_ string, keys []string, args ...interface{}) (int, error) {
+				// The approval script runs first and is passed the
+				// volume's approved field.
+				if gotExistsKey == "" {
+					gotExistsKey, gotExistsField = keys[0], args[1].(string)
+				}
 				return 1, nil
 			},
 		}))
@@ -187,6 +192,7 @@ func testPowerMaxServeHTTP(t *testing.T) {
 			}
 		}))
 		enf := quota.NewRedisEnforcement(context.Background(), quota.WithDB(&quota.FakeRedis{
+			HGetFn: unmigratedTenant,
 			HExistsFn: func(key, field string) (bool, error) {
 				gotExistsKey, gotExistsField = key, field
 				return true, nil
@@ -230,6 +236,258 @@ func testPowerMaxServeHTTP(t *testing.T) {
 			t.Errorf("exists field: got %q, want %q", gotExistsField, wantExistsField)
 		}
 	})
+	t.Run("it validates ownership of linked snapshot volumes", func(t *testing.T) {
+		var linked bool
+		fakeUni := fakeServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Logf("fake unisphere received: %s %s", r.Method, r.URL)
+			switch r.URL.Path {
+			case "/univmax/restapi/100/sloprovisioning/symmetrix/1234567890/volume/003E4":
+				b, err := os.ReadFile("testdata/powermax_getvolumebyid_response.json")
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Write(b)
+			case "/univmax/restapi/100/sloprovisioning/symmetrix/1234567890/storagegroup/csi-CSM-Bronze-SRP_1-SG":
+				b, err := os.ReadFile("testdata/powermax_getstoragegroup_response.json")
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Write(b)
+			case "/univmax/restapi/private/100/replication/symmetrix/1234567890/snapshot/snap1/":
+				linked = true
+			}
+		}))
+		var owned bool
+		var gotExistsKey, gotExistsField string
+		enf := quota.NewRedisEnforcement(context.Background(), quota.WithDB(&quota.FakeRedis{
+			HGetFn: unmigratedTenant,
+			HExistsFn: func(key, field string) (bool, error) {
+				gotExistsKey, gotExistsField = key, field
+				return owned, nil
+			},
+		}))
+		sut := buildPowerMaxHandler(t, withEnforcer(enf))
+		err := sut.UpdateSystems(context.Background(), strings.NewReader(systemJSON(fakeUni.URL)), logrus.New().WithContext(context.Background()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		link := func() *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodPut,
+				"/univmax/restapi/private/100/replication/symmetrix/1234567890/snapshot/snap1/",
+				strings.NewReader(`{"deviceNameListSource": [{"name": "003E4"}], "deviceNameListTarget": [{"name": "003E4"}], "action": "Link"}`))
+			r.Header.Set("Forwarded", "for=csm-authorization;https://1.1.1.1;1234567890")
+			addJWTToRequestHeader(t, r)
+			w := httptest.NewRecorder()
+			web.Adapt(sut, web.AuthMW(discardLogger(), jwx.NewTokenManager(jwx.HS256))).ServeHTTP(w, r)
+			return w
+		}
+
+		w := link()
+
+		if w.Result().StatusCode != http.StatusBadRequest {
+			t.Errorf("status: got %d, want 400", w.Result().StatusCode)
+		}
+		if linked {
+			t.Error("expected link request not to be forwarded")
+		}
+		wantExistsKey := "quota:powermax:1234567890:SRP_1:karavi-tenant:data"
+		if gotExistsKey != wantExistsKey {
+			t.Errorf("exists key: got %q, want %q", gotExistsKey, wantExistsKey)
+		}
+		wantExistsField := "vol:csi-CSM-pmax-9c79d51b18:created"
+		if gotExistsField != wantExistsField {
+			t.Errorf("exists field: got %q, want %q", gotExistsField, wantExistsField)
+		}
+
+		owned = true
+		w = link()
+
+		if w.Result().StatusCode != http.StatusOK {
+			t.Errorf("status: got %d, want 200", w.Result().StatusCode)
+		}
+		if !linked {
+			t.Error("expected link request to be forwarded")
+		}
+	})
+	t.Run("it validates ownership of volumes moved between storage groups", func(t *testing.T) {
+		var forwarded int
+		fakeUni := fakeServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Logf("fake unisphere received: %s %s", r.Method, r.URL)
+			switch r.URL.Path {
+			case "/univmax/restapi/100/sloprovisioning/symmetrix/1234567890/volume/003E4":
+				b, err := os.ReadFile("testdata/powermax_getvolumebyid_response.json")
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Write(b)
+			case "/univmax/restapi/100/sloprovisioning/symmetrix/1234567890/storagegroup/csi-CSM-Bronze-SRP_1-SG":
+				b, err := os.ReadFile("testdata/powermax_getstoragegroup_response.json")
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Write(b)
+			case "/univmax/restapi/91/sloprovisioning/symmetrix/1234567890/storagegroup/csi-no-srp-sg-other-host/":
+				forwarded++
+			}
+		}))
+		var owned bool
+		var gotExistsKey, gotExistsField string
+		enf := quota.NewRedisEnforcement(context.Background(), quota.WithDB(&quota.FakeRedis{
+			HGetFn: unmigratedTenant,
+			HExistsFn: func(key, field string) (bool, error) {
+				gotExistsKey, gotExistsField = key, field
+				return owned, nil
+			},
+		}))
+		var gotPolicy string
+		sut := buildPowerMaxHandler(t,
+			withOPAServer(func(w http.ResponseWriter, r *http.Request) {
+				gotPolicy = r.URL.Path
+				fmt.Fprintf(w, `{ "result": { "response": { "allowed": true } } }`)
+			}),
+			withEnforcer(enf),
+		)
+		err := sut.UpdateSystems(context.Background(), strings.NewReader(systemJSON(fakeUni.URL)), logrus.New().WithContext(context.Background()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		edit := func(payload string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodPut,
+				"/univmax/restapi/91/sloprovisioning/symmetrix/1234567890/storagegroup/csi-no-srp-sg-other-host/",
+				strings.NewReader(payload))
+			r.Header.Set("Forwarded", "for=csm-authorization;https://1.1.1.1;1234567890")
+			addJWTToRequestHeader(t, r)
+			w := httptest.NewRecorder()
+			web.Adapt(sut, web.AuthMW(discardLogger(), jwx.NewTokenManager(jwx.HS256))).ServeHTTP(w, r)
+			return w
+		}
+		add := `{"editStorageGroupActionParam": {"expandStorageGroupParam": {"addSpecificVolumeParam": {"volumeId": ["003E4"]}}}, "executionOption": "SYNCHRONOUS"}`
+		remove := `{"editStorageGroupActionParam": {"removeVolumeParam": {"volumeId": ["003E4"]}}, "executionOption": "SYNCHRONOUS"}`
+
+		for _, payload := range []string{add, remove} {
+			w := edit(payload)
+
+			if w.Result().StatusCode != http.StatusBadRequest {
+				t.Errorf("status: got %d, want 400", w.Result().StatusCode)
+			}
+		}
+		if forwarded != 0 {
+			t.Errorf("got %d forwarded requests, want 0", forwarded)
+		}
+		if want := "/v1/data/karavi/volumes/powermax/storagegroup"; gotPolicy != want {
+			t.Errorf("policy: got %q, want %q", gotPolicy, want)
+		}
+		wantExistsKey := "quota:powermax:1234567890:SRP_1:karavi-tenant:data"
+		if gotExistsKey != wantExistsKey {
+			t.Errorf("exists key: got %q, want %q", gotExistsKey, wantExistsKey)
+		}
+		wantExistsField := "vol:csi-CSM-pmax-9c79d51b18:created"
+		if gotExistsField != wantExistsField {
+			t.Errorf("exists field: got %q, want %q", gotExistsField, wantExistsField)
+		}
+
+		owned = true
+		for _, payload := range []string{add, remove} {
+			w := edit(payload)
+
+			if w.Result().StatusCode != http.StatusOK {
+				t.Errorf("status: got %d, want 200", w.Result().StatusCode)
+			}
+		}
+		if forwarded != 2 {
+			t.Errorf("got %d forwarded requests, want 2", forwarded)
+		}
+	})
+	t.Run("it validates storage groups and initiators of masking views", func(t *testing.T) {
+		var forwarded int
+		fakeUni := fakeServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Logf("fake unisphere received: %s %s", r.Method, r.URL)
+			switch r.URL.Path {
+			case "/univmax/restapi/100/sloprovisioning/symmetrix/1234567890/volume":
+				fmt.Fprintf(w, `{"count": 1, "maxPageSize": 1000, "resultList": {"result": [{"volumeId": "003E4"}], "from": 1, "to": 1}}`)
+			case "/univmax/restapi/100/sloprovisioning/symmetrix/1234567890/volume/003E4":
+				b, err := os.ReadFile("testdata/powermax_getvolumebyid_response.json")
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Write(b)
+			case "/univmax/restapi/100/sloprovisioning/symmetrix/1234567890/storagegroup/csi-CSM-Bronze-SRP_1-SG":
+				b, err := os.ReadFile("testdata/powermax_getstoragegroup_response.json")
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Write(b)
+			case "/univmax/restapi/100/sloprovisioning/symmetrix/1234567890/host/host-1":
+				fmt.Fprintf(w, `{"hostId": "host-1", "initiator": ["iqn.1993-08.org.debian:01:host-1"]}`)
+			case "/univmax/restapi/91/sloprovisioning/symmetrix/1234567890/maskingview/":
+				forwarded++
+			}
+		}))
+		var owned bool
+		enf := quota.NewRedisEnforcement(context.Background(), quota.WithDB(&quota.FakeRedis{
+			HGetFn: unmigratedTenant,
+			HExistsFn: func(_, _ string) (bool, error) {
+				return owned, nil
+			},
+		}))
+		sdcs := &fakeSdcDB{}
+		var gotPolicy string
+		sut := buildPowerMaxHandler(t,
+			withOPAServer(func(w http.ResponseWriter, r *http.Request) {
+				gotPolicy = r.URL.Path
+				fmt.Fprintf(w, `{ "result": { "response": { "allowed": true } } }`)
+			}),
+			withEnforcer(enf),
+		)
+		sut.SetSdcApprover(sdc.NewSdcApprover(context.Background(), sdc.WithDB(sdcs)))
+		err := sut.UpdateSystems(context.Background(), strings.NewReader(systemJSON(fakeUni.URL)), logrus.New().WithContext(context.Background()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		create := func(payload string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodPost,
+				"/univmax/restapi/91/sloprovisioning/symmetrix/1234567890/maskingview/",
+				strings.NewReader(payload))
+			r.Header.Set("Forwarded", "for=csm-authorization;https://1.1.1.1;1234567890")
+			addJWTToRequestHeader(t, r)
+			w := httptest.NewRecorder()
+			web.Adapt(sut, web.AuthMW(discardLogger(), jwx.NewTokenManager(jwx.HS256))).ServeHTTP(w, r)
+			return w
+		}
+		payload := `{"maskingViewId": "csi-mv-host-1", "hostOrHostGroupSelection": {"useExistingHostParam": {"hostId": "host-1"}}, "storageGroupSelection": {"useExistingStorageGroupParam": {"storageGroupId": "csi-no-srp-sg-host-1"}}, "executionOption": "SYNCHRONOUS"}`
+
+		// the storage group has volumes of another tenant
+		if w := create(payload); w.Result().StatusCode != http.StatusBadRequest {
+			t.Errorf("status: got %d, want 400", w.Result().StatusCode)
+		}
+		if want := "/v1/data/karavi/volumes/powermax/masking"; gotPolicy != want {
+			t.Errorf("policy: got %q, want %q", gotPolicy, want)
+		}
+
+		// the initiator of the host is not allowed
+		owned = true
+		sdcs.allowed = []string{"iqn.1993-08.org.debian:01:host-2"}
+		if w := create(payload); w.Result().StatusCode != http.StatusForbidden {
+			t.Errorf("status: got %d, want 403", w.Result().StatusCode)
+		}
+
+		// a storage group may not be created with the masking view
+		sdcs.allowed = []string{"iqn.1993-08.org.debian:01:host-1"}
+		createSG := `{"maskingViewId": "csi-mv-host-1", "hostOrHostGroupSelection": {"useExistingHostParam": {"hostId": "host-1"}}, "storageGroupSelection": {"createStorageGroupParam": {"storageGroupId": "sg"}}}`
+		if w := create(createSG); w.Result().StatusCode != http.StatusBadRequest {
+			t.Errorf("status: got %d, want 400", w.Result().StatusCode)
+		}
+		if forwarded != 0 {
+			t.Errorf("got %d forwarded requests, want 0", forwarded)
+		}
+
+		if w := create(payload); w.Result().StatusCode != http.StatusOK {
+			t.Errorf("status: got %d, want 200", w.Result().StatusCode)
+		}
+		if forwarded != 1 {
+			t.Errorf("got %d forwarded requests, want 1", forwarded)
+		}
+	})
 	t.Run("provisioning request with a role with infinite quota", func(t *testing.T) {
 		var gotExistsKey, gotExistsField string
 		fakeUni := fakeServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -244,11 +502,14 @@ func testPowerMaxServeHTTP(t *testing.T) {
 			}
 		}))
 		enf := quota.NewRedisEnforcement(context.Background(), quota.WithDB(&quota.FakeRedis{
-			HExistsFn: func(key, field string) (bool, error) {
-				gotExistsKey, gotExistsField = key, field
-				return true, nil
-			},
-			EvalIntFn: func(_ string, _ []string, _ ...interface{}) (int, error) {
+			HGetFn:  unmigratedTenant,
+			HMGetFn: emptyTenantFields,
+			EvalIntFn: func(_ string, keys []string, args ...interface{}) (int, error) {
+				// The approval script runs first and is passed the
+				// volume's approved field.
+				if gotExistsKey == "" {
+					gotExistsKey, gotExistsField = keys[0], args[1].(string)
+				}
 				return 1, nil
 			},
 		}))
@@ -282,7 +543,7 @@ func testPowerMaxServeHTTP(t *testing.T) {
 		r := httptest.NewRequest(http.MethodPut,
 			"/univmax/restapi/91/sloprovisioning/symmetrix/1234567890/storagegroup/csi-CSM-Bronze-SRP_1-SG/",
 			bytes.NewReader(payloadBytes))
-		r.Header.Set("Forwarded", "for=csm-authorization;ttps://1.1.1.1;1234567890")
+		r.Header.Set("Forwarded", "for=csm-authorization;https://1.1.1.1;1234567890")
 		addJWTToRequestHeader(t, r)
 		w := httptest.NewRecorder()
 
@@ -349,7 +610,7 @@ func withUnisphereServer(h http.HandlerFunc) powermaxHandlerOption {
 func withOPAServer(h http.HandlerFunc) powermaxHandlerOption {
 	return func(t *testing.T, pmh *PowerMaxHandler) {
 		fakeOPA := fakeServer(t, h)
-		pmh.opaHost = hostPortFromFakeServer(t, fakeOPA)
+		pmh.SetOPAHost(hostPortFromFakeServer(t, fakeOPA))
 	}
 }
 
@@ -458,3 +719,25 @@ func discardLogger() *logrus.Entry {
 	logger := logrus.New()
 	return logger.WithContext(context.Background())
 }
+
+// unmigratedTenant fakes the lookup of a tenant without a UUID.
+func unmigratedTenant(_, _ string) (string, error) {
+	return "", redis.Nil
+}
+
+// emptyTenantFields fakes the lookup of fields a tenant does not have, such
+// as a volume name policy.
+func emptyTenantFields(_ string, fields ...string) ([]interface{}, error) {
+	return make([]interface{}, len(fields)), nil
+}
+
+// fakeSdcDB fakes the SDCs and hosts that are allowed for the tenant.
+type fakeSdcDB struct {
+	allowed []string
+}
+
+func (f *fakeSdcDB) Ping() (string, error) { return "PONG", nil }
+
+func (f *fakeSdcDB) HGet(_, _ string) (string, error) { return "", redis.Nil }
+
+func (f *fakeSdcDB) SMembers(_ string) ([]string, error) { return f.allowed, nil }
@@ -0,0 +1,402 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"karavi-authorization/internal/decision"
+	"karavi-authorization/internal/quota"
+	"karavi-authorization/internal/sdc"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/web"
+	"net/http"
+
+	pmax "github.com/dell/gopowermax/v2"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Kinds of the PowerMax masking objects.
+const (
+	maskingHost        = "host"
+	maskingHostGroup   = "hostgroup"
+	maskingMaskingView = "maskingview"
+)
+
+// errMaskingStorageGroup is returned for masking views that would create
+// their storage group, and its volumes, outside of the quota.
+var errMaskingStorageGroup = errors.New("masking views must use an existing storage group")
+
+// maskingReferences are the initiators and storage groups that a host, host
+// group or masking view references, after a request to create or modify it.
+type maskingReferences struct {
+	Initiators    []string
+	StorageGroups []string
+}
+
+// maskingHandler handles requests to create or modify hosts, host groups
+// and masking views, which expose the volumes of storage groups to the
+// initiators of hosts. They are allowed if OPA allows the tenant to use the
+// system and every referenced initiator is allowed for the tenant and every
+// volume of every referenced storage group is owned by the tenant.
+//
+// The REST calls are:
+// POST /univmax/restapi/:version/sloprovisioning/symmetrix/:systemid/{host,hostgroup,maskingview}
+// PUT /univmax/restapi/:version/sloprovisioning/symmetrix/:systemid/{host,hostgroup,maskingview}/:id
+func (s *PowerMaxSystem) maskingHandler(kind string, next http.Handler, enf *quota.RedisEnforcement, sdcapp *sdc.RedisSdcApprover, opaHost string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "powermaxMaskingHandler")
+		defer span.End()
+
+		params := httprouter.ParamsFromContext(r.Context())
+		systemID := params.ByName("systemid")
+
+		b, err := io.ReadAll(io.LimitReader(r.Body, limitBodySizeInBytes))
+		if err != nil {
+			writeError(w, "powermax", "failure reading request body", http.StatusInternalServerError, s.log)
+			return
+		}
+		defer r.Body.Close()
+
+		action := "create"
+		if r.Method == http.MethodPut {
+			action = "modify"
+		}
+
+		client, err := s.newClient()
+		if err != nil {
+			writeError(w, "powermax", "failed to build powermax client", http.StatusInternalServerError, s.log)
+			return
+		}
+		if err := client.Authenticate(ctx, &pmax.ConfigConnect{
+			Username: s.User,
+			Password: s.Password,
+		}); err != nil {
+			writeError(w, "powermax", "failed to authenticate with unisphere", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		name, refs, err := s.maskingReferences(ctx, client, kind, systemID, params.ByName("id"), b)
+		if err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, errMaskingStorageGroup) {
+				writeError(w, "powermax", err.Error(), http.StatusBadRequest, s.log)
+				return
+			}
+			writeError(w, "powermax", err.Error(), http.StatusInternalServerError, s.log)
+			return
+		}
+
+		s.log.WithFields(logrus.Fields{
+			"system_id":      systemID,
+			"kind":           kind,
+			"name":           name,
+			"action":         action,
+			"initiators":     refs.Initiators,
+			"storage_groups": refs.StorageGroups,
+		}).Debug("Changing masking")
+
+		jwtValue := r.Context().Value(web.JWTKey)
+		jwtToken, ok := jwtValue.(token.Token)
+		if !ok {
+			writeError(w, "powermax", "incorrect type for JWT token", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		jwtClaims, err := jwtToken.Claims()
+		if err != nil {
+			writeError(w, "powermax", "decoding token claims", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		// Request policy decision from OPA
+		ans, err := decision.CanWithContext(ctx, func() decision.Query {
+			return decision.Query{
+				Host:   opaHost,
+				Policy: "/karavi/volumes/powermax/masking",
+				Input: map[string]interface{}{
+					"claims":          jwtClaims,
+					"action":          action,
+					"kind":            kind,
+					"name":            name,
+					"initiators":      refs.Initiators,
+					"storagegroups":   refs.StorageGroups,
+					"storagesystemid": systemID,
+					"systemtype":      "powermax",
+				},
+			}
+		})
+		if err != nil {
+			s.log.WithError(err).Error("asking OPA for masking decision")
+			writeError(w, "powermax", fmt.Sprintf("asking OPA for masking decision: %v", err), http.StatusInternalServerError, s.log)
+			return
+		}
+
+		var opaResp OPAResponse
+		if err := json.NewDecoder(bytes.NewReader(ans)).Decode(&opaResp); err != nil {
+			s.log.WithError(err).Error("decoding opa response")
+			writeError(w, "powermax", "decoding opa request body", http.StatusInternalServerError, s.log)
+			return
+		}
+		s.log.WithField("opa_response", opaResp).Debug()
+		if resp := opaResp.Result; !resp.Response.Allowed {
+			reason := resp.Response.Status.Reason
+			s.log.WithField("reason", reason).Debug("request denied")
+			setDecisionAttributes(span, false, reason)
+			writeDenied(w, "powermax", fmt.Sprintf("request denied: %v", reason), http.StatusBadRequest, web.Deny{Code: web.CodePolicyDenied, Reason: reason, Tenant: jwtClaims.Group}, s.log)
+			return
+		}
+
+		if sdcapp != nil {
+			for _, initiator := range refs.Initiators {
+				ok, err := sdcapp.CheckSdcAllowed(ctx, sdc.Request{Group: jwtClaims.Group}, initiator)
+				if err != nil {
+					writeError(w, "powermax", "checking allowed initiators failed", http.StatusInternalServerError, s.log)
+					return
+				}
+				if !ok {
+					reason := fmt.Sprintf("initiator %s is not allowed for the tenant", initiator)
+					setDecisionAttributes(span, false, reason)
+					writeDenied(w, "powermax", "request was denied", http.StatusForbidden, web.Deny{Code: web.CodeInitiatorNotAllowed, Reason: reason, Tenant: jwtClaims.Group}, s.log)
+					return
+				}
+			}
+		}
+
+		tenantKey, err := tenantQuotaKey(ctx, enf, jwtClaims.Group)
+		if err != nil {
+			writeError(w, "powermax", "resolving tenant", http.StatusInternalServerError, s.log)
+			return
+		}
+
+		for _, sgID := range refs.StorageGroups {
+			volumeIDs, err := client.GetVolumeIDListInStorageGroup(ctx, systemID, sgID)
+			if err != nil {
+				writeError(w, "powermax", fmt.Sprintf("get volumes of storage group: %q: %v", sgID, err), http.StatusInternalServerError, s.log)
+				return
+			}
+			for _, volumeID := range volumeIDs {
+				qr, err := s.volumeQuotaRequest(ctx, client, systemID, volumeID, tenantKey)
+				if err != nil {
+					writeError(w, "powermax", err.Error(), http.StatusInternalServerError, s.log)
+					return
+				}
+				ok, err = enf.ValidateOwnership(ctx, qr)
+				if err != nil {
+					writeError(w, "powermax", "validating ownership failed", http.StatusInternalServerError, s.log)
+					return
+				}
+				if !ok {
+					reason := fmt.Sprintf("storage group %s has volumes not owned by tenant", sgID)
+					setDecisionAttributes(span, false, reason)
+					writeDenied(w, "powermax", "request was denied", http.StatusBadRequest, web.Deny{Code: web.CodeNotOwner, Reason: reason, Tenant: jwtClaims.Group, Pool: qr.StoragePoolID}, s.log)
+					return
+				}
+			}
+		}
+		setDecisionAttributes(span, true, "")
+
+		r.Body = io.NopCloser(bytes.NewReader(b))
+		r = r.WithContext(ctx)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// maskingReferences returns the name of the host, host group or masking view
+// of the request and what it references once the request is applied. The
+// existing object is looked up for modifications, as they may only be made
+// to objects the tenant could have created.
+func (s *PowerMaxSystem) maskingReferences(ctx context.Context, client pmax.Pmax, kind, systemID, id string, b []byte) (string, maskingReferences, error) {
+	var refs maskingReferences
+	switch {
+	case kind == maskingHost && id == "":
+		var payload powermaxCreateHostRequest
+		if err := json.Unmarshal(b, &payload); err != nil {
+			return "", refs, err
+		}
+		refs.Initiators = payload.InitiatorID
+		return payload.HostID, refs, nil
+	case kind == maskingHost:
+		var payload powermaxEditHostRequest
+		if err := json.Unmarshal(b, &payload); err != nil {
+			return "", refs, err
+		}
+		if err := s.addHostInitiators(ctx, client, systemID, &refs, id); err != nil {
+			return "", refs, err
+		}
+		if add := payload.Edithostactionparam.Addinitiatorparam; add != nil {
+			refs.Initiators = append(refs.Initiators, add.Initiator...)
+		}
+		return id, refs, nil
+	case kind == maskingHostGroup && id == "":
+		var payload powermaxCreateHostGroupRequest
+		if err := json.Unmarshal(b, &payload); err != nil {
+			return "", refs, err
+		}
+		if err := s.addHostGroupInitiators(ctx, client, systemID, &refs, payload); err != nil {
+			return "", refs, err
+		}
+		return payload.HostGroupID, refs, nil
+	case kind == maskingHostGroup:
+		var payload powermaxEditHostGroupRequest
+		if err := json.Unmarshal(b, &payload); err != nil {
+			return "", refs, err
+		}
+		if err := s.addExistingHostGroupInitiators(ctx, client, systemID, &refs, id); err != nil {
+			return "", refs, err
+		}
+		if add := payload.Edithostgroupactionparam.Addhostparam; add != nil {
+			for _, hostID := range add.Host {
+				if err := s.addHostInitiators(ctx, client, systemID, &refs, hostID); err != nil {
+					return "", refs, err
+				}
+			}
+		}
+		return id, refs, nil
+	case kind == maskingMaskingView && id == "":
+		var payload powermaxCreateMaskingViewRequest
+		if err := json.Unmarshal(b, &payload); err != nil {
+			return "", refs, err
+		}
+		sel := payload.Hostorhostgroupselection
+		switch {
+		case sel.Createhostparam != nil:
+			refs.Initiators = append(refs.Initiators, sel.Createhostparam.InitiatorID...)
+		case sel.Useexistinghostparam != nil:
+			if err := s.addHostInitiators(ctx, client, systemID, &refs, sel.Useexistinghostparam.HostID); err != nil {
+				return "", refs, err
+			}
+		case sel.Createhostgroupparam != nil:
+			if err := s.addHostGroupInitiators(ctx, client, systemID, &refs, *sel.Createhostgroupparam); err != nil {
+				return "", refs, err
+			}
+		case sel.Useexistinghostgroupparam != nil:
+			if err := s.addExistingHostGroupInitiators(ctx, client, systemID, &refs, sel.Useexistinghostgroupparam.HostGroupID); err != nil {
+				return "", refs, err
+			}
+		}
+		if payload.Storagegroupselection.Createstoragegroupparam != nil {
+			return "", refs, errMaskingStorageGroup
+		}
+		if use := payload.Storagegroupselection.Useexistingstoragegroupparam; use != nil {
+			refs.StorageGroups = append(refs.StorageGroups, use.StorageGroupID)
+		}
+		return payload.MaskingViewID, refs, nil
+	default:
+		mv, err := client.GetMaskingViewByID(ctx, systemID, id)
+		if err != nil {
+			return "", refs, fmt.Errorf("get masking view: %q: %w", id, err)
+		}
+		if mv.HostID != "" {
+			if err := s.addHostInitiators(ctx, client, systemID, &refs, mv.HostID); err != nil {
+				return "", refs, err
+			}
+		}
+		if mv.HostGroupID != "" {
+			if err := s.addExistingHostGroupInitiators(ctx, client, systemID, &refs, mv.HostGroupID); err != nil {
+				return "", refs, err
+			}
+		}
+		refs.StorageGroups = append(refs.StorageGroups, mv.StorageGroupID)
+		return id, refs, nil
+	}
+}
+
+func (s *PowerMaxSystem) addHostInitiators(ctx context.Context, client pmax.Pmax, systemID string, refs *maskingReferences, hostID string) error {
+	host, err := client.GetHostByID(ctx, systemID, hostID)
+	if err != nil {
+		return fmt.Errorf("get host: %q: %w", hostID, err)
+	}
+	refs.Initiators = append(refs.Initiators, host.Initiators...)
+	return nil
+}
+
+func (s *PowerMaxSystem) addExistingHostGroupInitiators(ctx context.Context, client pmax.Pmax, systemID string, refs *maskingReferences, hostGroupID string) error {
+	hg, err := client.GetHostGroupByID(ctx, systemID, hostGroupID)
+	if err != nil {
+		return fmt.Errorf("get host group: %q: %w", hostGroupID, err)
+	}
+	for _, h := range hg.Hosts {
+		refs.Initiators = append(refs.Initiators, h.Initiators...)
+	}
+	return nil
+}
+
+func (s *PowerMaxSystem) addHostGroupInitiators(ctx context.Context, client pmax.Pmax, systemID string, refs *maskingReferences, payload powermaxCreateHostGroupRequest) error {
+	for _, hostID := range payload.HostID {
+		if err := s.addHostInitiators(ctx, client, systemID, refs, hostID); err != nil {
+			return err
+		}
+	}
+	for _, h := range payload.NewHosts {
+		refs.Initiators = append(refs.Initiators, h.InitiatorID...)
+	}
+	return nil
+}
+
+type powermaxCreateHostRequest struct {
+	HostID      string   `json:"hostId"`
+	InitiatorID []string `json:"initiatorId"`
+}
+
+type powermaxEditHostRequest struct {
+	Edithostactionparam struct {
+		Addinitiatorparam *struct {
+			Initiator []string `json:"initiator"`
+		} `json:"addInitiatorParam"`
+	} `json:"editHostActionParam"`
+}
+
+type powermaxCreateHostGroupRequest struct {
+	HostGroupID string                      `json:"hostGroupId"`
+	HostID      []string                    `json:"hostId"`
+	NewHosts    []powermaxCreateHostRequest `json:"new_hosts"`
+}
+
+type powermaxEditHostGroupRequest struct {
+	Edithostgroupactionparam struct {
+		Addhostparam *struct {
+			Host []string `json:"host"`
+		} `json:"addHostParam"`
+	} `json:"editHostGroupActionParam"`
+}
+
+type powermaxCreateMaskingViewRequest struct {
+	MaskingViewID            string `json:"maskingViewId"`
+	Hostorhostgroupselection struct {
+		Createhostparam      *powermaxCreateHostRequest `json:"createHostParam"`
+		Useexistinghostparam *struct {
+			HostID string `json:"hostId"`
+		} `json:"useExistingHostParam"`
+		Createhostgroupparam      *powermaxCreateHostGroupRequest `json:"createHostGroupParam"`
+		Useexistinghostgroupparam *struct {
+			HostGroupID string `json:"hostGroupId"`
+		} `json:"useExistingHostGroupParam"`
+	} `json:"hostOrHostGroupSelection"`
+	Storagegroupselection struct {
+		Createstoragegroupparam      *struct{} `json:"createStorageGroupParam"`
+		Useexistingstoragegroupparam *struct {
+			StorageGroupID string `json:"storageGroupId"`
+		} `json:"useExistingStorageGroupParam"`
+	} `json:"storageGroupSelection"`
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"karavi-authorization/internal/quota"
+	"karavi-authorization/internal/redact"
 	"karavi-authorization/internal/web"
 	"net/http"
 	"net/http/httputil"
@@ -50,17 +51,27 @@ type PowerScaleHandler struct {
 	mu       sync.Mutex // guards systems map
 	systems  map[string]*PowerScaleSystem
 	enforcer *quota.RedisEnforcement
-	opaHost  string
+	opaHost  hostAddr
+	// owners are the owners of the directories; exports and shares are
+	// only checked against them when enforceOwners is set.
+	owners        *PowerScaleOwners
+	enforceOwners bool
 }
 
 // NewPowerScaleHandler returns a new PowerScaleHandler.
 func NewPowerScaleHandler(log *logrus.Entry, enforcer *quota.RedisEnforcement, opaHost string) *PowerScaleHandler {
-	return &PowerScaleHandler{
+	h := &PowerScaleHandler{
 		log:      log,
 		systems:  make(map[string]*PowerScaleSystem),
 		enforcer: enforcer,
-		opaHost:  opaHost,
 	}
+	h.opaHost.Set(opaHost)
+	return h
+}
+
+// SetOPAHost changes the OPA host asked for policy decisions.
+func (h *PowerScaleHandler) SetOPAHost(opaHost string) {
+	h.opaHost.Set(opaHost)
 }
 
 // GetSystems returns the configured systems
@@ -112,10 +123,16 @@ func buildPowerScaleSystem(_ context.Context, e SystemEntry, log *logrus.Entry)
 		return nil, err
 	}
 
+	t, err := e.transport()
+	if err != nil {
+		return nil, err
+	}
+	rp := httputil.NewSingleHostReverseProxy(tgt)
+	rp.Transport = t
 	return &PowerScaleSystem{
 		SystemEntry: e,
 		log:         log,
-		rp:          httputil.NewSingleHostReverseProxy(tgt),
+		rp:          rp,
 	}, nil
 }
 
@@ -129,6 +146,7 @@ func (h *PowerScaleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"system_id": systemID,
 	}).Debug("Serving request")
 	r = r.WithContext(context.WithValue(r.Context(), web.SystemIDKey, systemID))
+	setRequestAttributes(r, "powerscale", systemID)
 
 	v, ok := h.systems[systemID]
 	if !ok {
@@ -136,6 +154,9 @@ func (h *PowerScaleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	r, cancel := withBackendTimeout(r, v.Timeouts, "powerscale")
+	defer cancel()
+
 	// Strip uneeded headers
 	r.Header.Del("Cookie")
 	r.Header.Del("X-Csrf-Token")
@@ -169,7 +190,7 @@ func (h *PowerScaleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	mux := http.NewServeMux()
 	mux.Handle("/session/1/session/", http.HandlerFunc(h.spoofSession))
-	mux.Handle("/", proxyHandler)
+	mux.Handle("/", h.ownershipHandler(v, systemID, proxyHandler))
 
 	mux.ServeHTTP(w, r)
 }
@@ -180,7 +201,7 @@ func (h *PowerScaleHandler) spoofSession(w http.ResponseWriter, r *http.Request)
 		h.log.Error("Could not read session request body")
 		w.WriteHeader(http.StatusInternalServerError)
 	}
-	h.log.Infof("Spoofing session for %v request at %v: %v", r.Method, r.URL.RawPath, string(b))
+	h.log.Infof("Spoofing session for %v request at %v: %s", r.Method, r.URL.RawPath, redact.Current().JSON(b))
 	_, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "spoofSessionCheck")
 	defer span.End()
 
@@ -272,7 +293,7 @@ func (h *PowerScaleHandler) addSessionHeaders(r *http.Request, v *PowerScaleSyst
 		if err != nil {
 			return fmt.Errorf("failed to marshal session request body: %e", err)
 		}
-		h.log.Debugf("New session request body: %v", string(reqBody))
+		h.log.Debugf("New session request body: %s", redact.Current().JSON(reqBody))
 		newSessionResp, err := http.Post(v.Endpoint+"/session/1/session", "application/json", bytes.NewBuffer(reqBody))
 		if err != nil {
 			return fmt.Errorf("error requesting new session: %e", err)
@@ -305,9 +326,9 @@ func (h *PowerScaleHandler) addSessionHeaders(r *http.Request, v *PowerScaleSyst
 
 	// Add the session cookie to the request's headers
 	r.Header.Add("Cookie", v.sessionCookie)
-	h.log.Debugf("added session cookie to request header: %v", v.sessionCookie)
+	h.log.Debug("added session cookie to request header")
 	r.Header.Add("X-CSRF-Token", v.csrfToken)
-	h.log.Debugf("added CSRF token to request header: %v", v.csrfToken)
+	h.log.Debug("added CSRF token to request header")
 
 	// Add referrer header
 	r.Header.Add("Referer", v.Endpoint)
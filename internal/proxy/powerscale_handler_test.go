@@ -209,7 +209,7 @@ func (m *powerscaleHandlerOptionManager) withOPAServer(h http.HandlerFunc) power
 	return func(t *testing.T, pmh *PowerScaleHandler) {
 		m := &powerscaleUtils{}
 		fakeOPA := m.fakeServer(t, h)
-		pmh.opaHost = m.hostPortFromFakeServer(t, fakeOPA)
+		pmh.SetOPAHost(m.hostPortFromFakeServer(t, fakeOPA))
 	}
 }
 
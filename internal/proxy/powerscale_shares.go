@@ -0,0 +1,385 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"karavi-authorization/internal/decision"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// KeyPowerScaleOwnersPrefix prefixes the hash of the directories created
+// through the proxy on a PowerScale system, e.g. powerscale:owners:<system id>,
+// from the path of each directory to the tenant that created it.
+const KeyPowerScaleOwnersPrefix = "powerscale:owners:"
+
+// headerTargetType is the PowerScale header that makes a namespace PUT
+// create a directory, with the value "container".
+const headerTargetType = "X-Isi-Ifs-Target-Type"
+
+// PowerScaleOwners keeps the tenants that own the directories created through
+// the proxy, shared by every proxy-server through redis. A path is owned by
+// the tenant that created it or the nearest directory above it.
+type PowerScaleOwners struct {
+	log *logrus.Entry
+	rdb func() *redis.Client
+}
+
+// NewPowerScaleOwners returns the PowerScaleOwners kept in redis.
+func NewPowerScaleOwners(log *logrus.Entry, rdb func() *redis.Client) *PowerScaleOwners {
+	return &PowerScaleOwners{
+		log: log,
+		rdb: rdb,
+	}
+}
+
+// Record records the tenant as the owner of the directory.
+func (o *PowerScaleOwners) Record(systemID, dir, tenant string) error {
+	return o.rdb().HSet(KeyPowerScaleOwnersPrefix+systemID, path.Clean(dir), tenant).Err()
+}
+
+// Forget removes the owner of the directory.
+func (o *PowerScaleOwners) Forget(systemID, dir string) error {
+	return o.rdb().HDel(KeyPowerScaleOwnersPrefix+systemID, path.Clean(dir)).Err()
+}
+
+// Owner returns the tenant that owns the path, or "" if no tenant created it
+// or a directory above it.
+func (o *PowerScaleOwners) Owner(systemID, p string) (string, error) {
+	var dirs []string
+	for dir := path.Clean(p); ; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == "/" || dir == "." {
+			break
+		}
+	}
+
+	owners, err := o.rdb().HMGet(KeyPowerScaleOwnersPrefix+systemID, dirs...).Result()
+	if err != nil {
+		return "", err
+	}
+	for _, v := range owners {
+		if tenant, ok := v.(string); ok {
+			return tenant, nil
+		}
+	}
+	return "", nil
+}
+
+// SetOwners sets the owners of the directories created through the proxy.
+// When enforced, tenants may only create, modify or delete the NFS exports and
+// SMB shares of paths they own, as permitted by their roles. It must be
+// called before serving.
+func (h *PowerScaleHandler) SetOwners(owners *PowerScaleOwners, enforce bool) {
+	h.owners = owners
+	h.enforceOwners = enforce
+}
+
+// powerScaleShare is a request to manage the NFS exports or SMB shares.
+type powerScaleShare struct {
+	// protocol is nfs or smb.
+	protocol string
+	// id is the ID of the export, or the name of the share, that is
+	// modified or deleted.
+	id     string
+	action string
+}
+
+// parsePowerScaleShare returns the export or share request of the path, e.g.
+// /platform/2/protocols/nfs/exports/12.
+func parsePowerScaleShare(method, p string) (powerScaleShare, bool) {
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	if len(parts) < 5 || len(parts) > 6 || parts[0] != "platform" || parts[2] != "protocols" {
+		return powerScaleShare{}, false
+	}
+	var s powerScaleShare
+	switch parts[3] + "/" + parts[4] {
+	case "nfs/exports":
+		s.protocol = "nfs"
+	case "smb/shares":
+		s.protocol = "smb"
+	default:
+		return powerScaleShare{}, false
+	}
+	if len(parts) == 6 {
+		s.id = parts[5]
+	}
+
+	switch {
+	case method == http.MethodPost && s.id == "":
+		s.action = "create"
+	case method == http.MethodPut && s.id != "":
+		s.action = "modify"
+	case method == http.MethodDelete && s.id != "":
+		s.action = "delete"
+	default:
+		return powerScaleShare{}, false
+	}
+	return s, true
+}
+
+// powerScaleSharePaths is the body of a request to create or modify an NFS
+// export, which has paths, or an SMB share, which has a path.
+type powerScaleSharePaths struct {
+	Paths []string `json:"paths,omitempty"`
+	Path  string   `json:"path,omitempty"`
+}
+
+func (p powerScaleSharePaths) all() []string {
+	if p.Path != "" {
+		return append(p.Paths, p.Path)
+	}
+	return p.Paths
+}
+
+// ownershipHandler records the tenant that creates a directory as its owner
+// and, when enforced, only lets tenants manage the exports and shares of the
+// paths they own.
+func (h *PowerScaleHandler) ownershipHandler(v *PowerScaleSystem, systemID string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.owners == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/namespace/") {
+			h.directoryHandler(systemID, next).ServeHTTP(w, r)
+			return
+		}
+		if share, ok := parsePowerScaleShare(r.Method, r.URL.Path); ok && h.enforceOwners {
+			h.shareHandler(v, systemID, share, next).ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// directoryHandler records the owner of the directories that are created, and
+// forgets it when they are deleted. A tenant may not create or delete a
+// directory owned by another tenant.
+func (h *PowerScaleHandler) directoryHandler(systemID string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		create := r.Method == http.MethodPut && strings.EqualFold(r.Header.Get(headerTargetType), "container")
+		if !create && r.Method != http.MethodDelete {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims, tenantKey, err := h.requestTenant(r)
+		if err != nil {
+			writeError(w, "powerscale", err.Error(), http.StatusInternalServerError, h.log)
+			return
+		}
+
+		dir := path.Clean("/" + strings.TrimPrefix(r.URL.Path, "/namespace/"))
+		owner, err := h.owners.Owner(systemID, dir)
+		if err != nil {
+			h.log.WithError(err).Error("reading owner of powerscale directory")
+			writeError(w, "powerscale", "failed to read directory owner", http.StatusInternalServerError, h.log)
+			return
+		}
+		if owner != "" && owner != tenantKey && h.enforceOwners {
+			writeDenied(w, "powerscale", "request was denied", http.StatusForbidden, web.Deny{Code: web.CodeNotOwner, Reason: "directory not owned by tenant", Tenant: claims.Group}, h.log)
+			return
+		}
+
+		sw := &web.StatusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		if sw.Status < http.StatusOK || sw.Status >= http.StatusMultipleChoices {
+			return
+		}
+
+		switch {
+		case create && owner == "":
+			err = h.owners.Record(systemID, dir, tenantKey)
+		case r.Method == http.MethodDelete:
+			err = h.owners.Forget(systemID, dir)
+		}
+		if err != nil {
+			h.log.WithError(err).WithField("path", dir).Error("recording owner of powerscale directory")
+		}
+	})
+}
+
+// shareHandler asks OPA whether the roles of the tenant permit the paths of an
+// export or share, and checks that the tenant owns them. The paths of an
+// existing export or share are read from the array.
+func (h *PowerScaleHandler) shareHandler(v *PowerScaleSystem, systemID string, share powerScaleShare, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("").Start(r.Context(), "powerscaleShareHandler")
+		defer span.End()
+
+		b, err := io.ReadAll(io.LimitReader(r.Body, limitBodySizeInBytes))
+		if err != nil {
+			writeError(w, "powerscale", "failed to read body", http.StatusInternalServerError, h.log)
+			return
+		}
+		defer r.Body.Close()
+
+		var paths []string
+		if share.action != "delete" {
+			var body powerScaleSharePaths
+			if err := json.Unmarshal(b, &body); err != nil {
+				writeError(w, "powerscale", "failed to decode body to json", http.StatusBadRequest, h.log)
+				return
+			}
+			paths = body.all()
+		}
+		if share.action != "create" {
+			existing, err := h.sharePaths(ctx, v, r)
+			if err != nil {
+				h.log.WithError(err).Error("reading paths of powerscale share")
+				writeError(w, "powerscale", fmt.Sprintf("reading paths of %s %s: %v", share.protocol, share.id, err), http.StatusInternalServerError, h.log)
+				return
+			}
+			paths = append(paths, existing...)
+		}
+
+		claims, tenantKey, err := h.requestTenant(r)
+		if err != nil {
+			writeError(w, "powerscale", err.Error(), http.StatusInternalServerError, h.log)
+			return
+		}
+
+		h.log.WithFields(logrus.Fields{
+			"system_id": systemID,
+			"protocol":  share.protocol,
+			"action":    share.action,
+			"paths":     paths,
+		}).Debug("Managing powerscale share")
+
+		ans, err := decision.CanWithContext(ctx, func() decision.Query {
+			return decision.Query{
+				Host:   h.opaHost.Get(),
+				Policy: "/karavi/volumes/powerscale/share",
+				Input: map[string]interface{}{
+					"claims":          claims,
+					"action":          share.action,
+					"protocol":        share.protocol,
+					"paths":           paths,
+					"storagesystemid": systemID,
+					"systemtype":      "powerscale",
+				},
+			}
+		})
+		if err != nil {
+			h.log.WithError(err).Error("asking OPA for share decision")
+			writeError(w, "powerscale", fmt.Sprintf("asking OPA for share decision: %v", err), http.StatusInternalServerError, h.log)
+			return
+		}
+
+		var opaResp OPAResponse
+		if err := json.NewDecoder(bytes.NewReader(ans)).Decode(&opaResp); err != nil {
+			h.log.WithError(err).Error("decoding opa response")
+			writeError(w, "powerscale", "decoding opa request body", http.StatusInternalServerError, h.log)
+			return
+		}
+		if resp := opaResp.Result; !resp.Response.Allowed {
+			reason := resp.Response.Status.Reason
+			setDecisionAttributes(span, false, reason)
+			writeDenied(w, "powerscale", fmt.Sprintf("request denied: %v", reason), http.StatusBadRequest, web.Deny{Code: web.CodePolicyDenied, Reason: reason, Tenant: claims.Group}, h.log)
+			return
+		}
+
+		for _, p := range paths {
+			owner, err := h.owners.Owner(systemID, p)
+			if err != nil {
+				h.log.WithError(err).Error("reading owner of powerscale path")
+				writeError(w, "powerscale", "failed to read path owner", http.StatusInternalServerError, h.log)
+				return
+			}
+			if owner != tenantKey {
+				reason := fmt.Sprintf("path %s not owned by tenant", p)
+				setDecisionAttributes(span, false, reason)
+				writeDenied(w, "powerscale", "request was denied", http.StatusForbidden, web.Deny{Code: web.CodeNotOwner, Reason: reason, Tenant: claims.Group}, h.log)
+				return
+			}
+		}
+		setDecisionAttributes(span, true, "")
+
+		r.Body = io.NopCloser(bytes.NewReader(b))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// sharePaths returns the paths of the export or share the request modifies or
+// deletes, read from the array with the session of the proxy.
+func (h *PowerScaleHandler) sharePaths(ctx context.Context, v *PowerScaleSystem, r *http.Request) ([]string, error) {
+	u := v.Endpoint + r.URL.Path
+	if zone := r.URL.Query().Get("zone"); zone != "" {
+		u += "?zone=" + zone
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.addSessionHeaders(req, v); err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Exports []powerScaleSharePaths `json:"exports"`
+		Shares  []powerScaleSharePaths `json:"shares"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, s := range append(body.Exports, body.Shares...) {
+		paths = append(paths, s.all()...)
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("not found")
+	}
+	return paths, nil
+}
+
+// requestTenant returns the claims of the token of the request and the key of
+// its tenant that directories are owned by.
+func (h *PowerScaleHandler) requestTenant(r *http.Request) (token.Claims, string, error) {
+	jwtToken, ok := r.Context().Value(web.JWTKey).(token.Token)
+	if !ok {
+		return token.Claims{}, "", errors.New("incorrect type for JWT token")
+	}
+	claims, err := jwtToken.Claims()
+	if err != nil {
+		return token.Claims{}, "", errors.New("decoding token claims")
+	}
+	tenantKey, err := tenantQuotaKey(r.Context(), h.enforcer, claims.Group)
+	if err != nil {
+		return token.Claims{}, "", errors.New("resolving tenant")
+	}
+	return claims, tenantKey, nil
+}
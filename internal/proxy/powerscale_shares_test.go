@@ -0,0 +1,227 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"karavi-authorization/internal/quota"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/token/jwx"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+func TestPowerScaleShares(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	var forwarded []string
+	fakePowerScale := fakeServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/session/1/session":
+		case r.Method == http.MethodGet && r.URL.Path == "/platform/2/protocols/nfs/exports/7":
+			fmt.Fprint(w, `{"exports": [{"id": 7, "paths": ["/ifs/data/csi/k8s-1"]}]}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/platform/2/protocols/smb/shares/k8s-2":
+			fmt.Fprint(w, `{"shares": [{"name": "k8s-2", "path": "/ifs/data/csi/k8s-2"}]}`)
+		default:
+			forwarded = append(forwarded, r.Method+" "+r.URL.Path)
+		}
+	}))
+
+	var opaInput map[string]interface{}
+	fakeOPA := fakeServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input map[string]interface{} `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		opaInput = body.Input
+		if strings.Contains(fmt.Sprint(body.Input["paths"]), "/ifs/other") {
+			fmt.Fprint(w, `{"result": {"response": {"allowed": false, "status": {"reason": "no roles allow it"}}}}`)
+			return
+		}
+		fmt.Fprint(w, `{"result": {"response": {"allowed": true}}}`)
+	}))
+
+	m := &powerscaleHandlerOptionManager{}
+	sut := buildPowerScaleHandler(t,
+		m.withEnforcer(quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rdb))))
+	u := &powerscaleUtils{}
+	if err := sut.UpdateSystems(context.Background(), strings.NewReader(u.systemJSON(fakePowerScale.URL)), testLogger()); err != nil {
+		t.Fatal(err)
+	}
+	sut.SetOPAHost(u.hostPortFromFakeServer(t, fakeOPA))
+	owners := NewPowerScaleOwners(testLogger(), func() *redis.Client { return rdb })
+	sut.SetOwners(owners, true)
+
+	serve := func(t *testing.T, tenant, method, path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Forwarded", "for=csm-authorization;https://1.1.1.1;1234567890")
+		if method == http.MethodPut && strings.HasPrefix(path, "/namespace/") {
+			r.Header.Set(headerTargetType, "container")
+		}
+		addTenantJWTToRequestHeader(t, r, tenant)
+		w := httptest.NewRecorder()
+		web.Adapt(sut, web.AuthMW(discardLogger(), jwx.NewTokenManager(jwx.HS256))).ServeHTTP(w, r)
+		return w
+	}
+	wantDeny := func(t *testing.T, w *httptest.ResponseRecorder, status int, code web.ErrorCode) {
+		t.Helper()
+		if w.Code != status {
+			t.Fatalf("got status %d, want %d: %s", w.Code, status, w.Body)
+		}
+		var body struct {
+			Deny web.Deny `json:"deny"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Deny.Code != code {
+			t.Errorf("got deny code %s, want %s", body.Deny.Code, code)
+		}
+	}
+
+	t.Run("it records the owner of created directories", func(t *testing.T) {
+		for tenant, dir := range map[string]string{"karavi-tenant": "k8s-1", "other-tenant": "k8s-2"} {
+			if w := serve(t, tenant, http.MethodPut, "/namespace/ifs/data/csi/"+dir, ""); w.Code != http.StatusOK {
+				t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+			}
+		}
+
+		for p, want := range map[string]string{
+			"/ifs/data/csi/k8s-1":      "karavi-tenant",
+			"/ifs/data/csi/k8s-1/data": "karavi-tenant",
+			"/ifs/data/csi/k8s-2":      "other-tenant",
+			"/ifs/data/csi":            "",
+		} {
+			got, err := owners.Owner("1234567890", p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("%s: got owner %q, want %q", p, got, want)
+			}
+		}
+	})
+
+	t.Run("it denies creating a directory in one of another tenant", func(t *testing.T) {
+		w := serve(t, "karavi-tenant", http.MethodPut, "/namespace/ifs/data/csi/k8s-2/snap", "")
+		wantDeny(t, w, http.StatusForbidden, web.CodeNotOwner)
+	})
+
+	t.Run("it allows exports of owned paths", func(t *testing.T) {
+		forwarded = nil
+		w := serve(t, "karavi-tenant", http.MethodPost, "/platform/2/protocols/nfs/exports?zone=System", `{"paths": ["/ifs/data/csi/k8s-1"]}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+		}
+		if want := "POST /platform/2/protocols/nfs/exports"; len(forwarded) != 1 || forwarded[0] != want {
+			t.Errorf("got forwarded %v, want %s", forwarded, want)
+		}
+		if opaInput["action"] != "create" || opaInput["protocol"] != "nfs" {
+			t.Errorf("got OPA input %v, want the creation of an nfs export", opaInput)
+		}
+	})
+
+	t.Run("it denies exports of paths owned by another tenant", func(t *testing.T) {
+		forwarded = nil
+		w := serve(t, "karavi-tenant", http.MethodPost, "/platform/2/protocols/nfs/exports", `{"paths": ["/ifs/data/csi/k8s-2"]}`)
+		wantDeny(t, w, http.StatusForbidden, web.CodeNotOwner)
+		if len(forwarded) != 0 {
+			t.Errorf("got forwarded %v, want none", forwarded)
+		}
+	})
+
+	t.Run("it denies shares of paths the roles do not permit", func(t *testing.T) {
+		w := serve(t, "karavi-tenant", http.MethodPost, "/platform/2/protocols/smb/shares", `{"name": "s", "path": "/ifs/other"}`)
+		wantDeny(t, w, http.StatusBadRequest, web.CodePolicyDenied)
+	})
+
+	t.Run("it checks the paths of the exports and shares that are changed", func(t *testing.T) {
+		forwarded = nil
+		if w := serve(t, "karavi-tenant", http.MethodDelete, "/platform/2/protocols/nfs/exports/7", ""); w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+		}
+		if want := "DELETE /platform/2/protocols/nfs/exports/7"; len(forwarded) != 1 || forwarded[0] != want {
+			t.Errorf("got forwarded %v, want %s", forwarded, want)
+		}
+
+		w := serve(t, "karavi-tenant", http.MethodPut, "/platform/2/protocols/smb/shares/k8s-2", `{"description": "mine now"}`)
+		wantDeny(t, w, http.StatusForbidden, web.CodeNotOwner)
+	})
+
+	t.Run("it forgets the owner of deleted directories", func(t *testing.T) {
+		if w := serve(t, "karavi-tenant", http.MethodDelete, "/namespace/ifs/data/csi/k8s-1", ""); w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+		}
+		got, err := owners.Owner("1234567890", "/ifs/data/csi/k8s-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "" {
+			t.Errorf("got owner %q, want none", got)
+		}
+	})
+}
+
+func TestParsePowerScaleShare(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         powerScaleShare
+		wantOK       bool
+	}{
+		{http.MethodPost, "/platform/2/protocols/nfs/exports", powerScaleShare{protocol: "nfs", action: "create"}, true},
+		{http.MethodPut, "/platform/4/protocols/nfs/exports/12", powerScaleShare{protocol: "nfs", id: "12", action: "modify"}, true},
+		{http.MethodDelete, "/platform/1/protocols/smb/shares/k8s-1", powerScaleShare{protocol: "smb", id: "k8s-1", action: "delete"}, true},
+		{http.MethodGet, "/platform/1/protocols/smb/shares/k8s-1", powerScaleShare{}, false},
+		{http.MethodPost, "/platform/1/protocols/nfs/aliases", powerScaleShare{}, false},
+		{http.MethodPost, "/namespace/ifs/data", powerScaleShare{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parsePowerScaleShare(tt.method, tt.path)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s %s: got %+v, %v, want %+v, %v", tt.method, tt.path, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func addTenantJWTToRequestHeader(t *testing.T, r *http.Request, tenant string) {
+	p, err := token.Create(jwx.NewTokenManager(jwx.HS256), token.Config{
+		Tenant:            tenant,
+		Roles:             []string{"us-east-1"},
+		JWTSigningSecret:  "secret",
+		RefreshExpiration: 999 * time.Minute,
+		AccessExpiration:  999 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Authorization", "Bearer "+p.Access)
+}
@@ -0,0 +1,223 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/quota"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+	"net/http"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Page sizes of the raw volume listing.
+const (
+	DefaultRawVolumesPageSize = 100
+	MaxRawVolumesPageSize     = 1000
+)
+
+// Ownership of a volume of a storage system.
+const (
+	// VolumeOwned is a volume that a tenant owns.
+	VolumeOwned = "owned"
+	// VolumeNotOwned is a volume that no tenant owns, e.g. one created
+	// outside of authorization.
+	VolumeNotOwned = "not-owned"
+	// VolumeOwnershipUnknown is a volume that a tenant is being approved
+	// for, or that more than one tenant claims.
+	VolumeOwnershipUnknown = "unknown"
+)
+
+// VolumeOwners returns the owners of the volumes of a storage system.
+type VolumeOwners interface {
+	VolumeOwners(ctx context.Context, systemType, systemID string) (map[string]quota.VolumeOwner, error)
+}
+
+// RawVolume is a volume of a storage system with its ownership.
+type RawVolume struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Pool      string `json:"pool"`
+	SizeInKb  int64  `json:"sizeInKb"`
+	Ownership string `json:"ownership"`
+	// Tenant is the tenant that owns the volume, or that the volume is
+	// being approved for.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// RawVolumesResponse is a page of the volumes of a storage system.
+type RawVolumesResponse struct {
+	Volumes []RawVolume `json:"volumes"`
+	// NextPageToken requests the next page, if there is one.
+	NextPageToken string `json:"nextPageToken,omitempty"`
+}
+
+// RawVolumesHandler is the proxy handler that lists the volumes of a
+// storage system with their ownership, for admins to audit volumes that
+// no tenant owns.
+type RawVolumesHandler struct {
+	mux    *http.ServeMux
+	client pb.StorageServiceClient
+	owners VolumeOwners
+	log    *logrus.Entry
+}
+
+// NewRawVolumesHandler returns a RawVolumesHandler
+func NewRawVolumesHandler(log *logrus.Entry, client pb.StorageServiceClient, owners VolumeOwners) *RawVolumesHandler {
+	vh := &RawVolumesHandler{
+		client: client,
+		owners: owners,
+		log:    log,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(web.ProxyRawVolumesPath, web.Adapt(web.HandlerWithError(vh.listHandler), web.TelemetryMW("rawVolumesHandler", log)))
+	vh.mux = mux
+
+	return vh
+}
+
+// ServeHTTP implements the http.Handler interface
+func (vh *RawVolumesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vh.mux.ServeHTTP(w, r)
+}
+
+func (vh *RawVolumesHandler) listHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return handleMethodNotAllowed(vh.log, w, r)
+	}
+	// The volumes of a storage system are not limited to the tenants of
+	// an organization.
+	if admin, _ := r.Context().Value(web.JWTAdminName).(string); admin == "" || adminOrganization(r) != "" {
+		err := errors.New("admin token required that is not scoped to an organization")
+		handleJSONErrorResponse(vh.log, w, http.StatusForbidden, err)
+		return err
+	}
+	ctx := r.Context()
+
+	query := r.URL.Query()
+	storType := query.Get("StorageType")
+	sysID := query.Get("SystemId")
+	if storType == "" || sysID == "" {
+		err := errors.New("storage type and systemid must be provided in query parameters")
+		handleJSONErrorResponse(vh.log, w, http.StatusBadRequest, err)
+		return err
+	}
+	pageSize, offset, err := rawVolumesPage(query.Get("PageSize"), query.Get("PageToken"))
+	if err != nil {
+		handleJSONErrorResponse(vh.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	setAttributes(trace.SpanFromContext(ctx), map[string]interface{}{
+		"storageType": storType,
+		"systemID":    sysID,
+		"pageSize":    pageSize,
+		"pageToken":   offset,
+	})
+
+	vh.log.WithFields(logrus.Fields{
+		"storageType": storType,
+		"systemID":    sysID,
+		"pageSize":    pageSize,
+		"pageToken":   offset,
+	}).Info("Requesting raw volumes")
+
+	resp, err := vh.client.ListVolumes(ctx, &pb.ListVolumesRequest{StorageType: storType, SystemId: sysID})
+	if err != nil {
+		err = fmt.Errorf("listing volumes: %w", err)
+		handleRPCErrorResponse(vh.log, w, err)
+		return err
+	}
+
+	owners, err := vh.owners.VolumeOwners(ctx, storType, sysID)
+	if err != nil {
+		err = fmt.Errorf("getting volume owners: %w", err)
+		handleJSONErrorResponse(vh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+
+	page := RawVolumesResponse{Volumes: []RawVolume{}}
+	vols := resp.Volumes
+	if offset < len(vols) {
+		vols = vols[offset:]
+	} else {
+		vols = nil
+	}
+	if len(vols) > pageSize {
+		vols = vols[:pageSize]
+		page.NextPageToken = strconv.Itoa(offset + pageSize)
+	}
+	for _, vol := range vols {
+		v := RawVolume{
+			ID:        vol.Id,
+			Name:      vol.Name,
+			Pool:      vol.Pool,
+			SizeInKb:  vol.SizeInKb,
+			Ownership: VolumeNotOwned,
+		}
+		// Volumes that have not been changed since they were tracked by
+		// name are still tracked by name.
+		owner, ok := owners[vol.Id]
+		if !ok {
+			owner, ok = owners[vol.Name]
+		}
+		if ok {
+			v.Tenant = owner.Tenant
+			v.Ownership = VolumeOwned
+			if !owner.Settled {
+				v.Ownership = VolumeOwnershipUnknown
+			}
+		}
+		page.Volumes = append(page.Volumes, v)
+	}
+
+	err = json.NewEncoder(w).Encode(&page)
+	if err != nil {
+		err = fmt.Errorf("writing raw volumes response: %w", err)
+		handleJSONErrorResponse(vh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return nil
+}
+
+// rawVolumesPage returns the page size and the offset of the first volume
+// of the page, from the query parameters.
+func rawVolumesPage(size, token string) (int, int, error) {
+	pageSize := DefaultRawVolumesPageSize
+	if size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 || n > MaxRawVolumesPageSize {
+			return 0, 0, fmt.Errorf("page size must be between 1 and %d", MaxRawVolumesPageSize)
+		}
+		pageSize = n
+	}
+	var offset int
+	if token != "" {
+		n, err := strconv.Atoi(token)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("invalid page token %q", token)
+		}
+		offset = n
+	}
+	return pageSize, offset, nil
+}
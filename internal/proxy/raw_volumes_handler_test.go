@@ -0,0 +1,146 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"karavi-authorization/internal/quota"
+	mocks "karavi-authorization/internal/storage-service/mocks"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeVolumeOwners map[string]quota.VolumeOwner
+
+func (f fakeVolumeOwners) VolumeOwners(_ context.Context, _, _ string) (map[string]quota.VolumeOwner, error) {
+	return f, nil
+}
+
+func TestRawVolumesHandler(t *testing.T) {
+	adminRequest := func(target, org string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		ctx := context.WithValue(r.Context(), web.JWTAdminName, "admin-1")
+		ctx = context.WithValue(ctx, web.JWTOrganization, org)
+		return r.WithContext(ctx)
+	}
+	client := &mocks.FakeStorageServiceClient{
+		ListVolumesFn: func(_ context.Context, req *pb.ListVolumesRequest, _ ...grpc.CallOption) (*pb.ListVolumesResponse, error) {
+			if req.SystemId != "542a2d5f5122210f" {
+				return nil, status.Error(codes.NotFound, "system not found")
+			}
+			return &pb.ListVolumesResponse{Volumes: []*pb.Volume{
+				{Id: "vol-1", Name: "k8s-1", Pool: "bronze", SizeInKb: 8388608},
+				{Id: "vol-2", Name: "k8s-2", Pool: "bronze", SizeInKb: 8388608},
+				{Id: "vol-3", Name: "untracked", Pool: "silver", SizeInKb: 16777216},
+				{Id: "vol-4", Name: "k8s-4", Pool: "silver", SizeInKb: 8388608},
+			}}, nil
+		},
+	}
+	owners := fakeVolumeOwners{
+		"vol-1": {Tenant: "tenant-a", Pool: "bronze", Settled: true},
+		"k8s-2": {Tenant: "tenant-b", Pool: "bronze", Settled: true},
+		"vol-4": {Tenant: "tenant-a", Pool: "silver", Settled: false},
+	}
+	list := func(t *testing.T, target string) RawVolumesResponse {
+		t.Helper()
+		sut := NewRawVolumesHandler(logrus.NewEntry(logrus.New()), client, owners)
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, adminRequest(target, ""))
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+		}
+		var got RawVolumesResponse
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	t.Run("it annotates the volumes with their ownership", func(t *testing.T) {
+		got := list(t, "/proxy/volumes/raw/?StorageType=powerflex&SystemId=542a2d5f5122210f")
+
+		want := RawVolumesResponse{Volumes: []RawVolume{
+			{ID: "vol-1", Name: "k8s-1", Pool: "bronze", SizeInKb: 8388608, Ownership: VolumeOwned, Tenant: "tenant-a"},
+			{ID: "vol-2", Name: "k8s-2", Pool: "bronze", SizeInKb: 8388608, Ownership: VolumeOwned, Tenant: "tenant-b"},
+			{ID: "vol-3", Name: "untracked", Pool: "silver", SizeInKb: 16777216, Ownership: VolumeNotOwned},
+			{ID: "vol-4", Name: "k8s-4", Pool: "silver", SizeInKb: 8388608, Ownership: VolumeOwnershipUnknown, Tenant: "tenant-a"},
+		}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+	t.Run("it pages the volumes", func(t *testing.T) {
+		first := list(t, "/proxy/volumes/raw/?StorageType=powerflex&SystemId=542a2d5f5122210f&PageSize=3")
+		if len(first.Volumes) != 3 || first.NextPageToken != "3" {
+			t.Fatalf("got %d volumes and next page %q, want 3 and %q", len(first.Volumes), first.NextPageToken, "3")
+		}
+
+		second := list(t, "/proxy/volumes/raw/?StorageType=powerflex&SystemId=542a2d5f5122210f&PageSize=3&PageToken="+first.NextPageToken)
+		if len(second.Volumes) != 1 || second.Volumes[0].ID != "vol-4" || second.NextPageToken != "" {
+			t.Errorf("got %+v, want only vol-4 and no next page", second)
+		}
+	})
+	t.Run("it requires an admin token", func(t *testing.T) {
+		sut := NewRawVolumesHandler(logrus.NewEntry(logrus.New()), client, owners)
+
+		for _, r := range []*http.Request{
+			httptest.NewRequest(http.MethodGet, "/proxy/volumes/raw/?StorageType=powerflex&SystemId=542a2d5f5122210f", nil),
+			adminRequest("/proxy/volumes/raw/?StorageType=powerflex&SystemId=542a2d5f5122210f", "org-1"),
+		} {
+			w := httptest.NewRecorder()
+			sut.ServeHTTP(w, r)
+
+			if w.Code != http.StatusForbidden {
+				t.Errorf("got status %d, want %d", w.Code, http.StatusForbidden)
+			}
+		}
+	})
+	t.Run("it rejects invalid queries", func(t *testing.T) {
+		sut := NewRawVolumesHandler(logrus.NewEntry(logrus.New()), client, owners)
+
+		for _, target := range []string{
+			"/proxy/volumes/raw/?SystemId=542a2d5f5122210f",
+			"/proxy/volumes/raw/?StorageType=powerflex&SystemId=542a2d5f5122210f&PageSize=0",
+			"/proxy/volumes/raw/?StorageType=powerflex&SystemId=542a2d5f5122210f&PageToken=-1",
+		} {
+			w := httptest.NewRecorder()
+			sut.ServeHTTP(w, adminRequest(target, ""))
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: got status %d, want %d", target, w.Code, http.StatusBadRequest)
+			}
+		}
+	})
+	t.Run("it handles storage service errors", func(t *testing.T) {
+		sut := NewRawVolumesHandler(logrus.NewEntry(logrus.New()), client, owners)
+
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, adminRequest("/proxy/volumes/raw/?StorageType=powerflex&SystemId=unknown", ""))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+}
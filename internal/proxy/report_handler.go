@@ -0,0 +1,111 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// ReportHandler is the proxy handler for karavictl report requests
+type ReportHandler struct {
+	mux    *http.ServeMux
+	client pb.ReportServiceClient
+	log    *logrus.Entry
+}
+
+// NewReportHandler returns a ReportHandler
+func NewReportHandler(log *logrus.Entry, client pb.ReportServiceClient) *ReportHandler {
+	rh := &ReportHandler{
+		client: client,
+		log:    log,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyReportsPath, "usage"), web.Adapt(web.HandlerWithError(rh.usageHandler), web.TelemetryMW("reportHandler", log)))
+	rh.mux = mux
+
+	return rh
+}
+
+// ServeHTTP implements the http.Handler interface
+func (rh *ReportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rh.mux.ServeHTTP(w, r)
+}
+
+// usageHandler gets the history of the usage of tenants between two days,
+// for chargeback.
+func (rh *ReportHandler) usageHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return handleMethodNotAllowed(rh.log, w, r)
+	}
+	// Only admins may see the usage of every tenant.
+	if admin, _ := r.Context().Value(web.JWTAdminName).(string); admin == "" {
+		err := errors.New("admin token required")
+		handleJSONErrorResponse(rh.log, w, http.StatusForbidden, err)
+		return err
+	}
+
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	query := r.URL.Query()
+	req := &pb.GetUsageHistoryRequest{
+		From:         query.Get("from"),
+		To:           query.Get("to"),
+		Tenant:       query.Get("tenant"),
+		Organization: adminOrganization(r),
+	}
+	if req.Organization == "" {
+		req.Organization = query.Get("organization")
+	}
+
+	setAttributes(span, map[string]interface{}{
+		"from":         req.From,
+		"to":           req.To,
+		"tenant":       req.Tenant,
+		"organization": req.Organization,
+	})
+	rh.log.WithFields(logrus.Fields{
+		"from":         req.From,
+		"to":           req.To,
+		"tenant":       req.Tenant,
+		"organization": req.Organization,
+	}).Info("Requesting usage history")
+
+	// call report service
+	history, err := rh.client.GetUsageHistory(ctx, req)
+	if err != nil {
+		err = fmt.Errorf("getting usage history: %w", err)
+		handleRPCErrorResponse(rh.log, w, err)
+		return err
+	}
+
+	// return history to client
+	_, err = fmt.Fprint(w, protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true, Indent: ""}.Format(history))
+	if err != nil {
+		err = fmt.Errorf("writing usage history response: %w", err)
+		handleJSONErrorResponse(rh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+type fakeReportClient struct {
+	gotReq *pb.GetUsageHistoryRequest
+	resp   *pb.GetUsageHistoryResponse
+	err    error
+}
+
+func (f *fakeReportClient) GetUsageHistory(_ context.Context, req *pb.GetUsageHistoryRequest, _ ...grpc.CallOption) (*pb.GetUsageHistoryResponse, error) {
+	f.gotReq = req
+	return f.resp, f.err
+}
+
+func TestReportHandler(t *testing.T) {
+	adminRequest := func(method, target, org string) *http.Request {
+		r := httptest.NewRequest(method, target, nil)
+		ctx := context.WithValue(r.Context(), web.JWTAdminName, "admin-1")
+		ctx = context.WithValue(ctx, web.JWTOrganization, org)
+		return r.WithContext(ctx)
+	}
+
+	t.Run("it gets the usage history", func(t *testing.T) {
+		client := &fakeReportClient{resp: &pb.GetUsageHistoryResponse{
+			Summaries: []*pb.UsageSummary{{Tenant: "tenant-a", Days: 31, CapacityDays: 260046848}},
+		}}
+		sut := NewReportHandler(logrus.NewEntry(logrus.New()), client)
+
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, adminRequest(http.MethodGet, "/proxy/reports/usage/?from=2024-03-01&to=2024-03-31&tenant=tenant-a&organization=other", "org-1"))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		want := &pb.GetUsageHistoryRequest{From: "2024-03-01", To: "2024-03-31", Tenant: "tenant-a", Organization: "org-1"}
+		if got := client.gotReq; got.From != want.From || got.To != want.To || got.Tenant != want.Tenant || got.Organization != want.Organization {
+			t.Errorf("expected request %v, got %v", want, got)
+		}
+		var got pb.GetUsageHistoryResponse
+		if err := protojson.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Summaries) != 1 || got.Summaries[0].CapacityDays != 260046848 {
+			t.Errorf("expected the history in the response, got %v", &got)
+		}
+	})
+	t.Run("it requires an admin token", func(t *testing.T) {
+		sut := NewReportHandler(logrus.NewEntry(logrus.New()), &fakeReportClient{})
+
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/proxy/reports/usage/?from=2024-03-01&to=2024-03-31", nil))
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected status code %d, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+	t.Run("it handles invalid days", func(t *testing.T) {
+		client := &fakeReportClient{err: status.Error(codes.InvalidArgument, "invalid request: from must be a date as YYYY-MM-DD")}
+		sut := NewReportHandler(logrus.NewEntry(logrus.New()), client)
+
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, adminRequest(http.MethodGet, "/proxy/reports/usage/?from=yesterday&to=2024-03-31", ""))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+	t.Run("it handles bad method", func(t *testing.T) {
+		sut := NewReportHandler(logrus.NewEntry(logrus.New()), &fakeReportClient{})
+
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, adminRequest(http.MethodPost, "/proxy/reports/usage/", ""))
+
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, w.Code)
+		}
+	})
+}
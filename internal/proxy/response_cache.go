@@ -0,0 +1,138 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultPowerFlexCachedPaths are the read-only PowerFlex endpoints that the
+// CSI drivers call repeatedly, e.g. when they all restart at once, whose
+// responses are cached when the response cache is enabled.
+var DefaultPowerFlexCachedPaths = []string{
+	"/api/version/",
+	"/api/types/System/instances/",
+	"/api/types/StoragePool/instances/",
+}
+
+// DefaultResponseCacheTTL is how long cached responses are reused.
+const DefaultResponseCacheTTL = 5 * time.Second
+
+// responseCache reuses the successful responses of a storage system to GET
+// requests of the cached paths for a short time. Concurrent requests of a
+// response that is not cached are forwarded only once.
+type responseCache struct {
+	systemID string
+	log      *logrus.Entry
+	paths    []string
+	ttl      time.Duration
+	sf       singleflight.Group
+
+	mu      sync.Mutex // guards entries and pruned
+	entries map[string]cachedResponse
+	pruned  time.Time
+}
+
+type cachedResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// newResponseCache returns the response cache of a storage system, or nil,
+// which caches nothing, if there are no cached paths or the TTL is not
+// positive.
+func newResponseCache(systemID string, log *logrus.Entry, paths []string, ttl time.Duration) *responseCache {
+	if len(paths) == 0 || ttl <= 0 {
+		return nil
+	}
+	return &responseCache{
+		systemID: systemID,
+		log:      log,
+		paths:    paths,
+		ttl:      ttl,
+		entries:  make(map[string]cachedResponse),
+	}
+}
+
+// Handler serves GET requests of the cached paths from the cache, and
+// forwards the others to next.
+func (c *responseCache) Handler(next http.Handler) http.Handler {
+	if c == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || !isFilteredPath(c.paths, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := r.URL.RequestURI()
+		c.mu.Lock()
+		e, ok := c.entries[key]
+		c.mu.Unlock()
+		if ok && time.Now().Before(e.expires) {
+			responseCacheRequests.WithLabelValues(c.systemID, "hit").Inc()
+			c.write(w, e)
+			return
+		}
+		responseCacheRequests.WithLabelValues(c.systemID, "miss").Inc()
+
+		v, _, _ := c.sf.Do(key, func() (interface{}, error) {
+			// The response is shared, so ask for it uncompressed.
+			r.Header.Del("Accept-Encoding")
+			bw := &bufferedResponseWriter{header: make(http.Header)}
+			next.ServeHTTP(bw, r)
+			if bw.status == 0 {
+				bw.status = http.StatusOK
+			}
+
+			now := time.Now()
+			resp := cachedResponse{status: bw.status, header: bw.header, body: bw.body.Bytes(), expires: now.Add(c.ttl)}
+			if resp.status != http.StatusOK {
+				return resp, nil
+			}
+			c.mu.Lock()
+			if now.Sub(c.pruned) > c.ttl {
+				for k, e := range c.entries {
+					if now.After(e.expires) {
+						delete(c.entries, k)
+					}
+				}
+				c.pruned = now
+			}
+			c.entries[key] = resp
+			c.mu.Unlock()
+			return resp, nil
+		})
+		c.write(w, v.(cachedResponse))
+	})
+}
+
+func (c *responseCache) write(w http.ResponseWriter, e cachedResponse) {
+	for k, v := range e.header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(e.body)))
+	w.WriteHeader(e.status)
+	if _, err := w.Write(e.body); err != nil {
+		c.log.WithError(err).Error("writing cached response")
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestResponseCache(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	var calls int32
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "%s %s", r.Method, r.URL.RequestURI())
+	})
+	serve := func(h http.Handler, method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	t.Run("it reuses the responses of cached paths", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		h := newResponseCache("542a2d5f5122210f", log.WithField("test", t.Name()), DefaultPowerFlexCachedPaths, time.Minute).Handler(next)
+
+		for i := 0; i < 3; i++ {
+			w := serve(h, http.MethodGet, "/api/version/")
+			if got, want := w.Body.String(), "GET /api/version/"; got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		}
+		serve(h, http.MethodGet, "/api/types/StoragePool/instances/")
+
+		if got := atomic.LoadInt32(&calls); got != 2 {
+			t.Errorf("got %d forwarded requests, want 2", got)
+		}
+	})
+	t.Run("it forwards concurrent requests once", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		release := make(chan struct{})
+		slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			next.ServeHTTP(w, r)
+		})
+		h := newResponseCache("542a2d5f5122210f", log.WithField("test", t.Name()), DefaultPowerFlexCachedPaths, time.Minute).Handler(slow)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				serve(h, http.MethodGet, "/api/types/System/instances/")
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if got := atomic.LoadInt32(&calls); got != 1 {
+			t.Errorf("got %d forwarded requests, want 1", got)
+		}
+	})
+	t.Run("it does not cache other requests", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		h := newResponseCache("542a2d5f5122210f", log.WithField("test", t.Name()), DefaultPowerFlexCachedPaths, time.Minute).Handler(next)
+
+		for i := 0; i < 2; i++ {
+			serve(h, http.MethodGet, "/api/types/Volume/instances/")
+			serve(h, http.MethodPost, "/api/version/")
+			if w := serve(h, http.MethodGet, "/api/version/?fail=1"); w.Code != http.StatusInternalServerError {
+				t.Errorf("got status %d, want %d", w.Code, http.StatusInternalServerError)
+			}
+		}
+
+		if got := atomic.LoadInt32(&calls); got != 6 {
+			t.Errorf("got %d forwarded requests, want 6", got)
+		}
+	})
+	t.Run("it expires responses after the TTL", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		h := newResponseCache("542a2d5f5122210f", log.WithField("test", t.Name()), DefaultPowerFlexCachedPaths, 10*time.Millisecond).Handler(next)
+
+		serve(h, http.MethodGet, "/api/version/")
+		time.Sleep(20 * time.Millisecond)
+		serve(h, http.MethodGet, "/api/version/")
+
+		if got := atomic.LoadInt32(&calls); got != 2 {
+			t.Errorf("got %d forwarded requests, want 2", got)
+		}
+	})
+	t.Run("it is disabled without a TTL", func(t *testing.T) {
+		if c := newResponseCache("542a2d5f5122210f", log.WithField("test", t.Name()), DefaultPowerFlexCachedPaths, 0); c != nil {
+			t.Errorf("got %v, want no cache", c)
+		}
+	})
+}
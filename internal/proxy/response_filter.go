@@ -0,0 +1,150 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultPowerFlexFilteredPaths are the PowerFlex list endpoints whose
+// responses are filtered to the resources of the requesting tenant.
+var DefaultPowerFlexFilteredPaths = []string{
+	"/api/types/Volume/instances/",
+	"/api/instances/StoragePool::*/relationships/Volume/",
+}
+
+// listItemFilter reports whether an item of a JSON list response is kept.
+type listItemFilter func(ctx context.Context, item map[string]json.RawMessage) (bool, error)
+
+// bufferedResponseWriter holds a response so that it can be rewritten
+// before it is sent to the client.
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+// teeResponseWriter keeps a copy of the response it writes, e.g. to read
+// the ID of a created volume.
+type teeResponseWriter struct {
+	web.StatusWriter
+	body bytes.Buffer
+}
+
+func (t *teeResponseWriter) Write(p []byte) (int, error) {
+	t.body.Write(p)
+	return t.StatusWriter.Write(p)
+}
+
+// filterListHandler strips the items that keep rejects from successful
+// JSON list responses of next. Other responses are sent unchanged.
+func filterListHandler(next http.Handler, log *logrus.Entry, keep listItemFilter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The response is decoded, so ask for it uncompressed.
+		r.Header.Del("Accept-Encoding")
+
+		bw := &bufferedResponseWriter{header: make(http.Header)}
+		next.ServeHTTP(bw, r)
+		if bw.status == 0 {
+			bw.status = http.StatusOK
+		}
+
+		body := bw.body.Bytes()
+		if bw.status == http.StatusOK {
+			filtered, ok, err := filterList(r.Context(), body, keep)
+			switch {
+			case err != nil:
+				log.WithError(err).Error("filtering list response")
+				writeError(w, "proxy", "failed to filter response", http.StatusInternalServerError, log)
+				return
+			case ok:
+				body = filtered
+			}
+		}
+
+		for k, v := range bw.header {
+			w.Header()[k] = v
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(bw.status)
+		if _, err := w.Write(body); err != nil {
+			log.WithError(err).Error("writing filtered response")
+		}
+	})
+}
+
+// filterList returns the JSON list in body without the items that keep
+// rejects. It returns false if body is not a JSON list of objects.
+func filterList(ctx context.Context, body []byte, keep listItemFilter) ([]byte, bool, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		return nil, false, nil
+	}
+
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, false, nil
+	}
+
+	kept := make([]map[string]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		ok, err := keep(ctx, item)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			kept = append(kept, item)
+		}
+	}
+
+	b, err := json.Marshal(kept)
+	if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+// isFilteredPath reports whether the path matches one of the filtered path
+// patterns, in the syntax of path.Match.
+func isFilteredPath(patterns []string, pth string) bool {
+	for _, p := range patterns {
+		if ok, err := path.Match(p, pth); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
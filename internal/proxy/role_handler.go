@@ -50,6 +50,14 @@ func (th *RoleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (th *RoleHandler) roleHandler(w http.ResponseWriter, r *http.Request) error {
+	// roles are shared by every organization, so admins scoped to one may
+	// only read them
+	if org := adminOrganization(r); org != "" && r.Method != http.MethodGet {
+		err := fmt.Errorf("admin of organization %s may not modify roles", org)
+		handleJSONErrorResponse(th.log, w, http.StatusForbidden, err)
+		return err
+	}
+
 	switch r.Method {
 	case http.MethodPost:
 		return th.createHandler(w, r)
@@ -60,7 +68,7 @@ func (th *RoleHandler) roleHandler(w http.ResponseWriter, r *http.Request) error
 	case http.MethodDelete:
 		return th.deleteHandler(w, r)
 	default:
-		return nil
+		return handleMethodNotAllowed(th.log, w, r)
 	}
 }
 
@@ -71,6 +79,12 @@ type CreateRoleBody struct {
 	SystemID    string `json:"systemId,omitempty"`
 	Pool        string `json:"pool,omitempty"`
 	Quota       string `json:"quota,omitempty"`
+	SoftQuota   string `json:"softQuota,omitempty"`
+	// ServiceLevels pins a powermax role to the service levels.
+	ServiceLevels []string `json:"serviceLevels,omitempty"`
+	// MinSize and MaxSize bound the size of each volume of the pool.
+	MinSize string `json:"minSize,omitempty"`
+	MaxSize string `json:"maxSize,omitempty"`
 }
 
 func (th *RoleHandler) createHandler(w http.ResponseWriter, r *http.Request) error {
@@ -87,31 +101,43 @@ func (th *RoleHandler) createHandler(w http.ResponseWriter, r *http.Request) err
 	}
 
 	setAttributes(span, map[string]interface{}{
-		"name":        body.Name,
-		"storageType": body.StorageType,
-		"systemId":    body.SystemID,
-		"pool":        body.Pool,
-		"quota":       body.Quota,
+		"name":          body.Name,
+		"storageType":   body.StorageType,
+		"systemId":      body.SystemID,
+		"pool":          body.Pool,
+		"quota":         body.Quota,
+		"softQuota":     body.SoftQuota,
+		"serviceLevels": body.ServiceLevels,
+		"minSize":       body.MinSize,
+		"maxSize":       body.MaxSize,
 	})
 	th.log.WithFields(logrus.Fields{
-		"name":        body.Name,
-		"storageType": body.StorageType,
-		"systemId":    body.SystemID,
-		"pool":        body.Pool,
-		"quota":       body.Quota,
+		"name":          body.Name,
+		"storageType":   body.StorageType,
+		"systemId":      body.SystemID,
+		"pool":          body.Pool,
+		"quota":         body.Quota,
+		"softQuota":     body.SoftQuota,
+		"serviceLevels": body.ServiceLevels,
+		"minSize":       body.MinSize,
+		"maxSize":       body.MaxSize,
 	}).Info("Requesting role creation")
 
 	// call role service
 	_, err = th.client.Create(ctx, &pb.RoleCreateRequest{
-		Name:        body.Name,
-		StorageType: body.StorageType,
-		SystemId:    body.SystemID,
-		Pool:        body.Pool,
-		Quota:       body.Quota,
+		Name:          body.Name,
+		StorageType:   body.StorageType,
+		SystemId:      body.SystemID,
+		Pool:          body.Pool,
+		Quota:         body.Quota,
+		SoftQuota:     body.SoftQuota,
+		ServiceLevels: body.ServiceLevels,
+		MinSize:       body.MinSize,
+		MaxSize:       body.MaxSize,
 	})
 	if err != nil {
 		err = fmt.Errorf("creating role %s: %w", body, err)
-		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(th.log, w, err)
 		return err
 	}
 	w.WriteHeader(http.StatusCreated)
@@ -132,30 +158,42 @@ func (th *RoleHandler) updateHandler(w http.ResponseWriter, r *http.Request) err
 	}
 
 	setAttributes(span, map[string]interface{}{
-		"name":        body.Name,
-		"storageType": body.StorageType,
-		"systemId":    body.SystemID,
-		"pool":        body.Pool,
-		"quota":       body.Quota,
+		"name":          body.Name,
+		"storageType":   body.StorageType,
+		"systemId":      body.SystemID,
+		"pool":          body.Pool,
+		"quota":         body.Quota,
+		"softQuota":     body.SoftQuota,
+		"serviceLevels": body.ServiceLevels,
+		"minSize":       body.MinSize,
+		"maxSize":       body.MaxSize,
 	})
 	th.log.WithFields(logrus.Fields{
-		"name":        body.Name,
-		"storageType": body.StorageType,
-		"systemId":    body.SystemID,
-		"pool":        body.Pool,
-		"quota":       body.Quota,
+		"name":          body.Name,
+		"storageType":   body.StorageType,
+		"systemId":      body.SystemID,
+		"pool":          body.Pool,
+		"quota":         body.Quota,
+		"softQuota":     body.SoftQuota,
+		"serviceLevels": body.ServiceLevels,
+		"minSize":       body.MinSize,
+		"maxSize":       body.MaxSize,
 	}).Info("Requesting role update")
 
 	_, err = th.client.Update(ctx, &pb.RoleUpdateRequest{
-		Name:        body.Name,
-		StorageType: body.StorageType,
-		SystemId:    body.SystemID,
-		Pool:        body.Pool,
-		Quota:       body.Quota,
+		Name:          body.Name,
+		StorageType:   body.StorageType,
+		SystemId:      body.SystemID,
+		Pool:          body.Pool,
+		Quota:         body.Quota,
+		SoftQuota:     body.SoftQuota,
+		ServiceLevels: body.ServiceLevels,
+		MinSize:       body.MinSize,
+		MaxSize:       body.MaxSize,
 	})
 	if err != nil {
 		err = fmt.Errorf("updating role %s: %w", body, err)
-		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(th.log, w, err)
 		return err
 	}
 
@@ -178,7 +216,7 @@ func (th *RoleHandler) getHandler(w http.ResponseWriter, r *http.Request) error
 		roles, err := th.client.List(ctx, &pb.RoleListRequest{})
 		if err != nil {
 			err = fmt.Errorf("listing roles: %w", err)
-			handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+			handleRPCErrorResponse(th.log, w, err)
 			return err
 		}
 
@@ -207,7 +245,7 @@ func (th *RoleHandler) getHandler(w http.ResponseWriter, r *http.Request) error
 	})
 	if err != nil {
 		err = fmt.Errorf("getting role %s: %w", name, err)
-		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(th.log, w, err)
 		return err
 	}
 
@@ -258,7 +296,7 @@ func (th *RoleHandler) deleteHandler(w http.ResponseWriter, r *http.Request) err
 	})
 	if err != nil {
 		err = fmt.Errorf("deleting role %s: %w", body, err)
-		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(th.log, w, err)
 		return err
 	}
 
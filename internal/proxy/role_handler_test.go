@@ -19,6 +19,7 @@ import (
 	"errors"
 	"karavi-authorization/internal/role-service/mocks"
 	"karavi-authorization/internal/role-service/roles"
+	"karavi-authorization/internal/web"
 	"karavi-authorization/pb"
 	"net/http"
 	"net/http/httptest"
@@ -57,6 +58,33 @@ func TestRoleHandler(t *testing.T) {
 				t.Errorf("expected status code %d, got %d", http.StatusCreated, code)
 			}
 		})
+		t.Run("refuses an organization admin", func(t *testing.T) {
+			client := &mocks.FakeRoleServiceClient{}
+
+			sut := NewRoleHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&CreateRoleBody{
+				Name:        "test",
+				StorageType: "powerflex",
+				SystemID:    "542a2d5f5122210f",
+				Pool:        "bronze",
+				Quota:       "10",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/proxy/roles/", bytes.NewReader(payload))
+			r = r.WithContext(context.WithValue(r.Context(), web.JWTOrganization, "org"))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusForbidden {
+				t.Errorf("expected status code %d, got %d", http.StatusForbidden, code)
+			}
+		})
 		t.Run("handles malformed request body", func(t *testing.T) {
 			client := &mocks.FakeRoleServiceClient{}
 
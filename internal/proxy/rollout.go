@@ -0,0 +1,436 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/decision"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+// KeyRollout is the current or last rollout, as JSON.
+const KeyRollout = "rollout:current"
+
+// rolloutStatsKey is the hash of the decision counts of a rollout.
+func rolloutStatsKey(id string) string {
+	return "rollout:" + id + ":stats"
+}
+
+// Fields of the rollout stats hash.
+const (
+	statCanaryDecisions   = "canary:decisions"
+	statCanaryDenied      = "canary:denied"
+	statBaselineDecisions = "baseline:decisions"
+	statBaselineDenied    = "baseline:denied"
+)
+
+// States of a rollout.
+const (
+	RolloutRunning    = "running"
+	RolloutPromoted   = "promoted"
+	RolloutRolledBack = "rolled-back"
+)
+
+// Defaults of a rollout.
+const (
+	// DefaultRolloutMaxDenyRateIncrease is by how many percentage points
+	// the denial rate of the cohort may exceed that of the other tenants.
+	DefaultRolloutMaxDenyRateIncrease = 5.0
+	// DefaultRolloutMinDecisions is how many decisions the cohort must
+	// have had before its denial rate is compared.
+	DefaultRolloutMinDecisions = 50
+	// DefaultRolloutSyncInterval is how often a proxy-server syncs with
+	// the rollout in redis.
+	DefaultRolloutSyncInterval = 10 * time.Second
+)
+
+// ErrRolloutRunning is returned when a rollout is started while another is
+// running.
+var ErrRolloutRunning = errors.New("a rollout is already running")
+
+// ErrInvalidRollout is returned when a rollout is started with invalid
+// settings.
+var ErrInvalidRollout = errors.New("invalid rollout")
+
+// ErrNoRollout is returned when there is no running rollout to end.
+var ErrNoRollout = errors.New("no rollout is running")
+
+// Rollout is a change to OPA, e.g. new roles, that is rolled out to a cohort
+// of the tenants first: their queries are answered by a canary OPA instance
+// with the change. The rollout is rolled back automatically if the denial
+// rate of the cohort exceeds that of the other tenants by more than
+// MaxDenyRateIncrease percentage points.
+type Rollout struct {
+	ID string `json:"id"`
+	// OPAHost is the host:port of the canary OPA instance.
+	OPAHost string `json:"opaHost"`
+	// Percent is the percentage of the tenants in the cohort.
+	Percent float64 `json:"percent"`
+	// Data are the OPA data documents of the change by path, e.g.
+	// karavi/custom, which are pushed to the canary instance when the
+	// rollout starts and to the primary instance when it is promoted.
+	Data                map[string]json.RawMessage `json:"data,omitempty"`
+	MaxDenyRateIncrease float64                    `json:"maxDenyRateIncrease"`
+	MinDecisions        int64                      `json:"minDecisions"`
+	State               string                     `json:"state"`
+	// Reason is why the rollout was rolled back.
+	Reason    string     `json:"reason,omitempty"`
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+}
+
+// RolloutStats are the decisions of the cohort and of the other tenants
+// during a rollout, summed over every proxy-server.
+type RolloutStats struct {
+	CanaryDecisions   int64 `json:"canaryDecisions"`
+	CanaryDenied      int64 `json:"canaryDenied"`
+	BaselineDecisions int64 `json:"baselineDecisions"`
+	BaselineDenied    int64 `json:"baselineDenied"`
+}
+
+// CanaryDenyRate is the percentage of the decisions of the cohort that
+// were denied.
+func (s RolloutStats) CanaryDenyRate() float64 {
+	return denyRate(s.CanaryDenied, s.CanaryDecisions)
+}
+
+// BaselineDenyRate is the percentage of the decisions of the other tenants
+// that were denied.
+func (s RolloutStats) BaselineDenyRate() float64 {
+	return denyRate(s.BaselineDenied, s.BaselineDecisions)
+}
+
+func denyRate(denied, decisions int64) float64 {
+	if decisions == 0 {
+		return 0
+	}
+	return float64(denied) * 100 / float64(decisions)
+}
+
+// RolloutStatus is a rollout and its decisions.
+type RolloutStatus struct {
+	Rollout *Rollout     `json:"rollout"`
+	Stats   RolloutStats `json:"stats"`
+}
+
+// Rollouts keeps the rollout shared by every proxy-server through redis.
+// Each proxy-server routes the queries of the cohort to the canary instance
+// while the rollout runs, and adds the outcome of its decisions to the
+// stats of the rollout when it syncs.
+type Rollouts struct {
+	log     *logrus.Entry
+	rdb     func() *redis.Client
+	opaHost hostAddr
+
+	mu      sync.Mutex // guards current
+	current string     // ID of the rollout the canary is set for
+	// counts are the decisions not yet added to the stats, by stat field.
+	counts map[string]*atomic.Int64
+}
+
+// NewRollouts returns the Rollouts kept in redis. Promoted changes are
+// pushed to the OPA instance at opaHost.
+func NewRollouts(log *logrus.Entry, rdb func() *redis.Client, opaHost string) *Rollouts {
+	r := &Rollouts{
+		log: log,
+		rdb: rdb,
+		counts: map[string]*atomic.Int64{
+			statCanaryDecisions:   {},
+			statCanaryDenied:      {},
+			statBaselineDecisions: {},
+			statBaselineDenied:    {},
+		},
+	}
+	r.opaHost.Set(opaHost)
+	return r
+}
+
+// SetOPAHost changes the OPA host that promoted changes are pushed to.
+func (r *Rollouts) SetOPAHost(opaHost string) {
+	r.opaHost.Set(opaHost)
+}
+
+// Get returns the current or last rollout and its stats, or nil if there
+// has been none.
+func (r *Rollouts) Get() (*Rollout, RolloutStats, error) {
+	ro, err := r.load()
+	if err != nil || ro == nil {
+		return nil, RolloutStats{}, err
+	}
+	stats, err := r.stats(ro.ID)
+	return ro, stats, err
+}
+
+// Start pushes the data of the rollout to its canary instance and starts
+// routing the queries of the cohort to it.
+func (r *Rollouts) Start(ctx context.Context, ro Rollout) (*Rollout, error) {
+	if ro.OPAHost == "" {
+		return nil, fmt.Errorf("%w: canary opa host must be provided", ErrInvalidRollout)
+	}
+	if ro.Percent <= 0 || ro.Percent > 100 {
+		return nil, fmt.Errorf("%w: percent %v must be above 0 and at most 100", ErrInvalidRollout, ro.Percent)
+	}
+	if ro.MaxDenyRateIncrease <= 0 {
+		ro.MaxDenyRateIncrease = DefaultRolloutMaxDenyRateIncrease
+	}
+	if ro.MinDecisions <= 0 {
+		ro.MinDecisions = DefaultRolloutMinDecisions
+	}
+	current, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && current.State == RolloutRunning {
+		return nil, ErrRolloutRunning
+	}
+
+	for path, doc := range ro.Data {
+		if err := decision.PutData(ctx, ro.OPAHost, path, doc); err != nil {
+			return nil, fmt.Errorf("pushing %s to the canary opa: %w", path, err)
+		}
+	}
+
+	ro.State = RolloutRunning
+	ro.Reason = ""
+	ro.StartedAt = time.Now().UTC()
+	ro.EndedAt = nil
+	if ro.ID == "" {
+		ro.ID = ro.StartedAt.Format("20060102150405")
+	}
+	b, err := json.Marshal(&ro)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.rdb().TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(rolloutStatsKey(ro.ID))
+		pipe.Set(KeyRollout, b, 0)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.log.WithFields(logrus.Fields{
+		"rollout": ro.ID,
+		"host":    ro.OPAHost,
+		"percent": ro.Percent,
+	}).Info("Started rollout")
+	return &ro, r.Sync()
+}
+
+// Promote pushes the data of the running rollout to the primary instance
+// and ends the rollout.
+func (r *Rollouts) Promote(ctx context.Context) (*Rollout, error) {
+	ro, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	if ro == nil || ro.State != RolloutRunning {
+		return nil, ErrNoRollout
+	}
+	for path, doc := range ro.Data {
+		if err := decision.PutData(ctx, r.opaHost.Get(), path, doc); err != nil {
+			return nil, fmt.Errorf("pushing %s to the opa: %w", path, err)
+		}
+	}
+	return r.end(ro, RolloutPromoted, "")
+}
+
+// RollBack ends the running rollout without promoting it.
+func (r *Rollouts) RollBack(reason string) (*Rollout, error) {
+	ro, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	if ro == nil || ro.State != RolloutRunning {
+		return nil, ErrNoRollout
+	}
+	return r.end(ro, RolloutRolledBack, reason)
+}
+
+func (r *Rollouts) end(ro *Rollout, state, reason string) (*Rollout, error) {
+	now := time.Now().UTC()
+	ro.State = state
+	ro.Reason = reason
+	ro.EndedAt = &now
+	b, err := json.Marshal(ro)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.rdb().Set(KeyRollout, b, 0).Err(); err != nil {
+		return nil, err
+	}
+	r.log.WithFields(logrus.Fields{
+		"rollout": ro.ID,
+		"state":   state,
+		"reason":  reason,
+	}).Info("Ended rollout")
+	return ro, r.Sync()
+}
+
+// Sync adds the decisions of this proxy-server to the stats of the running
+// rollout, rolls it back if the denial rate of the cohort is too high, and
+// routes the queries of the cohort as the rollout says.
+func (r *Rollouts) Sync() error {
+	ro, err := r.load()
+	if err != nil {
+		return err
+	}
+	reason, err := r.sync(ro)
+	if err != nil || reason == "" {
+		return err
+	}
+	r.log.WithField("rollout", ro.ID).Warn("Rolling back rollout: " + reason)
+	_, err = r.RollBack(reason)
+	return err
+}
+
+// sync syncs with the rollout ro, and returns why it must be rolled back,
+// if it must.
+func (r *Rollouts) sync(ro *Rollout) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current != "" {
+		// The decisions were counted for the rollout the canary was set
+		// for, which may have ended since.
+		if err := r.flush(r.current); err != nil {
+			return "", err
+		}
+	}
+	if ro == nil || ro.State != RolloutRunning {
+		if r.current != "" {
+			decision.SetCanary(nil)
+			r.current = ""
+		}
+		return "", nil
+	}
+
+	stats, err := r.stats(ro.ID)
+	if err != nil {
+		return "", err
+	}
+	if stats.CanaryDecisions >= ro.MinDecisions && stats.CanaryDenyRate()-stats.BaselineDenyRate() > ro.MaxDenyRateIncrease {
+		return fmt.Sprintf("denial rate of the cohort %.1f%% exceeds %.1f%% of the other tenants by more than %v points",
+			stats.CanaryDenyRate(), stats.BaselineDenyRate(), ro.MaxDenyRateIncrease), nil
+	}
+
+	if r.current != ro.ID {
+		decision.SetCanary(&decision.Canary{
+			Host:    ro.OPAHost,
+			Percent: ro.Percent,
+			Observe: r.observe,
+		})
+		r.current = ro.ID
+	}
+	return "", nil
+}
+
+// Run syncs with the rollout in redis every interval until ctx is done.
+func (r *Rollouts) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := r.Sync(); err != nil {
+			r.log.WithError(err).Error("syncing rollout")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// observe counts the outcome of a decision during the rollout.
+func (r *Rollouts) observe(canary, denied bool) {
+	decisions, deniedField := statBaselineDecisions, statBaselineDenied
+	if canary {
+		decisions, deniedField = statCanaryDecisions, statCanaryDenied
+	}
+	r.counts[decisions].Add(1)
+	if denied {
+		r.counts[deniedField].Add(1)
+	}
+}
+
+// flush adds the counted decisions to the stats of the rollout.
+func (r *Rollouts) flush(id string) error {
+	deltas := make(map[string]int64)
+	for field, n := range r.counts {
+		if v := n.Swap(0); v != 0 {
+			deltas[field] = v
+		}
+	}
+	if len(deltas) == 0 {
+		return nil
+	}
+	_, err := r.rdb().TxPipelined(func(pipe redis.Pipeliner) error {
+		for field, v := range deltas {
+			pipe.HIncrBy(rolloutStatsKey(id), field, v)
+		}
+		return nil
+	})
+	if err != nil {
+		// Keep the decisions for the next sync.
+		for field, v := range deltas {
+			r.counts[field].Add(v)
+		}
+	}
+	return err
+}
+
+func (r *Rollouts) load() (*Rollout, error) {
+	b, err := r.rdb().Get(KeyRollout).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ro Rollout
+	if err := json.Unmarshal(b, &ro); err != nil {
+		return nil, fmt.Errorf("decoding rollout: %w", err)
+	}
+	return &ro, nil
+}
+
+func (r *Rollouts) stats(id string) (RolloutStats, error) {
+	m, err := r.rdb().HGetAll(rolloutStatsKey(id)).Result()
+	if err != nil {
+		return RolloutStats{}, err
+	}
+	var s RolloutStats
+	for field, p := range map[string]*int64{
+		statCanaryDecisions:   &s.CanaryDecisions,
+		statCanaryDenied:      &s.CanaryDenied,
+		statBaselineDecisions: &s.BaselineDecisions,
+		statBaselineDenied:    &s.BaselineDenied,
+	} {
+		if v, ok := m[field]; ok {
+			if _, err := fmt.Sscan(v, p); err != nil {
+				return RolloutStats{}, fmt.Errorf("decoding rollout stat %s: %w", field, err)
+			}
+		}
+	}
+	return s, nil
+}
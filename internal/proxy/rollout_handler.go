@@ -0,0 +1,164 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/web"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RolloutRollBackReason is the reason of the rollouts rolled back by admins.
+const RolloutRollBackReason = "rolled back by an admin"
+
+// RolloutHandler is the proxy handler for karavictl rollout requests.
+type RolloutHandler struct {
+	mux      *http.ServeMux
+	rollouts *Rollouts
+	log      *logrus.Entry
+}
+
+// NewRolloutHandler returns a RolloutHandler
+func NewRolloutHandler(log *logrus.Entry, rollouts *Rollouts) *RolloutHandler {
+	rh := &RolloutHandler{
+		rollouts: rollouts,
+		log:      log,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(web.ProxyRolloutsPath, web.Adapt(web.HandlerWithError(rh.rolloutHandler), web.TelemetryMW("rolloutHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyRolloutsPath, "promote"), web.Adapt(web.HandlerWithError(rh.promoteHandler), web.TelemetryMW("rolloutHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyRolloutsPath, "rollback"), web.Adapt(web.HandlerWithError(rh.rollBackHandler), web.TelemetryMW("rolloutHandler", log)))
+	rh.mux = mux
+
+	return rh
+}
+
+// ServeHTTP implements the http.Handler interface
+func (rh *RolloutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The rolled out data applies to every tenant, so only admins not
+	// scoped to an organization may roll out changes.
+	if admin, _ := r.Context().Value(web.JWTAdminName).(string); admin == "" || adminOrganization(r) != "" {
+		handleJSONErrorResponse(rh.log, w, http.StatusForbidden, errors.New("admin token required that is not scoped to an organization"))
+		return
+	}
+	rh.mux.ServeHTTP(w, r)
+}
+
+func (rh *RolloutHandler) rolloutHandler(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return rh.statusHandler(w, r)
+	case http.MethodPost:
+		return rh.startHandler(w, r)
+	default:
+		return handleMethodNotAllowed(rh.log, w, r)
+	}
+}
+
+func (rh *RolloutHandler) statusHandler(w http.ResponseWriter, _ *http.Request) error {
+	ro, stats, err := rh.rollouts.Get()
+	if err != nil {
+		err = fmt.Errorf("getting rollout: %w", err)
+		handleJSONErrorResponse(rh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return rh.writeRollout(w, http.StatusOK, ro, stats)
+}
+
+func (rh *RolloutHandler) startHandler(w http.ResponseWriter, r *http.Request) error {
+	var body Rollout
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(rh.log, w, http.StatusBadRequest, err)
+		return err
+	}
+	for path, doc := range body.Data {
+		if !json.Valid(doc) {
+			err := fmt.Errorf("data %s is not valid json", path)
+			handleJSONErrorResponse(rh.log, w, http.StatusBadRequest, err)
+			return err
+		}
+	}
+
+	ro, err := rh.rollouts.Start(r.Context(), body)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrRolloutRunning):
+			status = http.StatusConflict
+		case errors.Is(err, ErrInvalidRollout):
+			status = http.StatusBadRequest
+		}
+		err = fmt.Errorf("starting rollout: %w", err)
+		handleJSONErrorResponse(rh.log, w, status, err)
+		return err
+	}
+	return rh.writeRollout(w, http.StatusCreated, ro, RolloutStats{})
+}
+
+func (rh *RolloutHandler) promoteHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return handleMethodNotAllowed(rh.log, w, r)
+	}
+	return rh.endRollout(w, func() (*Rollout, error) {
+		return rh.rollouts.Promote(r.Context())
+	})
+}
+
+func (rh *RolloutHandler) rollBackHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return handleMethodNotAllowed(rh.log, w, r)
+	}
+	return rh.endRollout(w, func() (*Rollout, error) {
+		return rh.rollouts.RollBack(RolloutRollBackReason)
+	})
+}
+
+func (rh *RolloutHandler) endRollout(w http.ResponseWriter, end func() (*Rollout, error)) error {
+	ro, err := end()
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrNoRollout) {
+			status = http.StatusNotFound
+		}
+		err = fmt.Errorf("ending rollout: %w", err)
+		handleJSONErrorResponse(rh.log, w, status, err)
+		return err
+	}
+	_, stats, err := rh.rollouts.Get()
+	if err != nil {
+		err = fmt.Errorf("getting rollout: %w", err)
+		handleJSONErrorResponse(rh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return rh.writeRollout(w, http.StatusOK, ro, stats)
+}
+
+func (rh *RolloutHandler) writeRollout(w http.ResponseWriter, status int, ro *Rollout, stats RolloutStats) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(&RolloutStatus{Rollout: ro, Stats: stats})
+	if err != nil {
+		err = fmt.Errorf("writing rollout response: %w", err)
+		rh.log.WithError(err).Error()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"karavi-authorization/internal/decision"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRollouts(t *testing.T) {
+	defer decision.SetCanary(nil)
+
+	mr, err := miniredis.Run()
+	checkError(t, err)
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	// opa is a fake OPA instance that decides every query with allow and
+	// records the data documents pushed to it.
+	type opa struct {
+		host string
+		mu   sync.Mutex
+		data []string
+	}
+	newOPA := func(allow bool) *opa {
+		o := &opa{}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut {
+				o.mu.Lock()
+				o.data = append(o.data, r.URL.Path)
+				o.mu.Unlock()
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			fmt.Fprintf(w, `{"result": {"allow": %t}}`, allow)
+		}))
+		t.Cleanup(srv.Close)
+		o.host = strings.TrimPrefix(srv.URL, "http://")
+		return o
+	}
+	primary, canary := newOPA(true), newOPA(false)
+
+	log := logrus.New().WithContext(context.Background())
+	rollouts := proxy.NewRollouts(log, func() *redis.Client { return rdb }, primary.host)
+	h := proxy.NewRolloutHandler(log, rollouts)
+	serve := func(method, path, body string) (*httptest.ResponseRecorder, proxy.RolloutStatus) {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r = r.WithContext(context.WithValue(r.Context(), web.JWTAdminName, "admin"))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		var status proxy.RolloutStatus
+		if w.Code < 300 {
+			checkError(t, json.NewDecoder(w.Body).Decode(&status))
+		}
+		return w, status
+	}
+	// decide queries the primary instance for the tenant.
+	decide := func(tenant string) {
+		ctx := context.WithValue(context.Background(), web.JWTTenantName, tenant)
+		_, err := decision.CanWithContext(ctx, func() decision.Query {
+			return decision.Query{Host: primary.host, Policy: "/karavi/volumes/create"}
+		})
+		checkError(t, err)
+	}
+	var cohort, others []string
+	for i := 0; len(cohort) < 3 || len(others) < 3; i++ {
+		tenant := fmt.Sprintf("tenant-%d", i)
+		if decision.InCohort(tenant, 50) {
+			cohort = append(cohort, tenant)
+		} else {
+			others = append(others, tenant)
+		}
+	}
+	start := fmt.Sprintf(`{"id": "roles-v2", "opaHost": %q, "percent": 50, "minDecisions": 3, "data": {"karavi/custom": {"teams": ["a"]}}}`, canary.host)
+
+	t.Run("it starts a rollout on the canary", func(t *testing.T) {
+		w, got := serve(http.MethodPost, web.ProxyRolloutsPath, start)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("got status %d: %s, want %d", w.Code, w.Body, http.StatusCreated)
+		}
+		if got.Rollout.State != proxy.RolloutRunning || got.Rollout.MaxDenyRateIncrease != proxy.DefaultRolloutMaxDenyRateIncrease {
+			t.Errorf("got %+v, want a running rollout", got.Rollout)
+		}
+		if len(canary.data) != 1 || canary.data[0] != "/v1/data/karavi/custom" {
+			t.Errorf("got canary data %v, want karavi/custom", canary.data)
+		}
+	})
+
+	t.Run("it refuses a second rollout", func(t *testing.T) {
+		w, _ := serve(http.MethodPost, web.ProxyRolloutsPath, start)
+		if w.Code != http.StatusConflict {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusConflict)
+		}
+	})
+
+	t.Run("it rolls back when the cohort is denied more", func(t *testing.T) {
+		for i := range cohort {
+			decide(cohort[i])
+			decide(others[i])
+		}
+		checkError(t, rollouts.Sync())
+
+		_, got := serve(http.MethodGet, web.ProxyRolloutsPath, "")
+		if got.Rollout.State != proxy.RolloutRolledBack || got.Rollout.Reason == "" {
+			t.Errorf("got %+v, want the rollout rolled back", got.Rollout)
+		}
+		if got.Stats.CanaryDenied != 3 || got.Stats.BaselineDecisions != 3 || got.Stats.BaselineDenied != 0 {
+			t.Errorf("got stats %+v, want 3 denied canary and 3 allowed baseline decisions", got.Stats)
+		}
+
+		// The cohort is decided by the primary instance again.
+		decide(cohort[0])
+		checkError(t, rollouts.Sync())
+		if _, got = serve(http.MethodGet, web.ProxyRolloutsPath, ""); got.Stats.CanaryDecisions != 3 {
+			t.Errorf("got %d canary decisions, want 3", got.Stats.CanaryDecisions)
+		}
+	})
+
+	t.Run("it promotes a rollout to the primary", func(t *testing.T) {
+		w, _ := serve(http.MethodPost, web.ProxyRolloutsPath, strings.Replace(start, "roles-v2", "roles-v3", 1))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("got status %d: %s, want %d", w.Code, w.Body, http.StatusCreated)
+		}
+
+		w, got := serve(http.MethodPost, web.ProxyRolloutsPath+"promote/", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d: %s, want %d", w.Code, w.Body, http.StatusOK)
+		}
+		if got.Rollout.ID != "roles-v3" || got.Rollout.State != proxy.RolloutPromoted {
+			t.Errorf("got %+v, want roles-v3 promoted", got.Rollout)
+		}
+		if len(primary.data) != 1 || primary.data[0] != "/v1/data/karavi/custom" {
+			t.Errorf("got primary data %v, want karavi/custom", primary.data)
+		}
+	})
+
+	t.Run("it fails to end a rollout that is not running", func(t *testing.T) {
+		w, _ := serve(http.MethodPost, web.ProxyRolloutsPath+"rollback/", "")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("it rejects an invalid rollout", func(t *testing.T) {
+		w, _ := serve(http.MethodPost, web.ProxyRolloutsPath, `{"opaHost": "opa-canary:8181", "percent": 120}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("it requires an admin token", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, web.ProxyRolloutsPath, nil)
+		r = r.WithContext(context.WithValue(r.Context(), web.JWTTenantName, "tenant-1"))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusForbidden)
+		}
+	})
+
+	t.Run("it requires an admin of every organization", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, web.ProxyRolloutsPath, strings.NewReader(start))
+		ctx := context.WithValue(r.Context(), web.JWTAdminName, "admin")
+		r = r.WithContext(context.WithValue(ctx, web.JWTOrganization, "finance"))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusForbidden)
+		}
+	})
+}
@@ -15,10 +15,13 @@
 package proxy
 
 import (
-	"encoding/json"
+	"context"
+	"fmt"
+	"karavi-authorization/internal/quota"
 	"karavi-authorization/internal/web"
 	"net/http"
 	"path"
+	"sort"
 	"sync"
 
 	"github.com/sirupsen/logrus"
@@ -59,6 +62,16 @@ func cleanPath(pth string) string {
 }
 
 func writeError(w http.ResponseWriter, storage string, msg string, code int, log *logrus.Entry) {
+	writeErrorBody(w, storage, msg, code, nil, log)
+}
+
+// writeDenied writes the error response of a denied storage request with
+// the machine-readable reason it was denied.
+func writeDenied(w http.ResponseWriter, storage string, msg string, code int, deny web.Deny, log *logrus.Entry) {
+	writeErrorBody(w, storage, msg, code, &deny, log)
+}
+
+func writeErrorBody(w http.ResponseWriter, storage string, msg string, code int, deny *web.Deny, log *logrus.Entry) {
 	log.WithFields(logrus.Fields{
 		"storage": storage,
 		"code":    code,
@@ -66,21 +79,91 @@ func writeError(w http.ResponseWriter, storage string, msg string, code int, log
 	}).Debug("proxy: writing error")
 	w.WriteHeader(code)
 	errBody := struct {
-		Code       int    `json:"errorCode"`
-		StatusCode int    `json:"httpStatusCode"`
-		Message    string `json:"message"`
+		Code       int       `json:"errorCode"`
+		StatusCode int       `json:"httpStatusCode"`
+		Message    string    `json:"message"`
+		Deny       *web.Deny `json:"deny,omitempty"`
 	}{
 		Code:       code,
 		StatusCode: code,
 		Message:    msg,
+		Deny:       deny,
 	}
-	err := json.NewEncoder(w).Encode(&errBody)
+	err := writeJSON(w, &errBody)
 	if err != nil {
 		log.WithError(err).Error("encoding error response")
 		http.Error(w, "Failed to encode error response", http.StatusInternalServerError)
 	}
 }
 
+// quotaDeny returns the deny reason of a quota request that was not
+// approved, along with the usage of the tenant in the storage pool. The
+// limit is the quota in kilobytes, the soft quota for
+// web.CodeSoftQuotaExpired, or the maximum number of volumes for
+// web.CodeMaxVolumes.
+func quotaDeny(ctx context.Context, enf *quota.RedisEnforcement, qr quota.Request, tenant string, code web.ErrorCode, limit int64, log *logrus.Entry) web.Deny {
+	deny := web.Deny{
+		Code:   code,
+		Reason: "not enough quota",
+		Tenant: tenant,
+		Pool:   qr.StoragePoolID,
+		Limit:  limit,
+	}
+	switch code {
+	case web.CodeMaxVolumes:
+		deny.Reason = "maximum number of volumes reached"
+	case web.CodeSoftQuotaExpired:
+		deny.Reason = "soft quota grace period expired"
+	}
+
+	capacity, volumes, err := enf.Usage(ctx, qr)
+	if err != nil {
+		log.WithError(err).Warn("reading tenant usage")
+		return deny
+	}
+	deny.Usage = capacity
+	if code == web.CodeMaxVolumes {
+		deny.Usage = volumes
+	}
+	return deny
+}
+
+// poolHints returns the alternative pools, mapped to the quota of the
+// tenant in them, that still have capacity for a request of sizeInKb, and
+// volumes left if qr has a maximum. They are ordered by the capacity left,
+// unlimited first, so that the driver can retry the request in the first.
+func poolHints(ctx context.Context, enf *quota.RedisEnforcement, qr quota.Request, sizeInKb int64, alternatives map[string]int64, log *logrus.Entry) []web.PoolHint {
+	var hints []web.PoolHint
+	for pool, limit := range alternatives {
+		alt := qr
+		alt.StoragePoolID = pool
+		capacity, volumes, err := enf.Usage(ctx, alt)
+		if err != nil {
+			log.WithError(err).WithField("pool", pool).Warn("reading tenant usage")
+			continue
+		}
+		if qr.MaxVolumes > 0 && volumes >= qr.MaxVolumes {
+			continue
+		}
+		available := quota.Unlimited
+		if limit != quota.Unlimited {
+			available = limit - capacity
+			if available < sizeInKb {
+				continue
+			}
+		}
+		hints = append(hints, web.PoolHint{Pool: pool, Available: available})
+	}
+	sort.Slice(hints, func(i, j int) bool {
+		a, b := hints[i].Available, hints[j].Available
+		if a != b {
+			return a == quota.Unlimited || (b != quota.Unlimited && a > b)
+		}
+		return hints[i].Pool < hints[j].Pool
+	})
+	return hints
+}
+
 // handleJSONErrorResponse logs the error and writes an error response
 func handleJSONErrorResponse(log *logrus.Entry, w http.ResponseWriter, code int, err error) {
 	log.Error(err)
@@ -88,3 +171,18 @@ func handleJSONErrorResponse(log *logrus.Entry, w http.ResponseWriter, code int,
 		log.WithError(err).Error("writing json error response")
 	}
 }
+
+// handleRPCErrorResponse logs an error returned from one of the gRPC
+// services and writes an error response with the matching code and status.
+func handleRPCErrorResponse(log *logrus.Entry, w http.ResponseWriter, err error) {
+	code := web.RPCErrorCode(err)
+	handleJSONErrorResponse(log, w, code.Status(), web.NewError(code, err))
+}
+
+// handleMethodNotAllowed writes an error response for a request method that
+// the endpoint does not serve.
+func handleMethodNotAllowed(log *logrus.Entry, w http.ResponseWriter, r *http.Request) error {
+	err := fmt.Errorf("method %s not allowed", r.Method)
+	handleJSONErrorResponse(log, w, http.StatusMethodNotAllowed, err)
+	return err
+}
@@ -14,7 +14,17 @@
 
 package proxy
 
-import "testing"
+import (
+	"context"
+	"karavi-authorization/internal/quota"
+	"karavi-authorization/internal/web"
+	"reflect"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
 
 func Test_cleanPath(t *testing.T) {
 	tests := []struct {
@@ -35,3 +45,67 @@ func Test_cleanPath(t *testing.T) {
 		})
 	}
 }
+
+func Test_poolHints(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	enf := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rdb))
+	log := logrus.NewEntry(logrus.New())
+
+	qr := quota.Request{SystemType: "powerflex", SystemID: "542a2d5f5122210f", StoragePoolID: "bronze", Group: "mytenant"}
+	usage := func(pool, capacity, volumes string) {
+		alt := qr
+		alt.StoragePoolID = pool
+		mr.HSet(alt.DataKey(), alt.ApprovedCapacityField(), capacity)
+		mr.HSet(alt.DataKey(), alt.ApprovedVolumesField(), volumes)
+	}
+	usage("silver", "60", "1")
+	usage("gold", "10", "1")
+	usage("full", "95", "1")
+	usage("crowded", "0", "3")
+	alternatives := map[string]int64{
+		"silver":    100,
+		"gold":      100,
+		"full":      100,
+		"crowded":   100,
+		"unlimited": quota.Unlimited,
+	}
+
+	t.Run("it orders the pools with capacity left by it", func(t *testing.T) {
+		got := poolHints(context.Background(), enf, qr, 20, alternatives, log)
+
+		want := []web.PoolHint{
+			{Pool: "unlimited", Available: quota.Unlimited},
+			{Pool: "crowded", Available: 100},
+			{Pool: "gold", Available: 90},
+			{Pool: "silver", Available: 40},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+	t.Run("it leaves out the pools without volumes left", func(t *testing.T) {
+		withMax := qr
+		withMax.MaxVolumes = 3
+
+		got := poolHints(context.Background(), enf, withMax, 50, alternatives, log)
+
+		want := []web.PoolHint{
+			{Pool: "unlimited", Available: quota.Unlimited},
+			{Pool: "gold", Available: 90},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+	t.Run("it returns none without alternatives", func(t *testing.T) {
+		if got := poolHints(context.Background(), enf, qr, 20, nil, log); got != nil {
+			t.Errorf("got %+v, want none", got)
+		}
+	})
+}
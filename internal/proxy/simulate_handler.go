@@ -0,0 +1,340 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/decision"
+	"karavi-authorization/internal/quota"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"strconv"
+
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Operations supported by the simulate endpoint.
+const (
+	SimulateCreate = "create"
+	SimulateDelete = "delete"
+	SimulateMap    = "map"
+	SimulateUnmap  = "unmap"
+)
+
+// errInvalidSimulation is returned when the simulated request is incomplete.
+var errInvalidSimulation = errors.New("invalid simulation request")
+
+// SimulateHandler is the proxy handler for karavictl policy simulate requests.
+// It evaluates a hypothetical storage request against OPA and the quota
+// enforcer without executing it.
+type SimulateHandler struct {
+	mux      *http.ServeMux
+	enforcer *quota.RedisEnforcement
+	opaHost  hostAddr
+	log      *logrus.Entry
+}
+
+// NewSimulateHandler returns a SimulateHandler
+func NewSimulateHandler(log *logrus.Entry, enforcer *quota.RedisEnforcement, opaHost string) *SimulateHandler {
+	sh := &SimulateHandler{
+		enforcer: enforcer,
+		log:      log,
+	}
+	sh.opaHost.Set(opaHost)
+
+	mux := http.NewServeMux()
+	mux.Handle(web.ProxySimulatePath, web.Adapt(web.HandlerWithError(sh.simulateHandler), web.TelemetryMW("simulateHandler", log)))
+	sh.mux = mux
+
+	return sh
+}
+
+// SetOPAHost changes the OPA host asked for policy decisions.
+func (sh *SimulateHandler) SetOPAHost(opaHost string) {
+	sh.opaHost.Set(opaHost)
+}
+
+// ServeHTTP implements the http.Handler interface
+func (sh *SimulateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Simulations reveal the usage and quota of any tenant, so only admins
+	// not scoped to an organization may run them.
+	if admin, _ := r.Context().Value(web.JWTAdminName).(string); admin == "" || adminOrganization(r) != "" {
+		handleJSONErrorResponse(sh.log, w, http.StatusForbidden, errors.New("admin token required that is not scoped to an organization"))
+		return
+	}
+	sh.mux.ServeHTTP(w, r)
+}
+
+// SimulateBody is the request body for a policy simulation
+type SimulateBody struct {
+	Tenant     string `json:"tenant"`
+	Roles      string `json:"roles"`
+	SystemType string `json:"systemType"`
+	SystemID   string `json:"systemId"`
+	Pool       string `json:"pool"`
+	// ProtectionDomainID is the ID of the PowerFlex protection domain of
+	// the pool, for roles that permit any pool of a protection domain.
+	ProtectionDomainID string `json:"protectionDomainId,omitempty"`
+	// ServiceLevel is the PowerMax service level of the volume, for roles
+	// pinned to service levels.
+	ServiceLevel string `json:"serviceLevel,omitempty"`
+	Capacity     string `json:"capacity,omitempty"`
+	Operation    string `json:"operation"`
+	// Claims are the custom claims of the tenant.
+	Claims map[string]string `json:"claims,omitempty"`
+}
+
+// SimulateQuota is the quota outcome of a simulated create request
+type SimulateQuota struct {
+	Approved      bool   `json:"approved"`
+	LimitInKb     int64  `json:"limitInKb"`
+	SoftLimitInKb int64  `json:"softLimitInKb,omitempty"`
+	UsedInKb      uint64 `json:"usedInKb"`
+	RequestedInKb uint64 `json:"requestedInKb"`
+}
+
+// SimulateResponse is the response body of a policy simulation
+type SimulateResponse struct {
+	Allowed        bool             `json:"allowed"`
+	Reasons        []string         `json:"reasons,omitempty"`
+	PermittedRoles map[string]int64 `json:"permittedRoles,omitempty"`
+	Quota          *SimulateQuota   `json:"quota,omitempty"`
+}
+
+func (sh *SimulateHandler) simulateHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		err := fmt.Errorf("method %s not allowed", r.Method)
+		handleJSONErrorResponse(sh.log, w, http.StatusMethodNotAllowed, err)
+		return err
+	}
+
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	var body SimulateBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(sh.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	if body.Tenant == "" || body.Operation == "" {
+		err = errors.New("tenant and operation are required")
+		handleJSONErrorResponse(sh.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	setAttributes(span, map[string]interface{}{
+		"tenant":     body.Tenant,
+		"roles":      body.Roles,
+		"systemType": body.SystemType,
+		"systemId":   body.SystemID,
+		"pool":       body.Pool,
+		"capacity":   body.Capacity,
+		"operation":  body.Operation,
+	})
+	sh.log.WithFields(logrus.Fields{
+		"tenant":     body.Tenant,
+		"roles":      body.Roles,
+		"systemType": body.SystemType,
+		"systemId":   body.SystemID,
+		"pool":       body.Pool,
+		"capacity":   body.Capacity,
+		"operation":  body.Operation,
+	}).Info("Simulating request")
+
+	var resp SimulateResponse
+	switch body.Operation {
+	case SimulateCreate:
+		resp, err = sh.simulateCreate(r, body)
+	case SimulateDelete, SimulateMap, SimulateUnmap:
+		resp, err = sh.simulateClaimsOnly(r, body)
+	default:
+		err = fmt.Errorf("unsupported operation %q", body.Operation)
+		handleJSONErrorResponse(sh.log, w, http.StatusBadRequest, err)
+		return err
+	}
+	if err != nil {
+		err = fmt.Errorf("simulating %s request: %w", body.Operation, err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, errInvalidSimulation) {
+			status = http.StatusBadRequest
+		}
+		handleJSONErrorResponse(sh.log, w, status, err)
+		return err
+	}
+
+	reason := ""
+	if len(resp.Reasons) > 0 {
+		reason = resp.Reasons[0]
+	}
+	setDecisionAttributes(span, resp.Allowed, reason)
+
+	err = json.NewEncoder(w).Encode(&resp)
+	if err != nil {
+		err = fmt.Errorf("writing simulate response: %w", err)
+		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return nil
+}
+
+func (sh *SimulateHandler) simulateCreate(r *http.Request, body SimulateBody) (SimulateResponse, error) {
+	ctx := r.Context()
+
+	if body.SystemType == "" || body.SystemID == "" || body.Pool == "" || body.Capacity == "" {
+		return SimulateResponse{}, fmt.Errorf("%w: systemType, systemId, pool and capacity are required", errInvalidSimulation)
+	}
+	capBytes, err := humanize.ParseBytes(body.Capacity)
+	if err != nil {
+		return SimulateResponse{}, fmt.Errorf("%w: parsing capacity: %v", errInvalidSimulation, err)
+	}
+	capKb := capBytes / 1024
+
+	policy := "/karavi/volumes/create"
+	if body.SystemType == "powermax" {
+		policy = "/karavi/volumes/powermax/create"
+	}
+
+	ans, err := decision.CanWithContext(ctx, func() decision.Query {
+		return decision.Query{
+			Host:   sh.opaHost.Get(),
+			Policy: policy,
+			Input: map[string]interface{}{
+				"claims":             simulateClaims(body),
+				"request":            map[string]interface{}{"volumeSizeInKb": strconv.FormatUint(capKb, 10)},
+				"storagepool":        body.Pool,
+				"protectiondomainid": body.ProtectionDomainID,
+				"servicelevel":       body.ServiceLevel,
+				"storagesystemid":    body.SystemID,
+				"systemtype":         body.SystemType,
+			},
+		}
+	})
+	if err != nil {
+		return SimulateResponse{}, fmt.Errorf("asking OPA for volume create decision: %w", err)
+	}
+
+	var opaResp CreateOPAResponse
+	err = json.NewDecoder(bytes.NewReader(ans)).Decode(&opaResp)
+	if err != nil {
+		return SimulateResponse{}, fmt.Errorf("decoding opa response: %w", err)
+	}
+	resp := SimulateResponse{
+		Allowed:        opaResp.Result.Allow,
+		Reasons:        opaResp.Result.Deny,
+		PermittedRoles: opaResp.Result.PermittedRoles,
+	}
+	if !resp.Allowed {
+		return resp, nil
+	}
+
+	// Check the volume size bounds of the roles, as the storage handlers do.
+	permittedRoles, err := sizePermittedRoles(opaResp.Result.PermittedRoles, opaResp.Result.MinSizes, opaResp.Result.MaxSizes, int64(capKb))
+	if err != nil {
+		resp.Allowed = false
+		resp.Reasons = append(resp.Reasons, err.Error())
+		return resp, nil
+	}
+	resp.PermittedRoles = permittedRoles
+
+	// Choose the role with the most quota, as the storage handlers do.
+	maxQuotaInKb := maxPermittedQuota(permittedRoles)
+
+	tenantKey, err := sh.enforcer.TenantID(ctx, body.Tenant)
+	if err != nil {
+		return SimulateResponse{}, fmt.Errorf("resolving tenant: %w", err)
+	}
+	maxVolumes, err := sh.enforcer.MaxVolumes(ctx, body.Tenant)
+	if err != nil {
+		return SimulateResponse{}, fmt.Errorf("resolving tenant max volumes: %w", err)
+	}
+
+	qr := quota.Request{
+		SystemType:    body.SystemType,
+		SystemID:      body.SystemID,
+		StoragePoolID: body.Pool,
+		Group:         tenantKey,
+		Capacity:      strconv.FormatUint(capKb, 10),
+		MaxVolumes:    maxVolumes,
+		SoftQuota:     permittedSoftQuota(permittedRoles, opaResp.Result.SoftQuotas),
+	}
+	ok, used, err := sh.enforcer.CheckRequest(ctx, qr, maxQuotaInKb)
+	maxVolumesReached := errors.Is(err, quota.ErrMaxVolumes)
+	graceExpired := errors.Is(err, quota.ErrGracePeriodExpired)
+	if err != nil && !maxVolumesReached && !graceExpired {
+		return SimulateResponse{}, fmt.Errorf("checking quota: %w", err)
+	}
+	resp.Quota = &SimulateQuota{
+		Approved:      ok,
+		LimitInKb:     maxQuotaInKb,
+		SoftLimitInKb: qr.SoftQuota,
+		UsedInKb:      used,
+		RequestedInKb: capKb,
+	}
+	switch {
+	case maxVolumesReached:
+		resp.Allowed = false
+		resp.Reasons = append(resp.Reasons, "maximum number of volumes reached")
+	case graceExpired:
+		resp.Allowed = false
+		resp.Reasons = append(resp.Reasons, "soft quota grace period expired")
+	case !ok:
+		resp.Allowed = false
+		resp.Reasons = append(resp.Reasons, "not enough quota")
+	}
+	return resp, nil
+}
+
+func (sh *SimulateHandler) simulateClaimsOnly(r *http.Request, body SimulateBody) (SimulateResponse, error) {
+	ans, err := decision.CanWithContext(r.Context(), func() decision.Query {
+		return decision.Query{
+			Host:   sh.opaHost.Get(),
+			Policy: fmt.Sprintf("/karavi/volumes/%s", body.Operation),
+			Input: map[string]interface{}{
+				"claims": simulateClaims(body),
+			},
+		}
+	})
+	if err != nil {
+		return SimulateResponse{}, fmt.Errorf("asking OPA for volume %s decision: %w", body.Operation, err)
+	}
+
+	var opaResp OPAResponse
+	err = json.NewDecoder(bytes.NewReader(ans)).Decode(&opaResp)
+	if err != nil {
+		return SimulateResponse{}, fmt.Errorf("decoding opa response: %w", err)
+	}
+	resp := SimulateResponse{Allowed: opaResp.Result.Response.Allowed}
+	if reason := opaResp.Result.Response.Status.Reason; reason != "" {
+		resp.Reasons = []string{reason}
+	}
+	return resp, nil
+}
+
+func simulateClaims(body SimulateBody) map[string]interface{} {
+	claims := map[string]interface{}{
+		"group": body.Tenant,
+		"roles": body.Roles,
+	}
+	if len(body.Claims) > 0 {
+		claims["custom"] = body.Claims
+	}
+	return claims
+}
@@ -0,0 +1,274 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"karavi-authorization/internal/quota"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSimulateHandler(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	enf := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()})))
+
+	var gotInput map[string]interface{}
+	fakeOPA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input map[string]interface{} `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		gotInput = body.Input
+		switch r.URL.Path {
+		case "/v1/data/karavi/volumes/create":
+			w.Write([]byte(`{"result": {"allow": true, "permitted_roles": {"role": 10485760}}}`))
+		case "/v1/data/karavi/volumes/delete":
+			w.Write([]byte(`{"result": {"response": {"allowed": false, "status": {"reason": "token has expired"}}}}`))
+		default:
+			t.Errorf("unexpected OPA request: %s", r.URL.Path)
+		}
+	}))
+	defer fakeOPA.Close()
+	u, err := url.Parse(fakeOPA.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// simulateAs simulates the request with a token of the given claims.
+	simulateAs := func(t *testing.T, claims map[interface{}]string, body SimulateBody) (*httptest.ResponseRecorder, SimulateResponse) {
+		t.Helper()
+		sut := NewSimulateHandler(logrus.NewEntry(logrus.New()), enf, u.Host)
+
+		payload, err := json.Marshal(&body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := httptest.NewRequest(http.MethodPost, "/proxy/simulate/", bytes.NewReader(payload))
+		ctx := r.Context()
+		for k, v := range claims {
+			ctx = context.WithValue(ctx, k, v)
+		}
+		w := httptest.NewRecorder()
+
+		sut.ServeHTTP(w, r.WithContext(ctx))
+
+		var resp SimulateResponse
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w, resp
+	}
+	simulate := func(t *testing.T, body SimulateBody) (*httptest.ResponseRecorder, SimulateResponse) {
+		t.Helper()
+		return simulateAs(t, map[interface{}]string{web.JWTAdminName: "admin"}, body)
+	}
+
+	create := SimulateBody{
+		Tenant:     "PancakeGroup",
+		Roles:      "role",
+		SystemType: "powerflex",
+		SystemID:   "542a2d5f5122210f",
+		Pool:       "bronze",
+		Capacity:   "8GiB",
+		Operation:  SimulateCreate,
+	}
+
+	t.Run("it approves a create request within quota", func(t *testing.T) {
+		mr.FlushAll()
+
+		w, resp := simulate(t, create)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+		}
+		if !resp.Allowed || resp.Quota == nil || !resp.Quota.Approved {
+			t.Errorf("expected request to be allowed, got %+v", resp)
+		}
+		if resp.Quota.RequestedInKb != 8388608 {
+			t.Errorf("got requested %d, want %d", resp.Quota.RequestedInKb, 8388608)
+		}
+		if got := gotInput["request"].(map[string]interface{})["volumeSizeInKb"]; got != "8388608" {
+			t.Errorf("got OPA volumeSizeInKb %v, want 8388608", got)
+		}
+	})
+
+	t.Run("it denies a create request that exceeds quota without changing state", func(t *testing.T) {
+		mr.FlushAll()
+		qr := quota.Request{SystemType: "powerflex", SystemID: "542a2d5f5122210f", StoragePoolID: "bronze", Group: "PancakeGroup"}
+		mr.HSet(qr.DataKey(), qr.ApprovedCapacityField(), "4194304")
+
+		w, resp := simulate(t, create)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+		}
+		if resp.Allowed || resp.Quota.Approved || resp.Quota.UsedInKb != 4194304 {
+			t.Errorf("expected request to be denied by quota, got %+v", resp)
+		}
+		if got := mr.HGet(qr.DataKey(), qr.ApprovedCapacityField()); got != "4194304" {
+			t.Errorf("approved capacity changed to %s", got)
+		}
+	})
+
+	t.Run("it returns the OPA reason for a denied delete request", func(t *testing.T) {
+		w, resp := simulate(t, SimulateBody{Tenant: "PancakeGroup", Roles: "role", Operation: SimulateDelete})
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+		}
+		if resp.Allowed || len(resp.Reasons) != 1 || resp.Reasons[0] != "token has expired" {
+			t.Errorf("expected denied response with reason, got %+v", resp)
+		}
+	})
+
+	t.Run("it passes the custom claims to OPA", func(t *testing.T) {
+		body := SimulateBody{Tenant: "PancakeGroup", Roles: "role", Operation: SimulateDelete, Claims: map[string]string{"team": "payments"}}
+
+		w, _ := simulate(t, body)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+		}
+		claims := gotInput["claims"].(map[string]interface{})
+		if got := claims["custom"].(map[string]interface{})["team"]; got != "payments" {
+			t.Errorf("got OPA custom claim team %v, want payments", got)
+		}
+	})
+
+	t.Run("it requires an admin of every organization", func(t *testing.T) {
+		for name, claims := range map[string]map[interface{}]string{
+			"tenant":             {web.JWTTenantName: "PancakeGroup"},
+			"organization admin": {web.JWTAdminName: "admin", web.JWTOrganization: "finance"},
+		} {
+			w, _ := simulateAs(t, claims, create)
+			if w.Code != http.StatusForbidden {
+				t.Errorf("%s: got status %d, want %d", name, w.Code, http.StatusForbidden)
+			}
+		}
+	})
+
+	t.Run("it rejects incomplete requests", func(t *testing.T) {
+		body := create
+		body.Capacity = ""
+
+		w, _ := simulate(t, body)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("it rejects unsupported operations", func(t *testing.T) {
+		body := create
+		body.Operation = "expand"
+
+		w, _ := simulate(t, body)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestMaxPermittedQuota(t *testing.T) {
+	tests := map[string]struct {
+		roles map[string]int64
+		want  int64
+	}{
+		"largest quota":  {map[string]int64{"small": 100, "large": 1000}, 1000},
+		"zero quota":     {map[string]int64{"none": 0}, 0},
+		"unlimited role": {map[string]int64{"large": 1000, "unlimited": -1}, quota.Unlimited},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := maxPermittedQuota(tc.roles); got != tc.want {
+				t.Errorf("got %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPermittedSoftQuota(t *testing.T) {
+	tests := map[string]struct {
+		roles map[string]int64
+		soft  map[string]int64
+		want  int64
+	}{
+		"no soft quotas":        {map[string]int64{"large": 1000}, nil, 0},
+		"soft quota of largest": {map[string]int64{"small": 100, "large": 1000}, map[string]int64{"small": 50, "large": 800}, 800},
+		"soft quota of smaller": {map[string]int64{"small": 100, "large": 1000}, map[string]int64{"small": 50}, 0},
+		"unlimited role":        {map[string]int64{"large": 1000, "unlimited": -1}, map[string]int64{"unlimited": 5000}, 5000},
+		"one role without":      {map[string]int64{"a": 1000, "b": 1000}, map[string]int64{"a": 800}, 0},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := permittedSoftQuota(tc.roles, tc.soft); got != tc.want {
+				t.Errorf("got %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSizePermittedRoles(t *testing.T) {
+	roles := map[string]int64{"small": 100000, "large": 1000000}
+	tests := map[string]struct {
+		roles      map[string]int64
+		min, max   map[string]int64
+		want       map[string]int64
+		wantReason string
+	}{
+		"no bounds":         {roles, nil, nil, roles, ""},
+		"within bounds":     {roles, map[string]int64{"large": 1000}, map[string]int64{"large": 10000}, roles, ""},
+		"out of one role":   {roles, map[string]int64{"large": 10000}, nil, map[string]int64{"small": 100000}, ""},
+		"no permitted role": {nil, nil, nil, map[string]int64{}, ""},
+		"below every min":   {roles, map[string]int64{"small": 10000, "large": 10000}, nil, nil, "volume size not allowed: 5000 Kb is below the minSize 10000 Kb of role large,5000 Kb is below the minSize 10000 Kb of role small"},
+		"above every max":   {roles, nil, map[string]int64{"small": 1000, "large": 1000}, nil, "volume size not allowed: 5000 Kb is above the maxSize 1000 Kb of role large,5000 Kb is above the maxSize 1000 Kb of role small"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := sizePermittedRoles(tc.roles, tc.min, tc.max, 5000)
+			if tc.wantReason != "" {
+				if !errors.Is(err, errVolumeSize) || err.Error() != tc.wantReason {
+					t.Fatalf("got error %v, want %q", err, tc.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"karavi-authorization/internal/eventsvc"
+	"karavi-authorization/internal/quota"
+	"karavi-authorization/internal/web"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SoftQuotaExceeded returns the function for quota.WithSoftQuotaExceeded
+// that logs the requests approved beyond the soft quota of a tenant and
+// counts them in the soft_quota_exceeded_total metric, to alert on. They are
+// also published as quota threshold events, unless events is nil.
+func SoftQuotaExceeded(log *logrus.Entry, events *eventsvc.Publisher) func(context.Context, quota.Request) {
+	return func(ctx context.Context, r quota.Request) {
+		tenant, _ := ctx.Value(web.JWTTenantName).(string)
+		if tenant == "" {
+			tenant = r.Group
+		}
+		softQuotaExceeded.WithLabelValues(tenant, r.SystemType, r.SystemID, r.StoragePoolID).Inc()
+		log.WithFields(logrus.Fields{
+			"tenant":     tenant,
+			"system_id":  r.SystemID,
+			"pool":       r.StoragePoolID,
+			"volume":     r.VolumeName,
+			"soft_quota": r.SoftQuota,
+		}).Warn("request approved beyond the soft quota")
+		events.Publish(eventsvc.TypeQuotaThresholdCrossed, tenant, "",
+			"system_type", r.SystemType,
+			"system_id", r.SystemID,
+			"pool", r.StoragePoolID,
+			"volume", r.VolumeName,
+			"soft_quota", strconv.FormatInt(r.SoftQuota, 10))
+	}
+}
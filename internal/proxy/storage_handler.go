@@ -14,10 +14,14 @@ package proxy
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"karavi-authorization/internal/quota"
 	"karavi-authorization/internal/web"
 	"karavi-authorization/pb"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/trace"
@@ -26,9 +30,12 @@ import (
 
 // StorageHandler is the proxy handler for karavictl storage requests
 type StorageHandler struct {
-	mux    *http.ServeMux
-	client pb.StorageServiceClient
-	log    *logrus.Entry
+	mux     *http.ServeMux
+	client  pb.StorageServiceClient
+	log     *logrus.Entry
+	enf     *quota.RedisEnforcement
+	tenants pb.TenantServiceClient
+	pauses  *SystemPauses
 }
 
 type createStorageBody struct {
@@ -40,6 +47,24 @@ type createStorageBody struct {
 	Insecure    bool   `json:"Insecure"`
 }
 
+// AdoptVolumeBody is the request body to adopt an existing volume of a
+// storage system for a tenant.
+type AdoptVolumeBody struct {
+	StorageType string `json:"StorageType"`
+	SystemID    string `json:"SystemId"`
+	VolumeID    string `json:"VolumeId"`
+	Tenant      string `json:"Tenant"`
+}
+
+// PauseSystemBody is the request body to pause a storage system for
+// maintenance, or to resume it.
+type PauseSystemBody struct {
+	StorageType string   `json:"StorageType"`
+	SystemID    string   `json:"SystemId"`
+	Reason      string   `json:"Reason,omitempty"`
+	RetryAfter  Duration `json:"RetryAfter,omitempty"`
+}
+
 // NewStorageHandler returns a StorageHandler
 func NewStorageHandler(log *logrus.Entry, client pb.StorageServiceClient) *StorageHandler {
 	sh := &StorageHandler{
@@ -49,12 +74,39 @@ func NewStorageHandler(log *logrus.Entry, client pb.StorageServiceClient) *Stora
 
 	mux := http.NewServeMux()
 	mux.Handle(web.ProxyStoragePath, web.Adapt(web.HandlerWithError(sh.storageHandler), web.TelemetryMW("storageHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyStoragePath, "status"), web.Adapt(web.HandlerWithError(sh.statusHandler), web.TelemetryMW("storageHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyStoragePath, "discover"), web.Adapt(web.HandlerWithError(sh.discoverHandler), web.TelemetryMW("storageHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyStoragePath, "adopt"), web.Adapt(web.HandlerWithError(sh.adoptHandler), web.TelemetryMW("storageHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyStoragePath, "pause"), web.Adapt(web.HandlerWithError(sh.pauseHandler), web.TelemetryMW("storageHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyStoragePath, "resume"), web.Adapt(web.HandlerWithError(sh.resumeHandler), web.TelemetryMW("storageHandler", log)))
 	sh.mux = mux
 
 	return sh
 }
 
+// SetVolumeAdoption sets the quota enforcement that adopted volumes are
+// registered with and the tenant service that their tenants are checked
+// with. Volumes cannot be adopted until it is set.
+func (sh *StorageHandler) SetVolumeAdoption(enf *quota.RedisEnforcement, tenants pb.TenantServiceClient) {
+	sh.enf = enf
+	sh.tenants = tenants
+}
+
+// SetSystemPauses sets where the storage systems that are paused for
+// maintenance are kept. Systems cannot be paused until it is set.
+func (sh *StorageHandler) SetSystemPauses(p *SystemPauses) {
+	sh.pauses = p
+}
+
 func (sh *StorageHandler) storageHandler(w http.ResponseWriter, r *http.Request) error {
+	// Storage systems, and their credentials, are shared by the tenants of
+	// every organization.
+	if org := adminOrganization(r); org != "" {
+		err := fmt.Errorf("admins of organization %s may not manage storage systems", org)
+		handleJSONErrorResponse(sh.log, w, http.StatusForbidden, err)
+		return err
+	}
+
 	switch r.Method {
 	case http.MethodPost:
 		return sh.createHandler(w, r)
@@ -65,7 +117,7 @@ func (sh *StorageHandler) storageHandler(w http.ResponseWriter, r *http.Request)
 	case http.MethodDelete:
 		return sh.deleteHandler(w, r)
 	default:
-		return nil
+		return handleMethodNotAllowed(sh.log, w, r)
 	}
 }
 
@@ -90,7 +142,6 @@ func (sh *StorageHandler) createHandler(w http.ResponseWriter, r *http.Request)
 		"Endpoint":    body.Endpoint,
 		"SystemId":    body.SystemID,
 		"UserName":    body.UserName,
-		"Password":    body.Password,
 		"Insecure":    body.Insecure,
 	})
 
@@ -99,7 +150,6 @@ func (sh *StorageHandler) createHandler(w http.ResponseWriter, r *http.Request)
 		"Endpoint":    body.Endpoint,
 		"SystemId":    body.SystemID,
 		"UserName":    body.UserName,
-		"Password":    body.Password,
 		"Insecure":    body.Insecure,
 	}).Info("Requesting storage creation")
 
@@ -114,7 +164,7 @@ func (sh *StorageHandler) createHandler(w http.ResponseWriter, r *http.Request)
 	})
 	if err != nil {
 		sh.log.WithError(err).Errorf("creating storage: %v", err)
-		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(sh.log, w, err)
 		return err
 	}
 
@@ -140,7 +190,6 @@ func (sh *StorageHandler) updateHandler(w http.ResponseWriter, r *http.Request)
 		"Endpoint":    body.Endpoint,
 		"SystemId":    body.SystemID,
 		"UserName":    body.UserName,
-		"Password":    body.Password,
 		"Insecure":    body.Insecure,
 	})
 
@@ -149,7 +198,6 @@ func (sh *StorageHandler) updateHandler(w http.ResponseWriter, r *http.Request)
 		"Endpoint":    body.Endpoint,
 		"SystemId":    body.SystemID,
 		"UserName":    body.UserName,
-		"Password":    body.Password,
 		"Insecure":    body.Insecure,
 	}).Info("Requesting storage update")
 
@@ -164,7 +212,7 @@ func (sh *StorageHandler) updateHandler(w http.ResponseWriter, r *http.Request)
 	})
 	if err != nil {
 		sh.log.WithError(err).Errorf("updating storage: %v", err)
-		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(sh.log, w, err)
 		return err
 	}
 
@@ -185,7 +233,7 @@ func (sh *StorageHandler) getHandler(w http.ResponseWriter, r *http.Request) err
 		storages, err := sh.client.List(ctx, &pb.StorageListRequest{})
 		if err != nil {
 			err = fmt.Errorf("listing storages: %w", err)
-			handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+			handleRPCErrorResponse(sh.log, w, err)
 			return err
 		}
 
@@ -229,7 +277,7 @@ func (sh *StorageHandler) getHandler(w http.ResponseWriter, r *http.Request) err
 	})
 	if err != nil {
 		sh.log.WithError(err).Errorf("getting storage: %v", err)
-		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(sh.log, w, err)
 		return err
 	}
 
@@ -244,6 +292,281 @@ func (sh *StorageHandler) getHandler(w http.ResponseWriter, r *http.Request) err
 	return nil
 }
 
+// statusHandler returns the health of each storage system
+func (sh *StorageHandler) statusHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return handleMethodNotAllowed(sh.log, w, r)
+	}
+
+	sh.log.Info("Requesting storage status")
+
+	resp, err := sh.client.Status(r.Context(), &pb.StorageStatusRequest{})
+	if err != nil {
+		sh.log.WithError(err).Errorf("getting storage status: %v", err)
+		handleRPCErrorResponse(sh.log, w, err)
+		return err
+	}
+
+	_, err = fmt.Fprint(w, protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true, Indent: ""}.Format(resp))
+	if err != nil {
+		sh.log.WithError(err).Errorf("writing storage status response: %v", err)
+		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+
+	return nil
+}
+
+// discoverHandler returns the storage pools of a storage system
+func (sh *StorageHandler) discoverHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return handleMethodNotAllowed(sh.log, w, r)
+	}
+
+	storType := r.URL.Query().Get("StorageType")
+	sysID := r.URL.Query().Get("SystemId")
+	if storType == "" || sysID == "" {
+		err := fmt.Errorf("storage type and systemid must be provided in query parameters")
+		sh.log.WithError(err).Error()
+		handleJSONErrorResponse(sh.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	setAttributes(trace.SpanFromContext(r.Context()), map[string]interface{}{
+		"storageType": storType,
+		"systemID":    sysID,
+	})
+
+	sh.log.WithFields(logrus.Fields{
+		"storageType": storType,
+		"systemID":    sysID,
+	}).Info("Requesting storage discovery")
+
+	resp, err := sh.client.Discover(r.Context(), &pb.StorageDiscoverRequest{StorageType: storType, SystemId: sysID})
+	if err != nil {
+		sh.log.WithError(err).Errorf("discovering storage: %v", err)
+		handleRPCErrorResponse(sh.log, w, err)
+		return err
+	}
+
+	_, err = fmt.Fprint(w, protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true, Indent: ""}.Format(resp))
+	if err != nil {
+		sh.log.WithError(err).Errorf("writing storage discover response: %v", err)
+		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+
+	return nil
+}
+
+// adoptHandler registers an existing volume of a storage system, created
+// outside of authorization, under the ownership and quota of a tenant
+func (sh *StorageHandler) adoptHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return handleMethodNotAllowed(sh.log, w, r)
+	}
+	ctx := r.Context()
+
+	if sh.enf == nil || sh.tenants == nil {
+		err := errors.New("volume adoption is not configured")
+		handleJSONErrorResponse(sh.log, w, http.StatusServiceUnavailable, err)
+		return err
+	}
+
+	var body AdoptVolumeBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		sh.log.WithError(err).Errorf("decoding request body: %v", err)
+		handleJSONErrorResponse(sh.log, w, http.StatusBadRequest, err)
+		return err
+	}
+	if body.StorageType == "" || body.SystemID == "" || body.VolumeID == "" || body.Tenant == "" {
+		err = errors.New("storage type, systemid, volume id and tenant must be provided")
+		handleJSONErrorResponse(sh.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	setAttributes(trace.SpanFromContext(ctx), map[string]interface{}{
+		"storageType": body.StorageType,
+		"systemID":    body.SystemID,
+		"volumeID":    body.VolumeID,
+		"tenant":      body.Tenant,
+	})
+
+	sh.log.WithFields(logrus.Fields{
+		"storageType": body.StorageType,
+		"systemID":    body.SystemID,
+		"volumeID":    body.VolumeID,
+		"tenant":      body.Tenant,
+	}).Info("Requesting volume adoption")
+
+	tenant, err := sh.tenants.GetTenant(ctx, &pb.GetTenantRequest{Name: body.Tenant})
+	if err != nil {
+		err = fmt.Errorf("getting tenant %s: %w", body.Tenant, err)
+		handleRPCErrorResponse(sh.log, w, err)
+		return err
+	}
+	if org := adminOrganization(r); org != "" && tenant.Organization != org {
+		err = fmt.Errorf("tenant %s is not in organization %s", body.Tenant, org)
+		handleJSONErrorResponse(sh.log, w, http.StatusForbidden, err)
+		return err
+	}
+
+	resp, err := sh.client.GetVolume(ctx, &pb.GetVolumeRequest{StorageType: body.StorageType, SystemId: body.SystemID, VolumeId: body.VolumeID})
+	if err != nil {
+		sh.log.WithError(err).Errorf("getting volume: %v", err)
+		handleRPCErrorResponse(sh.log, w, err)
+		return err
+	}
+	vol := resp.Volume
+
+	tenantKey, err := sh.enf.TenantID(ctx, body.Tenant)
+	if err != nil {
+		err = fmt.Errorf("resolving tenant %s: %w", body.Tenant, err)
+		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	err = sh.enf.AdoptVolume(ctx, quota.Request{
+		SystemType:    body.StorageType,
+		SystemID:      body.SystemID,
+		StoragePoolID: vol.Pool,
+		Group:         tenantKey,
+		VolumeName:    vol.Name,
+		VolumeID:      vol.Id,
+		Capacity:      strconv.FormatInt(vol.SizeInKb, 10),
+	})
+	switch {
+	case errors.Is(err, quota.ErrVolumeOwned), errors.Is(err, quota.ErrVolumeNameInUse):
+		err = fmt.Errorf("adopting volume %s: %w", body.VolumeID, err)
+		handleJSONErrorResponse(sh.log, w, http.StatusConflict, err)
+		return err
+	case err != nil:
+		err = fmt.Errorf("adopting volume %s: %w", body.VolumeID, err)
+		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+
+	_, err = fmt.Fprint(w, protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true, Indent: ""}.Format(vol))
+	if err != nil {
+		sh.log.WithError(err).Errorf("writing volume adopt response: %v", err)
+		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+
+	return nil
+}
+
+// pauseHandler lists the paused storage systems, or pauses one so that
+// the proxy refuses the requests that change it until it is resumed.
+func (sh *StorageHandler) pauseHandler(w http.ResponseWriter, r *http.Request) error {
+	body, err := sh.decodePauseBody(w, r)
+	if body == nil {
+		return err
+	}
+
+	if r.Method == http.MethodGet {
+		pauses, err := sh.pauses.List()
+		if err != nil {
+			err = fmt.Errorf("listing paused systems: %w", err)
+			handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+			return err
+		}
+		return writeJSON(w, pauses)
+	}
+
+	sh.log.WithFields(logrus.Fields{
+		"storageType": body.StorageType,
+		"systemID":    body.SystemID,
+		"reason":      body.Reason,
+		"retryAfter":  time.Duration(body.RetryAfter),
+	}).Info("Requesting storage pause")
+
+	pause := SystemPause{
+		StorageType: body.StorageType,
+		SystemID:    body.SystemID,
+		Reason:      body.Reason,
+		RetryAfter:  body.RetryAfter,
+	}
+	if err := sh.pauses.Pause(pause); err != nil {
+		err = fmt.Errorf("pausing system %s: %w", body.SystemID, err)
+		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// resumeHandler resumes a paused storage system.
+func (sh *StorageHandler) resumeHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return handleMethodNotAllowed(sh.log, w, r)
+	}
+	body, err := sh.decodePauseBody(w, r)
+	if body == nil {
+		return err
+	}
+
+	sh.log.WithFields(logrus.Fields{
+		"storageType": body.StorageType,
+		"systemID":    body.SystemID,
+	}).Info("Requesting storage resume")
+
+	paused, err := sh.pauses.Resume(body.StorageType, body.SystemID)
+	if err != nil {
+		err = fmt.Errorf("resuming system %s: %w", body.SystemID, err)
+		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	if !paused {
+		err = fmt.Errorf("system %s is not paused", body.SystemID)
+		handleJSONErrorResponse(sh.log, w, http.StatusNotFound, err)
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// decodePauseBody checks that the request may pause or resume storage
+// systems and returns its body, which is empty for a GET request. It writes
+// the error response and returns a nil body otherwise.
+func (sh *StorageHandler) decodePauseBody(w http.ResponseWriter, r *http.Request) (*PauseSystemBody, error) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		return nil, handleMethodNotAllowed(sh.log, w, r)
+	}
+	if sh.pauses == nil {
+		err := errors.New("storage pauses are not configured")
+		handleJSONErrorResponse(sh.log, w, http.StatusServiceUnavailable, err)
+		return nil, err
+	}
+	// A paused system is paused for the tenants of every organization.
+	if org := adminOrganization(r); org != "" {
+		err := fmt.Errorf("admins of organization %s may not pause storage systems", org)
+		handleJSONErrorResponse(sh.log, w, http.StatusForbidden, err)
+		return nil, err
+	}
+
+	var body PauseSystemBody
+	if r.Method == http.MethodGet {
+		return &body, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.log.WithError(err).Errorf("decoding request body: %v", err)
+		handleJSONErrorResponse(sh.log, w, http.StatusBadRequest, err)
+		return nil, err
+	}
+	if body.StorageType == "" || body.SystemID == "" {
+		err := errors.New("storage type and systemid must be provided")
+		handleJSONErrorResponse(sh.log, w, http.StatusBadRequest, err)
+		return nil, err
+	}
+
+	setAttributes(trace.SpanFromContext(r.Context()), map[string]interface{}{
+		"storageType": body.StorageType,
+		"systemID":    body.SystemID,
+	})
+	return &body, nil
+}
+
 func (sh *StorageHandler) deleteHandler(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 	span := trace.SpanFromContext(ctx)
@@ -287,7 +610,7 @@ func (sh *StorageHandler) deleteHandler(w http.ResponseWriter, r *http.Request)
 	})
 	if err != nil {
 		sh.log.WithError(err).Errorf("deleting storage: %v", err)
-		handleJSONErrorResponse(sh.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(sh.log, w, err)
 		return err
 	}
 
@@ -17,15 +17,23 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"karavi-authorization/internal/quota"
 	mocks "karavi-authorization/internal/storage-service/mocks"
+	tenantmocks "karavi-authorization/internal/tenantsvc/mocks"
+	"karavi-authorization/internal/web"
 	"karavi-authorization/pb"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 func TestStorageHandler(t *testing.T) {
@@ -372,4 +380,312 @@ func TestStorageHandler(t *testing.T) {
 			}
 		})
 	})
+	t.Run("it handles storage status", func(t *testing.T) {
+		t.Run("successfully gets the storage status", func(t *testing.T) {
+			client := &mocks.FakeStorageServiceClient{
+				StatusFn: func(_ context.Context, _ *pb.StorageStatusRequest, _ ...grpc.CallOption) (*pb.StorageStatusResponse, error) {
+					return &pb.StorageStatusResponse{
+						Systems: []*pb.StorageSystemStatus{
+							{StorageType: "powerflex", SystemId: "542a2d5f5122210f", Status: "auth-failed", Error: "bad credentials"},
+						},
+					}, nil
+				},
+			}
+
+			sut := NewStorageHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/storage/status/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusOK {
+				t.Errorf("expected status code %d, got %d", http.StatusOK, code)
+			}
+
+			var got pb.StorageStatusResponse
+			err := protojson.Unmarshal(w.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Systems) != 1 || got.Systems[0].Status != "auth-failed" {
+				t.Errorf("expected the storage status, got %v", &got)
+			}
+		})
+
+		t.Run("handles method not allowed", func(t *testing.T) {
+			sut := NewStorageHandler(logrus.NewEntry(logrus.New()), &mocks.FakeStorageServiceClient{})
+
+			r := httptest.NewRequest(http.MethodPost, "/proxy/storage/status/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusMethodNotAllowed {
+				t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, code)
+			}
+		})
+	})
+
+	t.Run("it handles storage discover", func(t *testing.T) {
+		t.Run("successfully discovers the storage pools", func(t *testing.T) {
+			client := &mocks.FakeStorageServiceClient{
+				DiscoverFn: func(_ context.Context, req *pb.StorageDiscoverRequest, _ ...grpc.CallOption) (*pb.StorageDiscoverResponse, error) {
+					if req.StorageType != "powerflex" || req.SystemId != "542a2d5f5122210f" {
+						t.Errorf("unexpected request %v", req)
+					}
+					return &pb.StorageDiscoverResponse{
+						Pools: []*pb.DiscoveredPool{
+							{Name: "bronze", ProtectionDomain: "domain1", CapacityInKb: 1048576},
+						},
+					}, nil
+				},
+			}
+
+			sut := NewStorageHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/storage/discover/?StorageType=powerflex&SystemId=542a2d5f5122210f", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusOK {
+				t.Errorf("expected status code %d, got %d", http.StatusOK, code)
+			}
+
+			var got pb.StorageDiscoverResponse
+			err := protojson.Unmarshal(w.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Pools) != 1 || got.Pools[0].Name != "bronze" || got.Pools[0].CapacityInKb != 1048576 {
+				t.Errorf("expected the discovered pools, got %v", &got)
+			}
+		})
+
+		t.Run("handles missing query params", func(t *testing.T) {
+			sut := NewStorageHandler(logrus.NewEntry(logrus.New()), &mocks.FakeStorageServiceClient{})
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/storage/discover/?StorageType=powerflex", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusBadRequest {
+				t.Errorf("expected status code %d, got %d", http.StatusBadRequest, code)
+			}
+		})
+	})
+	t.Run("it handles volume adopt", func(t *testing.T) {
+		mr, err := miniredis.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer mr.Close()
+		enf := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()})))
+
+		client := &mocks.FakeStorageServiceClient{
+			GetVolumeFn: func(_ context.Context, req *pb.GetVolumeRequest, _ ...grpc.CallOption) (*pb.GetVolumeResponse, error) {
+				if req.StorageType != "powerflex" || req.SystemId != "542a2d5f5122210f" || req.VolumeId != "0a1b2c3d00000001" {
+					t.Errorf("unexpected request %v", req)
+				}
+				return &pb.GetVolumeResponse{Volume: &pb.Volume{Name: "k8s-456", Id: "0a1b2c3d00000001", Pool: "bronze", SystemId: req.SystemId, SizeInKb: 8388608}}, nil
+			},
+		}
+		tenants := &tenantmocks.FakeTenantServiceClient{
+			GetTenantFn: func(_ context.Context, req *pb.GetTenantRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+				if req.Name != "mytenant" {
+					return nil, errors.New("tenant not found")
+				}
+				return &pb.Tenant{Name: req.Name}, nil
+			},
+		}
+		adopt := func(body AdoptVolumeBody) *httptest.ResponseRecorder {
+			sut := NewStorageHandler(logrus.NewEntry(logrus.New()), client)
+			sut.SetVolumeAdoption(enf, tenants)
+
+			payload, err := json.Marshal(&body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := httptest.NewRequest(http.MethodPost, "/proxy/storage/adopt/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+			sut.ServeHTTP(w, r)
+			return w
+		}
+		body := AdoptVolumeBody{StorageType: "powerflex", SystemID: "542a2d5f5122210f", VolumeID: "0a1b2c3d00000001", Tenant: "mytenant"}
+
+		t.Run("successfully adopts a volume", func(t *testing.T) {
+			mr.FlushAll()
+
+			w := adopt(body)
+
+			if code := w.Result().StatusCode; code != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, code)
+			}
+			var got pb.Volume
+			if err := protojson.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != "k8s-456" || got.Pool != "bronze" {
+				t.Errorf("expected the adopted volume, got %v", &got)
+			}
+			r := quota.Request{SystemType: "powerflex", SystemID: "542a2d5f5122210f", StoragePoolID: "bronze", Group: "mytenant", VolumeName: "k8s-456", VolumeID: "0a1b2c3d00000001"}
+			if ok, err := enf.ValidateOwnership(context.Background(), r); err != nil || !ok {
+				t.Errorf("expected the volume to be owned, got %v, %v", ok, err)
+			}
+		})
+		t.Run("handles a volume that is already owned", func(t *testing.T) {
+			mr.FlushAll()
+
+			adopt(body)
+			w := adopt(body)
+
+			if code := w.Result().StatusCode; code != http.StatusConflict {
+				t.Errorf("expected status code %d, got %d", http.StatusConflict, code)
+			}
+		})
+		t.Run("handles an unknown tenant", func(t *testing.T) {
+			mr.FlushAll()
+			unknown := body
+			unknown.Tenant = "unknown"
+
+			w := adopt(unknown)
+
+			if code := w.Result().StatusCode; code == http.StatusOK {
+				t.Errorf("expected an error status code, got %d", code)
+			}
+			if mr.Exists("quota:powerflex:542a2d5f5122210f:bronze:unknown:data") {
+				t.Error("expected the volume not to be adopted")
+			}
+		})
+		t.Run("handles missing fields", func(t *testing.T) {
+			w := adopt(AdoptVolumeBody{StorageType: "powerflex", SystemID: "542a2d5f5122210f"})
+
+			if code := w.Result().StatusCode; code != http.StatusBadRequest {
+				t.Errorf("expected status code %d, got %d", http.StatusBadRequest, code)
+			}
+		})
+	})
+	t.Run("it rejects organization admins", func(t *testing.T) {
+		var called bool
+		client := &mocks.FakeStorageServiceClient{
+			CreateStorageFn: func(_ context.Context, _ *pb.StorageCreateRequest, _ ...grpc.CallOption) (*pb.StorageCreateResponse, error) {
+				called = true
+				return &pb.StorageCreateResponse{}, nil
+			},
+			UpdateStorageFn: func(_ context.Context, _ *pb.StorageUpdateRequest, _ ...grpc.CallOption) (*pb.StorageUpdateResponse, error) {
+				called = true
+				return &pb.StorageUpdateResponse{}, nil
+			},
+			GetStorageFn: func(_ context.Context, _ *pb.StorageGetRequest, _ ...grpc.CallOption) (*pb.StorageGetResponse, error) {
+				called = true
+				return &pb.StorageGetResponse{}, nil
+			},
+			ListStorageFn: func(_ context.Context, _ *pb.StorageListRequest, _ ...grpc.CallOption) (*pb.StorageListResponse, error) {
+				called = true
+				return &pb.StorageListResponse{}, nil
+			},
+			DeleteStorageFn: func(_ context.Context, _ *pb.StorageDeleteRequest, _ ...grpc.CallOption) (*pb.StorageDeleteResponse, error) {
+				called = true
+				return &pb.StorageDeleteResponse{}, nil
+			},
+		}
+		sut := NewStorageHandler(logrus.NewEntry(logrus.New()), client)
+		payload := `{"StorageType":"powerflex","Endpoint":"0.0.0.0:443","SystemId":"542a2d5f5122210f","UserName":"test","Password":"test"}`
+
+		for _, tc := range []struct{ method, target, body string }{
+			{http.MethodPost, "/proxy/storage/", payload},
+			{http.MethodPatch, "/proxy/storage/", payload},
+			{http.MethodGet, "/proxy/storage/", ""},
+			{http.MethodGet, "/proxy/storage/?StorageType=powerflex&SystemId=542a2d5f5122210f", ""},
+			{http.MethodDelete, "/proxy/storage/?StorageType=powerflex&SystemId=542a2d5f5122210f", ""},
+		} {
+			called = false
+			r := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			r = r.WithContext(context.WithValue(r.Context(), web.JWTOrganization, "org-1"))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			if code := w.Code; code != http.StatusForbidden {
+				t.Errorf("%s %s: expected status code %d, got %d", tc.method, tc.target, http.StatusForbidden, code)
+			}
+			if called {
+				t.Errorf("%s %s: expected the storage service not to be called", tc.method, tc.target)
+			}
+		}
+	})
+	t.Run("it handles storage pause and resume", func(t *testing.T) {
+		mr, err := miniredis.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer mr.Close()
+		rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		pauses := NewSystemPauses(logrus.NewEntry(logrus.New()), func() *redis.Client { return rdb })
+
+		serve := func(method, path, org string, body *PauseSystemBody) *httptest.ResponseRecorder {
+			sut := NewStorageHandler(logrus.NewEntry(logrus.New()), &mocks.FakeStorageServiceClient{})
+			sut.SetSystemPauses(pauses)
+
+			var payload []byte
+			if body != nil {
+				payload, err = json.Marshal(body)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			r := httptest.NewRequest(method, path, bytes.NewReader(payload))
+			r = r.WithContext(context.WithValue(r.Context(), web.JWTOrganization, org))
+			w := httptest.NewRecorder()
+			sut.ServeHTTP(w, r)
+			return w
+		}
+		body := &PauseSystemBody{StorageType: "powerflex", SystemID: "542a2d5f5122210f", Reason: "firmware upgrade", RetryAfter: Duration(5 * time.Minute)}
+
+		t.Run("successfully pauses and resumes a system", func(t *testing.T) {
+			mr.FlushAll()
+
+			if code := serve(http.MethodPost, "/proxy/storage/pause/", "", body).Code; code != http.StatusNoContent {
+				t.Fatalf("expected status code %d, got %d", http.StatusNoContent, code)
+			}
+			w := serve(http.MethodGet, "/proxy/storage/pause/", "", nil)
+			var got []SystemPause
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 || got[0].SystemID != body.SystemID || got[0].Reason != body.Reason || got[0].RetryAfter != body.RetryAfter {
+				t.Errorf("expected the system to be paused, got %+v", got)
+			}
+
+			if code := serve(http.MethodPost, "/proxy/storage/resume/", "", body).Code; code != http.StatusNoContent {
+				t.Errorf("expected status code %d, got %d", http.StatusNoContent, code)
+			}
+			if code := serve(http.MethodPost, "/proxy/storage/resume/", "", body).Code; code != http.StatusNotFound {
+				t.Errorf("expected status code %d, got %d", http.StatusNotFound, code)
+			}
+		})
+		t.Run("handles an organization admin", func(t *testing.T) {
+			mr.FlushAll()
+
+			if code := serve(http.MethodPost, "/proxy/storage/pause/", "org-1", body).Code; code != http.StatusForbidden {
+				t.Errorf("expected status code %d, got %d", http.StatusForbidden, code)
+			}
+			if mr.Exists(KeySystemPauses) {
+				t.Error("expected the system not to be paused")
+			}
+		})
+		t.Run("handles missing fields", func(t *testing.T) {
+			w := serve(http.MethodPost, "/proxy/storage/pause/", "", &PauseSystemBody{StorageType: "powerflex"})
+
+			if code := w.Code; code != http.StatusBadRequest {
+				t.Errorf("expected status code %d, got %d", http.StatusBadRequest, code)
+			}
+		})
+	})
 }
@@ -0,0 +1,173 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+// KeySystemPauses is a hash of the storage systems that are paused for
+// maintenance, by <storage type>:<system id>, to their SystemPause as JSON.
+const KeySystemPauses = "storage:paused"
+
+// Defaults of the response to the requests for a paused storage system.
+const (
+	DefaultPauseStatus     = http.StatusServiceUnavailable
+	DefaultPauseRetryAfter = 30 * time.Second
+)
+
+// SystemPause is why and since when a storage system is paused.
+type SystemPause struct {
+	StorageType string    `json:"StorageType"`
+	SystemID    string    `json:"SystemId"`
+	Reason      string    `json:"Reason,omitempty"`
+	RetryAfter  Duration  `json:"RetryAfter,omitempty"`
+	PausedAt    time.Time `json:"PausedAt"`
+}
+
+// SystemPauses keeps the storage systems that are paused for maintenance,
+// shared by every proxy-server through redis. Requests that provision,
+// delete or map volumes on a paused system are refused with a retryable
+// error, while reads are still forwarded.
+type SystemPauses struct {
+	log        *logrus.Entry
+	rdb        func() *redis.Client
+	status     int
+	retryAfter time.Duration
+}
+
+// NewSystemPauses returns the SystemPauses kept in redis.
+func NewSystemPauses(log *logrus.Entry, rdb func() *redis.Client) *SystemPauses {
+	return &SystemPauses{
+		log:        log,
+		rdb:        rdb,
+		status:     DefaultPauseStatus,
+		retryAfter: DefaultPauseRetryAfter,
+	}
+}
+
+// SetResponse sets the status code of the responses to the refused
+// requests, and how long clients are told to wait before they retry unless
+// the pause says otherwise. It must be called before serving.
+func (p *SystemPauses) SetResponse(status int, retryAfter time.Duration) {
+	if status != 0 {
+		p.status = status
+	}
+	if retryAfter > 0 {
+		p.retryAfter = retryAfter
+	}
+}
+
+func pauseField(storageType, systemID string) string {
+	return storageType + ":" + systemID
+}
+
+// Pause pauses the storage system of the pause, or updates its pause.
+func (p *SystemPauses) Pause(pause SystemPause) error {
+	if pause.StorageType == "" || pause.SystemID == "" {
+		return errors.New("storage type and system id must be provided")
+	}
+	if pause.PausedAt.IsZero() {
+		pause.PausedAt = time.Now().UTC()
+	}
+	b, err := json.Marshal(pause)
+	if err != nil {
+		return err
+	}
+	_, err = p.rdb().HSet(KeySystemPauses, pauseField(pause.StorageType, pause.SystemID), b).Result()
+	return err
+}
+
+// Resume resumes the storage system, and reports whether it was paused.
+func (p *SystemPauses) Resume(storageType, systemID string) (bool, error) {
+	n, err := p.rdb().HDel(KeySystemPauses, pauseField(storageType, systemID)).Result()
+	return n > 0, err
+}
+
+// Get returns the pause of the storage system, or nil if it is not paused.
+func (p *SystemPauses) Get(storageType, systemID string) (*SystemPause, error) {
+	s, err := p.rdb().HGet(KeySystemPauses, pauseField(storageType, systemID)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pause SystemPause
+	if err := json.Unmarshal([]byte(s), &pause); err != nil {
+		return nil, fmt.Errorf("decoding pause of %s: %w", systemID, err)
+	}
+	return &pause, nil
+}
+
+// List returns the pauses of the paused storage systems.
+func (p *SystemPauses) List() ([]SystemPause, error) {
+	m, err := p.rdb().HGetAll(KeySystemPauses).Result()
+	if err != nil {
+		return nil, err
+	}
+	pauses := make([]SystemPause, 0, len(m))
+	for field, s := range m {
+		var pause SystemPause
+		if err := json.Unmarshal([]byte(s), &pause); err != nil {
+			return nil, fmt.Errorf("decoding pause of %s: %w", field, err)
+		}
+		pauses = append(pauses, pause)
+	}
+	sort.Slice(pauses, func(i, j int) bool {
+		return pauseField(pauses[i].StorageType, pauses[i].SystemID) < pauseField(pauses[j].StorageType, pauses[j].SystemID)
+	})
+	return pauses, nil
+}
+
+// Handler refuses the requests that change the storage system while it is
+// paused, and forwards the others to next. Requests are forwarded if the
+// pause cannot be looked up, since it is not a security control.
+func (p *SystemPauses) Handler(storageType, systemID string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if backendOperation(r, storageType) == OperationQuery {
+			next.ServeHTTP(w, r)
+			return
+		}
+		pause, err := p.Get(storageType, systemID)
+		if err != nil {
+			p.log.WithError(err).WithField("system_id", systemID).Warn("looking up system pause")
+		}
+		if pause == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		retryAfter := time.Duration(pause.RetryAfter)
+		if retryAfter <= 0 {
+			retryAfter = p.retryAfter
+		}
+		reason := "storage system is paused for maintenance"
+		if pause.Reason != "" {
+			reason = fmt.Sprintf("%s: %s", reason, pause.Reason)
+		}
+		tenant, _ := r.Context().Value(web.JWTTenantName).(string)
+		w.Header().Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+		writeDenied(w, storageType, reason, p.status, web.Deny{Code: web.CodeSystemPaused, Reason: reason, Tenant: tenant}, p.log)
+	})
+}
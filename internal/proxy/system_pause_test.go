@@ -0,0 +1,125 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSystemPauses(t *testing.T) {
+	const systemID = "1b2e5a7c9d3f4a6b"
+
+	mr, err := miniredis.Run()
+	checkError(t, err)
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	log := logrus.New().WithContext(context.Background())
+	pauses := proxy.NewSystemPauses(log, func() *redis.Client { return rdb })
+	pauses.SetResponse(http.StatusTooManyRequests, time.Minute)
+
+	var forwarded int
+	systems := map[string]http.Handler{
+		"powerflex": http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+			forwarded++
+		}),
+	}
+	h := proxy.NewDispatchHandler(log, systems, proxy.WithSystemPauses(pauses))
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Add("Forwarded", "by=csm-authorization;csi-vxflexos")
+		r.Header.Add("Forwarded", "for=csm-authorization;https://10.0.0.1;"+systemID)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("it forwards the requests of systems that are not paused", func(t *testing.T) {
+		forwarded = 0
+		serve(http.MethodPost, "/api/types/Volume/instances/")
+		if forwarded != 1 {
+			t.Errorf("got %d forwarded requests, want 1", forwarded)
+		}
+	})
+	t.Run("it refuses the changes to a paused system", func(t *testing.T) {
+		forwarded = 0
+		checkError(t, pauses.Pause(proxy.SystemPause{StorageType: "powerflex", SystemID: systemID, Reason: "firmware upgrade"}))
+
+		w := serve(http.MethodPost, "/api/types/Volume/instances/")
+
+		if forwarded != 0 {
+			t.Errorf("got %d forwarded requests, want 0", forwarded)
+		}
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusTooManyRequests)
+		}
+		if got := w.Header().Get("Retry-After"); got != "60" {
+			t.Errorf("got Retry-After %q, want %q", got, "60")
+		}
+		var body struct {
+			Deny web.Deny `json:"deny"`
+		}
+		checkError(t, json.NewDecoder(w.Body).Decode(&body))
+		if body.Deny.Code != web.CodeSystemPaused || body.Deny.Reason != "storage system is paused for maintenance: firmware upgrade" {
+			t.Errorf("got deny %+v, want the system paused", body.Deny)
+		}
+	})
+	t.Run("it forwards the reads of a paused system", func(t *testing.T) {
+		forwarded = 0
+		serve(http.MethodGet, "/api/types/Volume/instances/")
+		serve(http.MethodPost, "/api/types/Volume/instances/action/queryIdByKey/")
+		if forwarded != 2 {
+			t.Errorf("got %d forwarded requests, want 2", forwarded)
+		}
+	})
+	t.Run("it uses the retry after of the pause", func(t *testing.T) {
+		checkError(t, pauses.Pause(proxy.SystemPause{StorageType: "powerflex", SystemID: systemID, RetryAfter: proxy.Duration(90 * time.Second)}))
+
+		w := serve(http.MethodDelete, "/api/instances/Volume::1/")
+
+		if got := w.Header().Get("Retry-After"); got != "90" {
+			t.Errorf("got Retry-After %q, want %q", got, "90")
+		}
+	})
+	t.Run("it forwards the changes of a resumed system", func(t *testing.T) {
+		forwarded = 0
+		paused, err := pauses.Resume("powerflex", systemID)
+		checkError(t, err)
+		if !paused {
+			t.Error("expected the system to have been paused")
+		}
+
+		serve(http.MethodPost, "/api/types/Volume/instances/")
+
+		if forwarded != 1 {
+			t.Errorf("got %d forwarded requests, want 1", forwarded)
+		}
+		list, err := pauses.List()
+		checkError(t, err)
+		if len(list) != 0 {
+			t.Errorf("got pauses %v, want none", list)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"karavi-authorization/internal/web"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attribute keys used to filter proxied storage requests by tenant
+// and storage system.
+const (
+	AttrTenant          = attribute.Key("karavi.tenant")
+	AttrRoles           = attribute.Key("karavi.roles")
+	AttrSystemID        = attribute.Key("karavi.system.id")
+	AttrSystemType      = attribute.Key("karavi.system.type")
+	AttrDecisionAllowed = attribute.Key("karavi.decision.allowed")
+	AttrDecisionReason  = attribute.Key("karavi.decision.reason")
+)
+
+// setRequestAttributes adds the tenant, roles and storage system of the
+// request to the current span.
+func setRequestAttributes(r *http.Request, systemType, systemID string) {
+	span := trace.SpanFromContext(r.Context())
+	attrs := []attribute.KeyValue{
+		AttrSystemType.String(systemType),
+		AttrSystemID.String(systemID),
+	}
+	if tenant, ok := r.Context().Value(web.JWTTenantName).(string); ok {
+		attrs = append(attrs, AttrTenant.String(tenant))
+	}
+	if roles, ok := r.Context().Value(web.JWTRoles).(string); ok {
+		attrs = append(attrs, AttrRoles.String(roles))
+	}
+	span.SetAttributes(attrs...)
+}
+
+// setDecisionAttributes records the outcome of an authorization decision
+// on the span.
+func setDecisionAttributes(span trace.Span, allowed bool, reason string) {
+	attrs := []attribute.KeyValue{AttrDecisionAllowed.Bool(allowed)}
+	if reason != "" {
+		attrs = append(attrs, AttrDecisionReason.String(reason))
+	}
+	span.SetAttributes(attrs...)
+}
@@ -0,0 +1,84 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"karavi-authorization/internal/web"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_setRequestAttributes(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	ctx, span := tp.Tracer("").Start(context.Background(), "test")
+	ctx = context.WithValue(ctx, web.JWTTenantName, "mytenant")
+	ctx = context.WithValue(ctx, web.JWTRoles, "role1,role2")
+	r := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+
+	setRequestAttributes(r, "powerflex", "542a2d5f5122210f")
+	setDecisionAttributes(span, false, "not enough quota")
+	span.End()
+
+	got := make(map[attribute.Key]attribute.Value)
+	for _, kv := range exporter.GetSpans()[0].Attributes {
+		got[kv.Key] = kv.Value
+	}
+
+	want := map[attribute.Key]attribute.Value{
+		AttrTenant:          attribute.StringValue("mytenant"),
+		AttrRoles:           attribute.StringValue("role1,role2"),
+		AttrSystemType:      attribute.StringValue("powerflex"),
+		AttrSystemID:        attribute.StringValue("542a2d5f5122210f"),
+		AttrDecisionAllowed: attribute.BoolValue(false),
+		AttrDecisionReason:  attribute.StringValue("not enough quota"),
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: got %v, want %v", k, got[k].Emit(), v.Emit())
+		}
+	}
+}
+
+func Test_setAttributes(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	_, span := tp.Tracer("").Start(context.Background(), "test")
+	setAttributes(span, map[string]interface{}{
+		"UserName": "admin",
+		"Password": "Password123",
+		"Insecure": true,
+	})
+	span.End()
+
+	got := make(map[attribute.Key]attribute.Value)
+	for _, kv := range exporter.GetSpans()[0].Attributes {
+		got[kv.Key] = kv.Value
+	}
+
+	if _, ok := got["Password"]; ok {
+		t.Errorf("got a password attribute, want none")
+	}
+	if got["UserName"] != attribute.StringValue("admin") || got["Insecure"] != attribute.BoolValue(true) {
+		t.Errorf("got attributes %v, want the user name and insecure", got)
+	}
+}
@@ -13,11 +13,16 @@
 package proxy
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"karavi-authorization/internal/redact"
+	"karavi-authorization/internal/usage"
 	"karavi-authorization/internal/web"
 	"karavi-authorization/pb"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -46,6 +51,18 @@ func NewTenantHandler(log *logrus.Entry, client pb.TenantServiceClient) *TenantH
 	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "unbind"), web.Adapt(web.HandlerWithError(th.unbindRoleHandler), web.TelemetryMW("tenantHandler", log)))
 	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "token"), web.Adapt(web.HandlerWithError(th.generateTokenHandler), web.TelemetryMW("tenantHandler", log)))
 	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "revoke"), web.Adapt(web.HandlerWithError(th.revokeHandler), web.TelemetryMW("tenantHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "approve-sdc"), web.Adapt(web.HandlerWithError(th.approveSdcHandler), web.TelemetryMW("tenantHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "sdc/allow"), web.Adapt(web.HandlerWithError(th.allowSdcHandler), web.TelemetryMW("tenantHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "sdc/disallow"), web.Adapt(web.HandlerWithError(th.disallowSdcHandler), web.TelemetryMW("tenantHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "claims"), web.Adapt(web.HandlerWithError(th.claimsHandler), web.TelemetryMW("tenantHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "protect"), web.Adapt(web.HandlerWithError(th.protectHandler), web.TelemetryMW("tenantHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "volume-name"), web.Adapt(web.HandlerWithError(th.volumeNameHandler), web.TelemetryMW("tenantHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "restore"), web.Adapt(web.HandlerWithError(th.restoreHandler), web.TelemetryMW("tenantHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "quota"), web.Adapt(web.HandlerWithError(th.quotaHandler), web.TelemetryMW("tenantHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "usage"), web.Adapt(web.HandlerWithError(th.usageHandler), web.TelemetryMW("tenantHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "activity"), web.Adapt(web.HandlerWithError(th.activityHandler), web.TelemetryMW("tenantHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "organization"), web.Adapt(web.HandlerWithError(th.organizationHandler), web.TelemetryMW("tenantHandler", log)))
+	mux.Handle(fmt.Sprintf("%s%s/", web.ProxyTenantPath, "default-role"), web.Adapt(web.HandlerWithError(th.defaultRoleHandler), web.TelemetryMW("tenantHandler", log)))
 	th.mux = mux
 
 	return th
@@ -67,14 +84,49 @@ func (th *TenantHandler) tenantHandler(w http.ResponseWriter, r *http.Request) e
 	case http.MethodDelete:
 		return th.deleteHandler(w, r)
 	default:
-		return nil
+		return handleMethodNotAllowed(th.log, w, r)
 	}
 }
 
 // CreateTenantBody is the request body for tenant creation
 type CreateTenantBody struct {
-	Tenant     string `json:"tenant"`
-	ApproveSdc bool   `json:"approve_sdc"`
+	Tenant        string `json:"tenant"`
+	ApproveSdc    bool   `json:"approve_sdc"`
+	Organization  string `json:"organization,omitempty"`
+	MaxVolumes    int64  `json:"max_volumes,omitempty"`
+	NoDefaultRole bool   `json:"no_default_role,omitempty"`
+}
+
+// adminOrganization returns the organization the admin token of the request
+// is scoped to, or an empty string if the admin may manage every tenant.
+func adminOrganization(r *http.Request) string {
+	org, _ := r.Context().Value(web.JWTOrganization).(string)
+	return org
+}
+
+// checkOrganization writes a forbidden response and returns an error if the
+// admin token of the request is scoped to an organization that the tenant
+// is not in.
+func (th *TenantHandler) checkOrganization(w http.ResponseWriter, r *http.Request, tenant string) error {
+	org := adminOrganization(r)
+	if org == "" {
+		return nil
+	}
+
+	t, err := th.client.GetTenant(r.Context(), &pb.GetTenantRequest{
+		Name: tenant,
+	})
+	if err != nil {
+		err = fmt.Errorf("getting tenant %s: %w", tenant, err)
+		handleRPCErrorResponse(th.log, w, err)
+		return err
+	}
+	if t.Organization != org {
+		err = fmt.Errorf("tenant %s is not in organization %s", tenant, org)
+		handleJSONErrorResponse(th.log, w, http.StatusForbidden, err)
+		return err
+	}
+	return nil
 }
 
 func (th *TenantHandler) createHandler(w http.ResponseWriter, r *http.Request) error {
@@ -90,25 +142,44 @@ func (th *TenantHandler) createHandler(w http.ResponseWriter, r *http.Request) e
 		return err
 	}
 
+	// admins scoped to an organization create tenants in it
+	if org := adminOrganization(r); org != "" {
+		if body.Organization != "" && body.Organization != org {
+			err = fmt.Errorf("tenant %s may not be created in organization %s", body.Tenant, body.Organization)
+			handleJSONErrorResponse(th.log, w, http.StatusForbidden, err)
+			return err
+		}
+		body.Organization = org
+	}
+
 	setAttributes(span, map[string]interface{}{
-		"tenant":      body.Tenant,
-		"approve_sdc": body.ApproveSdc,
+		"tenant":          body.Tenant,
+		"approve_sdc":     body.ApproveSdc,
+		"organization":    body.Organization,
+		"max_volumes":     body.MaxVolumes,
+		"no_default_role": body.NoDefaultRole,
 	})
 	th.log.WithFields(logrus.Fields{
-		"tenant":      body.Tenant,
-		"approve_sdc": body.ApproveSdc,
+		"tenant":          body.Tenant,
+		"approve_sdc":     body.ApproveSdc,
+		"organization":    body.Organization,
+		"max_volumes":     body.MaxVolumes,
+		"no_default_role": body.NoDefaultRole,
 	}).Info("Requesting tenant creation")
 
 	// call tenant service
 	_, err = th.client.CreateTenant(ctx, &pb.CreateTenantRequest{
 		Tenant: &pb.Tenant{
-			Name:       body.Tenant,
-			Approvesdc: body.ApproveSdc,
+			Name:         body.Tenant,
+			Approvesdc:   body.ApproveSdc,
+			Organization: body.Organization,
+			MaxVolumes:   body.MaxVolumes,
 		},
+		NoDefaultRole: body.NoDefaultRole,
 	})
 	if err != nil {
 		err = fmt.Errorf("creating tenant %s: %w", body.Tenant, err)
-		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(th.log, w, err)
 		return err
 	}
 
@@ -138,6 +209,10 @@ func (th *TenantHandler) updateHandler(w http.ResponseWriter, r *http.Request) e
 		"approve_sdc": body.ApproveSdc,
 	}).Info("Requesting tenant update")
 
+	if err := th.checkOrganization(w, r, body.Tenant); err != nil {
+		return err
+	}
+
 	// call tenant service
 	_, err = th.client.UpdateTenant(ctx, &pb.UpdateTenantRequest{
 		TenantName: body.Tenant,
@@ -145,7 +220,7 @@ func (th *TenantHandler) updateHandler(w http.ResponseWriter, r *http.Request) e
 	})
 	if err != nil {
 		err = fmt.Errorf("updating tenant %s: %w", body.Tenant, err)
-		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(th.log, w, err)
 		return err
 	}
 
@@ -161,13 +236,20 @@ func (th *TenantHandler) getHandler(w http.ResponseWriter, r *http.Request) erro
 	params := r.URL.Query()["name"]
 
 	if len(params) == 0 || params[0] == "" {
-		th.log.Info("Requesting tenant list")
+		org := adminOrganization(r)
+		if org == "" {
+			org = r.URL.Query().Get("organization")
+		}
+
+		th.log.WithField("organization", org).Info("Requesting tenant list")
 
 		// call tenant service
-		tenants, err := th.client.ListTenant(ctx, &pb.ListTenantRequest{})
+		tenants, err := th.client.ListTenant(ctx, &pb.ListTenantRequest{
+			Organization: org,
+		})
 		if err != nil {
 			err = fmt.Errorf("listing tenants: %w", err)
-			handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+			handleRPCErrorResponse(th.log, w, err)
 			return err
 		}
 
@@ -196,7 +278,12 @@ func (th *TenantHandler) getHandler(w http.ResponseWriter, r *http.Request) erro
 	})
 	if err != nil {
 		err = fmt.Errorf("getting tenant %s: %w", name, err)
-		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(th.log, w, err)
+		return err
+	}
+	if org := adminOrganization(r); org != "" && tenant.Organization != org {
+		err = fmt.Errorf("tenant %s is not in organization %s", name, org)
+		handleJSONErrorResponse(th.log, w, http.StatusForbidden, err)
 		return err
 	}
 
@@ -232,15 +319,25 @@ func (th *TenantHandler) deleteHandler(w http.ResponseWriter, r *http.Request) e
 		"tenant": name,
 	}).Info("Requesting tenant delete")
 
+	if err := th.checkOrganization(w, r, name); err != nil {
+		return err
+	}
+
 	// call tenant service
-	_, err := th.client.DeleteTenant(ctx, &pb.DeleteTenantRequest{
+	resp, err := th.client.DeleteTenant(ctx, &pb.DeleteTenantRequest{
 		Name: name,
 	})
 	if err != nil {
 		err = fmt.Errorf("deleting tenant %s: %w", name, err)
-		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(th.log, w, err)
 		return err
 	}
+	if resp.GetRestorableUntil() > 0 {
+		th.log.WithFields(logrus.Fields{
+			"tenant":           name,
+			"restorable_until": time.Unix(resp.RestorableUntil, 0).UTC().Format(time.RFC3339),
+		}).Info("Tenant can be restored until it is purged")
+	}
 
 	w.WriteHeader(http.StatusNoContent)
 	return nil
@@ -281,6 +378,10 @@ func (th *TenantHandler) bindRoleHandler(w http.ResponseWriter, r *http.Request)
 		"role":   body.Role,
 	})
 
+	if err := th.checkOrganization(w, r, body.Tenant); err != nil {
+		return err
+	}
+
 	// call tenant service
 	_, err = th.client.BindRole(ctx, &pb.BindRoleRequest{
 		TenantName: body.Tenant,
@@ -288,7 +389,7 @@ func (th *TenantHandler) bindRoleHandler(w http.ResponseWriter, r *http.Request)
 	})
 	if err != nil {
 		err = fmt.Errorf("binding tenant %s to %s: %w", body.Tenant, body.Role, err)
-		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(th.log, w, err)
 		return err
 	}
 
@@ -325,13 +426,17 @@ func (th *TenantHandler) unbindRoleHandler(w http.ResponseWriter, r *http.Reques
 		"role":   body.Role,
 	}).Info("Requesting tenant unbind role")
 
+	if err := th.checkOrganization(w, r, body.Tenant); err != nil {
+		return err
+	}
+
 	_, err = th.client.UnbindRole(ctx, &pb.UnbindRoleRequest{
 		TenantName: body.Tenant,
 		RoleName:   body.Role,
 	})
 	if err != nil {
 		err = fmt.Errorf("unbinding tenant %s from %s: %w", body.Tenant, body.Role, err)
-		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(th.log, w, err)
 		return err
 	}
 
@@ -344,6 +449,9 @@ type GenerateTokenBody struct {
 	Tenant          string `json:"tenant"`
 	AccessTokenTTL  string `json:"accessTokenTTL"`
 	RefreshTokenTTL string `json:"refreshTokenTTL"`
+	// RevokePrevious refuses to refresh the tokens issued to the tenant
+	// before the generated ones.
+	RevokePrevious bool `json:"revokePrevious,omitempty"`
 }
 
 func (th *TenantHandler) generateTokenHandler(w http.ResponseWriter, r *http.Request) error {
@@ -385,22 +493,29 @@ func (th *TenantHandler) generateTokenHandler(w http.ResponseWriter, r *http.Req
 		"tenant":            body.Tenant,
 		"access_token_TTL":  body.AccessTokenTTL,
 		"refresh_token_TTL": body.RefreshTokenTTL,
+		"revoke_previous":   body.RevokePrevious,
 	})
 	th.log.WithFields(logrus.Fields{
 		"tenant":          body.Tenant,
 		"accessTokenTTL":  body.AccessTokenTTL,
 		"refreshTokenTTL": body.RefreshTokenTTL,
+		"revokePrevious":  body.RevokePrevious,
 	}).Info("Requesting token generation")
 
+	if err := th.checkOrganization(w, r, body.Tenant); err != nil {
+		return err
+	}
+
 	// call tenant service
 	token, err := th.client.GenerateToken(ctx, &pb.GenerateTokenRequest{
 		TenantName:      body.Tenant,
 		AccessTokenTTL:  int64(accessTokenDuration),
 		RefreshTokenTTL: int64(refreshTokenDuration),
+		RevokePrevious:  body.RevokePrevious,
 	})
 	if err != nil {
 		err = fmt.Errorf("generating token for %s: %w", body.Tenant, err)
-		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		handleRPCErrorResponse(th.log, w, err)
 		return err
 	}
 
@@ -452,6 +567,10 @@ func (th *TenantHandler) revokeHandler(w http.ResponseWriter, r *http.Request) e
 		},
 	).Info("Requesting tenant revoke")
 
+	if err := th.checkOrganization(w, r, body.Tenant); err != nil {
+		return err
+	}
+
 	// call tenant service
 	switch {
 	case body.Cancel:
@@ -460,7 +579,7 @@ func (th *TenantHandler) revokeHandler(w http.ResponseWriter, r *http.Request) e
 		})
 		if err != nil {
 			err = fmt.Errorf("cancelling tenant %s revocation: %w", body.Tenant, err)
-			handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+			handleRPCErrorResponse(th.log, w, err)
 			return err
 		}
 	default:
@@ -469,7 +588,7 @@ func (th *TenantHandler) revokeHandler(w http.ResponseWriter, r *http.Request) e
 		})
 		if err != nil {
 			err = fmt.Errorf("revoking tenant %s: %w", body.Tenant, err)
-			handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+			handleRPCErrorResponse(th.log, w, err)
 			return err
 		}
 	}
@@ -478,15 +597,802 @@ func (th *TenantHandler) revokeHandler(w http.ResponseWriter, r *http.Request) e
 	return nil
 }
 
+// setAttributes sets the data as attributes of the span, leaving out the
+// secrets, e.g. passwords.
 func setAttributes(span trace.Span, data map[string]interface{}) {
+	red := redact.Current()
 	var attr []attribute.KeyValue
 	for k, v := range data {
+		if red.IsSecret(k) {
+			continue
+		}
 		switch d := v.(type) {
 		case string:
 			attr = append(attr, attribute.KeyValue{Key: attribute.Key(k), Value: attribute.StringValue(d)})
 		case bool:
 			attr = append(attr, attribute.KeyValue{Key: attribute.Key(k), Value: attribute.BoolValue(d)})
+		case int64:
+			attr = append(attr, attribute.KeyValue{Key: attribute.Key(k), Value: attribute.Int64Value(d)})
 		}
 	}
 	span.SetAttributes(attr...)
 }
+
+// ApproveSdcBody is the request and response body for a tenant's SDC
+// approval setting. Tenants that may not approve SDCs may only map volumes
+// to SDCs the PowerFlex has already approved.
+type ApproveSdcBody struct {
+	Tenant     string `json:"tenant"`
+	ApproveSdc bool   `json:"approve_sdc"`
+}
+
+func (th *TenantHandler) approveSdcHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	switch r.Method {
+	case http.MethodGet:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			err := fmt.Errorf("tenant name not provided in query parameters")
+			handleJSONErrorResponse(th.log, w, http.StatusBadRequest, err)
+			return err
+		}
+
+		setAttributes(span, map[string]interface{}{
+			"tenant": name,
+		})
+		th.log.WithField("tenant", name).Info("Requesting tenant sdc approval")
+
+		if err := th.checkOrganization(w, r, name); err != nil {
+			return err
+		}
+
+		// call tenant service
+		tenant, err := th.client.GetTenant(ctx, &pb.GetTenantRequest{
+			Name: name,
+		})
+		if err != nil {
+			err = fmt.Errorf("getting tenant %s: %w", name, err)
+			handleRPCErrorResponse(th.log, w, err)
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(&ApproveSdcBody{Tenant: name, ApproveSdc: tenant.Approvesdc})
+		if err != nil {
+			err = fmt.Errorf("writing tenant sdc approval response: %w", err)
+			handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+			return err
+		}
+		return nil
+	case http.MethodPatch:
+		var body ApproveSdcBody
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			err = fmt.Errorf("decoding request body: %w", err)
+			handleJSONErrorResponse(th.log, w, http.StatusBadRequest, err)
+			return err
+		}
+
+		setAttributes(span, map[string]interface{}{
+			"tenant":      body.Tenant,
+			"approve_sdc": body.ApproveSdc,
+		})
+		th.log.WithFields(logrus.Fields{
+			"tenant":      body.Tenant,
+			"approve_sdc": body.ApproveSdc,
+		}).Info("Requesting tenant sdc approval update")
+
+		if err := th.checkOrganization(w, r, body.Tenant); err != nil {
+			return err
+		}
+
+		// call tenant service
+		_, err = th.client.UpdateTenant(ctx, &pb.UpdateTenantRequest{
+			TenantName: body.Tenant,
+			Approvesdc: body.ApproveSdc,
+		})
+		if err != nil {
+			err = fmt.Errorf("updating tenant %s: %w", body.Tenant, err)
+			handleRPCErrorResponse(th.log, w, err)
+			return err
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	default:
+		err := fmt.Errorf("method %s not allowed", r.Method)
+		handleJSONErrorResponse(th.log, w, http.StatusMethodNotAllowed, err)
+		return err
+	}
+}
+
+// TenantSdcBody is the request body for allowing or disallowing the SDCs,
+// by GUID or IP, that volumes of a tenant may be mapped to. The response
+// body lists the SDCs allowed after the request.
+type TenantSdcBody struct {
+	Tenant string   `json:"tenant"`
+	Sdcs   []string `json:"sdcs"`
+}
+
+func (th *TenantHandler) allowSdcHandler(w http.ResponseWriter, r *http.Request) error {
+	return th.tenantSdcHandler(w, r, "allow", func(ctx context.Context, body TenantSdcBody) (*pb.Tenant, error) {
+		return th.client.AllowSdc(ctx, &pb.AllowSdcRequest{
+			TenantName: body.Tenant,
+			Sdcs:       body.Sdcs,
+		})
+	})
+}
+
+func (th *TenantHandler) disallowSdcHandler(w http.ResponseWriter, r *http.Request) error {
+	return th.tenantSdcHandler(w, r, "disallow", func(ctx context.Context, body TenantSdcBody) (*pb.Tenant, error) {
+		return th.client.DisallowSdc(ctx, &pb.DisallowSdcRequest{
+			TenantName: body.Tenant,
+			Sdcs:       body.Sdcs,
+		})
+	})
+}
+
+func (th *TenantHandler) tenantSdcHandler(w http.ResponseWriter, r *http.Request, op string, fn func(context.Context, TenantSdcBody) (*pb.Tenant, error)) error {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// only allow POST requests
+	if r.Method != http.MethodPost {
+		err := fmt.Errorf("method %s not allowed", r.Method)
+		handleJSONErrorResponse(th.log, w, http.StatusMethodNotAllowed, err)
+		return err
+	}
+
+	// read request body
+	var body TenantSdcBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(th.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	setAttributes(span, map[string]interface{}{
+		"tenant": body.Tenant,
+		"sdcs":   strings.Join(body.Sdcs, ","),
+	})
+	th.log.WithFields(logrus.Fields{
+		"tenant": body.Tenant,
+		"sdcs":   body.Sdcs,
+	}).Infof("Requesting tenant %s sdcs", op)
+
+	if err := th.checkOrganization(w, r, body.Tenant); err != nil {
+		return err
+	}
+
+	// call tenant service
+	tenant, err := fn(ctx, body)
+	if err != nil {
+		err = fmt.Errorf("%s sdcs of tenant %s: %w", op, body.Tenant, err)
+		handleRPCErrorResponse(th.log, w, err)
+		return err
+	}
+
+	err = json.NewEncoder(w).Encode(&TenantSdcBody{Tenant: body.Tenant, Sdcs: tenant.AllowedSdcs})
+	if err != nil {
+		err = fmt.Errorf("writing tenant sdcs response: %w", err)
+		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return nil
+}
+
+// TenantClaimsBody is the request body for setting and removing the claims
+// of a tenant, which are embedded in its tokens and passed to OPA. The
+// response body has the claims after the request.
+type TenantClaimsBody struct {
+	Tenant string            `json:"tenant"`
+	Claims map[string]string `json:"claims,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
+}
+
+func (th *TenantHandler) claimsHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// only allow PATCH requests
+	if r.Method != http.MethodPatch {
+		return handleMethodNotAllowed(th.log, w, r)
+	}
+
+	// read request body
+	var body TenantClaimsBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(th.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	keys := make([]string, 0, len(body.Claims))
+	for k := range body.Claims {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	setAttributes(span, map[string]interface{}{
+		"tenant": body.Tenant,
+		"claims": strings.Join(keys, ","),
+		"remove": strings.Join(body.Remove, ","),
+	})
+	th.log.WithFields(logrus.Fields{
+		"tenant": body.Tenant,
+		"claims": keys,
+		"remove": body.Remove,
+	}).Info("Requesting tenant claims update")
+
+	if err := th.checkOrganization(w, r, body.Tenant); err != nil {
+		return err
+	}
+
+	// call tenant service
+	tenant, err := th.client.SetClaims(ctx, &pb.SetClaimsRequest{
+		TenantName: body.Tenant,
+		Claims:     body.Claims,
+		Remove:     body.Remove,
+	})
+	if err != nil {
+		err = fmt.Errorf("setting claims of tenant %s: %w", body.Tenant, err)
+		handleRPCErrorResponse(th.log, w, err)
+		return err
+	}
+
+	err = json.NewEncoder(w).Encode(&TenantClaimsBody{Tenant: body.Tenant, Claims: tenant.Claims})
+	if err != nil {
+		err = fmt.Errorf("writing tenant claims response: %w", err)
+		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return nil
+}
+
+// TenantProtectBody is the request body for setting or removing the
+// protection of a tenant from deletion.
+type TenantProtectBody struct {
+	Tenant    string `json:"tenant"`
+	Protected bool   `json:"protected"`
+}
+
+func (th *TenantHandler) protectHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// only allow PATCH requests
+	if r.Method != http.MethodPatch {
+		return handleMethodNotAllowed(th.log, w, r)
+	}
+
+	// read request body
+	var body TenantProtectBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(th.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	setAttributes(span, map[string]interface{}{
+		"tenant":    body.Tenant,
+		"protected": body.Protected,
+	})
+	th.log.WithFields(logrus.Fields{
+		"tenant":    body.Tenant,
+		"protected": body.Protected,
+	}).Info("Requesting tenant protection update")
+
+	if err := th.checkOrganization(w, r, body.Tenant); err != nil {
+		return err
+	}
+
+	// call tenant service
+	_, err = th.client.SetProtected(ctx, &pb.SetProtectedRequest{
+		TenantName: body.Tenant,
+		Protected:  body.Protected,
+	})
+	if err != nil {
+		err = fmt.Errorf("setting protection of tenant %s: %w", body.Tenant, err)
+		handleRPCErrorResponse(th.log, w, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// TenantVolumeNameBody is the request body for setting the volume name
+// policy of a tenant. An empty prefix or pattern is left as is; Remove
+// lists the parts to remove, "prefix" or "pattern".
+type TenantVolumeNameBody struct {
+	Tenant  string   `json:"tenant"`
+	Prefix  string   `json:"prefix,omitempty"`
+	Pattern string   `json:"pattern,omitempty"`
+	Remove  []string `json:"remove,omitempty"`
+}
+
+func (th *TenantHandler) volumeNameHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// only allow PATCH requests
+	if r.Method != http.MethodPatch {
+		return handleMethodNotAllowed(th.log, w, r)
+	}
+
+	// read request body
+	var body TenantVolumeNameBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(th.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	setAttributes(span, map[string]interface{}{
+		"tenant":  body.Tenant,
+		"prefix":  body.Prefix,
+		"pattern": body.Pattern,
+	})
+	th.log.WithFields(logrus.Fields{
+		"tenant":  body.Tenant,
+		"prefix":  body.Prefix,
+		"pattern": body.Pattern,
+		"remove":  body.Remove,
+	}).Info("Requesting tenant volume name policy update")
+
+	if err := th.checkOrganization(w, r, body.Tenant); err != nil {
+		return err
+	}
+
+	// call tenant service
+	_, err = th.client.SetVolumeNamePolicy(ctx, &pb.SetVolumeNamePolicyRequest{
+		TenantName:    body.Tenant,
+		VolumePrefix:  body.Prefix,
+		VolumePattern: body.Pattern,
+		Remove:        body.Remove,
+	})
+	if err != nil {
+		err = fmt.Errorf("setting volume name policy of tenant %s: %w", body.Tenant, err)
+		handleRPCErrorResponse(th.log, w, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// RestoreTenantBody is the request body for restoring a deleted tenant
+type RestoreTenantBody struct {
+	Tenant string `json:"tenant"`
+}
+
+// restoreHandler restores a deleted tenant. The organization of a deleted
+// tenant cannot be checked, so only admins that are not scoped to an
+// organization may restore tenants.
+func (th *TenantHandler) restoreHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// only allow POST requests
+	if r.Method != http.MethodPost {
+		return handleMethodNotAllowed(th.log, w, r)
+	}
+
+	if org := adminOrganization(r); org != "" {
+		err := fmt.Errorf("admin of organization %s may not restore tenants", org)
+		handleJSONErrorResponse(th.log, w, http.StatusForbidden, err)
+		return err
+	}
+
+	// read request body
+	var body RestoreTenantBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(th.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	setAttributes(span, map[string]interface{}{
+		"tenant": body.Tenant,
+	})
+	th.log.WithField("tenant", body.Tenant).Info("Requesting tenant restore")
+
+	// call tenant service
+	_, err = th.client.RestoreTenant(ctx, &pb.RestoreTenantRequest{
+		Name: body.Tenant,
+	})
+	if err != nil {
+		err = fmt.Errorf("restoring tenant %s: %w", body.Tenant, err)
+		handleRPCErrorResponse(th.log, w, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// TenantMaxVolumesBody is the request body for setting the maximum number of
+// volumes a tenant may have in each storage pool. A maximum of 0 removes the
+// limit.
+type TenantMaxVolumesBody struct {
+	Tenant     string `json:"tenant"`
+	MaxVolumes int64  `json:"max_volumes"`
+}
+
+// quotaHandler gets the capacity and the number of volumes approved for a
+// tenant in each storage pool, or sets the maximum number of volumes.
+func (th *TenantHandler) quotaHandler(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return th.getQuotaHandler(w, r)
+	case http.MethodPatch:
+		return th.setMaxVolumesHandler(w, r)
+	default:
+		return handleMethodNotAllowed(th.log, w, r)
+	}
+}
+
+func (th *TenantHandler) getQuotaHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// parse tenant name from request parameters
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		err := fmt.Errorf("tenant name not provided in query parameters")
+		handleJSONErrorResponse(th.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	setAttributes(span, map[string]interface{}{
+		"tenant": name,
+	})
+	th.log.WithFields(logrus.Fields{
+		"tenant": name,
+	}).Info("Requesting tenant quota")
+
+	if err := th.checkOrganization(w, r, name); err != nil {
+		return err
+	}
+
+	// call tenant service
+	quota, err := th.client.GetTenantQuota(ctx, &pb.GetTenantQuotaRequest{
+		Name: name,
+	})
+	if err != nil {
+		err = fmt.Errorf("getting quota of tenant %s: %w", name, err)
+		handleRPCErrorResponse(th.log, w, err)
+		return err
+	}
+
+	// return quota to client
+	_, err = fmt.Fprint(w, protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true, Indent: ""}.Format(quota))
+	if err != nil {
+		err = fmt.Errorf("writing tenant quota response: %w", err)
+		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return nil
+}
+
+// TenantUsageResponse is the response of exporting the usage of tenants.
+type TenantUsageResponse struct {
+	Records []usage.Record `json:"records"`
+}
+
+// usageHandler exports a snapshot of the capacity and the number of volumes
+// approved for every tenant, or for those in the organization of the admin.
+func (th *TenantHandler) usageHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return handleMethodNotAllowed(th.log, w, r)
+	}
+
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	org := adminOrganization(r)
+	if org == "" {
+		org = r.URL.Query().Get("organization")
+	}
+
+	setAttributes(span, map[string]interface{}{
+		"organization": org,
+	})
+	th.log.WithField("organization", org).Info("Requesting tenant usage")
+
+	records, err := usage.Collect(ctx, th.client, org, time.Now())
+	if err != nil {
+		err = fmt.Errorf("collecting tenant usage: %w", err)
+		handleRPCErrorResponse(th.log, w, err)
+		return err
+	}
+
+	err = json.NewEncoder(w).Encode(&TenantUsageResponse{Records: records})
+	if err != nil {
+		err = fmt.Errorf("writing tenant usage response: %w", err)
+		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return nil
+}
+
+func (th *TenantHandler) activityHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return handleMethodNotAllowed(th.log, w, r)
+	}
+
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// parse the optional tenant name from request parameters
+	name := r.URL.Query().Get("name")
+
+	setAttributes(span, map[string]interface{}{
+		"tenant": name,
+	})
+	th.log.WithFields(logrus.Fields{
+		"tenant": name,
+	}).Info("Requesting tenant activity")
+
+	if name != "" {
+		if err := th.checkOrganization(w, r, name); err != nil {
+			return err
+		}
+	}
+
+	// call tenant service
+	activity, err := th.client.GetActivity(ctx, &pb.GetActivityRequest{
+		Name:         name,
+		Organization: adminOrganization(r),
+	})
+	if err != nil {
+		err = fmt.Errorf("getting tenant activity: %w", err)
+		handleRPCErrorResponse(th.log, w, err)
+		return err
+	}
+
+	// return activity to client
+	_, err = fmt.Fprint(w, protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true, Indent: ""}.Format(activity))
+	if err != nil {
+		err = fmt.Errorf("writing tenant activity response: %w", err)
+		handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+		return err
+	}
+	return nil
+}
+
+func (th *TenantHandler) setMaxVolumesHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// read request body
+	var body TenantMaxVolumesBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		err = fmt.Errorf("decoding request body: %w", err)
+		handleJSONErrorResponse(th.log, w, http.StatusBadRequest, err)
+		return err
+	}
+
+	setAttributes(span, map[string]interface{}{
+		"tenant":      body.Tenant,
+		"max_volumes": body.MaxVolumes,
+	})
+	th.log.WithFields(logrus.Fields{
+		"tenant":      body.Tenant,
+		"max_volumes": body.MaxVolumes,
+	}).Info("Requesting tenant max volumes update")
+
+	if err := th.checkOrganization(w, r, body.Tenant); err != nil {
+		return err
+	}
+
+	// call tenant service
+	_, err = th.client.SetMaxVolumes(ctx, &pb.SetMaxVolumesRequest{
+		TenantName: body.Tenant,
+		MaxVolumes: body.MaxVolumes,
+	})
+	if err != nil {
+		err = fmt.Errorf("setting max volumes of tenant %s: %w", body.Tenant, err)
+		handleRPCErrorResponse(th.log, w, err)
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// DefaultRoleBody is the request body and response of the default role
+type DefaultRoleBody struct {
+	Role string `json:"role"`
+}
+
+// defaultRoleHandler gets or sets the role bound to tenants when they are
+// created. Only admins that are not scoped to an organization may set it.
+func (th *TenantHandler) defaultRoleHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	switch r.Method {
+	case http.MethodGet:
+		th.log.Info("Requesting default role")
+
+		// call tenant service
+		role, err := th.client.GetDefaultRole(ctx, &pb.GetDefaultRoleRequest{})
+		if err != nil {
+			err = fmt.Errorf("getting default role: %w", err)
+			handleRPCErrorResponse(th.log, w, err)
+			return err
+		}
+
+		// return default role to client
+		err = json.NewEncoder(w).Encode(&DefaultRoleBody{Role: role.RoleName})
+		if err != nil {
+			err = fmt.Errorf("writing default role response: %w", err)
+			handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+			return err
+		}
+		return nil
+	case http.MethodPatch:
+		if org := adminOrganization(r); org != "" {
+			err := fmt.Errorf("admin of organization %s may not set the default role", org)
+			handleJSONErrorResponse(th.log, w, http.StatusForbidden, err)
+			return err
+		}
+
+		var body DefaultRoleBody
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			err = fmt.Errorf("decoding request body: %w", err)
+			handleJSONErrorResponse(th.log, w, http.StatusBadRequest, err)
+			return err
+		}
+
+		setAttributes(span, map[string]interface{}{
+			"role": body.Role,
+		})
+		th.log.WithField("role", body.Role).Info("Requesting default role update")
+
+		// call tenant service
+		_, err = th.client.SetDefaultRole(ctx, &pb.SetDefaultRoleRequest{
+			RoleName: body.Role,
+		})
+		if err != nil {
+			err = fmt.Errorf("setting default role %s: %w", body.Role, err)
+			handleRPCErrorResponse(th.log, w, err)
+			return err
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	default:
+		return handleMethodNotAllowed(th.log, w, r)
+	}
+}
+
+// OrganizationBody is the request body for organization creation
+type OrganizationBody struct {
+	Organization string `json:"organization"`
+}
+
+// organizationHandler manages organizations. Only admins that are not
+// scoped to an organization may manage them.
+func (th *TenantHandler) organizationHandler(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	if org := adminOrganization(r); org != "" {
+		err := fmt.Errorf("admin of organization %s may not manage organizations", org)
+		handleJSONErrorResponse(th.log, w, http.StatusForbidden, err)
+		return err
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var body OrganizationBody
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			err = fmt.Errorf("decoding request body: %w", err)
+			handleJSONErrorResponse(th.log, w, http.StatusBadRequest, err)
+			return err
+		}
+
+		setAttributes(span, map[string]interface{}{
+			"organization": body.Organization,
+		})
+		th.log.WithField("organization", body.Organization).Info("Requesting organization creation")
+
+		// call tenant service
+		_, err = th.client.CreateOrganization(ctx, &pb.CreateOrganizationRequest{
+			Organization: &pb.Organization{
+				Name: body.Organization,
+			},
+		})
+		if err != nil {
+			err = fmt.Errorf("creating organization %s: %w", body.Organization, err)
+			handleRPCErrorResponse(th.log, w, err)
+			return err
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		return nil
+	case http.MethodGet:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			th.log.Info("Requesting organization list")
+
+			// call tenant service
+			orgs, err := th.client.ListOrganization(ctx, &pb.ListOrganizationRequest{})
+			if err != nil {
+				err = fmt.Errorf("listing organizations: %w", err)
+				handleRPCErrorResponse(th.log, w, err)
+				return err
+			}
+
+			err = json.NewEncoder(w).Encode(&orgs)
+			if err != nil {
+				err = fmt.Errorf("writing organization list response: %w", err)
+				handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+				return err
+			}
+			return nil
+		}
+
+		setAttributes(span, map[string]interface{}{
+			"organization": name,
+		})
+		th.log.WithField("organization", name).Info("Requesting organization get")
+
+		// call tenant service
+		org, err := th.client.GetOrganization(ctx, &pb.GetOrganizationRequest{
+			Name: name,
+		})
+		if err != nil {
+			err = fmt.Errorf("getting organization %s: %w", name, err)
+			handleRPCErrorResponse(th.log, w, err)
+			return err
+		}
+
+		_, err = fmt.Fprint(w, protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true, Indent: ""}.Format(org))
+		if err != nil {
+			err = fmt.Errorf("writing organization get response: %w", err)
+			handleJSONErrorResponse(th.log, w, http.StatusInternalServerError, err)
+			return err
+		}
+		return nil
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			err := fmt.Errorf("organization name not provided in query parameters")
+			handleJSONErrorResponse(th.log, w, http.StatusBadRequest, err)
+			return err
+		}
+
+		setAttributes(span, map[string]interface{}{
+			"organization": name,
+		})
+		th.log.WithField("organization", name).Info("Requesting organization delete")
+
+		// call tenant service
+		_, err := th.client.DeleteOrganization(ctx, &pb.DeleteOrganizationRequest{
+			Name: name,
+		})
+		if err != nil {
+			err = fmt.Errorf("deleting organization %s: %w", name, err)
+			handleRPCErrorResponse(th.log, w, err)
+			return err
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	default:
+		err := fmt.Errorf("method %s not allowed", r.Method)
+		handleJSONErrorResponse(th.log, w, http.StatusMethodNotAllowed, err)
+		return err
+	}
+}
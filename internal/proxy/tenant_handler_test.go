@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"errors"
 	"karavi-authorization/internal/tenantsvc/mocks"
+	"karavi-authorization/internal/web"
 	"karavi-authorization/pb"
 	"net/http"
 	"net/http/httptest"
@@ -26,6 +27,9 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 func TestTenantHandler(t *testing.T) {
@@ -226,6 +230,47 @@ func TestTenantHandler(t *testing.T) {
 				t.Errorf("expectecd %v, got %v", want, got)
 			}
 		})
+		t.Run("responds not found for an unknown tenant", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{
+				GetTenantFn: func(_ context.Context, _ *pb.GetTenantRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					return nil, status.Error(codes.NotFound, "tenant not found")
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/?name=test", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusNotFound {
+				t.Errorf("expected status code %d, got %d", http.StatusNotFound, code)
+			}
+			var body web.JSONError
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Reason != web.CodeNotFound {
+				t.Errorf("expected reason %q, got %q", web.CodeNotFound, body.Reason)
+			}
+		})
+		t.Run("handles bad method", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodPut, "/proxy/tenant/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusMethodNotAllowed {
+				t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, code)
+			}
+		})
 		t.Run("handles error from tenant service get", func(t *testing.T) {
 			client := &mocks.FakeTenantServiceClient{
 				GetTenantFn: func(_ context.Context, _ *pb.GetTenantRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
@@ -803,6 +848,1048 @@ func TestTenantHandler(t *testing.T) {
 
 			sut.ServeHTTP(w, r)
 
+			code := w.Result().StatusCode
+			if code != http.StatusInternalServerError {
+				t.Errorf("expected status code %d, got %d", http.StatusInternalServerError, code)
+			}
+		})
+	})
+	t.Run("it handles tenant sdc approval", func(t *testing.T) {
+		t.Run("successfully gets the sdc approval", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{
+				GetTenantFn: func(_ context.Context, _ *pb.GetTenantRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					return &pb.Tenant{
+						Name:       "test",
+						Approvesdc: false,
+					}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/approve-sdc/?name=test", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusOK {
+				t.Errorf("expected status code %d, got %d", http.StatusOK, code)
+			}
+
+			var got ApproveSdcBody
+			err := json.NewDecoder(w.Result().Body).Decode(&got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := ApproveSdcBody{Tenant: "test", ApproveSdc: false}
+			if got != want {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+		})
+		t.Run("successfully updates the sdc approval", func(t *testing.T) {
+			var gotReq *pb.UpdateTenantRequest
+			client := &mocks.FakeTenantServiceClient{
+				UpdateTenantFn: func(_ context.Context, req *pb.UpdateTenantRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					gotReq = req
+					return &pb.Tenant{}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&ApproveSdcBody{
+				Tenant:     "test",
+				ApproveSdc: false,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPatch, "/proxy/tenant/approve-sdc/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusNoContent {
+				t.Errorf("expected status code %d, got %d", http.StatusNoContent, code)
+			}
+			if gotReq == nil || gotReq.TenantName != "test" || gotReq.Approvesdc {
+				t.Errorf("expected sdc approval to be disabled for tenant test, got %v", gotReq)
+			}
+		})
+		t.Run("handles bad query param", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/approve-sdc/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusBadRequest {
+				t.Errorf("expected status code %d, got %d", http.StatusBadRequest, code)
+			}
+		})
+		t.Run("handles bad method", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodPost, "/proxy/tenant/approve-sdc/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusMethodNotAllowed {
+				t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, code)
+			}
+		})
+	})
+	t.Run("it handles tenant sdc allow lists", func(t *testing.T) {
+		t.Run("successfully allows sdcs", func(t *testing.T) {
+			var gotReq *pb.AllowSdcRequest
+			client := &mocks.FakeTenantServiceClient{
+				AllowSdcFn: func(_ context.Context, req *pb.AllowSdcRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					gotReq = req
+					return &pb.Tenant{Name: req.TenantName, AllowedSdcs: req.Sdcs}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&TenantSdcBody{
+				Tenant: "test",
+				Sdcs:   []string{"10.0.0.1"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/proxy/tenant/sdc/allow/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusOK {
+				t.Errorf("expected status code %d, got %d", http.StatusOK, code)
+			}
+			if gotReq == nil || gotReq.TenantName != "test" {
+				t.Errorf("expected the tenant service to be called for tenant test, got %v", gotReq)
+			}
+			var got TenantSdcBody
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Sdcs, []string{"10.0.0.1"}) {
+				t.Errorf("expected the allowed sdcs in the response, got %v", got.Sdcs)
+			}
+		})
+		t.Run("successfully disallows sdcs", func(t *testing.T) {
+			var called bool
+			client := &mocks.FakeTenantServiceClient{
+				DisallowSdcFn: func(_ context.Context, req *pb.DisallowSdcRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					called = true
+					return &pb.Tenant{Name: req.TenantName}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&TenantSdcBody{
+				Tenant: "test",
+				Sdcs:   []string{"10.0.0.1"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/proxy/tenant/sdc/disallow/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusOK {
+				t.Errorf("expected status code %d, got %d", http.StatusOK, code)
+			}
+			if !called {
+				t.Error("expected the tenant service to be called")
+			}
+		})
+		t.Run("handles bad method", func(t *testing.T) {
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), &mocks.FakeTenantServiceClient{})
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/sdc/allow/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusMethodNotAllowed {
+				t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, code)
+			}
+		})
+		t.Run("handles error from tenant service", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{
+				AllowSdcFn: func(_ context.Context, _ *pb.AllowSdcRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					return nil, errors.New("error")
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&TenantSdcBody{
+				Tenant: "test",
+				Sdcs:   []string{"10.0.0.1"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/proxy/tenant/sdc/allow/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusInternalServerError {
+				t.Errorf("expected status code %d, got %d", http.StatusInternalServerError, code)
+			}
+		})
+	})
+	t.Run("it handles tenant claims", func(t *testing.T) {
+		t.Run("successfully sets claims", func(t *testing.T) {
+			var gotReq *pb.SetClaimsRequest
+			client := &mocks.FakeTenantServiceClient{
+				SetClaimsFn: func(_ context.Context, req *pb.SetClaimsRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					gotReq = req
+					return &pb.Tenant{Name: req.TenantName, Claims: req.Claims}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&TenantClaimsBody{
+				Tenant: "test",
+				Claims: map[string]string{"team": "payments"},
+				Remove: []string{"env"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPatch, "/proxy/tenant/claims/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusOK {
+				t.Errorf("expected status code %d, got %d", http.StatusOK, code)
+			}
+			if gotReq == nil || gotReq.TenantName != "test" || !reflect.DeepEqual(gotReq.Remove, []string{"env"}) {
+				t.Errorf("expected the tenant service to be called for tenant test, got %v", gotReq)
+			}
+			var got TenantClaimsBody
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Claims, map[string]string{"team": "payments"}) {
+				t.Errorf("expected the claims in the response, got %v", got.Claims)
+			}
+		})
+		t.Run("handles bad method", func(t *testing.T) {
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), &mocks.FakeTenantServiceClient{})
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/claims/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusMethodNotAllowed {
+				t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, code)
+			}
+		})
+		t.Run("handles error from tenant service", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{
+				SetClaimsFn: func(_ context.Context, _ *pb.SetClaimsRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					return nil, errors.New("error")
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&TenantClaimsBody{
+				Tenant: "test",
+				Claims: map[string]string{"team": "payments"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPatch, "/proxy/tenant/claims/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusInternalServerError {
+				t.Errorf("expected status code %d, got %d", http.StatusInternalServerError, code)
+			}
+		})
+	})
+	t.Run("it handles tenant protection", func(t *testing.T) {
+		t.Run("successfully protects a tenant", func(t *testing.T) {
+			var gotReq *pb.SetProtectedRequest
+			client := &mocks.FakeTenantServiceClient{
+				SetProtectedFn: func(_ context.Context, req *pb.SetProtectedRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					gotReq = req
+					return &pb.Tenant{Name: req.TenantName, Protected: req.Protected}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&TenantProtectBody{Tenant: "test", Protected: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPatch, "/proxy/tenant/protect/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusNoContent {
+				t.Errorf("expected status code %d, got %d", http.StatusNoContent, code)
+			}
+			if gotReq == nil || gotReq.TenantName != "test" || !gotReq.Protected {
+				t.Errorf("expected tenant test to be protected, got %v", gotReq)
+			}
+		})
+		t.Run("handles bad method", func(t *testing.T) {
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), &mocks.FakeTenantServiceClient{})
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/protect/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusMethodNotAllowed {
+				t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, code)
+			}
+		})
+	})
+	t.Run("it handles the tenant volume name policy", func(t *testing.T) {
+		t.Run("successfully sets the policy", func(t *testing.T) {
+			var gotReq *pb.SetVolumeNamePolicyRequest
+			client := &mocks.FakeTenantServiceClient{
+				SetVolumeNamePolicyFn: func(_ context.Context, req *pb.SetVolumeNamePolicyRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					gotReq = req
+					return &pb.Tenant{Name: req.TenantName, VolumePrefix: req.VolumePrefix, VolumePattern: req.VolumePattern}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&TenantVolumeNameBody{Tenant: "test", Prefix: "t1-", Pattern: "^t1-[a-z0-9]+$"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPatch, "/proxy/tenant/volume-name/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusNoContent {
+				t.Errorf("expected status code %d, got %d", http.StatusNoContent, code)
+			}
+			if gotReq == nil || gotReq.TenantName != "test" || gotReq.VolumePrefix != "t1-" || gotReq.VolumePattern != "^t1-[a-z0-9]+$" {
+				t.Errorf("expected the volume name policy of tenant test to be set, got %v", gotReq)
+			}
+		})
+		t.Run("handles an invalid pattern", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{
+				SetVolumeNamePolicyFn: func(_ context.Context, _ *pb.SetVolumeNamePolicyRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					return nil, status.Error(codes.InvalidArgument, "volume pattern must be a valid regular expression")
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&TenantVolumeNameBody{Tenant: "test", Pattern: "t1-("})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPatch, "/proxy/tenant/volume-name/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusBadRequest {
+				t.Errorf("expected status code %d, got %d", http.StatusBadRequest, code)
+			}
+		})
+		t.Run("handles bad method", func(t *testing.T) {
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), &mocks.FakeTenantServiceClient{})
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/volume-name/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusMethodNotAllowed {
+				t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, code)
+			}
+		})
+	})
+	t.Run("it handles tenant restore", func(t *testing.T) {
+		t.Run("successfully restores a tenant", func(t *testing.T) {
+			var gotReq *pb.RestoreTenantRequest
+			client := &mocks.FakeTenantServiceClient{
+				RestoreTenantFn: func(_ context.Context, req *pb.RestoreTenantRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					gotReq = req
+					return &pb.Tenant{Name: req.Name}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&RestoreTenantBody{Tenant: "test"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/proxy/tenant/restore/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusNoContent {
+				t.Errorf("expected status code %d, got %d", http.StatusNoContent, code)
+			}
+			if gotReq == nil || gotReq.Name != "test" {
+				t.Errorf("expected tenant test to be restored, got %v", gotReq)
+			}
+		})
+		t.Run("refuses organization admins", func(t *testing.T) {
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), &mocks.FakeTenantServiceClient{})
+
+			payload, err := json.Marshal(&RestoreTenantBody{Tenant: "test"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/proxy/tenant/restore/", bytes.NewReader(payload))
+			r = r.WithContext(context.WithValue(r.Context(), web.JWTOrganization, "org"))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusForbidden {
+				t.Errorf("expected status code %d, got %d", http.StatusForbidden, code)
+			}
+		})
+		t.Run("handles error from tenant service", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{
+				RestoreTenantFn: func(_ context.Context, _ *pb.RestoreTenantRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					return nil, status.Error(codes.NotFound, "tenant not found")
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&RestoreTenantBody{Tenant: "test"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/proxy/tenant/restore/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusNotFound {
+				t.Errorf("expected status code %d, got %d", http.StatusNotFound, code)
+			}
+		})
+	})
+	t.Run("it handles tenant quotas", func(t *testing.T) {
+		t.Run("successfully gets the quota", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{
+				GetTenantQuotaFn: func(_ context.Context, req *pb.GetTenantQuotaRequest, _ ...grpc.CallOption) (*pb.TenantQuota, error) {
+					return &pb.TenantQuota{
+						Name:       req.Name,
+						MaxVolumes: 10,
+						Pools:      []*pb.PoolUsage{{SystemType: "powerflex", SystemId: "542a2d5f5122210f", Pool: "bronze", ApprovedVolumes: 2}},
+					}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/quota/?name=test", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusOK {
+				t.Errorf("expected status code %d, got %d", http.StatusOK, code)
+			}
+			var got pb.TenantQuota
+			if err := protojson.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.MaxVolumes != 10 || len(got.Pools) != 1 || got.Pools[0].ApprovedVolumes != 2 {
+				t.Errorf("expected the quota in the response, got %v", &got)
+			}
+		})
+		t.Run("successfully sets the max volumes", func(t *testing.T) {
+			var gotReq *pb.SetMaxVolumesRequest
+			client := &mocks.FakeTenantServiceClient{
+				SetMaxVolumesFn: func(_ context.Context, req *pb.SetMaxVolumesRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					gotReq = req
+					return &pb.Tenant{Name: req.TenantName, MaxVolumes: req.MaxVolumes}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&TenantMaxVolumesBody{
+				Tenant:     "test",
+				MaxVolumes: 10,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPatch, "/proxy/tenant/quota/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusNoContent {
+				t.Errorf("expected status code %d, got %d", http.StatusNoContent, code)
+			}
+			if gotReq == nil || gotReq.TenantName != "test" || gotReq.MaxVolumes != 10 {
+				t.Errorf("expected the tenant service to be called for tenant test, got %v", gotReq)
+			}
+		})
+		t.Run("handles a missing tenant name", func(t *testing.T) {
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), &mocks.FakeTenantServiceClient{})
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/quota/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusBadRequest {
+				t.Errorf("expected status code %d, got %d", http.StatusBadRequest, code)
+			}
+		})
+		t.Run("handles bad method", func(t *testing.T) {
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), &mocks.FakeTenantServiceClient{})
+
+			r := httptest.NewRequest(http.MethodPost, "/proxy/tenant/quota/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusMethodNotAllowed {
+				t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, code)
+			}
+		})
+	})
+	t.Run("it handles tenant usage", func(t *testing.T) {
+		t.Run("successfully exports the usage of an organization", func(t *testing.T) {
+			var gotOrg string
+			client := &mocks.FakeTenantServiceClient{
+				ListTenantFn: func(_ context.Context, req *pb.ListTenantRequest, _ ...grpc.CallOption) (*pb.ListTenantResponse, error) {
+					gotOrg = req.Organization
+					return &pb.ListTenantResponse{Tenants: []*pb.Tenant{{Name: "b"}, {Name: "a"}}}, nil
+				},
+				GetTenantQuotaFn: func(_ context.Context, req *pb.GetTenantQuotaRequest, _ ...grpc.CallOption) (*pb.TenantQuota, error) {
+					return &pb.TenantQuota{
+						Name:  req.Name,
+						Pools: []*pb.PoolUsage{{SystemType: "powerflex", SystemId: "542a2d5f5122210f", Pool: "bronze", ApprovedCapacity: 8388608, ApprovedVolumes: 1}},
+					}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/usage/?organization=acme", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusOK {
+				t.Errorf("expected status code %d, got %d", http.StatusOK, code)
+			}
+			if gotOrg != "acme" {
+				t.Errorf("expected organization %q, got %q", "acme", gotOrg)
+			}
+			var got TenantUsageResponse
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Records) != 2 || got.Records[0].Tenant != "a" || got.Records[1].ApprovedCapacity != 8388608 {
+				t.Errorf("expected the usage of both tenants, got %+v", got.Records)
+			}
+		})
+		t.Run("handles a tenant service error", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{
+				ListTenantFn: func(_ context.Context, _ *pb.ListTenantRequest, _ ...grpc.CallOption) (*pb.ListTenantResponse, error) {
+					return nil, errors.New("test error")
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/usage/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusInternalServerError {
+				t.Errorf("expected status code %d, got %d", http.StatusInternalServerError, code)
+			}
+		})
+		t.Run("handles bad method", func(t *testing.T) {
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), &mocks.FakeTenantServiceClient{})
+
+			r := httptest.NewRequest(http.MethodPost, "/proxy/tenant/usage/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusMethodNotAllowed {
+				t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, code)
+			}
+		})
+	})
+	t.Run("it handles tenant activity", func(t *testing.T) {
+		t.Run("successfully gets the activity of an organization", func(t *testing.T) {
+			var gotReq *pb.GetActivityRequest
+			client := &mocks.FakeTenantServiceClient{
+				GetActivityFn: func(_ context.Context, req *pb.GetActivityRequest, _ ...grpc.CallOption) (*pb.GetActivityResponse, error) {
+					gotReq = req
+					return &pb.GetActivityResponse{
+						Tenants: []*pb.TenantActivity{{Name: "test", Creates: 3, LastActivity: 1700000000}},
+					}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/activity/", nil)
+			r = r.WithContext(context.WithValue(r.Context(), web.JWTOrganization, "org"))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusOK {
+				t.Errorf("expected status code %d, got %d", http.StatusOK, code)
+			}
+			if gotReq == nil || gotReq.Name != "" || gotReq.Organization != "org" {
+				t.Errorf("expected the activity of organization org to be requested, got %v", gotReq)
+			}
+			var got pb.GetActivityResponse
+			if err := protojson.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Tenants) != 1 || got.Tenants[0].Creates != 3 {
+				t.Errorf("expected the activity in the response, got %v", &got)
+			}
+		})
+		t.Run("handles bad method", func(t *testing.T) {
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), &mocks.FakeTenantServiceClient{})
+
+			r := httptest.NewRequest(http.MethodPost, "/proxy/tenant/activity/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusMethodNotAllowed {
+				t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, code)
+			}
+		})
+	})
+	t.Run("it handles the default role", func(t *testing.T) {
+		t.Run("successfully gets the default role", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{
+				GetDefaultRoleFn: func(_ context.Context, _ *pb.GetDefaultRoleRequest, _ ...grpc.CallOption) (*pb.DefaultRole, error) {
+					return &pb.DefaultRole{RoleName: "role-1"}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/default-role/", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusOK {
+				t.Errorf("expected status code %d, got %d", http.StatusOK, code)
+			}
+			var got DefaultRoleBody
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Role != "role-1" {
+				t.Errorf("expected default role role-1, got %q", got.Role)
+			}
+		})
+		t.Run("successfully sets the default role", func(t *testing.T) {
+			var gotReq *pb.SetDefaultRoleRequest
+			client := &mocks.FakeTenantServiceClient{
+				SetDefaultRoleFn: func(_ context.Context, req *pb.SetDefaultRoleRequest, _ ...grpc.CallOption) (*pb.DefaultRole, error) {
+					gotReq = req
+					return &pb.DefaultRole{RoleName: req.RoleName}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&DefaultRoleBody{Role: "role-1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPatch, "/proxy/tenant/default-role/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusNoContent {
+				t.Errorf("expected status code %d, got %d", http.StatusNoContent, code)
+			}
+			if gotReq == nil || gotReq.RoleName != "role-1" {
+				t.Errorf("expected the default role to be set to role-1, got %v", gotReq)
+			}
+		})
+		t.Run("passes the opt out on tenant creation", func(t *testing.T) {
+			var gotReq *pb.CreateTenantRequest
+			client := &mocks.FakeTenantServiceClient{
+				CreateTenantFn: func(_ context.Context, req *pb.CreateTenantRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					gotReq = req
+					return req.Tenant, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&CreateTenantBody{Tenant: "test", NoDefaultRole: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/proxy/tenant/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusCreated {
+				t.Errorf("expected status code %d, got %d", http.StatusCreated, code)
+			}
+			if gotReq == nil || !gotReq.NoDefaultRole {
+				t.Errorf("expected the tenant to be created without the default role, got %v", gotReq)
+			}
+		})
+		t.Run("refuses organization admins", func(t *testing.T) {
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), &mocks.FakeTenantServiceClient{})
+
+			r := httptest.NewRequest(http.MethodPatch, "/proxy/tenant/default-role/", bytes.NewReader([]byte(`{"role":"role-1"}`)))
+			r = r.WithContext(context.WithValue(r.Context(), web.JWTOrganization, "org"))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusForbidden {
+				t.Errorf("expected status code %d, got %d", http.StatusForbidden, code)
+			}
+		})
+	})
+	t.Run("it scopes organization admins", func(t *testing.T) {
+		withOrganization := func(r *http.Request, org string) *http.Request {
+			return r.WithContext(context.WithValue(r.Context(), web.JWTOrganization, org))
+		}
+
+		t.Run("creates a tenant in the admin organization", func(t *testing.T) {
+			var gotReq *pb.CreateTenantRequest
+			client := &mocks.FakeTenantServiceClient{
+				CreateTenantFn: func(_ context.Context, req *pb.CreateTenantRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					gotReq = req
+					return req.Tenant, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&CreateTenantBody{
+				Tenant: "test",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := withOrganization(httptest.NewRequest(http.MethodPost, "/proxy/tenant/", bytes.NewReader(payload)), "org")
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusCreated {
+				t.Errorf("expected status code %d, got %d", http.StatusCreated, code)
+			}
+			if gotReq == nil || gotReq.Tenant.Organization != "org" {
+				t.Errorf("expected tenant to be created in organization org, got %v", gotReq)
+			}
+		})
+		t.Run("refuses to create a tenant in another organization", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{
+				CreateTenantFn: func(_ context.Context, _ *pb.CreateTenantRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					t.Error("expected tenant service not to be called")
+					return &pb.Tenant{}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&CreateTenantBody{
+				Tenant:       "test",
+				Organization: "other",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := withOrganization(httptest.NewRequest(http.MethodPost, "/proxy/tenant/", bytes.NewReader(payload)), "org")
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusForbidden {
+				t.Errorf("expected status code %d, got %d", http.StatusForbidden, code)
+			}
+		})
+		t.Run("refuses to bind a role to a tenant in another organization", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{
+				GetTenantFn: func(_ context.Context, _ *pb.GetTenantRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					return &pb.Tenant{Name: "test", Organization: "other"}, nil
+				},
+				BindRoleFn: func(_ context.Context, _ *pb.BindRoleRequest, _ ...grpc.CallOption) (*pb.BindRoleResponse, error) {
+					t.Error("expected tenant service not to be called")
+					return &pb.BindRoleResponse{}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&BindRoleBody{
+				Tenant: "test",
+				Role:   "test",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := withOrganization(httptest.NewRequest(http.MethodPost, "/proxy/tenant/bind/", bytes.NewReader(payload)), "org")
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusForbidden {
+				t.Errorf("expected status code %d, got %d", http.StatusForbidden, code)
+			}
+		})
+		t.Run("binds a role to a tenant in the admin organization", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{
+				GetTenantFn: func(_ context.Context, _ *pb.GetTenantRequest, _ ...grpc.CallOption) (*pb.Tenant, error) {
+					return &pb.Tenant{Name: "test", Organization: "org"}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&BindRoleBody{
+				Tenant: "test",
+				Role:   "test",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := withOrganization(httptest.NewRequest(http.MethodPost, "/proxy/tenant/bind/", bytes.NewReader(payload)), "org")
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusCreated {
+				t.Errorf("expected status code %d, got %d", http.StatusCreated, code)
+			}
+		})
+		t.Run("lists the tenants of the admin organization", func(t *testing.T) {
+			var gotReq *pb.ListTenantRequest
+			client := &mocks.FakeTenantServiceClient{
+				ListTenantFn: func(_ context.Context, req *pb.ListTenantRequest, _ ...grpc.CallOption) (*pb.ListTenantResponse, error) {
+					gotReq = req
+					return &pb.ListTenantResponse{}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := withOrganization(httptest.NewRequest(http.MethodGet, "/proxy/tenant/?organization=other", nil), "org")
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusOK {
+				t.Errorf("expected status code %d, got %d", http.StatusOK, code)
+			}
+			if gotReq == nil || gotReq.Organization != "org" {
+				t.Errorf("expected tenants of organization org to be listed, got %v", gotReq)
+			}
+		})
+		t.Run("refuses to manage organizations", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := withOrganization(httptest.NewRequest(http.MethodGet, "/proxy/tenant/organization/", nil), "org")
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusForbidden {
+				t.Errorf("expected status code %d, got %d", http.StatusForbidden, code)
+			}
+		})
+	})
+	t.Run("it handles organizations", func(t *testing.T) {
+		t.Run("successfully creates an organization", func(t *testing.T) {
+			var gotReq *pb.CreateOrganizationRequest
+			client := &mocks.FakeTenantServiceClient{
+				CreateOrganizationFn: func(_ context.Context, req *pb.CreateOrganizationRequest, _ ...grpc.CallOption) (*pb.Organization, error) {
+					gotReq = req
+					return req.Organization, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			payload, err := json.Marshal(&OrganizationBody{
+				Organization: "org",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/proxy/tenant/organization/", bytes.NewReader(payload))
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusCreated {
+				t.Errorf("expected status code %d, got %d", http.StatusCreated, code)
+			}
+			if gotReq == nil || gotReq.Organization.Name != "org" {
+				t.Errorf("expected organization org to be created, got %v", gotReq)
+			}
+		})
+		t.Run("successfully gets an organization", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{
+				GetOrganizationFn: func(_ context.Context, _ *pb.GetOrganizationRequest, _ ...grpc.CallOption) (*pb.Organization, error) {
+					return &pb.Organization{Name: "org", Tenants: []string{"test"}}, nil
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/organization/?name=org", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusOK {
+				t.Errorf("expected status code %d, got %d", http.StatusOK, code)
+			}
+			var got pb.Organization
+			if err := protojson.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != "org" || !reflect.DeepEqual(got.Tenants, []string{"test"}) {
+				t.Errorf("expected organization org with tenant test, got %v", &got)
+			}
+		})
+		t.Run("successfully deletes an organization", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodDelete, "/proxy/tenant/organization/?name=org", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			code := w.Result().StatusCode
+			if code != http.StatusNoContent {
+				t.Errorf("expected status code %d, got %d", http.StatusNoContent, code)
+			}
+		})
+		t.Run("handles error from tenant service", func(t *testing.T) {
+			client := &mocks.FakeTenantServiceClient{
+				DeleteOrganizationFn: func(_ context.Context, _ *pb.DeleteOrganizationRequest, _ ...grpc.CallOption) (*pb.DeleteOrganizationResponse, error) {
+					return nil, errors.New("error")
+				},
+			}
+
+			sut := NewTenantHandler(logrus.NewEntry(logrus.New()), client)
+
+			r := httptest.NewRequest(http.MethodDelete, "/proxy/tenant/organization/?name=org", nil)
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
 			code := w.Result().StatusCode
 			if code != http.StatusInternalServerError {
 				t.Errorf("expected status code %d, got %d", http.StatusInternalServerError, code)
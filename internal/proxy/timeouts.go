@@ -0,0 +1,135 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Backend operations that can be given their own timeout.
+const (
+	OperationCreate = "create"
+	OperationDelete = "delete"
+	OperationMap    = "map"
+	OperationQuery  = "query"
+)
+
+// Duration is a time.Duration that is configured as a string such as "30s".
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string, or a number of seconds.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		*d = Duration(v * float64(time.Second))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("parsing timeout: %w", err)
+		}
+		*d = Duration(parsed)
+	default:
+		return fmt.Errorf("invalid timeout %s", b)
+	}
+	return nil
+}
+
+// MarshalJSON formats the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Timeouts bound the calls made to a storage system for a request, including
+// the lookups the proxy makes before forwarding it. An operation without a
+// timeout uses Default; without either, calls are bounded only by the
+// proxy-server timeouts.
+type Timeouts struct {
+	Default Duration `json:"default,omitempty"`
+	Create  Duration `json:"create,omitempty"`
+	Delete  Duration `json:"delete,omitempty"`
+	Map     Duration `json:"map,omitempty"`
+	Query   Duration `json:"query,omitempty"`
+}
+
+// For returns the timeout of the operation, or zero for none.
+func (t Timeouts) For(op string) time.Duration {
+	var d Duration
+	switch op {
+	case OperationCreate:
+		d = t.Create
+	case OperationDelete:
+		d = t.Delete
+	case OperationMap:
+		d = t.Map
+	case OperationQuery:
+		d = t.Query
+	}
+	if d == 0 {
+		d = t.Default
+	}
+	return time.Duration(d)
+}
+
+// withBackendTimeout returns the request with a context that ends after the
+// timeout of its operation on the storage system.
+func withBackendTimeout(r *http.Request, t Timeouts, storageType string) (*http.Request, context.CancelFunc) {
+	d := t.For(backendOperation(r, storageType))
+	if d <= 0 {
+		return r, func() {}
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), d)
+	return r.WithContext(ctx), cancel
+}
+
+// mapPaths are the path fragments of the requests that map volumes to hosts.
+var mapPaths = map[string][]string{
+	"powerflex":  {"/action/addMappedSdc/", "/action/removeMappedSdc/", "/action/addMappedHost/", "/action/removeMappedHost/"},
+	"powermax":   {"/maskingview/", "/host/", "/hostgroup/", "/portgroup/"},
+	"powerscale": {"/protocols/nfs/exports"},
+}
+
+// backendOperation classifies the request to a storage system.
+func backendOperation(r *http.Request, storageType string) string {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return OperationQuery
+	case http.MethodDelete:
+		return OperationDelete
+	}
+
+	for _, p := range mapPaths[storageType] {
+		if strings.Contains(r.URL.Path, p) {
+			return OperationMap
+		}
+	}
+	if storageType == "powerflex" {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/action/removeVolume/"):
+			return OperationDelete
+		case strings.HasPrefix(r.URL.Path, "/api/types/") && strings.Contains(r.URL.Path, "/action/query"):
+			return OperationQuery
+		}
+	}
+	return OperationCreate
+}
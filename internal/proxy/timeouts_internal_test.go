@@ -0,0 +1,99 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeouts(t *testing.T) {
+	t.Run("it decodes the timeouts of a system", func(t *testing.T) {
+		var got SystemEntry
+		err := json.Unmarshal([]byte(`{"endpoint": "https://10.0.0.1", "timeouts": {"default": "30s", "create": "2m", "query": 5}}`), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := Timeouts{Default: Duration(30 * time.Second), Create: Duration(2 * time.Minute), Query: Duration(5 * time.Second)}
+		if got.Timeouts != want {
+			t.Errorf("got %+v, want %+v", got.Timeouts, want)
+		}
+	})
+
+	t.Run("it rejects an invalid timeout", func(t *testing.T) {
+		var got Timeouts
+		if err := json.Unmarshal([]byte(`{"create": "soon"}`), &got); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("it falls back to the default timeout", func(t *testing.T) {
+		timeouts := Timeouts{Default: Duration(time.Minute), Map: Duration(time.Second)}
+		if got := timeouts.For(OperationMap); got != time.Second {
+			t.Errorf("got %v, want %v", got, time.Second)
+		}
+		if got := timeouts.For(OperationDelete); got != time.Minute {
+			t.Errorf("got %v, want %v", got, time.Minute)
+		}
+		if got := (Timeouts{}).For(OperationCreate); got != 0 {
+			t.Errorf("got %v, want no timeout", got)
+		}
+	})
+
+	t.Run("it classifies backend operations", func(t *testing.T) {
+		tests := []struct {
+			storageType, method, path, want string
+		}{
+			{"powerflex", http.MethodPost, "/api/types/Volume/instances/", OperationCreate},
+			{"powerflex", http.MethodPost, "/api/types/Volume/instances/action/queryIdByKey/", OperationQuery},
+			{"powerflex", http.MethodPost, "/api/instances/Volume::1/action/removeVolume/", OperationDelete},
+			{"powerflex", http.MethodPost, "/api/instances/Volume::1/action/addMappedSdc/", OperationMap},
+			{"powerflex", http.MethodPost, "/api/instances/Volume::1/action/removeMappedHost/", OperationMap},
+			{"powerflex", http.MethodGet, "/api/types/StoragePool/instances/", OperationQuery},
+			{"powermax", http.MethodPut, "/univmax/restapi/100/sloprovisioning/symmetrix/1/storagegroup/sg/", OperationCreate},
+			{"powermax", http.MethodPost, "/univmax/restapi/100/sloprovisioning/symmetrix/1/maskingview/", OperationMap},
+			{"powermax", http.MethodDelete, "/univmax/restapi/100/sloprovisioning/symmetrix/1/volume/1/", OperationDelete},
+			{"powerscale", http.MethodPost, "/platform/2/protocols/nfs/exports", OperationMap},
+			{"powerscale", http.MethodPut, "/namespace/ifs/data/vol", OperationCreate},
+		}
+		for _, tt := range tests {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if got := backendOperation(r, tt.storageType); got != tt.want {
+				t.Errorf("%s %s %s: got %q, want %q", tt.storageType, tt.method, tt.path, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("it bounds the request context", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodDelete, "/api/instances/Volume::1/", nil)
+		r, cancel := withBackendTimeout(r, Timeouts{Delete: Duration(time.Minute)}, "powerflex")
+		defer cancel()
+
+		deadline, ok := r.Context().Deadline()
+		if !ok || time.Until(deadline) > time.Minute {
+			t.Errorf("got deadline %v, want within a minute", deadline)
+		}
+
+		r, cancel = withBackendTimeout(httptest.NewRequest(http.MethodGet, "/", nil), Timeouts{}, "powerflex")
+		defer cancel()
+		if _, ok := r.Context().Deadline(); ok {
+			t.Error("expected no deadline")
+		}
+	})
+}
@@ -0,0 +1,170 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"karavi-authorization/internal/decision"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// URLRule allows the requests with the method, or with any method if it is
+// empty, to the paths that match the regular expression.
+type URLRule struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// DefaultURLRules are the storage API requests of the CSI drivers that the
+// URL policy allows, by system type. Requests to system types without rules
+// are all allowed.
+var DefaultURLRules = map[string][]URLRule{
+	"powerflex": {
+		{Method: http.MethodGet, Path: `^/api/login/$`},
+		{Method: http.MethodGet, Path: `^/api/version/$`},
+		{Method: http.MethodGet, Path: `^/api/types/[A-Za-z]+/instances/$`},
+		{Method: http.MethodPost, Path: `^/api/types/[A-Za-z]+/instances/action/queryIdByKey/$`},
+		{Method: http.MethodPost, Path: `^/api/types/Volume/instances/$`},
+		{Method: http.MethodGet, Path: `^/api/instances/[A-Za-z]+::[a-f0-9]+/$`},
+		{Method: http.MethodGet, Path: `^/api/instances/[A-Za-z]+::[a-f0-9]+/relationships/[A-Za-z]+/$`},
+		{Method: http.MethodPost, Path: `^/api/instances/Volume::[a-f0-9]+/action/(addMappedSdc|removeMappedSdc|addMappedHost|removeMappedHost|setMappedSdcLimits|removeVolume|setVolumeName|setVolumeSize)/$`},
+		{Method: http.MethodPost, Path: `^/api/instances/System(::[a-f0-9]+)?/action/(snapshotVolumes|approveSdc)/$`},
+	},
+}
+
+type urlRule struct {
+	method string
+	path   *regexp.Regexp
+}
+
+// URLPolicy is the allow-list of the storage API requests that the proxy
+// forwards to the storage systems. It is evaluated natively with the
+// compiled rules unless the OPA override is enabled and OPA has a decision.
+type URLPolicy struct {
+	log         *logrus.Entry
+	rules       map[string][]urlRule
+	opaOverride bool
+	opaHost     hostAddr
+}
+
+// NewURLPolicy returns the URL policy of the rules of each system type. It
+// returns an error if a path is not a valid regular expression.
+func NewURLPolicy(log *logrus.Entry, rules map[string][]URLRule) (*URLPolicy, error) {
+	p := &URLPolicy{
+		log:   log,
+		rules: make(map[string][]urlRule),
+	}
+	for systemType, rr := range rules {
+		for _, r := range rr {
+			re, err := regexp.Compile(r.Path)
+			if err != nil {
+				return nil, fmt.Errorf("url rule %s %s of %s: %w", r.Method, r.Path, systemType, err)
+			}
+			p.rules[systemType] = append(p.rules[systemType], urlRule{method: strings.ToUpper(r.Method), path: re})
+		}
+	}
+	return p, nil
+}
+
+// EnableOPAOverride asks the karavi.authz.url policy of OPA at the host
+// first. Its decision overrides the rules when the policy is loaded.
+func (p *URLPolicy) EnableOPAOverride(opaHost string) {
+	p.opaOverride = true
+	p.opaHost.Set(opaHost)
+}
+
+// SetOPAHost changes the OPA host asked for the OPA override.
+func (p *URLPolicy) SetOPAHost(opaHost string) {
+	p.opaHost.Set(opaHost)
+}
+
+// Allowed reports whether the request to a system of the system type is
+// allowed.
+func (p *URLPolicy) Allowed(ctx context.Context, systemType string, r *http.Request) (bool, error) {
+	if p.opaOverride {
+		allowed, ok, err := p.askOPA(ctx, systemType, r)
+		if err != nil || ok {
+			return allowed, err
+		}
+	}
+
+	rules, ok := p.rules[systemType]
+	if !ok {
+		return true, nil
+	}
+	for _, rule := range rules {
+		if (rule.method == "" || rule.method == r.Method) && rule.path.MatchString(r.URL.Path) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// askOPA returns the decision of the karavi.authz.url policy of OPA, and
+// false if the policy is not loaded.
+func (p *URLPolicy) askOPA(ctx context.Context, systemType string, r *http.Request) (bool, bool, error) {
+	ans, err := decision.CanWithContext(ctx, func() decision.Query {
+		return decision.Query{
+			Host:   p.opaHost.Get(),
+			Policy: "/karavi/authz/url",
+			Input: map[string]interface{}{
+				"method":     r.Method,
+				"url":        r.URL.Path,
+				"systemtype": systemType,
+			},
+		}
+	})
+	if err != nil {
+		return false, false, fmt.Errorf("asking OPA for url decision: %w", err)
+	}
+
+	var resp struct {
+		Result *struct {
+			Allow bool `json:"allow"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(ans, &resp); err != nil {
+		return false, false, fmt.Errorf("decoding OPA url decision: %w", err)
+	}
+	if resp.Result == nil {
+		return false, false, nil
+	}
+	return resp.Result.Allow, true, nil
+}
+
+// Handler forwards the allowed requests to systems of the system type to
+// next, and denies the others.
+func (p *URLPolicy) Handler(systemType string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, err := p.Allowed(r.Context(), systemType, r)
+		if err != nil {
+			p.log.WithError(err).Error("evaluating url policy")
+			writeError(w, systemType, "failed to evaluate url policy", http.StatusInternalServerError, p.log)
+			return
+		}
+		if !allowed {
+			reason := fmt.Sprintf("%s %s is not allowed", r.Method, r.URL.Path)
+			tenant, _ := r.Context().Value(web.JWTTenantName).(string)
+			writeDenied(w, systemType, "request denied: "+reason, http.StatusForbidden, web.Deny{Code: web.CodePolicyDenied, Reason: reason, Tenant: tenant}, p.log)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
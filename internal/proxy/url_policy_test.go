@@ -0,0 +1,135 @@
+// Copyright © 2024 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_test
+
+import (
+	"context"
+	"karavi-authorization/internal/proxy"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestURLPolicy(t *testing.T) {
+	ctx := context.Background()
+	log := logrus.New().WithContext(ctx)
+
+	allowed := func(t *testing.T, p *proxy.URLPolicy, systemType, method, path string) bool {
+		t.Helper()
+		ok, err := p.Allowed(ctx, systemType, httptest.NewRequest(method, path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+
+	t.Run("it allows the requests of the default rules", func(t *testing.T) {
+		p, err := proxy.NewURLPolicy(log, proxy.DefaultURLRules)
+		checkError(t, err)
+
+		tests := []struct {
+			method, path string
+			want         bool
+		}{
+			{http.MethodGet, "/api/version/", true},
+			{http.MethodGet, "/api/types/StoragePool/instances/", true},
+			{http.MethodPost, "/api/types/Volume/instances/", true},
+			{http.MethodGet, "/api/instances/Volume::3df6b86600000000/", true},
+			{http.MethodPost, "/api/instances/Volume::3df6b86600000000/action/addMappedSdc/", true},
+			{http.MethodPost, "/api/instances/Volume::3df6b86600000000/action/addMappedHost/", true},
+			{http.MethodPost, "/api/instances/System::542a2d5f5122210f/action/snapshotVolumes/", true},
+			{http.MethodPost, "/api/version/", false},
+			{http.MethodPost, "/api/instances/System::542a2d5f5122210f/action/removeSystem/", false},
+			{http.MethodDelete, "/api/instances/Volume::3df6b86600000000/", false},
+		}
+		for _, tt := range tests {
+			if got := allowed(t, p, "powerflex", tt.method, tt.path); got != tt.want {
+				t.Errorf("%s %s: got %v, want %v", tt.method, tt.path, got, tt.want)
+			}
+		}
+	})
+	t.Run("it allows the requests of system types without rules", func(t *testing.T) {
+		p, err := proxy.NewURLPolicy(log, proxy.DefaultURLRules)
+		checkError(t, err)
+
+		if !allowed(t, p, "powerscale", http.MethodDelete, "/namespace/ifs/data/") {
+			t.Error("expected the request to be allowed")
+		}
+	})
+	t.Run("it matches any method for rules without one", func(t *testing.T) {
+		p, err := proxy.NewURLPolicy(log, map[string][]proxy.URLRule{"powermax": {{Path: "^/univmax/restapi/version/$"}}})
+		checkError(t, err)
+
+		if !allowed(t, p, "powermax", http.MethodHead, "/univmax/restapi/version/") {
+			t.Error("expected the request to be allowed")
+		}
+	})
+	t.Run("it rejects invalid rules", func(t *testing.T) {
+		_, err := proxy.NewURLPolicy(log, map[string][]proxy.URLRule{"powerflex": {{Method: http.MethodGet, Path: "^/api/(version/$"}}})
+		if err == nil {
+			t.Error("expected non-nil error")
+		}
+	})
+	t.Run("it asks OPA first with the override", func(t *testing.T) {
+		decision := `{"result": {"allow": false}}`
+		fakeOPA := buildTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/data/karavi/authz/url" {
+				t.Errorf("unexpected OPA request %s", r.URL.Path)
+			}
+			w.Write([]byte(decision))
+		}))
+		p, err := proxy.NewURLPolicy(log, proxy.DefaultURLRules)
+		checkError(t, err)
+		p.EnableOPAOverride(hostPort(t, fakeOPA.URL))
+
+		if allowed(t, p, "powerflex", http.MethodGet, "/api/version/") {
+			t.Error("expected OPA to deny the request")
+		}
+		decision = `{"result": {"allow": true}}`
+		if !allowed(t, p, "powerflex", http.MethodDelete, "/api/instances/Volume::3df6b86600000000/") {
+			t.Error("expected OPA to allow the request")
+		}
+		decision = `{}`
+		if allowed(t, p, "powerflex", http.MethodDelete, "/api/instances/Volume::3df6b86600000000/") {
+			t.Error("expected the rules to deny the request without an OPA policy")
+		}
+	})
+	t.Run("it denies requests through the dispatch handler", func(t *testing.T) {
+		p, err := proxy.NewURLPolicy(log, proxy.DefaultURLRules)
+		checkError(t, err)
+		var forwarded int
+		systems := map[string]http.Handler{
+			"powerflex": http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+				forwarded++
+			}),
+		}
+		h := proxy.NewDispatchHandler(log, systems, proxy.WithURLPolicy(p))
+
+		for _, method := range []string{http.MethodGet, http.MethodDelete} {
+			r := httptest.NewRequest(method, "/api/version/", nil)
+			r.Header.Add("Forwarded", "by=csm-authorization;csi-vxflexos")
+			r.Header.Add("Forwarded", "for=csm-authorization;https://10.0.0.1;1b2e5a7c9d3f4a6b")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if method == http.MethodDelete && w.Code != http.StatusForbidden {
+				t.Errorf("got status %d, want %d", w.Code, http.StatusForbidden)
+			}
+		}
+		if forwarded != 1 {
+			t.Errorf("got %d forwarded requests, want 1", forwarded)
+		}
+	})
+}
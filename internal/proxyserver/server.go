@@ -0,0 +1,1676 @@
+// Copyright © 2021-2023 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package proxyserver runs the CSM Authorization proxy-server.
+package proxyserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"karavi-authorization/internal/appconfig"
+	"karavi-authorization/internal/decision"
+	"karavi-authorization/internal/envelope"
+	"karavi-authorization/internal/eventsvc"
+	"karavi-authorization/internal/k8s"
+	"karavi-authorization/internal/mounts"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/quota"
+	"karavi-authorization/internal/role-service"
+	"karavi-authorization/internal/role-service/roles"
+	"karavi-authorization/internal/sdc"
+	"karavi-authorization/internal/storage-service"
+	"karavi-authorization/internal/tenantsvc"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/token/jwx"
+	"karavi-authorization/internal/token/session"
+	"karavi-authorization/internal/usage"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-redis/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	authenticationclient "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	configParamJWTSigningScrt = "web.jwtsigningsecret"
+	configParamOPAHost        = "openpolicyagent.host"
+	configParamOPAShadowHost  = "openpolicyagent.shadow.host"
+	configParamOPAShadowPct   = "openpolicyagent.shadow.percent"
+	configParamDatabaseHost   = "database.host"
+	configParamDatabasePass   = "database.password"
+	storageSystemsPath        = "/etc/karavi-authorization/storage/storage-systems.yaml"
+	csmConfigParamsDir        = "/etc/karavi-authorization/csm-config-params/"
+	namespaceEnv              = "NAMESPACE"
+	podNameEnv                = "POD_NAME"
+	defaultNamespace          = "karavi"
+	leaderElectionLease       = "proxy-server-leader"
+)
+
+var (
+	// build is to be set via build flags in the makefile.
+	build = "develop"
+	cfg   Config
+	// JWTSigningSecret is the secret string used to sign JWT tokens
+	JWTSigningSecret = "secret"
+)
+
+func init() {
+	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec G402
+}
+
+// Options are the settings of a proxy-server that are not in its
+// configuration file, e.g. from command line flags. Empty fields keep the
+// configured value.
+type Options struct {
+	// RedisHost is the address of redis.
+	RedisHost string
+	// TenantService, RoleService and StorageService are the addresses of
+	// the backend services.
+	TenantService  string
+	RoleService    string
+	StorageService string
+	// ConfigFile is the path of the configuration file and CSMConfigFile
+	// that of the csm-config-params file, e.g. of the log level.
+	ConfigFile    string
+	CSMConfigFile string
+	// StorageSystemsFile is the path of the storage systems file that is
+	// watched when the Kubernetes API is unavailable.
+	StorageSystemsFile string
+	// DialOptions are added to the connections to the backend services,
+	// e.g. to dial them in-process.
+	DialOptions []grpc.DialOption
+}
+
+// Listener is an address the proxy is served on.
+type Listener struct {
+	// Host is the address to listen on, e.g. "[::]:8443".
+	Host string
+	// TLSCertFile and TLSKeyFile are the key pair the listener serves TLS
+	// with. Without them it serves plain HTTP.
+	TLSCertFile string
+	TLSKeyFile  string
+	// Scope is which routes the listener serves: web.ScopeAll, the
+	// default, web.ScopeAdmin or web.ScopeData. Admin listeners only accept
+	// admin tokens, and once there is one the admin routes are no longer
+	// served on the listeners of proxy.host and proxy.tlshost.
+	Scope string
+	// AllowedCIDRs are the networks the listener accepts requests from,
+	// e.g. ["10.0.0.0/8"]. It accepts all requests without them.
+	AllowedCIDRs []string
+}
+
+type roleClientService struct {
+	roleService *role.Service
+	roleClient  pb.RoleServiceClient
+}
+
+type storageClientService struct {
+	storageService *storage.Service
+	storageClient  pb.StorageServiceClient
+}
+
+// Config is the configuration details on the proxy-server
+type Config struct {
+	Version     string
+	Zipkin      appconfig.Zipkin
+	Certificate struct {
+		CrtFile         string
+		KeyFile         string
+		RootCertificate string
+	}
+	Proxy struct {
+		Host         string
+		ReadTimeout  time.Duration
+		WriteTimeout time.Duration
+		// FilteredPaths are the path patterns of the PowerFlex list
+		// endpoints whose responses only list the tenant's volumes.
+		FilteredPaths []string
+		// URLPolicy is the allow-list of the storage API requests, as
+		// rules of a method and a path regular expression by system type.
+		// With OPAOverride, the karavi.authz.url policy of OPA decides
+		// instead when it is loaded.
+		URLPolicy struct {
+			Enabled     bool
+			OPAOverride bool
+			Rules       map[string][]proxy.URLRule
+		}
+		// Cache reuses the responses of read-only array endpoints, e.g.
+		// when the CSI drivers restart at once, for a short TTL.
+		Cache struct {
+			Enabled bool
+			TTL     time.Duration
+			// Paths are the path patterns of the cached endpoints.
+			Paths []string
+		}
+		// Maintenance is the response to the requests that change a
+		// storage system paused with karavictl storage pause: the status
+		// code, which the CSI drivers must retry on, and the Retry-After of
+		// the pauses that do not set their own.
+		Maintenance struct {
+			StatusCode int
+			RetryAfter time.Duration
+		}
+		// PowerScale is the authorization of the NFS exports and SMB
+		// shares: with EnforceShareOwnership, tenants may only manage the
+		// exports and shares of the directories they created.
+		PowerScale struct {
+			EnforceShareOwnership bool
+		}
+		// ForwardedSchemes are the schemes the storage system endpoints
+		// in the Forwarded header of the sidecar proxies may have.
+		ForwardedSchemes []string
+		// SidecarPorts is the range of the ports allocated to the
+		// listeners of the sidecar proxies that request them, and how
+		// many of them each tenant may request.
+		SidecarPorts struct {
+			Start        int
+			End          int
+			MaxPerCaller int
+		}
+		// TLSHost is an address on which the proxy is also served with
+		// TLS, using TLSCertFile and TLSKeyFile, for when it is exposed
+		// without an ingress in front of it.
+		TLSHost     string
+		TLSCertFile string
+		TLSKeyFile  string
+		// Listeners are further addresses the proxy is served on, e.g.
+		// to listen on both IPv4 and IPv6, or to serve the admin REST API
+		// apart from the CSI data path.
+		Listeners []Listener
+	}
+	Web struct {
+		ShowDebugHTTP    bool
+		DebugHost        string
+		ShutdownTimeout  time.Duration
+		JWTSigningSecret string
+		// JWTSigningAlgorithm is HS256, HS512 or RS256; tokens signed
+		// with any other algorithm are refused. With RS256, the signing
+		// secret is a PEM encoded RSA private key. It must be the same
+		// for the tenant-service.
+		JWTSigningAlgorithm string
+		JWTIssuer           string
+		JWTAudience         string
+		// JWTEncryptionKey, when set, encrypts the tokens of tenants so
+		// that their claims are hidden from the CSI driver side. It must
+		// be the same for the tenant-service.
+		JWTEncryptionKey string
+		// LegacyTokensUntil is the end, in RFC 3339, of the upgrade window
+		// in which tokens in the legacy format are accepted. They are not
+		// accepted if it is empty.
+		LegacyTokensUntil string
+		CORS              struct {
+			AllowedOrigins   []string
+			AllowedMethods   []string
+			AllowedHeaders   []string
+			ExposedHeaders   []string
+			AllowCredentials bool
+			MaxAge           time.Duration
+		}
+		SecurityHeaders  map[string]string
+		ReplayProtection struct {
+			Enabled bool
+			// Window is how far the time of a request may be from the
+			// time of the proxy-server.
+			Window time.Duration
+			// Paths are the path prefixes of the protected endpoints.
+			Paths []string
+		}
+		// AdminSessions tracks the refresh tokens of admins so they can
+		// be listed and revoked. Zero durations disable the limit.
+		AdminSessions struct {
+			Enabled     bool
+			Lifetime    time.Duration
+			IdleTimeout time.Duration
+		}
+	}
+	Database struct {
+		Host     string
+		Password string
+	}
+	// Services are the addresses of the backend services, as host:port or
+	// as k8s://[namespace/]name[:port] to look up the Kubernetes Service,
+	// e.g. one of type ExternalName, instead of relying on cluster DNS.
+	Services struct {
+		Tenant  string
+		Role    string
+		Storage string
+	}
+	OpenPolicyAgent struct {
+		Host string
+		// Shadow mirrors a percentage of the queries to a secondary OPA
+		// instance and logs the decisions that differ.
+		Shadow struct {
+			Host    string
+			Percent float64
+		}
+		// RolloutSyncInterval is how often the rollout started with
+		// karavictl rollout is synced from redis.
+		RolloutSyncInterval time.Duration
+	}
+	Storage struct {
+		MasterKeyFile string
+	}
+	Quota struct {
+		// GracePeriod is how long a tenant may use more than the soft
+		// quota of a role before requests beyond it are denied. Requests
+		// beyond the soft quota are always approved up to the quota when
+		// it is 0.
+		GracePeriod time.Duration
+		// Compaction periodically removes the fields of the volumes that
+		// were deleted longer than DeletedRetention ago and trims the
+		// quota streams to their latest StreamMaxLen entries.
+		Compaction proxy.CompactionConfig
+	}
+	Login proxy.LoginConfig
+	// Bootstrap accepts cluster-scoped bootstrap tokens, with which CSI
+	// drivers are onboarded as tenants that are created on first use.
+	Bootstrap proxy.BootstrapConfig
+	// Observer lets trusted in-cluster services, e.g. the CSM
+	// observability modules, read from the storage systems with the
+	// tokens of their Kubernetes service accounts.
+	Observer proxy.ObserverConfig
+	Events   struct {
+		// Enabled publishes quota and policy denials as Kubernetes Events
+		// on the persistent volume claims of the denied requests.
+		Enabled bool
+	}
+	// UsageExport periodically exports a snapshot of the capacity approved
+	// for every tenant, as a CSV file in CSV.Dir for chargeback or to the
+	// Prometheus remote-write endpoint at RemoteWrite.URL. It is disabled
+	// when Interval is 0.
+	UsageExport struct {
+		Interval time.Duration
+		// Format is either csv or prometheus.
+		Format string
+		CSV    struct {
+			Dir string
+		}
+		RemoteWrite struct {
+			URL     string
+			Timeout time.Duration
+		}
+	}
+}
+
+// Run runs the proxy-server until it is interrupted or fails.
+func Run(log *logrus.Entry, opts Options) error {
+	appCfg := newConfig(log, opts.ConfigFile)
+	if err := appCfg.Read(opts.ConfigFile == ""); err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if err := appCfg.Decode(&cfg); err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	web.JWTSigningSecret = cfg.Web.JWTSigningSecret
+	JWTSigningSecret = cfg.Web.JWTSigningSecret
+	legacyUntil, err := jwx.LegacyWindowEnd(cfg.Web.LegacyTokensUntil)
+	if err != nil {
+		return err
+	}
+	if !legacyUntil.IsZero() {
+		log.WithField("until", legacyUntil.Format(time.RFC3339)).Info("main: accepting tokens in the legacy format")
+	}
+	signingAlg, err := jwx.ParseSignatureAlgorithm(cfg.Web.JWTSigningAlgorithm)
+	if err != nil {
+		return err
+	}
+	log.WithField("algorithm", signingAlg).Info("main: signing tokens")
+	tokenOpts := []jwx.Option{jwx.WithSigningAlgorithm(signingAlg), jwx.WithIssuer(cfg.Web.JWTIssuer), jwx.WithAudience(cfg.Web.JWTAudience), jwx.WithLegacyTokensUntil(legacyUntil), jwx.WithEncryptionKey(cfg.Web.JWTEncryptionKey)}
+	tokenManager := jwx.NewTokenManager(signingAlg, tokenOpts...)
+	if cfg.Web.JWTEncryptionKey != "" {
+		log.Info("main: encrypting tokens")
+	}
+
+	if err := mounts.Check(requiredMounts(cfg, opts)...); err != nil {
+		return fmt.Errorf("checking mounts: %w", err)
+	}
+
+	csmCfg, err := appconfig.Load(log, appconfig.Source{
+		Name:  "csm-config-params",
+		Paths: []string{csmConfigParamsDir},
+		File:  opts.CSMConfigFile,
+	}, nil)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	csmCfg.WatchLogging(log, appconfig.DefaultLogKeys)
+
+	// Initializing application
+
+	cfg.Version = build
+	expvar.NewString("build").Set(build)
+
+	log.Infof("main: started application version %q", build)
+	defer log.Info("main: stopped application")
+
+	// Initialize authentication
+
+	// Initialize OPA
+
+	setOPAShadow(log)
+
+	// Initialize database connections
+
+	redisAddr := cfg.Database.Host
+	if opts.RedisHost != "" {
+		redisAddr = opts.RedisHost
+	}
+
+	conns := newConnections(log, cfg.OpenPolicyAgent.Host, redisAddr, cfg.Database.Password, cfg.Proxy.WriteTimeout)
+	conns.redisFlag = opts.RedisHost
+	defer func() {
+		if err := conns.Close(); err != nil {
+			log.WithError(err).Warn("closing redis")
+		}
+	}()
+	rdb := conns.Redis()
+	enf := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rdb),
+		quota.WithGracePeriod(cfg.Quota.GracePeriod),
+		quota.WithSoftQuotaExceeded(proxy.SoftQuotaExceeded(log, &eventsvc.Publisher{Redis: conns.Redis, Log: log})))
+	sdcapr := sdc.NewSdcApprover(context.Background(), sdc.WithRedis(rdb))
+	conns.redisClients = append(conns.redisClients, enf, sdcapr)
+
+	appCfg.Watch(func() {
+		updateConfiguration(appCfg.Viper(), log, conns)
+	})
+
+	// Start tracing support
+
+	tp, err := appconfig.InitTracing(log, cfg.Zipkin, "csm-authorization-proxy-server")
+	if err != nil {
+		return err
+	}
+
+	// Start debug service
+	log.Info("main: initializing debugging support")
+
+	// Default prometheus metrics, and those of the proxy handlers
+	prometheus.MustRegister(proxy.Collectors()...)
+	http.Handle("/metrics", promhttp.Handler())
+
+	go func() {
+		expvar.Publish("goroutines", expvar.Func(func() interface{} {
+			return fmt.Sprintf("%d", runtime.NumGoroutine())
+		}))
+		log.WithField("debug host", cfg.Web.DebugHost).Debug("main: debug listening")
+		s := http.Server{
+			Addr:              cfg.Web.DebugHost,
+			Handler:           http.DefaultServeMux,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		if err := s.ListenAndServe(); err != nil {
+			log.WithError(err).Warn("main: debug listener closed")
+		}
+	}()
+
+	// Load the master key of the storage system passwords, if they are
+	// encrypted.
+	var storageKeys envelope.KeyWrapper
+	if cfg.Storage.MasterKeyFile != "" {
+		key, err := envelope.LoadMasterKey(cfg.Storage.MasterKeyFile)
+		if err != nil {
+			return err
+		}
+		storageKeys = key
+	}
+
+	k8sAPI := &k8s.API{
+		Namespace: namespace(),
+		Log:       log,
+	}
+
+	tenantAddr, err := serviceAddr(k8sAPI, opts.TenantService, cfg.Services.Tenant)
+	if err != nil {
+		return err
+	}
+	roleAddr, err := serviceAddr(k8sAPI, opts.RoleService, cfg.Services.Role)
+	if err != nil {
+		return err
+	}
+	storageAddr, err := serviceAddr(k8sAPI, opts.StorageService, cfg.Services.Storage)
+	if err != nil {
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"tenant":  tenantAddr,
+		"role":    roleAddr,
+		"storage": storageAddr,
+	}).Info("main: connecting to services")
+
+	dialOpts := append([]grpc.DialOption{
+		grpc.WithTimeout(10 * time.Second),
+		grpc.WithInsecure(),
+		grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()),
+	}, opts.DialOptions...)
+
+	tenantConn, err := grpc.Dial(tenantAddr, dialOpts...)
+	if err != nil {
+		return err
+	}
+	defer tenantConn.Close()
+
+	roleConn, err := grpc.Dial(roleAddr, dialOpts...)
+	if err != nil {
+		return err
+	}
+	defer roleConn.Close()
+
+	storageConn, err := grpc.Dial(storageAddr, dialOpts...)
+	if err != nil {
+		return err
+	}
+	defer storageConn.Close()
+
+	// Create handlers for the supported storage arrays.
+	powerFlexHandler := proxy.NewPowerFlexHandler(log, enf, sdcapr, cfg.OpenPolicyAgent.Host)
+	powerFlexHandler.SetFilteredPaths(cfg.Proxy.FilteredPaths)
+	if cfg.Proxy.Cache.Enabled {
+		powerFlexHandler.SetCachedPaths(cfg.Proxy.Cache.Paths, cfg.Proxy.Cache.TTL)
+	}
+	powerMaxHandler := proxy.NewPowerMaxHandler(log, enf, cfg.OpenPolicyAgent.Host)
+	powerMaxHandler.SetSdcApprover(sdcapr)
+	powerScaleHandler := proxy.NewPowerScaleHandler(log, enf, cfg.OpenPolicyAgent.Host)
+	powerScaleHandler.SetOwners(proxy.NewPowerScaleOwners(log, conns.Redis), cfg.Proxy.PowerScale.EnforceShareOwnership)
+	conns.opaClients = append(conns.opaClients, powerFlexHandler, powerMaxHandler, powerScaleHandler)
+
+	// Pass HTTP/2 requests, e.g. gRPC calls, through to the arrays,
+	// authorizing each stream against the roles of the tenant.
+	passthroughHandler := proxy.NewPassthroughHandler(log, roleStreamAuthorizer(pb.NewRoleServiceClient(roleConn)))
+	// Apply the header policies of the systems to the requests forwarded to them.
+	headerPolicies := proxy.NewHeaderPolicies()
+	// Allocate the ports of the sidecar listeners for the configured systems.
+	portHandler, err := proxy.NewPortHandler(log, conns.Redis, cfg.Proxy.SidecarPorts.Start, cfg.Proxy.SidecarPorts.End, cfg.Proxy.SidecarPorts.MaxPerCaller)
+	if err != nil {
+		return fmt.Errorf("main: sidecar ports: %w", err)
+	}
+
+	compaction := proxy.NewCompaction(log, enf, cfg.Quota.Compaction)
+
+	exporter, err := usageExporter(log, cfg, pb.NewTenantServiceClient(tenantConn))
+	if err != nil {
+		return fmt.Errorf("main: %w", err)
+	}
+
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	defer bgCancel()
+
+	// Start watching for changes to storage systems. When running in a
+	// cluster, the storage secret is watched through the Kubernetes API so
+	// that every proxy-server replica converges on the same configuration.
+	// Otherwise, fall back to watching the mounted file.
+
+	if err := k8s.ConnectFn(k8sAPI); err == nil {
+		if cfg.Events.Enabled {
+			log.Info("main: publishing decision events")
+			events := &proxy.DecisionEvents{Client: k8sAPI.Client, Log: log}
+			powerFlexHandler.SetDecisionEvents(events)
+			powerMaxHandler.SetDecisionEvents(events)
+		}
+
+		log.WithField("secret", k8s.StorageSecret).Info("main: watching storage systems secret")
+		go func() {
+			err := k8sAPI.WatchStorage(bgCtx, func(data []byte) {
+				err := updateStorageSystemsData(log, data, storageKeys, powerFlexHandler, powerMaxHandler, powerScaleHandler, passthroughHandler, headerPolicies, portHandler)
+				if err != nil {
+					log.WithError(err).Error("main: updating storage systems")
+				}
+			})
+			if err != nil {
+				log.WithError(err).Error("main: watching storage systems secret")
+			}
+		}()
+
+		// Singleton background jobs are only run by the elected leader.
+		var singletonJobs []func(context.Context)
+		if exporter != nil {
+			singletonJobs = append(singletonJobs, exporter.Run)
+		}
+		singletonJobs = append(singletonJobs, compaction.Run)
+		go func() {
+			err := k8sAPI.RunAsLeader(bgCtx, k8s.LeaderElectionConfig{
+				LeaseName: leaderElectionLease,
+				Identity:  podName(),
+			}, singletonJobs...)
+			if err != nil {
+				log.WithError(err).Error("main: running leader election")
+			}
+		}()
+	} else {
+		log.WithError(err).Warn("main: kubernetes api unavailable, watching storage systems file")
+		if cfg.Events.Enabled {
+			log.Warn("main: kubernetes api unavailable, decision events are not published")
+		}
+		// Without leader election, there is assumed to be a single
+		// proxy-server to export the usage.
+		if exporter != nil {
+			go exporter.Run(bgCtx)
+		}
+		go compaction.Run(bgCtx)
+
+		sysViper := viper.New()
+		sysViper.SetConfigName("storage-systems")
+		sysViper.AddConfigPath(".")
+		sysViper.AddConfigPath("/etc/karavi-authorization/storage/")
+		systemsPath := storageSystemsPath
+		if opts.StorageSystemsFile != "" {
+			sysViper.SetConfigFile(opts.StorageSystemsFile)
+			systemsPath = opts.StorageSystemsFile
+		}
+		sysViper.WatchConfig()
+
+		updaterFn := func() {
+			err := updateStorageSystems(log, systemsPath, storageKeys, powerFlexHandler, powerMaxHandler, powerScaleHandler, passthroughHandler, headerPolicies, portHandler)
+			if err != nil {
+				log.WithError(err).Error("main: updating storage systems")
+			}
+		}
+
+		// Update on config changes.
+		sysViper.OnConfigChange(func(e fsnotify.Event) {
+			log.Infof("Configuration changed! %+v, %s", e.Op, e.Name)
+			updaterFn()
+		})
+		updaterFn()
+	}
+
+	// Create the handlers
+
+	systemHandlers := map[string]http.Handler{
+		"powerflex":  web.Adapt(powerFlexHandler, web.OtelMW(tp, "powerflex")),
+		"powermax":   web.Adapt(powerMaxHandler, web.OtelMW(tp, "powermax")),
+		"powerscale": web.Adapt(powerScaleHandler, web.OtelMW(tp, "powerscale")),
+	}
+	systemPauses := proxy.NewSystemPauses(log, conns.Redis)
+	captures := proxy.NewCaptures(log, conns.Redis)
+	systemPauses.SetResponse(cfg.Proxy.Maintenance.StatusCode, cfg.Proxy.Maintenance.RetryAfter)
+	dispatchOpts := []proxy.DispatchOption{
+		proxy.WithPassthrough(web.Adapt(passthroughHandler, web.OtelMW(tp, "passthrough"))),
+		proxy.WithActivityRecorder(func(tenant, field string, at time.Time) error {
+			return tenantsvc.RecordActivity(conns.Redis(), tenant, field, at)
+		}),
+		proxy.WithSystemPauses(systemPauses),
+		proxy.WithCaptures(captures),
+		proxy.WithHeaderPolicies(headerPolicies),
+		proxy.WithForwardedSchemes(cfg.Proxy.ForwardedSchemes),
+	}
+	if cfg.Proxy.URLPolicy.Enabled {
+		urlPolicy, err := proxy.NewURLPolicy(log, cfg.Proxy.URLPolicy.Rules)
+		if err != nil {
+			return fmt.Errorf("main: %w", err)
+		}
+		if cfg.Proxy.URLPolicy.OPAOverride {
+			urlPolicy.EnableOPAOverride(cfg.OpenPolicyAgent.Host)
+			conns.opaClients = append(conns.opaClients, urlPolicy)
+		}
+		dispatchOpts = append(dispatchOpts, proxy.WithURLPolicy(urlPolicy))
+	}
+	dh := proxy.NewDispatchHandler(log, systemHandlers, dispatchOpts...)
+
+	simulateHandler := proxy.NewSimulateHandler(log, enf, cfg.OpenPolicyAgent.Host)
+	policyHandler := proxy.NewPolicyHandler(log, rdb, cfg.OpenPolicyAgent.Host)
+	rollouts := proxy.NewRollouts(log, conns.Redis, cfg.OpenPolicyAgent.Host)
+	conns.opaClients = append(conns.opaClients, simulateHandler, policyHandler, rollouts)
+	go rollouts.Run(bgCtx, cfg.OpenPolicyAgent.RolloutSyncInterval)
+	conns.redisClients = append(conns.redisClients, policyHandler)
+
+	var sessionStore *session.Store
+	var adminSessions proxy.SessionStore
+	if cfg.Web.AdminSessions.Enabled {
+		sessionStore = session.NewStore(conns.Redis,
+			session.WithLifetime(cfg.Web.AdminSessions.Lifetime),
+			session.WithIdleTimeout(cfg.Web.AdminSessions.IdleTimeout))
+		adminSessions = sessionStore
+	}
+
+	storageHandler := proxy.NewStorageHandler(log, pb.NewStorageServiceClient(storageConn))
+	storageHandler.SetVolumeAdoption(enf, pb.NewTenantServiceClient(tenantConn))
+	storageHandler.SetSystemPauses(systemPauses)
+
+	router := &web.Router{
+		RolesHandler:        web.Adapt(proxy.NewRoleHandler(log, pb.NewRoleServiceClient(roleConn)), web.OtelMW(tp, "role_handler")),
+		TokenHandler:        web.Adapt(refreshTokenHandler(pb.NewTenantServiceClient(tenantConn), tokenManager, log), web.OtelMW(tp, "tenant_refresh")),
+		AdminTokenHandler:   web.Adapt(refreshAdminTokenHandler(log, sessionStore, tokenOpts...), web.OtelMW(tp, "admin_refresh")),
+		ProxyHandler:        web.Adapt(dh, web.OtelMW(tp, "dispatch")),
+		VolumesHandler:      web.Adapt(volumesHandler(&roleClientService{roleClient: pb.NewRoleServiceClient(roleConn)}, &storageClientService{storageClient: pb.NewStorageServiceClient(storageConn)}, conns.Redis, tokenManager, log), web.OtelMW(tp, "volumes")),
+		RawVolumesHandler:   web.Adapt(proxy.NewRawVolumesHandler(log, pb.NewStorageServiceClient(storageConn), enf), web.OtelMW(tp, "raw_volumes")),
+		TenantHandler:       web.Adapt(proxy.NewTenantHandler(log, pb.NewTenantServiceClient(tenantConn)), web.OtelMW(tp, "tenant_handler")),
+		StorageHandler:      web.Adapt(storageHandler, web.OtelMW(tp, "storage_handler")),
+		SimulateHandler:     web.Adapt(simulateHandler, web.OtelMW(tp, "simulate_handler")),
+		PolicyHandler:       web.Adapt(policyHandler, web.OtelMW(tp, "policy_handler")),
+		LoginHandler:        web.Adapt(proxy.NewLoginHandler(log, pb.NewTenantServiceClient(tenantConn), cfg.Login), web.OtelMW(tp, "login_handler")),
+		AdminSessionHandler: web.Adapt(proxy.NewAdminSessionHandler(log, adminSessions), web.OtelMW(tp, "admin_session_handler")),
+		ReportHandler:       web.Adapt(proxy.NewReportHandler(log, pb.NewReportServiceClient(tenantConn)), web.OtelMW(tp, "report_handler")),
+		EventHandler:        web.Adapt(proxy.NewEventHandler(log, pb.NewEventServiceClient(tenantConn)), web.OtelMW(tp, "event_handler")),
+		PortHandler:         web.Adapt(portHandler, web.OtelMW(tp, "port_handler")),
+		RolloutHandler:      web.Adapt(proxy.NewRolloutHandler(log, rollouts), web.OtelMW(tp, "rollout_handler")),
+		CaptureHandler:      web.Adapt(proxy.NewCaptureHandler(log, captures), web.OtelMW(tp, "capture_handler")),
+		MaintenanceHandler:  web.Adapt(proxy.NewMaintenanceHandler(log, compaction), web.OtelMW(tp, "maintenance_handler")),
+	}
+
+	// Start the proxy service
+	log.Info("main: initializing proxy service")
+
+	var tokenReviews authenticationclient.TokenReviewInterface
+	if cfg.Observer.Enabled {
+		if k8sAPI.Client == nil {
+			if err := k8s.ConnectFn(k8sAPI); err != nil {
+				return fmt.Errorf("main: observers require the kubernetes api: %w", err)
+			}
+		}
+		tokenReviews = k8sAPI.Client.AuthenticationV1().TokenReviews()
+	}
+	observerMW, err := proxy.ObserverMW(log, tokenReviews, tokenManager, cfg.Observer)
+	if err != nil {
+		return fmt.Errorf("main: %w", err)
+	}
+	corsOpts := web.CORSOptions{
+		PathPrefixes:     web.APIPaths(),
+		AllowedOrigins:   cfg.Web.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.Web.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.Web.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.Web.CORS.ExposedHeaders,
+		AllowCredentials: cfg.Web.CORS.AllowCredentials,
+		MaxAge:           cfg.Web.CORS.MaxAge,
+	}
+	if err := corsOpts.Validate(); err != nil {
+		return fmt.Errorf("main: %w", err)
+	}
+
+	// Accept HTTP/2 without TLS (h2c), since TLS is terminated in front
+	// of the proxy-server, so that HTTP/2 passthrough is not downgraded.
+	handler := h2c.NewHandler(web.Adapt(router.Handler(),
+		replayMW(log, conns.Redis, cfg.Web.ReplayProtection.Enabled, web.ReplayOptions{
+			PathPrefixes: cfg.Web.ReplayProtection.Paths,
+			Window:       cfg.Web.ReplayProtection.Window,
+		}),
+		web.AuthMW(log, tokenManager),
+		proxy.BootstrapMW(log, pb.NewTenantServiceClient(tenantConn), tokenManager, cfg.Bootstrap),
+		observerMW,
+		web.ForwardedMW(web.ForwardedParser{Schemes: cfg.Proxy.ForwardedSchemes}),
+		web.CORSMW(corsOpts),
+		web.SecurityHeadersMW(web.APIPaths(), securityHeaders(cfg.Web.SecurityHeaders)),
+		web.LoggingMW(log, cfg.Web.ShowDebugHTTP), // log all requests
+		web.CleanMW(), // clean paths
+		web.OtelMW(tp, "", // format the span name
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				return fmt.Sprintf("%s %s", r.Method, r.URL.Path)
+			}))), &http2.Server{})
+
+	listeners, err := proxyListeners(cfg)
+	if err != nil {
+		return fmt.Errorf("main: %w", err)
+	}
+	var servers []*http.Server
+	var netListeners []net.Listener
+	for _, l := range listeners {
+		ln, tlsConfig, err := listen(l)
+		if err != nil {
+			for _, ln := range netListeners {
+				_ = ln.Close()
+			}
+			return fmt.Errorf("main: %w", err)
+		}
+		netListeners = append(netListeners, ln)
+		h := handler
+		if l.Scope == web.ScopeAdmin {
+			h = web.Adapt(h, web.AdminAuthMW(log, tokenManager))
+		}
+		// The networks were validated with the listeners.
+		nets, _ := web.ParseNetworks(l.AllowedCIDRs)
+		servers = append(servers, &http.Server{
+			Addr:              l.Host,
+			Handler:           web.Adapt(h, web.ScopeMW(l.Scope), web.AllowlistMW(log, nets)),
+			TLSConfig:         tlsConfig,
+			ReadTimeout:       cfg.Proxy.ReadTimeout,
+			WriteTimeout:      cfg.Proxy.WriteTimeout,
+			ReadHeaderTimeout: 5 * time.Second,
+		})
+	}
+
+	// Start listening for requests
+	serverErrors := make(chan error, len(servers))
+	for i, svr := range servers {
+		l, ln := listeners[i], netListeners[i]
+		go func() {
+			log.WithFields(logrus.Fields{
+				"proxy host":    l.Host,
+				"scope":         l.Scope,
+				"tls":           svr.TLSConfig != nil,
+				"allowed cidrs": l.AllowedCIDRs,
+			}).Info("main: proxy listening")
+			if svr.TLSConfig != nil {
+				serverErrors <- svr.ServeTLS(ln, "", "")
+				return
+			}
+			serverErrors <- svr.Serve(ln)
+		}()
+	}
+
+	// Handle graceful shutdown
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-serverErrors:
+		return fmt.Errorf("main: server error: %w", err)
+	case sig := <-shutdown:
+		log.WithField("signal", sig).Info("main: starting shutdown")
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		// Ask the proxy to shutdown and shed load
+		for _, svr := range servers {
+			if err := svr.Shutdown(ctx); err != nil {
+				closeErr := svr.Close()
+				if closeErr != nil {
+					return fmt.Errorf("main: failed to close server: %w", closeErr)
+				}
+				return fmt.Errorf("main: failed to gracefully shutdown server: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// usageExporter returns the exporter of tenant usage, or nil if it is
+// disabled.
+func usageExporter(log *logrus.Entry, cfg Config, client usage.TenantClient) (*usage.Exporter, error) {
+	if cfg.UsageExport.Interval <= 0 {
+		return nil, nil
+	}
+	sink, err := usage.NewSink(cfg.UsageExport.Format, cfg.UsageExport.CSV.Dir, cfg.UsageExport.RemoteWrite.URL, cfg.UsageExport.RemoteWrite.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	log.WithFields(logrus.Fields{
+		"interval": cfg.UsageExport.Interval,
+		"format":   cfg.UsageExport.Format,
+	}).Info("main: exporting tenant usage")
+	return &usage.Exporter{
+		Log:      log,
+		Client:   client,
+		Sink:     sink,
+		Interval: cfg.UsageExport.Interval,
+	}, nil
+}
+
+// requiredMounts returns the files and directories the proxy-server needs:
+// the csm-config-params and, when the usage is exported as CSV files, the
+// directory they are written to.
+func requiredMounts(cfg Config, opts Options) []mounts.Mount {
+	csmConfig := csmConfigParamsDir
+	if opts.CSMConfigFile != "" {
+		csmConfig = opts.CSMConfigFile
+	}
+	ms := []mounts.Mount{{Name: "csm-config-params", Path: csmConfig}}
+	if cfg.UsageExport.Interval > 0 && cfg.UsageExport.Format == usage.FormatCSV && cfg.UsageExport.CSV.Dir != "" {
+		ms = append(ms, mounts.Mount{Name: "usage export directory", Path: cfg.UsageExport.CSV.Dir, Writable: true})
+	}
+	return ms
+}
+
+// proxyListeners returns the listeners of the proxy: those of proxy.host
+// and proxy.tlshost, if set, followed by proxy.listeners.
+func proxyListeners(cfg Config) ([]Listener, error) {
+	var listeners []Listener
+	if cfg.Proxy.Host != "" {
+		listeners = append(listeners, Listener{Host: cfg.Proxy.Host})
+	}
+	if cfg.Proxy.TLSHost != "" {
+		listeners = append(listeners, Listener{
+			Host:        cfg.Proxy.TLSHost,
+			TLSCertFile: cfg.Proxy.TLSCertFile,
+			TLSKeyFile:  cfg.Proxy.TLSKeyFile,
+		})
+	}
+	listeners = append(listeners, cfg.Proxy.Listeners...)
+	if len(listeners) == 0 {
+		return nil, errors.New("no proxy listeners are configured")
+	}
+
+	hosts := make(map[string]struct{})
+	var hasAdmin bool
+	for i, l := range listeners {
+		if l.Host == "" {
+			return nil, fmt.Errorf("proxy listener %d: missing host", i)
+		}
+		if _, ok := hosts[l.Host]; ok {
+			return nil, fmt.Errorf("proxy listener %s: duplicate host", l.Host)
+		}
+		hosts[l.Host] = struct{}{}
+		if (l.TLSCertFile == "") != (l.TLSKeyFile == "") {
+			return nil, fmt.Errorf("proxy listener %s: both a tls certificate and key file are required", l.Host)
+		}
+		if l.Scope == "" {
+			listeners[i].Scope = web.ScopeAll
+		} else if !web.ValidScope(l.Scope) {
+			return nil, fmt.Errorf("proxy listener %s: unknown scope %q", l.Host, l.Scope)
+		}
+		if _, err := web.ParseNetworks(l.AllowedCIDRs); err != nil {
+			return nil, fmt.Errorf("proxy listener %s: allowed cidrs: %w", l.Host, err)
+		}
+		hasAdmin = hasAdmin || l.Scope == web.ScopeAdmin
+	}
+
+	// The admin routes are only served on the admin listener once there is
+	// one, to keep them off the data path.
+	if hasAdmin {
+		for i, l := range listeners[:len(listeners)-len(cfg.Proxy.Listeners)] {
+			if l.Scope == web.ScopeAll {
+				listeners[i].Scope = web.ScopeData
+			}
+		}
+	}
+	return listeners, nil
+}
+
+// listen listens on the host of the listener, returning the TLS
+// configuration to serve it with if it has a key pair.
+func listen(l Listener) (net.Listener, *tls.Config, error) {
+	var tlsConfig *tls.Config
+	if l.TLSCertFile != "" {
+		certs := &certificateReloader{certFile: l.TLSCertFile, keyFile: l.TLSKeyFile}
+		if _, err := certs.GetCertificate(nil); err != nil {
+			return nil, nil, fmt.Errorf("proxy listener %s: tls certificate: %w", l.Host, err)
+		}
+		tlsConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+	}
+	ln, err := net.Listen("tcp", l.Host)
+	if err != nil {
+		return nil, nil, fmt.Errorf("proxy listener %s: %w", l.Host, err)
+	}
+	return ln, tlsConfig, nil
+}
+
+// roleStreamAuthorizer authorizes the passthrough streams of tenants with a
+// role on the storage system.
+func roleStreamAuthorizer(roleClient pb.RoleServiceClient) proxy.StreamAuthorizer {
+	return func(ctx context.Context, claims token.Claims, systemType, systemID string) error {
+		resp, err := roleClient.List(ctx, &pb.RoleListRequest{})
+		if err != nil {
+			return fmt.Errorf("listing roles: %w", err)
+		}
+
+		claimed := make(map[string]struct{})
+		for _, r := range strings.Split(claims.Roles, ",") {
+			claimed[strings.TrimSpace(r)] = struct{}{}
+		}
+		for _, ins := range resp.Instances {
+			if _, ok := claimed[ins.Name]; ok && ins.StorageType == systemType && ins.SystemId == systemID {
+				return nil
+			}
+		}
+		return fmt.Errorf("no roles in [%s] allow access to %s/%s", claims.Roles, systemType, systemID)
+	}
+}
+
+// securityHeaders overlays the configured security headers onto the defaults.
+// A header configured with an empty value is disabled.
+func securityHeaders(configured map[string]string) map[string]string {
+	headers := web.DefaultSecurityHeaders()
+	for k, v := range configured {
+		headers[http.CanonicalHeaderKey(k)] = v
+	}
+	return headers
+}
+
+// namespace returns the namespace the proxy-server is running in.
+func namespace() string {
+	if ns, ok := os.LookupEnv(namespaceEnv); ok && ns != "" {
+		return ns
+	}
+	return defaultNamespace
+}
+
+// serviceAddr returns the address of a backend service: the command line
+// flag if set, or else the configured address, which is resolved through the
+// Kubernetes API if it is a k8s:// address.
+func serviceAddr(api *k8s.API, flagAddr, cfgAddr string) (string, error) {
+	addr := cfgAddr
+	if flagAddr != "" {
+		addr = flagAddr
+	}
+	resolved, err := api.ResolveServiceAddr(context.Background(), addr)
+	if err != nil {
+		return "", fmt.Errorf("resolving service address %q: %w", addr, err)
+	}
+	return resolved, nil
+}
+
+// podName returns an identity for this replica, used for leader election.
+func podName() string {
+	if name, ok := os.LookupEnv(podNameEnv); ok && name != "" {
+		return name
+	}
+	name, err := os.Hostname()
+	if err != nil {
+		return fmt.Sprintf("proxy-server-%d", os.Getpid())
+	}
+	return name
+}
+
+// newConfig returns the proxy-server configuration, read from the file if
+// it is set, with its defaults. Environment variables override the config
+// file, with the dots of keys replaced by underscores, e.g.
+// DATABASE_PASSWORD overrides database.password; OPA_HOST may be used for
+// openpolicyagent.host.
+func newConfig(log *logrus.Entry, file string) *appconfig.Config {
+	appCfg := appconfig.New(log, appconfig.Source{
+		Name:  "config",
+		Paths: []string{".", "/etc/karavi-authorization/config/"},
+		File:  file,
+	})
+	cfgViper := appCfg.Viper()
+
+	cfgViper.SetDefault("certificate.crtfile", "")
+	cfgViper.SetDefault("certificate.keyfile", "")
+
+	cfgViper.SetDefault("proxy.host", ":8080")
+	cfgViper.SetDefault("proxy.readtimeout", 30*time.Second)
+	cfgViper.SetDefault("proxy.writetimeout", 30*time.Second)
+	cfgViper.SetDefault("proxy.filteredpaths", proxy.DefaultPowerFlexFilteredPaths)
+	cfgViper.SetDefault("proxy.urlpolicy.enabled", false)
+	cfgViper.SetDefault("proxy.urlpolicy.opaoverride", false)
+	cfgViper.SetDefault("proxy.urlpolicy.rules", proxy.DefaultURLRules)
+	cfgViper.SetDefault("proxy.cache.enabled", false)
+	cfgViper.SetDefault("proxy.cache.ttl", proxy.DefaultResponseCacheTTL)
+	cfgViper.SetDefault("proxy.cache.paths", proxy.DefaultPowerFlexCachedPaths)
+	cfgViper.SetDefault("proxy.powerscale.enforceshareownership", false)
+	cfgViper.SetDefault("proxy.maintenance.statuscode", proxy.DefaultPauseStatus)
+	cfgViper.SetDefault("proxy.maintenance.retryafter", proxy.DefaultPauseRetryAfter)
+	cfgViper.SetDefault("proxy.forwardedschemes", web.DefaultForwardedSchemes)
+	cfgViper.SetDefault("proxy.sidecarports.start", proxy.DefaultPortRangeStart)
+	cfgViper.SetDefault("proxy.sidecarports.end", proxy.DefaultPortRangeEnd)
+	cfgViper.SetDefault("proxy.sidecarports.maxpercaller", proxy.DefaultMaxPortsPerCaller)
+	cfgViper.SetDefault("proxy.tlshost", "")
+	cfgViper.SetDefault("proxy.tlscertfile", "")
+	cfgViper.SetDefault("proxy.tlskeyfile", "")
+
+	cfgViper.SetDefault("web.debughost", ":9090")
+	cfgViper.SetDefault("web.shutdowntimeout", 15*time.Second)
+	cfgViper.SetDefault(configParamJWTSigningScrt, "secret")
+	cfgViper.SetDefault("web.showdebughttp", false)
+	cfgViper.SetDefault("web.jwtsigningalgorithm", string(jwx.HS256))
+	cfgViper.SetDefault("web.jwtissuer", "")
+	cfgViper.SetDefault("web.jwtaudience", "")
+	cfgViper.SetDefault("web.jwtencryptionkey", "")
+	cfgViper.SetDefault("web.legacytokensuntil", "")
+	cfgViper.SetDefault("web.cors.allowedorigins", []string{})
+	cfgViper.SetDefault("web.cors.allowedmethods", []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete, http.MethodOptions})
+	cfgViper.SetDefault("web.cors.allowedheaders", []string{"Authorization", "Content-Type", web.HeaderRequestNonce, web.HeaderRequestTime})
+	cfgViper.SetDefault("web.cors.exposedheaders", []string{})
+	cfgViper.SetDefault("web.cors.allowcredentials", false)
+	cfgViper.SetDefault("web.cors.maxage", 10*time.Minute)
+	cfgViper.SetDefault("web.replayprotection.enabled", false)
+	cfgViper.SetDefault("web.replayprotection.window", web.DefaultReplayWindow)
+	cfgViper.SetDefault("web.replayprotection.paths", []string{web.ProxyTenantPath, web.ProxyStoragePath, web.VersionedPath(web.RouteTenant), web.VersionedPath(web.RouteStorage)})
+	cfgViper.SetDefault("web.adminsessions.enabled", true)
+	cfgViper.SetDefault("web.adminsessions.lifetime", time.Duration(0))
+	cfgViper.SetDefault("web.adminsessions.idletimeout", time.Duration(0))
+
+	cfgViper.SetDefault("zipkin.collectoruri", "")
+	cfgViper.SetDefault("zipkin.servicename", "proxy-server")
+	cfgViper.SetDefault("zipkin.probability", 0.8)
+
+	cfgViper.SetDefault("database.host", k8s.ServiceAddr("redis", namespace(), 6379))
+	cfgViper.SetDefault("database.password", "")
+
+	cfgViper.SetDefault("services.tenant", k8s.ServiceAddr("tenant-service", namespace(), 50051))
+	cfgViper.SetDefault("services.role", k8s.ServiceAddr("role-service", namespace(), 50051))
+	cfgViper.SetDefault("services.storage", k8s.ServiceAddr("storage-service", namespace(), 50051))
+
+	cfgViper.SetDefault("openpolicyagent.host", "127.0.0.1:8181")
+	cfgViper.SetDefault("openpolicyagent.shadow.host", "")
+	cfgViper.SetDefault("openpolicyagent.shadow.percent", 0)
+	cfgViper.SetDefault("openpolicyagent.rolloutsyncinterval", proxy.DefaultRolloutSyncInterval)
+
+	cfgViper.SetDefault("storage.masterkeyfile", "")
+	cfgViper.SetDefault("quota.graceperiod", time.Duration(0))
+	cfgViper.SetDefault("quota.compaction.interval", proxy.DefaultCompactionInterval)
+	cfgViper.SetDefault("quota.compaction.deletedretention", proxy.DefaultDeletedRetention)
+	cfgViper.SetDefault("quota.compaction.streammaxlen", proxy.DefaultStreamMaxLen)
+
+	cfgViper.SetDefault("events.enabled", false)
+
+	cfgViper.SetDefault("usageexport.interval", time.Duration(0))
+	cfgViper.SetDefault("usageexport.format", usage.FormatCSV)
+	cfgViper.SetDefault("usageexport.csv.dir", "")
+	cfgViper.SetDefault("usageexport.remotewrite.url", "")
+	cfgViper.SetDefault("usageexport.remotewrite.timeout", 30*time.Second)
+
+	cfgViper.SetDefault("login.github.clientid", "")
+	cfgViper.SetDefault("login.github.clientsecret", "")
+	cfgViper.SetDefault("login.github.apiurl", proxy.DefaultGitHubAPIURL)
+	cfgViper.SetDefault("login.oidc.issuer", "")
+	cfgViper.SetDefault("login.oidc.clientid", "")
+	cfgViper.SetDefault("login.accesstokenttl", time.Minute)
+	cfgViper.SetDefault("login.refreshtokenttl", 30*24*time.Hour)
+
+	cfgViper.SetDefault("bootstrap.enabled", false)
+	cfgViper.SetDefault("bootstrap.tenantnaming", proxy.TenantNamingCluster)
+	cfgViper.SetDefault("bootstrap.tokenttl", proxy.DefaultBootstrapTokenTTL)
+
+	cfgViper.SetDefault("observer.enabled", false)
+	cfgViper.SetDefault("observer.reviewttl", proxy.DefaultObserverReviewTTL)
+
+	// BindEnv only fails without a key
+	_ = cfgViper.BindEnv(configParamOPAHost, "OPENPOLICYAGENT_HOST", "OPA_HOST")
+
+	return appCfg
+}
+
+func updateConfiguration(vc *viper.Viper, log *logrus.Entry, conns *connections) {
+	jss := cfg.Web.JWTSigningSecret
+	if vc.IsSet(configParamJWTSigningScrt) {
+		value := vc.GetString(configParamJWTSigningScrt)
+		jss = value
+		log.WithField(configParamJWTSigningScrt, "***").Info("configuration has been set")
+	}
+	web.JWTSigningSecret = jss
+	JWTSigningSecret = jss
+
+	if vc.IsSet(configParamOPAHost) {
+		cfg.OpenPolicyAgent.Host = vc.GetString(configParamOPAHost)
+	}
+	if vc.IsSet(configParamOPAShadowHost) {
+		cfg.OpenPolicyAgent.Shadow.Host = vc.GetString(configParamOPAShadowHost)
+	}
+	if vc.IsSet(configParamOPAShadowPct) {
+		cfg.OpenPolicyAgent.Shadow.Percent = vc.GetFloat64(configParamOPAShadowPct)
+	}
+	setOPAShadow(log)
+	if vc.IsSet(configParamDatabaseHost) {
+		cfg.Database.Host = vc.GetString(configParamDatabaseHost)
+	}
+	if vc.IsSet(configParamDatabasePass) {
+		cfg.Database.Password = vc.GetString(configParamDatabasePass)
+	}
+	if conns != nil {
+		conns.Update(cfg.OpenPolicyAgent.Host, cfg.Database.Host, cfg.Database.Password)
+	}
+}
+
+// setOPAShadow mirrors OPA queries to the shadow instance of the
+// configuration, if any.
+func setOPAShadow(log *logrus.Entry) {
+	shadow := cfg.OpenPolicyAgent.Shadow
+	if shadow.Host != "" && shadow.Percent > 0 {
+		log.WithFields(logrus.Fields{
+			"host":    shadow.Host,
+			"percent": shadow.Percent,
+		}).Info("mirroring opa queries")
+	}
+	decision.SetShadow(&decision.Shadow{
+		Host:    shadow.Host,
+		Percent: shadow.Percent,
+		Log:     log,
+	})
+}
+
+// connections holds the OPA host and the redis client shared by the
+// handlers, so that changes to openpolicyagent.host and database.host take
+// effect without restarting the proxy-server.
+type connections struct {
+	log       *logrus.Entry
+	drain     time.Duration // how long a replaced redis client is kept open
+	redisFlag string        // redis address from the command line; overrides the config
+
+	opaClients   []interface{ SetOPAHost(string) }
+	redisClients []interface{ SetRedis(*redis.Client) }
+
+	mu      sync.RWMutex // guards the fields below
+	opaHost string
+	rdb     *redis.Client
+}
+
+func newConnections(log *logrus.Entry, opaHost, redisAddr, redisPassword string, drain time.Duration) *connections {
+	return &connections{
+		log:     log,
+		drain:   drain,
+		opaHost: opaHost,
+		rdb:     newRedisClient(redisAddr, redisPassword),
+	}
+}
+
+func newRedisClient(addr, password string) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     addr, // "redis.karavi.svc.cluster.local:6379",
+		Password: password,
+		DB:       0,
+	})
+}
+
+// Redis returns the current redis client.
+func (c *connections) Redis() *redis.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rdb
+}
+
+// Update points the handlers at a changed OPA host or redis address. The
+// replaced redis client is closed once the requests using it have had time
+// to finish.
+func (c *connections) Update(opaHost, redisAddr, redisPassword string) {
+	if c.redisFlag != "" {
+		redisAddr = c.redisFlag
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if opaHost != c.opaHost {
+		c.opaHost = opaHost
+		for _, h := range c.opaClients {
+			h.SetOPAHost(opaHost)
+		}
+		c.log.WithField(configParamOPAHost, opaHost).Info("configuration has been set")
+	}
+
+	opts := c.rdb.Options()
+	if redisAddr == opts.Addr && redisPassword == opts.Password {
+		return
+	}
+	old := c.rdb
+	c.rdb = newRedisClient(redisAddr, redisPassword)
+	for _, h := range c.redisClients {
+		h.SetRedis(c.rdb)
+	}
+	c.log.WithField(configParamDatabaseHost, redisAddr).Info("configuration has been set")
+
+	time.AfterFunc(c.drain, func() {
+		if err := old.Close(); err != nil {
+			c.log.WithError(err).Warn("closing redis")
+		}
+	})
+}
+
+// Close closes the current redis client.
+func (c *connections) Close() error {
+	return c.Redis().Close()
+}
+
+func updateStorageSystems(log *logrus.Entry, storageSystemsPath string, keys envelope.KeyWrapper, powerFlexHandler *proxy.PowerFlexHandler, powerMaxHandler *proxy.PowerMaxHandler, powerScaleHandler *proxy.PowerScaleHandler, passthroughHandler *proxy.PassthroughHandler, headerPolicies *proxy.HeaderPolicies, portHandler *proxy.PortHandler) error {
+	// read the storage-systems file
+	storageYamlBytes, err := os.ReadFile(filepath.Clean(storageSystemsPath))
+	if err != nil {
+		return fmt.Errorf("reading storage systems: %w", err)
+	}
+
+	return updateStorageSystemsData(log, storageYamlBytes, keys, powerFlexHandler, powerMaxHandler, powerScaleHandler, passthroughHandler, headerPolicies, portHandler)
+}
+
+func updateStorageSystemsData(log *logrus.Entry, storageYamlBytes []byte, keys envelope.KeyWrapper, powerFlexHandler *proxy.PowerFlexHandler, powerMaxHandler *proxy.PowerMaxHandler, powerScaleHandler *proxy.PowerScaleHandler, passthroughHandler *proxy.PassthroughHandler, headerPolicies *proxy.HeaderPolicies, portHandler *proxy.PortHandler) error {
+	// unmarshal the yaml data
+	var v map[string]interface{}
+	err := yaml.Unmarshal(storageYamlBytes, &v)
+	if err != nil {
+		return fmt.Errorf("unmarshaling storage systems: %w", err)
+	}
+
+	// extract the storage field
+	storage, ok := v["storage"]
+	if !ok {
+		return fmt.Errorf("storage key not found in storage-systems data")
+	}
+
+	// decrypt the passwords of the storage systems
+	if err := decryptStoragePasswords(keys, storage); err != nil {
+		return err
+	}
+
+	// marshal the storage data
+	systemsYamlBytes, err := yaml.Marshal(storage)
+	if err != nil {
+		return fmt.Errorf("marshaling storage systems: %w", err)
+	}
+
+	// convert above storage data to json
+	systemsJSONBytes, err := yaml.YAMLToJSON(systemsYamlBytes)
+	if err != nil {
+		return fmt.Errorf("converting yaml to json: %w", err)
+	}
+
+	// update the systems with the json data
+
+	err = powerFlexHandler.UpdateSystems(context.Background(), bytes.NewReader(systemsJSONBytes), log)
+	if err != nil {
+		log.WithError(err).Error("main: updating powerflex systems")
+	}
+
+	err = powerMaxHandler.UpdateSystems(context.Background(), bytes.NewReader(systemsJSONBytes), log)
+	if err != nil {
+		log.WithError(err).Error("main: updating powermax systems")
+	}
+
+	err = powerScaleHandler.UpdateSystems(context.Background(), bytes.NewReader(systemsJSONBytes), log)
+	if err != nil {
+		log.WithError(err).Error("main: updating powerscale systems")
+	}
+
+	if passthroughHandler != nil {
+		err = passthroughHandler.UpdateSystems(context.Background(), bytes.NewReader(systemsJSONBytes), log)
+		if err != nil {
+			log.WithError(err).Error("main: updating passthrough systems")
+		}
+	}
+
+	if headerPolicies != nil {
+		err = headerPolicies.UpdateSystems(context.Background(), bytes.NewReader(systemsJSONBytes), log)
+		if err != nil {
+			log.WithError(err).Error("main: updating header policies")
+		}
+	}
+
+	if portHandler != nil {
+		err = portHandler.UpdateSystems(context.Background(), bytes.NewReader(systemsJSONBytes), log)
+		if err != nil {
+			log.WithError(err).Error("main: updating sidecar port systems")
+		}
+	}
+
+	return nil
+}
+
+// decryptStoragePasswords decrypts the encrypted passwords of the storage
+// systems, which are keyed by storage type and then system ID.
+func decryptStoragePasswords(keys envelope.KeyWrapper, storage interface{}) error {
+	types, ok := storage.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	for _, systems := range types {
+		systems, ok := systems.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for id, system := range systems {
+			fields, ok := system.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for k, v := range fields {
+				password, ok := v.(string)
+				if !ok || !strings.EqualFold(k, "password") {
+					continue
+				}
+				dec, err := envelope.Decrypt(keys, password)
+				if err != nil {
+					return fmt.Errorf("decrypting password of %s: %w", id, err)
+				}
+				fields[k] = dec
+			}
+		}
+	}
+	return nil
+}
+
+func refreshTokenHandler(client pb.TenantServiceClient, tm token.Manager, log *logrus.Entry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Info("Refreshing token!")
+		type tokenPair struct {
+			RefreshToken string `json:"refreshToken,omitempty"`
+			AccessToken  string `json:"accessToken"`
+		}
+		var input tokenPair
+		err := json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
+			log.WithError(err).Error("decoding token pair")
+			writeJSONError(w, log, web.CodeBadRequest, fmt.Errorf("decoding token pair: %v", err))
+			return
+		}
+
+		refreshResp, err := client.RefreshToken(r.Context(), &pb.RefreshTokenRequest{
+			AccessToken:      input.AccessToken,
+			RefreshToken:     input.RefreshToken,
+			JWTSigningSecret: JWTSigningSecret,
+		})
+		if err != nil {
+			log.WithError(err).Error("refreshing token")
+			code := web.CodeUnauthorized
+			if status.Code(err) == codes.PermissionDenied {
+				code = web.CodeTenantRevoked
+			}
+			writeJSONError(w, log, code, fmt.Errorf("refreshing token: %v", err))
+			return
+		}
+
+		// The tenant service has validated the refresh token.
+		var claims token.Claims
+		if _, err := tm.ParseWithClaims(input.RefreshToken, JWTSigningSecret, &claims); err == nil {
+			proxy.RecordTokenRefresh(claims.Group)
+		}
+
+		var output tokenPair
+		output.AccessToken = refreshResp.AccessToken
+		err = json.NewEncoder(w).Encode(&output)
+		if err != nil {
+			log.WithError(err).Error("encoding token pair")
+			writeJSONError(w, log, web.CodeInternal, fmt.Errorf("encoding token pair: %v", err))
+			return
+		}
+	})
+}
+
+// refreshAdminTokenHandler refreshes an admin token. When sessions is not
+// nil, the refresh token must belong to a session that is still valid.
+func refreshAdminTokenHandler(log *logrus.Entry, sessions *session.Store, opts ...jwx.Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Info("Refreshing admin token!")
+		var input token.AdminToken
+		err := json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
+			writeJSONError(w, log, web.CodeBadRequest, fmt.Errorf("decoding admin token pair: %v", err))
+			return
+		}
+
+		refreshResp, err := jwx.RefreshAdminToken(context.Background(), &pb.RefreshAdminTokenRequest{
+			RefreshToken:     input.Refresh,
+			AccessToken:      input.Access,
+			JWTSigningSecret: JWTSigningSecret,
+		}, opts...)
+		if err != nil {
+			writeJSONError(w, log, web.CodeUnauthorized, fmt.Errorf("refreshing admin token: %v", err))
+			return
+		}
+
+		if sessions != nil {
+			var claims token.Claims
+			_, err = jwx.NewTokenManager(jwx.HS256, opts...).ParseWithClaims(input.Refresh, JWTSigningSecret, &claims)
+			if err != nil {
+				writeJSONError(w, log, web.CodeUnauthorized, fmt.Errorf("parsing admin refresh token: %v", err))
+				return
+			}
+			if err := sessions.Refresh(input.Refresh, claims); err != nil {
+				writeJSONError(w, log, web.CodeUnauthorized, fmt.Errorf("refreshing admin session: %v", err))
+				return
+			}
+		}
+
+		var resp pb.RefreshAdminTokenResponse
+		resp.AccessToken = refreshResp.AccessToken
+		err = json.NewEncoder(w).Encode(&resp)
+		if err != nil {
+			writeJSONError(w, log, web.CodeInternal, fmt.Errorf("encoding admin token pair: %v", err))
+			return
+		}
+	})
+}
+
+func rolesHandler(log *logrus.Entry, opaHost string) http.Handler {
+	url := fmt.Sprintf("http://%s/v1/data/karavi/common/roles", opaHost)
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			log.WithError(err).Fatal()
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.WithError(err).Fatal()
+		}
+		_, err = io.Copy(w, res.Body)
+		if err != nil {
+			log.WithError(err).Fatal()
+		}
+		defer res.Body.Close()
+	})
+}
+
+func volumesHandler(roleServ *roleClientService, storageServ *storageClientService, redisClient func() *redis.Client, tm token.Manager, log *logrus.Entry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rdb := redisClient()
+		var tenant string
+		volumeMap := make(map[string]map[string]string)
+		var volumeList []*pb.Volume
+		var resp *pb.RoleListResponse
+		keyTenantRevoked := "tenant:revoked"
+
+		authz := r.Header.Get("Authorization")
+		parts := strings.Split(authz, " ")
+		if len(parts) != 2 {
+			log.Errorf("invalid authz header: %v", parts)
+			writeJSONError(w, log, web.CodeUnauthorized, fmt.Errorf("invalid authz header"))
+			return
+		}
+		scheme, tkn := parts[0], parts[1]
+
+		switch scheme {
+		case "Bearer":
+			var claims token.Claims
+			// check validity of token
+			_, err := tm.ParseWithClaims(tkn, JWTSigningSecret, &claims)
+			if err != nil {
+				log.WithError(err).Printf("error parsing token: %v", err)
+				writeJSONError(w, log, web.CodeUnauthorized, fmt.Errorf("validating token: %v", err))
+				return
+			}
+			// Tokens issued before the tenant was assigned a UUID are
+			// resolved by the tenant name.
+			tenantKey := claims.TenantKey()
+			if claims.TenantID == "" {
+				enf := quota.NewRedisEnforcement(r.Context(), quota.WithRedis(rdb))
+				tenantKey, err = enf.TenantID(r.Context(), claims.Group)
+				if err != nil {
+					log.WithError(err).Printf("error resolving tenant: %v", err)
+					writeJSONError(w, log, web.CodeInternal, fmt.Errorf("resolving tenant: %v", err))
+					return
+				}
+			}
+
+			// Check if the tenant is being denied.
+			ok, err := rdb.SIsMember(keyTenantRevoked, tenantKey).Result()
+			if err != nil {
+				log.WithError(err).Printf("error checking tenant revoked status: %v", err)
+				writeJSONError(w, log, web.CodeInternal, fmt.Errorf("checking tenant revoked status: %v", err))
+				return
+			}
+			if ok {
+				writeJSONError(w, log, web.CodeTenantRevoked, fmt.Errorf("tenant is revoked"))
+				return
+			}
+
+			log.Debugf("Serving get volumes request for tenant %s", claims.Group)
+
+			if roleServ.roleService == nil {
+				resp, err = roleServ.roleClient.List(r.Context(), &pb.RoleListRequest{})
+			} else {
+				resp, err = roleServ.roleService.List(r.Context(), &pb.RoleListRequest{})
+			}
+
+			if err != nil {
+				log.WithError(err).Printf("error listing roles: %v", err)
+				writeJSONError(w, log, web.CodeInternal, fmt.Errorf("listing configured roles: %v", err))
+				return
+			}
+
+			roleJSON := roles.NewJSON()
+			err = roleJSON.UnmarshalJSON(resp.Roles)
+			if err != nil {
+				log.WithError(err).Printf("error unmarshalling role data: %v", err)
+				writeJSONError(w, log, web.CodeInternal, fmt.Errorf("unmarhsalling role data: %v", err))
+				return
+			}
+
+			rolesSplit := strings.Split(claims.Roles, ",")
+			tenant = claims.Group
+
+			// Roles are selected in a callback, so the first error is kept
+			// and written once they have all been visited.
+			var selectErr error
+			roleJSON.Select(func(rInst roles.Instance) {
+				if selectErr != nil {
+					return
+				}
+				for _, role := range rolesSplit {
+					if rInst.Name != role {
+						continue
+					}
+					sysID := rInst.SystemID
+					dataKey := fmt.Sprintf("quota:%s:%s:%s:%s:data", rInst.SystemType, sysID, rInst.Pool, tenantKey)
+
+					res, err := rdb.HGetAll(dataKey).Result()
+					if err != nil {
+						log.WithError(err).Printf("getting volume data for tenant %s, %v", tenant, err)
+						selectErr = fmt.Errorf("getting volume data: %v", err)
+						return
+					}
+
+					if len(res) == 0 {
+						log.Printf("no volumes found for tenant %s in pool %s", tenant, rInst.Pool)
+						continue
+					}
+
+					if volumeMap[sysID] == nil {
+						volumeMap[sysID] = make(map[string]string)
+					}
+					for volKey := range res {
+						if strings.Contains(volKey, "capacity") {
+							splitStr := strings.Split(volKey, ":")
+							// example : vol:k8s-cb89d36285:capacity, or
+							// vol:<volume id>:capacity with the name in
+							// vol:<volume id>:name
+							if len(splitStr) == 3 {
+								name := splitStr[1]
+								if v, ok := res[fmt.Sprintf("vol:%s:name", splitStr[1])]; ok {
+									name = v
+								}
+								volumeMap[sysID][splitStr[1]] = name
+							}
+						}
+					}
+					for volKey := range res {
+						if strings.Contains(volKey, "deleted") {
+							splitStr := strings.Split(volKey, ":")
+							// example : vol:k8s-cb89d36285:deleted
+							if len(splitStr) == 3 {
+								delete(volumeMap[sysID], splitStr[1])
+							}
+						}
+					}
+
+					// If none found for sysId, delete in map so we can output later if there's none found for tenant
+					if len(volumeMap[sysID]) == 0 {
+						delete(volumeMap, sysID)
+					}
+				}
+			})
+			if selectErr != nil {
+				writeJSONError(w, log, web.CodeInternal, selectErr)
+				return
+			}
+
+		case "Basic":
+			log.Println("Basic authentication used")
+			return
+		}
+		if len(volumeMap) == 0 {
+			log.Errorf("no volumes found for tenant %s", tenant)
+			writeJSONError(w, log, web.CodeNotFound, fmt.Errorf("no volumes found"))
+			return
+		}
+
+		for sysID, nameMap := range volumeMap {
+			var currentVolumeNameList []string
+			var storageResp *pb.GetPowerflexVolumesResponse
+			var err error
+
+			for _, v := range nameMap {
+				currentVolumeNameList = append(currentVolumeNameList, v)
+			}
+
+			// grpc call to storage service to get volume details
+			powerflexVolumesRequest := &pb.GetPowerflexVolumesRequest{
+				SystemId:   sysID,
+				VolumeName: currentVolumeNameList,
+			}
+
+			storageResp, err = storageServ.storageClient.GetPowerflexVolumes(r.Context(), powerflexVolumesRequest)
+			if err != nil {
+				log.WithError(err).Println("getting powerflex volumes")
+				writeJSONError(w, log, web.RPCErrorCode(err), fmt.Errorf("getting powerflex volumes: %v", err))
+				return
+			}
+
+			volumeList = append(volumeList, storageResp.Volume...)
+
+			log.Printf("Volume Details for System ID: %s\n %v", sysID, storageResp.String())
+		}
+
+		// Encode before writing the status, so that an encoding error can
+		// still be sent as an error response.
+		b, err := json.Marshal(&volumeList)
+		if err != nil {
+			log.WithError(err).Println("unable to encode body")
+			writeJSONError(w, log, web.CodeInternal, fmt.Errorf("encoding volumes: %v", err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(b); err != nil {
+			log.WithError(err).Println("writing volumes response")
+		}
+	})
+}
+
+// writeJSONError writes err as a JSON error response with the error code.
+// replayMW returns the replay protection middleware, or one that does
+// nothing when it is not enabled.
+func replayMW(log *logrus.Entry, rdb func() *redis.Client, enabled bool, opts web.ReplayOptions) web.Middleware {
+	if !enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	log.WithField("paths", opts.PathPrefixes).Info("main: enabling replay protection")
+	return web.ReplayMW(log, web.RedisNonceStore{Redis: rdb}, opts)
+}
+
+func writeJSONError(w http.ResponseWriter, log *logrus.Entry, code web.ErrorCode, err error) {
+	if jsonErr := web.ErrorResponse(w, web.NewError(code, err)); jsonErr != nil {
+		log.WithError(jsonErr).Println("error creating json response")
+	}
+}
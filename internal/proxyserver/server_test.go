@@ -12,34 +12,53 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package main
+package proxyserver
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	cmd "karavi-authorization/cmd/karavictl/cmd"
+	"karavi-authorization/internal/decision"
+	"karavi-authorization/internal/envelope"
+	"karavi-authorization/internal/k8s"
+	"karavi-authorization/internal/mounts"
 	"karavi-authorization/internal/proxy"
+	"karavi-authorization/internal/quota"
 	"karavi-authorization/internal/role-service"
+	"karavi-authorization/internal/role-service/mocks"
 	"karavi-authorization/internal/role-service/roles"
 	mockStorage "karavi-authorization/internal/storage-service/mocks"
 	"karavi-authorization/internal/tenantsvc"
+	mockTenant "karavi-authorization/internal/tenantsvc/mocks"
+	"karavi-authorization/internal/token"
 	"karavi-authorization/internal/token/jwx"
+	"karavi-authorization/internal/token/session"
+	"karavi-authorization/internal/usage"
+	"karavi-authorization/internal/web"
 	"karavi-authorization/pb"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis"
 	"github.com/orlangure/gnomock"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 	"sigs.k8s.io/yaml"
 )
 
@@ -59,6 +78,8 @@ func TestUpdateConfiguration(t *testing.T) {
 	v.Set("certificate.keyfile", "testKeyFile")
 	v.Set("certificate.rootcertificate", "testRootCertificate")
 	v.Set("web.jwtsigningsecret", "testSecret")
+	v.Set("openpolicyagent.shadow.host", "opa-staging:8181")
+	v.Set("openpolicyagent.shadow.percent", 12.5)
 
 	oldCfg := cfg
 	cfg = Config{}
@@ -68,13 +89,376 @@ func TestUpdateConfiguration(t *testing.T) {
 	defer func() {
 		cfg = oldCfg
 		JWTSigningSecret = oldJWTSigningSecret
+		decision.SetShadow(nil)
 	}()
 
-	updateConfiguration(v, logrus.NewEntry(logrus.StandardLogger()))
+	updateConfiguration(v, logrus.NewEntry(logrus.StandardLogger()), nil)
 
 	if JWTSigningSecret != "testSecret" {
 		t.Errorf("expeted web.jwtsigningsecret to be %v, got %v", "testSecret", JWTSigningSecret)
 	}
+	if got := cfg.OpenPolicyAgent.Shadow; got.Host != "opa-staging:8181" || got.Percent != 12.5 {
+		t.Errorf("openpolicyagent.shadow: got %+v, want opa-staging:8181 at 12.5%%", got)
+	}
+}
+
+func TestNewConfigViper(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	config := []byte("proxy:\n  host: \":8080\"\ndatabase:\n  host: redis:6379\n  password: file-password\nopenpolicyagent:\n  host: opa:8181\n")
+	if err := os.WriteFile(file, config, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PROXY_HOST", ":9443")
+	t.Setenv("DATABASE_PASSWORD", "env-password")
+	t.Setenv("OPA_HOST", "env-opa:8181")
+	t.Setenv("WEB_CORS_ALLOWEDORIGINS", "https://a.example.com,https://b.example.com")
+	t.Setenv("NAMESPACE", "authz")
+	t.Setenv("SERVICES_STORAGE", "k8s://storage-service")
+
+	v := newConfig(logrus.NewEntry(logrus.StandardLogger()), "").Viper()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	var got Config
+	if err := v.Unmarshal(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Proxy.Host != ":9443" {
+		t.Errorf("proxy.host: got %q, want %q", got.Proxy.Host, ":9443")
+	}
+	if got.Database.Password != "env-password" {
+		t.Errorf("database.password: got %q, want %q", got.Database.Password, "env-password")
+	}
+	if got.OpenPolicyAgent.Host != "env-opa:8181" {
+		t.Errorf("openpolicyagent.host: got %q, want %q", got.OpenPolicyAgent.Host, "env-opa:8181")
+	}
+	if got.Database.Host != "redis:6379" {
+		t.Errorf("database.host: got %q, want the config file value %q", got.Database.Host, "redis:6379")
+	}
+	if want := []string{"https://a.example.com", "https://b.example.com"}; !reflect.DeepEqual(got.Web.CORS.AllowedOrigins, want) {
+		t.Errorf("web.cors.allowedorigins: got %v, want %v", got.Web.CORS.AllowedOrigins, want)
+	}
+	if want := "tenant-service.authz.svc.cluster.local:50051"; got.Services.Tenant != want {
+		t.Errorf("services.tenant: got %q, want the namespace default %q", got.Services.Tenant, want)
+	}
+	if want := "k8s://storage-service"; got.Services.Storage != want {
+		t.Errorf("services.storage: got %q, want %q", got.Services.Storage, want)
+	}
+
+	oldCfg := cfg
+	oldJWTSigningSecret := JWTSigningSecret
+	defer func() {
+		cfg = oldCfg
+		JWTSigningSecret = oldJWTSigningSecret
+	}()
+
+	// the environment keeps precedence when the config file changes
+	if updateConfiguration(v, logrus.NewEntry(logrus.StandardLogger()), nil); cfg.OpenPolicyAgent.Host != "env-opa:8181" {
+		t.Errorf("reloaded openpolicyagent.host: got %q, want %q", cfg.OpenPolicyAgent.Host, "env-opa:8181")
+	}
+}
+
+func TestServiceAddr(t *testing.T) {
+	api := &k8s.API{
+		Namespace: "authz",
+		Client: fake.NewSimpleClientset(&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "role-service", Namespace: "authz"},
+			Spec: corev1.ServiceSpec{
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: "role.example.com",
+				Ports:        []corev1.ServicePort{{Port: 50051}},
+			},
+		}),
+	}
+
+	tests := []struct {
+		name, flagAddr, cfgAddr, want string
+	}{
+		{"it uses the configured address", "", "role-service.authz.svc.cluster.local:50051", "role-service.authz.svc.cluster.local:50051"},
+		{"it prefers the flag", "localhost:50051", "role-service.authz.svc.cluster.local:50051", "localhost:50051"},
+		{"it resolves a kubernetes service", "", "k8s://role-service", "role.example.com:50051"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serviceAddr(api, tt.flagAddr, tt.cfgAddr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("it fails for a missing service", func(t *testing.T) {
+		if _, err := serviceAddr(api, "", "k8s://missing"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestProxyListeners(t *testing.T) {
+	t.Run("it listens on the configured hosts", func(t *testing.T) {
+		var cfg Config
+		cfg.Proxy.Host = ":8080"
+		cfg.Proxy.TLSHost = ":8443"
+		cfg.Proxy.TLSCertFile = "tls.crt"
+		cfg.Proxy.TLSKeyFile = "tls.key"
+		cfg.Proxy.Listeners = []Listener{
+			{Host: "[::]:9443", TLSCertFile: "admin.crt", TLSKeyFile: "admin.key", Scope: web.ScopeAdmin},
+			{Host: "0.0.0.0:9080", Scope: web.ScopeData},
+		}
+
+		got, err := proxyListeners(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []Listener{
+			{Host: ":8080", Scope: web.ScopeData},
+			{Host: ":8443", TLSCertFile: "tls.crt", TLSKeyFile: "tls.key", Scope: web.ScopeData},
+			{Host: "[::]:9443", TLSCertFile: "admin.crt", TLSKeyFile: "admin.key", Scope: web.ScopeAdmin},
+			{Host: "0.0.0.0:9080", Scope: web.ScopeData},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
+	tests := []struct {
+		name      string
+		listeners []Listener
+	}{
+		{"no listeners", nil},
+		{"missing host", []Listener{{Scope: web.ScopeData}}},
+		{"duplicate host", []Listener{{Host: ":9080"}, {Host: ":9080"}}},
+		{"missing key file", []Listener{{Host: ":9443", TLSCertFile: "tls.crt"}}},
+		{"unknown scope", []Listener{{Host: ":9080", Scope: "storage"}}},
+		{"invalid allowed cidr", []Listener{{Host: ":9443", AllowedCIDRs: []string{"10.0.0.0/33"}}}},
+	}
+	for _, tt := range tests {
+		t.Run("it fails for "+tt.name, func(t *testing.T) {
+			var cfg Config
+			cfg.Proxy.Listeners = tt.listeners
+
+			if _, err := proxyListeners(cfg); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	t.Run("it keeps admin routes off the other listeners with an admin listener", func(t *testing.T) {
+		var cfg Config
+		cfg.Proxy.Host = ":8080"
+		cfg.Proxy.Listeners = []Listener{
+			{Host: ":9443", Scope: web.ScopeAdmin, AllowedCIDRs: []string{"10.0.0.0/8"}},
+			{Host: ":9080"},
+		}
+
+		got, err := proxyListeners(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{web.ScopeData, web.ScopeAdmin, web.ScopeAll}
+		for i, l := range got {
+			if l.Scope != want[i] {
+				t.Errorf("%s: got scope %q, want %q", l.Host, l.Scope, want[i])
+			}
+		}
+	})
+	t.Run("it decodes listeners from the configuration", func(t *testing.T) {
+		v := viper.New()
+		v.SetConfigType("yaml")
+		if err := v.ReadConfig(strings.NewReader(`
+proxy:
+  listeners:
+  - host: "[::]:9443"
+    tlsCertFile: /etc/tls/tls.crt
+    tlsKeyFile: /etc/tls/tls.key
+    scope: admin
+`)); err != nil {
+			t.Fatal(err)
+		}
+		var cfg Config
+		if err := v.Unmarshal(&cfg); err != nil {
+			t.Fatal(err)
+		}
+
+		want := []Listener{{Host: "[::]:9443", TLSCertFile: "/etc/tls/tls.crt", TLSKeyFile: "/etc/tls/tls.key", Scope: web.ScopeAdmin}}
+		if !reflect.DeepEqual(cfg.Proxy.Listeners, want) {
+			t.Errorf("got %+v, want %+v", cfg.Proxy.Listeners, want)
+		}
+	})
+}
+
+func TestURLPolicyConfig(t *testing.T) {
+	t.Run("it decodes url rules from the configuration", func(t *testing.T) {
+		v := newConfig(logrus.NewEntry(logrus.StandardLogger()), "").Viper()
+		v.SetConfigType("yaml")
+		if err := v.ReadConfig(strings.NewReader(`
+proxy:
+  urlpolicy:
+    enabled: true
+    rules:
+      powerscale:
+      - method: GET
+        path: ^/platform/latest/$
+`)); err != nil {
+			t.Fatal(err)
+		}
+		var cfg Config
+		if err := v.Unmarshal(&cfg); err != nil {
+			t.Fatal(err)
+		}
+
+		want := map[string][]proxy.URLRule{"powerscale": {{Method: "GET", Path: "^/platform/latest/$"}}}
+		if !cfg.Proxy.URLPolicy.Enabled || !reflect.DeepEqual(cfg.Proxy.URLPolicy.Rules, want) {
+			t.Errorf("got %+v, want the rules %+v", cfg.Proxy.URLPolicy, want)
+		}
+	})
+	t.Run("it defaults to the default url rules", func(t *testing.T) {
+		var cfg Config
+		if err := newConfig(logrus.NewEntry(logrus.StandardLogger()), "").Viper().Unmarshal(&cfg); err != nil {
+			t.Fatal(err)
+		}
+
+		if cfg.Proxy.URLPolicy.Enabled || !reflect.DeepEqual(cfg.Proxy.URLPolicy.Rules, proxy.DefaultURLRules) {
+			t.Errorf("got %+v, want the disabled default rules", cfg.Proxy.URLPolicy)
+		}
+	})
+}
+
+func TestUpdateConfiguration_Connections(t *testing.T) {
+	oldCfg := cfg
+	oldJWTSigningSecret := JWTSigningSecret
+	defer func() {
+		cfg = oldCfg
+		JWTSigningSecret = oldJWTSigningSecret
+	}()
+
+	oldRedis, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer oldRedis.Close()
+	newRedis, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newRedis.Close()
+
+	log := logrus.NewEntry(logrus.StandardLogger())
+	conns := newConnections(log, "opa:8181", oldRedis.Addr(), "", 10*time.Millisecond)
+	defer conns.Close()
+	enf := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(conns.Redis()))
+	policyHandler := proxy.NewPolicyHandler(log, conns.Redis(), "opa:8181")
+	opaHosts := &fakeOPAClient{}
+	conns.opaClients = append(conns.opaClients, opaHosts, policyHandler)
+	conns.redisClients = append(conns.redisClients, enf, policyHandler)
+	oldClient := conns.Redis()
+
+	v := viper.New()
+	v.Set(configParamOPAHost, "new-opa:8181")
+	v.Set(configParamDatabaseHost, newRedis.Addr())
+	updateConfiguration(v, log, conns)
+
+	if opaHosts.host != "new-opa:8181" {
+		t.Errorf("opa host: got %q, want %q", opaHosts.host, "new-opa:8181")
+	}
+	if got := conns.Redis().Options().Addr; got != newRedis.Addr() {
+		t.Errorf("redis address: got %q, want %q", got, newRedis.Addr())
+	}
+
+	newRedis.HSet("tenant:mytenant:data", quota.TenantIDField, "id")
+	if _, err := enf.TenantID(context.Background(), "mytenant"); err != nil {
+		t.Errorf("expected the enforcer to use the new redis: %v", err)
+	}
+
+	// The replaced client keeps working until it is drained.
+	if err := oldClient.Ping().Err(); err != nil {
+		t.Errorf("expected the old redis client to be open: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := oldClient.Ping().Err(); err == nil {
+		t.Error("expected the old redis client to be closed")
+	}
+}
+
+type fakeOPAClient struct {
+	host string
+}
+
+func (f *fakeOPAClient) SetOPAHost(host string) {
+	f.host = host
+}
+
+func TestUsageExporter(t *testing.T) {
+	log := logrus.NewEntry(logrus.New())
+
+	t.Run("it is disabled without an interval", func(t *testing.T) {
+		var cfg Config
+		cfg.UsageExport.Format = "csv"
+
+		got, err := usageExporter(log, cfg, &mockTenant.FakeTenantServiceClient{})
+		if err != nil || got != nil {
+			t.Errorf("got %v, %v, want a disabled exporter", got, err)
+		}
+	})
+
+	t.Run("it exports csv files", func(t *testing.T) {
+		var cfg Config
+		cfg.UsageExport.Interval = time.Hour
+		cfg.UsageExport.Format = "csv"
+		cfg.UsageExport.CSV.Dir = t.TempDir()
+
+		got, err := usageExporter(log, cfg, &mockTenant.FakeTenantServiceClient{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Interval != time.Hour || !reflect.DeepEqual(got.Sink, &usage.CSVDir{Dir: cfg.UsageExport.CSV.Dir}) {
+			t.Errorf("got %+v, want a csv exporter every hour", got)
+		}
+	})
+
+	t.Run("it rejects an incomplete config", func(t *testing.T) {
+		var cfg Config
+		cfg.UsageExport.Interval = time.Hour
+		cfg.UsageExport.Format = "prometheus"
+
+		if _, err := usageExporter(log, cfg, &mockTenant.FakeTenantServiceClient{}); err == nil {
+			t.Error("expected an error without a remote-write url")
+		}
+	})
+}
+
+func TestRequiredMounts(t *testing.T) {
+	t.Run("it requires the csm-config-params", func(t *testing.T) {
+		got := requiredMounts(Config{}, Options{})
+		want := []mounts.Mount{{Name: "csm-config-params", Path: csmConfigParamsDir}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("it requires a writable directory for csv exports", func(t *testing.T) {
+		var cfg Config
+		cfg.UsageExport.Interval = time.Hour
+		cfg.UsageExport.Format = "csv"
+		cfg.UsageExport.CSV.Dir = "/var/lib/usage"
+
+		got := requiredMounts(cfg, Options{CSMConfigFile: "config.yaml"})
+		want := []mounts.Mount{
+			{Name: "csm-config-params", Path: "config.yaml"},
+			{Name: "usage export directory", Path: "/var/lib/usage", Writable: true},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
 }
 
 func TestUpdateStorageSystems(t *testing.T) {
@@ -128,7 +512,7 @@ func TestUpdateStorageSystems(t *testing.T) {
 			powerMaxHandler := proxy.NewPowerMaxHandler(logger, nil, "")
 
 			// When
-			err := updateStorageSystems(logger, fmt.Sprintf("testdata/%s", tc.storageSystemsFile), powerFlexHandler, powerMaxHandler, powerScaleHandler)
+			err := updateStorageSystems(logger, fmt.Sprintf("testdata/%s", tc.storageSystemsFile), nil, powerFlexHandler, powerMaxHandler, powerScaleHandler, nil, nil, nil)
 
 			// Then
 			tc.checkFn(t, err, powerScaleHandler.GetSystems(), powerFlexHandler.GetSystems(), powerMaxHandler.GetSystems())
@@ -136,6 +520,93 @@ func TestUpdateStorageSystems(t *testing.T) {
 	}
 }
 
+func TestDecryptStoragePasswords(t *testing.T) {
+	key, err := envelope.NewMasterKey([]byte(strings.Repeat("k", envelope.MasterKeySize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := envelope.Encrypt(key, "Password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(fmt.Sprintf(`
+storage:
+  powerflex:
+    542a2d5f5122210f:
+      User: admin
+      Password: %s
+      Endpoint: https://10.0.0.1
+`, enc))
+
+	t.Run("it decrypts the passwords", func(t *testing.T) {
+		logger := logrus.NewEntry(logrus.New())
+		powerFlexHandler := proxy.NewPowerFlexHandler(logger, nil, nil, "")
+
+		err := updateStorageSystemsData(logger, data, key, powerFlexHandler, proxy.NewPowerMaxHandler(logger, nil, ""), proxy.NewPowerScaleHandler(logger, nil, ""), nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := powerFlexHandler.GetSystems()["542a2d5f5122210f"]; !ok {
+			t.Error("expected powerFlex 542a2d5f5122210f to be configured")
+		}
+	})
+
+	t.Run("it fails without the master key", func(t *testing.T) {
+		var v map[string]interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			t.Fatal(err)
+		}
+
+		err := decryptStoragePasswords(nil, v["storage"])
+		if !errors.Is(err, envelope.ErrNoKey) {
+			t.Errorf("got err %v, want %v", err, envelope.ErrNoKey)
+		}
+	})
+
+	t.Run("it leaves plain passwords", func(t *testing.T) {
+		storage := map[string]interface{}{
+			"powermax": map[string]interface{}{
+				"000197900714": map[string]interface{}{"password": "Password123"},
+			},
+		}
+
+		if err := decryptStoragePasswords(nil, storage); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestRoleStreamAuthorizer(t *testing.T) {
+	client := &mocks.FakeRoleServiceClient{
+		ListRoleFn: func(_ context.Context, _ *pb.RoleListRequest, _ ...grpc.CallOption) (*pb.RoleListResponse, error) {
+			return &pb.RoleListResponse{Instances: []*pb.RoleInstance{
+				{Name: "role-1", StorageType: "powerflex", SystemId: "542a2d5f5122210f", Pool: "bronze"},
+				{Name: "role-2", StorageType: "powermax", SystemId: "000197900714", Pool: "SRP_1"},
+			}}, nil
+		},
+	}
+	authorize := roleStreamAuthorizer(client)
+
+	tests := map[string]struct {
+		roles   string
+		wantErr bool
+	}{
+		"role on the system":     {roles: "role-2,role-1", wantErr: false},
+		"no role on the system":  {roles: "role-2", wantErr: true},
+		"unknown role":           {roles: "role-3", wantErr: true},
+		"no roles in the claims": {roles: "", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := authorize(context.Background(), token.Claims{Group: "tenant", Roles: tc.roles}, "powerflex", "542a2d5f5122210f")
+			if (err != nil) != tc.wantErr {
+				t.Errorf("got err %v, want err %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
 func TestVolumesHandler(t *testing.T) {
 	ctx := context.Background()
 	log := logrus.New().WithContext(ctx)
@@ -201,11 +672,11 @@ func TestVolumesHandler(t *testing.T) {
 			}
 
 			// create volume
-			rdb.HSetNX("quota:powerflex:542a2d5f5122210f:bronze:PancakeGroup-0:data", "vol:k8s-6aac50817e:capacity", 1)
+			rdb.HSetNX(fmt.Sprintf("quota:powerflex:542a2d5f5122210f:bronze:%s:data", tenantID(t, rdb, name)), "vol:k8s-6aac50817e:capacity", 1)
 
 			// list volumes test
 
-			h := volumesHandler(&roleClientService{roleService: roleSvc}, &storageClientService{storageClient: storageClient}, rdb, jwx.NewTokenManager(jwx.HS256), log)
+			h := volumesHandler(&roleClientService{roleService: roleSvc}, &storageClientService{storageClient: storageClient}, func() *redis.Client { return rdb }, jwx.NewTokenManager(jwx.HS256), log)
 			w := httptest.NewRecorder()
 			r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/proxy/volumes/", nil)
 			r.Header.Add("Authorization", "Bearer "+string(decAccTkn))
@@ -296,11 +767,11 @@ func TestVolumesHandler(t *testing.T) {
 				},
 			}
 			// create volume
-			rdb.HSetNX("quota:powerflex:542a2d5f5122210f:bronze:PancakeGroup-1:data", "vol:k8s-6aac50817e:capacity", 1)
+			rdb.HSetNX(fmt.Sprintf("quota:powerflex:542a2d5f5122210f:bronze:%s:data", tenantID(t, rdb, name)), "vol:k8s-6aac50817e:capacity", 1)
 
 			// list volumes test
 
-			h := volumesHandler(&roleClientService{roleService: rolesSvc}, &storageClientService{storageClient: storageClient}, rdb, jwx.NewTokenManager(jwx.HS256), log)
+			h := volumesHandler(&roleClientService{roleService: rolesSvc}, &storageClientService{storageClient: storageClient}, func() *redis.Client { return rdb }, jwx.NewTokenManager(jwx.HS256), log)
 			w := httptest.NewRecorder()
 			r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/proxy/volumes/", nil)
 			r.Header.Add("Authorization", "Bearer "+string(decAccTkn))
@@ -381,11 +852,11 @@ func TestVolumesHandler(t *testing.T) {
 			}
 
 			// create volume
-			rdb.HSetNX("quota:powerflex:542a2d5f5122210f:bronze:PancakeGroup-2:data", "vol:k8s-6aac50817e:capacity", 1)
+			rdb.HSetNX(fmt.Sprintf("quota:powerflex:542a2d5f5122210f:bronze:%s:data", tenantID(t, rdb, name)), "vol:k8s-6aac50817e:capacity", 1)
 
 			// list volumes test
 
-			h := volumesHandler(&roleClientService{roleService: roleSvc}, &storageClientService{storageClient: storageClient}, rdb, jwx.NewTokenManager(jwx.HS256), log)
+			h := volumesHandler(&roleClientService{roleService: roleSvc}, &storageClientService{storageClient: storageClient}, func() *redis.Client { return rdb }, jwx.NewTokenManager(jwx.HS256), log)
 			w := httptest.NewRecorder()
 			r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/proxy/volumes/", nil)
 			r.Header.Add("Authorization", "Bearer "+string(decAccTkn))
@@ -394,9 +865,16 @@ func TestVolumesHandler(t *testing.T) {
 
 			h.ServeHTTP(w, r)
 
-			// check if endpoint returns internalErrorServer status
-			if got := w.Result().StatusCode; got != http.StatusInternalServerError {
-				t.Errorf("got %d, want %d", got, http.StatusInternalServerError)
+			// check if endpoint returns not found status
+			if got := w.Result().StatusCode; got != http.StatusNotFound {
+				t.Errorf("got %d, want %d", got, http.StatusNotFound)
+			}
+			var body web.JSONError
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Reason != web.CodeNotFound {
+				t.Errorf("got reason %q, want %q", body.Reason, web.CodeNotFound)
 			}
 			return
 		},
@@ -460,12 +938,12 @@ func TestVolumesHandler(t *testing.T) {
 			}
 
 			// create volume
-			rdb.HSetNX("quota:powerflex:542a2d5f5122210f:bronze:PancakeGroup-3:data", "vol:k8s-6aac50817e:capacity", 1)
-			rdb.HSetNX("quota:powerflex:542a2d5f5122210f:steel:PancakeGroup-3:data", "vol:k8s-6aac50818e:capacity", 1)
+			rdb.HSetNX(fmt.Sprintf("quota:powerflex:542a2d5f5122210f:bronze:%s:data", tenantID(t, rdb, name)), "vol:k8s-6aac50817e:capacity", 1)
+			rdb.HSetNX(fmt.Sprintf("quota:powerflex:542a2d5f5122210f:steel:%s:data", tenantID(t, rdb, name)), "vol:k8s-6aac50818e:capacity", 1)
 
 			// list volumes test
 
-			h := volumesHandler(&roleClientService{roleService: roleSvc}, &storageClientService{storageClient: storageClient}, rdb, jwx.NewTokenManager(jwx.HS256), log)
+			h := volumesHandler(&roleClientService{roleService: roleSvc}, &storageClientService{storageClient: storageClient}, func() *redis.Client { return rdb }, jwx.NewTokenManager(jwx.HS256), log)
 			w := httptest.NewRecorder()
 			r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/proxy/volumes/", nil)
 			r.Header.Add("Authorization", "Bearer "+string(decAccTkn))
@@ -556,12 +1034,12 @@ func TestVolumesHandler(t *testing.T) {
 			}
 
 			// create volume
-			rdb.HSetNX("quota:powerflex:542a2d5f5122210f:bronze:PancakeGroup-4:data", "vol:k8s-6aac50817e:capacity", 1)
-			rdb.HSetNX("quota:powerflex:542a2d5f5122210f:bronze:PancakeGroup-4:data", "vol:k8s-6aac50818e:deleted", 1)
+			rdb.HSetNX(fmt.Sprintf("quota:powerflex:542a2d5f5122210f:bronze:%s:data", tenantID(t, rdb, name)), "vol:k8s-6aac50817e:capacity", 1)
+			rdb.HSetNX(fmt.Sprintf("quota:powerflex:542a2d5f5122210f:bronze:%s:data", tenantID(t, rdb, name)), "vol:k8s-6aac50818e:deleted", 1)
 
 			// list volumes test
 
-			h := volumesHandler(&roleClientService{roleService: roleSvc}, &storageClientService{storageClient: storageClient}, rdb, jwx.NewTokenManager(jwx.HS256), log)
+			h := volumesHandler(&roleClientService{roleService: roleSvc}, &storageClientService{storageClient: storageClient}, func() *redis.Client { return rdb }, jwx.NewTokenManager(jwx.HS256), log)
 			w := httptest.NewRecorder()
 			r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/proxy/volumes/", nil)
 			r.Header.Add("Authorization", "Bearer "+string(decAccTkn))
@@ -602,6 +1080,14 @@ func TestVolumesHandler(t *testing.T) {
 	}
 }
 
+// tenantID returns the UUID that the named tenant's quota data is keyed by.
+func tenantID(t *testing.T, rdb *redis.Client, name string) string {
+	t.Helper()
+	id, err := rdb.HGet(fmt.Sprintf("tenant:%s:data", name), "uuid").Result()
+	checkError(t, err)
+	return id
+}
+
 func checkError(t *testing.T, err error) {
 	t.Helper()
 	if err != nil {
@@ -722,3 +1208,54 @@ type successfulStorageValidator struct{}
 func (v successfulStorageValidator) Validate(_ context.Context, _ string, _ string, _ cmd.System) error {
 	return nil
 }
+
+func TestRefreshAdminTokenHandler(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	tp, err := token.Create(jwx.NewTokenManager(jwx.HS256), token.Config{
+		AdminName:         "admin",
+		Subject:           "admin",
+		JWTSigningSecret:  JWTSigningSecret,
+		RefreshExpiration: time.Hour,
+		AccessExpiration:  -time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(token.AdminToken{Access: tp.Access, Refresh: tp.Refresh})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := session.NewStore(func() *redis.Client { return rdb })
+	h := refreshAdminTokenHandler(logrus.NewEntry(logrus.New()), sessions)
+	refresh := func() int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, web.VersionedPath(web.RouteRefreshAdmin), bytes.NewReader(body)))
+		return w.Code
+	}
+
+	if got := refresh(); got != http.StatusOK {
+		t.Fatalf("first refresh: got %d, want %d", got, http.StatusOK)
+	}
+	list, err := sessions.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Admin != "admin" {
+		t.Fatalf("sessions: got %+v, want one session for admin", list)
+	}
+
+	if err := sessions.Revoke(list[0].ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := refresh(); got != http.StatusUnauthorized {
+		t.Errorf("refresh after revoke: got %d, want %d", got, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxyserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCertificateReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	writeKeyPair := func(t *testing.T, serial int64, modTime time.Time) {
+		t.Helper()
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "karavi-auth"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(certFile, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	serial := func(t *testing.T, r *certificateReloader) int64 {
+		t.Helper()
+		cert, err := r.GetCertificate(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return leaf.SerialNumber.Int64()
+	}
+	modTime := time.Now().Add(-time.Minute)
+
+	t.Run("it fails without a key pair", func(t *testing.T) {
+		r := &certificateReloader{certFile: certFile, keyFile: keyFile}
+
+		if _, err := r.GetCertificate(nil); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	r := &certificateReloader{certFile: certFile, keyFile: keyFile}
+	writeKeyPair(t, 1, modTime)
+	if got := serial(t, r); got != 1 {
+		t.Fatalf("got serial %d, want 1", got)
+	}
+
+	t.Run("it reloads a changed certificate", func(t *testing.T) {
+		modTime = modTime.Add(time.Second)
+		writeKeyPair(t, 2, modTime)
+
+		if got := serial(t, r); got != 2 {
+			t.Errorf("got serial %d, want 2", got)
+		}
+	})
+	t.Run("it keeps the certificate when the reload fails", func(t *testing.T) {
+		if err := os.WriteFile(keyFile, []byte("invalid"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		modTime = modTime.Add(time.Second)
+		if err := os.Chtimes(certFile, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := serial(t, r); got != 2 {
+			t.Errorf("got serial %d, want 2", got)
+		}
+	})
+}
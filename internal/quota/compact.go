@@ -0,0 +1,189 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CompactOptions are the limits that Compact enforces on the quota data.
+type CompactOptions struct {
+	// DeletedRetention is how long the fields of a deleted volume are kept
+	// after it was deleted. They are kept forever when it is 0.
+	DeletedRetention time.Duration `json:"deletedRetention,omitempty"`
+	// StreamMaxLen is how many of the latest entries of each quota stream
+	// are kept. Streams are not trimmed when it is 0.
+	StreamMaxLen int64 `json:"streamMaxLen,omitempty"`
+}
+
+// CompactResult is what Compact removed.
+type CompactResult struct {
+	// Keys is the number of data keys that were compacted.
+	Keys int `json:"keys"`
+	// Volumes is the number of deleted volumes whose fields were removed.
+	Volumes int `json:"volumes"`
+	// StreamEntries is the number of stream entries that were trimmed.
+	StreamEntries int `json:"streamEntries"`
+}
+
+// compactDeletedScript removes the fields of the volumes that were deleted
+// at or before the cutoff, in seconds since the Unix epoch. It returns the
+// number of volumes removed.
+const compactDeletedScript = `
+local key = KEYS[1]
+local cutoff = tonumber(ARGV[1])
+local fields = redis.call('HGETALL', key)
+local removed = 0
+for i = 1, #fields, 2 do
+  local ref = string.match(fields[i], '^vol:(.+):deleted$')
+  local deletedAt = tonumber(fields[i + 1])
+  if ref and deletedAt and deletedAt <= cutoff then
+    local prefix = 'vol:' .. ref .. ':'
+    redis.call('HDEL', key,
+      prefix .. 'approved',
+      prefix .. 'capacity',
+      prefix .. 'created',
+      prefix .. 'deleting',
+      prefix .. 'deleted',
+      prefix .. 'name')
+    removed = removed + 1
+  end
+end
+return removed
+`
+
+// trimStreamScript trims a stream to its latest entries and returns the
+// number of entries removed.
+const trimStreamScript = `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return 0
+end
+return redis.call('XTRIM', KEYS[1], 'MAXLEN', tonumber(ARGV[1]))
+`
+
+// Compact removes the fields of the volumes that were deleted longer ago
+// than the retention, and trims the quota streams, so that the quota data
+// of tenants does not grow forever. Volumes deleted before the time of
+// deletion was kept are removed with any retention.
+func (e *RedisEnforcement) Compact(ctx context.Context, opts CompactOptions) (CompactResult, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "Compact")
+	defer span.End()
+
+	var res CompactResult
+	if opts.DeletedRetention > 0 {
+		keys, err := e.db().Scan("quota:*:data")
+		if err != nil {
+			return res, err
+		}
+		cutoff := e.now().Add(-opts.DeletedRetention).Unix()
+		for _, key := range keys {
+			n, err := e.db().EvalInt(compactDeletedScript, []string{key}, cutoff)
+			if err != nil {
+				return res, err
+			}
+			if n > 0 {
+				res.Keys++
+				res.Volumes += n
+			}
+		}
+	}
+	if opts.StreamMaxLen > 0 {
+		keys, err := e.db().Scan("quota:*:stream")
+		if err != nil {
+			return res, err
+		}
+		for _, key := range keys {
+			n, err := e.db().EvalInt(trimStreamScript, []string{key}, opts.StreamMaxLen)
+			if err != nil {
+				return res, err
+			}
+			res.StreamEntries += n
+		}
+	}
+	span.SetAttributes(attribute.Int("volumes", res.Volumes), attribute.Int("stream_entries", res.StreamEntries))
+	return res, nil
+}
+
+// KeyspaceSize is the size of the quota data of a tenant in redis.
+type KeyspaceSize struct {
+	Tenant string
+	// Keys is the number of data and stream keys.
+	Keys int
+	// Fields is the number of fields of the data keys.
+	Fields int
+	// StreamEntries is the number of entries of the stream keys.
+	StreamEntries int
+}
+
+const hashLenScript = `return redis.call('HLEN', KEYS[1])`
+
+const streamLenScript = `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return 0
+end
+return redis.call('XLEN', KEYS[1])
+`
+
+// KeyspaceSizes returns the size of the quota data of every tenant, sorted
+// by tenant.
+func (e *RedisEnforcement) KeyspaceSizes(ctx context.Context) ([]KeyspaceSize, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "KeyspaceSizes")
+	defer span.End()
+
+	sizes := make(map[string]*KeyspaceSize)
+	for _, kind := range []struct {
+		suffix string
+		script string
+		count  func(*KeyspaceSize, int)
+	}{
+		{":data", hashLenScript, func(s *KeyspaceSize, n int) { s.Fields += n }},
+		{":stream", streamLenScript, func(s *KeyspaceSize, n int) { s.StreamEntries += n }},
+	} {
+		keys, err := e.db().Scan("quota:*" + kind.suffix)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			// e.g. quota:<type>:<system>:<pool>:<tenant>:data; the tenant
+			// comes last, so the pool may contain colons.
+			rest := strings.TrimSuffix(key, kind.suffix)
+			tenant := rest[strings.LastIndex(rest, ":")+1:]
+			n, err := e.db().EvalInt(kind.script, []string{key})
+			if err != nil {
+				return nil, err
+			}
+			s, ok := sizes[tenant]
+			if !ok {
+				s = &KeyspaceSize{Tenant: tenant}
+				sizes[tenant] = s
+			}
+			s.Keys++
+			kind.count(s, n)
+		}
+	}
+
+	res := make([]KeyspaceSize, 0, len(sizes))
+	for _, s := range sizes {
+		res = append(res, *s)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Tenant < res[j].Tenant })
+	return res, nil
+}
@@ -0,0 +1,164 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota_test
+
+import (
+	"context"
+	"karavi-authorization/internal/quota"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+func TestRedisEnforcement_Compact(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	sut := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rc), quota.WithNow(func() time.Time { return now }))
+	ctx := context.Background()
+
+	deleteVolume := func(t *testing.T, r quota.Request, at time.Time) {
+		t.Helper()
+		sut := quota.NewRedisEnforcement(ctx, quota.WithRedis(rc), quota.WithNow(func() time.Time { return at }))
+		if ok, err := sut.ApproveRequest(ctx, r, quota.Unlimited); err != nil || !ok {
+			t.Fatalf("approving request: %v, %v", ok, err)
+		}
+		if ok, err := sut.PublishCreated(ctx, r); err != nil || !ok {
+			t.Fatalf("publishing created: %v, %v", ok, err)
+		}
+		if ok, err := sut.DeleteRequest(ctx, r); err != nil || !ok {
+			t.Fatalf("deleting: %v, %v", ok, err)
+		}
+		if ok, err := sut.PublishDeleted(ctx, r); err != nil || !ok {
+			t.Fatalf("publishing deleted: %v, %v", ok, err)
+		}
+	}
+
+	t.Run("it keeps the time a volume was deleted", func(t *testing.T) {
+		mr.FlushAll()
+		r := buildRequest()
+		deleteVolume(t, r, now)
+
+		if got, want := mr.HGet(r.DataKey(), r.DeletedField()), strconv.FormatInt(now.Unix(), 10); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+	t.Run("it removes the volumes deleted before the retention", func(t *testing.T) {
+		mr.FlushAll()
+		old := buildRequest()
+		old.VolumeID = "0a1b2c3d00000001"
+		deleteVolume(t, old, now.Add(-48*time.Hour))
+		recent := buildRequest()
+		recent.VolumeName = "k8s-recent"
+		recent.VolumeID = "0a1b2c3d00000002"
+		deleteVolume(t, recent, now.Add(-time.Hour))
+		legacy := buildRequest()
+		legacy.VolumeName = "k8s-legacy"
+		mr.HSet(legacy.DataKey(), legacy.ApprovedField(), "1")
+		mr.HSet(legacy.DataKey(), legacy.DeletedField(), "1")
+		live := buildRequest()
+		live.VolumeName = "k8s-live"
+		if ok, err := sut.ApproveRequest(ctx, live, quota.Unlimited); err != nil || !ok {
+			t.Fatalf("approving request: %v, %v", ok, err)
+		}
+
+		got, err := sut.Compact(ctx, quota.CompactOptions{DeletedRetention: 24 * time.Hour})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := (quota.CompactResult{Keys: 1, Volumes: 2}); got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+		for _, r := range []quota.Request{old, legacy} {
+			for _, f := range []string{r.ApprovedField(), r.CapacityField(), r.CreatedField(), r.DeletedField(), r.NameField()} {
+				if mr.HGet(r.DataKey(), f) != "" {
+					t.Errorf("expected %s to be removed", f)
+				}
+			}
+		}
+		if mr.HGet(recent.DataKey(), recent.DeletedField()) == "" {
+			t.Error("expected the recently deleted volume to be kept")
+		}
+		if mr.HGet(live.DataKey(), live.ApprovedField()) == "" {
+			t.Error("expected the volume that was not deleted to be kept")
+		}
+	})
+	t.Run("it trims the streams", func(t *testing.T) {
+		mr.FlushAll()
+		r := buildRequest()
+		deleteVolume(t, r, now)
+
+		got, err := sut.Compact(ctx, quota.CompactOptions{StreamMaxLen: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := (quota.CompactResult{StreamEntries: 3}); got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+		if n := rc.XLen(r.StreamKey()).Val(); n != 1 {
+			t.Errorf("got %d stream entries, want 1", n)
+		}
+		if mr.HGet(r.DataKey(), r.DeletedField()) == "" {
+			t.Error("expected the deleted volume to be kept without a retention")
+		}
+	})
+}
+
+func TestRedisEnforcement_KeyspaceSizes(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	sut := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rc))
+	ctx := context.Background()
+
+	r := buildRequest()
+	other := buildRequest()
+	other.StoragePoolID = "pool:with:colons"
+	other.VolumeName = "k8s-other"
+	third := buildRequest()
+	third.Group = "othertenant"
+	for _, r := range []quota.Request{r, other, third} {
+		if ok, err := sut.ApproveRequest(ctx, r, quota.Unlimited); err != nil || !ok {
+			t.Fatalf("approving request: %v, %v", ok, err)
+		}
+	}
+
+	got, err := sut.KeyspaceSizes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := int(rc.HLen(r.DataKey()).Val())
+	want := []quota.KeyspaceSize{
+		{Tenant: "mytenant", Keys: 4, Fields: fields * 2, StreamEntries: 2},
+		{Tenant: "othertenant", Keys: 2, Fields: fields, StreamEntries: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
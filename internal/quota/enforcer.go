@@ -18,9 +18,15 @@ package quota
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-redis/redis"
 	"go.opentelemetry.io/otel/attribute"
@@ -36,8 +42,12 @@ type DB interface {
 	HExists(key, field string) (bool, error)
 	HSetNX(key, field string, value interface{}) (bool, error)
 	HGet(key, field string) (string, error)
+	HMGet(key string, fields ...string) ([]interface{}, error)
+	HGetAll(key string) (map[string]string, error)
 	EvalInt(script string, keys []string, args ...interface{}) (int, error)
+	EvalIntBatch(script string, evals []EvalArgs) ([]int, error)
 	XRange(stream, start, stop string) ([]redis.XMessage, error)
+	Scan(match string) ([]string, error)
 }
 
 // RedisDB wraps a real redis client and adapts it
@@ -68,9 +78,73 @@ func (r *RedisDB) HGet(key, field string) (string, error) {
 	return r.Client.HGet(key, field).Result()
 }
 
-// EvalInt wraps the original EvalInt method.
+// HMGet wraps the original HMGet method.
+func (r *RedisDB) HMGet(key string, fields ...string) ([]interface{}, error) {
+	return r.Client.HMGet(key, fields...).Result()
+}
+
+// HGetAll wraps the original HGetAll method.
+func (r *RedisDB) HGetAll(key string) (map[string]string, error) {
+	return r.Client.HGetAll(key).Result()
+}
+
+// scripts caches the SHA1 digest of each script that has been run, so
+// that it only has to be sent to Redis once.
+var scripts sync.Map
+
+func loadScript(src string) *redis.Script {
+	if s, ok := scripts.Load(src); ok {
+		return s.(*redis.Script)
+	}
+	s, _ := scripts.LoadOrStore(src, redis.NewScript(src))
+	return s.(*redis.Script)
+}
+
+// EvalInt runs the script with EVALSHA, falling back to EVAL if Redis
+// does not have the script cached yet.
 func (r *RedisDB) EvalInt(script string, keys []string, args ...interface{}) (int, error) {
-	return r.Client.Eval(script, keys, args...).Int()
+	return loadScript(script).Run(r.Client, keys, args...).Int()
+}
+
+// EvalIntBatch evaluates the script once for each of the evals in a single
+// pipeline.
+func (r *RedisDB) EvalIntBatch(script string, evals []EvalArgs) ([]int, error) {
+	s := loadScript(script)
+	cmds, err := r.evalBatch(func(pipe redis.Pipeliner, e EvalArgs) *redis.Cmd {
+		return s.EvalSha(pipe, e.Keys, e.Args...)
+	}, evals)
+	if err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT ") {
+		cmds, err = r.evalBatch(func(pipe redis.Pipeliner, e EvalArgs) *redis.Cmd {
+			return s.Eval(pipe, e.Keys, e.Args...)
+		}, evals)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]int, len(cmds))
+	for i, cmd := range cmds {
+		n, err := cmd.Int()
+		if err != nil {
+			return nil, err
+		}
+		res[i] = n
+	}
+	return res, nil
+}
+
+func (r *RedisDB) evalBatch(eval func(redis.Pipeliner, EvalArgs) *redis.Cmd, evals []EvalArgs) ([]*redis.Cmd, error) {
+	pipe := r.Client.Pipeline()
+	defer pipe.Close()
+
+	cmds := make([]*redis.Cmd, len(evals))
+	for i, e := range evals {
+		cmds[i] = eval(pipe, e)
+	}
+	if _, err := pipe.Exec(); err != nil {
+		return nil, err
+	}
+	return cmds, nil
 }
 
 // XRange wraps the original XRange method.
@@ -78,9 +152,30 @@ func (r *RedisDB) XRange(stream, start, stop string) ([]redis.XMessage, error) {
 	return r.Client.XRange(stream, start, stop).Result()
 }
 
+// Scan returns the keys matching the pattern, iterating with SCAN.
+func (r *RedisDB) Scan(match string) ([]string, error) {
+	var keys []string
+	iter := r.Client.Scan(0, match, 0).Iterator()
+	for iter.Next() {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// EvalArgs are the keys and arguments of a single script evaluation.
+type EvalArgs struct {
+	Keys []string
+	Args []interface{}
+}
+
 // RedisEnforcement is a wrapper around a redis client to approve requests.
 type RedisEnforcement struct {
+	mu  sync.RWMutex // guards rdb
 	rdb DB
+
+	grace       time.Duration
+	now         func() time.Time
+	onSoftQuota func(context.Context, Request)
 }
 
 // VolumeData is data about a backend storage volume.
@@ -110,15 +205,68 @@ func WithDB(db DB) Option {
 	}
 }
 
+// WithGracePeriod sets how long a tenant may use more capacity than the
+// soft quota of a Request before requests beyond the soft quota are
+// denied. With no grace period, requests beyond the soft quota are always
+// approved up to the quota.
+func WithGracePeriod(d time.Duration) Option {
+	return func(v *RedisEnforcement) {
+		v.grace = d
+	}
+}
+
+// WithSoftQuotaExceeded sets a function that is called when a Request is
+// approved beyond its soft quota, e.g. to log or alert on it.
+func WithSoftQuotaExceeded(fn func(context.Context, Request)) Option {
+	return func(v *RedisEnforcement) {
+		v.onSoftQuota = fn
+	}
+}
+
+// WithNow sets the function returning the current time, which the grace
+// period is measured with.
+func WithNow(now func() time.Time) Option {
+	return func(v *RedisEnforcement) {
+		v.now = now
+	}
+}
+
 // NewRedisEnforcement returns a new RedisEnforcement.
 func NewRedisEnforcement(_ context.Context, opts ...Option) *RedisEnforcement {
-	v := &RedisEnforcement{}
+	v := &RedisEnforcement{now: time.Now}
 	for _, opt := range opts {
 		opt(v)
 	}
 	return v
 }
 
+// SetRedis changes the redis client used to approve requests. Requests
+// already being approved finish with the previous client.
+func (e *RedisEnforcement) SetRedis(rdb *redis.Client) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rdb = &RedisDB{Client: rdb}
+}
+
+func (e *RedisEnforcement) db() DB {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.rdb
+}
+
+// Unlimited is the quota of a tenant whose capacity in a storage pool is
+// not capped. A quota of 0 approves no capacity.
+const Unlimited int64 = -1
+
+// ErrMaxVolumes is the error for a volume request that would exceed the
+// maximum number of volumes of a tenant in a storage pool.
+var ErrMaxVolumes = errors.New("maximum number of volumes reached")
+
+// ErrGracePeriodExpired is the error for a volume request beyond the soft
+// quota of a tenant that has been using more than the soft quota for longer
+// than the grace period.
+var ErrGracePeriodExpired = errors.New("soft quota grace period expired")
+
 // Request is a request to redis.
 type Request struct {
 	SystemType    string `json:"system_type"`
@@ -126,12 +274,24 @@ type Request struct {
 	StoragePoolID string `json:"storage_pool_id"`
 	Group         string `json:"group"`
 	VolumeName    string `json:"volume_name"`
-	Capacity      string `json:"capacity"`
+	// VolumeID is the ID the array assigned to the volume, which the volume
+	// is tracked by once it is known. Volumes are tracked by name until
+	// then, and volumes tracked by name before are migrated to their ID the
+	// next time they are changed with it.
+	VolumeID string `json:"volume_id,omitempty"`
+	Capacity string `json:"capacity"`
+	// MaxVolumes is the maximum number of volumes the tenant may have in
+	// the storage pool. There is no maximum when it is 0.
+	MaxVolumes int64 `json:"max_volumes,omitempty"`
+	// SoftQuota is the capacity, in kilobytes, the tenant may exceed in the
+	// storage pool only for the grace period. There is no soft quota when
+	// it is 0.
+	SoftQuota int64 `json:"soft_quota,omitempty"`
 }
 
 // Ping pings the redis instance.
 func (e *RedisEnforcement) Ping() error {
-	res, err := e.rdb.Ping()
+	res, err := e.db().Ping()
 	if err != nil {
 		return err
 	}
@@ -149,29 +309,63 @@ func (r Request) StreamKey() string {
 	return fmt.Sprintf("quota:%s:%s:%s:%s:stream", r.SystemType, r.SystemID, r.StoragePoolID, r.Group)
 }
 
+// volumeRef returns what the fields of the Request volume are keyed by: its
+// ID, or its name if the ID is not known.
+func (r Request) volumeRef() string {
+	if r.VolumeID != "" {
+		return r.VolumeID
+	}
+	return r.VolumeName
+}
+
+// byName returns the Request for the volume tracked by name.
+func (r Request) byName() Request {
+	r.VolumeID = ""
+	return r
+}
+
 // ApprovedField returns a redis formatted approved string with the Request volume.
 func (r Request) ApprovedField() string {
-	return fmt.Sprintf("vol:%s:approved", r.VolumeName)
+	return fmt.Sprintf("vol:%s:approved", r.volumeRef())
 }
 
 // CapacityField returns a redis formatted capacity string with the Request volume.
 func (r Request) CapacityField() string {
-	return fmt.Sprintf("vol:%s:capacity", r.VolumeName)
+	return fmt.Sprintf("vol:%s:capacity", r.volumeRef())
 }
 
 // CreatedField returns a redis formatted created string with the Request volume.
 func (r Request) CreatedField() string {
-	return fmt.Sprintf("vol:%s:created", r.VolumeName)
+	return fmt.Sprintf("vol:%s:created", r.volumeRef())
 }
 
 // DeletingField returns a redis formatted deleting string with the Request volume.
 func (r Request) DeletingField() string {
-	return fmt.Sprintf("vol:%s:deleting", r.VolumeName)
+	return fmt.Sprintf("vol:%s:deleting", r.volumeRef())
 }
 
-// DeletedField returns a redis formatted deleted string with the Request volume.
+// DeletedField returns a redis formatted deleted string with the Request
+// volume. It holds the time, in seconds since the Unix epoch, the volume
+// was deleted, or 1 for volumes deleted before the time was kept.
 func (r Request) DeletedField() string {
-	return fmt.Sprintf("vol:%s:deleted", r.VolumeName)
+	return fmt.Sprintf("vol:%s:deleted", r.volumeRef())
+}
+
+// NameField returns the redis formatted field holding the name of the
+// Request volume when it is tracked by ID.
+func (r Request) NameField() string {
+	return fmt.Sprintf("vol:%s:name", r.volumeRef())
+}
+
+// NameIndexField returns the redis formatted field holding the ID of the
+// volume with the Request volume name that has not been deleted.
+func (r Request) NameIndexField() string {
+	return fmt.Sprintf("name:%s", r.VolumeName)
+}
+
+// volumeFields returns the fields of the Request volume.
+func (r Request) volumeFields() []interface{} {
+	return []interface{}{r.ApprovedField(), r.CapacityField(), r.CreatedField(), r.DeletingField(), r.DeletedField()}
 }
 
 // ApprovedCapacityField returns the redis formatted approved capacity field.
@@ -179,6 +373,117 @@ func (r Request) ApprovedCapacityField() string {
 	return "approved_capacity"
 }
 
+// ApprovedVolumesField returns the redis formatted approved volumes field.
+func (r Request) ApprovedVolumesField() string {
+	return "approved_volumes"
+}
+
+// SoftQuotaExceededField returns the redis formatted field holding the
+// time, in seconds since the Unix epoch, the approved capacity exceeded the
+// soft quota.
+func (r Request) SoftQuotaExceededField() string {
+	return "soft_quota_exceeded_at"
+}
+
+// TenantIDField is the field of a tenant's data hash that holds the
+// tenant UUID assigned by the tenant service.
+const TenantIDField = "uuid"
+
+// MaxVolumesField is the field of a tenant's data hash that holds the
+// maximum number of volumes the tenant may have in each storage pool.
+const MaxVolumesField = "max_volumes"
+
+// MaxVolumes returns the maximum number of volumes the named tenant may have
+// in each storage pool, or 0 if there is no maximum.
+func (e *RedisEnforcement) MaxVolumes(ctx context.Context, name string) (int64, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "MaxVolumes")
+	defer span.End()
+
+	v, err := e.db().HGet(fmt.Sprintf("tenant:%s:data", name), MaxVolumesField)
+	switch err {
+	case nil:
+	case redis.Nil:
+		return 0, nil
+	default:
+		return 0, err
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse max volumes: %w", err)
+	}
+	return n, nil
+}
+
+// Fields of a tenant's data hash that hold the volume name policy of the
+// tenant.
+const (
+	VolumePrefixField  = "volume_prefix"
+	VolumePatternField = "volume_pattern"
+)
+
+// ErrVolumeName is the error for a volume request whose volume name does not
+// conform to the volume name policy of the tenant.
+var ErrVolumeName = errors.New("volume name not allowed")
+
+// VolumeNamePolicy is the policy the names of the volumes a tenant creates
+// must conform to. The names must start with Prefix and match the regular
+// expression Pattern, unless they are empty.
+type VolumeNamePolicy struct {
+	Prefix  string `json:"prefix"`
+	Pattern string `json:"pattern"`
+}
+
+// Check returns an error wrapping ErrVolumeName if name does not conform to
+// the policy. The reasons are the ones the OPA volume create policies give.
+func (p VolumeNamePolicy) Check(name string) error {
+	if p.Prefix != "" && !strings.HasPrefix(name, p.Prefix) {
+		return fmt.Errorf("%w: %s does not start with %s", ErrVolumeName, name, p.Prefix)
+	}
+	if p.Pattern != "" {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return fmt.Errorf("parse volume pattern: %w", err)
+		}
+		if !re.MatchString(name) {
+			return fmt.Errorf("%w: %s does not match %s", ErrVolumeName, name, p.Pattern)
+		}
+	}
+	return nil
+}
+
+// VolumeNamePolicy returns the volume name policy of the named tenant. The
+// policy allows any name if none is set.
+func (e *RedisEnforcement) VolumeNamePolicy(ctx context.Context, name string) (VolumeNamePolicy, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "VolumeNamePolicy")
+	defer span.End()
+
+	vals, err := e.db().HMGet(fmt.Sprintf("tenant:%s:data", name), VolumePrefixField, VolumePatternField)
+	if err != nil {
+		return VolumeNamePolicy{}, err
+	}
+	var p VolumeNamePolicy
+	p.Prefix, _ = vals[0].(string)
+	p.Pattern, _ = vals[1].(string)
+	return p, nil
+}
+
+// TenantID returns the UUID of the named tenant, which quota data is keyed
+// by. Tenants that have not been assigned a UUID yet are identified by name.
+func (e *RedisEnforcement) TenantID(ctx context.Context, name string) (string, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "TenantID")
+	defer span.End()
+
+	id, err := e.db().HGet(fmt.Sprintf("tenant:%s:data", name), TenantIDField)
+	switch err {
+	case nil:
+		return id, nil
+	case redis.Nil:
+		return name, nil
+	default:
+		return "", err
+	}
+}
+
 // ValidateOwnership validates ownership of a storage resource against the
 // given tenant.
 func (e *RedisEnforcement) ValidateOwnership(ctx context.Context, r Request) (bool, error) {
@@ -191,112 +496,324 @@ func (e *RedisEnforcement) ValidateOwnership(ctx context.Context, r Request) (bo
 	defer func() {
 		span.AddEvent("ValidateOwnership", trace.WithAttributes(attribute.Bool("validated", ok)))
 	}()
-	ok, err = e.rdb.HExists(r.DataKey(), r.CreatedField())
+	ok, err = e.db().HExists(r.DataKey(), r.CreatedField())
+	if err != nil || ok {
+		return ok, err
+	}
+
+	switch {
+	case r.VolumeID != "" && r.VolumeName != "":
+		// The volume may not have been migrated to its ID yet.
+		ok, err = e.db().HExists(r.DataKey(), r.byName().CreatedField())
+	case r.VolumeID == "":
+		var id string
+		id, err = e.db().HGet(r.DataKey(), r.NameIndexField())
+		switch err {
+		case nil:
+			byID := r
+			byID.VolumeID = id
+			ok, err = e.db().HExists(r.DataKey(), byID.CreatedField())
+		case redis.Nil:
+			err = nil
+		}
+	}
 	if err != nil {
 		return false, err
 	}
 	return ok, nil
 }
 
-// ApproveRequest approves or disapproves a redis Request.
-func (e *RedisEnforcement) ApproveRequest(ctx context.Context, r Request, quota uint64) (bool, error) {
+// migrateVolumeScript moves the fields of a volume tracked by name, that
+// has not been deleted, to the fields of its ID, and indexes the name of the
+// volume by ID. It returns 1 if it moved the fields.
+const migrateVolumeScript = `
+local key = KEYS[1]
+local n = tonumber(ARGV[1])
+local nameField = ARGV[2]
+local indexField = ARGV[3]
+local name = ARGV[4]
+local id = ARGV[5]
+local byNameApproved = ARGV[6]
+local byNameDeleted = ARGV[5 + n]
+local approved = ARGV[6 + n]
+
+local moved = 0
+if redis.call('HEXISTS', key, approved) == 0 and redis.call('HEXISTS', key, byNameApproved) == 1 and redis.call('HEXISTS', key, byNameDeleted) == 0 then
+  for i = 0, n - 1 do
+    local v = redis.call('HGET', key, ARGV[6 + i])
+    if v then
+      redis.call('HSET', key, ARGV[6 + n + i], v)
+      redis.call('HDEL', key, ARGV[6 + i])
+    end
+  end
+  moved = 1
+end
+if redis.call('HEXISTS', key, approved) == 1 and redis.call('HEXISTS', key, nameField) == 0 then
+  redis.call('HSET', key, nameField, name)
+  redis.call('HSET', key, indexField, id)
+end
+return moved
+`
+
+func (r Request) migrateVolumeArgs() EvalArgs {
+	byName, byID := r.byName().volumeFields(), r.volumeFields()
+	args := []interface{}{
+		len(byID),
+		r.NameField(),
+		r.NameIndexField(),
+		r.VolumeName,
+		r.VolumeID,
+	}
+	args = append(args, byName...)
+	args = append(args, byID...)
+	return EvalArgs{Keys: []string{r.DataKey()}, Args: args}
+}
+
+// migrateVolumes tracks the volumes of the requests that have both a name
+// and an ID by their ID. Volumes approved by name are migrated once they
+// are created, and volumes tracked by name before the next time they are
+// changed, so data is migrated without downtime.
+func (e *RedisEnforcement) migrateVolumes(rs ...Request) error {
+	var evals []EvalArgs
+	for _, r := range rs {
+		if r.VolumeID != "" && r.VolumeName != "" {
+			evals = append(evals, r.migrateVolumeArgs())
+		}
+	}
+	switch len(evals) {
+	case 0:
+		return nil
+	case 1:
+		_, err := e.db().EvalInt(migrateVolumeScript, evals[0].Keys, evals[0].Args...)
+		return err
+	default:
+		_, err := e.db().EvalIntBatch(migrateVolumeScript, evals)
+		return err
+	}
+}
+
+// approveRequestScript approves the volume if it is already approved and
+// not deleted, or created and tracked by ID, or if its capacity fits in the
+// quota, where a negative quota is unlimited and a quota of 0 approves
+// nothing, and one more volume fits in the maximum number of volumes, where
+// 0 is no maximum. It returns 2 when the maximum number of volumes is
+// reached. Beyond a positive soft quota, it records when the approved
+// capacity first exceeded it and returns 3 once that is longer ago than a
+// positive grace period, or 4 if it approves the volume. It runs
+// atomically, so concurrent approvals cannot exceed the quota.
+const approveRequestScript = `
+local key = KEYS[1]
+local approvedCapField = ARGV[1]
+local approvedField = ARGV[2]
+local capField = ARGV[3]
+local delta = tonumber(ARGV[4])
+local quota = tonumber(ARGV[5])
+local streamKey = ARGV[6]
+local approvedVolsField = ARGV[13]
+local maxVols = tonumber(ARGV[14])
+local softQuota = tonumber(ARGV[15])
+local softField = ARGV[16]
+local now = tonumber(ARGV[17])
+local grace = tonumber(ARGV[18])
+local deletedField = ARGV[19]
+local indexField = ARGV[22]
+
+if redis.call('HEXISTS', key, indexField) == 1 then
+  return 1
+end
+if redis.call('HEXISTS', key, approvedField) == 1 then
+  if redis.call('HEXISTS', key, deletedField) == 0 then
+    return 1
+  end
+  -- The name of a deleted volume is approved for a new volume.
+  redis.call('HDEL', key, approvedField, capField, deletedField, ARGV[20], ARGV[21])
+end
+redis.call('HSETNX', key, approvedCapField, 0)
+local approvedCap = tonumber(redis.call('HGET', key, approvedCapField))
+if quota == 0 or (quota > 0 and approvedCap + delta > quota) then
+  return 0
+end
+local approvedVols = tonumber(redis.call('HGET', key, approvedVolsField) or 0)
+if maxVols > 0 and approvedVols + 1 > maxVols then
+  return 2
+end
+local overSoft = false
+if softQuota > 0 then
+  if approvedCap + delta > softQuota then
+    local since = tonumber(redis.call('HGET', key, softField) or 0)
+    if since == 0 or approvedCap <= softQuota then
+      since = now
+      redis.call('HSET', key, softField, now)
+    end
+    if grace > 0 and now - since > grace then
+      return 3
+    end
+    overSoft = true
+  else
+    redis.call('HDEL', key, softField)
+  end
+end
+redis.call('HSET', key, approvedField, 1)
+redis.call('HSET', key, capField, ARGV[4])
+redis.call('HINCRBY', key, approvedCapField, ARGV[4])
+redis.call('HINCRBY', key, approvedVolsField, 1)
+redis.call('XADD', streamKey, '*',
+	ARGV[7], ARGV[8],
+	ARGV[9], ARGV[10],
+	ARGV[11], ARGV[12])
+if overSoft then
+  return 4
+end
+return 1
+`
+
+// ApproveRequest approves or disapproves a redis Request. The quota is in
+// kilobytes, or Unlimited. It returns ErrMaxVolumes if the tenant already
+// has the maximum number of volumes of the Request in the storage pool, and
+// ErrGracePeriodExpired if the tenant has exceeded the soft quota of the
+// Request for longer than the grace period.
+func (e *RedisEnforcement) ApproveRequest(ctx context.Context, r Request, quota int64) (bool, error) {
 	ctx, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "ApproveRequest")
 	defer span.End()
 
-	reqCapInt, err := strconv.ParseUint(r.Capacity, 10, 64)
-	if err != nil {
+	if _, err := strconv.ParseUint(r.Capacity, 10, 64); err != nil {
 		return false, fmt.Errorf("parse capacity: %w", err)
 	}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return false, ctx.Err()
-		default:
-		}
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	default:
+	}
 
-		ok, err := e.rdb.HExists(r.DataKey(), r.ApprovedField())
-		if err != nil {
-			return false, err
-		}
-		if ok {
-			return true, nil
+	approved, err := e.db().EvalInt(approveRequestScript, []string{r.DataKey()},
+		r.ApprovedCapacityField(),
+		r.ApprovedField(),
+		r.CapacityField(),
+		r.Capacity,
+		strconv.FormatInt(quota, 10),
+		r.StreamKey(),
+		"name", r.VolumeName,
+		"cap", r.Capacity,
+		"status", "approved",
+		r.ApprovedVolumesField(),
+		strconv.FormatInt(r.MaxVolumes, 10),
+		strconv.FormatInt(r.SoftQuota, 10),
+		r.SoftQuotaExceededField(),
+		strconv.FormatInt(e.now().Unix(), 10),
+		strconv.FormatInt(int64(e.grace/time.Second), 10),
+		r.DeletedField(),
+		r.CreatedField(),
+		r.DeletingField(),
+		r.NameIndexField())
+	if err != nil {
+		return false, err
+	}
+	switch approved {
+	case 2:
+		return false, ErrMaxVolumes
+	case 3:
+		return false, ErrGracePeriodExpired
+	case 4:
+		span.AddEvent("SoftQuotaExceeded")
+		if e.onSoftQuota != nil {
+			e.onSoftQuota(ctx, r)
 		}
+		return true, nil
+	}
+	return approved == 1, nil
+}
+
+// CheckRequest reports whether ApproveRequest would approve the Request
+// against the given quota and the maximum number of volumes of the Request,
+// along with the capacity already approved for the tenant in the storage
+// pool. Like ApproveRequest, it returns ErrMaxVolumes if the maximum number
+// of volumes is reached and ErrGracePeriodExpired if the grace period of the
+// soft quota has expired. It does not modify any state.
+func (e *RedisEnforcement) CheckRequest(ctx context.Context, r Request, quota int64) (bool, uint64, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "CheckRequest")
+	defer span.End()
+
+	reqCapInt, err := strconv.ParseUint(r.Capacity, 10, 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("parse capacity: %w", err)
+	}
+
+	// The used capacity and the volume's approval are read in one round trip.
+	vals, err := e.db().HMGet(r.DataKey(), r.ApprovedCapacityField(), r.ApprovedField(), r.ApprovedVolumesField(), r.SoftQuotaExceededField())
+	if err != nil {
+		return false, 0, err
+	}
 
-		_, err = e.rdb.HSetNX(r.DataKey(), r.ApprovedCapacityField(), "0")
+	var approvedCapInt uint64
+	if approvedCap, ok := vals[0].(string); ok {
+		approvedCapInt, err = strconv.ParseUint(approvedCap, 10, 64)
 		if err != nil {
-			continue
+			return false, 0, fmt.Errorf("parse capacity: %w", err)
 		}
-		approvedCap, err := e.rdb.HGet(r.DataKey(), r.ApprovedCapacityField())
+	}
+
+	if r.VolumeName != "" && vals[1] != nil {
+		return true, approvedCapInt, nil
+	}
+
+	var approvedVols int64
+	if v, ok := vals[2].(string); ok {
+		approvedVols, err = strconv.ParseInt(v, 10, 64)
 		if err != nil {
-			return false, err
+			return false, 0, fmt.Errorf("parse volumes: %w", err)
 		}
+	}
+
+	switch {
+	case quota == 0:
+		return false, approvedCapInt, nil
+	case quota > 0 && approvedCapInt+reqCapInt > uint64(quota):
+		return false, approvedCapInt, nil
+	case r.MaxVolumes > 0 && approvedVols+1 > r.MaxVolumes:
+		return false, approvedCapInt, ErrMaxVolumes
+	}
 
-		if quota != 0 {
-			approvedCapInt, err := strconv.ParseUint(approvedCap, 10, 64)
+	// The grace period only runs while the approved capacity is already
+	// beyond the soft quota.
+	if soft := r.SoftQuota; soft > 0 && e.grace > 0 && approvedCapInt > uint64(soft) && len(vals) > 3 {
+		if v, ok := vals[3].(string); ok {
+			since, err := strconv.ParseInt(v, 10, 64)
 			if err != nil {
-				return false, fmt.Errorf("parse capacity: %w", err)
+				return false, 0, fmt.Errorf("parse soft quota time: %w", err)
 			}
-			if approvedCapInt+reqCapInt > quota {
-				return false, nil
+			if e.now().Unix()-since > int64(e.grace/time.Second) {
+				return false, approvedCapInt, ErrGracePeriodExpired
 			}
 		}
+	}
+	return true, approvedCapInt, nil
+}
 
-		select {
-		case <-ctx.Done():
-			return false, ctx.Err()
-		default:
-		}
+// Usage returns the capacity, in kilobytes, and the number of volumes
+// approved for the tenant of the Request in its storage pool.
+func (e *RedisEnforcement) Usage(ctx context.Context, r Request) (int64, int64, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "Usage")
+	defer span.End()
 
-		// TODO(ian): Pass in the quota and perhaps we can
-		// check if quota is exceeded right there, in order
-		// to reduce locking churn
-		changed, err := e.rdb.EvalInt(`
-local key = KEYS[1]
-local approvedCapField = ARGV[1]
-local fenceCap = ARGV[2]
-local approvedField = ARGV[3]
-local capField = ARGV[4]
-local delta = ARGV[5]
-local streamKey = ARGV[6]
+	vals, err := e.db().HMGet(r.DataKey(), r.ApprovedCapacityField(), r.ApprovedVolumesField())
+	if err != nil {
+		return 0, 0, err
+	}
 
-if redis.call('HGET', key, approvedCapField) == fenceCap then
-  redis.call('HSET', key, approvedField, 1)
-  redis.call('HSET', key, capField, delta)
-  redis.call('HINCRBY', key, approvedCapField, delta)
-  redis.call('XADD', streamKey, '*',
-	ARGV[7], ARGV[8],
-	ARGV[9], ARGV[10],
-	ARGV[11], ARGV[12])
-  return 1
-end
-return 0
-`, []string{r.DataKey()},
-			r.ApprovedCapacityField(),
-			approvedCap, // this is the fencing token
-			r.ApprovedField(),
-			r.CapacityField(),
-			r.Capacity,
-			r.StreamKey(),
-			"name", r.VolumeName,
-			"cap", r.Capacity,
-			"status", "approved")
-		if err != nil {
-			return false, err
-		}
-		if changed == 0 {
-			continue
+	var usage [2]int64
+	for i, v := range vals {
+		if s, ok := v.(string); ok {
+			usage[i], err = strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return 0, 0, fmt.Errorf("parse usage: %w", err)
+			}
 		}
-		break
 	}
-	return true, nil
+	return usage[0], usage[1], nil
 }
 
-// DeleteRequest marks the volume as being in the process of deletion only.
-// It's OK for this to be called multiple times, as the only negative impact
-// would be multiple stream entries.
-func (e *RedisEnforcement) DeleteRequest(_ context.Context, r Request) (bool, error) {
-	changed, err := e.rdb.EvalInt(`
+const deleteRequestScript = `
 local key = KEYS[1]
 local approvedField = ARGV[1]
 local deletingField = ARGV[2]
@@ -310,21 +827,57 @@ if redis.call('HEXISTS', key, approvedField) == 1 then
   return 1
 end
 return 0
-`, []string{r.DataKey()},
-		r.ApprovedField(),
-		r.DeletingField(),
-		r.StreamKey(),
-		"name", r.VolumeName,
-		"status", "deleting")
+`
+
+func (r Request) deleteRequestArgs() EvalArgs {
+	return EvalArgs{
+		Keys: []string{r.DataKey()},
+		Args: []interface{}{
+			r.ApprovedField(),
+			r.DeletingField(),
+			r.StreamKey(),
+			"name", r.VolumeName,
+			"status", "deleting",
+		},
+	}
+}
+
+// DeleteRequest marks the volume as being in the process of deletion only.
+// It's OK for this to be called multiple times, as the only negative impact
+// would be multiple stream entries.
+func (e *RedisEnforcement) DeleteRequest(ctx context.Context, r Request) (bool, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "DeleteRequest")
+	defer span.End()
+
+	if err := e.migrateVolumes(r); err != nil {
+		return false, err
+	}
+	a := r.deleteRequestArgs()
+	changed, err := e.db().EvalInt(deleteRequestScript, a.Keys, a.Args...)
 	if err != nil {
 		return false, err
 	}
 	return changed == 1, nil
 }
 
-// PublishCreated publishes that a volume was created
-func (e *RedisEnforcement) PublishCreated(_ context.Context, r Request) (bool, error) {
-	changed, err := e.rdb.EvalInt(`
+// DeleteRequests is DeleteRequest for many volumes in a single round trip.
+// The results are in the same order as the requests.
+func (e *RedisEnforcement) DeleteRequests(ctx context.Context, rs []Request) ([]bool, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "DeleteRequests")
+	defer span.End()
+	span.SetAttributes(attribute.Int("requests", len(rs)))
+
+	if err := e.migrateVolumes(rs...); err != nil {
+		return nil, err
+	}
+	evals := make([]EvalArgs, len(rs))
+	for i, r := range rs {
+		evals[i] = r.deleteRequestArgs()
+	}
+	return e.evalBatch(deleteRequestScript, evals)
+}
+
+const publishCreatedScript = `
 local key = KEYS[1]
 local approvedField = ARGV[1]
 local createdField = ARGV[2]
@@ -339,36 +892,63 @@ if redis.call('HEXISTS', key, approvedField) == 1 then
   return 1
 end
 return 0
-`, []string{r.DataKey()},
-		r.ApprovedField(),
-		r.CreatedField(),
-		r.StreamKey(),
-		"name", r.VolumeName,
-		"cap", r.Capacity,
-		"status", "created")
+`
+
+func (r Request) publishCreatedArgs() EvalArgs {
+	return EvalArgs{
+		Keys: []string{r.DataKey()},
+		Args: []interface{}{
+			r.ApprovedField(),
+			r.CreatedField(),
+			r.StreamKey(),
+			"name", r.VolumeName,
+			"cap", r.Capacity,
+			"status", "created",
+		},
+	}
+}
+
+// PublishCreated publishes that a volume was created. A volume approved by
+// name is tracked by ID from then on if the Request has its ID.
+func (e *RedisEnforcement) PublishCreated(ctx context.Context, r Request) (bool, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "PublishCreated")
+	defer span.End()
+
+	if err := e.migrateVolumes(r); err != nil {
+		return false, err
+	}
+	a := r.publishCreatedArgs()
+	changed, err := e.db().EvalInt(publishCreatedScript, a.Keys, a.Args...)
 	if err != nil {
 		return false, err
 	}
 	return changed == 1, nil
 }
 
-// PublishDeleted publishes that a volume was deleted
-func (e *RedisEnforcement) PublishDeleted(_ context.Context, r Request) (bool, error) {
-	changed, err := e.rdb.EvalInt(`
+const publishDeletedScript = `
 local key = KEYS[1]
 local approvedField = ARGV[1]
 local deletedField = ARGV[2]
 local approvedCapField = ARGV[3]
 local capField = ARGV[4]
 local streamKey = ARGV[5]
+local approvedVolsField = ARGV[12]
+local indexField = ARGV[13]
 
 if redis.call('HEXISTS', key, approvedField) == 1 then
-  redis.call('HSET', key, deletedField, 1)
+  redis.call('HSET', key, deletedField, ARGV[15])
+  if redis.call('HGET', key, indexField) == ARGV[14] then
+    redis.call('HDEL', key, indexField)
+  end
   redis.call('HSETNX', key, capField, 0)
   local cap = redis.call('HGET', key, capField)
   if tonumber(cap) > 0 then
     redis.call('HINCRBY', key, approvedCapField, tonumber(cap)*-1)
   end
+  -- Volumes approved before they were counted are not in the count.
+  if tonumber(redis.call('HGET', key, approvedVolsField) or 0) > 0 then
+    redis.call('HINCRBY', key, approvedVolsField, -1)
+  end
   redis.call('XADD', streamKey, '*',
 	ARGV[6], ARGV[7],
 	ARGV[8], ARGV[9],
@@ -376,26 +956,414 @@ if redis.call('HEXISTS', key, approvedField) == 1 then
   return 1
 end
 return 0
-`, []string{r.DataKey()},
+`
+
+func (r Request) publishDeletedArgs(deletedAt time.Time) EvalArgs {
+	return EvalArgs{
+		Keys: []string{r.DataKey()},
+		Args: []interface{}{
+			r.ApprovedField(),
+			r.DeletedField(),
+			r.ApprovedCapacityField(),
+			r.CapacityField(),
+			r.StreamKey(),
+			"name", r.VolumeName,
+			"cap", r.Capacity,
+			"status", "deleted",
+			r.ApprovedVolumesField(),
+			r.NameIndexField(),
+			r.volumeRef(),
+			deletedAt.Unix(),
+		},
+	}
+}
+
+// PublishDeleted publishes that a volume was deleted
+func (e *RedisEnforcement) PublishDeleted(ctx context.Context, r Request) (bool, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "PublishDeleted")
+	defer span.End()
+
+	if err := e.migrateVolumes(r); err != nil {
+		return false, err
+	}
+	a := r.publishDeletedArgs(e.now())
+	changed, err := e.db().EvalInt(publishDeletedScript, a.Keys, a.Args...)
+	if err != nil {
+		return false, err
+	}
+	return changed == 1, nil
+}
+
+// PublishDeletedBatch is PublishDeleted for many volumes in a single round
+// trip. The results are in the same order as the requests.
+func (e *RedisEnforcement) PublishDeletedBatch(ctx context.Context, rs []Request) ([]bool, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "PublishDeletedBatch")
+	defer span.End()
+	span.SetAttributes(attribute.Int("requests", len(rs)))
+
+	if err := e.migrateVolumes(rs...); err != nil {
+		return nil, err
+	}
+	now := e.now()
+	evals := make([]EvalArgs, len(rs))
+	for i, r := range rs {
+		evals[i] = r.publishDeletedArgs(now)
+	}
+	return e.evalBatch(publishDeletedScript, evals)
+}
+
+// ErrVolumeNameInUse is the error for renaming a volume to the name of
+// another volume of the tenant that has not been deleted.
+var ErrVolumeNameInUse = errors.New("volume name already in use")
+
+// renameVolumeScript moves the fields of the volume to the fields of its new
+// name, replacing those of a deleted volume with the new name. It returns 0
+// if the volume was not created, and 2 if a volume with the new name exists.
+const renameVolumeScript = `
+local key = KEYS[1]
+local streamKey = ARGV[1]
+local n = tonumber(ARGV[2])
+local createdField = ARGV[3]
+local newApprovedField = ARGV[4]
+local newDeletedField = ARGV[5]
+
+if redis.call('HEXISTS', key, createdField) == 0 then
+  return 0
+end
+if redis.call('HEXISTS', key, newApprovedField) == 1 and redis.call('HEXISTS', key, newDeletedField) == 0 then
+  return 2
+end
+for i = 0, n - 1 do
+  local oldField = ARGV[6 + i]
+  local newField = ARGV[6 + n + i]
+  redis.call('HDEL', key, newField)
+  local v = redis.call('HGET', key, oldField)
+  if v then
+    redis.call('HSET', key, newField, v)
+    redis.call('HDEL', key, oldField)
+  end
+end
+redis.call('XADD', streamKey, '*',
+	ARGV[6 + 2*n], ARGV[7 + 2*n],
+	ARGV[8 + 2*n], ARGV[9 + 2*n],
+	ARGV[10 + 2*n], ARGV[11 + 2*n])
+return 1
+`
+
+// renameVolumeByIDScript changes the name of a volume tracked by ID. It
+// returns 0 if the volume was not created, and 2 if another volume with the
+// new name exists.
+const renameVolumeByIDScript = `
+local key = KEYS[1]
+local streamKey = ARGV[1]
+local createdField = ARGV[2]
+local nameField = ARGV[3]
+local indexField = ARGV[4]
+local newIndexField = ARGV[5]
+local newApprovedField = ARGV[6]
+local newDeletedField = ARGV[7]
+local id = ARGV[8]
+local newName = ARGV[9]
+
+if redis.call('HEXISTS', key, createdField) == 0 then
+  return 0
+end
+local other = redis.call('HGET', key, newIndexField)
+if other and other ~= id then
+  return 2
+end
+if redis.call('HEXISTS', key, newApprovedField) == 1 and redis.call('HEXISTS', key, newDeletedField) == 0 then
+  return 2
+end
+if redis.call('HGET', key, indexField) == id then
+  redis.call('HDEL', key, indexField)
+end
+redis.call('HSET', key, nameField, newName)
+redis.call('HSET', key, newIndexField, id)
+redis.call('XADD', streamKey, '*',
+	ARGV[10], ARGV[11],
+	ARGV[12], ARGV[13],
+	ARGV[14], ARGV[15])
+return 1
+`
+
+// RenameVolume renames the created volume of the Request to newName, keeping
+// its capacity and state. Only the name of a volume tracked by ID changes.
+// It returns false if the volume was not created,
+// and ErrVolumeNameInUse if the tenant has another volume named newName.
+func (e *RedisEnforcement) RenameVolume(ctx context.Context, r Request, newName string) (bool, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "RenameVolume")
+	defer span.End()
+
+	renamed := r
+	renamed.VolumeName = newName
+
+	var (
+		script string
+		args   []interface{}
+	)
+	if r.VolumeID != "" {
+		if err := e.migrateVolumes(r); err != nil {
+			return false, err
+		}
+		script = renameVolumeByIDScript
+		args = []interface{}{
+			r.StreamKey(),
+			r.CreatedField(),
+			r.NameField(),
+			r.NameIndexField(),
+			renamed.NameIndexField(),
+			renamed.byName().ApprovedField(),
+			renamed.byName().DeletedField(),
+			r.VolumeID,
+			newName,
+		}
+	} else {
+		oldFields, newFields := r.volumeFields(), renamed.volumeFields()
+		script = renameVolumeScript
+		args = []interface{}{
+			r.StreamKey(),
+			len(oldFields),
+			r.CreatedField(),
+			renamed.ApprovedField(),
+			renamed.DeletedField(),
+		}
+		args = append(args, oldFields...)
+		args = append(args, newFields...)
+	}
+	args = append(args,
+		"name", newName,
+		"previous_name", r.VolumeName,
+		"status", "renamed")
+
+	changed, err := e.db().EvalInt(script, []string{r.DataKey()}, args...)
+	if err != nil {
+		return false, err
+	}
+	if changed == 2 {
+		return false, ErrVolumeNameInUse
+	}
+	return changed == 1, nil
+}
+
+// ErrVolumeOwned is the error for adopting a volume that a tenant already
+// owns.
+var ErrVolumeOwned = errors.New("volume already owned")
+
+// adoptVolumeScript tracks a volume created outside of authorization as
+// created by the tenant and adds its capacity to the approved capacity,
+// regardless of the quota. It returns 2 if the tenant already owns the
+// volume, and 3 if the tenant has another volume with its name.
+const adoptVolumeScript = `
+local key = KEYS[1]
+local approvedField = ARGV[1]
+local capField = ARGV[2]
+local createdField = ARGV[3]
+local deletingField = ARGV[4]
+local deletedField = ARGV[5]
+local nameField = ARGV[6]
+local indexField = ARGV[7]
+local approvedCapField = ARGV[8]
+local approvedVolsField = ARGV[9]
+local streamKey = ARGV[10]
+local id = ARGV[11]
+local name = ARGV[12]
+local cap = ARGV[13]
+
+if redis.call('HEXISTS', key, createdField) == 1 and redis.call('HEXISTS', key, deletedField) == 0 then
+  return 2
+end
+local other = redis.call('HGET', key, indexField)
+if other and other ~= id then
+  return 3
+end
+redis.call('HDEL', key, deletingField, deletedField)
+redis.call('HSET', key, approvedField, 1)
+redis.call('HSET', key, capField, cap)
+redis.call('HSET', key, createdField, 1)
+redis.call('HSET', key, nameField, name)
+redis.call('HSET', key, indexField, id)
+redis.call('HINCRBY', key, approvedCapField, cap)
+redis.call('HINCRBY', key, approvedVolsField, 1)
+redis.call('XADD', streamKey, '*',
+	ARGV[14], ARGV[15],
+	ARGV[16], ARGV[17],
+	ARGV[18], ARGV[19])
+return 1
+`
+
+// AdoptVolume registers an existing volume of the storage pool, created
+// outside of authorization, as created by the tenant of the Request, which
+// must have both the name and the ID of the volume. Its capacity counts
+// against the quota of the tenant from then on, even if that exceeds it.
+// It returns ErrVolumeOwned if the tenant, or any other tenant, already owns
+// the volume, and ErrVolumeNameInUse if the tenant has another volume with
+// its name.
+func (e *RedisEnforcement) AdoptVolume(ctx context.Context, r Request) error {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "AdoptVolume")
+	defer span.End()
+
+	if r.VolumeID == "" || r.VolumeName == "" {
+		return errors.New("adopting a volume requires its name and ID")
+	}
+	if _, err := strconv.ParseUint(r.Capacity, 10, 64); err != nil {
+		return fmt.Errorf("parse capacity: %w", err)
+	}
+
+	owned, err := e.ownedByOtherTenant(r)
+	if err != nil {
+		return err
+	}
+	if owned {
+		return ErrVolumeOwned
+	}
+	if err := e.migrateVolumes(r); err != nil {
+		return err
+	}
+
+	adopted, err := e.db().EvalInt(adoptVolumeScript, []string{r.DataKey()},
 		r.ApprovedField(),
+		r.CapacityField(),
+		r.CreatedField(),
+		r.DeletingField(),
 		r.DeletedField(),
+		r.NameField(),
+		r.NameIndexField(),
 		r.ApprovedCapacityField(),
-		r.CapacityField(),
+		r.ApprovedVolumesField(),
 		r.StreamKey(),
+		r.VolumeID,
+		r.VolumeName,
+		r.Capacity,
 		"name", r.VolumeName,
 		"cap", r.Capacity,
-		"status", "deleted")
+		"status", "adopted")
+	if err != nil {
+		return err
+	}
+	switch adopted {
+	case 2:
+		return ErrVolumeOwned
+	case 3:
+		return ErrVolumeNameInUse
+	}
+	return nil
+}
+
+// ownedByOtherTenant reports whether a tenant other than the one of the
+// Request tracks the Request volume, by ID or, if it has not been deleted,
+// by name, in the storage pool.
+func (e *RedisEnforcement) ownedByOtherTenant(r Request) (bool, error) {
+	all := r
+	all.Group = "*"
+	keys, err := e.db().Scan(all.DataKey())
 	if err != nil {
 		return false, err
 	}
-	return changed == 1, nil
+	for _, key := range keys {
+		if key == r.DataKey() {
+			continue
+		}
+		vals, err := e.db().HMGet(key, r.CreatedField(), r.DeletedField(), r.byName().CreatedField(), r.byName().DeletedField())
+		if err != nil {
+			return false, err
+		}
+		if (vals[0] != nil && vals[1] == nil) || (vals[2] != nil && vals[3] == nil) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// VolumeOwner is the tenant a volume is tracked for.
+type VolumeOwner struct {
+	// Tenant is the key of the tenant's data, its ID or, for tenants from
+	// before tenants had IDs, its name.
+	Tenant string
+	Pool   string
+	// Settled is false if the volume has been approved but not created
+	// yet, or if more than one tenant tracks it, so that its owner is not
+	// known for sure.
+	Settled bool
+}
+
+// VolumeOwners returns the owners of the volumes of a storage system, by
+// volume ID or, for volumes that are still tracked by name, by name.
+// Deleted volumes are left out.
+func (e *RedisEnforcement) VolumeOwners(ctx context.Context, systemType, systemID string) (map[string]VolumeOwner, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "VolumeOwners")
+	defer span.End()
+
+	all := Request{SystemType: systemType, SystemID: systemID, StoragePoolID: "*", Group: "*"}
+	keys, err := e.db().Scan(all.DataKey())
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+
+	owners := make(map[string]VolumeOwner)
+	prefix := fmt.Sprintf("quota:%s:%s:", systemType, systemID)
+	for _, key := range keys {
+		// The tenant comes last, so the pool may contain colons.
+		rest := strings.TrimSuffix(strings.TrimPrefix(key, prefix), ":data")
+		i := strings.LastIndex(rest, ":")
+		if i < 0 {
+			continue
+		}
+		pool, tenant := rest[:i], rest[i+1:]
+
+		fields, err := e.db().HGetAll(key)
+		if err != nil {
+			return nil, err
+		}
+		refs := make(map[string]struct{})
+		for field := range fields {
+			// e.g. vol:<volume id or name>:created
+			parts := strings.Split(field, ":")
+			if len(parts) == 3 && parts[0] == "vol" {
+				refs[parts[1]] = struct{}{}
+			}
+		}
+		for ref := range refs {
+			r := Request{VolumeID: ref}
+			_, approved := fields[r.ApprovedField()]
+			_, created := fields[r.CreatedField()]
+			_, deleted := fields[r.DeletedField()]
+			if deleted || !(approved || created) {
+				continue
+			}
+			owner := VolumeOwner{Tenant: tenant, Pool: pool, Settled: created}
+			if prev, ok := owners[ref]; ok && prev.Tenant != tenant {
+				owner = prev
+				owner.Settled = false
+			}
+			owners[ref] = owner
+		}
+	}
+	span.AddEvent("VolumeOwners", trace.WithAttributes(attribute.Int("volumes", len(owners))))
+	return owners, nil
+}
+
+func (e *RedisEnforcement) evalBatch(script string, evals []EvalArgs) ([]bool, error) {
+	if len(evals) == 0 {
+		return nil, nil
+	}
+	changed, err := e.db().EvalIntBatch(script, evals)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]bool, len(changed))
+	for i, c := range changed {
+		res[i] = c == 1
+	}
+	return res, nil
 }
 
 // ApprovedNotCreated returns volume data for a volume that was approved to be created but not created
 // TODO(ian): this should be a continous stream to build an eventually
 // consistent view.
 func (e *RedisEnforcement) ApprovedNotCreated(_ context.Context, streamKey string) []VolumeData {
-	msgs, err := e.rdb.XRange(streamKey, "-", "+")
+	msgs, err := e.db().XRange(streamKey, "-", "+")
 	if err != nil {
 		panic(err)
 	}
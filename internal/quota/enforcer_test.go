@@ -18,10 +18,12 @@ import (
 	"errors"
 	"fmt"
 	"karavi-authorization/internal/quota"
+	"reflect"
 	"strconv"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis"
@@ -285,6 +287,346 @@ func TestRedisEnforcement_PublishDeleted(t *testing.T) {
 	})
 }
 
+func TestRedisEnforcement_RenameVolume(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	req := buildRequest()
+	renamed := req
+	renamed.VolumeName = "k8s-789"
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	sut := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rc))
+
+	t.Run("moves the volume to its new name", func(t *testing.T) {
+		mr.FlushAll()
+		mr.HSet(req.DataKey(), req.ApprovedField(), "1")
+		mr.HSet(req.DataKey(), req.CapacityField(), req.Capacity)
+		mr.HSet(req.DataKey(), req.CreatedField(), "1")
+
+		got, err := sut.RenameVolume(context.Background(), req, renamed.VolumeName)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !got {
+			t.Errorf("got %v, want true", got)
+		}
+		if mr.HGet(req.DataKey(), req.CreatedField()) != "" {
+			t.Error("expected the previous name to no longer be owned")
+		}
+		if got := mr.HGet(renamed.DataKey(), renamed.CapacityField()); got != req.Capacity {
+			t.Errorf("got capacity %q, want %q", got, req.Capacity)
+		}
+		if ok, err := sut.ValidateOwnership(context.Background(), renamed); err != nil || !ok {
+			t.Errorf("expected the new name to be owned, got %v, %v", ok, err)
+		}
+	})
+	t.Run("replaces a deleted volume with the new name", func(t *testing.T) {
+		mr.FlushAll()
+		mr.HSet(req.DataKey(), req.CreatedField(), "1")
+		mr.HSet(renamed.DataKey(), renamed.ApprovedField(), "1")
+		mr.HSet(renamed.DataKey(), renamed.DeletedField(), "1")
+
+		got, err := sut.RenameVolume(context.Background(), req, renamed.VolumeName)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !got {
+			t.Errorf("got %v, want true", got)
+		}
+		if mr.HGet(renamed.DataKey(), renamed.DeletedField()) != "" {
+			t.Error("expected the deleted volume to be replaced")
+		}
+	})
+	t.Run("returns ErrVolumeNameInUse for a name in use", func(t *testing.T) {
+		mr.FlushAll()
+		mr.HSet(req.DataKey(), req.CreatedField(), "1")
+		mr.HSet(renamed.DataKey(), renamed.ApprovedField(), "1")
+
+		_, got := sut.RenameVolume(context.Background(), req, renamed.VolumeName)
+
+		if !errors.Is(got, quota.ErrVolumeNameInUse) {
+			t.Errorf("got %v, want %v", got, quota.ErrVolumeNameInUse)
+		}
+		if mr.HGet(req.DataKey(), req.CreatedField()) != "1" {
+			t.Error("expected the volume not to be renamed")
+		}
+	})
+	t.Run("returns false if the volume was not created", func(t *testing.T) {
+		mr.FlushAll()
+
+		got, err := sut.RenameVolume(context.Background(), req, renamed.VolumeName)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got {
+			t.Errorf("got %v, want false", got)
+		}
+	})
+}
+
+func TestRedisEnforcement_VolumeID(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	sut := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rc))
+	ctx := context.Background()
+
+	byName := buildRequest()
+	byID := byName
+	byID.VolumeID = "0a1b2c3d00000001"
+
+	t.Run("tracks a volume approved by name by its ID once created", func(t *testing.T) {
+		mr.FlushAll()
+		if ok, err := sut.ApproveRequest(ctx, byName, quota.Unlimited); err != nil || !ok {
+			t.Fatalf("approving request: %v, %v", ok, err)
+		}
+
+		ok, err := sut.PublishCreated(ctx, byID)
+		if err != nil || !ok {
+			t.Fatalf("publishing created: %v, %v", ok, err)
+		}
+
+		if got := mr.HGet(byID.DataKey(), byID.CapacityField()); got != byName.Capacity {
+			t.Errorf("got capacity %q, want %q", got, byName.Capacity)
+		}
+		if got := mr.HGet(byID.DataKey(), byID.NameIndexField()); got != byID.VolumeID {
+			t.Errorf("got name indexed as %q, want %q", got, byID.VolumeID)
+		}
+		if mr.HGet(byName.DataKey(), byName.ApprovedField()) != "" {
+			t.Error("expected the volume to no longer be tracked by name")
+		}
+		for _, r := range []quota.Request{byName, byID} {
+			if ok, err := sut.ValidateOwnership(ctx, r); err != nil || !ok {
+				t.Errorf("expected %+v to be owned, got %v, %v", r, ok, err)
+			}
+		}
+		if ok, err := sut.ApproveRequest(ctx, byName, quota.Unlimited); err != nil || !ok {
+			t.Errorf("expected the created volume to stay approved, got %v, %v", ok, err)
+		}
+		if _, vols, _ := sut.Usage(ctx, byName); vols != 1 {
+			t.Errorf("got %d volumes, want 1", vols)
+		}
+	})
+	t.Run("migrates a volume tracked by name when it is deleted", func(t *testing.T) {
+		mr.FlushAll()
+		mr.HSet(byName.DataKey(), byName.ApprovedField(), "1")
+		mr.HSet(byName.DataKey(), byName.CapacityField(), byName.Capacity)
+		mr.HSet(byName.DataKey(), byName.CreatedField(), "1")
+		mr.HSet(byName.DataKey(), byName.ApprovedCapacityField(), byName.Capacity)
+
+		if ok, err := sut.ValidateOwnership(ctx, byID); err != nil || !ok {
+			t.Errorf("expected the volume tracked by name to be owned, got %v, %v", ok, err)
+		}
+		if ok, err := sut.DeleteRequest(ctx, byID); err != nil || !ok {
+			t.Fatalf("deleting: %v, %v", ok, err)
+		}
+		if ok, err := sut.PublishDeleted(ctx, byID); err != nil || !ok {
+			t.Fatalf("publishing deleted: %v, %v", ok, err)
+		}
+
+		if got := mr.HGet(byID.DataKey(), byID.DeletedField()); got == "" {
+			t.Errorf("expected the volume to be deleted by ID, got %q", got)
+		}
+		if got := mr.HGet(byID.DataKey(), byID.NameIndexField()); got != "" {
+			t.Errorf("expected the name to be free, got it indexed as %q", got)
+		}
+		if got := mr.HGet(byID.DataKey(), byID.ApprovedCapacityField()); got != "0" {
+			t.Errorf("got approved capacity %q, want 0", got)
+		}
+	})
+	t.Run("approves a new volume with the name of a deleted volume", func(t *testing.T) {
+		mr.FlushAll()
+		mr.HSet(byName.DataKey(), byName.ApprovedField(), "1")
+		mr.HSet(byName.DataKey(), byName.CreatedField(), "1")
+		mr.HSet(byName.DataKey(), byName.DeletedField(), "1")
+
+		if ok, err := sut.ApproveRequest(ctx, byName, quota.Unlimited); err != nil || !ok {
+			t.Fatalf("approving request: %v, %v", ok, err)
+		}
+
+		if mr.HGet(byName.DataKey(), byName.DeletedField()) != "" {
+			t.Error("expected the deleted volume to be replaced")
+		}
+		if got := mr.HGet(byName.DataKey(), byName.ApprovedCapacityField()); got != byName.Capacity {
+			t.Errorf("got approved capacity %q, want %q", got, byName.Capacity)
+		}
+	})
+	t.Run("renames a volume tracked by ID", func(t *testing.T) {
+		mr.FlushAll()
+		if ok, err := sut.ApproveRequest(ctx, byName, quota.Unlimited); err != nil || !ok {
+			t.Fatalf("approving request: %v, %v", ok, err)
+		}
+		if ok, err := sut.PublishCreated(ctx, byID); err != nil || !ok {
+			t.Fatalf("publishing created: %v, %v", ok, err)
+		}
+
+		ok, err := sut.RenameVolume(ctx, byID, "k8s-789")
+		if err != nil || !ok {
+			t.Fatalf("renaming: %v, %v", ok, err)
+		}
+
+		renamed := byName
+		renamed.VolumeName = "k8s-789"
+		if ok, err := sut.ValidateOwnership(ctx, renamed); err != nil || !ok {
+			t.Errorf("expected the new name to be owned, got %v, %v", ok, err)
+		}
+		if ok, err := sut.ValidateOwnership(ctx, byName); err != nil || ok {
+			t.Errorf("expected the previous name to no longer be owned, got %v, %v", ok, err)
+		}
+		if got := mr.HGet(byID.DataKey(), byID.NameField()); got != "k8s-789" {
+			t.Errorf("got name %q, want k8s-789", got)
+		}
+	})
+}
+
+func TestRedisEnforcement_AdoptVolume(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	sut := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rc))
+	ctx := context.Background()
+
+	req := buildRequest()
+	req.VolumeID = "0a1b2c3d00000001"
+	other := req
+	other.Group = "othertenant"
+
+	t.Run("tracks the volume as created by the tenant", func(t *testing.T) {
+		mr.FlushAll()
+		mr.HSet(req.DataKey(), req.ApprovedCapacityField(), "100")
+
+		if err := sut.AdoptVolume(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+
+		if ok, err := sut.ValidateOwnership(ctx, req); err != nil || !ok {
+			t.Errorf("expected the volume to be owned, got %v, %v", ok, err)
+		}
+		if got := mr.HGet(req.DataKey(), req.NameIndexField()); got != req.VolumeID {
+			t.Errorf("got name indexed as %q, want %q", got, req.VolumeID)
+		}
+		if got := mr.HGet(req.DataKey(), req.ApprovedCapacityField()); got != "8300100" {
+			t.Errorf("got approved capacity %q, want %q", got, "8300100")
+		}
+		if got := mr.HGet(req.DataKey(), req.ApprovedVolumesField()); got != "1" {
+			t.Errorf("got approved volumes %q, want %q", got, "1")
+		}
+	})
+	t.Run("returns ErrVolumeOwned for a volume the tenant owns", func(t *testing.T) {
+		mr.FlushAll()
+		byName := req
+		byName.VolumeID = ""
+		mr.HSet(byName.DataKey(), byName.ApprovedField(), "1")
+		mr.HSet(byName.DataKey(), byName.CreatedField(), "1")
+
+		got := sut.AdoptVolume(ctx, req)
+
+		if !errors.Is(got, quota.ErrVolumeOwned) {
+			t.Errorf("got %v, want %v", got, quota.ErrVolumeOwned)
+		}
+	})
+	t.Run("returns ErrVolumeOwned for a volume another tenant owns", func(t *testing.T) {
+		mr.FlushAll()
+		mr.HSet(other.DataKey(), other.CreatedField(), "1")
+
+		got := sut.AdoptVolume(ctx, req)
+
+		if !errors.Is(got, quota.ErrVolumeOwned) {
+			t.Errorf("got %v, want %v", got, quota.ErrVolumeOwned)
+		}
+		if mr.Exists(req.DataKey()) {
+			t.Error("expected the volume not to be adopted")
+		}
+	})
+	t.Run("adopts a volume another tenant deleted", func(t *testing.T) {
+		mr.FlushAll()
+		mr.HSet(other.DataKey(), other.CreatedField(), "1")
+		mr.HSet(other.DataKey(), other.DeletedField(), "1")
+
+		if err := sut.AdoptVolume(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("returns ErrVolumeNameInUse for a name in use", func(t *testing.T) {
+		mr.FlushAll()
+		mr.HSet(req.DataKey(), req.NameIndexField(), "0a1b2c3d00000002")
+
+		got := sut.AdoptVolume(ctx, req)
+
+		if !errors.Is(got, quota.ErrVolumeNameInUse) {
+			t.Errorf("got %v, want %v", got, quota.ErrVolumeNameInUse)
+		}
+	})
+}
+
+func TestRedisEnforcement_VolumeOwners(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	sut := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rc))
+	ctx := context.Background()
+
+	created := buildRequest()
+	created.VolumeID = "0a1b2c3d00000001"
+	mr.HSet(created.DataKey(), created.ApprovedField(), "1")
+	mr.HSet(created.DataKey(), created.CreatedField(), "1")
+
+	byName := buildRequest()
+	byName.VolumeName = "k8s-byname"
+	mr.HSet(byName.DataKey(), byName.ApprovedField(), "1")
+	mr.HSet(byName.DataKey(), byName.CreatedField(), "1")
+
+	approved := buildRequest()
+	approved.VolumeID = "0a1b2c3d00000002"
+	mr.HSet(approved.DataKey(), approved.ApprovedField(), "1")
+
+	deleted := buildRequest()
+	deleted.VolumeID = "0a1b2c3d00000003"
+	mr.HSet(deleted.DataKey(), deleted.CreatedField(), "1")
+	mr.HSet(deleted.DataKey(), deleted.DeletedField(), "1")
+
+	shared := buildRequest()
+	shared.VolumeID = "0a1b2c3d00000004"
+	mr.HSet(shared.DataKey(), shared.CreatedField(), "1")
+	other := shared
+	other.Group = "othertenant"
+	mr.HSet(other.DataKey(), other.CreatedField(), "1")
+
+	otherSystem := buildRequest()
+	otherSystem.SystemID = "456"
+	otherSystem.VolumeID = "0a1b2c3d00000005"
+	mr.HSet(otherSystem.DataKey(), otherSystem.CreatedField(), "1")
+
+	got, err := sut.VolumeOwners(ctx, "powerflex", "123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]quota.VolumeOwner{
+		"0a1b2c3d00000001": {Tenant: "mytenant", Pool: "mypool", Settled: true},
+		"k8s-byname":       {Tenant: "mytenant", Pool: "mypool", Settled: true},
+		"0a1b2c3d00000002": {Tenant: "mytenant", Pool: "mypool", Settled: false},
+		"0a1b2c3d00000004": {Tenant: "mytenant", Pool: "mypool", Settled: false},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
 func TestRedisEnforcement_ApproveRequest(t *testing.T) {
 	mr, err := miniredis.Run()
 	if err != nil {
@@ -301,7 +643,7 @@ func TestRedisEnforcement_ApproveRequest(t *testing.T) {
 		req := buildRequest()
 
 		cancel()
-		got, gotErr := sut.ApproveRequest(ctx, req, 0)
+		got, gotErr := sut.ApproveRequest(ctx, req, quota.Unlimited)
 
 		want := false
 		if got != want {
@@ -312,10 +654,10 @@ func TestRedisEnforcement_ApproveRequest(t *testing.T) {
 			t.Errorf("got err = %v, want %v", gotErr, wantErr)
 		}
 	})
-	t.Run("early return on HExists failure", func(t *testing.T) {
+	t.Run("returns any error", func(t *testing.T) {
 		sut := quota.NewRedisEnforcement(context.Background(), quota.WithDB(&quota.FakeRedis{
-			HExistsFn: func(_, _ string) (bool, error) {
-				return false, ErrFake
+			EvalIntFn: func(_ string, _ []string, _ ...interface{}) (int, error) {
+				return 0, ErrFake
 			},
 		}))
 
@@ -344,6 +686,346 @@ func TestRedisEnforcement_ApproveRequest(t *testing.T) {
 	})
 }
 
+func TestRedisEnforcement_CheckRequest(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	sut := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rc))
+
+	t.Run("approves when no capacity has been used", func(t *testing.T) {
+		mr.FlushAll()
+		req := buildRequest()
+
+		ok, used, err := sut.CheckRequest(context.Background(), req, 10000000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok || used != 0 {
+			t.Errorf("got (%v, %d), want (true, 0)", ok, used)
+		}
+	})
+	t.Run("denies when quota would be exceeded without changing state", func(t *testing.T) {
+		mr.FlushAll()
+		req := buildRequest()
+		mr.HSet(req.DataKey(), req.ApprovedCapacityField(), "5000000")
+
+		ok, used, err := sut.CheckRequest(context.Background(), req, 10000000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok || used != 5000000 {
+			t.Errorf("got (%v, %d), want (false, 5000000)", ok, used)
+		}
+		if mr.Exists(req.StreamKey()) || mr.HGet(req.DataKey(), req.ApprovedField()) != "" {
+			t.Errorf("expected no state to be written")
+		}
+	})
+	t.Run("approves any capacity with an unlimited quota", func(t *testing.T) {
+		mr.FlushAll()
+		req := buildRequest()
+		mr.HSet(req.DataKey(), req.ApprovedCapacityField(), "5000000")
+
+		ok, _, err := sut.CheckRequest(context.Background(), req, quota.Unlimited)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Errorf("got %v, want true", ok)
+		}
+	})
+	t.Run("denies any capacity with a zero quota", func(t *testing.T) {
+		mr.FlushAll()
+		req := buildRequest()
+
+		ok, _, err := sut.CheckRequest(context.Background(), req, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			t.Errorf("got %v, want false", ok)
+		}
+	})
+	t.Run("returns any error", func(t *testing.T) {
+		sut := quota.NewRedisEnforcement(context.Background(),
+			quota.WithDB(&quota.FakeRedis{HMGetFn: func(_ string, _ ...string) ([]interface{}, error) {
+				return nil, ErrFake
+			}}))
+
+		_, _, got := sut.CheckRequest(context.Background(), buildRequest(), 0)
+
+		want := ErrFake
+		if got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestRedisEnforcement_SoftQuota(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	now := time.Unix(1700000000, 0)
+	var exceeded []quota.Request
+	sut := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rc),
+		quota.WithGracePeriod(time.Hour),
+		quota.WithNow(func() time.Time { return now }),
+		quota.WithSoftQuotaExceeded(func(_ context.Context, r quota.Request) {
+			exceeded = append(exceeded, r)
+		}))
+	request := func(name string) quota.Request {
+		r := buildRequest()
+		r.VolumeName = name
+		r.Capacity = "4000000"
+		r.SoftQuota = 6000000
+		return r
+	}
+
+	t.Run("approves requests beyond the soft quota during the grace period", func(t *testing.T) {
+		mr.FlushAll()
+		exceeded = nil
+
+		for _, name := range []string{"k8s-1", "k8s-2"} {
+			ok, err := sut.ApproveRequest(context.Background(), request(name), 20000000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Fatalf("%s: got not approved, want approved", name)
+			}
+		}
+		if len(exceeded) != 1 || exceeded[0].VolumeName != "k8s-2" {
+			t.Errorf("got soft quota exceeded for %+v, want k8s-2", exceeded)
+		}
+
+		now = now.Add(30 * time.Minute)
+		ok, err := sut.ApproveRequest(context.Background(), request("k8s-3"), 20000000)
+		if err != nil || !ok {
+			t.Errorf("got (%v, %v), want (true, nil)", ok, err)
+		}
+	})
+	t.Run("denies requests beyond the soft quota after the grace period", func(t *testing.T) {
+		now = now.Add(time.Hour)
+
+		ok, _, err := sut.CheckRequest(context.Background(), request("k8s-4"), 20000000)
+		if ok || !errors.Is(err, quota.ErrGracePeriodExpired) {
+			t.Errorf("check: got (%v, %v), want (false, %v)", ok, err, quota.ErrGracePeriodExpired)
+		}
+		ok, err = sut.ApproveRequest(context.Background(), request("k8s-4"), 20000000)
+		if ok || !errors.Is(err, quota.ErrGracePeriodExpired) {
+			t.Errorf("approve: got (%v, %v), want (false, %v)", ok, err, quota.ErrGracePeriodExpired)
+		}
+	})
+	t.Run("restarts the grace period once usage is below the soft quota", func(t *testing.T) {
+		r := request("k8s-1")
+		mr.HSet(r.DataKey(), r.ApprovedCapacityField(), "4000000")
+
+		ok, err := sut.ApproveRequest(context.Background(), request("k8s-5"), 20000000)
+		if err != nil || !ok {
+			t.Errorf("got (%v, %v), want (true, nil)", ok, err)
+		}
+		if got, want := mr.HGet(r.DataKey(), r.SoftQuotaExceededField()), strconv.FormatInt(now.Unix(), 10); got != want {
+			t.Errorf("got soft quota exceeded at %q, want %q", got, want)
+		}
+	})
+	t.Run("never denies below the soft quota", func(t *testing.T) {
+		mr.FlushAll()
+		exceeded = nil
+
+		ok, err := sut.ApproveRequest(context.Background(), request("k8s-1"), 20000000)
+		if err != nil || !ok {
+			t.Errorf("got (%v, %v), want (true, nil)", ok, err)
+		}
+		if len(exceeded) != 0 {
+			t.Errorf("got soft quota exceeded for %+v, want none", exceeded)
+		}
+	})
+}
+
+func TestRedisEnforcement_TenantID(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	sut := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rc))
+
+	t.Run("returns the tenant UUID", func(t *testing.T) {
+		mr.FlushAll()
+		mr.HSet("tenant:mytenant:data", quota.TenantIDField, "9b7b1f4e-uuid")
+
+		got, err := sut.TenantID(context.Background(), "mytenant")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "9b7b1f4e-uuid"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+	t.Run("falls back to the tenant name", func(t *testing.T) {
+		mr.FlushAll()
+
+		got, err := sut.TenantID(context.Background(), "mytenant")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "mytenant"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+	t.Run("returns any error", func(t *testing.T) {
+		sut := quota.NewRedisEnforcement(context.Background(),
+			quota.WithDB(&quota.FakeRedis{HGetFn: func(_, _ string) (string, error) {
+				return "", ErrFake
+			}}))
+
+		_, got := sut.TenantID(context.Background(), "mytenant")
+
+		want := ErrFake
+		if got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestRedisEnforcement_MaxVolumes(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	sut := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rc))
+
+	t.Run("returns the max volumes of the tenant", func(t *testing.T) {
+		mr.FlushAll()
+		mr.HSet("tenant:mytenant:data", quota.MaxVolumesField, "5")
+
+		got, err := sut.MaxVolumes(context.Background(), "mytenant")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := int64(5); got != want {
+			t.Errorf("got %d, want %d", got, want)
+		}
+	})
+	t.Run("returns 0 if the tenant has no max volumes", func(t *testing.T) {
+		mr.FlushAll()
+
+		got, err := sut.MaxVolumes(context.Background(), "mytenant")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != 0 {
+			t.Errorf("got %d, want 0", got)
+		}
+	})
+}
+
+func TestRedisEnforcement_VolumeNamePolicy(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	sut := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rc))
+
+	t.Run("returns the volume name policy of the tenant", func(t *testing.T) {
+		mr.FlushAll()
+		mr.HSet("tenant:mytenant:data", quota.VolumePrefixField, "pay-")
+		mr.HSet("tenant:mytenant:data", quota.VolumePatternField, "^pay-[a-z0-9-]+$")
+
+		got, err := sut.VolumeNamePolicy(context.Background(), "mytenant")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := quota.VolumeNamePolicy{Prefix: "pay-", Pattern: "^pay-[a-z0-9-]+$"}
+		if got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+	t.Run("returns an empty policy if the tenant has none", func(t *testing.T) {
+		mr.FlushAll()
+
+		got, err := sut.VolumeNamePolicy(context.Background(), "mytenant")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != (quota.VolumeNamePolicy{}) {
+			t.Errorf("got %+v, want an empty policy", got)
+		}
+	})
+}
+
+func TestVolumeNamePolicy_Check(t *testing.T) {
+	tests := map[string]struct {
+		policy  quota.VolumeNamePolicy
+		name    string
+		wantErr bool
+	}{
+		"no policy":             {name: "k8s-1234"},
+		"matching prefix":       {policy: quota.VolumeNamePolicy{Prefix: "pay-"}, name: "pay-1234"},
+		"wrong prefix":          {policy: quota.VolumeNamePolicy{Prefix: "pay-"}, name: "k8s-1234", wantErr: true},
+		"matching pattern":      {policy: quota.VolumeNamePolicy{Pattern: "^pay-[0-9]+$"}, name: "pay-1234"},
+		"wrong pattern":         {policy: quota.VolumeNamePolicy{Pattern: "^pay-[0-9]+$"}, name: "pay-abcd", wantErr: true},
+		"wrong prefix, pattern": {policy: quota.VolumeNamePolicy{Prefix: "pay-", Pattern: "[0-9]+$"}, name: "k8s-1234", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tc.policy.Check(tc.name)
+			if tc.wantErr != errors.Is(err, quota.ErrVolumeName) {
+				t.Errorf("got err %v, want ErrVolumeName %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRedisEnforcement_Usage(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	sut := quota.NewRedisEnforcement(context.Background(), quota.WithRedis(rc))
+
+	t.Run("returns the approved capacity and volumes", func(t *testing.T) {
+		mr.FlushAll()
+		req := buildRequest()
+		ok, err := sut.ApproveRequest(context.Background(), req, quota.Unlimited)
+		if err != nil || !ok {
+			t.Fatalf("approving request: %v, %v", ok, err)
+		}
+
+		capacity, volumes, err := sut.Usage(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if capacity != 8300000 || volumes != 1 {
+			t.Errorf("got usage %d, %d, want 8300000, 1", capacity, volumes)
+		}
+	})
+	t.Run("returns 0 without approved requests", func(t *testing.T) {
+		mr.FlushAll()
+
+		capacity, volumes, err := sut.Usage(context.Background(), buildRequest())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if capacity != 0 || volumes != 0 {
+			t.Errorf("got usage %d, %d, want 0, 0", capacity, volumes)
+		}
+	})
+}
+
 func buildRequest() quota.Request {
 	return quota.Request{
 		SystemType:    "powerflex",
@@ -455,13 +1137,13 @@ func TestRedisEnforcement(t *testing.T) {
 	t.Run("approves volume request with infinte quota", func(t *testing.T) {
 		r := quota.Request{
 			StoragePoolID: "mypool",
-			Group:         "mygroup1",
+			Group:         "mygroup-unlimited",
 			VolumeName:    "k8s-0",
-			Capacity:      "10",
+			Capacity:      "1000000000",
 		}
 
 		want := true
-		got, err := sut.ApproveRequest(ctx, r, 0)
+		got, err := sut.ApproveRequest(ctx, r, quota.Unlimited)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -484,6 +1166,26 @@ func TestRedisEnforcement(t *testing.T) {
 		}
 	})
 
+	t.Run("denies volume request with zero quota", func(t *testing.T) {
+		r := quota.Request{
+			StoragePoolID: "mypool",
+			Group:         "mygroup-denied",
+			VolumeName:    "k8s-0",
+			Capacity:      "10",
+		}
+
+		got, err := sut.ApproveRequest(ctx, r, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got {
+			t.Errorf("ApproveRequest: got %v, want false", got)
+		}
+		if n, err := rdb.Exists(r.StreamKey()).Result(); err != nil || n != 0 {
+			t.Errorf("expected no approval to be published, got %d stream keys (%v)", n, err)
+		}
+	})
+
 	t.Run("denies volume request exceeding quota", func(t *testing.T) {
 		// Approve requests 0-9 to fill up the quota
 		for i := 0; i < 10; i++ {
@@ -629,6 +1331,116 @@ func TestRedisEnforcement(t *testing.T) {
 			t.Errorf("approved_cap: got %v, want %v", got, want)
 		}
 	})
+
+	t.Run("denies volume requests beyond the max volumes", func(t *testing.T) {
+		r := quota.Request{
+			SystemType:    "powerflex",
+			SystemID:      "123",
+			StoragePoolID: "mypool",
+			Group:         "mygroup8",
+			Capacity:      "10",
+			MaxVolumes:    2,
+		}
+		for _, name := range []string{"k8s-0", "k8s-1"} {
+			r.VolumeName = name
+			if ok, err := sut.ApproveRequest(ctx, r, tenantQuota); err != nil || !ok {
+				t.Fatalf("ApproveRequest(%s): got %v, %v", name, ok, err)
+			}
+		}
+
+		r.VolumeName = "k8s-2"
+		ok, err := sut.ApproveRequest(ctx, r, tenantQuota)
+		if ok || err != quota.ErrMaxVolumes {
+			t.Errorf("ApproveRequest: got %v, %v, want false, %v", ok, err, quota.ErrMaxVolumes)
+		}
+		if ok, _, err := sut.CheckRequest(ctx, r, tenantQuota); ok || err != quota.ErrMaxVolumes {
+			t.Errorf("CheckRequest: got %v, %v, want false, %v", ok, err, quota.ErrMaxVolumes)
+		}
+
+		// A deleted volume frees its slot.
+		r.VolumeName = "k8s-0"
+		if ok, err := sut.PublishDeleted(ctx, r); err != nil || !ok {
+			t.Fatalf("PublishDeleted: got %v, %v", ok, err)
+		}
+		if got, want := rdb.HGet(r.DataKey(), "approved_volumes").Val(), "1"; got != want {
+			t.Errorf("approved_volumes: got %v, want %v", got, want)
+		}
+		r.VolumeName = "k8s-2"
+		if ok, err := sut.ApproveRequest(ctx, r, tenantQuota); err != nil || !ok {
+			t.Errorf("ApproveRequest: got %v, %v, want true", ok, err)
+		}
+	})
+
+	t.Run("reloads scripts that Redis no longer has", func(t *testing.T) {
+		r := quota.Request{
+			SystemType:    "powerflex",
+			SystemID:      "123",
+			StoragePoolID: "mypool",
+			Group:         "mygroup7",
+			VolumeName:    "k8s-0",
+			Capacity:      "10",
+		}
+		if _, err := sut.ApproveRequest(ctx, r, tenantQuota); err != nil {
+			t.Fatal(err)
+		}
+		if err := rdb.ScriptFlush().Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		if ok, err := sut.PublishCreated(ctx, r); err != nil || !ok {
+			t.Errorf("PublishCreated: got %v, %v", ok, err)
+		}
+		if got, err := sut.DeleteRequests(ctx, []quota.Request{r}); err != nil || !reflect.DeepEqual(got, []bool{true}) {
+			t.Errorf("DeleteRequests: got %v, %v", got, err)
+		}
+	})
+
+	t.Run("deletes volumes in a batch", func(t *testing.T) {
+		var rs []quota.Request
+		for i := 0; i < 3; i++ {
+			r := quota.Request{
+				SystemType:    "powerflex",
+				SystemID:      "123",
+				StoragePoolID: "mypool",
+				Group:         "mygroup6",
+				VolumeName:    fmt.Sprintf("k8s-%d", i),
+				Capacity:      "10",
+			}
+			if ok, err := sut.ApproveRequest(ctx, r, tenantQuota); err != nil || !ok {
+				t.Fatalf("ApproveRequest: got %v, %v", ok, err)
+			}
+			rs = append(rs, r)
+		}
+		// A volume that was never approved is not owned by the tenant.
+		rs = append(rs, quota.Request{
+			SystemType:    "powerflex",
+			SystemID:      "123",
+			StoragePoolID: "mypool",
+			Group:         "mygroup6",
+			VolumeName:    "k8s-unknown",
+			Capacity:      "10",
+		})
+		want := []bool{true, true, true, false}
+
+		got, err := sut.DeleteRequests(ctx, rs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("DeleteRequests: got %v, want %v", got, want)
+		}
+
+		got, err = sut.PublishDeletedBatch(ctx, rs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("PublishDeletedBatch: got %v, want %v", got, want)
+		}
+		if got, want := rdb.HGet("quota:powerflex:123:mypool:mygroup6:data", "approved_capacity").Val(), "0"; got != want {
+			t.Errorf("approved_cap: got %v, want %v", got, want)
+		}
+	})
 }
 
 type tb interface {
@@ -672,3 +1484,54 @@ func BenchmarkApproveRequest(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkApproveRequest_Parallel(b *testing.B) {
+	rdb := testCreateRedisInstance(b)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sut := quota.NewRedisEnforcement(ctx, quota.WithRedis(rdb))
+
+	var n int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ok, err := sut.ApproveRequest(ctx, quota.Request{
+				StoragePoolID: "mypool",
+				Group:         "mygroup",
+				VolumeName:    fmt.Sprintf("k8s-%d", atomic.AddInt64(&n, 1)),
+				Capacity:      "1",
+			}, quota.Unlimited)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			if !ok {
+				b.Error("failed to approve")
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkCheckRequest(b *testing.B) {
+	rdb := testCreateRedisInstance(b)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sut := quota.NewRedisEnforcement(ctx, quota.WithRedis(rdb))
+	r := quota.Request{
+		StoragePoolID: "mypool",
+		Group:         "mygroup",
+		VolumeName:    "k8s-0",
+		Capacity:      "1",
+	}
+	if _, err := sut.ApproveRequest(ctx, r, quota.Unlimited); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := sut.CheckRequest(ctx, r, 1_000_000); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
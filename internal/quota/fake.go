@@ -19,12 +19,16 @@ import "github.com/go-redis/redis"
 // FakeRedis is used for mocking out commonly used functions for
 // the Redis client.
 type FakeRedis struct {
-	PingFn    func() (string, error)
-	HExistsFn func(key, field string) (bool, error)
-	EvalIntFn func(script string, keys []string, args ...interface{}) (int, error)
-	HSetNXFn  func(key, field string, value interface{}) (bool, error)
-	HGetFn    func(key, field string) (string, error)
-	XRangeFn  func(stream, start, stop string) ([]redis.XMessage, error)
+	PingFn         func() (string, error)
+	HExistsFn      func(key, field string) (bool, error)
+	EvalIntFn      func(script string, keys []string, args ...interface{}) (int, error)
+	EvalIntBatchFn func(script string, evals []EvalArgs) ([]int, error)
+	HSetNXFn       func(key, field string, value interface{}) (bool, error)
+	HGetFn         func(key, field string) (string, error)
+	HMGetFn        func(key string, fields ...string) ([]interface{}, error)
+	HGetAllFn      func(key string) (map[string]string, error)
+	XRangeFn       func(stream, start, stop string) ([]redis.XMessage, error)
+	ScanFn         func(match string) ([]string, error)
 }
 
 // Ping delegates to the PingFn function field.
@@ -47,12 +51,32 @@ func (f *FakeRedis) HGet(key, field string) (string, error) {
 	return f.HGetFn(key, field)
 }
 
+// HMGet delegates to the HMGetFn function field.
+func (f *FakeRedis) HMGet(key string, fields ...string) ([]interface{}, error) {
+	return f.HMGetFn(key, fields...)
+}
+
+// HGetAll delegates to the HGetAllFn function field.
+func (f *FakeRedis) HGetAll(key string) (map[string]string, error) {
+	return f.HGetAllFn(key)
+}
+
 // EvalInt delegates to the EvalIntFn function field.
 func (f *FakeRedis) EvalInt(script string, keys []string, args ...interface{}) (int, error) {
 	return f.EvalIntFn(script, keys, args...)
 }
 
+// EvalIntBatch delegates to the EvalIntBatchFn function field.
+func (f *FakeRedis) EvalIntBatch(script string, evals []EvalArgs) ([]int, error) {
+	return f.EvalIntBatchFn(script, evals)
+}
+
 // XRange delegates to the XRangeFn function field.
 func (f *FakeRedis) XRange(stream, start, stop string) ([]redis.XMessage, error) {
 	return f.XRangeFn(stream, start, stop)
 }
+
+// Scan delegates to the ScanFn function field.
+func (f *FakeRedis) Scan(match string) ([]string, error) {
+	return f.ScanFn(match)
+}
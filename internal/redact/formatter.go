@@ -0,0 +1,63 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact
+
+import "github.com/sirupsen/logrus"
+
+// Formatter masks the secrets in the message and fields of log entries
+// before formatting them with the wrapped Formatter.
+type Formatter struct {
+	logrus.Formatter
+	// Redactor masks the secrets; if nil, the current one is used.
+	Redactor *Redactor
+}
+
+// NewFormatter returns a Formatter wrapping f with the current Redactor.
+func NewFormatter(f logrus.Formatter) *Formatter {
+	return &Formatter{Formatter: f}
+}
+
+// Format masks the secrets in the entry and formats it.
+func (f *Formatter) Format(e *logrus.Entry) ([]byte, error) {
+	r := f.Redactor
+	if r == nil {
+		r = Current()
+	}
+
+	data := make(logrus.Fields, len(e.Data))
+	for k, v := range e.Data {
+		switch t := v.(type) {
+		case string:
+			if r.IsSecret(k) {
+				data[k] = Mask
+			} else {
+				data[k] = r.String(t)
+			}
+		case error:
+			data[k] = r.String(t.Error())
+		default:
+			if r.IsSecret(k) {
+				data[k] = Mask
+			} else {
+				data[k] = v
+			}
+		}
+	}
+
+	redacted := *e
+	redacted.Data = data
+	redacted.Message = r.String(e.Message)
+	return f.Formatter.Format(&redacted)
+}
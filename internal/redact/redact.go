@@ -0,0 +1,215 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redact masks credentials, tokens and other secrets in what is
+// logged, e.g. dumps of HTTP requests.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// Mask replaces the redacted values.
+const Mask = "[REDACTED]"
+
+// defaultFields are the names of the headers and JSON fields whose values
+// are redacted, compared case-insensitively. Fields whose name contains
+// "password" or "secret" are redacted too.
+var defaultFields = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-CSRF-Token",
+	"X-Auth-Token",
+	"token",
+	"access",
+	"refresh",
+	"accessToken",
+	"refreshToken",
+}
+
+// defaultPatterns are the regular expressions of secrets in free text. If a
+// pattern has a capture group, the text of the first one is kept and only
+// the rest of the match is masked.
+var defaultPatterns = []string{
+	// authorization credentials, e.g. in a dumped header
+	`(?i)\b((?:bearer|basic)\s+)[A-Za-z0-9._~+/=-]+`,
+	// JSON web tokens
+	`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`,
+	// PowerScale session cookies
+	`(?i)\b((?:isisessid|isicsrf)=)[^;\s]+`,
+	// passwords and secrets in JSON, YAML or formatted structs, quoted
+	// or not
+	`(?i)((?:password|secret)\w*["']?\s*[:=]\s*")[^"]*`,
+	`(?i)((?:password|secret)\w*["']?\s*[:=]\s*')[^']*`,
+	`(?i)((?:password|secret)\w*["']?\s*[:=]\s*)[^\s"',}&]+`,
+}
+
+// Redactor masks secrets by the names of headers and fields, and by
+// patterns in free text.
+type Redactor struct {
+	fields   map[string]struct{}
+	patterns []*regexp.Regexp
+}
+
+// New returns a Redactor of the default fields and patterns, and of the
+// given ones.
+func New(fields, patterns []string) (*Redactor, error) {
+	r := &Redactor{fields: make(map[string]struct{})}
+	for _, f := range append(append([]string{}, defaultFields...), fields...) {
+		r.fields[strings.ToLower(f)] = struct{}{}
+	}
+	for _, p := range append(append([]string{}, defaultPatterns...), patterns...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+var current atomic.Pointer[Redactor]
+
+func init() {
+	r, err := New(nil, nil)
+	if err != nil {
+		panic(err)
+	}
+	current.Store(r)
+}
+
+// Configure makes the Redactor of the given fields and patterns, in
+// addition to the default ones, the current one. On error the current one
+// is kept.
+func Configure(fields, patterns []string) error {
+	r, err := New(fields, patterns)
+	if err != nil {
+		return err
+	}
+	current.Store(r)
+	return nil
+}
+
+// Current returns the current Redactor, as set by Configure.
+func Current() *Redactor {
+	return current.Load()
+}
+
+// IsSecret reports whether the values of the header or field are redacted.
+func (r *Redactor) IsSecret(name string) bool {
+	name = strings.ToLower(name)
+	if _, ok := r.fields[name]; ok {
+		return true
+	}
+	return strings.Contains(name, "password") || strings.Contains(name, "secret")
+}
+
+// String masks the secrets matched by the patterns in s.
+func (r *Redactor) String(s string) string {
+	for _, re := range r.patterns {
+		if re.NumSubexp() > 0 {
+			s = re.ReplaceAllString(s, "${1}"+Mask)
+		} else {
+			s = re.ReplaceAllLiteralString(s, Mask)
+		}
+	}
+	return s
+}
+
+// Header returns a copy of h with the values of secret headers masked.
+func (r *Redactor) Header(h http.Header) http.Header {
+	out := h.Clone()
+	for k, vs := range out {
+		for i, v := range vs {
+			if r.IsSecret(k) {
+				vs[i] = Mask
+			} else {
+				vs[i] = r.String(v)
+			}
+		}
+	}
+	return out
+}
+
+// JSON masks the values of secret fields in the JSON document b. Anything
+// else is masked by the patterns.
+func (r *Redactor) JSON(b []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return []byte(r.String(string(b)))
+	}
+	out, err := json.Marshal(r.value(v))
+	if err != nil {
+		return []byte(r.String(string(b)))
+	}
+	return out
+}
+
+func (r *Redactor) value(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			if r.IsSecret(k) {
+				t[k] = Mask
+			} else {
+				t[k] = r.value(e)
+			}
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = r.value(e)
+		}
+	case string:
+		return r.String(t)
+	}
+	return v
+}
+
+// DumpRequest returns the wire representation of req, like
+// httputil.DumpRequest with its body, with the secrets masked. The body of
+// req can still be read afterwards.
+func (r *Redactor) DumpRequest(req *http.Request) ([]byte, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	clone := req.Clone(req.Context())
+	clone.Header = r.Header(req.Header)
+	clone.URL.RawQuery = r.String(req.URL.RawQuery)
+	if len(body) > 0 {
+		redacted := r.JSON(body)
+		clone.Body = io.NopCloser(bytes.NewReader(redacted))
+		clone.ContentLength = int64(len(redacted))
+	}
+	return httputil.DumpRequest(clone, true)
+}
@@ -0,0 +1,158 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact_test
+
+import (
+	"bytes"
+	"io"
+	"karavi-authorization/internal/redact"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRedactor_String(t *testing.T) {
+	r, err := redact.New(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, in, want string
+	}{
+		{"bearer token", "Authorization: Bearer abc.def-123", "Authorization: Bearer [REDACTED]"},
+		{"basic credentials", "basic dXNlcjpwYXNz", "basic [REDACTED]"},
+		{"json web token", "token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJ0In0.c2ln here", "token [REDACTED] here"},
+		{"session cookie", "isisessid=1234abcd; isicsrf=5678", "isisessid=[REDACTED]; isicsrf=[REDACTED]"},
+		{"json password", `{"username":"admin","password":"Passw0rd!"}`, `{"username":"admin","password":"[REDACTED]"}`},
+		{"formatted struct", "{Host:redis:6379 Password:hunter2}", "{Host:redis:6379 Password:[REDACTED]}"},
+		{"yaml secret", "JWTSigningSecret: s3cr3t", "JWTSigningSecret: [REDACTED]"},
+		{"no secrets", "Serving 10.0.0.1 GET /api/version/", "Serving 10.0.0.1 GET /api/version/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.String(tt.in); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactor_JSON(t *testing.T) {
+	r, err := redact.New([]string{"apiKey"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := r.JSON([]byte(`{"name":"pool","size":8,"Password":"x","nested":[{"apikey":"y","refreshToken":"z"}]}`))
+
+	want := `{"Password":"[REDACTED]","name":"pool","nested":[{"apikey":"[REDACTED]","refreshToken":"[REDACTED]"}],"size":8}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	t.Run("it falls back to the patterns for other content", func(t *testing.T) {
+		got := r.JSON([]byte("password=secret&user=admin"))
+
+		if want := "password=[REDACTED]&user=admin"; string(got) != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	})
+}
+
+func TestRedactor_DumpRequest(t *testing.T) {
+	r := redact.Current()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/storage/", strings.NewReader(`{"user":"admin","password":"Passw0rd!"}`))
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("X-Csrf-Token", "csrf")
+	req.Header.Set("Content-Type", "application/json")
+
+	b, err := r.DumpRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dump := string(b)
+	for _, secret := range []string{"abc", "csrf", "Passw0rd!"} {
+		if strings.Contains(dump, secret) {
+			t.Errorf("expected %q to be redacted, got %s", secret, dump)
+		}
+	}
+	if !strings.Contains(dump, "Content-Type: application/json") || !strings.Contains(dump, `"user":"admin"`) {
+		t.Errorf("expected the rest of the request to be dumped, got %s", dump)
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"user":"admin","password":"Passw0rd!"}`; string(body) != want {
+		t.Errorf("got body %s, want %s", body, want)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer abc" {
+		t.Errorf("got Authorization %q, want it unchanged", got)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	defer func() {
+		if err := redact.Configure(nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	if err := redact.Configure(nil, []string{`\bcustomer-\d+`}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := redact.Current().String("owner customer-42"), "owner [REDACTED]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	t.Run("it keeps the current redactor on an invalid pattern", func(t *testing.T) {
+		before := redact.Current()
+
+		if err := redact.Configure(nil, []string{"("}); err == nil {
+			t.Error("expected an error")
+		}
+		if redact.Current() != before {
+			t.Error("expected the current redactor to be kept")
+		}
+	})
+}
+
+func TestFormatter(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+	logger.SetFormatter(redact.NewFormatter(&logrus.JSONFormatter{}))
+
+	logger.WithFields(logrus.Fields{
+		"password": "hunter2",
+		"header":   "Bearer abc",
+		"count":    3,
+	}).Infof("Config: %+v", struct{ Host, Password string }{"redis", "s3cr3t"})
+
+	got := out.String()
+	for _, secret := range []string{"hunter2", "abc", "s3cr3t"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("expected %q to be redacted, got %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, `"count":3`) || !strings.Contains(got, "Host:redis") {
+		t.Errorf("expected the rest of the entry to be logged, got %s", got)
+	}
+}
@@ -0,0 +1,323 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reportsvc keeps daily snapshots of the capacity approved for each
+// tenant and serves the history of it for chargeback and showback.
+package reportsvc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"karavi-authorization/internal/usage"
+	"karavi-authorization/pb"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// DateLayout is the layout of the days of snapshots.
+const DateLayout = time.DateOnly
+
+// KeyUsageDays is a sorted set of the days that have a usage snapshot,
+// scored by the start of the day in seconds since the Unix epoch. The
+// snapshot of each day is a hash, in report:usage:<day>, of the usage of
+// each tenant in each storage pool.
+const KeyUsageDays = "report:usage:days"
+
+// ReportService is the gRPC implementation of the ReportServiceServer.
+type ReportService struct {
+	pb.UnimplementedReportServiceServer
+
+	log     *logrus.Entry
+	rdb     *redis.Client
+	tenants usage.TenantClient
+	// retention is how long snapshots are kept for. They are kept forever
+	// if it is 0.
+	retention time.Duration
+}
+
+// Option allows for functional option arguments on the ReportService.
+type Option func(*ReportService)
+
+// WithLogger provides a logger.
+func WithLogger(log *logrus.Entry) Option {
+	return func(s *ReportService) {
+		s.log = log
+	}
+}
+
+// WithRedis provides a redis client.
+func WithRedis(rdb *redis.Client) Option {
+	return func(s *ReportService) {
+		s.rdb = rdb
+	}
+}
+
+// WithTenants provides the tenant service that usage is collected from.
+func WithTenants(tenants usage.TenantClient) Option {
+	return func(s *ReportService) {
+		s.tenants = tenants
+	}
+}
+
+// WithRetention sets how long snapshots are kept for.
+func WithRetention(d time.Duration) Option {
+	return func(s *ReportService) {
+		s.retention = d
+	}
+}
+
+// NewReportService allocates a new ReportService.
+func NewReportService(opts ...Option) *ReportService {
+	s := ReportService{
+		log: logrus.NewEntry(logrus.New()),
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return &s
+}
+
+// Run takes a snapshot at once and then every interval until ctx is done.
+// The last snapshot of a day is kept as the usage of that day.
+func (s *ReportService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Snapshot(ctx, time.Now()); err != nil {
+			s.log.WithError(err).Error("report: taking usage snapshot")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Snapshot replaces the snapshot of the day of now with the current usage
+// of every tenant, and removes the snapshots past the retention.
+func (s *ReportService) Snapshot(ctx context.Context, now time.Time) error {
+	records, err := usage.Collect(ctx, s.tenants, "", now)
+	if err != nil {
+		return err
+	}
+
+	day := startOfDay(now)
+	date := day.Format(DateLayout)
+	key := usageKey(date)
+	_, err = s.rdb.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(key)
+		for _, r := range records {
+			b, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			pipe.HSet(key, recordField(r), b)
+		}
+		pipe.ZAdd(KeyUsageDays, redis.Z{Score: float64(day.Unix()), Member: date})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("storing usage snapshot of %s: %w", date, err)
+	}
+
+	if s.retention <= 0 {
+		return nil
+	}
+	return s.purge(day.Add(-s.retention))
+}
+
+// purge removes the snapshots of the days before the cutoff.
+func (s *ReportService) purge(cutoff time.Time) error {
+	max := "(" + strconv.FormatInt(cutoff.Unix(), 10)
+	days, err := s.rdb.ZRangeByScore(KeyUsageDays, redis.ZRangeBy{Min: "-inf", Max: max}).Result()
+	if err != nil {
+		return err
+	}
+	if len(days) == 0 {
+		return nil
+	}
+
+	_, err = s.rdb.TxPipelined(func(pipe redis.Pipeliner) error {
+		for _, d := range days {
+			pipe.Del(usageKey(d))
+			pipe.ZRem(KeyUsageDays, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("purging usage snapshots: %w", err)
+	}
+	s.log.WithField("days", len(days)).Debug("report: purged usage snapshots")
+	return nil
+}
+
+// GetUsageHistory returns the daily snapshots of the usage of tenants
+// between two days, and a summary of the usage of each tenant in each
+// storage pool over those days.
+func (s *ReportService) GetUsageHistory(ctx context.Context, req *pb.GetUsageHistoryRequest) (*pb.GetUsageHistoryResponse, error) {
+	from, err := time.Parse(DateLayout, req.GetFrom())
+	if err != nil {
+		return nil, fmt.Errorf("parsing from: %w", err)
+	}
+	to, err := time.Parse(DateLayout, req.GetTo())
+	if err != nil {
+		return nil, fmt.Errorf("parsing to: %w", err)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("to %s is before from %s", req.GetTo(), req.GetFrom())
+	}
+
+	include, err := s.tenantFilter(ctx, req.GetTenant(), req.GetOrganization())
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := s.rdb.ZRangeByScore(KeyUsageDays, redis.ZRangeBy{
+		Min: strconv.FormatInt(from.Unix(), 10),
+		Max: strconv.FormatInt(to.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetUsageHistoryResponse{}
+	summaries := make(map[string]*pb.UsageSummary)
+	for _, date := range days {
+		m, err := s.rdb.HGetAll(usageKey(date)).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		var snapshots []*pb.UsageSnapshot
+		for field, v := range m {
+			var r usage.Record
+			if err := json.Unmarshal([]byte(v), &r); err != nil {
+				s.log.WithError(err).WithField("day", date).Warn("report: skipping malformed usage snapshot")
+				continue
+			}
+			if !include(r.Tenant) {
+				continue
+			}
+			snapshots = append(snapshots, &pb.UsageSnapshot{
+				Date:             date,
+				Tenant:           r.Tenant,
+				SystemType:       r.SystemType,
+				SystemId:         r.SystemID,
+				Pool:             r.Pool,
+				ApprovedCapacity: r.ApprovedCapacity,
+				ApprovedVolumes:  r.ApprovedVolumes,
+			})
+
+			sum, ok := summaries[field]
+			if !ok {
+				sum = &pb.UsageSummary{
+					Tenant:     r.Tenant,
+					SystemType: r.SystemType,
+					SystemId:   r.SystemID,
+					Pool:       r.Pool,
+				}
+				summaries[field] = sum
+			}
+			sum.CapacityDays += r.ApprovedCapacity
+			sum.PeakCapacity = max(sum.PeakCapacity, r.ApprovedCapacity)
+			sum.PeakVolumes = max(sum.PeakVolumes, r.ApprovedVolumes)
+		}
+		sort.Slice(snapshots, func(i, j int) bool {
+			a, b := snapshots[i], snapshots[j]
+			return poolKey(a.Tenant, a.SystemType, a.SystemId, a.Pool) < poolKey(b.Tenant, b.SystemType, b.SystemId, b.Pool)
+		})
+		resp.Snapshots = append(resp.Snapshots, snapshots...)
+	}
+
+	for _, sum := range summaries {
+		sum.Days = int64(len(days))
+		sum.AverageCapacity = sum.CapacityDays / sum.Days
+		resp.Summaries = append(resp.Summaries, sum)
+	}
+	sort.Slice(resp.Summaries, func(i, j int) bool {
+		a, b := resp.Summaries[i], resp.Summaries[j]
+		return poolKey(a.Tenant, a.SystemType, a.SystemId, a.Pool) < poolKey(b.Tenant, b.SystemType, b.SystemId, b.Pool)
+	})
+	return resp, nil
+}
+
+// tenantFilter returns whether the usage of a tenant is in a report of the
+// tenant, if not empty, in the organization, if not empty.
+func (s *ReportService) tenantFilter(ctx context.Context, tenant, organization string) (func(string) bool, error) {
+	if organization == "" {
+		return func(name string) bool {
+			return tenant == "" || name == tenant
+		}, nil
+	}
+
+	resp, err := s.tenants.ListTenant(ctx, &pb.ListTenantRequest{
+		Organization: organization,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing tenants of organization %s: %w", organization, err)
+	}
+	members := make(map[string]struct{})
+	for _, t := range resp.GetTenants() {
+		members[t.GetName()] = struct{}{}
+	}
+	return func(name string) bool {
+		_, ok := members[name]
+		return ok && (tenant == "" || name == tenant)
+	}, nil
+}
+
+// LocalTenants is a usage.TenantClient of a tenant service in the same
+// process.
+type LocalTenants struct {
+	Server pb.TenantServiceServer
+}
+
+// ListTenant implements usage.TenantClient.
+func (l LocalTenants) ListTenant(ctx context.Context, in *pb.ListTenantRequest, _ ...grpc.CallOption) (*pb.ListTenantResponse, error) {
+	return l.Server.ListTenant(ctx, in)
+}
+
+// GetTenantQuota implements usage.TenantClient.
+func (l LocalTenants) GetTenantQuota(ctx context.Context, in *pb.GetTenantQuotaRequest, _ ...grpc.CallOption) (*pb.TenantQuota, error) {
+	return l.Server.GetTenantQuota(ctx, in)
+}
+
+func usageKey(date string) string {
+	return fmt.Sprintf("report:usage:%s", date)
+}
+
+// recordField is the field of the usage of a tenant in a storage pool in
+// the hash of a snapshot.
+func recordField(r usage.Record) string {
+	return poolKey(r.Tenant, r.SystemType, r.SystemID, r.Pool)
+}
+
+// poolKey identifies, and orders, the usage of a tenant in a storage pool.
+func poolKey(tenant, systemType, systemID, pool string) string {
+	return strings.Join([]string{tenant, systemType, systemID, pool}, "/")
+}
+
+// startOfDay returns the start of the day of t in UTC.
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
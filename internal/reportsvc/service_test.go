@@ -0,0 +1,204 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reportsvc_test
+
+import (
+	"context"
+	"karavi-authorization/internal/reportsvc"
+	"karavi-authorization/internal/tenantsvc/mocks"
+	"karavi-authorization/pb"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeUsage is the approved capacity of tenants in the bronze pool.
+type fakeUsage map[string]int64
+
+func (u fakeUsage) client() *mocks.FakeTenantServiceClient {
+	return &mocks.FakeTenantServiceClient{
+		ListTenantFn: func(_ context.Context, req *pb.ListTenantRequest, _ ...grpc.CallOption) (*pb.ListTenantResponse, error) {
+			var resp pb.ListTenantResponse
+			for name := range u {
+				if req.Organization == "" || (req.Organization == "acme" && name == "tenant-a") {
+					resp.Tenants = append(resp.Tenants, &pb.Tenant{Name: name})
+				}
+			}
+			return &resp, nil
+		},
+		GetTenantQuotaFn: func(_ context.Context, req *pb.GetTenantQuotaRequest, _ ...grpc.CallOption) (*pb.TenantQuota, error) {
+			return &pb.TenantQuota{
+				Name: req.Name,
+				Pools: []*pb.PoolUsage{
+					{SystemType: "powerflex", SystemId: "542a2d5f5122210f", Pool: "bronze", ApprovedCapacity: u[req.Name], ApprovedVolumes: u[req.Name] / 8388608},
+				},
+			}, nil
+		},
+	}
+}
+
+func day(date string) time.Time {
+	t, err := time.Parse(reportsvc.DateLayout, date)
+	if err != nil {
+		panic(err)
+	}
+	return t.Add(23 * time.Hour)
+}
+
+func newService(t *testing.T, u fakeUsage, opts ...reportsvc.Option) (*reportsvc.ReportService, *redis.Client) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mr.Close)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	opts = append([]reportsvc.Option{reportsvc.WithRedis(rdb), reportsvc.WithTenants(u.client())}, opts...)
+	return reportsvc.NewReportService(opts...), rdb
+}
+
+func TestReportService_GetUsageHistory(t *testing.T) {
+	ctx := context.Background()
+	u := fakeUsage{"tenant-a": 8388608, "tenant-b": 16777216}
+	sut, _ := newService(t, u)
+
+	// tenant-b grows on the second day and is deleted on the third.
+	if err := sut.Snapshot(ctx, day("2024-03-01")); err != nil {
+		t.Fatal(err)
+	}
+	u["tenant-b"] = 33554432
+	if err := sut.Snapshot(ctx, day("2024-03-02").Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	delete(u, "tenant-b")
+	if err := sut.Snapshot(ctx, day("2024-03-03")); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("it reports the daily usage and summaries", func(t *testing.T) {
+		got, err := sut.GetUsageHistory(ctx, &pb.GetUsageHistoryRequest{From: "2024-03-01", To: "2024-03-03"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got.Snapshots) != 5 {
+			t.Fatalf("expected 5 snapshots, got %d: %v", len(got.Snapshots), got.Snapshots)
+		}
+		if s := got.Snapshots[3]; s.Date != "2024-03-02" || s.Tenant != "tenant-b" || s.ApprovedCapacity != 33554432 {
+			t.Errorf("expected the usage of tenant-b on 2024-03-02, got %v", s)
+		}
+		want := []*pb.UsageSummary{
+			{Tenant: "tenant-a", SystemType: "powerflex", SystemId: "542a2d5f5122210f", Pool: "bronze", Days: 3, PeakCapacity: 8388608, AverageCapacity: 8388608, CapacityDays: 25165824, PeakVolumes: 1},
+			{Tenant: "tenant-b", SystemType: "powerflex", SystemId: "542a2d5f5122210f", Pool: "bronze", Days: 3, PeakCapacity: 33554432, AverageCapacity: 16777216, CapacityDays: 50331648, PeakVolumes: 4},
+		}
+		if len(got.Summaries) != len(want) {
+			t.Fatalf("expected %d summaries, got %v", len(want), got.Summaries)
+		}
+		for i := range want {
+			if !proto.Equal(got.Summaries[i], want[i]) {
+				t.Errorf("summary %d: got %v, want %v", i, got.Summaries[i], want[i])
+			}
+		}
+	})
+
+	t.Run("it limits the report to the days", func(t *testing.T) {
+		got, err := sut.GetUsageHistory(ctx, &pb.GetUsageHistoryRequest{From: "2024-03-02", To: "2024-03-02"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Snapshots) != 2 || got.Summaries[0].Days != 1 {
+			t.Errorf("expected the usage of a single day, got %v", got)
+		}
+	})
+
+	t.Run("it limits the report to a tenant", func(t *testing.T) {
+		got, err := sut.GetUsageHistory(ctx, &pb.GetUsageHistoryRequest{From: "2024-03-01", To: "2024-03-31", Tenant: "tenant-b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Snapshots) != 2 || len(got.Summaries) != 1 || got.Summaries[0].Tenant != "tenant-b" {
+			t.Errorf("expected the usage of tenant-b, got %v", got)
+		}
+	})
+
+	t.Run("it limits the report to an organization", func(t *testing.T) {
+		got, err := sut.GetUsageHistory(ctx, &pb.GetUsageHistoryRequest{From: "2024-03-01", To: "2024-03-31", Organization: "acme"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Summaries) != 1 || got.Summaries[0].Tenant != "tenant-a" {
+			t.Errorf("expected the usage of tenant-a, got %v", got)
+		}
+	})
+
+	t.Run("it rejects reversed days", func(t *testing.T) {
+		_, err := sut.GetUsageHistory(ctx, &pb.GetUsageHistoryRequest{From: "2024-03-03", To: "2024-03-01"})
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestReportService_Snapshot(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it replaces the snapshot of the day", func(t *testing.T) {
+		u := fakeUsage{"tenant-a": 8388608, "tenant-b": 8388608}
+		sut, _ := newService(t, u)
+
+		if err := sut.Snapshot(ctx, day("2024-03-01").Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		delete(u, "tenant-b")
+		if err := sut.Snapshot(ctx, day("2024-03-01")); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := sut.GetUsageHistory(ctx, &pb.GetUsageHistoryRequest{From: "2024-03-01", To: "2024-03-01"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Snapshots) != 1 || got.Snapshots[0].Tenant != "tenant-a" {
+			t.Errorf("expected the last snapshot of the day, got %v", got.Snapshots)
+		}
+	})
+
+	t.Run("it purges snapshots past the retention", func(t *testing.T) {
+		sut, rdb := newService(t, fakeUsage{"tenant-a": 8388608}, reportsvc.WithRetention(48*time.Hour))
+
+		for _, d := range []string{"2024-03-01", "2024-03-02", "2024-03-03", "2024-03-04"} {
+			if err := sut.Snapshot(ctx, day(d)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		days, err := rdb.ZRange(reportsvc.KeyUsageDays, 0, -1).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(days) != 3 || days[0] != "2024-03-02" {
+			t.Errorf("expected the snapshots of the last 3 days, got %v", days)
+		}
+		if n, _ := rdb.Exists("report:usage:2024-03-01").Result(); n != 0 {
+			t.Error("expected the purged snapshot to be removed")
+		}
+	})
+}
@@ -178,6 +178,25 @@ func (t *TelemetryMW) Delete(ctx context.Context, req *pb.RoleDeleteRequest) (*p
 	return resp, nil
 }
 
+// Status wraps Status
+func (t *TelemetryMW) Status(ctx context.Context, req *pb.RoleStatusRequest) (*pb.RoleStatusResponse, error) {
+	now := time.Now()
+	defer t.timeSince(now, "Status")
+
+	span := trace.SpanFromContext(ctx)
+
+	t.log.Info("Getting role sync status")
+
+	resp, err := t.next.Status(ctx, req)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
 func (t *TelemetryMW) timeSince(start time.Time, fName string) {
 	t.log.WithFields(logrus.Fields{
 		"duration": fmt.Sprintf("%v", time.Since(start)),
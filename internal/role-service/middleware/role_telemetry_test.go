@@ -113,6 +113,25 @@ func TestTelemetry(t *testing.T) {
 		}
 	})
 
+	t.Run("Status", func(t *testing.T) {
+		var gotCalled bool
+		next := &mocks.FakeRoleServiceServer{
+			StatusFn: func(_ context.Context, _ *pb.RoleStatusRequest) (*pb.RoleStatusResponse, error) {
+				gotCalled = true
+				return &pb.RoleStatusResponse{InSync: true}, nil
+			},
+		}
+
+		sut := NewRoleTelemetryMW(logrus.NewEntry(logrus.StandardLogger()), next)
+		_, err := sut.Status(context.Background(), &pb.RoleStatusRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !gotCalled {
+			t.Errorf("expected next service to be called")
+		}
+	})
+
 	t.Run("DeleteRole", func(t *testing.T) {
 		var gotCalled bool
 		next := &mocks.FakeRoleServiceServer{
@@ -29,6 +29,7 @@ type FakeRoleServiceClient struct {
 	GetRoleFn    func(context.Context, *pb.RoleGetRequest, ...grpc.CallOption) (*pb.RoleGetResponse, error)
 	ListRoleFn   func(context.Context, *pb.RoleListRequest, ...grpc.CallOption) (*pb.RoleListResponse, error)
 	DeleteRoleFn func(context.Context, *pb.RoleDeleteRequest, ...grpc.CallOption) (*pb.RoleDeleteResponse, error)
+	StatusFn     func(context.Context, *pb.RoleStatusRequest, ...grpc.CallOption) (*pb.RoleStatusResponse, error)
 }
 
 // Create executes the mock Create
@@ -70,3 +71,11 @@ func (f *FakeRoleServiceClient) Delete(ctx context.Context, in *pb.RoleDeleteReq
 	}
 	return &pb.RoleDeleteResponse{}, nil
 }
+
+// Status executes the mock Status
+func (f *FakeRoleServiceClient) Status(ctx context.Context, in *pb.RoleStatusRequest, opts ...grpc.CallOption) (*pb.RoleStatusResponse, error) {
+	if f.StatusFn != nil {
+		return f.StatusFn(ctx, in, opts...)
+	}
+	return &pb.RoleStatusResponse{InSync: true}, nil
+}
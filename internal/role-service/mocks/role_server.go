@@ -27,6 +27,7 @@ type FakeRoleServiceServer struct {
 	GetRoleFn    func(context.Context, *pb.RoleGetRequest) (*pb.RoleGetResponse, error)
 	ListRoleFn   func(context.Context, *pb.RoleListRequest) (*pb.RoleListResponse, error)
 	DeleteRoleFn func(context.Context, *pb.RoleDeleteRequest) (*pb.RoleDeleteResponse, error)
+	StatusFn     func(context.Context, *pb.RoleStatusRequest) (*pb.RoleStatusResponse, error)
 }
 
 // Create handles the mock Create
@@ -68,3 +69,11 @@ func (f *FakeRoleServiceServer) Delete(ctx context.Context, in *pb.RoleDeleteReq
 	}
 	return &pb.RoleDeleteResponse{}, nil
 }
+
+// Status handles the mock Status
+func (f *FakeRoleServiceServer) Status(ctx context.Context, in *pb.RoleStatusRequest) (*pb.RoleStatusResponse, error) {
+	if f.StatusFn != nil {
+		return f.StatusFn(ctx, in)
+	}
+	return &pb.RoleStatusResponse{InSync: true}, nil
+}
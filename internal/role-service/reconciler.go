@@ -0,0 +1,283 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package role
+
+import (
+	"context"
+	"karavi-authorization/internal/role-service/roles"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = time.Minute
+)
+
+// ReconcilerOption allows for functional option arguments on the Reconciler.
+type ReconcilerOption func(*Reconciler)
+
+// WithBackoff sets the delays between retries of a failed sync. The delay
+// starts at min and doubles with each failure, up to max.
+func WithBackoff(minDelay, maxDelay time.Duration) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.minBackoff = minDelay
+		r.maxBackoff = maxDelay
+	}
+}
+
+// WithReconcilerLogger provides a logger.
+func WithReconcilerLogger(log *logrus.Entry) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.log = log
+	}
+}
+
+// SyncStatus is the state of syncing the desired roles to OPA.
+type SyncStatus struct {
+	DesiredGeneration int64
+	AppliedGeneration int64
+	LastSync          time.Time
+	LastError         error
+	FailedAttempts    int
+	Lag               time.Duration
+}
+
+// InSync returns true if the desired roles have been applied.
+func (s SyncStatus) InSync() bool {
+	return s.AppliedGeneration == s.DesiredGeneration
+}
+
+// Reconciler syncs the desired roles to OPA, by way of the roles ConfigMap
+// that OPA loads, in the background. It implements Kube so that the role
+// service records the desired roles with it instead of writing them
+// directly; roles that failed to sync are retried with backoff, and are
+// served to readers in the meantime so that they see their own changes.
+type Reconciler struct {
+	kube       Kube
+	log        *logrus.Entry
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	now        func() time.Time
+	notify     chan struct{}
+
+	// modifyMu serializes ModifyRoles so that concurrent modifications of
+	// the desired roles are not lost.
+	modifyMu sync.Mutex
+
+	mu           sync.Mutex // guards the fields below
+	desired      []byte
+	desiredGen   int64
+	appliedGen   int64
+	pendingSince time.Time
+	lastSync     time.Time
+	lastErr      error
+	attempts     int
+}
+
+// NewReconciler returns a new Reconciler that syncs roles with kube.
+func NewReconciler(kube Kube, opts ...ReconcilerOption) *Reconciler {
+	r := &Reconciler{
+		kube:       kube,
+		log:        logrus.NewEntry(logrus.New()),
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+		now:        time.Now,
+		notify:     make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// GetConfiguredRoles returns the desired roles if they have not been
+// applied yet, otherwise the configured roles.
+func (r *Reconciler) GetConfiguredRoles(ctx context.Context) (*roles.JSON, error) {
+	r.mu.Lock()
+	desired := r.desired
+	pending := r.desiredGen != r.appliedGen
+	r.mu.Unlock()
+
+	if !pending {
+		return r.kube.GetConfiguredRoles(ctx)
+	}
+
+	ret := roles.NewJSON()
+	if err := ret.UnmarshalJSON(desired); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// UpdateRoles records the roles as the desired roles and returns without
+// waiting for them to be applied.
+func (r *Reconciler) UpdateRoles(_ context.Context, rs *roles.JSON) error {
+	// Keep a copy, since the caller owns rs.
+	b, err := rs.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.desired = b
+	r.desiredGen++
+	if r.pendingSince.IsZero() {
+		r.pendingSince = r.now()
+	}
+	r.mu.Unlock()
+
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// ModifyRoles applies fn to the current roles, as returned by
+// GetConfiguredRoles, and records the result as the desired roles.
+func (r *Reconciler) ModifyRoles(ctx context.Context, fn func(*roles.JSON) error) error {
+	r.modifyMu.Lock()
+	defer r.modifyMu.Unlock()
+
+	rs, err := r.GetConfiguredRoles(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(rs); err != nil {
+		return err
+	}
+	return r.UpdateRoles(ctx, rs)
+}
+
+// Run syncs the desired roles until the context is done.
+func (r *Reconciler) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.notify:
+		case <-timer.C:
+		}
+
+		retry, err := r.Sync(ctx)
+		if err != nil {
+			r.log.WithError(err).WithField("retry", retry).Warn("Syncing roles to OPA")
+		}
+		if retry > 0 {
+			timer.Stop()
+			select {
+			case <-timer.C:
+			default:
+			}
+			timer.Reset(retry)
+		}
+	}
+}
+
+// Sync applies the desired roles, if they have not been applied yet. On
+// failure, it returns the delay before the sync should be retried.
+func (r *Reconciler) Sync(ctx context.Context) (time.Duration, error) {
+	r.mu.Lock()
+	desired := r.desired
+	gen := r.desiredGen
+	if gen == r.appliedGen {
+		r.mu.Unlock()
+		return 0, nil
+	}
+	started := r.now()
+	r.mu.Unlock()
+
+	rs := roles.NewJSON()
+	err := rs.UnmarshalJSON(desired)
+	if err == nil {
+		err = r.kube.UpdateRoles(ctx, &rs)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.lastErr = err
+		r.attempts++
+		return r.backoff(), err
+	}
+
+	r.appliedGen = gen
+	r.lastSync = r.now()
+	r.lastErr = nil
+	r.attempts = 0
+	if r.appliedGen == r.desiredGen {
+		r.pendingSince = time.Time{}
+	} else {
+		// The roles changed during the sync; those changes are no older
+		// than the start of it.
+		r.pendingSince = started
+	}
+	return 0, nil
+}
+
+// backoff returns the delay before the next retry, doubling with each
+// failed attempt.
+func (r *Reconciler) backoff() time.Duration {
+	d := r.minBackoff
+	for i := 1; i < r.attempts && d < r.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > r.maxBackoff {
+		d = r.maxBackoff
+	}
+	return d
+}
+
+// Status returns the state of syncing the desired roles.
+func (r *Reconciler) Status() SyncStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var lag time.Duration
+	if !r.pendingSince.IsZero() {
+		lag = r.now().Sub(r.pendingSince)
+	}
+	return SyncStatus{
+		DesiredGeneration: r.desiredGen,
+		AppliedGeneration: r.appliedGen,
+		LastSync:          r.lastSync,
+		LastError:         r.lastErr,
+		FailedAttempts:    r.attempts,
+		Lag:               lag,
+	}
+}
+
+// LagCollector returns a metric of how long the desired roles have been
+// waiting to be synced to OPA, for registering with Prometheus.
+func (r *Reconciler) LagCollector() prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "karavi",
+		Subsystem: "role_service",
+		Name:      "opa_sync_lag_seconds",
+		Help:      "Seconds the oldest role change has been waiting to be synced to OPA.",
+	}, func() float64 {
+		return r.Status().Lag.Seconds()
+	})
+}
@@ -0,0 +1,229 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package role_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/role-service"
+	"karavi-authorization/internal/role-service/roles"
+	"karavi-authorization/pb"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReconciler(t *testing.T) {
+	newRoles := func(t *testing.T) *roles.JSON {
+		t.Helper()
+		ins, err := roles.NewInstance("test", "powerflex", "542a2d5f5122210f", "bronze", "9GB")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs := roles.NewJSON()
+		if err := rs.Add(ins); err != nil {
+			t.Fatal(err)
+		}
+		return &rs
+	}
+
+	t.Run("it serves the desired roles until they are applied", func(t *testing.T) {
+		var applied *roles.JSON
+		kube := fakeKube{
+			UpdateRolesRn: func(_ context.Context, rs *roles.JSON) error {
+				applied = rs
+				return nil
+			},
+		}
+		sut := role.NewReconciler(kube)
+
+		if err := sut.UpdateRoles(context.Background(), newRoles(t)); err != nil {
+			t.Fatal(err)
+		}
+		if applied != nil {
+			t.Fatal("expected the roles not to be applied yet")
+		}
+		got, err := sut.GetConfiguredRoles(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Instances()) != 1 {
+			t.Errorf("got %d instances, want 1", len(got.Instances()))
+		}
+		if st := sut.Status(); st.InSync() || st.DesiredGeneration != 1 {
+			t.Errorf("got status %+v, want desired generation 1 out of sync", st)
+		}
+
+		if _, err := sut.Sync(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if applied == nil || len(applied.Instances()) != 1 {
+			t.Errorf("got applied roles %v, want 1 instance", applied)
+		}
+		st := sut.Status()
+		if !st.InSync() || st.Lag != 0 || st.LastSync.IsZero() {
+			t.Errorf("got status %+v, want in sync", st)
+		}
+		got, err = sut.GetConfiguredRoles(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Instances()) != 0 {
+			t.Errorf("got %d instances, want the configured roles", len(got.Instances()))
+		}
+	})
+
+	t.Run("it backs off failed syncs", func(t *testing.T) {
+		kube := fakeKube{
+			UpdateRolesRn: func(_ context.Context, _ *roles.JSON) error {
+				return errors.New("test error")
+			},
+		}
+		sut := role.NewReconciler(kube, role.WithBackoff(time.Second, 3*time.Second))
+		if err := sut.UpdateRoles(context.Background(), newRoles(t)); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+			got, err := sut.Sync(context.Background())
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got != want {
+				t.Errorf("got retry %v, want %v", got, want)
+			}
+		}
+		st := sut.Status()
+		if st.InSync() || st.FailedAttempts != 4 || st.LastError == nil {
+			t.Errorf("got status %+v, want 4 failed attempts", st)
+		}
+	})
+
+	t.Run("it retries until the roles are applied", func(t *testing.T) {
+		var calls int32
+		kube := fakeKube{
+			UpdateRolesRn: func(_ context.Context, _ *roles.JSON) error {
+				if atomic.AddInt32(&calls, 1) < 3 {
+					return errors.New("test error")
+				}
+				return nil
+			},
+		}
+		sut := role.NewReconciler(kube, role.WithBackoff(time.Millisecond, 5*time.Millisecond))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go sut.Run(ctx)
+
+		if err := sut.UpdateRoles(context.Background(), newRoles(t)); err != nil {
+			t.Fatal(err)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for !sut.Status().InSync() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for sync, status %+v", sut.Status())
+			}
+			time.Sleep(time.Millisecond)
+		}
+		if got := atomic.LoadInt32(&calls); got != 3 {
+			t.Errorf("got %d calls, want 3", got)
+		}
+	})
+
+	t.Run("it does not lose concurrent modifications", func(t *testing.T) {
+		kube := fakeKube{
+			GetConfiguredRolesFn: func(_ context.Context) (*roles.JSON, error) {
+				rs := roles.NewJSON()
+				return &rs, nil
+			},
+		}
+		sut := role.NewReconciler(kube)
+
+		const n = 10
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				err := sut.ModifyRoles(context.Background(), func(rs *roles.JSON) error {
+					ins, err := roles.NewInstance(fmt.Sprintf("role-%d", i), "powerflex", "542a2d5f5122210f", "bronze", "9GB")
+					if err != nil {
+						return err
+					}
+					return rs.Add(ins)
+				})
+				if err != nil {
+					t.Error(err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		got, err := sut.GetConfiguredRoles(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l := len(got.Instances()); l != n {
+			t.Errorf("got %d roles, want %d", l, n)
+		}
+	})
+}
+
+func TestServiceStatus(t *testing.T) {
+	t.Run("it reports the reconciler status", func(t *testing.T) {
+		kube := fakeKube{
+			UpdateRolesRn: func(_ context.Context, _ *roles.JSON) error {
+				return errors.New("test error")
+			},
+		}
+		reconciler := role.NewReconciler(kube)
+		rs := roles.NewJSON()
+		if err := reconciler.UpdateRoles(context.Background(), &rs); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := reconciler.Sync(context.Background()); err == nil {
+			t.Fatal("expected an error")
+		}
+		svc := role.NewService(reconciler, successfulValidator{})
+
+		got, err := svc.Status(context.Background(), &pb.RoleStatusRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.InSync || got.DesiredGeneration != 1 || got.AppliedGeneration != 0 {
+			t.Errorf("got %+v, want generation 1 out of sync", got)
+		}
+		if got.LastError != "test error" || got.FailedAttempts != 1 {
+			t.Errorf("got %+v, want 1 failed attempt with the error", got)
+		}
+	})
+
+	t.Run("it is in sync without a reconciler", func(t *testing.T) {
+		svc := role.NewService(fakeKube{}, successfulValidator{})
+
+		got, err := svc.Status(context.Background(), &pb.RoleStatusRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !got.InSync {
+			t.Errorf("got %+v, want in sync", got)
+		}
+	})
+}
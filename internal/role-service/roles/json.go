@@ -17,15 +17,22 @@ package roles
 import (
 	"encoding/json"
 
-	"github.com/dustin/go-humanize"
 	"github.com/valyala/fastjson"
 )
 
 // ReadableInstance embeds a RoleKey and adds additional data, e.g. the
 // quota.
 type ReadableInstance struct {
-	Role  RoleKey
-	Quota string
+	Role      RoleKey
+	Quota     string
+	SoftQuota string
+	// ProtectionDomainID is set for pools naming a protection domain.
+	ProtectionDomainID string
+	// ServiceLevels are set for powermax pools pinned to service levels.
+	ServiceLevels []string
+	// MinSize and MaxSize are set for pools that bound the volume size.
+	MinSize string
+	MaxSize string
 }
 
 // ReadableJSON is the outer wrapper for performing JSON operations
@@ -46,8 +53,18 @@ func TransformReadable(rolesmap *JSON) *ReadableJSON {
 		ins := &ReadableInstance{
 			Role: k,
 		}
-		// quota is stored as kilobytes, so convert back to bytes before returning
-		ins.Quota = humanize.Bytes(uint64(v.Quota) * 1000)
+		ins.Quota = FormatQuota(v.Quota)
+		if v.SoftQuota > 0 {
+			ins.SoftQuota = FormatQuota(v.SoftQuota)
+		}
+		ins.ProtectionDomainID = v.ProtectionDomainID
+		ins.ServiceLevels = v.ServiceLevels
+		if v.MinSize > 0 {
+			ins.MinSize = FormatQuota(v.MinSize)
+		}
+		if v.MaxSize > 0 {
+			ins.MaxSize = FormatQuota(v.MaxSize)
+		}
 		ins.Role = v.RoleKey
 		readableroles.m[k] = ins
 	}
@@ -92,6 +109,25 @@ func (j *ReadableJSON) MarshalJSON() ([]byte, error) {
 		}
 		// pool quotas
 		p[k.Pool] = v.Quota
+		// pool soft quotas, only for the pools that have one
+		if v.SoftQuota != "" {
+			initMap(sid[k.SystemID], "pool_soft_quotas")[k.Pool] = v.SoftQuota
+		}
+		// protection domain ids, only for the pools naming one
+		if v.ProtectionDomainID != "" {
+			initMap(sid[k.SystemID], "protection_domains")[k.Pool] = v.ProtectionDomainID
+		}
+		// service levels, only for the pools pinned to some
+		if len(v.ServiceLevels) > 0 {
+			initMap(sid[k.SystemID], "pool_service_levels")[k.Pool] = v.ServiceLevels
+		}
+		// volume size bounds, only for the pools that have them
+		if v.MinSize != "" {
+			initMap(sid[k.SystemID], "pool_min_sizes")[k.Pool] = v.MinSize
+		}
+		if v.MaxSize != "" {
+			initMap(sid[k.SystemID], "pool_max_sizes")[k.Pool] = v.MaxSize
+		}
 	}
 
 	return json.Marshal(&m)
@@ -137,6 +173,63 @@ func (j *ReadableJSON) UnmarshalJSON(b []byte) error {
 					}
 					j.m[r.Role] = &r
 				})
+				v3.GetObject("pool_soft_quotas").Visit(func(k4 []byte, v4 *fastjson.Value) {
+					k := RoleKey{
+						Name:       string(k1),
+						SystemType: string(k2),
+						SystemID:   string(k3),
+						Pool:       string(k4),
+					}
+					if r, ok := j.m[k]; ok {
+						r.SoftQuota = v4.String()
+					}
+				})
+				v3.GetObject("protection_domains").Visit(func(k4 []byte, v4 *fastjson.Value) {
+					k := RoleKey{
+						Name:       string(k1),
+						SystemType: string(k2),
+						SystemID:   string(k3),
+						Pool:       string(k4),
+					}
+					if r, ok := j.m[k]; ok {
+						r.ProtectionDomainID = string(v4.GetStringBytes())
+					}
+				})
+				v3.GetObject("pool_service_levels").Visit(func(k4 []byte, v4 *fastjson.Value) {
+					k := RoleKey{
+						Name:       string(k1),
+						SystemType: string(k2),
+						SystemID:   string(k3),
+						Pool:       string(k4),
+					}
+					if r, ok := j.m[k]; ok {
+						for _, sl := range v4.GetArray() {
+							r.ServiceLevels = append(r.ServiceLevels, string(sl.GetStringBytes()))
+						}
+					}
+				})
+				v3.GetObject("pool_min_sizes").Visit(func(k4 []byte, v4 *fastjson.Value) {
+					k := RoleKey{
+						Name:       string(k1),
+						SystemType: string(k2),
+						SystemID:   string(k3),
+						Pool:       string(k4),
+					}
+					if r, ok := j.m[k]; ok {
+						r.MinSize = v4.String()
+					}
+				})
+				v3.GetObject("pool_max_sizes").Visit(func(k4 []byte, v4 *fastjson.Value) {
+					k := RoleKey{
+						Name:       string(k1),
+						SystemType: string(k2),
+						SystemID:   string(k3),
+						Pool:       string(k4),
+					}
+					if r, ok := j.m[k]; ok {
+						r.MaxSize = v4.String()
+					}
+				})
 			})
 		})
 	})
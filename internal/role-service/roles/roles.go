@@ -18,6 +18,7 @@ package roles
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"sync"
@@ -37,11 +38,44 @@ type RoleKey struct {
 	Pool       string
 }
 
+// UnlimitedQuota is the quota of a role whose storage pool capacity is not
+// capped. A quota of 0 denies all provisioning in the pool.
+const UnlimitedQuota int64 = -1
+
+// unlimitedKeyword may be given instead of -1 for an unlimited quota.
+const unlimitedKeyword = "unlimited"
+
+// ProtectionDomainPrefix prefixes the pool of a PowerFlex role to permit
+// any pool of the named protection domain, e.g. "pd:domain1". The quota
+// applies to each pool of the protection domain.
+const ProtectionDomainPrefix = "pd:"
+
+// ServiceLevelSeparator separates the service levels a PowerMax role is
+// pinned to, e.g. "Diamond+Gold".
+const ServiceLevelSeparator = "+"
+
 // Instance embeds a RoleKey and adds additional data, e.g. the
 // quota.
 type Instance struct {
 	RoleKey
-	Quota uint64
+	// Quota is in kilobytes, or UnlimitedQuota.
+	Quota int64
+	// SoftQuota is in kilobytes. Usage above it is allowed, but reported,
+	// for the grace period of the quota enforcement. There is no soft
+	// quota when it is 0.
+	SoftQuota int64
+	// ProtectionDomainID is the ID of the PowerFlex protection domain
+	// named by a pool with the ProtectionDomainPrefix. It is resolved
+	// when the role is validated.
+	ProtectionDomainID string
+	// ServiceLevels are the PowerMax service levels, e.g. Diamond, that
+	// volumes of the pool may be created with. Any service level of the
+	// pool is permitted when there are none.
+	ServiceLevels []string
+	// MinSize and MaxSize bound the size, in kilobytes, of each volume
+	// created in the pool. There is no bound when they are 0.
+	MinSize int64
+	MaxSize int64
 }
 
 // JSON is the outer wrapper for performing JSON operations
@@ -84,6 +118,10 @@ func NewJSON() JSON {
 // - parts[1]: system id
 // - parts[2]: pool name
 // - parts[3]: quota
+// - parts[4]: soft quota, optional
+// - parts[5]: powermax service levels separated by ServiceLevelSeparator, optional
+// - parts[6]: minimum volume size, optional
+// - parts[7]: maximum volume size, optional
 func NewInstance(role string, parts ...string) (*Instance, error) {
 	ins := &Instance{}
 	ins.Name = role
@@ -96,21 +134,112 @@ func NewInstance(role string, parts ...string) (*Instance, error) {
 		case 2: // pool name
 			ins.Pool = v
 		case 3: // quota
-			// if quota can be converted to an integer, set units to kilobytes
-			if _, err := strconv.Atoi(v); err == nil {
-				v = fmt.Sprintf("%s KB", v)
+			n, err := ParseQuota(v)
+			if err != nil {
+				return nil, err
+			}
+			ins.Quota = n
+		case 4: // soft quota
+			if strings.TrimSpace(v) == "" {
+				continue
+			}
+			n, err := ParseQuota(v)
+			if err != nil {
+				return nil, err
+			}
+			if n == UnlimitedQuota {
+				return nil, fmt.Errorf("invalid soft quota %q: must not be unlimited", v)
+			}
+			ins.SoftQuota = n
+		case 5: // service levels
+			for _, sl := range strings.Split(v, ServiceLevelSeparator) {
+				if sl = strings.TrimSpace(sl); sl != "" {
+					ins.ServiceLevels = append(ins.ServiceLevels, sl)
+				}
+			}
+			if len(ins.ServiceLevels) > 0 && ins.SystemType != "powermax" {
+				return nil, fmt.Errorf("invalid service levels %q: only powermax roles may be pinned to service levels", v)
+			}
+		case 6: // min size
+			n, err := parseVolumeSize("min size", v)
+			if err != nil {
+				return nil, err
 			}
-			n, err := humanize.ParseBytes(v)
+			ins.MinSize = n
+		case 7: // max size
+			n, err := parseVolumeSize("max size", v)
 			if err != nil {
 				return nil, err
 			}
-			// store quota in kilobytes
-			ins.Quota = n / 1000
+			ins.MaxSize = n
 		}
 	}
+	if ins.SoftQuota > 0 && ins.Quota != UnlimitedQuota && ins.SoftQuota >= ins.Quota {
+		return nil, fmt.Errorf("invalid soft quota %s: must be less than the quota %s", FormatQuota(ins.SoftQuota), FormatQuota(ins.Quota))
+	}
+	if ins.MinSize > 0 && ins.MaxSize > 0 && ins.MinSize > ins.MaxSize {
+		return nil, fmt.Errorf("invalid min size %s: must not be more than the max size %s", FormatQuota(ins.MinSize), FormatQuota(ins.MaxSize))
+	}
+	if ins.MinSize > 0 && ins.Quota != UnlimitedQuota && ins.MinSize > ins.Quota {
+		return nil, fmt.Errorf("invalid min size %s: must not be more than the quota %s", FormatQuota(ins.MinSize), FormatQuota(ins.Quota))
+	}
 	return ins, nil
 }
 
+// parseVolumeSize returns the volume size bound of a role in kilobytes, or
+// 0 if v is empty. The bound may have units like a quota, but may not be
+// unlimited.
+func parseVolumeSize(name, v string) (int64, error) {
+	if strings.TrimSpace(v) == "" {
+		return 0, nil
+	}
+	n, err := ParseQuota(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if n == UnlimitedQuota {
+		return 0, fmt.Errorf("invalid %s %q: must not be unlimited", name, v)
+	}
+	return n, nil
+}
+
+// ParseQuota returns the quota in kilobytes, the unit quotas are stored
+// in. The quota may have decimal or binary units, e.g. 100GB or 1TiB; a
+// quota without units is already in kilobytes. Either -1 or "unlimited"
+// is UnlimitedQuota.
+func ParseQuota(v string) (int64, error) {
+	v = strings.TrimSpace(v)
+	if strings.EqualFold(v, unlimitedKeyword) {
+		return UnlimitedQuota, nil
+	}
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		switch {
+		case n == UnlimitedQuota:
+			return UnlimitedQuota, nil
+		case n < 0:
+			return 0, fmt.Errorf("invalid quota %q: must not be negative, except -1 for unlimited", v)
+		}
+		v = fmt.Sprintf("%s KB", v)
+	}
+	n, err := humanize.ParseBytes(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quota %q: %w", v, err)
+	}
+	if n/1000 > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid quota %q: too large", v)
+	}
+	return int64(n / 1000), nil
+}
+
+// FormatQuota returns a quota in kilobytes with units, e.g. "10 GB", or
+// "unlimited".
+func FormatQuota(kb int64) string {
+	if kb < 0 {
+		return unlimitedKeyword
+	}
+	return humanize.Bytes(uint64(kb) * 1000)
+}
+
 // Get returns an *Instance associated with the given key.
 func (j *JSON) Get(k RoleKey) *Instance {
 	j.mu.Lock()
@@ -216,6 +345,25 @@ func (j *JSON) MarshalJSON() ([]byte, error) {
 		}
 		// pool quotas
 		p[k.Pool] = v.Quota
+		// pool soft quotas, only for the pools that have one
+		if v.SoftQuota > 0 {
+			initMap(sid[k.SystemID], "pool_soft_quotas")[k.Pool] = v.SoftQuota
+		}
+		// protection domain ids, only for the pools naming one
+		if v.ProtectionDomainID != "" {
+			initMap(sid[k.SystemID], "protection_domains")[k.Pool] = v.ProtectionDomainID
+		}
+		// service levels, only for the pools pinned to some
+		if len(v.ServiceLevels) > 0 {
+			initMap(sid[k.SystemID], "pool_service_levels")[k.Pool] = v.ServiceLevels
+		}
+		// volume size bounds, only for the pools that have them
+		if v.MinSize > 0 {
+			initMap(sid[k.SystemID], "pool_min_sizes")[k.Pool] = v.MinSize
+		}
+		if v.MaxSize > 0 {
+			initMap(sid[k.SystemID], "pool_max_sizes")[k.Pool] = v.MaxSize
+		}
 	}
 
 	return json.Marshal(&m)
@@ -248,7 +396,7 @@ func (j *JSON) UnmarshalJSON(b []byte) error {
 			v2.GetObject("system_ids").Visit(func(k3 []byte, v3 *fastjson.Value) {
 				// k3 = system id
 				v3.GetObject("pool_quotas").Visit(func(k4 []byte, v4 *fastjson.Value) {
-					n, err := v4.Uint64()
+					n, err := v4.Int64()
 					if err != nil {
 						return
 					}
@@ -264,6 +412,67 @@ func (j *JSON) UnmarshalJSON(b []byte) error {
 					}
 					j.M[r.RoleKey] = &r
 				})
+				v3.GetObject("pool_soft_quotas").Visit(func(k4 []byte, v4 *fastjson.Value) {
+					n, err := v4.Int64()
+					if err != nil {
+						return
+					}
+					k := RoleKey{
+						Name:       string(k1),
+						SystemType: string(k2),
+						SystemID:   string(k3),
+						Pool:       string(k4),
+					}
+					if r, ok := j.M[k]; ok {
+						r.SoftQuota = n
+					}
+				})
+				v3.GetObject("protection_domains").Visit(func(k4 []byte, v4 *fastjson.Value) {
+					k := RoleKey{
+						Name:       string(k1),
+						SystemType: string(k2),
+						SystemID:   string(k3),
+						Pool:       string(k4),
+					}
+					if r, ok := j.M[k]; ok {
+						r.ProtectionDomainID = string(v4.GetStringBytes())
+					}
+				})
+				v3.GetObject("pool_service_levels").Visit(func(k4 []byte, v4 *fastjson.Value) {
+					k := RoleKey{
+						Name:       string(k1),
+						SystemType: string(k2),
+						SystemID:   string(k3),
+						Pool:       string(k4),
+					}
+					if r, ok := j.M[k]; ok {
+						for _, sl := range v4.GetArray() {
+							r.ServiceLevels = append(r.ServiceLevels, string(sl.GetStringBytes()))
+						}
+					}
+				})
+				v3.GetObject("pool_min_sizes").Visit(func(k4 []byte, v4 *fastjson.Value) {
+					k := RoleKey{
+						Name:       string(k1),
+						SystemType: string(k2),
+						SystemID:   string(k3),
+						Pool:       string(k4),
+					}
+					if r, ok := j.M[k]; ok {
+						r.MinSize = v4.GetInt64()
+					}
+				})
+				v3.GetObject("pool_max_sizes").Visit(func(k4 []byte, v4 *fastjson.Value) {
+					k := RoleKey{
+						Name:       string(k1),
+						SystemType: string(k2),
+						SystemID:   string(k3),
+						Pool:       string(k4),
+					}
+					if r, ok := j.M[k]; ok {
+						r.MaxSize = v4.GetInt64()
+					}
+				})
 			})
 		})
 	})
@@ -17,6 +17,7 @@ package roles_test
 import (
 	"encoding/json"
 	"karavi-authorization/internal/role-service/roles"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -108,15 +109,154 @@ func TestJSON_Unmarshal(t *testing.T) {
 	}
 }
 
+func TestJSON_UnmarshalUnlimitedQuota(t *testing.T) {
+	var sut roles.JSON
+	err := sut.UnmarshalJSON([]byte(`{"role":{"system_types":{"powerflex":{"system_ids":{"542a2d5f5122210f":{"pool_quotas":{"bronze":-1}}}}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := sut.Instances()
+
+	if len(got) != 1 || got[0].Quota != roles.UnlimitedQuota {
+		t.Errorf("got %v, want one instance with an unlimited quota", got)
+	}
+}
+
+func TestJSON_SoftQuota(t *testing.T) {
+	ins, err := roles.NewInstance("role", "powerflex", "542a2d5f5122210f", "bronze", "100 GB", "80 GB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := roles.NewInstance("role", "powerflex", "542a2d5f5122210f", "silver", "100 GB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sut := roles.NewJSON()
+	for _, v := range []*roles.Instance{ins, other} {
+		if err := sut.Add(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := json.Marshal(&sut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"role":{"system_types":{"powerflex":{"system_ids":{"542a2d5f5122210f":{"pool_quotas":{"bronze":100000000,"silver":100000000},"pool_soft_quotas":{"bronze":80000000}}}}}}}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	var got roles.JSON
+	if err := got.UnmarshalJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	if v := got.Get(ins.RoleKey); v == nil || v.SoftQuota != 80000000 {
+		t.Errorf("got %+v, want a soft quota of 80000000", v)
+	}
+	if v := got.Get(other.RoleKey); v == nil || v.SoftQuota != 0 {
+		t.Errorf("got %+v, want no soft quota", v)
+	}
+}
+
+func TestJSON_ProtectionDomain(t *testing.T) {
+	ins, err := roles.NewInstance("role", "powerflex", "542a2d5f5122210f", roles.ProtectionDomainPrefix+"domain1", "100 GB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ins.ProtectionDomainID = "75b661b400000000"
+	sut := roles.NewJSON()
+	if err := sut.Add(ins); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(&sut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"role":{"system_types":{"powerflex":{"system_ids":{"542a2d5f5122210f":{"pool_quotas":{"pd:domain1":100000000},"protection_domains":{"pd:domain1":"75b661b400000000"}}}}}}}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	var got roles.JSON
+	if err := got.UnmarshalJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	if v := got.Get(ins.RoleKey); v == nil || v.ProtectionDomainID != "75b661b400000000" {
+		t.Errorf("got %+v, want protection domain ID 75b661b400000000", v)
+	}
+}
+
+func TestJSON_ServiceLevels(t *testing.T) {
+	sut := roles.NewJSON()
+	ins, err := roles.NewInstance("role", "powermax", "000197900714", "SRP_1", "100 GB", "", "Diamond+Gold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sut.Add(ins); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(&sut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"role":{"system_types":{"powermax":{"system_ids":{"000197900714":{"pool_quotas":{"SRP_1":100000000},"pool_service_levels":{"SRP_1":["Diamond","Gold"]}}}}}}}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	var got roles.JSON
+	if err := got.UnmarshalJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	if v := got.Get(ins.RoleKey); v == nil || !reflect.DeepEqual(v.ServiceLevels, []string{"Diamond", "Gold"}) {
+		t.Errorf("got %+v, want service levels Diamond and Gold", v)
+	}
+}
+
+func TestJSON_VolumeSizes(t *testing.T) {
+	sut := roles.NewJSON()
+	ins, err := roles.NewInstance("role", "powerflex", "542a2d5f5122210f", "bronze", "100 GB", "", "", "1 GB", "10 GB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sut.Add(ins); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(&sut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"role":{"system_types":{"powerflex":{"system_ids":{"542a2d5f5122210f":{"pool_max_sizes":{"bronze":10000000},"pool_min_sizes":{"bronze":1000000},"pool_quotas":{"bronze":100000000}}}}}}}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	var got roles.JSON
+	if err := got.UnmarshalJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	if v := got.Get(ins.RoleKey); v == nil || v.MinSize != 1000000 || v.MaxSize != 10000000 {
+		t.Errorf("got %+v, want a min size of 1000000 and a max size of 10000000", v)
+	}
+}
+
 func TestNewInstance(t *testing.T) {
 	t.Run("validation", func(t *testing.T) {
 		tests := []struct {
 			name          string
 			args          []string
-			expectedQuota uint64
+			expectedQuota int64
 		}{
 			{"numeric quota", []string{"powerflex", "542", "bronze", "100"}, 100},
 			{"string quota", []string{"powerflex", "542", "bronze", "50 GB"}, 50000000},
+			{"binary quota", []string{"powerflex", "542", "bronze", "1TiB"}, 1099511627},
+			{"zero quota", []string{"powerflex", "542", "bronze", "0"}, 0},
+			{"unlimited quota", []string{"powerflex", "542", "bronze", "unlimited"}, roles.UnlimitedQuota},
 		}
 		for _, tt := range tests {
 			tt := tt
@@ -132,17 +272,124 @@ func TestNewInstance(t *testing.T) {
 						SystemID:   tt.args[1],
 						Pool:       tt.args[2],
 					},
-					Quota: uint64(tt.expectedQuota),
+					Quota: tt.expectedQuota,
 				}
 				if got.Quota != want.Quota {
 					t.Errorf("quotas: got %+v, want %+v", got.Quota, want.Quota)
 				}
-				if *got != *want {
+				if !reflect.DeepEqual(got, want) {
 					t.Errorf("got %+v, want %+v", got, want)
 				}
 			})
 		}
 	})
+	t.Run("soft quota", func(t *testing.T) {
+		got, err := roles.NewInstance("test", "powerflex", "542", "bronze", "100 GB", "80 GB")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.SoftQuota != 80000000 {
+			t.Errorf("got soft quota %d, want %d", got.SoftQuota, 80000000)
+		}
+	})
+
+	t.Run("service levels", func(t *testing.T) {
+		got, err := roles.NewInstance("test", "powermax", "000197900714", "SRP_1", "100 GB", "", "Diamond + Gold")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"Diamond", "Gold"}; !reflect.DeepEqual(got.ServiceLevels, want) {
+			t.Errorf("got service levels %v, want %v", got.ServiceLevels, want)
+		}
+	})
+
+	t.Run("service levels of other storage", func(t *testing.T) {
+		_, err := roles.NewInstance("test", "powerflex", "542", "bronze", "100 GB", "", "Diamond")
+		if err == nil {
+			t.Error("expected non-nil error")
+		}
+	})
+
+	t.Run("volume sizes", func(t *testing.T) {
+		got, err := roles.NewInstance("test", "powerflex", "542", "bronze", "unlimited", "", "", "1 GiB", "1 TiB")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.MinSize != 1073741 || got.MaxSize != 1099511627 {
+			t.Errorf("got min size %d and max size %d, want %d and %d", got.MinSize, got.MaxSize, 1073741, 1099511627)
+		}
+	})
+
+	t.Run("invalid volume sizes", func(t *testing.T) {
+		for _, sizes := range [][]string{
+			{"10 GB", "1 GB"},
+			{"200 GB", ""},
+			{"unlimited", ""},
+			{"", "-1"},
+			{"lots", ""},
+		} {
+			if _, err := roles.NewInstance("test", "powerflex", "542", "bronze", "100 GB", "", "", sizes[0], sizes[1]); err == nil {
+				t.Errorf("sizes %q: expected an error", sizes)
+			}
+		}
+	})
+
+	t.Run("invalid soft quota", func(t *testing.T) {
+		for _, soft := range []string{"100 GB", "200 GB", "unlimited", "-5"} {
+			if _, err := roles.NewInstance("test", "powerflex", "542", "bronze", "100 GB", soft); err == nil {
+				t.Errorf("soft quota %q: expected an error", soft)
+			}
+		}
+	})
+}
+
+func TestParseQuota(t *testing.T) {
+	tests := map[string]struct {
+		quota   string
+		want    int64
+		wantErr bool
+	}{
+		"kilobytes":        {"100", 100, false},
+		"decimal units":    {"10GB", 10000000, false},
+		"binary units":     {"2 GiB", 2147483, false},
+		"lowercase units":  {"1tb", 1000000000, false},
+		"zero":             {"0", 0, false},
+		"minus one":        {"-1", roles.UnlimitedQuota, false},
+		"unlimited":        {"Unlimited", roles.UnlimitedQuota, false},
+		"negative":         {"-100", 0, true},
+		"unknown units":    {"10 XB", 0, true},
+		"not a size":       {"lots", 0, true},
+		"surrounded space": {" 100 ", 100, false},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := roles.ParseQuota(tc.quota)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got err %v, want err %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("got %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatQuota(t *testing.T) {
+	tests := map[string]struct {
+		quota int64
+		want  string
+	}{
+		"kilobytes": {10000000, "10 GB"},
+		"zero":      {0, "0 B"},
+		"unlimited": {roles.UnlimitedQuota, "unlimited"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := roles.FormatQuota(tc.quota); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
 }
 
 func TestJSON_Instances(t *testing.T) {
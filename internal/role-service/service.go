@@ -18,12 +18,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"karavi-authorization/internal/errcode"
+	"karavi-authorization/internal/k8s"
 	"karavi-authorization/internal/role-service/roles"
 	"karavi-authorization/pb"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
 )
 
 // Option allows for functional option arguments on the RoleService.
@@ -53,6 +59,44 @@ type Kube interface {
 	UpdateRoles(ctx context.Context, roles *roles.JSON) error
 }
 
+// RoleModifier is a Kube that modifies the roles without losing concurrent
+// updates, e.g. a Reconciler or k8s.API.
+type RoleModifier interface {
+	ModifyRoles(ctx context.Context, fn func(*roles.JSON) error) error
+}
+
+// modifyRoles applies fn to the configured roles and writes them. A
+// conflict with a concurrent update is returned as an Aborted error for the
+// caller to retry.
+func (s *Service) modifyRoles(ctx context.Context, fn func(*roles.JSON) error) error {
+	if m, ok := s.kube.(RoleModifier); ok {
+		err := m.ModifyRoles(ctx, fn)
+		if errors.Is(err, k8s.ErrConflict) {
+			return errcode.Newf(codes.Aborted, pb.ErrorCode_CONCURRENT_UPDATE, "%v; retry the request", err)
+		}
+		return err
+	}
+
+	existing, err := s.kube.GetConfiguredRoles(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(existing); err != nil {
+		return err
+	}
+	return s.kube.UpdateRoles(ctx, existing)
+}
+
+// invalidRole returns err as an error of an invalid role.
+func invalidRole(name string, err error) error {
+	return errcode.New(codes.InvalidArgument, pb.ErrorCode_ROLE_INVALID, err.Error()).WithParam("role", name)
+}
+
+// SyncStatuser reports the state of syncing roles to OPA, e.g. a Reconciler.
+type SyncStatuser interface {
+	Status() SyncStatus
+}
+
 // Service implements the RoleService protobuf definiton
 type Service struct {
 	kube      Kube
@@ -79,38 +123,35 @@ func NewService(kube Kube, validator Validator, opts ...Option) *Service {
 // Create creates a role
 func (s *Service) Create(ctx context.Context, req *pb.RoleCreateRequest) (*pb.RoleCreateResponse, error) {
 	s.log.WithFields(logrus.Fields{
-		"Name":        req.Name,
-		"StorageType": req.StorageType,
-		"SystemId":    req.SystemId,
-		"Pool":        req.Pool,
-		"Quota(kb)":   req.Quota,
+		"Name":          req.Name,
+		"StorageType":   req.StorageType,
+		"SystemId":      req.SystemId,
+		"Pool":          req.Pool,
+		"Quota(kb)":     req.Quota,
+		"SoftQuota":     req.SoftQuota,
+		"ServiceLevels": req.ServiceLevels,
+		"MinSize":       req.MinSize,
+		"MaxSize":       req.MaxSize,
 	}).Info("Serving create role request")
 
-	roleInstance, err := roles.NewInstance(req.Name, req.StorageType, req.SystemId, req.Pool, req.Quota)
+	roleInstance, err := roles.NewInstance(req.Name, req.StorageType, req.SystemId, req.Pool, req.Quota, req.SoftQuota, strings.Join(req.ServiceLevels, roles.ServiceLevelSeparator), req.MinSize, req.MaxSize)
 	if err != nil {
-		return nil, err
+		return nil, invalidRole(req.Name, err)
 	}
 
 	s.log.Debug("Validating role")
 	err = s.validator.Validate(ctx, roleInstance)
 	if err != nil {
-		err = fmt.Errorf("%s failed validation: %+v", roleInstance.Name, err)
-		return nil, err
+		return nil, invalidRole(roleInstance.Name, fmt.Errorf("%s failed validation: %+v", roleInstance.Name, err))
 	}
 
 	s.log.Debug("Updating roles in Kubernetes")
-	existingRoles, err := s.kube.GetConfiguredRoles(ctx)
-	if err != nil {
-		s.log.WithError(err).Debug()
-		return nil, err
-	}
-
-	err = existingRoles.Add(roleInstance)
-	if err != nil {
-		return nil, err
-	}
-
-	err = s.kube.UpdateRoles(ctx, existingRoles)
+	err = s.modifyRoles(ctx, func(existingRoles *roles.JSON) error {
+		if existingRoles.Get(roleInstance.RoleKey) != nil {
+			return errcode.Newf(codes.AlreadyExists, pb.ErrorCode_ROLE_ALREADY_EXISTS, "%q is duplicated", roleInstance.RoleKey).WithParam("role", roleInstance.Name)
+		}
+		return existingRoles.Add(roleInstance)
+	})
 	if err != nil {
 		s.log.WithError(err).Debug()
 		return nil, err
@@ -131,40 +172,32 @@ func (s *Service) Delete(ctx context.Context, req *pb.RoleDeleteRequest) (*pb.Ro
 
 	roleInstance, err := roles.NewInstance(req.Name, req.StorageType, req.SystemId, req.Pool, req.Quota)
 	if err != nil {
-		return nil, err
-	}
-
-	s.log.Debug("Getting existing roles from Kubernetes")
-	existingRoles, err := s.kube.GetConfiguredRoles(ctx)
-	if err != nil {
-		s.log.WithError(err).Debug()
-		return nil, err
+		return nil, invalidRole(req.Name, err)
 	}
 
 	s.log.WithFields(logrus.Fields{
 		"Role": roleInstance.RoleKey.String(),
 	}).Debug("Deleting role")
 
-	matched := make(map[roles.Instance]struct{})
-	existingRoles.Select(func(e roles.Instance) {
-		if strings.Contains(e.RoleKey.String(), roleInstance.RoleKey.String()) {
-			matched[e] = struct{}{}
-		}
-	})
-
-	if len(matched) == 0 {
-		return nil, fmt.Errorf("role not found")
-	}
+	err = s.modifyRoles(ctx, func(existingRoles *roles.JSON) error {
+		matched := make(map[roles.RoleKey]struct{})
+		existingRoles.Select(func(e roles.Instance) {
+			if strings.Contains(e.RoleKey.String(), roleInstance.RoleKey.String()) {
+				matched[e.RoleKey] = struct{}{}
+			}
+		})
 
-	for k := range matched {
-		err = existingRoles.Remove(&k)
-		if err != nil {
-			return nil, err
+		if len(matched) == 0 {
+			return errcode.New(codes.NotFound, pb.ErrorCode_ROLE_NOT_FOUND, "role not found").WithParam("role", roleInstance.Name)
 		}
-	}
 
-	s.log.Debug("Updating roles in Kubernetes")
-	err = s.kube.UpdateRoles(ctx, existingRoles)
+		for k := range matched {
+			if err := existingRoles.Remove(&roles.Instance{RoleKey: k}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		s.log.WithError(err).Debug()
 		return nil, err
@@ -191,7 +224,7 @@ func (s *Service) List(ctx context.Context, _ *pb.RoleListRequest) (*pb.RoleList
 		return nil, err
 	}
 
-	return &pb.RoleListResponse{Roles: b}, nil
+	return &pb.RoleListResponse{Roles: b, Instances: roleInstances(existingRoles.Instances())}, nil
 }
 
 // Get gets a role
@@ -207,14 +240,14 @@ func (s *Service) Get(ctx context.Context, req *pb.RoleGetRequest) (*pb.RoleGetR
 		return nil, err
 	}
 
-	matches := []roles.Instance{}
+	matches := []*roles.Instance{}
 	existingRoles.Select(func(r roles.Instance) {
 		if r.Name == req.Name {
-			matches = append(matches, r)
+			matches = append(matches, &r)
 		}
 	})
 	if len(matches) == 0 {
-		return nil, fmt.Errorf("role %s does not exist", req.Name)
+		return nil, errcode.Newf(codes.NotFound, pb.ErrorCode_ROLE_NOT_FOUND, "role %s does not exist", req.Name).WithParam("role", req.Name)
 	}
 
 	s.log.Debug("Filtering roles for supplied name")
@@ -237,22 +270,47 @@ func (s *Service) Get(ctx context.Context, req *pb.RoleGetRequest) (*pb.RoleGetR
 		return nil, err
 	}
 
-	return &pb.RoleGetResponse{Role: b}, nil
+	return &pb.RoleGetResponse{Role: b, Instances: roleInstances(matches)}, nil
+}
+
+// roleInstances returns the role instances, sorted by their keys, with
+// their quotas in kilobytes and with units.
+func roleInstances(rs []*roles.Instance) []*pb.RoleInstance {
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].RoleKey.String() < rs[j].RoleKey.String()
+	})
+
+	ret := make([]*pb.RoleInstance, 0, len(rs))
+	for _, r := range rs {
+		ret = append(ret, &pb.RoleInstance{
+			Name:          r.Name,
+			StorageType:   r.SystemType,
+			SystemId:      r.SystemID,
+			Pool:          r.Pool,
+			QuotaKB:       r.Quota,
+			QuotaReadable: roles.FormatQuota(r.Quota),
+		})
+	}
+	return ret
 }
 
 // Update updates a role
 func (s *Service) Update(ctx context.Context, req *pb.RoleUpdateRequest) (*pb.RoleUpdateResponse, error) {
 	s.log.WithFields(logrus.Fields{
-		"Name":        req.Name,
-		"StorageType": req.StorageType,
-		"SystemId":    req.SystemId,
-		"Pool":        req.Pool,
-		"Quota(kb)":   req.Quota,
+		"Name":          req.Name,
+		"StorageType":   req.StorageType,
+		"SystemId":      req.SystemId,
+		"Pool":          req.Pool,
+		"Quota(kb)":     req.Quota,
+		"SoftQuota":     req.SoftQuota,
+		"ServiceLevels": req.ServiceLevels,
+		"MinSize":       req.MinSize,
+		"MaxSize":       req.MaxSize,
 	}).Info("Serving update role request")
 
-	roleInstance, err := roles.NewInstance(req.Name, req.StorageType, req.SystemId, req.Pool, req.Quota)
+	roleInstance, err := roles.NewInstance(req.Name, req.StorageType, req.SystemId, req.Pool, req.Quota, req.SoftQuota, strings.Join(req.ServiceLevels, roles.ServiceLevelSeparator), req.MinSize, req.MaxSize)
 	if err != nil {
-		return nil, err
+		return nil, invalidRole(req.Name, err)
 	}
 
 	s.log.Debug("Getting existing roles in Kubernetes")
@@ -263,32 +321,56 @@ func (s *Service) Update(ctx context.Context, req *pb.RoleUpdateRequest) (*pb.Ro
 	}
 
 	if existingRoles.Get(roleInstance.RoleKey) == nil {
-		return nil, fmt.Errorf("only role quota can be updated")
+		return nil, errcode.New(codes.NotFound, pb.ErrorCode_ROLE_NOT_FOUND, "only role quota can be updated").WithParam("role", roleInstance.Name)
 	}
 
 	s.log.Debug("Validating role")
 	err = s.validator.Validate(ctx, roleInstance)
 	if err != nil {
-		err = fmt.Errorf("%s failed validation: %+v", roleInstance.Name, err)
-		return nil, err
+		return nil, invalidRole(roleInstance.Name, fmt.Errorf("%s failed validation: %+v", roleInstance.Name, err))
 	}
 
 	s.log.Debug("Updating roles in Kubernetes")
-	err = existingRoles.Remove(roleInstance)
+	err = s.modifyRoles(ctx, func(existingRoles *roles.JSON) error {
+		if existingRoles.Get(roleInstance.RoleKey) == nil {
+			return errcode.New(codes.NotFound, pb.ErrorCode_ROLE_NOT_FOUND, "only role quota can be updated").WithParam("role", roleInstance.Name)
+		}
+		if err := existingRoles.Remove(roleInstance); err != nil {
+			return err
+		}
+		return existingRoles.Add(roleInstance)
+	})
 	if err != nil {
+		s.log.WithError(err).Debug()
 		return nil, err
 	}
 
-	err = existingRoles.Add(roleInstance)
-	if err != nil {
-		return nil, err
-	}
+	return &pb.RoleUpdateResponse{}, nil
+}
 
-	err = s.kube.UpdateRoles(ctx, existingRoles)
-	if err != nil {
-		s.log.WithError(err).Debug()
-		return nil, err
+// Status returns the state of syncing the roles to OPA. Roles written
+// directly to Kubernetes, without a Reconciler, are always in sync.
+func (s *Service) Status(_ context.Context, _ *pb.RoleStatusRequest) (*pb.RoleStatusResponse, error) {
+	s.log.Info("Serving role status request")
+
+	st, ok := s.kube.(SyncStatuser)
+	if !ok {
+		return &pb.RoleStatusResponse{InSync: true}, nil
 	}
 
-	return &pb.RoleUpdateResponse{}, nil
+	status := st.Status()
+	resp := &pb.RoleStatusResponse{
+		InSync:            status.InSync(),
+		DesiredGeneration: status.DesiredGeneration,
+		AppliedGeneration: status.AppliedGeneration,
+		FailedAttempts:    int32(status.FailedAttempts),
+		LagSeconds:        status.Lag.Round(time.Millisecond).Seconds(),
+	}
+	if !status.LastSync.IsZero() {
+		resp.LastSyncTime = status.LastSync.Unix()
+	}
+	if status.LastError != nil {
+		resp.LastError = status.LastError.Error()
+	}
+	return resp, nil
 }
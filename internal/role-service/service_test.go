@@ -217,6 +217,9 @@ func TestServiceList(t *testing.T) {
 			if want != string(got.Roles) {
 				t.Errorf("want %s, got %s", want, string(got.Roles))
 			}
+			if len(got.Instances) != 1 || got.Instances[0].QuotaKB != 9000000 || got.Instances[0].QuotaReadable != "9.0 GB" {
+				t.Errorf("want one instance with a quota of 9.0 GB, got %v", got.Instances)
+			}
 		}
 	}
 
@@ -294,6 +297,9 @@ func TestServiceGet(t *testing.T) {
 			if want != string(got.Role) {
 				t.Errorf("want %s, got %s", want, string(got.Role))
 			}
+			if len(got.Instances) != 1 || got.Instances[0].Name != "test" || got.Instances[0].QuotaReadable != "9.0 GB" {
+				t.Errorf("want the instance of role test with a quota of 9.0 GB, got %v", got.Instances)
+			}
 		}
 	}
 
@@ -412,6 +418,18 @@ func TestServiceUpdate(t *testing.T) {
 
 			return req, successfulValidator{}, fakeKube{GetConfiguredRolesFn: getRolesFn}, errIsNil
 		},
+		"fail update soft quota above quota": func(_ *testing.T) (*pb.RoleUpdateRequest, role.Validator, role.Kube, checkFn) {
+			req := &pb.RoleUpdateRequest{
+				Name:        "test",
+				StorageType: "powerflex",
+				SystemId:    "542a2d5f5122210f",
+				Pool:        "bronze",
+				Quota:       "20GB",
+				SoftQuota:   "30GB",
+			}
+
+			return req, successfulValidator{}, fakeKube{}, errIsNotNil
+		},
 		"fail update non-quota": func(t *testing.T) (*pb.RoleUpdateRequest, role.Validator, role.Kube, checkFn) {
 			req := &pb.RoleUpdateRequest{
 				Name:        "test",
@@ -21,6 +21,7 @@ import (
 	"net/url"
 
 	storage "karavi-authorization/cmd/karavictl/cmd"
+	"karavi-authorization/internal/role-service/roles"
 
 	"github.com/dell/goscaleio"
 	"github.com/sirupsen/logrus"
@@ -32,15 +33,77 @@ var GetPowerFlexEndpoint = func(system storage.System) string {
 }
 
 // PowerFlex validates powerflex role parameters
-func PowerFlex(_ context.Context, log *logrus.Entry, system storage.System, systemID string, pool string, quota uint64) error {
-	if quota < 0 {
-		return errors.New("the specified quota needs to be a positive number")
+func PowerFlex(_ context.Context, log *logrus.Entry, system storage.System, systemID string, pool string, quota int64) error {
+	if quota < roles.UnlimitedQuota {
+		return errors.New("the specified quota needs to be a positive number, 0 or unlimited")
 	}
 
+	powerFlexClient, err := newPowerFlexClient(log, system, systemID)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"SystemId":    systemID,
+		"StoragePool": pool,
+	}).Debug("Validating storage pool existence on PowerFlex")
+
+	storagePool, err := getPowerFlexStoragePool(powerFlexClient, systemID, pool)
+	if err != nil {
+		return err
+	}
+
+	// Ensuring that the storage pool exists
+	_, err = storagePool.GetStatistics()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PowerFlexProtectionDomain validates powerflex role parameters of a role
+// permitting any pool of a protection domain, returning the ID of the
+// protection domain
+func PowerFlexProtectionDomain(_ context.Context, log *logrus.Entry, system storage.System, systemID string, protectionDomain string, quota int64) (string, error) {
+	if quota < roles.UnlimitedQuota {
+		return "", errors.New("the specified quota needs to be a positive number, 0 or unlimited")
+	}
+
+	powerFlexClient, err := newPowerFlexClient(log, system, systemID)
+	if err != nil {
+		return "", err
+	}
+
+	log.WithFields(logrus.Fields{
+		"SystemId":         systemID,
+		"ProtectionDomain": protectionDomain,
+	}).Debug("Validating protection domain existence on PowerFlex")
+
+	systems, err := powerFlexClient.FindSystem(systemID, "", "")
+	if err != nil {
+		return "", fmt.Errorf("sytem ID %s was not found on powerflex: %+v", systemID, err)
+	}
+
+	protectionDomains, err := systems.GetProtectionDomain("")
+	if err != nil {
+		return "", fmt.Errorf("failed to get powerflex protection domains: %+v", err)
+	}
+
+	for _, pd := range protectionDomains {
+		if pd.Name == protectionDomain {
+			return pd.ID, nil
+		}
+	}
+
+	return "", fmt.Errorf("unable to find protection domain %s on powerflex %s", protectionDomain, systemID)
+}
+
+func newPowerFlexClient(log *logrus.Entry, system storage.System, systemID string) (*goscaleio.Client, error) {
 	endpoint := GetPowerFlexEndpoint(system)
 	epURL, err := url.Parse(endpoint)
 	if err != nil {
-		return fmt.Errorf("endpoint %s is invalid: %+v", epURL, err)
+		return nil, fmt.Errorf("endpoint %s is invalid: %+v", epURL, err)
 	}
 
 	log.WithFields(logrus.Fields{
@@ -51,7 +114,7 @@ func PowerFlex(_ context.Context, log *logrus.Entry, system storage.System, syst
 	epURL.Scheme = "https"
 	powerFlexClient, err := goscaleio.NewClientWithArgs(epURL.String(), "", 0, system.Insecure, false)
 	if err != nil {
-		return fmt.Errorf("failed to connect to powerflex %s: %+v", systemID, err)
+		return nil, fmt.Errorf("failed to connect to powerflex %s: %+v", systemID, err)
 	}
 
 	_, err = powerFlexClient.Authenticate(&goscaleio.ConfigConnect{
@@ -59,26 +122,10 @@ func PowerFlex(_ context.Context, log *logrus.Entry, system storage.System, syst
 		Password: system.Password,
 	})
 	if err != nil {
-		return fmt.Errorf("powerflex authentication failed: %+v", err)
+		return nil, fmt.Errorf("powerflex authentication failed: %+v", err)
 	}
 
-	log.WithFields(logrus.Fields{
-		"SystemId":    systemID,
-		"StoragePool": pool,
-	}).Debug("Validating storage pool existence on PowerFlex")
-
-	storagePool, err := getPowerFlexStoragePool(powerFlexClient, systemID, pool)
-	if err != nil {
-		return err
-	}
-
-	// Ensuring that the storage pool exists
-	_, err = storagePool.GetStatistics()
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return powerFlexClient, nil
 }
 
 func getPowerFlexStoragePool(powerFlexClient *goscaleio.Client, storageSystemID string, storagePoolName string) (*goscaleio.StoragePool, error) {
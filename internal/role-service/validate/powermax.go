@@ -19,7 +19,9 @@ import (
 	"errors"
 	"fmt"
 	storage "karavi-authorization/cmd/karavictl/cmd"
+	"karavi-authorization/internal/role-service/roles"
 	"net/url"
+	"strings"
 
 	pmax "github.com/dell/gopowermax/v2"
 	"github.com/sirupsen/logrus"
@@ -31,9 +33,15 @@ var GetPowerMaxEndpoint = func(storageSystemDetails storage.System) string {
 }
 
 // PowerMax validates powermax role parameters
-func PowerMax(ctx context.Context, log *logrus.Entry, system storage.System, systemID string, pool string, quota uint64) error {
-	if quota < 0 {
-		return errors.New("the specified quota needs to be a positive number")
+func PowerMax(ctx context.Context, log *logrus.Entry, system storage.System, systemID string, pool string, quota int64) error {
+	return PowerMaxServiceLevels(ctx, log, system, systemID, pool, quota, nil)
+}
+
+// PowerMaxServiceLevels validates powermax role parameters of a role pinned
+// to service levels, which must be service levels of the storage resource pool
+func PowerMaxServiceLevels(ctx context.Context, log *logrus.Entry, system storage.System, systemID string, pool string, quota int64, serviceLevels []string) error {
+	if quota < roles.UnlimitedQuota {
+		return errors.New("the specified quota needs to be a positive number, 0 or unlimited")
 	}
 
 	endpoint := GetPowerMaxEndpoint(system)
@@ -70,10 +78,31 @@ func PowerMax(ctx context.Context, log *logrus.Entry, system storage.System, sys
 		"StoragePool": pool,
 	}).Debug("Validating storage pool existence on PowerMax")
 
-	_, err = powerMaxClient.GetStoragePool(ctx, systemID, pool)
+	srp, err := powerMaxClient.GetStoragePool(ctx, systemID, pool)
 	if err != nil {
-		return err
+		return fmt.Errorf("storage resource pool %s not found on %s: %+v", pool, systemID, err)
+	}
+
+	for _, sl := range serviceLevels {
+		log.WithFields(logrus.Fields{
+			"StoragePool":  pool,
+			"ServiceLevel": sl,
+		}).Debug("Validating service level of storage pool on PowerMax")
+
+		if !hasServiceLevel(srp.ServiceLevels, sl) {
+			return fmt.Errorf("service level %s not found in storage resource pool %s, which has %s", sl, pool, strings.Join(srp.ServiceLevels, ", "))
+		}
 	}
 
 	return nil
 }
+
+// hasServiceLevel returns whether the service levels include sl, ignoring case.
+func hasServiceLevel(serviceLevels []string, sl string) bool {
+	for _, v := range serviceLevels {
+		if strings.EqualFold(v, sl) {
+			return true
+		}
+	}
+	return false
+}
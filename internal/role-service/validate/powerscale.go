@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	storage "karavi-authorization/cmd/karavictl/cmd"
+	"karavi-authorization/internal/role-service/roles"
 	"net/url"
 
 	pscale "github.com/dell/goisilon"
@@ -31,9 +32,9 @@ var GetPowerScaleEndpoint = func(storageSystemDetails storage.System) string {
 }
 
 // PowerScale validates powerscale role parameters
-func PowerScale(ctx context.Context, log *logrus.Entry, system storage.System, systemID string, pool string, quota uint64) error {
-	if quota != 0 {
-		return errors.New("quota must be 0 as it is not enforced by CSM-Authorization")
+func PowerScale(ctx context.Context, log *logrus.Entry, system storage.System, systemID string, pool string, quota int64) error {
+	if quota != 0 && quota != roles.UnlimitedQuota {
+		return errors.New("quota must be 0 or unlimited as it is not enforced by CSM-Authorization")
 	}
 
 	endpoint := GetPowerScaleEndpoint(system)
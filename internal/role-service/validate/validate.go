@@ -20,6 +20,7 @@ import (
 	storage "karavi-authorization/cmd/karavictl/cmd"
 	"karavi-authorization/internal/k8s"
 	"karavi-authorization/internal/role-service/roles"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 )
@@ -54,8 +55,24 @@ func (v *RoleValidator) Validate(ctx context.Context, role *roles.Instance) erro
 		return err
 	}
 
+	// a powerflex role may permit any pool of a protection domain
+	if role.SystemType == "powerflex" && strings.HasPrefix(role.Pool, roles.ProtectionDomainPrefix) {
+		pdName := strings.TrimPrefix(role.Pool, roles.ProtectionDomainPrefix)
+		pdID, err := PowerFlexProtectionDomain(ctx, v.log, system, role.SystemID, pdName, role.Quota)
+		if err != nil {
+			return err
+		}
+		role.ProtectionDomainID = pdID
+		return nil
+	}
+
+	// a powermax role may be pinned to service levels of its storage resource pool
+	if role.SystemType == "powermax" && len(role.ServiceLevels) > 0 {
+		return PowerMaxServiceLevels(ctx, v.log, system, role.SystemID, role.Pool, role.Quota, role.ServiceLevels)
+	}
+
 	// quota is in kilobytes (kb)
-	type validateFn func(ctx context.Context, log *logrus.Entry, system storage.System, systemID string, pool string, quota uint64) error
+	type validateFn func(ctx context.Context, log *logrus.Entry, system storage.System, systemID string, pool string, quota int64) error
 	var vFn validateFn
 
 	switch role.SystemType {
@@ -69,7 +86,7 @@ func (v *RoleValidator) Validate(ctx context.Context, role *roles.Instance) erro
 		return fmt.Errorf("system type %s is not supported", systemType)
 	}
 
-	return vFn(ctx, v.log, system, role.SystemID, role.Pool, uint64(role.Quota))
+	return vFn(ctx, v.log, system, role.SystemID, role.Pool, role.Quota)
 }
 
 func validSystemType(sysType string) bool {
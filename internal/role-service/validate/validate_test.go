@@ -134,6 +134,95 @@ storage:
 	})
 }
 
+func TestValidatePowerFlexProtectionDomain(t *testing.T) {
+	backendPowerFlex := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/login":
+				fmt.Fprintf(w, `"token"`)
+			case "/api/version":
+				fmt.Fprintf(w, "3.5")
+			case "/api/types/System/instances":
+				write(t, w, "powerflex_api_types_System_instances_542a2d5f5122210f.json")
+			case "/api/instances/System::542a2d5f5122210f/relationships/ProtectionDomain":
+				write(t, w, "protection_domains.json")
+			default:
+				t.Errorf("unhandled request path: %s", r.URL.Path)
+			}
+		}))
+	defer backendPowerFlex.Close()
+
+	oldGetPowerFlexEndpoint := validate.GetPowerFlexEndpoint
+	validate.GetPowerFlexEndpoint = func(_ storage.System) string {
+		return backendPowerFlex.URL
+	}
+	defer func() { validate.GetPowerFlexEndpoint = oldGetPowerFlexEndpoint }()
+
+	data := []byte(fmt.Sprintf(`
+storage:
+  powerflex:
+    542a2d5f5122210f:
+      endpoint: %s
+      insecure: true
+      password: Password123
+      user: admin`, backendPowerFlex.URL))
+
+	secret := &v1.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      k8s.StorageSecret,
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			k8s.StorageSecretDataKey: data,
+		},
+	}
+
+	api := &k8s.API{
+		Client:    fake.NewSimpleClientset(secret),
+		Namespace: "test",
+		Lock:      sync.Mutex{},
+		Log:       logrus.NewEntry(logrus.StandardLogger()),
+	}
+	rv := validate.NewRoleValidator(api, logrus.NewEntry(logrus.StandardLogger()))
+
+	t.Run("resolves the protection domain", func(t *testing.T) {
+		role := &roles.Instance{
+			RoleKey: roles.RoleKey{
+				Name:       "success",
+				SystemType: "powerflex",
+				SystemID:   "542a2d5f5122210f",
+				Pool:       roles.ProtectionDomainPrefix + "scaleio",
+			},
+			Quota: 1000,
+		}
+
+		err := rv.Validate(context.Background(), role)
+		if err != nil {
+			t.Fatalf("expected nil err, got %v", err)
+		}
+		if want := "0000000000000001"; role.ProtectionDomainID != want {
+			t.Errorf("expected protection domain ID %s, got %s", want, role.ProtectionDomainID)
+		}
+	})
+
+	t.Run("unknown protection domain", func(t *testing.T) {
+		role := &roles.Instance{
+			RoleKey: roles.RoleKey{
+				Name:       "fail",
+				SystemType: "powerflex",
+				SystemID:   "542a2d5f5122210f",
+				Pool:       roles.ProtectionDomainPrefix + "missing",
+			},
+			Quota: 1000,
+		}
+
+		err := rv.Validate(context.Background(), role)
+		if err == nil {
+			t.Error("expected non-nil err")
+		}
+	})
+}
+
 func TestValidatePowerMax(t *testing.T) {
 	// Happy pahts
 	t.Run("Success", func(t *testing.T) {
@@ -223,6 +312,84 @@ storage:
 	})
 }
 
+func TestValidatePowerMaxServiceLevels(t *testing.T) {
+	backendPowerMax := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/univmax/restapi/version":
+				fmt.Fprintf(w, `{ "version": "V10.0.0.1"}`)
+			case "/univmax/restapi/100/sloprovisioning/symmetrix/000197900714/srp/SRP_1":
+				fmt.Fprintf(w, `{"srpId": "SRP_1", "service_levels": ["Diamond", "Optimized"]}`)
+			case "/univmax/restapi/100/sloprovisioning/symmetrix/000197900714/srp/SRP_2":
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, `{"message": "Cannot find SRP SRP_2"}`)
+			default:
+				t.Errorf("unhandled unisphere request path: %s", r.URL.Path)
+			}
+		}))
+	defer backendPowerMax.Close()
+
+	oldGetPowerMaxEndpoint := validate.GetPowerMaxEndpoint
+	validate.GetPowerMaxEndpoint = func(_ storage.System) string {
+		return backendPowerMax.URL
+	}
+	defer func() { validate.GetPowerMaxEndpoint = oldGetPowerMaxEndpoint }()
+
+	data := []byte(fmt.Sprintf(`
+storage:
+  powermax:
+    "000197900714":
+      Endpoint: %s
+      Insecure: true
+      Password: Password123
+      User: admin`, backendPowerMax.URL))
+	api := &k8s.API{
+		Client: fake.NewSimpleClientset(&v1.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      k8s.StorageSecret,
+				Namespace: "test",
+			},
+			Data: map[string][]byte{
+				k8s.StorageSecretDataKey: data,
+			},
+		}),
+		Namespace: "test",
+		Lock:      sync.Mutex{},
+		Log:       logrus.NewEntry(logrus.StandardLogger()),
+	}
+
+	tests := []struct {
+		name          string
+		pool          string
+		serviceLevels []string
+		wantErr       bool
+	}{
+		{"service levels of the pool", "SRP_1", []string{"Diamond", "optimized"}, false},
+		{"service level not in the pool", "SRP_1", []string{"Diamond", "Gold"}, true},
+		{"missing pool", "SRP_2", []string{"Diamond"}, true},
+		{"missing pool without service levels", "SRP_2", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role := &roles.Instance{
+				Quota: 1000,
+				RoleKey: roles.RoleKey{
+					Name:       "pinned",
+					SystemType: "powermax",
+					SystemID:   "000197900714",
+					Pool:       tt.pool,
+				},
+				ServiceLevels: tt.serviceLevels,
+			}
+			rv := validate.NewRoleValidator(api, logrus.NewEntry(logrus.StandardLogger()))
+			err := rv.Validate(context.Background(), role)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got err %v, want err %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidatePowerScale(t *testing.T) {
 	// Happy paths
 	t.Run("Success", func(t *testing.T) {
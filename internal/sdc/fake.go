@@ -17,8 +17,9 @@ package sdc
 // FakeRedis is used for mocking out commonly used functions for
 // the Redis client.
 type FakeRedis struct {
-	PingFn func() (string, error)
-	HGetFn func(key, field string) (string, error)
+	PingFn     func() (string, error)
+	HGetFn     func(key, field string) (string, error)
+	SMembersFn func(key string) ([]string, error)
 }
 
 // Ping delegates to the PingFn function field.
@@ -30,3 +31,8 @@ func (f *FakeRedis) Ping() (string, error) {
 func (f *FakeRedis) HGet(key, field string) (string, error) {
 	return f.HGetFn(key, field)
 }
+
+// SMembers delegates to the SMembersFn function field.
+func (f *FakeRedis) SMembers(key string) ([]string, error) {
+	return f.SMembersFn(key)
+}
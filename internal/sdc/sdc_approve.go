@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/go-redis/redis"
 	"go.opentelemetry.io/otel/trace"
@@ -27,6 +29,7 @@ import (
 type sdcDB interface {
 	Ping() (string, error)
 	HGet(key, field string) (string, error)
+	SMembers(key string) ([]string, error)
 }
 
 // RedisDB wraps a real redis client and adapts it
@@ -47,8 +50,14 @@ func (r *RedisDB) HGet(key, field string) (string, error) {
 	return r.Client.HGet(key, field).Result()
 }
 
+// SMembers wraps the original SMembers method.
+func (r *RedisDB) SMembers(key string) ([]string, error) {
+	return r.Client.SMembers(key).Result()
+}
+
 // RedisSdcApprover is a wrapper around a redis client to approve requests.
 type RedisSdcApprover struct {
+	mu  sync.RWMutex // guards rdb
 	rdb sdcDB
 }
 
@@ -81,6 +90,19 @@ func NewSdcApprover(_ context.Context, opts ...Option) *RedisSdcApprover {
 	return v
 }
 
+// SetRedis changes the redis client used to approve requests.
+func (sa *RedisSdcApprover) SetRedis(rdb *redis.Client) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	sa.rdb = &RedisDB{Client: rdb}
+}
+
+func (sa *RedisSdcApprover) db() sdcDB {
+	sa.mu.RLock()
+	defer sa.mu.RUnlock()
+	return sa.rdb
+}
+
 // Request is a request to redis.
 type Request struct {
 	Group string `json:"group"`
@@ -88,7 +110,7 @@ type Request struct {
 
 // Ping pings the redis instance.
 func (sa *RedisSdcApprover) Ping() error {
-	res, err := sa.rdb.Ping()
+	res, err := sa.db().Ping()
 	if err != nil {
 		return err
 	}
@@ -101,6 +123,12 @@ func (r Request) DataKey() string {
 	return fmt.Sprintf("tenant:%s:data", r.Group)
 }
 
+// AllowedSdcsKey returns the redis formatted key of the set of SDC GUIDs
+// and IPs that volumes of the tenant may be mapped to.
+func (r Request) AllowedSdcsKey() string {
+	return fmt.Sprintf("tenant:%s:sdcs", r.Group)
+}
+
 // ApproveSdcField returns the redis formatted approved capacity field.
 func (r Request) ApproveSdcField() string {
 	return "approve_sdc"
@@ -112,7 +140,7 @@ func (sa *RedisSdcApprover) CheckSdcApproveFlag(ctx context.Context, r Request)
 	defer span.End()
 	var err error
 
-	flagvalue, err := sa.rdb.HGet(r.DataKey(), r.ApproveSdcField())
+	flagvalue, err := sa.db().HGet(r.DataKey(), r.ApproveSdcField())
 	if err != nil {
 		return false, err
 	}
@@ -127,3 +155,28 @@ func (sa *RedisSdcApprover) CheckSdcApproveFlag(ctx context.Context, r Request)
 	}
 	return false, nil
 }
+
+// CheckSdcAllowed checks that the SDC, identified by its GUID and IPs, is
+// allowed for the tenant. Every SDC is allowed if the tenant allows none in
+// particular.
+func (sa *RedisSdcApprover) CheckSdcAllowed(ctx context.Context, r Request, ids ...string) (bool, error) {
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "checkSdcAllowed")
+	defer span.End()
+
+	allowed, err := sa.db().SMembers(r.AllowedSdcsKey())
+	if err != nil {
+		return false, err
+	}
+	if len(allowed) == 0 {
+		return true, nil
+	}
+
+	for _, a := range allowed {
+		for _, id := range ids {
+			if id != "" && strings.EqualFold(a, id) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -105,6 +105,68 @@ func TestSdcApprover_checkSdcApproveFlag(t *testing.T) {
 	})
 }
 
+func TestSdcApprover_CheckSdcAllowed(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	req := buildRequest()
+	sut := sdc.NewSdcApprover(context.Background(), sdc.WithRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()})))
+
+	t.Run("allows every sdc without an allow list", func(t *testing.T) {
+		got, err := sut.CheckSdcAllowed(context.Background(), req, "sdc-guid", "10.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !got {
+			t.Error("expected the sdc to be allowed")
+		}
+	})
+
+	if _, err := mr.SAdd(req.AllowedSdcsKey(), "sdc-guid", "10.0.0.2"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		ids  []string
+		want bool
+	}{
+		{"allows a listed guid", []string{"SDC-GUID", "10.0.0.1"}, true},
+		{"allows a listed ip", []string{"other-guid", "10.0.0.1", "10.0.0.2"}, true},
+		{"denies an unlisted sdc", []string{"other-guid", "10.0.0.1"}, false},
+		{"denies an unknown sdc", []string{""}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := sut.CheckSdcAllowed(context.Background(), req, tc.ids...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("returns any error", func(t *testing.T) {
+		sut := sdc.NewSdcApprover(context.Background(),
+			sdc.WithDB(&sdc.FakeRedis{
+				SMembersFn: func(_ string) ([]string, error) {
+					return nil, ErrFake
+				},
+			}))
+
+		_, got := sut.CheckSdcAllowed(context.Background(), req, "sdc-guid")
+
+		if got != ErrFake {
+			t.Errorf("got %v, want %v", got, ErrFake)
+		}
+	})
+}
+
 func buildRequest() sdc.Request {
 	return sdc.Request{
 		Group: "mytenant",
@@ -122,6 +184,7 @@ func TestRequest(t *testing.T) {
 			want string
 		}{
 			{"DataKey", r.DataKey, "tenant:mytenant:data"},
+			{"AllowedSdcsKey", r.AllowedSdcsKey, "tenant:mytenant:sdcs"},
 		}
 		for _, tt := range tests {
 			tt := tt
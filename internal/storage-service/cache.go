@@ -0,0 +1,83 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultCacheTTL is how long volume and storage pool lookups are reused.
+// Tenants with many volumes list them repeatedly, and their size and pool
+// rarely change.
+const DefaultCacheTTL = 30 * time.Second
+
+// ttlCache remembers lookups for a short time. Concurrent lookups of a key
+// that is not cached are made only once.
+type ttlCache struct {
+	ttl time.Duration
+	sf  singleflight.Group
+
+	mu      sync.Mutex // guards entries and pruned
+	entries map[string]cacheEntry
+	pruned  time.Time
+}
+
+type cacheEntry struct {
+	v       interface{}
+	expires time.Time
+}
+
+func newTTLCache(ttl time.Duration) *ttlCache {
+	return &ttlCache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Get returns the cached value for key, or calls fetch and caches its
+// result. Errors are not cached.
+func (c *ttlCache) Get(key string, fetch func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.v, nil
+	}
+
+	v, err, _ := c.sf.Do(key, func() (interface{}, error) {
+		v, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		c.mu.Lock()
+		if now.Sub(c.pruned) > c.ttl {
+			for k, e := range c.entries {
+				if now.After(e.expires) {
+					delete(c.entries, k)
+				}
+			}
+			c.pruned = now
+		}
+		c.entries[key] = cacheEntry{v: v, expires: now.Add(c.ttl)}
+		c.mu.Unlock()
+		return v, nil
+	})
+	return v, err
+}
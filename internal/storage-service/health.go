@@ -0,0 +1,303 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	storage "karavi-authorization/cmd/karavictl/cmd"
+	"karavi-authorization/internal/powerflex"
+
+	pscale "github.com/dell/goisilon"
+	pmax "github.com/dell/gopowermax/v2"
+	"github.com/dell/goscaleio"
+	"github.com/sirupsen/logrus"
+)
+
+// Health statuses reported for a storage system.
+const (
+	HealthReachable   = "reachable"
+	HealthUnreachable = "unreachable"
+	HealthAuthFailed  = "auth-failed"
+	HealthDegraded    = "degraded"
+)
+
+// DefaultHealthInterval is how often storage systems are checked by default.
+const DefaultHealthInterval = time.Minute
+
+// SystemHealth is the result of the last check of a storage system.
+type SystemHealth struct {
+	StorageType string
+	SystemID    string
+	Status      string
+	Err         error
+	CheckedAt   time.Time
+}
+
+// Prober logs in to a storage system, runs a trivial query and returns the
+// resulting health status.
+type Prober func(ctx context.Context, systemType, systemID string, system storage.System) (string, error)
+
+// HealthOption allows for functional option arguments on the HealthChecker.
+type HealthOption func(*HealthChecker)
+
+// WithHealthInterval sets how often the storage systems are checked.
+func WithHealthInterval(d time.Duration) HealthOption {
+	return func(h *HealthChecker) {
+		h.interval = d
+	}
+}
+
+// WithHealthLogger provides a logger.
+func WithHealthLogger(log *logrus.Entry) HealthOption {
+	return func(h *HealthChecker) {
+		h.log = log
+	}
+}
+
+// WithProber overrides how a storage system is checked.
+func WithProber(p Prober) HealthOption {
+	return func(h *HealthChecker) {
+		h.probe = p
+	}
+}
+
+// HealthChecker periodically checks each configured storage system.
+type HealthChecker struct {
+	kube     Kube
+	probe    Prober
+	interval time.Duration
+	log      *logrus.Entry
+
+	mu     sync.RWMutex
+	health map[string]SystemHealth
+}
+
+// NewHealthChecker returns a HealthChecker for the storage systems in kube.
+func NewHealthChecker(kube Kube, opts ...HealthOption) *HealthChecker {
+	h := &HealthChecker{
+		kube:     kube,
+		probe:    ProbeSystem,
+		interval: DefaultHealthInterval,
+		log:      logrus.NewEntry(logrus.New()),
+		health:   make(map[string]SystemHealth),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Run checks the storage systems immediately and then on every interval
+// until ctx is done.
+func (h *HealthChecker) Run(ctx context.Context) {
+	h.check(ctx)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.check(ctx)
+		}
+	}
+}
+
+func (h *HealthChecker) check(ctx context.Context) {
+	if err := h.Check(ctx); err != nil {
+		h.log.WithError(err).Error("checking storage health")
+	}
+}
+
+// Check checks every configured storage system once and replaces the
+// previous results.
+func (h *HealthChecker) Check(ctx context.Context) error {
+	storages, err := h.kube.GetConfiguredStorage(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make(map[string]SystemHealth)
+	)
+	for systemType, systems := range storages {
+		for systemID, system := range systems {
+			wg.Add(1)
+			go func(systemType, systemID string, system storage.System) {
+				defer wg.Done()
+				status, err := h.probe(ctx, systemType, systemID, system)
+				if err != nil {
+					h.log.WithFields(logrus.Fields{
+						"storageType": systemType,
+						"systemID":    systemID,
+						"status":      status,
+					}).WithError(err).Warn("Storage system is unhealthy")
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				results[healthKey(systemType, systemID)] = SystemHealth{
+					StorageType: systemType,
+					SystemID:    systemID,
+					Status:      status,
+					Err:         err,
+					CheckedAt:   time.Now(),
+				}
+			}(systemType, systemID, system)
+		}
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.health = results
+	return nil
+}
+
+// Health returns the result of the last check, sorted by storage type and
+// system ID.
+func (h *HealthChecker) Health() []SystemHealth {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	health := make([]SystemHealth, 0, len(h.health))
+	for _, v := range h.health {
+		health = append(health, v)
+	}
+	sort.Slice(health, func(i, j int) bool {
+		if health[i].StorageType != health[j].StorageType {
+			return health[i].StorageType < health[j].StorageType
+		}
+		return health[i].SystemID < health[j].SystemID
+	})
+	return health
+}
+
+func healthKey(systemType, systemID string) string {
+	return systemType + ":" + systemID
+}
+
+// ProbeSystem logs in to the storage system and queries the system itself.
+// A failed login is reported as unreachable or auth-failed depending on
+// whether the array answered; a failed query after login is degraded.
+func ProbeSystem(ctx context.Context, systemType, systemID string, system storage.System) (string, error) {
+	switch systemType {
+	case "powerflex":
+		return probePowerflex(ctx, systemID, system)
+	case "powermax":
+		return probePowermax(ctx, systemID, system)
+	case "powerscale":
+		return probePowerscale(ctx, systemID, system)
+	default:
+		return HealthUnreachable, fmt.Errorf("system type %s is not supported", systemType)
+	}
+}
+
+func probePowerflex(ctx context.Context, systemID string, system storage.System) (string, error) {
+	epURL, err := url.Parse(GetPowerFlexEndpoint(system))
+	if err != nil {
+		return HealthUnreachable, fmt.Errorf("endpoint is invalid: %v", err)
+	}
+
+	epURL.Scheme = "https"
+	client, err := goscaleio.NewClientWithArgs(epURL.String(), "", 0, system.Insecure, false)
+	if err != nil {
+		return HealthUnreachable, fmt.Errorf("creating powerflex client for %s: %w", systemID, err)
+	}
+
+	err = powerflex.Authenticate(ctx, client, &goscaleio.ConfigConnect{
+		Endpoint: epURL.String(),
+		Username: system.User,
+		Password: system.Password,
+		Insecure: system.Insecure,
+	})
+	if err != nil {
+		return loginStatus(err), fmt.Errorf("powerflex authentication failed: %v", err)
+	}
+
+	if _, err := client.FindSystem(systemID, "", ""); err != nil {
+		return HealthDegraded, fmt.Errorf("finding powerflex system %s: %v", systemID, err)
+	}
+	return HealthReachable, nil
+}
+
+func probePowermax(ctx context.Context, systemID string, system storage.System) (string, error) {
+	epURL, err := url.Parse(GetPowerMaxEndpoint(system))
+	if err != nil {
+		return HealthUnreachable, fmt.Errorf("endpoint is invalid: %v", err)
+	}
+
+	epURL.Scheme = "https"
+	client, err := pmax.NewClientWithArgs(epURL.String(), "CSM-Authz", true, false, "")
+	if err != nil {
+		return HealthUnreachable, fmt.Errorf("creating powermax client for %s: %w", systemID, err)
+	}
+
+	err = client.Authenticate(ctx, &pmax.ConfigConnect{
+		Username: system.User,
+		Password: system.Password,
+	})
+	if err != nil {
+		return loginStatus(err), fmt.Errorf("powermax authentication failed: %v", err)
+	}
+
+	if _, err := client.GetSymmetrixByID(ctx, systemID); err != nil {
+		return HealthDegraded, fmt.Errorf("getting powermax system %s: %v", systemID, err)
+	}
+	return HealthReachable, nil
+}
+
+func probePowerscale(ctx context.Context, systemID string, system storage.System) (string, error) {
+	epURL, err := url.Parse(GetPowerScaleEndpoint(system))
+	if err != nil {
+		return HealthUnreachable, fmt.Errorf("endpoint is invalid: %v", err)
+	}
+
+	epURL.Scheme = "https"
+	client, err := pscale.NewClientWithArgs(ctx, epURL.String(), system.Insecure, uint(1), system.User, "Administrators", system.Password, "", "777", false, uint8(0))
+	if err != nil {
+		return loginStatus(err), fmt.Errorf("failed to connect to powerscale %s: %v", systemID, err)
+	}
+
+	if _, err := client.GetClusterConfig(ctx); err != nil {
+		return HealthDegraded, fmt.Errorf("failed to get cluster config: %v", err)
+	}
+	return HealthReachable, nil
+}
+
+// loginStatus classifies a failed login: the array could not be reached at
+// all, or it answered and rejected the credentials.
+func loginStatus(err error) string {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return HealthUnreachable
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return HealthUnreachable
+	}
+	return HealthAuthFailed
+}
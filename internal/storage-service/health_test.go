@@ -0,0 +1,114 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	storage "karavi-authorization/cmd/karavictl/cmd"
+	service "karavi-authorization/internal/storage-service"
+	"karavi-authorization/pb"
+)
+
+func TestHealthChecker(t *testing.T) {
+	kube := fakeKube{
+		GetConfiguredStorageFn: func(_ context.Context) (storage.Storage, error) {
+			return storage.Storage{
+				"powerflex": {
+					"542a2d5f5122210f": {User: "admin", Password: "bad"},
+					"11e4e7d35817bd0f": {User: "admin", Password: "good"},
+				},
+				"powermax": {
+					"000197900714": {User: "admin", Password: "good"},
+				},
+			}, nil
+		},
+	}
+	prober := func(_ context.Context, systemType, systemID string, system storage.System) (string, error) {
+		switch {
+		case system.Password == "bad":
+			return service.HealthAuthFailed, errors.New("bad credentials")
+		case systemType == "powermax":
+			return service.HealthDegraded, errors.New("query failed")
+		default:
+			return service.HealthReachable, nil
+		}
+	}
+
+	t.Run("it reports the health of each system", func(t *testing.T) {
+		h := service.NewHealthChecker(kube, service.WithProber(prober))
+		if err := h.Check(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		got := h.Health()
+		want := []struct{ typ, id, status string }{
+			{"powerflex", "11e4e7d35817bd0f", service.HealthReachable},
+			{"powerflex", "542a2d5f5122210f", service.HealthAuthFailed},
+			{"powermax", "000197900714", service.HealthDegraded},
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %d systems, want %d", len(got), len(want))
+		}
+		for i, w := range want {
+			if got[i].StorageType != w.typ || got[i].SystemID != w.id || got[i].Status != w.status {
+				t.Errorf("got %+v, want %+v", got[i], w)
+			}
+			if got[i].CheckedAt.IsZero() {
+				t.Errorf("expected %s to have a check time", w.id)
+			}
+		}
+	})
+
+	t.Run("it is reported by the service", func(t *testing.T) {
+		h := service.NewHealthChecker(kube, service.WithProber(prober))
+		if err := h.Check(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		svc := service.NewService(kube, successfulValidator{}, service.WithHealthChecker(h))
+
+		resp, err := svc.Status(context.Background(), &pb.StorageStatusRequest{})
+		errIsNil(t, err)
+
+		if len(resp.Systems) != 3 {
+			t.Fatalf("got %d systems, want 3", len(resp.Systems))
+		}
+		if got := resp.Systems[1]; got.Status != service.HealthAuthFailed || got.Error != "bad credentials" {
+			t.Errorf("got %v, want the auth failure", got)
+		}
+	})
+
+	t.Run("it returns an error when health checks are disabled", func(t *testing.T) {
+		svc := service.NewService(kube, successfulValidator{})
+
+		_, err := svc.Status(context.Background(), &pb.StorageStatusRequest{})
+		errIsNotNil(t, err)
+	})
+
+	t.Run("it reports an unreachable system", func(t *testing.T) {
+		status, err := service.ProbeSystem(context.Background(), "powerflex", "542a2d5f5122210f", storage.System{
+			User:     "admin",
+			Password: "password",
+			Endpoint: "https://127.0.0.1:1",
+			Insecure: true,
+		})
+		errIsNotNil(t, err)
+		if status != service.HealthUnreachable {
+			t.Errorf("got %q, want %q", status, service.HealthUnreachable)
+		}
+	})
+}
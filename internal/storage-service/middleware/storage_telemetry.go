@@ -50,7 +50,6 @@ func (t *TelemetryMW) Create(ctx context.Context, req *pb.StorageCreateRequest)
 		"Endpoint":    req.Endpoint,
 		"SystemId":    req.SystemId,
 		"UserName":    req.UserName,
-		"Password":    req.Password,
 		"Insecure":    req.Insecure,
 	})
 
@@ -59,7 +58,6 @@ func (t *TelemetryMW) Create(ctx context.Context, req *pb.StorageCreateRequest)
 		"Endpoint":    req.Endpoint,
 		"SystemId":    req.SystemId,
 		"UserName":    req.UserName,
-		"Password":    req.Password,
 		"Insecure":    req.Insecure,
 	}).Info("Creating storage")
 
@@ -84,7 +82,6 @@ func (t *TelemetryMW) Update(ctx context.Context, req *pb.StorageUpdateRequest)
 		"Endpoint":    req.Endpoint,
 		"SystemId":    req.SystemId,
 		"UserName":    req.UserName,
-		"Password":    req.Password,
 		"Insecure":    req.Insecure,
 	})
 
@@ -93,7 +90,6 @@ func (t *TelemetryMW) Update(ctx context.Context, req *pb.StorageUpdateRequest)
 		"Endpoint":    req.Endpoint,
 		"SystemId":    req.SystemId,
 		"UserName":    req.UserName,
-		"Password":    req.Password,
 		"Insecure":    req.Insecure,
 	}).Info("Updating storage")
 
@@ -197,6 +193,105 @@ func (t *TelemetryMW) GetPowerflexVolumes(ctx context.Context, req *pb.GetPowerf
 	return storages, nil
 }
 
+// Status wraps Status
+func (t *TelemetryMW) Status(ctx context.Context, req *pb.StorageStatusRequest) (*pb.StorageStatusResponse, error) {
+	now := time.Now()
+	defer t.timeSince(now, "Status")
+
+	span := trace.SpanFromContext(ctx)
+
+	t.log.Info("Getting storage status")
+
+	resp, err := t.next.Status(ctx, req)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Discover wraps Discover
+func (t *TelemetryMW) Discover(ctx context.Context, req *pb.StorageDiscoverRequest) (*pb.StorageDiscoverResponse, error) {
+	now := time.Now()
+	defer t.timeSince(now, "Discover")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"StorageType": req.StorageType,
+		"SystemId":    req.SystemId,
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"StorageType": req.StorageType,
+		"SystemId":    req.SystemId,
+	}).Info("Discovering storage")
+
+	resp, err := t.next.Discover(ctx, req)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// GetVolume wraps GetVolume
+func (t *TelemetryMW) GetVolume(ctx context.Context, req *pb.GetVolumeRequest) (*pb.GetVolumeResponse, error) {
+	now := time.Now()
+	defer t.timeSince(now, "GetVolume")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"StorageType": req.StorageType,
+		"SystemId":    req.SystemId,
+		"VolumeId":    req.VolumeId,
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"StorageType": req.StorageType,
+		"SystemId":    req.SystemId,
+		"VolumeId":    req.VolumeId,
+	}).Info("Getting volume")
+
+	resp, err := t.next.GetVolume(ctx, req)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ListVolumes wraps ListVolumes
+func (t *TelemetryMW) ListVolumes(ctx context.Context, req *pb.ListVolumesRequest) (*pb.ListVolumesResponse, error) {
+	now := time.Now()
+	defer t.timeSince(now, "ListVolumes")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"StorageType": req.StorageType,
+		"SystemId":    req.SystemId,
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"StorageType": req.StorageType,
+		"SystemId":    req.SystemId,
+	}).Info("Listing volumes")
+
+	resp, err := t.next.ListVolumes(ctx, req)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
 func (t *TelemetryMW) timeSince(start time.Time, fName string) {
 	t.log.WithFields(logrus.Fields{
 		"duration": fmt.Sprintf("%v", time.Since(start)),
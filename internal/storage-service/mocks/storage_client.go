@@ -29,6 +29,10 @@ type FakeStorageServiceClient struct {
 	DeleteStorageFn       func(context.Context, *pb.StorageDeleteRequest, ...grpc.CallOption) (*pb.StorageDeleteResponse, error)
 	GetStorageFn          func(context.Context, *pb.StorageGetRequest, ...grpc.CallOption) (*pb.StorageGetResponse, error)
 	GetPowerflexVolumesFn func(context.Context, *pb.GetPowerflexVolumesRequest, ...grpc.CallOption) (*pb.GetPowerflexVolumesResponse, error)
+	StatusFn              func(context.Context, *pb.StorageStatusRequest, ...grpc.CallOption) (*pb.StorageStatusResponse, error)
+	DiscoverFn            func(context.Context, *pb.StorageDiscoverRequest, ...grpc.CallOption) (*pb.StorageDiscoverResponse, error)
+	GetVolumeFn           func(context.Context, *pb.GetVolumeRequest, ...grpc.CallOption) (*pb.GetVolumeResponse, error)
+	ListVolumesFn         func(context.Context, *pb.ListVolumesRequest, ...grpc.CallOption) (*pb.ListVolumesResponse, error)
 }
 
 // Create mocks Create for StorageServiceClient
@@ -78,3 +82,35 @@ func (f *FakeStorageServiceClient) GetPowerflexVolumes(ctx context.Context, in *
 	}
 	return &pb.GetPowerflexVolumesResponse{}, nil
 }
+
+// Status mocks Status for StorageServiceClient
+func (f *FakeStorageServiceClient) Status(ctx context.Context, in *pb.StorageStatusRequest, opts ...grpc.CallOption) (*pb.StorageStatusResponse, error) {
+	if f.StatusFn != nil {
+		return f.StatusFn(ctx, in, opts...)
+	}
+	return &pb.StorageStatusResponse{}, nil
+}
+
+// Discover mocks Discover for StorageServiceClient
+func (f *FakeStorageServiceClient) Discover(ctx context.Context, in *pb.StorageDiscoverRequest, opts ...grpc.CallOption) (*pb.StorageDiscoverResponse, error) {
+	if f.DiscoverFn != nil {
+		return f.DiscoverFn(ctx, in, opts...)
+	}
+	return &pb.StorageDiscoverResponse{}, nil
+}
+
+// GetVolume mocks GetVolume for StorageServiceClient
+func (f *FakeStorageServiceClient) GetVolume(ctx context.Context, in *pb.GetVolumeRequest, opts ...grpc.CallOption) (*pb.GetVolumeResponse, error) {
+	if f.GetVolumeFn != nil {
+		return f.GetVolumeFn(ctx, in, opts...)
+	}
+	return &pb.GetVolumeResponse{}, nil
+}
+
+// ListVolumes mocks ListVolumes for StorageServiceClient
+func (f *FakeStorageServiceClient) ListVolumes(ctx context.Context, in *pb.ListVolumesRequest, opts ...grpc.CallOption) (*pb.ListVolumesResponse, error) {
+	if f.ListVolumesFn != nil {
+		return f.ListVolumesFn(ctx, in, opts...)
+	}
+	return &pb.ListVolumesResponse{}, nil
+}
@@ -28,6 +28,10 @@ type FakeStorageServiceServer struct {
 	DeleteStorageFn       func(context.Context, *pb.StorageDeleteRequest) (*pb.StorageDeleteResponse, error)
 	GetStorageFn          func(context.Context, *pb.StorageGetRequest) (*pb.StorageGetResponse, error)
 	GetPowerflexVolumesFn func(context.Context, *pb.GetPowerflexVolumesRequest) (*pb.GetPowerflexVolumesResponse, error)
+	StatusFn              func(context.Context, *pb.StorageStatusRequest) (*pb.StorageStatusResponse, error)
+	DiscoverFn            func(context.Context, *pb.StorageDiscoverRequest) (*pb.StorageDiscoverResponse, error)
+	GetVolumeFn           func(context.Context, *pb.GetVolumeRequest) (*pb.GetVolumeResponse, error)
+	ListVolumesFn         func(context.Context, *pb.ListVolumesRequest) (*pb.ListVolumesResponse, error)
 }
 
 // Create mocks Create for StorageServiceServer
@@ -77,3 +81,35 @@ func (f *FakeStorageServiceServer) GetPowerflexVolumes(ctx context.Context, in *
 	}
 	return &pb.GetPowerflexVolumesResponse{}, nil
 }
+
+// Status mocks Status for StorageServiceServer
+func (f *FakeStorageServiceServer) Status(ctx context.Context, in *pb.StorageStatusRequest) (*pb.StorageStatusResponse, error) {
+	if f.StatusFn != nil {
+		return f.StatusFn(ctx, in)
+	}
+	return &pb.StorageStatusResponse{}, nil
+}
+
+// Discover mocks Discover for StorageServiceServer
+func (f *FakeStorageServiceServer) Discover(ctx context.Context, in *pb.StorageDiscoverRequest) (*pb.StorageDiscoverResponse, error) {
+	if f.DiscoverFn != nil {
+		return f.DiscoverFn(ctx, in)
+	}
+	return &pb.StorageDiscoverResponse{}, nil
+}
+
+// GetVolume mocks GetVolume for StorageServiceServer
+func (f *FakeStorageServiceServer) GetVolume(ctx context.Context, in *pb.GetVolumeRequest) (*pb.GetVolumeResponse, error) {
+	if f.GetVolumeFn != nil {
+		return f.GetVolumeFn(ctx, in)
+	}
+	return &pb.GetVolumeResponse{}, nil
+}
+
+// ListVolumes mocks ListVolumes for StorageServiceServer
+func (f *FakeStorageServiceServer) ListVolumes(ctx context.Context, in *pb.ListVolumesRequest) (*pb.ListVolumesResponse, error) {
+	if f.ListVolumesFn != nil {
+		return f.ListVolumesFn(ctx, in)
+	}
+	return &pb.ListVolumesResponse{}, nil
+}
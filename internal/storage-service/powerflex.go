@@ -39,3 +39,59 @@ func (c *rateLimitedPowerFlexClient) FindStoragePool(ctx context.Context, id str
 
 	return c.client.FindStoragePool(id, name, href, protectionDomain)
 }
+
+// GetAllStoragePools returns the storage pools of every protection domain
+func (c *rateLimitedPowerFlexClient) GetAllStoragePools(ctx context.Context) ([]*types.StoragePool, error) {
+	err := c.sem.Acquire(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer c.sem.Release(1)
+
+	return c.client.GetStoragePool("")
+}
+
+// GetProtectionDomains returns the protection domains of the system
+func (c *rateLimitedPowerFlexClient) GetProtectionDomains(ctx context.Context, systemID string) ([]*types.ProtectionDomain, error) {
+	err := c.sem.Acquire(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer c.sem.Release(1)
+
+	system, err := c.client.FindSystem(systemID, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return system.GetProtectionDomain("")
+}
+
+// GetStoragePools returns the storage pools of the protection domain
+func (c *rateLimitedPowerFlexClient) GetStoragePools(ctx context.Context, pd *types.ProtectionDomain) ([]*goscaleio.StoragePool, error) {
+	err := c.sem.Acquire(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer c.sem.Release(1)
+
+	pools, err := goscaleio.NewProtectionDomainEx(c.client, pd).GetStoragePool("")
+	if err != nil {
+		return nil, err
+	}
+	var ret []*goscaleio.StoragePool
+	for _, pool := range pools {
+		ret = append(ret, goscaleio.NewStoragePoolEx(c.client, pool))
+	}
+	return ret, nil
+}
+
+// GetStatistics returns the statistics of the storage pool
+func (c *rateLimitedPowerFlexClient) GetStatistics(ctx context.Context, pool *goscaleio.StoragePool) (*types.Statistics, error) {
+	err := c.sem.Acquire(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer c.sem.Release(1)
+
+	return pool.GetStatistics()
+}
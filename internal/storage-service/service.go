@@ -17,18 +17,26 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	storage "karavi-authorization/cmd/karavictl/cmd"
+	"karavi-authorization/internal/errcode"
+	"karavi-authorization/internal/k8s"
+	"karavi-authorization/internal/powerflex"
 	"karavi-authorization/pb"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
 
 	"github.com/dell/goscaleio"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -42,6 +50,7 @@ type Option func(*Service)
 func defaultOptions() []Option {
 	return []Option{
 		WithLogger(logrus.NewEntry(logrus.New())),
+		WithCacheTTL(DefaultCacheTTL),
 	}
 }
 
@@ -52,6 +61,22 @@ func WithLogger(log *logrus.Entry) func(*Service) {
 	}
 }
 
+// WithCacheTTL sets how long PowerFlex volume lookups are reused. A TTL of
+// zero disables the cache.
+func WithCacheTTL(ttl time.Duration) func(*Service) {
+	return func(t *Service) {
+		t.cache = newTTLCache(ttl)
+	}
+}
+
+// WithHealthChecker provides the checker whose results are reported by
+// Status.
+func WithHealthChecker(h *HealthChecker) func(*Service) {
+	return func(t *Service) {
+		t.health = h
+	}
+}
+
 // Validator validates a storage instance
 type Validator interface {
 	Validate(ctx context.Context, systemID string, systemType string, system storage.System) error
@@ -63,6 +88,37 @@ type Kube interface {
 	UpdateStorages(ctx context.Context, storages storage.Storage) error
 }
 
+// StorageModifier is a Kube that modifies the storages without losing
+// concurrent updates, e.g. k8s.API.
+type StorageModifier interface {
+	ModifyStorages(ctx context.Context, fn func(storage.Storage) error) error
+}
+
+// modifyStorages applies fn to the configured storages and writes them. A
+// conflict with a concurrent update is returned as an Aborted error for the
+// caller to retry.
+func (s *Service) modifyStorages(ctx context.Context, fn func(storage.Storage) error) error {
+	if m, ok := s.kube.(StorageModifier); ok {
+		err := m.ModifyStorages(ctx, fn)
+		if errors.Is(err, k8s.ErrConflict) {
+			return errcode.Newf(codes.Aborted, pb.ErrorCode_CONCURRENT_UPDATE, "%v; retry the request", err)
+		}
+		return err
+	}
+
+	existing, err := s.kube.GetConfiguredStorage(ctx)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		existing = make(storage.Storage)
+	}
+	if err := fn(existing); err != nil {
+		return err
+	}
+	return s.kube.UpdateStorages(ctx, existing)
+}
+
 // Service implements the StorageService protobuf definiton
 type Service struct {
 	kube                        Kube
@@ -70,6 +126,8 @@ type Service struct {
 	log                         *logrus.Entry
 	concurrentPowerFlexRequests int
 	powerFlexConfigurationLock  sync.Mutex // lock for concurrent powerflex requests
+	cache                       *ttlCache
+	health                      *HealthChecker
 	pb.UnimplementedStorageServiceServer
 }
 
@@ -95,7 +153,6 @@ func (s *Service) Create(ctx context.Context, req *pb.StorageCreateRequest) (*pb
 		"Endpoint":    req.Endpoint,
 		"SystemId":    req.SystemId,
 		"Username":    req.UserName,
-		"Password":    req.Password,
 	}).Info("Create storage request")
 
 	// Get the current list of registered storage systems
@@ -129,15 +186,21 @@ func (s *Service) Create(ctx context.Context, req *pb.StorageCreateRequest) (*pb
 		return nil, err
 	}
 
-	// Creating new storage and adding it to the list of existing storages
+	// Creating new storage and adding it to the list of existing storages,
+	// checking again for a duplicate created during the validation
 	s.log.Debug("Applying new storage in Kubernetes")
-	systemType := existingStorages[req.StorageType]
-	if systemType == nil {
-		systemType = make(map[string]storage.System)
-	}
-	systemType[req.SystemId] = newSystem
-	existingStorages[req.StorageType] = systemType
-	err = s.kube.UpdateStorages(ctx, existingStorages)
+	err = s.modifyStorages(ctx, func(existingStorages storage.Storage) error {
+		if err := CheckForDuplicates(ctx, existingStorages, req.SystemId, req.StorageType); err != nil {
+			return err
+		}
+		systemType := existingStorages[req.StorageType]
+		if systemType == nil {
+			systemType = make(map[string]storage.System)
+		}
+		systemType[req.SystemId] = newSystem
+		existingStorages[req.StorageType] = systemType
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -174,43 +237,21 @@ func (s *Service) Update(ctx context.Context, req *pb.StorageUpdateRequest) (*pb
 		"Endpoint":    req.Endpoint,
 		"SystemId":    req.SystemId,
 		"Username":    req.UserName,
-		"Password":    req.Password,
 	}).Info("Serving update storage request")
 
-	// Get the current list of registered storage systems
-	s.log.Debug("Getting configured storage")
-	cfgStorage, err := s.kube.GetConfiguredStorage(ctx)
-	if err != nil {
-		s.log.WithError(err).Debug()
-		return nil, err
-	}
-
-	var didUpdate bool
-	for k := range cfgStorage {
-		if k != req.StorageType {
-			continue
-		}
-		_, ok := cfgStorage[k][req.SystemId]
+	s.log.Debug("Applying updated storage in Kubernetes")
+	err := s.modifyStorages(ctx, func(cfgStorage storage.Storage) error {
+		existing, ok := cfgStorage[req.StorageType][req.SystemId]
 		if !ok {
-			continue
-		}
-
-		cfgStorage[k][req.SystemId] = storage.System{
-			User:     req.UserName,
-			Password: req.Password,
-			Endpoint: req.Endpoint,
-			Insecure: req.Insecure,
+			return errcode.New(codes.NotFound, pb.ErrorCode_STORAGE_NOT_FOUND, "no matching storage systems to update").WithParam("system", req.SystemId)
 		}
-		didUpdate = true
-		break
-	}
-
-	if !didUpdate {
-		return nil, fmt.Errorf("no matching storage systems to update")
-	}
-
-	s.log.Debug("Applying updated storage in Kubernetes")
-	err = s.kube.UpdateStorages(ctx, cfgStorage)
+		existing.User = req.UserName
+		existing.Password = req.Password
+		existing.Endpoint = req.Endpoint
+		existing.Insecure = req.Insecure
+		cfgStorage[req.StorageType][req.SystemId] = existing
+		return nil
+	})
 	if err != nil {
 		s.log.WithError(err).Debug()
 		return nil, err
@@ -226,28 +267,18 @@ func (s *Service) Delete(ctx context.Context, req *pb.StorageDeleteRequest) (*pb
 		"SystemId":    req.SystemId,
 	}).Info("Serving delete storage request")
 
-	// Get the current list of registered storage systems
-	s.log.Debug("Getting configured storage")
-	existingStorages, err := s.kube.GetConfiguredStorage(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	s.log.Debug("Getting system type")
-	systemType, ok := existingStorages[req.StorageType]
-	if !ok {
-		return nil, fmt.Errorf("error: storage of type %s is missing", req.StorageType)
-	}
-
-	s.log.Debug("Check the requested system ID exists")
-	if _, systemIDExists := systemType[req.SystemId]; !systemIDExists {
-		return nil, fmt.Errorf("error: system with ID %s does not exist", req.SystemId)
-	}
-
-	s.log.Debug("Deleting the storage and updating the secret in Kubernetes")
-	delete(systemType, req.SystemId)
-	existingStorages[req.StorageType] = systemType
-	err = s.kube.UpdateStorages(ctx, existingStorages)
+	s.log.Debug("Deleting the storage and updating the secrets in Kubernetes")
+	err := s.modifyStorages(ctx, func(existingStorages storage.Storage) error {
+		systemType, ok := existingStorages[req.StorageType]
+		if !ok {
+			return errcode.Newf(codes.NotFound, pb.ErrorCode_STORAGE_NOT_FOUND, "error: storage of type %s is missing", req.StorageType).WithParam("type", req.StorageType)
+		}
+		if _, systemIDExists := systemType[req.SystemId]; !systemIDExists {
+			return errcode.Newf(codes.NotFound, pb.ErrorCode_STORAGE_NOT_FOUND, "error: system with ID %s does not exist", req.SystemId).WithParam("system", req.SystemId)
+		}
+		delete(systemType, req.SystemId)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -272,12 +303,12 @@ func (s *Service) Get(ctx context.Context, req *pb.StorageGetRequest) (*pb.Stora
 	s.log.Debug("Getting system type")
 	systemType, ok := existingStorages[req.StorageType]
 	if !ok {
-		return nil, fmt.Errorf("error: storage of type %s is missing", req.StorageType)
+		return nil, errcode.Newf(codes.NotFound, pb.ErrorCode_STORAGE_NOT_FOUND, "error: storage of type %s is missing", req.StorageType).WithParam("type", req.StorageType)
 	}
 
 	s.log.Debug("Check the requested system ID exists")
 	if _, systemIDExists := systemType[req.SystemId]; !systemIDExists {
-		return nil, fmt.Errorf("error: system with ID %s does not exist", req.SystemId)
+		return nil, errcode.Newf(codes.NotFound, pb.ErrorCode_STORAGE_NOT_FOUND, "error: system with ID %s does not exist", req.SystemId).WithParam("system", req.SystemId)
 	}
 
 	s.log.Debug("JSON marshaling configured storage")
@@ -308,15 +339,254 @@ func (s *Service) GetPowerflexVolumes(ctx context.Context, req *pb.GetPowerflexV
 	// Extract relevant storage system from requested systemId
 	systemType, ok := existingStorages["powerflex"]
 	if !ok {
-		return nil, fmt.Errorf("error: no powerflex storage configured")
+		return nil, errcode.New(codes.NotFound, pb.ErrorCode_STORAGE_NOT_FOUND, "error: no powerflex storage configured").WithParam("type", "powerflex")
 	}
 
 	system, ok := systemType[req.SystemId]
 	if !ok {
-		return nil, fmt.Errorf("error: system with ID %s does not exist", req.SystemId)
+		return nil, errcode.Newf(codes.NotFound, pb.ErrorCode_STORAGE_NOT_FOUND, "error: system with ID %s does not exist", req.SystemId).WithParam("system", req.SystemId)
+	}
+
+	// Names may be repeated, but each is looked up once.
+	indices := make(map[string][]int)
+	for i, volumeName := range req.VolumeName {
+		indices[volumeName] = append(indices[volumeName], i)
+	}
+
+	// Only connect to powerflex if a volume is not cached.
+	var (
+		connectOnce sync.Once
+		rlc         *rateLimitedPowerFlexClient
+		connectErr  error
+	)
+	connect := func() (*rateLimitedPowerFlexClient, error) {
+		connectOnce.Do(func() {
+			rlc, connectErr = s.connectPowerFlex(ctx, req.SystemId, system)
+		})
+		return rlc, connectErr
+	}
+
+	limit := s.GetConcurrentPowerFlexRequests()
+	if limit < 1 {
+		limit = 1
+	}
+	volumes := make([]*pb.Volume, len(req.VolumeName))
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(limit)
+
+	// Get each volume from powerflex
+	for volumeName, is := range indices {
+		volumeName, is := volumeName, is
+		eg.Go(func() error {
+			vol, err := s.getPowerFlexVolume(egCtx, req.SystemId, volumeName, connect)
+			if err != nil {
+				return err
+			}
+			for _, i := range is {
+				volumes[i] = vol
+			}
+			return nil
+		})
+	}
+	err = eg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetPowerflexVolumesResponse{Volume: volumes}, nil
+}
+
+// Status returns the health of each storage system from the last check
+func (s *Service) Status(_ context.Context, _ *pb.StorageStatusRequest) (*pb.StorageStatusResponse, error) {
+	if s.health == nil {
+		return nil, status.Error(codes.FailedPrecondition, "storage health checks are disabled")
+	}
+
+	var systems []*pb.StorageSystemStatus
+	for _, h := range s.health.Health() {
+		sys := &pb.StorageSystemStatus{
+			StorageType:   h.StorageType,
+			SystemId:      h.SystemID,
+			Status:        h.Status,
+			LastCheckTime: h.CheckedAt.Unix(),
+		}
+		if h.Err != nil {
+			sys.Error = h.Err.Error()
+		}
+		systems = append(systems, sys)
+	}
+	return &pb.StorageStatusResponse{Systems: systems}, nil
+}
+
+// Discover returns the storage pools of a storage system, with their
+// protection domains and capacity. Only powerflex is supported.
+func (s *Service) Discover(ctx context.Context, req *pb.StorageDiscoverRequest) (*pb.StorageDiscoverResponse, error) {
+	s.log.WithFields(logrus.Fields{
+		"StorageType": req.StorageType,
+		"SystemId":    req.SystemId,
+	}).Info("Serving discover storage request")
+
+	if req.StorageType != "powerflex" {
+		return nil, status.Errorf(codes.Unimplemented, "discovery of %s storage is not supported", req.StorageType)
+	}
+
+	s.log.Debug("Getting configured storage")
+	existingStorages, err := s.kube.GetConfiguredStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	system, ok := existingStorages[req.StorageType][req.SystemId]
+	if !ok {
+		return nil, errcode.Newf(codes.NotFound, pb.ErrorCode_STORAGE_NOT_FOUND, "system with ID %s does not exist", req.SystemId).WithParam("system", req.SystemId)
+	}
+
+	client, err := s.connectPowerFlex(ctx, req.SystemId, system)
+	if err != nil {
+		return nil, err
+	}
+
+	protectionDomains, err := client.GetProtectionDomains(ctx, req.SystemId)
+	if err != nil {
+		return nil, fmt.Errorf("getting protection domains of %s: %w", req.SystemId, err)
+	}
+
+	var pools []*pb.DiscoveredPool
+	for _, pd := range protectionDomains {
+		pdPools, err := client.GetStoragePools(ctx, pd)
+		if err != nil {
+			return nil, fmt.Errorf("getting storage pools of protection domain %s: %w", pd.Name, err)
+		}
+		for _, pool := range pdPools {
+			stats, err := client.GetStatistics(ctx, pool)
+			if err != nil {
+				return nil, fmt.Errorf("getting statistics of storage pool %s: %w", pool.StoragePool.Name, err)
+			}
+			pools = append(pools, &pb.DiscoveredPool{
+				Name:             pool.StoragePool.Name,
+				ProtectionDomain: pd.Name,
+				CapacityInKb:     int64(stats.MaxCapacityInKb),
+				UsedInKb:         int64(stats.CapacityInUseInKb),
+			})
+		}
+	}
+	return &pb.StorageDiscoverResponse{Pools: pools}, nil
+}
+
+// GetVolume returns a volume of a storage system by the ID the system
+// assigned to it, e.g. to adopt a volume created outside of authorization.
+// Only powerflex is supported.
+func (s *Service) GetVolume(ctx context.Context, req *pb.GetVolumeRequest) (*pb.GetVolumeResponse, error) {
+	s.log.WithFields(logrus.Fields{
+		"StorageType": req.StorageType,
+		"SystemId":    req.SystemId,
+		"VolumeId":    req.VolumeId,
+	}).Info("Serving get volume request")
+
+	if req.StorageType != "powerflex" {
+		return nil, status.Errorf(codes.Unimplemented, "getting volumes of %s storage is not supported", req.StorageType)
+	}
+
+	s.log.Debug("Getting configured storage")
+	existingStorages, err := s.kube.GetConfiguredStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	system, ok := existingStorages[req.StorageType][req.SystemId]
+	if !ok {
+		return nil, errcode.Newf(codes.NotFound, pb.ErrorCode_STORAGE_NOT_FOUND, "system with ID %s does not exist", req.SystemId).WithParam("system", req.SystemId)
+	}
+
+	client, err := s.connectPowerFlex(ctx, req.SystemId, system)
+	if err != nil {
+		return nil, err
+	}
+
+	vols, err := client.GetVolume(ctx, "", req.VolumeId, "", "", false)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "getting volume %s: %v", req.VolumeId, err)
+	}
+	if len(vols) == 0 {
+		return nil, status.Errorf(codes.NotFound, "volume %s does not exist", req.VolumeId)
+	}
+	vol := vols[0]
+
+	pool, err := client.FindStoragePool(ctx, vol.StoragePoolID, "", "", "")
+	if err != nil {
+		return nil, fmt.Errorf("getting storage pool of volume %s: %w", req.VolumeId, err)
+	}
+
+	return &pb.GetVolumeResponse{Volume: &pb.Volume{
+		Name:     vol.Name,
+		Size:     float32(vol.SizeInKb) / float32(KbInGb),
+		SystemId: req.SystemId,
+		Id:       vol.ID,
+		Pool:     pool.Name,
+		SizeInKb: int64(vol.SizeInKb),
+	}}, nil
+}
+
+// ListVolumes returns the volumes of a storage system, other than
+// snapshots, ordered by ID.
+func (s *Service) ListVolumes(ctx context.Context, req *pb.ListVolumesRequest) (*pb.ListVolumesResponse, error) {
+	s.log.WithFields(logrus.Fields{
+		"StorageType": req.StorageType,
+		"SystemId":    req.SystemId,
+	}).Info("Serving list volumes request")
+
+	if req.StorageType != "powerflex" {
+		return nil, status.Errorf(codes.Unimplemented, "listing volumes of %s storage is not supported", req.StorageType)
+	}
+
+	s.log.Debug("Getting configured storage")
+	existingStorages, err := s.kube.GetConfiguredStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	system, ok := existingStorages[req.StorageType][req.SystemId]
+	if !ok {
+		return nil, errcode.Newf(codes.NotFound, pb.ErrorCode_STORAGE_NOT_FOUND, "system with ID %s does not exist", req.SystemId).WithParam("system", req.SystemId)
+	}
+
+	client, err := s.connectPowerFlex(ctx, req.SystemId, system)
+	if err != nil {
+		return nil, err
 	}
 
-	// Establish connection to powerflex
+	vols, err := client.GetVolume(ctx, "", "", "", "", false)
+	if err != nil {
+		return nil, fmt.Errorf("listing volumes of %s: %w", req.SystemId, err)
+	}
+	pools, err := client.GetAllStoragePools(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing storage pools of %s: %w", req.SystemId, err)
+	}
+	poolNames := make(map[string]string)
+	for _, pool := range pools {
+		poolNames[pool.ID] = pool.Name
+	}
+
+	resp := &pb.ListVolumesResponse{}
+	for _, vol := range vols {
+		resp.Volumes = append(resp.Volumes, &pb.Volume{
+			Name:     vol.Name,
+			Size:     float32(vol.SizeInKb) / float32(KbInGb),
+			SystemId: req.SystemId,
+			Id:       vol.ID,
+			Pool:     poolNames[vol.StoragePoolID],
+			SizeInKb: int64(vol.SizeInKb),
+		})
+	}
+	sort.Slice(resp.Volumes, func(i, j int) bool {
+		return resp.Volumes[i].Id < resp.Volumes[j].Id
+	})
+	return resp, nil
+}
+
+// connectPowerFlex returns an authenticated, rate limited client for the
+// powerflex system.
+func (s *Service) connectPowerFlex(ctx context.Context, systemID string, system storage.System) (*rateLimitedPowerFlexClient, error) {
 	s.log.Debug("Connecting to Powerflex")
 	endpoint := GetPowerFlexEndpoint(system)
 	epURL, err := url.Parse(endpoint)
@@ -327,57 +597,65 @@ func (s *Service) GetPowerflexVolumes(ctx context.Context, req *pb.GetPowerflexV
 	epURL.Scheme = "https"
 	client, err := goscaleio.NewClientWithArgs(epURL.String(), "", 0, system.Insecure, false)
 	if err != nil {
-		return nil, fmt.Errorf("creating powerflex client for %s: %w", req.SystemId, err)
+		return nil, fmt.Errorf("creating powerflex client for %s: %w", systemID, err)
 	}
 
-	_, err = client.Authenticate(&goscaleio.ConfigConnect{
+	err = powerflex.Authenticate(ctx, client, &goscaleio.ConfigConnect{
+		Endpoint: epURL.String(),
 		Username: system.User,
 		Password: system.Password,
+		Insecure: system.Insecure,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("powerflex authentication failed: %v", err)
 	}
 
 	// rate limit the client
-	rateLimitedClient := newRateLimitedPowerFlexClient(client, semaphore.NewWeighted(int64(s.GetConcurrentPowerFlexRequests())))
+	return newRateLimitedPowerFlexClient(client, semaphore.NewWeighted(int64(s.GetConcurrentPowerFlexRequests()))), nil
+}
 
-	volumes := make([]*pb.Volume, len(req.VolumeName))
-	var eg errgroup.Group
+// getPowerFlexVolume looks up a volume and the name of its storage pool.
+// Lookups are cached, and concurrent lookups of the same volume or pool,
+// even from different requests, are made only once.
+func (s *Service) getPowerFlexVolume(ctx context.Context, systemID, volumeName string, connect func() (*rateLimitedPowerFlexClient, error)) (*pb.Volume, error) {
+	v, err := s.cache.Get("volume:"+systemID+":"+volumeName, func() (interface{}, error) {
+		client, err := connect()
+		if err != nil {
+			return nil, err
+		}
 
-	// Get each volume from powerflex
-	for i, volumeName := range req.VolumeName {
-		i := i
-		volumeName := volumeName
-		eg.Go(func() error {
-			vol, err := rateLimitedClient.GetVolume(ctx, "", "", "", volumeName, false)
-			if err != nil {
-				return fmt.Errorf("getting volume %s: %w", volumeName, err)
-			}
+		vol, err := client.GetVolume(ctx, "", "", "", volumeName, false)
+		if err != nil {
+			return nil, fmt.Errorf("getting volume %s: %w", volumeName, err)
+		}
 
-			if len(vol) == 0 {
-				return fmt.Errorf("couldn't find volumes for %s", volumeName)
-			}
+		if len(vol) == 0 {
+			return nil, fmt.Errorf("couldn't find volumes for %s", volumeName)
+		}
 
-			storagePoolName, err := rateLimitedClient.FindStoragePool(ctx, vol[0].StoragePoolID, "", "", "")
+		poolName, err := s.cache.Get("pool:"+systemID+":"+vol[0].StoragePoolID, func() (interface{}, error) {
+			storagePool, err := client.FindStoragePool(ctx, vol[0].StoragePoolID, "", "", "")
 			if err != nil {
-				return fmt.Errorf("getting storage pool name for %s: %w", volumeName, err)
+				return nil, err
 			}
-
-			volumes[i] = &pb.Volume{
-				Name:     volumeName,
-				Size:     float32(vol[0].SizeInKb) / float32(KbInGb),
-				SystemId: req.SystemId,
-				Id:       vol[0].ID,
-				Pool:     storagePoolName.Name,
-			}
-			return nil
+			return storagePool.Name, nil
 		})
-	}
-	err = eg.Wait()
+		if err != nil {
+			return nil, fmt.Errorf("getting storage pool name for %s: %w", volumeName, err)
+		}
+
+		return &pb.Volume{
+			Name:     volumeName,
+			Size:     float32(vol[0].SizeInKb) / float32(KbInGb),
+			SystemId: systemID,
+			Id:       vol[0].ID,
+			Pool:     poolName.(string),
+		}, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &pb.GetPowerflexVolumesResponse{Volume: volumes}, nil
+	return v.(*pb.Volume), nil
 }
 
 // CheckForDuplicates checks if requested systemID already exists
@@ -399,7 +677,7 @@ func CheckForDuplicates(_ context.Context, existingStorages storage.Storage, sys
 	}
 
 	if id, result := isDuplicate(); result {
-		err := fmt.Errorf("error: %s system with ID %s is already registered", storageType, id)
+		err := errcode.Newf(codes.AlreadyExists, pb.ErrorCode_STORAGE_ALREADY_EXISTS, "error: %s system with ID %s is already registered", storageType, id).WithParam("system", fmt.Sprint(id))
 		return err
 	}
 
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	storage "karavi-authorization/cmd/karavictl/cmd"
+	"karavi-authorization/internal/k8s"
 	service "karavi-authorization/internal/storage-service"
 	"karavi-authorization/pb"
 	"net/http"
@@ -27,9 +28,32 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+func TestServiceConflict(t *testing.T) {
+	kube := &conflictKube{}
+	svc := service.NewService(kube, successfulValidator{})
+
+	_, err := svc.Create(context.Background(), &pb.StorageCreateRequest{
+		StorageType: "powerflex",
+		Endpoint:    "https://10.0.0.1",
+		SystemId:    "542a2d5f5122210f",
+		UserName:    "admin",
+		Password:    "password",
+	})
+	if got := status.Code(err); got != codes.Aborted {
+		t.Errorf("got code %v, want %v", got, codes.Aborted)
+	}
+	if kube.calls != 2 {
+		t.Errorf("got %d calls, want the modification applied on each attempt", kube.calls)
+	}
+}
+
 func TestServiceCreate(t *testing.T) {
 	// define check functions to pass or fail tests
 	type checkFn func(*testing.T, error)
@@ -198,6 +222,8 @@ func TestServiceUpdate(t *testing.T) {
 						Password: "test",
 						Endpoint: "https://10.0.0.10",
 						Insecure: false,
+						Timeouts: &storage.SystemTimeouts{Create: "2m"},
+						Headers:  &storage.SystemHeaders{Remove: []string{"Forwarded"}},
 					},
 				},
 			}
@@ -208,6 +234,8 @@ func TestServiceUpdate(t *testing.T) {
 						Password: "test",
 						Endpoint: "https://10.0.0.1",
 						Insecure: false,
+						Timeouts: &storage.SystemTimeouts{Create: "2m"},
+						Headers:  &storage.SystemHeaders{Remove: []string{"Forwarded"}},
 					},
 				},
 			}
@@ -810,6 +838,8 @@ func TestServiceGetPowerflexVolumes(t *testing.T) {
 					switch r.URL.Path {
 					case "/api/login":
 						w.WriteHeader(http.StatusUnauthorized)
+					case "/rest/auth/login":
+						w.WriteHeader(http.StatusNotFound)
 					default:
 						t.Errorf("unhandled request path: %s", r.URL.Path)
 					}
@@ -965,6 +995,372 @@ func TestServiceGetPowerflexVolumes(t *testing.T) {
 	}
 }
 
+func TestServiceGetPowerflexVolumes_Cache(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	mockPowerflex := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			calls[r.URL.Path]++
+			mu.Unlock()
+			switch r.URL.Path {
+			case "/api/login":
+				fmt.Fprintf(w, `"token"`)
+			case "/api/version":
+				fmt.Fprintf(w, "3.5")
+			case "/api/types/Volume/instances/action/queryIdByKey":
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Error(err)
+				}
+				if strings.Contains(string(body), "volume1") {
+					fmt.Fprintf(w, "volume1Id")
+				}
+				if strings.Contains(string(body), "volume2") {
+					fmt.Fprintf(w, "volume2Id")
+				}
+			case "/api/instances/Volume::volume1Id":
+				b, err := os.ReadFile("testdata/powerflex_api_instances_volume_volume1Id.json")
+				if err != nil {
+					t.Error(err)
+				}
+				w.Write(b)
+			case "/api/instances/Volume::volume2Id":
+				b, err := os.ReadFile("testdata/powerflex_api_instances_volume_volume2Id.json")
+				if err != nil {
+					t.Error(err)
+				}
+				w.Write(b)
+			case "/api/types/StoragePool/instances":
+				b, err := os.ReadFile("testdata/powerflex_api_types_storagepool_instances.json")
+				if err != nil {
+					t.Error(err)
+				}
+				w.Write(b)
+			default:
+				t.Errorf("unhandled request path: %s", r.URL.Path)
+			}
+		}))
+	defer mockPowerflex.Close()
+
+	kube := fakeKube{
+		GetConfiguredStorageFn: func(_ context.Context) (storage.Storage, error) {
+			return storage.Storage{
+				"powerflex": storage.SystemType{
+					"systemId1": storage.System{
+						User:     "admin",
+						Password: "test",
+						Endpoint: mockPowerflex.URL,
+						Insecure: true,
+					},
+				},
+			}, nil
+		},
+	}
+	req := &pb.GetPowerflexVolumesRequest{
+		SystemId:   "systemId1",
+		VolumeName: []string{"volume1", "volume2", "volume1"},
+	}
+
+	t.Run("it looks up repeated volumes once", func(t *testing.T) {
+		calls = make(map[string]int)
+		svc := service.NewService(kube, nil)
+		svc.SetConcurrentPowerFlexRequests(2)
+
+		resp, err := svc.GetPowerflexVolumes(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, v := range resp.Volume {
+			got = append(got, v.Id)
+		}
+		if want := []string{"volumeId1", "volumeId2", "volumeId1"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+		if got := calls["/api/types/Volume/instances/action/queryIdByKey"]; got != 2 {
+			t.Errorf("got %d volume lookups, want 2", got)
+		}
+	})
+	t.Run("it reuses cached volumes", func(t *testing.T) {
+		calls = make(map[string]int)
+		svc := service.NewService(kube, nil)
+		svc.SetConcurrentPowerFlexRequests(2)
+
+		for i := 0; i < 2; i++ {
+			if _, err := svc.GetPowerflexVolumes(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if got := calls["/api/login"]; got != 1 {
+			t.Errorf("got %d logins, want 1", got)
+		}
+		if got := calls["/api/types/Volume/instances/action/queryIdByKey"]; got != 2 {
+			t.Errorf("got %d volume lookups, want 2", got)
+		}
+	})
+	t.Run("it does not cache with a zero TTL", func(t *testing.T) {
+		calls = make(map[string]int)
+		svc := service.NewService(kube, nil, service.WithCacheTTL(0))
+		svc.SetConcurrentPowerFlexRequests(2)
+
+		for i := 0; i < 2; i++ {
+			if _, err := svc.GetPowerflexVolumes(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if got := calls["/api/types/Volume/instances/action/queryIdByKey"]; got != 4 {
+			t.Errorf("got %d volume lookups, want 4", got)
+		}
+	})
+}
+
+func TestServiceDiscover(t *testing.T) {
+	mockPowerflex := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var file string
+			switch r.URL.Path {
+			case "/api/login":
+				fmt.Fprintf(w, `"token"`)
+				return
+			case "/api/version":
+				fmt.Fprintf(w, "3.5")
+				return
+			case "/api/types/System/instances":
+				file = "powerflex_api_types_system_instances.json"
+			case "/api/instances/System::542a2d5f5122210f/relationships/ProtectionDomain":
+				file = "powerflex_api_instances_system_protectiondomains.json"
+			case "/api/instances/ProtectionDomain::0000000000000001/relationships/StoragePool":
+				file = "powerflex_api_instances_protectiondomain_storagepools.json"
+			case "/api/instances/StoragePool::7000000000000000/relationships/Statistics":
+				file = "powerflex_api_instances_storagepool_statistics.json"
+			default:
+				t.Errorf("unhandled request path: %s", r.URL.Path)
+				return
+			}
+			b, err := os.ReadFile("testdata/" + file)
+			if err != nil {
+				t.Error(err)
+			}
+			w.Write(b)
+		}))
+	defer mockPowerflex.Close()
+
+	kube := fakeKube{
+		GetConfiguredStorageFn: func(_ context.Context) (storage.Storage, error) {
+			return storage.Storage{
+				"powerflex": storage.SystemType{
+					"542a2d5f5122210f": storage.System{
+						User:     "admin",
+						Password: "test",
+						Endpoint: mockPowerflex.URL,
+						Insecure: true,
+					},
+				},
+			}, nil
+		},
+	}
+
+	t.Run("it returns the pools of each protection domain", func(t *testing.T) {
+		svc := service.NewService(kube, nil)
+		svc.SetConcurrentPowerFlexRequests(2)
+
+		resp, err := svc.Discover(context.Background(), &pb.StorageDiscoverRequest{StorageType: "powerflex", SystemId: "542a2d5f5122210f"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(resp.Pools) != 1 {
+			t.Fatalf("got %d pools, want 1", len(resp.Pools))
+		}
+		got := resp.Pools[0]
+		if got.Name != "bronze" || got.ProtectionDomain != "scaleio" || got.CapacityInKb != 625689600 || got.UsedInKb != 0 {
+			t.Errorf("got %+v, want the bronze pool of the scaleio protection domain", got)
+		}
+	})
+	t.Run("it rejects an unknown system", func(t *testing.T) {
+		svc := service.NewService(kube, nil)
+
+		_, err := svc.Discover(context.Background(), &pb.StorageDiscoverRequest{StorageType: "powerflex", SystemId: "unknown"})
+		if err == nil {
+			t.Error("expected non-nil error")
+		}
+	})
+	t.Run("it rejects other storage types", func(t *testing.T) {
+		svc := service.NewService(kube, nil)
+
+		_, err := svc.Discover(context.Background(), &pb.StorageDiscoverRequest{StorageType: "powermax", SystemId: "542a2d5f5122210f"})
+		if err == nil {
+			t.Error("expected non-nil error")
+		}
+	})
+}
+
+func TestServiceGetVolume(t *testing.T) {
+	mockPowerflex := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var file string
+			switch r.URL.Path {
+			case "/api/login":
+				fmt.Fprintf(w, `"token"`)
+				return
+			case "/api/version":
+				fmt.Fprintf(w, "3.5")
+				return
+			case "/api/instances/Volume::volumeId1":
+				file = "powerflex_api_instances_volume_volume1Id.json"
+			case "/api/types/StoragePool/instances":
+				file = "powerflex_api_types_storagepool_instances.json"
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, `{"message":"Could not find the volume","httpStatusCode":404,"errorCode":79}`)
+				return
+			}
+			b, err := os.ReadFile("testdata/" + file)
+			if err != nil {
+				t.Error(err)
+			}
+			w.Write(b)
+		}))
+	defer mockPowerflex.Close()
+
+	kube := fakeKube{
+		GetConfiguredStorageFn: func(_ context.Context) (storage.Storage, error) {
+			return storage.Storage{
+				"powerflex": storage.SystemType{
+					"systemId1": storage.System{
+						User:     "admin",
+						Password: "test",
+						Endpoint: mockPowerflex.URL,
+						Insecure: true,
+					},
+				},
+			}, nil
+		},
+	}
+
+	t.Run("it returns the volume and its pool", func(t *testing.T) {
+		svc := service.NewService(kube, nil)
+		svc.SetConcurrentPowerFlexRequests(2)
+
+		resp, err := svc.GetVolume(context.Background(), &pb.GetVolumeRequest{StorageType: "powerflex", SystemId: "systemId1", VolumeId: "volumeId1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := resp.Volume
+		if got.Id != "volumeId1" || got.Name != "volume1" || got.Pool != "pool1" || got.SizeInKb != 8388608 || got.Size != 8 {
+			t.Errorf("got %+v, want volume1 of pool1", got)
+		}
+	})
+	t.Run("it rejects an unknown volume", func(t *testing.T) {
+		svc := service.NewService(kube, nil)
+		svc.SetConcurrentPowerFlexRequests(2)
+
+		_, err := svc.GetVolume(context.Background(), &pb.GetVolumeRequest{StorageType: "powerflex", SystemId: "systemId1", VolumeId: "unknown"})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("got %v, want a not found error", err)
+		}
+	})
+	t.Run("it rejects an unknown system", func(t *testing.T) {
+		svc := service.NewService(kube, nil)
+
+		_, err := svc.GetVolume(context.Background(), &pb.GetVolumeRequest{StorageType: "powerflex", SystemId: "unknown", VolumeId: "volumeId1"})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("got %v, want a not found error", err)
+		}
+	})
+	t.Run("it rejects other storage types", func(t *testing.T) {
+		svc := service.NewService(kube, nil)
+
+		_, err := svc.GetVolume(context.Background(), &pb.GetVolumeRequest{StorageType: "powermax", SystemId: "systemId1", VolumeId: "volumeId1"})
+		if status.Code(err) != codes.Unimplemented {
+			t.Errorf("got %v, want an unimplemented error", err)
+		}
+	})
+}
+
+func TestServiceListVolumes(t *testing.T) {
+	mockPowerflex := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var file string
+			switch r.URL.Path {
+			case "/api/login":
+				fmt.Fprintf(w, `"token"`)
+				return
+			case "/api/version":
+				fmt.Fprintf(w, "3.5")
+				return
+			case "/api/types/Volume/instances":
+				file = "powerflex_api_types_volume_instances.json"
+			case "/api/types/StoragePool/instances":
+				file = "powerflex_api_types_storagepool_instances.json"
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			b, err := os.ReadFile("testdata/" + file)
+			if err != nil {
+				t.Error(err)
+			}
+			w.Write(b)
+		}))
+	defer mockPowerflex.Close()
+
+	kube := fakeKube{
+		GetConfiguredStorageFn: func(_ context.Context) (storage.Storage, error) {
+			return storage.Storage{
+				"powerflex": storage.SystemType{
+					"systemId1": storage.System{
+						User:     "admin",
+						Password: "test",
+						Endpoint: mockPowerflex.URL,
+						Insecure: true,
+					},
+				},
+			}, nil
+		},
+	}
+
+	t.Run("it returns the volumes and their pools by ID", func(t *testing.T) {
+		svc := service.NewService(kube, nil)
+		svc.SetConcurrentPowerFlexRequests(2)
+
+		resp, err := svc.ListVolumes(context.Background(), &pb.ListVolumesRequest{StorageType: "powerflex", SystemId: "systemId1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, v := range resp.Volumes {
+			got = append(got, fmt.Sprintf("%s/%s/%s/%d", v.Id, v.Name, v.Pool, v.SizeInKb))
+		}
+		want := []string{"volumeId1/volume1/pool1/8388608", "volumeId2/volume2/pool2/16777216"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("it rejects an unknown system", func(t *testing.T) {
+		svc := service.NewService(kube, nil)
+
+		_, err := svc.ListVolumes(context.Background(), &pb.ListVolumesRequest{StorageType: "powerflex", SystemId: "unknown"})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("got %v, want a not found error", err)
+		}
+	})
+	t.Run("it rejects other storage types", func(t *testing.T) {
+		svc := service.NewService(kube, nil)
+
+		_, err := svc.ListVolumes(context.Background(), &pb.ListVolumesRequest{StorageType: "powermax", SystemId: "systemId1"})
+		if status.Code(err) != codes.Unimplemented {
+			t.Errorf("got %v, want an unimplemented error", err)
+		}
+	})
+}
+
 func TestCheckForDuplicates(t *testing.T) {
 	// define check functions to pass or fail tests
 	type checkFn func(*testing.T, error)
@@ -1031,6 +1427,23 @@ func (k failKube) GetConfiguredStorage(_ context.Context) (storage.Storage, erro
 	return nil, nil
 }
 
+// conflictKube is a kube whose modifications conflict with concurrent
+// updates until it gives up.
+type conflictKube struct {
+	successfulKube
+	calls int
+}
+
+func (k *conflictKube) ModifyStorages(_ context.Context, fn func(storage.Storage) error) error {
+	for i := 0; i < 2; i++ {
+		k.calls++
+		if err := fn(storage.Storage{}); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: secret modified", k8s.ErrConflict)
+}
+
 type successfulValidator struct{}
 
 func (v successfulValidator) Validate(_ context.Context, _ string, _ string, _ storage.System) error {
@@ -20,6 +20,7 @@ import (
 	"net/url"
 
 	storage "karavi-authorization/cmd/karavictl/cmd"
+	"karavi-authorization/internal/powerflex"
 
 	pscale "github.com/dell/goisilon"
 	pmax "github.com/dell/gopowermax/v2"
@@ -75,7 +76,7 @@ func (v *SystemValidator) Validate(ctx context.Context, systemID string, systemT
 	}
 }
 
-func validatePowerflex(_ context.Context, _ *logrus.Entry, system storage.System, systemID string) error {
+func validatePowerflex(ctx context.Context, _ *logrus.Entry, system storage.System, systemID string) error {
 	endpoint := GetPowerFlexEndpoint(system)
 	epURL, err := url.Parse(endpoint)
 	if err != nil {
@@ -88,9 +89,11 @@ func validatePowerflex(_ context.Context, _ *logrus.Entry, system storage.System
 		return fmt.Errorf("failed to connect to powerflex %s: %+v", systemID, err)
 	}
 
-	_, err = powerFlexClient.Authenticate(&goscaleio.ConfigConnect{
+	err = powerflex.Authenticate(ctx, powerFlexClient, &goscaleio.ConfigConnect{
+		Endpoint: epURL.String(),
 		Username: system.User,
 		Password: system.Password,
+		Insecure: system.Insecure,
 	})
 	if err != nil {
 		return fmt.Errorf("powerflex authentication failed: %+v", err)
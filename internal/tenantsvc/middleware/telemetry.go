@@ -16,6 +16,8 @@ import (
 	"context"
 	"fmt"
 	"karavi-authorization/pb"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -46,13 +48,17 @@ func (t *TelemetryMW) CreateTenant(ctx context.Context, req *pb.CreateTenantRequ
 
 	span := trace.SpanFromContext(ctx)
 	setAttributes(span, map[string]interface{}{
-		"tenant":      req.Tenant.Name,
-		"approve_sdc": req.Tenant.Approvesdc,
+		"tenant":          req.Tenant.Name,
+		"approve_sdc":     req.Tenant.Approvesdc,
+		"organization":    req.Tenant.Organization,
+		"no_default_role": req.NoDefaultRole,
 	})
 
 	t.log.WithFields(logrus.Fields{
-		"tenant":      req.Tenant.Name,
-		"approve_sdc": req.Tenant.Approvesdc,
+		"tenant":          req.Tenant.Name,
+		"approve_sdc":     req.Tenant.Approvesdc,
+		"organization":    req.Tenant.Organization,
+		"no_default_role": req.NoDefaultRole,
 	}).Info("Creating tenant")
 
 	tenant, err := t.next.CreateTenant(ctx, req)
@@ -126,13 +132,13 @@ func (t *TelemetryMW) DeleteTenant(ctx context.Context, req *pb.DeleteTenantRequ
 		"tenant": req.Name,
 	}).Info("Deleting tenant")
 
-	_, err := t.next.DeleteTenant(ctx, req)
+	resp, err := t.next.DeleteTenant(ctx, req)
 	if err != nil {
 		t.handleError(span, err)
 		return nil, err
 	}
 
-	return &pb.DeleteTenantResponse{}, nil
+	return resp, nil
 }
 
 // ListTenant wraps ListTenant
@@ -141,8 +147,13 @@ func (t *TelemetryMW) ListTenant(ctx context.Context, req *pb.ListTenantRequest)
 	defer t.timeSince(now, "ListTenant")
 
 	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"organization": req.Organization,
+	})
 
-	t.log.Info("Listing tenants")
+	t.log.WithFields(logrus.Fields{
+		"organization": req.Organization,
+	}).Info("Listing tenants")
 
 	tenants, err := t.next.ListTenant(ctx, req)
 	if err != nil {
@@ -296,6 +307,366 @@ func (t *TelemetryMW) CancelRevokeTenant(ctx context.Context, req *pb.CancelRevo
 	return resp, nil
 }
 
+// CreateOrganization wraps CreateOrganization
+func (t *TelemetryMW) CreateOrganization(ctx context.Context, req *pb.CreateOrganizationRequest) (*pb.Organization, error) {
+	now := time.Now()
+	defer t.timeSince(now, "CreateOrganization")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"organization": req.Organization.GetName(),
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"organization": req.Organization.GetName(),
+	}).Info("Creating organization")
+
+	org, err := t.next.CreateOrganization(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return org, nil
+}
+
+// GetOrganization wraps GetOrganization
+func (t *TelemetryMW) GetOrganization(ctx context.Context, req *pb.GetOrganizationRequest) (*pb.Organization, error) {
+	now := time.Now()
+	defer t.timeSince(now, "GetOrganization")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"organization": req.Name,
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"organization": req.Name,
+	}).Info("Getting organization")
+
+	org, err := t.next.GetOrganization(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return org, nil
+}
+
+// DeleteOrganization wraps DeleteOrganization
+func (t *TelemetryMW) DeleteOrganization(ctx context.Context, req *pb.DeleteOrganizationRequest) (*pb.DeleteOrganizationResponse, error) {
+	now := time.Now()
+	defer t.timeSince(now, "DeleteOrganization")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"organization": req.Name,
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"organization": req.Name,
+	}).Info("Deleting organization")
+
+	resp, err := t.next.DeleteOrganization(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ListOrganization wraps ListOrganization
+func (t *TelemetryMW) ListOrganization(ctx context.Context, req *pb.ListOrganizationRequest) (*pb.ListOrganizationResponse, error) {
+	now := time.Now()
+	defer t.timeSince(now, "ListOrganization")
+
+	span := trace.SpanFromContext(ctx)
+
+	t.log.Info("Listing organizations")
+
+	orgs, err := t.next.ListOrganization(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return orgs, nil
+}
+
+// AllowSdc wraps AllowSdc
+func (t *TelemetryMW) AllowSdc(ctx context.Context, req *pb.AllowSdcRequest) (*pb.Tenant, error) {
+	now := time.Now()
+	defer t.timeSince(now, "AllowSdc")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"tenant": req.TenantName,
+		"sdcs":   strings.Join(req.Sdcs, ","),
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"tenant": req.TenantName,
+		"sdcs":   req.Sdcs,
+	}).Info("Allowing tenant sdcs")
+
+	tenant, err := t.next.AllowSdc(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
+// DisallowSdc wraps DisallowSdc
+func (t *TelemetryMW) DisallowSdc(ctx context.Context, req *pb.DisallowSdcRequest) (*pb.Tenant, error) {
+	now := time.Now()
+	defer t.timeSince(now, "DisallowSdc")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"tenant": req.TenantName,
+		"sdcs":   strings.Join(req.Sdcs, ","),
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"tenant": req.TenantName,
+		"sdcs":   req.Sdcs,
+	}).Info("Disallowing tenant sdcs")
+
+	tenant, err := t.next.DisallowSdc(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
+// SetMaxVolumes wraps SetMaxVolumes
+func (t *TelemetryMW) SetMaxVolumes(ctx context.Context, req *pb.SetMaxVolumesRequest) (*pb.Tenant, error) {
+	now := time.Now()
+	defer t.timeSince(now, "SetMaxVolumes")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"tenant":      req.TenantName,
+		"max_volumes": req.MaxVolumes,
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"tenant":      req.TenantName,
+		"max_volumes": req.MaxVolumes,
+	}).Info("Setting tenant max volumes")
+
+	tenant, err := t.next.SetMaxVolumes(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
+// SetClaims wraps SetClaims
+func (t *TelemetryMW) SetClaims(ctx context.Context, req *pb.SetClaimsRequest) (*pb.Tenant, error) {
+	now := time.Now()
+	defer t.timeSince(now, "SetClaims")
+
+	keys := make([]string, 0, len(req.Claims))
+	for k := range req.Claims {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"tenant": req.TenantName,
+		"claims": strings.Join(keys, ","),
+		"remove": strings.Join(req.Remove, ","),
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"tenant": req.TenantName,
+		"claims": keys,
+		"remove": req.Remove,
+	}).Info("Setting tenant claims")
+
+	tenant, err := t.next.SetClaims(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
+// SetProtected wraps SetProtected
+func (t *TelemetryMW) SetProtected(ctx context.Context, req *pb.SetProtectedRequest) (*pb.Tenant, error) {
+	now := time.Now()
+	defer t.timeSince(now, "SetProtected")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"tenant":    req.TenantName,
+		"protected": req.Protected,
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"tenant":    req.TenantName,
+		"protected": req.Protected,
+	}).Info("Setting tenant protection")
+
+	tenant, err := t.next.SetProtected(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
+// SetVolumeNamePolicy wraps SetVolumeNamePolicy
+func (t *TelemetryMW) SetVolumeNamePolicy(ctx context.Context, req *pb.SetVolumeNamePolicyRequest) (*pb.Tenant, error) {
+	now := time.Now()
+	defer t.timeSince(now, "SetVolumeNamePolicy")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"tenant":         req.TenantName,
+		"volume_prefix":  req.VolumePrefix,
+		"volume_pattern": req.VolumePattern,
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"tenant":         req.TenantName,
+		"volume_prefix":  req.VolumePrefix,
+		"volume_pattern": req.VolumePattern,
+		"remove":         req.Remove,
+	}).Info("Setting tenant volume name policy")
+
+	tenant, err := t.next.SetVolumeNamePolicy(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
+// RestoreTenant wraps RestoreTenant
+func (t *TelemetryMW) RestoreTenant(ctx context.Context, req *pb.RestoreTenantRequest) (*pb.Tenant, error) {
+	now := time.Now()
+	defer t.timeSince(now, "RestoreTenant")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"tenant": req.Name,
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"tenant": req.Name,
+	}).Info("Restoring tenant")
+
+	tenant, err := t.next.RestoreTenant(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
+// GetTenantQuota wraps GetTenantQuota
+func (t *TelemetryMW) GetTenantQuota(ctx context.Context, req *pb.GetTenantQuotaRequest) (*pb.TenantQuota, error) {
+	now := time.Now()
+	defer t.timeSince(now, "GetTenantQuota")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"tenant": req.Name,
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"tenant": req.Name,
+	}).Info("Getting tenant quota")
+
+	quota, err := t.next.GetTenantQuota(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return quota, nil
+}
+
+// SetDefaultRole wraps SetDefaultRole
+func (t *TelemetryMW) SetDefaultRole(ctx context.Context, req *pb.SetDefaultRoleRequest) (*pb.DefaultRole, error) {
+	now := time.Now()
+	defer t.timeSince(now, "SetDefaultRole")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"role": req.RoleName,
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"role": req.RoleName,
+	}).Info("Setting default role")
+
+	role, err := t.next.SetDefaultRole(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// GetDefaultRole wraps GetDefaultRole
+func (t *TelemetryMW) GetDefaultRole(ctx context.Context, req *pb.GetDefaultRoleRequest) (*pb.DefaultRole, error) {
+	now := time.Now()
+	defer t.timeSince(now, "GetDefaultRole")
+
+	span := trace.SpanFromContext(ctx)
+
+	t.log.Info("Getting default role")
+
+	role, err := t.next.GetDefaultRole(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// GetActivity wraps GetActivity
+func (t *TelemetryMW) GetActivity(ctx context.Context, req *pb.GetActivityRequest) (*pb.GetActivityResponse, error) {
+	now := time.Now()
+	defer t.timeSince(now, "GetActivity")
+
+	span := trace.SpanFromContext(ctx)
+	setAttributes(span, map[string]interface{}{
+		"name":         req.Name,
+		"organization": req.Organization,
+	})
+
+	t.log.WithFields(logrus.Fields{
+		"name":         req.Name,
+		"organization": req.Organization,
+	}).Info("Getting tenant activity")
+
+	resp, err := t.next.GetActivity(ctx, req)
+	if err != nil {
+		t.handleError(span, err)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
 func (t *TelemetryMW) timeSince(start time.Time, fName string) {
 	t.log.WithFields(logrus.Fields{
 		"function": fName,
@@ -311,6 +682,8 @@ func setAttributes(span trace.Span, data map[string]interface{}) {
 			attr = append(attr, attribute.KeyValue{Key: attribute.Key(k), Value: attribute.StringValue(d)})
 		case bool:
 			attr = append(attr, attribute.KeyValue{Key: attribute.Key(k), Value: attribute.BoolValue(d)})
+		case int64:
+			attr = append(attr, attribute.KeyValue{Key: attribute.Key(k), Value: attribute.Int64Value(d)})
 		}
 	}
 	span.SetAttributes(attr...)
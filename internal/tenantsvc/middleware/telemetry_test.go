@@ -252,4 +252,132 @@ func TestTelemetry(t *testing.T) {
 			t.Errorf("expected next service to be called")
 		}
 	})
+
+	t.Run("CreateOrganization", func(t *testing.T) {
+		var gotCalled bool
+		next := &mocks.FakeTenantServiceServer{
+			CreateOrganizationFn: func(_ context.Context, _ *pb.CreateOrganizationRequest) (*pb.Organization, error) {
+				gotCalled = true
+				return &pb.Organization{}, nil
+			},
+		}
+
+		sut := NewTelemetryMW(logrus.NewEntry(logrus.StandardLogger()), next)
+		_, err := sut.CreateOrganization(context.Background(), &pb.CreateOrganizationRequest{
+			Organization: &pb.Organization{
+				Name: "test",
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !gotCalled {
+			t.Errorf("expected next service to be called")
+		}
+	})
+
+	t.Run("GetOrganization", func(t *testing.T) {
+		var gotCalled bool
+		next := &mocks.FakeTenantServiceServer{
+			GetOrganizationFn: func(_ context.Context, _ *pb.GetOrganizationRequest) (*pb.Organization, error) {
+				gotCalled = true
+				return &pb.Organization{}, nil
+			},
+		}
+
+		sut := NewTelemetryMW(logrus.NewEntry(logrus.StandardLogger()), next)
+		_, err := sut.GetOrganization(context.Background(), &pb.GetOrganizationRequest{
+			Name: "test",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !gotCalled {
+			t.Errorf("expected next service to be called")
+		}
+	})
+
+	t.Run("DeleteOrganization", func(t *testing.T) {
+		var gotCalled bool
+		next := &mocks.FakeTenantServiceServer{
+			DeleteOrganizationFn: func(_ context.Context, _ *pb.DeleteOrganizationRequest) (*pb.DeleteOrganizationResponse, error) {
+				gotCalled = true
+				return &pb.DeleteOrganizationResponse{}, nil
+			},
+		}
+
+		sut := NewTelemetryMW(logrus.NewEntry(logrus.StandardLogger()), next)
+		_, err := sut.DeleteOrganization(context.Background(), &pb.DeleteOrganizationRequest{
+			Name: "test",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !gotCalled {
+			t.Errorf("expected next service to be called")
+		}
+	})
+
+	t.Run("ListOrganization", func(t *testing.T) {
+		var gotCalled bool
+		next := &mocks.FakeTenantServiceServer{
+			ListOrganizationFn: func(_ context.Context, _ *pb.ListOrganizationRequest) (*pb.ListOrganizationResponse, error) {
+				gotCalled = true
+				return &pb.ListOrganizationResponse{}, nil
+			},
+		}
+
+		sut := NewTelemetryMW(logrus.NewEntry(logrus.StandardLogger()), next)
+		_, err := sut.ListOrganization(context.Background(), &pb.ListOrganizationRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !gotCalled {
+			t.Errorf("expected next service to be called")
+		}
+	})
+
+	t.Run("AllowSdc", func(t *testing.T) {
+		var gotCalled bool
+		next := &mocks.FakeTenantServiceServer{
+			AllowSdcFn: func(_ context.Context, _ *pb.AllowSdcRequest) (*pb.Tenant, error) {
+				gotCalled = true
+				return &pb.Tenant{}, nil
+			},
+		}
+
+		sut := NewTelemetryMW(logrus.NewEntry(logrus.StandardLogger()), next)
+		_, err := sut.AllowSdc(context.Background(), &pb.AllowSdcRequest{
+			TenantName: "test",
+			Sdcs:       []string{"sdc-guid"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !gotCalled {
+			t.Errorf("expected next service to be called")
+		}
+	})
+
+	t.Run("DisallowSdc", func(t *testing.T) {
+		var gotCalled bool
+		next := &mocks.FakeTenantServiceServer{
+			DisallowSdcFn: func(_ context.Context, _ *pb.DisallowSdcRequest) (*pb.Tenant, error) {
+				gotCalled = true
+				return &pb.Tenant{}, nil
+			},
+		}
+
+		sut := NewTelemetryMW(logrus.NewEntry(logrus.StandardLogger()), next)
+		_, err := sut.DisallowSdc(context.Background(), &pb.DisallowSdcRequest{
+			TenantName: "test",
+			Sdcs:       []string{"sdc-guid"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !gotCalled {
+			t.Errorf("expected next service to be called")
+		}
+	})
 }
@@ -24,16 +24,31 @@ import (
 // FakeTenantServiceClient is a mock tenant service client
 type FakeTenantServiceClient struct {
 	pb.TenantServiceClient
-	CreateTenantFn       func(context.Context, *pb.CreateTenantRequest, ...grpc.CallOption) (*pb.Tenant, error)
-	UpdateTenantFn       func(context.Context, *pb.UpdateTenantRequest, ...grpc.CallOption) (*pb.Tenant, error)
-	GetTenantFn          func(context.Context, *pb.GetTenantRequest, ...grpc.CallOption) (*pb.Tenant, error)
-	DeleteTenantFn       func(context.Context, *pb.DeleteTenantRequest, ...grpc.CallOption) (*pb.DeleteTenantResponse, error)
-	ListTenantFn         func(context.Context, *pb.ListTenantRequest, ...grpc.CallOption) (*pb.ListTenantResponse, error)
-	BindRoleFn           func(context.Context, *pb.BindRoleRequest, ...grpc.CallOption) (*pb.BindRoleResponse, error)
-	UnbindRoleFn         func(context.Context, *pb.UnbindRoleRequest, ...grpc.CallOption) (*pb.UnbindRoleResponse, error)
-	GenerateTokenFn      func(context.Context, *pb.GenerateTokenRequest, ...grpc.CallOption) (*pb.GenerateTokenResponse, error)
-	RevokeTenantFn       func(context.Context, *pb.RevokeTenantRequest, ...grpc.CallOption) (*pb.RevokeTenantResponse, error)
-	CancelRevokeTenantFn func(context.Context, *pb.CancelRevokeTenantRequest, ...grpc.CallOption) (*pb.CancelRevokeTenantResponse, error)
+	CreateTenantFn        func(context.Context, *pb.CreateTenantRequest, ...grpc.CallOption) (*pb.Tenant, error)
+	UpdateTenantFn        func(context.Context, *pb.UpdateTenantRequest, ...grpc.CallOption) (*pb.Tenant, error)
+	GetTenantFn           func(context.Context, *pb.GetTenantRequest, ...grpc.CallOption) (*pb.Tenant, error)
+	DeleteTenantFn        func(context.Context, *pb.DeleteTenantRequest, ...grpc.CallOption) (*pb.DeleteTenantResponse, error)
+	ListTenantFn          func(context.Context, *pb.ListTenantRequest, ...grpc.CallOption) (*pb.ListTenantResponse, error)
+	BindRoleFn            func(context.Context, *pb.BindRoleRequest, ...grpc.CallOption) (*pb.BindRoleResponse, error)
+	UnbindRoleFn          func(context.Context, *pb.UnbindRoleRequest, ...grpc.CallOption) (*pb.UnbindRoleResponse, error)
+	GenerateTokenFn       func(context.Context, *pb.GenerateTokenRequest, ...grpc.CallOption) (*pb.GenerateTokenResponse, error)
+	RevokeTenantFn        func(context.Context, *pb.RevokeTenantRequest, ...grpc.CallOption) (*pb.RevokeTenantResponse, error)
+	CancelRevokeTenantFn  func(context.Context, *pb.CancelRevokeTenantRequest, ...grpc.CallOption) (*pb.CancelRevokeTenantResponse, error)
+	CreateOrganizationFn  func(context.Context, *pb.CreateOrganizationRequest, ...grpc.CallOption) (*pb.Organization, error)
+	GetOrganizationFn     func(context.Context, *pb.GetOrganizationRequest, ...grpc.CallOption) (*pb.Organization, error)
+	DeleteOrganizationFn  func(context.Context, *pb.DeleteOrganizationRequest, ...grpc.CallOption) (*pb.DeleteOrganizationResponse, error)
+	ListOrganizationFn    func(context.Context, *pb.ListOrganizationRequest, ...grpc.CallOption) (*pb.ListOrganizationResponse, error)
+	AllowSdcFn            func(context.Context, *pb.AllowSdcRequest, ...grpc.CallOption) (*pb.Tenant, error)
+	DisallowSdcFn         func(context.Context, *pb.DisallowSdcRequest, ...grpc.CallOption) (*pb.Tenant, error)
+	SetMaxVolumesFn       func(context.Context, *pb.SetMaxVolumesRequest, ...grpc.CallOption) (*pb.Tenant, error)
+	SetClaimsFn           func(context.Context, *pb.SetClaimsRequest, ...grpc.CallOption) (*pb.Tenant, error)
+	SetProtectedFn        func(context.Context, *pb.SetProtectedRequest, ...grpc.CallOption) (*pb.Tenant, error)
+	SetVolumeNamePolicyFn func(context.Context, *pb.SetVolumeNamePolicyRequest, ...grpc.CallOption) (*pb.Tenant, error)
+	RestoreTenantFn       func(context.Context, *pb.RestoreTenantRequest, ...grpc.CallOption) (*pb.Tenant, error)
+	GetTenantQuotaFn      func(context.Context, *pb.GetTenantQuotaRequest, ...grpc.CallOption) (*pb.TenantQuota, error)
+	SetDefaultRoleFn      func(context.Context, *pb.SetDefaultRoleRequest, ...grpc.CallOption) (*pb.DefaultRole, error)
+	GetDefaultRoleFn      func(context.Context, *pb.GetDefaultRoleRequest, ...grpc.CallOption) (*pb.DefaultRole, error)
+	GetActivityFn         func(context.Context, *pb.GetActivityRequest, ...grpc.CallOption) (*pb.GetActivityResponse, error)
 }
 
 // CreateTenant executes the mock CreateTenant
@@ -121,3 +136,143 @@ func (f *FakeTenantServiceClient) CancelRevokeTenant(ctx context.Context, in *pb
 	}
 	return &pb.CancelRevokeTenantResponse{}, nil
 }
+
+// CreateOrganization executes the mock CreateOrganization
+func (f *FakeTenantServiceClient) CreateOrganization(ctx context.Context, in *pb.CreateOrganizationRequest, opts ...grpc.CallOption) (*pb.Organization, error) {
+	if f.CreateOrganizationFn != nil {
+		return f.CreateOrganizationFn(ctx, in, opts...)
+	}
+	return &pb.Organization{
+		Name: "testname",
+	}, nil
+}
+
+// GetOrganization executes the mock GetOrganization
+func (f *FakeTenantServiceClient) GetOrganization(ctx context.Context, in *pb.GetOrganizationRequest, opts ...grpc.CallOption) (*pb.Organization, error) {
+	if f.GetOrganizationFn != nil {
+		return f.GetOrganizationFn(ctx, in, opts...)
+	}
+	return &pb.Organization{
+		Name: "testname",
+	}, nil
+}
+
+// DeleteOrganization executes the mock DeleteOrganization
+func (f *FakeTenantServiceClient) DeleteOrganization(ctx context.Context, in *pb.DeleteOrganizationRequest, opts ...grpc.CallOption) (*pb.DeleteOrganizationResponse, error) {
+	if f.DeleteOrganizationFn != nil {
+		return f.DeleteOrganizationFn(ctx, in, opts...)
+	}
+	return &pb.DeleteOrganizationResponse{}, nil
+}
+
+// ListOrganization executes the mock ListOrganization
+func (f *FakeTenantServiceClient) ListOrganization(ctx context.Context, in *pb.ListOrganizationRequest, opts ...grpc.CallOption) (*pb.ListOrganizationResponse, error) {
+	if f.ListOrganizationFn != nil {
+		return f.ListOrganizationFn(ctx, in, opts...)
+	}
+	return &pb.ListOrganizationResponse{}, nil
+}
+
+// AllowSdc executes the mock AllowSdc
+func (f *FakeTenantServiceClient) AllowSdc(ctx context.Context, in *pb.AllowSdcRequest, opts ...grpc.CallOption) (*pb.Tenant, error) {
+	if f.AllowSdcFn != nil {
+		return f.AllowSdcFn(ctx, in, opts...)
+	}
+	return &pb.Tenant{
+		Name: "testname",
+	}, nil
+}
+
+// DisallowSdc executes the mock DisallowSdc
+func (f *FakeTenantServiceClient) DisallowSdc(ctx context.Context, in *pb.DisallowSdcRequest, opts ...grpc.CallOption) (*pb.Tenant, error) {
+	if f.DisallowSdcFn != nil {
+		return f.DisallowSdcFn(ctx, in, opts...)
+	}
+	return &pb.Tenant{
+		Name: "testname",
+	}, nil
+}
+
+// SetMaxVolumes executes the mock SetMaxVolumes
+func (f *FakeTenantServiceClient) SetMaxVolumes(ctx context.Context, in *pb.SetMaxVolumesRequest, opts ...grpc.CallOption) (*pb.Tenant, error) {
+	if f.SetMaxVolumesFn != nil {
+		return f.SetMaxVolumesFn(ctx, in, opts...)
+	}
+	return &pb.Tenant{
+		Name: "testname",
+	}, nil
+}
+
+// SetClaims executes the mock SetClaims
+func (f *FakeTenantServiceClient) SetClaims(ctx context.Context, in *pb.SetClaimsRequest, opts ...grpc.CallOption) (*pb.Tenant, error) {
+	if f.SetClaimsFn != nil {
+		return f.SetClaimsFn(ctx, in, opts...)
+	}
+	return &pb.Tenant{
+		Name: "testname",
+	}, nil
+}
+
+// SetProtected executes the mock SetProtected
+func (f *FakeTenantServiceClient) SetProtected(ctx context.Context, in *pb.SetProtectedRequest, opts ...grpc.CallOption) (*pb.Tenant, error) {
+	if f.SetProtectedFn != nil {
+		return f.SetProtectedFn(ctx, in, opts...)
+	}
+	return &pb.Tenant{
+		Name: "testname",
+	}, nil
+}
+
+// SetVolumeNamePolicy executes the mock SetVolumeNamePolicy
+func (f *FakeTenantServiceClient) SetVolumeNamePolicy(ctx context.Context, in *pb.SetVolumeNamePolicyRequest, opts ...grpc.CallOption) (*pb.Tenant, error) {
+	if f.SetVolumeNamePolicyFn != nil {
+		return f.SetVolumeNamePolicyFn(ctx, in, opts...)
+	}
+	return &pb.Tenant{
+		Name: "testname",
+	}, nil
+}
+
+// RestoreTenant executes the mock RestoreTenant
+func (f *FakeTenantServiceClient) RestoreTenant(ctx context.Context, in *pb.RestoreTenantRequest, opts ...grpc.CallOption) (*pb.Tenant, error) {
+	if f.RestoreTenantFn != nil {
+		return f.RestoreTenantFn(ctx, in, opts...)
+	}
+	return &pb.Tenant{
+		Name: "testname",
+	}, nil
+}
+
+// GetTenantQuota executes the mock GetTenantQuota
+func (f *FakeTenantServiceClient) GetTenantQuota(ctx context.Context, in *pb.GetTenantQuotaRequest, opts ...grpc.CallOption) (*pb.TenantQuota, error) {
+	if f.GetTenantQuotaFn != nil {
+		return f.GetTenantQuotaFn(ctx, in, opts...)
+	}
+	return &pb.TenantQuota{
+		Name: "testname",
+	}, nil
+}
+
+// SetDefaultRole executes the mock SetDefaultRole
+func (f *FakeTenantServiceClient) SetDefaultRole(ctx context.Context, in *pb.SetDefaultRoleRequest, opts ...grpc.CallOption) (*pb.DefaultRole, error) {
+	if f.SetDefaultRoleFn != nil {
+		return f.SetDefaultRoleFn(ctx, in, opts...)
+	}
+	return &pb.DefaultRole{}, nil
+}
+
+// GetDefaultRole executes the mock GetDefaultRole
+func (f *FakeTenantServiceClient) GetDefaultRole(ctx context.Context, in *pb.GetDefaultRoleRequest, opts ...grpc.CallOption) (*pb.DefaultRole, error) {
+	if f.GetDefaultRoleFn != nil {
+		return f.GetDefaultRoleFn(ctx, in, opts...)
+	}
+	return &pb.DefaultRole{}, nil
+}
+
+// GetActivity executes the mock GetActivity
+func (f *FakeTenantServiceClient) GetActivity(ctx context.Context, in *pb.GetActivityRequest, opts ...grpc.CallOption) (*pb.GetActivityResponse, error) {
+	if f.GetActivityFn != nil {
+		return f.GetActivityFn(ctx, in, opts...)
+	}
+	return &pb.GetActivityResponse{}, nil
+}
@@ -22,17 +22,32 @@ import (
 // FakeTenantServiceServer is a mock tenant service server
 type FakeTenantServiceServer struct {
 	pb.UnimplementedTenantServiceServer
-	CreateTenantFn       func(context.Context, *pb.CreateTenantRequest) (*pb.Tenant, error)
-	UpdateTenantFn       func(context.Context, *pb.UpdateTenantRequest) (*pb.Tenant, error)
-	GetTenantFn          func(context.Context, *pb.GetTenantRequest) (*pb.Tenant, error)
-	DeleteTenantFn       func(context.Context, *pb.DeleteTenantRequest) (*pb.DeleteTenantResponse, error)
-	ListTenantFn         func(context.Context, *pb.ListTenantRequest) (*pb.ListTenantResponse, error)
-	BindRoleFn           func(context.Context, *pb.BindRoleRequest) (*pb.BindRoleResponse, error)
-	UnbindRoleFn         func(context.Context, *pb.UnbindRoleRequest) (*pb.UnbindRoleResponse, error)
-	GenerateTokenFn      func(context.Context, *pb.GenerateTokenRequest) (*pb.GenerateTokenResponse, error)
-	RefreshTokenFn       func(context.Context, *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error)
-	RevokeTenantFn       func(context.Context, *pb.RevokeTenantRequest) (*pb.RevokeTenantResponse, error)
-	CancelRevokeTenantFn func(context.Context, *pb.CancelRevokeTenantRequest) (*pb.CancelRevokeTenantResponse, error)
+	CreateTenantFn        func(context.Context, *pb.CreateTenantRequest) (*pb.Tenant, error)
+	UpdateTenantFn        func(context.Context, *pb.UpdateTenantRequest) (*pb.Tenant, error)
+	GetTenantFn           func(context.Context, *pb.GetTenantRequest) (*pb.Tenant, error)
+	DeleteTenantFn        func(context.Context, *pb.DeleteTenantRequest) (*pb.DeleteTenantResponse, error)
+	ListTenantFn          func(context.Context, *pb.ListTenantRequest) (*pb.ListTenantResponse, error)
+	BindRoleFn            func(context.Context, *pb.BindRoleRequest) (*pb.BindRoleResponse, error)
+	UnbindRoleFn          func(context.Context, *pb.UnbindRoleRequest) (*pb.UnbindRoleResponse, error)
+	GenerateTokenFn       func(context.Context, *pb.GenerateTokenRequest) (*pb.GenerateTokenResponse, error)
+	RefreshTokenFn        func(context.Context, *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error)
+	RevokeTenantFn        func(context.Context, *pb.RevokeTenantRequest) (*pb.RevokeTenantResponse, error)
+	CancelRevokeTenantFn  func(context.Context, *pb.CancelRevokeTenantRequest) (*pb.CancelRevokeTenantResponse, error)
+	CreateOrganizationFn  func(context.Context, *pb.CreateOrganizationRequest) (*pb.Organization, error)
+	GetOrganizationFn     func(context.Context, *pb.GetOrganizationRequest) (*pb.Organization, error)
+	DeleteOrganizationFn  func(context.Context, *pb.DeleteOrganizationRequest) (*pb.DeleteOrganizationResponse, error)
+	ListOrganizationFn    func(context.Context, *pb.ListOrganizationRequest) (*pb.ListOrganizationResponse, error)
+	AllowSdcFn            func(context.Context, *pb.AllowSdcRequest) (*pb.Tenant, error)
+	DisallowSdcFn         func(context.Context, *pb.DisallowSdcRequest) (*pb.Tenant, error)
+	SetMaxVolumesFn       func(context.Context, *pb.SetMaxVolumesRequest) (*pb.Tenant, error)
+	SetClaimsFn           func(context.Context, *pb.SetClaimsRequest) (*pb.Tenant, error)
+	SetProtectedFn        func(context.Context, *pb.SetProtectedRequest) (*pb.Tenant, error)
+	SetVolumeNamePolicyFn func(context.Context, *pb.SetVolumeNamePolicyRequest) (*pb.Tenant, error)
+	RestoreTenantFn       func(context.Context, *pb.RestoreTenantRequest) (*pb.Tenant, error)
+	GetTenantQuotaFn      func(context.Context, *pb.GetTenantQuotaRequest) (*pb.TenantQuota, error)
+	SetDefaultRoleFn      func(context.Context, *pb.SetDefaultRoleRequest) (*pb.DefaultRole, error)
+	GetDefaultRoleFn      func(context.Context, *pb.GetDefaultRoleRequest) (*pb.DefaultRole, error)
+	GetActivityFn         func(context.Context, *pb.GetActivityRequest) (*pb.GetActivityResponse, error)
 }
 
 // CreateTenant handles the mock CreateTenant
@@ -128,3 +143,143 @@ func (f *FakeTenantServiceServer) CancelRevokeTenant(ctx context.Context, in *pb
 	}
 	return &pb.CancelRevokeTenantResponse{}, nil
 }
+
+// CreateOrganization handles the mock CreateOrganization
+func (f *FakeTenantServiceServer) CreateOrganization(ctx context.Context, in *pb.CreateOrganizationRequest) (*pb.Organization, error) {
+	if f.CreateOrganizationFn != nil {
+		return f.CreateOrganizationFn(ctx, in)
+	}
+	return &pb.Organization{
+		Name: "testname",
+	}, nil
+}
+
+// GetOrganization handles the mock GetOrganization
+func (f *FakeTenantServiceServer) GetOrganization(ctx context.Context, in *pb.GetOrganizationRequest) (*pb.Organization, error) {
+	if f.GetOrganizationFn != nil {
+		return f.GetOrganizationFn(ctx, in)
+	}
+	return &pb.Organization{
+		Name: "testname",
+	}, nil
+}
+
+// DeleteOrganization handles the mock DeleteOrganization
+func (f *FakeTenantServiceServer) DeleteOrganization(ctx context.Context, in *pb.DeleteOrganizationRequest) (*pb.DeleteOrganizationResponse, error) {
+	if f.DeleteOrganizationFn != nil {
+		return f.DeleteOrganizationFn(ctx, in)
+	}
+	return &pb.DeleteOrganizationResponse{}, nil
+}
+
+// ListOrganization handles the mock ListOrganization
+func (f *FakeTenantServiceServer) ListOrganization(ctx context.Context, in *pb.ListOrganizationRequest) (*pb.ListOrganizationResponse, error) {
+	if f.ListOrganizationFn != nil {
+		return f.ListOrganizationFn(ctx, in)
+	}
+	return &pb.ListOrganizationResponse{}, nil
+}
+
+// AllowSdc handles the mock AllowSdc
+func (f *FakeTenantServiceServer) AllowSdc(ctx context.Context, in *pb.AllowSdcRequest) (*pb.Tenant, error) {
+	if f.AllowSdcFn != nil {
+		return f.AllowSdcFn(ctx, in)
+	}
+	return &pb.Tenant{
+		Name: "testname",
+	}, nil
+}
+
+// DisallowSdc handles the mock DisallowSdc
+func (f *FakeTenantServiceServer) DisallowSdc(ctx context.Context, in *pb.DisallowSdcRequest) (*pb.Tenant, error) {
+	if f.DisallowSdcFn != nil {
+		return f.DisallowSdcFn(ctx, in)
+	}
+	return &pb.Tenant{
+		Name: "testname",
+	}, nil
+}
+
+// SetMaxVolumes handles the mock SetMaxVolumes
+func (f *FakeTenantServiceServer) SetMaxVolumes(ctx context.Context, in *pb.SetMaxVolumesRequest) (*pb.Tenant, error) {
+	if f.SetMaxVolumesFn != nil {
+		return f.SetMaxVolumesFn(ctx, in)
+	}
+	return &pb.Tenant{
+		Name: "testname",
+	}, nil
+}
+
+// SetClaims handles the mock SetClaims
+func (f *FakeTenantServiceServer) SetClaims(ctx context.Context, in *pb.SetClaimsRequest) (*pb.Tenant, error) {
+	if f.SetClaimsFn != nil {
+		return f.SetClaimsFn(ctx, in)
+	}
+	return &pb.Tenant{
+		Name: "testname",
+	}, nil
+}
+
+// SetProtected handles the mock SetProtected
+func (f *FakeTenantServiceServer) SetProtected(ctx context.Context, in *pb.SetProtectedRequest) (*pb.Tenant, error) {
+	if f.SetProtectedFn != nil {
+		return f.SetProtectedFn(ctx, in)
+	}
+	return &pb.Tenant{
+		Name: "testname",
+	}, nil
+}
+
+// SetVolumeNamePolicy handles the mock SetVolumeNamePolicy
+func (f *FakeTenantServiceServer) SetVolumeNamePolicy(ctx context.Context, in *pb.SetVolumeNamePolicyRequest) (*pb.Tenant, error) {
+	if f.SetVolumeNamePolicyFn != nil {
+		return f.SetVolumeNamePolicyFn(ctx, in)
+	}
+	return &pb.Tenant{
+		Name: "testname",
+	}, nil
+}
+
+// RestoreTenant handles the mock RestoreTenant
+func (f *FakeTenantServiceServer) RestoreTenant(ctx context.Context, in *pb.RestoreTenantRequest) (*pb.Tenant, error) {
+	if f.RestoreTenantFn != nil {
+		return f.RestoreTenantFn(ctx, in)
+	}
+	return &pb.Tenant{
+		Name: "testname",
+	}, nil
+}
+
+// GetTenantQuota handles the mock GetTenantQuota
+func (f *FakeTenantServiceServer) GetTenantQuota(ctx context.Context, in *pb.GetTenantQuotaRequest) (*pb.TenantQuota, error) {
+	if f.GetTenantQuotaFn != nil {
+		return f.GetTenantQuotaFn(ctx, in)
+	}
+	return &pb.TenantQuota{
+		Name: "testname",
+	}, nil
+}
+
+// SetDefaultRole handles the mock SetDefaultRole
+func (f *FakeTenantServiceServer) SetDefaultRole(ctx context.Context, in *pb.SetDefaultRoleRequest) (*pb.DefaultRole, error) {
+	if f.SetDefaultRoleFn != nil {
+		return f.SetDefaultRoleFn(ctx, in)
+	}
+	return &pb.DefaultRole{}, nil
+}
+
+// GetDefaultRole handles the mock GetDefaultRole
+func (f *FakeTenantServiceServer) GetDefaultRole(ctx context.Context, in *pb.GetDefaultRoleRequest) (*pb.DefaultRole, error) {
+	if f.GetDefaultRoleFn != nil {
+		return f.GetDefaultRoleFn(ctx, in)
+	}
+	return &pb.DefaultRole{}, nil
+}
+
+// GetActivity handles the mock GetActivity
+func (f *FakeTenantServiceServer) GetActivity(ctx context.Context, in *pb.GetActivityRequest) (*pb.GetActivityResponse, error) {
+	if f.GetActivityFn != nil {
+		return f.GetActivityFn(ctx, in)
+	}
+	return &pb.GetActivityResponse{}, nil
+}
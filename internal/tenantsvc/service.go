@@ -18,25 +18,40 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"karavi-authorization/internal/errcode"
+	"karavi-authorization/internal/eventsvc"
 	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/webhook"
 	"karavi-authorization/pb"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-redis/redis"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 // Common errors.
 var (
-	ErrTenantAlreadyExists = status.Error(codes.InvalidArgument, "tenant already exists")
-	ErrTenantNotFound      = status.Error(codes.InvalidArgument, "tenant not found")
-	ErrNilTenant           = status.Error(codes.InvalidArgument, "nil tenant")
-	ErrNoRolesForTenant    = status.Error(codes.InvalidArgument, "tenant has no roles")
-	ErrTenantIsRevoked     = status.Error(codes.InvalidArgument, "tenant has been revoked")
+	ErrTenantAlreadyExists  = errcode.New(codes.AlreadyExists, pb.ErrorCode_TENANT_ALREADY_EXISTS, "tenant already exists")
+	ErrTenantNotFound       = errcode.New(codes.NotFound, pb.ErrorCode_TENANT_NOT_FOUND, "tenant not found")
+	ErrNilTenant            = errcode.New(codes.InvalidArgument, pb.ErrorCode_INVALID_ARGUMENT, "nil tenant")
+	ErrNoRolesForTenant     = errcode.New(codes.FailedPrecondition, pb.ErrorCode_TENANT_HAS_NO_ROLES, "tenant has no roles")
+	ErrTenantIsRevoked      = errcode.New(codes.PermissionDenied, pb.ErrorCode_TENANT_REVOKED, "tenant has been revoked")
+	ErrTokenRevoked         = errcode.New(codes.PermissionDenied, pb.ErrorCode_TOKEN_REVOKED, "token has been revoked")
+	ErrInvalidMaxVolumes    = errcode.New(codes.InvalidArgument, pb.ErrorCode_INVALID_MAX_VOLUMES, "max volumes must not be negative")
+	ErrInvalidVolumePattern = errcode.New(codes.InvalidArgument, pb.ErrorCode_INVALID_VOLUME_PATTERN, "volume pattern must be a valid regular expression")
+	ErrTenantProtected      = errcode.New(codes.FailedPrecondition, pb.ErrorCode_TENANT_PROTECTED, "tenant is protected from deletion")
+	ErrTenantDeleted        = errcode.New(codes.AlreadyExists, pb.ErrorCode_TENANT_DELETED, "a deleted tenant of the same name can still be restored")
+
+	ErrOrganizationAlreadyExists = errcode.New(codes.AlreadyExists, pb.ErrorCode_ORGANIZATION_ALREADY_EXISTS, "organization already exists")
+	ErrOrganizationNotFound      = errcode.New(codes.NotFound, pb.ErrorCode_ORGANIZATION_NOT_FOUND, "organization not found")
+	ErrNilOrganization           = errcode.New(codes.InvalidArgument, pb.ErrorCode_INVALID_ARGUMENT, "nil organization")
+	ErrOrganizationHasTenants    = errcode.New(codes.FailedPrecondition, pb.ErrorCode_ORGANIZATION_HAS_TENANTS, "organization has tenants")
 
 	// JWTSigningSecret is the secret string used to sign JWT tokens
 	JWTSigningSecret = "secret"
@@ -46,7 +61,40 @@ var (
 const (
 	FieldRefreshCount = "refresh_count"
 	FieldCreatedAt    = "created_at"
-	KeyTenantRevoked  = "tenant:revoked"
+	FieldTenantID     = "uuid"
+	FieldOrganization = "organization"
+	FieldMaxVolumes   = "max_volumes"
+	FieldProtected    = "protected"
+	// FieldVolumePrefix and FieldVolumePattern hold the volume name policy
+	// the proxy-server enforces on the volumes the tenant creates.
+	FieldVolumePrefix  = "volume_prefix"
+	FieldVolumePattern = "volume_pattern"
+	KeyTenantRevoked   = "tenant:revoked"
+	KeyDefaultRole     = "tenant:default-role"
+	// KeyTenantDeleted is a sorted set of the tenants that were deleted
+	// but can still be restored, scored by when they are purged.
+	KeyTenantDeleted = "tenant:deleted"
+	// FieldTokensRevokedBefore is the Unix time of the last rotation of the
+	// tokens of the tenant. Refresh tokens issued before it are refused.
+	FieldTokensRevokedBefore = "tokens_revoked_before"
+)
+
+// Parts of the volume name policy of a tenant that a SetVolumeNamePolicy
+// request may remove.
+const (
+	VolumeNamePrefix  = "prefix"
+	VolumeNamePattern = "pattern"
+)
+
+// Fields of the activity of a tenant, kept in tenant:<name>:activity. The
+// counts are of the requests to storage systems that the proxy-server has
+// allowed or denied.
+const (
+	ActivityCreates   = "creates"
+	ActivityDeletes   = "deletes"
+	ActivityMaps      = "maps"
+	ActivityDenials   = "denials"
+	FieldLastActivity = "last_activity"
 )
 
 // TenantService is the gRPC implementation of the TenantServiceServer.
@@ -56,6 +104,12 @@ type TenantService struct {
 	log *logrus.Entry
 	rdb *redis.Client
 	tm  token.Manager
+	// deleteRetention is how long deleted tenants can be restored for.
+	// Tenants are deleted at once if it is 0.
+	deleteRetention time.Duration
+	// hooks are called around the creation and deletion of tenants and
+	// the binding of their roles.
+	hooks *webhook.Hooks
 }
 
 // Option allows for functional option arguments on the TenantService.
@@ -95,6 +149,20 @@ func WithTokenManager(tm token.Manager) func(*TenantService) {
 	}
 }
 
+// WithDeleteRetention sets how long deleted tenants can be restored for.
+func WithDeleteRetention(d time.Duration) func(*TenantService) {
+	return func(t *TenantService) {
+		t.deleteRetention = d
+	}
+}
+
+// WithWebhooks sets the webhooks that are called around tenant changes.
+func WithWebhooks(hooks *webhook.Hooks) func(*TenantService) {
+	return func(t *TenantService) {
+		t.hooks = hooks
+	}
+}
+
 // NewTenantService allocates a new TenantService.
 func NewTenantService(opts ...Option) *TenantService {
 	var t TenantService
@@ -107,9 +175,45 @@ func NewTenantService(opts ...Option) *TenantService {
 	return &t
 }
 
-// CreateTenant handles tenant creation requests.
+// CreateTenant handles tenant creation requests. The default role, if
+// any, is bound to the new tenant unless the request opts out of it.
 func (t *TenantService) CreateTenant(ctx context.Context, req *pb.CreateTenantRequest) (*pb.Tenant, error) {
-	return t.createOrUpdateTenant(ctx, req.Tenant, false)
+	if req.Tenant != nil {
+		err := t.hooks.Before(ctx, webhook.Event{
+			Type:    eventsvc.TypeTenantCreated,
+			Tenant:  req.Tenant.Name,
+			Details: map[string]string{"organization": req.Tenant.Organization},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	tenant, err := t.createOrUpdateTenant(ctx, req.Tenant, false)
+	if err != nil {
+		return nil, err
+	}
+	t.publish(eventsvc.TypeTenantCreated, tenant.Name, "")
+	t.hooks.After(webhook.Event{
+		Type:    eventsvc.TypeTenantCreated,
+		Tenant:  tenant.Name,
+		Details: map[string]string{"organization": tenant.Organization},
+	})
+	if req.NoDefaultRole {
+		return tenant, nil
+	}
+
+	role, err := t.defaultRole()
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return tenant, nil
+	}
+	if _, err := t.BindRole(ctx, &pb.BindRoleRequest{TenantName: tenant.Name, RoleName: role}); err != nil {
+		return nil, err
+	}
+	tenant.Roles = role
+	return tenant, nil
 }
 
 // UpdateTenant handles tenant updation requests.
@@ -182,17 +286,83 @@ func (t *TenantService) GetTenant(_ context.Context, req *pb.GetTenantRequest) (
 		return nil, err
 	}
 
+	sdcs, err := t.rdb.SMembers(tenantSdcsKey(req.Name)).Result()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(sdcs)
+
+	var maxVolumes int64
+	if v, ok := m[FieldMaxVolumes]; ok {
+		maxVolumes, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var protected bool
+	if v, ok := m[FieldProtected]; ok {
+		protected, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	claims, err := t.tenantClaims(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
 	return &pb.Tenant{
-		Name:       req.Name,
-		Roles:      strings.Join(roles, ","),
-		Approvesdc: approvesdc,
+		Name:          req.Name,
+		Roles:         strings.Join(roles, ","),
+		Approvesdc:    approvesdc,
+		Organization:  m[FieldOrganization],
+		AllowedSdcs:   sdcs,
+		MaxVolumes:    maxVolumes,
+		Claims:        claims,
+		Protected:     protected,
+		VolumePrefix:  m[FieldVolumePrefix],
+		VolumePattern: m[FieldVolumePattern],
 	}, nil
 }
 
-// DeleteTenant handles tenant deletion requests.
+// DeleteTenant handles tenant deletion requests. Protected tenants are not
+// deleted. If a retention is set, the tenant is only removed until it is
+// purged, and can be restored until then.
 func (t *TenantService) DeleteTenant(ctx context.Context, req *pb.DeleteTenantRequest) (*pb.DeleteTenantResponse, error) {
 	var emp pb.DeleteTenantResponse
 
+	m, err := t.rdb.HGetAll(tenantKey(req.Name)).Result()
+	if err != nil {
+		return &emp, err
+	}
+	if len(m) == 0 {
+		return nil, ErrTenantNotFound
+	}
+	if protected, _ := strconv.ParseBool(m[FieldProtected]); protected {
+		return nil, ErrTenantProtected
+	}
+	org := m[FieldOrganization]
+	if err := t.hooks.Before(ctx, webhook.Event{Type: eventsvc.TypeTenantDeleted, Tenant: req.Name}); err != nil {
+		return nil, err
+	}
+
+	if t.deleteRetention > 0 {
+		until, err := t.softDeleteTenant(req.Name, org)
+		if err != nil {
+			return &emp, err
+		}
+		restorableUntil := strconv.FormatInt(until.Unix(), 10)
+		t.publish(eventsvc.TypeTenantDeleted, req.Name, "", "restorable_until", restorableUntil)
+		t.hooks.After(webhook.Event{
+			Type:    eventsvc.TypeTenantDeleted,
+			Tenant:  req.Name,
+			Details: map[string]string{"restorable_until": restorableUntil},
+		})
+		return &pb.DeleteTenantResponse{RestorableUntil: until.Unix()}, nil
+	}
+
 	revoked, err := t.CheckRevoked(ctx, req.Name)
 	if err != nil {
 		return &emp, err
@@ -205,21 +375,186 @@ func (t *TenantService) DeleteTenant(ctx context.Context, req *pb.DeleteTenantRe
 		}
 	}
 
-	n, err := t.rdb.Del(tenantKey(req.Name)).Result()
+	_, err = t.rdb.Del(tenantDataKeys(req.Name)...).Result()
 	if err != nil {
 		return &emp, err
 	}
-	if n == 0 {
-		return nil, ErrTenantNotFound
+
+	if org != "" {
+		_, err = t.rdb.SRem(organizationTenantsKey(org), req.Name).Result()
+		if err != nil {
+			return &emp, err
+		}
 	}
 
+	t.publish(eventsvc.TypeTenantDeleted, req.Name, "")
+	t.hooks.After(webhook.Event{Type: eventsvc.TypeTenantDeleted, Tenant: req.Name})
 	return &emp, nil
 }
 
-// ListTenant handles tenant listing requests.
-func (t *TenantService) ListTenant(_ context.Context, _ *pb.ListTenantRequest) (*pb.ListTenantResponse, error) {
+// softDeleteTenant moves the data of a tenant aside until the retention has
+// passed, and returns when it will be purged. Its role bindings, quota data
+// and revocation are kept as they are, so that it can be restored with them.
+func (t *TenantService) softDeleteTenant(name, org string) (time.Time, error) {
+	if err := t.purgeDeletedTenants(); err != nil {
+		return time.Time{}, err
+	}
+
+	for _, k := range tenantDataKeys(name) {
+		if err := t.renameIfExists(k, deletedKey(k)); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	if org != "" {
+		_, err := t.rdb.SRem(organizationTenantsKey(org), name).Result()
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	until := time.Now().Add(t.deleteRetention)
+	_, err := t.rdb.ZAdd(KeyTenantDeleted, redis.Z{Score: float64(until.Unix()), Member: name}).Result()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return until, nil
+}
+
+// RestoreTenant restores a deleted tenant whose retention has not passed.
+func (t *TenantService) RestoreTenant(ctx context.Context, req *pb.RestoreTenantRequest) (*pb.Tenant, error) {
+	if err := t.purgeDeletedTenants(); err != nil {
+		return nil, err
+	}
+	deleted, err := t.isDeleted(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, ErrTenantNotFound
+	}
+
+	org, err := t.rdb.HGet(deletedKey(tenantKey(req.Name)), FieldOrganization).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if org != "" {
+		exists, err := t.rdb.Exists(organizationKey(org)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if exists == 0 {
+			return nil, ErrOrganizationNotFound
+		}
+	}
+
+	for _, k := range tenantDataKeys(req.Name) {
+		if err := t.renameIfExists(deletedKey(k), k); err != nil {
+			return nil, err
+		}
+	}
+
+	if org != "" {
+		_, err = t.rdb.SAdd(organizationTenantsKey(org), req.Name).Result()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = t.rdb.ZRem(KeyTenantDeleted, req.Name).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	t.publish(eventsvc.TypeTenantRestored, req.Name, "")
+	return t.GetTenant(ctx, &pb.GetTenantRequest{Name: req.Name})
+}
+
+// purgeDeletedTenants removes the deleted tenants whose retention has
+// passed, as if they had been deleted at once.
+func (t *TenantService) purgeDeletedTenants() error {
+	names, err := t.rdb.ZRangeByScore(KeyTenantDeleted, redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		id, err := t.rdb.HGet(deletedKey(tenantKey(name)), FieldTenantID).Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		revoked := []interface{}{name}
+		if id != "" {
+			revoked = append(revoked, id)
+		}
+		_, err = t.rdb.SRem(KeyTenantRevoked, revoked...).Result()
+		if err != nil {
+			return err
+		}
+
+		keys := tenantDataKeys(name)
+		for i, k := range keys {
+			keys[i] = deletedKey(k)
+		}
+		_, err = t.rdb.Del(keys...).Result()
+		if err != nil {
+			return err
+		}
+
+		_, err = t.rdb.ZRem(KeyTenantDeleted, name).Result()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isDeleted reports whether the named tenant was deleted and can still be
+// restored.
+func (t *TenantService) isDeleted(name string) (bool, error) {
+	_, err := t.rdb.ZScore(KeyTenantDeleted, name).Result()
+	switch {
+	case err == redis.Nil:
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
+
+func (t *TenantService) renameIfExists(from, to string) error {
+	exists, err := t.rdb.Exists(from).Result()
+	if err != nil || exists == 0 {
+		return err
+	}
+	return t.rdb.Rename(from, to).Err()
+}
+
+// ListTenant handles tenant listing requests. If an organization is given,
+// only the tenants in that organization are listed.
+func (t *TenantService) ListTenant(_ context.Context, req *pb.ListTenantRequest) (*pb.ListTenantResponse, error) {
 	var tenants []*pb.Tenant
 
+	if req.GetOrganization() != "" {
+		names, err := t.rdb.SMembers(organizationTenantsKey(req.Organization)).Result()
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			tenants = append(tenants, &pb.Tenant{
+				Name:         name,
+				Organization: req.Organization,
+			})
+		}
+		return &pb.ListTenantResponse{
+			Tenants: tenants,
+		}, nil
+	}
+
 	var cursor uint64
 	for {
 		// TODO(ian): Store tenants in a Set to avoid the scan.
@@ -246,147 +581,596 @@ func (t *TenantService) ListTenant(_ context.Context, _ *pb.ListTenantRequest) (
 }
 
 // BindRole handles rolebinding creation requests.
-func (t *TenantService) BindRole(_ context.Context, req *pb.BindRoleRequest) (*pb.BindRoleResponse, error) {
+func (t *TenantService) BindRole(ctx context.Context, req *pb.BindRoleRequest) (*pb.BindRoleResponse, error) {
+	e := webhook.Event{Type: eventsvc.TypeRoleBound, Tenant: req.TenantName, Role: req.RoleName}
+	if err := t.hooks.Before(ctx, e); err != nil {
+		return nil, err
+	}
+
 	// Update a set with role -> tenants mappings
 	t.rdb.SAdd(rolesTenantKey(req.RoleName), req.TenantName)
 	// Update a set with tenant -> roles mappings
 	t.rdb.SAdd(tenantRolesKey(req.TenantName), req.RoleName)
 
+	t.publish(eventsvc.TypeRoleBound, req.TenantName, req.RoleName)
+	t.hooks.After(e)
 	return &pb.BindRoleResponse{}, nil
 }
 
 // UnbindRole handles rolebinding deletion requests.
-func (t *TenantService) UnbindRole(_ context.Context, req *pb.UnbindRoleRequest) (*pb.UnbindRoleResponse, error) {
+func (t *TenantService) UnbindRole(ctx context.Context, req *pb.UnbindRoleRequest) (*pb.UnbindRoleResponse, error) {
+	e := webhook.Event{Type: eventsvc.TypeRoleUnbound, Tenant: req.TenantName, Role: req.RoleName}
+	if err := t.hooks.Before(ctx, e); err != nil {
+		return nil, err
+	}
+
 	// Update a set with role -> tenants mappings
 	t.rdb.SRem(rolesTenantKey(req.RoleName), req.TenantName)
 	// Update a set with tenant -> roles mappings
 	t.rdb.SRem(tenantRolesKey(req.TenantName), req.RoleName)
 
+	t.publish(eventsvc.TypeRoleUnbound, req.TenantName, req.RoleName)
+	t.hooks.After(e)
 	return &pb.UnbindRoleResponse{}, nil
 }
 
-// GenerateToken generates a token for a given tenant.  The returned token is
-// in the format of a Kubernetes Secret resource.
-func (t *TenantService) GenerateToken(_ context.Context, req *pb.GenerateTokenRequest) (*pb.GenerateTokenResponse, error) {
-	// Check the tenant exists.
-	exists, err := t.rdb.Exists(tenantKey(req.TenantName)).Result()
-	if err != nil {
+// AllowSdc allows volumes of a tenant to be mapped to the given SDCs, which
+// are identified by their GUIDs or IPs. Once a tenant allows any SDCs, its
+// volumes may only be mapped to those.
+func (t *TenantService) AllowSdc(ctx context.Context, req *pb.AllowSdcRequest) (*pb.Tenant, error) {
+	if err := t.checkTenantExists(req.TenantName); err != nil {
 		return nil, err
 	}
-	if exists == 0 {
-		return nil, ErrTenantNotFound
-	}
 
-	// Get the roles bound to this tenant.
-	roles, err := t.rdb.SMembers(tenantRolesKey(req.TenantName)).Result()
+	_, err := t.rdb.SAdd(tenantSdcsKey(req.TenantName), normalizeSdcs(req.Sdcs)...).Result()
 	if err != nil {
 		return nil, err
 	}
-	if len(roles) == 0 {
-		return nil, ErrNoRolesForTenant
-	}
 
-	// Get the expiration values from config.
-	if req.RefreshTokenTTL <= 0 {
-		req.RefreshTokenTTL = int64(24 * time.Hour)
-	}
-	if req.AccessTokenTTL <= 0 {
-		req.AccessTokenTTL = int64(5 * time.Minute)
+	return t.GetTenant(ctx, &pb.GetTenantRequest{Name: req.TenantName})
+}
+
+// DisallowSdc removes SDCs allowed by AllowSdc. Removing every SDC lifts
+// the restriction.
+func (t *TenantService) DisallowSdc(ctx context.Context, req *pb.DisallowSdcRequest) (*pb.Tenant, error) {
+	if err := t.checkTenantExists(req.TenantName); err != nil {
+		return nil, err
 	}
 
-	// Generate the token.
-	s, err := token.CreateAsK8sSecret(t.tm, token.Config{
-		Tenant:            req.TenantName,
-		Roles:             roles,
-		JWTSigningSecret:  JWTSigningSecret,
-		RefreshExpiration: time.Duration(req.RefreshTokenTTL),
-		AccessExpiration:  time.Duration(req.AccessTokenTTL),
-	})
+	_, err := t.rdb.SRem(tenantSdcsKey(req.TenantName), normalizeSdcs(req.Sdcs)...).Result()
 	if err != nil {
 		return nil, err
 	}
 
-	// Return the token.
-	return &pb.GenerateTokenResponse{
-		Token: s,
-	}, nil
+	return t.GetTenant(ctx, &pb.GetTenantRequest{Name: req.TenantName})
 }
 
-// RefreshToken refreshes a token given a valid refresh and access token.
-// A refresh token is refused if the owning tenant is found to be in the
-// revocation list (tenant:revoked).
-func (t *TenantService) RefreshToken(_ context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
-	refreshToken := req.RefreshToken
-	accessToken := req.AccessToken
-
-	var refreshClaims token.Claims
-	_, err := t.tm.ParseWithClaims(refreshToken, req.JWTSigningSecret, &refreshClaims)
-	if err != nil {
-		return nil, fmt.Errorf("parsing refresh token: %w", err)
+// SetMaxVolumes sets the maximum number of volumes a tenant may have in each
+// storage pool. A maximum of 0 removes the limit.
+func (t *TenantService) SetMaxVolumes(ctx context.Context, req *pb.SetMaxVolumesRequest) (*pb.Tenant, error) {
+	if req.MaxVolumes < 0 {
+		return nil, ErrInvalidMaxVolumes
+	}
+	if err := t.checkTenantExists(req.TenantName); err != nil {
+		return nil, err
 	}
 
-	// Check if the tenant is being denied.
-	ok, err := t.rdb.SIsMember(KeyTenantRevoked, refreshClaims.Group).Result()
+	_, err := t.rdb.HSet(tenantKey(req.TenantName), FieldMaxVolumes, req.MaxVolumes).Result()
 	if err != nil {
-		return nil, fmt.Errorf("checking revoked list: %w", err)
-	}
-	if ok {
-		return nil, ErrTenantIsRevoked
+		return nil, err
 	}
 
-	var accessClaims token.Claims
-	_, err = t.tm.ParseWithClaims(accessToken, req.JWTSigningSecret, &accessClaims)
-	if err == nil {
-		return nil, errors.New("access token was valid")
-	}
+	return t.GetTenant(ctx, &pb.GetTenantRequest{Name: req.TenantName})
+}
 
-	switch err {
-	case token.ErrExpired:
-		t.log.WithField("audience", accessClaims.Audience).Debug("Refreshing token")
-	default:
-		return nil, fmt.Errorf("jwt validation: %w", err)
+// SetProtected sets or removes the protection of a tenant from deletion.
+func (t *TenantService) SetProtected(ctx context.Context, req *pb.SetProtectedRequest) (*pb.Tenant, error) {
+	if err := t.checkTenantExists(req.TenantName); err != nil {
+		return nil, err
 	}
 
-	if tenant := strings.TrimSpace(accessClaims.Subject); tenant == "" {
-		t.log.WithField("tenant", tenant).Debug("invalid tenant")
-		return nil, fmt.Errorf("invalid tenant: %q", tenant)
+	var err error
+	if req.Protected {
+		_, err = t.rdb.HSet(tenantKey(req.TenantName), FieldProtected, strconv.FormatBool(true)).Result()
+	} else {
+		_, err = t.rdb.HDel(tenantKey(req.TenantName), FieldProtected).Result()
 	}
-	_, err = t.rdb.HIncrBy(
-		tenantKey(accessClaims.Group),
-		FieldRefreshCount,
-		1).Result()
 	if err != nil {
-		t.log.WithError(err).Debug("increasing token refresh count")
 		return nil, err
 	}
 
-	// Use the refresh token with a smaller expiration timestamp to be
-	// the new access token.
-	refreshClaims.ExpiresAt = time.Now().Add(30 * time.Second).Unix()
-	newAccess, err := t.tm.NewWithClaims(refreshClaims)
-	if err != nil {
+	return t.GetTenant(ctx, &pb.GetTenantRequest{Name: req.TenantName})
+}
+
+// SetVolumeNamePolicy sets or removes the prefix and the regular expression
+// the names of the volumes a tenant creates must conform to.
+func (t *TenantService) SetVolumeNamePolicy(ctx context.Context, req *pb.SetVolumeNamePolicyRequest) (*pb.Tenant, error) {
+	if _, err := regexp.Compile(req.VolumePattern); err != nil {
+		return nil, ErrInvalidVolumePattern
+	}
+	if err := t.checkTenantExists(req.TenantName); err != nil {
 		return nil, err
 	}
 
-	newAccessStr, err := newAccess.SignedString(req.JWTSigningSecret)
+	set := make(map[string]interface{})
+	if req.VolumePrefix != "" {
+		set[FieldVolumePrefix] = req.VolumePrefix
+	}
+	if req.VolumePattern != "" {
+		set[FieldVolumePattern] = req.VolumePattern
+	}
+	var del []string
+	for _, part := range req.Remove {
+		switch part {
+		case VolumeNamePrefix:
+			del = append(del, FieldVolumePrefix)
+		case VolumeNamePattern:
+			del = append(del, FieldVolumePattern)
+		}
+	}
+
+	_, err := t.rdb.TxPipelined(func(pipe redis.Pipeliner) error {
+		if len(del) > 0 {
+			pipe.HDel(tenantKey(req.TenantName), del...)
+		}
+		if len(set) > 0 {
+			pipe.HMSet(tenantKey(req.TenantName), set)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &pb.RefreshTokenResponse{
-		AccessToken: newAccessStr,
-	}, nil
+	return t.GetTenant(ctx, &pb.GetTenantRequest{Name: req.TenantName})
 }
 
-// RevokeTenant revokes access for the given tenant.
-func (t *TenantService) RevokeTenant(_ context.Context, req *pb.RevokeTenantRequest) (*pb.RevokeTenantResponse, error) {
-	_, err := t.rdb.SAdd(KeyTenantRevoked, req.TenantName).Result()
-	if err != nil {
+// SetClaims adds, replaces or removes the claims of a tenant. The claims
+// are embedded in tokens generated for the tenant, and in access tokens as
+// they are refreshed, so that policies may use them.
+func (t *TenantService) SetClaims(ctx context.Context, req *pb.SetClaimsRequest) (*pb.Tenant, error) {
+	if err := t.checkTenantExists(req.TenantName); err != nil {
 		return nil, err
 	}
 
-	return &pb.RevokeTenantResponse{}, nil
-}
+	if len(req.Remove) > 0 {
+		_, err := t.rdb.HDel(tenantClaimsKey(req.TenantName), req.Remove...).Result()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(req.Claims) > 0 {
+		fields := make(map[string]interface{}, len(req.Claims))
+		for k, v := range req.Claims {
+			fields[k] = v
+		}
+		_, err := t.rdb.HMSet(tenantClaimsKey(req.TenantName), fields).Result()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return t.GetTenant(ctx, &pb.GetTenantRequest{Name: req.TenantName})
+}
+
+// tenantClaims returns the claims of a tenant, or nil if it has none.
+func (t *TenantService) tenantClaims(name string) (map[string]string, error) {
+	claims, err := t.rdb.HGetAll(tenantClaimsKey(name)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(claims) == 0 {
+		return nil, nil
+	}
+	return claims, nil
+}
+
+// SetDefaultRole sets or replaces the role that is bound to tenants when
+// they are created. An empty role name removes the default role. Tenants
+// that already exist are not changed.
+func (t *TenantService) SetDefaultRole(_ context.Context, req *pb.SetDefaultRoleRequest) (*pb.DefaultRole, error) {
+	var err error
+	if req.RoleName == "" {
+		_, err = t.rdb.Del(KeyDefaultRole).Result()
+	} else {
+		_, err = t.rdb.Set(KeyDefaultRole, req.RoleName, 0).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DefaultRole{RoleName: req.RoleName}, nil
+}
+
+// GetDefaultRole returns the role that is bound to tenants when they are
+// created.
+func (t *TenantService) GetDefaultRole(_ context.Context, _ *pb.GetDefaultRoleRequest) (*pb.DefaultRole, error) {
+	role, err := t.defaultRole()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DefaultRole{RoleName: role}, nil
+}
+
+func (t *TenantService) defaultRole() (string, error) {
+	role, err := t.rdb.Get(KeyDefaultRole).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return role, err
+}
+
+// GetTenantQuota returns the capacity and the number of volumes approved for
+// a tenant in each storage pool.
+func (t *TenantService) GetTenantQuota(ctx context.Context, req *pb.GetTenantQuotaRequest) (*pb.TenantQuota, error) {
+	tenant, err := t.GetTenant(ctx, &pb.GetTenantRequest{Name: req.Name})
+	if err != nil {
+		return nil, err
+	}
+	ref, err := t.tenantRef(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &pb.TenantQuota{
+		Name:       req.Name,
+		MaxVolumes: tenant.MaxVolumes,
+	}
+
+	// Quota keys are quota:<type>:<system>:<pool>:<tenant>:<data|stream>.
+	var cursor uint64
+	for {
+		keys, nextCursor, err := t.rdb.Scan(cursor, fmt.Sprintf("quota:*:*:*:%s:data", ref), 100).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			parts := strings.Split(k, ":")
+			if len(parts) != 6 || parts[4] != ref {
+				continue
+			}
+			vals, err := t.rdb.HMGet(k, "approved_capacity", "approved_volumes").Result()
+			if err != nil {
+				return nil, err
+			}
+			usage := &pb.PoolUsage{
+				SystemType: parts[1],
+				SystemId:   parts[2],
+				Pool:       parts[3],
+			}
+			if v, ok := vals[0].(string); ok {
+				if usage.ApprovedCapacity, err = strconv.ParseInt(v, 10, 64); err != nil {
+					return nil, err
+				}
+			}
+			if v, ok := vals[1].(string); ok {
+				if usage.ApprovedVolumes, err = strconv.ParseInt(v, 10, 64); err != nil {
+					return nil, err
+				}
+			}
+			ret.Pools = append(ret.Pools, usage)
+		}
+
+		cursor = nextCursor
+		if cursor == 0 {
+			break
+		}
+	}
+
+	sort.Slice(ret.Pools, func(i, j int) bool {
+		a, b := ret.Pools[i], ret.Pools[j]
+		if a.SystemType != b.SystemType {
+			return a.SystemType < b.SystemType
+		}
+		if a.SystemId != b.SystemId {
+			return a.SystemId < b.SystemId
+		}
+		return a.Pool < b.Pool
+	})
+	return ret, nil
+}
+
+// GetActivity returns the activity of a tenant, or of every tenant if no
+// name is given, so that idle tenants can be found.
+func (t *TenantService) GetActivity(ctx context.Context, req *pb.GetActivityRequest) (*pb.GetActivityResponse, error) {
+	var names []string
+	if req.Name != "" {
+		if err := t.checkTenantExists(req.Name); err != nil {
+			return nil, err
+		}
+		if req.Organization != "" {
+			org, err := t.rdb.HGet(tenantKey(req.Name), FieldOrganization).Result()
+			if err != nil && err != redis.Nil {
+				return nil, err
+			}
+			if org != req.Organization {
+				return nil, ErrTenantNotFound
+			}
+		}
+		names = append(names, req.Name)
+	} else {
+		list, err := t.ListTenant(ctx, &pb.ListTenantRequest{Organization: req.Organization})
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range list.Tenants {
+			names = append(names, v.Name)
+		}
+		sort.Strings(names)
+	}
+
+	resp := &pb.GetActivityResponse{}
+	for _, name := range names {
+		activity, err := t.tenantActivity(name)
+		if err != nil {
+			return nil, err
+		}
+		resp.Tenants = append(resp.Tenants, activity)
+	}
+	return resp, nil
+}
+
+func (t *TenantService) tenantActivity(name string) (*pb.TenantActivity, error) {
+	m, err := t.rdb.HGetAll(tenantActivityKey(name)).Result()
+	if err != nil {
+		return nil, err
+	}
+	refreshes, err := t.rdb.HGet(tenantKey(name), FieldRefreshCount).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	m[FieldRefreshCount] = refreshes
+
+	ret := &pb.TenantActivity{Name: name}
+	for field, dst := range map[string]*int64{
+		ActivityCreates:   &ret.Creates,
+		ActivityDeletes:   &ret.Deletes,
+		ActivityMaps:      &ret.Maps,
+		ActivityDenials:   &ret.Denials,
+		FieldRefreshCount: &ret.Refreshes,
+		FieldLastActivity: &ret.LastActivity,
+	} {
+		if m[field] == "" {
+			continue
+		}
+		if *dst, err = strconv.ParseInt(m[field], 10, 64); err != nil {
+			return nil, fmt.Errorf("parsing %s of tenant %s: %w", field, name, err)
+		}
+	}
+	return ret, nil
+}
+
+// RecordActivity counts a request of the tenant under the activity field,
+// one of the Activity constants, and marks the tenant active at the given
+// time. It is used by the proxy-server, which shares the database.
+func RecordActivity(rdb *redis.Client, tenant, field string, at time.Time) error {
+	key := tenantActivityKey(tenant)
+	_, err := rdb.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(key, field, 1)
+		pipe.HSet(key, FieldLastActivity, at.Unix())
+		return nil
+	})
+	return err
+}
+
+func (t *TenantService) checkTenantExists(name string) error {
+	exists, err := t.rdb.Exists(tenantKey(name)).Result()
+	if err != nil {
+		return err
+	}
+	if exists == 0 {
+		return ErrTenantNotFound
+	}
+	return nil
+}
+
+// normalizeSdcs lower cases SDC GUIDs so that they match regardless of case.
+func normalizeSdcs(sdcs []string) []interface{} {
+	ret := make([]interface{}, len(sdcs))
+	for i, v := range sdcs {
+		ret[i] = strings.ToLower(strings.TrimSpace(v))
+	}
+	return ret
+}
+
+// GenerateToken generates a token for a given tenant.  The returned token is
+// in the format of a Kubernetes Secret resource.
+func (t *TenantService) GenerateToken(_ context.Context, req *pb.GenerateTokenRequest) (*pb.GenerateTokenResponse, error) {
+	// Check the tenant exists.
+	exists, err := t.rdb.Exists(tenantKey(req.TenantName)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		return nil, ErrTenantNotFound
+	}
+
+	// Get the roles bound to this tenant.
+	roles, err := t.rdb.SMembers(tenantRolesKey(req.TenantName)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, ErrNoRolesForTenant
+	}
+
+	tenantID, err := t.tenantID(req.TenantName)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := t.tenantClaims(req.TenantName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the expiration values from config.
+	if req.RefreshTokenTTL <= 0 {
+		req.RefreshTokenTTL = int64(24 * time.Hour)
+	}
+	if req.AccessTokenTTL <= 0 {
+		req.AccessTokenTTL = int64(5 * time.Minute)
+	}
+
+	// Refuse the refresh tokens issued before this one.
+	if req.RevokePrevious {
+		_, err = t.rdb.HSet(tenantKey(req.TenantName), FieldTokensRevokedBefore, time.Now().Unix()).Result()
+		if err != nil {
+			return nil, err
+		}
+		t.publish(eventsvc.TypeTokenRevoked, req.TenantName, "", "reason", "tokens rotated")
+	}
+
+	// Generate the token.
+	s, err := token.CreateAsK8sSecret(t.tm, token.Config{
+		Tenant:            req.TenantName,
+		TenantID:          tenantID,
+		Roles:             roles,
+		Claims:            claims,
+		JWTSigningSecret:  JWTSigningSecret,
+		RefreshExpiration: time.Duration(req.RefreshTokenTTL),
+		AccessExpiration:  time.Duration(req.AccessTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the token.
+	return &pb.GenerateTokenResponse{
+		Token: s,
+	}, nil
+}
+
+// RefreshToken refreshes a token given a valid refresh and access token.
+// A refresh token is refused if the owning tenant is found to be in the
+// revocation list (tenant:revoked).
+func (t *TenantService) RefreshToken(_ context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	refreshToken := req.RefreshToken
+	accessToken := req.AccessToken
+
+	var refreshClaims token.Claims
+	_, err := t.tm.ParseWithClaims(refreshToken, req.JWTSigningSecret, &refreshClaims)
+	if err != nil {
+		return nil, fmt.Errorf("parsing refresh token: %w", err)
+	}
+	if refreshClaims.Legacy {
+		t.log.WithField("tenant", refreshClaims.Group).Warn("Deprecated: refreshing a token in the legacy format; upgrade the sidecar of the tenant")
+	}
+
+	// Tokens issued before the tenant was assigned a UUID are resolved by
+	// name, and the refreshed access token carries the UUID from then on.
+	// A token for a previous tenant of the same name is refused.
+	tenantID, err := t.tenantID(refreshClaims.Group)
+	if err != nil {
+		return nil, fmt.Errorf("resolving tenant: %w", err)
+	}
+	switch {
+	case refreshClaims.TenantID == "":
+		refreshClaims.TenantID = tenantID
+	case tenantID != "" && refreshClaims.TenantID != tenantID:
+		return nil, ErrTenantNotFound
+	}
+
+	// Deleted tenants may be restored, but are refused until then.
+	deleted, err := t.isDeleted(refreshClaims.Group)
+	if err != nil {
+		return nil, fmt.Errorf("checking deleted tenants: %w", err)
+	}
+	if deleted {
+		return nil, ErrTenantNotFound
+	}
+
+	// Check if the tenant is being denied.
+	ok, err := t.rdb.SIsMember(KeyTenantRevoked, refreshClaims.TenantKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("checking revoked list: %w", err)
+	}
+	if ok {
+		return nil, ErrTenantIsRevoked
+	}
+
+	// Check if the token was issued before the tokens of the tenant were
+	// rotated.
+	revokedBefore, err := t.rdb.HGet(tenantKey(refreshClaims.Group), FieldTokensRevokedBefore).Int64()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("checking revoked tokens: %w", err)
+	}
+	if refreshClaims.IssuedAt < revokedBefore {
+		return nil, ErrTokenRevoked
+	}
+
+	// Refresh the claims too, so that changes to them take effect without
+	// generating a new token.
+	claims, err := t.tenantClaims(refreshClaims.Group)
+	if err != nil {
+		return nil, fmt.Errorf("getting tenant claims: %w", err)
+	}
+	refreshClaims.Custom = claims
+
+	var accessClaims token.Claims
+	_, err = t.tm.ParseWithClaims(accessToken, req.JWTSigningSecret, &accessClaims)
+	if err == nil {
+		return nil, errors.New("access token was valid")
+	}
+
+	switch err {
+	case token.ErrExpired:
+		t.log.WithField("audience", accessClaims.Audience).Debug("Refreshing token")
+	default:
+		return nil, fmt.Errorf("jwt validation: %w", err)
+	}
+
+	if tenant := strings.TrimSpace(accessClaims.Subject); tenant == "" {
+		t.log.WithField("tenant", tenant).Debug("invalid tenant")
+		return nil, fmt.Errorf("invalid tenant: %q", tenant)
+	}
+	_, err = t.rdb.HIncrBy(
+		tenantKey(accessClaims.Group),
+		FieldRefreshCount,
+		1).Result()
+	if err != nil {
+		t.log.WithError(err).Debug("increasing token refresh count")
+		return nil, err
+	}
+	_, err = t.rdb.HSet(tenantActivityKey(accessClaims.Group), FieldLastActivity, time.Now().Unix()).Result()
+	if err != nil {
+		t.log.WithError(err).Debug("updating tenant last activity")
+		return nil, err
+	}
+
+	// Use the refresh token with a smaller expiration timestamp to be
+	// the new access token.
+	refreshClaims.ExpiresAt = time.Now().Add(30 * time.Second).Unix()
+	newAccess, err := t.tm.NewWithClaims(refreshClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	newAccessStr, err := newAccess.SignedString(req.JWTSigningSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RefreshTokenResponse{
+		AccessToken: newAccessStr,
+	}, nil
+}
+
+// RevokeTenant revokes access for the given tenant.
+func (t *TenantService) RevokeTenant(_ context.Context, req *pb.RevokeTenantRequest) (*pb.RevokeTenantResponse, error) {
+	ref, err := t.tenantRef(req.TenantName)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = t.rdb.SAdd(KeyTenantRevoked, ref).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	t.publish(eventsvc.TypeTokenRevoked, req.TenantName, "", "reason", "tenant revoked")
+	return &pb.RevokeTenantResponse{}, nil
+}
 
 // CancelRevokeTenant cancels the revocation of access for the given tenant.
 func (t *TenantService) CancelRevokeTenant(_ context.Context, req *pb.CancelRevokeTenantRequest) (*pb.CancelRevokeTenantResponse, error) {
@@ -399,7 +1183,13 @@ func (t *TenantService) CancelRevokeTenant(_ context.Context, req *pb.CancelRevo
 }
 
 func (t *TenantService) cancelRevokeTenant(name string) error {
-	_, err := t.rdb.SRem(KeyTenantRevoked, name).Result()
+	ref, err := t.tenantRef(name)
+	if err != nil {
+		return err
+	}
+
+	// Also remove the name, in case it was revoked before the migration.
+	_, err = t.rdb.SRem(KeyTenantRevoked, ref, name).Result()
 	if err != nil {
 		return err
 	}
@@ -409,17 +1199,35 @@ func (t *TenantService) cancelRevokeTenant(name string) error {
 
 // CheckRevoked checks to see if the given Tenant has had their access revoked.
 func (t *TenantService) CheckRevoked(_ context.Context, tenantName string) (bool, error) {
-	b, err := t.rdb.SIsMember(KeyTenantRevoked, tenantName).Result()
+	ref, err := t.tenantRef(tenantName)
+	if err != nil {
+		return false, err
+	}
+
+	b, err := t.rdb.SIsMember(KeyTenantRevoked, ref).Result()
 	if err != nil {
 		return false, err
 	}
 	return b, nil
 }
 
+// publish publishes an event of a change the service made. The change is not
+// undone if the event cannot be published.
+func (t *TenantService) publish(typ, tenant, role string, details ...string) {
+	p := eventsvc.Publisher{
+		Redis: func() *redis.Client { return t.rdb },
+		Log:   t.log,
+	}
+	p.Publish(typ, tenant, role, details...)
+}
+
 func (t *TenantService) createOrUpdateTenant(_ context.Context, v *pb.Tenant, isUpdate bool) (*pb.Tenant, error) {
 	if v == nil {
 		return nil, ErrNilTenant
 	}
+	if v.MaxVolumes < 0 {
+		return nil, ErrInvalidMaxVolumes
+	}
 
 	exists, err := t.rdb.Exists(tenantKey(v.Name)).Result()
 	if err != nil {
@@ -431,6 +1239,28 @@ func (t *TenantService) createOrUpdateTenant(_ context.Context, v *pb.Tenant, is
 	if !isUpdate && exists == 1 {
 		return nil, ErrTenantAlreadyExists
 	}
+	if !isUpdate {
+		if err := t.purgeDeletedTenants(); err != nil {
+			return nil, err
+		}
+		deleted, err := t.isDeleted(v.Name)
+		if err != nil {
+			return nil, err
+		}
+		if deleted {
+			return nil, ErrTenantDeleted
+		}
+	}
+
+	if v.Organization != "" {
+		exists, err := t.rdb.Exists(organizationKey(v.Organization)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if exists == 0 {
+			return nil, ErrOrganizationNotFound
+		}
+	}
 
 	_, err = t.rdb.HSet(tenantKey(v.Name), FieldCreatedAt, time.Now().Unix()).Result()
 	if err != nil {
@@ -442,17 +1272,272 @@ func (t *TenantService) createOrUpdateTenant(_ context.Context, v *pb.Tenant, is
 		return nil, err
 	}
 
+	if !isUpdate {
+		_, err = t.rdb.HSet(tenantKey(v.Name), FieldTenantID, uuid.New().String()).Result()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if v.MaxVolumes > 0 {
+		_, err = t.rdb.HSet(tenantKey(v.Name), FieldMaxVolumes, v.MaxVolumes).Result()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if v.Organization != "" {
+		_, err = t.rdb.HSet(tenantKey(v.Name), FieldOrganization, v.Organization).Result()
+		if err != nil {
+			return nil, err
+		}
+		_, err = t.rdb.SAdd(organizationTenantsKey(v.Organization), v.Name).Result()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !isUpdate && len(v.Claims) > 0 {
+		fields := make(map[string]interface{}, len(v.Claims))
+		for k, c := range v.Claims {
+			fields[k] = c
+		}
+		_, err = t.rdb.HMSet(tenantClaimsKey(v.Name), fields).Result()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &pb.Tenant{
-		Name:       v.Name,
-		Roles:      v.Roles,
-		Approvesdc: v.Approvesdc,
+		Name:         v.Name,
+		Roles:        v.Roles,
+		Approvesdc:   v.Approvesdc,
+		Organization: v.Organization,
+		MaxVolumes:   v.MaxVolumes,
+		Claims:       v.Claims,
 	}, nil
 }
 
+// CreateOrganization handles organization creation requests.
+func (t *TenantService) CreateOrganization(_ context.Context, req *pb.CreateOrganizationRequest) (*pb.Organization, error) {
+	if req.Organization == nil {
+		return nil, ErrNilOrganization
+	}
+
+	created, err := t.rdb.HSetNX(organizationKey(req.Organization.Name), FieldCreatedAt, time.Now().Unix()).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrOrganizationAlreadyExists
+	}
+
+	return &pb.Organization{
+		Name: req.Organization.Name,
+	}, nil
+}
+
+// GetOrganization handles organization query requests.
+func (t *TenantService) GetOrganization(_ context.Context, req *pb.GetOrganizationRequest) (*pb.Organization, error) {
+	exists, err := t.rdb.Exists(organizationKey(req.Name)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		return nil, ErrOrganizationNotFound
+	}
+
+	tenants, err := t.rdb.SMembers(organizationTenantsKey(req.Name)).Result()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(tenants)
+
+	return &pb.Organization{
+		Name:    req.Name,
+		Tenants: tenants,
+	}, nil
+}
+
+// DeleteOrganization handles organization deletion requests. An
+// organization can only be deleted once its tenants have been deleted.
+func (t *TenantService) DeleteOrganization(_ context.Context, req *pb.DeleteOrganizationRequest) (*pb.DeleteOrganizationResponse, error) {
+	n, err := t.rdb.SCard(organizationTenantsKey(req.Name)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		return nil, ErrOrganizationHasTenants
+	}
+
+	n, err = t.rdb.Del(organizationKey(req.Name)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrOrganizationNotFound
+	}
+
+	return &pb.DeleteOrganizationResponse{}, nil
+}
+
+// ListOrganization handles organization listing requests.
+func (t *TenantService) ListOrganization(_ context.Context, _ *pb.ListOrganizationRequest) (*pb.ListOrganizationResponse, error) {
+	var orgs []*pb.Organization
+
+	var cursor uint64
+	for {
+		keys, nextCursor, err := t.rdb.Scan(cursor, "organization:*:data", 10).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range keys {
+			split := strings.Split(v, ":")
+			orgs = append(orgs, &pb.Organization{
+				Name: split[1],
+			})
+		}
+
+		cursor = nextCursor
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return &pb.ListOrganizationResponse{
+		Organizations: orgs,
+	}, nil
+}
+
+// MigrateTenantIDs assigns a UUID to tenants created before tenants were
+// assigned one, and moves their quota and revocation data from keys based
+// on the tenant name to keys based on the UUID. It is safe to run again.
+func (t *TenantService) MigrateTenantIDs(_ context.Context) error {
+	var cursor uint64
+	for {
+		keys, nextCursor, err := t.rdb.Scan(cursor, "tenant:*:data", 10).Result()
+		if err != nil {
+			return err
+		}
+		for _, v := range keys {
+			name := strings.Split(v, ":")[1]
+			if err := t.migrateTenantID(name); err != nil {
+				return fmt.Errorf("migrating tenant %s: %w", name, err)
+			}
+		}
+
+		cursor = nextCursor
+		if cursor == 0 {
+			break
+		}
+	}
+	return nil
+}
+
+func (t *TenantService) migrateTenantID(name string) error {
+	assigned, err := t.rdb.HSetNX(tenantKey(name), FieldTenantID, uuid.New().String()).Result()
+	if err != nil {
+		return err
+	}
+	tenantID, err := t.tenantID(name)
+	if err != nil {
+		return err
+	}
+	if assigned {
+		t.log.WithField("tenant", name).WithField("uuid", tenantID).Info("assigned tenant UUID")
+	}
+
+	// Quota keys are quota:<type>:<system>:<pool>:<tenant>:<data|stream>.
+	var cursor uint64
+	for {
+		keys, nextCursor, err := t.rdb.Scan(cursor, fmt.Sprintf("quota:*:*:*:%s:*", name), 100).Result()
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			parts := strings.Split(k, ":")
+			if len(parts) != 6 || parts[4] != name {
+				continue
+			}
+			parts[4] = tenantID
+			renamed, err := t.rdb.RenameNX(k, strings.Join(parts, ":")).Result()
+			if err != nil {
+				return err
+			}
+			if !renamed {
+				t.log.WithField("key", k).Warn("quota data already exists for tenant UUID, leaving name-based key")
+			}
+		}
+
+		cursor = nextCursor
+		if cursor == 0 {
+			break
+		}
+	}
+
+	revoked, err := t.rdb.SIsMember(KeyTenantRevoked, name).Result()
+	if err != nil {
+		return err
+	}
+	if revoked {
+		if _, err := t.rdb.SAdd(KeyTenantRevoked, tenantID).Result(); err != nil {
+			return err
+		}
+		if _, err := t.rdb.SRem(KeyTenantRevoked, name).Result(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tenantID returns the UUID of the named tenant, or an empty string if it
+// has not been assigned one.
+func (t *TenantService) tenantID(name string) (string, error) {
+	id, err := t.rdb.HGet(tenantKey(name), FieldTenantID).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return id, err
+}
+
+// tenantRef returns the identifier that the named tenant's quota and
+// revocation data is keyed by: its UUID, or its name if it has none.
+func (t *TenantService) tenantRef(name string) (string, error) {
+	id, err := t.tenantID(name)
+	if err != nil || id != "" {
+		return id, err
+	}
+	return name, nil
+}
+
 func tenantKey(name string) string {
 	return fmt.Sprintf("tenant:%s:data", name)
 }
 
+// tenantDataKeys returns the keys of the data of the named tenant that are
+// removed when it is deleted.
+func tenantDataKeys(name string) []string {
+	return []string{tenantKey(name), tenantSdcsKey(name), tenantActivityKey(name), tenantClaimsKey(name)}
+}
+
+// deletedKey returns the key that key is moved to while its tenant is
+// deleted but can be restored.
+func deletedKey(key string) string {
+	return "deleted:" + key
+}
+
+func tenantActivityKey(name string) string {
+	return fmt.Sprintf("tenant:%s:activity", name)
+}
+
+func tenantSdcsKey(name string) string {
+	return fmt.Sprintf("tenant:%s:sdcs", name)
+}
+
+func tenantClaimsKey(name string) string {
+	return fmt.Sprintf("tenant:%s:claims", name)
+}
+
 func tenantRolesKey(name string) string {
 	return fmt.Sprintf("tenant:%s:roles", name)
 }
@@ -460,3 +1545,11 @@ func tenantRolesKey(name string) string {
 func rolesTenantKey(name string) string {
 	return fmt.Sprintf("role:%s:tenants", name)
 }
+
+func organizationKey(name string) string {
+	return fmt.Sprintf("organization:%s:data", name)
+}
+
+func organizationTenantsKey(name string) string {
+	return fmt.Sprintf("organization:%s:tenants", name)
+}
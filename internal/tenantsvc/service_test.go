@@ -17,17 +17,29 @@ package tenantsvc_test
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"karavi-authorization/internal/errcode"
+	"karavi-authorization/internal/quota"
 	"karavi-authorization/internal/tenantsvc"
+	"karavi-authorization/internal/token"
 	"karavi-authorization/internal/token/jwx"
+	"karavi-authorization/internal/webhook"
 	"karavi-authorization/pb"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis"
 	"github.com/orlangure/gnomock"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
 	"sigs.k8s.io/yaml"
 )
 
@@ -50,13 +62,88 @@ func TestTenantService(t *testing.T) {
 	t.Run("UpdateTenant", testUpdateTenant(sut, afterFn))
 	t.Run("GetTenant", testGetTenant(sut, rdb, afterFn))
 	t.Run("DeleteTenant", testDeleteTenant(sut, afterFn))
+	t.Run("SoftDeleteTenant", testSoftDeleteTenant(rdb, afterFn))
 	t.Run("ListTenant", testListTenant(sut, rdb, afterFn))
 	t.Run("BindRole", testBindRole(sut, rdb, afterFn))
 	t.Run("UnbindRole", testUnbindRole(sut, rdb, afterFn))
+	t.Run("AllowSdc", testAllowSdc(sut, rdb, afterFn))
+	t.Run("Claims", testClaims(sut, rdb, afterFn))
+	t.Run("TenantQuota", testTenantQuota(sut, rdb, afterFn))
+	t.Run("Activity", testActivity(sut, rdb, afterFn))
+	t.Run("DefaultRole", testDefaultRole(sut, rdb, afterFn))
 	t.Run("GenerateToken", testGenerateToken(sut, rdb, afterFn))
 	t.Run("RefreshToken", testRefreshToken(sut, rdb, afterFn))
 	t.Run("RevokeTenant", testRevokeTenant(sut, rdb, afterFn))
 	t.Run("CancelRevokeTenant", testCancelRevokeTenant(sut, rdb, afterFn))
+	t.Run("MigrateTenantIDs", testMigrateTenantIDs(sut, rdb, afterFn))
+	t.Run("Organization", testOrganization(sut, rdb, afterFn))
+}
+
+func TestTenantService_Webhooks(t *testing.T) {
+	mr, err := miniredis.Run()
+	checkError(t, err)
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	// The pre hook vetoes every change of the "denied" tenant.
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e webhook.Event
+		checkError(t, json.NewDecoder(r.Body).Decode(&e))
+		calls = append(calls, e.Phase+" "+e.Type+" "+e.Tenant)
+		if e.Tenant == "denied" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+	hooks, err := webhook.New(logrus.NewEntry(logrus.New()), []webhook.Config{
+		{URL: srv.URL, Phase: webhook.PhasePre},
+		{URL: srv.URL, Phase: webhook.PhasePost, Events: []string{"tenant.created"}},
+	})
+	checkError(t, err)
+	sut := tenantsvc.NewTenantService(
+		tenantsvc.WithRedis(rdb),
+		tenantsvc.WithWebhooks(hooks))
+
+	t.Run("it calls the hooks around a change", func(t *testing.T) {
+		calls = nil
+
+		_, err := sut.CreateTenant(context.Background(), &pb.CreateTenantRequest{Tenant: &pb.Tenant{Name: "allowed"}})
+		checkError(t, err)
+		hooks.Wait()
+
+		want := []string{"pre tenant.created allowed", "post tenant.created allowed"}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("got calls %v, want %v", calls, want)
+		}
+	})
+	t.Run("it does not make a vetoed change", func(t *testing.T) {
+		calls = nil
+
+		_, err := sut.CreateTenant(context.Background(), &pb.CreateTenantRequest{Tenant: &pb.Tenant{Name: "denied"}})
+		hooks.Wait()
+
+		if got := errcode.Code(err); got != pb.ErrorCode_PERMISSION_DENIED {
+			t.Errorf("got code %v, want %v", got, pb.ErrorCode_PERMISSION_DENIED)
+		}
+		if mr.Exists("tenant:denied:data") {
+			t.Error("expected the tenant not to be created")
+		}
+		if want := []string{"pre tenant.created denied"}; !reflect.DeepEqual(calls, want) {
+			t.Errorf("got calls %v, want %v", calls, want)
+		}
+	})
+	t.Run("it vetoes role bindings", func(t *testing.T) {
+		_, err := sut.BindRole(context.Background(), &pb.BindRoleRequest{TenantName: "denied", RoleName: "role"})
+
+		if got := errcode.Code(err); got != pb.ErrorCode_PERMISSION_DENIED {
+			t.Errorf("got code %v, want %v", got, pb.ErrorCode_PERMISSION_DENIED)
+		}
+		if mr.Exists("tenant:denied:roles") {
+			t.Error("expected the role not to be bound")
+		}
+	})
 }
 
 func testCreateTenant(sut *tenantsvc.TenantService, afterFn AfterFunc) func(*testing.T) {
@@ -77,6 +164,24 @@ func testCreateTenant(sut *tenantsvc.TenantService, afterFn AfterFunc) func(*tes
 				t.Errorf("CreateTenant: got name = %q, want %q", got.Name, wantName)
 			}
 		})
+		t.Run("it stores the claims of a tenant", func(t *testing.T) {
+			defer afterFn()
+
+			want := map[string]string{"bootstrapCluster": "cluster-1"}
+			_, err := sut.CreateTenant(context.Background(), &pb.CreateTenantRequest{
+				Tenant: &pb.Tenant{
+					Name:   "tenant",
+					Claims: want,
+				},
+			})
+			checkError(t, err)
+
+			got, err := sut.GetTenant(context.Background(), &pb.GetTenantRequest{Name: "tenant"})
+			checkError(t, err)
+			if !reflect.DeepEqual(got.Claims, want) {
+				t.Errorf("CreateTenant: got claims = %v, want %v", got.Claims, want)
+			}
+		})
 		t.Run("it errors on a duplicate tenant", func(t *testing.T) {
 			first, err := sut.CreateTenant(context.Background(), &pb.CreateTenantRequest{
 				Tenant: &pb.Tenant{
@@ -273,6 +378,127 @@ func testDeleteTenant(sut *tenantsvc.TenantService, afterFn AfterFunc) func(*tes
 				t.Errorf("DeleteTenant: got err %v, want %v", gotErr, wantErr)
 			}
 		})
+		t.Run("it does not delete a protected tenant", func(t *testing.T) {
+			defer afterFn()
+			name := "testname"
+			createTenant(t, sut, tenantConfig{Name: name})
+
+			got, err := sut.SetProtected(context.Background(), &pb.SetProtectedRequest{TenantName: name, Protected: true})
+			checkError(t, err)
+			if !got.Protected {
+				t.Errorf("SetProtected: got %v, want protected", got)
+			}
+
+			_, gotErr := sut.DeleteTenant(context.Background(), &pb.DeleteTenantRequest{Name: name})
+			if gotErr != tenantsvc.ErrTenantProtected {
+				t.Errorf("DeleteTenant: got err %v, want %v", gotErr, tenantsvc.ErrTenantProtected)
+			}
+
+			_, err = sut.SetProtected(context.Background(), &pb.SetProtectedRequest{TenantName: name})
+			checkError(t, err)
+			_, err = sut.DeleteTenant(context.Background(), &pb.DeleteTenantRequest{Name: name})
+			checkError(t, err)
+		})
+	}
+}
+
+func testSoftDeleteTenant(rdb *redis.Client, afterFn AfterFunc) func(*testing.T) {
+	return func(t *testing.T) {
+		sut := tenantsvc.NewTenantService(
+			tenantsvc.WithRedis(rdb),
+			tenantsvc.WithTokenManager(jwx.NewTokenManager(jwx.HS256)),
+			tenantsvc.WithDeleteRetention(time.Hour))
+
+		t.Run("it restores a deleted tenant with its bindings", func(t *testing.T) {
+			defer afterFn()
+			name := "testname"
+			createOrganization(t, sut, "org-1")
+			createTenant(t, sut, tenantConfig{Name: name, Roles: "role-1", Organization: "org-1"})
+			_, err := sut.SetClaims(context.Background(), &pb.SetClaimsRequest{TenantName: name, Claims: map[string]string{"team": "payments"}})
+			checkError(t, err)
+
+			resp, err := sut.DeleteTenant(context.Background(), &pb.DeleteTenantRequest{Name: name})
+			checkError(t, err)
+			if until := time.Unix(resp.RestorableUntil, 0); until.Before(time.Now().Add(59 * time.Minute)) {
+				t.Errorf("DeleteTenant: got restorable until %v, want about an hour from now", until)
+			}
+
+			_, gotErr := sut.GetTenant(context.Background(), &pb.GetTenantRequest{Name: name})
+			if gotErr != tenantsvc.ErrTenantNotFound {
+				t.Errorf("GetTenant: got err %v, want %v", gotErr, tenantsvc.ErrTenantNotFound)
+			}
+			list, err := sut.ListTenant(context.Background(), &pb.ListTenantRequest{})
+			checkError(t, err)
+			if len(list.Tenants) != 0 {
+				t.Errorf("ListTenant: got %v, want no tenants", list.Tenants)
+			}
+			_, gotErr = sut.CreateTenant(context.Background(), &pb.CreateTenantRequest{Tenant: &pb.Tenant{Name: name}})
+			if gotErr != tenantsvc.ErrTenantDeleted {
+				t.Errorf("CreateTenant: got err %v, want %v", gotErr, tenantsvc.ErrTenantDeleted)
+			}
+
+			got, err := sut.RestoreTenant(context.Background(), &pb.RestoreTenantRequest{Name: name})
+			checkError(t, err)
+
+			if got.Roles != "role-1" || got.Organization != "org-1" || got.Claims["team"] != "payments" {
+				t.Errorf("RestoreTenant: got %v", got)
+			}
+			org, err := sut.GetOrganization(context.Background(), &pb.GetOrganizationRequest{Name: "org-1"})
+			checkError(t, err)
+			if !reflect.DeepEqual(org.Tenants, []string{name}) {
+				t.Errorf("GetOrganization: got tenants %v, want %v", org.Tenants, []string{name})
+			}
+		})
+		t.Run("it keeps the revocation of a deleted tenant", func(t *testing.T) {
+			defer afterFn()
+			name := "testname"
+			createTenant(t, sut, tenantConfig{Name: name, Revoked: true})
+
+			_, err := sut.DeleteTenant(context.Background(), &pb.DeleteTenantRequest{Name: name})
+			checkError(t, err)
+			_, err = sut.RestoreTenant(context.Background(), &pb.RestoreTenantRequest{Name: name})
+			checkError(t, err)
+
+			revoked, err := sut.CheckRevoked(context.Background(), name)
+			checkError(t, err)
+			if !revoked {
+				t.Error("CheckRevoked: got false, want true")
+			}
+		})
+		t.Run("it purges a tenant once the retention has passed", func(t *testing.T) {
+			defer afterFn()
+			name := "testname"
+			createTenant(t, sut, tenantConfig{Name: name, Revoked: true})
+			_, err := sut.DeleteTenant(context.Background(), &pb.DeleteTenantRequest{Name: name})
+			checkError(t, err)
+
+			_, err = rdb.ZAdd(tenantsvc.KeyTenantDeleted, redis.Z{Score: float64(time.Now().Add(-time.Minute).Unix()), Member: name}).Result()
+			checkError(t, err)
+
+			_, gotErr := sut.RestoreTenant(context.Background(), &pb.RestoreTenantRequest{Name: name})
+			if gotErr != tenantsvc.ErrTenantNotFound {
+				t.Errorf("RestoreTenant: got err %v, want %v", gotErr, tenantsvc.ErrTenantNotFound)
+			}
+			keys, err := rdb.Keys("deleted:*").Result()
+			checkError(t, err)
+			if len(keys) != 0 {
+				t.Errorf("got keys %v, want none", keys)
+			}
+			revoked, err := rdb.SMembers(tenantsvc.KeyTenantRevoked).Result()
+			checkError(t, err)
+			if len(revoked) != 0 {
+				t.Errorf("got revoked %v, want none", revoked)
+			}
+			createTenant(t, sut, tenantConfig{Name: name})
+		})
+		t.Run("it errors on restoring a tenant that was not deleted", func(t *testing.T) {
+			defer afterFn()
+
+			_, gotErr := sut.RestoreTenant(context.Background(), &pb.RestoreTenantRequest{Name: "doesnotexist"})
+			if gotErr != tenantsvc.ErrTenantNotFound {
+				t.Errorf("RestoreTenant: got err %v, want %v", gotErr, tenantsvc.ErrTenantNotFound)
+			}
+		})
 	}
 }
 
@@ -326,6 +552,426 @@ func testUnbindRole(sut *tenantsvc.TenantService, _ *redis.Client, afterFn After
 	}
 }
 
+func testAllowSdc(sut *tenantsvc.TenantService, rdb *redis.Client, afterFn AfterFunc) func(*testing.T) {
+	return func(t *testing.T) {
+		t.Run("it allows and disallows sdcs", func(t *testing.T) {
+			defer afterFn()
+			createTenant(t, sut, tenantConfig{Name: "tenant-1"})
+
+			got, err := sut.AllowSdc(context.Background(), &pb.AllowSdcRequest{
+				TenantName: "tenant-1",
+				Sdcs:       []string{"0E7A082862FEDF0F", "10.0.0.1"},
+			})
+			checkError(t, err)
+
+			want := []string{"0e7a082862fedf0f", "10.0.0.1"}
+			if !reflect.DeepEqual(got.AllowedSdcs, want) {
+				t.Errorf("AllowSdc: got sdcs = %v, want %v", got.AllowedSdcs, want)
+			}
+
+			got, err = sut.DisallowSdc(context.Background(), &pb.DisallowSdcRequest{
+				TenantName: "tenant-1",
+				Sdcs:       []string{"10.0.0.1"},
+			})
+			checkError(t, err)
+
+			want = []string{"0e7a082862fedf0f"}
+			if !reflect.DeepEqual(got.AllowedSdcs, want) {
+				t.Errorf("DisallowSdc: got sdcs = %v, want %v", got.AllowedSdcs, want)
+			}
+		})
+		t.Run("it errors on a non-existent tenant", func(t *testing.T) {
+			defer afterFn()
+
+			_, err := sut.AllowSdc(context.Background(), &pb.AllowSdcRequest{
+				TenantName: "tenant-1",
+				Sdcs:       []string{"10.0.0.1"},
+			})
+
+			if err != tenantsvc.ErrTenantNotFound {
+				t.Errorf("AllowSdc: got err = %v, want %v", err, tenantsvc.ErrTenantNotFound)
+			}
+		})
+		t.Run("it removes the sdcs of a deleted tenant", func(t *testing.T) {
+			defer afterFn()
+			createTenant(t, sut, tenantConfig{Name: "tenant-1"})
+			_, err := sut.AllowSdc(context.Background(), &pb.AllowSdcRequest{
+				TenantName: "tenant-1",
+				Sdcs:       []string{"10.0.0.1"},
+			})
+			checkError(t, err)
+
+			_, err = sut.DeleteTenant(context.Background(), &pb.DeleteTenantRequest{Name: "tenant-1"})
+			checkError(t, err)
+
+			n, err := rdb.Exists("tenant:tenant-1:sdcs").Result()
+			checkError(t, err)
+			if n != 0 {
+				t.Error("DeleteTenant: expected the allowed sdcs to be removed")
+			}
+		})
+	}
+}
+
+func testClaims(sut *tenantsvc.TenantService, rdb *redis.Client, afterFn AfterFunc) func(*testing.T) {
+	return func(t *testing.T) {
+		t.Run("it sets and removes claims", func(t *testing.T) {
+			defer afterFn()
+			createTenant(t, sut, tenantConfig{Name: "tenant-1"})
+
+			got, err := sut.SetClaims(context.Background(), &pb.SetClaimsRequest{
+				TenantName: "tenant-1",
+				Claims:     map[string]string{"team": "payments", "env": "prod"},
+			})
+			checkError(t, err)
+
+			want := map[string]string{"team": "payments", "env": "prod"}
+			if !reflect.DeepEqual(got.Claims, want) {
+				t.Errorf("SetClaims: got claims = %v, want %v", got.Claims, want)
+			}
+
+			got, err = sut.SetClaims(context.Background(), &pb.SetClaimsRequest{
+				TenantName: "tenant-1",
+				Claims:     map[string]string{"team": "billing"},
+				Remove:     []string{"env"},
+			})
+			checkError(t, err)
+
+			want = map[string]string{"team": "billing"}
+			if !reflect.DeepEqual(got.Claims, want) {
+				t.Errorf("SetClaims: got claims = %v, want %v", got.Claims, want)
+			}
+		})
+		t.Run("it errors on a non-existent tenant", func(t *testing.T) {
+			defer afterFn()
+
+			_, err := sut.SetClaims(context.Background(), &pb.SetClaimsRequest{
+				TenantName: "tenant-1",
+				Claims:     map[string]string{"team": "payments"},
+			})
+
+			if err != tenantsvc.ErrTenantNotFound {
+				t.Errorf("SetClaims: got err = %v, want %v", err, tenantsvc.ErrTenantNotFound)
+			}
+		})
+		t.Run("it embeds the claims in tokens", func(t *testing.T) {
+			defer afterFn()
+			createTenant(t, sut, tenantConfig{Name: "tenant-1", Roles: "role-1"})
+			_, err := sut.SetClaims(context.Background(), &pb.SetClaimsRequest{
+				TenantName: "tenant-1",
+				Claims:     map[string]string{"team": "payments"},
+			})
+			checkError(t, err)
+
+			tkn, err := sut.GenerateToken(context.Background(), &pb.GenerateTokenRequest{
+				TenantName: "tenant-1",
+			})
+			checkError(t, err)
+
+			var tokenData struct {
+				Data struct {
+					Access string `yaml:"access"`
+				} `yaml:"data"`
+			}
+			err = yaml.Unmarshal([]byte(tkn.Token), &tokenData)
+			checkError(t, err)
+			decAccTkn, err := base64.StdEncoding.DecodeString(tokenData.Data.Access)
+			checkError(t, err)
+
+			var claims token.Claims
+			_, err = jwx.NewTokenManager(jwx.HS256).ParseWithClaims(string(decAccTkn), "secret", &claims)
+			checkError(t, err)
+
+			want := map[string]string{"team": "payments"}
+			if !reflect.DeepEqual(claims.Custom, want) {
+				t.Errorf("GenerateToken: got claims = %v, want %v", claims.Custom, want)
+			}
+		})
+		t.Run("it removes the claims of a deleted tenant", func(t *testing.T) {
+			defer afterFn()
+			createTenant(t, sut, tenantConfig{Name: "tenant-1"})
+			_, err := sut.SetClaims(context.Background(), &pb.SetClaimsRequest{
+				TenantName: "tenant-1",
+				Claims:     map[string]string{"team": "payments"},
+			})
+			checkError(t, err)
+
+			_, err = sut.DeleteTenant(context.Background(), &pb.DeleteTenantRequest{Name: "tenant-1"})
+			checkError(t, err)
+
+			n, err := rdb.Exists("tenant:tenant-1:claims").Result()
+			checkError(t, err)
+			if n != 0 {
+				t.Error("DeleteTenant: expected the claims to be removed")
+			}
+		})
+	}
+}
+
+func testTenantQuota(sut *tenantsvc.TenantService, rdb *redis.Client, afterFn AfterFunc) func(*testing.T) {
+	return func(t *testing.T) {
+		t.Run("it sets the max volumes", func(t *testing.T) {
+			defer afterFn()
+			createTenant(t, sut, tenantConfig{Name: "tenant-1"})
+
+			got, err := sut.SetMaxVolumes(context.Background(), &pb.SetMaxVolumesRequest{
+				TenantName: "tenant-1",
+				MaxVolumes: 10,
+			})
+			checkError(t, err)
+
+			if got.MaxVolumes != 10 {
+				t.Errorf("SetMaxVolumes: got max volumes = %d, want 10", got.MaxVolumes)
+			}
+		})
+		t.Run("it errors on negative max volumes", func(t *testing.T) {
+			defer afterFn()
+			createTenant(t, sut, tenantConfig{Name: "tenant-1"})
+
+			_, err := sut.SetMaxVolumes(context.Background(), &pb.SetMaxVolumesRequest{
+				TenantName: "tenant-1",
+				MaxVolumes: -1,
+			})
+
+			if err != tenantsvc.ErrInvalidMaxVolumes {
+				t.Errorf("SetMaxVolumes: got err = %v, want %v", err, tenantsvc.ErrInvalidMaxVolumes)
+			}
+		})
+		t.Run("it sets and removes the volume name policy", func(t *testing.T) {
+			defer afterFn()
+			createTenant(t, sut, tenantConfig{Name: "tenant-1"})
+
+			got, err := sut.SetVolumeNamePolicy(context.Background(), &pb.SetVolumeNamePolicyRequest{
+				TenantName:    "tenant-1",
+				VolumePrefix:  "t1-",
+				VolumePattern: "^t1-[a-z0-9-]+$",
+			})
+			checkError(t, err)
+			if got.VolumePrefix != "t1-" || got.VolumePattern != "^t1-[a-z0-9-]+$" {
+				t.Errorf("SetVolumeNamePolicy: got prefix %q, pattern %q", got.VolumePrefix, got.VolumePattern)
+			}
+			// the proxy-server reads the policy from the tenant data
+			if v := rdb.HGet("tenant:tenant-1:data", quota.VolumePrefixField).Val(); v != "t1-" {
+				t.Errorf("got stored prefix %q, want %q", v, "t1-")
+			}
+
+			got, err = sut.SetVolumeNamePolicy(context.Background(), &pb.SetVolumeNamePolicyRequest{
+				TenantName:   "tenant-1",
+				VolumePrefix: "pay-",
+			})
+			checkError(t, err)
+			if got.VolumePrefix != "pay-" || got.VolumePattern != "^t1-[a-z0-9-]+$" {
+				t.Errorf("SetVolumeNamePolicy: got prefix %q, pattern %q, want the pattern kept", got.VolumePrefix, got.VolumePattern)
+			}
+
+			got, err = sut.SetVolumeNamePolicy(context.Background(), &pb.SetVolumeNamePolicyRequest{
+				TenantName: "tenant-1",
+				Remove:     []string{tenantsvc.VolumeNamePattern},
+			})
+			checkError(t, err)
+			if got.VolumePrefix != "pay-" || got.VolumePattern != "" {
+				t.Errorf("SetVolumeNamePolicy: got prefix %q, pattern %q, want the pattern removed", got.VolumePrefix, got.VolumePattern)
+			}
+		})
+		t.Run("it errors on an invalid volume pattern", func(t *testing.T) {
+			defer afterFn()
+			createTenant(t, sut, tenantConfig{Name: "tenant-1"})
+
+			_, err := sut.SetVolumeNamePolicy(context.Background(), &pb.SetVolumeNamePolicyRequest{
+				TenantName:    "tenant-1",
+				VolumePattern: "t1-(",
+			})
+
+			if err != tenantsvc.ErrInvalidVolumePattern {
+				t.Errorf("SetVolumeNamePolicy: got err = %v, want %v", err, tenantsvc.ErrInvalidVolumePattern)
+			}
+		})
+		t.Run("it gets the usage in each storage pool", func(t *testing.T) {
+			defer afterFn()
+			createTenant(t, sut, tenantConfig{Name: "tenant-1"})
+			_, err := sut.SetMaxVolumes(context.Background(), &pb.SetMaxVolumesRequest{
+				TenantName: "tenant-1",
+				MaxVolumes: 10,
+			})
+			checkError(t, err)
+			id, err := rdb.HGet("tenant:tenant-1:data", tenantsvc.FieldTenantID).Result()
+			checkError(t, err)
+			checkError(t, rdb.HMSet(fmt.Sprintf("quota:powerflex:542a2d5f5122210f:bronze:%s:data", id), map[string]interface{}{
+				"approved_capacity": 8000000,
+				"approved_volumes":  1,
+			}).Err())
+			checkError(t, rdb.HSet("quota:powerflex:542a2d5f5122210f:bronze:tenant-2:data", "approved_volumes", 3).Err())
+
+			got, err := sut.GetTenantQuota(context.Background(), &pb.GetTenantQuotaRequest{Name: "tenant-1"})
+			checkError(t, err)
+
+			want := &pb.TenantQuota{
+				Name:       "tenant-1",
+				MaxVolumes: 10,
+				Pools: []*pb.PoolUsage{
+					{
+						SystemType:       "powerflex",
+						SystemId:         "542a2d5f5122210f",
+						Pool:             "bronze",
+						ApprovedCapacity: 8000000,
+						ApprovedVolumes:  1,
+					},
+				},
+			}
+			if !proto.Equal(got, want) {
+				t.Errorf("GetTenantQuota: got %v, want %v", got, want)
+			}
+		})
+		t.Run("it errors on a non-existent tenant", func(t *testing.T) {
+			defer afterFn()
+
+			_, err := sut.GetTenantQuota(context.Background(), &pb.GetTenantQuotaRequest{Name: "tenant-1"})
+
+			if err != tenantsvc.ErrTenantNotFound {
+				t.Errorf("GetTenantQuota: got err = %v, want %v", err, tenantsvc.ErrTenantNotFound)
+			}
+		})
+	}
+}
+
+func testActivity(sut *tenantsvc.TenantService, rdb *redis.Client, afterFn AfterFunc) func(*testing.T) {
+	return func(t *testing.T) {
+		t.Run("it gets the recorded activity", func(t *testing.T) {
+			defer afterFn()
+			createTenant(t, sut, tenantConfig{Name: "tenant-1"})
+			createTenant(t, sut, tenantConfig{Name: "tenant-2"})
+			at := time.Unix(1700000000, 0)
+			checkError(t, tenantsvc.RecordActivity(rdb, "tenant-1", tenantsvc.ActivityCreates, at))
+			checkError(t, tenantsvc.RecordActivity(rdb, "tenant-1", tenantsvc.ActivityCreates, at))
+			checkError(t, tenantsvc.RecordActivity(rdb, "tenant-1", tenantsvc.ActivityDenials, at.Add(time.Minute)))
+			checkError(t, rdb.HSet("tenant:tenant-1:data", tenantsvc.FieldRefreshCount, 3).Err())
+
+			got, err := sut.GetActivity(context.Background(), &pb.GetActivityRequest{})
+			checkError(t, err)
+
+			want := &pb.GetActivityResponse{
+				Tenants: []*pb.TenantActivity{
+					{
+						Name:         "tenant-1",
+						Creates:      2,
+						Denials:      1,
+						Refreshes:    3,
+						LastActivity: at.Add(time.Minute).Unix(),
+					},
+					{
+						Name: "tenant-2",
+					},
+				},
+			}
+			if !proto.Equal(got, want) {
+				t.Errorf("GetActivity: got %v, want %v", got, want)
+			}
+		})
+		t.Run("it gets the activity of an organization", func(t *testing.T) {
+			defer afterFn()
+			createOrganization(t, sut, "org-1")
+			createTenant(t, sut, tenantConfig{Name: "tenant-1", Organization: "org-1"})
+			createTenant(t, sut, tenantConfig{Name: "tenant-2"})
+
+			got, err := sut.GetActivity(context.Background(), &pb.GetActivityRequest{Organization: "org-1"})
+			checkError(t, err)
+			if len(got.Tenants) != 1 || got.Tenants[0].Name != "tenant-1" {
+				t.Errorf("GetActivity: got %v, want only tenant-1", got.Tenants)
+			}
+
+			_, err = sut.GetActivity(context.Background(), &pb.GetActivityRequest{Name: "tenant-2", Organization: "org-1"})
+			if err != tenantsvc.ErrTenantNotFound {
+				t.Errorf("GetActivity: got err = %v, want %v", err, tenantsvc.ErrTenantNotFound)
+			}
+		})
+		t.Run("it removes the activity of a deleted tenant", func(t *testing.T) {
+			defer afterFn()
+			createTenant(t, sut, tenantConfig{Name: "tenant-1"})
+			checkError(t, tenantsvc.RecordActivity(rdb, "tenant-1", tenantsvc.ActivityMaps, time.Now()))
+
+			_, err := sut.DeleteTenant(context.Background(), &pb.DeleteTenantRequest{Name: "tenant-1"})
+			checkError(t, err)
+
+			n, err := rdb.Exists("tenant:tenant-1:activity").Result()
+			checkError(t, err)
+			if n != 0 {
+				t.Errorf("DeleteTenant: activity of the tenant was not removed")
+			}
+		})
+		t.Run("it errors on a non-existent tenant", func(t *testing.T) {
+			defer afterFn()
+
+			_, err := sut.GetActivity(context.Background(), &pb.GetActivityRequest{Name: "tenant-1"})
+
+			if err != tenantsvc.ErrTenantNotFound {
+				t.Errorf("GetActivity: got err = %v, want %v", err, tenantsvc.ErrTenantNotFound)
+			}
+		})
+	}
+}
+
+func testDefaultRole(sut *tenantsvc.TenantService, _ *redis.Client, afterFn AfterFunc) func(*testing.T) {
+	return func(t *testing.T) {
+		getRoles := func(t *testing.T, name string) string {
+			t.Helper()
+			got, err := sut.GetTenant(context.Background(), &pb.GetTenantRequest{Name: name})
+			checkError(t, err)
+			return got.Roles
+		}
+
+		t.Run("it binds the default role to a new tenant", func(t *testing.T) {
+			defer afterFn()
+			_, err := sut.SetDefaultRole(context.Background(), &pb.SetDefaultRoleRequest{RoleName: "role-1"})
+			checkError(t, err)
+
+			got, err := sut.CreateTenant(context.Background(), &pb.CreateTenantRequest{
+				Tenant: &pb.Tenant{Name: "tenant-1"},
+			})
+			checkError(t, err)
+
+			if got.Roles != "role-1" {
+				t.Errorf("CreateTenant: got roles = %q, want %q", got.Roles, "role-1")
+			}
+			if roles := getRoles(t, "tenant-1"); roles != "role-1" {
+				t.Errorf("GetTenant: got roles = %q, want %q", roles, "role-1")
+			}
+		})
+		t.Run("it skips the default role on request", func(t *testing.T) {
+			defer afterFn()
+			_, err := sut.SetDefaultRole(context.Background(), &pb.SetDefaultRoleRequest{RoleName: "role-1"})
+			checkError(t, err)
+
+			_, err = sut.CreateTenant(context.Background(), &pb.CreateTenantRequest{
+				Tenant:        &pb.Tenant{Name: "tenant-1"},
+				NoDefaultRole: true,
+			})
+			checkError(t, err)
+
+			if roles := getRoles(t, "tenant-1"); roles != "" {
+				t.Errorf("GetTenant: got roles = %q, want none", roles)
+			}
+		})
+		t.Run("it replaces and removes the default role", func(t *testing.T) {
+			defer afterFn()
+			for _, role := range []string{"role-1", "role-2", ""} {
+				_, err := sut.SetDefaultRole(context.Background(), &pb.SetDefaultRoleRequest{RoleName: role})
+				checkError(t, err)
+
+				got, err := sut.GetDefaultRole(context.Background(), &pb.GetDefaultRoleRequest{})
+				checkError(t, err)
+				if got.RoleName != role {
+					t.Errorf("GetDefaultRole: got %q, want %q", got.RoleName, role)
+				}
+			}
+
+			createTenant(t, sut, tenantConfig{Name: "tenant-1"})
+			if roles := getRoles(t, "tenant-1"); roles != "" {
+				t.Errorf("GetTenant: got roles = %q, want none", roles)
+			}
+		})
+	}
+}
+
 func testListTenant(sut *tenantsvc.TenantService, _ *redis.Client, afterFn AfterFunc) func(*testing.T) {
 	return func(t *testing.T) {
 		t.Run("it lists existing tenants", func(t *testing.T) {
@@ -342,6 +988,111 @@ func testListTenant(sut *tenantsvc.TenantService, _ *redis.Client, afterFn After
 				t.Errorf("got len = %d, want %d", gotLen, wantLen)
 			}
 		})
+		t.Run("it lists the tenants of an organization", func(t *testing.T) {
+			defer afterFn()
+			createOrganization(t, sut, "org")
+			createTenant(t, sut, tenantConfig{Name: "tenant-a", Organization: "org"})
+			createTenant(t, sut, tenantConfig{Name: "tenant-b", Organization: "org"})
+			createTenant(t, sut, tenantConfig{Name: "tenant-c"})
+
+			res, err := sut.ListTenant(context.Background(), &pb.ListTenantRequest{Organization: "org"})
+			checkError(t, err)
+
+			var got []string
+			for _, v := range res.Tenants {
+				got = append(got, v.Name)
+			}
+			if want := []string{"tenant-a", "tenant-b"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func testOrganization(sut *tenantsvc.TenantService, _ *redis.Client, afterFn AfterFunc) func(*testing.T) {
+	return func(t *testing.T) {
+		t.Run("it creates an organization", func(t *testing.T) {
+			defer afterFn()
+
+			got, err := sut.CreateOrganization(context.Background(), &pb.CreateOrganizationRequest{
+				Organization: &pb.Organization{Name: "org"},
+			})
+			checkError(t, err)
+
+			if got.Name != "org" {
+				t.Errorf("got name = %q, want %q", got.Name, "org")
+			}
+		})
+		t.Run("it errors on a duplicate organization", func(t *testing.T) {
+			defer afterFn()
+			createOrganization(t, sut, "org")
+
+			_, err := sut.CreateOrganization(context.Background(), &pb.CreateOrganizationRequest{
+				Organization: &pb.Organization{Name: "org"},
+			})
+
+			if err != tenantsvc.ErrOrganizationAlreadyExists {
+				t.Errorf("got err = %v, want %v", err, tenantsvc.ErrOrganizationAlreadyExists)
+			}
+		})
+		t.Run("it places a tenant in an organization", func(t *testing.T) {
+			defer afterFn()
+			createOrganization(t, sut, "org")
+			createTenant(t, sut, tenantConfig{Name: "tenant", Organization: "org"})
+
+			if got := getTenant(t, sut, "tenant").Organization; got != "org" {
+				t.Errorf("got tenant organization = %q, want %q", got, "org")
+			}
+			org, err := sut.GetOrganization(context.Background(), &pb.GetOrganizationRequest{Name: "org"})
+			checkError(t, err)
+			if want := []string{"tenant"}; !reflect.DeepEqual(org.Tenants, want) {
+				t.Errorf("got tenants = %v, want %v", org.Tenants, want)
+			}
+		})
+		t.Run("it errors on a tenant in an unknown organization", func(t *testing.T) {
+			defer afterFn()
+
+			_, err := sut.CreateTenant(context.Background(), &pb.CreateTenantRequest{
+				Tenant: &pb.Tenant{Name: "tenant", Organization: "unknown"},
+			})
+
+			if err != tenantsvc.ErrOrganizationNotFound {
+				t.Errorf("got err = %v, want %v", err, tenantsvc.ErrOrganizationNotFound)
+			}
+		})
+		t.Run("it refuses to delete an organization with tenants", func(t *testing.T) {
+			defer afterFn()
+			createOrganization(t, sut, "org")
+			createTenant(t, sut, tenantConfig{Name: "tenant", Organization: "org"})
+
+			_, err := sut.DeleteOrganization(context.Background(), &pb.DeleteOrganizationRequest{Name: "org"})
+			if err != tenantsvc.ErrOrganizationHasTenants {
+				t.Fatalf("got err = %v, want %v", err, tenantsvc.ErrOrganizationHasTenants)
+			}
+
+			_, err = sut.DeleteTenant(context.Background(), &pb.DeleteTenantRequest{Name: "tenant"})
+			checkError(t, err)
+			_, err = sut.DeleteOrganization(context.Background(), &pb.DeleteOrganizationRequest{Name: "org"})
+			checkError(t, err)
+
+			_, err = sut.GetOrganization(context.Background(), &pb.GetOrganizationRequest{Name: "org"})
+			if err != tenantsvc.ErrOrganizationNotFound {
+				t.Errorf("got err = %v, want %v", err, tenantsvc.ErrOrganizationNotFound)
+			}
+		})
+		t.Run("it lists existing organizations", func(t *testing.T) {
+			defer afterFn()
+			for i := 0; i < 3; i++ {
+				createOrganization(t, sut, fmt.Sprintf("org-%d", i))
+			}
+
+			res, err := sut.ListOrganization(context.Background(), &pb.ListOrganizationRequest{})
+			checkError(t, err)
+
+			if gotLen := len(res.Organizations); gotLen != 3 {
+				t.Errorf("got len = %d, want %d", gotLen, 3)
+			}
+		})
 	}
 }
 
@@ -390,7 +1141,7 @@ func testGenerateToken(sut *tenantsvc.TenantService, _ *redis.Client, afterFn Af
 	}
 }
 
-func testRefreshToken(sut *tenantsvc.TenantService, _ *redis.Client, afterFn AfterFunc) func(*testing.T) {
+func testRefreshToken(sut *tenantsvc.TenantService, rdb *redis.Client, afterFn AfterFunc) func(*testing.T) {
 	return func(t *testing.T) {
 		tokens := make(map[string]interface{})
 		credFile, err := os.ReadFile("../../tokens.yaml")
@@ -467,6 +1218,86 @@ func testRefreshToken(sut *tenantsvc.TenantService, _ *redis.Client, afterFn Aft
 				t.Errorf("got err = %+v, want %+v", got, want)
 			}
 		})
+		t.Run("it refuses a token issued before the tokens were rotated", func(t *testing.T) {
+			defer afterFn()
+			name := "tenant-1"
+			createTenant(t, sut, tenantConfig{Name: name, Roles: "role-1"})
+			tkn, err := sut.GenerateToken(context.Background(), &pb.GenerateTokenRequest{
+				TenantName: name,
+			})
+			checkError(t, err)
+
+			// a rotation in the same second keeps the token
+			_, err = sut.GenerateToken(context.Background(), &pb.GenerateTokenRequest{
+				TenantName:     name,
+				RevokePrevious: true,
+			})
+			checkError(t, err)
+			revokedBefore, err := rdb.HGet("tenant:"+name+":data", tenantsvc.FieldTokensRevokedBefore).Int64()
+			checkError(t, err)
+			_, err = rdb.HSet("tenant:"+name+":data", tenantsvc.FieldTokensRevokedBefore, revokedBefore+1).Result()
+			checkError(t, err)
+
+			var tokenData struct {
+				Data struct {
+					Refresh string `yaml:"refresh"`
+					Access  string `yaml:"access"`
+				} `yaml:"data"`
+			}
+			err = yaml.Unmarshal([]byte(tkn.Token), &tokenData)
+			checkError(t, err)
+			decRefTkn, err := base64.StdEncoding.DecodeString(tokenData.Data.Refresh)
+			checkError(t, err)
+			decAccTkn, err := base64.StdEncoding.DecodeString(tokenData.Data.Access)
+			checkError(t, err)
+
+			_, err = sut.RefreshToken(context.Background(), &pb.RefreshTokenRequest{
+				RefreshToken:     string(decRefTkn),
+				AccessToken:      string(decAccTkn),
+				JWTSigningSecret: "secret",
+			})
+
+			want := tenantsvc.ErrTokenRevoked
+			if got := err; got != want {
+				t.Errorf("got err = %+v, want %+v", got, want)
+			}
+		})
+		t.Run("it refuses a token for a previous tenant of the same name", func(t *testing.T) {
+			defer afterFn()
+			name := "tenant"
+			createTenant(t, sut, tenantConfig{Name: name, Roles: "role-1"})
+			tkn, err := sut.GenerateToken(context.Background(), &pb.GenerateTokenRequest{
+				TenantName: name,
+			})
+			checkError(t, err)
+			_, err = sut.DeleteTenant(context.Background(), &pb.DeleteTenantRequest{Name: name})
+			checkError(t, err)
+			createTenant(t, sut, tenantConfig{Name: name, Roles: "role-1"})
+
+			var tokenData struct {
+				Data struct {
+					Refresh string `yaml:"refresh"`
+					Access  string `yaml:"access"`
+				} `yaml:"data"`
+			}
+			err = yaml.Unmarshal([]byte(tkn.Token), &tokenData)
+			checkError(t, err)
+			decRefTkn, err := base64.StdEncoding.DecodeString(tokenData.Data.Refresh)
+			checkError(t, err)
+			decAccTkn, err := base64.StdEncoding.DecodeString(tokenData.Data.Access)
+			checkError(t, err)
+
+			_, err = sut.RefreshToken(context.Background(), &pb.RefreshTokenRequest{
+				RefreshToken:     string(decRefTkn),
+				AccessToken:      string(decAccTkn),
+				JWTSigningSecret: "secret",
+			})
+
+			want := tenantsvc.ErrTenantNotFound
+			if got := err; got != want {
+				t.Errorf("got err = %+v, want %+v", got, want)
+			}
+		})
 		t.Run("it handles a token from a new tenant", func(t *testing.T) {
 			defer afterFn()
 			name := "tenant"
@@ -502,7 +1333,7 @@ func testRefreshToken(sut *tenantsvc.TenantService, _ *redis.Client, afterFn Aft
 	}
 }
 
-func testRevokeTenant(sut *tenantsvc.TenantService, _ *redis.Client, afterFn AfterFunc) func(*testing.T) {
+func testRevokeTenant(sut *tenantsvc.TenantService, rdb *redis.Client, afterFn AfterFunc) func(*testing.T) {
 	return func(t *testing.T) {
 		t.Run("it revokes access to a tenant", func(t *testing.T) {
 			defer afterFn()
@@ -520,6 +1351,19 @@ func testRevokeTenant(sut *tenantsvc.TenantService, _ *redis.Client, afterFn Aft
 				t.Errorf("CheckRevoked: got %v, want %v", got, want)
 			}
 		})
+		t.Run("it revokes the tenant by its UUID", func(t *testing.T) {
+			defer afterFn()
+			name := "tenant"
+			createTenant(t, sut, tenantConfig{Name: name, Revoked: true})
+
+			id, err := rdb.HGet("tenant:tenant:data", tenantsvc.FieldTenantID).Result()
+			checkError(t, err)
+			members, err := rdb.SMembers(tenantsvc.KeyTenantRevoked).Result()
+			checkError(t, err)
+			if len(members) != 1 || members[0] != id {
+				t.Errorf("got revoked %v, want [%s]", members, id)
+			}
+		})
 	}
 }
 
@@ -544,6 +1388,48 @@ func testCancelRevokeTenant(sut *tenantsvc.TenantService, _ *redis.Client, after
 	}
 }
 
+func testMigrateTenantIDs(sut *tenantsvc.TenantService, rdb *redis.Client, afterFn AfterFunc) func(*testing.T) {
+	return func(t *testing.T) {
+		t.Run("it moves name-based data to the tenant UUID", func(t *testing.T) {
+			defer afterFn()
+			name := "legacy"
+			checkError(t, rdb.HSet("tenant:legacy:data", tenantsvc.FieldCreatedAt, 1).Err())
+			checkError(t, rdb.HSet("quota:powerflex:542a2d5f5122210f:bronze:legacy:data", "vol:k8s-1:approved", 1).Err())
+			checkError(t, rdb.XAdd(&redis.XAddArgs{
+				Stream: "quota:powerflex:542a2d5f5122210f:bronze:legacy:stream",
+				Values: map[string]interface{}{"name": "k8s-1", "status": "approved"},
+			}).Err())
+			checkError(t, rdb.SAdd(tenantsvc.KeyTenantRevoked, name).Err())
+
+			checkError(t, sut.MigrateTenantIDs(context.Background()))
+
+			id, err := rdb.HGet("tenant:legacy:data", tenantsvc.FieldTenantID).Result()
+			checkError(t, err)
+			for _, suffix := range []string{"data", "stream"} {
+				n, err := rdb.Exists(
+					fmt.Sprintf("quota:powerflex:542a2d5f5122210f:bronze:%s:%s", id, suffix),
+					fmt.Sprintf("quota:powerflex:542a2d5f5122210f:bronze:%s:%s", name, suffix)).Result()
+				checkError(t, err)
+				if n != 1 {
+					t.Errorf("%s: got %d keys, want only the UUID key", suffix, n)
+				}
+			}
+			revoked, err := sut.CheckRevoked(context.Background(), name)
+			checkError(t, err)
+			if !revoked {
+				t.Errorf("CheckRevoked: got %v, want true", revoked)
+			}
+
+			checkError(t, sut.MigrateTenantIDs(context.Background()))
+			again, err := rdb.HGet("tenant:legacy:data", tenantsvc.FieldTenantID).Result()
+			checkError(t, err)
+			if again != id {
+				t.Errorf("got UUID %q after migrating again, want %q", again, id)
+			}
+		})
+	}
+}
+
 func checkError(t *testing.T, err error) {
 	t.Helper()
 	if err != nil {
@@ -552,10 +1438,11 @@ func checkError(t *testing.T, err error) {
 }
 
 type tenantConfig struct {
-	Name       string
-	Roles      string
-	Revoked    bool
-	Approvesdc bool
+	Name         string
+	Roles        string
+	Revoked      bool
+	Approvesdc   bool
+	Organization string
 }
 
 func createTenant(t *testing.T, svc *tenantsvc.TenantService, cfg tenantConfig) {
@@ -563,8 +1450,9 @@ func createTenant(t *testing.T, svc *tenantsvc.TenantService, cfg tenantConfig)
 
 	tnt, err := svc.CreateTenant(context.Background(), &pb.CreateTenantRequest{
 		Tenant: &pb.Tenant{
-			Name:       cfg.Name,
-			Approvesdc: cfg.Approvesdc,
+			Name:         cfg.Name,
+			Approvesdc:   cfg.Approvesdc,
+			Organization: cfg.Organization,
 		},
 	})
 	checkError(t, err)
@@ -588,6 +1476,15 @@ func createTenant(t *testing.T, svc *tenantsvc.TenantService, cfg tenantConfig)
 	}
 }
 
+func createOrganization(t *testing.T, svc *tenantsvc.TenantService, name string) {
+	t.Helper()
+
+	_, err := svc.CreateOrganization(context.Background(), &pb.CreateOrganizationRequest{
+		Organization: &pb.Organization{Name: name},
+	})
+	checkError(t, err)
+}
+
 func getTenant(t *testing.T, svc *tenantsvc.TenantService, name string) *pb.Tenant {
 	res, err := svc.GetTenant(context.Background(), &pb.GetTenantRequest{
 		Name: name,
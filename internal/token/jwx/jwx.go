@@ -16,7 +16,11 @@ package jwx
 
 import (
 	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"karavi-authorization/internal/token"
@@ -25,20 +29,99 @@ import (
 	"time"
 
 	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwe"
 	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws"
 	"github.com/lestrrat-go/jwx/jwt"
 	"github.com/sirupsen/logrus"
 )
 
 // Manager implements the token.Manager API via github.com/lestrrat-go/jwx
 type Manager struct {
+	// SigningAlgorithm signs new tokens and is the only algorithm that
+	// parsed tokens may be signed with. With RS256, the secret is a PEM
+	// encoded RSA private key, or a public key for only parsing tokens.
 	SigningAlgorithm jwa.SignatureAlgorithm
+	// Issuer and Audience are set on new tokens and, when not empty,
+	// required of parsed tokens.
+	Issuer   string
+	Audience string
+	// LegacyUntil is the end of the transition window in which tokens in
+	// the legacy claim layout are accepted. They are refused when zero.
+	LegacyUntil time.Time
+	// EncryptionKey, when set, encrypts new tokens as JWE so that their
+	// claims cannot be read by the holder, e.g. from the environment of a
+	// sidecar. Both encrypted and plain tokens are parsed.
+	EncryptionKey []byte
+}
+
+// Option configures a Manager
+type Option func(*Manager)
+
+// WithSigningAlgorithm overrides the signature algorithm of the Manager
+func WithSigningAlgorithm(alg SignatureAlgorithm) Option {
+	return func(m *Manager) {
+		m.SigningAlgorithm = jwa.SignatureAlgorithm(alg)
+	}
+}
+
+// WithIssuer sets the issuer of new tokens and rejects tokens from other issuers
+func WithIssuer(iss string) Option {
+	return func(m *Manager) {
+		m.Issuer = iss
+	}
+}
+
+// WithAudience sets the audience of new tokens and rejects tokens for other audiences
+func WithAudience(aud string) Option {
+	return func(m *Manager) {
+		m.Audience = aud
+	}
+}
+
+// WithLegacyTokensUntil accepts tokens in the legacy claim layout, e.g. of
+// sidecars not yet upgraded, until the given time
+func WithLegacyTokensUntil(until time.Time) Option {
+	return func(m *Manager) {
+		m.LegacyUntil = until
+	}
+}
+
+// WithEncryptionKey encrypts new tokens with a key derived from the secret
+// and decrypts encrypted tokens with it. Tokens are not encrypted when the
+// secret is empty.
+func WithEncryptionKey(secret string) Option {
+	return func(m *Manager) {
+		if strings.TrimSpace(secret) == "" {
+			m.EncryptionKey = nil
+			return
+		}
+		key := sha256.Sum256([]byte(secret))
+		m.EncryptionKey = key[:]
+	}
+}
+
+// LegacyWindowEnd returns the end of the transition window for tokens in the
+// legacy claim layout from an RFC 3339 time. The window must be configured
+// with a fixed end, so that restarts do not extend it; when it is empty,
+// tokens in the legacy claim layout are not accepted
+func LegacyWindowEnd(s string) (time.Time, error) {
+	if strings.TrimSpace(s) == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing end of legacy token window: %w", err)
+	}
+	return t, nil
 }
 
 // Token implements the token.Token API via github.com/lestrrat-go/jwx
 type Token struct {
 	token            jwt.Token
 	SigningAlgorithm jwa.SignatureAlgorithm
+	// EncryptionKey, when set, encrypts the signed token.
+	EncryptionKey []byte
 }
 
 // SignatureAlgorithm is a wrapper for jwx signature algorithms
@@ -47,6 +130,15 @@ type SignatureAlgorithm jwa.SignatureAlgorithm
 const (
 	// HS256 is the HS256 signature algorithm from jwx
 	HS256 = SignatureAlgorithm(jwa.HS256)
+	// HS512 is the HS512 signature algorithm from jwx
+	HS512 = SignatureAlgorithm(jwa.HS512)
+	// RS256 is the RS256 signature algorithm from jwx
+	RS256 = SignatureAlgorithm(jwa.RS256)
+
+	// DefaultIssuer is the issuer of new tokens when none is configured
+	DefaultIssuer = "com.dell.csm"
+	// DefaultAudience is the audience of new tokens when none is configured
+	DefaultAudience = "csm"
 )
 
 var (
@@ -60,20 +152,39 @@ var (
 	_ token.Token   = &Token{}
 )
 
-// NewTokenManager returns a Manager configured with the supplied signature algorithm
-func NewTokenManager(alg SignatureAlgorithm) token.Manager {
+// ParseSignatureAlgorithm returns the supported signature algorithm of the
+// name, e.g. of the web.jwtSigningAlgorithm setting, or HS256 when it is
+// empty
+func ParseSignatureAlgorithm(name string) (SignatureAlgorithm, error) {
+	switch alg := SignatureAlgorithm(strings.ToUpper(strings.TrimSpace(name))); alg {
+	case "":
+		return HS256, nil
+	case HS256, HS512, RS256:
+		return alg, nil
+	default:
+		return "", fmt.Errorf("unsupported token signing algorithm %q, want %s, %s or %s", name, HS256, HS512, RS256)
+	}
+}
+
+// NewTokenManager returns a Manager configured with the supplied signature
+// algorithm, unless overridden by WithSigningAlgorithm
+func NewTokenManager(alg SignatureAlgorithm, opts ...Option) token.Manager {
 	jwt.Settings(jwt.WithFlattenAudience(true))
-	return &Manager{SigningAlgorithm: jwa.SignatureAlgorithm(alg)}
+	m := &Manager{SigningAlgorithm: jwa.SignatureAlgorithm(alg)}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // NewPair returns a new access/refresh Pair
 func (m *Manager) NewPair(cfg token.Config) (token.Pair, error) {
-	t, err := tokenFromConfig(cfg)
+	t, err := m.tokenFromConfig(cfg)
 	if err != nil {
 		return token.Pair{}, err
 	}
 
-	key, err := jwk.New([]byte(cfg.JWTSigningSecret))
+	key, err := signingKey(m.SigningAlgorithm, cfg.JWTSigningSecret)
 	if err != nil {
 		return token.Pair{}, err
 	}
@@ -83,14 +194,30 @@ func (m *Manager) NewPair(cfg token.Config) (token.Pair, error) {
 	if err != nil {
 		return token.Pair{}, err
 	}
+	accessToken, err = encrypt(accessToken, m.EncryptionKey)
+	if err != nil {
+		return token.Pair{}, err
+	}
 
-	// Sign for a refresh token
-	err = t.Set(jwt.ExpirationKey, time.Now().Add(cfg.RefreshExpiration).Unix())
+	// Sign for a refresh token. Only the refresh token has the time it was
+	// issued at, so that access tokens are not refused by servers whose
+	// clocks are behind; refresh tokens are used well after they are issued.
+	now := time.Now()
+	err = t.Set(jwt.ExpirationKey, now.Add(cfg.RefreshExpiration).Unix())
 	if err != nil {
 		return token.Pair{}, err
 	}
 
-	refreshToken, err := jwt.Sign(t, jwa.HS256, key)
+	err = t.Set(jwt.IssuedAtKey, now.Unix())
+	if err != nil {
+		return token.Pair{}, err
+	}
+
+	refreshToken, err := jwt.Sign(t, m.SigningAlgorithm, key)
+	if err != nil {
+		return token.Pair{}, err
+	}
+	refreshToken, err = encrypt(refreshToken, m.EncryptionKey)
 	if err != nil {
 		return token.Pair{}, err
 	}
@@ -111,13 +238,33 @@ func (m *Manager) NewWithClaims(claims token.Claims) (token.Token, error) {
 	return &Token{
 		token:            t,
 		SigningAlgorithm: m.SigningAlgorithm,
+		EncryptionKey:    m.EncryptionKey,
 	}, nil
 }
 
 // ParseWithClaims verifies and validates a token and unmarshals it into the supplied Claims
 func (m *Manager) ParseWithClaims(tokenStr string, secret string, claims *token.Claims) (token.Token, error) {
+	tokenStr, err := m.decrypt(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only tokens signed with the configured algorithm are verified, so
+	// that e.g. an RS256 public key cannot be used as an HMAC secret.
+	msg, err := jws.ParseString(tokenStr)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying token: %v", err)
+	}
+	if sigs := msg.Signatures(); len(sigs) != 1 || sigs[0].ProtectedHeaders().Algorithm() != m.SigningAlgorithm {
+		return nil, fmt.Errorf("error verifying token: not signed with %s", m.SigningAlgorithm)
+	}
+	key, err := verificationKey(m.SigningAlgorithm, secret)
+	if err != nil {
+		return nil, err
+	}
+
 	// verify the token with the secret, but don't validate it yet so we can use the token
-	verifiedToken, err := jwt.ParseString(tokenStr, jwt.WithVerify(m.SigningAlgorithm, []byte(secret)))
+	verifiedToken, err := jwt.ParseString(tokenStr, jwt.WithVerify(m.SigningAlgorithm, key))
 	if err != nil {
 		return nil, fmt.Errorf("error verifying token: %v", err)
 	}
@@ -127,13 +274,23 @@ func (m *Manager) ParseWithClaims(tokenStr string, secret string, claims *token.
 		return nil, fmt.Errorf("failed to marshal token: %v", err)
 	}
 
-	err = json.Unmarshal(data, &claims)
+	err = unmarshalClaims(data, claims)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal token: %v", err)
 	}
+	if claims.Legacy && !time.Now().Before(m.LegacyUntil) {
+		return nil, token.ErrLegacy
+	}
 
 	// now validate the verified token
-	t, err := jwt.ParseString(tokenStr, jwt.WithValidate(true))
+	parseOpts := []jwt.ParseOption{jwt.WithValidate(true)}
+	if m.Issuer != "" {
+		parseOpts = append(parseOpts, jwt.WithIssuer(m.Issuer))
+	}
+	if m.Audience != "" {
+		parseOpts = append(parseOpts, jwt.WithAudience(m.Audience))
+	}
+	t, err := jwt.ParseString(tokenStr, parseOpts...)
 	if err != nil {
 		if strings.Contains(err.Error(), errExpiredMsg) {
 			return nil, token.ErrExpired
@@ -144,12 +301,13 @@ func (m *Manager) ParseWithClaims(tokenStr string, secret string, claims *token.
 	return &Token{
 		token:            t,
 		SigningAlgorithm: m.SigningAlgorithm,
+		EncryptionKey:    m.EncryptionKey,
 	}, nil
 }
 
 // SignedString returns a signed, serialized token with the supplied secret
 func (t *Token) SignedString(secret string) (string, error) {
-	key, err := jwk.New([]byte(secret))
+	key, err := signingKey(t.SigningAlgorithm, secret)
 	if err != nil {
 		return "", err
 	}
@@ -159,9 +317,115 @@ func (t *Token) SignedString(secret string) (string, error) {
 		return "", err
 	}
 
+	token, err = encrypt(token, t.EncryptionKey)
+	if err != nil {
+		return "", err
+	}
+
 	return string(token), nil
 }
 
+// signingKey returns the key that signs tokens with the algorithm: the
+// secret for HMAC, or the RSA private key PEM encoded in the secret for RSA.
+func signingKey(alg jwa.SignatureAlgorithm, secret string) (interface{}, error) {
+	if alg != jwa.RS256 {
+		return jwk.New([]byte(secret))
+	}
+	key, err := parseRSAKey(secret)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing token: an RSA private key is required")
+	}
+	return private, nil
+}
+
+// verificationKey returns the key that verifies tokens signed with the
+// algorithm: the secret for HMAC, or the public key of the RSA key PEM
+// encoded in the secret for RSA.
+func verificationKey(alg jwa.SignatureAlgorithm, secret string) (interface{}, error) {
+	if alg != jwa.RS256 {
+		return []byte(secret), nil
+	}
+	key, err := parseRSAKey(secret)
+	if err != nil {
+		return nil, err
+	}
+	if private, ok := key.(*rsa.PrivateKey); ok {
+		return &private.PublicKey, nil
+	}
+	return key, nil
+}
+
+// parseRSAKey returns the RSA private or public key PEM encoded in s.
+func parseRSAKey(s string) (interface{}, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("parsing RSA key: no PEM encoded key found")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("parsing RSA key: unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing RSA key: %v", err)
+	}
+	switch key.(type) {
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("parsing RSA key: not an RSA key but %T", key)
+	}
+}
+
+// encrypt encrypts a signed token as a nested JWT, unless key is empty.
+func encrypt(signed []byte, key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return signed, nil
+	}
+
+	hdrs := jwe.NewHeaders()
+	if err := hdrs.Set(jwe.ContentTypeKey, "JWT"); err != nil {
+		return nil, err
+	}
+	encrypted, err := jwe.Encrypt(signed, jwa.DIRECT, key, jwa.A256GCM, jwa.NoCompress, jwe.WithProtectedHeaders(hdrs))
+	if err != nil {
+		return nil, fmt.Errorf("encrypting token: %v", err)
+	}
+	return encrypted, nil
+}
+
+// decrypt returns the signed token within an encrypted token. Plain tokens
+// are returned as is.
+func (m *Manager) decrypt(tokenStr string) (string, error) {
+	// A JWE in the compact serialization has five parts, a JWS three.
+	if strings.Count(tokenStr, ".") != 4 {
+		return tokenStr, nil
+	}
+	if len(m.EncryptionKey) == 0 {
+		return "", errors.New("error decrypting token: no encryption key is configured")
+	}
+
+	signed, err := jwe.Decrypt([]byte(tokenStr), jwa.DIRECT, m.EncryptionKey)
+	if err != nil {
+		return "", fmt.Errorf("error decrypting token: %v", err)
+	}
+	return string(signed), nil
+}
+
 // Claims returns the Claims of a token
 func (t *Token) Claims() (token.Claims, error) {
 	data, err := json.Marshal(t.token)
@@ -170,7 +434,7 @@ func (t *Token) Claims() (token.Claims, error) {
 	}
 
 	var c token.Claims
-	err = json.Unmarshal(data, &c)
+	err = unmarshalClaims(data, &c)
 	if err != nil {
 		return token.Claims{}, err
 	}
@@ -178,19 +442,64 @@ func (t *Token) Claims() (token.Claims, error) {
 	return c, nil
 }
 
-func tokenFromConfig(cfg token.Config) (jwt.Token, error) {
+// unmarshalClaims unmarshals the claims of a token in either the current or
+// the legacy claim layout, where the roles are a list rather than a comma
+// separated string.
+func unmarshalClaims(data []byte, claims *token.Claims) error {
+	var raw struct {
+		Roles json.RawMessage `json:"roles"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var roles []string
+	if len(raw.Roles) == 0 || raw.Roles[0] != '[' {
+		return json.Unmarshal(data, claims)
+	}
+	if err := json.Unmarshal(raw.Roles, &roles); err != nil {
+		return err
+	}
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	delete(m, "roles")
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, claims); err != nil {
+		return err
+	}
+	claims.Roles = strings.Join(roles, ",")
+	claims.Legacy = true
+	return nil
+}
+
+func (m *Manager) tokenFromConfig(cfg token.Config) (jwt.Token, error) {
+	iss, aud := m.Issuer, m.Audience
+	if iss == "" {
+		iss = DefaultIssuer
+	}
+	if aud == "" {
+		aud = DefaultAudience
+	}
+
 	t := jwt.New()
-	err := t.Set(jwt.IssuerKey, "com.dell.csm")
+	err := t.Set(jwt.IssuerKey, iss)
 	if err != nil {
 		return nil, err
 	}
 
-	err = t.Set(jwt.AudienceKey, "csm")
+	err = t.Set(jwt.AudienceKey, aud)
 	if err != nil {
 		return nil, err
 	}
 
-	if cfg.Subject == "admin" {
+	switch cfg.Subject {
+	case "admin":
 		err = t.Set(jwt.SubjectKey, "csm-admin")
 		if err != nil {
 			return nil, err
@@ -199,7 +508,22 @@ func tokenFromConfig(cfg token.Config) (jwt.Token, error) {
 		if err != nil {
 			return nil, err
 		}
-	} else {
+		if cfg.Organization != "" {
+			err = t.Set("org", cfg.Organization)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case "bootstrap":
+		err = t.Set(jwt.SubjectKey, token.SubjectBootstrap)
+		if err != nil {
+			return nil, err
+		}
+		err = t.Set("cluster", cfg.Cluster)
+		if err != nil {
+			return nil, err
+		}
+	default:
 		err = t.Set(jwt.SubjectKey, "csm-tenant")
 		if err != nil {
 			return nil, err
@@ -208,6 +532,18 @@ func tokenFromConfig(cfg token.Config) (jwt.Token, error) {
 		if err != nil {
 			return nil, err
 		}
+		if cfg.TenantID != "" {
+			err = t.Set("tid", cfg.TenantID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(cfg.Claims) > 0 {
+			err = t.Set("custom", cfg.Claims)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	err = t.Set(jwt.ExpirationKey, time.Now().Add(cfg.AccessExpiration).Unix())
@@ -255,13 +591,48 @@ func tokenFromClaims(claims token.Claims) (jwt.Token, error) {
 		return nil, err
 	}
 
+	if claims.TenantID != "" {
+		err = t.Set("tid", claims.TenantID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if claims.Organization != "" {
+		err = t.Set("org", claims.Organization)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(claims.Custom) > 0 {
+		err = t.Set("custom", claims.Custom)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if claims.Cluster != "" {
+		err = t.Set("cluster", claims.Cluster)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if claims.IssuedAt != 0 {
+		err = t.Set(jwt.IssuedAtKey, claims.IssuedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return t, nil
 }
 
 // GenerateAdminToken generates a token for an admin. The returned token is
 // in JSON format.
-func GenerateAdminToken(_ context.Context, req *pb.GenerateAdminTokenRequest) (*pb.GenerateAdminTokenResponse, error) {
-	tm := NewTokenManager(HS256)
+func GenerateAdminToken(_ context.Context, req *pb.GenerateAdminTokenRequest, opts ...Option) (*pb.GenerateAdminTokenResponse, error) {
+	tm := NewTokenManager(HS256, opts...)
 
 	// Get the expiration values from config.
 	if req.RefreshExpiration <= 0 {
@@ -274,6 +645,7 @@ func GenerateAdminToken(_ context.Context, req *pb.GenerateAdminTokenRequest) (*
 	// Generate the token.
 	s, err := token.CreateAdminSecret(tm, token.Config{
 		AdminName:         req.AdminName,
+		Organization:      req.Organization,
 		Subject:           "admin",
 		Roles:             nil,
 		JWTSigningSecret:  req.JWTSigningSecret,
@@ -290,9 +662,31 @@ func GenerateAdminToken(_ context.Context, req *pb.GenerateAdminTokenRequest) (*
 	}, nil
 }
 
+// GenerateBootstrapToken generates a bootstrap token scoped to the cluster.
+// Sidecars of the cluster may use it in place of the tokens of a tenant, if
+// the proxy-server is configured to create tenants for bootstrap tokens.
+func GenerateBootstrapToken(cluster, secret string, expiration time.Duration, opts ...Option) (string, error) {
+	if strings.TrimSpace(cluster) == "" {
+		return "", errors.New("empty cluster not allowed")
+	}
+	tm := NewTokenManager(HS256, opts...)
+
+	p, err := token.Create(tm, token.Config{
+		Subject:           "bootstrap",
+		Cluster:           cluster,
+		JWTSigningSecret:  secret,
+		RefreshExpiration: expiration,
+		AccessExpiration:  expiration,
+	})
+	if err != nil {
+		return "", err
+	}
+	return p.Access, nil
+}
+
 // RefreshAdminToken refreshes an admin access token given a valid refresh and access token.
-func RefreshAdminToken(_ context.Context, req *pb.RefreshAdminTokenRequest) (*pb.RefreshAdminTokenResponse, error) {
-	tm := NewTokenManager(HS256)
+func RefreshAdminToken(_ context.Context, req *pb.RefreshAdminTokenRequest, opts ...Option) (*pb.RefreshAdminTokenResponse, error) {
+	tm := NewTokenManager(HS256, opts...)
 	refreshToken := req.RefreshToken
 	accessToken := req.AccessToken
 
@@ -16,10 +16,15 @@ package jwx_test
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"karavi-authorization/internal/token"
 	"karavi-authorization/internal/token/jwx"
 	"karavi-authorization/pb"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -57,6 +62,78 @@ func TestNewPair(t *testing.T) {
 	}
 }
 
+func TestNewPair_IssuedAt(t *testing.T) {
+	tm := jwx.NewTokenManager(jwx.HS256)
+	secret := "secret"
+
+	before := time.Now().Unix()
+	p, err := tm.NewPair(token.Config{
+		Tenant:            "tenant",
+		Roles:             []string{"role"},
+		JWTSigningSecret:  secret,
+		RefreshExpiration: time.Hour,
+		AccessExpiration:  time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var access, refresh token.Claims
+	if _, err := tm.ParseWithClaims(p.Access, secret, &access); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tm.ParseWithClaims(p.Refresh, secret, &refresh); err != nil {
+		t.Fatal(err)
+	}
+	if access.IssuedAt != 0 {
+		t.Errorf("access: got iat %d, want none", access.IssuedAt)
+	}
+	if refresh.IssuedAt < before || refresh.IssuedAt > time.Now().Unix() {
+		t.Errorf("refresh: got iat %d, want the time it was issued", refresh.IssuedAt)
+	}
+}
+
+func TestNewPair_Claims(t *testing.T) {
+	tm := jwx.NewTokenManager(jwx.HS256)
+	secret := "secret"
+	want := map[string]string{"team": "payments"}
+
+	p, err := tm.NewPair(token.Config{
+		Tenant:            "tenant",
+		Roles:             []string{"role"},
+		Claims:            want,
+		JWTSigningSecret:  secret,
+		RefreshExpiration: time.Hour,
+		AccessExpiration:  time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{p.Access, p.Refresh} {
+		var claims token.Claims
+		if _, err := tm.ParseWithClaims(s, secret, &claims); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(claims.Custom, want) {
+			t.Errorf("got %v, want %v", claims.Custom, want)
+		}
+
+		// the claims are kept when the token is refreshed
+		refreshed, err := tm.NewWithClaims(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := refreshed.Claims()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Custom, want) {
+			t.Errorf("refreshed: got %v, want %v", got.Custom, want)
+		}
+	}
+}
+
 func TestNewWithClaims(t *testing.T) {
 	tm := jwx.NewTokenManager(jwx.HS256)
 
@@ -67,6 +144,7 @@ func TestNewWithClaims(t *testing.T) {
 		Subject:   "karavi-tenant",
 		Roles:     "CA-medium",
 		Group:     "PancakeGroup",
+		TenantID:  "0f8e3c1a-6f5d-4c4e-9a53-2b6f0c4d7e21",
 	}
 
 	token, err := tm.NewWithClaims(want)
@@ -150,6 +228,106 @@ func TestParseWithClaims(t *testing.T) {
 			t.Errorf("got %v, want %v", err, token.ErrExpired)
 		}
 	})
+
+	t.Run("it validates the configured issuer and audience", func(t *testing.T) {
+		secret := "secret"
+		cfg := token.Config{
+			Tenant:            "tenant",
+			Roles:             []string{"role"},
+			JWTSigningSecret:  secret,
+			RefreshExpiration: time.Hour,
+			AccessExpiration:  time.Minute,
+		}
+		tm := jwx.NewTokenManager(jwx.HS256, jwx.WithIssuer("site-a"), jwx.WithAudience("karavi-a"))
+
+		p, err := tm.NewPair(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got token.Claims
+		if _, err := tm.ParseWithClaims(p.Access, secret, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Issuer != "site-a" || got.Audience != "karavi-a" {
+			t.Errorf("got issuer %q audience %q, want site-a karavi-a", got.Issuer, got.Audience)
+		}
+
+		others := []token.Manager{
+			jwx.NewTokenManager(jwx.HS256, jwx.WithIssuer("site-b"), jwx.WithAudience("karavi-a")),
+			jwx.NewTokenManager(jwx.HS256, jwx.WithIssuer("site-a"), jwx.WithAudience("karavi-b")),
+		}
+		for _, other := range others {
+			if _, err := other.ParseWithClaims(p.Access, secret, &token.Claims{}); err == nil {
+				t.Errorf("expected token for another installation to be rejected")
+			}
+		}
+	})
+
+	t.Run("it accepts legacy tokens during the upgrade window", func(t *testing.T) {
+		secret := "secret"
+		tokenStr := legacyToken(t, secret, time.Now().Add(time.Hour))
+
+		tm := jwx.NewTokenManager(jwx.HS256, jwx.WithLegacyTokensUntil(time.Now().Add(time.Hour)))
+		var got token.Claims
+		if _, err := tm.ParseWithClaims(tokenStr, secret, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !got.Legacy {
+			t.Errorf("expected claims to be marked legacy")
+		}
+		if got.Roles != "CA-medium,CA-large" {
+			t.Errorf("got roles %q, want %q", got.Roles, "CA-medium,CA-large")
+		}
+	})
+
+	t.Run("it refuses legacy tokens after the upgrade window", func(t *testing.T) {
+		secret := "secret"
+		tokenStr := legacyToken(t, secret, time.Now().Add(time.Hour))
+
+		for _, tm := range []token.Manager{
+			jwx.NewTokenManager(jwx.HS256),
+			jwx.NewTokenManager(jwx.HS256, jwx.WithLegacyTokensUntil(time.Now().Add(-time.Hour))),
+		} {
+			_, err := tm.ParseWithClaims(tokenStr, secret, &token.Claims{})
+			if err != token.ErrLegacy {
+				t.Errorf("got %v, want %v", err, token.ErrLegacy)
+			}
+		}
+	})
+
+	t.Run("it returns an expired error for expired legacy tokens", func(t *testing.T) {
+		secret := "secret"
+		tokenStr := legacyToken(t, secret, time.Now().Add(-time.Hour))
+
+		tm := jwx.NewTokenManager(jwx.HS256, jwx.WithLegacyTokensUntil(time.Now().Add(time.Hour)))
+		_, err := tm.ParseWithClaims(tokenStr, secret, &token.Claims{})
+		if err != token.ErrExpired {
+			t.Errorf("got %v, want %v", err, token.ErrExpired)
+		}
+	})
+
+	t.Run("it uses the default issuer and audience", func(t *testing.T) {
+		secret := "secret"
+		tm := jwx.NewTokenManager(jwx.HS256)
+
+		p, err := tm.NewPair(token.Config{
+			Tenant:            "tenant",
+			Roles:             []string{"role"},
+			JWTSigningSecret:  secret,
+			RefreshExpiration: time.Hour,
+			AccessExpiration:  time.Minute,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got token.Claims
+		if _, err := tm.ParseWithClaims(p.Access, secret, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Issuer != jwx.DefaultIssuer || got.Audience != jwx.DefaultAudience {
+			t.Errorf("got issuer %q audience %q, want %q %q", got.Issuer, got.Audience, jwx.DefaultIssuer, jwx.DefaultAudience)
+		}
+	})
 }
 
 func TestGenerateAdminToken(t *testing.T) {
@@ -166,6 +344,50 @@ func TestGenerateAdminToken(t *testing.T) {
 	}
 }
 
+func TestGenerateAdminToken_Organization(t *testing.T) {
+	got, err := jwx.GenerateAdminToken(context.Background(), &pb.GenerateAdminTokenRequest{
+		AdminName:        "admin",
+		JWTSigningSecret: "secret",
+		Organization:     "org",
+	})
+	checkError(t, err)
+
+	var tokenData struct {
+		Access string `yaml:"Access"`
+	}
+	err = yaml.Unmarshal(got.Token, &tokenData)
+	checkError(t, err)
+
+	var claims token.Claims
+	_, err = jwx.NewTokenManager(jwx.HS256).ParseWithClaims(tokenData.Access, "secret", &claims)
+	checkError(t, err)
+
+	if claims.Organization != "org" {
+		t.Errorf("got organization %q, want %q", claims.Organization, "org")
+	}
+}
+
+func TestGenerateBootstrapToken(t *testing.T) {
+	got, err := jwx.GenerateBootstrapToken("cluster-1", "secret", time.Hour)
+	checkError(t, err)
+
+	var claims token.Claims
+	_, err = jwx.NewTokenManager(jwx.HS256).ParseWithClaims(got, "secret", &claims)
+	checkError(t, err)
+
+	if claims.Subject != token.SubjectBootstrap || claims.Cluster != "cluster-1" {
+		t.Errorf("got subject %q, cluster %q, want %q, %q", claims.Subject, claims.Cluster, token.SubjectBootstrap, "cluster-1")
+	}
+	if claims.Group != "" || claims.Roles != "" {
+		t.Errorf("got group %q, roles %q, want none", claims.Group, claims.Roles)
+	}
+
+	_, err = jwx.GenerateBootstrapToken(" ", "secret", time.Hour)
+	if err == nil {
+		t.Error("expected an error for an empty cluster")
+	}
+}
+
 func TestRefreshAdminToken(t *testing.T) {
 	secret := "secret"
 	t.Run("it refreshes an admin token", func(t *testing.T) {
@@ -278,3 +500,246 @@ func checkError(t *testing.T, err error) {
 		t.Fatal(err)
 	}
 }
+
+func TestLegacyWindowEnd(t *testing.T) {
+	t.Run("it does not open a window by default", func(t *testing.T) {
+		got, err := jwx.LegacyWindowEnd("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.IsZero() {
+			t.Errorf("got %v, want the zero time", got)
+		}
+	})
+
+	t.Run("it parses an RFC 3339 time", func(t *testing.T) {
+		got, err := jwx.LegacyWindowEnd("2030-01-02T15:04:05Z")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC)
+		if !got.Equal(want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("it returns an error for an invalid time", func(t *testing.T) {
+		if _, err := jwx.LegacyWindowEnd("next month"); err == nil {
+			t.Errorf("expected non-nil err")
+		}
+	})
+}
+
+// legacyToken returns a token in the legacy claim layout, with the roles as a
+// list.
+func legacyToken(t *testing.T, secret string, exp time.Time) string {
+	t.Helper()
+	tkn := jwt.New()
+	claims := map[string]interface{}{
+		jwt.IssuerKey:     "com.dell.karavi",
+		jwt.AudienceKey:   "karavi",
+		jwt.SubjectKey:    "karavi-tenant",
+		jwt.ExpirationKey: exp.Unix(),
+		"roles":           []string{"CA-medium", "CA-large"},
+		"group":           "PancakeGroup",
+	}
+	for k, v := range claims {
+		if err := tkn.Set(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := jwt.Sign(tkn, jwa.HS256, []byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestEncryption(t *testing.T) {
+	secret := "secret"
+	cfg := token.Config{
+		Tenant:            "tenant",
+		Roles:             []string{"role"},
+		JWTSigningSecret:  secret,
+		RefreshExpiration: time.Hour,
+		AccessExpiration:  time.Minute,
+	}
+	tm := jwx.NewTokenManager(jwx.HS256, jwx.WithEncryptionKey("encryption-key"))
+
+	t.Run("it encrypts and parses tokens", func(t *testing.T) {
+		p, err := tm.NewPair(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, s := range []string{p.Access, p.Refresh} {
+			if got := strings.Count(s, "."); got != 4 {
+				t.Errorf("got %d dots, want the 4 of a JWE", got)
+			}
+			var claims token.Claims
+			if _, err := tm.ParseWithClaims(s, secret, &claims); err != nil {
+				t.Fatal(err)
+			}
+			if claims.Group != "tenant" || claims.Roles != "role" {
+				t.Errorf("got %+v, want the claims of the tenant", claims)
+			}
+		}
+	})
+
+	t.Run("it encrypts refreshed tokens", func(t *testing.T) {
+		tkn, err := tm.NewWithClaims(token.Claims{Group: "tenant", ExpiresAt: time.Now().Add(time.Minute).Unix()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := tkn.SignedString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var claims token.Claims
+		if _, err := tm.ParseWithClaims(s, secret, &claims); err != nil {
+			t.Fatal(err)
+		}
+		if claims.Group != "tenant" {
+			t.Errorf("got group %q, want %q", claims.Group, "tenant")
+		}
+	})
+
+	t.Run("it parses plain tokens", func(t *testing.T) {
+		p, err := jwx.NewTokenManager(jwx.HS256).NewPair(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var claims token.Claims
+		if _, err := tm.ParseWithClaims(p.Access, secret, &claims); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("it refuses encrypted tokens with another key", func(t *testing.T) {
+		p, err := tm.NewPair(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, other := range []token.Manager{
+			jwx.NewTokenManager(jwx.HS256),
+			jwx.NewTokenManager(jwx.HS256, jwx.WithEncryptionKey("other-key")),
+		} {
+			var claims token.Claims
+			if _, err := other.ParseWithClaims(p.Access, secret, &claims); err == nil {
+				t.Error("expected an error")
+			}
+		}
+	})
+}
+
+func TestSigningAlgorithm(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}))
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	cfg := token.Config{
+		Tenant:            "tenant",
+		Roles:             []string{"role"},
+		RefreshExpiration: time.Hour,
+		AccessExpiration:  time.Minute,
+	}
+
+	for name, tc := range map[string]struct {
+		alg            jwx.SignatureAlgorithm
+		secret, verify string
+	}{
+		"HS512":              {jwx.HS512, "secret", "secret"},
+		"RS256":              {jwx.RS256, privatePEM, privatePEM},
+		"RS256 verification": {jwx.RS256, privatePEM, publicPEM},
+	} {
+		t.Run("it signs and parses tokens with "+name, func(t *testing.T) {
+			tm := jwx.NewTokenManager(jwx.HS256, jwx.WithSigningAlgorithm(tc.alg))
+			cfg := cfg
+			cfg.JWTSigningSecret = tc.secret
+
+			p, err := tm.NewPair(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, s := range []string{p.Access, p.Refresh} {
+				var claims token.Claims
+				if _, err := tm.ParseWithClaims(s, tc.verify, &claims); err != nil {
+					t.Fatal(err)
+				}
+				if claims.Group != "tenant" {
+					t.Errorf("got group %q, want %q", claims.Group, "tenant")
+				}
+			}
+		})
+	}
+	t.Run("it refuses tokens signed with another algorithm", func(t *testing.T) {
+		cfg := cfg
+		cfg.JWTSigningSecret = "secret"
+		p, err := jwx.NewTokenManager(jwx.HS256).NewPair(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var claims token.Claims
+		if _, err := jwx.NewTokenManager(jwx.HS512).ParseWithClaims(p.Access, "secret", &claims); err == nil {
+			t.Error("expected an error")
+		}
+	})
+	t.Run("it refuses HMAC tokens signed with the RSA public key", func(t *testing.T) {
+		cfg := cfg
+		cfg.JWTSigningSecret = publicPEM
+		p, err := jwx.NewTokenManager(jwx.HS256).NewPair(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var claims token.Claims
+		if _, err := jwx.NewTokenManager(jwx.RS256).ParseWithClaims(p.Access, publicPEM, &claims); err == nil {
+			t.Error("expected an error")
+		}
+	})
+	t.Run("it requires an RSA private key to sign", func(t *testing.T) {
+		for _, secret := range []string{"secret", publicPEM} {
+			cfg := cfg
+			cfg.JWTSigningSecret = secret
+			if _, err := jwx.NewTokenManager(jwx.RS256).NewPair(cfg); err == nil {
+				t.Errorf("expected an error signing with %q", secret)
+			}
+		}
+	})
+}
+
+func TestParseSignatureAlgorithm(t *testing.T) {
+	tests := map[string]struct {
+		name    string
+		want    jwx.SignatureAlgorithm
+		wantErr bool
+	}{
+		"default":     {"", jwx.HS256, false},
+		"HS256":       {"HS256", jwx.HS256, false},
+		"HS512":       {"hs512", jwx.HS512, false},
+		"RS256":       {"RS256", jwx.RS256, false},
+		"unsupported": {"none", "", true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := jwx.ParseSignatureAlgorithm(tc.name)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got err %v, want error %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package session tracks the sessions of admin refresh tokens, so that a
+// session can be limited in time and revoked although the tokens themselves
+// are stateless.
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"karavi-authorization/internal/token"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Errors.
+var (
+	ErrRevoked  = errors.New("admin session has been revoked")
+	ErrExpired  = errors.New("admin session has expired")
+	ErrIdle     = errors.New("admin session has been idle for too long")
+	ErrNotFound = errors.New("admin session not found")
+)
+
+// Redis names.
+const (
+	KeySessions       = "admin:sessions"
+	FieldAdmin        = "admin"
+	FieldOrganization = "organization"
+	FieldCreatedAt    = "created_at"
+	FieldLastUsed     = "last_used"
+	FieldExpiresAt    = "expires_at"
+	FieldRevoked      = "revoked"
+)
+
+// Session is the use of an admin refresh token. A session starts when the
+// refresh token is first used.
+type Session struct {
+	ID           string    `json:"id"`
+	Admin        string    `json:"admin"`
+	Organization string    `json:"organization,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	LastUsed     time.Time `json:"lastUsed"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	Revoked      bool      `json:"revoked"`
+}
+
+// Option allows for functional option arguments on the Store.
+type Option func(*Store)
+
+// WithLifetime limits the time from the start of a session until its last
+// refresh. There is no limit other than the expiration of the refresh token
+// if d is zero.
+func WithLifetime(d time.Duration) Option {
+	return func(s *Store) {
+		s.lifetime = d
+	}
+}
+
+// WithIdleTimeout limits the time between two refreshes of a session. There
+// is no limit if d is zero.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(s *Store) {
+		s.idleTimeout = d
+	}
+}
+
+// WithNow overrides the current time, for testing.
+func WithNow(now func() time.Time) Option {
+	return func(s *Store) {
+		s.now = now
+	}
+}
+
+// Store keeps admin sessions in Redis. A session is kept until its refresh
+// token expires, so that a revoked or timed out session stays refused.
+type Store struct {
+	rdb         func() *redis.Client
+	lifetime    time.Duration
+	idleTimeout time.Duration
+	now         func() time.Time
+}
+
+// NewStore returns a Store that uses the current redis client.
+func NewStore(rdb func() *redis.Client, opts ...Option) *Store {
+	s := &Store{
+		rdb: rdb,
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ID returns the identifier of the session of a refresh token. The token
+// itself is not stored.
+func ID(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Refresh records a refresh of the admin access token with the refresh token
+// of the given claims, starting its session on first use. It returns an
+// error if the session may no longer be refreshed.
+func (s *Store) Refresh(refreshToken string, claims token.Claims) error {
+	rdb := s.rdb()
+	id := ID(refreshToken)
+	key := sessionKey(id)
+	now := s.now()
+
+	m, err := rdb.HGetAll(key).Result()
+	if err != nil {
+		return err
+	}
+	if len(m) == 0 {
+		expiresAt := time.Unix(claims.ExpiresAt, 0)
+		_, err = rdb.TxPipelined(func(pipe redis.Pipeliner) error {
+			pipe.HMSet(key, map[string]interface{}{
+				FieldAdmin:        claims.Group,
+				FieldOrganization: claims.Organization,
+				FieldCreatedAt:    now.Unix(),
+				FieldLastUsed:     now.Unix(),
+				FieldExpiresAt:    expiresAt.Unix(),
+			})
+			pipe.ExpireAt(key, expiresAt)
+			pipe.SAdd(KeySessions, id)
+			return nil
+		})
+		return err
+	}
+
+	sess, err := parseSession(id, m)
+	if err != nil {
+		return err
+	}
+	switch {
+	case sess.Revoked:
+		return ErrRevoked
+	case s.lifetime > 0 && now.Sub(sess.CreatedAt) > s.lifetime:
+		return ErrExpired
+	case s.idleTimeout > 0 && now.Sub(sess.LastUsed) > s.idleTimeout:
+		return ErrIdle
+	}
+	return rdb.HSet(key, FieldLastUsed, now.Unix()).Err()
+}
+
+// List returns the sessions, oldest first. If an organization is given,
+// only the sessions of its admins are listed.
+func (s *Store) List(organization string) ([]Session, error) {
+	rdb := s.rdb()
+	ids, err := rdb.SMembers(KeySessions).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []Session{}
+	for _, id := range ids {
+		m, err := rdb.HGetAll(sessionKey(id)).Result()
+		if err != nil {
+			return nil, err
+		}
+		// The session has expired with its refresh token.
+		if len(m) == 0 {
+			if err := rdb.SRem(KeySessions, id).Err(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		sess, err := parseSession(id, m)
+		if err != nil {
+			return nil, err
+		}
+		if organization != "" && sess.Organization != organization {
+			continue
+		}
+		sessions = append(sessions, sess)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].CreatedAt.Equal(sessions[j].CreatedAt) {
+			return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions, nil
+}
+
+// Revoke revokes the session so that its refresh token is refused. If an
+// organization is given, only the sessions of its admins may be revoked.
+func (s *Store) Revoke(id, organization string) error {
+	rdb := s.rdb()
+	key := sessionKey(id)
+	org, err := rdb.HGet(key, FieldOrganization).Result()
+	switch {
+	case err == redis.Nil:
+		return ErrNotFound
+	case err != nil:
+		return err
+	case organization != "" && org != organization:
+		return ErrNotFound
+	}
+	return rdb.HSet(key, FieldRevoked, true).Err()
+}
+
+func parseSession(id string, m map[string]string) (Session, error) {
+	sess := Session{
+		ID:           id,
+		Admin:        m[FieldAdmin],
+		Organization: m[FieldOrganization],
+		Revoked:      m[FieldRevoked] == "1" || m[FieldRevoked] == "true",
+	}
+	for field, dst := range map[string]*time.Time{
+		FieldCreatedAt: &sess.CreatedAt,
+		FieldLastUsed:  &sess.LastUsed,
+		FieldExpiresAt: &sess.ExpiresAt,
+	} {
+		sec, err := strconv.ParseInt(m[field], 10, 64)
+		if err != nil {
+			return Session{}, err
+		}
+		*dst = time.Unix(sec, 0)
+	}
+	return sess, nil
+}
+
+func sessionKey(id string) string {
+	return "admin:session:" + id
+}
@@ -0,0 +1,133 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session_test
+
+import (
+	"errors"
+	"karavi-authorization/internal/token"
+	"karavi-authorization/internal/token/session"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	claims := token.Claims{
+		Subject:   "csm-admin",
+		Group:     "admin-1",
+		ExpiresAt: now.Add(24 * time.Hour).Unix(),
+	}
+
+	newStore := func(t *testing.T, opts ...session.Option) (*session.Store, *miniredis.Miniredis) {
+		t.Helper()
+		mr, err := miniredis.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(mr.Close)
+		mr.SetTime(now)
+		rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { rdb.Close() })
+		opts = append(opts, session.WithNow(func() time.Time { return now }))
+		return session.NewStore(func() *redis.Client { return rdb }, opts...), mr
+	}
+
+	t.Run("it starts a session on the first refresh", func(t *testing.T) {
+		sut, mr := newStore(t)
+
+		checkError(t, sut.Refresh("refresh-1", claims))
+
+		got, err := sut.List("")
+		checkError(t, err)
+		if len(got) != 1 || got[0].ID != session.ID("refresh-1") || got[0].Admin != "admin-1" || !got[0].CreatedAt.Equal(now) {
+			t.Errorf("got sessions %+v, want the session of refresh-1", got)
+		}
+		if ttl := mr.TTL("admin:session:" + session.ID("refresh-1")); ttl <= 0 {
+			t.Errorf("got ttl %v, want the session to expire with its refresh token", ttl)
+		}
+	})
+	t.Run("it refuses a revoked session", func(t *testing.T) {
+		sut, _ := newStore(t)
+		checkError(t, sut.Refresh("refresh-1", claims))
+
+		checkError(t, sut.Revoke(session.ID("refresh-1"), ""))
+
+		if err := sut.Refresh("refresh-1", claims); !errors.Is(err, session.ErrRevoked) {
+			t.Errorf("got err = %v, want %v", err, session.ErrRevoked)
+		}
+	})
+	t.Run("it limits the lifetime of a session", func(t *testing.T) {
+		sut, _ := newStore(t, session.WithLifetime(time.Hour))
+		checkError(t, sut.Refresh("refresh-1", claims))
+
+		now = now.Add(30 * time.Minute)
+		checkError(t, sut.Refresh("refresh-1", claims))
+		now = now.Add(31 * time.Minute)
+		if err := sut.Refresh("refresh-1", claims); !errors.Is(err, session.ErrExpired) {
+			t.Errorf("got err = %v, want %v", err, session.ErrExpired)
+		}
+	})
+	t.Run("it slides the idle timeout with each refresh", func(t *testing.T) {
+		sut, _ := newStore(t, session.WithIdleTimeout(10*time.Minute))
+		checkError(t, sut.Refresh("refresh-1", claims))
+
+		for i := 0; i < 3; i++ {
+			now = now.Add(9 * time.Minute)
+			checkError(t, sut.Refresh("refresh-1", claims))
+		}
+		now = now.Add(11 * time.Minute)
+		if err := sut.Refresh("refresh-1", claims); !errors.Is(err, session.ErrIdle) {
+			t.Errorf("got err = %v, want %v", err, session.ErrIdle)
+		}
+	})
+	t.Run("it scopes sessions to an organization", func(t *testing.T) {
+		sut, _ := newStore(t)
+		orgClaims := claims
+		orgClaims.Organization = "org-1"
+		checkError(t, sut.Refresh("refresh-1", claims))
+		checkError(t, sut.Refresh("refresh-2", orgClaims))
+
+		got, err := sut.List("org-1")
+		checkError(t, err)
+		if len(got) != 1 || got[0].ID != session.ID("refresh-2") {
+			t.Errorf("got sessions %+v, want only the session of refresh-2", got)
+		}
+		if err := sut.Revoke(session.ID("refresh-1"), "org-1"); !errors.Is(err, session.ErrNotFound) {
+			t.Errorf("got err = %v, want %v", err, session.ErrNotFound)
+		}
+	})
+	t.Run("it drops expired sessions from the list", func(t *testing.T) {
+		sut, mr := newStore(t)
+		checkError(t, sut.Refresh("refresh-1", claims))
+
+		mr.FastForward(25 * time.Hour)
+
+		got, err := sut.List("")
+		checkError(t, err)
+		if len(got) != 0 {
+			t.Errorf("got sessions %+v, want none", got)
+		}
+	})
+}
+
+func checkError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
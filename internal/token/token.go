@@ -22,6 +22,15 @@ import (
 // ErrExpired is the error for an expired token
 var ErrExpired = errors.New("token has expired")
 
+// ErrLegacy is the error for a token in the legacy claim layout once the
+// legacy layout is no longer accepted.
+var ErrLegacy = errors.New("token is in the legacy format, which is no longer accepted")
+
+// SubjectBootstrap is the subject of the cluster-scoped bootstrap tokens,
+// which the proxy-server exchanges for tokens of a tenant it creates for
+// the cluster, if enabled.
+const SubjectBootstrap = "csm-bootstrap"
+
 // Claims represents the standard JWT claims in addition
 // to Karavi-Authorization specific claims.
 type Claims struct {
@@ -31,6 +40,31 @@ type Claims struct {
 	Subject   string `json:"sub,omitempty"`
 	Roles     string `json:"roles"`
 	Group     string `json:"group"`
+	TenantID  string `json:"tid,omitempty"`
+	// Organization scopes an admin token to the tenants of one
+	// organization. It is empty for admins of every tenant.
+	Organization string `json:"org,omitempty"`
+	// Custom are the claims that admins set on the tenant, e.g. a team,
+	// for site-specific policies.
+	Custom map[string]string `json:"custom,omitempty"`
+	// Cluster is the ID of the cluster a bootstrap token is scoped to.
+	Cluster string `json:"cluster,omitempty"`
+	// IssuedAt is set on refresh tokens only, for revoking the tokens
+	// issued to a tenant before a point in time.
+	IssuedAt int64 `json:"iat,omitempty"`
+	// Legacy is set on the claims of a token in the legacy claim layout,
+	// which has the roles as a list. It is accepted during upgrades only.
+	Legacy bool `json:"-"`
+}
+
+// TenantKey returns the identifier that the tenant's data is stored
+// under: the tenant UUID, or the group for tokens issued before tenants
+// were assigned one.
+func (c Claims) TenantKey() string {
+	if c.TenantID != "" {
+		return c.TenantID
+	}
+	return c.Group
 }
 
 // Pair represents a pair of tokens, refresh and access.
@@ -42,9 +76,13 @@ type Pair struct {
 // Config contains configurable options when creating tokens.
 type Config struct {
 	Tenant            string
+	TenantID          string
 	AdminName         string
+	Organization      string
+	Cluster           string
 	Subject           string
 	Roles             []string
+	Claims            map[string]string
 	JWTSigningSecret  string
 	RefreshExpiration time.Duration
 	AccessExpiration  time.Duration
@@ -0,0 +1,140 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The formats that usage may be exported in.
+const (
+	FormatCSV        = "csv"
+	FormatPrometheus = "prometheus"
+)
+
+// Sink receives the snapshots of an Exporter.
+type Sink interface {
+	Export(ctx context.Context, records []Record) error
+}
+
+// CSVDir writes each snapshot to a new CSV file in a directory, named after
+// the time of the snapshot.
+type CSVDir struct {
+	Dir string
+}
+
+// Export implements Sink.
+func (d *CSVDir) Export(_ context.Context, records []Record) error {
+	if err := os.MkdirAll(d.Dir, 0o750); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, records); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that readers never see a
+	// partial snapshot.
+	name := filepath.Join(d.Dir, fmt.Sprintf("usage-%s.csv", snapshotTime(records).Format("20060102T150405Z")))
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// snapshotTime returns the time of the records, or the current time if
+// there are none.
+func snapshotTime(records []Record) time.Time {
+	if len(records) == 0 {
+		return time.Now().UTC()
+	}
+	return records[0].Time.UTC()
+}
+
+// Exporter periodically exports a snapshot of the usage of every tenant.
+type Exporter struct {
+	Log      *logrus.Entry
+	Client   TenantClient
+	Sink     Sink
+	Interval time.Duration
+	// Now returns the current time; it defaults to time.Now.
+	Now func() time.Time
+}
+
+// Run exports a snapshot every interval until ctx is done. A failed export
+// is logged and retried at the next interval.
+func (e *Exporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Export(ctx); err != nil {
+				e.Log.WithError(err).Error("usage: exporting tenant usage")
+			}
+		}
+	}
+}
+
+// Export exports a snapshot of the usage of every tenant.
+func (e *Exporter) Export(ctx context.Context) error {
+	now := time.Now
+	if e.Now != nil {
+		now = e.Now
+	}
+
+	records, err := Collect(ctx, e.Client, "", now())
+	if err != nil {
+		return err
+	}
+	if err := e.Sink.Export(ctx, records); err != nil {
+		return err
+	}
+	e.Log.WithField("records", len(records)).Debug("usage: exported tenant usage")
+	return nil
+}
+
+// NewSink returns the sink of the format, writing CSV files to dir or
+// Prometheus remote-write requests to url.
+func NewSink(format, dir, url string, timeout time.Duration) (Sink, error) {
+	switch format {
+	case FormatCSV:
+		if dir == "" {
+			return nil, errors.New("usage export: the csv directory is not set")
+		}
+		return &CSVDir{Dir: dir}, nil
+	case FormatPrometheus:
+		if url == "" {
+			return nil, errors.New("usage export: the remote-write url is not set")
+		}
+		return &RemoteWriter{URL: url, Client: &http.Client{Timeout: timeout}}, nil
+	default:
+		return nil, fmt.Errorf("usage export: unknown format %q, want %q or %q", format, FormatCSV, FormatPrometheus)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+
+	"github.com/klauspost/compress/s2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The metrics that usage is written to Prometheus as.
+const (
+	MetricApprovedCapacity = "karavi_tenant_approved_capacity_kilobytes"
+	MetricApprovedVolumes  = "karavi_tenant_approved_volumes"
+)
+
+// RemoteWriter writes usage to a Prometheus remote-write endpoint.
+type RemoteWriter struct {
+	URL    string
+	Client *http.Client
+}
+
+// Export implements Sink.
+func (rw *RemoteWriter) Export(ctx context.Context, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	body := bytes.NewReader(s2.EncodeSnappy(nil, EncodeWriteRequest(records)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rw.URL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	client := rw.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("writing usage to %s: %w", rw.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("writing usage to %s: %s: %s", rw.URL, resp.Status, bytes.TrimSpace(b))
+	}
+	return nil
+}
+
+// EncodeWriteRequest encodes the records as a Prometheus remote-write
+// WriteRequest, uncompressed, with a time series of each metric for every
+// record.
+func EncodeWriteRequest(records []Record) []byte {
+	var b []byte
+	for _, r := range records {
+		for _, m := range []struct {
+			name  string
+			value int64
+		}{
+			{MetricApprovedCapacity, r.ApprovedCapacity},
+			{MetricApprovedVolumes, r.ApprovedVolumes},
+		} {
+			// The labels are sorted by name, as remote-write requires.
+			labels := [][2]string{
+				{"__name__", m.name},
+				{"pool", r.Pool},
+				{"system_id", r.SystemID},
+				{"system_type", r.SystemType},
+				{"tenant", r.Tenant},
+			}
+			b = protowire.AppendTag(b, 1, protowire.BytesType)
+			b = protowire.AppendBytes(b, encodeTimeSeries(labels, float64(m.value), r.Time.UnixMilli()))
+		}
+	}
+	return b
+}
+
+// encodeTimeSeries encodes a TimeSeries of a single sample.
+func encodeTimeSeries(labels [][2]string, value float64, timestamp int64) []byte {
+	var b []byte
+	for _, l := range labels {
+		var label []byte
+		label = protowire.AppendTag(label, 1, protowire.BytesType)
+		label = protowire.AppendString(label, l[0])
+		label = protowire.AppendTag(label, 2, protowire.BytesType)
+		label = protowire.AppendString(label, l[1])
+
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, label)
+	}
+
+	var sample []byte
+	sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+	sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+	sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+	sample = protowire.AppendVarint(sample, uint64(timestamp))
+
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	return protowire.AppendBytes(b, sample)
+}
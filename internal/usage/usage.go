@@ -0,0 +1,116 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package usage collects snapshots of the capacity approved for each tenant
+// and exports them, e.g. as CSV files for chargeback or to Prometheus.
+package usage
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"karavi-authorization/pb"
+	"sort"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Record is the capacity, in kilobytes, and the number of volumes approved
+// for a tenant in a storage pool when the snapshot was taken.
+type Record struct {
+	Time             time.Time `json:"time"`
+	Tenant           string    `json:"tenant"`
+	SystemType       string    `json:"systemType"`
+	SystemID         string    `json:"systemId"`
+	Pool             string    `json:"pool"`
+	ApprovedCapacity int64     `json:"approvedCapacity"`
+	ApprovedVolumes  int64     `json:"approvedVolumes"`
+}
+
+// TenantClient is the part of the tenant service that usage is collected
+// from.
+type TenantClient interface {
+	ListTenant(ctx context.Context, in *pb.ListTenantRequest, opts ...grpc.CallOption) (*pb.ListTenantResponse, error)
+	GetTenantQuota(ctx context.Context, in *pb.GetTenantQuotaRequest, opts ...grpc.CallOption) (*pb.TenantQuota, error)
+}
+
+// Collect returns a snapshot, taken at now, of the usage of the tenants in
+// the organization, or of every tenant if it is empty. The records are
+// ordered by tenant, then by storage pool.
+func Collect(ctx context.Context, client TenantClient, organization string, now time.Time) ([]Record, error) {
+	resp, err := client.ListTenant(ctx, &pb.ListTenantRequest{
+		Organization: organization,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing tenants: %w", err)
+	}
+
+	names := make([]string, 0, len(resp.GetTenants()))
+	for _, t := range resp.GetTenants() {
+		names = append(names, t.GetName())
+	}
+	sort.Strings(names)
+
+	var records []Record
+	for _, name := range names {
+		quota, err := client.GetTenantQuota(ctx, &pb.GetTenantQuotaRequest{
+			Name: name,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("getting quota of tenant %s: %w", name, err)
+		}
+		for _, p := range quota.GetPools() {
+			records = append(records, Record{
+				Time:             now.UTC(),
+				Tenant:           name,
+				SystemType:       p.GetSystemType(),
+				SystemID:         p.GetSystemId(),
+				Pool:             p.GetPool(),
+				ApprovedCapacity: p.GetApprovedCapacity(),
+				ApprovedVolumes:  p.GetApprovedVolumes(),
+			})
+		}
+	}
+	return records, nil
+}
+
+// csvHeader is the first row of the CSV format.
+var csvHeader = []string{"time", "tenant", "system_type", "system_id", "pool", "approved_capacity_kb", "approved_volumes"}
+
+// WriteCSV writes the records to w in the CSV format, preceded by a header.
+func WriteCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		err := cw.Write([]string{
+			r.Time.UTC().Format(time.RFC3339),
+			r.Tenant,
+			r.SystemType,
+			r.SystemID,
+			r.Pool,
+			strconv.FormatInt(r.ApprovedCapacity, 10),
+			strconv.FormatInt(r.ApprovedVolumes, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
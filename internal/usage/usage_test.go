@@ -0,0 +1,313 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"karavi-authorization/internal/tenantsvc/mocks"
+	"karavi-authorization/internal/usage"
+	"karavi-authorization/pb"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var snapshotTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func fakeTenantClient() *mocks.FakeTenantServiceClient {
+	return &mocks.FakeTenantServiceClient{
+		ListTenantFn: func(_ context.Context, _ *pb.ListTenantRequest, _ ...grpc.CallOption) (*pb.ListTenantResponse, error) {
+			return &pb.ListTenantResponse{Tenants: []*pb.Tenant{{Name: "tenant-b"}, {Name: "tenant-a"}}}, nil
+		},
+		GetTenantQuotaFn: func(_ context.Context, req *pb.GetTenantQuotaRequest, _ ...grpc.CallOption) (*pb.TenantQuota, error) {
+			if req.Name == "tenant-b" {
+				return &pb.TenantQuota{Name: req.Name}, nil
+			}
+			return &pb.TenantQuota{
+				Name: req.Name,
+				Pools: []*pb.PoolUsage{
+					{SystemType: "powerflex", SystemId: "542a2d5f5122210f", Pool: "bronze", ApprovedCapacity: 8388608, ApprovedVolumes: 1},
+					{SystemType: "powermax", SystemId: "000197900000", Pool: "SRP_1", ApprovedCapacity: 1048576, ApprovedVolumes: 2},
+				},
+			}, nil
+		},
+	}
+}
+
+func TestCollect(t *testing.T) {
+	t.Run("it collects the usage of every tenant", func(t *testing.T) {
+		got, err := usage.Collect(context.Background(), fakeTenantClient(), "", snapshotTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []usage.Record{
+			{Time: snapshotTime, Tenant: "tenant-a", SystemType: "powerflex", SystemID: "542a2d5f5122210f", Pool: "bronze", ApprovedCapacity: 8388608, ApprovedVolumes: 1},
+			{Time: snapshotTime, Tenant: "tenant-a", SystemType: "powermax", SystemID: "000197900000", Pool: "SRP_1", ApprovedCapacity: 1048576, ApprovedVolumes: 2},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Collect() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("it lists the tenants of the organization", func(t *testing.T) {
+		var gotOrg string
+		client := fakeTenantClient()
+		client.ListTenantFn = func(_ context.Context, req *pb.ListTenantRequest, _ ...grpc.CallOption) (*pb.ListTenantResponse, error) {
+			gotOrg = req.Organization
+			return &pb.ListTenantResponse{}, nil
+		}
+
+		got, err := usage.Collect(context.Background(), client, "acme", snapshotTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotOrg != "acme" || len(got) != 0 {
+			t.Errorf("got organization %q and %d records, want acme and none", gotOrg, len(got))
+		}
+	})
+
+	t.Run("it returns quota errors", func(t *testing.T) {
+		client := fakeTenantClient()
+		client.GetTenantQuotaFn = func(_ context.Context, _ *pb.GetTenantQuotaRequest, _ ...grpc.CallOption) (*pb.TenantQuota, error) {
+			return nil, errors.New("test error")
+		}
+
+		_, err := usage.Collect(context.Background(), client, "", snapshotTime)
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestWriteCSV(t *testing.T) {
+	records, err := usage.Collect(context.Background(), fakeTenantClient(), "", snapshotTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := usage.WriteCSV(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `time,tenant,system_type,system_id,pool,approved_capacity_kb,approved_volumes
+2024-03-01T12:00:00Z,tenant-a,powerflex,542a2d5f5122210f,bronze,8388608,1
+2024-03-01T12:00:00Z,tenant-a,powermax,000197900000,SRP_1,1048576,2
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteCSV() = %q, want %q", got, want)
+	}
+}
+
+// sample is a decoded time series of a remote-write request.
+type sample struct {
+	labels    map[string]string
+	value     float64
+	timestamp int64
+}
+
+// decodeWriteRequest decodes the time series of a WriteRequest.
+func decodeWriteRequest(t *testing.T, b []byte) []sample {
+	t.Helper()
+
+	// fields consumes the length-delimited and fixed fields of a message.
+	fields := func(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, n uint64)) {
+		for len(b) > 0 {
+			num, typ, l := protowire.ConsumeTag(b)
+			if l < 0 {
+				t.Fatal(protowire.ParseError(l))
+			}
+			b = b[l:]
+			switch typ {
+			case protowire.BytesType:
+				v, l := protowire.ConsumeBytes(b)
+				fn(num, typ, v, 0)
+				b = b[l:]
+			case protowire.Fixed64Type:
+				v, l := protowire.ConsumeFixed64(b)
+				fn(num, typ, nil, v)
+				b = b[l:]
+			case protowire.VarintType:
+				v, l := protowire.ConsumeVarint(b)
+				fn(num, typ, nil, v)
+				b = b[l:]
+			default:
+				t.Fatalf("unexpected wire type %d", typ)
+			}
+		}
+	}
+
+	var samples []sample
+	fields(b, func(_ protowire.Number, _ protowire.Type, ts []byte, _ uint64) {
+		s := sample{labels: make(map[string]string)}
+		var names []string
+		fields(ts, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) {
+			switch num {
+			case 1:
+				var name, value string
+				fields(v, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) {
+					if num == 1 {
+						name = string(v)
+					} else {
+						value = string(v)
+					}
+				})
+				names = append(names, name)
+				s.labels[name] = value
+			case 2:
+				fields(v, func(num protowire.Number, _ protowire.Type, _ []byte, n uint64) {
+					if num == 1 {
+						s.value = math.Float64frombits(n)
+					} else {
+						s.timestamp = int64(n)
+					}
+				})
+			}
+		})
+		for i := 1; i < len(names); i++ {
+			if names[i-1] >= names[i] {
+				t.Errorf("labels %v are not sorted", names)
+			}
+		}
+		samples = append(samples, s)
+	})
+	return samples
+}
+
+func TestRemoteWriter_Export(t *testing.T) {
+	t.Run("it writes the usage as time series", func(t *testing.T) {
+		var got []sample
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+				t.Errorf("unexpected headers %v", r.Header)
+			}
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err = s2.Decode(nil, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = decodeWriteRequest(t, b)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer ts.Close()
+
+		records := []usage.Record{
+			{Time: snapshotTime, Tenant: "tenant-a", SystemType: "powerflex", SystemID: "542a2d5f5122210f", Pool: "bronze", ApprovedCapacity: 8388608, ApprovedVolumes: 1},
+		}
+		sut := &usage.RemoteWriter{URL: ts.URL}
+		if err := sut.Export(context.Background(), records); err != nil {
+			t.Fatal(err)
+		}
+
+		labels := map[string]string{"pool": "bronze", "system_id": "542a2d5f5122210f", "system_type": "powerflex", "tenant": "tenant-a"}
+		withName := func(name string) map[string]string {
+			m := map[string]string{"__name__": name}
+			for k, v := range labels {
+				m[k] = v
+			}
+			return m
+		}
+		want := []sample{
+			{labels: withName(usage.MetricApprovedCapacity), value: 8388608, timestamp: snapshotTime.UnixMilli()},
+			{labels: withName(usage.MetricApprovedVolumes), value: 1, timestamp: snapshotTime.UnixMilli()},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("it returns rejected writes", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "out of order sample", http.StatusBadRequest)
+		}))
+		defer ts.Close()
+
+		sut := &usage.RemoteWriter{URL: ts.URL}
+		err := sut.Export(context.Background(), []usage.Record{{Time: snapshotTime, Tenant: "tenant-a"}})
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestExporter_Export(t *testing.T) {
+	dir := t.TempDir()
+	sut := &usage.Exporter{
+		Log:    logrus.NewEntry(logrus.New()),
+		Client: fakeTenantClient(),
+		Sink:   &usage.CSVDir{Dir: dir},
+		Now:    func() time.Time { return snapshotTime },
+	}
+
+	if err := sut.Export(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "usage-20240301T120000Z.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte("tenant-a,powermax,000197900000,SRP_1,1048576,2")) {
+		t.Errorf("expected the usage in the snapshot, got %s", b)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected a single snapshot file, got %d files", len(entries))
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		dir     string
+		url     string
+		wantErr bool
+	}{
+		{"csv", usage.FormatCSV, "/var/lib/usage", "", false},
+		{"csv without a directory", usage.FormatCSV, "", "", true},
+		{"prometheus", usage.FormatPrometheus, "", "http://prometheus:9090/api/v1/write", false},
+		{"prometheus without a url", usage.FormatPrometheus, "", "", true},
+		{"unknown format", "xml", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := usage.NewSink(tt.format, tt.dir, tt.url, time.Second)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSink() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
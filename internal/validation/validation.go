@@ -0,0 +1,421 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package validation checks requests to the tenant, role and storage
+// services before they are served, so that malformed requests are refused
+// with InvalidArgument rather than failing in Kubernetes or Redis.
+package validation
+
+import (
+	"context"
+	"fmt"
+	storage "karavi-authorization/cmd/karavictl/cmd"
+	"karavi-authorization/internal/role-service/roles"
+	"karavi-authorization/pb"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Size limits of request fields.
+const (
+	MaxNameLength    = 253
+	MaxFieldLength   = 1024
+	MaxTokenLength   = 16 * 1024
+	MaxVolumeNames   = 1024
+	MaxSdcs          = 1024
+	MaxClaims        = 64
+	MaxPageSize      = 1000
+	maxQuotaFieldLen = 64
+)
+
+var (
+	// names become part of Redis keys, which are separated by colons
+	nameRegexp = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?$`)
+	// claim keys are used as rego references, e.g. input.claims.custom.team
+	claimKeyRegexp   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	powerFlexIDRegex = regexp.MustCompile(`^[0-9a-fA-F]{16}$`)
+	powerMaxIDRegex  = regexp.MustCompile(`^[0-9]{12}$`)
+)
+
+// UnaryServerInterceptor returns an interceptor that refuses requests that
+// are not valid. The returned error has the InvalidArgument code and a
+// BadRequest detail with a violation for each invalid field.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := Request(req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Request validates a request to one of the services. Requests of other
+// types are valid.
+func Request(req interface{}) error {
+	var v violations
+
+	switch r := req.(type) {
+	// tenant service
+	case *pb.CreateTenantRequest:
+		if r.Tenant == nil {
+			v.add("tenant", "is required")
+			break
+		}
+		v.name("tenant.name", r.Tenant.Name)
+		v.optionalName("tenant.organization", r.Tenant.Organization)
+	case *pb.UpdateTenantRequest:
+		v.name("TenantName", r.TenantName)
+	case *pb.GetTenantRequest:
+		v.name("name", r.Name)
+	case *pb.DeleteTenantRequest:
+		v.name("name", r.Name)
+	case *pb.RestoreTenantRequest:
+		v.name("name", r.Name)
+	case *pb.ListTenantRequest:
+		if r.PageSize < 0 || r.PageSize > MaxPageSize {
+			v.add("page_size", "must be between 0 and %d", MaxPageSize)
+		}
+		if r.PageToken < 0 {
+			v.add("page_token", "must not be negative")
+		}
+		v.optionalName("organization", r.Organization)
+	case *pb.BindRoleRequest:
+		v.name("TenantName", r.TenantName)
+		v.name("RoleName", r.RoleName)
+	case *pb.UnbindRoleRequest:
+		v.name("TenantName", r.TenantName)
+		v.name("RoleName", r.RoleName)
+	case *pb.AllowSdcRequest:
+		v.name("TenantName", r.TenantName)
+		v.sdcs("Sdcs", r.Sdcs)
+	case *pb.DisallowSdcRequest:
+		v.name("TenantName", r.TenantName)
+		v.sdcs("Sdcs", r.Sdcs)
+	case *pb.SetProtectedRequest:
+		v.name("TenantName", r.TenantName)
+	case *pb.SetVolumeNamePolicyRequest:
+		v.name("TenantName", r.TenantName)
+		if len(r.VolumePrefix) > MaxNameLength {
+			v.add("volumePrefix", "must not be longer than %d characters", MaxNameLength)
+		}
+		v.pattern("volumePattern", r.VolumePattern)
+		for i, part := range r.Remove {
+			if part != "prefix" && part != "pattern" {
+				v.add(fmt.Sprintf("remove[%d]", i), "must be prefix or pattern")
+			}
+		}
+	case *pb.SetClaimsRequest:
+		v.name("TenantName", r.TenantName)
+		v.claims("claims", r.Claims, r.Remove)
+	case *pb.GenerateTokenRequest:
+		v.name("TenantName", r.TenantName)
+		if r.RefreshTokenTTL < 0 {
+			v.add("RefreshTokenTTL", "must not be negative")
+		}
+		if r.AccessTokenTTL < 0 {
+			v.add("AccessTokenTTL", "must not be negative")
+		}
+	case *pb.RefreshTokenRequest:
+		v.token("RefreshToken", r.RefreshToken)
+		v.token("AccessToken", r.AccessToken)
+		v.required("JWTSigningSecret", r.JWTSigningSecret, MaxFieldLength)
+	case *pb.RevokeTenantRequest:
+		v.name("TenantName", r.TenantName)
+	case *pb.CancelRevokeTenantRequest:
+		v.name("TenantName", r.TenantName)
+	case *pb.CreateOrganizationRequest:
+		if r.Organization == nil {
+			v.add("organization", "is required")
+			break
+		}
+		v.name("organization.name", r.Organization.Name)
+	case *pb.GetOrganizationRequest:
+		v.name("name", r.Name)
+	case *pb.DeleteOrganizationRequest:
+		v.name("name", r.Name)
+	case *pb.SetDefaultRoleRequest:
+		v.optionalName("RoleName", r.RoleName)
+
+	// report service
+	case *pb.GetUsageHistoryRequest:
+		from, fromOK := v.date("from", r.From)
+		to, toOK := v.date("to", r.To)
+		if fromOK && toOK && to.Before(from) {
+			v.add("to", "must not be before from")
+		}
+		v.optionalName("tenant", r.Tenant)
+		v.optionalName("organization", r.Organization)
+
+	// role service
+	case *pb.RoleCreateRequest:
+		v.role(r.Name, r.StorageType, r.SystemId, r.Pool, r.Quota, true)
+	case *pb.RoleUpdateRequest:
+		v.role(r.Name, r.StorageType, r.SystemId, r.Pool, r.Quota, true)
+	case *pb.RoleDeleteRequest:
+		v.role(r.Name, r.StorageType, r.SystemId, r.Pool, r.Quota, false)
+	case *pb.RoleGetRequest:
+		v.name("name", r.Name)
+
+	// storage service
+	case *pb.StorageCreateRequest:
+		v.storage(r.StorageType, r.SystemId, r.Endpoint, r.UserName, r.Password)
+	case *pb.StorageUpdateRequest:
+		v.storage(r.StorageType, r.SystemId, r.Endpoint, r.UserName, r.Password)
+	case *pb.StorageDeleteRequest:
+		v.storageType("storageType", r.StorageType)
+		v.systemID("systemId", r.StorageType, r.SystemId)
+	case *pb.StorageGetRequest:
+		v.storageType("storageType", r.StorageType)
+		v.systemID("systemId", r.StorageType, r.SystemId)
+	case *pb.ListVolumesRequest:
+		v.storageType("storageType", r.StorageType)
+		v.systemID("systemId", r.StorageType, r.SystemId)
+	case *pb.GetPowerflexVolumesRequest:
+		v.systemID("systemId", "powerflex", r.SystemId)
+		if len(r.VolumeName) > MaxVolumeNames {
+			v.add("volumeName", "must not have more than %d names", MaxVolumeNames)
+		}
+		for i, name := range r.VolumeName {
+			v.required(fmt.Sprintf("volumeName[%d]", i), name, MaxFieldLength)
+		}
+	}
+
+	return v.err()
+}
+
+// violations collects the invalid fields of a request.
+type violations []*errdetails.BadRequest_FieldViolation
+
+func (v *violations) add(field, format string, a ...interface{}) {
+	*v = append(*v, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: fmt.Sprintf(format, a...),
+	})
+}
+
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, 0, len(v))
+	for _, fv := range v {
+		msgs = append(msgs, fmt.Sprintf("%s %s", fv.Field, fv.Description))
+	}
+	st := status.New(codes.InvalidArgument, fmt.Sprintf("invalid request: %s", strings.Join(msgs, "; ")))
+	detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: v})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func (v *violations) required(field, value string, maxLen int) bool {
+	switch {
+	case strings.TrimSpace(value) == "":
+		v.add(field, "is required")
+		return false
+	case len(value) > maxLen:
+		v.add(field, "must not be longer than %d characters", maxLen)
+		return false
+	}
+	return true
+}
+
+func (v *violations) name(field, value string) {
+	if !v.required(field, value, MaxNameLength) {
+		return
+	}
+	if !nameRegexp.MatchString(value) {
+		v.add(field, "must consist of letters, digits, '-', '_' or '.', and start and end with a letter or digit")
+	}
+}
+
+// sdcs checks a list of SDC GUIDs or IPs.
+func (v *violations) sdcs(field string, values []string) {
+	switch {
+	case len(values) == 0:
+		v.add(field, "is required")
+	case len(values) > MaxSdcs:
+		v.add(field, "must not have more than %d sdcs", MaxSdcs)
+	}
+	for i, sdc := range values {
+		v.required(fmt.Sprintf("%s[%d]", field, i), sdc, MaxNameLength)
+	}
+}
+
+// claims checks the claims to set on a tenant and the keys to remove.
+func (v *violations) claims(field string, claims map[string]string, remove []string) {
+	if len(claims) > MaxClaims {
+		v.add(field, "must not have more than %d claims", MaxClaims)
+	}
+	keys := make([]string, 0, len(claims))
+	for k := range claims {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v.claimKey(fmt.Sprintf("%s[%s]", field, k), k)
+		if len(claims[k]) > MaxFieldLength {
+			v.add(fmt.Sprintf("%s[%s]", field, k), "must not be longer than %d characters", MaxFieldLength)
+		}
+	}
+	for i, k := range remove {
+		v.claimKey(fmt.Sprintf("remove[%d]", i), k)
+	}
+}
+
+func (v *violations) claimKey(field, key string) {
+	if !v.required(field, key, MaxNameLength) {
+		return
+	}
+	if !claimKeyRegexp.MatchString(key) {
+		v.add(field, "must consist of letters, digits or '_', and not start with a digit")
+	}
+}
+
+// pattern checks an optional regular expression.
+func (v *violations) pattern(field, value string) {
+	if value == "" {
+		return
+	}
+	if len(value) > MaxFieldLength {
+		v.add(field, "must not be longer than %d characters", MaxFieldLength)
+		return
+	}
+	if _, err := regexp.Compile(value); err != nil {
+		v.add(field, "must be a valid regular expression")
+	}
+}
+
+func (v *violations) optionalName(field, value string) {
+	if value != "" {
+		v.name(field, value)
+	}
+}
+
+// date checks a day in the YYYY-MM-DD format.
+func (v *violations) date(field, value string) (time.Time, bool) {
+	if !v.required(field, value, MaxFieldLength) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		v.add(field, "must be a date as YYYY-MM-DD")
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func (v *violations) token(field, value string) {
+	if !v.required(field, value, MaxTokenLength) {
+		return
+	}
+	if strings.Count(value, ".") != 2 {
+		v.add(field, "must be a JSON web token")
+	}
+}
+
+func (v *violations) storageType(field, value string) bool {
+	if !v.required(field, value, MaxFieldLength) {
+		return false
+	}
+	if _, ok := storage.SupportedStorageTypes[value]; !ok {
+		v.add(field, "must be one of the supported storage types")
+		return false
+	}
+	return true
+}
+
+func (v *violations) systemID(field, storageType, value string) {
+	if !v.required(field, value, MaxFieldLength) {
+		return
+	}
+	switch storageType {
+	case "powerflex":
+		if !powerFlexIDRegex.MatchString(value) {
+			v.add(field, "must be a PowerFlex system ID of 16 hexadecimal digits")
+		}
+	case "powermax":
+		if !powerMaxIDRegex.MatchString(value) {
+			v.add(field, "must be a PowerMax symmetrix ID of 12 digits")
+		}
+	default:
+		if strings.ContainsAny(value, ": \t\n") {
+			v.add(field, "must not contain colons or whitespace")
+		}
+	}
+}
+
+func (v *violations) quota(field, value string) {
+	if len(value) > maxQuotaFieldLen {
+		v.add(field, "must not be longer than %d characters", maxQuotaFieldLen)
+		return
+	}
+	if _, err := roles.ParseQuota(value); err != nil {
+		v.add(field, "must be a size such as 100GB or 1TiB, or a number of kilobytes")
+	}
+}
+
+// role validates the fields of a role request. Deletion only needs the
+// name; the other fields are checked if they are given.
+func (v *violations) role(name, storageType, systemID, pool, quota string, all bool) {
+	v.name("name", name)
+	if !all && storageType == "" && systemID == "" && pool == "" && quota == "" {
+		return
+	}
+
+	if v.storageType("storageType", storageType) {
+		v.systemID("systemId", storageType, systemID)
+	}
+	v.required("pool", pool, MaxFieldLength)
+	// A PowerFlex role may permit any pool of a protection domain, named
+	// with the protection domain prefix.
+	pd := strings.TrimPrefix(pool, roles.ProtectionDomainPrefix)
+	switch {
+	case pd == pool || storageType != "powerflex":
+		if strings.Contains(pool, ":") {
+			v.add("pool", "must not contain colons")
+		}
+	case pd == "" || strings.Contains(pd, ":"):
+		v.add("pool", "must name a protection domain without colons after %q", roles.ProtectionDomainPrefix)
+	}
+	if all || quota != "" {
+		if v.required("quota", quota, maxQuotaFieldLen) {
+			v.quota("quota", quota)
+		}
+	}
+}
+
+func (v *violations) storage(storageType, systemID, endpoint, user, password string) {
+	if v.storageType("storageType", storageType) {
+		v.systemID("systemId", storageType, systemID)
+	}
+	if v.required("endpoint", endpoint, MaxFieldLength) {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			v.add("endpoint", "must be an http or https URL with a host")
+		}
+	}
+	v.required("userName", user, MaxFieldLength)
+	v.required("password", password, MaxFieldLength)
+}
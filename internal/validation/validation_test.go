@@ -0,0 +1,218 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation_test
+
+import (
+	"context"
+	"karavi-authorization/internal/validation"
+	"karavi-authorization/pb"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRequest(t *testing.T) {
+	tests := map[string]struct {
+		req        interface{}
+		wantFields []string
+	}{
+		"valid tenant": {
+			req: &pb.CreateTenantRequest{Tenant: &pb.Tenant{Name: "tenant-1"}},
+		},
+		"missing tenant": {
+			req:        &pb.CreateTenantRequest{},
+			wantFields: []string{"tenant"},
+		},
+		"tenant name with colon": {
+			req:        &pb.CreateTenantRequest{Tenant: &pb.Tenant{Name: "tenant:1", Organization: "org 1"}},
+			wantFields: []string{"tenant.name", "tenant.organization"},
+		},
+		"tenant name too long": {
+			req:        &pb.GetTenantRequest{Name: strings.Repeat("a", validation.MaxNameLength+1)},
+			wantFields: []string{"name"},
+		},
+		"negative token ttl": {
+			req:        &pb.GenerateTokenRequest{TenantName: "tenant-1", AccessTokenTTL: -1},
+			wantFields: []string{"AccessTokenTTL"},
+		},
+		"malformed refresh token": {
+			req:        &pb.RefreshTokenRequest{RefreshToken: "token", AccessToken: "a.b.c", JWTSigningSecret: "secret"},
+			wantFields: []string{"RefreshToken"},
+		},
+		"allow sdcs": {
+			req: &pb.AllowSdcRequest{TenantName: "tenant-1", Sdcs: []string{"0e7a082862fedf0f", "10.0.0.1"}},
+		},
+		"disallow no sdcs": {
+			req:        &pb.DisallowSdcRequest{TenantName: "tenant-1", Sdcs: []string{""}},
+			wantFields: []string{"Sdcs[0]"},
+		},
+		"tenant claims": {
+			req: &pb.SetClaimsRequest{TenantName: "tenant-1", Claims: map[string]string{"team": "payments", "cost_center": "42"}, Remove: []string{"env"}},
+		},
+		"invalid tenant claims": {
+			req:        &pb.SetClaimsRequest{TenantName: "tenant-1", Claims: map[string]string{"team-name": "payments", "env": strings.Repeat("a", validation.MaxFieldLength+1)}, Remove: []string{"1st"}},
+			wantFields: []string{"claims[env]", "claims[team-name]", "remove[0]"},
+		},
+		"invalid restored tenant": {
+			req:        &pb.RestoreTenantRequest{Name: "tenant:1"},
+			wantFields: []string{"name"},
+		},
+		"tenant protection": {
+			req: &pb.SetProtectedRequest{TenantName: "tenant-1", Protected: true},
+		},
+		"volume name policy": {
+			req: &pb.SetVolumeNamePolicyRequest{TenantName: "tenant-1", VolumePrefix: "t1-", VolumePattern: "^t1-[a-z0-9]+$"},
+		},
+		"invalid volume name policy": {
+			req:        &pb.SetVolumeNamePolicyRequest{TenantName: "tenant-1", VolumePrefix: strings.Repeat("a", validation.MaxNameLength+1), VolumePattern: "t1-(", Remove: []string{"suffix"}},
+			wantFields: []string{"remove[0]", "volumePattern", "volumePrefix"},
+		},
+		"usage history": {
+			req: &pb.GetUsageHistoryRequest{From: "2024-03-01", To: "2024-03-31", Tenant: "tenant-1"},
+		},
+		"invalid usage history": {
+			req:        &pb.GetUsageHistoryRequest{From: "2024-03-31", To: "2024-03-01", Organization: "org 1"},
+			wantFields: []string{"organization", "to"},
+		},
+		"malformed usage history dates": {
+			req:        &pb.GetUsageHistoryRequest{From: "03/01/2024"},
+			wantFields: []string{"from", "to"},
+		},
+		"valid role": {
+			req: &pb.RoleCreateRequest{Name: "role-1", StorageType: "powerflex", SystemId: "542a2d5f5122210f", Pool: "bronze", Quota: "10GB"},
+		},
+		"role quota in kilobytes": {
+			req: &pb.RoleUpdateRequest{Name: "role-1", StorageType: "powerflex", SystemId: "542a2d5f5122210f", Pool: "bronze", Quota: "1000"},
+		},
+		"role of a protection domain": {
+			req: &pb.RoleCreateRequest{Name: "role-1", StorageType: "powerflex", SystemId: "542a2d5f5122210f", Pool: "pd:domain1", Quota: "10GB"},
+		},
+		"empty protection domain": {
+			req:        &pb.RoleCreateRequest{Name: "role-1", StorageType: "powerflex", SystemId: "542a2d5f5122210f", Pool: "pd:", Quota: "10GB"},
+			wantFields: []string{"pool"},
+		},
+		"protection domain pool of another storage type": {
+			req:        &pb.RoleCreateRequest{Name: "role-1", StorageType: "powermax", SystemId: "000197900714", Pool: "pd:domain1", Quota: "10GB"},
+			wantFields: []string{"pool"},
+		},
+		"invalid role": {
+			req:        &pb.RoleCreateRequest{Name: "role-1", StorageType: "powerflex", SystemId: "542a2d5f", Pool: "", Quota: "lots"},
+			wantFields: []string{"pool", "quota", "systemId"},
+		},
+		"unsupported storage type": {
+			req:        &pb.RoleCreateRequest{Name: "role-1", StorageType: "unity", SystemId: "id", Pool: "bronze", Quota: "10GB"},
+			wantFields: []string{"storageType"},
+		},
+		"role delete by name": {
+			req: &pb.RoleDeleteRequest{Name: "role-1"},
+		},
+		"valid storage": {
+			req: &pb.StorageCreateRequest{StorageType: "powermax", SystemId: "000197900714", Endpoint: "https://10.0.0.1:8443", UserName: "admin", Password: "password"},
+		},
+		"invalid storage": {
+			req:        &pb.StorageUpdateRequest{StorageType: "powermax", SystemId: "0001979", Endpoint: "10.0.0.1", UserName: "admin"},
+			wantFields: []string{"endpoint", "password", "systemId"},
+		},
+		"powerscale cluster name": {
+			req: &pb.StorageGetRequest{StorageType: "powerscale", SystemId: "myPowerScale"},
+		},
+		"invalid volume listing": {
+			req:        &pb.ListVolumesRequest{StorageType: "powerflex", SystemId: "not-an-id"},
+			wantFields: []string{"systemId"},
+		},
+		"empty volume name": {
+			req:        &pb.GetPowerflexVolumesRequest{SystemId: "542a2d5f5122210f", VolumeName: []string{"k8s-1", ""}},
+			wantFields: []string{"volumeName[1]"},
+		},
+		"unknown request": {
+			req: &pb.StorageListRequest{},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validation.Request(tc.req)
+
+			if got := violatedFields(t, err); !reflect.DeepEqual(got, tc.wantFields) {
+				t.Errorf("got violations of %v, want %v (%v)", got, tc.wantFields, err)
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := validation.UnaryServerInterceptor()
+
+	t.Run("it refuses invalid requests", func(t *testing.T) {
+		var called bool
+		handler := func(context.Context, interface{}) (interface{}, error) {
+			called = true
+			return nil, nil
+		}
+
+		_, err := interceptor(context.Background(), &pb.GetTenantRequest{}, &grpc.UnaryServerInfo{}, handler)
+
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("got code %v, want %v", status.Code(err), codes.InvalidArgument)
+		}
+		if called {
+			t.Error("expected the handler not to be called")
+		}
+	})
+
+	t.Run("it serves valid requests", func(t *testing.T) {
+		want := &pb.Tenant{Name: "tenant-1"}
+		handler := func(context.Context, interface{}) (interface{}, error) {
+			return want, nil
+		}
+
+		got, err := interceptor(context.Background(), &pb.GetTenantRequest{Name: "tenant-1"}, &grpc.UnaryServerInfo{}, handler)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
+
+// violatedFields returns the sorted fields of the BadRequest detail of err.
+func violatedFields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("got code %v, want %v", st.Code(), codes.InvalidArgument)
+	}
+	var fields []string
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, fv := range br.FieldViolations {
+				fields = append(fields, fv.Field)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,95 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Error codes of the storage requests denied by the proxy.
+const (
+	CodeMaxVolumes          ErrorCode = "MAX_VOLUMES_REACHED"
+	CodeNotOwner            ErrorCode = "NOT_OWNER"
+	CodeSdcNotAllowed       ErrorCode = "SDC_NOT_ALLOWED"
+	CodeHostNotAllowed      ErrorCode = "HOST_NOT_ALLOWED"
+	CodeInitiatorNotAllowed ErrorCode = "INITIATOR_NOT_ALLOWED"
+	CodeSoftQuotaExpired    ErrorCode = "SOFT_QUOTA_GRACE_EXPIRED"
+	CodeVolumeName          ErrorCode = "VOLUME_NAME_NOT_ALLOWED"
+	CodeVolumeSize          ErrorCode = "VOLUME_SIZE_NOT_ALLOWED"
+	CodeSystemPaused        ErrorCode = "SYSTEM_PAUSED"
+)
+
+// Headers the sidecar-proxy passes the deny reason of a denied storage
+// request to the CSI driver in.
+const (
+	HeaderDenyCode   = "X-Csm-Deny-Code"
+	HeaderDenyReason = "X-Csm-Deny-Reason"
+	HeaderDenyTenant = "X-Csm-Deny-Tenant"
+	HeaderDenyPool   = "X-Csm-Deny-Pool"
+	HeaderDenyLimit  = "X-Csm-Deny-Limit"
+	HeaderDenyUsage  = "X-Csm-Deny-Usage"
+	// HeaderDenyAlternatives lists the pools of the alternatives, in
+	// order, separated by commas.
+	HeaderDenyAlternatives = "X-Csm-Deny-Alternatives"
+)
+
+// Deny is the machine-readable reason a storage request was denied. For
+// quota denials, Limit and Usage are the quota and the usage of the tenant
+// in the pool, in kilobytes or in volumes for CodeMaxVolumes. Alternatives
+// are the other pools that the request could be retried in.
+type Deny struct {
+	Code         ErrorCode  `json:"code"`
+	Reason       string     `json:"reason"`
+	Tenant       string     `json:"tenant,omitempty"`
+	Pool         string     `json:"pool,omitempty"`
+	Limit        int64      `json:"limit,omitempty"`
+	Usage        int64      `json:"usage,omitempty"`
+	Alternatives []PoolHint `json:"alternatives,omitempty"`
+}
+
+// PoolHint is a storage pool that the roles of the tenant permit a denied
+// request in, with the capacity left to the tenant there in kilobytes, or
+// -1 if it is unlimited.
+type PoolHint struct {
+	Pool      string `json:"pool"`
+	Available int64  `json:"available"`
+}
+
+// SetHeaders sets the deny headers from d.
+func (d Deny) SetHeaders(h http.Header) {
+	h.Set(HeaderDenyCode, string(d.Code))
+	h.Set(HeaderDenyReason, d.Reason)
+	if d.Tenant != "" {
+		h.Set(HeaderDenyTenant, d.Tenant)
+	}
+	if d.Pool != "" {
+		h.Set(HeaderDenyPool, d.Pool)
+	}
+	if d.Limit != 0 {
+		h.Set(HeaderDenyLimit, strconv.FormatInt(d.Limit, 10))
+	}
+	if d.Usage != 0 {
+		h.Set(HeaderDenyUsage, strconv.FormatInt(d.Usage, 10))
+	}
+	if len(d.Alternatives) > 0 {
+		pools := make([]string, len(d.Alternatives))
+		for i, a := range d.Alternatives {
+			pools[i] = a.Pool
+		}
+		h.Set(HeaderDenyAlternatives, strings.Join(pools, ","))
+	}
+}
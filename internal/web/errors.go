@@ -0,0 +1,146 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorCode is a machine-readable reason for an error response from a
+// proxy REST endpoint. Clients should check it rather than the message.
+type ErrorCode string
+
+// Error codes of the proxy REST endpoints.
+const (
+	CodeBadRequest       ErrorCode = "BAD_REQUEST"
+	CodeUnauthorized     ErrorCode = "UNAUTHORIZED"
+	CodeForbidden        ErrorCode = "FORBIDDEN"
+	CodeTenantRevoked    ErrorCode = "TENANT_REVOKED"
+	CodePolicyDenied     ErrorCode = "POLICY_DENIED"
+	CodeNotFound         ErrorCode = "NOT_FOUND"
+	CodeMethodNotAllowed ErrorCode = "METHOD_NOT_ALLOWED"
+	CodeConflict         ErrorCode = "CONFLICT"
+	CodeConcurrentUpdate ErrorCode = "CONCURRENT_UPDATE"
+	CodeQuotaExceeded    ErrorCode = "QUOTA_EXCEEDED"
+	CodeUnavailable      ErrorCode = "UNAVAILABLE"
+	CodeInternal         ErrorCode = "INTERNAL"
+)
+
+var codeStatus = map[ErrorCode]int{
+	CodeBadRequest:       http.StatusBadRequest,
+	CodeUnauthorized:     http.StatusUnauthorized,
+	CodeForbidden:        http.StatusForbidden,
+	CodeTenantRevoked:    http.StatusForbidden,
+	CodePolicyDenied:     http.StatusForbidden,
+	CodeNotFound:         http.StatusNotFound,
+	CodeMethodNotAllowed: http.StatusMethodNotAllowed,
+	CodeConflict:         http.StatusConflict,
+	CodeConcurrentUpdate: http.StatusConflict,
+	CodeQuotaExceeded:    http.StatusInsufficientStorage,
+	CodeUnavailable:      http.StatusServiceUnavailable,
+	CodeInternal:         http.StatusInternalServerError,
+}
+
+// Status returns the HTTP status code that responses with the error code
+// are sent with.
+func (c ErrorCode) Status() int {
+	if s, ok := codeStatus[c]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+// codeForStatus returns the general error code for an HTTP status code.
+func codeForStatus(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusInsufficientStorage:
+		return CodeQuotaExceeded
+	case http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return CodeUnavailable
+	default:
+		return CodeInternal
+	}
+}
+
+// Error is an error with the code to respond with.
+type Error struct {
+	Code ErrorCode
+	Err  error
+}
+
+// NewError returns an Error with the code.
+func NewError(code ErrorCode, err error) *Error {
+	return &Error{Code: code, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// RPCErrorCode returns the error code for an error returned from one of
+// the gRPC services. Errors that are not gRPC errors are internal errors.
+func RPCErrorCode(err error) ErrorCode {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return CodeBadRequest
+	case codes.Unauthenticated:
+		return CodeUnauthorized
+	case codes.PermissionDenied:
+		return CodeForbidden
+	case codes.NotFound:
+		return CodeNotFound
+	case codes.AlreadyExists:
+		return CodeConflict
+	case codes.Aborted:
+		return CodeConcurrentUpdate
+	case codes.ResourceExhausted:
+		return CodeQuotaExceeded
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return CodeUnavailable
+	default:
+		return CodeInternal
+	}
+}
+
+// ErrorResponse writes err as a JSON error response. The code and status
+// come from err if it is an Error; otherwise it is an internal error.
+func ErrorResponse(w http.ResponseWriter, err error) error {
+	code := CodeInternal
+	var e *Error
+	if errors.As(err, &e) {
+		code = e.Code
+	}
+	return JSONErrorResponse(w, code.Status(), err)
+}
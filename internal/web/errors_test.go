@@ -0,0 +1,118 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"karavi-authorization/internal/errcode"
+	"karavi-authorization/internal/web"
+	"karavi-authorization/pb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorResponse(t *testing.T) {
+	tests := map[string]struct {
+		err        error
+		wantStatus int
+		wantReason web.ErrorCode
+	}{
+		"tenant revoked":     {web.NewError(web.CodeTenantRevoked, errors.New("tenant is revoked")), http.StatusForbidden, web.CodeTenantRevoked},
+		"quota exceeded":     {web.NewError(web.CodeQuotaExceeded, errors.New("not enough quota")), http.StatusInsufficientStorage, web.CodeQuotaExceeded},
+		"wrapped error":      {fmt.Errorf("listing: %w", web.NewError(web.CodeNotFound, errors.New("no volumes"))), http.StatusNotFound, web.CodeNotFound},
+		"unclassified error": {errors.New("test error"), http.StatusInternalServerError, web.CodeInternal},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			if err := web.ErrorResponse(w, tc.err); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.Code != tc.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tc.wantStatus)
+			}
+			var got web.JSONError
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Reason != tc.wantReason || got.Code != tc.wantStatus || got.ErrorMsg != tc.err.Error() {
+				t.Errorf("body: got %+v, want reason %q, code %d and message %q", got, tc.wantReason, tc.wantStatus, tc.err.Error())
+			}
+		})
+	}
+}
+
+func TestJSONErrorResponse_Reason(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	if err := web.JSONErrorResponse(w, http.StatusMethodNotAllowed, errors.New("method PUT not allowed")); err != nil {
+		t.Fatal(err)
+	}
+
+	var got web.JSONError
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Reason != web.CodeMethodNotAllowed {
+		t.Errorf("got reason %q, want %q", got.Reason, web.CodeMethodNotAllowed)
+	}
+}
+
+func TestRPCErrorCode(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want web.ErrorCode
+	}{
+		"not found":      {status.Error(codes.NotFound, "tenant not found"), web.CodeNotFound},
+		"already exists": {status.Error(codes.AlreadyExists, "tenant already exists"), web.CodeConflict},
+		"aborted":        {status.Error(codes.Aborted, "concurrent update"), web.CodeConcurrentUpdate},
+		"wrapped":        {fmt.Errorf("getting tenant: %w", status.Error(codes.InvalidArgument, "nil tenant")), web.CodeBadRequest},
+		"not rpc":        {errors.New("test error"), web.CodeInternal},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := web.RPCErrorCode(tc.err); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJSONErrorResponse_ServiceCode(t *testing.T) {
+	rpcErr := errcode.New(codes.NotFound, pb.ErrorCode_ROLE_NOT_FOUND, "role not found").WithParam("role", "bronze")
+	w := httptest.NewRecorder()
+
+	// The error as the proxy sees it, from the gRPC client.
+	err := fmt.Errorf("deleting role: %w", status.ErrorProto(rpcErr.GRPCStatus().Proto()))
+	if err := web.ErrorResponse(w, web.NewError(web.RPCErrorCode(err), err)); err != nil {
+		t.Fatal(err)
+	}
+
+	var got web.JSONError
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ServiceCode != "ROLE_NOT_FOUND" || got.Params["role"] != "bronze" || got.Reason != web.CodeNotFound {
+		t.Errorf("got %+v, want service code %q with the role param and reason %q", got, "ROLE_NOT_FOUND", web.CodeNotFound)
+	}
+}
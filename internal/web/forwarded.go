@@ -0,0 +1,261 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// forwardedMarker begins the values of the Forwarded parameters that the
+// sidecar proxies add, e.g. for=csm-authorization;https://10.0.0.1;542a2d5f5122210f
+// and by=csm-authorization;csi-vxflexos.
+const forwardedMarker = "csm-authorization;"
+
+// DefaultForwardedSchemes are the schemes the storage system endpoints of
+// the Forwarded header may have by default.
+var DefaultForwardedSchemes = []string{"https"}
+
+// Reasons a Forwarded header is malformed.
+const (
+	ForwardedSyntax    = "syntax"
+	ForwardedScheme    = "scheme"
+	ForwardedSystemID  = "system_id"
+	ForwardedPluginID  = "plugin_id"
+	ForwardedDuplicate = "duplicate"
+	ForwardedConflict  = "conflict"
+)
+
+var (
+	// forwardedToken is a token of RFC 7230, which the parameter names and
+	// unquoted values of RFC 7239 are.
+	forwardedToken = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+	// forwardedSystemID matches the IDs of PowerFlex, PowerMax and
+	// PowerScale systems, e.g. 542a2d5f5122210f, 000197900714 or a cluster
+	// name.
+	forwardedSystemID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+	// forwardedPluginID matches the plugin IDs of the drivers.
+	forwardedPluginID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+)
+
+// ForwardedError is a malformed Forwarded header.
+type ForwardedError struct {
+	// Reason is one of the Forwarded reasons, e.g. ForwardedSyntax.
+	Reason string
+	Err    error
+}
+
+func (e *ForwardedError) Error() string {
+	return fmt.Sprintf("malformed Forwarded header (%s): %v", e.Reason, e.Err)
+}
+
+func (e *ForwardedError) Unwrap() error {
+	return e.Err
+}
+
+func forwardedError(reason, format string, a ...interface{}) error {
+	return &ForwardedError{Reason: reason, Err: fmt.Errorf(format, a...)}
+}
+
+// Forwarded is the storage system a sidecar proxy forwarded a request for.
+type Forwarded struct {
+	PluginID string
+	Endpoint string
+	SystemID string
+}
+
+// ForwardedParser parses the Forwarded header strictly: the header must be
+// valid RFC 7239, except for the values of the sidecar proxies, which must
+// name a plugin, and an endpoint with one of Schemes and a system ID. A
+// sidecar value may only be given once.
+type ForwardedParser struct {
+	// Schemes are the allowed schemes of the endpoints, or
+	// DefaultForwardedSchemes if empty.
+	Schemes []string
+}
+
+// Parse parses the Forwarded headers of h. Parameters the sidecar proxies
+// did not add are validated, but not returned. The returned error is a
+// *ForwardedError.
+func (p ForwardedParser) Parse(h http.Header) (Forwarded, error) {
+	var fwd Forwarded
+	var seenFor, seenBy string
+	for _, line := range h.Values("Forwarded") {
+		elements, err := splitForwarded(line, ',')
+		if err != nil {
+			return Forwarded{}, err
+		}
+		for _, e := range elements {
+			e = strings.TrimSpace(e)
+			if e == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(e, "=")
+			if ok && strings.HasPrefix(value, forwardedMarker) {
+				value = strings.TrimPrefix(value, forwardedMarker)
+				switch strings.ToLower(name) {
+				case "for":
+					if err := checkRepeated(seenFor, value, "for"); err != nil {
+						return Forwarded{}, err
+					}
+					seenFor = value
+					fwd.Endpoint, fwd.SystemID, err = p.parseFor(value)
+				case "by":
+					if err := checkRepeated(seenBy, value, "by"); err != nil {
+						return Forwarded{}, err
+					}
+					seenBy = value
+					fwd.PluginID, err = parseBy(value)
+				default:
+					err = forwardedError(ForwardedSyntax, "unknown parameter %q", name)
+				}
+				if err != nil {
+					return Forwarded{}, err
+				}
+				continue
+			}
+			if err := parseForwardedPairs(e); err != nil {
+				return Forwarded{}, err
+			}
+		}
+	}
+	return fwd, nil
+}
+
+// keptForwarded is a parsed Forwarded header in a request context.
+type keptForwarded struct {
+	fwd Forwarded
+	err error
+}
+
+// Keep parses the Forwarded header of the request and returns the request
+// with the result kept in its context. A result that was already kept is
+// returned as is, without parsing the header again, so that it is the same
+// for every handler even once the header is removed.
+func (p ForwardedParser) Keep(r *http.Request) (*http.Request, Forwarded, error) {
+	if kept, ok := r.Context().Value(forwardedKey).(keptForwarded); ok {
+		return r, kept.fwd, kept.err
+	}
+	fwd, err := p.Parse(r.Header)
+	r = r.WithContext(context.WithValue(r.Context(), forwardedKey, keptForwarded{fwd: fwd, err: err}))
+	return r, fwd, err
+}
+
+func checkRepeated(seen, value, name string) error {
+	switch {
+	case seen == "":
+		return nil
+	case seen == value:
+		return forwardedError(ForwardedDuplicate, "%s given more than once", name)
+	default:
+		return forwardedError(ForwardedConflict, "%s given as both %q and %q", name, seen, value)
+	}
+}
+
+// parseFor returns the endpoint and the system ID of the value of a for
+// parameter of a sidecar proxy, e.g. https://10.0.0.1;542a2d5f5122210f.
+func (p ForwardedParser) parseFor(value string) (string, string, error) {
+	endpoint, systemID, ok := strings.Cut(value, ";")
+	if !ok || strings.Contains(systemID, ";") {
+		return "", "", forwardedError(ForwardedSyntax, "for must be an endpoint and a system id")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", "", forwardedError(ForwardedSyntax, "invalid endpoint %q", endpoint)
+	}
+	schemes := p.Schemes
+	if len(schemes) == 0 {
+		schemes = DefaultForwardedSchemes
+	}
+	allowed := false
+	for _, s := range schemes {
+		if strings.EqualFold(u.Scheme, s) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", "", forwardedError(ForwardedScheme, "scheme %q of endpoint %q not allowed", u.Scheme, endpoint)
+	}
+	if !forwardedSystemID.MatchString(systemID) {
+		return "", "", forwardedError(ForwardedSystemID, "invalid system id %q", systemID)
+	}
+	return endpoint, systemID, nil
+}
+
+// parseBy returns the plugin ID of the value of a by parameter of a sidecar
+// proxy.
+func parseBy(value string) (string, error) {
+	if !forwardedPluginID.MatchString(value) {
+		return "", forwardedError(ForwardedPluginID, "invalid plugin id %q", value)
+	}
+	return value, nil
+}
+
+// parseForwardedPairs validates a forwarded-element of RFC 7239: pairs of a
+// token and a token or a quoted string, separated by semicolons.
+func parseForwardedPairs(e string) error {
+	pairs, err := splitForwarded(e, ';')
+	if err != nil {
+		return err
+	}
+	for _, pair := range pairs {
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || !forwardedToken.MatchString(name) {
+			return forwardedError(ForwardedSyntax, "invalid pair %q", pair)
+		}
+		if strings.HasPrefix(value, `"`) {
+			if len(value) < 2 || !strings.HasSuffix(value, `"`) {
+				return forwardedError(ForwardedSyntax, "unterminated quoted value in %q", pair)
+			}
+			continue
+		}
+		if !forwardedToken.MatchString(value) {
+			return forwardedError(ForwardedSyntax, "invalid value in %q", pair)
+		}
+	}
+	return nil
+}
+
+// splitForwarded splits s at the separators outside of quoted strings.
+func splitForwarded(s string, sep byte) ([]string, error) {
+	var parts []string
+	var quoted, escaped bool
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == sep:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if quoted {
+		return nil, forwardedError(ForwardedSyntax, "unterminated quoted string")
+	}
+	return append(parts, strings.TrimSpace(s[start:])), nil
+}
@@ -0,0 +1,113 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web_test
+
+import (
+	"errors"
+	"karavi-authorization/internal/web"
+	"net/http"
+	"testing"
+)
+
+func TestForwardedParser(t *testing.T) {
+	tests := []struct {
+		name       string
+		headers    []string
+		schemes    []string
+		want       web.Forwarded
+		wantReason string
+	}{
+		{
+			name:    "it parses the values of the sidecar proxy",
+			headers: []string{"for=csm-authorization;https://10.0.0.1;542a2d5f5122210f", "by=csm-authorization;csi-vxflexos"},
+			want:    web.Forwarded{PluginID: "csi-vxflexos", Endpoint: "https://10.0.0.1", SystemID: "542a2d5f5122210f"},
+		},
+		{
+			name:    "it validates the values of other proxies",
+			headers: []string{`for=192.0.2.43;proto=https, for="[2001:db8:cafe::17]:4711"`, "by=csm-authorization;csi-powermax", "for=csm-authorization;https://unisphere.com:8443;000197900714"},
+			want:    web.Forwarded{PluginID: "csi-powermax", Endpoint: "https://unisphere.com:8443", SystemID: "000197900714"},
+		},
+		{
+			name:       "it rejects a malformed pair",
+			headers:    []string{"for=192.0.2.43;host", "by=csm-authorization;csi-vxflexos"},
+			wantReason: web.ForwardedSyntax,
+		},
+		{
+			name:       "it rejects an unterminated quoted string",
+			headers:    []string{`for="[2001:db8:cafe::17]:4711`},
+			wantReason: web.ForwardedSyntax,
+		},
+		{
+			name:       "it rejects an endpoint without a system id",
+			headers:    []string{"for=csm-authorization;https://10.0.0.1"},
+			wantReason: web.ForwardedSyntax,
+		},
+		{
+			name:       "it rejects a scheme that is not allowed",
+			headers:    []string{"for=csm-authorization;http://10.0.0.1;542a2d5f5122210f"},
+			wantReason: web.ForwardedScheme,
+		},
+		{
+			name:    "it allows the configured schemes",
+			headers: []string{"for=csm-authorization;http://10.0.0.1;542a2d5f5122210f"},
+			schemes: []string{"https", "http"},
+			want:    web.Forwarded{Endpoint: "http://10.0.0.1", SystemID: "542a2d5f5122210f"},
+		},
+		{
+			name:       "it rejects an invalid system id",
+			headers:    []string{"for=csm-authorization;https://10.0.0.1;../542a2d5f5122210f"},
+			wantReason: web.ForwardedSystemID,
+		},
+		{
+			name:       "it rejects an invalid plugin id",
+			headers:    []string{"by=csm-authorization;csi-vxflexos;powermax"},
+			wantReason: web.ForwardedPluginID,
+		},
+		{
+			name:       "it rejects a duplicate value",
+			headers:    []string{"by=csm-authorization;csi-vxflexos", "by=csm-authorization;csi-vxflexos"},
+			wantReason: web.ForwardedDuplicate,
+		},
+		{
+			name:       "it rejects conflicting values",
+			headers:    []string{"for=csm-authorization;https://10.0.0.1;542a2d5f5122210f, for=csm-authorization;https://10.0.0.2;1b2e5a7c9d3f4a6b"},
+			wantReason: web.ForwardedConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := make(http.Header)
+			for _, v := range tt.headers {
+				h.Add("Forwarded", v)
+			}
+
+			got, err := web.ForwardedParser{Schemes: tt.schemes}.Parse(h)
+
+			if tt.wantReason != "" {
+				var fe *web.ForwardedError
+				if !errors.As(err, &fe) || fe.Reason != tt.wantReason {
+					t.Fatalf("got error %v, want a %s error", err, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
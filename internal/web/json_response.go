@@ -16,27 +16,47 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
+	"karavi-authorization/internal/errcode"
 	"log"
 	"net/http"
 	"strconv"
 )
 
-// JSONError wraps a json error response
+// JSONError wraps a json error response. Code is the HTTP status code and
+// Reason is the machine-readable error code. ServiceCode is the name of the
+// pb.ErrorCode of an error from one of the gRPC services, with its Params.
 type JSONError struct {
-	ErrorMsg string `json:"error"`
-	Code     int    `json:"code"`
+	ErrorMsg    string            `json:"error"`
+	Code        int               `json:"code"`
+	Reason      ErrorCode         `json:"reason,omitempty"`
+	ServiceCode string            `json:"serviceCode,omitempty"`
+	Params      map[string]string `json:"params,omitempty"`
 }
 
 func (e JSONError) Error() string {
 	return e.ErrorMsg
 }
 
-// JSONErrorResponse writes an error to an http ResponseWriter
+// JSONErrorResponse writes an error to an http ResponseWriter. The reason is
+// the code of err if it is an Error, or the general code for the status. The
+// service code is that of err if it is an error of a gRPC service.
 func JSONErrorResponse(w http.ResponseWriter, code int, err error) error {
-	b, err := json.Marshal(&JSONError{ErrorMsg: err.Error(), Code: code})
+	reason := codeForStatus(code)
+	var e *Error
+	if errors.As(err, &e) {
+		reason = e.Code
+	}
+	jsonErr := JSONError{ErrorMsg: err.Error(), Code: code, Reason: reason}
+	if detail := errcode.Attached(err); detail != nil {
+		jsonErr.ServiceCode = detail.Code.String()
+		jsonErr.Params = detail.Params
+	}
+	b, err := json.Marshal(&jsonErr)
 	if err != nil {
 		return err
 	}
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 	_, err = w.Write(b)
 	if err != nil {
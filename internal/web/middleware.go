@@ -16,11 +16,14 @@ package web
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"karavi-authorization/internal/redact"
 	"karavi-authorization/internal/token"
+	"net"
 	"net/http"
-	"net/http/httputil"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -37,11 +40,15 @@ type CtxKey int
 
 // Common JWT values to be store inside the request context.
 const (
-	JWTKey        CtxKey = iota // JWTKey is the context key for the json web token
-	JWTTenantName               // TenantName is the name of the Tenant.
-	JWTAdminName                // AdminName is the name of the admin.
-	JWTRoles                    // Roles is the list of claimed roles.
-	SystemIDKey                 // SystemIDKey is the context key for a system ID
+	JWTKey          CtxKey = iota // JWTKey is the context key for the json web token
+	JWTTenantName                 // TenantName is the name of the Tenant.
+	JWTAdminName                  // AdminName is the name of the admin.
+	JWTRoles                      // Roles is the list of claimed roles.
+	SystemIDKey                   // SystemIDKey is the context key for a system ID
+	JWTTenantID                   // TenantID is the UUID of the Tenant, if the token has one.
+	JWTOrganization               // Organization is the organization an admin token is scoped to, if any.
+	ObserverKey                   // ObserverKey is the service account of an authenticated read-only observer.
+	forwardedKey                  // forwardedKey holds the parsed Forwarded header of a request.
 )
 
 // JWTSigningSecret is the secret string used to sign JWT tokens
@@ -72,13 +79,14 @@ func OtelMW(tp trace.TracerProvider, op string, opts ...otelhttp.Option) Middlew
 	}
 }
 
-// LoggingMW configures logging incoming requests
+// LoggingMW configures logging incoming requests. The dumps of requests
+// have their credentials and other secrets masked.
 func LoggingMW(log *logrus.Entry, showHTTPDump bool) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			log.Printf("Serving %s %s %v", r.RemoteAddr, r.Method, r.URL.Path)
 			if showHTTPDump {
-				b, err := httputil.DumpRequest(r, true)
+				b, err := redact.Current().DumpRequest(r)
 				if err != nil {
 					log.Printf("web: http dump request: %v", err)
 					return
@@ -108,12 +116,29 @@ func cleanPath(pth string) string {
 	return pth
 }
 
+// isPublicPath reports whether the path is served without a token: the tenant
+// token refresh, login and health checks.
+func isPublicPath(p string) bool {
+	switch p {
+	case ProxyRefreshTokenPath, VersionedPath(RouteRefreshToken), ProxyLoginPath, VersionedPath(RouteLogin), HealthzPath, cleanPath(HealthzPath):
+		return true
+	}
+	return false
+}
+
 // AuthMW configures validating the admin or the tenant json web token from the request
 func AuthMW(log *logrus.Entry, tm token.Manager) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// let tenant refresh token go through
-			if r.URL.Path == "/proxy/refresh-token/" {
+			// let tenant refresh token, login and health checks go through
+			if isPublicPath(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// observers were authenticated and restricted to their read-only
+			// endpoints on the storage systems by proxy.ObserverMW
+			if observer, _ := r.Context().Value(ObserverKey).(string); observer != "" && IsDispatchPath(r.URL.Path) {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -156,13 +181,34 @@ func AuthMW(log *logrus.Entry, tm token.Manager) Middleware {
 					return
 				}
 
+				// Legacy tokens are accepted during upgrades only, so warn
+				// of the sidecars that still have to be upgraded.
+				if claims.Legacy {
+					log.WithFields(logrus.Fields{
+						"tenant":  claims.Group,
+						"subject": claims.Subject,
+					}).Warn("Deprecated: accepted a token in the legacy format; upgrade the sidecar of the tenant")
+				}
+
+				// Bootstrap tokens are only accepted once exchanged for a
+				// token of a tenant, if bootstrapping is enabled.
+				if claims.Subject == token.SubjectBootstrap {
+					log.WithField("cluster", claims.Cluster).Warn("Rejected a bootstrap token; tenant bootstrapping is disabled")
+					if err := JSONErrorResponse(w, http.StatusUnauthorized, errors.New("bootstrap tokens are not accepted")); err != nil {
+						log.WithError(err).Println("sending json response")
+					}
+					return
+				}
+
 				if claims.Subject == "csm-admin" {
 					ctx := context.WithValue(r.Context(), JWTKey, parsedToken)
 					ctx = context.WithValue(ctx, JWTAdminName, claims.Group)
+					ctx = context.WithValue(ctx, JWTOrganization, claims.Organization)
 					r = r.WithContext(ctx)
 				} else {
 					ctx := context.WithValue(r.Context(), JWTKey, parsedToken)
 					ctx = context.WithValue(ctx, JWTTenantName, claims.Group)
+					ctx = context.WithValue(ctx, JWTTenantID, claims.TenantID)
 					ctx = context.WithValue(ctx, JWTRoles, claims.Roles)
 					r = r.WithContext(ctx)
 				}
@@ -175,6 +221,227 @@ func AuthMW(log *logrus.Entry, tm token.Manager) Middleware {
 	}
 }
 
+// AdminAuthMW only lets requests with a valid admin token through, for the
+// listener of the admin REST API. The admin token refresh validates the
+// token pair itself and health checks need no token.
+func AdminAuthMW(log *logrus.Entry, tm token.Manager) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch cleanPath(r.URL.Path) {
+			case AdminRefreshTokenPath, VersionedPath(RouteRefreshAdmin), cleanPath(HealthzPath):
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scheme, tkn, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !ok || scheme != "Bearer" {
+				if err := ErrorResponse(w, NewError(CodeUnauthorized, errors.New("an admin token is required"))); err != nil {
+					log.WithError(err).Println("sending json response")
+				}
+				return
+			}
+			var claims token.Claims
+			if _, err := tm.ParseWithClaims(tkn, JWTSigningSecret, &claims); err != nil {
+				if err := ErrorResponse(w, NewError(CodeUnauthorized, err)); err != nil {
+					log.WithError(err).Println("sending json response")
+				}
+				return
+			}
+			if claims.Subject != "csm-admin" {
+				log.WithField("tenant", claims.Group).Warn("Rejected a tenant token on the admin listener")
+				if err := ErrorResponse(w, NewError(CodeForbidden, errors.New("an admin token is required"))); err != nil {
+					log.WithError(err).Println("sending json response")
+				}
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AllowlistMW only lets requests from the networks through, by the address
+// of the peer, i.e. that of the ingress if there is one in front of the
+// proxy-server. No networks allow all requests.
+func AllowlistMW(log *logrus.Entry, nets []*net.IPNet) Middleware {
+	return func(next http.Handler) http.Handler {
+		if len(nets) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			if ip := net.ParseIP(host); ip != nil {
+				for _, n := range nets {
+					if n.Contains(ip) {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+			log.WithField("remote", r.RemoteAddr).Warn("Rejected a request from outside of the allowed networks")
+			if err := ErrorResponse(w, NewError(CodeForbidden, errors.New("address not allowed"))); err != nil {
+				log.WithError(err).Println("sending json response")
+			}
+		})
+	}
+}
+
+// ParseNetworks parses the networks in CIDR notation, or single addresses.
+func ParseNetworks(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		if !strings.Contains(c, "/") {
+			ip := net.ParseIP(c)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", c)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// CORSOptions configures the CORSMW middleware.
+type CORSOptions struct {
+	// PathPrefixes limits the middleware to requests whose path begins with
+	// one of them.
+	PathPrefixes     []string
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// ErrCORSWildcardCredentials is returned by CORSOptions.Validate when any
+// origin is allowed together with credentials.
+var ErrCORSWildcardCredentials = errors.New("cors: credentials cannot be allowed for any origin")
+
+// Validate returns an error if the options would let any origin make
+// requests with credentials.
+func (o CORSOptions) Validate() error {
+	if !o.AllowCredentials {
+		return nil
+	}
+	for _, origin := range o.AllowedOrigins {
+		if origin == "*" {
+			return ErrCORSWildcardCredentials
+		}
+	}
+	return nil
+}
+
+// CORSMW configures Cross-Origin Resource Sharing headers so that browser
+// based dashboards can call the REST endpoints. Preflight requests from an
+// allowed origin are answered directly and do not reach the next handler.
+// Credentials are only allowed for the origins that are listed, never for
+// the "*" wildcard.
+func CORSMW(opts CORSOptions) Middleware {
+	allowAll := false
+	origins := make(map[string]struct{}, len(opts.AllowedOrigins))
+	for _, o := range opts.AllowedOrigins {
+		if o == "*" {
+			allowAll = true
+			continue
+		}
+		origins[strings.TrimSuffix(o, "/")] = struct{}{}
+	}
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	headers := strings.Join(opts.AllowedHeaders, ", ")
+	exposed := strings.Join(opts.ExposedHeaders, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" || !hasAnyPrefix(r.URL.Path, opts.PathPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Origin")
+			_, ok := origins[origin]
+			if !ok && !allowAll {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !ok {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				if opts.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+			if exposed != "" {
+				w.Header().Set("Access-Control-Expose-Headers", exposed)
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+				if methods != "" {
+					w.Header().Set("Access-Control-Allow-Methods", methods)
+				}
+				if headers != "" {
+					w.Header().Set("Access-Control-Allow-Headers", headers)
+				}
+				if opts.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DefaultSecurityHeaders returns the security headers set by SecurityHeadersMW
+// when none are configured.
+func DefaultSecurityHeaders() map[string]string {
+	return map[string]string{
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "no-referrer",
+		"Content-Security-Policy":   "default-src 'none'; frame-ancestors 'none'",
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"Cache-Control":             "no-store",
+	}
+}
+
+// SecurityHeadersMW sets the given response headers on requests whose path
+// begins with one of pathPrefixes. Headers with an empty value are skipped.
+func SecurityHeadersMW(pathPrefixes []string, headers map[string]string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if hasAnyPrefix(r.URL.Path, pathPrefixes) {
+				for k, v := range headers {
+					if v == "" {
+						continue
+					}
+					w.Header().Set(k, v)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // HandlerWithError is a http HandlerFunc that returns an error
 type HandlerWithError func(w http.ResponseWriter, r *http.Request) error
 
@@ -213,24 +480,49 @@ func timeSince(start time.Time, fName string, log *logrus.Entry) {
 	}).Debug()
 }
 
-// ForwardedHeader splits forward headers for verification
+// ForwardedHeader returns the values of the sidecar proxies in the Forwarded
+// header, parsed by a ForwardedParser and kept in the request context by
+// ForwardedMW or KeepForwarded, or parsed with the default schemes if they
+// were not kept. It is empty if the header is malformed.
+//
+//	Forwarded: for=10.0.0.1;host=ingress.com, for=csm-authorization;https://10.0.0.1;12345, by=csm-authorization;powerflex
+//	-> map[for] = https://10.0.0.1;12345; map[by] = powerflex
 func ForwardedHeader(r *http.Request) map[string]string {
-	// Forwarded: for=10.0.0.1;host=ingress.com for=csm-authorization;https://10.0.0.1;12345 by=csm-authorization;powerflex
-	// -> map[for] = https://10.0.0.1;12345; map[by] = powerflex
-	fwd := r.Header["Forwarded"]
-
+	_, fwd, err := ForwardedParser{}.Keep(r)
 	m := make(map[string]string)
-	for _, e := range fwd {
-		if strings.Contains(e, "csm-authorization;") {
-			split := strings.Split(strings.ReplaceAll(e, "csm-authorization;", ""), "=")
-			if len(split) >= 2 {
-				m[split[0]] = split[1]
-			}
-		}
+	if err != nil {
+		return m
+	}
+	if fwd.Endpoint != "" {
+		m["for"] = fwd.Endpoint + ";" + fwd.SystemID
+	}
+	if fwd.PluginID != "" {
+		m["by"] = fwd.PluginID
 	}
 	return m
 }
 
+// KeepForwarded returns the request with its Forwarded values kept in its
+// context, so that ForwardedHeader still returns them once the Forwarded
+// header is removed before the request is forwarded to a storage system.
+func KeepForwarded(r *http.Request) *http.Request {
+	r, _, _ = ForwardedParser{}.Keep(r)
+	return r
+}
+
+// ForwardedMW parses the Forwarded header of each request once with the
+// parser and keeps the result in the request context, so that every
+// handler sees the same storage system. Malformed headers are kept as an
+// error, which the handlers that route by them reject.
+func ForwardedMW(p ForwardedParser) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, _, _ = p.Keep(r)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // NormalizePluginID returns an array identifier to the forwarded header
 func NormalizePluginID(s string) string {
 	l := []map[string]map[string]struct{}{
@@ -0,0 +1,155 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+// Headers with which clients make each request to a replay protected
+// endpoint unique.
+const (
+	// HeaderRequestNonce is the unique ID (jti) of the request.
+	HeaderRequestNonce = "X-Csm-Nonce"
+	// HeaderRequestTime is the time (iat) the request was issued at, in
+	// seconds since the Unix epoch.
+	HeaderRequestTime = "X-Csm-Timestamp"
+)
+
+// DefaultReplayWindow is how far the time of a request may be from the
+// time of the proxy-server by default.
+const DefaultReplayWindow = 5 * time.Minute
+
+const maxNonceLength = 128
+
+// NewRequestNonce returns a random nonce for HeaderRequestNonce.
+func NewRequestNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// The time is unique enough for a single client.
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// NonceStore remembers the nonces of accepted requests.
+type NonceStore interface {
+	// Claim records the nonce for ttl and reports whether it was unused.
+	Claim(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// RedisNonceStore stores nonces in Redis.
+type RedisNonceStore struct {
+	// Redis returns the current redis client.
+	Redis func() *redis.Client
+}
+
+// Claim implements NonceStore.
+func (s RedisNonceStore) Claim(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return s.Redis().SetNX("replay:nonce:"+nonce, 1, ttl).Result()
+}
+
+// ReplayOptions configures the ReplayMW middleware.
+type ReplayOptions struct {
+	// PathPrefixes limits the middleware to requests whose path begins with
+	// one of them.
+	PathPrefixes []string
+	// Window is how far the time of a request may be from now.
+	Window time.Duration
+	// Now returns the current time; time.Now if nil.
+	Now func() time.Time
+}
+
+// ReplayMW rejects requests to the protected paths that are not made unique
+// by a nonce and a recent time. Nonces are remembered for twice the window,
+// which covers every time a request with the nonce would still be accepted.
+//
+// This only keeps requests from being sent again unchanged, e.g. by a
+// client that retries or from a request recorded in a log. The nonce and
+// the time are not bound to the request, so anyone who has captured a
+// request, and with it the bearer token, can send it again with new ones;
+// keeping requests from being captured is left to TLS.
+func ReplayMW(log *logrus.Entry, store NonceStore, opts ReplayOptions) Middleware {
+	if opts.Window <= 0 {
+		opts.Window = DefaultReplayWindow
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions || !hasAnyPrefix(r.URL.Path, opts.PathPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if err := checkReplay(r, store, opts); err != nil {
+				log.WithError(err).WithField("path", r.URL.Path).Warn("Rejected request without replay protection")
+				if jsonErr := ErrorResponse(w, err); jsonErr != nil {
+					log.WithError(jsonErr).Println("sending json response")
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func checkReplay(r *http.Request, store NonceStore, opts ReplayOptions) error {
+	nonce := r.Header.Get(HeaderRequestNonce)
+	if nonce == "" || len(nonce) > maxNonceLength {
+		return NewError(CodeUnauthorized, fmt.Errorf("missing or invalid %s header", HeaderRequestNonce))
+	}
+
+	iat, err := strconv.ParseInt(r.Header.Get(HeaderRequestTime), 10, 64)
+	if err != nil {
+		return NewError(CodeUnauthorized, fmt.Errorf("missing or invalid %s header", HeaderRequestTime))
+	}
+	skew := opts.Now().Sub(time.Unix(iat, 0))
+	if skew > opts.Window || skew < -opts.Window {
+		return NewError(CodeUnauthorized, errors.New("request time is outside the allowed window"))
+	}
+
+	ok, err := store.Claim(r.Context(), nonce, 2*opts.Window)
+	if err != nil {
+		return NewError(CodeUnavailable, fmt.Errorf("checking request nonce: %w", err))
+	}
+	if !ok {
+		return NewError(CodeUnauthorized, errors.New("request has already been used"))
+	}
+	return nil
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web_test
+
+import (
+	"karavi-authorization/internal/web"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+func TestReplayMW(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	sut := web.Adapt(handler, web.ReplayMW(logrus.NewEntry(logrus.New()),
+		web.RedisNonceStore{Redis: func() *redis.Client { return rdb }},
+		web.ReplayOptions{
+			PathPrefixes: []string{web.ProxyTenantPath},
+			Window:       time.Minute,
+			Now:          func() time.Time { return now },
+		}))
+
+	do := func(path, nonce string, iat time.Time) int {
+		r := httptest.NewRequest(http.MethodPatch, path, nil)
+		if nonce != "" {
+			r.Header.Set(web.HeaderRequestNonce, nonce)
+		}
+		if !iat.IsZero() {
+			r.Header.Set(web.HeaderRequestTime, strconv.FormatInt(iat.Unix(), 10))
+		}
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	t.Run("it accepts a unique recent request", func(t *testing.T) {
+		if got := do("/proxy/tenant/", "nonce-1", now.Add(-30*time.Second)); got != http.StatusOK {
+			t.Errorf("got %d, want %d", got, http.StatusOK)
+		}
+	})
+
+	t.Run("it rejects a replayed request", func(t *testing.T) {
+		if got := do("/proxy/tenant/", "nonce-2", now); got != http.StatusOK {
+			t.Fatalf("got %d, want %d", got, http.StatusOK)
+		}
+		if got := do("/proxy/tenant/", "nonce-2", now); got != http.StatusUnauthorized {
+			t.Errorf("got %d, want %d", got, http.StatusUnauthorized)
+		}
+		if ttl := mr.TTL("replay:nonce:nonce-2"); ttl != 2*time.Minute {
+			t.Errorf("got nonce ttl %v, want %v", ttl, 2*time.Minute)
+		}
+	})
+
+	t.Run("it rejects a request outside the window", func(t *testing.T) {
+		if got := do("/proxy/tenant/", "nonce-3", now.Add(-2*time.Minute)); got != http.StatusUnauthorized {
+			t.Errorf("got %d, want %d", got, http.StatusUnauthorized)
+		}
+		if got := do("/proxy/tenant/", "nonce-4", now.Add(2*time.Minute)); got != http.StatusUnauthorized {
+			t.Errorf("got %d, want %d", got, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("it rejects a request without a nonce or time", func(t *testing.T) {
+		if got := do("/proxy/tenant/", "", now); got != http.StatusUnauthorized {
+			t.Errorf("got %d, want %d", got, http.StatusUnauthorized)
+		}
+		if got := do("/proxy/tenant/", "nonce-5", time.Time{}); got != http.StatusUnauthorized {
+			t.Errorf("got %d, want %d", got, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("it ignores unprotected paths", func(t *testing.T) {
+		if got := do("/proxy/roles/", "", time.Time{}); got != http.StatusOK {
+			t.Errorf("got %d, want %d", got, http.StatusOK)
+		}
+	})
+
+	t.Run("it is unavailable when the nonce cannot be stored", func(t *testing.T) {
+		mr.SetError("down")
+		defer mr.SetError("")
+		if got := do("/proxy/tenant/", "nonce-6", now); got != http.StatusServiceUnavailable {
+			t.Errorf("got %d, want %d", got, http.StatusServiceUnavailable)
+		}
+	})
+}
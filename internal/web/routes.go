@@ -15,46 +15,254 @@
 package web
 
 import (
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 )
 
 // Constants for known routes to serve.
 const (
 	DebugPath               = "/debug/"
+	ProxyRESTPath           = "/proxy/"
 	ProxyRefreshTokenPath   = "/proxy/refresh-token/"
 	AdminRefreshTokenPath   = "/proxy/refresh-admin/"
 	ProxyRolesPath          = "/proxy/roles/"
 	ProxyVolumesPath        = "/proxy/volumes/"
+	ProxyRawVolumesPath     = "/proxy/volumes/raw/"
 	ProxyTenantPath         = "/proxy/tenant/"
 	ProxyStoragePath        = "/proxy/storage/"
+	ProxySimulatePath       = "/proxy/simulate/"
+	ProxyPolicyPath         = "/proxy/policies/"
+	ProxyLoginPath          = "/proxy/login/"
+	ProxyAdminSessionsPath  = "/proxy/admin/sessions/"
+	ProxyReportsPath        = "/proxy/reports/"
+	ProxyEventsPath         = "/proxy/events/"
+	ProxyPortsPath          = "/proxy/ports/"
+	ProxyRolloutsPath       = "/proxy/rollouts/"
+	ProxyCapturesPath       = "/proxy/captures/"
+	ProxyMaintenancePath    = "/proxy/maintenance/"
 	ClientInstallScriptPath = "/install/"
+	HealthzPath             = "/healthz"
 	ProxyPath               = "/"
 )
 
+// APIV1Path is the prefix of version 1 of the REST API. The unversioned
+// ProxyRESTPath routes are deprecated aliases of it.
+const APIV1Path = "/api/v1/"
+
+// Names of the REST API routes, which are their paths below the API version
+// prefix.
+const (
+	RouteRefreshToken = "refresh-token/"
+	RouteRefreshAdmin = "refresh-admin/"
+	RouteRoles        = "roles/"
+	RouteVolumes      = "volumes/"
+	RouteRawVolumes   = "volumes/raw/"
+	RouteTenant       = "tenant/"
+	RouteStorage      = "storage/"
+	RouteSimulate     = "simulate/"
+	RoutePolicies     = "policies/"
+	RouteLogin        = "login/"
+	RouteAdminSession = "admin/sessions/"
+	RouteReports      = "reports/"
+	RouteEvents       = "events/"
+	RoutePorts        = "ports/"
+	RouteRollouts     = "rollouts/"
+	RouteCaptures     = "captures/"
+	RouteMaintenance  = "maintenance/"
+)
+
+// APIPaths returns the prefixes of the REST API paths, versioned or not.
+func APIPaths() []string {
+	return []string{APIV1Path, ProxyRESTPath}
+}
+
+// Listener scopes, which select the routes a listener of the proxy-server
+// serves so that the admin REST API can be kept apart from the CSI data path.
+const (
+	ScopeAll   = "all"
+	ScopeAdmin = "admin"
+	ScopeData  = "data"
+)
+
+// ValidScope reports whether the scope is one of the listener scopes.
+func ValidScope(scope string) bool {
+	switch scope {
+	case ScopeAll, ScopeAdmin, ScopeData:
+		return true
+	}
+	return false
+}
+
+// tenantRoutes are the REST API routes that tenants use with their own
+// tokens, or to get them, rather than admins.
+var tenantRoutes = []string{RouteRefreshToken, RouteLogin, RouteVolumes, RoutePorts}
+
+// adminRoutes are the REST API routes of admins below the routes of tenants.
+var adminRoutes = []string{RouteRawVolumes}
+
+// IsAdminPath reports whether the path is of the REST API, other than the
+// routes of tenants, e.g. the token refresh of the sidecar proxies.
+func IsAdminPath(p string) bool {
+	p = cleanPath(p)
+	for _, route := range adminRoutes {
+		if strings.HasPrefix(p, ProxyRESTPath+route) || strings.HasPrefix(p, VersionedPath(route)) {
+			return true
+		}
+	}
+	for _, route := range tenantRoutes {
+		if strings.HasPrefix(p, ProxyRESTPath+route) || strings.HasPrefix(p, VersionedPath(route)) {
+			return false
+		}
+	}
+	for _, prefix := range APIPaths() {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDispatchPath reports whether requests to the path are dispatched to a
+// storage system, rather than served by the REST API or the health checks.
+func IsDispatchPath(p string) bool {
+	p = cleanPath(p)
+	if p == cleanPath(HealthzPath) {
+		return false
+	}
+	for _, prefix := range APIPaths() {
+		if strings.HasPrefix(p, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// ScopeMW responds with not found to requests for routes outside of the
+// listener scope. Health checks are served in every scope.
+func ScopeMW(scope string) Middleware {
+	return func(next http.Handler) http.Handler {
+		if scope == ScopeAll || scope == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cleanPath(r.URL.Path) != cleanPath(HealthzPath) && IsAdminPath(r.URL.Path) != (scope == ScopeAdmin) {
+				_ = ErrorResponse(w, NewError(CodeNotFound, errors.New("not served on this listener")))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// VersionedPath returns the path of the route in version 1 of the API.
+func VersionedPath(route string) string {
+	return APIV1Path + route
+}
+
 // Router is an HTTP handler for routing requests
 // for named paths to their configured handler.
 type Router struct {
-	TokenHandler      http.Handler
-	AdminTokenHandler http.Handler
-	RolesHandler      http.Handler
-	ProxyHandler      http.Handler
-	VolumesHandler    http.Handler
-	TenantHandler     http.Handler
-	StorageHandler    http.Handler
+	TokenHandler        http.Handler
+	AdminTokenHandler   http.Handler
+	RolesHandler        http.Handler
+	ProxyHandler        http.Handler
+	VolumesHandler      http.Handler
+	RawVolumesHandler   http.Handler
+	TenantHandler       http.Handler
+	StorageHandler      http.Handler
+	SimulateHandler     http.Handler
+	PolicyHandler       http.Handler
+	LoginHandler        http.Handler
+	AdminSessionHandler http.Handler
+	ReportHandler       http.Handler
+	EventHandler        http.Handler
+	PortHandler         http.Handler
+	RolloutHandler      http.Handler
+	CaptureHandler      http.Handler
+	MaintenanceHandler  http.Handler
+
+	// Middleware adapts the handler of a route, by route name, on both its
+	// versioned path and its deprecated alias.
+	Middleware map[string][]Middleware
+	// Sunset is when the deprecated aliases will be removed, announced in
+	// their responses if set.
+	Sunset time.Time
 }
 
 // Handler returns an http.Handler for routing.
 func (rtr *Router) Handler() http.Handler {
+	routes := map[string]http.Handler{
+		RouteRefreshToken: rtr.TokenHandler,
+		RouteRefreshAdmin: rtr.AdminTokenHandler,
+		RouteRoles:        rtr.RolesHandler,
+		RouteVolumes:      rtr.VolumesHandler,
+		RouteRawVolumes:   rtr.RawVolumesHandler,
+		RouteTenant:       rtr.TenantHandler,
+		RouteStorage:      rtr.StorageHandler,
+		RouteSimulate:     rtr.SimulateHandler,
+		RoutePolicies:     rtr.PolicyHandler,
+		RouteLogin:        rtr.LoginHandler,
+		RouteAdminSession: rtr.AdminSessionHandler,
+		RouteReports:      rtr.ReportHandler,
+		RouteEvents:       rtr.EventHandler,
+		RoutePorts:        rtr.PortHandler,
+		RouteRollouts:     rtr.RolloutHandler,
+		RouteCaptures:     rtr.CaptureHandler,
+		RouteMaintenance:  rtr.MaintenanceHandler,
+	}
+
 	mux := http.NewServeMux()
-	mux.Handle(ProxyRefreshTokenPath, rtr.TokenHandler)
-	mux.Handle(AdminRefreshTokenPath, rtr.AdminTokenHandler)
-	mux.Handle(ProxyRolesPath, rtr.RolesHandler)
+	for route, h := range routes {
+		if h == nil {
+			panic("web: no handler for route " + route)
+		}
+		h = Adapt(h, rtr.Middleware[route]...)
+		mux.Handle(VersionedPath(route), legacyPathHandler(h))
+		mux.Handle(ProxyRESTPath+route, Adapt(h, DeprecationMW(VersionedPath(route), rtr.Sunset)))
+	}
 	mux.Handle(ProxyPath, rtr.ProxyHandler)
-	mux.Handle(ProxyVolumesPath, rtr.VolumesHandler)
-	mux.Handle(ProxyTenantPath, rtr.TenantHandler)
-	mux.Handle(ProxyStoragePath, rtr.StorageHandler)
+	// Health checks are served with and without the trailing slash that
+	// CleanMW adds to the paths.
+	mux.HandleFunc(HealthzPath, healthzHandler)
+	mux.HandleFunc(cleanPath(HealthzPath), healthzHandler)
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		mux.ServeHTTP(w, r)
 	})
 }
+
+// legacyPathHandler serves a versioned request with a handler that routes on
+// the unversioned paths. A version with breaking changes gets handlers of its
+// own instead.
+func legacyPathHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = ProxyRESTPath + strings.TrimPrefix(r.URL.Path, APIV1Path)
+		r2.URL.RawPath = ""
+		next.ServeHTTP(w, r2)
+	})
+}
+
+// DeprecationMW marks the responses of a deprecated path with the
+// Deprecation header and a link to its successor, and with the Sunset header
+// if the time the path will be removed is known.
+func DeprecationMW(successor string, sunset time.Time) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Add("Link", "<"+successor+`>; rel="successor-version"`)
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// healthzHandler reports that the server is up and serving requests.
+func healthzHandler(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
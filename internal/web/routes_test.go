@@ -20,6 +20,7 @@ import (
 	"net/http/httptest"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestRouter(t *testing.T) {
@@ -30,8 +31,19 @@ func TestRouter(t *testing.T) {
 	sut.RolesHandler = noopHandler
 	sut.ProxyHandler = noopHandler
 	sut.VolumesHandler = noopHandler
+	sut.RawVolumesHandler = noopHandler
 	sut.TenantHandler = noopHandler
 	sut.StorageHandler = noopHandler
+	sut.SimulateHandler = noopHandler
+	sut.PolicyHandler = noopHandler
+	sut.LoginHandler = noopHandler
+	sut.AdminSessionHandler = noopHandler
+	sut.ReportHandler = noopHandler
+	sut.EventHandler = noopHandler
+	sut.PortHandler = noopHandler
+	sut.RolloutHandler = noopHandler
+	sut.CaptureHandler = noopHandler
+	sut.MaintenanceHandler = noopHandler
 
 	defer func() {
 		if err := recover(); err != nil {
@@ -67,4 +79,121 @@ func TestRouter(t *testing.T) {
 			t.Error("expected the handler to be called, but it wasn't")
 		}
 	})
+	t.Run("it serves the versioned api routes", func(t *testing.T) {
+		var gotPath string
+		sut.TenantHandler = http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/tenant/quota/", nil)
+
+		sut.Handler().ServeHTTP(w, r)
+
+		if gotPath != "/proxy/tenant/quota/" {
+			t.Errorf("got path %q, want %q", gotPath, "/proxy/tenant/quota/")
+		}
+		if got := w.Header().Get("Deprecation"); got != "" {
+			t.Errorf("got Deprecation %q, want none", got)
+		}
+	})
+	t.Run("it marks the legacy routes as deprecated", func(t *testing.T) {
+		sut.Sunset = time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+		defer func() { sut.Sunset = time.Time{} }()
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/proxy/tenant/quota/", nil)
+
+		sut.Handler().ServeHTTP(w, r)
+
+		if got := w.Header().Get("Deprecation"); got != "true" {
+			t.Errorf("got Deprecation %q, want true", got)
+		}
+		if got, want := w.Header().Get("Link"), `</api/v1/tenant/>; rel="successor-version"`; got != want {
+			t.Errorf("got Link %q, want %q", got, want)
+		}
+		if got, want := w.Header().Get("Sunset"), "Fri, 01 Jan 2027 00:00:00 GMT"; got != want {
+			t.Errorf("got Sunset %q, want %q", got, want)
+		}
+	})
+	t.Run("it applies the middleware of a route to both of its paths", func(t *testing.T) {
+		var calls int
+		sut.Middleware = map[string][]web.Middleware{
+			web.RouteStorage: {func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls++
+					next.ServeHTTP(w, r)
+				})
+			}},
+		}
+		defer func() { sut.Middleware = nil }()
+
+		h := sut.Handler()
+		for _, p := range []string{"/api/v1/storage/", "/proxy/storage/", "/proxy/tenant/"} {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, p, nil))
+		}
+
+		if calls != 2 {
+			t.Errorf("got %d calls of the storage middleware, want 2", calls)
+		}
+	})
+	t.Run("it serves health checks", func(t *testing.T) {
+		for _, p := range []string{web.HealthzPath, web.HealthzPath + "/"} {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, p, nil)
+
+			sut.Handler().ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("%s: got status %d, want %d", p, w.Code, http.StatusOK)
+			}
+		}
+	})
+}
+
+func TestIsDispatchPath(t *testing.T) {
+	tests := map[string]bool{
+		"/api/types/Volume/instances/": true,
+		"/api/instances/System/":       true,
+		"/proxy/tenant/":               false,
+		"/proxy":                       false,
+		"/api/v1/storage/":             false,
+		"/api/v1/../v1/tenant/":        false,
+		"/healthz":                     false,
+	}
+	for p, want := range tests {
+		if got := web.IsDispatchPath(p); got != want {
+			t.Errorf("%s: got %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestScopeMW(t *testing.T) {
+	noopHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	paths := []string{"/api/types/Volume/instances", "/proxy/refresh-token", "/api/v1/refresh-token/", "/api/v1/login/token", "/proxy/volumes/", "/proxy/volumes/raw/", "/api/v1/tenant/", "/proxy/roles/", "/proxy/refresh-admin", "/healthz"}
+	tests := []struct {
+		scope string
+		want  []int
+	}{
+		{web.ScopeAll, []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK}},
+		{web.ScopeData, []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusNotFound, http.StatusNotFound, http.StatusNotFound, http.StatusNotFound, http.StatusOK}},
+		{web.ScopeAdmin, []int{http.StatusNotFound, http.StatusNotFound, http.StatusNotFound, http.StatusNotFound, http.StatusNotFound, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			sut := web.Adapt(noopHandler, web.ScopeMW(tt.scope))
+			for i, p := range paths {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodGet, p, nil)
+
+				sut.ServeHTTP(w, r)
+
+				if got := w.Code; got != tt.want[i] {
+					t.Errorf("%s: got status %d, want %d", p, got, tt.want[i])
+				}
+			}
+		})
+	}
 }
@@ -37,3 +37,9 @@ func (w *StatusWriter) Write(b []byte) (int, error) {
 	w.Length += n
 	return n, err
 }
+
+// Unwrap returns the underlying ResponseWriter, so that a streamed response,
+// e.g. of an HTTP/2 passthrough, can be flushed through the StatusWriter.
+func (w *StatusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
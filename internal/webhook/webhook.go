@@ -0,0 +1,367 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook calls external webhooks around tenant provisioning, e.g.
+// to have tenant changes approved by a ticketing system. Pre hooks are
+// called before a change and can veto it; post hooks are called after it
+// and only informed.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"karavi-authorization/internal/errcode"
+	"karavi-authorization/pb"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+)
+
+// The phases that a webhook is called in.
+const (
+	PhasePre  = "pre"
+	PhasePost = "post"
+)
+
+// The policies for a pre hook that cannot be reached.
+const (
+	// FailurePolicyFail vetoes the change.
+	FailurePolicyFail = "fail"
+	// FailurePolicyIgnore allows the change.
+	FailurePolicyIgnore = "ignore"
+)
+
+// Defaults of a webhook.
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultRetries      = 2
+	DefaultRetryBackoff = time.Second
+)
+
+// maxResponseSize is how much of a response body is read.
+const maxResponseSize = 64 << 10
+
+// Config configures a webhook.
+type Config struct {
+	// Name identifies the webhook in logs and errors. It defaults to the
+	// URL.
+	Name string
+	// URL is where the events are posted to.
+	URL string
+	// Phase is pre or post.
+	Phase string
+	// Events are the types of the events that the webhook is called for,
+	// e.g. tenant.created. It is called for every event if there are none.
+	Events []string
+	// Timeout is how long a call may take. It defaults to DefaultTimeout.
+	Timeout time.Duration
+	// Retries is how often a failed call is retried. Calls are not
+	// retried if it is negative; it defaults to DefaultRetries.
+	Retries int
+	// RetryBackoff is how long to wait before the first retry. It doubles
+	// with each retry and defaults to DefaultRetryBackoff.
+	RetryBackoff time.Duration
+	// FailurePolicy is fail or ignore, and applies to pre hooks that
+	// still fail after the retries. It defaults to fail.
+	FailurePolicy string
+	// Headers are added to every call, e.g. for authentication.
+	Headers map[string]string
+}
+
+// Event is posted as JSON to the webhooks.
+type Event struct {
+	// Type is the type of the change, one of the eventsvc types.
+	Type    string            `json:"type"`
+	Phase   string            `json:"phase"`
+	Tenant  string            `json:"tenant"`
+	Role    string            `json:"role,omitempty"`
+	Time    int64             `json:"time"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Response is the optional JSON response of a pre hook. A pre hook
+// allows a change by responding with a 2xx status and no body.
+type Response struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type hook struct {
+	Config
+	events map[string]bool
+}
+
+func (h *hook) wants(typ string) bool {
+	return len(h.events) == 0 || h.events[typ]
+}
+
+// Hooks calls the configured webhooks. A nil Hooks calls none.
+type Hooks struct {
+	log    *logrus.Entry
+	client *http.Client
+	pre    []*hook
+	post   []*hook
+	wg     sync.WaitGroup
+}
+
+// New validates the configs and returns the Hooks that calls them.
+func New(log *logrus.Entry, configs []Config) (*Hooks, error) {
+	hs := &Hooks{
+		log:    log,
+		client: &http.Client{},
+	}
+	for i, cfg := range configs {
+		if cfg.Name == "" {
+			cfg.Name = cfg.URL
+		}
+		u, err := url.Parse(cfg.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("webhook %d: invalid url %q", i, cfg.URL)
+		}
+		if cfg.Timeout <= 0 {
+			cfg.Timeout = DefaultTimeout
+		}
+		switch {
+		case cfg.Retries < 0:
+			cfg.Retries = 0
+		case cfg.Retries == 0:
+			cfg.Retries = DefaultRetries
+		}
+		if cfg.RetryBackoff <= 0 {
+			cfg.RetryBackoff = DefaultRetryBackoff
+		}
+		switch cfg.FailurePolicy {
+		case "":
+			cfg.FailurePolicy = FailurePolicyFail
+		case FailurePolicyFail, FailurePolicyIgnore:
+		default:
+			return nil, fmt.Errorf("webhook %s: invalid failure policy %q", cfg.Name, cfg.FailurePolicy)
+		}
+
+		h := &hook{Config: cfg}
+		if len(cfg.Events) > 0 {
+			h.events = make(map[string]bool, len(cfg.Events))
+			for _, e := range cfg.Events {
+				h.events[e] = true
+			}
+		}
+		switch cfg.Phase {
+		case PhasePre:
+			hs.pre = append(hs.pre, h)
+		case PhasePost:
+			hs.post = append(hs.post, h)
+		default:
+			return nil, fmt.Errorf("webhook %s: invalid phase %q", cfg.Name, cfg.Phase)
+		}
+	}
+	return hs, nil
+}
+
+// Before calls the pre hooks of the event in order, and returns a
+// PermissionDenied error as soon as one of them vetoes the change. A pre
+// hook that cannot be reached vetoes it with an Unavailable error, unless
+// its failure policy is ignore.
+func (hs *Hooks) Before(ctx context.Context, e Event) error {
+	if hs == nil {
+		return nil
+	}
+	e.Phase = PhasePre
+	if e.Time == 0 {
+		e.Time = time.Now().Unix()
+	}
+	for _, h := range hs.pre {
+		if !h.wants(e.Type) {
+			continue
+		}
+		resp, err := hs.decide(ctx, h, e)
+		if err != nil {
+			log := hs.log.WithError(err).WithFields(logrus.Fields{
+				"webhook": h.Name,
+				"type":    e.Type,
+				"tenant":  e.Tenant,
+			})
+			if h.FailurePolicy == FailurePolicyIgnore {
+				log.Warn("calling pre hook; ignoring the failure")
+				continue
+			}
+			log.Error("calling pre hook")
+			return errcode.Newf(codes.Unavailable, pb.ErrorCode_UNAVAILABLE, "webhook %s unavailable", h.Name).
+				WithParam("webhook", h.Name)
+		}
+		if !resp.Allowed {
+			reason := resp.Reason
+			if reason == "" {
+				reason = "no reason given"
+			}
+			return errcode.Newf(codes.PermissionDenied, pb.ErrorCode_PERMISSION_DENIED, "denied by webhook %s: %s", h.Name, reason).
+				WithParam("webhook", h.Name)
+		}
+	}
+	return nil
+}
+
+// After calls the post hooks of the event in the background. Their
+// failures are only logged.
+func (hs *Hooks) After(e Event) {
+	if hs == nil {
+		return
+	}
+	e.Phase = PhasePost
+	if e.Time == 0 {
+		e.Time = time.Now().Unix()
+	}
+	for _, h := range hs.post {
+		if !h.wants(e.Type) {
+			continue
+		}
+		hs.wg.Add(1)
+		go func(h *hook) {
+			defer hs.wg.Done()
+			if _, err := hs.call(context.Background(), h, e); err != nil {
+				hs.log.WithError(err).WithFields(logrus.Fields{
+					"webhook": h.Name,
+					"type":    e.Type,
+					"tenant":  e.Tenant,
+				}).Warn("calling post hook")
+			}
+		}(h)
+	}
+}
+
+// Wait waits for the post hooks that are being called.
+func (hs *Hooks) Wait() {
+	if hs == nil {
+		return
+	}
+	hs.wg.Wait()
+}
+
+// errDenied is returned for a 4xx status, which is not retried.
+type errDenied struct {
+	status int
+	reason string
+}
+
+func (e *errDenied) Error() string {
+	return fmt.Sprintf("status %d: %s", e.status, e.reason)
+}
+
+// decide calls a pre hook and returns its decision. A 2xx status without
+// a body allows the change and a 4xx status denies it.
+func (hs *Hooks) decide(ctx context.Context, h *hook, e Event) (Response, error) {
+	b, err := hs.call(ctx, h, e)
+	var denied *errDenied
+	if errors.As(err, &denied) {
+		reason := denied.reason
+		var r Response
+		if json.Unmarshal([]byte(reason), &r) == nil && r.Reason != "" {
+			reason = r.Reason
+		}
+		return Response{Allowed: false, Reason: reason}, nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return Response{Allowed: true}, nil
+	}
+	var r Response
+	if err := json.Unmarshal(b, &r); err != nil {
+		return Response{}, fmt.Errorf("decoding response: %w", err)
+	}
+	return r, nil
+}
+
+// call posts the event to the hook, retrying server errors and failed
+// requests, and returns the body of its response.
+func (hs *Hooks) call(ctx context.Context, h *hook, e Event) ([]byte, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	backoff := h.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		b, err := hs.do(ctx, h, body)
+		var denied *errDenied
+		if err == nil || errors.As(err, &denied) || attempt >= h.Retries || ctx.Err() != nil {
+			return b, err
+		}
+		hs.log.WithError(err).WithFields(logrus.Fields{
+			"webhook": h.Name,
+			"attempt": attempt + 1,
+		}).Debug("retrying webhook")
+		if err := sleep(ctx, backoff); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+func (hs *Hooks) do(ctx context.Context, h *hook, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range h.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := hs.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode >= 500:
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	case resp.StatusCode >= 400:
+		reason := string(bytes.TrimSpace(b))
+		if reason == "" {
+			reason = http.StatusText(resp.StatusCode)
+		}
+		return nil, &errDenied{status: resp.StatusCode, reason: reason}
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return b, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
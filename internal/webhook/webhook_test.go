@@ -0,0 +1,243 @@
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"karavi-authorization/internal/errcode"
+	"karavi-authorization/internal/webhook"
+	"karavi-authorization/pb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestHooks(t *testing.T) {
+	log := logrus.NewEntry(logrus.New())
+	log.Logger.SetOutput(io.Discard)
+
+	// server responds with the given statuses and bodies in turn, repeating
+	// the last one, and records the events it receives.
+	type reply struct {
+		status int
+		body   string
+	}
+	server := func(t *testing.T, replies ...reply) (*httptest.Server, *[]webhook.Event, *int32) {
+		var mu sync.Mutex
+		var events []webhook.Event
+		var calls int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var e webhook.Event
+			if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+				t.Errorf("decoding event: %v", err)
+			}
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+			if got := r.Header.Get("Authorization"); got != "Bearer token" {
+				t.Errorf("got authorization %q, want %q", got, "Bearer token")
+			}
+			n := int(atomic.AddInt32(&calls, 1)) - 1
+			if n >= len(replies) {
+				n = len(replies) - 1
+			}
+			w.WriteHeader(replies[n].status)
+			_, _ = io.WriteString(w, replies[n].body)
+		}))
+		t.Cleanup(srv.Close)
+		return srv, &events, &calls
+	}
+	config := func(url, phase string) webhook.Config {
+		return webhook.Config{
+			Name:         "approval",
+			URL:          url,
+			Phase:        phase,
+			RetryBackoff: time.Millisecond,
+			Headers:      map[string]string{"Authorization": "Bearer token"},
+		}
+	}
+	event := webhook.Event{Type: "tenant.created", Tenant: "mytenant"}
+
+	t.Run("it allows a change without a response body", func(t *testing.T) {
+		srv, events, _ := server(t, reply{http.StatusOK, ""})
+		hs, err := webhook.New(log, []webhook.Config{config(srv.URL, webhook.PhasePre)})
+		checkError(t, err)
+
+		checkError(t, hs.Before(context.Background(), event))
+
+		if len(*events) != 1 {
+			t.Fatalf("got %d calls, want 1", len(*events))
+		}
+		if got := (*events)[0]; got.Type != event.Type || got.Tenant != event.Tenant || got.Phase != webhook.PhasePre || got.Time == 0 {
+			t.Errorf("got event %+v", got)
+		}
+	})
+	t.Run("it denies a change the hook disallows", func(t *testing.T) {
+		srv, _, _ := server(t, reply{http.StatusOK, `{"allowed":false,"reason":"no change ticket"}`})
+		hs, err := webhook.New(log, []webhook.Config{config(srv.URL, webhook.PhasePre)})
+		checkError(t, err)
+
+		err = hs.Before(context.Background(), event)
+
+		if got := errcode.Code(err); got != pb.ErrorCode_PERMISSION_DENIED {
+			t.Errorf("got code %v, want %v", got, pb.ErrorCode_PERMISSION_DENIED)
+		}
+		if err == nil || !strings.Contains(err.Error(), "no change ticket") {
+			t.Errorf("got %v, want the reason of the hook", err)
+		}
+	})
+	t.Run("it denies a change on a client error without retrying", func(t *testing.T) {
+		srv, _, calls := server(t, reply{http.StatusForbidden, "tenant not approved"})
+		hs, err := webhook.New(log, []webhook.Config{config(srv.URL, webhook.PhasePre)})
+		checkError(t, err)
+
+		err = hs.Before(context.Background(), event)
+
+		if got := errcode.Code(err); got != pb.ErrorCode_PERMISSION_DENIED {
+			t.Errorf("got code %v, want %v", got, pb.ErrorCode_PERMISSION_DENIED)
+		}
+		if *calls != 1 {
+			t.Errorf("got %d calls, want 1", *calls)
+		}
+	})
+	t.Run("it retries server errors", func(t *testing.T) {
+		srv, _, calls := server(t, reply{http.StatusBadGateway, ""}, reply{http.StatusOK, `{"allowed":true}`})
+		hs, err := webhook.New(log, []webhook.Config{config(srv.URL, webhook.PhasePre)})
+		checkError(t, err)
+
+		checkError(t, hs.Before(context.Background(), event))
+
+		if *calls != 2 {
+			t.Errorf("got %d calls, want 2", *calls)
+		}
+	})
+	t.Run("it fails once the retries are exhausted", func(t *testing.T) {
+		srv, _, calls := server(t, reply{http.StatusServiceUnavailable, ""})
+		cfg := config(srv.URL, webhook.PhasePre)
+		cfg.Retries = 1
+		hs, err := webhook.New(log, []webhook.Config{cfg})
+		checkError(t, err)
+
+		err = hs.Before(context.Background(), event)
+
+		if got := errcode.Code(err); got != pb.ErrorCode_UNAVAILABLE {
+			t.Errorf("got code %v, want %v", got, pb.ErrorCode_UNAVAILABLE)
+		}
+		if *calls != 2 {
+			t.Errorf("got %d calls, want 2", *calls)
+		}
+	})
+	t.Run("it ignores failures if configured to", func(t *testing.T) {
+		srv, _, _ := server(t, reply{http.StatusInternalServerError, ""})
+		cfg := config(srv.URL, webhook.PhasePre)
+		cfg.Retries = -1
+		cfg.FailurePolicy = webhook.FailurePolicyIgnore
+		hs, err := webhook.New(log, []webhook.Config{cfg})
+		checkError(t, err)
+
+		checkError(t, hs.Before(context.Background(), event))
+	})
+	t.Run("it times out slow hooks", func(t *testing.T) {
+		block := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+			<-block
+		}))
+		defer srv.Close()
+		defer close(block)
+		cfg := config(srv.URL, webhook.PhasePre)
+		cfg.Timeout = 10 * time.Millisecond
+		cfg.Retries = -1
+		hs, err := webhook.New(log, []webhook.Config{cfg})
+		checkError(t, err)
+
+		err = hs.Before(context.Background(), event)
+
+		if got := errcode.Code(err); got != pb.ErrorCode_UNAVAILABLE {
+			t.Errorf("got code %v, want %v", got, pb.ErrorCode_UNAVAILABLE)
+		}
+	})
+	t.Run("it only calls hooks of the event", func(t *testing.T) {
+		srv, _, calls := server(t, reply{http.StatusForbidden, ""})
+		cfg := config(srv.URL, webhook.PhasePre)
+		cfg.Events = []string{"tenant.deleted"}
+		hs, err := webhook.New(log, []webhook.Config{cfg})
+		checkError(t, err)
+
+		checkError(t, hs.Before(context.Background(), event))
+
+		if *calls != 0 {
+			t.Errorf("got %d calls, want 0", *calls)
+		}
+	})
+	t.Run("it informs post hooks after a change", func(t *testing.T) {
+		pre, _, preCalls := server(t, reply{http.StatusOK, ""})
+		post, events, _ := server(t, reply{http.StatusInternalServerError, ""}, reply{http.StatusOK, "ok"})
+		hs, err := webhook.New(log, []webhook.Config{
+			config(pre.URL, webhook.PhasePre),
+			config(post.URL, webhook.PhasePost),
+		})
+		checkError(t, err)
+
+		hs.After(event)
+		hs.Wait()
+
+		if *preCalls != 0 {
+			t.Errorf("got %d pre hook calls, want 0", *preCalls)
+		}
+		if len(*events) != 2 || (*events)[1].Phase != webhook.PhasePost {
+			t.Errorf("got events %+v, want a retried post event", *events)
+		}
+	})
+	t.Run("a nil Hooks calls nothing", func(t *testing.T) {
+		var hs *webhook.Hooks
+
+		checkError(t, hs.Before(context.Background(), event))
+		hs.After(event)
+		hs.Wait()
+	})
+}
+
+func TestNew(t *testing.T) {
+	log := logrus.NewEntry(logrus.New())
+
+	tests := map[string]webhook.Config{
+		"no url":         {Phase: webhook.PhasePre},
+		"relative url":   {URL: "/hook", Phase: webhook.PhasePre},
+		"unknown phase":  {URL: "https://hooks.example.com", Phase: "during"},
+		"unknown policy": {URL: "https://hooks.example.com", Phase: webhook.PhasePre, FailurePolicy: "retry"},
+	}
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := webhook.New(log, []webhook.Config{cfg}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func checkError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,319 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.2
+// 	protoc        (unknown)
+// source: pb/error_codes.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ErrorCode int32
+
+const (
+	ErrorCode_ERROR_CODE_UNSPECIFIED      ErrorCode = 0
+	ErrorCode_INTERNAL                    ErrorCode = 1
+	ErrorCode_INVALID_ARGUMENT            ErrorCode = 2
+	ErrorCode_NOT_FOUND                   ErrorCode = 3
+	ErrorCode_ALREADY_EXISTS              ErrorCode = 4
+	ErrorCode_PERMISSION_DENIED           ErrorCode = 5
+	ErrorCode_FAILED_PRECONDITION         ErrorCode = 6
+	ErrorCode_CONCURRENT_UPDATE           ErrorCode = 7
+	ErrorCode_UNAVAILABLE                 ErrorCode = 8
+	ErrorCode_UNAUTHENTICATED             ErrorCode = 9
+	ErrorCode_UNIMPLEMENTED               ErrorCode = 10
+	ErrorCode_QUOTA_EXCEEDED              ErrorCode = 11
+	ErrorCode_TENANT_NOT_FOUND            ErrorCode = 100
+	ErrorCode_TENANT_ALREADY_EXISTS       ErrorCode = 101
+	ErrorCode_TENANT_DELETED              ErrorCode = 102
+	ErrorCode_TENANT_REVOKED              ErrorCode = 103
+	ErrorCode_TENANT_HAS_NO_ROLES         ErrorCode = 104
+	ErrorCode_TENANT_PROTECTED            ErrorCode = 105
+	ErrorCode_TOKEN_REVOKED               ErrorCode = 106
+	ErrorCode_INVALID_MAX_VOLUMES         ErrorCode = 107
+	ErrorCode_INVALID_VOLUME_PATTERN      ErrorCode = 108
+	ErrorCode_ORGANIZATION_NOT_FOUND      ErrorCode = 120
+	ErrorCode_ORGANIZATION_ALREADY_EXISTS ErrorCode = 121
+	ErrorCode_ORGANIZATION_HAS_TENANTS    ErrorCode = 122
+	ErrorCode_ROLE_NOT_FOUND              ErrorCode = 200
+	ErrorCode_ROLE_ALREADY_EXISTS         ErrorCode = 201
+	ErrorCode_ROLE_INVALID                ErrorCode = 202
+	ErrorCode_STORAGE_NOT_FOUND           ErrorCode = 300
+	ErrorCode_STORAGE_ALREADY_EXISTS      ErrorCode = 301
+)
+
+// Enum value maps for ErrorCode.
+var (
+	ErrorCode_name = map[int32]string{
+		0:   "ERROR_CODE_UNSPECIFIED",
+		1:   "INTERNAL",
+		2:   "INVALID_ARGUMENT",
+		3:   "NOT_FOUND",
+		4:   "ALREADY_EXISTS",
+		5:   "PERMISSION_DENIED",
+		6:   "FAILED_PRECONDITION",
+		7:   "CONCURRENT_UPDATE",
+		8:   "UNAVAILABLE",
+		9:   "UNAUTHENTICATED",
+		10:  "UNIMPLEMENTED",
+		11:  "QUOTA_EXCEEDED",
+		100: "TENANT_NOT_FOUND",
+		101: "TENANT_ALREADY_EXISTS",
+		102: "TENANT_DELETED",
+		103: "TENANT_REVOKED",
+		104: "TENANT_HAS_NO_ROLES",
+		105: "TENANT_PROTECTED",
+		106: "TOKEN_REVOKED",
+		107: "INVALID_MAX_VOLUMES",
+		108: "INVALID_VOLUME_PATTERN",
+		120: "ORGANIZATION_NOT_FOUND",
+		121: "ORGANIZATION_ALREADY_EXISTS",
+		122: "ORGANIZATION_HAS_TENANTS",
+		200: "ROLE_NOT_FOUND",
+		201: "ROLE_ALREADY_EXISTS",
+		202: "ROLE_INVALID",
+		300: "STORAGE_NOT_FOUND",
+		301: "STORAGE_ALREADY_EXISTS",
+	}
+	ErrorCode_value = map[string]int32{
+		"ERROR_CODE_UNSPECIFIED":      0,
+		"INTERNAL":                    1,
+		"INVALID_ARGUMENT":            2,
+		"NOT_FOUND":                   3,
+		"ALREADY_EXISTS":              4,
+		"PERMISSION_DENIED":           5,
+		"FAILED_PRECONDITION":         6,
+		"CONCURRENT_UPDATE":           7,
+		"UNAVAILABLE":                 8,
+		"UNAUTHENTICATED":             9,
+		"UNIMPLEMENTED":               10,
+		"QUOTA_EXCEEDED":              11,
+		"TENANT_NOT_FOUND":            100,
+		"TENANT_ALREADY_EXISTS":       101,
+		"TENANT_DELETED":              102,
+		"TENANT_REVOKED":              103,
+		"TENANT_HAS_NO_ROLES":         104,
+		"TENANT_PROTECTED":            105,
+		"TOKEN_REVOKED":               106,
+		"INVALID_MAX_VOLUMES":         107,
+		"INVALID_VOLUME_PATTERN":      108,
+		"ORGANIZATION_NOT_FOUND":      120,
+		"ORGANIZATION_ALREADY_EXISTS": 121,
+		"ORGANIZATION_HAS_TENANTS":    122,
+		"ROLE_NOT_FOUND":              200,
+		"ROLE_ALREADY_EXISTS":         201,
+		"ROLE_INVALID":                202,
+		"STORAGE_NOT_FOUND":           300,
+		"STORAGE_ALREADY_EXISTS":      301,
+	}
+)
+
+func (x ErrorCode) Enum() *ErrorCode {
+	p := new(ErrorCode)
+	*p = x
+	return p
+}
+
+func (x ErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_error_codes_proto_enumTypes[0].Descriptor()
+}
+
+func (ErrorCode) Type() protoreflect.EnumType {
+	return &file_pb_error_codes_proto_enumTypes[0]
+}
+
+func (x ErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorCode.Descriptor instead.
+func (ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_pb_error_codes_proto_rawDescGZIP(), []int{0}
+}
+
+type ErrorDetail struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          ErrorCode              `protobuf:"varint,1,opt,name=code,proto3,enum=karavi.ErrorCode" json:"code,omitempty"`
+	Params        map[string]string      `protobuf:"bytes,2,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ErrorDetail) Reset() {
+	*x = ErrorDetail{}
+	mi := &file_pb_error_codes_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ErrorDetail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorDetail) ProtoMessage() {}
+
+func (x *ErrorDetail) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_error_codes_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorDetail.ProtoReflect.Descriptor instead.
+func (*ErrorDetail) Descriptor() ([]byte, []int) {
+	return file_pb_error_codes_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ErrorDetail) GetCode() ErrorCode {
+	if x != nil {
+		return x.Code
+	}
+	return ErrorCode_ERROR_CODE_UNSPECIFIED
+}
+
+func (x *ErrorDetail) GetParams() map[string]string {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+var File_pb_error_codes_proto protoreflect.FileDescriptor
+
+var file_pb_error_codes_proto_rawDesc = []byte{
+	0x0a, 0x14, 0x70, 0x62, 0x2f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x73,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x6b, 0x61, 0x72, 0x61, 0x76, 0x69, 0x22, 0xa8,
+	0x01, 0x0a, 0x0b, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x12, 0x25,
+	0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x6b,
+	0x61, 0x72, 0x61, 0x76, 0x69, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x52,
+	0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x37, 0x0a, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x6b, 0x61, 0x72, 0x61, 0x76, 0x69, 0x2e, 0x45,
+	0x72, 0x72, 0x6f, 0x72, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x50, 0x61, 0x72, 0x61, 0x6d,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x1a, 0x39,
+	0x0a, 0x0b, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x2a, 0xa4, 0x05, 0x0a, 0x09, 0x45, 0x72,
+	0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x1a, 0x0a, 0x16, 0x45, 0x52, 0x52, 0x4f, 0x52,
+	0x5f, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45,
+	0x44, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x49, 0x4e, 0x54, 0x45, 0x52, 0x4e, 0x41, 0x4c, 0x10,
+	0x01, 0x12, 0x14, 0x0a, 0x10, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x5f, 0x41, 0x52, 0x47,
+	0x55, 0x4d, 0x45, 0x4e, 0x54, 0x10, 0x02, 0x12, 0x0d, 0x0a, 0x09, 0x4e, 0x4f, 0x54, 0x5f, 0x46,
+	0x4f, 0x55, 0x4e, 0x44, 0x10, 0x03, 0x12, 0x12, 0x0a, 0x0e, 0x41, 0x4c, 0x52, 0x45, 0x41, 0x44,
+	0x59, 0x5f, 0x45, 0x58, 0x49, 0x53, 0x54, 0x53, 0x10, 0x04, 0x12, 0x15, 0x0a, 0x11, 0x50, 0x45,
+	0x52, 0x4d, 0x49, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x44, 0x45, 0x4e, 0x49, 0x45, 0x44, 0x10,
+	0x05, 0x12, 0x17, 0x0a, 0x13, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x5f, 0x50, 0x52, 0x45, 0x43,
+	0x4f, 0x4e, 0x44, 0x49, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x06, 0x12, 0x15, 0x0a, 0x11, 0x43, 0x4f,
+	0x4e, 0x43, 0x55, 0x52, 0x52, 0x45, 0x4e, 0x54, 0x5f, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45, 0x10,
+	0x07, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e, 0x41, 0x56, 0x41, 0x49, 0x4c, 0x41, 0x42, 0x4c, 0x45,
+	0x10, 0x08, 0x12, 0x13, 0x0a, 0x0f, 0x55, 0x4e, 0x41, 0x55, 0x54, 0x48, 0x45, 0x4e, 0x54, 0x49,
+	0x43, 0x41, 0x54, 0x45, 0x44, 0x10, 0x09, 0x12, 0x11, 0x0a, 0x0d, 0x55, 0x4e, 0x49, 0x4d, 0x50,
+	0x4c, 0x45, 0x4d, 0x45, 0x4e, 0x54, 0x45, 0x44, 0x10, 0x0a, 0x12, 0x12, 0x0a, 0x0e, 0x51, 0x55,
+	0x4f, 0x54, 0x41, 0x5f, 0x45, 0x58, 0x43, 0x45, 0x45, 0x44, 0x45, 0x44, 0x10, 0x0b, 0x12, 0x14,
+	0x0a, 0x10, 0x54, 0x45, 0x4e, 0x41, 0x4e, 0x54, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x46, 0x4f, 0x55,
+	0x4e, 0x44, 0x10, 0x64, 0x12, 0x19, 0x0a, 0x15, 0x54, 0x45, 0x4e, 0x41, 0x4e, 0x54, 0x5f, 0x41,
+	0x4c, 0x52, 0x45, 0x41, 0x44, 0x59, 0x5f, 0x45, 0x58, 0x49, 0x53, 0x54, 0x53, 0x10, 0x65, 0x12,
+	0x12, 0x0a, 0x0e, 0x54, 0x45, 0x4e, 0x41, 0x4e, 0x54, 0x5f, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45,
+	0x44, 0x10, 0x66, 0x12, 0x12, 0x0a, 0x0e, 0x54, 0x45, 0x4e, 0x41, 0x4e, 0x54, 0x5f, 0x52, 0x45,
+	0x56, 0x4f, 0x4b, 0x45, 0x44, 0x10, 0x67, 0x12, 0x17, 0x0a, 0x13, 0x54, 0x45, 0x4e, 0x41, 0x4e,
+	0x54, 0x5f, 0x48, 0x41, 0x53, 0x5f, 0x4e, 0x4f, 0x5f, 0x52, 0x4f, 0x4c, 0x45, 0x53, 0x10, 0x68,
+	0x12, 0x14, 0x0a, 0x10, 0x54, 0x45, 0x4e, 0x41, 0x4e, 0x54, 0x5f, 0x50, 0x52, 0x4f, 0x54, 0x45,
+	0x43, 0x54, 0x45, 0x44, 0x10, 0x69, 0x12, 0x11, 0x0a, 0x0d, 0x54, 0x4f, 0x4b, 0x45, 0x4e, 0x5f,
+	0x52, 0x45, 0x56, 0x4f, 0x4b, 0x45, 0x44, 0x10, 0x6a, 0x12, 0x17, 0x0a, 0x13, 0x49, 0x4e, 0x56,
+	0x41, 0x4c, 0x49, 0x44, 0x5f, 0x4d, 0x41, 0x58, 0x5f, 0x56, 0x4f, 0x4c, 0x55, 0x4d, 0x45, 0x53,
+	0x10, 0x6b, 0x12, 0x1a, 0x0a, 0x16, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x5f, 0x56, 0x4f,
+	0x4c, 0x55, 0x4d, 0x45, 0x5f, 0x50, 0x41, 0x54, 0x54, 0x45, 0x52, 0x4e, 0x10, 0x6c, 0x12, 0x1a,
+	0x0a, 0x16, 0x4f, 0x52, 0x47, 0x41, 0x4e, 0x49, 0x5a, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4e,
+	0x4f, 0x54, 0x5f, 0x46, 0x4f, 0x55, 0x4e, 0x44, 0x10, 0x78, 0x12, 0x1f, 0x0a, 0x1b, 0x4f, 0x52,
+	0x47, 0x41, 0x4e, 0x49, 0x5a, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x41, 0x4c, 0x52, 0x45, 0x41,
+	0x44, 0x59, 0x5f, 0x45, 0x58, 0x49, 0x53, 0x54, 0x53, 0x10, 0x79, 0x12, 0x1c, 0x0a, 0x18, 0x4f,
+	0x52, 0x47, 0x41, 0x4e, 0x49, 0x5a, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x48, 0x41, 0x53, 0x5f,
+	0x54, 0x45, 0x4e, 0x41, 0x4e, 0x54, 0x53, 0x10, 0x7a, 0x12, 0x13, 0x0a, 0x0e, 0x52, 0x4f, 0x4c,
+	0x45, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x46, 0x4f, 0x55, 0x4e, 0x44, 0x10, 0xc8, 0x01, 0x12, 0x18,
+	0x0a, 0x13, 0x52, 0x4f, 0x4c, 0x45, 0x5f, 0x41, 0x4c, 0x52, 0x45, 0x41, 0x44, 0x59, 0x5f, 0x45,
+	0x58, 0x49, 0x53, 0x54, 0x53, 0x10, 0xc9, 0x01, 0x12, 0x11, 0x0a, 0x0c, 0x52, 0x4f, 0x4c, 0x45,
+	0x5f, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x10, 0xca, 0x01, 0x12, 0x16, 0x0a, 0x11, 0x53,
+	0x54, 0x4f, 0x52, 0x41, 0x47, 0x45, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x46, 0x4f, 0x55, 0x4e, 0x44,
+	0x10, 0xac, 0x02, 0x12, 0x1b, 0x0a, 0x16, 0x53, 0x54, 0x4f, 0x52, 0x41, 0x47, 0x45, 0x5f, 0x41,
+	0x4c, 0x52, 0x45, 0x41, 0x44, 0x59, 0x5f, 0x45, 0x58, 0x49, 0x53, 0x54, 0x53, 0x10, 0xad, 0x02,
+	0x42, 0x29, 0x5a, 0x27, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x64,
+	0x65, 0x6c, 0x6c, 0x2f, 0x6b, 0x61, 0x72, 0x61, 0x76, 0x69, 0x2d, 0x61, 0x75, 0x74, 0x68, 0x6f,
+	0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pb_error_codes_proto_rawDescOnce sync.Once
+	file_pb_error_codes_proto_rawDescData = file_pb_error_codes_proto_rawDesc
+)
+
+func file_pb_error_codes_proto_rawDescGZIP() []byte {
+	file_pb_error_codes_proto_rawDescOnce.Do(func() {
+		file_pb_error_codes_proto_rawDescData = protoimpl.X.CompressGZIP(file_pb_error_codes_proto_rawDescData)
+	})
+	return file_pb_error_codes_proto_rawDescData
+}
+
+var file_pb_error_codes_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pb_error_codes_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_pb_error_codes_proto_goTypes = []any{
+	(ErrorCode)(0),      // 0: karavi.ErrorCode
+	(*ErrorDetail)(nil), // 1: karavi.ErrorDetail
+	nil,                 // 2: karavi.ErrorDetail.ParamsEntry
+}
+var file_pb_error_codes_proto_depIdxs = []int32{
+	0, // 0: karavi.ErrorDetail.code:type_name -> karavi.ErrorCode
+	2, // 1: karavi.ErrorDetail.params:type_name -> karavi.ErrorDetail.ParamsEntry
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_pb_error_codes_proto_init() }
+func file_pb_error_codes_proto_init() {
+	if File_pb_error_codes_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pb_error_codes_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_pb_error_codes_proto_goTypes,
+		DependencyIndexes: file_pb_error_codes_proto_depIdxs,
+		EnumInfos:         file_pb_error_codes_proto_enumTypes,
+		MessageInfos:      file_pb_error_codes_proto_msgTypes,
+	}.Build()
+	File_pb_error_codes_proto = out.File
+	file_pb_error_codes_proto_rawDesc = nil
+	file_pb_error_codes_proto_goTypes = nil
+	file_pb_error_codes_proto_depIdxs = nil
+}
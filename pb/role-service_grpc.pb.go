@@ -27,6 +27,7 @@ type RoleServiceClient interface {
 	List(ctx context.Context, in *RoleListRequest, opts ...grpc.CallOption) (*RoleListResponse, error)
 	Get(ctx context.Context, in *RoleGetRequest, opts ...grpc.CallOption) (*RoleGetResponse, error)
 	Update(ctx context.Context, in *RoleUpdateRequest, opts ...grpc.CallOption) (*RoleUpdateResponse, error)
+	Status(ctx context.Context, in *RoleStatusRequest, opts ...grpc.CallOption) (*RoleStatusResponse, error)
 }
 
 type roleServiceClient struct {
@@ -82,6 +83,15 @@ func (c *roleServiceClient) Update(ctx context.Context, in *RoleUpdateRequest, o
 	return out, nil
 }
 
+func (c *roleServiceClient) Status(ctx context.Context, in *RoleStatusRequest, opts ...grpc.CallOption) (*RoleStatusResponse, error) {
+	out := new(RoleStatusResponse)
+	err := c.cc.Invoke(ctx, "/karavi.RoleService/Status", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RoleServiceServer is the server API for RoleService service.
 // All implementations must embed UnimplementedRoleServiceServer
 // for forward compatibility
@@ -91,6 +101,7 @@ type RoleServiceServer interface {
 	List(context.Context, *RoleListRequest) (*RoleListResponse, error)
 	Get(context.Context, *RoleGetRequest) (*RoleGetResponse, error)
 	Update(context.Context, *RoleUpdateRequest) (*RoleUpdateResponse, error)
+	Status(context.Context, *RoleStatusRequest) (*RoleStatusResponse, error)
 	mustEmbedUnimplementedRoleServiceServer()
 }
 
@@ -113,6 +124,9 @@ func (UnimplementedRoleServiceServer) Get(context.Context, *RoleGetRequest) (*Ro
 func (UnimplementedRoleServiceServer) Update(context.Context, *RoleUpdateRequest) (*RoleUpdateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
 }
+func (UnimplementedRoleServiceServer) Status(context.Context, *RoleStatusRequest) (*RoleStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
 func (UnimplementedRoleServiceServer) mustEmbedUnimplementedRoleServiceServer() {}
 
 // UnsafeRoleServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -216,6 +230,24 @@ func _RoleService_Update_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RoleService_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoleStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoleServiceServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.RoleService/Status",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoleServiceServer).Status(ctx, req.(*RoleStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // RoleService_ServiceDesc is the grpc.ServiceDesc for RoleService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -243,6 +275,10 @@ var RoleService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Update",
 			Handler:    _RoleService_Update_Handler,
 		},
+		{
+			MethodName: "Status",
+			Handler:    _RoleService_Status_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pb/role-service.proto",
@@ -28,6 +28,10 @@ type StorageServiceClient interface {
 	Delete(ctx context.Context, in *StorageDeleteRequest, opts ...grpc.CallOption) (*StorageDeleteResponse, error)
 	Get(ctx context.Context, in *StorageGetRequest, opts ...grpc.CallOption) (*StorageGetResponse, error)
 	GetPowerflexVolumes(ctx context.Context, in *GetPowerflexVolumesRequest, opts ...grpc.CallOption) (*GetPowerflexVolumesResponse, error)
+	Status(ctx context.Context, in *StorageStatusRequest, opts ...grpc.CallOption) (*StorageStatusResponse, error)
+	Discover(ctx context.Context, in *StorageDiscoverRequest, opts ...grpc.CallOption) (*StorageDiscoverResponse, error)
+	GetVolume(ctx context.Context, in *GetVolumeRequest, opts ...grpc.CallOption) (*GetVolumeResponse, error)
+	ListVolumes(ctx context.Context, in *ListVolumesRequest, opts ...grpc.CallOption) (*ListVolumesResponse, error)
 }
 
 type storageServiceClient struct {
@@ -92,6 +96,42 @@ func (c *storageServiceClient) GetPowerflexVolumes(ctx context.Context, in *GetP
 	return out, nil
 }
 
+func (c *storageServiceClient) Status(ctx context.Context, in *StorageStatusRequest, opts ...grpc.CallOption) (*StorageStatusResponse, error) {
+	out := new(StorageStatusResponse)
+	err := c.cc.Invoke(ctx, "/karavi.StorageService/Status", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) Discover(ctx context.Context, in *StorageDiscoverRequest, opts ...grpc.CallOption) (*StorageDiscoverResponse, error) {
+	out := new(StorageDiscoverResponse)
+	err := c.cc.Invoke(ctx, "/karavi.StorageService/Discover", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) GetVolume(ctx context.Context, in *GetVolumeRequest, opts ...grpc.CallOption) (*GetVolumeResponse, error) {
+	out := new(GetVolumeResponse)
+	err := c.cc.Invoke(ctx, "/karavi.StorageService/GetVolume", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) ListVolumes(ctx context.Context, in *ListVolumesRequest, opts ...grpc.CallOption) (*ListVolumesResponse, error) {
+	out := new(ListVolumesResponse)
+	err := c.cc.Invoke(ctx, "/karavi.StorageService/ListVolumes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StorageServiceServer is the server API for StorageService service.
 // All implementations must embed UnimplementedStorageServiceServer
 // for forward compatibility
@@ -102,6 +142,10 @@ type StorageServiceServer interface {
 	Delete(context.Context, *StorageDeleteRequest) (*StorageDeleteResponse, error)
 	Get(context.Context, *StorageGetRequest) (*StorageGetResponse, error)
 	GetPowerflexVolumes(context.Context, *GetPowerflexVolumesRequest) (*GetPowerflexVolumesResponse, error)
+	Status(context.Context, *StorageStatusRequest) (*StorageStatusResponse, error)
+	Discover(context.Context, *StorageDiscoverRequest) (*StorageDiscoverResponse, error)
+	GetVolume(context.Context, *GetVolumeRequest) (*GetVolumeResponse, error)
+	ListVolumes(context.Context, *ListVolumesRequest) (*ListVolumesResponse, error)
 	mustEmbedUnimplementedStorageServiceServer()
 }
 
@@ -127,6 +171,18 @@ func (UnimplementedStorageServiceServer) Get(context.Context, *StorageGetRequest
 func (UnimplementedStorageServiceServer) GetPowerflexVolumes(context.Context, *GetPowerflexVolumesRequest) (*GetPowerflexVolumesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPowerflexVolumes not implemented")
 }
+func (UnimplementedStorageServiceServer) Status(context.Context, *StorageStatusRequest) (*StorageStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedStorageServiceServer) Discover(context.Context, *StorageDiscoverRequest) (*StorageDiscoverResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Discover not implemented")
+}
+func (UnimplementedStorageServiceServer) GetVolume(context.Context, *GetVolumeRequest) (*GetVolumeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVolume not implemented")
+}
+func (UnimplementedStorageServiceServer) ListVolumes(context.Context, *ListVolumesRequest) (*ListVolumesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVolumes not implemented")
+}
 func (UnimplementedStorageServiceServer) mustEmbedUnimplementedStorageServiceServer() {}
 
 // UnsafeStorageServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -248,6 +304,78 @@ func _StorageService_GetPowerflexVolumes_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StorageService_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StorageStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.StorageService/Status",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).Status(ctx, req.(*StorageStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_Discover_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StorageDiscoverRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).Discover(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.StorageService/Discover",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).Discover(ctx, req.(*StorageDiscoverRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_GetVolume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVolumeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).GetVolume(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.StorageService/GetVolume",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).GetVolume(ctx, req.(*GetVolumeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_ListVolumes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVolumesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).ListVolumes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.StorageService/ListVolumes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).ListVolumes(ctx, req.(*ListVolumesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // StorageService_ServiceDesc is the grpc.ServiceDesc for StorageService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -279,6 +407,22 @@ var StorageService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetPowerflexVolumes",
 			Handler:    _StorageService_GetPowerflexVolumes_Handler,
 		},
+		{
+			MethodName: "Status",
+			Handler:    _StorageService_Status_Handler,
+		},
+		{
+			MethodName: "Discover",
+			Handler:    _StorageService_Discover_Handler,
+		},
+		{
+			MethodName: "GetVolume",
+			Handler:    _StorageService_GetVolume_Handler,
+		},
+		{
+			MethodName: "ListVolumes",
+			Handler:    _StorageService_ListVolumes_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pb/storage_service.proto",
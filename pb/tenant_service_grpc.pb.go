@@ -33,6 +33,21 @@ type TenantServiceClient interface {
 	RefreshToken(ctx context.Context, in *RefreshTokenRequest, opts ...grpc.CallOption) (*RefreshTokenResponse, error)
 	RevokeTenant(ctx context.Context, in *RevokeTenantRequest, opts ...grpc.CallOption) (*RevokeTenantResponse, error)
 	CancelRevokeTenant(ctx context.Context, in *CancelRevokeTenantRequest, opts ...grpc.CallOption) (*CancelRevokeTenantResponse, error)
+	CreateOrganization(ctx context.Context, in *CreateOrganizationRequest, opts ...grpc.CallOption) (*Organization, error)
+	GetOrganization(ctx context.Context, in *GetOrganizationRequest, opts ...grpc.CallOption) (*Organization, error)
+	DeleteOrganization(ctx context.Context, in *DeleteOrganizationRequest, opts ...grpc.CallOption) (*DeleteOrganizationResponse, error)
+	ListOrganization(ctx context.Context, in *ListOrganizationRequest, opts ...grpc.CallOption) (*ListOrganizationResponse, error)
+	AllowSdc(ctx context.Context, in *AllowSdcRequest, opts ...grpc.CallOption) (*Tenant, error)
+	DisallowSdc(ctx context.Context, in *DisallowSdcRequest, opts ...grpc.CallOption) (*Tenant, error)
+	SetMaxVolumes(ctx context.Context, in *SetMaxVolumesRequest, opts ...grpc.CallOption) (*Tenant, error)
+	SetClaims(ctx context.Context, in *SetClaimsRequest, opts ...grpc.CallOption) (*Tenant, error)
+	SetProtected(ctx context.Context, in *SetProtectedRequest, opts ...grpc.CallOption) (*Tenant, error)
+	SetVolumeNamePolicy(ctx context.Context, in *SetVolumeNamePolicyRequest, opts ...grpc.CallOption) (*Tenant, error)
+	RestoreTenant(ctx context.Context, in *RestoreTenantRequest, opts ...grpc.CallOption) (*Tenant, error)
+	GetTenantQuota(ctx context.Context, in *GetTenantQuotaRequest, opts ...grpc.CallOption) (*TenantQuota, error)
+	SetDefaultRole(ctx context.Context, in *SetDefaultRoleRequest, opts ...grpc.CallOption) (*DefaultRole, error)
+	GetDefaultRole(ctx context.Context, in *GetDefaultRoleRequest, opts ...grpc.CallOption) (*DefaultRole, error)
+	GetActivity(ctx context.Context, in *GetActivityRequest, opts ...grpc.CallOption) (*GetActivityResponse, error)
 }
 
 type tenantServiceClient struct {
@@ -142,6 +157,141 @@ func (c *tenantServiceClient) CancelRevokeTenant(ctx context.Context, in *Cancel
 	return out, nil
 }
 
+func (c *tenantServiceClient) CreateOrganization(ctx context.Context, in *CreateOrganizationRequest, opts ...grpc.CallOption) (*Organization, error) {
+	out := new(Organization)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/CreateOrganization", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tenantServiceClient) GetOrganization(ctx context.Context, in *GetOrganizationRequest, opts ...grpc.CallOption) (*Organization, error) {
+	out := new(Organization)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/GetOrganization", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tenantServiceClient) DeleteOrganization(ctx context.Context, in *DeleteOrganizationRequest, opts ...grpc.CallOption) (*DeleteOrganizationResponse, error) {
+	out := new(DeleteOrganizationResponse)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/DeleteOrganization", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tenantServiceClient) ListOrganization(ctx context.Context, in *ListOrganizationRequest, opts ...grpc.CallOption) (*ListOrganizationResponse, error) {
+	out := new(ListOrganizationResponse)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/ListOrganization", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tenantServiceClient) AllowSdc(ctx context.Context, in *AllowSdcRequest, opts ...grpc.CallOption) (*Tenant, error) {
+	out := new(Tenant)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/AllowSdc", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tenantServiceClient) DisallowSdc(ctx context.Context, in *DisallowSdcRequest, opts ...grpc.CallOption) (*Tenant, error) {
+	out := new(Tenant)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/DisallowSdc", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tenantServiceClient) SetMaxVolumes(ctx context.Context, in *SetMaxVolumesRequest, opts ...grpc.CallOption) (*Tenant, error) {
+	out := new(Tenant)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/SetMaxVolumes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tenantServiceClient) SetClaims(ctx context.Context, in *SetClaimsRequest, opts ...grpc.CallOption) (*Tenant, error) {
+	out := new(Tenant)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/SetClaims", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tenantServiceClient) SetProtected(ctx context.Context, in *SetProtectedRequest, opts ...grpc.CallOption) (*Tenant, error) {
+	out := new(Tenant)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/SetProtected", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tenantServiceClient) SetVolumeNamePolicy(ctx context.Context, in *SetVolumeNamePolicyRequest, opts ...grpc.CallOption) (*Tenant, error) {
+	out := new(Tenant)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/SetVolumeNamePolicy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tenantServiceClient) RestoreTenant(ctx context.Context, in *RestoreTenantRequest, opts ...grpc.CallOption) (*Tenant, error) {
+	out := new(Tenant)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/RestoreTenant", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tenantServiceClient) GetTenantQuota(ctx context.Context, in *GetTenantQuotaRequest, opts ...grpc.CallOption) (*TenantQuota, error) {
+	out := new(TenantQuota)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/GetTenantQuota", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tenantServiceClient) SetDefaultRole(ctx context.Context, in *SetDefaultRoleRequest, opts ...grpc.CallOption) (*DefaultRole, error) {
+	out := new(DefaultRole)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/SetDefaultRole", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tenantServiceClient) GetDefaultRole(ctx context.Context, in *GetDefaultRoleRequest, opts ...grpc.CallOption) (*DefaultRole, error) {
+	out := new(DefaultRole)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/GetDefaultRole", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tenantServiceClient) GetActivity(ctx context.Context, in *GetActivityRequest, opts ...grpc.CallOption) (*GetActivityResponse, error) {
+	out := new(GetActivityResponse)
+	err := c.cc.Invoke(ctx, "/karavi.TenantService/GetActivity", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TenantServiceServer is the server API for TenantService service.
 // All implementations must embed UnimplementedTenantServiceServer
 // for forward compatibility
@@ -157,6 +307,21 @@ type TenantServiceServer interface {
 	RefreshToken(context.Context, *RefreshTokenRequest) (*RefreshTokenResponse, error)
 	RevokeTenant(context.Context, *RevokeTenantRequest) (*RevokeTenantResponse, error)
 	CancelRevokeTenant(context.Context, *CancelRevokeTenantRequest) (*CancelRevokeTenantResponse, error)
+	CreateOrganization(context.Context, *CreateOrganizationRequest) (*Organization, error)
+	GetOrganization(context.Context, *GetOrganizationRequest) (*Organization, error)
+	DeleteOrganization(context.Context, *DeleteOrganizationRequest) (*DeleteOrganizationResponse, error)
+	ListOrganization(context.Context, *ListOrganizationRequest) (*ListOrganizationResponse, error)
+	AllowSdc(context.Context, *AllowSdcRequest) (*Tenant, error)
+	DisallowSdc(context.Context, *DisallowSdcRequest) (*Tenant, error)
+	SetMaxVolumes(context.Context, *SetMaxVolumesRequest) (*Tenant, error)
+	SetClaims(context.Context, *SetClaimsRequest) (*Tenant, error)
+	SetProtected(context.Context, *SetProtectedRequest) (*Tenant, error)
+	SetVolumeNamePolicy(context.Context, *SetVolumeNamePolicyRequest) (*Tenant, error)
+	RestoreTenant(context.Context, *RestoreTenantRequest) (*Tenant, error)
+	GetTenantQuota(context.Context, *GetTenantQuotaRequest) (*TenantQuota, error)
+	SetDefaultRole(context.Context, *SetDefaultRoleRequest) (*DefaultRole, error)
+	GetDefaultRole(context.Context, *GetDefaultRoleRequest) (*DefaultRole, error)
+	GetActivity(context.Context, *GetActivityRequest) (*GetActivityResponse, error)
 	mustEmbedUnimplementedTenantServiceServer()
 }
 
@@ -197,6 +362,56 @@ func (UnimplementedTenantServiceServer) RevokeTenant(context.Context, *RevokeTen
 func (UnimplementedTenantServiceServer) CancelRevokeTenant(context.Context, *CancelRevokeTenantRequest) (*CancelRevokeTenantResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CancelRevokeTenant not implemented")
 }
+func (UnimplementedTenantServiceServer) CreateOrganization(context.Context, *CreateOrganizationRequest) (*Organization, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateOrganization not implemented")
+}
+func (UnimplementedTenantServiceServer) GetOrganization(context.Context, *GetOrganizationRequest) (*Organization, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrganization not implemented")
+}
+func (UnimplementedTenantServiceServer) DeleteOrganization(context.Context, *DeleteOrganizationRequest) (*DeleteOrganizationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteOrganization not implemented")
+}
+func (UnimplementedTenantServiceServer) ListOrganization(context.Context, *ListOrganizationRequest) (*ListOrganizationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOrganization not implemented")
+}
+func (UnimplementedTenantServiceServer) AllowSdc(context.Context, *AllowSdcRequest) (*Tenant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AllowSdc not implemented")
+}
+func (UnimplementedTenantServiceServer) DisallowSdc(context.Context, *DisallowSdcRequest) (*Tenant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisallowSdc not implemented")
+}
+
+func (UnimplementedTenantServiceServer) SetMaxVolumes(context.Context, *SetMaxVolumesRequest) (*Tenant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMaxVolumes not implemented")
+}
+func (UnimplementedTenantServiceServer) SetClaims(context.Context, *SetClaimsRequest) (*Tenant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetClaims not implemented")
+}
+func (UnimplementedTenantServiceServer) SetProtected(context.Context, *SetProtectedRequest) (*Tenant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetProtected not implemented")
+}
+func (UnimplementedTenantServiceServer) SetVolumeNamePolicy(context.Context, *SetVolumeNamePolicyRequest) (*Tenant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetVolumeNamePolicy not implemented")
+}
+func (UnimplementedTenantServiceServer) RestoreTenant(context.Context, *RestoreTenantRequest) (*Tenant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreTenant not implemented")
+}
+
+func (UnimplementedTenantServiceServer) GetTenantQuota(context.Context, *GetTenantQuotaRequest) (*TenantQuota, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTenantQuota not implemented")
+}
+
+func (UnimplementedTenantServiceServer) SetDefaultRole(context.Context, *SetDefaultRoleRequest) (*DefaultRole, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetDefaultRole not implemented")
+}
+
+func (UnimplementedTenantServiceServer) GetDefaultRole(context.Context, *GetDefaultRoleRequest) (*DefaultRole, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDefaultRole not implemented")
+}
+
+func (UnimplementedTenantServiceServer) GetActivity(context.Context, *GetActivityRequest) (*GetActivityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetActivity not implemented")
+}
 func (UnimplementedTenantServiceServer) mustEmbedUnimplementedTenantServiceServer() {}
 
 // UnsafeTenantServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -408,6 +623,276 @@ func _TenantService_CancelRevokeTenant_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TenantService_CreateOrganization_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateOrganizationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).CreateOrganization(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/CreateOrganization",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).CreateOrganization(ctx, req.(*CreateOrganizationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TenantService_GetOrganization_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrganizationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).GetOrganization(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/GetOrganization",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).GetOrganization(ctx, req.(*GetOrganizationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TenantService_DeleteOrganization_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteOrganizationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).DeleteOrganization(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/DeleteOrganization",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).DeleteOrganization(ctx, req.(*DeleteOrganizationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TenantService_ListOrganization_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOrganizationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).ListOrganization(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/ListOrganization",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).ListOrganization(ctx, req.(*ListOrganizationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TenantService_AllowSdc_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AllowSdcRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).AllowSdc(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/AllowSdc",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).AllowSdc(ctx, req.(*AllowSdcRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TenantService_DisallowSdc_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DisallowSdcRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).DisallowSdc(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/DisallowSdc",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).DisallowSdc(ctx, req.(*DisallowSdcRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TenantService_SetMaxVolumes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMaxVolumesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).SetMaxVolumes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/SetMaxVolumes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).SetMaxVolumes(ctx, req.(*SetMaxVolumesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TenantService_SetClaims_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetClaimsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).SetClaims(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/SetClaims",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).SetClaims(ctx, req.(*SetClaimsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TenantService_SetProtected_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetProtectedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).SetProtected(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/SetProtected",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).SetProtected(ctx, req.(*SetProtectedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TenantService_SetVolumeNamePolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetVolumeNamePolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).SetVolumeNamePolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/SetVolumeNamePolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).SetVolumeNamePolicy(ctx, req.(*SetVolumeNamePolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TenantService_RestoreTenant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreTenantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).RestoreTenant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/RestoreTenant",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).RestoreTenant(ctx, req.(*RestoreTenantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TenantService_GetTenantQuota_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTenantQuotaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).GetTenantQuota(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/GetTenantQuota",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).GetTenantQuota(ctx, req.(*GetTenantQuotaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TenantService_SetDefaultRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetDefaultRoleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).SetDefaultRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/SetDefaultRole",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).SetDefaultRole(ctx, req.(*SetDefaultRoleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TenantService_GetDefaultRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDefaultRoleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).GetDefaultRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/GetDefaultRole",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).GetDefaultRole(ctx, req.(*GetDefaultRoleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TenantService_GetActivity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetActivityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TenantServiceServer).GetActivity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/karavi.TenantService/GetActivity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TenantServiceServer).GetActivity(ctx, req.(*GetActivityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TenantService_ServiceDesc is the grpc.ServiceDesc for TenantService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -459,6 +944,66 @@ var TenantService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CancelRevokeTenant",
 			Handler:    _TenantService_CancelRevokeTenant_Handler,
 		},
+		{
+			MethodName: "CreateOrganization",
+			Handler:    _TenantService_CreateOrganization_Handler,
+		},
+		{
+			MethodName: "GetOrganization",
+			Handler:    _TenantService_GetOrganization_Handler,
+		},
+		{
+			MethodName: "DeleteOrganization",
+			Handler:    _TenantService_DeleteOrganization_Handler,
+		},
+		{
+			MethodName: "ListOrganization",
+			Handler:    _TenantService_ListOrganization_Handler,
+		},
+		{
+			MethodName: "AllowSdc",
+			Handler:    _TenantService_AllowSdc_Handler,
+		},
+		{
+			MethodName: "DisallowSdc",
+			Handler:    _TenantService_DisallowSdc_Handler,
+		},
+		{
+			MethodName: "SetMaxVolumes",
+			Handler:    _TenantService_SetMaxVolumes_Handler,
+		},
+		{
+			MethodName: "SetClaims",
+			Handler:    _TenantService_SetClaims_Handler,
+		},
+		{
+			MethodName: "SetProtected",
+			Handler:    _TenantService_SetProtected_Handler,
+		},
+		{
+			MethodName: "SetVolumeNamePolicy",
+			Handler:    _TenantService_SetVolumeNamePolicy_Handler,
+		},
+		{
+			MethodName: "RestoreTenant",
+			Handler:    _TenantService_RestoreTenant_Handler,
+		},
+		{
+			MethodName: "GetTenantQuota",
+			Handler:    _TenantService_GetTenantQuota_Handler,
+		},
+		{
+			MethodName: "SetDefaultRole",
+			Handler:    _TenantService_SetDefaultRole_Handler,
+		},
+		{
+			MethodName: "GetDefaultRole",
+			Handler:    _TenantService_GetDefaultRole_Handler,
+		},
+		{
+			MethodName: "GetActivity",
+			Handler:    _TenantService_GetActivity_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pb/tenant_service.proto",
@@ -41,9 +41,13 @@ then
     $K3S kubectl create configmap common -n karavi --from-file=./common.rego --save-config --dry-run=client -o yaml | $K3S kubectl apply -f -
 fi
 $K3S kubectl create configmap powermax-volumes-create -n karavi --from-file=./volumes_powermax_create.rego --save-config --dry-run=client -o yaml | $K3S kubectl apply -f -
+$K3S kubectl create configmap powermax-volumes-storagegroup -n karavi --from-file=./volumes_powermax_storagegroup.rego --save-config --dry-run=client -o yaml | $K3S kubectl apply -f -
+$K3S kubectl create configmap powermax-volumes-masking -n karavi --from-file=./volumes_powermax_masking.rego --save-config --dry-run=client -o yaml | $K3S kubectl apply -f -
+$K3S kubectl create configmap powerscale-volumes-share -n karavi --from-file=./volumes_powerscale_share.rego --save-config --dry-run=client -o yaml | $K3S kubectl apply -f -
 $K3S kubectl create configmap volumes-create -n karavi --from-file=./volumes_create.rego --save-config --dry-run=client -o yaml | $K3S kubectl apply -f -
 $K3S kubectl create configmap volumes-delete -n karavi --from-file=./volumes_delete.rego --save-config --dry-run=client -o yaml | $K3S kubectl apply -f -
 $K3S kubectl create configmap volumes-unmap -n karavi --from-file=./volumes_unmap.rego --save-config --dry-run=client -o yaml | $K3S kubectl apply -f -
 $K3S kubectl create configmap volumes-map -n karavi --from-file=./volumes_map.rego --save-config --dry-run=client -o yaml | $K3S kubectl apply -f -
+$K3S kubectl create configmap volumes-batch -n karavi --from-file=./volumes_batch.rego --save-config --dry-run=client -o yaml | $K3S kubectl apply -f -
 $K3S kubectl create configmap sdc-approve -n karavi --from-file=./sdc_approve.rego --save-config --dry-run=client -o yaml | $K3S kubectl apply -f -
 
@@ -0,0 +1,25 @@
+# Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http:#www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Batch entrypoints answer many queries in one round trip, e.g. the map and
+# unmap requests of a node that rebooted. The input is a list of the inputs
+# of single queries, and each result is the decision of the input at the
+# same index.
+package karavi.volumes.batch
+
+import data.karavi.volumes
+
+map := [volumes.map with input as q | q := input.queries[_]]
+
+unmap := [volumes.unmap with input as q | q := input.queries[_]]
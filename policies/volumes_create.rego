@@ -49,11 +49,56 @@ deny[msg] {
            input.storagepool])
 }
 
+#
+# Deny if the volume name does not conform to the
+# volume name policy of the tenant, set with
+# karavictl tenant update --volume-prefix and
+# --volume-pattern. The proxy-server checks the
+# policy as well, for policies without these rules.
+#
+deny[msg] {
+  input.volumename.prefix != ""
+  not startswith(input.volumename.name, input.volumename.prefix)
+  msg := sprintf("volume name not allowed: %s does not start with %s",
+           [input.volumename.name, input.volumename.prefix])
+}
+
+deny[msg] {
+  input.volumename.pattern != ""
+  not regex.match(input.volumename.pattern, input.volumename.name)
+  msg := sprintf("volume name not allowed: %s does not match %s",
+           [input.volumename.name, input.volumename.pattern])
+}
+
+#
+# Deny if the volume size is out of the bounds of every
+# permitted role, set with the min size and max size of
+# the roles. The proxy-server checks the bounds as well,
+# for policies without these rules.
+#
+deny[msg] {
+  count(permitted_roles) != 0
+  count(size_permitted_roles) == 0
+  some v
+  min_sizes[v] > to_number(input.request.volumeSizeInKb)
+  msg := sprintf("volume size not allowed: %v Kb is below the minSize %v Kb of role %s",
+           [input.request.volumeSizeInKb, min_sizes[v], v])
+}
+
+deny[msg] {
+  count(permitted_roles) != 0
+  count(size_permitted_roles) == 0
+  some v
+  max_sizes[v] < to_number(input.request.volumeSizeInKb)
+  msg := sprintf("volume size not allowed: %v Kb is above the maxSize %v Kb of role %s",
+           [input.request.volumeSizeInKb, max_sizes[v], v])
+}
+
 #
 # These are permitted roles that are configured
 # with the requested storage system, mapped to
 # the allowable quota for the request storage
-# pool.
+# pool. A quota of 0 permits no requests.
 #
 # Example: { "role-1": 800000 }
 #
@@ -69,12 +114,13 @@ permitted_roles[v] = y {
 
   # v will contain permitted roles that match the storage request.
   v := claimed_roles[i]
+  common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_quotas[input.storagepool] > 0
   common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_quotas[input.storagepool] >= to_number(input.request.volumeSizeInKb)
   y := to_number(common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_quotas[input.storagepool])
 }
 
 # These are the permitted roles that are configured
-# with zero quota, meaning infinite capacity.
+# with a quota of -1, meaning unlimited capacity.
 #
 permitted_roles[v] = y {
   # Split the claimed roles by comma into an array.
@@ -88,6 +134,179 @@ permitted_roles[v] = y {
 
   # v will contain permitted roles that match the storage request.
   v := claimed_roles[i]
-  common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_quotas[input.storagepool] == 0
+  common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_quotas[input.storagepool] == -1
   y := to_number(common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_quotas[input.storagepool])
 }
+
+#
+# These are the permitted PowerFlex roles that are configured
+# with the protection domain of the requested storage pool,
+# e.g. a "pd:domain1" pool, unless the role names the storage
+# pool itself. The quota applies to each pool of the domain.
+#
+permitted_roles[v] = y {
+  # Split the claimed roles by comma into an array.
+  claimed_roles := split(input.claims.roles, ",")
+
+  # This block filters 'a' to contain only roles
+  # that are found in 'common.roles'.
+  some i
+  a := claimed_roles[i]
+  common.roles[a]
+
+  # v will contain permitted roles that match the storage request.
+  v := claimed_roles[i]
+  system := common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid]
+  not system.pool_quotas[input.storagepool]
+  some pd
+  system.protection_domains[pd] == input.protectiondomainid
+  system.pool_quotas[pd] > 0
+  system.pool_quotas[pd] >= to_number(input.request.volumeSizeInKb)
+  y := to_number(system.pool_quotas[pd])
+}
+
+# These are the permitted PowerFlex roles that are configured
+# with the protection domain of the requested storage pool and
+# a quota of -1, meaning unlimited capacity.
+#
+permitted_roles[v] = y {
+  # Split the claimed roles by comma into an array.
+  claimed_roles := split(input.claims.roles, ",")
+
+  # This block filters 'a' to contain only roles
+  # that are found in 'common.roles'.
+  some i
+  a := claimed_roles[i]
+  common.roles[a]
+
+  # v will contain permitted roles that match the storage request.
+  v := claimed_roles[i]
+  system := common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid]
+  not system.pool_quotas[input.storagepool]
+  some pd
+  system.protection_domains[pd] == input.protectiondomainid
+  system.pool_quotas[pd] == -1
+  y := to_number(system.pool_quotas[pd])
+}
+
+#
+# These are the soft quotas of the permitted roles that
+# are configured with one for the requested storage pool.
+# Usage above the soft quota is allowed only during the
+# grace period of the quota enforcement.
+#
+# Example: { "role-1": 600000 }
+#
+soft_quotas[v] = y {
+  permitted_roles[v]
+  y := to_number(common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_soft_quotas[input.storagepool])
+}
+
+soft_quotas[v] = y {
+  permitted_roles[v]
+  system := common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid]
+  not system.pool_quotas[input.storagepool]
+  some pd
+  system.protection_domains[pd] == input.protectiondomainid
+  y := to_number(system.pool_soft_quotas[pd])
+}
+
+#
+# These are the volume size bounds of the permitted roles
+# that are configured with them for the requested storage
+# pool, in kilobytes.
+#
+# Example: { "role-1": 1048576 }
+#
+min_sizes[v] = y {
+  permitted_roles[v]
+  y := to_number(common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_min_sizes[input.storagepool])
+}
+
+min_sizes[v] = y {
+  permitted_roles[v]
+  system := common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid]
+  not system.pool_quotas[input.storagepool]
+  some pd
+  system.protection_domains[pd] == input.protectiondomainid
+  y := to_number(system.pool_min_sizes[pd])
+}
+
+max_sizes[v] = y {
+  permitted_roles[v]
+  y := to_number(common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_max_sizes[input.storagepool])
+}
+
+max_sizes[v] = y {
+  permitted_roles[v]
+  system := common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid]
+  not system.pool_quotas[input.storagepool]
+  some pd
+  system.protection_domains[pd] == input.protectiondomainid
+  y := to_number(system.pool_max_sizes[pd])
+}
+
+#
+# These are the permitted roles whose volume size bounds,
+# if any, allow the requested volume size.
+#
+size_permitted_roles[v] {
+  permitted_roles[v]
+  not size_out_of_bounds[v]
+}
+
+size_out_of_bounds[v] {
+  min_sizes[v] > to_number(input.request.volumeSizeInKb)
+}
+
+size_out_of_bounds[v] {
+  max_sizes[v] < to_number(input.request.volumeSizeInKb)
+}
+
+#
+# These are the other storage pools of the requested storage
+# system that the claimed roles would permit the request in,
+# mapped to the largest quota for the pool. The proxy-server
+# returns those with capacity left as hints, when the request
+# is denied for lack of quota in the requested pool. Protection
+# domains are not pools themselves, so they are left out.
+#
+# Example: { "silver": 93886080000 }
+#
+alternative_pools[p] = y {
+  alternative_pool_quotas[[p, _]]
+  quotas := {q | alternative_pool_quotas[[p, q]]}
+  y := largest_quota(quotas)
+}
+
+alternative_pool_quotas[[p, y]] {
+  # Split the claimed roles by comma into an array.
+  claimed_roles := split(input.claims.roles, ",")
+
+  some i
+  system := common.roles[claimed_roles[i]].system_types[input.systemtype].system_ids[input.storagesystemid]
+  some p
+  system.pool_quotas[p]
+  p != input.storagepool
+  not system.protection_domains[p]
+  y := to_number(system.pool_quotas[p])
+  quota_permits(y)
+}
+
+quota_permits(y) {
+  y == -1
+}
+
+quota_permits(y) {
+  y > 0
+  y >= to_number(input.request.volumeSizeInKb)
+}
+
+largest_quota(quotas) = -1 {
+  quotas[-1]
+}
+
+largest_quota(quotas) = y {
+  not quotas[-1]
+  y := max(quotas)
+}
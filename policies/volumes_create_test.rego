@@ -54,6 +54,83 @@ roles = {
         }
       }
     },
+    "us-west-3-none": {
+      "system_types": {
+        "powerflex": {
+          "system_ids": {
+            "3333": {
+              "pool_quotas": {
+                "bronze": 0
+              }
+            }
+          }
+        }
+      }
+    },
+    "us-west-3-unlimited": {
+      "system_types": {
+        "powerflex": {
+          "system_ids": {
+            "3333": {
+              "pool_quotas": {
+                "bronze": -1
+              }
+            }
+          }
+        }
+      }
+    },
+    "us-west-4-soft": {
+      "system_types": {
+        "powerflex": {
+          "system_ids": {
+            "4444": {
+              "pool_quotas": {
+                "bronze": 83886080
+              },
+              "pool_soft_quotas": {
+                "bronze": 41943040
+              }
+            }
+          }
+        }
+      }
+    },
+    "us-west-6-sized": {
+      "system_types": {
+        "powerflex": {
+          "system_ids": {
+            "6666": {
+              "pool_quotas": {
+                "bronze": 83886080
+              },
+              "pool_min_sizes": {
+                "bronze": 1048576
+              },
+              "pool_max_sizes": {
+                "bronze": 16777216
+              }
+            }
+          }
+        }
+      }
+    },
+    "us-west-5-domain": {
+      "system_types": {
+        "powerflex": {
+          "system_ids": {
+            "5555": {
+              "pool_quotas": {
+                "pd:domain1": 83886080
+              },
+              "protection_domains": {
+                "pd:domain1": "75b661b400000000"
+              }
+            }
+          }
+        }
+      }
+    },
     "us-west-2-large": {
       "system_types": {
         "powerflex": {
@@ -115,3 +192,233 @@ test_large_request_not_allowed {
     "storagetype": "powerflex"
   } with data.karavi.common.roles as roles
 }
+
+test_zero_quota_not_allowed {
+  not allow with input as {
+    "claims": {
+        "group": "DevOpsGroup1",
+        "roles":"us-west-3-none"
+    },
+    "request": {
+        "volumeSizeInKb":"8388608"
+    },
+    "storagepool":"bronze",
+    "storagesystemid":"3333",
+    "systemtype": "powerflex"
+  } with data.karavi.common.roles as roles
+}
+
+test_unlimited_quota_allowed {
+  allow with input as {
+    "claims": {
+        "group": "DevOpsGroup1",
+        "roles":"us-west-3-unlimited"
+    },
+    "request": {
+        "volumeSizeInKb":"9999999999"
+    },
+    "storagepool":"bronze",
+    "storagesystemid":"3333",
+    "systemtype": "powerflex"
+  } with data.karavi.common.roles as roles
+}
+
+test_soft_quota_reported {
+  soft_quotas == {"us-west-4-soft": 41943040} with input as {
+    "claims": {
+        "group": "DevOpsGroup1",
+        "roles":"us-west-4-soft,us-east-1"
+    },
+    "request": {
+        "volumeSizeInKb":"8388608"
+    },
+    "storagepool":"bronze",
+    "storagesystemid":"4444",
+    "systemtype": "powerflex"
+  } with data.karavi.common.roles as roles
+}
+
+test_no_soft_quota_reported {
+  count(soft_quotas) == 0 with input as {
+    "claims": {
+        "group": "DevOpsGroup1",
+        "roles":"us-east-1"
+    },
+    "request": {
+        "volumeSizeInKb":"8388608"
+    },
+    "storagepool":"bronze",
+    "storagesystemid":"2222",
+    "systemtype": "powerflex"
+  } with data.karavi.common.roles as roles
+}
+
+test_protection_domain_pool_allowed {
+  allow with input as {
+    "claims": {
+        "group": "DevOpsGroup1",
+        "roles":"us-west-5-domain"
+    },
+    "request": {
+        "volumeSizeInKb":"8388608"
+    },
+    "storagepool":"bronze",
+    "protectiondomainid":"75b661b400000000",
+    "storagesystemid":"5555",
+    "systemtype": "powerflex"
+  } with data.karavi.common.roles as roles
+}
+
+test_other_protection_domain_pool_not_allowed {
+  not allow with input as {
+    "claims": {
+        "group": "DevOpsGroup1",
+        "roles":"us-west-5-domain"
+    },
+    "request": {
+        "volumeSizeInKb":"8388608"
+    },
+    "storagepool":"bronze",
+    "protectiondomainid":"75b661b400000001",
+    "storagesystemid":"5555",
+    "systemtype": "powerflex"
+  } with data.karavi.common.roles as roles
+}
+
+test_volume_name_with_prefix_allowed {
+  allow with input as {
+    "claims": {
+        "group": "DevOpsGroup1",
+        "roles":"us-east-1"
+    },
+    "request": {
+        "name":"pay-0fc0695995",
+        "volumeSizeInKb":"8388608"
+    },
+    "storagepool":"bronze",
+    "storagesystemid":"2222",
+    "systemtype": "powerflex",
+    "volumename": {
+        "name":"pay-0fc0695995",
+        "prefix":"pay-",
+        "pattern":"^pay-[0-9a-f]+$"
+    }
+  } with data.karavi.common.roles as roles
+}
+
+test_volume_name_without_prefix_not_allowed {
+  deny["volume name not allowed: k8s-0fc0695995 does not start with pay-"] with input as {
+    "claims": {
+        "group": "DevOpsGroup1",
+        "roles":"us-east-1"
+    },
+    "request": {
+        "name":"k8s-0fc0695995",
+        "volumeSizeInKb":"8388608"
+    },
+    "storagepool":"bronze",
+    "storagesystemid":"2222",
+    "systemtype": "powerflex",
+    "volumename": {
+        "name":"k8s-0fc0695995",
+        "prefix":"pay-",
+        "pattern":""
+    }
+  } with data.karavi.common.roles as roles
+}
+
+test_volume_name_not_matching_pattern_not_allowed {
+  not allow with input as {
+    "claims": {
+        "group": "DevOpsGroup1",
+        "roles":"us-east-1"
+    },
+    "request": {
+        "name":"pay-volume",
+        "volumeSizeInKb":"8388608"
+    },
+    "storagepool":"bronze",
+    "storagesystemid":"2222",
+    "systemtype": "powerflex",
+    "volumename": {
+        "name":"pay-volume",
+        "prefix":"pay-",
+        "pattern":"^pay-[0-9a-f]+$"
+    }
+  } with data.karavi.common.roles as roles
+}
+
+test_volume_size_within_bounds_allowed {
+  allow with input as {
+    "claims": {
+        "group": "DevOpsGroup1",
+        "roles":"us-west-6-sized"
+    },
+    "request": {
+        "volumeSizeInKb":"8388608"
+    },
+    "storagepool":"bronze",
+    "storagesystemid":"6666",
+    "systemtype": "powerflex"
+  } with data.karavi.common.roles as roles
+}
+
+test_volume_size_below_min_size_not_allowed {
+  deny["volume size not allowed: 524288 Kb is below the minSize 1048576 Kb of role us-west-6-sized"] with input as {
+    "claims": {
+        "group": "DevOpsGroup1",
+        "roles":"us-west-6-sized"
+    },
+    "request": {
+        "volumeSizeInKb":"524288"
+    },
+    "storagepool":"bronze",
+    "storagesystemid":"6666",
+    "systemtype": "powerflex"
+  } with data.karavi.common.roles as roles
+}
+
+test_volume_size_above_max_size_not_allowed {
+  not allow with input as {
+    "claims": {
+        "group": "DevOpsGroup1",
+        "roles":"us-west-6-sized"
+    },
+    "request": {
+        "volumeSizeInKb":"33554432"
+    },
+    "storagepool":"bronze",
+    "storagesystemid":"6666",
+    "systemtype": "powerflex"
+  } with data.karavi.common.roles as roles
+}
+
+test_alternative_pools_reported {
+  alternative_pools == {"silver": 93886080000} with input as {
+    "claims": {
+        "group": "DevOpsGroup1",
+        "roles":"us-west-2-large,us-west-2-small"
+    },
+    "request": {
+        "volumeSizeInKb":"8388608"
+    },
+    "storagepool":"bronze",
+    "storagesystemid":"2222",
+    "systemtype": "powerflex"
+  } with data.karavi.common.roles as roles
+}
+
+test_alternative_pools_too_small_not_reported {
+  count(alternative_pools) == 0 with input as {
+    "claims": {
+        "group": "DevOpsGroup1",
+        "roles":"us-west-2-large"
+    },
+    "request": {
+        "volumeSizeInKb":"838860801"
+    },
+    "storagepool":"silver",
+    "storagesystemid":"2222",
+    "systemtype": "powerflex"
+  } with data.karavi.common.roles as roles
+}
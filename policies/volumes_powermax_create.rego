@@ -49,11 +49,56 @@ deny[msg] {
            input.storagepool])
 }
 
+#
+# Deny if the volume name does not conform to the
+# volume name policy of the tenant, set with
+# karavictl tenant update --volume-prefix and
+# --volume-pattern. The proxy-server checks the
+# policy as well, for policies without these rules.
+#
+deny[msg] {
+  input.volumename.prefix != ""
+  not startswith(input.volumename.name, input.volumename.prefix)
+  msg := sprintf("volume name not allowed: %s does not start with %s",
+           [input.volumename.name, input.volumename.prefix])
+}
+
+deny[msg] {
+  input.volumename.pattern != ""
+  not regex.match(input.volumename.pattern, input.volumename.name)
+  msg := sprintf("volume name not allowed: %s does not match %s",
+           [input.volumename.name, input.volumename.pattern])
+}
+
+#
+# Deny if the volume size is out of the bounds of every
+# permitted role, set with the min size and max size of
+# the roles. The proxy-server checks the bounds as well,
+# for policies without these rules.
+#
+deny[msg] {
+  count(permitted_roles) != 0
+  count(size_permitted_roles) == 0
+  some v
+  min_sizes[v] > to_number(input.request.volumeSizeInKb)
+  msg := sprintf("volume size not allowed: %v Kb is below the minSize %v Kb of role %s",
+           [input.request.volumeSizeInKb, min_sizes[v], v])
+}
+
+deny[msg] {
+  count(permitted_roles) != 0
+  count(size_permitted_roles) == 0
+  some v
+  max_sizes[v] < to_number(input.request.volumeSizeInKb)
+  msg := sprintf("volume size not allowed: %v Kb is above the maxSize %v Kb of role %s",
+           [input.request.volumeSizeInKb, max_sizes[v], v])
+}
+
 #
 # These are permitted roles that are configured
 # with the requested storage system, mapped to
 # the allowable quota for the request storage
-# pool.
+# pool. A quota of 0 permits no requests.
 #
 # Example: { "role-1": 800000 }
 #
@@ -69,12 +114,14 @@ permitted_roles[v] = y {
 
   # v will contain permitted roles that match the storage request.
   v := claimed_roles[i]
+  common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_quotas[input.storagepool] > 0
   common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_quotas[input.storagepool] >= to_number(input.request.volumeSizeInKb)
+  service_level_permitted(v)
   y := to_number(common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_quotas[input.storagepool])
 }
 
 # These are the permitted roles that are configured
-# with zero quota, meaning infinite capacity.
+# with a quota of -1, meaning unlimited capacity.
 #
 permitted_roles[v] = y {
   # Split the claimed roles by comma into an array.
@@ -88,6 +135,70 @@ permitted_roles[v] = y {
 
   # v will contain permitted roles that match the storage request.
   v := claimed_roles[i]
-  common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_quotas[input.storagepool] == 0
+  common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_quotas[input.storagepool] == -1
+  service_level_permitted(v)
   y := to_number(common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_quotas[input.storagepool])
 }
+
+#
+# A role permits any service level of a pool, unless
+# it is pinned to some with pool_service_levels, e.g.
+# { "SRP_1": ["Diamond"] }. Service levels are compared
+# ignoring case.
+#
+service_level_permitted(v) {
+  not common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_service_levels[input.storagepool]
+}
+
+service_level_permitted(v) {
+  some i
+  sl := common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_service_levels[input.storagepool][i]
+  lower(sl) == lower(input.servicelevel)
+}
+
+#
+# These are the soft quotas of the permitted roles that
+# are configured with one for the requested storage pool.
+# Usage above the soft quota is allowed only during the
+# grace period of the quota enforcement.
+#
+# Example: { "role-1": 600000 }
+#
+soft_quotas[v] = y {
+  permitted_roles[v]
+  y := to_number(common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_soft_quotas[input.storagepool])
+}
+
+#
+# These are the volume size bounds of the permitted roles
+# that are configured with them for the requested storage
+# pool, in kilobytes.
+#
+# Example: { "role-1": 1048576 }
+#
+min_sizes[v] = y {
+  permitted_roles[v]
+  y := to_number(common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_min_sizes[input.storagepool])
+}
+
+max_sizes[v] = y {
+  permitted_roles[v]
+  y := to_number(common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid].pool_max_sizes[input.storagepool])
+}
+
+#
+# These are the permitted roles whose volume size bounds,
+# if any, allow the requested volume size.
+#
+size_permitted_roles[v] {
+  permitted_roles[v]
+  not size_out_of_bounds[v]
+}
+
+size_out_of_bounds[v] {
+  min_sizes[v] > to_number(input.request.volumeSizeInKb)
+}
+
+size_out_of_bounds[v] {
+  max_sizes[v] < to_number(input.request.volumeSizeInKb)
+}
@@ -0,0 +1,65 @@
+# Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http:#www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+package karavi.volumes.powermax.storagegroup
+
+import data.karavi.common
+
+default response = {
+	"allowed": true
+}
+response = {
+    "allowed": false,
+    "status": {
+        "reason": reason,
+    },
+} {
+    reason = concat(", ", deny)
+    reason != ""
+}
+
+deny[msg] {
+  common.roles == {}
+  msg := sprintf("no role data found", [])
+}
+
+default claims = {}
+claims = input.claims
+deny[msg] {
+  claims == {}
+  msg := sprintf("missing claims", [])
+}
+
+#
+# Deny if none of the claimed roles is configured
+# with the storage system of the storage group.
+#
+deny[msg] {
+  claims != {}
+  count(permitted_roles) == 0
+  msg := sprintf("no roles in [%s] allow the %s of volumes in storage group %s on %s/%s",
+           [input.claims.roles,
+           input.action,
+           input.storagegroup,
+           input.systemtype,
+           input.storagesystemid])
+}
+
+permitted_roles[v] {
+  claimed_roles := split(input.claims.roles, ",")
+
+  some i
+  v := claimed_roles[i]
+  common.roles[v].system_types[input.systemtype].system_ids[input.storagesystemid]
+}
@@ -0,0 +1,77 @@
+# Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http:#www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+package karavi.volumes.powerscale.share
+
+import data.karavi.common
+
+default response = {
+	"allowed": true
+}
+response = {
+    "allowed": false,
+    "status": {
+        "reason": reason,
+    },
+} {
+    reason = concat(", ", deny)
+    reason != ""
+}
+
+deny[msg] {
+  common.roles == {}
+  msg := sprintf("no role data found", [])
+}
+
+default claims = {}
+claims = input.claims
+deny[msg] {
+  claims == {}
+  msg := sprintf("missing claims", [])
+}
+
+#
+# Deny if none of the claimed roles has a pool, the
+# isiPath of the role, that the path of the NFS export
+# or SMB share is within.
+#
+deny[msg] {
+  claims != {}
+  some i
+  p := input.paths[i]
+  not permitted_path(p)
+  msg := sprintf("no roles in [%s] allow the %s of %s shares of %s on %s/%s",
+           [input.claims.roles,
+           input.action,
+           input.protocol,
+           p,
+           input.systemtype,
+           input.storagesystemid])
+}
+
+permitted_path(p) {
+  claimed_roles := split(input.claims.roles, ",")
+
+  some i, pool
+  common.roles[claimed_roles[i]].system_types[input.systemtype].system_ids[input.storagesystemid].pool_quotas[pool]
+  within(p, pool)
+}
+
+within(p, pool) {
+  p == pool
+}
+
+within(p, pool) {
+  startswith(p, concat("", [trim_suffix(pool, "/"), "/"]))
+}
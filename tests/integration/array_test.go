@@ -0,0 +1,147 @@
+//go:build integration
+
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	types "github.com/dell/goscaleio/types/v1"
+)
+
+const (
+	powerFlexSystemID = "542a2d5f5122210f"
+	powerFlexPoolID   = "3df6b86600000000"
+	powerFlexPool     = "bronze"
+)
+
+// powerFlexArray is a fake PowerFlex array that keeps the volumes created
+// through the proxy-server, so that the tests can check the state of the
+// array at the end of a request sequence.
+type powerFlexArray struct {
+	*httptest.Server
+	systemID string
+
+	mu      sync.Mutex
+	volumes map[string]*types.Volume
+	nextID  int
+}
+
+func newPowerFlexArray() *powerFlexArray {
+	a := &powerFlexArray{
+		systemID: powerFlexSystemID,
+		volumes:  make(map[string]*types.Volume),
+	}
+	a.Server = httptest.NewTLSServer(http.HandlerFunc(a.serveHTTP))
+	return a
+}
+
+// volume returns a copy of the volume, or nil if it does not exist.
+func (a *powerFlexArray) volume(id string) *types.Volume {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	v, ok := a.volumes[id]
+	if !ok {
+		return nil
+	}
+	c := *v
+	c.MappedSdcInfo = append([]*types.MappedSdcInfo(nil), v.MappedSdcInfo...)
+	return &c
+}
+
+func (a *powerFlexArray) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == "/api/login":
+		writeJSON(w, "token")
+	case path == "/api/version":
+		writeJSON(w, "3.5")
+	case path == "/api/types/StoragePool/instances" && r.Method == http.MethodGet:
+		writeJSON(w, []types.StoragePool{{ID: powerFlexPoolID, Name: powerFlexPool, ProtectionDomainID: "b8b3919900000000"}})
+	case path == "/api/types/Volume/instances" && r.Method == http.MethodPost:
+		var body struct {
+			Name           string `json:"name"`
+			VolumeSizeInKb string `json:"volumeSizeInKb"`
+			StoragePoolID  string `json:"storagePoolId"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var size int
+		fmt.Sscan(body.VolumeSizeInKb, &size)
+		a.nextID++
+		id := fmt.Sprintf("%016x", a.nextID)
+		a.volumes[id] = &types.Volume{ID: id, Name: body.Name, SizeInKb: size, StoragePoolID: body.StoragePoolID}
+		writeJSON(w, types.VolumeResp{ID: id})
+	case strings.HasPrefix(path, "/api/instances/Volume::"):
+		a.serveVolume(w, r, strings.TrimPrefix(path, "/api/instances/Volume::"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveVolume serves the requests of a volume: getting it and the
+// actions on it.
+func (a *powerFlexArray) serveVolume(w http.ResponseWriter, r *http.Request, rest string) {
+	id, action, _ := strings.Cut(rest, "/action/")
+	v, ok := a.volumes[id]
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		writeJSON(w, types.Error{Message: "Could not find the volume", HTTPStatusCode: http.StatusInternalServerError, ErrorCode: 79})
+		return
+	}
+
+	var body struct {
+		SdcID string `json:"sdcId"`
+	}
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	switch action {
+	case "":
+		writeJSON(w, v)
+	case "addMappedSdc":
+		v.MappedSdcInfo = append(v.MappedSdcInfo, &types.MappedSdcInfo{SdcID: body.SdcID})
+	case "removeMappedSdc":
+		for i, m := range v.MappedSdcInfo {
+			if m.SdcID == body.SdcID {
+				v.MappedSdcInfo = append(v.MappedSdcInfo[:i], v.MappedSdcInfo[i+1:]...)
+				break
+			}
+		}
+	case "removeVolume":
+		delete(a.volumes, id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,442 @@
+//go:build integration
+
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package integration runs the proxy-server end to end, against OPA and
+// Redis in containers and fake storage arrays, and drives it with the
+// request sequences of the CSI drivers. It is run with make integration.
+//
+// Redis and OPA already running, e.g. as CI services, are used instead of
+// containers when REDIS_HOST and REDIS_PORT, and OPA_HOST, are set.
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"karavi-authorization/internal/decision"
+	"karavi-authorization/internal/proxyserver"
+	"karavi-authorization/internal/tenantsvc"
+	"karavi-authorization/internal/token/jwx"
+	"karavi-authorization/pb"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/orlangure/gnomock"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// The policies are written in rego v0, which OPA 1.0 and later only
+	// accept in compatibility mode.
+	opaImage   = "docker.io/openpolicyagent/opa:0.70.0"
+	redisImage = "docker.io/redis:6.0.8-alpine"
+
+	jwtSigningSecret = "integration"
+	tenantService    = "tenant-service"
+	bufSize          = 1024 * 1024
+)
+
+// h is the harness shared by the tests, since a proxy-server can only be
+// run once in a process.
+var h *harness
+
+func TestMain(m *testing.M) {
+	var err error
+	h, err = newHarness()
+	if err != nil {
+		log.Printf("starting the integration harness: %+v", err)
+		if h != nil {
+			h.Close()
+		}
+		os.Exit(1)
+	}
+	code := m.Run()
+	h.Close()
+	os.Exit(code)
+}
+
+// harness is a proxy-server with its tenant-service, OPA, Redis and a fake
+// PowerFlex array.
+type harness struct {
+	log        *logrus.Entry
+	proxyAddr  string
+	opaHost    string
+	rdb        *redis.Client
+	tenants    *tenantsvc.TenantService
+	powerflex  *powerFlexArray
+	dir        string
+	containers []*gnomock.Container
+	closers    []func()
+}
+
+func newHarness() (*harness, error) {
+	log := logrus.NewEntry(logrus.New())
+	log.Logger.SetLevel(logrus.WarnLevel)
+	h := &harness{log: log}
+
+	dir, err := os.MkdirTemp("", "karavi-integration")
+	if err != nil {
+		return h, err
+	}
+	h.dir = dir
+
+	redisAddr, err := h.startRedis()
+	if err != nil {
+		return h, fmt.Errorf("starting redis: %w", err)
+	}
+	h.rdb = redis.NewClient(&redis.Options{Addr: redisAddr})
+	h.closers = append(h.closers, func() { _ = h.rdb.Close() })
+
+	h.opaHost, err = h.startOPA()
+	if err != nil {
+		return h, fmt.Errorf("starting opa: %w", err)
+	}
+	if err := loadPolicies(h.opaHost, filepath.Join("..", "..", "policies")); err != nil {
+		return h, err
+	}
+
+	h.powerflex = newPowerFlexArray()
+	h.closers = append(h.closers, h.powerflex.Close)
+
+	// Serve the tenant-service in-process, as karavi-all does.
+	tenantsvc.JWTSigningSecret = jwtSigningSecret
+	h.tenants = tenantsvc.NewTenantService(
+		tenantsvc.WithLogger(log),
+		tenantsvc.WithRedis(h.rdb),
+		tenantsvc.WithTokenManager(jwx.NewTokenManager(jwx.HS256)))
+	l := bufconn.Listen(bufSize)
+	gs := grpc.NewServer()
+	pb.RegisterTenantServiceServer(gs, h.tenants)
+	go func() {
+		if err := gs.Serve(l); err != nil {
+			log.WithError(err).Error("serving tenant-service")
+		}
+	}()
+	h.closers = append(h.closers, gs.Stop)
+	dialer := grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		if addr != tenantService {
+			return nil, fmt.Errorf("unknown service %q", addr)
+		}
+		return l.DialContext(ctx)
+	})
+
+	if err := h.startProxy(redisAddr, dialer); err != nil {
+		return h, fmt.Errorf("starting proxy-server: %w", err)
+	}
+	return h, nil
+}
+
+// Close stops the containers and removes the files of the harness. The
+// proxy-server runs until the process exits.
+func (h *harness) Close() {
+	for i := len(h.closers) - 1; i >= 0; i-- {
+		h.closers[i]()
+	}
+	for _, c := range h.containers {
+		if err := gnomock.Stop(c); err != nil {
+			log.Printf("stopping container %s: %+v", c.ID, err)
+		}
+	}
+	if h.dir != "" {
+		_ = os.RemoveAll(h.dir)
+	}
+}
+
+func (h *harness) startRedis() (string, error) {
+	if host, port := os.Getenv("REDIS_HOST"), os.Getenv("REDIS_PORT"); host != "" && port != "" {
+		return net.JoinHostPort(host, port), nil
+	}
+	c, err := gnomock.StartCustom(redisImage, gnomock.DefaultTCP(6379))
+	if err != nil {
+		return "", err
+	}
+	h.containers = append(h.containers, c)
+	return c.DefaultAddress(), nil
+}
+
+func (h *harness) startOPA() (string, error) {
+	if host := os.Getenv("OPA_HOST"); host != "" {
+		return host, nil
+	}
+	c, err := gnomock.StartCustom(opaImage, gnomock.DefaultTCP(8181),
+		gnomock.WithCommand("run", "--server", "--addr=0.0.0.0:8181"),
+		gnomock.WithHealthCheck(func(ctx context.Context, c *gnomock.Container) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+c.DefaultAddress()+"/health", nil)
+			if err != nil {
+				return err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("opa health: %s", resp.Status)
+			}
+			return nil
+		}))
+	if err != nil {
+		return "", err
+	}
+	h.containers = append(h.containers, c)
+	return c.DefaultAddress(), nil
+}
+
+// startProxy runs the proxy-server with the storage systems of the fake
+// arrays and waits until it serves requests.
+func (h *harness) startProxy(redisAddr string, dialer grpc.DialOption) error {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	h.proxyAddr = l.Addr().String()
+	if err := l.Close(); err != nil {
+		return err
+	}
+
+	cfgFile := filepath.Join(h.dir, "config.yaml")
+	cfg := fmt.Sprintf(`LOG_LEVEL: warn
+proxy:
+  host: %s
+web:
+  debughost: 127.0.0.1:0
+  jwtsigningsecret: %s
+openpolicyagent:
+  host: %s
+`, h.proxyAddr, jwtSigningSecret, h.opaHost)
+	if err := os.WriteFile(cfgFile, []byte(cfg), 0o600); err != nil {
+		return err
+	}
+
+	systemsFile := filepath.Join(h.dir, "storage-systems.yaml")
+	systems := fmt.Sprintf(`storage:
+  powerflex:
+    %s:
+      endpoint: %s
+      user: admin
+      password: Password123
+      insecure: true
+`, h.powerflex.systemID, h.powerflex.URL)
+	if err := os.WriteFile(systemsFile, []byte(systems), 0o600); err != nil {
+		return err
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- proxyserver.Run(h.log, proxyserver.Options{
+			RedisHost:          redisAddr,
+			TenantService:      tenantService,
+			RoleService:        "role-service",
+			StorageService:     "storage-service",
+			ConfigFile:         cfgFile,
+			CSMConfigFile:      cfgFile,
+			StorageSystemsFile: systemsFile,
+			DialOptions:        []grpc.DialOption{dialer},
+		})
+	}()
+
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-errs:
+			return err
+		default:
+		}
+		resp, err := http.Get("http://" + h.proxyAddr + "/healthz")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return errors.New("timed out waiting for the proxy-server")
+}
+
+// loadPolicies pushes the rego policies in dir to OPA, other than tests.
+func loadPolicies(opaHost, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.rego"))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), ".rego")
+		if strings.HasSuffix(name, "_test") {
+			continue
+		}
+		module, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if err := decision.PutPolicy(context.Background(), opaHost, "karavi/"+name, module); err != nil {
+			return fmt.Errorf("pushing policy %s to opa: %w", f, err)
+		}
+	}
+	return nil
+}
+
+// setRoles replaces the roles in OPA, as the role-service does.
+func (h *harness) setRoles(t *testing.T, roles string) {
+	t.Helper()
+	module := "package karavi.common\ndefault roles = {}\nroles = " + roles
+	if err := decision.PutPolicy(context.Background(), h.opaHost, "karavi/common", []byte(module)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tenantTokens are the tokens of a tenant, as in its proxy-authz-tokens
+// secret.
+type tenantTokens struct {
+	Access  string
+	Refresh string
+}
+
+// createTenant creates the tenant bound to the role and returns its tokens,
+// with access tokens that expire after accessTTL.
+func (h *harness) createTenant(t *testing.T, name, role string, accessTTL time.Duration) tenantTokens {
+	t.Helper()
+	ctx := context.Background()
+	_, err := h.tenants.CreateTenant(ctx, &pb.CreateTenantRequest{
+		Tenant:        &pb.Tenant{Name: name, Approvesdc: true},
+		NoDefaultRole: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, err := h.tenants.DeleteTenant(ctx, &pb.DeleteTenantRequest{Name: name})
+		if err != nil {
+			t.Logf("deleting tenant %s: %+v", name, err)
+		}
+	})
+	_, err = h.tenants.BindRole(ctx, &pb.BindRoleRequest{TenantName: name, RoleName: role})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := h.tenants.GenerateToken(ctx, &pb.GenerateTokenRequest{
+		TenantName:     name,
+		AccessTokenTTL: int64(accessTTL),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var secret corev1.Secret
+	if err := yaml.Unmarshal([]byte(resp.Token), &secret); err != nil {
+		t.Fatal(err)
+	}
+	return tenantTokens{Access: string(secret.Data["access"]), Refresh: string(secret.Data["refresh"])}
+}
+
+// refresh exchanges the tokens for a new access token, as the sidecar
+// proxies do when theirs expired.
+func (h *harness) refresh(t *testing.T, tokens tenantTokens) (string, int) {
+	t.Helper()
+	body := strings.NewReader(fmt.Sprintf(`{"refreshToken": %q, "accessToken": %q}`, tokens.Refresh, tokens.Access))
+	resp, err := http.Post("http://"+h.proxyAddr+"/proxy/refresh-token/", "application/json", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var pair struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&pair); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return pair.AccessToken, resp.StatusCode
+}
+
+// sidecar sends the requests of a CSI driver through the proxy-server with
+// the headers its sidecar proxy adds to them.
+type sidecar struct {
+	h           *harness
+	plugin      string
+	endpoint    string
+	systemID    string
+	accessToken string
+}
+
+func (h *harness) powerFlexSidecar(accessToken string) *sidecar {
+	return &sidecar{
+		h:           h,
+		plugin:      "csi-vxflexos",
+		endpoint:    h.powerflex.URL,
+		systemID:    h.powerflex.systemID,
+		accessToken: accessToken,
+	}
+}
+
+// do sends the request, with the headers the CSI driver adds to it, and
+// decodes the response into out, if it is not nil and the request
+// succeeded, returning the status code.
+func (s *sidecar) do(t *testing.T, method, path string, header map[string]string, body, out interface{}) int {
+	t.Helper()
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = strings.NewReader(string(b))
+	}
+	req, err := http.NewRequest(method, "http://"+s.h.proxyAddr+path, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.accessToken)
+	req.Header.Add("Forwarded", "by=csm-authorization;"+s.plugin)
+	req.Header.Add("Forwarded", fmt.Sprintf("for=csm-authorization;%s;%s", s.endpoint, s.systemID))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal(b, out); err != nil {
+			t.Fatalf("decoding %s: %+v", b, err)
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Logf("%s %s: %d %s", method, path, resp.StatusCode, b)
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,198 @@
+//go:build integration
+
+// Copyright © 2024 Dell Inc., or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"karavi-authorization/internal/proxy"
+	"karavi-authorization/pb"
+	"net/http"
+	"testing"
+	"time"
+
+	types "github.com/dell/goscaleio/types/v1"
+)
+
+// powerFlexRoles are the roles of the tests: unlimited permits 100 GiB of
+// the pool and limited 16 GiB.
+var powerFlexRoles = fmt.Sprintf(`{
+  "unlimited": {"system_types": {"powerflex": {"system_ids": {%[1]q: {"pool_quotas": {%[2]q: 104857600}}}}}},
+  "limited": {"system_types": {"powerflex": {"system_ids": {%[1]q: {"pool_quotas": {%[2]q: 16777216}}}}}}
+}`, powerFlexSystemID, powerFlexPool)
+
+const (
+	volumeSizeInKb = 8388608 // 8 GiB
+	sdcID          = "0f4b5e1200000001"
+)
+
+// createVolume creates a volume of 8 GiB as the CSI driver does in
+// CreateVolume, named after the persistent volume, returning its ID.
+func (s *sidecar) createVolume(t *testing.T, name string) (string, int) {
+	t.Helper()
+	body := map[string]string{
+		"name":           name,
+		"volumeSizeInKb": fmt.Sprint(volumeSizeInKb),
+		"storagePoolId":  powerFlexPoolID,
+	}
+	var created types.VolumeResp
+	code := s.do(t, http.MethodPost, "/api/types/Volume/instances/", map[string]string{proxy.HeaderPVName: name}, body, &created)
+	return created.ID, code
+}
+
+func (s *sidecar) volumeAction(t *testing.T, id, action string, body interface{}) int {
+	t.Helper()
+	return s.do(t, http.MethodPost, fmt.Sprintf("/api/instances/Volume::%s/action/%s/", id, action), nil, body, nil)
+}
+
+func TestPowerFlexVolumeLifecycle(t *testing.T) {
+	h.setRoles(t, powerFlexRoles)
+	owner := h.createTenant(t, "lifecycle-owner", "unlimited", time.Minute)
+	other := h.createTenant(t, "lifecycle-other", "unlimited", time.Minute)
+	driver := h.powerFlexSidecar(owner.Access)
+
+	id, code := driver.createVolume(t, "k8s-lifecycle")
+	if code != http.StatusOK {
+		t.Fatalf("creating volume: got status %d, want %d", code, http.StatusOK)
+	}
+	if v := h.powerflex.volume(id); v == nil || v.Name != "k8s-lifecycle" || v.SizeInKb != volumeSizeInKb {
+		t.Fatalf("got volume %+v on the array, want k8s-lifecycle of %d Kb", v, volumeSizeInKb)
+	}
+
+	t.Run("it maps the volume", func(t *testing.T) {
+		code := driver.volumeAction(t, id, "addMappedSdc", map[string]interface{}{"sdcId": sdcID, "allowMultipleMappings": "TRUE"})
+		if code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", code, http.StatusOK)
+		}
+		if v := h.powerflex.volume(id); len(v.MappedSdcInfo) != 1 || v.MappedSdcInfo[0].SdcID != sdcID {
+			t.Errorf("got mappings %+v, want the volume mapped to %s", v.MappedSdcInfo, sdcID)
+		}
+	})
+
+	t.Run("it denies other tenants the volume", func(t *testing.T) {
+		intruder := h.powerFlexSidecar(other.Access)
+		if code := intruder.volumeAction(t, id, "removeMappedSdc", map[string]string{"sdcId": sdcID}); code != http.StatusForbidden {
+			t.Errorf("unmapping: got status %d, want %d", code, http.StatusForbidden)
+		}
+		if code := intruder.volumeAction(t, id, "removeVolume", map[string]string{"removeMode": "ONLY_ME"}); code != http.StatusForbidden {
+			t.Errorf("deleting: got status %d, want %d", code, http.StatusForbidden)
+		}
+		if v := h.powerflex.volume(id); v == nil || len(v.MappedSdcInfo) != 1 {
+			t.Errorf("got volume %+v, want it unchanged", v)
+		}
+	})
+
+	t.Run("it unmaps the volume", func(t *testing.T) {
+		code := driver.volumeAction(t, id, "removeMappedSdc", map[string]string{"sdcId": sdcID})
+		if code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", code, http.StatusOK)
+		}
+		if v := h.powerflex.volume(id); len(v.MappedSdcInfo) != 0 {
+			t.Errorf("got mappings %+v, want none", v.MappedSdcInfo)
+		}
+	})
+
+	t.Run("it deletes the volume", func(t *testing.T) {
+		code := driver.volumeAction(t, id, "removeVolume", map[string]string{"removeMode": "ONLY_ME"})
+		if code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", code, http.StatusOK)
+		}
+		if v := h.powerflex.volume(id); v != nil {
+			t.Errorf("got volume %+v, want it deleted", v)
+		}
+	})
+}
+
+func TestPowerFlexQuotaExhaustion(t *testing.T) {
+	h.setRoles(t, powerFlexRoles)
+	tokens := h.createTenant(t, "quota", "limited", time.Minute)
+	driver := h.powerFlexSidecar(tokens.Access)
+
+	// The quota of 16 GiB fits two volumes of 8 GiB.
+	var ids []string
+	for i := 0; i < 2; i++ {
+		id, code := driver.createVolume(t, fmt.Sprintf("k8s-quota-%d", i))
+		if code != http.StatusOK {
+			t.Fatalf("creating volume %d: got status %d, want %d", i, code, http.StatusOK)
+		}
+		ids = append(ids, id)
+	}
+
+	t.Run("it denies volumes beyond the quota", func(t *testing.T) {
+		id, code := driver.createVolume(t, "k8s-quota-2")
+		if code != http.StatusInsufficientStorage {
+			t.Errorf("got status %d, want %d", code, http.StatusInsufficientStorage)
+		}
+		if id != "" {
+			t.Errorf("got volume %s created on the array, want none", id)
+		}
+	})
+
+	t.Run("it releases the capacity of deleted volumes", func(t *testing.T) {
+		code := driver.volumeAction(t, ids[0], "removeVolume", map[string]string{"removeMode": "ONLY_ME"})
+		if code != http.StatusOK {
+			t.Fatalf("deleting: got status %d, want %d", code, http.StatusOK)
+		}
+		if _, code := driver.createVolume(t, "k8s-quota-3"); code != http.StatusOK {
+			t.Errorf("creating: got status %d, want %d", code, http.StatusOK)
+		}
+	})
+}
+
+func TestPowerFlexRevocation(t *testing.T) {
+	h.setRoles(t, powerFlexRoles)
+	// Access tokens are checked with a precision of a second.
+	const accessTTL = 2 * time.Second
+	tokens := h.createTenant(t, "revoked", "unlimited", accessTTL)
+	driver := h.powerFlexSidecar(tokens.Access)
+
+	if _, code := driver.createVolume(t, "k8s-revoked-0"); code != http.StatusOK {
+		t.Fatalf("creating volume: got status %d, want %d", code, http.StatusOK)
+	}
+
+	ctx := context.Background()
+	if _, err := h.tenants.RevokeTenant(ctx, &pb.RevokeTenantRequest{TenantName: "revoked"}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(accessTTL + time.Second)
+
+	t.Run("it refuses the expired access token", func(t *testing.T) {
+		if _, code := driver.createVolume(t, "k8s-revoked-1"); code != http.StatusUnauthorized {
+			t.Errorf("got status %d, want %d", code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("it refuses to refresh the tokens of a revoked tenant", func(t *testing.T) {
+		if _, code := h.refresh(t, tokens); code != http.StatusForbidden {
+			t.Errorf("got status %d, want %d", code, http.StatusForbidden)
+		}
+	})
+
+	t.Run("it serves the tenant again once the revocation is cancelled", func(t *testing.T) {
+		if _, err := h.tenants.CancelRevokeTenant(ctx, &pb.CancelRevokeTenantRequest{TenantName: "revoked"}); err != nil {
+			t.Fatal(err)
+		}
+		access, code := h.refresh(t, tokens)
+		if code != http.StatusOK {
+			t.Fatalf("refreshing: got status %d, want %d", code, http.StatusOK)
+		}
+		driver.accessToken = access
+		if _, code := driver.createVolume(t, "k8s-revoked-2"); code != http.StatusOK {
+			t.Errorf("creating volume: got status %d, want %d", code, http.StatusOK)
+		}
+	})
+}